@@ -0,0 +1,308 @@
+// Aircraft Library
+// Finds the aircraft configurations and their loading variants under a
+// directory tree, listing them from their headers alone and parsing each in
+// full only when it is loaded
+
+package main
+
+import (
+	"container/list"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultAircraftDir is where aircraft named on the command line are found
+const DefaultAircraftDir = "aircraft"
+
+// DefaultAircraftCacheSize is the number of parsed configurations a library
+// keeps
+const DefaultAircraftCacheSize = 8
+
+// AircraftInfo describes an aircraft configuration from its header
+type AircraftInfo struct {
+	Name        string // fdm_config name, or the file name without extension when it has none
+	Version     string // File header version, or the format version when there is none
+	Description string // File header description
+	Path        string
+	Err         error // Why the file could not be read or parsed; nil when it could
+
+	// Base is the path of the configuration a variant overlays; empty for
+	// a base aircraft
+	Base string
+}
+
+// String gives the name and description of the aircraft, or its error
+func (info AircraftInfo) String() string {
+	if info.Err != nil {
+		return fmt.Sprintf("%s (%s): error: %v", info.Name, info.Path, info.Err)
+	}
+	if info.Base != "" {
+		info.Path += ", variant of " + filepath.Base(info.Base)
+	}
+	if info.Description == "" {
+		return fmt.Sprintf("%s (%s)", info.Name, info.Path)
+	}
+	return fmt.Sprintf("%s (%s): %s", info.Name, info.Path, info.Description)
+}
+
+// AircraftLibrary is the set of aircraft configurations found under a root
+// directory, either flat or in the JSBSim aircraft/<name>/<name>.xml
+// layout, and the variant overlays (VariantExt files) beside them. XML
+// files whose root element is not fdm_config, such as engine and system
+// files, are not aircraft and are left out.
+type AircraftLibrary struct {
+	Root      string
+	Options   []ParseOption // Passed to ParseJSBSimConfig on every load
+	CacheSize int           // Parsed configurations kept, most recently loaded first
+
+	mu       sync.Mutex
+	aircraft []AircraftInfo
+	cache    *list.List // Of *cachedAircraft, most recently used first
+	cached   map[string]*list.Element
+}
+
+// cachedAircraft is a parsed configuration held by the library's cache
+type cachedAircraft struct {
+	path   string
+	config *JSBSimConfig
+}
+
+// errNotAircraft marks an XML file whose root element is not fdm_config
+var errNotAircraft = errors.New("not an aircraft configuration")
+
+// NewAircraftLibrary scans root for aircraft configurations
+func NewAircraftLibrary(root string, options ...ParseOption) (*AircraftLibrary, error) {
+	lib := &AircraftLibrary{Root: root, Options: options, CacheSize: DefaultAircraftCacheSize}
+	if err := lib.Scan(); err != nil {
+		return nil, err
+	}
+	return lib, nil
+}
+
+// Scan lists the aircraft under the root again and empties the cache. A
+// file whose header cannot be read is listed with the error; only a root
+// that cannot be walked fails the scan.
+func (lib *AircraftLibrary) Scan() error {
+	var aircraft []AircraftInfo
+	err := filepath.WalkDir(lib.Root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		if strings.HasSuffix(strings.ToLower(path), VariantExt) {
+			aircraft = append(aircraft, readVariantInfo(path))
+			return nil
+		}
+		if !strings.EqualFold(filepath.Ext(path), ".xml") {
+			return nil
+		}
+		info, err := readAircraftInfo(path)
+		if errors.Is(err, errNotAircraft) {
+			return nil
+		}
+		info.Err = err
+		aircraft = append(aircraft, info)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("scanning aircraft in %s: %w", lib.Root, err)
+	}
+	sort.Slice(aircraft, func(i, j int) bool { return aircraft[i].Path < aircraft[j].Path })
+
+	lib.mu.Lock()
+	defer lib.mu.Unlock()
+	lib.aircraft = aircraft
+	lib.cache = list.New()
+	lib.cached = make(map[string]*list.Element)
+	return nil
+}
+
+// List returns the aircraft found, in path order
+func (lib *AircraftLibrary) List() []AircraftInfo {
+	lib.mu.Lock()
+	defer lib.mu.Unlock()
+	return append([]AircraftInfo(nil), lib.aircraft...)
+}
+
+// Find returns the aircraft with the given name, matched without regard
+// to case against the configured name and then the file name without
+// extension
+func (lib *AircraftLibrary) Find(name string) (AircraftInfo, error) {
+	lib.mu.Lock()
+	defer lib.mu.Unlock()
+	i, err := lib.find(name)
+	if err != nil {
+		return AircraftInfo{}, err
+	}
+	return lib.aircraft[i], nil
+}
+
+func (lib *AircraftLibrary) find(name string) (int, error) {
+	for _, key := range []func(AircraftInfo) string{
+		func(info AircraftInfo) string { return info.Name },
+		func(info AircraftInfo) string { return fileStem(info.Path) },
+	} {
+		found := -1
+		for i, info := range lib.aircraft {
+			if !strings.EqualFold(key(info), name) {
+				continue
+			}
+			if found >= 0 {
+				return 0, fmt.Errorf("aircraft %q is ambiguous: %s and %s", name, lib.aircraft[found].Path, info.Path)
+			}
+			found = i
+		}
+		if found >= 0 {
+			return found, nil
+		}
+	}
+	return 0, fmt.Errorf("no aircraft %q in %s", name, lib.Root)
+}
+
+// Load returns the parsed configuration of the named aircraft, from the
+// cache when it holds it. The cached configuration is shared by every load
+// of the aircraft, so it should not be modified; parse the file at its
+// path for a copy to edit. A file that fails to parse is annotated with the
+// error in List.
+func (lib *AircraftLibrary) Load(name string) (*JSBSimConfig, error) {
+	lib.mu.Lock()
+	defer lib.mu.Unlock()
+	i, err := lib.find(name)
+	if err != nil {
+		return nil, err
+	}
+	info := lib.aircraft[i]
+	if info.Err != nil {
+		return nil, fmt.Errorf("aircraft %q: %w", name, info.Err)
+	}
+	if element, ok := lib.cached[info.Path]; ok {
+		lib.cache.MoveToFront(element)
+		return element.Value.(*cachedAircraft).config, nil
+	}
+
+	var config *JSBSimConfig
+	if info.Base != "" {
+		config, err = LoadAircraftVariant(info.Base, info.Path, lib.Options...)
+	} else {
+		config, err = parseConfigFile(info.Path, lib.Options...)
+	}
+	if err != nil {
+		lib.aircraft[i].Err = err
+		return nil, fmt.Errorf("aircraft %q: %w", name, err)
+	}
+	lib.cached[info.Path] = lib.cache.PushFront(&cachedAircraft{path: info.Path, config: config})
+	for lib.cache.Len() > max(lib.CacheSize, 1) {
+		oldest := lib.cache.Back()
+		lib.cache.Remove(oldest)
+		delete(lib.cached, oldest.Value.(*cachedAircraft).path)
+	}
+	return config, nil
+}
+
+// parseConfigFile parses the configuration in a file
+func parseConfigFile(path string, options ...ParseOption) (*JSBSimConfig, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ParseJSBSimConfig(file, append([]ParseOption{SourceFile(filepath.Base(path))}, options...)...)
+}
+
+// readAircraftInfo reads the root element and file header of a
+// configuration, stopping at the first element after the header. It
+// returns errNotAircraft when the root element is not fdm_config.
+func readAircraftInfo(path string) (AircraftInfo, error) {
+	info := AircraftInfo{Name: fileStem(path), Path: path}
+	file, err := os.Open(path)
+	if err != nil {
+		return info, err
+	}
+	defer file.Close()
+
+	decoder := xml.NewDecoder(file)
+	root, err := nextStartElement(decoder)
+	if err != nil {
+		return info, err
+	}
+	if root.Name.Local != "fdm_config" {
+		return info, errNotAircraft
+	}
+	for _, attr := range root.Attr {
+		switch attr.Name.Local {
+		case "name":
+			if name := strings.TrimSpace(attr.Value); name != "" {
+				info.Name = name
+			}
+		case "version":
+			info.Version = strings.TrimSpace(attr.Value)
+		}
+	}
+
+	element, err := nextStartElement(decoder)
+	if err != nil {
+		return info, fmt.Errorf("reading file header: %w", err)
+	}
+	if element.Name.Local != "fileheader" {
+		return info, nil
+	}
+	var header Header
+	if err := decoder.DecodeElement(&header, &element); err != nil {
+		return info, fmt.Errorf("reading file header: %w", err)
+	}
+	if version := strings.TrimSpace(header.Version); version != "" {
+		info.Version = version
+	}
+	info.Description = strings.TrimSpace(header.Description)
+	return info, nil
+}
+
+// readVariantInfo describes the variant overlay in a file, with its base
+// configuration's path resolved from the variant's directory
+func readVariantInfo(path string) AircraftInfo {
+	info := AircraftInfo{Name: fileStem(path), Path: path}
+	variant, err := readVariantFile(path)
+	if err != nil {
+		info.Err = err
+		return info
+	}
+	info.Name, info.Description = variant.Name, variant.Description
+	if variant.Base == "" {
+		info.Err = fmt.Errorf("variant %q names no base configuration", variant.Name)
+		return info
+	}
+	info.Base = filepath.Join(filepath.Dir(path), filepath.FromSlash(variant.Base))
+	return info
+}
+
+// nextStartElement returns the next start element of a document
+func nextStartElement(decoder *xml.Decoder) (xml.StartElement, error) {
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return xml.StartElement{}, err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start, nil
+		}
+	}
+}
+
+// fileStem returns the name of a file without its directory and extension,
+// the whole VariantExt for a variant
+func fileStem(path string) string {
+	base := filepath.Base(path)
+	if strings.HasSuffix(strings.ToLower(base), VariantExt) {
+		return base[:len(base)-len(VariantExt)]
+	}
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeAircraftFixtures lays out three aircraft under a temporary root: one
+// flat, one in the JSBSim directory layout with an engine file beside it,
+// and one whose header is malformed
+func writeAircraftFixtures(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	files := map[string]string{
+		"glider.xml": `<?xml version="1.0"?>
+<fdm_config name="Glider" version="2.0">
+	<fileheader>
+		<version> 1.3 </version>
+		<description> Training glider </description>
+	</fileheader>
+	<metrics><wingarea unit="FT2"> 160 </wingarea></metrics>
+	<buoyant_forces/>
+</fdm_config>`,
+		"trainer/trainer.xml": `<fdm_config name="Trainer" version="2.0">
+	<metrics><wingarea unit="FT2"> 174 </wingarea></metrics>
+</fdm_config>`,
+		"trainer/Engines/O-320.xml": `<piston_engine name="O-320"/>`,
+		"broken/broken.xml": `<fdm_config name="Broken">
+	<fileheader><description> Unterminated </fileheader>
+</fdm_config>`,
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestAircraftLibrary(t *testing.T) {
+	root := writeAircraftFixtures(t)
+
+	t.Run("List", func(t *testing.T) {
+		lib, err := NewAircraftLibrary(root)
+		if err != nil {
+			t.Fatalf("NewAircraftLibrary: %v", err)
+		}
+		aircraft := lib.List()
+		for _, info := range aircraft {
+			t.Log(info)
+		}
+		if len(aircraft) != 3 {
+			t.Fatalf("Expected the three aircraft and not the engine, got %d", len(aircraft))
+		}
+
+		broken, glider, trainer := aircraft[0], aircraft[1], aircraft[2]
+		assertEqual(t, glider.Name, "Glider")
+		assertEqual(t, glider.Version, "1.3")
+		assertEqual(t, glider.Description, "Training glider")
+		assertEqual(t, trainer.Name, "Trainer")
+		assertEqual(t, trainer.Version, "2.0")
+		assertEqual(t, trainer.Path, filepath.Join(root, "trainer", "trainer.xml"))
+		if glider.Err != nil || trainer.Err != nil {
+			t.Errorf("Unexpected errors: %v, %v", glider.Err, trainer.Err)
+		}
+		assertEqual(t, broken.Name, "Broken")
+		if broken.Err == nil {
+			t.Error("The malformed file should be listed with its error")
+		}
+	})
+
+	t.Run("Load", func(t *testing.T) {
+		lib, err := NewAircraftLibrary(root)
+		if err != nil {
+			t.Fatalf("NewAircraftLibrary: %v", err)
+		}
+		config, err := lib.Load("glider")
+		if err != nil {
+			t.Fatalf("Load: %v", err)
+		}
+		assertEqual(t, config.Metrics.WingArea.Value, 160.0)
+
+		// By file name as well as configured name
+		if _, err := lib.Load("trainer"); err != nil {
+			t.Fatalf("Load: %v", err)
+		}
+		if _, err := lib.Load("Broken"); err == nil {
+			t.Error("Expected the malformed aircraft to fail to load")
+		}
+		if _, err := lib.Load("Concorde"); err == nil || !strings.Contains(err.Error(), "no aircraft") {
+			t.Errorf("Expected an unknown aircraft error, got %v", err)
+		}
+	})
+
+	t.Run("Cache", func(t *testing.T) {
+		lib, err := NewAircraftLibrary(root)
+		if err != nil {
+			t.Fatalf("NewAircraftLibrary: %v", err)
+		}
+		lib.CacheSize = 1
+		first, _ := lib.Load("Glider")
+		again, _ := lib.Load("Glider")
+		if first != again {
+			t.Error("A cached aircraft should not be parsed again")
+		}
+
+		// Loading another evicts the least recently used
+		lib.Load("Trainer")
+		if evicted, _ := lib.Load("Glider"); evicted == first {
+			t.Error("Expected the glider to have been evicted")
+		}
+	})
+
+	t.Run("Parse Options", func(t *testing.T) {
+		lib, err := NewAircraftLibrary(root, WarnIgnoredElements())
+		if err != nil {
+			t.Fatalf("NewAircraftLibrary: %v", err)
+		}
+		config, err := lib.Load("Glider")
+		if err != nil {
+			t.Fatalf("Load: %v", err)
+		}
+		if len(config.ParseWarnings) != 1 {
+			t.Errorf("Expected the parse options to report the buoyant forces, got %v", config.ParseWarnings)
+		}
+	})
+
+	t.Run("Missing Root", func(t *testing.T) {
+		if _, err := NewAircraftLibrary(filepath.Join(root, "missing")); err == nil {
+			t.Error("Expected an error for a missing root")
+		}
+	})
+
+	t.Run("Validate By Name", func(t *testing.T) {
+		var out bytes.Buffer
+		if err := runValidate([]string{"p51d-jsbsim"}, &out); err != nil {
+			t.Fatalf("runValidate: %v\n%s", err, out.String())
+		}
+		if !strings.Contains(out.String(), filepath.Join(DefaultAircraftDir, "p51d-jsbsim.xml")) {
+			t.Errorf("Expected the P-51D to be found by name, got:\n%s", out.String())
+		}
+	})
+}
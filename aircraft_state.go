@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"math"
+	"strings"
 	"time"
 )
 
@@ -16,6 +17,21 @@ func (v Vector3) Add(other Vector3) Vector3 {
 	return Vector3{X: v.X + other.X, Y: v.Y + other.Y, Z: v.Z + other.Z}
 }
 
+// Sub subtracts other from the vector
+func (v Vector3) Sub(other Vector3) Vector3 {
+	return Vector3{X: v.X - other.X, Y: v.Y - other.Y, Z: v.Z - other.Z}
+}
+
+// MultiplyElementwise multiplies two vectors component by component
+func (v Vector3) MultiplyElementwise(other Vector3) Vector3 {
+	return Vector3{X: v.X * other.X, Y: v.Y * other.Y, Z: v.Z * other.Z}
+}
+
+// Lerp linearly interpolates from v (t=0) to other (t=1)
+func (v Vector3) Lerp(other Vector3, t float64) Vector3 {
+	return Vector3{X: v.X + (other.X-v.X)*t, Y: v.Y + (other.Y-v.Y)*t, Z: v.Z + (other.Z-v.Z)*t}
+}
+
 // Scale multiplies vector by a scalar
 func (v Vector3) Scale(scalar float64) Vector3 {
 	return Vector3{X: v.X * scalar, Y: v.Y * scalar, Z: v.Z * scalar}
@@ -49,6 +65,13 @@ func (v Vector3) Cross(other Vector3) Vector3 {
 	}
 }
 
+// AngleTo returns the angle in radians (0 to pi) between two vectors, or
+// zero when either is zero. The arctangent form stays accurate for nearly
+// parallel vectors, where the arccosine of the dot product does not.
+func (v Vector3) AngleTo(other Vector3) float64 {
+	return math.Atan2(v.Cross(other).Magnitude(), v.Dot(other))
+}
+
 // Quaternion represents rotation using quaternions for smooth interpolation
 type Quaternion struct {
 	W, X, Y, Z float64
@@ -71,25 +94,56 @@ func NewQuaternionFromEuler(roll, pitch, yaw float64) Quaternion {
 	}
 }
 
-// ToEuler converts quaternion to Euler angles (roll, pitch, yaw in radians)
+// NewQuaternionFromAxisAngle creates the quaternion of a right-handed
+// rotation by angle (radians) about axis, which need not be a unit vector.
+// A zero axis gives no rotation.
+func NewQuaternionFromAxisAngle(axis Vector3, angle float64) Quaternion {
+	axis = axis.Normalize()
+	if axis == (Vector3{}) {
+		return Quaternion{W: 1}
+	}
+	s, c := math.Sincos(angle * 0.5)
+	return Quaternion{W: c, X: axis.X * s, Y: axis.Y * s, Z: axis.Z * s}
+}
+
+// ToAxisAngle returns the unit axis and the angle (0 to pi) of the rotation,
+// taking the shorter way round. No rotation gives the X axis and zero.
+func (q Quaternion) ToAxisAngle() (axis Vector3, angle float64) {
+	q = q.Normalize()
+	if q.W < 0 {
+		q = q.Scale(-1)
+	}
+	v := Vector3{X: q.X, Y: q.Y, Z: q.Z}
+	s := v.Magnitude()
+	if s == 0 {
+		return Vector3{X: 1}, 0
+	}
+	return v.Scale(1 / s), 2 * math.Atan2(s, q.W)
+}
+
+// gimbalLockSinPitch is the sine of the pitch beyond which ToEuler treats
+// the attitude as gimbal locked
+const gimbalLockSinPitch = 1 - 1e-12
+
+// ToEuler converts quaternion to Euler angles (roll, pitch, yaw in radians).
+// At a pitch of ±90° roll and yaw turn about the same axis and only their
+// difference (climbing) or sum (diving) is defined; the whole rotation is
+// then given as yaw, with roll zero.
 func (q Quaternion) ToEuler() (roll, pitch, yaw float64) {
+	// Pitch (y-axis rotation)
+	sinp := 2 * (q.W*q.Y - q.Z*q.X)
+	if math.Abs(sinp) >= gimbalLockSinPitch {
+		pitch = math.Copysign(math.Pi/2, sinp)
+		yaw = math.Remainder(-math.Copysign(2, sinp)*math.Atan2(q.X, q.W), 2*math.Pi)
+		return 0, pitch, yaw
+	}
+	pitch = math.Asin(sinp)
+
 	// Roll (x-axis rotation)
 	sinr_cosp := 2 * (q.W*q.X + q.Y*q.Z)
 	cosr_cosp := 1 - 2*(q.X*q.X+q.Y*q.Y)
 	roll = math.Atan2(sinr_cosp, cosr_cosp)
 
-	// Pitch (y-axis rotation)
-	sinp := 2 * (q.W*q.Y - q.Z*q.X)
-	if math.Abs(sinp) >= 1 {
-		if sinp >= 0 {
-			pitch = math.Pi / 2
-		} else {
-			pitch = -math.Pi / 2
-		}
-	} else {
-		pitch = math.Asin(sinp)
-	}
-
 	// Yaw (z-axis rotation)
 	siny_cosp := 2 * (q.W*q.Z + q.X*q.Y)
 	cosy_cosp := 1 - 2*(q.Y*q.Y+q.Z*q.Z)
@@ -116,7 +170,7 @@ func (q Quaternion) Normalize() Quaternion {
 func (q Quaternion) RotateVector(v Vector3) Vector3 {
 	// v' = q * v * q^-1
 	qv := Quaternion{W: 0, X: v.X, Y: v.Y, Z: v.Z}
-	qConj := Quaternion{W: q.W, X: -q.X, Y: -q.Y, Z: -q.Z}
+	qConj := q.Conjugate()
 	
 	// q * v
 	temp := q.Multiply(qv)
@@ -126,6 +180,27 @@ func (q Quaternion) RotateVector(v Vector3) Vector3 {
 	return Vector3{X: result.X, Y: result.Y, Z: result.Z}
 }
 
+// RotateVectorInverse rotates a vector by the inverse of this unit
+// quaternion, as from the earth frame to the body frame of an attitude
+func (q Quaternion) RotateVectorInverse(v Vector3) Vector3 {
+	return q.Conjugate().RotateVector(v)
+}
+
+// Conjugate returns the conjugate, the inverse rotation of a unit quaternion
+func (q Quaternion) Conjugate() Quaternion {
+	return Quaternion{W: q.W, X: -q.X, Y: -q.Y, Z: -q.Z}
+}
+
+// Inverse returns the multiplicative inverse, the conjugate over the squared
+// norm; a zero quaternion has none and gives zero
+func (q Quaternion) Inverse() Quaternion {
+	norm2 := q.Dot(q)
+	if norm2 == 0 {
+		return Quaternion{}
+	}
+	return q.Conjugate().Scale(1 / norm2)
+}
+
 // Multiply multiplies two quaternions
 func (q Quaternion) Multiply(other Quaternion) Quaternion {
 	return Quaternion{
@@ -136,6 +211,44 @@ func (q Quaternion) Multiply(other Quaternion) Quaternion {
 	}
 }
 
+// Dot returns the four-dimensional dot product of two quaternions
+func (q Quaternion) Dot(other Quaternion) float64 {
+	return q.W*other.W + q.X*other.X + q.Y*other.Y + q.Z*other.Z
+}
+
+// Slerp spherically interpolates from q (t=0) to other (t=1) at a constant
+// angular rate. q and -q are the same attitude, so the target is negated
+// when needed to take the shorter of the two arcs.
+func (q Quaternion) Slerp(other Quaternion, t float64) Quaternion {
+	q = q.Normalize()
+	other = other.Normalize()
+	
+	dot := q.Dot(other)
+	if dot < 0 {
+		other = other.Scale(-1)
+		dot = -dot
+	}
+	
+	// Nearly identical attitudes: sin(theta) is too small to divide by, and
+	// a normalized linear interpolation is accurate
+	if dot > 0.9995 {
+		return q.Scale(1 - t).Add(other.Scale(t)).Normalize()
+	}
+	
+	theta := math.Acos(dot)
+	sinTheta := math.Sin(theta)
+	a := math.Sin((1-t)*theta) / sinTheta
+	b := math.Sin(t*theta) / sinTheta
+	return q.Scale(a).Add(other.Scale(b)).Normalize()
+}
+
+// AngleTo returns the rotation angle in radians (0 to pi) between the
+// attitudes q and other, taking the shorter way round
+func (q Quaternion) AngleTo(other Quaternion) float64 {
+	dot := math.Abs(q.Normalize().Dot(other.Normalize()))
+	return 2 * math.Acos(math.Min(dot, 1))
+}
+
 // ControlInputs represents pilot control inputs
 type ControlInputs struct {
 	Aileron   float64 `json:"aileron"`   // Roll control: -1 (full left) to +1 (full right)
@@ -144,9 +257,31 @@ type ControlInputs struct {
 	Throttle  float64 `json:"throttle"`  // Engine power: 0 (idle) to 1 (full power)
 	Flaps     float64 `json:"flaps"`     // Flap position: 0 (retracted) to 1 (full extended)
 	Gear      bool    `json:"gear"`      // Landing gear: true = down, false = up
-	Brake     float64 `json:"brake"`     // Brake pressure: 0 to 1
+	Brake     float64 `json:"brake"`     // Brake pressure on both sides: 0 to 1
 	Mixture   float64 `json:"mixture"`   // Fuel mixture: 0 (lean) to 1 (rich)
 	Propeller float64 `json:"propeller"` // Propeller pitch: 0 to 1 (for variable pitch props)
+	
+	// Differential braking and ground steering
+	BrakeLeft  float64 `json:"brake_left"`  // Left brake pressure: 0 to 1
+	BrakeRight float64 `json:"brake_right"` // Right brake pressure: 0 to 1
+	Steer      float64 `json:"steer"`       // Wheel steering: -1 (full left) to +1 (full right)
+}
+
+// BrakeCommand returns the brake pressure (0 to 1) for a JSBSim brake
+// group. The left and right groups take the greater of their own brake and
+// the both-sides Brake; CENTER follows Brake alone, and other groups (NONE)
+// have no brakes.
+func (c ControlInputs) BrakeCommand(group string) float64 {
+	var brake float64
+	switch strings.ToUpper(strings.TrimSpace(group)) {
+	case "LEFT":
+		brake = math.Max(c.Brake, c.BrakeLeft)
+	case "RIGHT":
+		brake = math.Max(c.Brake, c.BrakeRight)
+	case "CENTER":
+		brake = c.Brake
+	}
+	return math.Max(0, math.Min(1, brake))
 }
 
 // NewControlInputs creates control inputs with safe defaults
@@ -180,25 +315,55 @@ type AircraftState struct {
 	Orientation Quaternion `json:"orientation"` // Attitude quaternion
 	Roll        float64    `json:"roll"`        // Roll angle in radians
 	Pitch       float64    `json:"pitch"`       // Pitch angle in radians
-	Yaw         float64    `json:"yaw"`         // Yaw angle (heading) in radians
+	Yaw         float64    `json:"yaw"`         // Yaw angle (heading) in radians, wrapped to ±π
+	
+	// Heading without the ±π wrap, accumulated across updates so a
+	// 540° turn reads 3π; the wrapped Yaw is for display
+	HeadingContinuous float64 `json:"heading_continuous"`
+	
+	// Euler angle rates φ̇, θ̇, ψ̇ in rad/s: the roll, pitch and turn rates
+	// of the attitude itself, as opposed to the body-axis rates p, q, r
+	EulerRates Vector3 `json:"euler_rates"`
 	
 	// Linear Motion (Body frame)
 	Velocity     Vector3 `json:"velocity"`      // u, v, w in m/s (forward, right, down)
-	Acceleration Vector3 `json:"acceleration"`  // Linear acceleration in m/s²
+	Acceleration Vector3 `json:"acceleration"`  // u̇, v̇, ẇ in m/s², set by the dynamics engine each step
+	
+	// Wind: the velocity of the air over the ground, NED in m/s. Velocity
+	// is relative to the air, so the aircraft moves over the ground at
+	// Velocity plus Wind; see GroundVelocity.
+	Wind Vector3 `json:"wind"`
 	
 	// Angular Motion (Body frame)
 	AngularRate  Vector3 `json:"angular_rate"`  // p, q, r in rad/s (roll, pitch, yaw rates)
-	AngularAccel Vector3 `json:"angular_accel"` // Angular acceleration in rad/s²
+	AngularAccel Vector3 `json:"angular_accel"` // ṗ, q̇, ṙ in rad/s², set by the dynamics engine each step
 	
 	// Flight Parameters (derived from state)
 	Alpha         float64 `json:"alpha"`          // Angle of attack in radians
 	Beta          float64 `json:"beta"`           // Sideslip angle in radians
+	AlphaDot      float64 `json:"alphadot"`       // Rate of change of alpha in rad/s
+	BetaDot       float64 `json:"betadot"`        // Rate of change of beta in rad/s
 	Mach          float64 `json:"mach"`           // Mach number
 	IndicatedAirspeed float64 `json:"ias"`        // Indicated airspeed in m/s
 	TrueAirspeed     float64 `json:"tas"`         // True airspeed in m/s
 	CalibratedAirspeed float64 `json:"cas"`       // Calibrated airspeed in m/s
 	GroundSpeed      float64 `json:"groundspeed"` // Ground speed in m/s
 	
+	// Energy state
+	EnergyHeight        float64 `json:"energy_height"` // Altitude plus V²/2g in m
+	SpecificExcessPower float64 `json:"ps"`            // (T−D)·V/W in m/s, set by the dynamics engine each step
+	
+	// Load factors (nx, ny, nz) in g from the aerodynamic and propulsive
+	// forces, nz positive up; set by the dynamics engine each step
+	LoadFactor Vector3 `json:"load_factor"`
+	
+	// Specific force at the pilot's eyepoint in body axes (m/s²), what an
+	// accelerometer there reads; set by the dynamics engine each step
+	PilotSpecificForce Vector3 `json:"pilot_specific_force"`
+	
+	// Alpha and beta history for AlphaDot and BetaDot
+	angleRates angleRateHistory
+	
 	// Atmospheric Conditions
 	Temperature   float64 `json:"temperature"`    // Air temperature in Kelvin
 	Pressure      float64 `json:"pressure"`       // Static pressure in Pa
@@ -238,19 +403,33 @@ type AircraftState struct {
 	Gear struct {
 		Down         bool    `json:"down"`          // Gear down/up
 		Transition   float64 `json:"transition"`    // Transition state (0=up, 1=down)
+		Doors        float64 `json:"doors"`         // Gear door opening (0=closed, 1=open)
 		OnGround     bool    `json:"on_ground"`     // Aircraft on ground
+		WOWAny       bool    `json:"wow_any"`       // Any bogey's squat switch made, set by LandingGear
+		WOWAll       bool    `json:"wow_all"`       // Every bogey's squat switch made, set by LandingGear
 		GroundHeight float64 `json:"ground_height"` // Height of ground below aircraft
 		Compression  struct {
 			Main  float64 `json:"main"`   // Main gear compression
 			Nose  float64 `json:"nose"`   // Nose gear compression
 		} `json:"compression"`
+		Units []GearUnitState `json:"units,omitempty"` // Per-contact state, set by LandingGear
 	} `json:"gear"`
 	
+	// Placard speed warnings, set when PlacardLimits are checked
+	Placards struct {
+		OverspeedFlaps bool `json:"overspeed_flaps"` // Above the extended flaps' Vfe, or extending them was refused
+		OverspeedGear  bool `json:"overspeed_gear"`  // Above Vle with the gear not up, or extending it was refused
+		FlapsHeld      bool `json:"flaps_held"`      // The interlock refused a flap extension this step
+		GearHeld       bool `json:"gear_held"`       // The interlock refused the gear extension this step
+	} `json:"placards"`
+	
 	// Forces and Moments (for analysis/debugging)
 	Forces struct {
 		Aerodynamic Vector3 `json:"aerodynamic"` // Aerodynamic forces in body frame
 		Propulsive  Vector3 `json:"propulsive"`  // Propulsive forces in body frame
 		Gravity     Vector3 `json:"gravity"`     // Gravitational force in body frame
+		External    Vector3 `json:"external"`    // External point forces in body frame
+		Ground      Vector3 `json:"ground"`      // Landing gear ground reactions in body frame
 		Total       Vector3 `json:"total"`       // Total forces in body frame
 	} `json:"forces"`
 	
@@ -258,21 +437,36 @@ type AircraftState struct {
 		Aerodynamic Vector3 `json:"aerodynamic"` // Aerodynamic moments in body frame
 		Propulsive  Vector3 `json:"propulsive"`  // Propulsive moments in body frame
 		Gyroscopic  Vector3 `json:"gyroscopic"`  // Gyroscopic moments
+		External    Vector3 `json:"external"`    // Moments of the external point forces
 		Total       Vector3 `json:"total"`       // Total moments in body frame
 	} `json:"moments"`
 	
 	// Control Inputs (pilot commands)
 	Controls ControlInputs `json:"controls"`
+	
+	// Bounds SetControlInputs applies to Controls; the defaults when nil.
+	// Copies of the state share it, so its counts cover a whole run.
+	Limits *ControlLimits `json:"-"`
 }
 
 // Additional constants for aircraft state (others defined in jsbsimxmlparser.go)
 const (
 	KT_TO_MS   = 0.514444
 	MS_TO_KT   = 1.0 / KT_TO_MS
+	MPH_TO_MS  = 0.44704
 	LB_TO_N    = 4.44822
 	N_TO_LB    = 1.0 / LB_TO_N
 )
 
+// Low-speed air data. Below AirDataFadeSpeed the direction of the relative
+// wind is dominated by integration noise, so alpha and beta fade smoothly to
+// zero with the airspeed; dynamic pressure below MinDynamicPressure is taken
+// as none at all. AirDataFadeSpeed may be changed, or set to zero to
+// disable the fade.
+var AirDataFadeSpeed = 1.0 // m/s
+
+const MinDynamicPressure = 0.01 // Pa
+
 // NewAircraftState creates a new aircraft state with sensible defaults
 func NewAircraftState() *AircraftState {
 	state := &AircraftState{
@@ -288,18 +482,29 @@ func NewAircraftState() *AircraftState {
 		Yaw:         0.0,
 		Velocity:    Vector3{X: 50.0, Y: 0, Z: 0}, // 50 m/s forward (about 100 knots)
 		Controls:    NewControlInputs(),
+		Limits:      NewControlLimits(),
 	}
 	
-	// Initialize atmospheric conditions at 1000m
-	state.UpdateAtmosphere()
-	
-	// Calculate derived parameters
-	state.UpdateDerivedParameters()
+	// Initialize atmospheric conditions at 1000m and the derived parameters
+	state.UpdateFlightConditions()
 	
 	return state
 }
 
-// UpdateAtmosphere updates atmospheric conditions based on altitude (ISA Standard Atmosphere)
+// UpdateFlightConditions brings everything derived from the integrated state
+// up to date, in the one order that keeps it consistent: the atmosphere at
+// the altitude, then the airspeeds and air data angles from the velocity,
+// then dynamic pressure, once, from that density and airspeed. Anything
+// that moves a state, an integrator above all, calls it after setting the
+// altitude.
+func (state *AircraftState) UpdateFlightConditions() {
+	state.UpdateAtmosphere()
+	state.UpdateDerivedParameters()
+}
+
+// UpdateAtmosphere updates atmospheric conditions based on altitude (ISA
+// Standard Atmosphere). It leaves dynamic pressure, which also depends on
+// the airspeed, to UpdateDerivedParameters.
 func (state *AircraftState) UpdateAtmosphere() {
 	// ISA Standard Atmosphere model
 	const (
@@ -336,15 +541,20 @@ func (state *AircraftState) UpdateAtmosphere() {
 	
 	// Speed of sound
 	state.SoundSpeed = math.Sqrt(gamma * gasConstant * state.Temperature)
-	
-	// Dynamic pressure
-	state.DynamicPressure = 0.5 * state.Density * state.TrueAirspeed * state.TrueAirspeed
 }
 
-// UpdateDerivedParameters calculates derived flight parameters from basic state
+// UpdateDerivedParameters calculates derived flight parameters from basic
+// state. It is the one place dynamic pressure is computed, and takes the
+// density as it stands, so after a change of altitude it runs through
+// UpdateFlightConditions.
 func (state *AircraftState) UpdateDerivedParameters() {
-	// Update Euler angles from quaternion
+	// Update Euler angles from quaternion, carrying the heading change
+	// into the continuous heading. Attitude moves far less than half a
+	// turn between updates, so the shorter way round is the way it went.
+	previousYaw := state.Yaw
 	state.Roll, state.Pitch, state.Yaw = state.Orientation.ToEuler()
+	state.HeadingContinuous += math.Remainder(state.Yaw-previousYaw, 2*math.Pi)
+	state.EulerRates = eulerRates(state.Roll, state.Pitch, state.AngularRate)
 	
 	// Calculate airspeed components
 	state.TrueAirspeed = state.Velocity.Magnitude()
@@ -361,22 +571,206 @@ func (state *AircraftState) UpdateDerivedParameters() {
 		state.Mach = state.TrueAirspeed / state.SoundSpeed
 	}
 	
-	// Angle of attack (alpha) - angle between velocity and body X axis
-	if state.Velocity.X != 0 || state.Velocity.Z != 0 {
-		state.Alpha = math.Atan2(-state.Velocity.Z, state.Velocity.X)
+	// Angle of attack (alpha) - angle between velocity and body X axis, and
+	// sideslip angle (beta) - angle between velocity and XZ plane. Rounding
+	// can put |v| a hair above the airspeed, so the sine is clamped.
+	fade := lowSpeedFade(state.TrueAirspeed)
+	state.Alpha, state.Beta = 0, 0
+	if fade > 0 {
+		state.Alpha = fade * math.Atan2(-state.Velocity.Z, state.Velocity.X)
+		state.Beta = fade * math.Asin(math.Max(-1, math.Min(1, state.Velocity.Y/state.TrueAirspeed)))
 	}
 	
-	// Sideslip angle (beta) - angle between velocity and XZ plane
-	if state.TrueAirspeed > 0 {
-		state.Beta = math.Asin(state.Velocity.Y / state.TrueAirspeed)
-	}
+	// Alpha and beta rates, differenced across simulation time
+	state.AlphaDot, state.BetaDot = state.angleRates.update(state.Time, state.Alpha, state.Beta)
 	
 	// Ground speed (simplified - magnitude of horizontal velocity)
 	groundVel := Vector3{X: state.Velocity.X, Y: state.Velocity.Y, Z: 0}
 	state.GroundSpeed = groundVel.Magnitude()
 	
+	// Energy height: the altitude reached by trading all airspeed for height
+	state.EnergyHeight = state.Altitude + state.TrueAirspeed*state.TrueAirspeed/(2*StandardGravity)
+	
 	// Update dynamic pressure
 	state.DynamicPressure = 0.5 * state.Density * state.TrueAirspeed * state.TrueAirspeed
+	if state.DynamicPressure < MinDynamicPressure {
+		state.DynamicPressure = 0
+	}
+}
+
+// lowSpeedFade returns the weight of the air data angles at an airspeed:
+// zero at rest, rising smoothly to one at AirDataFadeSpeed
+func lowSpeedFade(airspeed float64) float64 {
+	if airspeed <= 0 {
+		return 0
+	}
+	if airspeed >= AirDataFadeSpeed {
+		return 1
+	}
+	x := airspeed / AirDataFadeSpeed
+	return x * x * (3 - 2*x)
+}
+
+// eulerRates returns the Euler angle rates (φ̇, θ̇, ψ̇) for body rates
+// (p, q, r) at roll phi and pitch theta: the rates the attitude quaternion
+// is turning at, resolved into Euler angles. Roll and turn rate are
+// undefined pointing straight up or down, where they are reported as the
+// body roll rate and zero.
+func eulerRates(phi, theta float64, rate Vector3) Vector3 {
+	sinPhi, cosPhi := math.Sincos(phi)
+	cosTheta := math.Cos(theta)
+	
+	// Rotation about the vertical, seen in the body Y-Z plane
+	vertical := rate.Y*sinPhi + rate.Z*cosPhi
+	thetaDot := rate.Y*cosPhi - rate.Z*sinPhi
+	if math.Abs(cosTheta) < 1e-9 {
+		return Vector3{X: rate.X, Y: thetaDot}
+	}
+	
+	psiDot := vertical / cosTheta
+	return Vector3{
+		X: rate.X + psiDot*math.Sin(theta),
+		Y: thetaDot,
+		Z: psiDot,
+	}
+}
+
+// angleRateHistory holds alpha and beta at the two most recent simulation
+// times seen by UpdateDerivedParameters. Recomputing at the same time
+// replaces the newest sample, so only a time advance starts a new interval.
+type angleRateHistory struct {
+	samples   int // Number of distinct times held, at most 2
+	prevTime  float64
+	prevAlpha float64
+	prevBeta  float64
+	curTime   float64
+	curAlpha  float64
+	curBeta   float64
+}
+
+// update records alpha and beta at time t and returns their rates. The
+// rates are zero until two distinct times have been seen, and after time
+// moves backwards (a reset).
+func (h *angleRateHistory) update(t, alpha, beta float64) (alphaDot, betaDot float64) {
+	if h.samples == 0 || t < h.curTime {
+		*h = angleRateHistory{samples: 1, curTime: t, curAlpha: alpha, curBeta: beta}
+		return 0, 0
+	}
+	if t > h.curTime {
+		h.prevTime, h.prevAlpha, h.prevBeta = h.curTime, h.curAlpha, h.curBeta
+		h.samples = 2
+	}
+	h.curTime, h.curAlpha, h.curBeta = t, alpha, beta
+	if h.samples < 2 {
+		return 0, 0
+	}
+	
+	// Alpha wraps at ±180° in tail-first flight
+	dt := h.curTime - h.prevTime
+	return math.Remainder(alpha-h.prevAlpha, 2*math.Pi) / dt, (beta - h.prevBeta) / dt
+}
+
+// GroundVelocity returns the velocity over the ground in NED (m/s): the
+// air-relative velocity turned to the earth frame, plus the wind
+func (state *AircraftState) GroundVelocity() Vector3 {
+	return state.Orientation.RotateVector(state.Velocity).Add(state.Wind)
+}
+
+// FlightPathAngle returns γ (rad), positive climbing, taken through the
+// air mass as performance test points are reduced: asin(ḣ/V) with ḣ the
+// climb rate relative to the air and V the true airspeed. It is zero at
+// rest; GroundFlightPathAngle follows the path over the ground.
+func (state *AircraftState) FlightPathAngle() float64 {
+	if state.TrueAirspeed <= 0 {
+		return 0
+	}
+	climb := -state.Orientation.RotateVector(state.Velocity).Z
+	return math.Asin(math.Max(-1, math.Min(1, climb/state.TrueAirspeed)))
+}
+
+// GroundFlightPathAngle returns the climb angle of the path over the
+// ground (rad), wind included
+func (state *AircraftState) GroundFlightPathAngle() float64 {
+	v := state.GroundVelocity()
+	if v.Magnitude() == 0 {
+		return 0
+	}
+	return math.Atan2(-v.Z, math.Hypot(v.X, v.Y))
+}
+
+// Track returns the direction of the path over the ground (rad, from
+// north, -π to π), wind included; see AirTrack for the air-relative one
+func (state *AircraftState) Track() float64 {
+	v := state.GroundVelocity()
+	if v.X == 0 && v.Y == 0 {
+		return 0
+	}
+	return math.Atan2(v.Y, v.X)
+}
+
+// AirTrack returns the direction of the path through the air mass (rad,
+// from north, -π to π): the heading turned by any sideslip
+func (state *AircraftState) AirTrack() float64 {
+	v := state.Orientation.RotateVector(state.Velocity)
+	if v.X == 0 && v.Y == 0 {
+		return 0
+	}
+	return math.Atan2(v.Y, v.X)
+}
+
+// ClimbGradient returns the height gained per distance through the air
+// mass (%), 100 tan γ
+func (state *AircraftState) ClimbGradient() float64 {
+	return 100 * math.Tan(state.FlightPathAngle())
+}
+
+// GroundClimbGradient returns the height gained per distance over the
+// ground (%), wind included: the gradient obstacle clearance needs
+func (state *AircraftState) GroundClimbGradient() float64 {
+	return 100 * math.Tan(state.GroundFlightPathAngle())
+}
+
+// VelocityAt returns the body-axis velocity of a point at offset from the
+// CG (body axes, m): v + ω×r
+func (state *AircraftState) VelocityAt(offset Vector3) Vector3 {
+	return state.Velocity.Add(state.AngularRate.Cross(offset))
+}
+
+// AccelerationAt returns the body-axis acceleration of a point at offset
+// from the CG (body axes, m), adding the lever-arm terms ω̇×r and ω×(ω×r)
+// to the CG acceleration
+func (state *AircraftState) AccelerationAt(offset Vector3) Vector3 {
+	return state.Acceleration.Add(state.leverArmAcceleration(offset))
+}
+
+// SpecificForceAt returns the specific force (acceleration less gravity)
+// at offset from the CG in body axes (m/s²), what an accelerometer there
+// would read. At the CG it is the load factor in m/s², so it points up
+// (negative Z) at 9.81 m/s² in level flight.
+func (state *AircraftState) SpecificForceAt(offset Vector3) Vector3 {
+	cg := Vector3{
+		X: state.LoadFactor.X * StandardGravity,
+		Y: state.LoadFactor.Y * StandardGravity,
+		Z: -state.LoadFactor.Z * StandardGravity,
+	}
+	return cg.Add(state.leverArmAcceleration(offset))
+}
+
+// leverArmAcceleration returns ω̇×r + ω×(ω×r), the acceleration of a point
+// at offset r relative to the CG of the rotating airframe
+func (state *AircraftState) leverArmAcceleration(offset Vector3) Vector3 {
+	tangential := state.AngularAccel.Cross(offset)
+	centripetal := state.AngularRate.Cross(state.AngularRate.Cross(offset))
+	return tangential.Add(centripetal)
+}
+
+// recordAccelerations stores a step's derivatives as the accelerations of
+// the state it produced, and the specific force at the pilot station (body
+// axes about the CG, m). The load factor must already be set.
+func (state *AircraftState) recordAccelerations(derivatives *StateDerivatives, pilotStation Vector3) {
+	state.Acceleration = derivatives.VelocityDot
+	state.AngularAccel = derivatives.AngularRateDot
+	state.PilotSpecificForce = state.SpecificForceAt(pilotStation)
 }
 
 // Copy creates a deep copy of the aircraft state
@@ -385,90 +779,217 @@ func (state *AircraftState) Copy() *AircraftState {
 	return &newState   // Return pointer to the copy
 }
 
-// ToPropertyMap converts the aircraft state to a property map for function evaluation
+// Validate checks that the dynamical state and the air data derived from it
+// are finite. The first NaN or infinite field is reported as a
+// *NonFiniteInputError named by its property (or field) name; integrated
+// states are checked before derived ones so the error names the origin.
+func (state *AircraftState) Validate() error {
+	fields := [...]struct {
+		name  string
+		value float64
+	}{
+		{"velocities/u-mps", state.Velocity.X},
+		{"velocities/v-mps", state.Velocity.Y},
+		{"velocities/w-mps", state.Velocity.Z},
+		{"velocities/p-rad_sec", state.AngularRate.X},
+		{"velocities/q-rad_sec", state.AngularRate.Y},
+		{"velocities/r-rad_sec", state.AngularRate.Z},
+		{"Orientation.W", state.Orientation.W},
+		{"Orientation.X", state.Orientation.X},
+		{"Orientation.Y", state.Orientation.Y},
+		{"Orientation.Z", state.Orientation.Z},
+		{"Position.X", state.Position.X},
+		{"Position.Y", state.Position.Y},
+		{"Position.Z", state.Position.Z},
+		{"position/h-sl-m", state.Altitude},
+		{"position/latitude-rad", state.Latitude},
+		{"position/longitude-rad", state.Longitude},
+		{"simulation/sim-time-sec", state.Time},
+		{"velocities/vt-mps", state.TrueAirspeed},
+		{"aero/alpha-rad", state.Alpha},
+		{"aero/beta-rad", state.Beta},
+		{"aero/qbar-Pa", state.DynamicPressure},
+		{"atmosphere/rho-kgm3", state.Density},
+	}
+	
+	for _, field := range fields {
+		if !isFinite(field.value) {
+			return &NonFiniteInputError{Property: field.name, Value: field.value}
+		}
+	}
+	return nil
+}
+
+// propertyMapSize is the number of entries written by FillPropertyMap,
+// not counting the per-unit gear properties
+const propertyMapSize = 106
+
+// ToPropertyMap converts the aircraft state to a property map for function evaluation.
+// It allocates a new map on every call; hot paths should reuse a map with FillPropertyMap.
 func (state *AircraftState) ToPropertyMap() map[string]float64 {
-	return map[string]float64{
-		// Position and orientation
-		"position/latitude-rad":     state.Latitude,
-		"position/longitude-rad":    state.Longitude,
-		"position/h-sl-m":          state.Altitude,
-		"position/h-agl-m":         state.Altitude - state.Gear.GroundHeight,
-		"attitude/roll-rad":        state.Roll,
-		"attitude/pitch-rad":       state.Pitch,
-		"attitude/heading-rad":     state.Yaw,
-		
-		// Velocities and rates
-		"velocities/u-mps":         state.Velocity.X,
-		"velocities/v-mps":         state.Velocity.Y,
-		"velocities/w-mps":         state.Velocity.Z,
-		"velocities/p-rad_sec":     state.AngularRate.X,
-		"velocities/q-rad_sec":     state.AngularRate.Y,
-		"velocities/r-rad_sec":     state.AngularRate.Z,
-		"velocities/vt-mps":        state.TrueAirspeed,
-		"velocities/vc-mps":        state.CalibratedAirspeed,
-		"velocities/vi-mps":        state.IndicatedAirspeed,
-		
-		// Flight parameters
-		"aero/alpha-rad":           state.Alpha,
-		"aero/beta-rad":            state.Beta,
-		"aero/alpha-deg":           state.Alpha * RAD_TO_DEG,
-		"aero/beta-deg":            state.Beta * RAD_TO_DEG,
-		"aero/mach":                state.Mach,
-		"aero/qbar-Pa":             state.DynamicPressure,
-		"aero/qbar-psf":            state.DynamicPressure * 0.020885, // Pa to psf conversion
-		
-		// Atmospheric conditions
-		"atmosphere/T-K":           state.Temperature,
-		"atmosphere/P-Pa":          state.Pressure,
-		"atmosphere/rho-kgm3":      state.Density,
-		"atmosphere/rho-slugs_ft3": state.Density * 0.00194032, // kg/m³ to slugs/ft³
-		"atmosphere/a-mps":         state.SoundSpeed,
-		
-		// Controls
-		"fcs/aileron-cmd-norm":     state.Controls.Aileron,
-		"fcs/elevator-cmd-norm":    state.Controls.Elevator,
-		"fcs/rudder-cmd-norm":      state.Controls.Rudder,
-		"fcs/throttle-cmd-norm":    state.Controls.Throttle,
-		"fcs/flap-cmd-norm":        state.Controls.Flaps,
-		"fcs/gear-cmd-norm":        func() float64 { if state.Controls.Gear { return 1.0 } else { return 0.0 } }(),
-		
-		// Control surfaces (actual positions)
-		"fcs/left-aileron-pos-rad":  state.ControlSurfaces.AileronLeft,
-		"fcs/right-aileron-pos-rad": state.ControlSurfaces.AileronRight,
-		"fcs/elevator-pos-rad":      state.ControlSurfaces.Elevator,
-		"fcs/rudder-pos-rad":        state.ControlSurfaces.Rudder,
-		"fcs/flap-pos-deg":          state.ControlSurfaces.FlapLeft * RAD_TO_DEG,
-		
-		// Engine
-		"propulsion/engine/thrust-N":    state.Engine.Thrust,
-		"propulsion/engine/thrust-lbs":  state.Engine.Thrust * N_TO_LB,
-		"propulsion/engine/power-hp":    state.Engine.Thrust * state.TrueAirspeed / 745.7, // Rough conversion
-		"engines/engine/rpm":            state.Engine.RPM,
-		"engines/engine/mp-inHg":        state.Engine.ManifoldP,
-		
-		// Landing gear
-		"gear/gear-down":               func() float64 { if state.Gear.Down { return 1.0 } else { return 0.0 } }(),
-		"gear/gear-pos-norm":           state.Gear.Transition,
-		"gear/wow":                     func() float64 { if state.Gear.OnGround { return 1.0 } else { return 0.0 } }(),
-		
-		// Forces and moments (for analysis)
-		"forces/fbx-N":                 state.Forces.Total.X,
-		"forces/fby-N":                 state.Forces.Total.Y,
-		"forces/fbz-N":                 state.Forces.Total.Z,
-		"moments/l-Nm":                 state.Moments.Total.X,
-		"moments/m-Nm":                 state.Moments.Total.Y,
-		"moments/n-Nm":                 state.Moments.Total.Z,
-		
-		// Time
-		"simulation/sim-time-sec":      state.Time,
-	}
-}
-
-// SetControlInputs updates only the control inputs in the aircraft state
+	m := make(map[string]float64, propertyMapSize)
+	state.FillPropertyMap(m)
+	return m
+}
+
+// FillPropertyMap writes the aircraft state properties into an existing map,
+// overwriting the same keys ToPropertyMap produces without allocating
+func (state *AircraftState) FillPropertyMap(m map[string]float64) {
+	// Position and orientation
+	m["position/latitude-rad"] = state.Latitude
+	m["position/longitude-rad"] = state.Longitude
+	m["position/h-sl-m"] = state.Altitude
+	m["position/h-agl-m"] = state.Altitude - state.Gear.GroundHeight
+	m["position/h-agl-ft"] = (state.Altitude - state.Gear.GroundHeight) * M_TO_FT
+	m["position/terrain-elevation-m"] = state.Gear.GroundHeight
+	m["attitude/roll-rad"] = state.Roll
+	m["attitude/pitch-rad"] = state.Pitch
+	m["attitude/heading-rad"] = state.Yaw
+	m["attitude/heading-continuous-rad"] = state.HeadingContinuous
+	m["attitude/heading-continuous-deg"] = state.HeadingContinuous * RAD_TO_DEG
+	
+	// Velocities and rates
+	m["velocities/u-mps"] = state.Velocity.X
+	m["velocities/v-mps"] = state.Velocity.Y
+	m["velocities/w-mps"] = state.Velocity.Z
+	m["velocities/p-rad_sec"] = state.AngularRate.X
+	m["velocities/q-rad_sec"] = state.AngularRate.Y
+	m["velocities/r-rad_sec"] = state.AngularRate.Z
+	m["velocities/phidot-rad_sec"] = state.EulerRates.X
+	m["velocities/thetadot-rad_sec"] = state.EulerRates.Y
+	m["velocities/psidot-rad_sec"] = state.EulerRates.Z
+	m["velocities/vt-mps"] = state.TrueAirspeed
+	m["velocities/vc-mps"] = state.CalibratedAirspeed
+	m["velocities/vi-mps"] = state.IndicatedAirspeed
+	m["velocities/mach"] = state.Mach
+	m["velocities/ve-kts"] = state.TrueAirspeed * math.Sqrt(state.Density/1.225) * MS_TO_KT
+	
+	// Flight path, through the air mass and over the ground
+	gamma, groundGamma := state.FlightPathAngle(), state.GroundFlightPathAngle()
+	m["flight-path/gamma-rad"] = gamma
+	m["flight-path/gamma-deg"] = gamma * RAD_TO_DEG
+	m["flight-path/gamma-ground-rad"] = groundGamma
+	m["flight-path/gamma-ground-deg"] = groundGamma * RAD_TO_DEG
+	m["flight-path/psi-gt-rad"] = state.Track()
+	m["flight-path/psi-gt-deg"] = state.Track() * RAD_TO_DEG
+	m["flight-path/psi-air-rad"] = state.AirTrack()
+	m["flight-path/climb-gradient-pct"] = 100 * math.Tan(gamma)
+	m["flight-path/climb-gradient-ground-pct"] = 100 * math.Tan(groundGamma)
+	
+	// Flight parameters
+	m["aero/alpha-rad"] = state.Alpha
+	m["aero/beta-rad"] = state.Beta
+	m["aero/alpha-deg"] = state.Alpha * RAD_TO_DEG
+	m["aero/beta-deg"] = state.Beta * RAD_TO_DEG
+	m["aero/alphadot-rad_sec"] = state.AlphaDot
+	m["aero/betadot-rad_sec"] = state.BetaDot
+	m["aero/alphadot-deg_sec"] = state.AlphaDot * RAD_TO_DEG
+	m["aero/betadot-deg_sec"] = state.BetaDot * RAD_TO_DEG
+	m["aero/mach"] = state.Mach
+	m["aero/qbar-Pa"] = state.DynamicPressure
+	m["aero/qbar-psf"] = state.DynamicPressure * 0.020885 // Pa to psf conversion
+	
+	// Atmospheric conditions
+	m["atmosphere/T-K"] = state.Temperature
+	m["atmosphere/P-Pa"] = state.Pressure
+	m["atmosphere/rho-kgm3"] = state.Density
+	m["atmosphere/rho-slugs_ft3"] = state.Density * 0.00194032 // kg/m³ to slugs/ft³
+	m["atmosphere/a-mps"] = state.SoundSpeed
+	m["atmosphere/total-wind-north-fps"] = state.Wind.X * M_TO_FT
+	m["atmosphere/total-wind-east-fps"] = state.Wind.Y * M_TO_FT
+	m["atmosphere/total-wind-down-fps"] = state.Wind.Z * M_TO_FT
+	m["atmosphere/wind-mag-fps"] = state.Wind.Magnitude() * M_TO_FT
+	
+	// Controls, within their limits however they were set
+	controls := state.controlLimits().Clamp(state.Controls)
+	m["fcs/aileron-cmd-norm"] = controls.Aileron
+	m["fcs/elevator-cmd-norm"] = controls.Elevator
+	m["fcs/rudder-cmd-norm"] = controls.Rudder
+	m["fcs/throttle-cmd-norm"] = controls.Throttle
+	m["fcs/mixture-cmd-norm"] = controls.Mixture
+	m["fcs/advance-cmd-norm"] = controls.Propeller
+	m["fcs/flap-cmd-norm"] = controls.Flaps
+	m["fcs/gear-cmd-norm"] = boolToFloat(controls.Gear)
+	m["fcs/left-brake-cmd-norm"] = controls.BrakeCommand("LEFT")
+	m["fcs/right-brake-cmd-norm"] = controls.BrakeCommand("RIGHT")
+	m["fcs/center-brake-cmd-norm"] = controls.BrakeCommand("CENTER")
+	m["fcs/steer-cmd-norm"] = controls.Steer
+	
+	// Control surfaces (actual positions)
+	m["fcs/left-aileron-pos-rad"] = state.ControlSurfaces.AileronLeft
+	m["fcs/right-aileron-pos-rad"] = state.ControlSurfaces.AileronRight
+	m["fcs/elevator-pos-rad"] = state.ControlSurfaces.Elevator
+	m["fcs/rudder-pos-rad"] = state.ControlSurfaces.Rudder
+	m["fcs/flap-pos-deg"] = state.ControlSurfaces.FlapLeft * RAD_TO_DEG
+	
+	// Engine
+	m["propulsion/engine/thrust-N"] = state.Engine.Thrust
+	m["propulsion/engine/thrust-lbs"] = state.Engine.Thrust * N_TO_LB
+	m["propulsion/engine/power-hp"] = state.Engine.Thrust * state.TrueAirspeed / 745.7 // Rough conversion
+	m["engines/engine/rpm"] = state.Engine.RPM
+	m["engines/engine/mp-inHg"] = state.Engine.ManifoldP
+	
+	// Landing gear
+	m["gear/gear-down"] = boolToFloat(state.Gear.Down)
+	m["gear/gear-pos-norm"] = state.Gear.Transition
+	m["gear/door-pos-norm"] = state.Gear.Doors
+	m["gear/wow"] = boolToFloat(state.Gear.OnGround)
+	m["gear/wow-any"] = boolToFloat(state.Gear.WOWAny)
+	m["gear/wow-all"] = boolToFloat(state.Gear.WOWAll)
+	for i, unit := range state.Gear.Units {
+		names := gearUnitProperties(i)
+		m[names[0]] = boolToFloat(unit.Squat)
+		m[names[1]] = unit.Compression * M_TO_FT
+		m[names[2]] = unit.CompressionVelocity * M_TO_FT
+	}
+	
+	// Placard warnings
+	m["limits/overspeed-flaps"] = boolToFloat(state.Placards.OverspeedFlaps)
+	m["limits/overspeed-gear"] = boolToFloat(state.Placards.OverspeedGear)
+	
+	// Forces and moments (for analysis)
+	m["forces/fbx-N"] = state.Forces.Total.X
+	m["forces/fby-N"] = state.Forces.Total.Y
+	m["forces/fbz-N"] = state.Forces.Total.Z
+	m["moments/l-Nm"] = state.Moments.Total.X
+	m["moments/m-Nm"] = state.Moments.Total.Y
+	m["moments/n-Nm"] = state.Moments.Total.Z
+	
+	// Load factors; n-pilot-z-norm follows the body Z axis, so it is -1 in
+	// level flight where Nz is 1
+	m["accelerations/n-pilot-x-norm"] = state.LoadFactor.X
+	m["accelerations/n-pilot-y-norm"] = state.LoadFactor.Y
+	m["accelerations/n-pilot-z-norm"] = -state.LoadFactor.Z
+	m["accelerations/Nz"] = state.LoadFactor.Z
+	
+	// Body-axis accelerations, and the specific force at the pilot's eyepoint
+	m["accelerations/udot-mps2"] = state.Acceleration.X
+	m["accelerations/vdot-mps2"] = state.Acceleration.Y
+	m["accelerations/wdot-mps2"] = state.Acceleration.Z
+	m["accelerations/pdot-rad_sec2"] = state.AngularAccel.X
+	m["accelerations/qdot-rad_sec2"] = state.AngularAccel.Y
+	m["accelerations/rdot-rad_sec2"] = state.AngularAccel.Z
+	m["accelerations/a-pilot-x-mps2"] = state.PilotSpecificForce.X
+	m["accelerations/a-pilot-y-mps2"] = state.PilotSpecificForce.Y
+	m["accelerations/a-pilot-z-mps2"] = state.PilotSpecificForce.Z
+	
+	// Energy performance
+	m["performance/Ps-mps"] = state.SpecificExcessPower
+	m["performance/energy-height-m"] = state.EnergyHeight
+	
+	// Time
+	m["simulation/sim-time-sec"] = state.Time
+}
+
+// SetControlInputs updates only the control inputs in the aircraft state,
+// clamping and rate limiting them by state.Limits.
 // For control surface positioning, use FlightDynamicsEngineWithFCS which processes
 // control inputs through the Flight Control System (FCS) with proper dynamics.
 func (state *AircraftState) SetControlInputs(controls ControlInputs) {
-	state.Controls = controls
+	if state.Limits == nil {
+		state.Limits = NewControlLimits()
+	}
+	state.Controls = state.Limits.Apply(controls, state.Time)
 	
 	// Update gear state (gear is typically not processed through FCS)
 	state.Gear.Down = controls.Gear
@@ -479,6 +1000,14 @@ func (state *AircraftState) SetControlInputs(controls ControlInputs) {
 	}
 }
 
+// controlLimits returns the state's control limits, or the defaults
+func (state *AircraftState) controlLimits() *ControlLimits {
+	if state.Limits == nil {
+		return defaultControlLimits
+	}
+	return state.Limits
+}
+
 // String returns a formatted string representation of key state parameters
 func (state *AircraftState) String() string {
 	return fmt.Sprintf(
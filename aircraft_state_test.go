@@ -106,6 +106,47 @@ func TestQuaternionOperations(t *testing.T) {
 		                      normalized.Y*normalized.Y + normalized.Z*normalized.Z)
 		assertApproxEqual(t, magnitude, 1.0, 0.001)
 	})
+	
+	t.Run("SLERP", func(t *testing.T) {
+		deg := math.Pi / 180
+		from := NewQuaternionFromEuler(0, 0, 0)
+		to := NewQuaternionFromEuler(0, 0, 170*deg)
+		
+		_, _, yaw := from.Slerp(to, 0.5).ToEuler()
+		assertApproxEqual(t, yaw/deg, 85.0, 1e-9)
+		
+		// Yaw advances steadily from 0 to 170 degrees, never the long way
+		// round through -180, and the result stays a unit quaternion
+		previous := 0.0
+		for i := 1; i <= 100; i++ {
+			q := from.Slerp(to, float64(i)/100)
+			_, _, yaw := q.ToEuler()
+			if yaw < previous || yaw > 170*deg+1e-9 {
+				t.Fatalf("Yaw %.2f deg at t=%.2f after %.2f deg", yaw/deg, float64(i)/100, previous/deg)
+			}
+			previous = yaw
+			assertApproxEqual(t, math.Sqrt(q.Dot(q)), 1.0, 1e-12)
+		}
+		
+		// The negated target is the same attitude and gives the same path
+		negated := to.Scale(-1)
+		assertApproxEqual(t, from.Slerp(negated, 0.5).AngleTo(from.Slerp(to, 0.5)), 0.0, 1e-6)
+		
+		// 190 degrees is reached through -170, the shorter way
+		_, _, yaw = from.Slerp(NewQuaternionFromEuler(0, 0, 190*deg), 0.5).ToEuler()
+		assertApproxEqual(t, yaw/deg, -85.0, 1e-9)
+	})
+	
+	t.Run("Angle Between Attitudes", func(t *testing.T) {
+		deg := math.Pi / 180
+		q := NewQuaternionFromEuler(0.3, 0.2, 0.1)
+		assertApproxEqual(t, q.AngleTo(q), 0.0, 1e-6)
+		assertApproxEqual(t, q.AngleTo(q.Scale(-1)), 0.0, 1e-6)
+		
+		yawed := NewQuaternionFromEuler(0, 0, 170*deg)
+		assertApproxEqual(t, NewQuaternionFromEuler(0, 0, 0).AngleTo(yawed)/deg, 170.0, 1e-9)
+		assertApproxEqual(t, NewQuaternionFromEuler(0, 0, -100*deg).AngleTo(NewQuaternionFromEuler(0, 0, 100*deg))/deg, 160.0, 1e-9)
+	})
 }
 
 // TestControlInputs tests the control input system
@@ -295,6 +336,43 @@ func TestDerivedParameters(t *testing.T) {
 		}
 	})
 	
+	t.Run("Low Speed Air Data", func(t *testing.T) {
+		state := NewAircraftState()
+		
+		// At rest there is no relative wind to take a direction from
+		state.Velocity = Vector3{}
+		state.UpdateDerivedParameters()
+		assertEqual(t, state.Alpha, 0.0)
+		assertEqual(t, state.Beta, 0.0)
+		assertEqual(t, state.DynamicPressure, 0.0)
+		
+		// Noise-sized velocities give small angles rather than wild ones
+		state.Velocity = Vector3{X: 0.001, Y: 0.002, Z: -0.003}
+		state.UpdateDerivedParameters()
+		if math.Abs(state.Alpha) > 0.01 || math.Abs(state.Beta) > 0.01 {
+			t.Errorf("Alpha %.4f and beta %.4f rad should be faded out", state.Alpha, state.Beta)
+		}
+		
+		// Halfway through the fade the angles carry half their weight
+		state.Velocity = Vector3{X: 0.5 * AirDataFadeSpeed * math.Cos(0.2), Z: -0.5 * AirDataFadeSpeed * math.Sin(0.2)}
+		state.UpdateDerivedParameters()
+		assertApproxEqual(t, state.Alpha, 0.1, 1e-9)
+		
+		// Straight sideways is the edge of the sine's domain
+		state.Velocity = Vector3{Y: -5}
+		state.UpdateDerivedParameters()
+		assertApproxEqual(t, state.Beta, -math.Pi/2, 1e-12)
+		
+		// Dynamic pressure follows this call's airspeed, not the last one's
+		state.Velocity = Vector3{X: 80}
+		state.UpdateDerivedParameters()
+		state.Altitude = 2000
+		state.UpdateAtmosphere()
+		state.Velocity = Vector3{X: 40}
+		state.UpdateDerivedParameters()
+		assertApproxEqual(t, state.DynamicPressure, 0.5*state.Density*40*40, 1e-9)
+	})
+	
 	t.Run("Mach Number Calculation", func(t *testing.T) {
 		state := NewAircraftState()
 		state.Velocity = Vector3{X: 343.0, Y: 0.0, Z: 0.0} // Speed of sound at sea level
@@ -304,6 +382,120 @@ func TestDerivedParameters(t *testing.T) {
 		// Should be approximately Mach 1
 		assertApproxEqual(t, state.Mach, 1.0, 0.1)
 	})
+	
+	t.Run("Alpha And Beta Rates", func(t *testing.T) {
+		state := NewAircraftState()
+		state.Velocity = Vector3{X: 50.0, Y: 0.0, Z: 0.0}
+		state.UpdateDerivedParameters()
+		assertEqual(t, state.AlphaDot, 0.0)
+		
+		// Recomputing at the same time replaces the sample without a rate
+		state.Velocity = Vector3{X: 50.0, Y: 5.0, Z: -5.0}
+		state.UpdateDerivedParameters()
+		assertEqual(t, state.AlphaDot, 0.0)
+		alpha0, beta0 := state.Alpha, state.Beta
+		
+		state.Time += 0.1
+		state.Velocity = Vector3{X: 50.0, Y: 2.0, Z: -8.0}
+		state.UpdateDerivedParameters()
+		assertApproxEqual(t, state.AlphaDot, (state.Alpha-alpha0)/0.1, 1e-9)
+		assertApproxEqual(t, state.BetaDot, (state.Beta-beta0)/0.1, 1e-9)
+		if state.AlphaDot <= 0 || state.BetaDot >= 0 {
+			t.Errorf("Expected alpha rising and beta falling, got %.3f and %.3f rad/s", state.AlphaDot, state.BetaDot)
+		}
+		
+		// A second evaluation at the same time still differences against the
+		// previous time, not the previous call
+		state.Velocity = Vector3{X: 50.0, Y: 2.0, Z: -9.0}
+		state.UpdateDerivedParameters()
+		assertApproxEqual(t, state.AlphaDot, (state.Alpha-alpha0)/0.1, 1e-9)
+		
+		properties := state.ToPropertyMap()
+		assertApproxEqual(t, properties["aero/alphadot-rad_sec"], state.AlphaDot, 1e-12)
+		assertApproxEqual(t, properties["aero/betadot-deg_sec"], state.BetaDot*RAD_TO_DEG, 1e-9)
+		
+		// Going back in time (a reset) clears the rates
+		state.Time = 0
+		state.UpdateDerivedParameters()
+		assertEqual(t, state.AlphaDot, 0.0)
+		assertEqual(t, state.BetaDot, 0.0)
+	})
+	
+	t.Run("Euler Angle Rates", func(t *testing.T) {
+		// Level coordinated turn at 0.2 rad/s with 30° of bank: the body
+		// rates split the turn between pitch and yaw
+		bank, omega := 30*DEG_TO_RAD, 0.2
+		state := NewAircraftState()
+		state.Orientation = NewQuaternionFromEuler(bank, 0, 0)
+		state.AngularRate = Vector3{X: 0, Y: omega * math.Sin(bank), Z: omega * math.Cos(bank)}
+		state.UpdateDerivedParameters()
+		assertApproxEqual(t, state.EulerRates.X, 0.0, 1e-9)
+		assertApproxEqual(t, state.EulerRates.Y, 0.0, 1e-9)
+		assertApproxEqual(t, state.EulerRates.Z, omega, 1e-9)
+		
+		// Pitched up, the roll rate picks up part of the yaw rate
+		state.Orientation = NewQuaternionFromEuler(bank, 20*DEG_TO_RAD, 0)
+		state.AngularRate = Vector3{X: 0.1, Y: 0.05, Z: 0.1}
+		state.UpdateDerivedParameters()
+		psiDot := (0.05*math.Sin(bank) + 0.1*math.Cos(bank)) / math.Cos(20*DEG_TO_RAD)
+		assertApproxEqual(t, state.EulerRates.Z, psiDot, 1e-9)
+		assertApproxEqual(t, state.EulerRates.X, 0.1+psiDot*math.Sin(20*DEG_TO_RAD), 1e-9)
+		
+		properties := state.ToPropertyMap()
+		assertApproxEqual(t, properties["velocities/psidot-rad_sec"], psiDot, 1e-9)
+		assertApproxEqual(t, properties["velocities/thetadot-rad_sec"], state.EulerRates.Y, 1e-12)
+	})
+	
+	t.Run("Continuous Heading", func(t *testing.T) {
+		state := NewAircraftState()
+		for _, heading := range []float64{90, 179, -179, -90, 0} {
+			state.Orientation = NewQuaternionFromEuler(0, 0, heading*DEG_TO_RAD)
+			state.UpdateDerivedParameters()
+		}
+		
+		// A full turn to the right through south ends at 360°, not 0°
+		assertApproxEqual(t, state.Yaw, 0.0, 1e-9)
+		assertApproxEqual(t, state.HeadingContinuous*RAD_TO_DEG, 360.0, 1e-3)
+	})
+	
+	t.Run("Flight Path", func(t *testing.T) {
+		// Heading east at 100 m/s climbing 10 m/s through the air, into a
+		// 20 m/s wind from the east
+		state := NewAircraftState()
+		state.Orientation = NewQuaternionFromEuler(0, 0, math.Pi/2)
+		state.Velocity = state.Orientation.RotateVectorInverse(Vector3{X: 0, Y: math.Sqrt(100*100 - 10*10), Z: -10})
+		state.Wind = Vector3{Y: -20}
+		state.UpdateDerivedParameters()
+		
+		assertApproxEqual(t, state.FlightPathAngle(), math.Asin(0.1), 1e-12)
+		assertApproxEqual(t, state.ClimbGradient(), 100*math.Tan(math.Asin(0.1)), 1e-9)
+		assertApproxEqual(t, state.AirTrack(), math.Pi/2, 1e-12)
+		assertApproxEqual(t, state.Track(), math.Pi/2, 1e-12)
+		
+		// The headwind steepens the path over the ground
+		ground := math.Sqrt(100*100-10*10) - 20
+		assertApproxEqual(t, state.GroundFlightPathAngle(), math.Atan2(10, ground), 1e-12)
+		assertApproxEqual(t, state.GroundClimbGradient(), 100*10/ground, 1e-9)
+		
+		// A crosswind from the south turns the track north of east
+		state.Wind = Vector3{X: 20}
+		assertApproxEqual(t, state.Track(), math.Atan2(math.Sqrt(100*100-10*10), 20), 1e-12)
+		assertApproxEqual(t, state.FlightPathAngle(), math.Asin(0.1), 1e-12)
+		
+		properties := state.ToPropertyMap()
+		assertApproxEqual(t, properties["flight-path/gamma-rad"], state.FlightPathAngle(), 1e-12)
+		assertApproxEqual(t, properties["flight-path/gamma-ground-deg"], state.GroundFlightPathAngle()*RAD_TO_DEG, 1e-12)
+		assertApproxEqual(t, properties["flight-path/psi-gt-deg"], state.Track()*RAD_TO_DEG, 1e-12)
+		assertApproxEqual(t, properties["flight-path/climb-gradient-ground-pct"], state.GroundClimbGradient(), 1e-9)
+		
+		// At rest there is no path
+		assertEqual(t, NewAircraftState().Track(), 0.0)
+		rest := NewAircraftState()
+		rest.Velocity = Vector3{}
+		rest.UpdateDerivedParameters()
+		assertEqual(t, rest.FlightPathAngle(), 0.0)
+		assertEqual(t, rest.GroundFlightPathAngle(), 0.0)
+	})
 }
 
 // TestControlSurfaceMapping tests the mapping from control inputs to surface positions
@@ -404,6 +596,29 @@ func TestPropertyMap(t *testing.T) {
 		assertApproxEqual(t, props["fcs/throttle-cmd-norm"], 0.8, 0.001)
 		assertEqual(t, props["fcs/gear-cmd-norm"], 1.0) // true -> 1.0
 	})
+	
+	t.Run("Fill Reused Map", func(t *testing.T) {
+		state := NewAircraftState()
+		state.Alpha = 0.2
+		state.Gear.OnGround = true
+		
+		m := make(map[string]float64, propertyMapSize)
+		state.FillPropertyMap(m)
+		assertEqual(t, m, state.ToPropertyMap())
+		
+		// Refilling overwrites in place without allocating
+		state.Alpha = 0.3
+		allocs := testing.AllocsPerRun(100, func() {
+			state.FillPropertyMap(m)
+		})
+		assertEqual(t, allocs, 0.0)
+		assertApproxEqual(t, m["aero/alpha-rad"], 0.3, 1e-9)
+		assertEqual(t, m["gear/wow"], 1.0)
+		
+		if len(m) > propertyMapSize {
+			t.Errorf("propertyMapSize %d is smaller than the %d properties written", propertyMapSize, len(m))
+		}
+	})
 }
 
 // TestStateCopy tests state copying functionality
@@ -486,6 +701,14 @@ func BenchmarkStateOperations(b *testing.B) {
 		}
 	})
 	
+	b.Run("FillPropertyMap", func(b *testing.B) {
+		state := NewAircraftState()
+		m := make(map[string]float64, propertyMapSize)
+		for i := 0; i < b.N; i++ {
+			state.FillPropertyMap(m)
+		}
+	})
+	
 	b.Run("SetControlInputs", func(b *testing.B) {
 		state := NewAircraftState()
 		controls := NewControlInputs()
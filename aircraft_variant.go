@@ -0,0 +1,173 @@
+// Aircraft Variants
+// Named loadings of an aircraft, such as clean, combat or ferry, kept as a
+// small JSON overlay of edits to its base configuration instead of a copy
+// of the XML
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// VariantExt is the file extension of an aircraft variant overlay
+const VariantExt = ".variant.json"
+
+// AircraftVariant is a loading variant of a base aircraft. Its edits are
+// made through the configuration override methods, tanks first, then point
+// masses, then drag.
+type AircraftVariant struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Base        string `json:"base,omitempty"` // Base configuration relative to the variant file, for the aircraft library
+
+	Tanks       []VariantTank      `json:"tanks,omitempty"`
+	PointMasses []VariantPointMass `json:"point_masses,omitempty"`
+	Drag        []VariantDrag      `json:"drag,omitempty"`
+}
+
+// VariantTank sets the contents of a numbered tank, or fills it
+type VariantTank struct {
+	Number   int     `json:"number"`
+	Contents float64 `json:"contents,omitempty"`
+	Unit     string  `json:"unit,omitempty"` // LBS when empty
+	Full     bool    `json:"full,omitempty"`
+}
+
+// VariantPointMass sets the weight (lbs) of a point mass, moving it when a
+// location is given. A point mass the base lacks is added, and needs a
+// location.
+type VariantPointMass struct {
+	Name     string    `json:"name"`
+	Weight   float64   `json:"weight"`
+	Location *Location `json:"location,omitempty"`
+}
+
+// VariantDrag adds a constant drag coefficient, such as that of external
+// tanks. A name without a slash is taken below aero/coefficient/.
+type VariantDrag struct {
+	Name    string  `json:"name"`
+	DeltaCD float64 `json:"delta_cd"`
+}
+
+// functionName returns the name of the drag function added
+func (d VariantDrag) functionName() string {
+	if strings.Contains(d.Name, "/") {
+		return d.Name
+	}
+	return "aero/coefficient/" + d.Name
+}
+
+// ReadAircraftVariant reads a JSON variant overlay
+func ReadAircraftVariant(r io.Reader) (*AircraftVariant, error) {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	variant := &AircraftVariant{}
+	if err := decoder.Decode(variant); err != nil {
+		return nil, fmt.Errorf("reading aircraft variant: %w", err)
+	}
+	if variant.Name == "" {
+		return nil, fmt.Errorf("aircraft variant has no name")
+	}
+	for _, drag := range variant.Drag {
+		if drag.Name == "" {
+			return nil, fmt.Errorf("aircraft variant %q adds drag without a name", variant.Name)
+		}
+	}
+	return variant, nil
+}
+
+// readVariantFile reads the variant overlay in a file
+func readVariantFile(path string) (*AircraftVariant, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadAircraftVariant(file)
+}
+
+// Apply makes the variant's edits to config, noting each in
+// config.Mutations. It stops at the first edit that fails.
+func (v *AircraftVariant) Apply(config *JSBSimConfig) error {
+	config.Variant = v.Name
+	apply := func(err error, format string, args ...interface{}) error {
+		mutation := fmt.Sprintf(format, args...)
+		if err != nil {
+			return fmt.Errorf("variant %q: %s: %w", v.Name, mutation, err)
+		}
+		config.Mutations = append(config.Mutations, mutation)
+		return nil
+	}
+
+	for _, tank := range v.Tanks {
+		var err error
+		if tank.Full {
+			err = apply(config.FillTank(tank.Number), "tank %d full", tank.Number)
+		} else {
+			unit := tank.Unit
+			if unit == "" {
+				unit = "LBS"
+			}
+			err = apply(config.SetTankContents(tank.Number, tank.Contents, unit),
+				"tank %d contents %g %s", tank.Number, tank.Contents, unit)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, pm := range v.PointMasses {
+		if _, err := config.pointMass(pm.Name); err != nil {
+			if pm.Location == nil {
+				return fmt.Errorf("variant %q: point mass %q is not in the base and has no location", v.Name, pm.Name)
+			}
+			loc := *pm.Location
+			if err := apply(config.AddPointMass(pm.Name, pm.Weight, loc),
+				"point mass %q added, %g lbs at (%g, %g, %g) %s", pm.Name, pm.Weight, loc.X, loc.Y, loc.Z, loc.Unit); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := apply(config.SetPointMassWeight(pm.Name, pm.Weight), "point mass %q %g lbs", pm.Name, pm.Weight); err != nil {
+			return err
+		}
+		if loc := pm.Location; loc != nil {
+			if err := apply(config.MovePointMass(pm.Name, *loc),
+				"point mass %q moved to (%g, %g, %g) %s", pm.Name, loc.X, loc.Y, loc.Z, loc.Unit); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, drag := range v.Drag {
+		name := drag.functionName()
+		if err := apply(config.AddDragIncrement(name, drag.DeltaCD), "drag %s ΔCD %g", name, drag.DeltaCD); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadAircraftVariant parses the base configuration, applies the variant
+// overlay to it and validates the result. The base file is only read.
+func LoadAircraftVariant(basePath, variantPath string, options ...ParseOption) (*JSBSimConfig, error) {
+	variant, err := readVariantFile(variantPath)
+	if err != nil {
+		return nil, err
+	}
+	config, err := parseConfigFile(basePath, options...)
+	if err != nil {
+		return nil, err
+	}
+	if err := variant.Apply(config); err != nil {
+		return nil, err
+	}
+	if err := ValidateConfig(config); err != nil {
+		return nil, fmt.Errorf("variant %q: %w", variant.Name, err)
+	}
+	return config, nil
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// ferryVariant fills the fuselage tank and hangs both drop tanks, with
+// their drag
+const ferryVariant = `{
+	"name": "ferry",
+	"description": "Full fuselage tank and two drop tanks",
+	"base": "p51d-jsbsim.xml",
+	"tanks": [{"number": 2, "full": true}],
+	"point_masses": [
+		{"name": "left drop tank", "weight": 600},
+		{"name": "right drop tank", "weight": 600}
+	],
+	"drag": [{"name": "CDdroptanks", "delta_cd": 0.004}]
+}`
+
+func TestAircraftVariant(t *testing.T) {
+	const basePath = "aircraft/p51d-jsbsim.xml"
+	before, err := os.ReadFile(basePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	variantPath := filepath.Join(dir, "p51d-ferry"+VariantExt)
+	if err := os.WriteFile(variantPath, []byte(ferryVariant), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	base := loadP51DConfig(t)
+	ferry, err := LoadAircraftVariant(basePath, variantPath)
+	if err != nil {
+		t.Fatalf("LoadAircraftVariant: %v", err)
+	}
+
+	t.Run("Mass And CG", func(t *testing.T) {
+		baseWeight, baseCG, _ := configMassProperties(base)
+		weight, cg, ok := configMassProperties(ferry)
+		if !ok {
+			t.Fatal("No mass properties")
+		}
+		fuselage := base.Propulsion.Tank[2]
+		assertApproxEqual(t, weight, baseWeight+fuselage.Capacity.Value+2*600, 1e-9)
+
+		// The weights added at their stations
+		left, _ := base.pointMass("left drop tank")
+		right, _ := base.pointMass("right drop tank")
+		moment := baseCG.Scale(baseWeight).
+			Add(StructuralPosition(fuselage.Location).Scale(fuselage.Capacity.Value)).
+			Add(StructuralPosition(left.Location).Scale(600)).
+			Add(StructuralPosition(right.Location).Scale(600))
+		expected := moment.Scale(1 / weight)
+		assertApproxEqual(t, cg.X, expected.X, 1e-9)
+		assertApproxEqual(t, cg.Y, expected.Y, 1e-9)
+		assertApproxEqual(t, cg.Z, expected.Z, 1e-9)
+
+		// The fuselage tank is aft of the CG
+		if cg.X <= baseCG.X {
+			t.Errorf("The full fuselage tank should move the CG aft of %.4f m, got %.4f m", baseCG.X, cg.X)
+		}
+	})
+
+	t.Run("Drag", func(t *testing.T) {
+		f := configFunction(t, ferry, "aero/coefficient/CDdroptanks")
+		value, err := EvaluateFunction(f, map[string]float64{"aero/qbar-psf": 50, "metrics/Sw-sqft": 235})
+		if err != nil {
+			t.Fatalf("EvaluateFunction: %v", err)
+		}
+		assertApproxEqual(t, value, 50*235*0.004, 1e-9)
+
+		// Acting in the drag axis, so the ferry has more drag at the same
+		// condition
+		drag := func(config *JSBSimConfig) float64 {
+			state := NewAircraftState()
+			state.Velocity = Vector3{X: 100}
+			state.UpdateFlightConditions()
+			components, err := NewForcesMomentsCalculator(config).CalculateForcesMoments(state)
+			if err != nil {
+				t.Fatalf("CalculateForcesMoments: %v", err)
+			}
+			return components.Aerodynamic.Drag
+		}
+		if drag(ferry) >= drag(base) {
+			t.Errorf("The drop tanks should add drag: %.1f N against %.1f N", drag(ferry), drag(base))
+		}
+	})
+
+	t.Run("Provenance", func(t *testing.T) {
+		assertEqual(t, ferry.Variant, "ferry")
+		assertEqual(t, len(ferry.Mutations), 4)
+		for i, want := range []string{"tank 2 full", `"left drop tank" 600 lbs`, `"right drop tank" 600 lbs`, "CDdroptanks"} {
+			if i < len(ferry.Mutations) && !strings.Contains(ferry.Mutations[i], want) {
+				t.Errorf("Mutation %d: expected %q in %q", i, want, ferry.Mutations[i])
+			}
+		}
+	})
+
+	t.Run("Base Untouched", func(t *testing.T) {
+		after, err := os.ReadFile(basePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(before, after) {
+			t.Error("Loading the variant changed the base configuration on disk")
+		}
+		assertEqual(t, loadP51DConfig(t).Propulsion.Tank[2].Contents.Value, 0.0)
+	})
+
+	t.Run("Library", func(t *testing.T) {
+		base, err := os.ReadFile(basePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "p51d-jsbsim.xml"), base, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		lib, err := NewAircraftLibrary(dir)
+		if err != nil {
+			t.Fatalf("NewAircraftLibrary: %v", err)
+		}
+		aircraft := lib.List()
+		assertEqual(t, len(aircraft), 2)
+		info, err := lib.Find("p51d-ferry")
+		if err != nil {
+			t.Fatalf("Find: %v", err)
+		}
+		assertEqual(t, info.Name, "ferry")
+		assertEqual(t, info.Base, filepath.Join(dir, "p51d-jsbsim.xml"))
+		if !strings.Contains(info.String(), "variant of p51d-jsbsim.xml") {
+			t.Errorf("The listing should name the base: %s", info)
+		}
+
+		config, err := lib.Load("ferry")
+		if err != nil {
+			t.Fatalf("Load: %v", err)
+		}
+		assertEqual(t, config.Propulsion.Tank[2].Contents.Value, config.Propulsion.Tank[2].Capacity.Value)
+		plain, err := lib.Load("p51d-jsbsim")
+		if err != nil {
+			t.Fatalf("Load: %v", err)
+		}
+		assertEqual(t, plain.Variant, "")
+	})
+
+	t.Run("Bad Variants", func(t *testing.T) {
+		for name, overlay := range map[string]string{
+			"overfilled":   `{"name": "x", "tanks": [{"number": 0, "contents": 900}]}`,
+			"no tank":      `{"name": "x", "tanks": [{"number": 9, "full": true}]}`,
+			"new store":    `{"name": "x", "point_masses": [{"name": "camera pod", "weight": 50}]}`,
+			"repeat drag":  `{"name": "x", "drag": [{"name": "CDo", "delta_cd": 0.01}]}`,
+			"unknown edit": `{"name": "x", "wings": 3}`,
+			"unnamed":      `{"tanks": []}`,
+		} {
+			path := filepath.Join(t.TempDir(), "bad"+VariantExt)
+			if err := os.WriteFile(path, []byte(overlay), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadAircraftVariant(basePath, path); err == nil {
+				t.Errorf("%s: expected an error", name)
+			}
+		}
+
+		// A new point mass with a location is added
+		config := loadP51DConfig(t)
+		if err := config.AddPointMass("camera pod", 50, Location{Unit: "IN", X: 120, Y: 40}); err != nil {
+			t.Fatalf("AddPointMass: %v", err)
+		}
+		pm, err := config.pointMass("camera pod")
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertEqual(t, pm.Mass.Value, 50.0)
+		assertEqual(t, pm.Location.Name, "POINTMASS")
+	})
+}
@@ -0,0 +1,47 @@
+// Demo Command
+// Flies the flight dynamics demonstration, or some of its scenarios, on the
+// simplified model or a configuration, for a quick look or a CI smoke test
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// runDemo runs the demonstration as args select, writing its report and
+// JSON summary to w
+func runDemo(args []string, w io.Writer) error {
+	runner := NewDemoRunner(w)
+	flags := flag.NewFlagSet("demo", flag.ContinueOnError)
+	flags.SetOutput(w)
+	quiet := flags.Bool("quiet", false, "print the JSON summary only")
+	verbose := flags.Bool("verbose", false, "print the aircraft, forces and approach drag too")
+	scenarios := flags.String("scenarios", "", "comma-separated scenarios to fly (default all: "+demoScenarioNames()+")")
+	flags.StringVar(&runner.Aircraft, "aircraft", "", "configuration or aircraft to fly (default the simplified model)")
+	flags.Float64Var(&runner.Dt, "dt", runner.Dt, "time step (s)")
+	flags.StringVar(&runner.RecordDir, "record", "", "directory to write a CSV of each scenario to")
+	flags.IntVar(&runner.RecordRate, "record-rate", runner.RecordRate, "recording rate (Hz)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return fmt.Errorf("usage: demo [flags]")
+	}
+
+	switch {
+	case *quiet:
+		runner.Verbosity = DemoQuiet
+	case *verbose:
+		runner.Verbosity = DemoVerbose
+	}
+	if *scenarios != "" {
+		if err := runner.Select(strings.Split(*scenarios, ",")...); err != nil {
+			return err
+		}
+	}
+	_, err := runner.Run()
+	return err
+}
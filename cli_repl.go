@@ -0,0 +1,469 @@
+// REPL Command
+// An interactive console for poking at an aircraft: reading and setting
+// properties, evaluating functions and tables, and trimming, stepping and
+// recording a live simulation. Read from a script, it runs the commands
+// without prompting, for tests and batch checks.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// replCommands are the console's commands and their usage
+var replCommands = []struct{ name, usage string }{
+	{"load", "load <config.xml|aircraft|compiled model>"},
+	{"get", "get <property>"},
+	{"set", "set <property> <value>"},
+	{"eval", "eval <function>"},
+	{"table", "table <table or function> <inputs...>"},
+	{"step", "step [n] [dt]"},
+	{"trim", "trim <speed m/s> <altitude m>"},
+	{"state", "state"},
+	{"record", "record start <file> | record stop"},
+	{"complete", "complete <prefix>"},
+	{"help", "help"},
+}
+
+// replDefaultDt is the time step of step when none is given (s)
+const replDefaultDt = 0.01
+
+// Console is the state of a REPL session: the aircraft loaded, an engine
+// built for it and a session of that engine flying the current trajectory
+type Console struct {
+	out  io.Writer
+	root string // Directory aircraft are looked up in by name
+
+	name      string
+	config    *JSBSimConfig
+	prototype *FlightDynamicsEngine
+	engine    *FlightDynamicsEngine // Session of the prototype
+	state     *AircraftState
+
+	recorder *OutputManager
+	record   *os.File
+}
+
+// NewConsole creates a console writing to out, with nothing loaded
+func NewConsole(out io.Writer) *Console {
+	return &Console{out: out, root: DefaultAircraftDir}
+}
+
+// runREPL runs the console on in, prompting when prompt is set, until in
+// ends. Without a prompt the commands are a script: each failure is
+// reported, and the run fails if any command did.
+func runREPL(args []string, in io.Reader, w io.Writer, prompt bool) error {
+	console := NewConsole(w)
+	defer console.stopRecording()
+	if len(args) > 0 {
+		if err := console.Execute("load " + args[0]); err != nil {
+			return err
+		}
+	}
+
+	failed := 0
+	scanner := bufio.NewScanner(in)
+	for {
+		if prompt {
+			fmt.Fprint(w, "camsim> ")
+		}
+		if !scanner.Scan() {
+			break
+		}
+		if err := console.Execute(scanner.Text()); err != nil {
+			fmt.Fprintf(w, "error: %v\n", err)
+			failed++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if failed > 0 && !prompt {
+		return fmt.Errorf("%d commands failed", failed)
+	}
+	return nil
+}
+
+// Execute runs one command line. Blank lines and lines starting with #
+// do nothing.
+func (c *Console) Execute(line string) error {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+		return nil
+	}
+	command, args := fields[0], fields[1:]
+	if command != "load" && command != "help" && command != "complete" && c.engine == nil {
+		return fmt.Errorf("no aircraft loaded")
+	}
+	switch command {
+	case "load":
+		if len(args) != 1 {
+			return replUsage(command)
+		}
+		return c.load(args[0])
+	case "get":
+		if len(args) != 1 {
+			return replUsage(command)
+		}
+		value, ok := c.property(args[0])
+		if !ok {
+			return fmt.Errorf("no property %s", args[0])
+		}
+		fmt.Fprintf(c.out, "%s = %g\n", args[0], value)
+	case "set":
+		if len(args) != 2 {
+			return replUsage(command)
+		}
+		value, err := strconv.ParseFloat(args[1], 64)
+		if err != nil {
+			return err
+		}
+		c.set(args[0], value)
+		fmt.Fprintf(c.out, "%s = %g\n", args[0], value)
+	case "eval":
+		if len(args) != 1 {
+			return replUsage(command)
+		}
+		return c.eval(args[0])
+	case "table":
+		if len(args) < 2 {
+			return replUsage(command)
+		}
+		return c.table(args[0], args[1:])
+	case "step":
+		return c.step(args)
+	case "trim":
+		if len(args) != 2 {
+			return replUsage(command)
+		}
+		return c.trim(args[0], args[1])
+	case "state":
+		c.printState()
+	case "record":
+		return c.recordCommand(args)
+	case "complete":
+		prefix := ""
+		if len(args) > 0 {
+			prefix = args[0]
+		}
+		fmt.Fprintln(c.out, strings.Join(c.Complete(prefix), " "))
+	case "help":
+		for _, cmd := range replCommands {
+			fmt.Fprintln(c.out, cmd.usage)
+		}
+	default:
+		return fmt.Errorf("unknown command %q; try help", command)
+	}
+	return nil
+}
+
+// replUsage is the error of a command given the wrong arguments
+func replUsage(command string) error {
+	for _, cmd := range replCommands {
+		if cmd.name == command {
+			return fmt.Errorf("usage: %s", cmd.usage)
+		}
+	}
+	return fmt.Errorf("unknown command %q", command)
+}
+
+// load reads an aircraft, from its XML or a compiled model, and starts a
+// trajectory at the simulate command's default initial condition
+func (c *Console) load(arg string) error {
+	path, err := (&aircraftResolver{root: c.root}).path(arg)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var prototype *FlightDynamicsEngine
+	if bytes.HasPrefix(data, []byte(compiledModelMagic)) {
+		model, err := LoadCompiledModel(bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		prototype = NewFlightDynamicsEngine(model.Config, NewRungeKutta4Integrator())
+		prototype.Calculator = model.ForcesMomentsCalculator
+	} else {
+		config, err := ParseJSBSimConfig(bytes.NewReader(data), SourceFile(path))
+		if err != nil {
+			return err
+		}
+		prototype = NewFlightDynamicsEngine(config, NewRungeKutta4Integrator())
+	}
+	c.stopRecording()
+	c.name, c.config, c.prototype = arg, prototype.Calculator.Config, prototype
+
+	state := NewAircraftState()
+	state.Altitude = 1000
+	state.Position = Vector3{Z: -state.Altitude}
+	state.Velocity = Vector3{X: 100}
+	state.Controls.Throttle = 0.7
+	state.UpdateFlightConditions()
+	c.begin(state)
+
+	var functions, tables int
+	if aero := c.config.Aerodynamics; aero != nil {
+		functions = len(aerodynamicsFunctions(aero))
+		tables = len(aerodynamicsTables(aero))
+	}
+	fmt.Fprintf(c.out, "loaded %s: %d functions, %d tables\n", c.config.Name, functions, tables)
+	return nil
+}
+
+// begin starts a new trajectory from state on a fresh session
+func (c *Console) begin(state *AircraftState) {
+	c.engine = c.prototype.NewSession()
+	c.state = state
+	if _, err := c.engine.Calculator.CalculateForcesMoments(state); err != nil {
+		fmt.Fprintf(c.out, "warning: %v\n", err)
+	}
+}
+
+// properties returns the property tree with the state's values over it
+func (c *Console) properties() map[string]float64 {
+	properties := c.engine.Calculator.Properties.GetPropertiesWithPrefix("")
+	c.state.FillPropertyMap(properties)
+	return properties
+}
+
+// property returns a property of the state or the property tree
+func (c *Console) property(name string) (float64, bool) {
+	value, ok := c.properties()[name]
+	return value, ok
+}
+
+// set writes a property: a pilot command goes to the state's controls,
+// within their limits, and anything else to the property tree, where the
+// next step's sync overwrites those the state owns
+func (c *Console) set(name string, value float64) {
+	controls := c.state.Controls
+	if field := commandField(&controls, name); field != nil {
+		*field = value
+		c.state.SetControlInputs(controls)
+		return
+	}
+	c.engine.Calculator.Properties.Set(name, value)
+}
+
+// eval evaluates a named aerodynamic function at the current state
+func (c *Console) eval(name string) error {
+	if c.config.Aerodynamics == nil {
+		return fmt.Errorf("no aerodynamics")
+	}
+	for _, f := range aerodynamicsFunctions(c.config.Aerodynamics) {
+		if f.Name != name {
+			continue
+		}
+		if _, err := c.engine.Calculator.CalculateForcesMoments(c.state); err != nil {
+			return err
+		}
+		value, err := EvaluateFunction(f, c.properties())
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(c.out, "%s = %g\n", name, value)
+		return nil
+	}
+	return fmt.Errorf("no aerodynamic function %s", name)
+}
+
+// table looks a table up at the given inputs. A function's name stands for
+// its first table.
+func (c *Console) table(name string, args []string) error {
+	var table *Table
+	if aero := c.config.Aerodynamics; aero != nil {
+		for _, f := range aerodynamicsFunctions(aero) {
+			for _, t := range functionTables(f) {
+				if table == nil && (t.Name == name || f.Name == name) {
+					table = t
+				}
+			}
+		}
+	}
+	if table == nil {
+		return fmt.Errorf("no table %s", name)
+	}
+	pt, err := cachedParseTable(table)
+	if err != nil {
+		return err
+	}
+	if len(args) != len(pt.IndependentVars) {
+		return fmt.Errorf("table %s takes %d inputs, %s", name, len(pt.IndependentVars), strings.Join(pt.IndependentVars, ", "))
+	}
+	inputs := make([]float64, len(args))
+	for i, arg := range args {
+		if inputs[i], err = strconv.ParseFloat(arg, 64); err != nil {
+			return err
+		}
+	}
+	value, err := InterpolateTable(pt, inputs...)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.out, "%s(%s) = %g\n", name, strings.Join(args, ", "), value)
+	return nil
+}
+
+// step flies n steps of dt, recording each when recording
+func (c *Console) step(args []string) error {
+	n, dt := 1, replDefaultDt
+	var err error
+	if len(args) > 2 {
+		return replUsage("step")
+	}
+	if len(args) > 0 {
+		if n, err = strconv.Atoi(args[0]); err != nil || n < 1 {
+			return fmt.Errorf("step count %q is not a positive integer", args[0])
+		}
+	}
+	if len(args) > 1 {
+		if dt, err = strconv.ParseFloat(args[1], 64); err != nil || dt <= 0 {
+			return fmt.Errorf("time step %q is not a positive number", args[1])
+		}
+	}
+	for i := 0; i < n; i++ {
+		if c.recorder != nil {
+			if err := c.recorder.Record(c.state); err != nil {
+				return err
+			}
+		}
+		next, err := c.engine.Step(c.state, dt)
+		if err != nil {
+			return fmt.Errorf("step at t = %.3f s: %w", c.state.Time, err)
+		}
+		c.state = next
+	}
+	fmt.Fprintf(c.out, "t = %.3f s, altitude %.1f m, airspeed %.1f m/s\n", c.state.Time, c.state.Altitude, c.state.TrueAirspeed)
+	return nil
+}
+
+// trim starts a new trajectory trimmed for level flight
+func (c *Console) trim(speedArg, altitudeArg string) error {
+	speed, err := strconv.ParseFloat(speedArg, 64)
+	if err != nil {
+		return err
+	}
+	altitude, err := strconv.ParseFloat(altitudeArg, 64)
+	if err != nil {
+		return err
+	}
+	engine := c.prototype.NewSession()
+	state, _, err := NewTrimCalculator(engine).TrimState(speed, altitude)
+	if err != nil {
+		return err
+	}
+	c.begin(state)
+	fmt.Fprintf(c.out, "trimmed at %g m/s, %g m: throttle %.3f, elevator %.3f\n",
+		speed, altitude, state.Controls.Throttle, state.Controls.Elevator)
+	return nil
+}
+
+// printState prints a summary of the state
+func (c *Console) printState() {
+	s := c.state
+	roll, pitch, heading := s.Orientation.ToEuler()
+	fmt.Fprintf(c.out, "time      %.3f s\n", s.Time)
+	fmt.Fprintf(c.out, "altitude  %.1f m\n", s.Altitude)
+	fmt.Fprintf(c.out, "airspeed  %.2f m/s (mach %.3f)\n", s.TrueAirspeed, s.Mach)
+	fmt.Fprintf(c.out, "alpha     %.2f deg, beta %.2f deg\n", s.Alpha*RAD_TO_DEG, s.Beta*RAD_TO_DEG)
+	fmt.Fprintf(c.out, "attitude  roll %.2f, pitch %.2f, heading %.2f deg\n",
+		roll*RAD_TO_DEG, pitch*RAD_TO_DEG, math.Mod(heading*RAD_TO_DEG+360, 360))
+	fmt.Fprintf(c.out, "rates     p %.3f, q %.3f, r %.3f rad/s\n", s.AngularRate.X, s.AngularRate.Y, s.AngularRate.Z)
+	fmt.Fprintf(c.out, "controls  elevator %.3f, aileron %.3f, rudder %.3f, throttle %.3f\n",
+		s.Controls.Elevator, s.Controls.Aileron, s.Controls.Rudder, s.Controls.Throttle)
+}
+
+// replOutput is what record writes: the time, position, velocities, rates
+// and surfaces
+var replOutput = &Output{
+	Type:         "CSV",
+	Simulation:   "ON",
+	Position:     "ON",
+	Velocities:   "ON",
+	Rates:        "ON",
+	AeroSurfaces: "ON",
+}
+
+// recordCommand starts recording each step to a CSV file, or stops
+func (c *Console) recordCommand(args []string) error {
+	switch {
+	case len(args) == 2 && args[0] == "start":
+		c.stopRecording()
+		file, err := os.Create(args[1])
+		if err != nil {
+			return err
+		}
+		recorder, err := NewOutputManager(replOutput, 1/replDefaultDt, file)
+		if err != nil {
+			file.Close()
+			return err
+		}
+		recorder.Properties = c.engine.Calculator.Properties
+		c.recorder, c.record = recorder, file
+		fmt.Fprintf(c.out, "recording to %s\n", args[1])
+	case len(args) == 1 && args[0] == "stop":
+		if c.recorder == nil {
+			return fmt.Errorf("not recording")
+		}
+		name := c.record.Name()
+		if err := c.stopRecording(); err != nil {
+			return err
+		}
+		fmt.Fprintf(c.out, "recorded to %s\n", name)
+	default:
+		return replUsage("record")
+	}
+	return nil
+}
+
+// stopRecording flushes and closes any recording
+func (c *Console) stopRecording() error {
+	if c.recorder == nil {
+		return nil
+	}
+	err := c.recorder.Flush()
+	if closeErr := c.record.Close(); err == nil {
+		err = closeErr
+	}
+	c.recorder, c.record = nil, nil
+	return err
+}
+
+// Complete returns the commands, properties and function names starting
+// with prefix, sorted, for completion by a line editor
+func (c *Console) Complete(prefix string) []string {
+	candidates := make(map[string]bool)
+	for _, cmd := range replCommands {
+		candidates[cmd.name] = true
+	}
+	if c.engine != nil {
+		for name := range c.properties() {
+			candidates[name] = true
+		}
+		if aero := c.config.Aerodynamics; aero != nil {
+			for _, f := range aerodynamicsFunctions(aero) {
+				candidates[f.Name] = true
+			}
+		}
+	}
+	var matches []string
+	for name := range candidates {
+		if name != "" && strings.HasPrefix(name, prefix) {
+			matches = append(matches, name)
+		}
+	}
+	sort.Strings(matches)
+	return matches
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// replValue returns the value printed for name by get or eval
+func replValue(t *testing.T, output, name string) float64 {
+	t.Helper()
+	for _, line := range strings.Split(output, "\n") {
+		if value, ok := strings.CutPrefix(line, name+" = "); ok {
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				t.Fatalf("Parsing %q: %v", line, err)
+			}
+			return v
+		}
+	}
+	t.Fatalf("No value of %s in:\n%s", name, output)
+	return 0
+}
+
+func TestREPL(t *testing.T) {
+	t.Run("Trim And Step", func(t *testing.T) {
+		script := strings.Join([]string{
+			"load aircraft/p51d-jsbsim.xml",
+			"trim 100 1000",
+			"step 100",
+			"get position/h-sl-m",
+			"get simulation/sim-time-sec",
+		}, "\n")
+		var out bytes.Buffer
+		if err := runREPL(nil, strings.NewReader(script), &out, false); err != nil {
+			t.Fatalf("runREPL: %v\n%s", err, out.String())
+		}
+		if strings.Contains(out.String(), "camsim>") {
+			t.Error("A script should not be prompted")
+		}
+		assertApproxEqual(t, replValue(t, out.String(), "simulation/sim-time-sec"), 1.0, 1e-9)
+		altitude := replValue(t, out.String(), "position/h-sl-m")
+		if abs(altitude-1000) > 150 {
+			t.Errorf("Altitude %.1f m a second after trimming at 1000 m", altitude)
+		}
+	})
+
+	t.Run("Properties And Functions", func(t *testing.T) {
+		var out bytes.Buffer
+		console := NewConsole(&out)
+		for _, line := range []string{
+			"load aircraft/p51d-jsbsim.xml",
+			"# a comment, then a blank line",
+			"",
+			"set fcs/elevator-cmd-norm -0.1",
+			"set fcs/elevator-cmd-norm -5",
+			"eval aero/coefficient/CLalpha",
+			"table aero/coefficient/CLalpha 4 5e6",
+		} {
+			if err := console.Execute(line); err != nil {
+				t.Fatalf("%s: %v", line, err)
+			}
+		}
+
+		// Pilot commands are held to their limits
+		assertEqual(t, console.state.Controls.Elevator, -1.0)
+		value, _ := console.property("fcs/elevator-cmd-norm")
+		assertEqual(t, value, -1.0)
+
+		// eval agrees with the calculator
+		if _, err := console.engine.Calculator.CalculateForcesMoments(console.state); err != nil {
+			t.Fatalf("CalculateForcesMoments: %v", err)
+		}
+		assertApproxEqual(t, replValue(t, out.String(), "aero/coefficient/CLalpha"),
+			console.engine.Calculator.Properties.Get("aero/coefficient/CLalpha"), 1e-9)
+		if !strings.Contains(out.String(), "aero/coefficient/CLalpha(4, 5e6) = ") {
+			t.Errorf("Expected a table lookup in:\n%s", out.String())
+		}
+
+		completions := console.Complete("aero/coefficient/CL")
+		assertEqual(t, completions, []string{"aero/coefficient/CLalpha", "aero/coefficient/CLde"})
+		assertEqual(t, console.Complete("st"), []string{"state", "step"})
+	})
+
+	t.Run("Record", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "run.csv")
+		script := "load aircraft/p51d-jsbsim.xml\nrecord start " + path + "\nstep 5\nrecord stop\nstep 5\n"
+		var out bytes.Buffer
+		if err := runREPL(nil, strings.NewReader(script), &out, false); err != nil {
+			t.Fatalf("runREPL: %v\n%s", err, out.String())
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Reading the recording: %v", err)
+		}
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		assertEqual(t, len(lines), 1+5)
+		if !strings.HasPrefix(lines[0], "simulation/sim-time-sec,") {
+			t.Errorf("Recording header %q", lines[0])
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		script := strings.Join([]string{
+			"get position/h-sl-m",
+			"load aircraft/p51d-jsbsim.xml",
+			"get no/such-property",
+			"table aero/coefficient/CLalpha 4",
+			"step -1",
+			"record stop",
+			"fly",
+			"get position/h-sl-m",
+		}, "\n")
+		var out bytes.Buffer
+		err := runREPL(nil, strings.NewReader(script), &out, false)
+		if err == nil || !strings.HasPrefix(err.Error(), "6 commands failed") {
+			t.Fatalf("Expected 6 failed commands, got %v\n%s", err, out.String())
+		}
+		assertEqual(t, strings.Count(out.String(), "error: "), 6)
+		replValue(t, out.String(), "position/h-sl-m")
+	})
+}
@@ -0,0 +1,90 @@
+// Simulate Command
+// Flies an aircraft from a given initial condition until a termination
+// condition ends the run, and exits with a code for how it ended
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// gearOverloadFactor is the strut load, in multiples of the aircraft's
+// weight, that the simulate command takes as structural gear overload
+const gearOverloadFactor = 3.0
+
+// runSimulate flies the aircraft named or given as a file in args with the
+// full model, printing the termination report to w. It returns the exit
+// code: ExitComplete or ExitAbort for how the run ended, or ExitUsage with
+// an error when it could not start.
+func runSimulate(args []string, w io.Writer) (int, error) {
+	flags := flag.NewFlagSet("simulate", flag.ContinueOnError)
+	flags.SetOutput(w)
+	duration := flags.Float64("duration", 60, "maximum simulation time (s)")
+	dt := flags.Float64("dt", 0.01, "time step (s)")
+	altitude := flags.Float64("altitude", 1000, "initial altitude (m)")
+	speed := flags.Float64("speed", 100, "initial airspeed (m/s)")
+	pitch := flags.Float64("pitch", 0, "initial pitch attitude and flight path (deg)")
+	throttle := flags.Float64("throttle", 0.7, "throttle (0 to 1)")
+	sinkRate := flags.Float64("sink-rate", 3, "sink rate at the ground that is an impact without a gear model (m/s)")
+	unitSpec := flags.String("units", "si", "units of the final state: si or imperial, with quantity=unit overrides and dual, comma separated")
+	if err := flags.Parse(args); err != nil {
+		return ExitUsage, err
+	}
+	units, err := ParseUnitPreferences(*unitSpec)
+	if err != nil {
+		return ExitUsage, err
+	}
+	if flags.NArg() != 1 || *dt <= 0 {
+		return ExitUsage, fmt.Errorf("usage: simulate [flags] <config.xml|aircraft>")
+	}
+
+	resolver := aircraftResolver{root: DefaultAircraftDir}
+	path, err := resolver.path(flags.Arg(0))
+	if err != nil {
+		return ExitUsage, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return ExitUsage, err
+	}
+	defer file.Close()
+	config, err := ParseJSBSimConfig(file)
+	if err != nil {
+		return ExitUsage, err
+	}
+
+	engine := NewFlightDynamicsEngine(config, NewRungeKutta4Integrator())
+	policy := NewTerminationPolicy(
+		GroundImpact(engine.Gear, GroundImpactLimits{
+			SinkRate:   *sinkRate,
+			StrutForce: gearOverloadFactor * engine.Calculator.Mass * StandardGravity,
+		}),
+		MaxSimTime(*duration),
+	)
+
+	state := NewAircraftState()
+	state.Altitude = *altitude
+	state.Position = Vector3{Z: -*altitude}
+	state.Orientation = NewQuaternionFromEuler(0, *pitch*DEG_TO_RAD, 0)
+	state.Velocity = Vector3{X: *speed}
+	state.Controls.Throttle = *throttle
+	state.UpdateFlightConditions()
+
+	analysis, err := AnalyzeTimeStep(engine, TimeStepAt(state), RequestedTimeStep(*dt))
+	if err != nil {
+		return ExitUsage, err
+	}
+	fmt.Fprintln(w, analysis)
+
+	runner := &ScenarioRunner{Engine: engine, Dt: *dt, Policy: policy}
+	report := runner.Run(state)
+	final := report.Final
+	fmt.Fprintf(w, "%s: %s\n", flags.Arg(0), report)
+	fmt.Fprintf(w, "final: altitude %s, airspeed %s, position N %s E %s\n",
+		units.Format(QuantityAltitude, final.Altitude, 1), units.Format(QuantitySpeed, final.TrueAirspeed, 1),
+		units.Format(QuantityDistance, final.Position.X, 1), units.Format(QuantityDistance, final.Position.Y, 1))
+	return report.ExitCode(), nil
+}
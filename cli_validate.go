@@ -0,0 +1,89 @@
+// Validate Command
+// Parses aircraft configurations and prints everything the parser skipped
+// or had to assume, without running a simulation
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// runValidate parses each file in args with WarnIgnoredElements and prints
+// its parse warnings and unit warnings to w. An argument that is not a file
+// names an aircraft in DefaultAircraftDir. Warnings do not fail
+// validation; a file that cannot be parsed does.
+func runValidate(args []string, w io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: validate <config.xml|aircraft>...")
+	}
+	resolver := aircraftResolver{root: DefaultAircraftDir}
+	failed := 0
+	for _, arg := range args {
+		path, err := resolver.path(arg)
+		if err == nil {
+			err = validateFile(path, w)
+		}
+		if err != nil {
+			fmt.Fprintf(w, "%s: %v\n", arg, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d configurations failed to parse", failed, len(args))
+	}
+	return nil
+}
+
+func validateFile(path string, w io.Writer) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	config, err := ParseJSBSimConfig(file, WarnIgnoredElements())
+	if err != nil {
+		return err
+	}
+	for _, note := range config.Translated {
+		fmt.Fprintf(w, "%s: legacy: %s\n", path, note)
+	}
+	for _, warning := range config.ParseWarnings {
+		fmt.Fprintf(w, "%s:%d: %s %s\n", path, warning.Line, warning.Category, warning.Path)
+	}
+	for _, warning := range config.Warnings {
+		fmt.Fprintf(w, "%s: %s\n", path, warning)
+	}
+	if len(config.ParseWarnings) == 0 && len(config.Warnings) == 0 {
+		fmt.Fprintf(w, "%s: ok\n", path)
+	}
+	return nil
+}
+
+// aircraftResolver finds the configuration file of a command line argument,
+// scanning its library only once a name is given
+type aircraftResolver struct {
+	root string
+	lib  *AircraftLibrary
+}
+
+// path returns arg when it is a file, or the path of the aircraft it names
+func (r *aircraftResolver) path(arg string) (string, error) {
+	if info, err := os.Stat(arg); err == nil && !info.IsDir() {
+		return arg, nil
+	}
+	if r.lib == nil {
+		lib, err := NewAircraftLibrary(r.root)
+		if err != nil {
+			return "", err
+		}
+		r.lib = lib
+	}
+	info, err := r.lib.Find(arg)
+	if err != nil {
+		return "", err
+	}
+	return info.Path, nil
+}
@@ -0,0 +1,552 @@
+// Compiled Aerodynamic Model
+// Bakes a parsed aircraft's aerodynamics into a compact binary artifact that
+// loads without XML parsing or table text parsing
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// compiledModelMagic starts every compiled model artifact
+const compiledModelMagic = "CAMAERO\x00"
+
+// compiledModelVersion is bumped whenever the payload layout or the meaning
+// of anything in it changes, so artifacts built by older code are rejected
+const compiledModelVersion uint32 = 3
+
+// maxCompiledModelSize bounds the payload length read from a header, so a
+// damaged header cannot make loading allocate without limit
+const maxCompiledModelSize = 1 << 28
+
+// compiledModelHeader precedes the payload: magic, format version, payload
+// length and the SHA-256 of the payload
+type compiledModelHeader struct {
+	Magic    [8]byte
+	Version  uint32
+	Length   uint64
+	Checksum [sha256.Size]byte
+}
+
+// CompiledAeroModel is an aircraft loaded from a compiled artifact. Its
+// calculator evaluates the same functions, in the same order, with the same
+// tables as one built from the XML, so the forces are identical.
+//
+// The artifact holds only what the forces use: the aerodynamics, the
+// reference dimensions, the empty weight, the first engine's propeller and
+// the points the forces act at.
+// Its Config has nothing else, so it has no landing gear.
+type CompiledAeroModel struct {
+	*ForcesMomentsCalculator
+}
+
+// The payload is written as a sequence of uvarints, little-endian float64s
+// and length-prefixed strings, in this order:
+//
+//	name
+//	wing area, wing span, chord and empty weight, each as a measurement
+//	propeller: present flag, then thruster file, sense and P-factor
+//	AERORP, thrust location and thrust axis, in body axes about the CG
+//	tables: count, then each table flattened (see compiledEncoder.table)
+//	standalone functions: count, then each function
+//	axes: count, then each axis name, function count and functions
+//
+// A measurement is a present flag followed by its unit and value.
+
+// ExportCompiledModel writes the aerodynamic model of config as a compiled
+// artifact. Every table is parsed here, so loading does no text parsing.
+func ExportCompiledModel(config *JSBSimConfig, w io.Writer) error {
+	e := &compiledEncoder{tables: make(map[*Table]uint64)}
+	e.string(config.Name)
+
+	var metrics Metrics
+	if config.Metrics != nil {
+		metrics = *config.Metrics
+	}
+	var emptyMass *Measurement
+	if config.MassBalance != nil {
+		emptyMass = config.MassBalance.EmptyMass
+	}
+	for _, m := range []*Measurement{metrics.WingArea, metrics.WingSpan, metrics.Chord, emptyMass} {
+		e.measurement(m)
+	}
+
+	var thruster *Thruster
+	if config.Propulsion != nil && len(config.Propulsion.Engine) > 0 {
+		thruster = config.Propulsion.Engine[0].Thruster
+	}
+	e.flag(thruster != nil)
+	if thruster != nil {
+		e.string(thruster.File)
+		e.float(thruster.Sense)
+		e.float(thruster.PFactor)
+	}
+	thrustLocation, thrustAxis := configThrustLine(config)
+	for _, v := range []Vector3{configAeroReference(config), thrustLocation, thrustAxis} {
+		e.float(v.X)
+		e.float(v.Y)
+		e.float(v.Z)
+	}
+
+	aero := config.Aerodynamics
+	if aero == nil {
+		aero = &Aerodynamics{}
+	}
+	tables := aerodynamicsTables(aero)
+	e.uvarint(uint64(len(tables)))
+	for i, table := range tables {
+		e.tables[table] = uint64(i)
+		pt, err := cachedParseTable(table)
+		if err != nil {
+			pt = &ParsedTable{Name: table.Name}
+		}
+		e.table(pt, err)
+	}
+
+	e.uvarint(uint64(len(aero.Function)))
+	for _, function := range aero.Function {
+		e.function(function)
+	}
+	e.uvarint(uint64(len(aero.Axis)))
+	for _, axis := range aero.Axis {
+		e.string(axis.Name)
+		e.uvarint(uint64(len(axis.Function)))
+		for _, function := range axis.Function {
+			e.function(function)
+		}
+	}
+
+	header := compiledModelHeader{
+		Version:  compiledModelVersion,
+		Length:   uint64(len(e.buf)),
+		Checksum: sha256.Sum256(e.buf),
+	}
+	copy(header.Magic[:], compiledModelMagic)
+	if err := binary.Write(w, binary.BigEndian, &header); err != nil {
+		return err
+	}
+	_, err := w.Write(e.buf)
+	return err
+}
+
+// LoadCompiledModel reads an artifact written by ExportCompiledModel. It is
+// rejected if it is not a compiled model, was written by a different format
+// version, or is truncated or corrupted. Each table holds its parsed form,
+// freed with the model.
+func LoadCompiledModel(r io.Reader) (*CompiledAeroModel, error) {
+	var header compiledModelHeader
+	if err := binary.Read(r, binary.BigEndian, &header); err != nil {
+		return nil, fmt.Errorf("reading compiled model header: %w", err)
+	}
+	if string(header.Magic[:]) != compiledModelMagic {
+		return nil, fmt.Errorf("not a compiled aerodynamic model")
+	}
+	if header.Version != compiledModelVersion {
+		return nil, fmt.Errorf("compiled model version %d is not the supported version %d; re-export it from the XML",
+			header.Version, compiledModelVersion)
+	}
+
+	if header.Length > maxCompiledModelSize {
+		return nil, fmt.Errorf("compiled model payload of %d bytes is too large", header.Length)
+	}
+	body := make([]byte, header.Length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, fmt.Errorf("reading compiled model: %w", err)
+	}
+	if sha256.Sum256(body) != header.Checksum {
+		return nil, fmt.Errorf("compiled model checksum mismatch")
+	}
+
+	d := &compiledDecoder{buf: body}
+	config := &JSBSimConfig{Name: d.string()}
+	config.Metrics = &Metrics{WingArea: d.measurement(), WingSpan: d.measurement(), Chord: d.measurement()}
+	config.MassBalance = &MassBalance{EmptyMass: d.measurement()}
+	if d.flag() {
+		thruster := &Thruster{File: d.string(), Sense: d.float(), PFactor: d.float()}
+		config.Propulsion = &Propulsion{Engine: []*Engine{{Thruster: thruster}}}
+	}
+	var arms [3]Vector3
+	for i := range arms {
+		arms[i] = Vector3{X: d.float(), Y: d.float(), Z: d.float()}
+	}
+
+	// Each table is given its parsed form, so evaluation never parses it
+	d.tables = make([]*Table, d.count())
+	for i := range d.tables {
+		pt, err := d.table()
+		table := &Table{Name: pt.Name, IndependentVar: make([]*IndependentVar, len(pt.IndependentVars))}
+		for j, name := range pt.IndependentVars {
+			table.IndependentVar[j] = &IndependentVar{Value: name}
+			if j < len(pt.LookupTypes) {
+				table.IndependentVar[j].Lookup = pt.LookupTypes[j]
+			}
+		}
+		table.setParsed(pt, err)
+		d.tables[i] = table
+	}
+
+	aero := &Aerodynamics{Function: make([]*Function, d.count())}
+	for i := range aero.Function {
+		aero.Function[i] = d.function()
+	}
+	aero.Axis = make([]*Axis, d.count())
+	for i := range aero.Axis {
+		aero.Axis[i] = &Axis{Name: d.string(), Function: make([]*Function, d.count())}
+		for j := range aero.Axis[i].Function {
+			aero.Axis[i].Function[j] = d.function()
+		}
+	}
+	config.Aerodynamics = aero
+
+	if d.err == nil && len(d.buf) > 0 {
+		d.err = fmt.Errorf("%d bytes left over", len(d.buf))
+	}
+	if d.err != nil {
+		return nil, fmt.Errorf("decoding compiled model: %w", d.err)
+	}
+	calc := NewForcesMomentsCalculator(config)
+	calc.AeroReference, calc.ThrustLocation, calc.ThrustAxis = arms[0], arms[1], arms[2]
+	return &CompiledAeroModel{calc}, nil
+}
+
+// aerodynamicsTables returns every table of the aerodynamics functions,
+// standalone functions first and then each axis in turn
+func aerodynamicsTables(aero *Aerodynamics) []*Table {
+	var tables []*Table
+	for _, function := range aero.Function {
+		tables = append(tables, functionTables(function)...)
+	}
+	for _, axis := range aero.Axis {
+		for _, function := range axis.Function {
+			tables = append(tables, functionTables(function)...)
+		}
+	}
+	return tables
+}
+
+// compiledEncoder appends the payload to buf
+type compiledEncoder struct {
+	buf    []byte
+	tables map[*Table]uint64 // Index of each table in the artifact
+}
+
+func (e *compiledEncoder) uvarint(v uint64) { e.buf = binary.AppendUvarint(e.buf, v) }
+
+func (e *compiledEncoder) float(v float64) {
+	e.buf = binary.LittleEndian.AppendUint64(e.buf, math.Float64bits(v))
+}
+
+func (e *compiledEncoder) string(s string) {
+	e.uvarint(uint64(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+func (e *compiledEncoder) flag(b bool) {
+	if b {
+		e.uvarint(1)
+	} else {
+		e.uvarint(0)
+	}
+}
+
+func (e *compiledEncoder) floats(values []float64) {
+	e.uvarint(uint64(len(values)))
+	for _, v := range values {
+		e.float(v)
+	}
+}
+
+func (e *compiledEncoder) strings(values []string) {
+	e.uvarint(uint64(len(values)))
+	for _, s := range values {
+		e.string(s)
+	}
+}
+
+func (e *compiledEncoder) measurement(m *Measurement) {
+	e.flag(m != nil)
+	if m != nil {
+		e.string(m.Unit)
+		e.float(m.Value)
+	}
+}
+
+// table writes a parsed table flattened: its name, dimension, variables,
+// lookup types and parse error, then the data. A 1D table is its indices
+// and values; a 2D table is one slice and a 3D table a count of slices.
+// Each slice is a present flag, its breakpoint, column and row indices,
+// then the length of each row and all the row values end to end.
+func (e *compiledEncoder) table(pt *ParsedTable, err error) {
+	e.string(pt.Name)
+	e.uvarint(uint64(pt.Dimension))
+	e.strings(pt.IndependentVars)
+	e.strings(pt.LookupTypes)
+	message := ""
+	if err != nil {
+		message = err.Error()
+	}
+	e.string(message)
+
+	slice := func(t *Table2D) {
+		e.flag(t != nil)
+		if t == nil {
+			return
+		}
+		e.float(t.Breakpoint)
+		e.floats(t.ColIndices)
+		e.floats(t.RowIndices)
+		var values []float64
+		for _, row := range t.Data {
+			e.uvarint(uint64(len(row)))
+			values = append(values, row...)
+		}
+		e.floats(values)
+	}
+	switch pt.Dimension {
+	case 1:
+		e.floats(pt.Data1D.Indices)
+		e.floats(pt.Data1D.Values)
+	case 2:
+		slice(pt.Data2D)
+	case 3:
+		e.uvarint(uint64(len(pt.Data3D)))
+		for _, t := range pt.Data3D {
+			slice(t)
+		}
+	}
+}
+
+// function writes a function's name and unit, then its body as a node
+func (e *compiledEncoder) function(f *Function) {
+	e.string(f.Name)
+	e.string(f.Unit)
+	var operands []operand
+	if f.Table != nil {
+		operands = append(operands, operand{kind: operandTable})
+	}
+	for _, named := range functionOperations(f) {
+		operands = append(operands, operand{kind: operandOperation, nested: named})
+	}
+	e.node(&Operation{Tables: []*Table{f.Table}}, operands)
+}
+
+// operation writes an operation's unit and then its body as a node
+func (e *compiledEncoder) operation(op *Operation) {
+	e.string(op.Unit)
+	e.node(op, op.operandList())
+}
+
+// node writes the operand count and then each operand in evaluation order,
+// as its kind followed by its property, value or table index, or for a
+// nested operation its operationNames index plus operandOperation
+// followed by the operation
+func (e *compiledEncoder) node(op *Operation, operands []operand) {
+	e.uvarint(uint64(len(operands)))
+	for _, operand := range operands {
+		switch operand.kind {
+		case operandProperty:
+			e.uvarint(uint64(operandProperty))
+			e.string(op.Property[operand.index])
+		case operandValue:
+			e.uvarint(uint64(operandValue))
+			e.float(op.Value[operand.index])
+		case operandTable:
+			e.uvarint(uint64(operandTable))
+			e.uvarint(e.tables[op.Tables[operand.index]])
+		case operandOperation:
+			e.uvarint(uint64(operandOperation) + uint64(operationIndex(operand.nested.name)))
+			e.operation(operand.nested.op)
+		}
+	}
+}
+
+// compiledDecoder reads the payload from buf. After the first error every
+// read returns a zero value and err keeps that error.
+type compiledDecoder struct {
+	buf    []byte
+	err    error
+	tables []*Table
+}
+
+func (d *compiledDecoder) fail(format string, args ...any) {
+	if d.err == nil {
+		d.err = fmt.Errorf(format, args...)
+	}
+	d.buf = nil
+}
+
+func (d *compiledDecoder) uvarint() uint64 {
+	v, n := binary.Uvarint(d.buf)
+	if n <= 0 {
+		d.fail("truncated integer")
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return v
+}
+
+// count reads a length, rejecting any larger than the bytes that remain
+func (d *compiledDecoder) count() int {
+	n := d.uvarint()
+	if n > uint64(len(d.buf)) {
+		d.fail("count %d exceeds the remaining %d bytes", n, len(d.buf))
+		return 0
+	}
+	return int(n)
+}
+
+func (d *compiledDecoder) float() float64 {
+	if len(d.buf) < 8 {
+		d.fail("truncated float")
+		return 0
+	}
+	v := math.Float64frombits(binary.LittleEndian.Uint64(d.buf))
+	d.buf = d.buf[8:]
+	return v
+}
+
+func (d *compiledDecoder) string() string {
+	n := d.count()
+	s := string(d.buf[:n])
+	d.buf = d.buf[n:]
+	return s
+}
+
+func (d *compiledDecoder) flag() bool { return d.uvarint() != 0 }
+
+func (d *compiledDecoder) floats() []float64 {
+	n := d.count()
+	if n == 0 {
+		return nil
+	}
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = d.float()
+	}
+	return values
+}
+
+func (d *compiledDecoder) strings() []string {
+	n := d.count()
+	if n == 0 {
+		return nil
+	}
+	values := make([]string, n)
+	for i := range values {
+		values[i] = d.string()
+	}
+	return values
+}
+
+func (d *compiledDecoder) measurement() *Measurement {
+	if !d.flag() {
+		return nil
+	}
+	return &Measurement{Unit: d.string(), Value: d.float()}
+}
+
+// table reads a table written by compiledEncoder.table. The rows of each
+// slice share one backing array.
+func (d *compiledDecoder) table() (*ParsedTable, error) {
+	pt := &ParsedTable{
+		Name:            d.string(),
+		Dimension:       int(d.uvarint()),
+		IndependentVars: d.strings(),
+		LookupTypes:     d.strings(),
+	}
+	var err error
+	if message := d.string(); message != "" {
+		err = errors.New(message)
+	}
+
+	slice := func() *Table2D {
+		if !d.flag() {
+			return nil
+		}
+		t := &Table2D{Breakpoint: d.float(), ColIndices: d.floats(), RowIndices: d.floats()}
+		lengths := make([]int, len(t.RowIndices))
+		for i := range lengths {
+			lengths[i] = d.count()
+		}
+		values := d.floats()
+		t.Data = make([][]float64, len(lengths))
+		for i, n := range lengths {
+			if n > len(values) {
+				d.fail("table %q row %d is longer than its values", pt.Name, i)
+				return t
+			}
+			t.Data[i], values = values[:n:n], values[n:]
+		}
+		return t
+	}
+	switch pt.Dimension {
+	case 1:
+		pt.Data1D = &Table1D{Indices: d.floats(), Values: d.floats()}
+	case 2:
+		pt.Data2D = slice()
+	case 3:
+		pt.Data3D = make([]*Table2D, d.count())
+		for i := range pt.Data3D {
+			pt.Data3D[i] = slice()
+		}
+	}
+	return pt, err
+}
+
+func (d *compiledDecoder) function() *Function {
+	f := &Function{Name: d.string(), Unit: d.string()}
+	body := &Operation{}
+	d.node(body)
+	if len(body.Property) > 0 || len(body.Value) > 0 {
+		d.fail("function %q has properties or values outside an operation", f.Name)
+	}
+	if len(body.Tables) > 1 {
+		d.fail("function %q has %d tables outside an operation", f.Name, len(body.Tables))
+	} else if len(body.Tables) == 1 {
+		f.Table = body.Tables[0]
+	}
+	slots := functionSlots(f)
+	for _, named := range nestedOperations(body) {
+		slot := slots[operationIndex(named.name)]
+		if *slot != nil {
+			d.fail("function %q has more than one %s", f.Name, named.name)
+		}
+		*slot = named.op
+	}
+	return f
+}
+
+func (d *compiledDecoder) operation() *Operation {
+	op := &Operation{Unit: d.string()}
+	d.node(op)
+	return op
+}
+
+// node reads the operands of a node into op
+func (d *compiledDecoder) node(op *Operation) {
+	for n := d.count(); n > 0 && d.err == nil; n-- {
+		switch code := d.uvarint(); {
+		case code == uint64(operandProperty):
+			op.addProperty(d.string())
+		case code == uint64(operandValue):
+			op.addValue(d.float())
+		case code == uint64(operandTable):
+			index := d.uvarint()
+			if index >= uint64(len(d.tables)) {
+				d.fail("table index %d out of range", index)
+				return
+			}
+			op.addTable(d.tables[index])
+		case code-uint64(operandOperation) < uint64(len(operationNames)):
+			op.addOperation(int(code-uint64(operandOperation)), d.operation())
+		default:
+			d.fail("unknown operand %d", code)
+			return
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestCompiledModel(t *testing.T) {
+	config := loadP51DConfig(t)
+	var artifact bytes.Buffer
+	if err := ExportCompiledModel(config, &artifact); err != nil {
+		t.Fatalf("ExportCompiledModel: %v", err)
+	}
+
+	t.Run("Forces Match XML Model", func(t *testing.T) {
+		model, err := LoadCompiledModel(bytes.NewReader(artifact.Bytes()))
+		if err != nil {
+			t.Fatalf("LoadCompiledModel: %v", err)
+		}
+		calc := NewForcesMomentsCalculator(config)
+
+		states := 0
+		for _, altitude := range []float64{0, 3000, 9000} {
+			for _, speed := range []float64{40, 100, 180} {
+				for alpha := -0.2; alpha <= 0.45; alpha += 0.05 {
+					for _, elevator := range []float64{-0.2, 0, 0.15} {
+						state := NewAircraftState()
+						state.Altitude = altitude
+						state.Position = Vector3{Z: -altitude}
+						state.Velocity = Vector3{X: speed * math.Cos(alpha), Y: 2, Z: speed * math.Sin(alpha)}
+						state.AngularRate = Vector3{X: 0.1, Y: -0.05, Z: 0.02}
+						state.Controls.Elevator = elevator
+						state.Controls.Aileron = 0.1
+						state.Controls.Throttle = 0.8
+						state.UpdateAtmosphere()
+						state.UpdateDerivedParameters()
+
+						want, err := calc.CalculateForcesMoments(state)
+						if err != nil {
+							t.Fatalf("XML model: %v", err)
+						}
+						got, err := model.CalculateForcesMoments(state)
+						if err != nil {
+							t.Fatalf("Compiled model: %v", err)
+						}
+						if *got != *want {
+							t.Fatalf("Forces differ at h=%.0f V=%.0f alpha=%.2f elevator=%.2f:\n%+v\n%+v",
+								altitude, speed, alpha, elevator, *got, *want)
+						}
+						states++
+					}
+				}
+			}
+		}
+		assertEqual(t, states, 378)
+		assertEqual(t, model.Config.Name, config.Name)
+	})
+
+	t.Run("No Table Text", func(t *testing.T) {
+		model, err := LoadCompiledModel(bytes.NewReader(artifact.Bytes()))
+		if err != nil {
+			t.Fatalf("LoadCompiledModel: %v", err)
+		}
+		tables := aerodynamicsTables(model.Config.Aerodynamics)
+		assertEqual(t, len(tables), len(aerodynamicsTables(config.Aerodynamics)))
+		for _, table := range tables {
+			if table.TableData != nil {
+				t.Fatalf("Table %q was loaded with its text", table.Name)
+			}
+			// Its parsed form is kept with it, not parsed from the text
+			if pt, err := cachedParseTable(table); pt == nil || err != nil {
+				t.Fatalf("Table %q was loaded without its parsed form", table.Name)
+			}
+		}
+	})
+
+	t.Run("Loads Faster Than Parsing", func(t *testing.T) {
+		data, err := os.ReadFile("aircraft/p51d-jsbsim.xml")
+		if err != nil {
+			t.Fatalf("Failed to read P-51D XML: %v", err)
+		}
+		// The fastest of several runs of each, so a slow run on a busy
+		// machine does not decide it
+		fastest := func(run func() error) time.Duration {
+			best := time.Duration(math.MaxInt64)
+			for i := 0; i < 10; i++ {
+				start := time.Now()
+				if err := run(); err != nil {
+					t.Fatal(err)
+				}
+				best = min(best, time.Since(start))
+			}
+			return best
+		}
+		parse := fastest(func() error {
+			_, err := ParseJSBSimConfig(bytes.NewReader(data))
+			return err
+		})
+		load := fastest(func() error {
+			_, err := LoadCompiledModel(bytes.NewReader(artifact.Bytes()))
+			return err
+		})
+		t.Logf("Parsing the XML took %v, loading the compiled model %v", parse, load)
+		// More than ten times faster when measured; a loose factor here
+		if load*3 > parse {
+			t.Errorf("The compiled model should load at least 3 times faster than the XML parses, took %v against %v", load, parse)
+		}
+	})
+
+	t.Run("Rejects Stale Or Corrupted Artifacts", func(t *testing.T) {
+		stale := bytes.Clone(artifact.Bytes())
+		binary.BigEndian.PutUint32(stale[8:], compiledModelVersion+1)
+		corrupted := bytes.Clone(artifact.Bytes())
+		corrupted[len(corrupted)-10] ^= 0xff
+
+		for name, data := range map[string][]byte{
+			"stale":     stale,
+			"corrupted": corrupted,
+			"truncated": artifact.Bytes()[:artifact.Len()/2],
+			"xml":       []byte("<fdm_config name=\"p51d\"></fdm_config>"),
+		} {
+			if _, err := LoadCompiledModel(bytes.NewReader(data)); err == nil {
+				t.Errorf("Expected the %s artifact to be rejected", name)
+			}
+		}
+	})
+}
+
+// BenchmarkLoadCompiledModel is compared with BenchmarkFullP51DParsing; the
+// compiled model loads more than ten times faster
+func BenchmarkLoadCompiledModel(b *testing.B) {
+	file, err := os.Open("aircraft/p51d-jsbsim.xml")
+	if err != nil {
+		b.Fatalf("Failed to open file: %v", err)
+	}
+	config, err := ParseJSBSimConfig(file)
+	file.Close()
+	if err != nil {
+		b.Fatalf("Parse error: %v", err)
+	}
+	var artifact bytes.Buffer
+	if err := ExportCompiledModel(config, &artifact); err != nil {
+		b.Fatalf("Export error: %v", err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := LoadCompiledModel(bytes.NewReader(artifact.Bytes())); err != nil {
+			b.Fatalf("Load error: %v", err)
+		}
+	}
+}
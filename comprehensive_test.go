@@ -2,7 +2,9 @@ package main
 
 import (
 	"fmt"
+	"math"
 	"os"
+	"regexp"
 	"strings"
 	"testing"
 	"time"
@@ -349,12 +351,12 @@ func TestRealP51DData(t *testing.T) {
 				}
 				
 				// Check tables in operations
-				if fn.Product != nil && fn.Product.Table != nil {
+				if fn.Product != nil && len(fn.Product.Tables) > 0 {
 					tableCount++
 					functionsWithTables = append(functionsWithTables, fn.Name+" (product)")
 					
 					// Test parsing and interpolation
-					pt, err := ParseTable(fn.Product.Table)
+					pt, err := ParseTable(fn.Product.Tables[0])
 					if err != nil {
 						t.Errorf("Failed to parse product table in %s: %v", fn.Name, err)
 						continue
@@ -495,6 +497,31 @@ func TestErrorHandling(t *testing.T) {
 		}
 	})
 
+	t.Run("Malformed Table Data", func(t *testing.T) {
+		table := &Table{
+			Name:           "malformed",
+			IndependentVar: []*IndependentVar{{Value: "aero/alpha-rad"}},
+			TableData:      []*TableData{{Data: "0 1\n5 x\n10\n15 2"}},
+		}
+		pt, err := ParseTable(table)
+		if err != nil {
+			t.Fatalf("Malformed data should parse with zeros: %v", err)
+		}
+		assertEqual(t, pt.Data1D.Indices, []float64{0, 5, 15})
+		assertEqual(t, pt.Data1D.Values, []float64{1, 0, 2})
+		assertEqual(t, pt.Warnings, []string{`malformed number "x" read as 0`, `row "10" has no value, skipped`})
+
+		config, err := ParseJSBSimConfig(strings.NewReader(`<fdm_config><aerodynamics>
+			<function name="aero/k"><table name="k"><independentVar>aero/alpha-rad</independentVar>
+				<tableData> 0 1
+				            5 1,5 </tableData></table></function>
+		</aerodynamics></fdm_config>`))
+		if err != nil {
+			t.Fatalf("Parse failed: %v", err)
+		}
+		assertEqual(t, config.Warnings, []string{`function aero/k: table k: malformed number "1,5" read as 0`})
+	})
+
 	t.Run("Invalid Table Dimension", func(t *testing.T) {
 		pt := &ParsedTable{Dimension: 5}
 		_, err := InterpolateTable(pt, 1.0)
@@ -719,29 +746,107 @@ func TestTableFeatures(t *testing.T) {
 
 // Test unit conversion functions
 func TestUnitConversions(t *testing.T) {
-	t.Run("Length Conversions", func(t *testing.T) {
-		// Test meter to feet
-		result := convertToStandardUnit(1.0, "M", "length")
-		assertApproxEqual(t, result, M_TO_FT, 0.001)
-
-		// Test inches to feet
-		result = convertToStandardUnit(12.0, "IN", "length")
-		assertApproxEqual(t, result, 1.0, 0.001)
+	// Every unit string in the bundled P-51D file, and the units they are
+	// converted from elsewhere, with the canonical FPS value of one unit
+	cases := []struct {
+		unit, unitType string
+		want           float64
+	}{
+		{"FT", "length", 1},
+		{"IN", "length", 1.0 / 12},
+		{"M", "length", M_TO_FT},
+		{"FT2", "area", 1},
+		{"M2", "area", M2_TO_FT2},
+		{"LBS", "mass", 1},
+		{"KG", "mass", KG_TO_LB},
+		{"SLUG", "mass", 32.174},
+		{"SLUG*FT2", "inertia", 1},
+		{"KG*M2", "inertia", 0.737562},
+		{"DEG", "angle", math.Pi / 180},
+		{"RAD", "angle", 1},
+		{"KTS", "velocity", KTS_TO_FPS},
+		{"HP", "power", 550},
+		{"WATTS", "power", 0.737562},
+		{"LBS/FT", "spring", 1},
+		{"N/M", "spring", 0.0685218},
+		{"LBS/FT/SEC", "damping", 1},
+		{" lbs/ft/sec ", "damping", 1},
+		{"", "length", 1},
+	}
+	tested := map[string]bool{}
+	for _, c := range cases {
+		result, err := convertToStandardUnit(1.0, c.unit, c.unitType)
+		if err != nil {
+			t.Errorf("%s %s: %v", c.unitType, c.unit, err)
+			continue
+		}
+		assertApproxEqual(t, result, c.want, 1e-4*c.want)
+		tested[strings.ToUpper(strings.TrimSpace(c.unit))] = true
+	}
+
+	t.Run("Units In P-51D File", func(t *testing.T) {
+		data, err := os.ReadFile("aircraft/p51d-jsbsim.xml")
+		if err != nil {
+			t.Skip("P-51D file not available")
+		}
+		for _, match := range regexp.MustCompile(`unit="([^"]*)"`).FindAllStringSubmatch(string(data), -1) {
+			if !tested[match[1]] {
+				t.Errorf("Unit %q in the P-51D file has no conversion case", match[1])
+				tested[match[1]] = true
+			}
+		}
 	})
 
-	t.Run("Area Conversions", func(t *testing.T) {
-		result := convertToStandardUnit(1.0, "M2", "area")
-		assertApproxEqual(t, result, M2_TO_FT2, 0.01)
+	t.Run("Parsed P-51D Values", func(t *testing.T) {
+		config := loadP51DConfig(t)
+		assertEqual(t, config.Warnings, []string(nil))
+		assertEqual(t, config.MassBalance.IXX.Value, 8031.0)
+		assertEqual(t, config.MassBalance.IXX.Unit, "SLUG*FT2")
+		assertEqual(t, config.Metrics.WingArea.Value, 235.0)
+
+		tail := config.GroundReactions.Contact[2]
+		assertEqual(t, tail.SpringCoeff.Unit, "LBS/FT")
+		assertApproxEqual(t, tail.MaxSteer.Value, 2*math.Pi, 1e-4)
+		assertEqual(t, tail.MaxSteer.Unit, "RAD")
+
+		engine := config.Propulsion.Engine[0]
+		assertApproxEqual(t, engine.Orient.Roll, -4.0*math.Pi/180, 1e-6)
+		assertApproxEqual(t, engine.Thruster.Orient.Pitch, 2.5*math.Pi/180, 1e-6)
+		assertEqual(t, config.Propulsion.Tank[0].Capacity.Value, 553.84)
 	})
 
-	t.Run("Mass Conversions", func(t *testing.T) {
-		result := convertToStandardUnit(1.0, "KG", "mass")
-		assertApproxEqual(t, result, KG_TO_LB, 0.01)
+	t.Run("Unknown Units", func(t *testing.T) {
+		result, err := convertToStandardUnit(5.0, "FURLONG", "length")
+		if err == nil {
+			t.Error("Expected an error for an unknown unit")
+		}
+		assertEqual(t, result, 5.0) // Returned unconverted
+
+		config, err := ParseJSBSimConfig(strings.NewReader(`<fdm_config>
+			<metrics><wingspan unit="FURLONG"> 0.2 </wingspan></metrics>
+			<ground_reactions><contact name="nose"><spring_coeff unit="LBS/YD"> 10 </spring_coeff></contact></ground_reactions>
+		</fdm_config>`))
+		if err != nil {
+			t.Fatalf("Parse failed: %v", err)
+		}
+		assertEqual(t, config.Warnings, []string{
+			`metrics/wingspan: unknown length unit "FURLONG", value used unconverted`,
+			`contact nose spring_coeff: unknown spring unit "LBS/YD", value used unconverted`,
+		})
+		assertEqual(t, config.Metrics.WingSpan.Value, 0.2)
 	})
 
-	t.Run("Unknown Unit", func(t *testing.T) {
-		result := convertToStandardUnit(5.0, "UNKNOWN", "length")
-		assertEqual(t, result, 5.0) // Should return unchanged
+	t.Run("Unitless Max Steer", func(t *testing.T) {
+		config, err := ParseJSBSimConfig(strings.NewReader(`<fdm_config>
+			<ground_reactions><contact type="BOGEY" name="tail"><max_steer> 360 </max_steer></contact></ground_reactions>
+		</fdm_config>`))
+		if err != nil {
+			t.Fatalf("Parse failed: %v", err)
+		}
+		steer := config.GroundReactions.Contact[0].MaxSteer
+		assertApproxEqual(t, steer.Value, 2*math.Pi, 1e-4)
+		assertEqual(t, steer.Unit, "RAD")
+		assertEqual(t, NewLandingGear(config).Units[0].Steering, GearCaster)
 	})
 }
 
@@ -770,8 +875,8 @@ func TestP51DAerodynamics(t *testing.T) {
 			t.Fatal("CDo function not found")
 		}
 
-		if cdoFunc.Product != nil && cdoFunc.Product.Table != nil {
-			pt, err := ParseTable(cdoFunc.Product.Table)
+		if cdoFunc.Product != nil && len(cdoFunc.Product.Tables) > 0 {
+			pt, err := ParseTable(cdoFunc.Product.Tables[0])
 			if err != nil {
 				t.Fatalf("Failed to parse CDo table: %v", err)
 			}
@@ -797,8 +902,8 @@ func TestP51DAerodynamics(t *testing.T) {
 
 		// Test CLalpha function
 		clAlphaFunc := findFunction(liftAxis.Function, "aero/coefficient/CLalpha")
-		if clAlphaFunc != nil && clAlphaFunc.Product != nil && clAlphaFunc.Product.Table != nil {
-			pt, err := ParseTable(clAlphaFunc.Product.Table)
+		if clAlphaFunc != nil && clAlphaFunc.Product != nil && len(clAlphaFunc.Product.Tables) > 0 {
+			pt, err := ParseTable(clAlphaFunc.Product.Tables[0])
 			if err != nil {
 				t.Fatalf("Failed to parse CLalpha table: %v", err)
 			}
@@ -0,0 +1,578 @@
+// Configuration Diff
+// Compares two aircraft configurations and reports what differs
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ConfigDiffKind classifies a configuration difference
+type ConfigDiffKind string
+
+const (
+	DiffChanged ConfigDiffKind = "changed" // A value differs
+	DiffAdded   ConfigDiffKind = "added"   // Present only in the second config
+	DiffRemoved ConfigDiffKind = "removed" // Present only in the first config
+	DiffTable   ConfigDiffKind = "table"   // Table dimension, breakpoints or data differ
+)
+
+// ConfigDiff is one difference between two configurations
+type ConfigDiff struct {
+	Section string         `json:"section"` // metrics, mass_balance, ground_reactions, propulsion, aerodynamics
+	Path    string         `json:"path"`    // Item within the section, e.g. "pointmass[Pilot]/weight"
+	Kind    ConfigDiffKind `json:"kind"`
+	Old     interface{}    `json:"old,omitempty"` // float64 or string, for changed values
+	New     interface{}    `json:"new,omitempty"`
+	Unit    string         `json:"unit,omitempty"`
+	Table   *TableDiff     `json:"table,omitempty"`
+	Detail  string         `json:"detail,omitempty"`
+}
+
+// TableDiff describes how two versions of a table differ. The data
+// statistics are only computed when both tables have the same shape.
+type TableDiff struct {
+	OldDimension       int     `json:"old_dimension"`
+	NewDimension       int     `json:"new_dimension"`
+	BreakpointsChanged bool    `json:"breakpoints_changed"`
+	MaxAbsDiff         float64 `json:"max_abs_diff"`
+	MeanAbsDiff        float64 `json:"mean_abs_diff"`
+	ChangedValues      int     `json:"changed_values"`
+}
+
+// configDiffer accumulates differences for one section
+type configDiffer struct {
+	section string
+	diffs   []ConfigDiff
+}
+
+func (d *configDiffer) add(diff ConfigDiff) {
+	diff.Section = d.section
+	d.diffs = append(d.diffs, diff)
+}
+
+// number reports a changed numeric value
+func (d *configDiffer) number(path string, a, b float64, unit string) {
+	if a != b {
+		d.add(ConfigDiff{Path: path, Kind: DiffChanged, Old: a, New: b, Unit: unit})
+	}
+}
+
+// text reports a changed string value
+func (d *configDiffer) text(path, a, b string) {
+	if strings.TrimSpace(a) != strings.TrimSpace(b) {
+		d.add(ConfigDiff{Path: path, Kind: DiffChanged, Old: a, New: b})
+	}
+}
+
+// measurement reports a changed measurement; unit overrides the file's unit
+// for values converted to standard units on parse
+func (d *configDiffer) measurement(path string, a, b *Measurement, unit string) {
+	switch {
+	case a == nil && b == nil:
+	case a == nil:
+		d.add(ConfigDiff{Path: path, Kind: DiffAdded, New: b.Value, Unit: measurementUnit(b, unit)})
+	case b == nil:
+		d.add(ConfigDiff{Path: path, Kind: DiffRemoved, Old: a.Value, Unit: measurementUnit(a, unit)})
+	default:
+		d.number(path, a.Value, b.Value, measurementUnit(b, unit))
+	}
+}
+
+func measurementUnit(m *Measurement, unit string) string {
+	if unit != "" {
+		return unit
+	}
+	return m.Unit
+}
+
+// location reports changed coordinates
+func (d *configDiffer) location(path string, a, b *Location) {
+	switch {
+	case a == nil && b == nil:
+	case a == nil:
+		d.add(ConfigDiff{Path: path, Kind: DiffAdded})
+	case b == nil:
+		d.add(ConfigDiff{Path: path, Kind: DiffRemoved})
+	default:
+		d.number(path+"/x", a.X, b.X, b.Unit)
+		d.number(path+"/y", a.Y, b.Y, b.Unit)
+		d.number(path+"/z", a.Z, b.Z, b.Unit)
+		d.text(path+"/unit", a.Unit, b.Unit)
+	}
+}
+
+// keyed pairs two lists of items by key. Keys repeated within a list get a
+// "#n" suffix so each item is matched with its counterpart in order.
+// Removed items are reported before added ones; matched items are passed
+// to compare in the order of the first list.
+func keyed[T any](d *configDiffer, kind string, a, b []T, key func(int, T) string, compare func(path string, x, y T)) {
+	keysA, keysB := uniqueKeys(a, key), uniqueKeys(b, key)
+	inB := make(map[string]int, len(b))
+	for i, k := range keysB {
+		inB[k] = i
+	}
+	inA := make(map[string]bool, len(a))
+	for i, k := range keysA {
+		inA[k] = true
+		path := fmt.Sprintf("%s[%s]", kind, k)
+		if j, ok := inB[k]; ok {
+			compare(path, a[i], b[j])
+		} else {
+			d.add(ConfigDiff{Path: path, Kind: DiffRemoved})
+		}
+	}
+	for _, k := range keysB {
+		if !inA[k] {
+			d.add(ConfigDiff{Path: fmt.Sprintf("%s[%s]", kind, k), Kind: DiffAdded})
+		}
+	}
+}
+
+func uniqueKeys[T any](items []T, key func(int, T) string) []string {
+	keys := make([]string, len(items))
+	seen := make(map[string]int, len(items))
+	for i, item := range items {
+		k := key(i, item)
+		seen[k]++
+		if seen[k] > 1 {
+			k = fmt.Sprintf("%s#%d", k, seen[k])
+		}
+		keys[i] = k
+	}
+	return keys
+}
+
+// nameOr returns name, or the fallback when name is blank
+func nameOr(name, fallback string) string {
+	if strings.TrimSpace(name) == "" {
+		return fallback
+	}
+	return strings.TrimSpace(name)
+}
+
+// DiffConfigs lists the differences between two configurations: scalar
+// value changes, added and removed point masses, contacts, engines, tanks
+// and aerodynamic functions, and table changes within functions present in
+// both. Differences are grouped by section in file order.
+func DiffConfigs(a, b *JSBSimConfig) []ConfigDiff {
+	if a == nil {
+		a = &JSBSimConfig{}
+	}
+	if b == nil {
+		b = &JSBSimConfig{}
+	}
+
+	var diffs []ConfigDiff
+	diffs = append(diffs, diffMetrics(a.Metrics, b.Metrics)...)
+	diffs = append(diffs, diffMassBalance(a.MassBalance, b.MassBalance)...)
+	diffs = append(diffs, diffGroundReactions(a.GroundReactions, b.GroundReactions)...)
+	diffs = append(diffs, diffPropulsion(a.Propulsion, b.Propulsion)...)
+	diffs = append(diffs, diffAerodynamics(a.Aerodynamics, b.Aerodynamics)...)
+	return diffs
+}
+
+func diffMetrics(a, b *Metrics) []ConfigDiff {
+	d := &configDiffer{section: "metrics"}
+	if a == nil {
+		a = &Metrics{}
+	}
+	if b == nil {
+		b = &Metrics{}
+	}
+
+	// Parsed metrics are converted to feet and square feet
+	d.measurement("wingarea", a.WingArea, b.WingArea, "FT2")
+	d.measurement("wingspan", a.WingSpan, b.WingSpan, "FT")
+	d.measurement("chord", a.Chord, b.Chord, "FT")
+	d.measurement("htailarea", a.HTailArea, b.HTailArea, "FT2")
+	d.measurement("htailarm", a.HTailArm, b.HTailArm, "FT")
+	d.measurement("vtailarea", a.VTailArea, b.VTailArea, "FT2")
+	d.measurement("vtailarm", a.VTailArm, b.VTailArm, "FT")
+
+	keyed(d, "location", a.Location, b.Location,
+		func(i int, l *Location) string { return nameOr(l.Name, strconv.Itoa(i)) },
+		func(path string, x, y *Location) { d.location(path, x, y) })
+
+	return d.diffs
+}
+
+func diffMassBalance(a, b *MassBalance) []ConfigDiff {
+	d := &configDiffer{section: "mass_balance"}
+	if a == nil {
+		a = &MassBalance{}
+	}
+	if b == nil {
+		b = &MassBalance{}
+	}
+
+	// Parsed inertias and weights are converted to slug·ft² and pounds
+	d.measurement("ixx", a.IXX, b.IXX, "SLUG*FT2")
+	d.measurement("iyy", a.IYY, b.IYY, "SLUG*FT2")
+	d.measurement("izz", a.IZZ, b.IZZ, "SLUG*FT2")
+	d.measurement("ixy", a.IXY, b.IXY, "SLUG*FT2")
+	d.measurement("ixz", a.IXZ, b.IXZ, "SLUG*FT2")
+	d.measurement("iyz", a.IYZ, b.IYZ, "SLUG*FT2")
+	d.measurement("emptywt", a.EmptyMass, b.EmptyMass, "LBS")
+	d.location("location", a.Location, b.Location)
+
+	keyed(d, "pointmass", a.PointMass, b.PointMass,
+		func(i int, pm *PointMass) string { return nameOr(pm.Name, strconv.Itoa(i)) },
+		func(path string, x, y *PointMass) {
+			d.measurement(path+"/weight", x.Mass, y.Mass, "")
+			d.location(path+"/location", x.Location, y.Location)
+		})
+
+	return d.diffs
+}
+
+func diffGroundReactions(a, b *GroundReactions) []ConfigDiff {
+	d := &configDiffer{section: "ground_reactions"}
+	if a == nil {
+		a = &GroundReactions{}
+	}
+	if b == nil {
+		b = &GroundReactions{}
+	}
+
+	keyed(d, "contact", a.Contact, b.Contact,
+		func(i int, c *Contact) string { return nameOr(c.Name, strconv.Itoa(i)) },
+		func(path string, x, y *Contact) {
+			d.text(path+"/type", x.Type, y.Type)
+			d.location(path+"/location", x.Location, y.Location)
+			d.number(path+"/static_friction", x.StaticFriction, y.StaticFriction, "")
+			d.number(path+"/dynamic_friction", x.DynamicFriction, y.DynamicFriction, "")
+			d.number(path+"/rolling_friction", x.RollingFriction, y.RollingFriction, "")
+			d.measurement(path+"/spring_coeff", x.SpringCoeff, y.SpringCoeff, "")
+			d.measurement(path+"/damping_coeff", x.DampingCoeff, y.DampingCoeff, "")
+			d.measurement(path+"/max_steer", x.MaxSteer, y.MaxSteer, "")
+			d.text(path+"/brake_group", x.BrakeGroup, y.BrakeGroup)
+			d.number(path+"/retractable", float64(x.Retractable), float64(y.Retractable), "")
+		})
+
+	return d.diffs
+}
+
+func diffPropulsion(a, b *Propulsion) []ConfigDiff {
+	d := &configDiffer{section: "propulsion"}
+	if a == nil {
+		a = &Propulsion{}
+	}
+	if b == nil {
+		b = &Propulsion{}
+	}
+
+	keyed(d, "engine", a.Engine, b.Engine,
+		func(i int, e *Engine) string { return nameOr(e.Name, nameOr(e.File, strconv.Itoa(i))) },
+		func(path string, x, y *Engine) {
+			d.text(path+"/file", x.File, y.File)
+			d.location(path+"/location", x.Location, y.Location)
+			if x.Thruster != nil && y.Thruster != nil {
+				d.text(path+"/thruster/file", x.Thruster.File, y.Thruster.File)
+				d.location(path+"/thruster/location", x.Thruster.Location, y.Thruster.Location)
+				d.number(path+"/thruster/sense", x.Thruster.Sense, y.Thruster.Sense, "")
+				d.number(path+"/thruster/p_factor", x.Thruster.PFactor, y.Thruster.PFactor, "")
+			}
+		})
+
+	keyed(d, "tank", a.Tank, b.Tank,
+		func(i int, t *Tank) string { return strconv.Itoa(i) },
+		func(path string, x, y *Tank) {
+			d.text(path+"/type", x.Type, y.Type)
+			d.location(path+"/location", x.Location, y.Location)
+			d.measurement(path+"/capacity", x.Capacity, y.Capacity, "")
+			d.measurement(path+"/contents", x.Contents, y.Contents, "")
+			d.number(path+"/temperature", x.Temperature, y.Temperature, "")
+		})
+
+	return d.diffs
+}
+
+func diffAerodynamics(a, b *Aerodynamics) []ConfigDiff {
+	d := &configDiffer{section: "aerodynamics"}
+	if a == nil {
+		a = &Aerodynamics{}
+	}
+	if b == nil {
+		b = &Aerodynamics{}
+	}
+
+	// Functions are identified by axis and name
+	functions := func(aero *Aerodynamics) []axisFunction {
+		var list []axisFunction
+		for _, f := range aero.Function {
+			list = append(list, axisFunction{axis: "", function: f})
+		}
+		for _, axis := range aero.Axis {
+			for _, f := range axis.Function {
+				list = append(list, axisFunction{axis: axis.Name, function: f})
+			}
+		}
+		return list
+	}
+
+	keyed(d, "function", functions(a), functions(b),
+		func(i int, af axisFunction) string {
+			name := nameOr(af.function.Name, strconv.Itoa(i))
+			if af.axis == "" {
+				return name
+			}
+			return af.axis + "/" + name
+		},
+		func(path string, x, y axisFunction) { d.function(path, x.function, y.function) })
+
+	return d.diffs
+}
+
+// axisFunction is an aerodynamic function with the axis it belongs to, or
+// no axis for standalone functions
+type axisFunction struct {
+	axis     string
+	function *Function
+}
+
+// function compares two versions of a function: its expression with tables
+// left out, then each table in turn
+func (d *configDiffer) function(path string, a, b *Function) {
+	if functionSignature(a) != functionSignature(b) {
+		d.add(ConfigDiff{Path: path, Kind: DiffChanged, Detail: "expression changed"})
+	}
+
+	tablesA, tablesB := functionTables(a), functionTables(b)
+	if len(tablesA) != len(tablesB) {
+		d.add(ConfigDiff{Path: path, Kind: DiffChanged,
+			Detail: fmt.Sprintf("table count %d -> %d", len(tablesA), len(tablesB))})
+		return
+	}
+	for i := range tablesA {
+		tablePath := path
+		if len(tablesA) > 1 {
+			tablePath = fmt.Sprintf("%s/table[%d]", path, i)
+		}
+		diff, err := diffTables(tablesA[i], tablesB[i])
+		if err != nil {
+			d.add(ConfigDiff{Path: tablePath, Kind: DiffChanged, Detail: err.Error()})
+		} else if diff != nil {
+			d.add(ConfigDiff{Path: tablePath, Kind: DiffTable, Table: diff})
+		}
+	}
+}
+
+// functionSignature renders a function's expression without table data
+func functionSignature(f *Function) string {
+	var sb strings.Builder
+	if f.Table != nil {
+		sb.WriteString("table")
+	}
+	for _, named := range functionOperations(f) {
+		writeOperationSignature(&sb, named)
+	}
+	return sb.String()
+}
+
+func writeOperationSignature(sb *strings.Builder, named namedOperation) {
+	op := named.op
+	sb.WriteString(named.name)
+	sb.WriteByte('(')
+	for _, operand := range op.operandList() {
+		switch operand.kind {
+		case operandProperty:
+			sb.WriteString(strings.TrimSpace(op.Property[operand.index]))
+			sb.WriteByte(' ')
+		case operandValue:
+			sb.WriteString(strconv.FormatFloat(op.Value[operand.index], 'g', -1, 64))
+			sb.WriteByte(' ')
+		case operandTable:
+			sb.WriteString("table ")
+		case operandOperation:
+			writeOperationSignature(sb, operand.nested)
+		}
+	}
+	sb.WriteByte(')')
+}
+
+// functionTables returns every table in a function in document order
+func functionTables(f *Function) []*Table {
+	var tables []*Table
+	if f.Table != nil {
+		tables = append(tables, f.Table)
+	}
+	for _, named := range functionOperations(f) {
+		tables = appendOperationTables(tables, named.op)
+	}
+	return tables
+}
+
+func appendOperationTables(tables []*Table, op *Operation) []*Table {
+	for _, operand := range op.operandList() {
+		switch operand.kind {
+		case operandTable:
+			tables = append(tables, op.Tables[operand.index])
+		case operandOperation:
+			tables = appendOperationTables(tables, operand.nested.op)
+		}
+	}
+	return tables
+}
+
+// diffTables compares two tables, returning nil when they are the same.
+// A table that fails to parse on either side is reported as an error,
+// unless both fail alike.
+func diffTables(a, b *Table) (*TableDiff, error) {
+	pa, errA := ParseTable(a)
+	pb, errB := ParseTable(b)
+	switch {
+	case errA != nil && errB != nil:
+		if errA.Error() == errB.Error() {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("neither table parses: %v; %v", errA, errB)
+	case errA != nil:
+		return nil, fmt.Errorf("old table does not parse: %w", errA)
+	case errB != nil:
+		return nil, fmt.Errorf("new table does not parse: %w", errB)
+	}
+
+	diff := &TableDiff{OldDimension: pa.Dimension, NewDimension: pb.Dimension}
+	if pa.Dimension != pb.Dimension {
+		return diff, nil
+	}
+
+	var sum float64
+	count := 0
+	compare := func(x, y []float64) bool {
+		if len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			delta := math.Abs(x[i] - y[i])
+			diff.MaxAbsDiff = math.Max(diff.MaxAbsDiff, delta)
+			sum += delta
+			count++
+			if delta != 0 {
+				diff.ChangedValues++
+			}
+		}
+		return true
+	}
+
+	switch pa.Dimension {
+	case 1:
+		diff.BreakpointsChanged = !floatsEqual(pa.Data1D.Indices, pb.Data1D.Indices)
+		if !diff.BreakpointsChanged {
+			compare(pa.Data1D.Values, pb.Data1D.Values)
+		}
+	case 2:
+		diff.BreakpointsChanged = !sameBreakpoints2D(pa.Data2D, pb.Data2D)
+		if !diff.BreakpointsChanged {
+			for i := range pa.Data2D.Data {
+				if !compare(pa.Data2D.Data[i], pb.Data2D.Data[i]) {
+					diff.BreakpointsChanged = true
+				}
+			}
+		}
+	case 3:
+		diff.BreakpointsChanged = len(pa.Data3D) != len(pb.Data3D)
+		for i := 0; !diff.BreakpointsChanged && i < len(pa.Data3D); i++ {
+			ta, tb := pa.Data3D[i], pb.Data3D[i]
+			diff.BreakpointsChanged = ta.Breakpoint != tb.Breakpoint || !sameBreakpoints2D(ta, tb)
+			for j := 0; !diff.BreakpointsChanged && j < len(ta.Data); j++ {
+				diff.BreakpointsChanged = !compare(ta.Data[j], tb.Data[j])
+			}
+		}
+	}
+
+	if diff.BreakpointsChanged {
+		diff.MaxAbsDiff, diff.MeanAbsDiff, diff.ChangedValues = 0, 0, 0
+		return diff, nil
+	}
+	if diff.ChangedValues == 0 {
+		return nil, nil
+	}
+	diff.MeanAbsDiff = sum / float64(count)
+	return diff, nil
+}
+
+// sameBreakpoints2D reports whether two 2D tables share row and column
+// breakpoints and have the same number of data rows
+func sameBreakpoints2D(a, b *Table2D) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return floatsEqual(a.RowIndices, b.RowIndices) && floatsEqual(a.ColIndices, b.ColIndices) &&
+		len(a.Data) == len(b.Data)
+}
+
+func floatsEqual(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// =============================================================================
+// RENDERING
+// =============================================================================
+
+// String describes one difference on a single line
+func (cd ConfigDiff) String() string {
+	switch cd.Kind {
+	case DiffAdded, DiffRemoved:
+		return fmt.Sprintf("%s %s", cd.Kind, cd.Path)
+	case DiffTable:
+		t := cd.Table
+		switch {
+		case t.OldDimension != t.NewDimension:
+			return fmt.Sprintf("table %s: dimension %d -> %d", cd.Path, t.OldDimension, t.NewDimension)
+		case t.BreakpointsChanged:
+			return fmt.Sprintf("table %s: breakpoints changed", cd.Path)
+		default:
+			return fmt.Sprintf("table %s: %d values changed, max |Δ| %g, mean |Δ| %g",
+				cd.Path, t.ChangedValues, t.MaxAbsDiff, t.MeanAbsDiff)
+		}
+	}
+
+	if cd.Detail != "" {
+		return fmt.Sprintf("changed %s: %s", cd.Path, cd.Detail)
+	}
+	unit := ""
+	if cd.Unit != "" {
+		unit = " " + cd.Unit
+	}
+	return fmt.Sprintf("changed %s: %v -> %v%s", cd.Path, cd.Old, cd.New, unit)
+}
+
+// FormatConfigDiffs renders differences as text grouped by section
+func FormatConfigDiffs(diffs []ConfigDiff) string {
+	if len(diffs) == 0 {
+		return "No differences\n"
+	}
+
+	var sb strings.Builder
+	section := ""
+	for _, diff := range diffs {
+		if diff.Section != section {
+			section = diff.Section
+			sb.WriteString(fmt.Sprintf("[%s]\n", section))
+		}
+		sb.WriteString("  " + diff.String() + "\n")
+	}
+	return sb.String()
+}
+
+// ConfigDiffsJSON renders differences as an indented JSON array
+func ConfigDiffsJSON(diffs []ConfigDiff) ([]byte, error) {
+	if diffs == nil {
+		diffs = []ConfigDiff{}
+	}
+	return json.MarshalIndent(diffs, "", "  ")
+}
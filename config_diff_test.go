@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func loadP51DConfig(t *testing.T) *JSBSimConfig {
+	t.Helper()
+	file, err := os.Open("aircraft/p51d-jsbsim.xml")
+	if err != nil {
+		t.Fatalf("Failed to open P-51D XML: %v", err)
+	}
+	defer file.Close()
+
+	config, err := ParseJSBSimConfig(file)
+	if err != nil {
+		t.Fatalf("Failed to parse P-51D config: %v", err)
+	}
+	return config
+}
+
+// firstTable1D returns the first one-dimensional table held directly by an
+// axis function
+func firstTable1D(t *testing.T, config *JSBSimConfig) (string, *Table) {
+	t.Helper()
+	for _, axis := range config.Aerodynamics.Axis {
+		for _, f := range axis.Function {
+			for _, table := range functionTables(f) {
+				if parsed, err := ParseTable(table); err == nil && parsed.Dimension == 1 {
+					return "function[" + axis.Name + "/" + f.Name + "]", table
+				}
+			}
+		}
+	}
+	t.Fatal("P-51D has no 1D table")
+	return "", nil
+}
+
+func TestDiffConfigs(t *testing.T) {
+	original := loadP51DConfig(t)
+
+	t.Run("Identical", func(t *testing.T) {
+		assertEqual(t, len(DiffConfigs(original, loadP51DConfig(t))), 0)
+		assertEqual(t, FormatConfigDiffs(nil), "No differences\n")
+	})
+
+	modified := loadP51DConfig(t)
+
+	// Wing area +5%
+	oldArea := modified.Metrics.WingArea.Value
+	modified.Metrics.WingArea.Value *= 1.05
+
+	// One table value changed
+	tablePath, table := firstTable1D(t, modified)
+	parsed, err := ParseTable(table)
+	if err != nil {
+		t.Fatalf("ParseTable: %v", err)
+	}
+	var data strings.Builder
+	for i, index := range parsed.Data1D.Indices {
+		value := parsed.Data1D.Values[i]
+		if i == 1 {
+			value += 0.01
+		}
+		fmt.Fprintf(&data, "%g %g\n", index, value)
+	}
+	table.TableData = []*TableData{{Data: data.String()}}
+
+	// One point mass added
+	modified.MassBalance.PointMass = append(modified.MassBalance.PointMass, &PointMass{
+		Name:     "Camera pod",
+		Mass:     &Measurement{Unit: "LBS", Value: 25},
+		Location: &Location{Unit: "IN", X: 100},
+	})
+
+	diffs := DiffConfigs(original, modified)
+	if len(diffs) != 3 {
+		t.Fatalf("Expected 3 differences, got %d:\n%s", len(diffs), FormatConfigDiffs(diffs))
+	}
+
+	t.Run("Wing Area", func(t *testing.T) {
+		d := diffs[0]
+		assertEqual(t, d.Section, "metrics")
+		assertEqual(t, d.Path, "wingarea")
+		assertEqual(t, d.Kind, DiffChanged)
+		assertEqual(t, d.Unit, "FT2")
+		assertApproxEqual(t, d.Old.(float64), oldArea, 1e-12)
+		assertApproxEqual(t, d.New.(float64), oldArea*1.05, 1e-9)
+	})
+
+	t.Run("Point Mass", func(t *testing.T) {
+		d := diffs[1]
+		assertEqual(t, d.Section, "mass_balance")
+		assertEqual(t, d.Path, "pointmass[Camera pod]")
+		assertEqual(t, d.Kind, DiffAdded)
+	})
+
+	t.Run("Table Value", func(t *testing.T) {
+		d := diffs[2]
+		assertEqual(t, d.Section, "aerodynamics")
+		assertEqual(t, d.Path, tablePath)
+		assertEqual(t, d.Kind, DiffTable)
+		assertEqual(t, d.Table.BreakpointsChanged, false)
+		assertEqual(t, d.Table.ChangedValues, 1)
+		assertApproxEqual(t, d.Table.MaxAbsDiff, 0.01, 1e-9)
+		assertApproxEqual(t, d.Table.MeanAbsDiff, 0.01/float64(len(parsed.Data1D.Values)), 1e-9)
+	})
+
+	t.Run("Breakpoints Changed", func(t *testing.T) {
+		first := parsed.Data1D.Indices[0]
+		shiftedData := strings.Replace(data.String(), fmt.Sprintf("%g ", first), fmt.Sprintf("%g ", first-1), 1)
+		shifted := &Table{IndependentVar: table.IndependentVar, TableData: []*TableData{{Data: shiftedData}}}
+		diff, err := diffTables(table, shifted)
+		if err != nil || diff == nil || !diff.BreakpointsChanged {
+			t.Fatalf("Expected breakpoint change, got %+v (%v)", diff, err)
+		}
+	})
+
+	t.Run("Table That Does Not Parse", func(t *testing.T) {
+		broken := loadP51DConfig(t)
+		_, table := firstTable1D(t, broken)
+		table.IndependentVar = nil
+		diffs := DiffConfigs(original, broken)
+		if len(diffs) != 1 || diffs[0].Path != tablePath || !strings.Contains(diffs[0].Detail, "new table does not parse") {
+			t.Fatalf("Expected the new table's parse failure at %s, got %+v", tablePath, diffs)
+		}
+		if diff, err := diffTables(table, table); diff != nil || err != nil {
+			t.Errorf("Tables failing alike should not differ, got %+v (%v)", diff, err)
+		}
+	})
+
+	t.Run("Removed And Text", func(t *testing.T) {
+		reverse := DiffConfigs(modified, original)
+		assertEqual(t, reverse[1].Kind, DiffRemoved)
+
+		text := FormatConfigDiffs(diffs)
+		for _, want := range []string{"[metrics]", "[mass_balance]", "[aerodynamics]",
+			"changed wingarea:", "added pointmass[Camera pod]", "1 values changed"} {
+			if !strings.Contains(text, want) {
+				t.Errorf("Text output missing %q:\n%s", want, text)
+			}
+		}
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		out, err := ConfigDiffsJSON(diffs)
+		if err != nil {
+			t.Fatalf("ConfigDiffsJSON: %v", err)
+		}
+		var decoded []map[string]interface{}
+		if err := json.Unmarshal(out, &decoded); err != nil {
+			t.Fatalf("Invalid JSON: %v", err)
+		}
+		assertEqual(t, len(decoded), 3)
+		assertEqual(t, decoded[0]["section"], "metrics")
+		assertEqual(t, decoded[2]["table"].(map[string]interface{})["changed_values"], 1.0)
+	})
+}
@@ -0,0 +1,435 @@
+// Configuration Overrides
+// Programmatic edits of a parsed configuration for parameter studies, so a
+// variant needs no hand-edited XML
+
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// metricFields maps the metrics element names to their quantity kinds
+var metricFields = []struct {
+	name, kind string
+	field      func(*Metrics) **Measurement
+}{
+	{"wingarea", "area", func(m *Metrics) **Measurement { return &m.WingArea }},
+	{"wingspan", "length", func(m *Metrics) **Measurement { return &m.WingSpan }},
+	{"wing_incidence", "angle", func(m *Metrics) **Measurement { return &m.WingIncidence }},
+	{"chord", "length", func(m *Metrics) **Measurement { return &m.Chord }},
+	{"htailarea", "area", func(m *Metrics) **Measurement { return &m.HTailArea }},
+	{"htailarm", "length", func(m *Metrics) **Measurement { return &m.HTailArm }},
+	{"vtailarea", "area", func(m *Metrics) **Measurement { return &m.VTailArea }},
+	{"vtailarm", "length", func(m *Metrics) **Measurement { return &m.VTailArm }},
+}
+
+// canonicalMeasurement converts a value to the canonical unit of its kind,
+// as the parser does, so an override reads like a parsed value
+func canonicalMeasurement(value float64, unit, kind string) (*Measurement, error) {
+	converted, err := convertToStandardUnit(value, unit, kind)
+	if err != nil {
+		return nil, err
+	}
+	return &Measurement{Unit: standardUnits[kind].canonical, Value: converted}, nil
+}
+
+// SetMetric sets one of the metrics by its element name, such as
+// "wingarea", converting from unit (canonical when empty). A metric the
+// configuration lacks is added.
+func (config *JSBSimConfig) SetMetric(name string, value float64, unit string) error {
+	for _, metric := range metricFields {
+		if metric.name != name {
+			continue
+		}
+		m, err := canonicalMeasurement(value, unit, metric.kind)
+		if err != nil {
+			return fmt.Errorf("metric %s: %w", name, err)
+		}
+		if config.Metrics == nil {
+			config.Metrics = &Metrics{}
+		}
+		*metric.field(config.Metrics) = m
+		return nil
+	}
+	return fmt.Errorf("no metric %q", name)
+}
+
+// SetEmptyWeight sets the empty weight, converting from unit (pounds when
+// empty)
+func (config *JSBSimConfig) SetEmptyWeight(value float64, unit string) error {
+	if config.MassBalance == nil {
+		return fmt.Errorf("configuration has no mass_balance")
+	}
+	m, err := canonicalMeasurement(value, unit, "mass")
+	if err != nil {
+		return fmt.Errorf("emptywt: %w", err)
+	}
+	config.MassBalance.EmptyMass = m
+	return nil
+}
+
+// pointMass returns the point mass with the given name, compared without
+// regard to case
+func (config *JSBSimConfig) pointMass(name string) (*PointMass, error) {
+	if config.MassBalance != nil {
+		for _, pm := range config.MassBalance.PointMass {
+			if strings.EqualFold(pm.Name, name) {
+				return pm, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("no point mass %q", name)
+}
+
+// SetPointMassWeight sets the weight (lbs) of a named point mass
+func (config *JSBSimConfig) SetPointMassWeight(name string, weight float64) error {
+	pm, err := config.pointMass(name)
+	if err != nil {
+		return err
+	}
+	pm.Mass = &Measurement{Unit: "LBS", Value: weight}
+	return nil
+}
+
+// MovePointMass moves a named point mass to a structural location
+func (config *JSBSimConfig) MovePointMass(name string, location Location) error {
+	pm, err := config.pointMass(name)
+	if err != nil {
+		return err
+	}
+	if _, err := convertToStandardUnit(0, location.Unit, "length"); err != nil {
+		return fmt.Errorf("point mass %s location: %w", name, err)
+	}
+	if pm.Location != nil && location.Name == "" {
+		location.Name = pm.Location.Name
+	}
+	pm.Location = &location
+	return nil
+}
+
+// AddPointMass adds a point mass of weight (lbs) at a structural location
+func (config *JSBSimConfig) AddPointMass(name string, weight float64, location Location) error {
+	if config.MassBalance == nil {
+		return fmt.Errorf("configuration has no mass_balance")
+	}
+	if _, err := config.pointMass(name); err == nil {
+		return fmt.Errorf("point mass %q already exists", name)
+	}
+	if _, err := convertToStandardUnit(0, location.Unit, "length"); err != nil {
+		return fmt.Errorf("point mass %s location: %w", name, err)
+	}
+	if location.Name == "" {
+		location.Name = "POINTMASS"
+	}
+	config.MassBalance.PointMass = append(config.MassBalance.PointMass, &PointMass{
+		Name:     name,
+		Mass:     &Measurement{Unit: "LBS", Value: weight},
+		Location: &location,
+	})
+	return nil
+}
+
+// SetTankContents sets the contents of a numbered tank, converting from
+// unit (pounds when empty). More than the tank's capacity is an error.
+func (config *JSBSimConfig) SetTankContents(number int, value float64, unit string) error {
+	if config.Propulsion != nil {
+		for _, tank := range config.Propulsion.Tank {
+			if tank.Number != number {
+				continue
+			}
+			m, err := canonicalMeasurement(value, unit, "mass")
+			if err != nil {
+				return fmt.Errorf("tank %d contents: %w", number, err)
+			}
+			if m.Value < 0 || (tank.Capacity != nil && m.Value > tank.Capacity.Value) {
+				return fmt.Errorf("tank %d contents %g %s outside its capacity", number, m.Value, m.Unit)
+			}
+			tank.Contents = m
+			return nil
+		}
+	}
+	return fmt.Errorf("no tank %d", number)
+}
+
+// FillTank fills a numbered tank to its capacity
+func (config *JSBSimConfig) FillTank(number int) error {
+	if config.Propulsion != nil {
+		for _, tank := range config.Propulsion.Tank {
+			if tank.Number == number {
+				if tank.Capacity == nil {
+					return fmt.Errorf("tank %d has no capacity", number)
+				}
+				contents := *tank.Capacity
+				tank.Contents = &contents
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("no tank %d", number)
+}
+
+// AddDragIncrement adds a drag axis function of a constant coefficient,
+// such as the drag of external stores: qbar times the wing area times
+// deltaCD, in pounds as the other drag functions are
+func (config *JSBSimConfig) AddDragIncrement(name string, deltaCD float64) error {
+	if config.Aerodynamics == nil {
+		return fmt.Errorf("configuration has no aerodynamics")
+	}
+	for _, named := range configNamedFunctions(config) {
+		if named.name == name {
+			return fmt.Errorf("function %q already exists", name)
+		}
+	}
+	var drag *Axis
+	for _, axis := range config.Aerodynamics.Axis {
+		if strings.EqualFold(axis.Name, "DRAG") {
+			drag = axis
+		}
+	}
+	if drag == nil {
+		drag = &Axis{Name: "DRAG"}
+		config.Aerodynamics.Axis = append(config.Aerodynamics.Axis, drag)
+	}
+	drag.Function = append(drag.Function, &Function{
+		Name:        name,
+		Description: "Drag increment",
+		Product: &Operation{
+			Property: []string{"aero/qbar-psf", "metrics/Sw-sqft"},
+			Value:    []float64{deltaCD},
+		},
+	})
+	return nil
+}
+
+// SetContactSpring sets the spring coefficient of a named ground contact,
+// converting from unit (lbf/ft when empty)
+func (config *JSBSimConfig) SetContactSpring(name string, value float64, unit string) error {
+	if config.GroundReactions != nil {
+		for _, contact := range config.GroundReactions.Contact {
+			if !strings.EqualFold(contact.Name, name) {
+				continue
+			}
+			m, err := canonicalMeasurement(value, unit, "spring")
+			if err != nil {
+				return fmt.Errorf("contact %s spring_coeff: %w", name, err)
+			}
+			contact.SpringCoeff = m
+			return nil
+		}
+	}
+	return fmt.Errorf("no contact %q", name)
+}
+
+// VisitTables calls visit with every table in the aerodynamic functions
+// and the flight control, autopilot and system component functions. A
+// table's path is its function's name (or its component's, for an unnamed
+// component function) followed by its place in the function, such as
+// "aero/coefficient/CLalpha/product/table". The visitor may edit the
+// table's data; its parsed form follows the edit. The first error
+// stops the walk and is returned.
+func (config *JSBSimConfig) VisitTables(visit func(path string, t *Table) error) error {
+	for _, named := range configNamedFunctions(config) {
+		for _, located := range locateFunctionTables(named.function) {
+			if err := visit(named.name+"/"+located.path, located.table); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ScaleTable multiplies the values, not the breakpoints, of the tables at
+// path: a single table's path as VisitTables gives it, or a prefix of such
+// paths, such as a function name for all of the function's tables.
+func (config *JSBSimConfig) ScaleTable(path string, factor float64) error {
+	path = strings.TrimSuffix(path, "/")
+	scaled := 0
+	err := config.VisitTables(func(tablePath string, t *Table) error {
+		if tablePath != path && !strings.HasPrefix(tablePath, path+"/") {
+			return nil
+		}
+		if err := scaleTable(t, factor); err != nil {
+			return fmt.Errorf("table %s: %w", tablePath, err)
+		}
+		scaled++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if scaled == 0 {
+		return fmt.Errorf("no table at %q", path)
+	}
+	return nil
+}
+
+// namedFunction is a function with the name its tables are found under
+type namedFunction struct {
+	name     string
+	function *Function
+}
+
+// configNamedFunctions returns the functions of configFunctions, each named
+// by the function or, for an unnamed component function, the component
+func configNamedFunctions(config *JSBSimConfig) []namedFunction {
+	var functions []namedFunction
+	if aero := config.Aerodynamics; aero != nil {
+		for _, f := range aero.Function {
+			functions = append(functions, namedFunction{f.Name, f})
+		}
+		for _, axis := range aero.Axis {
+			for _, f := range axis.Function {
+				functions = append(functions, namedFunction{f.Name, f})
+			}
+		}
+	}
+
+	var channels []*Channel
+	for _, fc := range []*FlightControl{config.FlightControl, config.Autopilot} {
+		if fc != nil {
+			channels = append(channels, fc.Channel...)
+		}
+	}
+	if config.SystemControl != nil {
+		channels = append(channels, config.SystemControl.Channel...)
+	}
+	for _, ch := range channels {
+		for _, comp := range ch.Component {
+			if comp.Function == nil {
+				continue
+			}
+			name := comp.Function.Name
+			if name == "" {
+				name = comp.Name
+			}
+			functions = append(functions, namedFunction{name, comp.Function})
+		}
+	}
+	return functions
+}
+
+// scaleTable multiplies a table's values by factor. The data text is
+// rewritten when the table has it, and the parsed form is replaced, so the
+// table evaluates, compiles and compares scaled.
+func scaleTable(t *Table, factor float64) error {
+	pt, err := cachedParseTable(t)
+	if err != nil {
+		return err
+	}
+	scaled := *pt
+	switch pt.Dimension {
+	case 1:
+		scaled.Data1D = &Table1D{Indices: pt.Data1D.Indices, Values: scaleValues(pt.Data1D.Values, factor)}
+	case 2:
+		scaled.Data2D = scaleTable2D(pt.Data2D, factor)
+	case 3:
+		scaled.Data3D = make([]*Table2D, len(pt.Data3D))
+		for i, layer := range pt.Data3D {
+			scaled.Data3D[i] = scaleTable2D(layer, factor)
+		}
+	}
+
+	return writeTableData(t, &scaled)
+}
+
+// writeTableData replaces a table's parsed form with pt and, when the table
+// has data text, rewrites it from pt in the layout its lookup attributes
+// declare. A 3D table gets a data element per slice of pt.
+func writeTableData(t *Table, pt *ParsedTable) error {
+	if len(t.TableData) > 0 {
+		layout := *pt
+		if pt.Collapsed != nil {
+			layout = *pt.expanded()
+		}
+		layout.Data3D = slices.Clone(pt.Data3D)
+		if layout.Dimension >= 2 {
+			declared := make([]string, len(t.IndependentVar))
+			for i, iv := range t.IndependentVar {
+				declared[i] = iv.Lookup
+			}
+			if err := layout.Reorder(declared); err != nil {
+				return err
+			}
+		}
+		switch layout.Dimension {
+		case 1:
+			t.TableData[0].Data = format1DTableData(layout.Data1D)
+		case 2:
+			t.TableData[0].Data = format2DTableData(layout.Data2D)
+		case 3:
+			data := make([]*TableData, len(layout.Data3D))
+			for i, layer := range layout.Data3D {
+				data[i] = &TableData{
+					Breakpoint: strconv.FormatFloat(layer.Breakpoint, 'g', -1, 64),
+					Data:       format2DTableData(layer),
+				}
+			}
+			t.TableData = data
+		}
+	}
+	t.setParsed(pt, nil)
+	return nil
+}
+
+func scaleValues(values []float64, factor float64) []float64 {
+	scaled := make([]float64, len(values))
+	for i, value := range values {
+		scaled[i] = value * factor
+	}
+	return scaled
+}
+
+func scaleTable2D(t *Table2D, factor float64) *Table2D {
+	if t == nil {
+		return nil
+	}
+	scaled := *t
+	scaled.Data = make([][]float64, len(t.Data))
+	for i, row := range t.Data {
+		scaled.Data[i] = scaleValues(row, factor)
+	}
+	return &scaled
+}
+
+// format1DTableData writes 1D table data as parse1DTableData reads it
+func format1DTableData(t *Table1D) string {
+	var sb strings.Builder
+	sb.WriteString("\n")
+	for i, index := range t.Indices {
+		sb.WriteString(formatTableRow(index, t.Values[i:i+1]))
+	}
+	return sb.String()
+}
+
+// format2DTableData writes 2D table data as parse2DTableData reads it: the
+// column breakpoints, then each row breakpoint with its values
+func format2DTableData(t *Table2D) string {
+	if t == nil {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("\n")
+	for _, column := range t.ColIndices {
+		sb.WriteString("\t")
+		sb.WriteString(strconv.FormatFloat(column, 'g', -1, 64))
+	}
+	sb.WriteString("\n")
+	for i, row := range t.RowIndices {
+		sb.WriteString(formatTableRow(row, t.Data[i]))
+	}
+	return sb.String()
+}
+
+func formatTableRow(breakpoint float64, values []float64) string {
+	var sb strings.Builder
+	sb.WriteString(strconv.FormatFloat(breakpoint, 'g', -1, 64))
+	for _, value := range values {
+		sb.WriteString("\t")
+		sb.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+// configFunction returns the named function of a configuration
+func configFunction(t *testing.T, config *JSBSimConfig, name string) *Function {
+	t.Helper()
+	for _, named := range configNamedFunctions(config) {
+		if named.name == name {
+			return named.function
+		}
+	}
+	t.Fatalf("No function %s", name)
+	return nil
+}
+
+func TestConfigOverrides(t *testing.T) {
+	original := loadP51DConfig(t)
+	modified := loadP51DConfig(t)
+
+	for _, err := range []error{
+		modified.SetMetric("wingarea", 250, "FT2"),
+		modified.SetEmptyWeight(3500, "KG"),
+		modified.SetPointMassWeight("pilot", 200),
+		modified.MovePointMass("left drop tank", Location{Unit: "IN", X: 110, Y: -90, Z: -5}),
+		modified.SetContactSpring("LEFT_MLG", 20000, "LBS/FT"),
+		modified.ScaleTable("aero/coefficient/CLalpha", 1.05),
+	} {
+		if err != nil {
+			t.Fatalf("Override failed: %v", err)
+		}
+	}
+
+	// CLalpha evaluated at a few alphas, for the scaled values
+	clAlpha := func(config *JSBSimConfig) []float64 {
+		t.Helper()
+		f := configFunction(t, config, "aero/coefficient/CLalpha")
+		var values []float64
+		for _, alpha := range []float64{-10, 2, 14} {
+			properties := map[string]float64{
+				"aero/qbar-psf": 50, "metrics/Sw-sqft": 235, "aero/function/kCLge": 1,
+				"aero/alpha-deg": alpha, "aero/Re": 8e6,
+			}
+			value, err := EvaluateFunction(f, properties)
+			if err != nil {
+				t.Fatalf("EvaluateFunction: %v", err)
+			}
+			values = append(values, value)
+		}
+		return values
+	}
+
+	t.Run("Only Intended Fields Change", func(t *testing.T) {
+		diffs := DiffConfigs(original, modified)
+		var paths []string
+		for _, d := range diffs {
+			paths = append(paths, d.Section+" "+d.Path)
+		}
+		assertEqual(t, paths, []string{
+			"metrics wingarea",
+			"mass_balance emptywt",
+			"mass_balance pointmass[pilot]/weight",
+			"mass_balance pointmass[left drop tank]/location/x",
+			"mass_balance pointmass[left drop tank]/location/y",
+			"mass_balance pointmass[left drop tank]/location/z",
+			"ground_reactions contact[LEFT_MLG]/spring_coeff",
+			"aerodynamics function[LIFT/aero/coefficient/CLalpha]",
+		})
+
+		// Values are stored in the parser's canonical units
+		assertApproxEqual(t, diffs[1].New.(float64), 3500*KG_TO_LB, 1e-9)
+		assertEqual(t, modified.MassBalance.EmptyMass.Unit, "LBS")
+		assertEqual(t, diffs[2].New.(float64), 200.0)
+		assertEqual(t, diffs[6].New.(float64), 20000.0)
+		assertEqual(t, diffs[7].Table.BreakpointsChanged, false)
+
+		for i, value := range clAlpha(modified) {
+			assertApproxEqual(t, value, 1.05*clAlpha(original)[i], 1e-9)
+		}
+	})
+
+	t.Run("Missing Targets", func(t *testing.T) {
+		config := loadP51DConfig(t)
+		for name, err := range map[string]error{
+			"metric":       config.SetMetric("wingload", 1, ""),
+			"metric unit":  config.SetMetric("wingarea", 1, "ACRE"),
+			"point mass":   config.SetPointMassWeight("copilot", 180),
+			"move":         config.MovePointMass("copilot", Location{Unit: "IN"}),
+			"move unit":    config.MovePointMass("pilot", Location{Unit: "FURLONG"}),
+			"contact":      config.SetContactSpring("NOSE_LG", 1000, ""),
+			"table":        config.ScaleTable("aero/coefficient/CLnone", 2),
+			"partial name": config.ScaleTable("aero/coefficient/CL", 2),
+		} {
+			if err == nil {
+				t.Errorf("Expected an error for a missing %s", name)
+			}
+		}
+		assertEqual(t, len(DiffConfigs(original, config)), 0)
+	})
+
+	t.Run("Visit Tables", func(t *testing.T) {
+		config := loadP51DConfig(t)
+		var paths []string
+		config.VisitTables(func(path string, _ *Table) error {
+			paths = append(paths, path)
+			return nil
+		})
+		if len(paths) < len(AnalyzeTables(config)) {
+			t.Fatalf("Visited %d tables, AnalyzeTables reports %d", len(paths), len(AnalyzeTables(config)))
+		}
+		for _, want := range []string{"aero/coefficient/CLalpha/product/table", "aero/coefficient/CDcooling/product/pow/table"} {
+			found := false
+			for _, path := range paths {
+				found = found || path == want
+			}
+			if !found {
+				t.Errorf("No table at %s", want)
+			}
+		}
+
+		// A single nested table is addressed by its full path
+		cooling := configFunction(t, config, "aero/coefficient/CDcooling")
+		properties := map[string]float64{"aero/qbar-psf": 1, "metrics/Sw-sqft": 1, "velocities/vc-kts": 150}
+		before, _ := EvaluateFunction(cooling, properties)
+		if err := config.ScaleTable("aero/coefficient/CDcooling/product/pow/table", 2); err != nil {
+			t.Fatalf("ScaleTable: %v", err)
+		}
+		after, _ := EvaluateFunction(cooling, properties)
+		assertApproxEqual(t, after, 4*before, 1e-9*math.Abs(before))
+
+		// Custom edits through the visitor take effect, and errors stop the walk
+		stop := errors.New("stop")
+		visited := 0
+		err := config.VisitTables(func(path string, table *Table) error {
+			visited++
+			if path != "aero/coefficient/CLalpha/product/table" {
+				return nil
+			}
+			table.TableData[0].Data = "\n\t0\t1e9\n-180\t1\t1\n180\t1\t1\n"
+			return stop
+		})
+		assertEqual(t, err, stop)
+		if visited >= len(paths) {
+			t.Errorf("Walk went on past the error")
+		}
+		properties = map[string]float64{"aero/qbar-psf": 1, "metrics/Sw-sqft": 1, "aero/function/kCLge": 1, "aero/Re": 8e6}
+		lift, _ := EvaluateFunction(configFunction(t, config, "aero/coefficient/CLalpha"), properties)
+		assertApproxEqual(t, lift, 0.92, 1e-12)
+	})
+
+	t.Run("Compose With Compiled Model And Validation", func(t *testing.T) {
+		if err := validateStandaloneFunctions(modified.Aerodynamics.Function); err != nil {
+			t.Fatalf("Modified configuration no longer validates: %v", err)
+		}
+		assertEqual(t, checkFunctionUnits(modified), checkFunctionUnits(original))
+
+		var artifact bytes.Buffer
+		if err := ExportCompiledModel(modified, &artifact); err != nil {
+			t.Fatalf("ExportCompiledModel: %v", err)
+		}
+		model, err := LoadCompiledModel(&artifact)
+		if err != nil {
+			t.Fatalf("LoadCompiledModel: %v", err)
+		}
+		assertApproxEqual(t, model.Config.Metrics.WingArea.Value, 250, 1e-12)
+		assertEqual(t, clAlpha(model.Config), clAlpha(modified))
+
+		// Compiled tables have no text, and scale through their parsed form
+		if err := model.Config.ScaleTable("aero/coefficient/CLalpha", 2); err != nil {
+			t.Fatalf("ScaleTable on a compiled model: %v", err)
+		}
+		for i, value := range clAlpha(model.Config) {
+			assertApproxEqual(t, value, 2*clAlpha(modified)[i], 1e-9)
+		}
+		if text := FormatConfigDiffs(DiffConfigs(original, modified)); !strings.Contains(text, "changed wingarea: 235 -> 250 FT2") {
+			t.Errorf("Unexpected diff text:\n%s", text)
+		}
+	})
+}
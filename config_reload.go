@@ -0,0 +1,315 @@
+// Configuration Reload
+// Swaps a new configuration into a running engine between steps, so a
+// coefficient can be edited and flown again from the same flight condition
+
+package main
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultWatchInterval is how often WatchAndReload looks at its file
+const DefaultWatchInterval = 250 * time.Millisecond
+
+// ConfigValidationError lists the problems that keep a configuration from
+// being flown
+type ConfigValidationError struct {
+	Problems []string
+}
+
+func (e *ConfigValidationError) Error() string {
+	return "invalid configuration: " + strings.Join(e.Problems, "; ")
+}
+
+// ValidateConfig checks that a configuration can be flown: it has the
+// reference dimensions and mass, its functions and autopilot build, and
+// its forces and moments are finite at a reference flight condition. The
+// flight_control section is not checked; the engines fly their own FCS. It returns a *ConfigValidationError listing every
+// problem found, or nil.
+func ValidateConfig(config *JSBSimConfig) error {
+	if config == nil {
+		return &ConfigValidationError{Problems: []string{"no configuration"}}
+	}
+	var problems []string
+	problem := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if config.Metrics == nil {
+		problem("no metrics")
+	} else {
+		metrics := config.Metrics
+		for i, m := range []*Measurement{metrics.WingArea, metrics.WingSpan, metrics.Chord} {
+			if m == nil || !(m.Value > 0) {
+				problem("metrics: %s must be positive", []string{"wingarea", "wingspan", "chord"}[i])
+			}
+		}
+	}
+	if config.MassBalance == nil || config.MassBalance.EmptyMass == nil || !(config.MassBalance.EmptyMass.Value > 0) {
+		problem("mass_balance: emptywt must be positive")
+	}
+	if config.Aerodynamics != nil {
+		if err := validateStandaloneFunctions(config.Aerodynamics.Function); err != nil {
+			problem("aerodynamics: %v", err)
+		}
+	}
+	if config.Autopilot != nil {
+		if _, err := BuildAutopilotFromConfig(config, NewPropertyManager()); err != nil {
+			problem("autopilot: %v", err)
+		}
+	}
+	if len(problems) > 0 {
+		// The forces are not worth evaluating without the reference data
+		return &ConfigValidationError{Problems: problems}
+	}
+
+	// Level flight at 100 m/s and 1000 m
+	state := NewAircraftState()
+	state.Altitude = 1000
+	state.Position = Vector3{Z: -1000}
+	state.Velocity = Vector3{X: 100}
+	state.UpdateFlightConditions()
+	components, err := NewForcesMomentsCalculator(config).CalculateForcesMoments(state)
+	switch {
+	case err != nil:
+		problem("forces and moments: %v", err)
+	case !finiteVector(components.TotalForce) || !finiteVector(components.TotalMoment):
+		problem("forces and moments are not finite at 100 m/s and 1000 m")
+	}
+	if len(problems) > 0 {
+		return &ConfigValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// finiteVector reports whether every component of v is finite
+func finiteVector(v Vector3) bool {
+	return isFinite(v.X) && isFinite(v.Y) && isFinite(v.Z)
+}
+
+// reloadedModel is everything an engine takes from its configuration,
+// built whole before a reload swaps it in
+type reloadedModel struct {
+	calc         *ForcesMomentsCalculator
+	externalCG   *Location // The new CG, for the kept external forces
+	gear         *LandingGear
+	placards     *PlacardLimits
+	pilotStation Vector3
+}
+
+// buildModel validates a configuration and builds the engine's model from
+// it. The calculator keeps this one's property tree, options, external
+// forces, rotating masses and slipstream model, and the mass it has gained or burned since
+// its configuration.
+func (fde *FlightDynamicsEngine) buildModel(config *JSBSimConfig) (*reloadedModel, error) {
+	if err := ValidateConfig(config); err != nil {
+		return nil, err
+	}
+	old := fde.Calculator
+	calc := NewForcesMomentsCalculator(config)
+	calc.Mass += old.Mass - old.Reference.EmptyMass
+	calc.Properties = old.Properties
+	calc.PropellerEffects = old.PropellerEffects
+	calc.Blowback = old.Blowback
+	calc.Trace = old.Trace
+	calc.Hybrid = old.Hybrid
+	calc.Rotating = old.Rotating
+	externalCG := calc.External.CG
+	if old.External != nil {
+		calc.External = old.External
+	}
+	if old.Slipstream != nil {
+		slipstream := *old.Slipstream
+		slipstream.tailTerms, slipstream.published = nil, nil
+		calc.Slipstream = &slipstream
+	}
+	return &reloadedModel{
+		calc:         calc,
+		externalCG:   externalCG,
+		gear:         NewLandingGear(config),
+		placards:     configPlacards(config),
+		pilotStation: configPilotStation(config),
+	}, nil
+}
+
+// swapModel puts a built model in place; the caller holds fde.stepping
+func (fde *FlightDynamicsEngine) swapModel(model *reloadedModel) {
+	fde.Calculator = model.calc
+	if model.calc.External != nil {
+		model.calc.External.CG = model.externalCG
+	}
+	fde.Gear = model.gear
+	fde.Placards = model.placards
+	fde.PilotStation = model.pilotStation
+}
+
+// ReloadConfig rebuilds the aerodynamic model from a new configuration,
+// keeping the statistics and everything set on the engine and its
+// calculator. The configuration is validated and the model built before
+// anything changes; a broken configuration is refused with the
+// validation errors and the engine flies on as it was. The swap waits for
+// a step in progress to finish, so no step sees part of each model. The
+// state being flown belongs to the caller and carries on unchanged.
+func (fde *FlightDynamicsEngine) ReloadConfig(config *JSBSimConfig) error {
+	model, err := fde.buildModel(config)
+	if err != nil {
+		return err
+	}
+	fde.stepping.Lock()
+	defer fde.stepping.Unlock()
+	fde.swapModel(model)
+	return nil
+}
+
+// ReloadConfig rebuilds the aerodynamic model and the autopilot from a new
+// configuration as FlightDynamicsEngine.ReloadConfig does. The FCS is the
+// one the engine was built with and keeps its state. The rebuilt
+// autopilot starts with its filters and integrators reset.
+func (engine *FlightDynamicsEngineWithFCS) ReloadConfig(config *JSBSimConfig) error {
+	model, err := engine.buildModel(config)
+	if err != nil {
+		return err
+	}
+	engine.stepping.Lock()
+	defer engine.stepping.Unlock()
+	if err := engine.rebuildAutopilot(config); err != nil {
+		return err
+	}
+	engine.swapModel(model)
+	return nil
+}
+
+// rebuildAutopilot replaces the autopilot with that of a configuration,
+// leaving the engine as it was when it fails to build. The properties it
+// declares take their defaults only where the tree has no value, so the
+// engaged flag and the setpoints carry on.
+func (engine *FlightDynamicsEngineWithFCS) rebuildAutopilot(config *JSBSimConfig) error {
+	if config.Autopilot == nil {
+		engine.Autopilot = nil
+		return nil
+	}
+	properties := engine.FCS.Properties
+	before := properties.checkpoint()
+	autopilot, err := BuildAutopilotFromConfig(config, properties)
+	if err != nil {
+		properties.restore(before)
+		return &ConfigValidationError{Problems: []string{fmt.Sprintf("autopilot: %v", err)}}
+	}
+	after := properties.checkpoint()
+	maps.Copy(after.properties, before.properties)
+	properties.restore(after)
+	engine.Autopilot = autopilot
+	return nil
+}
+
+// ReloadConfig reloads the configuration as
+// FlightDynamicsEngineWithFCS.ReloadConfig does, moving the propulsion
+// system to the new thrust line. The fuel and engine state are kept.
+func (engine *FlightDynamicsEngineWithPropulsion) ReloadConfig(config *JSBSimConfig) error {
+	model, err := engine.buildModel(config)
+	if err != nil {
+		return err
+	}
+	engine.stepping.Lock()
+	defer engine.stepping.Unlock()
+	if err := engine.rebuildAutopilot(config); err != nil {
+		return err
+	}
+	engine.swapModel(model)
+	if config.Propulsion != nil && len(config.Propulsion.Engine) > 0 {
+		engine.Propulsion.Position, _ = configThrustLine(config)
+	}
+	return nil
+}
+
+// ConfigReloader is an engine whose configuration can be reloaded
+type ConfigReloader interface {
+	ReloadConfig(config *JSBSimConfig) error
+}
+
+// ConfigWatcher reloads an engine's configuration each time its file
+// changes. Every reload attempt sends its result on Reloads, nil when it
+// succeeded; results are dropped when the channel is full.
+type ConfigWatcher struct {
+	Path    string
+	Reloads <-chan error
+
+	done chan struct{}
+}
+
+// WatchOption configures WatchAndReload
+type WatchOption func(*watchOptions)
+
+type watchOptions struct {
+	interval time.Duration
+}
+
+// WatchInterval sets how often the file is looked at, DefaultWatchInterval
+// otherwise
+func WatchInterval(interval time.Duration) WatchOption {
+	return func(o *watchOptions) { o.interval = interval }
+}
+
+// WatchAndReload watches a configuration file, parsing it and reloading
+// the engine with it whenever its modification time or size changes. The
+// file must exist when watching begins; its contents then are taken as
+// already loaded. Close stops the watching.
+func WatchAndReload(engine ConfigReloader, path string, options ...WatchOption) (*ConfigWatcher, error) {
+	opts := watchOptions{interval: DefaultWatchInterval}
+	for _, option := range options {
+		option(&opts)
+	}
+	last, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	reloads := make(chan error, 16)
+	watcher := &ConfigWatcher{Path: path, Reloads: reloads, done: make(chan struct{})}
+	go func() {
+		ticker := time.NewTicker(opts.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-watcher.done:
+				return
+			case <-ticker.C:
+			}
+			// A file missing for a moment is being saved over
+			info, err := os.Stat(path)
+			if err != nil || info.ModTime().Equal(last.ModTime()) && info.Size() == last.Size() {
+				continue
+			}
+			last = info
+			err = reloadFile(engine, path)
+			select {
+			case reloads <- err:
+			default:
+			}
+		}
+	}()
+	return watcher, nil
+}
+
+// reloadFile parses a configuration file and reloads an engine with it
+func reloadFile(engine ConfigReloader, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	config, err := ParseJSBSimConfig(file, SourceFile(path))
+	if err != nil {
+		return err
+	}
+	return engine.ReloadConfig(config)
+}
+
+// Close stops watching the file
+func (w *ConfigWatcher) Close() {
+	close(w.done)
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestConfigReload(t *testing.T) {
+	original, err := os.ReadFile("aircraft/p51d-jsbsim.xml")
+	if err != nil {
+		t.Fatalf("Failed to read P-51D XML: %v", err)
+	}
+	// The P-51D's drag of the non-wing components, with its CD0 doubled
+	const cd0 = "<value> 0.62 </value>"
+	if strings.Count(string(original), cd0) != 1 {
+		t.Fatalf("Expected one %q in the P-51D XML", cd0)
+	}
+	doubled := strings.Replace(string(original), cd0, "<value> 1.24 </value>", 1)
+	parse := func(xml string) *JSBSimConfig {
+		t.Helper()
+		config, err := ParseJSBSimConfig(strings.NewReader(xml))
+		if err != nil {
+			t.Fatalf("Parse failed: %v", err)
+		}
+		return config
+	}
+
+	t.Run("Mid-Run", func(t *testing.T) {
+		// Two engines fly the same steps; one has its CD0 doubled after 20
+		reloaded := NewFlightDynamicsEngine(parse(string(original)), NewRungeKutta4Integrator())
+		reloaded.Calculator.External = NewExternalForces(nil)
+		reloaded.Calculator.External.Add(&ExternalForce{Name: "tow", Direction: Vector3{X: 1}, Magnitude: 100})
+		unchanged := NewFlightDynamicsEngine(parse(string(original)), NewRungeKutta4Integrator())
+		unchanged.Calculator.External = reloaded.Calculator.External
+		state := cruiseState(1500, 100, 2*DEG_TO_RAD)
+		for range 20 {
+			next, err := reloaded.Step(state, 0.01)
+			if err != nil {
+				t.Fatalf("Step: %v", err)
+			}
+			if _, err := unchanged.Step(state, 0.01); err != nil {
+				t.Fatalf("Step: %v", err)
+			}
+			state = next
+		}
+		flightTime := reloaded.Statistics.FlightTime
+
+		if err := reloaded.ReloadConfig(parse(doubled)); err != nil {
+			t.Fatalf("ReloadConfig: %v", err)
+		}
+		assertEqual(t, reloaded.Statistics.FlightTime, flightTime)
+		assertEqual(t, reloaded.Calculator.External.Len(), 1)
+
+		// The very next step decelerates harder, from the same state
+		before, _, err := unchanged.Derivatives(state)
+		if err != nil {
+			t.Fatalf("Derivatives: %v", err)
+		}
+		after, _, err := reloaded.Derivatives(state)
+		if err != nil {
+			t.Fatalf("Derivatives: %v", err)
+		}
+		if after.VelocityDot.X >= before.VelocityDot.X-1e-4 {
+			t.Errorf("Doubled CD0 should decelerate harder at once, u̇ %.3f and %.3f m/s²",
+				after.VelocityDot.X, before.VelocityDot.X)
+		}
+		next, err := reloaded.Step(state, 0.01)
+		if err != nil {
+			t.Fatalf("Step: %v", err)
+		}
+		control, err := unchanged.Step(state, 0.01)
+		if err != nil {
+			t.Fatalf("Step: %v", err)
+		}
+		if next.TrueAirspeed >= control.TrueAirspeed {
+			t.Errorf("Airspeed after the reload %.4f m/s, without it %.4f m/s", next.TrueAirspeed, control.TrueAirspeed)
+		}
+
+		// Altitude and attitude carry on across the swap
+		assertApproxEqual(t, next.Altitude, control.Altitude, 1e-3)
+		assertApproxEqual(t, next.Orientation.Dot(control.Orientation), 1, 1e-6)
+	})
+
+	t.Run("Rejected", func(t *testing.T) {
+		engine := NewFlightDynamicsEngine(parse(string(original)), NewRungeKutta4Integrator())
+		calc := engine.Calculator
+		broken := parse(doubled)
+		broken.Metrics.WingArea, broken.Metrics.Chord = nil, nil
+		broken.MassBalance.EmptyMass.Value = 0
+
+		err := engine.ReloadConfig(broken)
+		var invalid *ConfigValidationError
+		if !errors.As(err, &invalid) {
+			t.Fatalf("A broken configuration should be refused with its problems, got %v", err)
+		}
+		assertEqual(t, invalid.Problems, []string{
+			"metrics: wingarea must be positive",
+			"metrics: chord must be positive",
+			"mass_balance: emptywt must be positive",
+		})
+		if engine.Calculator != calc {
+			t.Error("A refused reload should leave the model as it was")
+		}
+	})
+
+	t.Run("FCS", func(t *testing.T) {
+		engine, err := NewFlightDynamicsEngineWithFCS(parse(string(original)), true)
+		if err != nil {
+			t.Fatalf("NewFlightDynamicsEngineWithFCS: %v", err)
+		}
+		engine.FCS.Properties.Set("fcs/elevator-cmd-norm", 0.3)
+		if err := engine.ReloadConfig(parse(doubled)); err != nil {
+			t.Fatalf("ReloadConfig: %v", err)
+		}
+		if engine.Calculator.Properties != engine.FCS.Properties {
+			t.Error("The reloaded model should share the FCS's property tree")
+		}
+		assertApproxEqual(t, engine.FCS.Properties.Get("fcs/elevator-cmd-norm"), 0.3, 1e-12)
+		if _, _, err := engine.RunSimulationStepWithFCS(cruiseState(1500, 100, 0), 0.01); err != nil {
+			t.Fatalf("RunSimulationStepWithFCS: %v", err)
+		}
+	})
+
+	t.Run("Watch", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "p51d.xml")
+		if err := os.WriteFile(path, original, 0o644); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+		engine := NewFlightDynamicsEngine(parse(string(original)), NewRungeKutta4Integrator())
+		watcher, err := WatchAndReload(engine, path, WatchInterval(5*time.Millisecond))
+		if err != nil {
+			t.Fatalf("WatchAndReload: %v", err)
+		}
+		defer watcher.Close()
+
+		// wait returns the result of the next reload
+		wait := func() error {
+			t.Helper()
+			select {
+			case err := <-watcher.Reloads:
+				return err
+			case <-time.After(5 * time.Second):
+				t.Fatal("The change was not reloaded")
+				return nil
+			}
+		}
+
+		if err := os.WriteFile(path, []byte(doubled), 0o644); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+		if err := wait(); err != nil {
+			t.Fatalf("Reload: %v", err)
+		}
+		state := cruiseState(1500, 100, 2*DEG_TO_RAD)
+		components, err := engine.Calculator.CalculateForcesMoments(state)
+		if err != nil {
+			t.Fatalf("CalculateForcesMoments: %v", err)
+		}
+		base, err := NewForcesMomentsCalculator(parse(string(original))).CalculateForcesMoments(state)
+		if err != nil {
+			t.Fatalf("CalculateForcesMoments: %v", err)
+		}
+		// Drag is along body X, negative
+		if components.Aerodynamic.Drag >= base.Aerodynamic.Drag {
+			t.Errorf("The watched edit should raise the drag, %.1f and %.1f N", components.Aerodynamic.Drag, base.Aerodynamic.Drag)
+		}
+
+		// A file that does not parse is reported and not loaded
+		calc := engine.Calculator
+		if err := os.WriteFile(path, []byte("<fdm_config"), 0o644); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+		if err := wait(); err == nil {
+			t.Error("A broken file should be reported")
+		}
+		if engine.Calculator != calc {
+			t.Error("A broken file should leave the model as it was")
+		}
+	})
+}
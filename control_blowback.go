@@ -0,0 +1,89 @@
+// Control Surface Blowback
+// Limits the deflection the flight controls can hold against the hinge
+// moment of each surface, which grows with dynamic pressure
+
+package main
+
+import "math"
+
+// ControlBlowback is the hinge moment model of the primary flight controls.
+// The hinge moment of a surface at deflection δ is Chδ·δ·qbar·S·c, and the
+// pilot and control runs hold at most MaxHingeMoment, so at high dynamic
+// pressure the surface blows back from the commanded deflection. Surfaces
+// with a zero MaxHingeMoment are not limited.
+type ControlBlowback struct {
+	Elevator SurfaceHingeModel
+	Aileron  SurfaceHingeModel // Each aileron
+	Rudder   SurfaceHingeModel
+}
+
+// SurfaceHingeModel describes the hinge moment of one control surface
+type SurfaceHingeModel struct {
+	ChDelta        float64 // Hinge moment coefficient per radian of deflection
+	Area           float64 // Surface area aft of the hinge line (m²)
+	Chord          float64 // Surface chord aft of the hinge line (m)
+	MaxHingeMoment float64 // Largest hinge moment the controls can hold (N·m)
+}
+
+// MaxDeflection returns the largest deflection (rad) the controls can hold
+// at dynamic pressure qbar (Pa), or +Inf when the surface is not limited
+func (s SurfaceHingeModel) MaxDeflection(qbar float64) float64 {
+	moment := math.Abs(s.ChDelta) * qbar * s.Area * s.Chord
+	if s.MaxHingeMoment <= 0 || moment <= 0 {
+		return math.Inf(1)
+	}
+	return s.MaxHingeMoment / moment
+}
+
+// Surface deflections at a full command in the direct control mapping,
+// which models without an FCS fly on (rad)
+const (
+	directElevatorTravel = 25.0 * DEG_TO_RAD
+	directAileronTravel  = 30.0 * DEG_TO_RAD
+	directRudderTravel   = 30.0 * DEG_TO_RAD
+)
+
+// heldCommand returns the part of a normalised command the surface holds
+// at dynamic pressure qbar, a full command deflecting it by travel (rad)
+func (s SurfaceHingeModel) heldCommand(command, travel, qbar float64) float64 {
+	limit := s.MaxDeflection(qbar) / travel
+	return math.Max(-limit, math.Min(limit, command))
+}
+
+// blowbackSurfaces are the position properties of each limited surface in
+// radians, degrees and normalised, and the property its factor goes to
+var blowbackSurfaces = []struct {
+	rad, deg, norm, factor string
+	model                  func(*ControlBlowback) SurfaceHingeModel
+}{
+	{"fcs/elevator-pos-rad", "fcs/elevator-pos-deg", "fcs/elevator-pos-norm", "fcs/elevator-blowback-factor",
+		func(b *ControlBlowback) SurfaceHingeModel { return b.Elevator }},
+	{"fcs/left-aileron-pos-rad", "fcs/left-aileron-pos-deg", "fcs/left-aileron-pos-norm", "fcs/left-aileron-blowback-factor",
+		func(b *ControlBlowback) SurfaceHingeModel { return b.Aileron }},
+	{"fcs/right-aileron-pos-rad", "fcs/right-aileron-pos-deg", "fcs/right-aileron-pos-norm", "fcs/right-aileron-blowback-factor",
+		func(b *ControlBlowback) SurfaceHingeModel { return b.Aileron }},
+	{"fcs/rudder-pos-rad", "fcs/rudder-pos-deg", "fcs/rudder-pos-norm", "fcs/rudder-blowback-factor",
+		func(b *ControlBlowback) SurfaceHingeModel { return b.Rudder }},
+}
+
+// apply scales the surface positions in properties down to the deflection
+// each surface can hold at dynamic pressure qbar, and publishes the ratio
+// of held to commanded deflection as the surface's blowback factor. The
+// radian, degree and normalised positions are scaled together, by the
+// largest of the radian and degree deflections, so that applying the limit
+// twice leaves the positions unchanged.
+func (b *ControlBlowback) apply(properties map[string]float64, qbar float64) {
+	for _, surface := range blowbackSurfaces {
+		deflection := math.Max(math.Abs(properties[surface.rad]), math.Abs(properties[surface.deg])*DEG_TO_RAD)
+		factor := 1.0
+		if limit := surface.model(b).MaxDeflection(qbar); deflection > limit {
+			factor = limit / deflection
+		}
+		for _, name := range []string{surface.rad, surface.deg, surface.norm} {
+			if value, ok := properties[name]; ok {
+				properties[name] = value * factor
+			}
+		}
+		properties[surface.factor] = factor
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// p51dElevatorBlowback is a hinge moment model of the kind of the P-51D
+// elevator, the stick holding its full travel up to about 65 m/s at 1500 m
+var p51dElevatorBlowback = &ControlBlowback{
+	Elevator: SurfaceHingeModel{ChDelta: -0.45, Area: 1.25, Chord: 0.42, MaxHingeMoment: 240},
+}
+
+func TestControlBlowback(t *testing.T) {
+	const altitude = 1500.0
+
+	// maxPullUp returns the highest load factor, in steps of 0.25 g, at
+	// which the simplified model holds a steady pull-up within the full
+	// elevator command, and the elevator deflection (rad) at a full command
+	maxPullUp := func(airspeed float64, blowback *ControlBlowback) (nz, deflection float64) {
+		engine := NewSimplifiedFlightDynamicsEngine(NewEulerIntegrator())
+		engine.Calculator.Blowback = blowback
+		x := Vector3{Z: 0.5}
+		for nz = 1.0; nz < 15; nz += 0.25 {
+			build := func(x Vector3) *AircraftState { return pullUpState(altitude, airspeed, nz+0.25, x) }
+			next, ok := solveTrim(t, engine, build, nz+0.25, 0, x)
+			if !ok || math.Abs(next.Y) > 1 {
+				break
+			}
+			x = next
+		}
+		deflection = -directElevatorTravel
+		if blowback != nil {
+			qbar := pullUpState(altitude, airspeed, nz, x).DynamicPressure
+			deflection = blowback.Elevator.heldCommand(-1, directElevatorTravel, qbar) * directElevatorTravel
+		}
+		return nz, deflection
+	}
+
+	t.Run("Full Elevator Pull-Up", func(t *testing.T) {
+		slowNz, slowDeflection := maxPullUp(80, p51dElevatorBlowback)
+		fastNz, fastDeflection := maxPullUp(200, p51dElevatorBlowback)
+		t.Logf("80 m/s: %.1f° held, %.2f g", slowDeflection*RAD_TO_DEG, slowNz)
+		t.Logf("200 m/s: %.1f° held, %.2f g", fastDeflection*RAD_TO_DEG, fastNz)
+		if math.Abs(fastDeflection) >= math.Abs(slowDeflection) {
+			t.Errorf("Elevator should blow back further at 200 m/s: %.1f° against %.1f°",
+				fastDeflection*RAD_TO_DEG, slowDeflection*RAD_TO_DEG)
+		}
+		if fastNz >= slowNz {
+			t.Errorf("Full elevator should pull less at 200 m/s: %.2f g against %.2f g", fastNz, slowNz)
+		}
+
+		// Without blowback, full elevator at 200 m/s pulls far harder
+		unlimitedNz, unlimitedDeflection := maxPullUp(200, nil)
+		assertEqual(t, unlimitedDeflection, -directElevatorTravel)
+		if unlimitedNz < 2*slowNz {
+			t.Errorf("Unlimited elevator should pull well past %.2f g at 200 m/s, got %.2f g", slowNz, unlimitedNz)
+		}
+	})
+
+	t.Run("Blowback Factor Property", func(t *testing.T) {
+		calc := NewForcesMomentsCalculator(loadP51DConfig(t))
+		surfaces := func(airspeed float64) (position, factor, qbar float64) {
+			state := NewAircraftState()
+			state.Altitude = altitude
+			state.Position = Vector3{Z: -altitude}
+			state.Velocity = Vector3{X: airspeed}
+			state.ControlSurfaces.Elevator = -20 * DEG_TO_RAD
+			state.UpdateAtmosphere()
+			state.UpdateDerivedParameters()
+			if _, err := calc.CalculateForcesMoments(state); err != nil {
+				t.Fatalf("Force calculation failed: %v", err)
+			}
+			return calc.Properties.Get("fcs/elevator-pos-rad"), calc.Properties.Get("fcs/elevator-blowback-factor"), state.DynamicPressure
+		}
+
+		// Off by default: the surface reaches its commanded position
+		position, _, _ := surfaces(200)
+		assertEqual(t, position, -20*DEG_TO_RAD)
+
+		calc.Blowback = p51dElevatorBlowback
+		slow, slowFactor, _ := surfaces(80)
+		fast, fastFactor, qbar := surfaces(200)
+		assertApproxEqual(t, slow, -20*DEG_TO_RAD*slowFactor, 1e-12)
+		assertApproxEqual(t, fast, -20*DEG_TO_RAD*fastFactor, 1e-12)
+		if !(fastFactor < slowFactor && slowFactor <= 1) {
+			t.Errorf("Blowback factor should fall with speed: %.3f at 80 m/s, %.3f at 200 m/s", slowFactor, fastFactor)
+		}
+
+		// The held deflection is the hinge moment limit
+		assertApproxEqual(t, -fast, p51dElevatorBlowback.Elevator.MaxDeflection(qbar), 1e-12)
+	})
+}
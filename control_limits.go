@@ -0,0 +1,190 @@
+// Control Limits
+// Sanity bounds on the pilot control inputs, so a bad packet or script
+// cannot command values far outside the aerodynamic tables
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"maps"
+	"math"
+	"sync"
+)
+
+// ControlRange is the interval a control input is clamped to
+type ControlRange struct {
+	Min, Max float64
+}
+
+// clamp returns value within the range. NaN is clamped to the range's
+// nearest end to zero, so it never reaches the dynamics.
+func (r ControlRange) clamp(value float64) float64 {
+	if math.IsNaN(value) {
+		return math.Max(r.Min, math.Min(r.Max, 0))
+	}
+	return math.Max(r.Min, math.Min(r.Max, value))
+}
+
+// ControlLimits bounds the pilot control inputs as they enter the
+// simulation. Out-of-range inputs are clamped, counted and logged, never
+// rejected; the first clamp of each input is logged and later ones only
+// counted. The optional rate limits model how fast the stick, pedals and
+// levers can physically travel, in full travel per second.
+type ControlLimits struct {
+	Aileron, Elevator, Rudder, Steer      ControlRange
+	Throttle, Flaps, Brake, Mixture, Prop ControlRange // Brake bounds the left and right brakes too
+
+	StickRate float64 // Aileron and elevator; unlimited when 0
+	PedalRate float64 // Rudder, steering and brakes; unlimited when 0
+	LeverRate float64 // Throttle, flaps, mixture and propeller; unlimited when 0
+
+	Warn func(message string) // Receives the warnings; they are logged when nil
+
+	mu          sync.Mutex
+	clamped     map[string]int
+	rateLimited map[string]int
+	previous    *ControlInputs // Last inputs applied, for the rate limits
+	previousT   float64
+}
+
+// NewControlLimits returns the standard ranges: the stick, pedals and
+// steering over [-1, 1], the levers and brakes over [0, 1], and no rate
+// limits
+func NewControlLimits() *ControlLimits {
+	symmetric, positive := ControlRange{-1, 1}, ControlRange{0, 1}
+	return &ControlLimits{
+		Aileron: symmetric, Elevator: symmetric, Rudder: symmetric, Steer: symmetric,
+		Throttle: positive, Flaps: positive, Brake: positive, Mixture: positive, Prop: positive,
+	}
+}
+
+// fresh returns limits with the same ranges, rates and warning function
+// that have clamped nothing and applied no inputs yet. It is nil for nil
+// limits.
+func (l *ControlLimits) fresh() *ControlLimits {
+	if l == nil {
+		return nil
+	}
+	return &ControlLimits{
+		Aileron: l.Aileron, Elevator: l.Elevator, Rudder: l.Rudder, Steer: l.Steer,
+		Throttle: l.Throttle, Flaps: l.Flaps, Brake: l.Brake, Mixture: l.Mixture, Prop: l.Prop,
+		StickRate: l.StickRate, PedalRate: l.PedalRate, LeverRate: l.LeverRate,
+		Warn: l.Warn,
+	}
+}
+
+// defaultControlLimits clamps the inputs of states without limits of their
+// own
+var defaultControlLimits = NewControlLimits()
+
+// limitedControl is one input of ControlInputs with its range and rate
+type limitedControl struct {
+	name  string
+	field func(c *ControlInputs) *float64
+	rng   func(l *ControlLimits) ControlRange
+	rate  func(l *ControlLimits) float64
+}
+
+func stickRate(l *ControlLimits) float64 { return l.StickRate }
+func pedalRate(l *ControlLimits) float64 { return l.PedalRate }
+func leverRate(l *ControlLimits) float64 { return l.LeverRate }
+
+var limitedControls = []limitedControl{
+	{"aileron", func(c *ControlInputs) *float64 { return &c.Aileron }, func(l *ControlLimits) ControlRange { return l.Aileron }, stickRate},
+	{"elevator", func(c *ControlInputs) *float64 { return &c.Elevator }, func(l *ControlLimits) ControlRange { return l.Elevator }, stickRate},
+	{"rudder", func(c *ControlInputs) *float64 { return &c.Rudder }, func(l *ControlLimits) ControlRange { return l.Rudder }, pedalRate},
+	{"steer", func(c *ControlInputs) *float64 { return &c.Steer }, func(l *ControlLimits) ControlRange { return l.Steer }, pedalRate},
+	{"brake", func(c *ControlInputs) *float64 { return &c.Brake }, func(l *ControlLimits) ControlRange { return l.Brake }, pedalRate},
+	{"brake_left", func(c *ControlInputs) *float64 { return &c.BrakeLeft }, func(l *ControlLimits) ControlRange { return l.Brake }, pedalRate},
+	{"brake_right", func(c *ControlInputs) *float64 { return &c.BrakeRight }, func(l *ControlLimits) ControlRange { return l.Brake }, pedalRate},
+	{"throttle", func(c *ControlInputs) *float64 { return &c.Throttle }, func(l *ControlLimits) ControlRange { return l.Throttle }, leverRate},
+	{"flaps", func(c *ControlInputs) *float64 { return &c.Flaps }, func(l *ControlLimits) ControlRange { return l.Flaps }, leverRate},
+	{"mixture", func(c *ControlInputs) *float64 { return &c.Mixture }, func(l *ControlLimits) ControlRange { return l.Mixture }, leverRate},
+	{"propeller", func(c *ControlInputs) *float64 { return &c.Propeller }, func(l *ControlLimits) ControlRange { return l.Prop }, leverRate},
+}
+
+// Clamp returns the inputs within their ranges, without counting, logging
+// or rate limiting
+func (l *ControlLimits) Clamp(controls ControlInputs) ControlInputs {
+	// Written out, as the property sync calls it every step and must not
+	// allocate
+	controls.Aileron = l.Aileron.clamp(controls.Aileron)
+	controls.Elevator = l.Elevator.clamp(controls.Elevator)
+	controls.Rudder = l.Rudder.clamp(controls.Rudder)
+	controls.Steer = l.Steer.clamp(controls.Steer)
+	controls.Brake = l.Brake.clamp(controls.Brake)
+	controls.BrakeLeft = l.Brake.clamp(controls.BrakeLeft)
+	controls.BrakeRight = l.Brake.clamp(controls.BrakeRight)
+	controls.Throttle = l.Throttle.clamp(controls.Throttle)
+	controls.Flaps = l.Flaps.clamp(controls.Flaps)
+	controls.Mixture = l.Mixture.clamp(controls.Mixture)
+	controls.Propeller = l.Prop.clamp(controls.Propeller)
+	return controls
+}
+
+// Apply returns the inputs within their ranges and, when rate limited,
+// moved no further from the last inputs applied than their rates allow
+// since time t of those (s). The first inputs applied are not rate limited.
+// Clamps and rate limits are counted.
+func (l *ControlLimits) Apply(controls ControlInputs, t float64) ControlInputs {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var warnings []string
+	for _, c := range limitedControls {
+		value := c.field(&controls)
+		clamped := c.rng(l).clamp(*value)
+		if clamped != *value || math.IsNaN(*value) {
+			if l.clamped == nil {
+				l.clamped = make(map[string]int)
+			}
+			if l.clamped[c.name] == 0 {
+				warnings = append(warnings, fmt.Sprintf("WARNING: %s input %g at t=%.2fs clamped to %g; further clamps of it are only counted",
+					c.name, *value, t, clamped))
+			}
+			l.clamped[c.name]++
+		}
+		*value = clamped
+
+		rate := c.rate(l)
+		if rate <= 0 || l.previous == nil {
+			continue
+		}
+		previous := *c.field(l.previous)
+		step := rate * math.Max(t-l.previousT, 0)
+		if limited := math.Max(previous-step, math.Min(previous+step, *value)); limited != *value {
+			if l.rateLimited == nil {
+				l.rateLimited = make(map[string]int)
+			}
+			l.rateLimited[c.name]++
+			*value = limited
+		}
+	}
+	l.previous, l.previousT = &controls, t
+
+	for _, message := range warnings {
+		if l.Warn != nil {
+			l.Warn(message)
+		} else {
+			log.Print(message)
+		}
+	}
+	return controls
+}
+
+// ClampCounts returns how many times each input has been clamped to its
+// range, by name, such as "elevator"; nil before the first
+func (l *ControlLimits) ClampCounts() map[string]int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return maps.Clone(l.clamped)
+}
+
+// RateLimitCounts returns how many times each input has been held back by
+// its rate limit, by name
+func (l *ControlLimits) RateLimitCounts() map[string]int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return maps.Clone(l.rateLimited)
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// absurdControls are inputs a corrupt packet or buggy script might send
+var absurdControls = ControlInputs{
+	Aileron:    -12,
+	Elevator:   37.0,
+	Rudder:     math.NaN(),
+	Throttle:   -2,
+	Flaps:      5,
+	Gear:       true,
+	Brake:      math.Inf(1),
+	Mixture:    1e9,
+	Propeller:  -0.5,
+	BrakeLeft:  3,
+	BrakeRight: -1,
+	Steer:      math.Inf(-1),
+}
+
+// assertWithinLimits checks every input of controls is within the default
+// ranges
+func assertWithinLimits(t *testing.T, controls ControlInputs) {
+	t.Helper()
+	for _, c := range limitedControls {
+		value := *c.field(&controls)
+		r := c.rng(defaultControlLimits)
+		if !(value >= r.Min && value <= r.Max) {
+			t.Errorf("%s is %g, outside [%g, %g]", c.name, value, r.Min, r.Max)
+		}
+	}
+}
+
+func TestControlLimits(t *testing.T) {
+	t.Run("Direct API", func(t *testing.T) {
+		var warnings []string
+		state := NewAircraftState()
+		state.Limits.Warn = func(message string) { warnings = append(warnings, message) }
+
+		state.SetControlInputs(absurdControls)
+		assertWithinLimits(t, state.Controls)
+		assertEqual(t, state.Controls.Elevator, 1.0)
+		assertEqual(t, state.Controls.Throttle, 0.0)
+		assertEqual(t, state.Controls.Rudder, 0.0)
+		assertEqual(t, state.Controls.Steer, -1.0)
+		assertEqual(t, state.Controls.Gear, true)
+
+		// In-range inputs pass unchanged and are not counted
+		state.SetControlInputs(ControlInputs{Elevator: -0.3, Throttle: 0.75, Mixture: 0.9})
+		assertEqual(t, state.Controls.Elevator, -0.3)
+		assertEqual(t, state.Controls.Throttle, 0.75)
+
+		state.SetControlInputs(ControlInputs{Elevator: 37.0})
+		counts := state.Limits.ClampCounts()
+		assertEqual(t, counts["elevator"], 2)
+		assertEqual(t, counts["throttle"], 1)
+		assertEqual(t, counts["aileron"], 1)
+		assertEqual(t, len(counts), 11)
+
+		// One warning an input
+		assertEqual(t, len(warnings), 11)
+		if !strings.Contains(strings.Join(warnings, "\n"), "elevator input 37 at t=0.00s clamped to 1") {
+			t.Errorf("The warnings should name the input and value:\n%s", strings.Join(warnings, "\n"))
+		}
+
+		// Copies of the state keep counting together
+		next := state.Copy()
+		next.SetControlInputs(ControlInputs{Throttle: 2})
+		assertEqual(t, state.Limits.ClampCounts()["throttle"], 2)
+	})
+
+	t.Run("Direct Writes", func(t *testing.T) {
+		// Controls written without SetControlInputs reach the properties
+		// clamped
+		state := NewAircraftState()
+		state.Controls = absurdControls
+		properties := state.ToPropertyMap()
+		assertEqual(t, properties["fcs/elevator-cmd-norm"], 1.0)
+		assertEqual(t, properties["fcs/throttle-cmd-norm"], 0.0)
+		assertEqual(t, properties["fcs/left-brake-cmd-norm"], 1.0)
+		assertEqual(t, properties["fcs/steer-cmd-norm"], -1.0)
+	})
+
+	t.Run("FCS Engine", func(t *testing.T) {
+		engine, err := NewFlightDynamicsEngineWithFCS(loadP51DConfig(t), true)
+		if err != nil {
+			t.Fatalf("NewFlightDynamicsEngineWithFCS: %v", err)
+		}
+		engine.Limits.Warn = func(string) {}
+		state := NewAircraftState()
+		state.Limits.Warn = func(string) {}
+
+		engine.SetControlInputs(absurdControls)
+		assertEqual(t, engine.FCS.Properties.Get("fcs/elevator-cmd-norm"), 1.0)
+		assertEqual(t, engine.FCS.Properties.Get("fcs/throttle-cmd-norm"), 0.0)
+
+		engine.SetControlInputsOnState(state, absurdControls)
+		assertWithinLimits(t, state.Controls)
+		for i := 0; i < 20; i++ {
+			next, _, err := engine.RunSimulationStepWithFCS(state, 0.01)
+			if err != nil {
+				t.Fatalf("Step %d: %v", i, err)
+			}
+			state = next
+			assertWithinLimits(t, state.Controls)
+			for _, name := range []string{"fcs/elevator-cmd-norm", "fcs/aileron-cmd-norm", "fcs/mixture-cmd-norm"} {
+				if value := engine.FCS.Properties.Get(name); math.Abs(value) > 1 {
+					t.Fatalf("Step %d: %s is %g", i, name, value)
+				}
+			}
+		}
+		assertEqual(t, engine.Limits.ClampCounts()["elevator"], 2)
+	})
+
+	t.Run("Rate Limits", func(t *testing.T) {
+		state := NewAircraftState()
+		state.Limits.StickRate = 2 // Full travel in a second
+		state.Limits.LeverRate = 0.5
+		state.SetControlInputs(ControlInputs{Elevator: -1})
+
+		// A step from full down to full up moves 0.25 in 0.125 s
+		state.Time += 0.125
+		state.SetControlInputs(ControlInputs{Elevator: 1, Throttle: 1})
+		assertEqual(t, state.Controls.Elevator, -0.75)
+		assertEqual(t, state.Controls.Throttle, 0.0625)
+
+		// Held, the stick gets there in the rest of the second
+		for i := 0; i < 7; i++ {
+			state.Time += 0.125
+			state.SetControlInputs(ControlInputs{Elevator: 1, Throttle: 1})
+		}
+		assertEqual(t, state.Controls.Elevator, 1.0)
+		assertEqual(t, state.Controls.Throttle, 0.5)
+		assertEqual(t, state.Limits.RateLimitCounts()["elevator"], 7)
+		assertEqual(t, state.Limits.RateLimitCounts()["throttle"], 8)
+
+		// Rate limits apply to the clamped inputs
+		state.Time += 0.125
+		state.SetControlInputs(ControlInputs{Elevator: -50})
+		assertEqual(t, state.Controls.Elevator, 0.75)
+		assertEqual(t, state.Limits.RateLimitCounts()["rudder"], 0)
+	})
+}
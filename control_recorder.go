@@ -0,0 +1,172 @@
+// Control Recording and Replay
+// Captures the pilot inputs of a session as time-stamped changes, saved as
+// JSON lines, and feeds them back into an engine at the same simulation
+// times, to reproduce a run or fly it again on a modified aircraft
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// ControlSample is the pilot inputs from a simulation time on
+type ControlSample struct {
+	Time     float64       `json:"t"`
+	Controls ControlInputs `json:"controls"`
+}
+
+// ControlRecorder records the pilot inputs of a session, keeping only the
+// changes
+type ControlRecorder struct {
+	Samples []ControlSample
+}
+
+// NewControlRecorder creates an empty recorder
+func NewControlRecorder() *ControlRecorder {
+	return &ControlRecorder{}
+}
+
+// Record takes the inputs given at simulation time t, reporting whether
+// they were kept: inputs equal to the last recorded are not. Inputs given
+// again at the time of the last sample replace it.
+func (cr *ControlRecorder) Record(t float64, controls ControlInputs) bool {
+	if n := len(cr.Samples); n > 0 {
+		last := &cr.Samples[n-1]
+		if last.Controls == controls {
+			return false
+		}
+		if t <= last.Time {
+			last.Controls = controls
+			return true
+		}
+	}
+	cr.Samples = append(cr.Samples, ControlSample{Time: t, Controls: controls})
+	return true
+}
+
+// Save writes the samples as JSON lines, one sample per line. The times
+// are written exactly, so a replay steps on the same times.
+func (cr *ControlRecorder) Save(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	for _, sample := range cr.Samples {
+		if err := encoder.Encode(sample); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadControlRecording reads samples written by ControlRecorder.Save. The
+// times must increase; blank lines are skipped.
+func LoadControlRecording(r io.Reader) ([]ControlSample, error) {
+	var samples []ControlSample
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var sample ControlSample
+		if err := json.Unmarshal(scanner.Bytes(), &sample); err != nil {
+			return nil, fmt.Errorf("control recording line %d: %w", line, err)
+		}
+		if n := len(samples); n > 0 && sample.Time <= samples[n-1].Time {
+			return nil, fmt.Errorf("control recording line %d: time %g s does not follow %g s", line, sample.Time, samples[n-1].Time)
+		}
+		samples = append(samples, sample)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading control recording: %w", err)
+	}
+	return samples, nil
+}
+
+// ReplayPolicy is how a ControlReplayer fills the time between samples
+type ReplayPolicy int
+
+const (
+	// ReplayHold holds each sample until the next, as the recorded
+	// changes were flown
+	ReplayHold ReplayPolicy = iota
+
+	// ReplayInterpolate moves the continuous inputs linearly from each
+	// sample to the next; the gear is held
+	ReplayInterpolate
+)
+
+// ControlReplayer feeds recorded inputs into an engine. Set it as the
+// engine's Replay and each step starts with the inputs of its time; before
+// the first sample the inputs are left alone.
+type ControlReplayer struct {
+	Samples []ControlSample
+	Policy  ReplayPolicy
+
+	last    ControlInputs
+	started bool
+}
+
+// NewControlReplayer creates a replayer of samples with increasing times
+func NewControlReplayer(samples []ControlSample, policy ReplayPolicy) *ControlReplayer {
+	return &ControlReplayer{Samples: samples, Policy: policy}
+}
+
+// At returns the inputs at simulation time t, or false before the first
+// sample
+func (cr *ControlReplayer) At(t float64) (ControlInputs, bool) {
+	// The last sample at or before t, allowing for rounding in the times
+	// the engine steps on
+	const tolerance = 1e-9
+	i := -1
+	for i+1 < len(cr.Samples) && cr.Samples[i+1].Time <= t+tolerance {
+		i++
+	}
+	if i < 0 {
+		return ControlInputs{}, false
+	}
+	if cr.Policy != ReplayInterpolate || i+1 == len(cr.Samples) {
+		return cr.Samples[i].Controls, true
+	}
+
+	from, to := cr.Samples[i], cr.Samples[i+1]
+	f := (t - from.Time) / (to.Time - from.Time)
+	if f <= 0 {
+		return from.Controls, true
+	}
+	lerp := func(a, b float64) float64 { return a + (b-a)*f }
+	a, b := from.Controls, to.Controls
+	return ControlInputs{
+		Aileron:    lerp(a.Aileron, b.Aileron),
+		Elevator:   lerp(a.Elevator, b.Elevator),
+		Rudder:     lerp(a.Rudder, b.Rudder),
+		Throttle:   lerp(a.Throttle, b.Throttle),
+		Flaps:      lerp(a.Flaps, b.Flaps),
+		Gear:       a.Gear,
+		Brake:      lerp(a.Brake, b.Brake),
+		Mixture:    lerp(a.Mixture, b.Mixture),
+		Propeller:  lerp(a.Propeller, b.Propeller),
+		BrakeLeft:  lerp(a.BrakeLeft, b.BrakeLeft),
+		BrakeRight: lerp(a.BrakeRight, b.BrakeRight),
+		Steer:      lerp(a.Steer, b.Steer),
+	}, true
+}
+
+// Next returns the inputs at simulation time t and whether they differ
+// from those Next last returned; false before the first sample
+func (cr *ControlReplayer) Next(t float64) (ControlInputs, bool) {
+	controls, ok := cr.At(t)
+	if !ok || (cr.started && controls == cr.last) {
+		return controls, false
+	}
+	cr.last, cr.started = controls, true
+	return controls, true
+}
+
+// Apply sets the state's inputs to those of its time, from the first
+// sample on
+func (cr *ControlReplayer) Apply(state *AircraftState) {
+	if controls, ok := cr.At(state.Time); ok {
+		state.Controls = controls
+	}
+}
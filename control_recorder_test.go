@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// flySession flies the P-51D for 4 s from cruise, the pilot's inputs given
+// by script at the steps it names and any replay set on the engine
+func flySession(t *testing.T, config *JSBSimConfig, script map[int]ControlInputs, recorder *ControlRecorder, replay *ControlReplayer) []*AircraftState {
+	t.Helper()
+	engine, err := NewFlightDynamicsEngineWithFCS(config, true)
+	if err != nil {
+		t.Fatalf("NewFlightDynamicsEngineWithFCS: %v", err)
+	}
+	engine.Recorder, engine.Replay = recorder, replay
+
+	state := cruiseState(3000, 120, 0.02)
+	states := []*AircraftState{state}
+	for i := 0; i < 400; i++ {
+		if controls, ok := script[i]; ok {
+			engine.SetControlInputsOnState(state, controls)
+		}
+		next, _, err := engine.RunSimulationStepWithFCS(state, 0.01)
+		if err != nil {
+			t.Fatalf("Step %d failed: %v", i, err)
+		}
+		if err := next.Validate(); err != nil {
+			t.Fatalf("Step %d: %v", i, err)
+		}
+		state = next
+		states = append(states, state)
+	}
+	return states
+}
+
+func TestControlRecording(t *testing.T) {
+	script := map[int]ControlInputs{
+		50:  {Throttle: 0.7, Mixture: 1, Elevator: -0.1},
+		150: {Throttle: 0.7, Mixture: 1, Elevator: -0.1, Aileron: 0.3},
+		250: {Throttle: 0.9, Mixture: 1, Elevator: 0.05, Aileron: -0.2, Rudder: 0.1},
+	}
+	recorder := NewControlRecorder()
+	original := flySession(t, loadP51DConfig(t), script, recorder, nil)
+
+	var saved bytes.Buffer
+	if err := recorder.Save(&saved); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	assertEqual(t, strings.Count(saved.String(), "\n"), 3)
+	samples, err := LoadControlRecording(&saved)
+	if err != nil {
+		t.Fatalf("LoadControlRecording: %v", err)
+	}
+	assertEqual(t, samples, recorder.Samples)
+	assertEqual(t, samples[1].Time, original[150].Time)
+
+	t.Run("Replay Reproduces", func(t *testing.T) {
+		replayed := flySession(t, loadP51DConfig(t), nil, nil, NewControlReplayer(samples, ReplayHold))
+		channels := []string{
+			"position/h-sl-m", "velocities/u-mps", "velocities/v-mps", "velocities/w-mps",
+			"attitude/roll-rad", "attitude/pitch-rad", "attitude/heading-rad",
+			"velocities/p-rad_sec", "velocities/q-rad_sec", "velocities/r-rad_sec",
+		}
+		want, err := QuantizeStates(original, channels, 0)
+		if err != nil {
+			t.Fatalf("QuantizeStates: %v", err)
+		}
+		got, err := QuantizeStates(replayed, channels, 0)
+		if err != nil {
+			t.Fatalf("QuantizeStates: %v", err)
+		}
+		if want.Hash().Hash != got.Hash().Hash {
+			t.Fatalf("The replay hashes differently: %s", want.FirstDivergence(got))
+		}
+		for i := range original {
+			a, b := original[i], replayed[i]
+			if a.Position != b.Position || a.Velocity != b.Velocity || a.Orientation != b.Orientation || a.AngularRate != b.AngularRate {
+				t.Fatalf("Replay diverged at step %d: %+v against %+v", i, b.Position, a.Position)
+			}
+		}
+	})
+
+	t.Run("Modified Aircraft", func(t *testing.T) {
+		config := loadP51DConfig(t)
+		config.Metrics.WingArea.Value *= 1.1
+		replayed := flySession(t, config, nil, nil, NewControlReplayer(samples, ReplayHold))
+		assertEqual(t, len(replayed), len(original))
+		last, want := replayed[len(replayed)-1], original[len(original)-1]
+		if last.Position == want.Position {
+			t.Error("The larger wing should fly a different trajectory")
+		}
+	})
+
+	t.Run("Policies", func(t *testing.T) {
+		samples := []ControlSample{
+			{Time: 1, Controls: ControlInputs{Elevator: 0.2, Gear: true}},
+			{Time: 3, Controls: ControlInputs{Elevator: -0.2}},
+		}
+		hold := NewControlReplayer(samples, ReplayHold)
+		if _, ok := hold.At(0.5); ok {
+			t.Error("There are no inputs before the first sample")
+		}
+		controls, _ := hold.At(2)
+		assertEqual(t, controls, samples[0].Controls)
+
+		interpolate := NewControlReplayer(samples, ReplayInterpolate)
+		controls, _ = interpolate.At(2.5)
+		assertApproxEqual(t, controls.Elevator, -0.1, 1e-12)
+		assertEqual(t, controls.Gear, true)
+		controls, _ = interpolate.At(4)
+		assertEqual(t, controls, samples[1].Controls)
+
+		// Next reports only changes
+		_, changed := hold.Next(1)
+		assertEqual(t, changed, true)
+		_, changed = hold.Next(2)
+		assertEqual(t, changed, false)
+		_, changed = hold.Next(3)
+		assertEqual(t, changed, true)
+	})
+
+	t.Run("Recorder", func(t *testing.T) {
+		recorder := NewControlRecorder()
+		assertEqual(t, recorder.Record(0, ControlInputs{Throttle: 0.5}), true)
+		assertEqual(t, recorder.Record(0.5, ControlInputs{Throttle: 0.5}), false)
+		assertEqual(t, recorder.Record(1, ControlInputs{Throttle: 0.6}), true)
+		assertEqual(t, recorder.Record(1, ControlInputs{Throttle: 0.7}), true)
+		assertEqual(t, len(recorder.Samples), 2)
+		assertEqual(t, recorder.Samples[1].Controls.Throttle, 0.7)
+
+		_, err := LoadControlRecording(strings.NewReader("{\"t\":2,\"controls\":{}}\n{\"t\":1,\"controls\":{}}\n"))
+		if err == nil || !strings.Contains(err.Error(), "line 2") {
+			t.Errorf("Times out of order should be rejected at line 2, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,180 @@
+// Control Surface Mass
+// The reaction on the airframe of accelerating the flight control surfaces
+// about their hinges, for studies of large or quickly driven surfaces
+// where their mass is not negligible
+
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// SurfaceMass is one control surface, lumped at its CG a chordwise
+// distance aft of its hinge line. A positive deflection turns it about
+// Axis by the right-hand rule, so with Axis along +Y it moves the trailing
+// edge down, as a positive elevator does.
+type SurfaceMass struct {
+	Name     string
+	Position string    // Deflection property (rad), such as fcs/elevator-pos-rad
+	Rate     string    // Deflection rate property (rad/s) reported by an actuator; the deflection is differentiated when empty or unset
+	Mass     float64   // kg
+	Hinge    *Location // A point on the hinge line, structural
+	Axis     Vector3   // Hinge line in body axes
+	CGOffset float64   // Distance of the surface's CG aft of the hinge line (m)
+
+	motion surfaceMotion
+}
+
+// surfaceMotion is a surface's deflection, rate and acceleration at the
+// start of the last step
+type surfaceMotion struct {
+	time, position, rate, acceleration float64
+	started                            bool
+}
+
+// ControlSurfaceMass models the mass of the control surfaces: it adds them
+// to the aircraft's mass and the moments of driving them to its moments.
+// The deflection rate and acceleration are measured once a step and held
+// over it, as the surfaces are.
+type ControlSurfaceMass struct {
+	Surfaces []*SurfaceMass
+}
+
+// NewControlSurfaceMass creates a model of the given surfaces, each of
+// which needs a name, a position property, a positive mass, a hinge
+// location and a hinge axis
+func NewControlSurfaceMass(surfaces ...*SurfaceMass) (*ControlSurfaceMass, error) {
+	for _, s := range surfaces {
+		switch {
+		case s.Name == "" || s.Position == "":
+			return nil, fmt.Errorf("control surface mass needs a name and a position property")
+		case s.Mass <= 0:
+			return nil, fmt.Errorf("control surface %s has no mass", s.Name)
+		case s.Hinge == nil || s.Axis.Magnitude() == 0:
+			return nil, fmt.Errorf("control surface %s has no hinge line", s.Name)
+		}
+	}
+	return &ControlSurfaceMass{Surfaces: surfaces}, nil
+}
+
+// TotalMass returns the mass of the surfaces (kg)
+func (m *ControlSurfaceMass) TotalMass() float64 {
+	if m == nil {
+		return 0
+	}
+	var total float64
+	for _, s := range m.Surfaces {
+		total += s.Mass
+	}
+	return total
+}
+
+// clone returns a model of copies of the surfaces, with their motion
+func (m *ControlSurfaceMass) clone() *ControlSurfaceMass {
+	c := &ControlSurfaceMass{Surfaces: make([]*SurfaceMass, len(m.Surfaces))}
+	for i, s := range m.Surfaces {
+		copied := *s
+		c.Surfaces[i] = &copied
+	}
+	return c
+}
+
+// SetControlSurfaceMass models the control surfaces' mass, or stops when
+// nil; their mass is added to the aircraft's in place of any earlier
+// model's
+func (calc *ForcesMomentsCalculator) SetControlSurfaceMass(m *ControlSurfaceMass) {
+	calc.Mass += m.TotalMass() - calc.SurfaceMass.TotalMass()
+	calc.SurfaceMass = m
+}
+
+// holdSurfaceMotion stops the surface motion being measured, and the
+// dynamic stall advancing, until the returned function is called, for
+// evaluations within or outside a step
+func (calc *ForcesMomentsCalculator) holdSurfaceMotion() func() {
+	held := calc.surfaceMotionHeld
+	calc.surfaceMotionHeld = true
+	return func() { calc.surfaceMotionHeld = held }
+}
+
+// update measures the surfaces' motion at a later time than the last,
+// from a reported rate or else the change in deflection
+func (m *ControlSurfaceMass) update(time float64, properties map[string]float64) {
+	for _, s := range m.Surfaces {
+		motion := &s.motion
+		position := properties[s.Position]
+		if !motion.started {
+			*motion = surfaceMotion{time: time, position: position, started: true}
+			continue
+		}
+		dt := time - motion.time
+		if dt <= 0 {
+			continue
+		}
+		rate := (position - motion.position) / dt
+		if reported, ok := properties[s.Rate]; ok && s.Rate != "" {
+			rate = reported
+		}
+		motion.acceleration = (rate - motion.rate) / dt
+		motion.time, motion.position, motion.rate = time, position, rate
+	}
+}
+
+// moment returns the moment on the airframe about the CG (N·m) of driving
+// the surfaces, with their CGs accelerating about their hinges at the
+// measured rates, publishing each surface's rate and acceleration.
+// Driving a CG at r about the aircraft's CG with acceleration a takes the
+// moment m·r × a, so the airframe feels its opposite.
+func (m *ControlSurfaceMass) moment(cg Vector3, properties map[string]float64) Vector3 {
+	var total Vector3
+	for _, s := range m.Surfaces {
+		axis := s.Axis.Normalize()
+
+		// Aft, square to the hinge line, turned through the deflection
+		aft := Vector3{X: -1}
+		aft = aft.Sub(axis.Scale(aft.Dot(axis))).Normalize()
+		delta := properties[s.Position]
+		arm := aft.Scale(math.Cos(delta)).Add(axis.Cross(aft).Scale(math.Sin(delta)))
+
+		r := StructuralToBody(s.Hinge, cg).Add(arm.Scale(s.CGOffset))
+		rate, acceleration := s.motion.rate, s.motion.acceleration
+		a := axis.Cross(arm).Scale(acceleration * s.CGOffset).Sub(arm.Scale(rate * rate * s.CGOffset))
+		total = total.Sub(r.Cross(a).Scale(s.Mass))
+
+		properties["fcs/"+s.Name+"-rate-rad_sec"] = rate
+		properties["fcs/"+s.Name+"-accel-rad_sec2"] = acceleration
+	}
+	return total
+}
+
+// addSurfaceMassMoment adds the reaction to driving the control surfaces
+// to the moments, measuring their motion unless it is held
+func (calc *ForcesMomentsCalculator) addSurfaceMassMoment(state *AircraftState, properties map[string]float64, components *ForceMomentComponents) {
+	if calc.SurfaceMass == nil {
+		return
+	}
+	if !calc.surfaceMotionHeld {
+		calc.SurfaceMass.update(state.Time, properties)
+	}
+	moment := calc.SurfaceMass.moment(calc.CG, properties)
+	components.SurfaceMass.Moment = moment
+	components.Moments.Roll += moment.X
+	components.Moments.Pitch += moment.Y
+	components.Moments.Yaw += moment.Z
+}
+
+// massProperties returns the weight (lbs) and structural CG position (m)
+// of the configuration and any modelled surfaces
+func (calc *ForcesMomentsCalculator) massProperties() (weight float64, cg Vector3, ok bool) {
+	weight, cg, ok = configMassProperties(calc.Config)
+	if !ok || calc.SurfaceMass == nil {
+		return weight, cg, ok
+	}
+	moment := cg.Scale(weight)
+	for _, s := range calc.SurfaceMass.Surfaces {
+		surface := s.Mass * KG_TO_LB
+		weight += surface
+		moment = moment.Add(StructuralPosition(s.Hinge).Add(Vector3{X: s.CGOffset}).Scale(surface))
+	}
+	return weight, moment.Scale(1 / weight), true
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestControlSurfaceMass(t *testing.T) {
+	elevator := func() *ControlSurfaceMass {
+		t.Helper()
+		m, err := NewControlSurfaceMass(&SurfaceMass{
+			Name:     "elevator",
+			Position: "fcs/elevator-pos-rad",
+			Mass:     20,
+			Hinge:    &Location{Unit: "IN", X: 278, Z: 10},
+			Axis:     Vector3{Y: 1},
+			CGOffset: 0.15,
+		})
+		if err != nil {
+			t.Fatalf("NewControlSurfaceMass: %v", err)
+		}
+		return m
+	}
+
+	t.Run("Elevator Reversal", func(t *testing.T) {
+		// The elevator runs at 60°/s to 15° and straight back, sampled every
+		// 10 ms; the pitch moment with the surface mass modelled differs
+		// from that without by a spike at the reversal
+		const dt, rate = 0.01, 60 * DEG_TO_RAD
+		without := NewForcesMomentsCalculator(loadP51DConfig(t))
+		with := NewForcesMomentsCalculator(loadP51DConfig(t))
+		with.SetControlSurfaceMass(elevator())
+		state := cruiseState(1500, 100, 2*DEG_TO_RAD)
+
+		var spike, steady float64
+		for i := 0; i <= 50; i++ {
+			state.Time = float64(i) * dt
+			state.ControlSurfaces.Elevator = rate * math.Min(state.Time, 0.5-state.Time)
+			a, err := without.CalculateForcesMoments(state)
+			if err != nil {
+				t.Fatalf("CalculateForcesMoments: %v", err)
+			}
+			b, err := with.CalculateForcesMoments(state)
+			if err != nil {
+				t.Fatalf("CalculateForcesMoments: %v", err)
+			}
+			difference := math.Abs(b.TotalMoment.Y - a.TotalMoment.Y)
+			assertApproxEqual(t, difference, math.Abs(b.SurfaceMass.Moment.Y), 1e-6)
+			if i == 26 {
+				spike = difference
+			} else if i > 2 {
+				steady = math.Max(steady, difference)
+			}
+		}
+
+		// Reversing 2·60°/s in a step drives the CG, 0.15 m aft of a hinge
+		// about 4.6 m aft of the aircraft's, at 2·rate/dt·0.15 m/s²
+		expected := 20 * 4.6 * 2 * rate / dt * 0.15
+		assertApproxEqual(t, spike, expected, 0.1*expected)
+		if steady > 0.05*spike {
+			t.Errorf("Pitch moment difference away from the reversal %.1f N·m, want well below the spike %.1f N·m", steady, spike)
+		}
+		assertApproxEqual(t, with.Properties.Get("fcs/elevator-rate-rad_sec"), -rate, 1e-9)
+	})
+
+	t.Run("Held Outside Steps", func(t *testing.T) {
+		calc := NewForcesMomentsCalculator(loadP51DConfig(t))
+		calc.SetControlSurfaceMass(elevator())
+		state := cruiseState(1500, 100, 2*DEG_TO_RAD)
+		if _, err := calc.CalculateForcesMoments(state); err != nil {
+			t.Fatalf("CalculateForcesMoments: %v", err)
+		}
+
+		// An integrator stage later in the step sees the surface as it was
+		// at the step's start
+		stage := state.Copy()
+		stage.Time += 0.005
+		stage.ControlSurfaces.Elevator = 0.1
+		if _, _, err := calc.Derivatives(stage); err != nil {
+			t.Fatalf("Derivatives: %v", err)
+		}
+		assertEqual(t, calc.SurfaceMass.Surfaces[0].motion, surfaceMotion{time: state.Time, started: true})
+	})
+
+	t.Run("Mass Properties", func(t *testing.T) {
+		calc := NewForcesMomentsCalculator(loadP51DConfig(t))
+		mass := calc.Mass
+		weight, cg, _ := calc.massProperties()
+
+		calc.SetControlSurfaceMass(elevator())
+		assertApproxEqual(t, calc.Mass, mass+20, 1e-9)
+		withWeight, withCG, ok := calc.massProperties()
+		assertEqual(t, ok, true)
+		assertApproxEqual(t, withWeight, weight+20*KG_TO_LB, 1e-9)
+		if withCG.X <= cg.X {
+			t.Errorf("CG station %.4f m with the elevator, want aft of %.4f m", withCG.X, cg.X)
+		}
+
+		// Replacing and removing the model leave the surfaces counted once
+		calc.SetControlSurfaceMass(elevator())
+		assertApproxEqual(t, calc.Mass, mass+20, 1e-9)
+		calc.SetControlSurfaceMass(nil)
+		assertApproxEqual(t, calc.Mass, mass, 1e-9)
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		for _, s := range []*SurfaceMass{
+			{Position: "fcs/elevator-pos-rad", Mass: 1, Hinge: &Location{}, Axis: Vector3{Y: 1}},
+			{Name: "elevator", Position: "fcs/elevator-pos-rad", Hinge: &Location{}, Axis: Vector3{Y: 1}},
+			{Name: "elevator", Position: "fcs/elevator-pos-rad", Mass: 1, Axis: Vector3{Y: 1}},
+		} {
+			if _, err := NewControlSurfaceMass(s); err == nil {
+				t.Errorf("%+v should be refused", s)
+			}
+		}
+	})
+}
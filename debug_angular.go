@@ -18,8 +18,7 @@ func DebugAngularInstability() {
 	state.Position.Z = -3000.0
 	state.Velocity = Vector3{X: 100.0, Y: 0, Z: 0}
 	state.Controls.Throttle = 0.7
-	state.UpdateAtmosphere()
-	state.UpdateDerivedParameters()
+	state.UpdateFlightConditions()
 	
 	fmt.Printf("Starting conditions:\n")
 	fmt.Printf("   Angular Rate: (%.6f, %.6f, %.6f)\n", 
@@ -174,8 +173,7 @@ func DebugMomentCalculation() {
 	state.Beta = 0.0
 	state.AngularRate = Vector3{X: 0.01, Y: 2.0, Z: 0.01}  // High pitch rate
 	state.Controls.Elevator = 0.1
-	state.UpdateAtmosphere()
-	state.UpdateDerivedParameters()
+	state.UpdateFlightConditions()
 	
 	fmt.Printf("Test state:\n")
 	fmt.Printf("   Alpha: %.1f°\n", state.Alpha*RAD_TO_DEG)
@@ -184,7 +182,7 @@ func DebugMomentCalculation() {
 	fmt.Printf("   Controls: Elevator=%.3f\n", state.Controls.Elevator)
 	
 	// Calculate dynamic pressure
-	q := 0.5 * state.Density * state.TrueAirspeed * state.TrueAirspeed
+	q := state.DynamicPressure
 	qSc := q * calc.WingArea * calc.Chord
 	
 	fmt.Printf("\nMoment calculation details:\n")
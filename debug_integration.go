@@ -18,8 +18,7 @@ func DebugIntegrationStep() {
 	state.Altitude = 3000.0
 	state.Velocity = Vector3{X: 100.0, Y: 0, Z: 0}
 	state.Controls.Throttle = 0.7
-	state.UpdateAtmosphere()
-	state.UpdateDerivedParameters()
+	state.UpdateFlightConditions()
 	
 	fmt.Printf("Initial State:\n")
 	printStateDebug(state, "INITIAL")
@@ -112,6 +111,8 @@ func printForcesDebug(components *ForceMomentComponents, label string) {
 	fmt.Printf("[%s] Forces Debug:\n", label)
 	fmt.Printf("   Aerodynamic: Lift=%.6f, Drag=%.6f, Side=%.6f\n",
 		components.Aerodynamic.Lift, components.Aerodynamic.Drag, components.Aerodynamic.Side)
+	fmt.Printf("   Wind Axes: Lift=%.6f, Drag=%.6f, Side=%.6f\n",
+		components.Wind.Lift, components.Wind.Drag, components.Wind.Side)
 	fmt.Printf("   Propulsion: Thrust=%.6f, Torque=%.6f\n",
 		components.Propulsion.Thrust, components.Propulsion.Torque)
 	fmt.Printf("   Gravity: (%.6f, %.6f, %.6f)\n",
@@ -314,8 +315,7 @@ func DebugMultipleSteps() {
 	state.Altitude = 3000.0
 	state.Velocity = Vector3{X: 100.0, Y: 0, Z: 0}
 	state.Controls.Throttle = 0.7
-	state.UpdateAtmosphere()
-	state.UpdateDerivedParameters()
+	state.UpdateFlightConditions()
 	
 	dt := 0.01
 	maxSteps := 10
@@ -0,0 +1,134 @@
+// Decelerators
+// Parachutes and drogues for drop tests: a drag area opening with a lag
+// once deployed, through any reefed stages, pulling on its riser along the
+// relative wind
+
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// Decelerator is a parachute, on the airframe through ExternalForce or on
+// a released store. It does nothing until deployed.
+type Decelerator struct {
+	Name         string
+	CdS          float64   // Drag area fully open (m²)
+	TimeConstant float64   // Opening lag (s); the canopy opens at once when 0
+	Attachment   *Location // Riser attachment, structural; the CG when nil
+
+	// Reefed openings after deployment, by increasing time. The canopy
+	// opens towards each stage's fraction of CdS from its time on, and is
+	// closed before the first; without stages it opens fully at once.
+	Stages []ReefingStage
+
+	deployed   bool
+	deployedAt float64
+}
+
+// ReefingStage is an opening of a reefed canopy, from a time after
+// deployment; the last stage is usually the disreef to 1
+type ReefingStage struct {
+	After    float64 // s after deployment
+	Fraction float64 // Of the full drag area
+}
+
+// Deploy deploys the decelerator at simulation time t. A deployed
+// decelerator stays deployed; deploying it again does nothing.
+func (d *Decelerator) Deploy(t float64) {
+	if d.deployed {
+		return
+	}
+	d.deployed, d.deployedAt = true, t
+}
+
+// Deployed reports whether the decelerator has been deployed
+func (d *Decelerator) Deployed() bool {
+	return d.deployed
+}
+
+// OpenFraction returns the fraction of CdS acting at simulation time t:
+// each stage's opening approached with the lag of TimeConstant from the
+// opening reached when it began
+func (d *Decelerator) OpenFraction(t float64) float64 {
+	if !d.deployed || t < d.deployedAt {
+		return 0
+	}
+	elapsed := t - d.deployedAt
+	stages := d.Stages
+	if len(stages) == 0 {
+		stages = []ReefingStage{{After: 0, Fraction: 1}}
+	}
+
+	fraction := 0.0
+	for i, stage := range stages {
+		if elapsed < stage.After {
+			break
+		}
+		end := elapsed
+		if i+1 < len(stages) && stages[i+1].After < elapsed {
+			end = stages[i+1].After
+		}
+		if d.TimeConstant <= 0 {
+			fraction = stage.Fraction
+		} else {
+			fraction = stage.Fraction + (fraction-stage.Fraction)*math.Exp(-(end-stage.After)/d.TimeConstant)
+		}
+	}
+	return fraction
+}
+
+// Drag returns the decelerator's drag at simulation time t on a riser
+// point moving at velocity through air of density (kg/m³): opposite the
+// velocity and in its frame (N)
+func (d *Decelerator) Drag(velocity Vector3, density, t float64) Vector3 {
+	fraction := d.OpenFraction(t)
+	speed := velocity.Magnitude()
+	if fraction == 0 || speed == 0 {
+		return Vector3{}
+	}
+	return velocity.Scale(-0.5 * density * speed * d.CdS * fraction)
+}
+
+// Validate checks the decelerator's drag area, lag and stages
+func (d *Decelerator) Validate() error {
+	if !(d.CdS > 0) {
+		return fmt.Errorf("decelerator %q: drag area %g m² must be positive", d.Name, d.CdS)
+	}
+	if d.TimeConstant < 0 {
+		return fmt.Errorf("decelerator %q: negative opening time constant", d.Name)
+	}
+	for i, stage := range d.Stages {
+		if stage.Fraction < 0 || stage.Fraction > 1 {
+			return fmt.Errorf("decelerator %q: stage %d opens to %g of the drag area", d.Name, i, stage.Fraction)
+		}
+		if stage.After < 0 || (i > 0 && stage.After <= d.Stages[i-1].After) {
+			return fmt.Errorf("decelerator %q: stage %d at %g s is out of order", d.Name, i, stage.After)
+		}
+	}
+	return nil
+}
+
+// ExternalForce returns the decelerator as an external force on the
+// airframe, for an ExternalForces registry: its drag on the riser point,
+// taken from the point's own velocity through the air. Releasing the
+// force through the registry cuts the parachute away.
+func (d *Decelerator) ExternalForce() *ExternalForce {
+	return &ExternalForce{
+		Name:       d.Name,
+		Structural: d.Attachment,
+		Vector: func(state *AircraftState, location Vector3) Vector3 {
+			return d.Drag(state.VelocityAt(location), state.Density, state.Time)
+		},
+	}
+}
+
+// AttachDecelerator registers a decelerator as an external force of the
+// airframe
+func (ef *ExternalForces) AttachDecelerator(d *Decelerator) error {
+	if err := d.Validate(); err != nil {
+		return err
+	}
+	return ef.Add(d.ExternalForce())
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// pointMassDescent flies the simplified model's forces, its external
+// forces among them, as a point mass with the nose held along the
+// relative wind, as an aircraft hangs under a parachute on a tail riser.
+// The six-degree-of-freedom models do not hold an attitude under a canopy,
+// the simplified one diverging in pitch, so the descent is checked on
+// this instead.
+type pointMassDescent struct {
+	*SimplifiedFlightDynamicsEngine
+}
+
+func (e *pointMassDescent) Step(state *AircraftState, dt float64) (*AircraftState, error) {
+	v := state.GroundVelocity()
+	aligned := state.Copy()
+	aligned.Orientation = NewQuaternionFromEuler(0, math.Atan2(-v.Z, math.Hypot(v.X, v.Y)), math.Atan2(v.Y, v.X))
+	aligned.Velocity = Vector3{X: v.Magnitude()}
+	aligned.AngularRate = Vector3{}
+	aligned.UpdateFlightConditions()
+	components, err := e.Calculator.CalculateSimplifiedForces(aligned)
+	if err != nil {
+		return nil, err
+	}
+
+	acceleration := aligned.Orientation.RotateVector(components.TotalForce.Scale(1 / e.Calculator.Mass))
+	next := aligned.Copy()
+	next.Time = state.Time + dt
+	ned := v.Add(acceleration.Scale(dt))
+	next.Position = state.Position.Add(ned.Scale(dt))
+	next.Altitude = -next.Position.Z
+	next.Velocity = next.Orientation.RotateVectorInverse(ned)
+	next.UpdateFlightConditions()
+	return next, nil
+}
+
+func TestDecelerator(t *testing.T) {
+	t.Run("Opening", func(t *testing.T) {
+		chute := &Decelerator{Name: "chute", CdS: 50, TimeConstant: 0.5}
+		assertEqual(t, chute.OpenFraction(10), 0.0)
+		assertEqual(t, chute.Drag(Vector3{X: 100}, 1.2, 10), Vector3{})
+
+		chute.Deploy(10)
+		chute.Deploy(12) // Already out
+		assertEqual(t, chute.Deployed(), true)
+		assertEqual(t, chute.OpenFraction(9), 0.0)
+		assertEqual(t, chute.OpenFraction(10), 0.0)
+		assertApproxEqual(t, chute.OpenFraction(10.5), 1-math.Exp(-1), 1e-12)
+		assertApproxEqual(t, chute.OpenFraction(20), 1, 1e-8)
+
+		// Opposite the velocity, ½ρV²·CdS when open
+		drag := chute.Drag(Vector3{X: 60, Z: 80}, 1.2, 30)
+		assertApproxEqual(t, drag.Magnitude(), 0.5*1.2*100*100*50, 1e-6)
+		assertApproxEqual(t, drag.X/drag.Z, 60/80.0, 1e-12)
+		if drag.X >= 0 {
+			t.Errorf("The drag should oppose the motion: %+v", drag)
+		}
+	})
+
+	t.Run("Reefing Stages", func(t *testing.T) {
+		chute := &Decelerator{Name: "reefed", CdS: 100, TimeConstant: 0.2, Stages: []ReefingStage{
+			{After: 0.5, Fraction: 0.3},
+			{After: 3, Fraction: 1},
+		}}
+		chute.Deploy(0)
+		assertEqual(t, chute.OpenFraction(0.4), 0.0)
+		assertApproxEqual(t, chute.OpenFraction(0.7), 0.3*(1-math.Exp(-1)), 1e-12)
+		assertApproxEqual(t, chute.OpenFraction(2.9), 0.3, 1e-5)
+
+		// The disreef opens from where the reefed canopy was
+		reefed := 0.3 * (1 - math.Exp(-2.5/0.2))
+		assertApproxEqual(t, chute.OpenFraction(3.2), 1+(reefed-1)*math.Exp(-1), 1e-12)
+		assertApproxEqual(t, chute.OpenFraction(10), 1, 1e-9)
+
+		// Stepping up without jumps
+		previous := 0.0
+		for t0 := 0.0; t0 < 6; t0 += 0.01 {
+			f := chute.OpenFraction(t0)
+			if f < previous-1e-12 || f-previous > 0.1 {
+				t.Fatalf("The opening jumps from %.4f to %.4f at %.2f s", previous, f, t0)
+			}
+			previous = f
+		}
+
+		instant := &Decelerator{Name: "instant", CdS: 10, Stages: chute.Stages}
+		instant.Deploy(0)
+		assertEqual(t, instant.OpenFraction(1), 0.3)
+		assertEqual(t, instant.OpenFraction(3), 1.0)
+	})
+
+	t.Run("Registry", func(t *testing.T) {
+		external := NewExternalForces(&Location{X: 100, Unit: "IN"})
+		for _, bad := range []*Decelerator{
+			{Name: "no area"},
+			{Name: "lag", CdS: 10, TimeConstant: -1},
+			{Name: "overopen", CdS: 10, Stages: []ReefingStage{{After: 0, Fraction: 1.5}}},
+			{Name: "unordered", CdS: 10, Stages: []ReefingStage{{After: 2, Fraction: 0.5}, {After: 1, Fraction: 1}}},
+		} {
+			if err := external.AttachDecelerator(bad); err == nil {
+				t.Errorf("%s: expected an error", bad.Name)
+			}
+		}
+
+		// A tail riser 5 m aft of the CG: the drag pulls the tail back,
+		// and a sideslip yaws the nose back into the wind
+		chute := &Decelerator{Name: "spin chute", CdS: 10, Attachment: &Location{X: 100 + 5/0.0254, Unit: "IN"}}
+		if err := external.AttachDecelerator(chute); err != nil {
+			t.Fatalf("AttachDecelerator: %v", err)
+		}
+		state := NewAircraftState()
+		state.Velocity = Vector3{X: 50, Y: 5}
+		state.UpdateFlightConditions()
+		assertEqual(t, len(external.Reactions(state, nil)), 1)
+		assertEqual(t, external.Reactions(state, nil)[0].Force, Vector3{})
+
+		chute.Deploy(state.Time)
+		reaction := external.Reactions(state, nil)[0]
+		assertApproxEqual(t, reaction.Force.Magnitude(), state.DynamicPressure*10, 1e-6)
+		if reaction.Force.X >= 0 || reaction.Moment.Z <= 0 {
+			t.Errorf("Expected drag aft and a yaw towards the sideslip: %+v", reaction)
+		}
+
+		// Cut away
+		external.SetEnabled("spin chute", false)
+		assertEqual(t, len(external.Reactions(state, nil)), 0)
+	})
+
+	t.Run("Descent Under Canopy", func(t *testing.T) {
+		engine := &pointMassDescent{NewSimplifiedFlightDynamicsEngine(NewRungeKutta4Integrator())}
+		calc := engine.Calculator
+		calc.External = NewExternalForces(nil)
+		chute := &Decelerator{Name: "recovery chute", CdS: 150, TimeConstant: 1}
+		if err := calc.External.AttachDecelerator(chute); err != nil {
+			t.Fatalf("AttachDecelerator: %v", err)
+		}
+
+		state := trimLevelFlight(t, engine.SimplifiedFlightDynamicsEngine, 3000, 100)
+		state.Controls.Throttle = 0
+		chute.Deploy(state.Time)
+		start := state.Time
+		var err error
+		for state.Time-start < 40 {
+			if state, err = engine.Step(state, 0.01); err != nil {
+				t.Fatalf("Step: %v", err)
+			}
+		}
+
+		v := state.GroundVelocity()
+		expected := math.Sqrt(2 * calc.Mass * StandardGravity / (state.Density * chute.CdS))
+		t.Logf("Descending at %.2f m/s, drifting at %.2f m/s; expected %.2f m/s", v.Z, math.Hypot(v.X, v.Y), expected)
+		if math.Abs(v.Z-expected) > 0.05*expected {
+			t.Errorf("The quasi-steady descent rate %.2f m/s should be within 5%% of %.2f m/s", v.Z, expected)
+		}
+		if math.Hypot(v.X, v.Y) > 0.2*v.Z {
+			t.Errorf("Under the canopy the aircraft should come down nearly vertically: %+v", v)
+		}
+	})
+}
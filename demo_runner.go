@@ -0,0 +1,418 @@
+// Demo Runner
+// The end-to-end flight dynamics demonstration as data: a sequence of
+// scenarios flown one after another by the ScenarioRunner, reported to a
+// writer at a chosen verbosity, optionally recorded to CSV, and summarized
+// as JSON for smoke tests
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DemoScenario is one phase of the demonstration: a set of pilot inputs
+// held for a time, flown on from where the previous phase left off
+type DemoScenario struct {
+	Name        string
+	Description string
+	Duration    float64 // s
+	Controls    ControlInputs
+}
+
+// DemoScenarios are the demonstration's phases, in order
+var DemoScenarios = []DemoScenario{
+	{Name: "Level Flight", Description: "Steady level cruise", Duration: 5,
+		Controls: ControlInputs{Throttle: 0.7}},
+	{Name: "Climb", Description: "Full power climb", Duration: 8,
+		Controls: ControlInputs{Throttle: 1.0, Elevator: 0.15}},
+	{Name: "Banking Turn", Description: "Right banking turn", Duration: 6,
+		Controls: ControlInputs{Throttle: 0.8, Elevator: 0.05, Aileron: 0.3, Rudder: 0.1}},
+	{Name: "Descent", Description: "Power-reduced descent", Duration: 5,
+		Controls: ControlInputs{Throttle: 0.4, Elevator: -0.1}},
+}
+
+// DemoVerbosity is how much the demonstration prints besides its summary
+type DemoVerbosity int
+
+const (
+	DemoQuiet   DemoVerbosity = iota // The JSON summary only
+	DemoNormal                       // Each scenario's results
+	DemoVerbose                      // The aircraft, atmosphere, forces and configuration drag too
+)
+
+// DemoRunner flies the demonstration. The zero value is not ready; use
+// NewDemoRunner.
+type DemoRunner struct {
+	Out       io.Writer
+	Verbosity DemoVerbosity
+	Scenarios []DemoScenario
+
+	// Aircraft is a configuration file or library name flown by the
+	// configuration-driven engine; empty flies the simplified model
+	Aircraft string
+
+	// Initial is the state the first scenario starts from; nil starts
+	// untrimmed in level cruise at 3000 m and 100 m/s
+	Initial *AircraftState
+
+	Dt float64 // Integration step (s)
+
+	// RecordDir, when set, receives a CSV of each scenario, written at
+	// RecordRate in simulation time whatever the step, so recordings at
+	// different steps line up row for row
+	RecordDir  string
+	RecordRate int // Hz
+}
+
+// NewDemoRunner creates a runner of every scenario on the simplified
+// model at 100 Hz, reporting each scenario to out
+func NewDemoRunner(out io.Writer) *DemoRunner {
+	return &DemoRunner{
+		Out:        out,
+		Verbosity:  DemoNormal,
+		Scenarios:  DemoScenarios,
+		Dt:         0.01,
+		RecordRate: 20,
+	}
+}
+
+// Select restricts the runner to the named scenarios, matched without
+// regard to case, in the demonstration's order
+func (d *DemoRunner) Select(names ...string) error {
+	wanted := make(map[string]bool)
+	for _, name := range names {
+		wanted[strings.ToLower(strings.TrimSpace(name))] = true
+	}
+	var selected []DemoScenario
+	for _, s := range DemoScenarios {
+		if wanted[strings.ToLower(s.Name)] {
+			selected = append(selected, s)
+		}
+	}
+	if len(selected) < len(wanted) {
+		return fmt.Errorf("unknown demo scenarios in %q; the scenarios are %s", names, demoScenarioNames())
+	}
+	d.Scenarios = selected
+	return nil
+}
+
+// demoScenarioNames lists the scenarios' names
+func demoScenarioNames() string {
+	names := make([]string, len(DemoScenarios))
+	for i, s := range DemoScenarios {
+		names[i] = s.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// DemoSummary is the machine-readable result of a demonstration
+type DemoSummary struct {
+	Aircraft  string               `json:"aircraft"`
+	Engine    string               `json:"engine"`
+	Dt        float64              `json:"dt"`
+	Scenarios []DemoScenarioResult `json:"scenarios"`
+
+	FlightTime    float64 `json:"flight_time_s"`
+	MaxLoadFactor float64 `json:"max_load_factor_g"`
+	MaxClimbRate  float64 `json:"max_climb_rate_mps"`
+	MaxSpeed      float64 `json:"max_speed_mps"`
+	MaxAltitude   float64 `json:"max_altitude_m"`
+	FuelBurned    float64 `json:"fuel_burned_kg"`
+}
+
+// DemoScenarioResult is how one scenario went
+type DemoScenarioResult struct {
+	Name      string `json:"name"`
+	Condition string `json:"condition"` // The termination condition, max-sim-time when flown through
+	Steps     int    `json:"steps"`
+
+	AltitudeStart float64 `json:"altitude_start_m"`
+	AltitudeEnd   float64 `json:"altitude_end_m"`
+	ClimbRate     float64 `json:"climb_rate_mps"`
+	SpeedStart    float64 `json:"speed_start_mps"`
+	SpeedEnd      float64 `json:"speed_end_mps"`
+	Acceleration  float64 `json:"acceleration_mps2"`
+	HeadingChange float64 `json:"heading_change_deg"`
+	TurnRate      float64 `json:"turn_rate_dps"`
+	Roll          float64 `json:"roll_deg"`
+	Pitch         float64 `json:"pitch_deg"`
+
+	Lift   float64 `json:"lift_n"`
+	Drag   float64 `json:"drag_n"`
+	Thrust float64 `json:"thrust_n"`
+
+	Record string `json:"record,omitempty"` // CSV file, when recorded
+	Error  string `json:"error,omitempty"`
+}
+
+// demoAircraft is what the runner needs of an engine
+type demoAircraft struct {
+	name       string
+	engine     MonteCarloEngine
+	statistics *FlightStatistics
+	forces     func(state *AircraftState) (*ForceMomentComponents, error)
+	mass       float64 // kg
+	simplified *SimplifiedForcesMomentsCalculator
+}
+
+// aircraft builds the engine the runner flies
+func (d *DemoRunner) aircraft() (*demoAircraft, error) {
+	if d.Aircraft == "" {
+		engine := NewSimplifiedFlightDynamicsEngine(NewRungeKutta4Integrator())
+		return &demoAircraft{
+			name:       "P-51D (simplified)",
+			engine:     engine,
+			statistics: engine.Statistics,
+			forces:     engine.Calculator.CalculateSimplifiedForces,
+			mass:       engine.Calculator.Mass,
+			simplified: engine.Calculator,
+		}, nil
+	}
+
+	path, err := (&aircraftResolver{root: DefaultAircraftDir}).path(d.Aircraft)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	config, err := ParseJSBSimConfig(file, SourceFile(path))
+	if err != nil {
+		return nil, err
+	}
+	engine := NewFlightDynamicsEngine(config, NewRungeKutta4Integrator())
+	return &demoAircraft{
+		name:       config.Name,
+		engine:     engine,
+		statistics: engine.Statistics,
+		forces:     engine.Calculator.CalculateForcesMoments,
+		mass:       engine.Calculator.Mass,
+	}, nil
+}
+
+// Run flies the selected scenarios in turn from the initial state,
+// reporting each, and ends by writing the summary to Out as JSON.
+// A scenario that ends early, on a failed step or a non-finite state,
+// stops the demonstration there; the summary is written all the same.
+func (d *DemoRunner) Run() (*DemoSummary, error) {
+	if d.Dt <= 0 {
+		return nil, fmt.Errorf("demo time step %g s is not positive", d.Dt)
+	}
+	aircraft, err := d.aircraft()
+	if err != nil {
+		return nil, err
+	}
+	summary := &DemoSummary{Aircraft: aircraft.name, Engine: "simplified", Dt: d.Dt}
+	if aircraft.simplified == nil {
+		summary.Engine = "configuration"
+	}
+
+	state := d.Initial
+	if state == nil {
+		state = NewAircraftState()
+		state.Altitude = 3000
+		state.Position = Vector3{Z: -state.Altitude}
+		state.Velocity = Vector3{X: 100}
+		state.Controls.Throttle = 0.8
+		state.UpdateFlightConditions()
+	}
+	d.printHeader(aircraft, state)
+
+	for _, scenario := range d.Scenarios {
+		result, final, err := d.fly(aircraft, scenario, state)
+		if err != nil {
+			return nil, err
+		}
+		summary.Scenarios = append(summary.Scenarios, *result)
+		d.printResult(scenario, result)
+		if result.Error != "" {
+			break
+		}
+		state = final
+	}
+
+	stats := aircraft.statistics
+	summary.FlightTime = stats.FlightTime
+	summary.MaxLoadFactor = stats.MaxLoadFactor
+	summary.MaxClimbRate = stats.MaxClimbRate
+	summary.MaxSpeed = stats.MaxSpeed
+	summary.MaxAltitude = stats.MaxAltitude
+	summary.FuelBurned = stats.TotalFuelBurned
+	d.printSummary(aircraft, summary)
+
+	encoder := json.NewEncoder(d.Out)
+	encoder.SetIndent("", "  ")
+	return summary, encoder.Encode(summary)
+}
+
+// fly flies one scenario from state, recording it when asked
+func (d *DemoRunner) fly(aircraft *demoAircraft, scenario DemoScenario, state *AircraftState) (*DemoScenarioResult, *AircraftState, error) {
+	state = state.Copy()
+	state.SetControlInputs(scenario.Controls)
+	runner := &ScenarioRunner{
+		Engine: aircraft.engine,
+		Dt:     d.Dt,
+		Policy: NewTerminationPolicy(MaxSimTime(state.Time + scenario.Duration)),
+	}
+
+	result := &DemoScenarioResult{Name: scenario.Name}
+	var recorder *OutputManager
+	var recordErr error
+	if d.RecordDir != "" {
+		result.Record = filepath.Join(d.RecordDir, demoRecordName(scenario.Name))
+		file, err := os.Create(result.Record)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer file.Close()
+		recorder, err = NewOutputManager(&Output{
+			Type:       "CSV",
+			Rate:       d.RecordRate,
+			Simulation: "ON",
+			Position:   "ON",
+			Velocities: "ON",
+			Rates:      "ON",
+			Forces:     "ON",
+		}, 1/d.Dt, file)
+		if err != nil {
+			return nil, nil, err
+		}
+		runner.Pilot = func(state *AircraftState) {
+			if err := recorder.Record(state); err != nil && recordErr == nil {
+				recordErr = err
+			}
+		}
+	}
+
+	report := runner.Run(state)
+	final := report.Final
+	if recorder != nil {
+		if err := recorder.Record(final); err != nil && recordErr == nil {
+			recordErr = err
+		}
+		if err := recorder.Flush(); err != nil && recordErr == nil {
+			recordErr = err
+		}
+		if recordErr != nil {
+			return nil, nil, fmt.Errorf("recording %s: %w", result.Record, recordErr)
+		}
+	}
+
+	duration := final.Time - state.Time
+	result.Condition = report.Condition
+	result.Steps = report.Steps
+	if report.Err != nil {
+		result.Error = report.Err.Error()
+	}
+	result.AltitudeStart, result.AltitudeEnd = state.Altitude, final.Altitude
+	result.SpeedStart, result.SpeedEnd = state.TrueAirspeed, final.TrueAirspeed
+	result.HeadingChange = (final.HeadingContinuous - state.HeadingContinuous) * RAD_TO_DEG
+	if duration > 0 {
+		result.ClimbRate = (final.Altitude - state.Altitude) / duration
+		result.Acceleration = (final.TrueAirspeed - state.TrueAirspeed) / duration
+		result.TurnRate = result.HeadingChange / duration
+	}
+	result.Roll, result.Pitch = final.Roll*RAD_TO_DEG, final.Pitch*RAD_TO_DEG
+	if components, err := aircraft.forces(final); err == nil {
+		result.Lift = -components.Aerodynamic.Lift
+		result.Drag = -components.Aerodynamic.Drag
+		result.Thrust = components.Propulsion.Thrust
+	}
+	return result, final, nil
+}
+
+// demoRecordName is the CSV file name of a scenario
+func demoRecordName(scenario string) string {
+	return strings.ReplaceAll(strings.ToLower(scenario), " ", "-") + ".csv"
+}
+
+// printHeader prints the aircraft and initial conditions
+func (d *DemoRunner) printHeader(aircraft *demoAircraft, state *AircraftState) {
+	if d.Verbosity < DemoNormal {
+		return
+	}
+	fmt.Fprintf(d.Out, "%s flight dynamics demo\n", aircraft.name)
+	if d.Verbosity < DemoVerbose {
+		return
+	}
+	fmt.Fprintf(d.Out, "Mass: %.0f kg\n", aircraft.mass)
+	if calc := aircraft.simplified; calc != nil {
+		fmt.Fprintf(d.Out, "Wing: %.1f m² area, %.1f m span\n", calc.WingArea, calc.WingSpan)
+		fmt.Fprintf(d.Out, "Inertia: Ixx=%.0f, Iyy=%.0f, Izz=%.0f kg·m²\n",
+			calc.Inertia.XX, calc.Inertia.YY, calc.Inertia.ZZ)
+	}
+	fmt.Fprintf(d.Out, "Initial: %s\n", state.String())
+	fmt.Fprintf(d.Out, "Atmosphere: T=%.1f°C, P=%.0f hPa, ρ=%.3f kg/m³\n",
+		state.Temperature-273.15, state.Pressure/100.0, state.Density)
+}
+
+// printResult prints how a scenario went
+func (d *DemoRunner) printResult(scenario DemoScenario, r *DemoScenarioResult) {
+	if d.Verbosity < DemoNormal {
+		return
+	}
+	fmt.Fprintf(d.Out, "\n%s (%s), %.1f s\n", scenario.Name, scenario.Description, scenario.Duration)
+	if r.Error != "" {
+		fmt.Fprintf(d.Out, "  Stopped after %d steps: %s\n", r.Steps, r.Error)
+	}
+	fmt.Fprintf(d.Out, "  Altitude: %.0f → %.0f m (%.1f m/s climb rate)\n", r.AltitudeStart, r.AltitudeEnd, r.ClimbRate)
+	fmt.Fprintf(d.Out, "  Speed: %.1f → %.1f m/s (%.2f m/s² acceleration)\n", r.SpeedStart, r.SpeedEnd, r.Acceleration)
+	fmt.Fprintf(d.Out, "  Heading: %.1f° change (%.1f°/s turn rate)\n", r.HeadingChange, r.TurnRate)
+	if d.Verbosity >= DemoVerbose {
+		fmt.Fprintf(d.Out, "  Attitude: φ=%.1f° θ=%.1f°\n", r.Roll, r.Pitch)
+		fmt.Fprintf(d.Out, "  Forces: Lift=%.0fN, Drag=%.0fN, Thrust=%.0fN\n", r.Lift, r.Drag, r.Thrust)
+	}
+	if r.Record != "" {
+		fmt.Fprintf(d.Out, "  Recorded to %s\n", r.Record)
+	}
+}
+
+// printSummary prints the performance over the whole flight and, verbosely,
+// the simplified model's drag in approach configurations
+func (d *DemoRunner) printSummary(aircraft *demoAircraft, s *DemoSummary) {
+	if d.Verbosity < DemoNormal {
+		return
+	}
+	fmt.Fprintf(d.Out, "\nFlight performance over %.1f s (%.0f steps at %.0f Hz):\n", s.FlightTime, s.FlightTime/s.Dt, 1/s.Dt)
+	fmt.Fprintf(d.Out, "  Max load factor: %.2f g\n", s.MaxLoadFactor)
+	fmt.Fprintf(d.Out, "  Max climb rate: %.1f m/s (%.0f ft/min)\n", s.MaxClimbRate, s.MaxClimbRate*60*M_TO_FT)
+	fmt.Fprintf(d.Out, "  Max speed: %.1f m/s (%.1f kt)\n", s.MaxSpeed, s.MaxSpeed*MS_TO_KT)
+	fmt.Fprintf(d.Out, "  Max altitude: %.0f m (%.0f ft)\n", s.MaxAltitude, s.MaxAltitude*M_TO_FT)
+	fmt.Fprintf(d.Out, "  Fuel burned: %.2f kg\n", s.FuelBurned)
+
+	calc := aircraft.simplified
+	if d.Verbosity < DemoVerbose || calc == nil {
+		return
+	}
+	fmt.Fprintf(d.Out, "\nApproach configurations (70 m/s, level):\n")
+	approach := NewAircraftState()
+	approach.Velocity = Vector3{X: 70.0}
+	approach.UpdateFlightConditions()
+	approach.SetControlInputs(ControlInputs{Throttle: 0.4})
+	for _, cfg := range []struct {
+		name          string
+		flapDeg, gear float64
+	}{
+		{"Clean", 0, 0},
+		{"Gear down", 0, 1},
+		{"Gear down, flaps 30°", 30, 1},
+	} {
+		approach.ControlSurfaces.FlapLeft = cfg.flapDeg * DEG_TO_RAD
+		approach.ControlSurfaces.FlapRight = cfg.flapDeg * DEG_TO_RAD
+		approach.Gear.Transition = cfg.gear
+		components, err := calc.CalculateSimplifiedForces(approach)
+		if err != nil {
+			continue
+		}
+		drag := -components.Aerodynamic.Drag
+		fmt.Fprintf(d.Out, "  %-22s Drag=%5.0fN  Deceleration=%.2f m/s²  Stall=%.0f kt\n",
+			cfg.name, drag, drag/calc.Mass, calc.StallSpeed(approach)*MS_TO_KT)
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDemoRunner(t *testing.T) {
+	// The simplified model holds trim with a 2 ms step but departs within
+	// seconds of the demo's larger inputs, so each scenario is flown for a
+	// second from trim
+	short := func() []DemoScenario {
+		scenarios := append([]DemoScenario(nil), DemoScenarios...)
+		for i := range scenarios {
+			scenarios[i].Duration = 1
+		}
+		return scenarios
+	}
+	trimmed := func(runner *DemoRunner) {
+		runner.Initial = trimLevelFlight(t, NewSimplifiedFlightDynamicsEngine(NewRungeKutta4Integrator()), 3000, 100)
+		runner.Dt = 0.002
+		runner.Scenarios = short()
+	}
+
+	t.Run("Quiet Summary", func(t *testing.T) {
+		var out bytes.Buffer
+		runner := NewDemoRunner(&out)
+		runner.Verbosity = DemoQuiet
+		trimmed(runner)
+		if _, err := runner.Run(); err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+
+		// Quietly, the output is the summary alone
+		var summary DemoSummary
+		if err := json.Unmarshal(out.Bytes(), &summary); err != nil {
+			t.Fatalf("Reading the summary: %v\n%s", err, out.String())
+		}
+		assertEqual(t, summary.Engine, "simplified")
+		assertEqual(t, len(summary.Scenarios), 4)
+		for i, s := range summary.Scenarios {
+			assertEqual(t, s.Name, DemoScenarios[i].Name)
+			assertEqual(t, s.Condition, "max-sim-time")
+			assertEqual(t, s.Steps, 500)
+			for _, v := range []float64{s.AltitudeEnd, s.SpeedEnd, s.ClimbRate, s.Acceleration, s.TurnRate, s.Lift, s.Drag, s.Thrust} {
+				if math.IsNaN(v) || math.IsInf(v, 0) {
+					t.Fatalf("%s: non-finite metric in %+v", s.Name, s)
+				}
+			}
+			if s.AltitudeEnd < 2900 || s.AltitudeEnd > 3100 || s.SpeedEnd < 80 || s.SpeedEnd > 120 {
+				t.Errorf("%s: implausible end, %.0f m at %.1f m/s", s.Name, s.AltitudeEnd, s.SpeedEnd)
+			}
+			if s.Lift <= 0 || s.Drag <= 0 || s.Thrust <= 0 {
+				t.Errorf("%s: lift, drag and thrust should be positive: %+v", s.Name, s)
+			}
+		}
+		if summary.Scenarios[2].HeadingChange >= -1 {
+			t.Errorf("The banking turn should turn: %.1f°", summary.Scenarios[2].HeadingChange)
+		}
+		assertApproxEqual(t, summary.FlightTime, 4, 1e-6)
+		if summary.MaxLoadFactor < 1 || summary.MaxLoadFactor > 4 {
+			t.Errorf("Implausible peak load factor %.2f g", summary.MaxLoadFactor)
+		}
+	})
+
+	t.Run("Reporting", func(t *testing.T) {
+		var out bytes.Buffer
+		runner := NewDemoRunner(&out)
+		trimmed(runner)
+		if err := runner.Select("climb", "Banking Turn"); err != nil {
+			t.Fatalf("Select: %v", err)
+		}
+		summary, err := runner.Run()
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		assertEqual(t, len(summary.Scenarios), 2)
+		report := out.String()
+		for _, want := range []string{"Climb (Full power climb)", "Banking Turn (Right banking turn)", "Max load factor", `"scenarios"`} {
+			if !strings.Contains(report, want) {
+				t.Errorf("Expected %q in the report:\n%s", want, report)
+			}
+		}
+		if strings.Contains(report, "Level Flight") || strings.Contains(report, "Approach configurations") {
+			t.Errorf("Only the selected scenarios, without the verbose detail, should be reported:\n%s", report)
+		}
+		if err := runner.Select("loop"); err == nil {
+			t.Error("An unknown scenario should be refused")
+		}
+	})
+
+	t.Run("Recording Rate", func(t *testing.T) {
+		// Recordings at the same rate line up whatever the step
+		record := func(dt float64) []string {
+			dir := t.TempDir()
+			runner := NewDemoRunner(&bytes.Buffer{})
+			trimmed(runner)
+			runner.Dt = dt
+			runner.Scenarios = short()[:1]
+			runner.RecordDir = dir
+			runner.RecordRate = 10
+			if _, err := runner.Run(); err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			data, err := os.ReadFile(filepath.Join(dir, "level-flight.csv"))
+			if err != nil {
+				t.Fatalf("Reading the recording: %v", err)
+			}
+			var times []string
+			for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n")[1:] {
+				time, _, _ := strings.Cut(line, ",")
+				times = append(times, time)
+			}
+			return times
+		}
+		coarse, fine := record(0.002), record(0.001)
+		assertEqual(t, len(coarse), 11)
+		assertEqual(t, len(fine), len(coarse))
+		for i := range coarse {
+			a, _ := strconv.ParseFloat(coarse[i], 64)
+			b, _ := strconv.ParseFloat(fine[i], 64)
+			if math.Abs(a-0.1*float64(i)) > 0.002 || math.Abs(b-a) > 0.002 {
+				t.Errorf("Row %d at %s s with a 2 ms step, %s s with 1 ms", i, coarse[i], fine[i])
+			}
+		}
+	})
+}
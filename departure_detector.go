@@ -0,0 +1,261 @@
+// Departure Detector
+// Recognises departures from controlled flight in the state stream: spins,
+// spiral dives and deep stalls, with the states leading up to each
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// DepartureKind classifies a departure from controlled flight
+type DepartureKind int
+
+const (
+	DepartureSpin       DepartureKind = iota // Sustained autorotation above the stall
+	DepartureSpiralDive                      // Bank and speed increasing with the nose low
+	DepartureDeepStall                       // Alpha held far past the stall with little pitch rate
+)
+
+func (k DepartureKind) String() string {
+	switch k {
+	case DepartureSpin:
+		return "spin"
+	case DepartureSpiralDive:
+		return "spiral dive"
+	case DepartureDeepStall:
+		return "deep stall"
+	}
+	return fmt.Sprintf("DepartureKind(%d)", int(k))
+}
+
+// DepartureSample holds the key properties of one state, for post-mortem
+type DepartureSample struct {
+	Time        float64
+	Alpha       float64 // rad
+	Beta        float64 // rad
+	Roll        float64 // rad
+	Pitch       float64 // rad
+	Heading     float64 // rad
+	AngularRate Vector3 // p, q, r (rad/s)
+	Airspeed    float64 // True airspeed (m/s)
+	Altitude    float64 // m
+	Controls    ControlInputs
+}
+
+// newDepartureSample takes the key properties of a state
+func newDepartureSample(state *AircraftState) DepartureSample {
+	return DepartureSample{
+		Time:        state.Time,
+		Alpha:       state.Alpha,
+		Beta:        state.Beta,
+		Roll:        state.Roll,
+		Pitch:       state.Pitch,
+		Heading:     state.Yaw,
+		AngularRate: state.AngularRate,
+		Airspeed:    state.TrueAirspeed,
+		Altitude:    state.Altitude,
+		Controls:    state.Controls,
+	}
+}
+
+// Departure is one departure from controlled flight
+type Departure struct {
+	Kind         DepartureKind
+	Onset        float64 // Time the condition began (s)
+	DetectedTime float64 // Time it had held long enough to be classified (s)
+
+	// History holds the samples from the detector's HistoryDuration before
+	// the onset up to the detection, oldest first
+	History []DepartureSample
+}
+
+func (d *Departure) String() string {
+	return fmt.Sprintf("%s from t=%.2fs, detected at t=%.2fs", d.Kind, d.Onset, d.DetectedTime)
+}
+
+// DepartureError stops a simulation whose detector terminates on departure
+type DepartureError struct {
+	Departure *Departure
+}
+
+func (e *DepartureError) Error() string {
+	return "departure from controlled flight: " + e.Departure.String()
+}
+
+// DepartureDetector watches the states of a simulation for departures. Each
+// kind is classified once its condition has held for its time, and is
+// detected again only after the condition has cleared. Alpha is the
+// state's, as the aerodynamic data sees it.
+type DepartureDetector struct {
+	StallAlpha float64 // Stall angle of attack (rad)
+
+	// Spin: alpha above the stall with the roll and yaw rates of the same
+	// sign and both above SpinRate, for SpinTime
+	SpinRate float64 // rad/s
+	SpinTime float64 // s
+
+	// Spiral dive: bank beyond SpiralBank and not decreasing, pitch below
+	// -SpiralPitch and airspeed not decreasing, for SpiralTime
+	SpiralBank  float64 // rad
+	SpiralPitch float64 // rad
+	SpiralTime  float64 // s
+
+	// Deep stall: alpha at or above DeepStallAlpha, the edge of the
+	// aerodynamic data or an angle far past the stall, with the pitch rate
+	// below DeepStallPitchRate, for DeepStallTime
+	DeepStallAlpha     float64 // rad
+	DeepStallPitchRate float64 // rad/s
+	DeepStallTime      float64 // s
+
+	HistoryDuration float64 // Time kept before each onset (s)
+	Terminate       bool    // Fail the step on a departure instead of only recording it
+
+	// Departures lists those detected, in order. Statistics, when set,
+	// collects them too.
+	Departures []*Departure
+	Statistics *FlightStatistics
+
+	history  []DepartureSample // Recent samples, oldest first
+	previous *DepartureSample  // Sample before the latest, for the trends
+	onsets   [3]float64        // Onset of each kind's condition, NaN while it does not hold
+	detected [3]bool           // Kinds classified since their condition last cleared
+}
+
+// NewDepartureDetector creates a detector with thresholds for the P-51D:
+// a 16° stall, spins rotating at over 30°/s for 2 s, spiral dives past 45°
+// of bank and 10° nose down for 3 s and deep stalls above 30° of alpha
+// pitching at under 5°/s for 2 s, keeping 2 s of history. Departures are
+// recorded in stats when that is not nil.
+func NewDepartureDetector(stats *FlightStatistics) *DepartureDetector {
+	d := &DepartureDetector{
+		StallAlpha:         16 * DEG_TO_RAD,
+		SpinRate:           30 * DEG_TO_RAD,
+		SpinTime:           2,
+		SpiralBank:         45 * DEG_TO_RAD,
+		SpiralPitch:        10 * DEG_TO_RAD,
+		SpiralTime:         3,
+		DeepStallAlpha:     30 * DEG_TO_RAD,
+		DeepStallPitchRate: 5 * DEG_TO_RAD,
+		DeepStallTime:      2,
+		HistoryDuration:    2,
+		Statistics:         stats,
+	}
+	d.Reset()
+	return d
+}
+
+// Reset forgets the states seen, keeping the departures detected
+func (d *DepartureDetector) Reset() {
+	d.history = nil
+	d.previous = nil
+	d.onsets = [3]float64{math.NaN(), math.NaN(), math.NaN()}
+	d.detected = [3]bool{}
+}
+
+// Attach makes the detector observe every state evaluated by the bus. A
+// departure cannot stop the simulation from a bus callback; set the
+// detector on the engine for Terminate to take effect.
+func (d *DepartureDetector) Attach(bus *EventBus) *EventWatcher {
+	return bus.OnStep(func(state *AircraftState, _ float64) { d.Observe(state) })
+}
+
+// Observe takes the next state of the simulation, returning a
+// DepartureError for a departure detected at it when Terminate is set
+func (d *DepartureDetector) Observe(state *AircraftState) error {
+	sample := newDepartureSample(state)
+	d.record(sample)
+
+	var err error
+	for kind, holds := range d.conditions(sample) {
+		if !holds {
+			d.onsets[kind] = math.NaN()
+			d.detected[kind] = false
+			continue
+		}
+		if math.IsNaN(d.onsets[kind]) {
+			d.onsets[kind] = sample.Time
+		}
+		if d.detected[kind] || sample.Time-d.onsets[kind] < d.holdTime(DepartureKind(kind))-1e-9 {
+			continue
+		}
+		d.detected[kind] = true
+		departure := d.departure(DepartureKind(kind), sample.Time)
+		if d.Terminate && err == nil {
+			err = &DepartureError{Departure: departure}
+		}
+	}
+	d.previous = &sample
+	return err
+}
+
+// conditions reports which departure conditions hold at a sample
+func (d *DepartureDetector) conditions(s DepartureSample) [3]bool {
+	var holds [3]bool
+	p, q, r := s.AngularRate.X, s.AngularRate.Y, s.AngularRate.Z
+	holds[DepartureSpin] = s.Alpha > d.StallAlpha && p*r > 0 &&
+		math.Abs(p) > d.SpinRate && math.Abs(r) > d.SpinRate
+
+	if prev := d.previous; prev != nil {
+		holds[DepartureSpiralDive] = math.Abs(s.Roll) > d.SpiralBank && math.Abs(s.Roll) >= math.Abs(prev.Roll) &&
+			s.Pitch < -d.SpiralPitch && s.Airspeed >= prev.Airspeed
+	}
+
+	holds[DepartureDeepStall] = s.Alpha >= d.DeepStallAlpha && math.Abs(q) < d.DeepStallPitchRate
+	return holds
+}
+
+// holdTime returns the time a kind's condition must hold
+func (d *DepartureDetector) holdTime(kind DepartureKind) float64 {
+	switch kind {
+	case DepartureSpin:
+		return d.SpinTime
+	case DepartureSpiralDive:
+		return d.SpiralTime
+	}
+	return d.DeepStallTime
+}
+
+// record adds a sample to the history, dropping those no departure still
+// being timed can need: older than HistoryDuration before the longest hold
+func (d *DepartureDetector) record(sample DepartureSample) {
+	d.history = append(d.history, sample)
+	keep := d.HistoryDuration + math.Max(d.SpinTime, math.Max(d.SpiralTime, d.DeepStallTime))
+	stale := 0
+	for stale < len(d.history) && d.history[stale].Time < sample.Time-keep-1e-9 {
+		stale++
+	}
+	d.history = d.history[stale:]
+}
+
+// departure records a departure of a kind detected at a time, with the
+// history from HistoryDuration before its onset
+func (d *DepartureDetector) departure(kind DepartureKind, detected float64) *Departure {
+	onset := d.onsets[kind]
+	departure := &Departure{Kind: kind, Onset: onset, DetectedTime: detected}
+	for _, sample := range d.history {
+		if sample.Time >= onset-d.HistoryDuration-1e-9 {
+			departure.History = append(departure.History, sample)
+		}
+	}
+	d.Departures = append(d.Departures, departure)
+	if d.Statistics != nil {
+		d.Statistics.Departures = append(d.Statistics.Departures, departure)
+	}
+	return departure
+}
+
+// String lists the departures detected
+func (d *DepartureDetector) String() string {
+	if len(d.Departures) == 0 {
+		return "No departures"
+	}
+	var sb strings.Builder
+	sb.WriteString("Departures:\n")
+	for _, departure := range d.Departures {
+		sb.WriteString("  " + departure.String() + "\n")
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
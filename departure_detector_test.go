@@ -0,0 +1,172 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// flightSegment scripts the key properties of a synthetic state history
+// over its duration, from its fraction of the way through
+type flightSegment struct {
+	duration float64
+	state    func(f float64, s *AircraftState)
+}
+
+// levelFlight is a steady segment of cruise
+func levelFlight(duration float64) flightSegment {
+	return flightSegment{duration, func(_ float64, s *AircraftState) {
+		s.Alpha = 2 * DEG_TO_RAD
+		s.TrueAirspeed = 120
+	}}
+}
+
+// observeHistory feeds a detector the states of the segments at 20 Hz,
+// returning the first error it reports
+func observeHistory(d *DepartureDetector, segments ...flightSegment) error {
+	const dt = 0.05
+	var start float64
+	var first error
+	for _, segment := range segments {
+		steps := int(math.Round(segment.duration / dt))
+		for i := 0; i < steps; i++ {
+			state := NewAircraftState()
+			state.Altitude = 3000
+			state.Time = start + float64(i)*dt
+			segment.state(float64(i)/float64(steps), state)
+			if err := d.Observe(state); err != nil && first == nil {
+				first = err
+			}
+		}
+		start += float64(steps) * dt
+	}
+	return first
+}
+
+func TestDepartureDetector(t *testing.T) {
+	expectDepartures := func(t *testing.T, d *DepartureDetector, kinds ...DepartureKind) {
+		t.Helper()
+		t.Log(d)
+		if len(d.Departures) != len(kinds) {
+			t.Fatalf("Expected %v, got %d departures", kinds, len(d.Departures))
+		}
+		for i, kind := range kinds {
+			if d.Departures[i].Kind != kind {
+				t.Errorf("Departure %d: expected a %s, got a %s", i, kind, d.Departures[i].Kind)
+			}
+		}
+	}
+
+	t.Run("Spin", func(t *testing.T) {
+		stats := &FlightStatistics{}
+		d := NewDepartureDetector(stats)
+		observeHistory(d, levelFlight(3), flightSegment{5, func(_ float64, s *AircraftState) {
+			s.Alpha = 25 * DEG_TO_RAD
+			s.Pitch = -50 * DEG_TO_RAD
+			s.AngularRate = Vector3{X: 1.2, Y: 0.1, Z: 1.4}
+			s.TrueAirspeed = 45
+		}})
+		expectDepartures(t, d, DepartureSpin)
+
+		spin := d.Departures[0]
+		assertApproxEqual(t, spin.Onset, 3.0, 1e-9)
+		assertApproxEqual(t, spin.DetectedTime, 5.0, 1e-9)
+		assertEqual(t, stats.Departures, d.Departures)
+
+		// The history runs from 2 s before the onset to the detection
+		history := spin.History
+		assertApproxEqual(t, history[0].Time, 1.0, 1e-9)
+		assertApproxEqual(t, history[len(history)-1].Time, 5.0, 1e-9)
+		assertEqual(t, history[0].Alpha, 2*DEG_TO_RAD)
+		assertEqual(t, history[len(history)-1].AngularRate.Z, 1.4)
+	})
+
+	t.Run("Spiral Dive", func(t *testing.T) {
+		d := NewDepartureDetector(nil)
+		observeHistory(d, levelFlight(2), flightSegment{8, func(f float64, s *AircraftState) {
+			s.Alpha = 3 * DEG_TO_RAD
+			s.Roll = (50 + 35*f) * DEG_TO_RAD
+			s.Pitch = -(15 + 25*f) * DEG_TO_RAD
+			s.AngularRate = Vector3{X: 0.08, Y: 0.1, Z: 0.3}
+			s.TrueAirspeed = 120 + 50*f
+		}})
+		expectDepartures(t, d, DepartureSpiralDive)
+		assertApproxEqual(t, d.Departures[0].Onset, 2.0, 1e-9)
+	})
+
+	t.Run("Deep Stall", func(t *testing.T) {
+		d := NewDepartureDetector(nil)
+		observeHistory(d, levelFlight(2), flightSegment{5, func(f float64, s *AircraftState) {
+			s.Alpha = 40 * DEG_TO_RAD
+			s.Pitch = 5 * DEG_TO_RAD
+			s.AngularRate = Vector3{Y: 0.02 * math.Sin(2*math.Pi*f)}
+			s.TrueAirspeed = 35
+		}})
+		expectDepartures(t, d, DepartureDeepStall)
+	})
+
+	t.Run("Detected Again After Recovery", func(t *testing.T) {
+		d := NewDepartureDetector(nil)
+		stalled := flightSegment{3, func(_ float64, s *AircraftState) {
+			s.Alpha = 40 * DEG_TO_RAD
+			s.TrueAirspeed = 35
+		}}
+		observeHistory(d, stalled, levelFlight(2), stalled)
+		expectDepartures(t, d, DepartureDeepStall, DepartureDeepStall)
+	})
+
+	t.Run("Coordinated Aerobatic Roll", func(t *testing.T) {
+		// Three 90°/s aileron rolls, the nose dropping well below the
+		// horizon when inverted as the speed builds, with no sideslip or yaw
+		// rate
+		d := NewDepartureDetector(nil)
+		roll := flightSegment{12, func(f float64, s *AircraftState) {
+			phase := 3 * 2 * math.Pi * f
+			s.Alpha = 4 * DEG_TO_RAD
+			s.Roll = math.Remainder(phase, 2*math.Pi)
+			s.Pitch = (10*math.Cos(phase) - 5) * DEG_TO_RAD
+			s.AngularRate = Vector3{X: 90 * DEG_TO_RAD, Y: 0.2 * math.Cos(phase)}
+			s.TrueAirspeed = 130 + 20*f
+		}}
+		observeHistory(d, levelFlight(1), roll, levelFlight(1))
+		expectDepartures(t, d)
+	})
+
+	t.Run("Terminates The Run", func(t *testing.T) {
+		d := NewDepartureDetector(nil)
+		d.Terminate = true
+		err := observeHistory(d, levelFlight(1), flightSegment{4, func(_ float64, s *AircraftState) {
+			s.Alpha = 40 * DEG_TO_RAD
+		}})
+		var departure *DepartureError
+		if !errors.As(err, &departure) {
+			t.Fatalf("Expected a DepartureError, got %v", err)
+		}
+		assertEqual(t, departure.Departure.Kind, DepartureDeepStall)
+		assertApproxEqual(t, departure.Departure.DetectedTime, 3.0, 1e-9)
+	})
+
+	t.Run("Engine", func(t *testing.T) {
+		// The simplified model's trim alpha counts as a deep stall when the
+		// threshold is put below it, which stops the engine
+		engine := NewSimplifiedFlightDynamicsEngine(NewEulerIntegrator())
+		state := trimLevelFlight(t, engine, 1000, 100)
+		engine.Departures = NewDepartureDetector(engine.Statistics)
+		engine.Departures.DeepStallAlpha = state.Alpha / 2
+		engine.Departures.DeepStallTime = 0.1
+		engine.Departures.Terminate = true
+
+		var err error
+		for i := 0; i < 100 && err == nil; i++ {
+			var next *AircraftState
+			if next, err = engine.Step(state, 0.002); err == nil {
+				state = next
+			}
+		}
+		var departure *DepartureError
+		if !errors.As(err, &departure) {
+			t.Fatalf("Expected the engine to stop on the departure, got %v", err)
+		}
+		assertEqual(t, len(engine.Statistics.Departures), 1)
+	})
+}
@@ -0,0 +1,137 @@
+// Derived Geometry
+// Reference quantities derived from the metrics and mass balance of a
+// configuration: aspect ratio, tail volumes and a static margin estimate
+
+package main
+
+import (
+	"math"
+	"strings"
+)
+
+// Assumptions of the neutral point estimate, for a conventional layout
+const (
+	tailEfficiency  = 0.9 // Dynamic pressure ratio at the horizontal tail
+	tailAspectRatio = 4.0 // Horizontal tail aspect ratio, which metrics do not give
+)
+
+// DerivedGeometry holds the reference quantities derived from a
+// configuration. Quantities whose inputs are missing are zero.
+type DerivedGeometry struct {
+	AspectRatio   float64 // b²/S
+	WingIncidence float64 // Wing root incidence to the body X axis (rad)
+	HTailVolume   float64 // Vh = Sh·lh/(S·c̄)
+	VTailVolume   float64 // Vv = Sv·lv/(S·b)
+
+	// Station of the leading edge of the mean aerodynamic chord (m aft of
+	// the structural origin), taking the AERORP as its quarter chord, which
+	// holds whatever the taper
+	MACLeadingEdge float64
+
+	// Stick-fixed neutral point and CG aft of the MAC leading edge, as
+	// fractions of the MAC, and the static margin between them (positive
+	// when stable). Known only when the configuration has both an AERORP
+	// and a CG location.
+	NeutralPoint    float64
+	CGPosition      float64
+	StaticMargin    float64
+	HasStaticMargin bool
+}
+
+// NewDerivedGeometry derives the reference quantities of a configuration
+// from its metrics, with the CG of its mass balance
+func NewDerivedGeometry(config *JSBSimConfig) DerivedGeometry {
+	var g DerivedGeometry
+	if config == nil || config.Metrics == nil {
+		return g
+	}
+	m := config.Metrics
+	area, span, chord := measurementValue(m.WingArea), measurementValue(m.WingSpan), measurementValue(m.Chord)
+	g.WingIncidence = measurementValue(m.WingIncidence)
+	if area <= 0 {
+		return g
+	}
+	g.AspectRatio = span * span / area
+	if chord > 0 {
+		g.HTailVolume = measurementValue(m.HTailArea) * measurementValue(m.HTailArm) / (area * chord)
+	}
+	if span > 0 {
+		g.VTailVolume = measurementValue(m.VTailArea) * measurementValue(m.VTailArm) / (area * span)
+	}
+
+	aero := metricsLocation(m, "AERORP")
+	if aero == nil || chord <= 0 {
+		return g
+	}
+	chordM := chord * FT_TO_M
+	g.MACLeadingEdge = structuralStation(aero) - 0.25*chordM
+	g.NeutralPoint = 0.25 + g.neutralPointShift()
+	if config.MassBalance != nil && config.MassBalance.Location != nil {
+		g.CGPosition = (structuralStation(config.MassBalance.Location) - g.MACLeadingEdge) / chordM
+		g.StaticMargin = g.NeutralPoint - g.CGPosition
+		g.HasStaticMargin = true
+	}
+	return g
+}
+
+// neutralPointShift estimates how far aft of the wing-body aerodynamic
+// centre the horizontal tail moves the neutral point, as a fraction of the
+// MAC: η·Vh·(at/a)·(1 − dε/dα), with lift-curve slopes from Helmbold's
+// formula and the elliptic-wing downwash gradient 2a/(π·AR)
+func (g DerivedGeometry) neutralPointShift() float64 {
+	if g.AspectRatio <= 0 {
+		return 0
+	}
+	a := liftCurveSlope(g.AspectRatio)
+	downwash := 2 * a / (math.Pi * g.AspectRatio)
+	return tailEfficiency * g.HTailVolume * liftCurveSlope(tailAspectRatio) / a * (1 - downwash)
+}
+
+// liftCurveSlope returns Helmbold's estimate of the lift-curve slope (per
+// radian) of an unswept wing of aspect ratio ar
+func liftCurveSlope(ar float64) float64 {
+	return 2 * math.Pi * ar / (2 + math.Sqrt(ar*ar+4))
+}
+
+// InducedDragFactor returns K = 1/(π·e·AR) of the drag polar CD = CD0 + K·CL²
+// for Oswald efficiency e
+func (g DerivedGeometry) InducedDragFactor(e float64) float64 {
+	return 1 / (math.Pi * e * g.AspectRatio)
+}
+
+// FillPropertyMap writes the derived quantities into a property map under
+// their metrics/ names. The static margin is written only when known.
+func (g DerivedGeometry) FillPropertyMap(m map[string]float64) {
+	m["metrics/aspect-ratio"] = g.AspectRatio
+	m["metrics/iw-rad"] = g.WingIncidence
+	m["metrics/iw-deg"] = g.WingIncidence * RAD_TO_DEG
+	m["metrics/vbarh-norm"] = g.HTailVolume
+	m["metrics/vbarv-norm"] = g.VTailVolume
+	if g.HasStaticMargin {
+		m["metrics/static-margin-norm"] = g.StaticMargin
+	}
+}
+
+// measurementValue returns the value of m, or zero when it is missing
+func measurementValue(m *Measurement) float64 {
+	if m == nil {
+		return 0
+	}
+	return m.Value
+}
+
+// metricsLocation returns the named location of the metrics, or nil
+func metricsLocation(m *Metrics, name string) *Location {
+	for _, loc := range m.Location {
+		if loc != nil && strings.EqualFold(strings.TrimSpace(loc.Name), name) {
+			return loc
+		}
+	}
+	return nil
+}
+
+// structuralStation returns the X station of a location (m aft of the
+// structural origin)
+func structuralStation(loc *Location) float64 {
+	return StructuralPosition(loc).X
+}
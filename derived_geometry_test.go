@@ -0,0 +1,116 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDerivedGeometry(t *testing.T) {
+	config := loadP51DConfig(t)
+
+	t.Run("P-51D Reference Values", func(t *testing.T) {
+		g := NewDerivedGeometry(config)
+		assertApproxEqual(t, g.AspectRatio, 37.1*37.1/235, 1e-9)
+		assertApproxEqual(t, g.AspectRatio, 5.86, 0.01)
+		assertApproxEqual(t, g.WingIncidence, 1.0*DEG_TO_RAD, 1e-9)
+		assertApproxEqual(t, g.HTailVolume, 41*15/(235*6.6), 1e-9)
+		assertApproxEqual(t, g.VTailVolume, 20*15/(235*37.1), 1e-9)
+
+		// The AERORP at 99 in is the quarter chord of the 79.2 in MAC, so
+		// its leading edge is at 79.2 in and the CG at 98 in lies at 23.7%
+		assertApproxEqual(t, g.MACLeadingEdge, 79.2*0.0254, 1e-4)
+		assertApproxEqual(t, g.CGPosition, (98-79.2)/79.2, 1e-4)
+		if !g.HasStaticMargin {
+			t.Fatal("Static margin should be known with an AERORP and a CG")
+		}
+		if g.NeutralPoint <= 0.25 || g.NeutralPoint > 0.6 {
+			t.Errorf("Neutral point %.3f MAC should lie aft of the quarter chord", g.NeutralPoint)
+		}
+		if g.StaticMargin < 0.05 || g.StaticMargin > 0.3 {
+			t.Errorf("Static margin %.3f is not that of a stable fighter", g.StaticMargin)
+		}
+		assertApproxEqual(t, g.StaticMargin, g.NeutralPoint-g.CGPosition, 1e-12)
+	})
+
+	t.Run("Published Properties", func(t *testing.T) {
+		calc := NewForcesMomentsCalculator(config)
+		assertApproxEqual(t, calc.Geometry.AspectRatio, NewDerivedGeometry(config).AspectRatio, 1e-12)
+
+		state := NewAircraftState()
+		state.Altitude = 1000
+		state.Position = Vector3{Z: -1000}
+		state.Velocity = Vector3{X: 100}
+		state.UpdateAtmosphere()
+		state.UpdateDerivedParameters()
+		if _, err := calc.CalculateForcesMoments(state); err != nil {
+			t.Fatalf("CalculateForcesMoments: %v", err)
+		}
+		for name, want := range map[string]float64{
+			"metrics/aspect-ratio":       calc.Geometry.AspectRatio,
+			"metrics/iw-rad":             calc.Geometry.WingIncidence,
+			"metrics/iw-deg":             calc.Geometry.WingIncidence * RAD_TO_DEG,
+			"metrics/vbarh-norm":         calc.Geometry.HTailVolume,
+			"metrics/vbarv-norm":         calc.Geometry.VTailVolume,
+			"metrics/static-margin-norm": calc.Geometry.StaticMargin,
+		} {
+			got, ok := calc.Properties.GetSafe(name)
+			if !ok {
+				t.Errorf("%s not published", name)
+				continue
+			}
+			assertApproxEqual(t, got, want, 1e-9)
+		}
+	})
+
+	t.Run("Missing Inputs", func(t *testing.T) {
+		g := NewDerivedGeometry(&JSBSimConfig{Metrics: &Metrics{
+			WingArea: &Measurement{Value: 100},
+			WingSpan: &Measurement{Value: 30},
+		}})
+		assertApproxEqual(t, g.AspectRatio, 9, 1e-12)
+		assertEqual(t, g.HTailVolume, 0.0)
+		assertEqual(t, g.HasStaticMargin, false)
+
+		properties := make(map[string]float64)
+		g.FillPropertyMap(properties)
+		if _, ok := properties["metrics/static-margin-norm"]; ok {
+			t.Error("An unknown static margin should not be published")
+		}
+		assertEqual(t, NewDerivedGeometry(&JSBSimConfig{}), DerivedGeometry{})
+	})
+}
+
+func TestSimplifiedInducedDrag(t *testing.T) {
+	calc := NewSimplifiedCalculator()
+	ar := calc.WingSpan * calc.WingSpan / calc.WingArea
+	assertApproxEqual(t, calc.InducedDragFactor(), 1/(math.Pi*0.8*ar), 1e-12)
+
+	state := NewAircraftState()
+	state.Altitude = 1500
+	state.Position = Vector3{Z: -1500}
+	state.Velocity = Vector3{X: 80 * math.Cos(0.1), Z: 80 * math.Sin(0.1)}
+	state.UpdateAtmosphere()
+	state.UpdateDerivedParameters()
+	drag := func() float64 {
+		t.Helper()
+		components, err := calc.CalculateSimplifiedForces(state)
+		if err != nil {
+			t.Fatalf("CalculateSimplifiedForces: %v", err)
+		}
+		return components.Aerodynamic.Drag
+	}
+
+	// Drag acts along -X, so a longer span at the same lift reduces its magnitude
+	base := drag()
+	calc.WingSpan *= 1.2
+	longSpan := drag()
+	if math.Abs(longSpan) >= math.Abs(base) {
+		t.Errorf("Raising the aspect ratio should cut the induced drag: %.1f N, was %.1f N", longSpan, base)
+	}
+	calc.WingSpan /= 1.2
+	calc.OswaldEfficiency = 1.0
+	efficient := drag()
+	if math.Abs(efficient) >= math.Abs(base) {
+		t.Errorf("A more efficient span loading should cut the induced drag: %.1f N, was %.1f N", efficient, base)
+	}
+}
@@ -0,0 +1,250 @@
+// Dormand-Prince Integrator
+// Embedded Runge-Kutta 5(4) pair with adaptive steps: the fourth-order
+// solution of the same stages estimates the error without extra steps
+
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// Dormand-Prince 5(4) tableau. The fifth-order weights are the last row of
+// dpA, so the seventh stage is evaluated at the new state and is the first
+// stage of the next step (first same as last).
+var (
+	dpC = [7]float64{0, 1.0 / 5, 3.0 / 10, 4.0 / 5, 8.0 / 9, 1, 1}
+	dpA = [7][6]float64{
+		{},
+		{1.0 / 5},
+		{3.0 / 40, 9.0 / 40},
+		{44.0 / 45, -56.0 / 15, 32.0 / 9},
+		{19372.0 / 6561, -25360.0 / 2187, 64448.0 / 6561, -212.0 / 729},
+		{9017.0 / 3168, -355.0 / 33, 46732.0 / 5247, 49.0 / 176, -5103.0 / 18656},
+		{35.0 / 384, 0, 500.0 / 1113, 125.0 / 192, -2187.0 / 6784, 11.0 / 84},
+	}
+	// Fifth- less fourth-order weights, giving the error estimate
+	dpE = [7]float64{
+		71.0 / 57600, 0, -71.0 / 16695, 71.0 / 1920, -17253.0 / 339200, 22.0 / 525, -1.0 / 40,
+	}
+)
+
+// dpSlope is the rate of the integrated state at one stage
+type dpSlope struct {
+	position, velocity, angularRate Vector3
+	orientation                     Quaternion
+}
+
+// newDPSlope takes the rates of a stage from its derivatives, with the
+// quaternion rate of the stage's unnormalised orientation
+func newDPSlope(derivatives *StateDerivatives, orientation Quaternion, angularRate Vector3) dpSlope {
+	omega := Quaternion{W: 0, X: angularRate.X, Y: angularRate.Y, Z: angularRate.Z}
+	return dpSlope{
+		position:    derivatives.PositionDot,
+		velocity:    derivatives.VelocityDot,
+		angularRate: derivatives.AngularRateDot,
+		orientation: orientation.Multiply(omega).Scale(0.5),
+	}
+}
+
+// DormandPrinceIntegrator integrates with the embedded Dormand-Prince 5(4)
+// pair, re-evaluating the dynamics at each stage. Integrate advances by the
+// frame step in as many accepted steps as the tolerance needs, carrying the
+// step size over to the next frame; Step takes one adaptive step.
+type DormandPrinceIntegrator struct {
+	DynamicsFunc DynamicsFunction
+
+	MinDt        float64
+	MaxDt        float64
+	Tolerance    float64
+	SafetyFactor float64
+
+	// MaxRejections caps the retries of one step; the last attempt is then
+	// accepted above the tolerance and a warning recorded
+	MaxRejections int
+
+	Statistics *IntegrationStatistics
+	Warnings   []string
+
+	// Evaluations counts the calls of DynamicsFunc
+	Evaluations int
+
+	nextDt float64
+
+	// Last stage of the previous Step, reused as the first of the next when
+	// it starts from the state that step returned
+	fsalState *AircraftState
+	fsal      dpSlope
+}
+
+// NewDormandPrinceIntegrator creates an adaptive Dormand-Prince integrator
+// with the same limits as NewAdaptiveTimeStep
+func NewDormandPrinceIntegrator(dynamicsFunc DynamicsFunction) *DormandPrinceIntegrator {
+	return &DormandPrinceIntegrator{
+		DynamicsFunc:  dynamicsFunc,
+		MinDt:         1e-6,
+		MaxDt:         0.1,
+		Tolerance:     1e-3,
+		SafetyFactor:  0.9,
+		MaxRejections: 10,
+		Statistics:    NewIntegrationStatistics(),
+	}
+}
+
+// SetDynamics sets the dynamics evaluated at the stages
+func (dp *DormandPrinceIntegrator) SetDynamics(dynamicsFunc DynamicsFunction) {
+	dp.DynamicsFunc = dynamicsFunc
+}
+
+func (dp *DormandPrinceIntegrator) GetName() string {
+	return "Dormand-Prince 5(4)"
+}
+
+func (dp *DormandPrinceIntegrator) GetOrder() int {
+	return 5
+}
+
+// Integrate advances the state by dt, starting from the caller's
+// derivatives at state. Should the dynamics fail, the rest of the frame is
+// taken by a classical RK4 step with those derivatives.
+func (dp *DormandPrinceIntegrator) Integrate(state *AircraftState, derivatives *StateDerivatives, dt float64) *AircraftState {
+	k1 := newDPSlope(derivatives, state.Orientation, state.AngularRate)
+	current := state
+	end := state.Time + dt
+	h := dp.nextDt
+	if h <= 0 {
+		h = dt
+	}
+
+	for remaining := dt; remaining > 1e-12*dt; remaining = end - current.Time {
+		// Finish the frame exactly rather than leave a sliver for next time
+		last := h >= remaining*(1-1e-9)
+		if last {
+			h = remaining
+		}
+		next, k7, used, suggested, err := dp.adaptiveStep(current, k1, h)
+		if err != nil {
+			fallback := NewRungeKutta4Integrator().Integrate(current, derivatives, remaining)
+			fallback.Time = end
+			return fallback
+		}
+		if last && used >= h {
+			next.Time = end
+		}
+		dp.nextDt = suggested
+		current, k1, h = next, k7, suggested
+	}
+	return current
+}
+
+// Step takes one adaptive step from state, trying dt first. It returns the
+// new state, which may be less than dt on, and the step size suggested for
+// the next. Stepping on from the returned state reuses its last stage, so a
+// step in smooth flight costs six evaluations of the dynamics.
+func (dp *DormandPrinceIntegrator) Step(state *AircraftState, dt float64) (*AircraftState, float64, error) {
+	var k1 dpSlope
+	if state == dp.fsalState {
+		k1 = dp.fsal
+	} else {
+		derivatives, err := dp.evaluate(state)
+		if err != nil {
+			return nil, 0, err
+		}
+		k1 = newDPSlope(derivatives, state.Orientation, state.AngularRate)
+	}
+
+	next, k7, _, suggested, err := dp.adaptiveStep(state, k1, dt)
+	if err != nil {
+		return nil, 0, err
+	}
+	dp.fsalState, dp.fsal = next, k7
+	return next, suggested, nil
+}
+
+// adaptiveStep takes one accepted step from state, starting at h and
+// reducing it until the error is within tolerance or the rejections run
+// out. It returns the new state, its last stage, the step taken and the
+// step suggested for the next.
+func (dp *DormandPrinceIntegrator) adaptiveStep(state *AircraftState, k1 dpSlope, h float64) (*AircraftState, dpSlope, float64, float64, error) {
+	h = math.Max(dp.MinDt, math.Min(dp.MaxDt, h))
+
+	for rejections := 0; ; rejections++ {
+		next, k7, error, err := dp.attempt(state, k1, h)
+		if err != nil {
+			return nil, dpSlope{}, 0, 0, err
+		}
+
+		// The embedded error is of fourth order, so it scales as h^5
+		scale := 5.0
+		if error > 0 {
+			scale = math.Min(5.0, dp.SafetyFactor*math.Pow(dp.Tolerance/error, 0.2))
+		}
+
+		if error <= dp.Tolerance || rejections >= dp.MaxRejections || h <= dp.MinDt {
+			if error > dp.Tolerance {
+				dp.Statistics.CappedSteps++
+				dp.Warnings = append(dp.Warnings, fmt.Sprintf(
+					"t=%.4f s: step of %.3g s accepted after %d rejections with error %.3g above tolerance %.3g",
+					state.Time, h, rejections, error, dp.Tolerance))
+			}
+			dp.Statistics.UpdateStats(h, true, error)
+			suggested := math.Max(dp.MinDt, math.Min(dp.MaxDt, h*scale))
+			return next, k7, h, suggested, nil
+		}
+
+		dp.Statistics.UpdateStats(h, false, error)
+		h = math.Max(dp.MinDt, h*math.Max(0.1, math.Min(0.5, scale)))
+	}
+}
+
+// attempt evaluates the stages of one step of h, returning the fifth-order
+// state, its slope and the embedded error estimate
+func (dp *DormandPrinceIntegrator) attempt(state *AircraftState, k1 dpSlope, h float64) (*AircraftState, dpSlope, float64, error) {
+	var k [7]dpSlope
+	k[0] = k1
+	var stage *AircraftState
+	for i := 1; i < 7; i++ {
+		var orientation Quaternion
+		stage, orientation = dpAdvance(state, k[:i], dpA[i][:i], h)
+		stage.Time = state.Time + dpC[i]*h
+		derivatives, err := dp.evaluate(stage)
+		if err != nil {
+			return nil, dpSlope{}, 0, err
+		}
+		k[i] = newDPSlope(derivatives, orientation, stage.AngularRate)
+	}
+
+	// The seventh stage was evaluated at the fifth-order solution
+	var positionError, velocityError Vector3
+	for i, e := range dpE {
+		positionError = positionError.Add(k[i].position.Scale(e * h))
+		velocityError = velocityError.Add(k[i].velocity.Scale(e * h))
+	}
+	error := math.Max(positionError.Magnitude(), velocityError.Magnitude())
+	return stage, k[6], error, nil
+}
+
+func (dp *DormandPrinceIntegrator) evaluate(state *AircraftState) (*StateDerivatives, error) {
+	dp.Evaluations++
+	return dp.DynamicsFunc(state)
+}
+
+// dpAdvance returns state advanced by h along the weighted slopes, and its
+// unnormalised orientation for the stage's quaternion rate
+func dpAdvance(state *AircraftState, slopes []dpSlope, weights []float64, h float64) (*AircraftState, Quaternion) {
+	newState := state.Copy()
+	orientation := state.Orientation
+	for i, w := range weights {
+		if w == 0 {
+			continue
+		}
+		newState.Position = newState.Position.Add(slopes[i].position.Scale(w * h))
+		newState.Velocity = newState.Velocity.Add(slopes[i].velocity.Scale(w * h))
+		newState.AngularRate = newState.AngularRate.Add(slopes[i].angularRate.Scale(w * h))
+		orientation = orientation.Add(slopes[i].orientation.Scale(w * h))
+	}
+	newState.Orientation = orientation.Normalize()
+	newState.Altitude = -newState.Position.Z
+	newState.UpdateFlightConditions()
+	return newState, orientation
+}
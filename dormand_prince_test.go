@@ -0,0 +1,130 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// countingDynamics wraps a dynamics function and counts its evaluations
+func countingDynamics(dynamics DynamicsFunction, count *int) DynamicsFunction {
+	return func(state *AircraftState) (*StateDerivatives, error) {
+		*count++
+		return dynamics(state)
+	}
+}
+
+func TestDormandPrinceIntegrator(t *testing.T) {
+	t.Run("Accurate Adaptive Steps", func(t *testing.T) {
+		problem := NewOscillatorAccuracyProblem(10.0, 1.0, 2*math.Pi)
+		dp := NewDormandPrinceIntegrator(problem.Dynamics)
+		dp.Tolerance = 1e-8
+
+		state := problem.Initial()
+		dt := 0.05
+		for state.Time < problem.Duration-1e-9 {
+			next, suggested, err := dp.Step(state, math.Min(dt, problem.Duration-state.Time))
+			if err != nil {
+				t.Fatalf("Step failed: %v", err)
+			}
+			state, dt = next, suggested
+		}
+		assertApproxEqual(t, state.Time, problem.Duration, 1e-9)
+		if e := problem.Error(state, state.Time); e > 1e-6 {
+			t.Errorf("Error %.3g after one period, expected within 1e-6", e)
+		}
+		assertEqual(t, dp.Statistics.RejectedSteps, 0)
+		assertEqual(t, len(dp.Warnings), 0)
+	})
+
+	t.Run("Frame Steps Through Integrator", func(t *testing.T) {
+		problem := NewTurnAccuracyProblem(100.0, 0.2, 10.0)
+		dp := NewDormandPrinceIntegrator(problem.Dynamics)
+		var rk4 Integrator = NewTrueRK4Integrator(problem.Dynamics)
+
+		dt := 0.1
+		dpSample, err := runAccuracySample(problem, dp, dt)
+		if err != nil {
+			t.Fatalf("Dormand-Prince: %v", err)
+		}
+		rk4Sample, err := runAccuracySample(problem, rk4, dt)
+		if err != nil {
+			t.Fatalf("RK4: %v", err)
+		}
+		t.Logf("Max error at dt=%.2f: Dormand-Prince %.3g m, RK4 %.3g m", dt, dpSample.MaxError, rk4Sample.MaxError)
+		if dpSample.MaxError >= rk4Sample.MaxError {
+			t.Errorf("Fifth-order steps should beat RK4: %.3g m against %.3g m", dpSample.MaxError, rk4Sample.MaxError)
+		}
+	})
+
+	t.Run("Six Evaluations Per Smooth Step", func(t *testing.T) {
+		problem := NewTurnAccuracyProblem(100.0, 0.2, 10.0)
+		var evaluations int
+		dp := NewDormandPrinceIntegrator(countingDynamics(problem.Dynamics, &evaluations))
+		dp.MaxDt = 0.01 // One frame per step, as a fixed-step run would take
+
+		state := problem.Initial()
+		steps := 1000
+		for i := 0; i < steps; i++ {
+			next, _, err := dp.Step(state, 0.01)
+			if err != nil {
+				t.Fatalf("Step %d failed: %v", i, err)
+			}
+			state = next
+		}
+		assertEqual(t, dp.Statistics.AcceptedSteps, steps)
+		assertEqual(t, dp.Evaluations, evaluations)
+
+		// The first step evaluates its first stage; the rest reuse the last
+		assertEqual(t, evaluations, 1+6*steps)
+
+		// A fixed RK4 step evaluates the dynamics four times
+		ratio := float64(evaluations-1) / float64(4*steps)
+		if ratio > 1.5 {
+			t.Errorf("Adaptive step costs %.2f fixed RK4 steps, expected at most 1.5", ratio)
+		}
+	})
+
+	t.Run("Rejections Are Capped", func(t *testing.T) {
+		problem := NewOscillatorAccuracyProblem(10.0, 50.0, 1.0)
+		dp := NewDormandPrinceIntegrator(problem.Dynamics)
+		dp.Tolerance = 1e-12
+		dp.MinDt = 1e-4
+		dp.MaxRejections = 2
+
+		if _, _, err := dp.Step(problem.Initial(), 0.1); err != nil {
+			t.Fatalf("Step failed: %v", err)
+		}
+		assertEqual(t, dp.Statistics.RejectedSteps, 2)
+		assertEqual(t, dp.Statistics.CappedSteps, 1)
+		if len(dp.Warnings) != 1 || !strings.Contains(dp.Warnings[0], "above tolerance") {
+			t.Errorf("Expected one warning, got %q", dp.Warnings)
+		}
+	})
+}
+
+// BenchmarkSmoothFlightStep compares one step of the fixed RK4 integrator,
+// with the dynamics evaluated for its first stage, against one adaptive
+// Dormand-Prince step. The adaptive step costs about 1.5 fixed steps.
+func BenchmarkSmoothFlightStep(b *testing.B) {
+	problem := NewTurnAccuracyProblem(100.0, 0.2, 10.0)
+	dt := 0.01
+
+	b.Run("Fixed RK4", func(b *testing.B) {
+		rk4 := NewTrueRK4Integrator(problem.Dynamics)
+		state := problem.Initial()
+		for i := 0; i < b.N; i++ {
+			derivatives, _ := problem.Dynamics(state)
+			state = rk4.Integrate(state, derivatives, dt)
+		}
+	})
+
+	b.Run("Dormand-Prince", func(b *testing.B) {
+		dp := NewDormandPrinceIntegrator(problem.Dynamics)
+		dp.MaxDt = dt
+		state := problem.Initial()
+		for i := 0; i < b.N; i++ {
+			state, _, _ = dp.Step(state, dt)
+		}
+	})
+}
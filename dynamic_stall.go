@@ -0,0 +1,248 @@
+// Dynamic Stall
+// A first-order dynamic stall model in the manner of Leishman and
+// Beddoes: in a rapid pitch-up the flow separates later than the static
+// tables say and a leading-edge vortex carries extra lift aft over the
+// wing, overshooting the static CLmax and delaying the pitch break. It
+// corrects the table-derived lift and pitching moment of the whole wing.
+
+package main
+
+import "math"
+
+// Default time constants of the dynamic stall model, in semi-chords of
+// travel, Leishman and Beddoes' values for a thin aerofoil
+const (
+	DefaultStallPressureLag   = 1.7
+	DefaultStallSeparationLag = 3.0
+	DefaultStallVortexDecay   = 6.0
+	DefaultStallVortexTransit = 7.0
+)
+
+// Dynamic stall constants: the separation point below which the
+// leading-edge vortex forms, and how far aft of the quarter chord it has
+// moved, in chords, by the time it passes the trailing edge
+const (
+	stallVortexOnset  = 0.7
+	stallVortexTravel = 0.4
+)
+
+// DynamicStall is the unsteady separation of the wing. The separation
+// point f, 1 attached and 0 fully separated, follows the static one the
+// tables give through two lags, one for the pressure and one for the
+// boundary layer, so the effective stall angle rises with the alpha rate.
+// The lift is Kirchhoff's, CLattached·((1+√f)/2)², blended between the
+// attached and separated flow by f; while the flow separates under rising
+// alpha the lift it loses is shed into a vortex that decays as it
+// crosses the chord. The tables' pitching moment is taken as the attached
+// flow's: separation moves the centre of pressure aft, and the vortex
+// with it as it travels.
+//
+// The time constants are in semi-chords of travel, the wing's own time,
+// so one set serves at any airspeed. A zero time constant is no lag.
+type DynamicStall struct {
+	PressureLag   float64 // Tp: lag of the separation behind alpha
+	SeparationLag float64 // Tf: lag of the boundary layer's separation point
+	VortexDecay   float64 // Tv: decay of the vortex lift
+	VortexTransit float64 // Tvl: time for the vortex to cross the chord
+
+	// Aft shift of the centre of pressure at full separation (chords)
+	MomentShift float64
+
+	// Attached-flow lift curve of a configuration's tables: slope (per
+	// rad, the Helmbold estimate from the aspect ratio when 0) and
+	// zero-lift alpha (rad). The simplified model knows its own.
+	LiftSlope     float64
+	ZeroLiftAlpha float64
+
+	state dynamicStallState
+}
+
+// dynamicStallState is the lag states at the time they were last advanced
+type dynamicStallState struct {
+	time, alpha float64
+	pressure    float64 // f': the static separation point lagged for the pressure
+	separation  float64 // f'': f' lagged for the boundary layer
+	shed        float64 // Lift lost to separation at the last update
+	vortex      float64 // Vortex lift coefficient
+	vortexTime  float64 // Semi-chords since the vortex formed; 0 without one
+	started     bool
+}
+
+// NewDynamicStall returns a dynamic stall model with the default time
+// constants and a centre of pressure moving a fifth of the chord aft
+func NewDynamicStall() *DynamicStall {
+	return &DynamicStall{
+		PressureLag:   DefaultStallPressureLag,
+		SeparationLag: DefaultStallSeparationLag,
+		VortexDecay:   DefaultStallVortexDecay,
+		VortexTransit: DefaultStallVortexTransit,
+		MomentShift:   0.2,
+	}
+}
+
+// Reset restarts the model from the static separation at the next state
+// it is given. It restarts on its own at a state earlier than its last.
+func (ds *DynamicStall) Reset() {
+	ds.state = dynamicStallState{}
+}
+
+// Separation returns the lagged separation point, 1 with the flow attached
+func (ds *DynamicStall) Separation() float64 {
+	if !ds.state.started {
+		return 1
+	}
+	return ds.state.separation
+}
+
+// VortexLift returns the lift coefficient the vortex carries
+func (ds *DynamicStall) VortexLift() float64 {
+	return ds.state.vortex
+}
+
+// clone returns a copy of the model with its state
+func (ds *DynamicStall) clone() *DynamicStall {
+	c := *ds
+	return &c
+}
+
+// kirchhoff returns the share of the attached lift kept with the flow
+// separated at f
+func kirchhoff(f float64) float64 {
+	k := (1 + math.Sqrt(f)) / 2
+	return k * k
+}
+
+// staticSeparation inverts Kirchhoff's lift for the separation point of
+// a static lift coefficient: 1 where the tables give the attached lift or
+// more, 0 where they give a quarter of it or less
+func staticSeparation(attached, static float64) float64 {
+	if math.Abs(attached) < 1e-6 {
+		return 1
+	}
+	ratio := static / attached
+	switch {
+	case ratio >= 1:
+		return 1
+	case ratio <= 0.25:
+		return 0
+	}
+	root := 2*math.Sqrt(ratio) - 1
+	return root * root
+}
+
+// stallLag returns the share of a first-order lag's error left after ds
+func stallLag(ds, constant float64) float64 {
+	if constant <= 0 {
+		return 0
+	}
+	return math.Exp(-ds / constant)
+}
+
+// advance moves the lag states to a later time, at the attached and
+// static lift coefficients of alpha (rad), over a wing of chord (m) at an
+// airspeed (m/s). A state earlier than the last restarts the model.
+func (ds *DynamicStall) advance(time, alpha, attached, static, airspeed, chord float64) {
+	s := &ds.state
+	fs := staticSeparation(attached, static)
+	if !s.started || time < s.time {
+		*s = dynamicStallState{
+			time: time, alpha: alpha, pressure: fs, separation: fs,
+			shed: attached * (1 - kirchhoff(fs)), started: true,
+		}
+		return
+	}
+	dt := time - s.time
+	if dt <= 0 || chord <= 0 {
+		return
+	}
+	travel := 2 * airspeed * dt / chord
+	s.pressure = fs + (s.pressure-fs)*stallLag(travel, ds.PressureLag)
+	s.separation = s.pressure + (s.separation-s.pressure)*stallLag(travel, ds.SeparationLag)
+
+	// The vortex forms once the flow has separated far enough, and takes
+	// up the lift shed while alpha grows until it leaves the trailing edge
+	shed := attached * (1 - kirchhoff(s.separation))
+	if s.separation < stallVortexOnset {
+		s.vortexTime += travel
+	} else {
+		s.vortexTime = 0
+	}
+	rising := (alpha-s.alpha)*alpha > 0
+	decay := stallLag(travel, ds.VortexDecay)
+	s.vortex *= decay
+	if rising && s.vortexTime > 0 && s.vortexTime <= ds.VortexTransit {
+		s.vortex += (shed - s.shed) * math.Sqrt(decay)
+	}
+	s.time, s.alpha, s.shed = time, alpha, shed
+}
+
+// correction returns the increments to the tables' lift and pitching
+// moment coefficients at the attached and static lift coefficients of the
+// current alpha, from the lag states as they are
+func (ds *DynamicStall) correction(attached, static float64) (dCL, dCm float64) {
+	s := &ds.state
+	if !s.started {
+		return 0, 0
+	}
+	fs := staticSeparation(attached, static)
+	separated := attached * kirchhoff(s.separation)
+	dCL = separated - attached*kirchhoff(fs) + s.vortex
+
+	travel := 0.0
+	if ds.VortexTransit > 0 {
+		travel = math.Min(s.vortexTime/ds.VortexTransit, 1)
+	}
+	position := stallVortexTravel * (1 - math.Cos(math.Pi*travel)) / 2
+	dCm = -ds.MomentShift*separated*(1-s.separation) - position*s.vortex
+	return dCL, dCm
+}
+
+// Apply advances the model to a state, over a wing of chord (m), and
+// returns the increments to the tables' lift and pitching moment
+// coefficients. attached and static are the attached-flow and the tables'
+// lift coefficients at the state's alpha.
+func (ds *DynamicStall) Apply(state *AircraftState, attached, static, chord float64) (dCL, dCm float64) {
+	ds.advance(state.Time, state.Alpha, attached, static, state.TrueAirspeed, chord)
+	return ds.correction(attached, static)
+}
+
+// addDynamicStall corrects the tables' lift and pitching moment for the
+// dynamic stall, publishing the model's state
+func (calc *ForcesMomentsCalculator) addDynamicStall(state *AircraftState, properties map[string]float64, components *ForceMomentComponents) {
+	ds := calc.DynamicStall
+	if ds == nil {
+		return
+	}
+	// Parsed reference area and chord are in square feet and feet
+	qS := state.DynamicPressure * calc.Reference.WingArea * FT2_TO_M2
+	if qS < MinDynamicPressure {
+		return
+	}
+	lift, _, _ := WindAxesForces(state, components)
+	slope := ds.LiftSlope
+	if slope == 0 {
+		slope = liftCurveSlope(calc.Geometry.AspectRatio)
+	}
+	attached := slope * (state.Alpha - ds.ZeroLiftAlpha)
+	chord := calc.Reference.Chord * FT_TO_M
+	// Evaluations outside a step see the model as the step left it
+	var dCL, dCm float64
+	if calc.surfaceMotionHeld {
+		dCL, dCm = ds.correction(attached, lift/qS)
+	} else {
+		dCL, dCm = ds.Apply(state, attached, lift/qS, chord)
+	}
+
+	body := WindForcesToBody(state, dCL*qS, 0, 0)
+	components.Aerodynamic.Lift += body.Z
+	components.Aerodynamic.Drag += body.X
+	components.Aerodynamic.Side += body.Y
+	components.DynamicStall.CL = dCL
+	components.DynamicStall.Cm = dCm
+	components.DynamicStall.PitchMoment = dCm * qS * chord
+
+	properties["aero/dynamic-stall/separation"] = ds.Separation()
+	properties["aero/dynamic-stall/vortex-CL"] = ds.VortexLift()
+	properties["aero/dynamic-stall/delta-CL"] = dCL
+	properties["aero/dynamic-stall/delta-Cm"] = dCm
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// stallSweep is a sample of a prescribed alpha sweep
+type stallSweep struct {
+	alpha, CL, Cm, dCm float64 // alpha in degrees
+}
+
+// sweepAlpha pitches the simplified calculator's wing from 5° to 30° at a
+// steady rate (deg/s) at 40 m/s without a pitch rate term, returning the
+// lift and pitching moment coefficients every 0.1°
+func sweepAlpha(t *testing.T, calc *SimplifiedForcesMomentsCalculator, rate float64) []stallSweep {
+	t.Helper()
+	dt := 0.1 / rate
+	var samples []stallSweep
+	for i := 0; i <= 250; i++ {
+		alpha := (5 + 0.1*float64(i)) * DEG_TO_RAD
+		state := cruiseState(1000, 40, alpha)
+		state.Time = float64(i) * dt
+		components, err := calc.CalculateSimplifiedForces(state)
+		if err != nil {
+			t.Fatalf("CalculateSimplifiedForces: %v", err)
+		}
+		qS := state.DynamicPressure * calc.WingArea
+		samples = append(samples, stallSweep{
+			alpha: alpha * RAD_TO_DEG,
+			CL:    -components.Aerodynamic.Lift / qS,
+			Cm:    components.Moments.Pitch / (qS * calc.Chord),
+			dCm:   components.DynamicStall.Cm,
+		})
+	}
+	return samples
+}
+
+// momentBreak returns the alpha (deg) where the pitching moment first falls
+// 0.1 below the attached flow's linear trend, or 0 if it does not
+func momentBreak(samples []stallSweep) float64 {
+	for _, s := range samples {
+		if s.dCm < -0.1 {
+			return s.alpha
+		}
+	}
+	return 0
+}
+
+func maxCL(samples []stallSweep) (CL, alpha float64) {
+	for _, s := range samples {
+		if s.CL > CL {
+			CL, alpha = s.CL, s.alpha
+		}
+	}
+	return CL, alpha
+}
+
+func TestDynamicStall(t *testing.T) {
+	t.Run("Off By Default", func(t *testing.T) {
+		calc := NewSimplifiedCalculator()
+		if calc.DynamicStall != nil {
+			t.Fatal("Dynamic stall should be off by default")
+		}
+		if NewForcesMomentsCalculator(loadP51DConfig(t)).DynamicStall != nil {
+			t.Fatal("Dynamic stall should be off by default")
+		}
+		for _, s := range sweepAlpha(t, calc, 50) {
+			if s.dCm != 0 {
+				t.Fatalf("No increment expected without the model at %.1f°", s.alpha)
+			}
+		}
+	})
+
+	t.Run("Rapid Pitch Up", func(t *testing.T) {
+		static := sweepAlpha(t, NewSimplifiedCalculator(), 50)
+		slowCalc, fastCalc := NewSimplifiedCalculator(), NewSimplifiedCalculator()
+		slowCalc.DynamicStall, fastCalc.DynamicStall = NewDynamicStall(), NewDynamicStall()
+		slow := sweepAlpha(t, slowCalc, 1)
+		fast := sweepAlpha(t, fastCalc, 50)
+
+		staticMax, _ := maxCL(static)
+		slowMax, slowAlpha := maxCL(slow)
+		fastMax, fastAlpha := maxCL(fast)
+		slowBreak, fastBreak := momentBreak(slow), momentBreak(fast)
+		t.Logf("CLmax: static %.3f, 1°/s %.3f at %.1f°, 50°/s %.3f at %.1f°", staticMax, slowMax, slowAlpha, fastMax, fastAlpha)
+		t.Logf("Moment break: 1°/s %.1f°, 50°/s %.1f°", slowBreak, fastBreak)
+
+		// The slow sweep follows the static curve, but for the few steps
+		// the flow takes to separate after the static lift drops at 18°
+		for i, s := range slow {
+			if s.alpha >= 18 && s.alpha < 18.5 {
+				continue
+			}
+			if math.Abs(s.CL-static[i].CL) > 0.05 {
+				t.Errorf("At 1°/s CL %.3f at %.1f°, static %.3f", s.CL, s.alpha, static[i].CL)
+			}
+		}
+		if fastMax <= staticMax+0.1 {
+			t.Errorf("At 50°/s the lift should overshoot the static CLmax %.3f, got %.3f", staticMax, fastMax)
+		}
+		if fastAlpha <= slowAlpha+2 {
+			t.Errorf("At 50°/s the lift should peak past %.1f°, got %.1f°", slowAlpha, fastAlpha)
+		}
+		if slowBreak == 0 || fastBreak <= slowBreak+2 {
+			t.Errorf("At 50°/s the moment should break later than %.1f°, got %.1f°", slowBreak, fastBreak)
+		}
+		// Once the vortex has passed the flow settles to the static values
+		last := fast[len(fast)-1]
+		settled := fastCalc.DynamicStall
+		state := cruiseState(1000, 40, last.alpha*DEG_TO_RAD)
+		for i := 0; i < 200; i++ {
+			state.Time = 0.5 + float64(i)*0.01
+			fastCalc.CalculateSimplifiedForces(state)
+		}
+		components, _ := fastCalc.CalculateSimplifiedForces(state)
+		assertApproxEqual(t, components.DynamicStall.CL, 0, 1e-3)
+		assertApproxEqual(t, settled.VortexLift(), 0, 1e-3)
+	})
+
+	t.Run("Reset", func(t *testing.T) {
+		calc := NewSimplifiedCalculator()
+		calc.DynamicStall = NewDynamicStall()
+		sweepAlpha(t, calc, 50)
+		if calc.DynamicStall.Separation() > 0.9 {
+			t.Fatalf("The flow should be separating, at %.3f", calc.DynamicStall.Separation())
+		}
+		calc.DynamicStall.Reset()
+		assertEqual(t, calc.DynamicStall.Separation(), 1.0)
+		assertEqual(t, calc.DynamicStall.VortexLift(), 0.0)
+
+		// A state earlier than the last restarts from the static flow
+		sweepAlpha(t, calc, 50)
+		components, _ := calc.CalculateSimplifiedForces(cruiseState(1000, 40, 25*DEG_TO_RAD))
+		assertEqual(t, components.DynamicStall.CL, 0.0)
+		assertEqual(t, calc.DynamicStall.VortexLift(), 0.0)
+	})
+
+	t.Run("Configuration Calculator", func(t *testing.T) {
+		calc := NewForcesMomentsCalculator(loadP51DConfig(t))
+		calc.DynamicStall = NewDynamicStall()
+		session := calc.NewSession()
+		if session.DynamicStall == calc.DynamicStall {
+			t.Fatal("A session should have its own dynamic stall state")
+		}
+
+		for i := 0; i <= 100; i++ {
+			state := cruiseState(1000, 60, (5+0.25*float64(i))*DEG_TO_RAD)
+			state.Time = float64(i) * 0.005
+			if _, err := session.CalculateForcesMoments(state); err != nil {
+				t.Fatalf("CalculateForcesMoments: %v", err)
+			}
+		}
+		if !session.DynamicStall.state.started {
+			t.Fatal("The session's model should have advanced")
+		}
+		assertEqual(t, calc.DynamicStall.state.started, false)
+		if session.Properties.Get("aero/dynamic-stall/separation") != session.DynamicStall.Separation() {
+			t.Error("The separation should be published")
+		}
+
+		// Evaluations outside a step leave the lag states as they are
+		held := *session.DynamicStall
+		state := cruiseState(1000, 60, 40*DEG_TO_RAD)
+		state.Time = 1
+		release := session.holdSurfaceMotion()
+		session.CalculateForcesMoments(state)
+		release()
+		assertEqual(t, session.DynamicStall.state, held.state)
+	})
+}
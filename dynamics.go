@@ -0,0 +1,263 @@
+// Dynamics
+// The derivatives of the aircraft state as a function of the state alone,
+// so the integrators that take intermediate stages can evaluate the forces
+// at each of them rather than extrapolating those of the step's start
+
+package main
+
+import "reflect"
+
+// Dynamics gives the state derivatives, and the forces and moments behind
+// them, at any state. Derivatives has no side effects: it may be called at
+// the integrator's stages, or twice on the same state, without disturbing
+// the property tree, the FCS or the statistics.
+type Dynamics interface {
+	Derivatives(state *AircraftState) (*StateDerivatives, *ForceMomentComponents, error)
+}
+
+// StageIntegrator is an integrator that evaluates the dynamics at its
+// intermediate stages, such as TrueRK4Integrator and DormandPrinceIntegrator
+type StageIntegrator interface {
+	Integrator
+	SetDynamics(dynamics DynamicsFunction)
+}
+
+// DynamicsFunc adapts dynamics to the integrators' DynamicsFunction
+func DynamicsFunc(dynamics Dynamics) DynamicsFunction {
+	return func(state *AircraftState) (*StateDerivatives, error) {
+		derivatives, _, err := dynamics.Derivatives(state)
+		return derivatives, err
+	}
+}
+
+// integrateDynamics advances state by dt with the derivatives at its start,
+// handing the dynamics to the integrator first when it evaluates stages
+func integrateDynamics(integrator Integrator, dynamics Dynamics, state *AircraftState, derivatives *StateDerivatives, dt float64) *AircraftState {
+	if stages, ok := integrator.(StageIntegrator); ok {
+		stages.SetDynamics(DynamicsFunc(dynamics))
+	}
+	return integrator.Integrate(state, derivatives, dt)
+}
+
+// FCSPolicy is how an engine with an FCS treats it at the integrator's
+// intermediate stages
+type FCSPolicy int
+
+const (
+	// FCSFrozen holds the surface positions the FCS commanded at the start
+	// of the step through its stages
+	FCSFrozen FCSPolicy = iota
+
+	// FCSReexecuted runs the FCS again on each stage's state, from where it
+	// began the step, so the surfaces respond within the step. The FCS is
+	// returned to the end of the step afterwards.
+	FCSReexecuted
+)
+
+func (p FCSPolicy) String() string {
+	if p == FCSReexecuted {
+		return "re-executed"
+	}
+	return "frozen"
+}
+
+// Derivatives returns the derivatives at a state, leaving the property tree
+// as it found it
+func (calc *ForcesMomentsCalculator) Derivatives(state *AircraftState) (*StateDerivatives, *ForceMomentComponents, error) {
+	if calc.Properties == nil {
+		calc.Properties = newEmptyPropertyManager()
+	}
+	defer calc.Properties.restore(calc.Properties.checkpoint())
+	defer calc.holdSurfaceMotion()()
+
+	components, err := calc.CalculateForcesMoments(state)
+	if err != nil {
+		return nil, nil, err
+	}
+	return calc.CalculateStateDerivatives(state, components), components, nil
+}
+
+// Derivatives returns the derivatives at a state
+func (calc *SimplifiedForcesMomentsCalculator) Derivatives(state *AircraftState) (*StateDerivatives, *ForceMomentComponents, error) {
+	components, err := calc.CalculateSimplifiedForces(state)
+	if err != nil {
+		return nil, nil, err
+	}
+	return calc.CalculateStateDerivatives(state, components), components, nil
+}
+
+// Derivatives returns the derivatives at a state with the ground reaction,
+// leaving the property tree as it found it
+func (fde *FlightDynamicsEngine) Derivatives(state *AircraftState) (*StateDerivatives, *ForceMomentComponents, error) {
+	if fde.Calculator.Properties == nil {
+		fde.Calculator.Properties = newEmptyPropertyManager()
+	}
+	defer fde.Calculator.Properties.restore(fde.Calculator.Properties.checkpoint())
+	defer fde.Calculator.holdSurfaceMotion()()
+	return fde.derivatives(state)
+}
+
+// derivatives is Derivatives leaving the step's properties in the tree
+func (fde *FlightDynamicsEngine) derivatives(state *AircraftState) (*StateDerivatives, *ForceMomentComponents, error) {
+	components, err := fde.Calculator.CalculateForcesMoments(state)
+	if err != nil {
+		return nil, nil, err
+	}
+	components.addGroundReaction(fde.Gear, state)
+	return fde.Calculator.CalculateStateDerivatives(state, components), components, nil
+}
+
+// Derivatives returns the derivatives at a state with the ground reaction
+// and the gear's CG shift
+func (sfde *SimplifiedFlightDynamicsEngine) Derivatives(state *AircraftState) (*StateDerivatives, *ForceMomentComponents, error) {
+	components, err := sfde.Calculator.CalculateSimplifiedForces(state)
+	if err != nil {
+		return nil, nil, err
+	}
+	components.addGroundReaction(sfde.Gear, state)
+	if sfde.GearSystem != nil {
+		sfde.GearSystem.shiftMoments(components, state.Gear.Transition, sfde.Calculator.Mass)
+	}
+	return sfde.Calculator.CalculateStateDerivatives(state, components), components, nil
+}
+
+// Derivatives returns the derivatives at a state under the engine's
+// StagePolicy, leaving the FCS, the autopilot and the property tree as it
+// found them. Re-executed outside a step, the FCS runs from where it is
+// for one period of its default rate.
+func (engine *FlightDynamicsEngineWithFCS) Derivatives(state *AircraftState) (*StateDerivatives, *ForceMomentComponents, error) {
+	if engine.StagePolicy == FCSFrozen {
+		return engine.Calculator.Derivatives(state)
+	}
+
+	now := engine.checkpointControls()
+	defer now.restore()
+	defer engine.Calculator.holdSurfaceMotion()()
+	start, dt := engine.stepStart, engine.stepDt
+	if start == nil {
+		start, dt = now, 1/engine.FCS.DefaultRate
+	}
+	start.restore()
+
+	// The FCS writes its surfaces into the state it runs on
+	state = state.Copy()
+	components, err := engine.Calculator.calculateForcesMoments(state, func() {
+		engine.executeControls(state, dt)
+		engine.ApplyFCSOutputsToState(state)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return engine.Calculator.CalculateStateDerivatives(state, components), components, nil
+}
+
+// controlsCheckpoint is the execution state of an engine's FCS and
+// autopilot and of the property trees they and the calculator use
+type controlsCheckpoint struct {
+	systems []fcsCheckpoint
+	trees   map[*PropertyManager]propertyCheckpoint
+}
+
+// checkpointControls records the FCS, the autopilot and their trees
+func (engine *FlightDynamicsEngineWithFCS) checkpointControls() *controlsCheckpoint {
+	c := &controlsCheckpoint{trees: make(map[*PropertyManager]propertyCheckpoint)}
+	for _, fcs := range []*FlightControlSystem{engine.FCS, engine.Autopilot} {
+		if fcs != nil {
+			c.systems = append(c.systems, fcs.checkpoint())
+			c.trees[fcs.Properties] = fcs.Properties.checkpoint()
+		}
+	}
+	if calc := engine.Calculator; calc.Properties != nil {
+		c.trees[calc.Properties] = calc.Properties.checkpoint()
+	}
+	return c
+}
+
+// restore returns the systems and trees to the checkpoint, which may be
+// restored again
+func (c *controlsCheckpoint) restore() {
+	for _, system := range c.systems {
+		system.restore()
+	}
+	for pm, tree := range c.trees {
+		pm.restore(tree)
+	}
+}
+
+// fcsCheckpoint is the execution state of an FCS: its time, statistics,
+// rate group schedules and the internal state of each component. The
+// components are copied shallowly, which covers the filter, integrator and
+// actuator states they hold by value.
+type fcsCheckpoint struct {
+	fcs        *FlightControlSystem
+	simTime    float64
+	executions int64
+	totalTime  float64
+	groups     map[*RateGroupScheduler]RateGroupScheduler
+	components map[ComponentProcessor]reflect.Value
+}
+
+func (fcs *FlightControlSystem) checkpoint() fcsCheckpoint {
+	c := fcsCheckpoint{
+		fcs:        fcs,
+		simTime:    fcs.SimTime,
+		executions: fcs.TotalExecutions,
+		totalTime:  fcs.TotalTime,
+		groups:     make(map[*RateGroupScheduler]RateGroupScheduler, len(fcs.RateGroups)),
+		components: make(map[ComponentProcessor]reflect.Value, len(fcs.Components)),
+	}
+	for _, group := range fcs.RateGroups {
+		c.groups[group] = *group
+	}
+	for _, component := range fcs.Components {
+		value := reflect.ValueOf(component)
+		if value.Kind() != reflect.Pointer || value.Elem().Kind() != reflect.Struct {
+			continue
+		}
+		saved := reflect.New(value.Elem().Type()).Elem()
+		saved.Set(value.Elem())
+		c.components[component] = saved
+	}
+	return c
+}
+
+func (c fcsCheckpoint) restore() {
+	c.fcs.SimTime = c.simTime
+	c.fcs.TotalExecutions = c.executions
+	c.fcs.TotalTime = c.totalTime
+	for group, saved := range c.groups {
+		*group = saved
+	}
+	for component, saved := range c.components {
+		reflect.ValueOf(component).Elem().Set(saved)
+	}
+}
+
+// propertyCheckpoint is the contents of a property tree
+type propertyCheckpoint struct {
+	properties map[string]float64
+	gearUnits  int
+}
+
+func (pm *PropertyManager) checkpoint() propertyCheckpoint {
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+
+	properties := make(map[string]float64, len(pm.properties))
+	for name, value := range pm.properties {
+		properties[name] = value
+	}
+	return propertyCheckpoint{properties: properties, gearUnits: pm.stateGearUnits}
+}
+
+// restore returns the tree to the checkpoint without notifying listeners
+func (pm *PropertyManager) restore(c propertyCheckpoint) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+
+	clear(pm.properties)
+	for name, value := range c.properties {
+		pm.properties[name] = value
+	}
+	pm.stateGearUnits = c.gearUnits
+}
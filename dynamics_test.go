@@ -0,0 +1,142 @@
+package main
+
+import (
+	"maps"
+	"reflect"
+	"testing"
+)
+
+// treeValues returns every value in a property tree
+func treeValues(pm *PropertyManager) map[string]float64 {
+	values := make(map[string]float64)
+	for _, name := range pm.ListProperties() {
+		values[name] = pm.Get(name)
+	}
+	return values
+}
+
+func TestDynamics(t *testing.T) {
+	const dt = 1.0 / 60.0
+	newEngine := func(t *testing.T, policy FCSPolicy, integrator Integrator) *FlightDynamicsEngineWithFCS {
+		t.Helper()
+		engine, err := NewFlightDynamicsEngineWithFCS(loadP51DConfig(t), true)
+		if err != nil {
+			t.Fatalf("NewFlightDynamicsEngineWithFCS: %v", err)
+		}
+		engine.StagePolicy = policy
+		if integrator != nil {
+			engine.Integrator = integrator
+		}
+		return engine
+	}
+	initialState := func() *AircraftState {
+		state := cruiseState(3000, 120, 0.02)
+		state.Controls.Elevator = -0.2
+		state.Controls.Aileron = 0.1
+		return state
+	}
+
+	t.Run("Repeatable", func(t *testing.T) {
+		simplified := NewSimplifiedFlightDynamicsEngine(NewRungeKutta4Integrator())
+		dynamics := map[string]Dynamics{
+			"calculator":            NewForcesMomentsCalculator(loadP51DConfig(t)),
+			"engine":                NewFlightDynamicsEngine(loadP51DConfig(t), NewRungeKutta4Integrator()),
+			"simplified calculator": simplified.Calculator,
+			"simplified engine":     simplified,
+			"frozen FCS":            newEngine(t, FCSFrozen, nil),
+			"re-executed FCS":       newEngine(t, FCSReexecuted, nil),
+		}
+		for name, d := range dynamics {
+			state := initialState()
+			first, _, err := d.Derivatives(state)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			second, _, err := d.Derivatives(state)
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			if *first != *second {
+				t.Errorf("%s: derivatives changed between calls, %+v then %+v", name, *first, *second)
+			}
+		}
+	})
+
+	t.Run("Side Effect Free", func(t *testing.T) {
+		// An engine probed before every step flies as one that is not
+		for _, policy := range []FCSPolicy{FCSFrozen, FCSReexecuted} {
+			probed, plain := newEngine(t, policy, nil), newEngine(t, policy, nil)
+			probedState, plainState := initialState(), initialState()
+			for i := 0; i < 30; i++ {
+				tree := treeValues(probed.FCS.Properties)
+				executions, simTime := probed.FCS.TotalExecutions, probed.FCS.SimTime
+				if _, _, err := probed.Derivatives(probedState); err != nil {
+					t.Fatalf("Derivatives: %v", err)
+				}
+				if !reflect.DeepEqual(treeValues(probed.FCS.Properties), tree) {
+					t.Fatalf("%s: Derivatives changed the property tree at step %d", policy, i)
+				}
+				assertEqual(t, probed.FCS.TotalExecutions, executions)
+				assertEqual(t, probed.FCS.SimTime, simTime)
+
+				var err error
+				if probedState, _, err = probed.RunSimulationStepWithFCS(probedState, dt); err != nil {
+					t.Fatalf("Step: %v", err)
+				}
+				if plainState, _, err = plain.RunSimulationStepWithFCS(plainState, dt); err != nil {
+					t.Fatalf("Step: %v", err)
+				}
+			}
+			if probedState.ControlSurfaces != plainState.ControlSurfaces || probedState.AngularRate != plainState.AngularRate || probedState.Velocity != plainState.Velocity {
+				t.Errorf("%s: the probed engine flew differently, %+v against %+v", policy, probedState.ControlSurfaces, plainState.ControlSurfaces)
+			}
+		}
+
+		// Nor does probing record statistics
+		engine := NewFlightDynamicsEngine(loadP51DConfig(t), NewRungeKutta4Integrator())
+		state, err := engine.Step(initialState(), dt)
+		if err != nil {
+			t.Fatalf("Step: %v", err)
+		}
+		before := *engine.Statistics
+		before.MaxPsByAltitude = maps.Clone(before.MaxPsByAltitude)
+		for i := 0; i < 2; i++ {
+			if _, _, err := engine.Derivatives(state); err != nil {
+				t.Fatalf("Derivatives: %v", err)
+			}
+		}
+		if !reflect.DeepEqual(*engine.Statistics, before) {
+			t.Errorf("Derivatives changed the statistics, %+v then %+v", before, *engine.Statistics)
+		}
+	})
+
+	t.Run("Stage Integrators", func(t *testing.T) {
+		// The engine hands its dynamics to integrators that evaluate stages
+		dp := NewDormandPrinceIntegrator(nil)
+		engine := NewFlightDynamicsEngine(loadP51DConfig(t), dp)
+		if _, err := engine.Step(initialState(), dt); err != nil {
+			t.Fatalf("Step: %v", err)
+		}
+		if dp.Evaluations == 0 {
+			t.Error("The integrator should have evaluated the engine's dynamics")
+		}
+
+		// and the FCS ends each step where it would without stages
+		for _, policy := range []FCSPolicy{FCSFrozen, FCSReexecuted} {
+			staged, single := newEngine(t, policy, NewTrueRK4Integrator(nil)), newEngine(t, policy, nil)
+			stagedState, singleState := initialState(), initialState()
+			for i := 0; i < 30; i++ {
+				var err error
+				if stagedState, _, err = staged.RunSimulationStepWithFCS(stagedState, dt); err != nil {
+					t.Fatalf("Step: %v", err)
+				}
+				if singleState, _, err = single.RunSimulationStepWithFCS(singleState, dt); err != nil {
+					t.Fatalf("Step: %v", err)
+				}
+			}
+			assertEqual(t, staged.FCS.TotalExecutions, single.FCS.TotalExecutions)
+			assertApproxEqual(t, staged.FCS.SimTime, 30*dt, 1e-9)
+			assertApproxEqual(t, stagedState.ControlSurfaces.Elevator, singleState.ControlSurfaces.Elevator, 1e-3)
+		}
+	})
+}
@@ -0,0 +1,173 @@
+// Engine State
+// Starting, running, shutting down and failing the engine, and the drag of
+// its propeller windmilling in the airflow when the engine is not driving it
+
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// EngineState is where an engine is in its start and shutdown sequence
+type EngineState int
+
+const (
+	EngineOff      EngineState = iota // Stopped; the propeller windmills
+	EngineStarting                    // Cranking on the starter toward idle
+	EngineRunning
+	EngineFailed // Stopped, and will not start again
+)
+
+func (s EngineState) String() string {
+	switch s {
+	case EngineStarting:
+		return "starting"
+	case EngineRunning:
+		return "running"
+	case EngineFailed:
+		return "failed"
+	}
+	return "off"
+}
+
+// Start sequence defaults
+const (
+	DefaultStartTime        = 4.0 // Cranking time from rest to idle (s)
+	DefaultStartMaxThrottle = 0.3 // Furthest open the throttle can be for a start
+)
+
+// Windmilling defaults for the P-51D propeller: the drag coefficient, on
+// the disk area, at the finest and coarsest pitch the governor sets, and
+// the advance ratio the propeller windmills at in each. A fine pitch blade
+// windmills faster and brakes the airflow harder.
+const (
+	WindmillDragFine      = 0.12
+	WindmillDragCoarse    = 0.04
+	windmillAdvanceFine   = 1.0
+	windmillAdvanceCoarse = 2.0
+)
+
+// Start begins cranking the engine, which runs once it reaches idle after
+// the engine's StartTime. It needs fuel, a mixture rich enough to fire and
+// the throttle no more than StartMaxThrottle open. Starting a starting or
+// running engine does nothing; a failed engine cannot be started.
+func (ps *PropulsionSystem) Start() error {
+	e := ps.Engine
+	switch e.State {
+	case EngineFailed:
+		return fmt.Errorf("engine %s has failed", e.Name)
+	case EngineStarting, EngineRunning:
+		return nil
+	}
+	switch {
+	case MixturePowerFactor(e.MixturePosition) == 0:
+		return fmt.Errorf("engine %s: mixture %.0f%% is too lean to start", e.Name, 100*e.MixturePosition)
+	case e.ThrottlePosition > e.StartMaxThrottle:
+		return fmt.Errorf("engine %s: throttle %.0f%% open, at most %.0f%% to start", e.Name, 100*e.ThrottlePosition, 100*e.StartMaxThrottle)
+	case ps.FuelSystem.TotalContents <= 0:
+		return fmt.Errorf("engine %s has no fuel", e.Name)
+	}
+	e.State, e.startElapsed = EngineStarting, 0
+	return nil
+}
+
+// Shutdown stops a starting or running engine, which then stays off until
+// started again rather than starting when the throttle is opened
+func (ps *PropulsionSystem) Shutdown() {
+	e := ps.Engine
+	if e.State == EngineStarting || e.State == EngineRunning {
+		e.State, e.IsRunning = EngineOff, false
+	}
+	e.AutoStart = false
+}
+
+// Fail stops the engine for good
+func (ps *PropulsionSystem) Fail() {
+	ps.Engine.State, ps.Engine.IsRunning = EngineFailed, false
+}
+
+// updateEngineState advances the start sequence and stops an engine whose
+// mixture has been cut off or which has run out of fuel, which fails it
+func (ps *PropulsionSystem) updateEngineState(mixtureFactor, dt float64) {
+	e := ps.Engine
+	fuel := ps.FuelSystem.TotalContents > 0
+	switch e.State {
+	case EngineOff:
+		if e.AutoStart && e.ThrottlePosition > 0.1 && mixtureFactor > 0 && fuel {
+			e.State = EngineRunning
+		}
+	case EngineStarting:
+		e.startElapsed += dt
+		switch {
+		case mixtureFactor == 0:
+			e.State = EngineOff
+		case e.startElapsed >= e.StartTime:
+			e.State = EngineRunning
+		}
+	case EngineRunning:
+		switch {
+		case mixtureFactor == 0:
+			e.State = EngineOff // Idle cutoff or too lean to fire
+		case !fuel:
+			e.State = EngineFailed
+		}
+	}
+	e.IsRunning = e.State == EngineRunning
+}
+
+// stoppedRPM returns the RPM of an engine that is not running: the
+// propeller windmilling, or the starter cranking it toward idle
+func (ps *PropulsionSystem) stoppedRPM() float64 {
+	e, p := ps.Engine, ps.Propeller
+	rpm := 0.0
+	if diameter := p.Diameter * FT_TO_M; diameter > 0 && p.Airspeed > 0 {
+		advance := windmillAdvanceCoarse + e.AdvancePosition*(windmillAdvanceFine-windmillAdvanceCoarse)
+		rpm = math.Min(e.MaxRPM, 60*p.Airspeed/(advance*diameter))
+	}
+	if e.State == EngineStarting && e.StartTime > 0 {
+		rpm = math.Max(rpm, e.IdleRPM*math.Min(1, e.startElapsed/e.StartTime))
+	}
+	return rpm
+}
+
+// WindmillDrag returns the drag (N) of the propeller windmilling in its
+// airflow, by a flat plate estimate on its disk area with the drag
+// coefficient for the pitch the propeller lever sets
+func (ps *PropulsionSystem) WindmillDrag() float64 {
+	p := ps.Propeller
+	radius := p.Diameter * FT_TO_M / 2
+	cd := p.WindmillDragCoarse + ps.Engine.AdvancePosition*(p.WindmillDragFine-p.WindmillDragCoarse)
+	return 0.5 * p.Density * p.Airspeed * p.Airspeed * cd * math.Pi * radius * radius
+}
+
+// ApplyProperties starts or shuts down the engine when its set-running
+// property, under propulsion/engine[i] or, for the first engine, unindexed,
+// has been set to other than UpdateProperties published. A start whose
+// conditions are not met is not made, and the property reads 0 again once
+// published.
+func (ps *PropulsionSystem) ApplyProperties(properties *PropertyManager) {
+	names := []string{IndexedPropertyName("propulsion/engine", ps.Index, "set-running")}
+	if ps.Index == 0 {
+		names = append(names, "propulsion/engine/set-running")
+	}
+	published := boolToFloat(ps.commandedRunning())
+	for _, name := range names {
+		value, ok := properties.GetSafe(name)
+		if !ok || value == published {
+			continue
+		}
+		if value >= 0.5 {
+			_ = ps.Start()
+		} else {
+			ps.Shutdown()
+		}
+		return
+	}
+}
+
+// commandedRunning reports whether the engine is starting or running,
+// which is what set-running publishes
+func (ps *PropulsionSystem) commandedRunning() bool {
+	return ps.Engine.State == EngineStarting || ps.Engine.State == EngineRunning
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEngineState(t *testing.T) {
+	t.Run("Start Sequence", func(t *testing.T) {
+		ps := NewPropulsionSystem()
+		ps.Engine.AutoStart = false
+		ps.UpdateControls(EngineControls{Throttle: 0.2, Mixture: 1, Advance: 1}, 0.01)
+		assertEqual(t, ps.Engine.State, EngineOff)
+
+		// Preconditions
+		ps.Engine.ThrottlePosition = 0.5
+		if err := ps.Start(); err == nil || !strings.Contains(err.Error(), "throttle") {
+			t.Errorf("A start with the throttle half open should be refused, got %v", err)
+		}
+		ps.Engine.ThrottlePosition, ps.Engine.MixturePosition = 0.2, 0
+		if err := ps.Start(); err == nil || !strings.Contains(err.Error(), "lean") {
+			t.Errorf("A start at idle cutoff should be refused, got %v", err)
+		}
+
+		// The starter cranks toward idle for the start time, then it runs
+		controls := EngineControls{Throttle: 0.2, Mixture: 1, Advance: 1}
+		ps.UpdateControls(controls, 0.01)
+		if err := ps.Start(); err != nil {
+			t.Fatalf("Start: %v", err)
+		}
+		previous := 0.0
+		for elapsed := 0.5; elapsed < DefaultStartTime; elapsed += 0.5 {
+			ps.UpdateControls(controls, 0.5)
+			assertEqual(t, ps.Engine.State, EngineStarting)
+			assertEqual(t, ps.Engine.IsRunning, false)
+			if ps.Engine.RPM <= previous || ps.Engine.RPM >= ps.Engine.IdleRPM || ps.Propeller.Thrust > 0 {
+				t.Fatalf("t=%.1f s: cranking should ramp the RPM toward idle without thrust, %.0f RPM, %.1f lbs", elapsed, ps.Engine.RPM, ps.Propeller.Thrust)
+			}
+			previous = ps.Engine.RPM
+		}
+		ps.UpdateControls(controls, 0.5)
+		assertEqual(t, ps.Engine.State, EngineRunning)
+		assertEqual(t, ps.Engine.IsRunning, true)
+		if ps.Propeller.Thrust <= 0 {
+			t.Error("The running engine should give thrust")
+		}
+
+		// Shut down, it stays off with the throttle opened; failed, it
+		// will not start
+		ps.Shutdown()
+		ps.UpdateControls(EngineControls{Throttle: 1, Mixture: 1, Advance: 1}, 0.01)
+		assertEqual(t, ps.Engine.State, EngineOff)
+		assertApproxEqual(t, ps.Engine.PowerHP, 0, 1e-12)
+		ps.Fail()
+		ps.UpdateControls(controls, 0.01)
+		if err := ps.Start(); err == nil {
+			t.Error("A failed engine should not start")
+		}
+		assertEqual(t, ps.Engine.State, EngineFailed)
+	})
+
+	t.Run("Fuel Starvation", func(t *testing.T) {
+		ps := NewPropulsionSystem()
+		ps.Propeller.Airspeed, ps.Propeller.Density = 100, 1.225
+		ps.Update(0.7, 0.01)
+		assertEqual(t, ps.Engine.State, EngineRunning)
+		for _, tank := range ps.FuelSystem.Tanks {
+			tank.Contents = 0
+		}
+		ps.FuelSystem.TotalContents = 0
+
+		ps.Update(0.7, 0.01)
+		assertEqual(t, ps.Engine.State, EngineFailed)
+		assertApproxEqual(t, ps.GetThrust(), -ps.WindmillDrag(), 1e-6)
+		if ps.Engine.RPM <= 0 || ps.Engine.RPM >= ps.Engine.MaxRPM {
+			t.Errorf("The starved engine's propeller should windmill, %.0f RPM", ps.Engine.RPM)
+		}
+
+		// Coarse pitch windmills slower with less drag
+		fine := ps.WindmillDrag()
+		ps.UpdateControls(EngineControls{Throttle: 0.7, Mixture: 1, Advance: 0}, 0.01)
+		if ps.WindmillDrag() >= fine/2 {
+			t.Errorf("Coarse pitch drag %.0f N should be well below fine pitch %.0f N", ps.WindmillDrag(), fine)
+		}
+	})
+
+	t.Run("Set Running Property", func(t *testing.T) {
+		engine, err := NewFlightDynamicsEngineWithPropulsion(loadP51DConfig(t), false, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		props := engine.FCS.Properties
+		state := cruiseState(1500, 100, 1.417*DEG_TO_RAD)
+		state.Controls = ControlInputs{Throttle: 0.2, Mixture: 1, Propeller: 1}
+		engine.Propulsion.Engine.AutoStart = false
+		step := func() {
+			t.Helper()
+			next, _, err := engine.RunSimulationStepWithPropulsion(state, 0.01)
+			if err != nil {
+				t.Fatalf("Step: %v", err)
+			}
+			state = next
+		}
+
+		step()
+		assertApproxEqual(t, props.Get("propulsion/engine/set-running"), 0, 1e-12)
+		props.Set("propulsion/engine[0]/set-running", 1)
+		step()
+		assertEqual(t, engine.Propulsion.Engine.State, EngineStarting)
+		assertApproxEqual(t, props.Get("propulsion/engine/set-running"), 1, 1e-12)
+
+		// The windmilling propeller's drag is published as negative thrust
+		props.Set("propulsion/engine/set-running", 0)
+		step()
+		assertEqual(t, engine.Propulsion.Engine.State, EngineOff)
+		if thrust := props.Get("propulsion/engine[0]/thrust-lbs"); thrust >= 0 {
+			t.Errorf("Windmilling at 100 m/s should publish a drag, got %.1f lbs", thrust)
+		}
+	})
+
+	t.Run("In-Flight Shutdown", func(t *testing.T) {
+		// Level at 100 m/s, the engine shut down: with a windmilling
+		// propeller, against the zero drag engine-off model
+		fly := func(windmill bool) []*AircraftState {
+			t.Helper()
+			engine := NewSimplifiedFlightDynamicsEngine(NewRungeKutta4Integrator())
+			state := trimLevelFlight(t, engine, 1000, 100)
+			state.Controls.Throttle = 0
+			engine.Calculator.External = NewExternalForces(nil)
+
+			// Attitude held, as the simplified model's short period wanders
+			inertia := &engine.Calculator.Inertia
+			inertia.XX, inertia.YY, inertia.ZZ = 1e9*inertia.XX, 1e9*inertia.YY, 1e9*inertia.ZZ
+
+			ps := NewPropulsionSystem()
+			ps.Update(0.7, 0.01)
+			ps.Shutdown()
+			if !windmill {
+				ps.Propeller.WindmillDragFine, ps.Propeller.WindmillDragCoarse = 0, 0
+			}
+			engine.Calculator.External.Add(&ExternalForce{
+				Name:      "propeller",
+				Direction: Vector3{X: 1},
+				Func: func(s *AircraftState) float64 {
+					ps.Propeller.Airspeed, ps.Propeller.Density = s.TrueAirspeed, s.Density
+					ps.Update(0, 0)
+					return ps.GetThrust()
+				},
+			})
+
+			states := []*AircraftState{state}
+			for i := 0; i < 200; i++ {
+				next, err := engine.Step(state, 0.01)
+				if err != nil {
+					t.Fatalf("Step: %v", err)
+				}
+				states = append(states, next)
+				state = next
+			}
+			return states
+		}
+		windmilling, clean := fly(true), fly(false)
+
+		// Over the first second the drag, near 5.9 kN, decelerates the
+		// 4100 kg aircraft by a further 1.4 m/s²
+		extra := (clean[100].TrueAirspeed - windmilling[100].TrueAirspeed) / 1.0
+		assertApproxEqual(t, extra, 5851/4100.0, 0.15)
+
+		// And slower, it sinks faster
+		for _, i := range []int{100, 200} {
+			sink, cleanSink := windmilling[i].GroundVelocity().Z, clean[i].GroundVelocity().Z
+			if sink <= cleanSink+0.1 {
+				t.Errorf("t=%.0f s: windmilling sink rate %.2f m/s should exceed %.2f m/s", windmilling[i].Time, sink, cleanSink)
+			}
+		}
+	})
+}
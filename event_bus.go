@@ -0,0 +1,206 @@
+// Simulation Event Bus
+// Callbacks on property threshold crossings, state predicates and sim time
+
+package main
+
+import (
+	"math"
+)
+
+// CrossingDirection selects which threshold crossings a watcher reports
+type CrossingDirection int
+
+const (
+	CrossingRising  CrossingDirection = iota // Value rising through the threshold
+	CrossingFalling                          // Value falling through the threshold
+	CrossingEither                           // Either direction
+)
+
+// EventCallback receives the state after the step in which an event
+// occurred, and its simulation time
+type EventCallback func(state *AircraftState, time float64)
+
+// EventWatcher is one registered watcher. Watchers repeat until removed
+// unless marked with Once.
+type EventWatcher struct {
+	callback EventCallback
+	once     bool
+	removed  bool
+
+	// check reports whether the watcher fires at a state; properties is
+	// only filled for watchers that use them
+	check          func(state *AircraftState, properties map[string]float64) bool
+	usesProperties bool
+}
+
+// Once makes the watcher remove itself after it first fires
+func (w *EventWatcher) Once() *EventWatcher {
+	w.once = true
+	return w
+}
+
+// EventBus evaluates watchers against each new state of a simulation, in
+// registration order. Watchers may be added and removed from within
+// callbacks; a watcher added during an evaluation is first evaluated at the
+// next one.
+type EventBus struct {
+	watchers   []*EventWatcher
+	properties map[string]float64 // Reused property map for crossing watchers
+}
+
+// NewEventBus creates an event bus with no watchers
+func NewEventBus() *EventBus {
+	return &EventBus{}
+}
+
+// OnPropertyCrossing fires when a property passes a threshold in the given
+// direction. After firing, a direction re-arms only once the value has
+// moved back past the threshold by the hysteresis, so noise about the
+// threshold does not fire it repeatedly. The first evaluation only records
+// which side of the threshold the value starts on.
+func (bus *EventBus) OnPropertyCrossing(name string, threshold float64, direction CrossingDirection, hysteresis float64, callback EventCallback) *EventWatcher {
+	hysteresis = math.Abs(hysteresis)
+	started := false
+	risingArmed, fallingArmed := false, false
+
+	w := &EventWatcher{callback: callback, usesProperties: true}
+	w.check = func(state *AircraftState, properties map[string]float64) bool {
+		value, _ := lookupProperty(properties, name)
+		if !started {
+			started = true
+			risingArmed = value < threshold
+			fallingArmed = value > threshold
+			return false
+		}
+
+		fired := false
+		if risingArmed && value >= threshold {
+			risingArmed = false
+			fired = direction != CrossingFalling
+		} else if !risingArmed && value <= threshold-hysteresis {
+			risingArmed = true
+		}
+		if fallingArmed && value <= threshold {
+			fallingArmed = false
+			fired = fired || direction != CrossingRising
+		} else if !fallingArmed && value >= threshold+hysteresis {
+			fallingArmed = true
+		}
+		return fired
+	}
+	return bus.add(w)
+}
+
+// OnPredicate fires on the rising edge of a predicate: at each evaluation
+// where it is true after being false. The first evaluation only records its
+// value, so a predicate that starts true does not fire.
+func (bus *EventBus) OnPredicate(predicate func(*AircraftState) bool, callback EventCallback) *EventWatcher {
+	started, previous := false, false
+
+	w := &EventWatcher{callback: callback}
+	w.check = func(state *AircraftState, _ map[string]float64) bool {
+		current := predicate(state)
+		edge := started && current && !previous
+		started, previous = true, current
+		return edge
+	}
+	return bus.add(w)
+}
+
+// OnTime fires at the first evaluation at or after simulation time at, and
+// then every interval seconds after that when interval is positive. A time
+// watcher without an interval fires once.
+func (bus *EventBus) OnTime(at, interval float64, callback EventCallback) *EventWatcher {
+	next := at
+
+	w := &EventWatcher{callback: callback, once: interval <= 0}
+	w.check = func(state *AircraftState, _ map[string]float64) bool {
+		if state.Time < next {
+			return false
+		}
+		if interval > 0 {
+			// Skip any intervals a long step passed over
+			for next <= state.Time {
+				next += interval
+			}
+		}
+		return true
+	}
+	return bus.add(w)
+}
+
+// OnStep fires at every evaluation, for observers that follow the whole
+// state stream
+func (bus *EventBus) OnStep(callback EventCallback) *EventWatcher {
+	w := &EventWatcher{callback: callback}
+	w.check = func(*AircraftState, map[string]float64) bool { return true }
+	return bus.add(w)
+}
+
+// Remove deregisters a watcher. It may be called from any callback,
+// including the watcher's own; a removed watcher does not fire again, even
+// later in the same evaluation.
+func (bus *EventBus) Remove(w *EventWatcher) {
+	w.removed = true
+}
+
+// Len returns the number of registered watchers
+func (bus *EventBus) Len() int {
+	n := 0
+	for _, w := range bus.watchers {
+		if !w.removed {
+			n++
+		}
+	}
+	return n
+}
+
+// add registers a watcher
+func (bus *EventBus) add(w *EventWatcher) *EventWatcher {
+	bus.watchers = append(bus.watchers, w)
+	return w
+}
+
+// Evaluate checks every watcher against a new state and runs the callbacks
+// of those that fire, in registration order
+func (bus *EventBus) Evaluate(state *AircraftState) {
+	if len(bus.watchers) == 0 {
+		return
+	}
+
+	// Only watchers registered before this evaluation take part
+	watchers := bus.watchers[:len(bus.watchers):len(bus.watchers)]
+
+	var properties map[string]float64
+	for _, w := range watchers {
+		if w.usesProperties && !w.removed {
+			if bus.properties == nil {
+				bus.properties = make(map[string]float64, propertyMapSize)
+			}
+			properties = bus.properties
+			clear(properties)
+			state.FillPropertyMap(properties)
+			break
+		}
+	}
+
+	for _, w := range watchers {
+		if w.removed || !w.check(state, properties) {
+			continue
+		}
+		if w.once {
+			w.removed = true
+		}
+		w.callback(state, state.Time)
+	}
+
+	// Drop removed watchers, keeping any added by the callbacks
+	kept := bus.watchers[:0]
+	for _, w := range bus.watchers {
+		if !w.removed {
+			kept = append(kept, w)
+		}
+	}
+	clear(bus.watchers[len(kept):])
+	bus.watchers = kept
+}
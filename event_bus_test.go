@@ -0,0 +1,190 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestEventBus(t *testing.T) {
+	stateAt := func(time, altitude float64) *AircraftState {
+		state := NewAircraftState()
+		state.Time = time
+		state.Altitude = altitude
+		return state
+	}
+
+	t.Run("Registration Order And Once", func(t *testing.T) {
+		bus := NewEventBus()
+		var fired []string
+		record := func(name string) EventCallback {
+			return func(*AircraftState, float64) { fired = append(fired, name) }
+		}
+		high := func(s *AircraftState) bool { return s.Altitude > 100 }
+		bus.OnPredicate(high, record("first"))
+		bus.OnPredicate(high, record("once")).Once()
+		bus.OnPredicate(high, record("last"))
+
+		for _, altitude := range []float64{0, 200, 0, 200} {
+			bus.Evaluate(stateAt(0, altitude))
+		}
+		assertEqual(t, fired, []string{"first", "once", "last", "first", "last"})
+		assertEqual(t, bus.Len(), 2)
+	})
+
+	t.Run("Predicate Starting True", func(t *testing.T) {
+		bus := NewEventBus()
+		count := 0
+		bus.OnPredicate(func(s *AircraftState) bool { return s.Altitude > 100 },
+			func(*AircraftState, float64) { count++ })
+		bus.Evaluate(stateAt(0, 200))
+		bus.Evaluate(stateAt(0, 300))
+		assertEqual(t, count, 0)
+	})
+
+	t.Run("Time Triggers", func(t *testing.T) {
+		bus := NewEventBus()
+		var once, repeating []float64
+		bus.OnTime(0.25, 0, func(_ *AircraftState, time float64) { once = append(once, time) })
+		bus.OnTime(0.2, 0.3, func(_ *AircraftState, time float64) { repeating = append(repeating, time) })
+		for i := 0; i <= 10; i++ {
+			bus.Evaluate(stateAt(float64(i)*0.1, 0))
+		}
+		assertEqual(t, len(once), 1)
+		assertApproxEqual(t, once[0], 0.3, 1e-9)
+		assertEqual(t, len(repeating), 3) // 0.2, 0.5, 0.8
+		assertApproxEqual(t, repeating[2], 0.8, 1e-9)
+	})
+
+	t.Run("Deregistration From Callbacks", func(t *testing.T) {
+		bus := NewEventBus()
+		count := 0
+		var self, other *EventWatcher
+		always := func(*AircraftState) bool { return true }
+
+		// The first watcher removes itself and the one after it, and adds
+		// one that is not evaluated until the next step
+		self = bus.OnTime(0, 0.1, func(*AircraftState, float64) {
+			count++
+			bus.Remove(self)
+			bus.Remove(other)
+			bus.OnTime(0, 0.1, func(*AircraftState, float64) { count += 10 })
+		})
+		other = bus.OnTime(0, 0.1, func(*AircraftState, float64) { count += 100 })
+		bus.OnPredicate(always, func(*AircraftState, float64) {})
+
+		bus.Evaluate(stateAt(0, 0))
+		assertEqual(t, count, 1)
+		bus.Evaluate(stateAt(0.1, 0))
+		assertEqual(t, count, 11)
+		assertEqual(t, bus.Len(), 2)
+	})
+
+	t.Run("Property Crossing Directions", func(t *testing.T) {
+		bus := NewEventBus()
+		var rising, falling, either int
+		bus.OnPropertyCrossing("position/h-sl-m", 1000, CrossingRising, 0, func(*AircraftState, float64) { rising++ })
+		bus.OnPropertyCrossing("position/h-sl-m", 1000, CrossingFalling, 0, func(*AircraftState, float64) { falling++ })
+		bus.OnPropertyCrossing("position/h-sl-m", 1000, CrossingEither, 0, func(*AircraftState, float64) { either++ })
+		for _, altitude := range []float64{900, 1100, 900, 1100} {
+			bus.Evaluate(stateAt(0, altitude))
+		}
+		assertEqual(t, rising, 2)
+		assertEqual(t, falling, 1)
+		assertEqual(t, either, 3)
+	})
+}
+
+// approachState returns the simplified model trimmed at 100 m/s, then put on
+// a descending flight path with the gear down
+func approachState(t *testing.T, engine *SimplifiedFlightDynamicsEngine, altitude, sinkRate float64) *AircraftState {
+	t.Helper()
+	state := trimLevelFlight(t, engine, altitude, 100.0)
+	state.Controls.Gear = true
+	state.Gear.Down = true
+	state.Gear.Transition = 1.0
+
+	earthVel := state.Orientation.RotateVector(state.Velocity)
+	earthVel.Z = sinkRate
+	inverse := Quaternion{W: state.Orientation.W, X: -state.Orientation.X, Y: -state.Orientation.Y, Z: -state.Orientation.Z}
+	state.Velocity = inverse.RotateVector(earthVel)
+	return state
+}
+
+func TestEventBusTouchdown(t *testing.T) {
+	engine := NewSimplifiedFlightDynamicsEngine(NewEulerIntegrator())
+	engine.Terrain = NewFlatTerrain(0)
+	engine.Gear = NewLandingGear(loadP51DConfig(t))
+	state := approachState(t, engine, 5.0, 2.0)
+
+	// The mains touch down nose low, pitching the aircraft up so that it
+	// skips off the runway before it settles. The raw weight on wheels
+	// makes at each contact; the squat switches, debounced, make once the
+	// aircraft has stayed down.
+	for i := 0; i < 2; i++ {
+		engine.Gear.Units[i].Squat = SquatSwitch{Make: 0.03, Break: 0.01, Dwell: 0.2}
+	}
+	var contacts, touchdowns []float64
+	mainsOnGround := func(s *AircraftState) bool { return s.Gear.Units[0].WOW || s.Gear.Units[1].WOW }
+	mainsSquat := func(s *AircraftState) bool { return s.Gear.Units[0].Squat || s.Gear.Units[1].Squat }
+	engine.Events.OnPredicate(mainsOnGround, func(s *AircraftState, time float64) {
+		contacts = append(contacts, time)
+	})
+	engine.Events.OnPredicate(mainsSquat, func(s *AircraftState, time float64) {
+		touchdowns = append(touchdowns, time)
+	})
+
+	// A short step keeps the stiff gear and tailwheel stable
+	for i := 0; i < 1500; i++ {
+		next, err := engine.Step(state, 0.002)
+		if err != nil {
+			t.Fatalf("Step %d failed: %v", i, err)
+		}
+		state = next
+	}
+
+	if len(touchdowns) != 1 {
+		t.Fatalf("Expected one touchdown, got %d at %v", len(touchdowns), touchdowns)
+	}
+	if len(contacts) == 0 || touchdowns[0] < contacts[len(contacts)-1] {
+		t.Errorf("The touchdown at %.2fs should follow the last contact, contacts at %v", touchdowns[0], contacts)
+	}
+	if !mainsOnGround(state) || !mainsSquat(state) {
+		t.Error("Aircraft should still be on its main wheels")
+	}
+	assertEqual(t, engine.Events.Len(), 2)
+	t.Logf("Touchdown at t=%.2fs, %d contacts", touchdowns[0], len(contacts))
+}
+
+func TestEventBusAltitudeHysteresis(t *testing.T) {
+	// Level flight just above the threshold, disturbed by vertical gusts
+	fly := func(hysteresis float64) int {
+		engine := NewSimplifiedFlightDynamicsEngine(NewEulerIntegrator())
+		state := trimLevelFlight(t, engine, 1000.1, 100.0)
+		crossings := 0
+		engine.Events.OnPropertyCrossing("position/h-sl-m", 1000, CrossingEither, hysteresis,
+			func(*AircraftState, float64) { crossings++ })
+
+		rng := rand.New(rand.NewSource(7))
+		gust := 0.0
+		for i := 0; i < 200; i++ {
+			next := rng.NormFloat64() * 2.0
+			state.Velocity.Z += next - gust
+			gust = next
+
+			var err error
+			if state, err = engine.Step(state, 0.01); err != nil {
+				t.Fatalf("Step %d failed: %v", i, err)
+			}
+		}
+		return crossings
+	}
+
+	chatter := fly(0)
+	if chatter < 3 {
+		t.Fatalf("Turbulence should carry the altitude back and forth across the threshold, got %d crossings", chatter)
+	}
+	if crossings := fly(5); crossings > 1 {
+		t.Errorf("Hysteresis should stop the chatter: %d crossings, %d without", crossings, chatter)
+	}
+	t.Logf("Crossings without hysteresis: %d", chatter)
+}
@@ -0,0 +1,193 @@
+// External Forces
+// Point forces on the airframe that are neither aerodynamic nor propulsive:
+// a tow rope, a catapult shuttle, a tether or a parachute
+
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ExternalForce is one point force. Vector gives the whole force when it is
+// set; otherwise its magnitude comes from Func when that is set, otherwise
+// from Property when that is set, otherwise it is the constant Magnitude.
+type ExternalForce struct {
+	Name string
+
+	// Application point in body axes about the CG (m). When Structural is
+	// set it is used instead, converted from the structural frame with the
+	// registry's CG.
+	Location   Vector3
+	Structural *Location
+
+	// Line of action in body axes; only its direction matters
+	Direction Vector3
+
+	Magnitude float64                      // N
+	Property  string                       // Property holding the magnitude (N)
+	Func      func(*AircraftState) float64 // Magnitude at a state (N)
+
+	// Force in body axes at a state, given the application point, for a
+	// force whose line of action moves, such as a parachute's drag (N)
+	Vector func(state *AircraftState, location Vector3) Vector3
+
+	// Disabled forces are kept but do not act, so a scenario can release
+	// and re-attach them
+	Disabled bool
+}
+
+// ExternalReaction is the contribution of one external force to a
+// calculation
+type ExternalReaction struct {
+	Name   string
+	Force  Vector3 // Body frame (N)
+	Moment Vector3 // About the CG (N·m)
+}
+
+// ExternalForces is a registry of external forces. Forces can be added,
+// removed and toggled between steps.
+type ExternalForces struct {
+	CG     *Location // Structural CG, for forces placed at structural locations
+	forces []*ExternalForce
+}
+
+// NewExternalForces creates an empty registry. cg may be nil, when forces
+// at structural locations are taken about the structural origin.
+func NewExternalForces(cg *Location) *ExternalForces {
+	return &ExternalForces{CG: cg}
+}
+
+// clone returns a registry of copies of the forces, so that adding,
+// removing or toggling forces in one does not change the other. The Func
+// of a force is shared by its copies.
+func (ef *ExternalForces) clone() *ExternalForces {
+	c := &ExternalForces{CG: ef.CG, forces: make([]*ExternalForce, len(ef.forces))}
+	for i, force := range ef.forces {
+		copied := *force
+		c.forces[i] = &copied
+	}
+	return c
+}
+
+// Add registers a force. Names must be unique and not empty.
+func (ef *ExternalForces) Add(force *ExternalForce) error {
+	if strings.TrimSpace(force.Name) == "" {
+		return fmt.Errorf("external force has no name")
+	}
+	if ef.Get(force.Name) != nil {
+		return fmt.Errorf("external force %q is already registered", force.Name)
+	}
+	if force.Vector == nil && force.Direction.Magnitude() == 0 {
+		return fmt.Errorf("external force %q has no direction", force.Name)
+	}
+	ef.forces = append(ef.forces, force)
+	return nil
+}
+
+// Remove unregisters a force, reporting whether it was registered
+func (ef *ExternalForces) Remove(name string) bool {
+	for i, force := range ef.forces {
+		if force.Name == name {
+			ef.forces = append(ef.forces[:i], ef.forces[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Get returns the force registered under name, or nil
+func (ef *ExternalForces) Get(name string) *ExternalForce {
+	if ef == nil {
+		return nil
+	}
+	for _, force := range ef.forces {
+		if force.Name == name {
+			return force
+		}
+	}
+	return nil
+}
+
+// SetEnabled releases or re-attaches a registered force
+func (ef *ExternalForces) SetEnabled(name string, enabled bool) error {
+	force := ef.Get(name)
+	if force == nil {
+		return fmt.Errorf("no external force %q", name)
+	}
+	force.Disabled = !enabled
+	return nil
+}
+
+// Names returns the registered forces in registration order
+func (ef *ExternalForces) Names() []string {
+	if ef == nil {
+		return nil
+	}
+	names := make([]string, len(ef.forces))
+	for i, force := range ef.forces {
+		names[i] = force.Name
+	}
+	return names
+}
+
+// Len returns the number of registered forces
+func (ef *ExternalForces) Len() int {
+	if ef == nil {
+		return 0
+	}
+	return len(ef.forces)
+}
+
+// Reactions returns the force and moment of each enabled force at a state.
+// lookup reads property-driven magnitudes; when it is nil, or the property
+// is unset, such a force does not act.
+func (ef *ExternalForces) Reactions(state *AircraftState, lookup func(string) (float64, bool)) []ExternalReaction {
+	var reactions []ExternalReaction
+	for _, force := range ef.forces {
+		if force.Disabled {
+			continue
+		}
+		location := force.Location
+		if force.Structural != nil {
+			location = StructuralToBody(force.Structural, StructuralPosition(ef.CG))
+		}
+		if force.Vector != nil {
+			vector := force.Vector(state, location)
+			reactions = append(reactions, ExternalReaction{Name: force.Name, Force: vector, Moment: location.Cross(vector)})
+			continue
+		}
+
+		magnitude := force.Magnitude
+		switch {
+		case force.Func != nil:
+			magnitude = force.Func(state)
+		case force.Property != "":
+			magnitude = 0
+			if lookup != nil {
+				magnitude, _ = lookup(force.Property)
+			}
+		}
+		vector := force.Direction.Normalize().Scale(magnitude)
+		reactions = append(reactions, ExternalReaction{
+			Name:   force.Name,
+			Force:  vector,
+			Moment: location.Cross(vector),
+		})
+	}
+	return reactions
+}
+
+// addExternalForces adds the external forces at a state to the breakdown
+// and the totals
+func (components *ForceMomentComponents) addExternalForces(external *ExternalForces, state *AircraftState, lookup func(string) (float64, bool)) {
+	if external.Len() == 0 {
+		return
+	}
+	for _, reaction := range external.Reactions(state, lookup) {
+		components.External.Force = components.External.Force.Add(reaction.Force)
+		components.External.Moment = components.External.Moment.Add(reaction.Moment)
+	}
+	components.TotalForce = components.TotalForce.Add(components.External.Force)
+	components.TotalMoment = components.TotalMoment.Add(components.External.Moment)
+}
@@ -369,8 +369,11 @@ func testGroundReactionsExtraction(t *testing.T, values map[string]interface{})
 	assertEqual(t, leftMLG["static_friction"], 0.8)
 	assertEqual(t, leftMLG["dynamic_friction"], 0.5)
 	assertEqual(t, leftMLG["rolling_friction"], 0.02)
-	assertEqual(t, leftMLG["spring_constant"], 9800.0)
-	assertEqual(t, leftMLG["damper_constant"], 2500.0)
+	assertEqual(t, leftMLG["spring_coeff"], 9800.0)
+	assertEqual(t, leftMLG["damping_coeff"], 2500.0)
+	if _, ok := leftMLG["spring_constant"]; ok {
+		t.Error("The coefficients should not share the unitless constants' keys")
+	}
 	assertEqual(t, leftMLG["max_steer"], 0.0)
 	assertEqual(t, leftMLG["brake_group"], "LEFT")
 	assertEqual(t, leftMLG["retractable"], 1)
@@ -422,8 +425,9 @@ func testPropulsionExtraction(t *testing.T, values map[string]interface{}) {
 		t.Errorf("Expected engine orientation to be map[string]float64, got %T", engine["orientation"])
 		return
 	}
-	assertEqual(t, engineOrient["roll"], -4.0)
-	assertEqual(t, engineOrient["pitch"], 2.5)
+	// Orientation is parsed in degrees and converted to radians
+	assertApproxEqual(t, engineOrient["roll"], -4.0*DEG_TO_RAD, 1e-12)
+	assertApproxEqual(t, engineOrient["pitch"], 2.5*DEG_TO_RAD, 1e-12)
 	assertEqual(t, engineOrient["yaw"], 0.0)
 
 	// Test feed
@@ -605,18 +609,18 @@ func testAerodynamicsExtraction(t *testing.T, values map[string]interface{}) {
 
 func testInputOutputExtraction(t *testing.T, values map[string]interface{}) {
 	// Test input
-	input, ok := values["input"].(map[string]interface{})
+	input, ok := values["input.0"].(map[string]interface{})
 	if !ok {
-		t.Errorf("Expected input to be map[string]interface{}, got %T", values["input"])
+		t.Errorf("Expected input.0 to be map[string]interface{}, got %T", values["input.0"])
 		return
 	}
 	assertEqual(t, input["port"], 1234)
 	assertEqual(t, input["protocol"], "UDP")
 
 	// Test output
-	output, ok := values["output"].(map[string]interface{})
+	output, ok := values["output.0"].(map[string]interface{})
 	if !ok {
-		t.Errorf("Expected output to be map[string]interface{}, got %T", values["output"])
+		t.Errorf("Expected output.0 to be map[string]interface{}, got %T", values["output.0"])
 		return
 	}
 	assertEqual(t, output["name"], "test-output")
@@ -710,8 +714,8 @@ func TestExtractAllValuesCompleteness(t *testing.T) {
 		"propulsion.engine.0",
 		"flight_control.name",
 		"aerodynamics.alpha_limits",
-		"input",
-		"output",
+		"input.0",
+		"output.0",
 	}
 
 	for _, section := range expectedSections {
@@ -0,0 +1,102 @@
+// Fallback Aerodynamics
+// A crude aerodynamic model estimated from the metrics alone, flown when a
+// configuration has no aerodynamics section so that it glides instead of
+// failing. Its forces are flagged in the breakdown as estimates.
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+)
+
+// Assumptions of the fallback model
+const (
+	fallbackOswaldEfficiency = 0.8    // Span efficiency of the drag polar
+	fallbackCLMax            = 1.4    // Lift coefficient at which the wing stalls
+	fallbackSkinFriction     = 0.0045 // Equivalent skin friction coefficient, light aircraft
+	fallbackFuselageWetted   = 1.5    // Fuselage wetted area per unit wing area, metrics give no fuselage
+)
+
+// FallbackAero is a flat-plate style estimate of the aerodynamics: a wing
+// with a lift slope from its aspect ratio, a parabolic drag polar and no
+// aerodynamic moments, so the attitude is neutrally stable. It knows
+// nothing of the controls.
+type FallbackAero struct {
+	LiftSlope   float64 // CLα from Helmbold's lifting-line formula (per rad)
+	CD0         float64 // Zero-lift drag coefficient
+	InducedDrag float64 // K of CD = CD0 + K·CL²
+	CLMax       float64 // Lift coefficient is limited to ±CLMax
+	Incidence   float64 // Wing incidence to the body X axis (rad)
+}
+
+// NewFallbackAero estimates the fallback model of a configuration from its
+// derived geometry. A positive cd0 is used as given; otherwise CD0 is
+// guessed as Cfe·Swet/S, with the wetted area of both sides of the wing
+// and tails and a typical fuselage. The aspect ratio must be known.
+func NewFallbackAero(config *JSBSimConfig, cd0 float64) (*FallbackAero, error) {
+	g := NewDerivedGeometry(config)
+	if g.AspectRatio <= 0 {
+		return nil, fmt.Errorf("fallback aerodynamics need the wing area and span")
+	}
+	if cd0 <= 0 {
+		m := config.Metrics
+		area := measurementValue(m.WingArea)
+		planform := area + measurementValue(m.HTailArea) + measurementValue(m.VTailArea)
+		cd0 = fallbackSkinFriction * (2.04*planform/area + fallbackFuselageWetted)
+	}
+	return &FallbackAero{
+		LiftSlope:   liftCurveSlope(g.AspectRatio),
+		CD0:         cd0,
+		InducedDrag: g.InducedDragFactor(fallbackOswaldEfficiency),
+		CLMax:       fallbackCLMax,
+		Incidence:   g.WingIncidence,
+	}, nil
+}
+
+// newConfigFallbackAero returns the fallback model of a configuration
+// without an aerodynamics section, warning that it is flown, or nil when
+// the configuration has aerodynamics or too few metrics to estimate them
+func newConfigFallbackAero(config *JSBSimConfig) *FallbackAero {
+	if config.Aerodynamics != nil {
+		return nil
+	}
+	aero, err := NewFallbackAero(config, 0)
+	if err != nil {
+		return nil
+	}
+	log.Printf("WARNING: no aerodynamics section; flying an estimated fallback model "+
+		"(CLα %.2f/rad, CD0 %.4f, no moments), not real aerodynamic data", aero.LiftSlope, aero.CD0)
+	return aero
+}
+
+// Coefficients returns the lift and drag coefficients at a wing angle of
+// attack (rad)
+func (f *FallbackAero) Coefficients(alpha float64) (CL, CD float64) {
+	CL = math.Max(-f.CLMax, math.Min(f.CLMax, f.LiftSlope*alpha))
+	return CL, f.CD0 + f.InducedDrag*CL*CL
+}
+
+// forces sets the aerodynamic forces of the breakdown for a wing of the
+// given area (ft²), resolved from the wind axes into the body axes. The
+// wing's angle of attack is taken from the body velocity, positive with the
+// relative wind from below so that lift restores it, which is the opposite
+// sign to AircraftState.Alpha.
+func (f *FallbackAero) forces(state *AircraftState, wingArea float64, components *ForceMomentComponents) {
+	components.Aerodynamic.Fallback = true
+	components.Aerodynamic.Lift, components.Aerodynamic.Drag, components.Aerodynamic.Side = 0, 0, 0
+	if state.DynamicPressure <= 0 {
+		return
+	}
+
+	alpha, _ := AirflowAngles(state.Velocity)
+	CL, CD := f.Coefficients(alpha + f.Incidence)
+	qS := state.DynamicPressure * wingArea * FT2_TO_M2
+
+	// Drag opposes the velocity, lift is normal to it in the plane of symmetry
+	force := WindForcesToBody(state, CL*qS, CD*qS, 0)
+	components.Aerodynamic.Lift = force.Z
+	components.Aerodynamic.Drag = force.X
+	components.Aerodynamic.Side = force.Y
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// metricsOnlyConfig is a P-51D sized configuration with metrics and mass but
+// no aerodynamics section
+const metricsOnlyConfig = `<fdm_config name="metrics only">
+	<metrics>
+		<wingarea unit="FT2"> 235 </wingarea>
+		<wingspan unit="FT"> 37 </wingspan>
+		<chord unit="FT"> 6.6 </chord>
+		<htailarea unit="FT2"> 41 </htailarea>
+		<htailarm unit="FT"> 15 </htailarm>
+		<vtailarea unit="FT2"> 20 </vtailarea>
+		<vtailarm unit="FT"> 15 </vtailarm>
+	</metrics>
+	<mass_balance>
+		<emptywt unit="LBS"> 7000 </emptywt>
+	</mass_balance>
+</fdm_config>`
+
+func TestFallbackAero(t *testing.T) {
+	config, err := ParseJSBSimConfig(strings.NewReader(metricsOnlyConfig))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	t.Run("Estimated From Geometry", func(t *testing.T) {
+		calc := NewForcesMomentsCalculator(config)
+		aero := calc.FallbackAero
+		if aero == nil {
+			t.Fatal("Expected a fallback model for a configuration without aerodynamics")
+		}
+		ar := 37.0 * 37.0 / 235.0
+		assertApproxEqual(t, aero.LiftSlope, liftCurveSlope(ar), 1e-12)
+		assertApproxEqual(t, aero.InducedDrag, 1/(math.Pi*0.8*ar), 1e-12)
+		if aero.CD0 < 0.012 || aero.CD0 > 0.03 {
+			t.Errorf("CD0 guess %.4f is not that of a clean propeller aircraft", aero.CD0)
+		}
+
+		// A user-supplied CD0 is kept
+		given, err := NewFallbackAero(config, 0.025)
+		if err != nil {
+			t.Fatalf("NewFallbackAero: %v", err)
+		}
+		assertEqual(t, given.CD0, 0.025)
+
+		// The P-51D has real aerodynamics, flown unless the fallback is asked for
+		if NewForcesMomentsCalculator(loadP51DConfig(t)).FallbackAero != nil {
+			t.Error("A configuration with aerodynamics should not fly the fallback")
+		}
+		if _, err := NewFallbackAero(&JSBSimConfig{Metrics: &Metrics{}}, 0); err == nil {
+			t.Error("Expected an error without wing area and span")
+		}
+	})
+
+	t.Run("Flagged In Breakdown", func(t *testing.T) {
+		calc := NewForcesMomentsCalculator(config)
+		components, err := calc.CalculateForcesMoments(cruiseState(1000, 80, 0))
+		if err != nil {
+			t.Fatalf("CalculateForcesMoments: %v", err)
+		}
+		if !components.Aerodynamic.Fallback {
+			t.Error("Fallback forces should be flagged in the breakdown")
+		}
+		assertEqual(t, components.Moments, ForceMomentComponents{}.Moments)
+
+		p51d := NewForcesMomentsCalculator(loadP51DConfig(t))
+		components, err = p51d.CalculateForcesMoments(cruiseState(1000, 80, 0))
+		if err != nil {
+			t.Fatalf("CalculateForcesMoments: %v", err)
+		}
+		if components.Aerodynamic.Fallback {
+			t.Error("Forces from aerodynamic data should not be flagged")
+		}
+	})
+
+	t.Run("Glides", func(t *testing.T) {
+		// Start at the best glide of the model, which it should hold
+		engine := NewFlightDynamicsEngine(config, NewEulerIntegrator())
+		aero := engine.Calculator.FallbackAero
+		CL := math.Sqrt(aero.CD0 / aero.InducedDrag)
+		_, CD := aero.Coefficients(CL / aero.LiftSlope)
+		gamma := -math.Atan(CD / CL)
+		alpha := CL/aero.LiftSlope - aero.Incidence
+		weight := engine.Calculator.Mass * StandardGravity
+		glideSpeed := func(state *AircraftState) float64 {
+			return math.Sqrt(2 * weight * math.Cos(gamma) / (state.Density * 235 * FT2_TO_M2 * CL))
+		}
+
+		state := cruiseState(2000, 1, 0)
+		state.Orientation = NewQuaternionFromEuler(0, alpha+gamma, 0)
+		state.Velocity = Vector3{X: math.Cos(alpha), Z: math.Sin(alpha)}.Scale(glideSpeed(state))
+		state.UpdateDerivedParameters()
+
+		const dt = 0.01
+		var err error
+		for i := 0; i < 6000; i++ {
+			if state, err = engine.Step(state, dt); err != nil {
+				t.Fatalf("Step at t=%.2f: %v", state.Time, err)
+			}
+		}
+
+		// The glide speed falls as the air thickens, slowing the aircraft
+		// and flattening the path a little
+		speed := glideSpeed(state)
+		earth := state.Orientation.RotateVector(state.Velocity)
+		flightPath := math.Atan2(-earth.Z, math.Hypot(earth.X, earth.Y))
+		t.Logf("After %.0f s: %.1f m/s, flight path %.2f°, glide at %.1f m/s and %.2f°",
+			state.Time, state.TrueAirspeed, flightPath*RAD_TO_DEG, speed, gamma*RAD_TO_DEG)
+
+		if state.Altitude > 2000-300 {
+			t.Errorf("Expected a steady descent, altitude %.0f m", state.Altitude)
+		}
+		if math.Abs(state.TrueAirspeed-speed) > 0.03*speed {
+			t.Errorf("Speed %.1f m/s strayed from the glide speed %.1f m/s", state.TrueAirspeed, speed)
+		}
+		if math.Abs(flightPath-gamma) > 0.5*DEG_TO_RAD {
+			t.Errorf("Flight path %.2f°, expected %.2f°", flightPath*RAD_TO_DEG, gamma*RAD_TO_DEG)
+		}
+		if rate := state.AngularRate.Magnitude(); rate > 1e-9 {
+			t.Errorf("Neutral moments should leave the attitude alone, rate %.3g rad/s", rate)
+		}
+	})
+}
@@ -0,0 +1,190 @@
+// Alpha Protection Channel
+// Envelope protection built from standard FCS components: an angle of
+// attack limiter that pushes the stick forward near the limit, and a stall
+// warning
+
+package main
+
+import (
+	"sort"
+)
+
+// Alpha protection property names
+const (
+	AlphaProtectionEngageProperty    = "fcs/alpha-protection-engage" // Protection armed when >= 0.5
+	ProtectedElevatorCommandProperty = "fcs/elevator-cmd-protected"  // Pilot + pusher
+	AlphaProtectionCommandProperty   = "fcs/alpha-protection-output" // Pusher contribution after engage switch
+	StallWarningProperty             = "fcs/stall-warning"           // 1 while the warning sounds
+)
+
+// DefaultAlphaProtectionCutout is the calibrated airspeed below which the
+// protection and the warning are inhibited on the ground (kts)
+const DefaultAlphaProtectionCutout = 50.0
+
+// AlphaProtectionChannel is an angle of attack limiter wired into the
+// elevator command path:
+//
+//	alpha sensor -> ramp -> authority limit -> engage switch -+
+//	                                                          +-> summer -> limit -> elevator-cmd-protected
+//	pilot elevator-cmd-norm ----------------------------------+
+//
+// Below the soft limit, limit less the soft margin, the pusher is silent.
+// Across the soft margin its nose-down command ramps up, overriding more
+// and more of the pilot's nose-up command, and at the hard limit it has
+// its full authority. Positive elevator is nose down, as in both the
+// simplified model and the P-51D. On the ground below the cutout airspeed
+// the pusher and the warning are inhibited, whether or not the channel is
+// engaged, so the tail can be held down on the takeoff roll.
+type AlphaProtectionChannel struct {
+	Sensor    *LagFilterComponent
+	Ramp      *SummerComponent
+	Gain      *GainComponent
+	Authority *ClipperComponent
+	Engage    *SwitchComponent
+	Summer    *SummerComponent
+	Limiter   *ClipperComponent
+	Warning   *SwitchComponent
+
+	LimitDeg         float64 // Hard limit (deg)
+	SoftMarginDeg    float64 // Width of the ramp below the hard limit (deg)
+	WarningMarginDeg float64 // The warning sounds this far below the hard limit (deg)
+
+	airborne *SwitchTest // In the air, or on the ground above the cutout
+	cutout   *SwitchComparison
+	warning  *SwitchComparison
+
+	fcs *FlightControlSystem
+}
+
+// CreateAlphaProtectionChannel adds an alpha limiter to an FCS. limitDeg is
+// the hard limit and softMarginDeg the width of the ramp below it, both in
+// degrees of angle of attack; maxAuthority is the nose-down elevator
+// command (normalized) the pusher reaches at the limit. The warning sounds
+// from the start of the ramp until SetWarningMargin moves it.
+//
+// Components that read fcs/elevator-cmd-norm are rewired to read
+// fcs/elevator-cmd-protected, and the channel is scheduled ahead of them.
+// The channel starts engaged; set fcs/alpha-protection-engage to 0 to
+// disengage it.
+func CreateAlphaProtectionChannel(fcs *FlightControlSystem, limitDeg, softMarginDeg, maxAuthority float64) *AlphaProtectionChannel {
+	ch := &AlphaProtectionChannel{
+		Sensor: NewLagFilterComponent("fcs/alpha-sensor",
+			"aero/alpha-deg", "fcs/alpha-sensed-deg", 0.05), // 50ms vane lag
+		Ramp: NewSummerComponent("fcs/alpha-protection-ramp",
+			[]string{"fcs/alpha-sensed-deg"}, "fcs/alpha-protection-excess-deg"),
+		Gain: NewGainComponent("fcs/alpha-protection-gain",
+			"fcs/alpha-protection-excess-deg", "fcs/alpha-protection-cmd-norm", 0),
+		Authority: NewClipperComponent("fcs/alpha-protection-authority",
+			"fcs/alpha-protection-cmd-norm", "fcs/alpha-protection-limited", 0, 0),
+		Engage: NewSwitchComponent("fcs/alpha-protection-switch", AlphaProtectionCommandProperty),
+		Summer: NewSummerComponent("fcs/elevator-cmd-summer",
+			[]string{"fcs/elevator-cmd-norm", AlphaProtectionCommandProperty}, "fcs/elevator-cmd-sum"),
+		Limiter: NewClipperComponent("fcs/elevator-cmd-limiter",
+			"fcs/elevator-cmd-sum", ProtectedElevatorCommandProperty, -1.0, 1.0),
+		Warning: NewSwitchComponent("fcs/stall-warning-switch", StallWarningProperty),
+		fcs:     fcs,
+	}
+
+	// Armed, and airborne or fast enough on the ground
+	ch.cutout = &SwitchComparison{
+		Left:     &SwitchOperand{Property: "velocities/vc-kts", Sign: 1},
+		Operator: "GE",
+		Right:    &SwitchOperand{Constant: DefaultAlphaProtectionCutout},
+	}
+	ch.airborne = &SwitchTest{Logic: "OR", Comparisons: []*SwitchComparison{{
+		Left:     &SwitchOperand{Property: "gear/wow", Sign: 1},
+		Operator: "EQ",
+		Right:    &SwitchOperand{Constant: 0},
+	}, ch.cutout}}
+	ch.Engage.AddBranch(&SwitchTest{
+		Logic: "AND",
+		Comparisons: []*SwitchComparison{{
+			Left:     &SwitchOperand{Property: AlphaProtectionEngageProperty, Sign: 1},
+			Operator: "GE",
+			Right:    &SwitchOperand{Constant: 0.5},
+		}},
+		Groups: []*SwitchTest{ch.airborne},
+	}, &SwitchOperand{Property: "fcs/alpha-protection-limited", Sign: 1})
+	ch.Engage.SetDefault(&SwitchOperand{})
+
+	ch.warning = &SwitchComparison{
+		Left:     &SwitchOperand{Property: "fcs/alpha-sensed-deg", Sign: 1},
+		Operator: "GE",
+		Right:    &SwitchOperand{},
+	}
+	ch.Warning.AddBranch(&SwitchTest{
+		Logic:       "AND",
+		Comparisons: []*SwitchComparison{ch.warning},
+		Groups:      []*SwitchTest{ch.airborne},
+	}, &SwitchOperand{Constant: 1})
+	ch.Warning.SetDefault(&SwitchOperand{})
+
+	ch.SetLimits(limitDeg, softMarginDeg, maxAuthority)
+	ch.SetWarningMargin(softMarginDeg)
+	fcs.Properties.Set(AlphaProtectionEngageProperty, 1.0)
+
+	// Rewire the existing consumers of the pilot command
+	var consumers []string
+	for name, component := range fcs.Components {
+		inputs := component.GetInputs()
+		for i, input := range inputs {
+			if input == "fcs/elevator-cmd-norm" {
+				inputs[i] = ProtectedElevatorCommandProperty
+				consumers = append(consumers, name)
+			}
+		}
+	}
+	sort.Strings(consumers)
+
+	for _, component := range ch.components() {
+		if len(consumers) > 0 {
+			fcs.InsertComponentBefore(component, consumers[0])
+		} else {
+			fcs.AddComponent(component)
+		}
+	}
+	if pitchChannel := fcs.GetChannel("Pitch"); pitchChannel != nil {
+		pitchChannel.Components = append(ch.components(), pitchChannel.Components...)
+	}
+
+	return ch
+}
+
+// components returns the channel's components in execution order
+func (ch *AlphaProtectionChannel) components() []ComponentProcessor {
+	return []ComponentProcessor{ch.Sensor, ch.Ramp, ch.Gain, ch.Authority, ch.Engage, ch.Summer, ch.Limiter, ch.Warning}
+}
+
+// SetLimits moves the hard limit and the ramp below it (deg) and sets the
+// pusher's authority at the limit (normalized elevator). The warning keeps
+// its margin below the limit.
+func (ch *AlphaProtectionChannel) SetLimits(limitDeg, softMarginDeg, maxAuthority float64) {
+	ch.LimitDeg, ch.SoftMarginDeg = limitDeg, softMarginDeg
+	ch.Ramp.SetBias(-(limitDeg - softMarginDeg))
+	ch.Gain.Gain = maxAuthority / softMarginDeg
+	ch.Authority.MinValue = 0
+	ch.Authority.MaxValue = maxAuthority
+	ch.warning.Right.Constant = limitDeg - ch.WarningMarginDeg
+}
+
+// SetWarningMargin sounds the stall warning marginDeg below the hard limit
+func (ch *AlphaProtectionChannel) SetWarningMargin(marginDeg float64) {
+	ch.WarningMarginDeg = marginDeg
+	ch.warning.Right.Constant = ch.LimitDeg - marginDeg
+}
+
+// SetCutout sets the calibrated airspeed below which the protection and the
+// warning are inhibited on the ground (kts)
+func (ch *AlphaProtectionChannel) SetCutout(kts float64) {
+	ch.cutout.Right.Constant = kts
+}
+
+// SetEngaged engages or disengages the pusher; the warning is unaffected
+func (ch *AlphaProtectionChannel) SetEngaged(engaged bool) {
+	ch.fcs.Properties.Set(AlphaProtectionEngageProperty, boolToFloat(engaged))
+}
+
+// StallWarning reports whether the stall warning is sounding
+func (ch *AlphaProtectionChannel) StallWarning() bool {
+	return ch.fcs.Properties.Get(StallWarningProperty) >= 0.5
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"testing"
+)
+
+// aftStickRun is a run of the simplified model with full aft stick held
+type aftStickRun struct {
+	states   []*AircraftState
+	elevator []float64 // Elevator command flown at each step
+	warning  []bool    // Stall warning at each step
+}
+
+// firstAbove returns the first step at which the angle of attack reaches
+// alphaDeg, or -1
+func (run aftStickRun) firstAbove(alphaDeg float64) int {
+	for i, state := range run.states {
+		if state.Alpha*RAD_TO_DEG >= alphaDeg {
+			return i
+		}
+	}
+	return -1
+}
+
+// flyFullAftStick flies the simplified model with full aft stick held from
+// level flight, the pilot command routed through an alpha protection
+// channel limiting alpha to 14° with a 4° ramp and full authority
+func flyFullAftStick(t *testing.T, engaged bool) aftStickRun {
+	t.Helper()
+	engine := NewSimplifiedFlightDynamicsEngine(NewRungeKutta4Integrator())
+	fcs := NewFlightControlSystem("Alpha Protection", 100.0)
+	protection := CreateAlphaProtectionChannel(fcs, 14.0, 4.0, 1.0)
+	protection.SetEngaged(engaged)
+
+	// 1.42° holds the weight at 100 m/s
+	state := cruiseState(1500, 100, 1.417*DEG_TO_RAD)
+	state.Controls.Throttle = 0.76
+
+	dt := 0.01
+	run := aftStickRun{states: []*AircraftState{state}}
+	for i := 0; i < 350; i++ {
+		state.Controls.Elevator = -1.0
+		fcs.Execute(state, dt)
+
+		// The simplified model reads the elevator straight from the command
+		stepState := state.Copy()
+		stepState.Controls.Elevator = fcs.Properties.Get(ProtectedElevatorCommandProperty)
+		run.elevator = append(run.elevator, stepState.Controls.Elevator)
+		run.warning = append(run.warning, protection.StallWarning())
+		next, err := engine.Step(stepState, dt)
+		if err != nil {
+			t.Fatalf("Step %d failed: %v", i, err)
+		}
+		state = next
+		run.states = append(run.states, state)
+	}
+	return run
+}
+
+func TestAlphaProtection(t *testing.T) {
+	t.Run("Full Aft Stick", func(t *testing.T) {
+		free := flyFullAftStick(t, false)
+		if free.firstAbove(18.0) < 0 {
+			t.Fatal("Without protection full aft stick should reach the 18° stall")
+		}
+		for i, elevator := range free.elevator {
+			if elevator != -1.0 {
+				t.Fatalf("Without protection the pilot's command should be flown, got %.3f at step %d", elevator, i)
+			}
+		}
+
+		// The simplified model's alpha does not follow the pitch attitude,
+		// so the pusher cannot hold it; it is checked to act in time
+		protected := flyFullAftStick(t, true)
+		soft, limit := protected.firstAbove(10.0), protected.firstAbove(14.0)
+		if soft < 0 || limit < 0 {
+			t.Fatal("The run should reach the limit")
+		}
+		warned := -1
+		for i, w := range protected.warning {
+			if w {
+				warned = i
+				break
+			}
+		}
+		if warned < 0 || warned > limit {
+			t.Errorf("The stall warning should sound before the limit at step %d, sounded at %d", limit, warned)
+		}
+		assertEqual(t, protected.elevator[soft-1], -1.0)
+		if protected.elevator[limit+10] < -0.1 {
+			t.Errorf("Past the limit the pusher should override the pull, flown %.3f", protected.elevator[limit+10])
+		}
+		if q := protected.states[limit+20].AngularRate.Y; q >= free.states[limit+20].AngularRate.Y {
+			t.Errorf("The pusher should check the pitch rate, %.3f against %.3f rad/s", q, free.states[limit+20].AngularRate.Y)
+		}
+	})
+
+	t.Run("Ramp", func(t *testing.T) {
+		fcs := NewFlightControlSystem("Alpha Protection", 100.0)
+		protection := CreateAlphaProtectionChannel(fcs, 14.0, 4.0, 0.8)
+		protection.SetWarningMargin(5.0)
+		protection.Sensor.C1 = 0
+
+		state := cruiseState(1500, 100, 0)
+		state.Controls.Elevator = -0.6
+		for _, c := range []struct {
+			alphaDeg, push float64
+			warning        bool
+		}{
+			{5, 0, false},
+			{9.5, 0, true},
+			{12, 0.4, true},
+			{14, 0.8, true},
+			{20, 0.8, true},
+		} {
+			state.Alpha = c.alphaDeg * DEG_TO_RAD
+			fcs.Execute(state, 0.01)
+			assertApproxEqual(t, fcs.Properties.Get(AlphaProtectionCommandProperty), c.push, 1e-4)
+			assertApproxEqual(t, fcs.Properties.Get(ProtectedElevatorCommandProperty), -0.6+c.push, 1e-4)
+			assertEqual(t, protection.StallWarning(), c.warning)
+		}
+
+		// Disengaged, the warning still sounds
+		protection.SetEngaged(false)
+		fcs.Execute(state, 0.01)
+		assertEqual(t, fcs.Properties.Get(ProtectedElevatorCommandProperty), -0.6)
+		assertEqual(t, protection.StallWarning(), true)
+		protection.SetEngaged(true)
+
+		// On the ground both are inhibited below the cutout
+		state.Gear.OnGround = true
+		state.CalibratedAirspeed = 40 * KT_TO_MS
+		fcs.Execute(state, 0.01)
+		assertEqual(t, fcs.Properties.Get(ProtectedElevatorCommandProperty), -0.6)
+		assertEqual(t, protection.StallWarning(), false)
+		state.CalibratedAirspeed = 60 * KT_TO_MS
+		fcs.Execute(state, 0.01)
+		assertApproxEqual(t, fcs.Properties.Get(ProtectedElevatorCommandProperty), 0.2, 1e-4)
+		protection.SetCutout(70)
+		fcs.Execute(state, 0.01)
+		assertEqual(t, fcs.Properties.Get(ProtectedElevatorCommandProperty), -0.6)
+	})
+
+	t.Run("Rewiring", func(t *testing.T) {
+		fcs := CreateStandardP51DFlightControlSystem()
+		CreateAlphaProtectionChannel(fcs, 16.0, 3.0, 1.0)
+		for _, component := range fcs.Components {
+			for _, input := range component.GetInputs() {
+				if input == "fcs/elevator-cmd-norm" && component.GetName() != "fcs/elevator-cmd-summer" {
+					t.Errorf("%s should read the protected command", component.GetName())
+				}
+			}
+		}
+	})
+}
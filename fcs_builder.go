@@ -0,0 +1,371 @@
+// FCS Builder
+// Constructs a FlightControlSystem from a parsed JSBSim <flight_control> section
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// AutopilotEngagedProperty is set to 1 while the autopilot is engaged and
+// 0 otherwise, for the autopilot's switches to key on
+const AutopilotEngagedProperty = "ap/engaged"
+
+// BuildFCSFromConfig creates a flight control system from the channels of a
+// parsed JSBSim configuration. Components are added in document order, which
+// is also JSBSim's execution order. Component types that are not supported
+// yet are skipped.
+//
+// The <property> declarations of the flight_control and system sections are
+// set to their default values before any component runs. Every component
+// input must be a standard property, a declared one or the output of an
+// earlier component; anything else is an error naming the input. The
+// autopilot runs before the flight controls, so the properties it declares
+// and outputs may be inputs too.
+func BuildFCSFromConfig(config *JSBSimConfig) (*FlightControlSystem, error) {
+	if config == nil || config.FlightControl == nil {
+		return nil, fmt.Errorf("configuration has no flight_control section")
+	}
+
+	resolved := standardPropertyNames()
+	if config.Autopilot != nil {
+		resolved[AutopilotEngagedProperty] = true
+		for _, p := range config.Autopilot.Property {
+			resolved[normalizePropertyName(p.Name)] = true
+		}
+		for _, ch := range config.Autopilot.Channel {
+			for _, comp := range ch.Component {
+				resolved[componentOutputProperty(comp)] = true
+				if comp.Name != "" {
+					resolved[componentNameProperty(comp)] = true
+				}
+			}
+		}
+	}
+	return buildFlightControl(config, config.FlightControl, "FCS", NewPropertyManager(), resolved)
+}
+
+// BuildAutopilotFromConfig creates the autopilot of a parsed JSBSim
+// configuration as a flight control system of its own, built like the
+// flight controls and sharing their property manager, so its outputs feed
+// the flight control channels. The autopilot is disengaged, with
+// AutopilotEngagedProperty at 0. Its inputs may also be any property
+// already in the manager.
+func BuildAutopilotFromConfig(config *JSBSimConfig, properties *PropertyManager) (*FlightControlSystem, error) {
+	if config == nil || config.Autopilot == nil {
+		return nil, fmt.Errorf("configuration has no autopilot section")
+	}
+	if properties == nil {
+		properties = NewPropertyManager()
+	}
+
+	resolved := standardPropertyNames()
+	for _, name := range properties.ListProperties() {
+		resolved[name] = true
+	}
+	properties.Set(AutopilotEngagedProperty, 0)
+	resolved[AutopilotEngagedProperty] = true
+	return buildFlightControl(config, config.Autopilot, "Autopilot", properties, resolved)
+}
+
+// buildFlightControl creates a flight control system from one section of a
+// configuration, given the properties its inputs may refer to besides those
+// it declares and outputs itself
+func buildFlightControl(config *JSBSimConfig, fc *FlightControl, defaultName string, properties *PropertyManager, resolved map[string]bool) (*FlightControlSystem, error) {
+	name := fc.Name
+	if name == "" {
+		name = defaultName
+	}
+
+	fcs := NewFlightControlSystem(name, 120.0)
+	fcs.Properties = properties
+	for _, rg := range fc.RateGroup {
+		fcs.AddRateGroup(rg.Name, rg.RateHz)
+	}
+
+	// Properties an input may refer to, growing with the declarations and
+	// each component's output
+	declared := fc.Property
+	if config.SystemControl != nil {
+		declared = append(append([]*DeclaredProperty{}, declared...), config.SystemControl.Property...)
+	}
+	for _, p := range declared {
+		name := normalizePropertyName(p.Name)
+		if name == "" {
+			return nil, fmt.Errorf("<property> declaration without a name")
+		}
+		value := 0.0
+		if p.Value != nil {
+			value = *p.Value
+		}
+		fcs.Properties.Set(name, value)
+		resolved[name] = true
+	}
+
+	for _, ch := range fc.Channel {
+		channel := fcs.AddChannel(ch.Name)
+
+		for _, comp := range ch.Component {
+			component, err := buildComponent(comp)
+			if err != nil {
+				return nil, fmt.Errorf("channel %q: %w", ch.Name, err)
+			}
+			if component == nil {
+				// JSBSim still produces the output of a type not modelled here
+				resolved[componentOutputProperty(comp)] = true
+				continue
+			}
+
+			for _, input := range component.GetInputs() {
+				if !resolved[input] {
+					return nil, fmt.Errorf("channel %q: %s %q: input %s is not a standard property, "+
+						"declared by <property> or the output of an earlier component", ch.Name, comp.Type, comp.Name, input)
+				}
+			}
+			resolved[component.GetOutput()] = true
+
+			// JSBSim publishes a component under its name as well as its
+			// <output>, so a name that is not already a property reads the
+			// output's value
+			if named := componentNameProperty(comp); comp.Name != "" && !resolved[named] {
+				fcs.Properties.SetAlias(named, component.GetOutput())
+				resolved[named] = true
+			}
+
+			if comp.RateGroup != "" {
+				component.SetRateGroup(comp.RateGroup)
+			}
+			channel.AddComponent(component)
+			fcs.AddComponent(component)
+		}
+	}
+
+	return fcs, nil
+}
+
+// standardPropertyNames returns the properties the simulator defines
+// without any configuration: the property manager's defaults and those the
+// aircraft state and propulsion system publish
+func standardPropertyNames() map[string]bool {
+	pm := NewPropertyManager()
+	NewPropulsionSystem().UpdateProperties(pm)
+
+	names := make(map[string]bool)
+	for _, name := range pm.ListProperties() {
+		names[name] = true
+	}
+	published := make(map[string]float64)
+	NewAircraftState().FillPropertyMap(published)
+	for name := range published {
+		names[name] = true
+	}
+	return names
+}
+
+// buildComponent converts a single parsed component, returning nil for
+// unsupported types
+func buildComponent(comp *Component) (ComponentProcessor, error) {
+	output := componentOutputProperty(comp)
+
+	switch strings.ToLower(comp.Type) {
+	case "switch":
+		return buildSwitchComponent(comp, output)
+
+	case "pure_gain", "gain":
+		if len(comp.Input) == 0 {
+			return nil, fmt.Errorf("%s %q has no input", comp.Type, comp.Name)
+		}
+		input, sign := parseComponentInput(comp.Input[0])
+		// A zero or missing gain means no gain element
+		gain := comp.Gain
+		if gain == 0 {
+			gain = 1
+		}
+		return NewGainComponent(comp.Name, input, output, sign*gain), nil
+
+	case "scheduled_gain":
+		if len(comp.Input) == 0 {
+			return nil, fmt.Errorf("%s %q has no input", comp.Type, comp.Name)
+		}
+		if comp.Table == nil {
+			return nil, fmt.Errorf("%s %q has no table", comp.Type, comp.Name)
+		}
+		schedule, err := ParseTable(comp.Table)
+		if err != nil {
+			return nil, fmt.Errorf("%s %q: %w", comp.Type, comp.Name, err)
+		}
+		if schedule.Dimension != 1 {
+			return nil, fmt.Errorf("%s %q: the schedule must be a one-dimensional table, not %d", comp.Type, comp.Name, schedule.Dimension)
+		}
+		input, sign := parseComponentInput(comp.Input[0])
+		gain := NewScheduledGainComponent(comp.Name, input, normalizePropertyName(schedule.IndependentVars[0]), output, schedule)
+		// A zero or missing gain means no gain element
+		if comp.Gain != 0 {
+			gain.Gain = comp.Gain
+		}
+		gain.Gain *= sign
+		return gain, nil
+
+	case "summer":
+		inputs := make([]string, len(comp.Input))
+		signs := make([]float64, len(comp.Input))
+		for i, raw := range comp.Input {
+			inputs[i], signs[i] = parseComponentInput(raw)
+		}
+		summer := NewSummerComponent(comp.Name, inputs, output)
+		summer.SetSigns(signs)
+		summer.SetBias(comp.Bias)
+		return summer, nil
+
+	case "lag_filter":
+		if len(comp.Input) == 0 {
+			return nil, fmt.Errorf("%s %q has no input", comp.Type, comp.Name)
+		}
+		input, sign := parseComponentInput(comp.Input[0])
+		filter := NewLagFilterComponent(comp.Name, input, output, bandwidthToTimeConstant(comp.C1))
+		filter.InputSign = sign
+		return filter, nil
+
+	case "aerosurface_scale":
+		if len(comp.Input) == 0 {
+			return nil, fmt.Errorf("%s %q has no input", comp.Type, comp.Name)
+		}
+		if comp.Range == nil {
+			return nil, fmt.Errorf("%s %q has no range", comp.Type, comp.Name)
+		}
+		input, sign := parseComponentInput(comp.Input[0])
+		scale := NewAerosurfaceScaleComponent(comp.Name, input, output, comp.Range.Min, comp.Range.Max)
+		if comp.Domain != nil {
+			scale.SetDomain(comp.Domain.Min, comp.Domain.Max)
+		}
+		if zc := strings.ToLower(strings.TrimSpace(comp.ZeroCentered)); zc == "false" || zc == "0" {
+			scale.ZeroCentered = false
+		}
+		// A zero or missing gain means no gain element
+		if comp.Gain != 0 {
+			scale.Gain = comp.Gain
+		}
+		scale.Gain *= sign
+		return scale, nil
+
+	case "saturation":
+		if len(comp.Input) == 0 {
+			return nil, fmt.Errorf("%s %q has no input", comp.Type, comp.Name)
+		}
+		input, sign := parseComponentInput(comp.Input[0])
+		saturation := NewSaturationComponent(comp.Name, input, output, math.Inf(-1), math.Inf(1))
+		if comp.Clipto != nil {
+			saturation = NewSaturationComponent(comp.Name, input, output, comp.Clipto.Min, comp.Clipto.Max)
+		}
+		saturation.InputSign = sign
+		minLimit, maxLimit := saturation.Min, saturation.Max
+		if comp.Min != "" {
+			limit, err := ParseSwitchOperand(comp.Min)
+			if err != nil {
+				return nil, fmt.Errorf("saturation %q: min: %w", comp.Name, err)
+			}
+			minLimit = limit
+		}
+		if comp.Max != "" {
+			limit, err := ParseSwitchOperand(comp.Max)
+			if err != nil {
+				return nil, fmt.Errorf("saturation %q: max: %w", comp.Name, err)
+			}
+			maxLimit = limit
+		}
+		saturation.SetLimits(minLimit, maxLimit)
+		return saturation, nil
+
+	case "actuator":
+		if len(comp.Input) == 0 {
+			return nil, fmt.Errorf("%s %q has no input", comp.Type, comp.Name)
+		}
+		input, sign := parseComponentInput(comp.Input[0])
+		actuator := NewActuatorComponent(comp.Name, input, output)
+		actuator.InputSign = sign
+		if comp.RateLimit > 0 {
+			actuator.SetRateLimit(comp.RateLimit)
+		}
+		actuator.SetLag(bandwidthToTimeConstant(comp.Lag))
+		actuator.BiasValue = comp.Bias
+		return actuator, nil
+	}
+
+	return nil, nil
+}
+
+// buildSwitchComponent converts the <test> and <default> children of a switch
+func buildSwitchComponent(comp *Component, output string) (*SwitchComponent, error) {
+	sw := NewSwitchComponent(comp.Name, output)
+
+	for _, t := range comp.Test {
+		test, err := ParseSwitchTest(t)
+		if err != nil {
+			return nil, fmt.Errorf("switch %q: %w", comp.Name, err)
+		}
+		value, err := ParseSwitchOperand(t.Value)
+		if err != nil {
+			return nil, fmt.Errorf("switch %q: test value: %w", comp.Name, err)
+		}
+		sw.AddBranch(test, value)
+	}
+
+	if comp.Default != nil {
+		value, err := ParseSwitchOperand(comp.Default.Value)
+		if err != nil {
+			return nil, fmt.Errorf("switch %q: default value: %w", comp.Name, err)
+		}
+		sw.SetDefault(value)
+	}
+
+	return sw, nil
+}
+
+// componentOutputProperty returns the property a component writes to: its
+// <output> element, or its name property
+func componentOutputProperty(comp *Component) string {
+	if output := normalizePropertyName(comp.Output); output != "" {
+		return output
+	}
+	return componentNameProperty(comp)
+}
+
+// componentNameProperty derives a property from a component's name as
+// JSBSim does: names that are paths are used as they are, others go under
+// fcs/ in lower case with dashes for spaces
+func componentNameProperty(comp *Component) string {
+	name := normalizePropertyName(comp.Name)
+	if strings.Contains(name, "/") {
+		return name
+	}
+	return "fcs/" + strings.ReplaceAll(strings.ToLower(name), " ", "-")
+}
+
+// parseComponentInput splits an input reference into its property and sign
+func parseComponentInput(raw string) (string, float64) {
+	raw = strings.TrimSpace(raw)
+	if strings.HasPrefix(raw, "-") {
+		if _, err := strconv.ParseFloat(raw, 64); err != nil {
+			return normalizePropertyName(raw[1:]), -1.0
+		}
+	}
+	return normalizePropertyName(raw), 1.0
+}
+
+// normalizePropertyName trims whitespace and the leading "/" of absolute
+// property paths
+func normalizePropertyName(name string) string {
+	return strings.TrimPrefix(strings.TrimSpace(name), "/")
+}
+
+// bandwidthToTimeConstant converts a JSBSim lag coefficient (rad/sec) into
+// the time constant used by the lag filter and actuator components
+func bandwidthToTimeConstant(c1 float64) float64 {
+	if c1 <= 0 {
+		return 0.0
+	}
+	return 1.0 / c1
+}
@@ -0,0 +1,664 @@
+package main
+
+import (
+	"math"
+	"os"
+	"strings"
+	"testing"
+)
+
+const switchTestXML = `<?xml version="1.0"?>
+<fdm_config name="switch-test" version="2.0">
+  <flight_control name="Switch Test FCS">
+    <property>fcs/flap-pos-deg</property>
+    <property>fcs/armed</property>
+    <property>fcs/alpha-deg</property>
+    <property>fcs/alpha-limit</property>
+    <property>fcs/beta-deg</property>
+    <property>fcs/select</property>
+    <channel name="Logic">
+      <switch name="fcs/mode">
+        <default value="0"/>
+        <test logic="AND" value="1">
+          gear/wow == 1
+          /velocities/vc-kts LT 40
+        </test>
+        <test logic="OR" value="2">
+          fcs/flap-pos-deg GE 20
+          gear/gear-pos-norm > 0.5
+        </test>
+        <test logic="AND" value="3">
+          fcs/armed == 1
+          <test logic="OR">
+            fcs/alpha-deg > fcs/alpha-limit
+            fcs/beta-deg > 10
+          </test>
+        </test>
+      </switch>
+      <switch name="Selector">
+        <default value="-fcs/elevator-cmd-norm"/>
+        <test value="fcs/aileron-cmd-norm">
+          fcs/select eq 1
+        </test>
+      </switch>
+      <pure_gain name="fcs/doubled">
+        <input>-fcs/mode</input>
+        <gain>2.0</gain>
+      </pure_gain>
+      <summer name="fcs/sum">
+        <input>fcs/mode</input>
+        <input>-fcs/doubled</input>
+        <bias>0.5</bias>
+      </summer>
+    </channel>
+  </flight_control>
+</fdm_config>`
+
+func buildSwitchTestFCS(t *testing.T) *FlightControlSystem {
+	config, err := ParseJSBSimConfig(strings.NewReader(switchTestXML))
+	if err != nil {
+		t.Fatalf("Failed to parse XML: %v", err)
+	}
+	fcs, err := BuildFCSFromConfig(config)
+	if err != nil {
+		t.Fatalf("Failed to build FCS: %v", err)
+	}
+	return fcs
+}
+
+func TestSwitchConditions(t *testing.T) {
+	fcs := buildSwitchTestFCS(t)
+	sw := fcs.GetComponent("fcs/mode")
+	if sw == nil {
+		t.Fatalf("Switch fcs/mode not built")
+	}
+	pm := fcs.Properties
+
+	t.Run("Default", func(t *testing.T) {
+		assertApproxEqual(t, sw.Execute(pm, 0.01), 0.0, 1e-9)
+	})
+
+	t.Run("AND Group", func(t *testing.T) {
+		pm.Set("gear/wow", 1.0)
+		pm.Set("velocities/vc-kts", 30.0)
+		assertApproxEqual(t, sw.Execute(pm, 0.01), 1.0, 1e-9)
+
+		// Both conditions must hold
+		pm.Set("velocities/vc-kts", 60.0)
+		assertApproxEqual(t, sw.Execute(pm, 0.01), 0.0, 1e-9)
+	})
+
+	t.Run("OR Group", func(t *testing.T) {
+		pm.Set("gear/gear-pos-norm", 1.0)
+		assertApproxEqual(t, sw.Execute(pm, 0.01), 2.0, 1e-9)
+		pm.Set("gear/gear-pos-norm", 0.0)
+		pm.Set("fcs/flap-pos-deg", 20.0)
+		assertApproxEqual(t, sw.Execute(pm, 0.01), 2.0, 1e-9)
+		pm.Set("fcs/flap-pos-deg", 0.0)
+	})
+
+	t.Run("Nested Group With Property Comparison", func(t *testing.T) {
+		pm.Set("fcs/armed", 1.0)
+		pm.Set("fcs/alpha-limit", 15.0)
+		pm.Set("fcs/alpha-deg", 10.0)
+		assertApproxEqual(t, sw.Execute(pm, 0.01), 0.0, 1e-9)
+
+		pm.Set("fcs/alpha-deg", 16.0)
+		assertApproxEqual(t, sw.Execute(pm, 0.01), 3.0, 1e-9)
+
+		pm.Set("fcs/alpha-limit", 20.0)
+		pm.Set("fcs/beta-deg", 12.0)
+		assertApproxEqual(t, sw.Execute(pm, 0.01), 3.0, 1e-9)
+	})
+
+	t.Run("First Passing Test Wins", func(t *testing.T) {
+		pm.Set("velocities/vc-kts", 10.0)
+		assertApproxEqual(t, sw.Execute(pm, 0.01), 1.0, 1e-9)
+		assertApproxEqual(t, pm.Get("fcs/mode"), 1.0, 1e-9)
+	})
+
+	t.Run("Property Outputs", func(t *testing.T) {
+		selector := fcs.GetComponent("Selector")
+		assertEqual(t, selector.GetOutput(), "fcs/selector")
+
+		pm.Set("fcs/elevator-cmd-norm", 0.4)
+		pm.Set("fcs/aileron-cmd-norm", 0.25)
+		assertApproxEqual(t, selector.Execute(pm, 0.01), -0.4, 1e-9)
+
+		pm.Set("fcs/select", 1.0)
+		assertApproxEqual(t, selector.Execute(pm, 0.01), 0.25, 1e-9)
+	})
+}
+
+func TestSwitchHoldsOutputWithoutDefault(t *testing.T) {
+	test, err := ParseSwitchTest(&Test{Test: "fcs/enable == 1"})
+	if err != nil {
+		t.Fatalf("Failed to parse test: %v", err)
+	}
+	sw := NewSwitchComponent("hold", "fcs/hold")
+	sw.AddBranch(test, &SwitchOperand{Constant: 5.0})
+
+	pm := NewPropertyManager()
+	assertApproxEqual(t, sw.Execute(pm, 0.01), 0.0, 1e-9)
+	pm.Set("fcs/enable", 1.0)
+	assertApproxEqual(t, sw.Execute(pm, 0.01), 5.0, 1e-9)
+	pm.Set("fcs/enable", 0.0)
+	assertApproxEqual(t, sw.Execute(pm, 0.01), 5.0, 1e-9)
+}
+
+func TestSwitchTestParseErrors(t *testing.T) {
+	bad := []*Test{
+		{Test: "gear/wow"},
+		{Test: "gear/wow ~= 1"},
+		{Test: "gear/wow == 1", Logic: "XOR"},
+		{Test: "   "},
+	}
+	for _, test := range bad {
+		if _, err := ParseSwitchTest(test); err == nil {
+			t.Errorf("Expected error for test %q logic %q", test.Test, test.Logic)
+		}
+	}
+}
+
+func TestBuildFCSFromConfig(t *testing.T) {
+	fcs := buildSwitchTestFCS(t)
+	assertEqual(t, fcs.GetChannel("Logic").GetComponentCount(), 4)
+
+	pm := fcs.Properties
+	pm.Set("gear/gear-pos-norm", 1.0)
+	fcs.GetChannel("Logic").Execute(pm, 0.01)
+
+	// mode = 2, doubled = -2*2 = -4, sum = 2 - (-4) + 0.5
+	assertApproxEqual(t, pm.Get("fcs/doubled"), -4.0, 1e-9)
+	assertApproxEqual(t, pm.Get("fcs/sum"), 6.5, 1e-9)
+
+	if _, err := BuildFCSFromConfig(&JSBSimConfig{}); err == nil {
+		t.Errorf("Expected error for config without flight_control")
+	}
+}
+
+const negatedInputXML = `<?xml version="1.0"?>
+<fdm_config name="negated-test" version="2.0">
+  <flight_control name="Negated Input FCS">
+    <channel name="Pitch">
+      <pure_gain name="fcs/unity">
+        <input>fcs/elevator-cmd-norm</input>
+      </pure_gain>
+      <lag_filter name="fcs/lagged">
+        <input>-fcs/elevator-cmd-norm</input>
+        <c1>10</c1>
+      </lag_filter>
+      <actuator name="fcs/actuated">
+        <input>-fcs/elevator-cmd-norm</input>
+        <bias>0.1</bias>
+      </actuator>
+    </channel>
+  </flight_control>
+</fdm_config>`
+
+func TestBuildNegatedInputs(t *testing.T) {
+	config, err := ParseJSBSimConfig(strings.NewReader(negatedInputXML))
+	if err != nil {
+		t.Fatalf("Failed to parse XML: %v", err)
+	}
+	fcs, err := BuildFCSFromConfig(config)
+	if err != nil {
+		t.Fatalf("Failed to build FCS: %v", err)
+	}
+
+	pm := fcs.Properties
+	pm.Set("fcs/elevator-cmd-norm", 0.5)
+	for i := 0; i < 500; i++ {
+		fcs.GetChannel("Pitch").Execute(pm, 0.01)
+	}
+
+	// A gain without a <gain> element passes its input through
+	assertApproxEqual(t, pm.Get("fcs/unity"), 0.5, 1e-9)
+	assertApproxEqual(t, pm.Get("fcs/lagged"), -0.5, 1e-6)
+	assertApproxEqual(t, pm.Get("fcs/actuated"), -0.4, 1e-9)
+}
+
+const scaleTestXML = `<?xml version="1.0"?>
+<fdm_config name="scale-test" version="2.0">
+  <flight_control name="Scale Test FCS">
+    <property>fcs/elevator-limit-deg</property>
+    <channel name="Pitch">
+      <aerosurface_scale name="Elevator Control">
+        <input>fcs/elevator-cmd-norm</input>
+        <range>
+          <min>-30.0</min>
+          <max>20.0</max>
+        </range>
+        <output>fcs/elevator-pos-deg</output>
+      </aerosurface_scale>
+      <aerosurface_scale name="Elevator Linear">
+        <input>fcs/elevator-cmd-norm</input>
+        <zero_centered>false</zero_centered>
+        <gain>2.0</gain>
+        <range>
+          <min>-30.0</min>
+          <max>20.0</max>
+        </range>
+      </aerosurface_scale>
+      <saturation name="Elevator Limit">
+        <input>fcs/elevator-pos-deg</input>
+        <min>-fcs/elevator-limit-deg</min>
+        <max>fcs/elevator-limit-deg</max>
+      </saturation>
+      <saturation name="Fixed Limit">
+        <input>fcs/elevator-pos-deg</input>
+        <clipto>
+          <min>-5.0</min>
+          <max>5.0</max>
+        </clipto>
+      </saturation>
+    </channel>
+  </flight_control>
+</fdm_config>`
+
+func TestBuildScaleAndSaturation(t *testing.T) {
+	config, err := ParseJSBSimConfig(strings.NewReader(scaleTestXML))
+	if err != nil {
+		t.Fatalf("Failed to parse XML: %v", err)
+	}
+	fcs, err := BuildFCSFromConfig(config)
+	if err != nil {
+		t.Fatalf("Failed to build FCS: %v", err)
+	}
+	assertEqual(t, fcs.GetChannel("Pitch").GetComponentCount(), 4)
+
+	pm := fcs.Properties
+	pm.Set("fcs/elevator-cmd-norm", 0.5)
+	pm.Set("fcs/elevator-limit-deg", 8.0)
+	fcs.GetChannel("Pitch").Execute(pm, 0.01)
+
+	assertApproxEqual(t, pm.Get("fcs/elevator-pos-deg"), 10.0, 1e-9)
+	assertApproxEqual(t, pm.Get("fcs/elevator-linear"), 2.0*7.5, 1e-9)
+	assertApproxEqual(t, pm.Get("fcs/elevator-limit"), 8.0, 1e-9)
+	assertApproxEqual(t, pm.Get("fcs/fixed-limit"), 5.0, 1e-9)
+
+	bad := strings.Replace(scaleTestXML, "<range>", "<ignored>", 1)
+	bad = strings.Replace(bad, "</range>", "</ignored>", 1)
+	config, err = ParseJSBSimConfig(strings.NewReader(bad))
+	if err != nil {
+		t.Fatalf("Failed to parse XML: %v", err)
+	}
+	if _, err := BuildFCSFromConfig(config); err == nil {
+		t.Errorf("Expected error for aerosurface_scale without a range")
+	}
+
+	// A negated input is limited after the sign is applied
+	negated := strings.Replace(scaleTestXML, "<input>fcs/elevator-pos-deg</input>", "<input>-fcs/elevator-pos-deg</input>", 1)
+	config, err = ParseJSBSimConfig(strings.NewReader(negated))
+	if err != nil {
+		t.Fatalf("Failed to parse XML: %v", err)
+	}
+	if fcs, err = BuildFCSFromConfig(config); err != nil {
+		t.Fatalf("Failed to build FCS: %v", err)
+	}
+	pm = fcs.Properties
+	pm.Set("fcs/elevator-cmd-norm", 0.5)
+	pm.Set("fcs/elevator-limit-deg", 8.0)
+	fcs.GetChannel("Pitch").Execute(pm, 0.01)
+	assertApproxEqual(t, pm.Get("fcs/elevator-limit"), -8.0, 1e-9)
+
+	// Limit properties must resolve like any other input
+	unknown := strings.Replace(scaleTestXML, "<max>fcs/elevator-limit-deg</max>", "<max>fcs/no-such-limit</max>", 1)
+	config, err = ParseJSBSimConfig(strings.NewReader(unknown))
+	if err != nil {
+		t.Fatalf("Failed to parse XML: %v", err)
+	}
+	if _, err := BuildFCSFromConfig(config); err == nil || !strings.Contains(err.Error(), "fcs/no-such-limit") {
+		t.Errorf("Expected an error naming the unknown limit property, got %v", err)
+	}
+}
+
+func TestBuildFCSFromP51D(t *testing.T) {
+	file, err := os.Open("aircraft/p51d-jsbsim.xml")
+	if err != nil {
+		t.Skip("P-51D XML file not found")
+	}
+	defer file.Close()
+
+	config, err := ParseJSBSimConfig(file)
+	if err != nil {
+		t.Fatalf("Failed to parse P-51D XML: %v", err)
+	}
+
+	// Properties FlightGear provides to the P-51D's FCS
+	for _, name := range []string{
+		"ai/submodels/submodel[6]/count",
+		"ai/submodels/submodel[8]/count",
+		"controls/armament/gun-trigger",
+		"controls/gear/brake-left",
+		"controls/gear/brake-right",
+		"sim/current-view/internal",
+		"sim/frame-rate",
+		"systems/brakes/brake-parking-cmd-out",
+	} {
+		config.FlightControl.Property = append(config.FlightControl.Property, &DeclaredProperty{Name: name})
+	}
+	fcs, err := BuildFCSFromConfig(config)
+	if err != nil {
+		t.Fatalf("Failed to build P-51D FCS: %v", err)
+	}
+
+	guns := fcs.GetComponent("systems/armament/innerGunsFiring")
+	if guns == nil {
+		t.Fatalf("Armament switch not built")
+	}
+	pm := fcs.Properties
+	assertApproxEqual(t, guns.Execute(pm, 0.01), 0.0, 1e-9)
+
+	pm.Set("controls/armament/gun-trigger", 1.0)
+	pm.Set("ai/submodels/submodel[6]/count", 100.0)
+	assertApproxEqual(t, guns.Execute(pm, 0.01), 1.0, 1e-9)
+	assertApproxEqual(t, pm.Get("systems/armament/innerGunsFiring"), 1.0, 1e-9)
+
+	// Pitch trim is scaled to elevator degrees
+	if fcs.GetComponent("Elevator Control") == nil {
+		t.Errorf("Elevator Control aerosurface_scale not built")
+	}
+}
+
+const declaredPropertyTestXML = `<?xml version="1.0"?>
+<fdm_config name="declared-property-test" version="2.0">
+  <flight_control name="Declared Property FCS">
+    <property value="1">fcs/gain-scheduling-enabled</property>
+    <property>fcs/scheduled-bias</property>
+    <channel name="Scheduling">
+      <switch name="fcs/scheduled-gain">
+        <default value="0.5"/>
+        <test value="2.0">
+          fcs/gain-scheduling-enabled == 1
+        </test>
+      </switch>
+      <pure_gain name="Scheduled Elevator">
+        <input>fcs/elevator-cmd-norm</input>
+        <gain>2.0</gain>
+        <output>fcs/elevator-scheduled</output>
+      </pure_gain>
+      <summer name="fcs/elevator-biased">
+        <input>fcs/scheduled-elevator</input>
+        <input>fcs/scheduled-bias</input>
+        <input>systems/trim/elevator-offset</input>
+      </summer>
+    </channel>
+  </flight_control>
+  <system name="Trim">
+    <property value="0.25">systems/trim/elevator-offset</property>
+  </system>
+</fdm_config>`
+
+func TestDeclaredProperties(t *testing.T) {
+	config, err := ParseJSBSimConfig(strings.NewReader(declaredPropertyTestXML))
+	if err != nil {
+		t.Fatalf("Failed to parse XML: %v", err)
+	}
+
+	t.Run("Parsed With Defaults", func(t *testing.T) {
+		declared := config.FlightControl.Property
+		assertEqual(t, len(declared), 2)
+		assertEqual(t, strings.TrimSpace(declared[0].Name), "fcs/gain-scheduling-enabled")
+		if declared[0].Value == nil || *declared[0].Value != 1.0 {
+			t.Errorf("Expected a default of 1, got %v", declared[0].Value)
+		}
+		if declared[1].Value != nil {
+			t.Errorf("Expected no default, got %v", *declared[1].Value)
+		}
+		assertEqual(t, len(config.SystemControl.Property), 1)
+	})
+
+	t.Run("Initialized Before First Tick", func(t *testing.T) {
+		fcs, err := BuildFCSFromConfig(config)
+		if err != nil {
+			t.Fatalf("Failed to build FCS: %v", err)
+		}
+		pm := fcs.Properties
+		assertApproxEqual(t, pm.Get("fcs/gain-scheduling-enabled"), 1.0, 1e-12)
+		if _, ok := pm.GetSafe("fcs/scheduled-bias"); !ok {
+			t.Error("fcs/scheduled-bias should be declared")
+		}
+
+		state := NewAircraftState()
+		state.Velocity = Vector3{X: 60.0}
+		state.Controls.Elevator = 0.1
+		fcs.Execute(state, 1.0/120.0)
+
+		// The switch takes its true branch on the first tick
+		assertApproxEqual(t, pm.Get("fcs/scheduled-gain"), 2.0, 1e-12)
+
+		// The gain is read under its name as well as its output
+		assertApproxEqual(t, pm.Get("fcs/elevator-scheduled"), 0.2, 1e-12)
+		assertApproxEqual(t, pm.Get("fcs/scheduled-elevator"), 0.2, 1e-12)
+		assertApproxEqual(t, pm.Get("fcs/elevator-biased"), 0.45, 1e-12)
+	})
+
+	t.Run("Unresolved Inputs", func(t *testing.T) {
+		for _, tc := range []struct {
+			name, from, to, input string
+		}{
+			{"Undeclared", "<property>fcs/scheduled-bias</property>", "", "fcs/scheduled-bias"},
+			{"Later Output", "fcs/gain-scheduling-enabled == 1", "fcs/elevator-biased == 1", "fcs/elevator-biased"},
+		} {
+			broken := strings.Replace(declaredPropertyTestXML, tc.from, tc.to, 1)
+			config, err := ParseJSBSimConfig(strings.NewReader(broken))
+			if err != nil {
+				t.Fatalf("%s: failed to parse XML: %v", tc.name, err)
+			}
+			_, err = BuildFCSFromConfig(config)
+			if err == nil || !strings.Contains(err.Error(), "input "+tc.input+" is not") {
+				t.Errorf("%s: expected an error naming %s, got %v", tc.name, tc.input, err)
+			}
+		}
+	})
+}
+
+// autopilotTestXML holds an altitude hold whose elevator command, passed on
+// by a switch only while engaged, is summed with the pilot's in the
+// flight control elevator channel
+const autopilotTestXML = `<?xml version="1.0"?>
+<fdm_config name="autopilot-test" version="2.0">
+  <flight_control name="FCS: autopilot test">
+    <channel name="Pitch">
+      <summer name="fcs/pitch-cmd-sum">
+        <input>fcs/elevator-cmd-norm</input>
+        <input>ap/elevator-cmd</input>
+      </summer>
+      <aerosurface_scale name="fcs/elevator-pos-rad">
+        <input>fcs/pitch-cmd-sum</input>
+        <range><min>-0.35</min><max>0.35</max></range>
+      </aerosurface_scale>
+    </channel>
+  </flight_control>
+  <autopilot name="Altitude Hold">
+    <property value="1000">ap/altitude-setpoint-ft</property>
+    <channel name="Altitude">
+      <summer name="ap/altitude-error-ft">
+        <input>ap/altitude-setpoint-ft</input>
+        <input>-position/h-sl-ft</input>
+      </summer>
+      <pure_gain name="ap/elevator-demand">
+        <input>ap/altitude-error-ft</input>
+        <gain>0.001</gain>
+      </pure_gain>
+      <switch name="ap/elevator-cmd">
+        <default value="0"/>
+        <test value="ap/elevator-demand">
+          ap/engaged == 1
+        </test>
+      </switch>
+    </channel>
+  </autopilot>
+</fdm_config>`
+
+func TestAutopilot(t *testing.T) {
+	config, err := ParseJSBSimConfig(strings.NewReader(autopilotTestXML))
+	if err != nil {
+		t.Fatalf("Failed to parse XML: %v", err)
+	}
+	fcs, err := BuildFCSFromConfig(config)
+	if err != nil {
+		t.Fatalf("Failed to build FCS: %v", err)
+	}
+	autopilot, err := BuildAutopilotFromConfig(config, fcs.Properties)
+	if err != nil {
+		t.Fatalf("Failed to build autopilot: %v", err)
+	}
+	assertEqual(t, autopilot.Name, "Altitude Hold")
+	assertEqual(t, autopilot.Properties, fcs.Properties)
+	assertEqual(t, len(autopilot.Components), 3)
+	assertEqual(t, len(fcs.Components), 2)
+
+	engine := &FlightDynamicsEngineWithFCS{FCS: fcs, Autopilot: autopilot}
+	pm := fcs.Properties
+
+	// 200 ft below the 1000 ft setpoint with a little pilot elevator
+	state := NewAircraftState()
+	state.Altitude = 800 / 3.28084
+	controls := ControlInputs{Elevator: 0.1}
+
+	t.Run("Disengaged", func(t *testing.T) {
+		assertEqual(t, engine.AutopilotEngaged(), false)
+		engine.SetControlInputsOnState(state, controls)
+		assertApproxEqual(t, pm.Get("ap/altitude-error-ft"), 200, 1e-9)
+		assertApproxEqual(t, pm.Get("ap/elevator-cmd"), 0, 1e-12)
+		assertApproxEqual(t, state.ControlSurfaces.Elevator, 0.1*0.35, 1e-9)
+	})
+
+	t.Run("Engaged", func(t *testing.T) {
+		// The autopilot runs first, so its command reaches the elevator in
+		// the same step
+		engine.EngageAutopilot()
+		assertEqual(t, engine.AutopilotEngaged(), true)
+		engine.SetControlInputsOnState(state, controls)
+		assertApproxEqual(t, pm.Get("ap/elevator-cmd"), 0.2, 1e-9)
+		assertApproxEqual(t, pm.Get("fcs/pitch-cmd-sum"), 0.3, 1e-9)
+		assertApproxEqual(t, state.ControlSurfaces.Elevator, 0.3*0.35, 1e-9)
+
+		// Climbing to the setpoint takes the command out
+		state.Altitude = 1000 / 3.28084
+		engine.SetControlInputsOnState(state, controls)
+		assertApproxEqual(t, state.ControlSurfaces.Elevator, 0.1*0.35, 1e-9)
+	})
+
+	t.Run("Disengage", func(t *testing.T) {
+		state.Altitude = 800 / 3.28084
+		engine.DisengageAutopilot()
+		engine.SetControlInputsOnState(state, controls)
+		assertApproxEqual(t, state.ControlSurfaces.Elevator, 0.1*0.35, 1e-9)
+	})
+
+	t.Run("Engine", func(t *testing.T) {
+		withFCS, err := NewFlightDynamicsEngineWithFCS(config, false)
+		if err != nil {
+			t.Fatalf("NewFlightDynamicsEngineWithFCS: %v", err)
+		}
+		if withFCS.Autopilot == nil || withFCS.Autopilot.Properties != withFCS.FCS.Properties {
+			t.Fatal("Expected the autopilot to be loaded on the FCS properties")
+		}
+		if _, err := BuildAutopilotFromConfig(&JSBSimConfig{}, nil); err == nil {
+			t.Error("Expected an error for a configuration without an autopilot")
+		}
+	})
+
+	t.Run("Unresolved Without Autopilot", func(t *testing.T) {
+		// The flight controls may only read the autopilot's command when
+		// there is an autopilot to write it
+		saved := config.Autopilot
+		config.Autopilot = nil
+		defer func() { config.Autopilot = saved }()
+		if _, err := BuildFCSFromConfig(config); err == nil || !strings.Contains(err.Error(), "ap/elevator-cmd") {
+			t.Errorf("Expected ap/elevator-cmd to be unresolved, got %v", err)
+		}
+	})
+}
+
+const scheduledGainTestXML = `<?xml version="1.0"?>
+<fdm_config name="scheduled-gain-test" version="2.0">
+  <flight_control name="Scheduled Gain Test FCS">
+    <channel name="Pitch">
+      <scheduled_gain name="fcs/elevator-scheduled">
+        <input>fcs/elevator-cmd-norm</input>
+        <table>
+          <independentVar>aero/qbar-psf</independentVar>
+          <tableData>
+              0.0  1.00
+             20.0  1.00
+             50.0  0.60
+            100.0  0.30
+          </tableData>
+        </table>
+        <gain>2.0</gain>
+      </scheduled_gain>
+      <scheduled_gain name="fcs/elevator-mach-scheduled">
+        <input>-fcs/elevator-cmd-norm</input>
+        <table>
+          <independentVar>velocities/mach</independentVar>
+          <tableData>
+            0.0  1.0
+            1.0  0.0
+          </tableData>
+        </table>
+      </scheduled_gain>
+    </channel>
+  </flight_control>
+</fdm_config>`
+
+func TestScheduledGain(t *testing.T) {
+	config, err := ParseJSBSimConfig(strings.NewReader(scheduledGainTestXML))
+	if err != nil {
+		t.Fatalf("Failed to parse XML: %v", err)
+	}
+	fcs, err := BuildFCSFromConfig(config)
+	if err != nil {
+		t.Fatalf("Failed to build FCS: %v", err)
+	}
+	assertEqual(t, fcs.GetChannel("Pitch").GetComponentCount(), 2)
+
+	// Sea-level airspeed (m/s) at a dynamic pressure (psf)
+	density := cruiseState(0, 1, 0).Density
+	airspeedAt := func(qbarPSF float64) float64 {
+		return math.Sqrt(2 * qbarPSF / 0.020885 / density)
+	}
+
+	// The elevator authority falls as the dynamic pressure rises, at the
+	// table's operating points
+	var previous float64
+	for i, point := range []struct{ qbar, gain float64 }{{20, 1.0}, {50, 0.6}, {100, 0.3}} {
+		state := cruiseState(0, airspeedAt(point.qbar), 0)
+		state.Controls.Elevator = 0.5
+		fcs.Execute(state, 0.01)
+
+		pm := fcs.Properties
+		assertApproxEqual(t, pm.Get("aero/qbar-psf"), point.qbar, 1e-6)
+		output := pm.Get("fcs/elevator-scheduled")
+		assertApproxEqual(t, output, 0.5*point.gain*2.0, 1e-6)
+		if i > 0 && output >= previous {
+			t.Errorf("At %g psf the output should be below %g, got %g", point.qbar, previous, output)
+		}
+		previous = output
+
+		// Mach is published for schedules too
+		assertApproxEqual(t, pm.Get("fcs/elevator-mach-scheduled"), -0.5*(1-state.Mach), 1e-9)
+	}
+
+	t.Run("Errors", func(t *testing.T) {
+		for name, edit := range map[string]func(string) string{
+			"No Table": func(xml string) string {
+				return strings.Replace(strings.Replace(xml, "<table>", "<ignored>", 1), "</table>", "</ignored>", 1)
+			},
+			"Unresolved Scheduling Property": func(xml string) string {
+				return strings.Replace(xml, "aero/qbar-psf", "aero/no-such-qbar", 1)
+			},
+		} {
+			config, err := ParseJSBSimConfig(strings.NewReader(edit(scheduledGainTestXML)))
+			if err != nil {
+				t.Fatalf("%s: failed to parse XML: %v", name, err)
+			}
+			if _, err := BuildFCSFromConfig(config); err == nil {
+				t.Errorf("%s: expected an error", name)
+			}
+		}
+	})
+}
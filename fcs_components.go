@@ -6,6 +6,8 @@ package main
 import (
 	"fmt"
 	"math"
+	"strconv"
+	"strings"
 )
 
 // ComponentProcessor defines the interface for all FCS components
@@ -93,6 +95,7 @@ type ActuatorComponent struct {
 	Lag              float64 // Time constant (seconds) - 0 means no lag
 	HysteresisWidth  float64 // Hysteresis band width
 	BiasValue        float64 // Bias offset
+	InputSign        float64 // -1 for a negated input
 	
 	// Internal state
 	currentValue     float64 // Current output value
@@ -115,6 +118,7 @@ func NewActuatorComponent(name, input, output string) *ActuatorComponent {
 		Lag:             0.0,         // No lag by default
 		HysteresisWidth: 0.0,         // No hysteresis by default
 		BiasValue:       0.0,         // No bias by default
+		InputSign:       1.0,
 	}
 }
 
@@ -125,7 +129,7 @@ func (ac *ActuatorComponent) Execute(properties *PropertyManager, dt float64) fl
 	}
 	
 	// Get input value
-	inputValue := properties.Get(ac.Inputs[0]) + ac.BiasValue
+	inputValue := ac.InputSign*properties.Get(ac.Inputs[0]) + ac.BiasValue
 	
 	// Apply hysteresis if configured
 	if ac.HysteresisWidth > 0.0 {
@@ -205,7 +209,8 @@ type LagFilterComponent struct {
 	BaseComponent
 	
 	// Configuration
-	C1 float64 // Time constant (seconds)
+	C1        float64 // Time constant (seconds)
+	InputSign float64 // -1 for a negated input
 	
 	// Internal state
 	output      float64
@@ -222,19 +227,20 @@ func NewLagFilterComponent(name, input, output string, timeConstant float64) *La
 			Output:  output,
 			Enabled: true,
 		},
-		C1: timeConstant,
+		C1:        timeConstant,
+		InputSign: 1.0,
 	}
 }
 
 // Execute processes the lag filter
 func (lf *LagFilterComponent) Execute(properties *PropertyManager, dt float64) float64 {
 	if !lf.Enabled || len(lf.Inputs) == 0 || lf.C1 <= 0.0 {
-		input := properties.Get(lf.Inputs[0])
+		input := lf.InputSign * properties.Get(lf.Inputs[0])
 		properties.Set(lf.Output, input)
 		return input
 	}
 	
-	input := properties.Get(lf.Inputs[0])
+	input := lf.InputSign * properties.Get(lf.Inputs[0])
 	
 	// Initialize on first run
 	if !lf.initialized {
@@ -260,6 +266,151 @@ func (lf *LagFilterComponent) Reset() {
 	lf.initialized = false
 }
 
+// =============================================================================
+// WASHOUT FILTER COMPONENT
+// =============================================================================
+
+// WashoutFilterComponent implements a first-order washout (high-pass) filter,
+// s/(s + 1/τ). Steady inputs decay to zero output; changes pass through.
+type WashoutFilterComponent struct {
+	BaseComponent
+	
+	// Configuration
+	C1 float64 // Time constant (seconds)
+	
+	// Internal state - low-pass of the input, subtracted from it
+	lowPass     float64
+	initialized bool
+}
+
+// NewWashoutFilterComponent creates a new washout filter
+func NewWashoutFilterComponent(name, input, output string, timeConstant float64) *WashoutFilterComponent {
+	return &WashoutFilterComponent{
+		BaseComponent: BaseComponent{
+			Name:    name,
+			Type:    "WASHOUT_FILTER",
+			Inputs:  []string{input},
+			Output:  output,
+			Enabled: true,
+		},
+		C1: timeConstant,
+	}
+}
+
+// Execute processes the washout filter
+func (wf *WashoutFilterComponent) Execute(properties *PropertyManager, dt float64) float64 {
+	if !wf.Enabled || len(wf.Inputs) == 0 {
+		return 0.0
+	}
+	
+	input := properties.Get(wf.Inputs[0])
+	
+	// Start from the current input so an initial offset is already washed out
+	if !wf.initialized {
+		wf.lowPass = input
+		wf.initialized = true
+	}
+	
+	output := 0.0
+	if wf.C1 > 0.0 {
+		wf.lowPass += dt / (wf.C1 + dt) * (input - wf.lowPass)
+		output = input - wf.lowPass
+	}
+	
+	// Set output property
+	if wf.Output != "" {
+		properties.Set(wf.Output, output)
+	}
+	
+	return output
+}
+
+// Reset resets the filter's internal state
+func (wf *WashoutFilterComponent) Reset() {
+	wf.lowPass = 0.0
+	wf.initialized = false
+}
+
+// =============================================================================
+// PID COMPONENT
+// =============================================================================
+
+// PIDComponent implements a proportional-integral-derivative controller on
+// its input, which is normally an error signal. The integral is clamped to
+// ±IntegralLimit when that is positive.
+type PIDComponent struct {
+	BaseComponent
+	
+	// Configuration
+	Kp            float64
+	Ki            float64
+	Kd            float64
+	IntegralLimit float64 // Anti-windup bound on the integral term (0 = none)
+	
+	// Internal state
+	integral    float64
+	previous    float64
+	initialized bool
+}
+
+// NewPIDComponent creates a new PID controller
+func NewPIDComponent(name, input, output string, kp, ki, kd float64) *PIDComponent {
+	return &PIDComponent{
+		BaseComponent: BaseComponent{
+			Name:    name,
+			Type:    "PID",
+			Inputs:  []string{input},
+			Output:  output,
+			Enabled: true,
+		},
+		Kp: kp,
+		Ki: ki,
+		Kd: kd,
+	}
+}
+
+// Execute processes the controller
+func (pid *PIDComponent) Execute(properties *PropertyManager, dt float64) float64 {
+	if !pid.Enabled || len(pid.Inputs) == 0 {
+		return 0.0
+	}
+	
+	input := properties.Get(pid.Inputs[0])
+	
+	// No derivative kick on the first frame
+	if !pid.initialized {
+		pid.previous = input
+		pid.initialized = true
+	}
+	
+	derivative := 0.0
+	if dt > 0.0 {
+		// Trapezoidal integration of the input
+		pid.integral += 0.5 * (input + pid.previous) * dt
+		derivative = (input - pid.previous) / dt
+	}
+	if pid.IntegralLimit > 0.0 {
+		pid.integral = math.Max(-pid.IntegralLimit, math.Min(pid.IntegralLimit, pid.integral))
+	}
+	pid.previous = input
+	
+	output := pid.Kp*input + pid.Ki*pid.integral + pid.Kd*derivative
+	
+	// Set output property
+	if pid.Output != "" {
+		properties.Set(pid.Output, output)
+	}
+	
+	return output
+}
+
+// Reset clears the integral and derivative history
+func (pid *PIDComponent) Reset() {
+	pid.integral = 0.0
+	pid.previous = 0.0
+	pid.initialized = false
+}
+
 // =============================================================================
 // GAIN COMPONENT
 // =============================================================================
@@ -303,6 +454,59 @@ func (gc *GainComponent) Execute(properties *PropertyManager, dt float64) float6
 	return output
 }
 
+// =============================================================================
+// SCHEDULED GAIN COMPONENT
+// =============================================================================
+
+// ScheduledGainComponent implements a JSBSim scheduled_gain: the input
+// times a gain looked up in a table on a scheduling property, such as
+// dynamic pressure or Mach, times a constant gain
+type ScheduledGainComponent struct {
+	BaseComponent
+	
+	// Configuration
+	Schedule *ParsedTable // One-dimensional, indexed on Inputs[1]
+	Gain     float64
+}
+
+// NewScheduledGainComponent creates a scheduled gain, the schedule indexed
+// on the scheduling property
+func NewScheduledGainComponent(name, input, scheduling, output string, schedule *ParsedTable) *ScheduledGainComponent {
+	return &ScheduledGainComponent{
+		BaseComponent: BaseComponent{
+			Name:    name,
+			Type:    "SCHEDULED_GAIN",
+			Inputs:  []string{input, scheduling},
+			Output:  output,
+			Enabled: true,
+		},
+		Schedule: schedule,
+		Gain:     1.0,
+	}
+}
+
+// Execute processes the scheduled gain. A schedule that cannot be looked
+// up, such as on a non-finite scheduling value, gives no gain.
+func (sg *ScheduledGainComponent) Execute(properties *PropertyManager, dt float64) float64 {
+	if !sg.Enabled || len(sg.Inputs) < 2 {
+		return 0.0
+	}
+	
+	input := properties.Get(sg.Inputs[0])
+	scheduled, err := InterpolateTable(sg.Schedule, properties.Get(sg.Inputs[1]))
+	if err != nil {
+		scheduled = 0.0
+	}
+	output := input * scheduled * sg.Gain
+	
+	// Set output property
+	if sg.Output != "" {
+		properties.Set(sg.Output, output)
+	}
+	
+	return output
+}
+
 // =============================================================================
 // SUMMER COMPONENT
 // =============================================================================
@@ -417,6 +621,155 @@ func (cc *ClipperComponent) Execute(properties *PropertyManager, dt float64) flo
 	return output
 }
 
+// =============================================================================
+// SATURATION COMPONENT
+// =============================================================================
+
+// SaturationComponent limits its input to [Min, Max]. Unlike the clipper,
+// either limit may be a property so the bounds can be scheduled at runtime.
+type SaturationComponent struct {
+	BaseComponent
+	
+	// Configuration
+	Min       *SwitchOperand
+	Max       *SwitchOperand
+	InputSign float64 // -1 for a negated input
+}
+
+// NewSaturationComponent creates a saturation with constant limits
+func NewSaturationComponent(name, input, output string, minVal, maxVal float64) *SaturationComponent {
+	return &SaturationComponent{
+		BaseComponent: BaseComponent{
+			Name:    name,
+			Type:    "SATURATION",
+			Inputs:  []string{input},
+			Output:  output,
+			Enabled: true,
+		},
+		Min:       &SwitchOperand{Constant: minVal},
+		Max:       &SwitchOperand{Constant: maxVal},
+		InputSign: 1.0,
+	}
+}
+
+// SetLimits replaces the limits, each a number or a (possibly negated)
+// property; limit properties become inputs after the signal
+func (sc *SaturationComponent) SetLimits(minVal, maxVal *SwitchOperand) {
+	sc.Min = minVal
+	sc.Max = maxVal
+	for _, limit := range []*SwitchOperand{minVal, maxVal} {
+		if limit != nil && limit.Property != "" {
+			sc.Inputs = append(sc.Inputs, limit.Property)
+		}
+	}
+}
+
+// Execute processes the saturation
+func (sc *SaturationComponent) Execute(properties *PropertyManager, dt float64) float64 {
+	if !sc.Enabled || len(sc.Inputs) == 0 {
+		return 0.0
+	}
+	
+	output := sc.InputSign * properties.Get(sc.Inputs[0])
+	if sc.Min != nil {
+		output = math.Max(sc.Min.Value(properties), output)
+	}
+	if sc.Max != nil {
+		output = math.Min(sc.Max.Value(properties), output)
+	}
+	
+	// Set output property
+	if sc.Output != "" {
+		properties.Set(sc.Output, output)
+	}
+	
+	return output
+}
+
+// =============================================================================
+// AEROSURFACE SCALE COMPONENT
+// =============================================================================
+
+// AerosurfaceScaleComponent maps a normalized command onto a surface range.
+// The input domain [DomainMin, DomainMax] (default [-1, 1]) is mapped
+// linearly onto [RangeMin, RangeMax] and multiplied by Gain. With
+// ZeroCentered, the positive and negative halves are scaled separately so
+// that zero input always gives zero output, even for asymmetric ranges.
+// Inputs outside the domain are extrapolated, not clipped.
+type AerosurfaceScaleComponent struct {
+	BaseComponent
+	
+	// Configuration
+	DomainMin    float64
+	DomainMax    float64
+	RangeMin     float64
+	RangeMax     float64
+	ZeroCentered bool
+	Gain         float64
+}
+
+// NewAerosurfaceScaleComponent creates a scale over the default [-1, 1]
+// domain. Zero centering is on by default, as in JSBSim.
+func NewAerosurfaceScaleComponent(name, input, output string, rangeMin, rangeMax float64) *AerosurfaceScaleComponent {
+	return &AerosurfaceScaleComponent{
+		BaseComponent: BaseComponent{
+			Name:    name,
+			Type:    "AEROSURFACE_SCALE",
+			Inputs:  []string{input},
+			Output:  output,
+			Enabled: true,
+		},
+		DomainMin:    -1.0,
+		DomainMax:    1.0,
+		RangeMin:     rangeMin,
+		RangeMax:     rangeMax,
+		ZeroCentered: true,
+		Gain:         1.0,
+	}
+}
+
+// SetDomain configures the input domain
+func (as *AerosurfaceScaleComponent) SetDomain(minVal, maxVal float64) {
+	as.DomainMin = minVal
+	as.DomainMax = maxVal
+}
+
+// Execute processes the scale
+func (as *AerosurfaceScaleComponent) Execute(properties *PropertyManager, dt float64) float64 {
+	if !as.Enabled || len(as.Inputs) == 0 {
+		return 0.0
+	}
+	
+	output := as.Gain * as.scale(properties.Get(as.Inputs[0]))
+	
+	// Set output property
+	if as.Output != "" {
+		properties.Set(as.Output, output)
+	}
+	
+	return output
+}
+
+// scale maps an input from the domain onto the range
+func (as *AerosurfaceScaleComponent) scale(input float64) float64 {
+	// Zero centering needs a domain that spans zero
+	if as.ZeroCentered && as.DomainMin < 0 && as.DomainMax > 0 {
+		switch {
+		case input > 0:
+			return input / as.DomainMax * as.RangeMax
+		case input < 0:
+			return input / as.DomainMin * as.RangeMin
+		default:
+			return 0.0
+		}
+	}
+	
+	if as.DomainMax == as.DomainMin {
+		return as.RangeMin
+	}
+	return as.RangeMin + (input-as.DomainMin)/(as.DomainMax-as.DomainMin)*(as.RangeMax-as.RangeMin)
+}
+
 // =============================================================================
 // SWITCH COMPONENT
 // =============================================================================
@@ -433,6 +786,15 @@ type SwitchComponent struct {
 	FalseValue   float64   // Value when test is false
 	TrueInput    string    // Input property when test is true
 	FalseInput   string    // Input property when test is false
+	
+	// JSBSim-style branches: the first branch whose test passes selects the
+	// output, otherwise the default is used. When Branches is empty the single
+	// TestProperty/TestType/TestValue condition above is used instead.
+	Branches     []*SwitchBranch
+	Default      *SwitchOperand
+	
+	// Internal state
+	output       float64
 }
 
 // NewSwitchComponent creates a new switch component
@@ -457,6 +819,10 @@ func (sw *SwitchComponent) Execute(properties *PropertyManager, dt float64) floa
 		return 0.0
 	}
 	
+	if len(sw.Branches) > 0 || sw.Default != nil {
+		return sw.executeBranches(properties)
+	}
+	
 	// Evaluate test condition
 	testResult := false
 	if sw.TestProperty != "" {
@@ -530,6 +896,249 @@ func (sw *SwitchComponent) SetInputs(trueInput, falseInput string) {
 	}
 }
 
+// executeBranches selects the output of the first passing branch, or the default
+func (sw *SwitchComponent) executeBranches(properties *PropertyManager) float64 {
+	selected := sw.Default
+	for _, branch := range sw.Branches {
+		if branch.Test.Evaluate(properties) {
+			selected = branch.Output
+			break
+		}
+	}
+	
+	// With no passing test and no default, JSBSim holds the previous output
+	if selected != nil {
+		sw.output = selected.Value(properties)
+	}
+	
+	if sw.Output != "" {
+		properties.Set(sw.Output, sw.output)
+	}
+	
+	return sw.output
+}
+
+// AddBranch appends a test/output pair evaluated in order
+func (sw *SwitchComponent) AddBranch(test *SwitchTest, output *SwitchOperand) {
+	sw.Branches = append(sw.Branches, &SwitchBranch{Test: test, Output: output})
+	sw.Inputs = append(sw.Inputs, test.Properties()...)
+	if output.Property != "" {
+		sw.Inputs = append(sw.Inputs, output.Property)
+	}
+}
+
+// SetDefault configures the output used when no branch test passes
+func (sw *SwitchComponent) SetDefault(output *SwitchOperand) {
+	sw.Default = output
+	if output.Property != "" {
+		sw.Inputs = append(sw.Inputs, output.Property)
+	}
+}
+
+// Reset clears the held output
+func (sw *SwitchComponent) Reset() {
+	sw.output = 0.0
+}
+
+// =============================================================================
+// SWITCH CONDITIONS
+// =============================================================================
+
+// SwitchOperand is either a literal number or a (possibly negated) property
+type SwitchOperand struct {
+	Property string  // Property name, empty for a literal
+	Sign     float64 // -1 for "-property" references
+	Constant float64 // Literal value when Property is empty
+}
+
+// ParseSwitchOperand parses a number, "property", or "-property"
+func ParseSwitchOperand(text string) (*SwitchOperand, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, fmt.Errorf("empty operand")
+	}
+	
+	if value, err := strconv.ParseFloat(text, 64); err == nil {
+		return &SwitchOperand{Constant: value}, nil
+	}
+	
+	sign := 1.0
+	if strings.HasPrefix(text, "-") {
+		sign = -1.0
+		text = text[1:]
+	}
+	
+	return &SwitchOperand{Property: normalizePropertyName(text), Sign: sign}, nil
+}
+
+// Value resolves the operand against the property tree
+func (op *SwitchOperand) Value(properties *PropertyManager) float64 {
+	if op.Property == "" {
+		return op.Constant
+	}
+	return op.Sign * properties.Get(op.Property)
+}
+
+// SwitchComparison is a single "left op right" condition line
+type SwitchComparison struct {
+	Left     *SwitchOperand
+	Operator string // Canonical form: EQ, NE, GT, GE, LT, LE
+	Right    *SwitchOperand
+}
+
+// switchOperators maps both JSBSim spellings of each operator to the canonical form
+var switchOperators = map[string]string{
+	"==": "EQ", "EQ": "EQ", "eq": "EQ",
+	"!=": "NE", "NE": "NE", "ne": "NE",
+	">":  "GT", "GT": "GT", "gt": "GT",
+	">=": "GE", "GE": "GE", "ge": "GE",
+	"<":  "LT", "LT": "LT", "lt": "LT",
+	"<=": "LE", "LE": "LE", "le": "LE",
+}
+
+// ParseSwitchComparison parses a condition line such as "gear/wow == 1"
+func ParseSwitchComparison(line string) (*SwitchComparison, error) {
+	fields := strings.Fields(line)
+	if len(fields) != 3 {
+		return nil, fmt.Errorf("condition %q must have the form <left> <op> <right>", strings.TrimSpace(line))
+	}
+	
+	operator, ok := switchOperators[fields[1]]
+	if !ok {
+		return nil, fmt.Errorf("condition %q has unknown operator %q", strings.TrimSpace(line), fields[1])
+	}
+	
+	left, err := ParseSwitchOperand(fields[0])
+	if err != nil {
+		return nil, fmt.Errorf("condition %q: %w", strings.TrimSpace(line), err)
+	}
+	right, err := ParseSwitchOperand(fields[2])
+	if err != nil {
+		return nil, fmt.Errorf("condition %q: %w", strings.TrimSpace(line), err)
+	}
+	
+	return &SwitchComparison{Left: left, Operator: operator, Right: right}, nil
+}
+
+// Evaluate compares the two operands
+func (c *SwitchComparison) Evaluate(properties *PropertyManager) bool {
+	left := c.Left.Value(properties)
+	right := c.Right.Value(properties)
+	
+	switch c.Operator {
+	case "EQ":
+		return left == right
+	case "NE":
+		return left != right
+	case "GT":
+		return left > right
+	case "GE":
+		return left >= right
+	case "LT":
+		return left < right
+	case "LE":
+		return left <= right
+	}
+	return false
+}
+
+// SwitchTest is a group of comparisons and nested groups joined by AND or OR
+type SwitchTest struct {
+	Logic       string // "AND" (JSBSim default) or "OR"
+	Comparisons []*SwitchComparison
+	Groups      []*SwitchTest
+}
+
+// ParseSwitchTest builds a condition tree from a parsed <test> element
+func ParseSwitchTest(t *Test) (*SwitchTest, error) {
+	logic := strings.ToUpper(strings.TrimSpace(t.Logic))
+	if logic == "" {
+		logic = "AND"
+	}
+	if logic != "AND" && logic != "OR" {
+		return nil, fmt.Errorf("unknown test logic %q", t.Logic)
+	}
+	
+	st := &SwitchTest{Logic: logic}
+	
+	for _, line := range strings.Split(t.Test, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		comparison, err := ParseSwitchComparison(line)
+		if err != nil {
+			return nil, err
+		}
+		st.Comparisons = append(st.Comparisons, comparison)
+	}
+	
+	for _, nested := range t.Nested {
+		group, err := ParseSwitchTest(nested)
+		if err != nil {
+			return nil, err
+		}
+		st.Groups = append(st.Groups, group)
+	}
+	
+	if len(st.Comparisons) == 0 && len(st.Groups) == 0 {
+		return nil, fmt.Errorf("test has no conditions")
+	}
+	
+	return st, nil
+}
+
+// Evaluate returns the combined result of all comparisons and groups
+func (st *SwitchTest) Evaluate(properties *PropertyManager) bool {
+	if st.Logic == "OR" {
+		for _, c := range st.Comparisons {
+			if c.Evaluate(properties) {
+				return true
+			}
+		}
+		for _, g := range st.Groups {
+			if g.Evaluate(properties) {
+				return true
+			}
+		}
+		return false
+	}
+	
+	for _, c := range st.Comparisons {
+		if !c.Evaluate(properties) {
+			return false
+		}
+	}
+	for _, g := range st.Groups {
+		if !g.Evaluate(properties) {
+			return false
+		}
+	}
+	return true
+}
+
+// Properties returns every property referenced by the condition tree
+func (st *SwitchTest) Properties() []string {
+	var names []string
+	for _, c := range st.Comparisons {
+		if c.Left.Property != "" {
+			names = append(names, c.Left.Property)
+		}
+		if c.Right.Property != "" {
+			names = append(names, c.Right.Property)
+		}
+	}
+	for _, g := range st.Groups {
+		names = append(names, g.Properties()...)
+	}
+	return names
+}
+
+// SwitchBranch pairs a test with the output it selects
+type SwitchBranch struct {
+	Test   *SwitchTest
+	Output *SwitchOperand
+}
+
 // String returns a string representation of the component
 func ComponentToString(comp ComponentProcessor) string {
 	return fmt.Sprintf("%s[%s]: %v → %s (rate_group: %s)",
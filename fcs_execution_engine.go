@@ -5,19 +5,24 @@ package main
 
 import (
 	"fmt"
+	"math"
 	"sort"
 	"time"
 )
 
-// RateGroupScheduler manages components that execute at a specific rate
+// RateGroupScheduler manages components that execute at a specific rate.
+// The rate is kept in simulation time: the group executes at the boundaries
+// of its schedule, each time with its period as the time step.
 type RateGroupScheduler struct {
 	Name          string
 	RateHz        float64
 	Period        float64 // 1.0 / RateHz
 	Components    []ComponentProcessor
-	LastExecution time.Time
-	ExecutionTime float64 // Accumulated execution time
+	LastExecution float64 // Simulation time of the last scheduled execution (s), NaN before the first
+	ExecutionTime float64 // Wall-clock time of the last execution (s), for profiling only
 	Enabled       bool
+	
+	schedule SimSchedule
 }
 
 // NewRateGroupScheduler creates a new rate group scheduler
@@ -27,8 +32,9 @@ func NewRateGroupScheduler(name string, rateHz float64) *RateGroupScheduler {
 		RateHz:        rateHz,
 		Period:        1.0 / rateHz,
 		Components:    make([]ComponentProcessor, 0),
-		LastExecution: time.Time{}, // Zero time - will execute immediately
+		LastExecution: math.NaN(),
 		Enabled:       true,
+		schedule:      NewSimSchedule(rateHz), // First boundary at time zero
 	}
 }
 
@@ -38,14 +44,10 @@ func (rgs *RateGroupScheduler) AddComponent(component ComponentProcessor) {
 	rgs.Components = append(rgs.Components, component)
 }
 
-// ShouldExecute determines if this rate group should execute now
-func (rgs *RateGroupScheduler) ShouldExecute(currentTime time.Time) bool {
-	if !rgs.Enabled {
-		return false
-	}
-	
-	elapsed := currentTime.Sub(rgs.LastExecution).Seconds()
-	return elapsed >= rgs.Period
+// ShouldExecute reports whether the group owes an execution in the
+// simulation step [from, to)
+func (rgs *RateGroupScheduler) ShouldExecute(from, to float64) bool {
+	return rgs.Enabled && rgs.schedule.Due(from, to)
 }
 
 // Execute runs all components in this rate group
@@ -61,10 +63,17 @@ func (rgs *RateGroupScheduler) Execute(properties *PropertyManager, dt float64)
 		component.Execute(properties, dt)
 	}
 	
-	rgs.LastExecution = startTime
 	rgs.ExecutionTime = time.Since(startTime).Seconds()
 }
 
+// executeScheduled runs the group at its next boundary, with its period as
+// the time step
+func (rgs *RateGroupScheduler) executeScheduled(properties *PropertyManager) {
+	rgs.LastExecution = rgs.schedule.Next()
+	rgs.Execute(properties, rgs.Period)
+	rgs.schedule.Fire()
+}
+
 // GetComponentCount returns the number of components in this rate group
 func (rgs *RateGroupScheduler) GetComponentCount() int {
 	return len(rgs.Components)
@@ -126,6 +135,11 @@ type FlightControlSystem struct {
 	DefaultRate  float64 // Default execution rate (Hz)
 	Enabled      bool
 	
+	// Simulation time the FCS has run to (s), advanced by each Execute
+	SimTime float64
+	
+	rateGroupOrder []string // Rate group names in the order they were added
+	
 	// Statistics
 	TotalExecutions int64
 	TotalTime       float64
@@ -144,17 +158,39 @@ func NewFlightControlSystem(name string, defaultRateHz float64) *FlightControlSy
 	}
 	
 	// Create default rate group
-	defaultGroup := NewRateGroupScheduler("default", defaultRateHz)
-	fcs.RateGroups["default"] = defaultGroup
+	fcs.AddRateGroup("default", defaultRateHz)
 	
 	return fcs
 }
 
-// AddRateGroup adds a new rate group
+// AddRateGroup adds a new rate group. Groups due at the same simulation time
+// execute in the order they were added.
 func (fcs *FlightControlSystem) AddRateGroup(name string, rateHz float64) {
+	if _, exists := fcs.RateGroups[name]; !exists {
+		fcs.rateGroupOrder = append(fcs.rateGroupOrder, name)
+	}
 	fcs.RateGroups[name] = NewRateGroupScheduler(name, rateHz)
 }
 
+// orderedRateGroups returns the rate groups in the order they were added,
+// followed by any put in RateGroups directly, by name
+func (fcs *FlightControlSystem) orderedRateGroups() []*RateGroupScheduler {
+	groups := make([]*RateGroupScheduler, 0, len(fcs.RateGroups))
+	listed := make(map[string]bool, len(fcs.rateGroupOrder))
+	for _, name := range fcs.rateGroupOrder {
+		if group, exists := fcs.RateGroups[name]; exists && !listed[name] {
+			groups = append(groups, group)
+			listed[name] = true
+		}
+	}
+	for _, name := range fcs.ListRateGroups() {
+		if !listed[name] {
+			groups = append(groups, fcs.RateGroups[name])
+		}
+	}
+	return groups
+}
+
 // AddChannel adds a new channel
 func (fcs *FlightControlSystem) AddChannel(name string) *ChannelProcessor {
 	channel := NewChannelProcessor(name)
@@ -183,7 +219,37 @@ func (fcs *FlightControlSystem) AddComponent(component ComponentProcessor) {
 	}
 }
 
-// Execute runs the flight control system for one time step
+// InsertComponentBefore adds a component to the rate group of the named
+// component, ahead of it, so its output is current when that component runs.
+// If the named component does not exist the component is added normally.
+func (fcs *FlightControlSystem) InsertComponentBefore(component ComponentProcessor, before string) {
+	next, exists := fcs.Components[before]
+	if !exists {
+		fcs.AddComponent(component)
+		return
+	}
+	
+	rateGroup, exists := fcs.RateGroups[next.GetRateGroup()]
+	if !exists {
+		rateGroup = fcs.RateGroups["default"]
+	}
+	
+	fcs.Components[component.GetName()] = component
+	component.SetRateGroup(rateGroup.Name)
+	for i, existing := range rateGroup.Components {
+		if existing == next {
+			rateGroup.Components = append(rateGroup.Components[:i],
+				append([]ComponentProcessor{component}, rateGroup.Components[i:]...)...)
+			return
+		}
+	}
+	rateGroup.Components = append(rateGroup.Components, component)
+}
+
+// Execute runs the flight control system for one simulation step of dt
+// seconds from SimTime. Each rate group executes once for every boundary of
+// its schedule in the step, in time order, so the results depend only on
+// the sequence of steps and not on how fast they are run.
 func (fcs *FlightControlSystem) Execute(state *AircraftState, dt float64) {
 	if !fcs.Enabled {
 		return
@@ -194,12 +260,26 @@ func (fcs *FlightControlSystem) Execute(state *AircraftState, dt float64) {
 	// Update properties from aircraft state
 	fcs.Properties.UpdateFromAircraftState(state)
 	
-	// Execute rate groups that are ready
-	for _, rateGroup := range fcs.RateGroups {
-		// For testing and high-frequency simulation, always execute but use simulation dt
-		// In real-time applications, you might want to use the time-based scheduling
-		rateGroup.Execute(fcs.Properties, dt)
+	// Execute the rate groups at their boundaries in the step, the earliest
+	// boundary first
+	from, to := fcs.SimTime, fcs.SimTime+dt
+	groups := fcs.orderedRateGroups()
+	for {
+		var next *RateGroupScheduler
+		for _, rateGroup := range groups {
+			if !rateGroup.ShouldExecute(from, to) {
+				continue
+			}
+			if next == nil || rateGroup.schedule.Next() < next.schedule.Next()-simScheduleTolerance {
+				next = rateGroup
+			}
+		}
+		if next == nil {
+			break
+		}
+		next.executeScheduled(fcs.Properties)
 	}
+	fcs.SimTime = to
 	
 	// Apply results back to aircraft state
 	fcs.Properties.ApplyToAircraftState(state)
@@ -216,9 +296,11 @@ func (fcs *FlightControlSystem) Reset() {
 	}
 	
 	for _, rateGroup := range fcs.RateGroups {
-		rateGroup.LastExecution = time.Now()
+		rateGroup.LastExecution = math.NaN()
+		rateGroup.schedule.Reset()
 	}
 	
+	fcs.SimTime = 0
 	fcs.TotalExecutions = 0
 	fcs.TotalTime = 0.0
 }
@@ -238,6 +320,16 @@ func (fcs *FlightControlSystem) GetChannel(name string) *ChannelProcessor {
 	return fcs.Channels[name]
 }
 
+// DrivesProperty reports whether any component writes the given property
+func (fcs *FlightControlSystem) DrivesProperty(name string) bool {
+	for _, component := range fcs.Components {
+		if component.GetOutput() == name {
+			return true
+		}
+	}
+	return false
+}
+
 // ListComponents returns all component names
 func (fcs *FlightControlSystem) ListComponents() []string {
 	var names []string
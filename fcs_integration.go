@@ -14,7 +14,16 @@ import (
 type FlightDynamicsEngineWithFCS struct {
 	*FlightDynamicsEngine  // Embed the basic engine
 	FCS                    *FlightControlSystem
+	Autopilot              *FlightControlSystem // Optional; runs before the FCS on its properties when set
 	UseRealisticControls   bool // Use FCS vs direct mapping
+	Limits                 *ControlLimits // Bounds on the pilot commands; the defaults when nil
+	StagePolicy            FCSPolicy // How the FCS is treated at the integrator's stages; frozen by default
+	Recorder               *ControlRecorder // Optional; records the pilot inputs set through the engine when set
+	
+	// The controls as they began the step being integrated, for
+	// FCSReexecuted; nil outside a step
+	stepStart *controlsCheckpoint
+	stepDt    float64
 }
 
 // NewFlightDynamicsEngineWithFCS creates a flight dynamics engine with FCS
@@ -31,76 +40,231 @@ func NewFlightDynamicsEngineWithFCS(config *JSBSimConfig, useRealisticFCS bool)
 		fcs = CreateBasicFlightControlSystem()
 	}
 	
+	// The FCS and the aerodynamics share one property tree
+	baseEngine.Calculator.Properties = fcs.Properties
+	
+	// The autopilot writes its commands into the same tree for the FCS
+	var autopilot *FlightControlSystem
+	if config != nil && config.Autopilot != nil {
+		var err error
+		if autopilot, err = BuildAutopilotFromConfig(config, fcs.Properties); err != nil {
+			return nil, fmt.Errorf("autopilot: %w", err)
+		}
+	}
+	
 	return &FlightDynamicsEngineWithFCS{
 		FlightDynamicsEngine: baseEngine,
 		FCS:                  fcs,
+		Autopilot:            autopilot,
 		UseRealisticControls: useRealisticFCS,
+		Limits:               NewControlLimits(),
 	}, nil
 }
 
-// RunSimulationStepWithFCS runs one simulation step with flight control processing
+// executeControls runs the autopilot, when there is one, and then the FCS,
+// so the FCS takes the autopilot's commands of the same step
+func (engine *FlightDynamicsEngineWithFCS) executeControls(state *AircraftState, dt float64) {
+	if engine.Autopilot != nil {
+		engine.Autopilot.Execute(state, dt)
+	}
+	engine.FCS.Execute(state, dt)
+}
+
+// EngageAutopilot sets AutopilotEngagedProperty, for the autopilot's
+// switches to pass their commands on
+func (engine *FlightDynamicsEngineWithFCS) EngageAutopilot() {
+	engine.FCS.Properties.Set(AutopilotEngagedProperty, 1)
+}
+
+// DisengageAutopilot clears AutopilotEngagedProperty, returning control to
+// the pilot's commands
+func (engine *FlightDynamicsEngineWithFCS) DisengageAutopilot() {
+	engine.FCS.Properties.Set(AutopilotEngagedProperty, 0)
+}
+
+// AutopilotEngaged reports whether the autopilot is engaged
+func (engine *FlightDynamicsEngineWithFCS) AutopilotEngaged() bool {
+	return engine.Autopilot != nil && engine.FCS.Properties.Get(AutopilotEngagedProperty) != 0
+}
+
+// RunSimulationStepWithFCS runs one simulation step with flight control processing.
+//
+// The FCS runs on the pre-step state, after the standalone aerodynamics
+// functions and the autopilot and before the axis functions, so it sees
+// this step's air data and autopilot commands, and the axis functions see
+// its outputs in the shared property tree. Its surface position outputs are
+// written into state.ControlSurfaces before forces are calculated, and the
+// returned state carries the same positions. Integrators that evaluate
+// stages see the FCS as StagePolicy sets.
 func (engine *FlightDynamicsEngineWithFCS) RunSimulationStepWithFCS(
 	state *AircraftState, 
 	dt float64) (*AircraftState, *StateDerivatives, error) {
 	
-	// 1. Process pilot inputs through flight control system
-	engine.FCS.Execute(state, dt)
+	engine.stepping.Lock()
+	defer engine.stepping.Unlock()
 	
-	// 2. Calculate forces and moments with FCS-processed control surfaces
-	components, err := engine.FlightDynamicsEngine.Calculator.CalculateForcesMoments(state)
+	// 1. Calculate forces, moments and state derivatives, processing pilot
+	// inputs through the flight control system part way through
+	derivatives, components, err := engine.beginStep(state, dt)
+	defer engine.endStep()
 	if err != nil {
 		return nil, nil, err
 	}
 	
-	// 3. Calculate state derivatives from forces and moments
-	derivatives := engine.FlightDynamicsEngine.Calculator.CalculateStateDerivatives(state, components)
+	// 2. Integrate to get new state
+	newState := integrateDynamics(engine.FlightDynamicsEngine.Integrator, engine, state, derivatives, dt)
+	
+	// 3. Surfaces are held over the step rather than integrated
+	newState.ControlSurfaces = state.ControlSurfaces
 	
-	// 4. Integrate to get new state
-	newState := engine.FlightDynamicsEngine.Integrator.Integrate(state, derivatives, dt)
+	// 4. Load factors and accelerations over the step
+	newState.LoadFactor = components.LoadFactor(engine.FlightDynamicsEngine.Calculator.Mass)
+	newState.recordAccelerations(derivatives, engine.FlightDynamicsEngine.PilotStation)
 	
 	return newState, derivatives, nil
 }
 
-// SetControlInputs applies pilot control inputs to the FCS property system
+// beginStep runs the controls for a step and returns the derivatives at
+// its start, recording where the controls began it for FCSReexecuted.
+// endStep must follow once the step is integrated.
+func (engine *FlightDynamicsEngineWithFCS) beginStep(state *AircraftState, dt float64) (*StateDerivatives, *ForceMomentComponents, error) {
+	// Replayed inputs go in as the pilot's did, through SetControlInputsOnState
+	if engine.Replay != nil {
+		if controls, changed := engine.Replay.Next(state.Time); changed {
+			engine.SetControlInputsOnState(state, controls)
+		}
+	}
+	if engine.StagePolicy == FCSReexecuted {
+		engine.stepStart, engine.stepDt = engine.checkpointControls(), dt
+	}
+	
+	calc := engine.FlightDynamicsEngine.Calculator
+	components, err := calc.calculateForcesMoments(state, func() {
+		engine.executeControls(state, dt)
+		engine.ApplyFCSOutputsToState(state)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return calc.CalculateStateDerivatives(state, components), components, nil
+}
+
+// endStep ends the step begun by beginStep
+func (engine *FlightDynamicsEngineWithFCS) endStep() {
+	engine.stepStart = nil
+}
+
+// SetControlInputs applies pilot control inputs to the FCS property system,
+// clamped and rate limited by engine.Limits
 func (engine *FlightDynamicsEngineWithFCS) SetControlInputs(controls ControlInputs) {
-	// Set pilot command properties (these feed into the FCS)
+	t := engine.FCS.Properties.Get("simulation/sim-time-sec")
+	if engine.Recorder != nil {
+		engine.Recorder.Record(t, controls)
+	}
+	engine.setCommands(engine.limitControls(controls, t))
+}
+
+// limitControls applies engine.Limits to controls given at time t
+func (engine *FlightDynamicsEngineWithFCS) limitControls(controls ControlInputs, t float64) ControlInputs {
+	if engine.Limits == nil {
+		engine.Limits = NewControlLimits()
+	}
+	return engine.Limits.Apply(controls, t)
+}
+
+// setCommands sets the pilot command properties, which feed into the FCS
+func (engine *FlightDynamicsEngineWithFCS) setCommands(controls ControlInputs) {
 	engine.FCS.Properties.Set("fcs/aileron-cmd-norm", controls.Aileron)
 	engine.FCS.Properties.Set("fcs/elevator-cmd-norm", controls.Elevator)
 	engine.FCS.Properties.Set("fcs/rudder-cmd-norm", controls.Rudder)
 	engine.FCS.Properties.Set("fcs/throttle-cmd-norm", controls.Throttle)
+	engine.FCS.Properties.Set("fcs/mixture-cmd-norm", controls.Mixture)
+	engine.FCS.Properties.Set("fcs/advance-cmd-norm", controls.Propeller)
 	engine.FCS.Properties.Set("fcs/flap-cmd-norm", controls.Flaps)
 }
 
 // SetControlInputsOnState applies control inputs to aircraft state (for integration)
 func (engine *FlightDynamicsEngineWithFCS) SetControlInputsOnState(state *AircraftState, controls ControlInputs) {
-	// Update aircraft state controls
-	state.Controls = controls
+	if engine.Recorder != nil {
+		engine.Recorder.Record(state.Time, controls)
+	}
+	
+	// Update aircraft state controls, within their limits
+	state.Controls = engine.limitControls(controls, state.Time)
 	
 	// Set FCS input properties
-	engine.SetControlInputs(controls)
+	engine.setCommands(state.Controls)
 	
 	// Execute FCS to get processed control surface positions
-	engine.FCS.Execute(state, 0.01) // Use small dt for property updates
+	engine.executeControls(state, 0.01) // Use small dt for property updates
 	
 	// Apply FCS-processed control surface positions to aircraft state
 	engine.ApplyFCSOutputsToState(state)
 }
 
-// ApplyFCSOutputsToState applies FCS-computed control surface positions to aircraft state
+// ApplyFCSOutputsToState applies FCS-computed control surface positions to aircraft state.
+// Only surfaces driven by an FCS component are written, so surfaces the FCS
+// does not model keep their current positions. If the FCS drives only the
+// left aileron, the right aileron mirrors it.
 func (engine *FlightDynamicsEngineWithFCS) ApplyFCSOutputsToState(state *AircraftState) {
-	// Get FCS-processed control surface outputs (in radians)
-	aileronPos := engine.FCS.Properties.Get("fcs/left-aileron-pos-rad")
-	elevatorPos := engine.FCS.Properties.Get("fcs/elevator-pos-rad")
-	rudderPos := engine.FCS.Properties.Get("fcs/rudder-pos-rad")
-	flapPos := engine.FCS.Properties.Get("fcs/flap-pos-rad")
-	
-	// Apply to aircraft state control surfaces
-	state.ControlSurfaces.AileronLeft = aileronPos
-	state.ControlSurfaces.AileronRight = -aileronPos // Opposite for differential aileron
-	state.ControlSurfaces.Elevator = elevatorPos
-	state.ControlSurfaces.Rudder = rudderPos
-	state.ControlSurfaces.FlapLeft = flapPos
-	state.ControlSurfaces.FlapRight = flapPos
+	fcs := engine.FCS
+	surfaces := &state.ControlSurfaces
+	
+	// Ailerons (differential)
+	if fcs.DrivesProperty("fcs/left-aileron-pos-rad") {
+		surfaces.AileronLeft = fcs.Properties.Get("fcs/left-aileron-pos-rad")
+		surfaces.AileronRight = -surfaces.AileronLeft
+	}
+	if fcs.DrivesProperty("fcs/right-aileron-pos-rad") {
+		surfaces.AileronRight = fcs.Properties.Get("fcs/right-aileron-pos-rad")
+	}
+	
+	// Elevator and rudder
+	if fcs.DrivesProperty("fcs/elevator-pos-rad") {
+		surfaces.Elevator = fcs.Properties.Get("fcs/elevator-pos-rad")
+	}
+	if fcs.DrivesProperty("fcs/rudder-pos-rad") {
+		surfaces.Rudder = fcs.Properties.Get("fcs/rudder-pos-rad")
+	}
+	
+	// Flaps (symmetric)
+	if fcs.DrivesProperty("fcs/flap-pos-rad") {
+		surfaces.FlapLeft = fcs.Properties.Get("fcs/flap-pos-rad")
+		surfaces.FlapRight = surfaces.FlapLeft
+	} else if fcs.DrivesProperty("fcs/flap-pos-deg") {
+		surfaces.FlapLeft = fcs.Properties.Get("fcs/flap-pos-deg") * DEG_TO_RAD
+		surfaces.FlapRight = surfaces.FlapLeft
+	}
+	
+	// Trim
+	if fcs.DrivesProperty("fcs/roll-trim-cmd-norm") {
+		surfaces.Trim.Aileron = fcs.Properties.Get("fcs/roll-trim-cmd-norm")
+	}
+	if fcs.DrivesProperty("fcs/pitch-trim-cmd-norm") {
+		surfaces.Trim.Elevator = fcs.Properties.Get("fcs/pitch-trim-cmd-norm")
+	}
+	if fcs.DrivesProperty("fcs/yaw-trim-cmd-norm") {
+		surfaces.Trim.Rudder = fcs.Properties.Get("fcs/yaw-trim-cmd-norm")
+	}
+}
+
+// EngineControlPositions returns the engine lever positions the FCS has
+// processed from the pilot's commands, in fcs/throttle-pos-norm,
+// fcs/mixture-pos-norm and fcs/advance-pos-norm. A lever the FCS does not
+// drive takes the command in state directly.
+func (engine *FlightDynamicsEngineWithFCS) EngineControlPositions(state *AircraftState) EngineControls {
+	fcs := engine.FCS
+	position := func(name string, command float64) float64 {
+		if fcs.DrivesProperty(name) {
+			return fcs.Properties.Get(name)
+		}
+		return command
+	}
+	return EngineControls{
+		Throttle: position("fcs/throttle-pos-norm", state.Controls.Throttle),
+		Mixture:  position("fcs/mixture-pos-norm", state.Controls.Mixture),
+		Advance:  position("fcs/advance-pos-norm", state.Controls.Propeller),
+	}
 }
 
 // GetControlSurfacePositions retrieves actual control surface positions from FCS
@@ -157,13 +321,19 @@ func (engine *FlightDynamicsEngineWithFCS) UpdateControlSurfacesFromFCS(state *A
 		state.ControlSurfaces.Rudder = engine.FCS.Properties.Get("fcs/rudder-pos-rad")
 	} else {
 		// Use direct mapping (bypass FCS for comparison)
-		state.ControlSurfaces.Elevator = state.Controls.Elevator * 25.0 * DEG_TO_RAD
-		state.ControlSurfaces.AileronLeft = state.Controls.Aileron * 30.0 * DEG_TO_RAD
-		state.ControlSurfaces.AileronRight = -state.Controls.Aileron * 30.0 * DEG_TO_RAD
-		state.ControlSurfaces.Rudder = state.Controls.Rudder * 30.0 * DEG_TO_RAD
+		directControlSurfaces(state)
 	}
 }
 
+// directControlSurfaces deflects the surfaces in proportion to the pilot's
+// commands, with no actuator dynamics
+func directControlSurfaces(state *AircraftState) {
+	state.ControlSurfaces.Elevator = state.Controls.Elevator * directElevatorTravel
+	state.ControlSurfaces.AileronLeft = state.Controls.Aileron * directAileronTravel
+	state.ControlSurfaces.AileronRight = -state.Controls.Aileron * directAileronTravel
+	state.ControlSurfaces.Rudder = state.Controls.Rudder * directRudderTravel
+}
+
 // =============================================================================
 // DEMONSTRATION FUNCTIONS
 // =============================================================================
@@ -179,8 +349,7 @@ func DemoFlightControlSystem() {
 	state.Altitude = 3000.0 // 3000m altitude
 	state.Position.Z = -3000.0 // NED coordinates
 	state.Velocity = Vector3{X: 100.0, Y: 0.0, Z: 0.0} // 100 m/s forward
-	state.UpdateAtmosphere()
-	state.UpdateDerivedParameters()
+	state.UpdateFlightConditions()
 	
 	// Create FCS engines for comparison
 	basicFCS := CreateBasicFlightControlSystem()
@@ -311,8 +480,7 @@ func DemoRealisticVsDirectControl() {
 		state.Altitude = 3000.0
 		state.Position.Z = -3000.0
 		state.Velocity = Vector3{X: 120.0, Y: 0.0, Z: 0.0} // 120 m/s
-		state.UpdateAtmosphere()
-		state.UpdateDerivedParameters()
+		state.UpdateFlightConditions()
 	}
 	
 	initialConditions(directState)
@@ -6,6 +6,7 @@ package main
 import (
 	"fmt"
 	"math"
+	"strconv"
 	"strings"
 	"sync"
 )
@@ -17,6 +18,9 @@ type PropertyManager struct {
 	aliases    map[string]string
 	mutex      sync.RWMutex
 	listeners  map[string][]PropertyListener
+	
+	// Gear units the aircraft state published at the last sync
+	stateGearUnits int
 }
 
 // PropertyListener interface for components that want to be notified of property changes
@@ -26,11 +30,7 @@ type PropertyListener interface {
 
 // NewPropertyManager creates a new property manager with common JSBSim properties
 func NewPropertyManager() *PropertyManager {
-	pm := &PropertyManager{
-		properties: make(map[string]float64),
-		aliases:    make(map[string]string),
-		listeners:  make(map[string][]PropertyListener),
-	}
+	pm := newEmptyPropertyManager()
 	
 	// Initialize common JSBSim properties with default values
 	pm.initializeStandardProperties()
@@ -38,6 +38,17 @@ func NewPropertyManager() *PropertyManager {
 	return pm
 }
 
+// newEmptyPropertyManager creates a property manager with nothing defined.
+// Function operations skip properties that are not defined, so a tree for
+// function evaluation alone starts empty rather than with zero defaults.
+func newEmptyPropertyManager() *PropertyManager {
+	return &PropertyManager{
+		properties: make(map[string]float64),
+		aliases:    make(map[string]string),
+		listeners:  make(map[string][]PropertyListener),
+	}
+}
+
 // initializeStandardProperties sets up the standard JSBSim property tree
 func (pm *PropertyManager) initializeStandardProperties() {
 	// Flight Control System properties
@@ -48,6 +59,10 @@ func (pm *PropertyManager) initializeStandardProperties() {
 	pm.properties["fcs/flap-cmd-norm"] = 0.0
 	pm.properties["fcs/speedbrake-cmd-norm"] = 0.0
 	pm.properties["fcs/gear-cmd-norm"] = 0.0
+	pm.properties["fcs/pitch-trim-cmd-norm"] = 0.0
+	pm.properties["fcs/roll-trim-cmd-norm"] = 0.0
+	pm.properties["fcs/yaw-trim-cmd-norm"] = 0.0
+	pm.properties["gear/gear-cmd-norm"] = 1.0 // JSBSim starts with the gear commanded down
 	
 	// Control surface positions (actuator outputs)
 	pm.properties["fcs/left-aileron-pos-rad"] = 0.0
@@ -84,6 +99,8 @@ func (pm *PropertyManager) initializeStandardProperties() {
 	
 	// Position and attitude
 	pm.properties["position/h-sl-ft"] = 0.0
+	pm.properties["position/h-agl-m"] = 0.0
+	pm.properties["position/terrain-elevation-m"] = 0.0
 	pm.properties["attitude/phi-rad"] = 0.0
 	pm.properties["attitude/theta-rad"] = 0.0
 	pm.properties["attitude/psi-rad"] = 0.0
@@ -125,17 +142,9 @@ func (pm *PropertyManager) Get(name string) float64 {
 	pm.mutex.RLock()
 	defer pm.mutex.RUnlock()
 	
-	// Resolve aliases
-	if alias, exists := pm.aliases[name]; exists {
-		name = alias
-	}
-	
-	if value, exists := pm.properties[name]; exists {
-		return value
-	}
-	
 	// Return 0.0 for non-existent properties (JSBSim behavior)
-	return 0.0
+	value, _ := pm.lookup(name)
+	return value
 }
 
 // GetSafe retrieves a property value with existence check
@@ -143,13 +152,51 @@ func (pm *PropertyManager) GetSafe(name string) (float64, bool) {
 	pm.mutex.RLock()
 	defer pm.mutex.RUnlock()
 	
-	// Resolve aliases
+	return pm.lookup(name)
+}
+
+// lookup resolves aliases and reads a property. An indexed name such as
+// propulsion/engine[0]/thrust-lbs that has not been set falls back to its
+// unindexed form, so single-engine and single-gear properties published
+// without an index are found under either name. Callers hold the read lock.
+func (pm *PropertyManager) lookup(name string) (float64, bool) {
 	if alias, exists := pm.aliases[name]; exists {
 		name = alias
 	}
 	
-	value, exists := pm.properties[name]
-	return value, exists
+	if value, exists := pm.properties[name]; exists {
+		return value, true
+	}
+	if unindexed, ok := unindexedPropertyName(name); ok {
+		value, exists := pm.properties[unindexed]
+		return value, exists
+	}
+	return 0.0, false
+}
+
+// Snapshot reads several properties at once, so they come from the same
+// update. It returns every property when names is empty, and the names
+// that are not set.
+func (pm *PropertyManager) Snapshot(names []string) (values map[string]float64, missing []string) {
+	pm.mutex.RLock()
+	defer pm.mutex.RUnlock()
+	
+	if len(names) == 0 {
+		values = make(map[string]float64, len(pm.properties))
+		for name, value := range pm.properties {
+			values[name] = value
+		}
+		return values, nil
+	}
+	values = make(map[string]float64, len(names))
+	for _, name := range names {
+		if value, ok := pm.lookup(name); ok {
+			values[name] = value
+		} else {
+			missing = append(missing, name)
+		}
+	}
+	return values, missing
 }
 
 // SetAlias creates an alias for a property
@@ -160,6 +207,33 @@ func (pm *PropertyManager) SetAlias(alias, target string) {
 	pm.aliases[alias] = target
 }
 
+// IndexedPropertyName returns the JSBSim name of a property of one of
+// several like elements, e.g. IndexedPropertyName("gear/unit", 2,
+// "compression-ft") is gear/unit[2]/compression-ft
+func IndexedPropertyName(node string, index int, leaf string) string {
+	return node + "[" + strconv.Itoa(index) + "]/" + leaf
+}
+
+// unindexedPropertyName drops the [0] indices from a property name. JSBSim
+// treats engine and engine[0] as the same node, so the unindexed name is
+// what a single-element publisher sets. It reports false when the name has
+// no [0] index.
+func unindexedPropertyName(name string) (string, bool) {
+	if !strings.Contains(name, "[0]") {
+		return name, false
+	}
+	if unindexed, ok := unindexedNames.Load(name); ok {
+		return unindexed.(string), true
+	}
+	unindexed := strings.ReplaceAll(name, "[0]", "")
+	unindexedNames.Store(name, unindexed)
+	return unindexed, true
+}
+
+// unindexedNames caches unindexedPropertyName, which function evaluation
+// calls on every lookup of an unset indexed property
+var unindexedNames sync.Map // map[string]string
+
 // AddListener registers a listener for property changes
 func (pm *PropertyManager) AddListener(propertyName string, listener PropertyListener) {
 	pm.mutex.Lock()
@@ -171,41 +245,26 @@ func (pm *PropertyManager) AddListener(propertyName string, listener PropertyLis
 	pm.listeners[propertyName] = append(pm.listeners[propertyName], listener)
 }
 
-// UpdateFromAircraftState synchronizes properties with aircraft state
+// UpdateFromAircraftState synchronizes properties with aircraft state. Every
+// property the state publishes (see FillPropertyMap) is written, and those
+// of gear units the state no longer has, as after a retraction, are
+// removed. Properties set by anything else, function and FCS outputs among
+// them, are left as they are.
 func (pm *PropertyManager) UpdateFromAircraftState(state *AircraftState) {
-	// Control inputs
-	pm.Set("fcs/aileron-cmd-norm", state.Controls.Aileron)
-	pm.Set("fcs/elevator-cmd-norm", state.Controls.Elevator)
-	pm.Set("fcs/rudder-cmd-norm", state.Controls.Rudder)
-	pm.Set("fcs/throttle-cmd-norm", state.Controls.Throttle)
-	pm.Set("fcs/flap-cmd-norm", state.Controls.Flaps)
-	
-	// Control surface positions
-	pm.Set("fcs/left-aileron-pos-rad", state.ControlSurfaces.AileronLeft)
-	pm.Set("fcs/right-aileron-pos-rad", state.ControlSurfaces.AileronRight)
-	pm.Set("fcs/elevator-pos-rad", state.ControlSurfaces.Elevator)
-	pm.Set("fcs/rudder-pos-rad", state.ControlSurfaces.Rudder)
+	pm.syncState(state)
 	
-	// Atmospheric properties
+	// FCS names the state does not publish itself
 	pm.Set("atmosphere/rho", state.Density)
 	pm.Set("atmosphere/pressure-psf", state.Pressure*0.020885) // Pa to psf
 	pm.Set("atmosphere/temperature-R", state.Temperature*1.8)  // K to R
-	
-	// Velocities and rates
 	pm.Set("velocities/vt-fps", state.Velocity.Magnitude()*3.28084) // m/s to ft/s
 	pm.Set("velocities/vc-kts", state.CalibratedAirspeed*1.94384)   // m/s to kts
-	// Calculate angle of attack and sideslip angle from velocity components
-	alpha := math.Atan2(state.Velocity.Z, state.Velocity.X) // w/u
-	beta := math.Asin(state.Velocity.Y / state.Velocity.Magnitude()) // v/V_total
+	// Angle of attack and sideslip angle from velocity components, faded at
+	// low airspeed like the state's own
+	alpha := lowSpeedFade(state.TrueAirspeed) * math.Atan2(state.Velocity.Z, state.Velocity.X) // w/u
+	beta := state.Beta // v/V_total
 	pm.Set("velocities/alpha-rad", alpha)
 	pm.Set("velocities/beta-rad", beta)
-	
-	// Angular rates
-	pm.Set("velocities/p-rad_sec", state.AngularRate.X)
-	pm.Set("velocities/q-rad_sec", state.AngularRate.Y)
-	pm.Set("velocities/r-rad_sec", state.AngularRate.Z)
-	
-	// Position and attitude
 	pm.Set("position/h-sl-ft", state.Altitude*3.28084) // m to ft
 	// Calculate Euler angles from quaternion
 	roll, pitch, yaw := state.Orientation.ToEuler()
@@ -214,6 +273,54 @@ func (pm *PropertyManager) UpdateFromAircraftState(state *AircraftState) {
 	pm.Set("attitude/psi-rad", yaw)      // Yaw angle (heading)
 }
 
+// syncState writes the state's own properties, notifying listeners of
+// those that changed, and removes the properties of gear units the state
+// no longer has
+func (pm *PropertyManager) syncState(state *AircraftState) {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+	
+	// Listened-to values before the sync, to tell which changed
+	var before map[string]float64
+	if len(pm.listeners) > 0 {
+		before = make(map[string]float64, len(pm.listeners))
+		for name := range pm.listeners {
+			if value, exists := pm.properties[name]; exists {
+				before[name] = value
+			}
+		}
+	}
+	
+	state.FillPropertyMap(pm.properties)
+	for i := len(state.Gear.Units); i < pm.stateGearUnits; i++ {
+		for _, name := range gearUnitProperties(i) {
+			delete(pm.properties, name)
+		}
+	}
+	pm.stateGearUnits = len(state.Gear.Units)
+	
+	for name, listeners := range pm.listeners {
+		value, exists := pm.properties[name]
+		oldValue, existed := before[name]
+		if !exists || (existed && oldValue == value) {
+			continue
+		}
+		for _, listener := range listeners {
+			go listener.OnPropertyChanged(name, value)
+		}
+	}
+}
+
+// evaluate runs fn with the property map itself under the write lock, for
+// function evaluation, which reads many properties and writes its outputs
+// back. fn must not call other PropertyManager methods.
+func (pm *PropertyManager) evaluate(fn func(properties map[string]float64) error) error {
+	pm.mutex.Lock()
+	defer pm.mutex.Unlock()
+	
+	return fn(pm.properties)
+}
+
 // ApplyToAircraftState updates aircraft state from properties
 func (pm *PropertyManager) ApplyToAircraftState(state *AircraftState) {
 	// Update control surface positions from FCS outputs
@@ -6,6 +6,7 @@ package main
 import (
 	"math"
 	"os"
+	"strings"
 	"testing"
 	"time"
 )
@@ -41,6 +42,21 @@ func TestPropertyManager(t *testing.T) {
 		assertApproxEqual(t, original, 456.0, 0.001)
 	})
 	
+	t.Run("Indexed Properties", func(t *testing.T) {
+		// engine[0] falls back to the unindexed single-engine name
+		pm.Set("propulsion/engine/thrust-lbs", 1500.0)
+		assertApproxEqual(t, pm.Get("propulsion/engine[0]/thrust-lbs"), 1500.0, 1e-12)
+		
+		// Other indices and names set with an index are separate properties
+		_, exists := pm.GetSafe("propulsion/engine[1]/thrust-lbs")
+		assertEqual(t, exists, false)
+		pm.Set("propulsion/engine[0]/thrust-lbs", 1200.0)
+		assertApproxEqual(t, pm.Get("propulsion/engine[0]/thrust-lbs"), 1200.0, 1e-12)
+		assertApproxEqual(t, pm.Get("propulsion/engine/thrust-lbs"), 1500.0, 1e-12)
+		
+		assertEqual(t, IndexedPropertyName("gear/unit", 2, "compression-ft"), "gear/unit[2]/compression-ft")
+	})
+	
 	t.Run("Standard Properties Initialization", func(t *testing.T) {
 		// Check that standard JSBSim properties are initialized
 		fcsProps := []string{
@@ -192,6 +208,76 @@ func TestLagFilterComponent(t *testing.T) {
 	})
 }
 
+func TestWashoutFilterComponent(t *testing.T) {
+	pm := NewPropertyManager()
+	washout := NewWashoutFilterComponent("washout", "input", "output", 1.0)
+	dt := 0.01
+	
+	// An initial steady input is already washed out
+	pm.Set("input", 0.4)
+	assertApproxEqual(t, washout.Execute(pm, dt), 0.0, 1e-12)
+	
+	// A step passes through, then decays with the time constant
+	pm.Set("input", 1.4)
+	assertApproxEqual(t, washout.Execute(pm, dt), 1.0-dt/(1.0+dt), 1e-12)
+	for i := 0; i < 99; i++ {
+		washout.Execute(pm, dt)
+	}
+	if output := pm.Get("output"); math.Abs(output-math.Exp(-1.0)) > 0.01 {
+		t.Errorf("After one time constant expected ~%.3f, got %.3f", math.Exp(-1.0), output)
+	}
+	for i := 0; i < 1000; i++ {
+		washout.Execute(pm, dt)
+	}
+	assertApproxEqual(t, pm.Get("output"), 0.0, 1e-4)
+	
+	washout.Reset()
+	pm.Set("input", -2.0)
+	assertApproxEqual(t, washout.Execute(pm, dt), 0.0, 1e-12)
+}
+
+func TestPIDComponent(t *testing.T) {
+	pm := NewPropertyManager()
+	dt := 0.1
+	
+	t.Run("Terms", func(t *testing.T) {
+		pid := NewPIDComponent("pid", "error", "output", 2.0, 0.5, 0.1)
+		
+		// No derivative kick on the first frame
+		pm.Set("error", 1.0)
+		assertApproxEqual(t, pid.Execute(pm, dt), 2.0+0.5*0.1, 1e-12)
+		
+		// Constant error integrates linearly
+		assertApproxEqual(t, pid.Execute(pm, dt), 2.0+0.5*0.2, 1e-12)
+		
+		// Error ramp adds the derivative term and a trapezoid of area
+		pm.Set("error", 1.5)
+		expected := 2.0*1.5 + 0.5*(0.2+0.125) + 0.1*(0.5/dt)
+		assertApproxEqual(t, pid.Execute(pm, dt), expected, 1e-12)
+		assertApproxEqual(t, pm.Get("output"), expected, 1e-12)
+	})
+	
+	t.Run("Integral Limit", func(t *testing.T) {
+		pid := NewPIDComponent("pid", "error", "output", 0.0, 1.0, 0.0)
+		pid.IntegralLimit = 0.3
+		pm.Set("error", 1.0)
+		for i := 0; i < 20; i++ {
+			pid.Execute(pm, dt)
+		}
+		assertApproxEqual(t, pm.Get("output"), 0.3, 1e-12)
+		
+		// Unwinds immediately when the error reverses
+		pm.Set("error", -1.0)
+		pid.Execute(pm, dt)
+		assertApproxEqual(t, pm.Get("output"), 0.3, 1e-12) // Trapezoid of +1 and -1
+		pid.Execute(pm, dt)
+		assertApproxEqual(t, pm.Get("output"), 0.2, 1e-12)
+		
+		pid.Reset()
+		assertApproxEqual(t, pid.Execute(pm, dt), -0.1, 1e-12)
+	})
+}
+
 func TestGainComponent(t *testing.T) {
 	pm := NewPropertyManager()
 	
@@ -250,6 +336,102 @@ func TestSummerComponent(t *testing.T) {
 	})
 }
 
+func TestAerosurfaceScaleComponent(t *testing.T) {
+	pm := NewPropertyManager()
+	
+	t.Run("Symmetric Range", func(t *testing.T) {
+		scale := NewAerosurfaceScaleComponent("rudder-scale", "input", "output", -30.0, 30.0)
+		scale.ZeroCentered = false
+		
+		cases := []struct{ input, expected float64 }{
+			{-1.0, -30.0}, {-0.5, -15.0}, {0.0, 0.0}, {0.25, 7.5}, {1.0, 30.0},
+			{1.5, 45.0}, {-2.0, -60.0}, // Outside the domain: extrapolated
+		}
+		for _, c := range cases {
+			pm.Set("input", c.input)
+			assertApproxEqual(t, scale.Execute(pm, 0.01), c.expected, 1e-9)
+		}
+		assertApproxEqual(t, pm.Get("output"), -60.0, 1e-9)
+	})
+	
+	t.Run("Asymmetric Range", func(t *testing.T) {
+		scale := NewAerosurfaceScaleComponent("elevator-scale", "input", "output", -30.0, 20.0)
+		scale.ZeroCentered = false
+		
+		// Linear over the whole domain, so zero input is the range midpoint
+		cases := []struct{ input, expected float64 }{
+			{-1.0, -30.0}, {0.0, -5.0}, {1.0, 20.0}, {2.0, 45.0},
+		}
+		for _, c := range cases {
+			pm.Set("input", c.input)
+			assertApproxEqual(t, scale.Execute(pm, 0.01), c.expected, 1e-9)
+		}
+	})
+	
+	t.Run("Zero Centered", func(t *testing.T) {
+		scale := NewAerosurfaceScaleComponent("elevator-scale", "input", "output", -30.0, 20.0)
+		assertEqual(t, scale.ZeroCentered, true)
+		
+		// Each half is scaled separately so zero stays zero
+		cases := []struct{ input, expected float64 }{
+			{-1.0, -30.0}, {-0.5, -15.0}, {0.0, 0.0}, {0.5, 10.0}, {1.0, 20.0},
+			{1.5, 30.0}, {-1.2, -36.0},
+		}
+		for _, c := range cases {
+			pm.Set("input", c.input)
+			assertApproxEqual(t, scale.Execute(pm, 0.01), c.expected, 1e-9)
+		}
+	})
+	
+	t.Run("Domain And Gain", func(t *testing.T) {
+		// Degrees back to a normalized position, as in the P-51D pitch channel
+		scale := NewAerosurfaceScaleComponent("elevator-norm", "input", "output", -1.0, 1.0)
+		scale.SetDomain(-30.0, 20.0)
+		scale.Gain = 2.0
+		
+		pm.Set("input", 10.0)
+		assertApproxEqual(t, scale.Execute(pm, 0.01), 1.0, 1e-9)
+		pm.Set("input", -15.0)
+		assertApproxEqual(t, scale.Execute(pm, 0.01), -1.0, 1e-9)
+		
+		// A domain that does not span zero falls back to the linear map
+		scale.SetDomain(0.0, 10.0)
+		pm.Set("input", 5.0)
+		assertApproxEqual(t, scale.Execute(pm, 0.01), 0.0, 1e-9)
+	})
+}
+
+func TestSaturationComponent(t *testing.T) {
+	pm := NewPropertyManager()
+	
+	t.Run("Constant Limits", func(t *testing.T) {
+		saturation := NewSaturationComponent("limit", "input", "output", -0.5, 0.8)
+		
+		cases := []struct{ input, expected float64 }{
+			{-2.0, -0.5}, {-0.5, -0.5}, {0.3, 0.3}, {0.8, 0.8}, {5.0, 0.8},
+		}
+		for _, c := range cases {
+			pm.Set("input", c.input)
+			assertApproxEqual(t, saturation.Execute(pm, 0.01), c.expected, 1e-9)
+		}
+		assertApproxEqual(t, pm.Get("output"), 0.8, 1e-9)
+	})
+	
+	t.Run("Property Limits", func(t *testing.T) {
+		saturation := NewSaturationComponent("scheduled-limit", "input", "output", 0, 0)
+		saturation.SetLimits(&SwitchOperand{Property: "limit", Sign: -1.0}, &SwitchOperand{Property: "limit", Sign: 1.0})
+		
+		pm.Set("limit", 0.25)
+		pm.Set("input", 1.0)
+		assertApproxEqual(t, saturation.Execute(pm, 0.01), 0.25, 1e-9)
+		pm.Set("input", -1.0)
+		assertApproxEqual(t, saturation.Execute(pm, 0.01), -0.25, 1e-9)
+		
+		pm.Set("limit", 2.0)
+		assertApproxEqual(t, saturation.Execute(pm, 0.01), -1.0, 1e-9)
+	})
+}
+
 func TestSwitchComponent(t *testing.T) {
 	pm := NewPropertyManager()
 	
@@ -322,25 +504,34 @@ func TestRateGroupScheduler(t *testing.T) {
 		
 		pm.Set("input", 0.5)
 		
-		// Should execute immediately on first call
-		now := time.Now()
-		if !rg.ShouldExecute(now) {
-			t.Error("Rate group should execute on first call")
+		// Should execute in the first step
+		if !rg.ShouldExecute(0, 0.001) {
+			t.Error("Rate group should execute in the first step")
 		}
-		
-		rg.Execute(pm, 0.01)
+		rg.executeScheduled(pm)
 		assertApproxEqual(t, pm.Get("output"), 1.0, 0.001)
+		assertEqual(t, rg.LastExecution, 0.0)
 		
-		// Should not execute immediately again
-		if rg.ShouldExecute(time.Now()) {
-			t.Error("Rate group should not execute immediately after execution")
+		// Not again until its period of simulation time has passed,
+		// however much wall-clock time goes by
+		time.Sleep(15 * time.Millisecond)
+		if rg.ShouldExecute(0.001, 0.009) {
+			t.Error("Rate group should not execute before its period elapsed")
 		}
-		
-		// Should execute after sufficient time
-		time.Sleep(15 * time.Millisecond) // Wait longer than period
-		if !rg.ShouldExecute(time.Now()) {
-			t.Error("Rate group should execute after period elapsed")
+		if !rg.ShouldExecute(0.009, 0.011) {
+			t.Error("Rate group should execute once its period elapsed")
 		}
+		rg.executeScheduled(pm)
+		assertEqual(t, rg.LastExecution, 0.01)
+		
+		// A step longer than the period owes an execution per boundary
+		count := 0
+		for rg.ShouldExecute(0.011, 0.05) {
+			rg.executeScheduled(pm)
+			count++
+		}
+		assertEqual(t, count, 3)
+		assertApproxEqual(t, rg.LastExecution, 0.04, 1e-12)
 	})
 }
 
@@ -540,6 +731,195 @@ func TestFlightDynamicsEngineWithFCS(t *testing.T) {
 	})
 }
 
+func TestFCSSurfaceFeedback(t *testing.T) {
+	file, err := os.Open("aircraft/p51d-jsbsim.xml")
+	if err != nil {
+		t.Skipf("Skipping FCS feedback test: %v", err)
+	}
+	defer file.Close()
+	
+	config, err := ParseJSBSimConfig(file)
+	if err != nil {
+		t.Fatalf("Failed to parse P-51D config: %v", err)
+	}
+	
+	newState := func() *AircraftState {
+		state := NewAircraftState()
+		state.Altitude = 3000.0
+		state.Position.Z = -3000.0
+		state.Velocity = Vector3{X: 100.0, Y: 0.0, Z: 0.0}
+		state.UpdateAtmosphere()
+		state.UpdateDerivedParameters()
+		return state
+	}
+	
+	t.Run("Elevator Step Reaches New State", func(t *testing.T) {
+		engine, err := NewFlightDynamicsEngineWithFCS(config, true)
+		if err != nil {
+			t.Fatalf("Failed to create engine: %v", err)
+		}
+		
+		dt := 1.0 / 60.0
+		state := newState()
+		state.Controls.Elevator = 0.5
+		
+		next, derivatives, err := engine.RunSimulationStepWithFCS(state, dt)
+		if err != nil {
+			t.Fatalf("Simulation step failed: %v", err)
+		}
+		
+		// The 120 Hz FCS executes twice in the 60 Hz step, each time moving
+		// the elevator actuator rate limited, then lagged
+		actuator := engine.FCS.GetComponent("fcs/elevator-actuator").(*ActuatorComponent)
+		period := engine.FCS.GetRateGroup("default").Period
+		target, expected := 0.0, 0.0
+		for i := 0; i < 2; i++ {
+			target = math.Min(0.5, target+actuator.RateLimit*period)
+			expected += (target - expected) * period / (actuator.Lag + period)
+		}
+		assertApproxEqual(t, next.ControlSurfaces.Elevator, expected, 1e-12)
+		if next.ControlSurfaces.Elevator >= 0.5 {
+			t.Errorf("Elevator should lag the command, got %.4f", next.ControlSurfaces.Elevator)
+		}
+		assertApproxEqual(t, next.ToPropertyMap()["fcs/elevator-pos-rad"], expected, 1e-12)
+		
+		// The pitch moment for this step used the same surface position
+		calc := engine.Calculator
+		check := newState()
+		check.ControlSurfaces = next.ControlSurfaces
+		components, err := calc.CalculateForcesMoments(check)
+		if err != nil {
+			t.Fatalf("Force calculation failed: %v", err)
+		}
+		consumed := calc.CalculateStateDerivatives(check, components)
+		assertApproxEqual(t, derivatives.AngularRateDot.Y, consumed.AngularRateDot.Y, 1e-12)
+		
+		components, _ = calc.CalculateForcesMoments(newState())
+		neutral := calc.CalculateStateDerivatives(newState(), components)
+		if math.Abs(derivatives.AngularRateDot.Y-neutral.AngularRateDot.Y) < 1e-9 {
+			t.Errorf("Elevator deflection did not change the pitch acceleration")
+		}
+	})
+	
+	t.Run("Differential Ailerons", func(t *testing.T) {
+		engine, err := NewFlightDynamicsEngineWithFCS(config, false)
+		if err != nil {
+			t.Fatalf("Failed to create engine: %v", err)
+		}
+		
+		state := newState()
+		state.Controls.Aileron = 0.3
+		state.ControlSurfaces.FlapLeft = 20.0 * DEG_TO_RAD
+		state.ControlSurfaces.FlapRight = 20.0 * DEG_TO_RAD
+		
+		next, _, err := engine.RunSimulationStepWithFCS(state, 1.0/60.0)
+		if err != nil {
+			t.Fatalf("Simulation step failed: %v", err)
+		}
+		
+		// The basic FCS only drives the left aileron
+		assertApproxEqual(t, next.ControlSurfaces.AileronLeft, 0.3, 1e-12)
+		assertApproxEqual(t, next.ControlSurfaces.AileronRight, -0.3, 1e-12)
+		
+		// Surfaces the FCS does not drive keep their positions
+		assertApproxEqual(t, next.ControlSurfaces.FlapLeft, 20.0*DEG_TO_RAD, 1e-12)
+		assertApproxEqual(t, next.ControlSurfaces.FlapRight, 20.0*DEG_TO_RAD, 1e-12)
+	})
+}
+
+const couplingXML = `<fdm_config name="coupling">
+	<metrics>
+		<wingarea unit="FT2"> 200 </wingarea>
+		<wingspan unit="FT"> 30 </wingspan>
+		<chord unit="FT"> 6 </chord>
+	</metrics>
+	<mass_balance>
+		<emptywt unit="LBS"> 2000 </emptywt>
+	</mass_balance>
+	<aerodynamics>
+		<function name="aero/function/qbar-twice">
+			<product>
+				<property>aero/qbar-psf</property>
+				<value>2.0</value>
+			</product>
+		</function>
+		<axis name="LIFT">
+			<function name="aero/coefficient/CLflap">
+				<product>
+					<property>aero/qbar-psf</property>
+					<table>
+						<independentVar>fcs/flap-pos-deg</independentVar>
+						<tableData>
+							0   0.0
+							40  1.0
+						</tableData>
+					</table>
+				</product>
+			</function>
+		</axis>
+	</aerodynamics>
+</fdm_config>`
+
+func TestPropertyCoupling(t *testing.T) {
+	config, err := ParseJSBSimConfig(strings.NewReader(couplingXML))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	engine, err := NewFlightDynamicsEngineWithFCS(config, false)
+	if err != nil {
+		t.Fatalf("Failed to create engine: %v", err)
+	}
+	assertEqual(t, engine.Calculator.Properties, engine.FCS.Properties)
+	
+	// A gain scheduled on dynamic pressure, one on a standalone aero
+	// function, and a flap actuator the lift table reads
+	fcs := engine.FCS
+	fcs.AddComponent(NewGainComponent("fcs/pitch-schedule", "aero/qbar-psf", "fcs/pitch-gain", 0.01))
+	fcs.AddComponent(NewGainComponent("fcs/qbar-twice-copy", "aero/function/qbar-twice", "fcs/qbar-twice", 1.0))
+	fcs.AddComponent(NewGainComponent("fcs/flap-actuator", "fcs/flap-cmd-norm", "fcs/flap-pos-deg", 40.0))
+	
+	state := NewAircraftState()
+	state.Altitude = 1000.0
+	state.Controls.Flaps = 0.5
+	state.UpdateAtmosphere()
+	for _, speed := range []float64{50.0, 80.0} {
+		state.Velocity = Vector3{X: speed}
+		state.UpdateDerivedParameters()
+		qbar := state.DynamicPressure * 0.020885
+		
+		next, _, err := engine.RunSimulationStepWithFCS(state, 0.01)
+		if err != nil {
+			t.Fatalf("Step failed: %v", err)
+		}
+		pm := engine.Calculator.Properties
+		
+		// The FCS saw this step's dynamic pressure and the standalone
+		// function evaluated from it
+		assertApproxEqual(t, pm.Get("fcs/pitch-gain"), 0.01*qbar, 1e-9)
+		assertApproxEqual(t, pm.Get("fcs/qbar-twice"), 2*qbar, 1e-9)
+		
+		// The lift table saw the FCS flap position from the same step,
+		// where the state synced at the start of the step had none, and
+		// its value is published under its name
+		assertApproxEqual(t, pm.Get("aero/coefficient/CLflap"), qbar*0.5, 1e-9)
+		assertApproxEqual(t, next.ControlSurfaces.FlapLeft, 20*DEG_TO_RAD, 1e-12)
+		state.ControlSurfaces.FlapLeft = 0
+	}
+	
+	t.Run("Stale Gear Units", func(t *testing.T) {
+		pm := NewPropertyManager()
+		state := NewAircraftState()
+		state.Gear.Units = []GearUnitState{{WOW: true, Squat: true}, {WOW: true, Squat: true}}
+		pm.UpdateFromAircraftState(state)
+		assertEqual(t, pm.Get("gear/unit[1]/WOW"), 1.0)
+		
+		state.Gear.Units = nil
+		pm.UpdateFromAircraftState(state)
+		_, exists := pm.GetSafe("gear/unit[1]/WOW")
+		assertEqual(t, exists, false)
+	})
+}
+
 // =============================================================================
 // PERFORMANCE TESTS
 // =============================================================================
@@ -600,4 +980,15 @@ func TestFCSPerformance(t *testing.T) {
 		
 		t.Logf("Realistic FCS: %.3f ms average execution time", avgTime*1000)
 	})
+	
+	t.Run("Property Sync Allocations", func(t *testing.T) {
+		pm := NewPropertyManager()
+		state := NewAircraftState()
+		pm.UpdateFromAircraftState(state)
+		
+		allocs := testing.AllocsPerRun(100, func() {
+			pm.UpdateFromAircraftState(state)
+		})
+		assertEqual(t, allocs, 0.0)
+	})
 }
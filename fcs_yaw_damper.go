@@ -0,0 +1,133 @@
+// Yaw Damper Channel
+// Example stability augmentation channel built from standard FCS components
+
+package main
+
+import (
+	"sort"
+)
+
+// Yaw damper property names
+const (
+	YawDamperEngageProperty     = "fcs/yaw-damper-engage"      // Damper on when >= 0.5
+	DampedRudderCommandProperty = "fcs/rudder-cmd-damped"      // Pilot + damper + interconnect
+	YawDamperCommandProperty    = "fcs/yaw-damper-output"      // Damper contribution after engage switch
+	InterconnectCommandProperty = "fcs/aileron-rudder-ic-norm" // Interconnect contribution
+)
+
+// YawDamperChannel is a yaw damper wired into the rudder command path:
+//
+//	r sensor -> washout -> gain -> authority limit -> engage switch -+
+//	                                                                 +-> summer -> limit -> rudder-cmd-damped
+//	pilot rudder-cmd-norm -------------------------------------------+
+//
+// The washout removes steady yaw rate so the damper opposes Dutch roll
+// without fighting the pilot in a steady turn. The damper has limited
+// authority and the pilot command is summed in unfiltered, so the pilot can
+// always override it.
+type YawDamperChannel struct {
+	Sensor    *LagFilterComponent
+	Washout   *WashoutFilterComponent
+	Gain      *GainComponent
+	Authority *ClipperComponent
+	Engage    *SwitchComponent
+	Summer    *SummerComponent
+	Limiter   *ClipperComponent
+
+	// Interconnect feeds aileron command into the rudder; nil until
+	// SetInterconnect is called
+	Interconnect *GainComponent
+
+	fcs *FlightControlSystem
+}
+
+// CreateYawDamperChannel adds a yaw damper to an FCS. gain is rudder command
+// (normalized) per rad/s of yaw rate; a positive gain opposes the yaw rate
+// when positive rudder yaws the nose left (negative Cn_dr), as in both the
+// simplified model and the P-51D. washoutTau is the washout time constant in
+// seconds.
+//
+// Components that read fcs/rudder-cmd-norm are rewired to read
+// fcs/rudder-cmd-damped, and the channel is scheduled ahead of them. The
+// damper starts disengaged; set fcs/yaw-damper-engage to 1 to engage it.
+func CreateYawDamperChannel(fcs *FlightControlSystem, gain, washoutTau float64) *YawDamperChannel {
+	ch := &YawDamperChannel{
+		Sensor: NewLagFilterComponent("fcs/yaw-rate-sensor",
+			"velocities/r-rad_sec", "fcs/yaw-rate-sensed", 0.02), // 20ms sensor lag
+		Washout: NewWashoutFilterComponent("fcs/yaw-damper-washout",
+			"fcs/yaw-rate-sensed", "fcs/yaw-rate-washed", washoutTau),
+		Gain: NewGainComponent("fcs/yaw-damper-gain",
+			"fcs/yaw-rate-washed", "fcs/yaw-damper-cmd-norm", gain),
+		Authority: NewClipperComponent("fcs/yaw-damper-authority",
+			"fcs/yaw-damper-cmd-norm", "fcs/yaw-damper-limited", -0.3, 0.3),
+		Engage: NewSwitchComponent("fcs/yaw-damper-switch", YawDamperCommandProperty),
+		Summer: NewSummerComponent("fcs/rudder-cmd-summer",
+			[]string{"fcs/rudder-cmd-norm", YawDamperCommandProperty}, "fcs/rudder-cmd-sum"),
+		Limiter: NewClipperComponent("fcs/rudder-cmd-limiter",
+			"fcs/rudder-cmd-sum", DampedRudderCommandProperty, -1.0, 1.0),
+		fcs: fcs,
+	}
+	ch.Engage.SetTest(YawDamperEngageProperty, "GE", 0.5)
+	ch.Engage.SetInputs("fcs/yaw-damper-limited", "")
+	ch.Engage.SetValues(0.0, 0.0)
+	fcs.Properties.Set(YawDamperEngageProperty, 0.0)
+
+	// Rewire the existing consumers of the pilot command
+	var consumers []string
+	for name, component := range fcs.Components {
+		inputs := component.GetInputs()
+		for i, input := range inputs {
+			if input == "fcs/rudder-cmd-norm" {
+				inputs[i] = DampedRudderCommandProperty
+				consumers = append(consumers, name)
+			}
+		}
+	}
+	sort.Strings(consumers)
+
+	for _, component := range ch.components() {
+		if len(consumers) > 0 {
+			fcs.InsertComponentBefore(component, consumers[0])
+		} else {
+			fcs.AddComponent(component)
+		}
+	}
+	if yawChannel := fcs.GetChannel("Yaw"); yawChannel != nil {
+		yawChannel.Components = append(ch.components(), yawChannel.Components...)
+	}
+
+	return ch
+}
+
+// components returns the channel's components in execution order
+func (ch *YawDamperChannel) components() []ComponentProcessor {
+	return []ComponentProcessor{ch.Sensor, ch.Washout, ch.Gain, ch.Authority, ch.Engage, ch.Summer, ch.Limiter}
+}
+
+// SetEngaged engages or disengages the damper
+func (ch *YawDamperChannel) SetEngaged(engaged bool) {
+	ch.fcs.Properties.Set(YawDamperEngageProperty, boolToFloat(engaged))
+}
+
+// SetAuthority limits the damper command to ±limit (normalized rudder)
+func (ch *YawDamperChannel) SetAuthority(limit float64) {
+	ch.Authority.MinValue = -limit
+	ch.Authority.MaxValue = limit
+}
+
+// SetInterconnect adds gain times the aileron command to the rudder command
+// to coordinate turn entries. The interconnect acts whether or not the
+// damper is engaged; a gain of zero disables it.
+func (ch *YawDamperChannel) SetInterconnect(gain float64) {
+	if ch.Interconnect == nil {
+		ch.Interconnect = NewGainComponent("fcs/aileron-rudder-interconnect",
+			"fcs/aileron-cmd-norm", InterconnectCommandProperty, gain)
+		ch.fcs.InsertComponentBefore(ch.Interconnect, ch.Summer.GetName())
+		if yawChannel := ch.fcs.GetChannel("Yaw"); yawChannel != nil {
+			yawChannel.AddComponent(ch.Interconnect)
+		}
+		ch.Summer.Inputs = append(ch.Summer.Inputs, InterconnectCommandProperty)
+		ch.Summer.Signs = append(ch.Summer.Signs, 1.0)
+	}
+	ch.Interconnect.Gain = gain
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// flyRudderDoublet flies the simplified model through a rudder doublet with
+// the pilot command routed through a yaw damper channel
+func flyRudderDoublet(t *testing.T, engaged bool) []*AircraftState {
+	t.Helper()
+	engine := NewSimplifiedFlightDynamicsEngine(NewRungeKutta4Integrator())
+	fcs := NewFlightControlSystem("Yaw Damper", 100.0)
+	damper := CreateYawDamperChannel(fcs, 1.0, 1.0)
+	damper.SetEngaged(engaged)
+
+	// Near level flight. The simplified model is not trimmed in pitch, so
+	// the run is kept short enough that the speed stays reasonable.
+	alpha := 0.0165
+	state := NewAircraftState()
+	state.Altitude = 1500.0
+	state.Position.Z = -1500.0
+	state.Velocity = Vector3{X: 100.0 * math.Cos(alpha), Y: 0, Z: 100.0 * math.Sin(alpha)}
+	state.Controls.Throttle = 0.76
+	state.Controls.Elevator = 0.035
+	state.UpdateAtmosphere()
+	state.UpdateDerivedParameters()
+
+	dt := 0.01
+	states := []*AircraftState{state}
+	for i := 0; i < 300; i++ {
+		pilot := 0.0
+		if tm := float64(i) * dt; tm < 0.5 {
+			pilot = 0.3
+		} else if tm < 1.0 {
+			pilot = -0.3
+		}
+		state.Controls.Rudder = pilot
+		fcs.Execute(state, dt)
+
+		// The simplified model reads the rudder straight from the command
+		stepState := state.Copy()
+		stepState.Controls.Rudder = fcs.Properties.Get(DampedRudderCommandProperty)
+		next, err := engine.Step(stepState, dt)
+		if err != nil {
+			t.Fatalf("Step %d failed: %v", i, err)
+		}
+		state = next
+		states = append(states, state)
+	}
+	return states
+}
+
+// peakAfter returns the largest |f(state)| from the given index on
+func peakAfter(states []*AircraftState, from int, f func(*AircraftState) float64) float64 {
+	peak := 0.0
+	for _, state := range states[from:] {
+		peak = math.Max(peak, math.Abs(f(state)))
+	}
+	return peak
+}
+
+func TestYawDamperDutchRoll(t *testing.T) {
+	free := flyRudderDoublet(t, false)
+	damped := flyRudderDoublet(t, true)
+
+	// Measure from half a second after the doublet ends
+	from := 150
+	yawRate := func(s *AircraftState) float64 { return s.AngularRate.Z }
+	sideslip := func(s *AircraftState) float64 { return s.Beta }
+
+	freeBeta, dampedBeta := peakAfter(free, from, sideslip), peakAfter(damped, from, sideslip)
+	if freeBeta < 2.0*DEG_TO_RAD {
+		t.Fatalf("Doublet should excite a Dutch roll without the damper, peak beta %.2f°", freeBeta*RAD_TO_DEG)
+	}
+	if dampedBeta > 0.5*freeBeta {
+		t.Errorf("Damper should at least halve the residual sideslip: %.2f° vs %.2f°",
+			dampedBeta*RAD_TO_DEG, freeBeta*RAD_TO_DEG)
+	}
+
+	freeRate, dampedRate := peakAfter(free, from, yawRate), peakAfter(damped, from, yawRate)
+	if dampedRate > 0.5*freeRate {
+		t.Errorf("Damper should at least halve the residual yaw rate: %.3f vs %.3f rad/s", dampedRate, freeRate)
+	}
+}
+
+func TestYawDamperChannel(t *testing.T) {
+	fcs := CreateStandardP51DFlightControlSystem()
+	damper := CreateYawDamperChannel(fcs, 0.8, 1.0)
+	dt := 0.01
+
+	actuator := fcs.GetComponent("fcs/rudder-actuator")
+	assertEqual(t, actuator.GetInputs(), []string{DampedRudderCommandProperty})
+
+	// The channel runs ahead of the actuator in its rate group
+	group := fcs.GetRateGroup(actuator.GetRateGroup())
+	index := func(name string) int {
+		for i, component := range group.Components {
+			if component.GetName() == name {
+				return i
+			}
+		}
+		return -1
+	}
+	if index(damper.Sensor.GetName()) < 0 || index(damper.Limiter.GetName()) > index("fcs/rudder-actuator") {
+		t.Errorf("Damper must execute before the rudder actuator")
+	}
+
+	state := NewAircraftState()
+	state.Controls.Rudder = 0.2
+	state.AngularRate.Z = 0.1
+
+	t.Run("Disengaged Passes Pilot Command", func(t *testing.T) {
+		for i := 0; i < 10; i++ {
+			fcs.Execute(state, dt)
+		}
+		assertApproxEqual(t, fcs.Properties.Get(DampedRudderCommandProperty), 0.2, 1e-12)
+	})
+
+	t.Run("Yaw Rate Change Opposed", func(t *testing.T) {
+		damper.SetEngaged(true)
+		state.AngularRate.Z = 0.3
+		for i := 0; i < 10; i++ {
+			fcs.Execute(state, dt)
+		}
+		command := fcs.Properties.Get(DampedRudderCommandProperty)
+		if command <= 0.2 {
+			t.Errorf("Increasing yaw rate should add positive rudder, got %.3f", command)
+		}
+	})
+
+	t.Run("Steady Turn Washed Out", func(t *testing.T) {
+		for i := 0; i < 1000; i++ {
+			fcs.Execute(state, dt)
+		}
+		assertApproxEqual(t, fcs.Properties.Get(DampedRudderCommandProperty), 0.2, 1e-3)
+	})
+
+	t.Run("Authority Limit", func(t *testing.T) {
+		damper.SetAuthority(0.05)
+		state.AngularRate.Z = 3.0
+		fcs.Execute(state, dt)
+		fcs.Execute(state, dt)
+		assertApproxEqual(t, fcs.Properties.Get(DampedRudderCommandProperty), 0.25, 1e-12)
+	})
+
+	t.Run("Aileron Rudder Interconnect", func(t *testing.T) {
+		damper.SetEngaged(false)
+		damper.SetInterconnect(0.25)
+		state.Controls.Aileron = 0.4
+		fcs.Execute(state, dt)
+		assertApproxEqual(t, fcs.Properties.Get(DampedRudderCommandProperty), 0.2+0.1, 1e-12)
+
+		// Changing the gain does not add a second path
+		damper.SetInterconnect(0.5)
+		fcs.Execute(state, dt)
+		assertApproxEqual(t, fcs.Properties.Get(DampedRudderCommandProperty), 0.2+0.2, 1e-12)
+		assertEqual(t, len(damper.Summer.GetInputs()), 3)
+
+		// Total command saturates
+		state.Controls.Rudder = 0.9
+		fcs.Execute(state, dt)
+		assertApproxEqual(t, fcs.Properties.Get(DampedRudderCommandProperty), 1.0, 1e-12)
+	})
+}
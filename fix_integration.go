@@ -25,8 +25,7 @@ func FixIntegrationDemo() {
 	state.Controls.Throttle = 0.7
 	
 	// Update atmosphere and derived parameters
-	state.UpdateAtmosphere()
-	state.UpdateDerivedParameters()
+	state.UpdateFlightConditions()
 	
 	fmt.Printf("✅ Properly initialized state:\n")
 	fmt.Printf("   Altitude: %.1f m\n", state.Altitude)
@@ -123,8 +122,7 @@ func TestClimbingFlight() {
 	state.Velocity = Vector3{X: 80.0, Y: 0, Z: -3.0}  // 80 m/s forward, 3 m/s climb
 	state.Controls.Throttle = 1.0    // Full power
 	state.Controls.Elevator = 0.1    // Slight up elevator
-	state.UpdateAtmosphere()
-	state.UpdateDerivedParameters()
+	state.UpdateFlightConditions()
 	
 	fmt.Printf("🛫 Initial Climb Conditions:\n")
 	fmt.Printf("   Altitude: %.0f m\n", state.Altitude)
@@ -209,8 +207,7 @@ func TestBankingTurn() {
 	state.Controls.Aileron = 0.3     // Bank input
 	state.Controls.Rudder = 0.1      // Coordinated turn
 	state.Controls.Elevator = 0.05   // Slight back pressure
-	state.UpdateAtmosphere()
-	state.UpdateDerivedParameters()
+	state.UpdateFlightConditions()
 	
 	fmt.Printf("🔄 Turn Setup:\n")
 	fmt.Printf("   Initial Heading: %.1f°\n", state.Yaw*RAD_TO_DEG)
@@ -222,7 +219,7 @@ func TestBankingTurn() {
 	dt := 0.01
 	steps := 2000 // 20 seconds
 	
-	initialHeading := state.Yaw
+	initialHeading := state.HeadingContinuous
 	initialAlt := state.Altitude
 	maxBank := 0.0
 	maxTurnRate := 0.0
@@ -259,16 +256,9 @@ func TestBankingTurn() {
 		}
 	}
 	
-	finalHeading := state.Yaw
+	finalHeading := state.HeadingContinuous
 	headingChange := (finalHeading - initialHeading) * RAD_TO_DEG
 	
-	// Handle heading wrap-around
-	if headingChange > 180 {
-		headingChange -= 360
-	} else if headingChange < -180 {
-		headingChange += 360
-	}
-	
 	altLoss := initialAlt - state.Altitude
 	
 	fmt.Printf("\n🎯 Turn Performance:\n")
@@ -2,7 +2,9 @@ package main
 
 import (
 	"fmt"
+	"log"
 	"math"
+	"os"
 )
 
 // SimplifiedForcesMomentsCalculator provides a simplified but realistic forces/moments model
@@ -13,6 +15,43 @@ type SimplifiedForcesMomentsCalculator struct {
 	WingSpan    float64
 	Chord       float64
 	Inertia     Matrix3
+	
+	// Span efficiency of the drag polar; the induced drag factor follows
+	// from it and the aspect ratio of WingSpan and WingArea
+	OswaldEfficiency float64
+	
+	// Configuration increments, indexed by flap deflection in degrees
+	FlapDeltaCL         *Table1D
+	FlapDeltaCD         *Table1D
+	FlapDeltaCm         *Table1D
+	FlapDeltaCLmax      *Table1D
+	FlapDeltaStallAlpha *Table1D // Change in stall angle (degrees)
+	GearDeltaCD         float64  // Drag increment with gear fully extended
+	GearDoorDeltaCD     float64  // Drag increment with the gear doors fully open
+	
+	// Propeller rotation: 1 clockwise seen from behind, -1 counter-clockwise.
+	// The engine torque reaction rolls the airframe the other way.
+	PropellerSense float64
+	
+	// Optional hinge moment limits on the surface deflections, through the
+	// direct control mapping; the commands act in full when nil
+	Blowback *ControlBlowback
+	
+	// Loaded CG right of the centreline (m), from asymmetric stores or
+	// fuel; the weight acting there rolls the aircraft
+	LateralCG float64
+	
+	// Optional ground effect: near the ground the induced drag falls by
+	// McCormick's factor of the wing's height above it. Off by default.
+	GroundEffect bool
+	
+	// Optional point forces such as a tow rope, at body-axis locations;
+	// property-driven magnitudes do not act, as there is no property tree
+	External *ExternalForces
+	
+	// Optional dynamic stall correction to the lift and pitching moment,
+	// with its lag states; off when nil
+	DynamicStall *DynamicStall
 }
 
 // NewSimplifiedCalculator creates a simplified calculator with P-51D characteristics
@@ -27,36 +66,130 @@ func NewSimplifiedCalculator() *SimplifiedForcesMomentsCalculator {
 		WingArea: wingArea,
 		WingSpan: wingSpan,
 		Chord:    chord,
+		OswaldEfficiency: 0.8,
 		Inertia: Matrix3{
 			XX: mass * wingSpan * wingSpan / 12.0,
 			YY: mass * chord * chord / 12.0,
 			ZZ: mass * (wingSpan*wingSpan + chord*chord) / 12.0,
 		},
+		
+		// Split flaps: lift and CLmax rise with deflection, drag rises
+		// steeply past 20°, and the stall comes slightly earlier
+		FlapDeltaCL: &Table1D{
+			Indices: []float64{0, 10, 20, 30, 40},
+			Values:  []float64{0, 0.25, 0.50, 0.70, 0.85},
+		},
+		FlapDeltaCD: &Table1D{
+			Indices: []float64{0, 10, 20, 30, 40},
+			Values:  []float64{0, 0.005, 0.015, 0.035, 0.060},
+		},
+		FlapDeltaCm: &Table1D{
+			Indices: []float64{0, 10, 20, 30, 40},
+			Values:  []float64{0, -0.02, -0.05, -0.08, -0.10},
+		},
+		FlapDeltaCLmax: &Table1D{
+			Indices: []float64{0, 10, 20, 30, 40},
+			Values:  []float64{0, 0.20, 0.40, 0.55, 0.65},
+		},
+		FlapDeltaStallAlpha: &Table1D{
+			Indices: []float64{0, 10, 20, 30, 40},
+			Values:  []float64{0, -1.0, -2.0, -3.0, -4.0},
+		},
+		GearDeltaCD:     0.020,
+		GearDoorDeltaCD: 0.015,
+		PropellerSense:  P51DPropellerSense,
+	}
+}
+
+// InducedDragFactor returns K = 1/(π·e·AR) of the drag polar, from the wing
+// aspect ratio and Oswald efficiency
+func (calc *SimplifiedForcesMomentsCalculator) InducedDragFactor() float64 {
+	geometry := DerivedGeometry{AspectRatio: calc.WingSpan * calc.WingSpan / calc.WingArea}
+	return geometry.InducedDragFactor(calc.OswaldEfficiency)
+}
+
+// groundEffectFactor returns the fraction of the free-air induced drag left
+// at a height above the ground (m), McCormick's (16h/b)²/(1+(16h/b)²). It is
+// one with GroundEffect off.
+func (calc *SimplifiedForcesMomentsCalculator) groundEffectFactor(height float64) float64 {
+	if !calc.GroundEffect || calc.WingSpan <= 0 {
+		return 1
 	}
+	x := 16 * math.Max(height, 0) / calc.WingSpan
+	return x * x / (1 + x*x)
+}
+
+// MaxFlapDeflectionDeg is the flap angle at a full flap command
+const MaxFlapDeflectionDeg = 40.0
+
+// configuration returns the current flap deflection (degrees) and gear
+// extension fraction from the surface positions, not the pilot commands
+func (calc *SimplifiedForcesMomentsCalculator) configuration(state *AircraftState) (flapDeg, gear float64) {
+	flapDeg = math.Max(0, math.Min(MaxFlapDeflectionDeg, state.ControlSurfaces.FlapLeft*RAD_TO_DEG))
+	gear = math.Max(0, math.Min(1, state.Gear.Transition))
+	return flapDeg, gear
+}
+
+// StallSpeed returns the 1 g stall speed (m/s) for the current flap setting and density
+func (calc *SimplifiedForcesMomentsCalculator) StallSpeed(state *AircraftState) float64 {
+	flapDeg, _ := calc.configuration(state)
+	CLmax := 1.8 + interpolate1D(calc.FlapDeltaCLmax, flapDeg)
+	return math.Sqrt(2 * calc.Mass * 9.81 / (state.Density * calc.WingArea * CLmax))
 }
 
-// CalculateSimplifiedForces computes realistic aerodynamic forces using simplified models
+// LateralTrim returns the aileron and rudder that zero the roll and yaw
+// moments in state, against the propeller torque and a lateral CG offset
+func (calc *SimplifiedForcesMomentsCalculator) LateralTrim(state *AircraftState) (aileron, rudder float64, err error) {
+	probe := state.Copy()
+	return solveLateralTrim(func(aileron, rudder float64) (float64, float64, error) {
+		probe.Controls.Aileron, probe.Controls.Rudder = aileron, rudder
+		components, err := calc.CalculateSimplifiedForces(probe)
+		if err != nil {
+			return 0, 0, err
+		}
+		return components.TotalMoment.X, components.TotalMoment.Z, nil
+	})
+}
+
+// CalculateSimplifiedForces computes realistic aerodynamic forces using simplified models.
+//
+// Sign conventions. Body axes are X forward, Y out the right wing and Z
+// down, and forces and moments are positive along and right-handed about
+// them: roll right, pitch nose up and yaw nose right are positive. Beta is
+// positive with the relative wind from the right (v > 0); alpha is the
+// state's, positive with the body velocity above the X axis. Controls follow
+// JSBSim: positive aileron rolls right, positive elevator is trailing edge
+// down and pitches the nose down, and positive rudder is trailing edge left
+// and yaws the nose left. So, for positive beta:
+//   - side force is negative, pushing the aircraft back downwind (CYβ < 0)
+//   - the yaw moment is positive, turning the nose into the wind (Cnβ > 0)
+//   - the roll moment is negative, rolling away from the wind, the effect
+//     of positive dihedral (Clβ < 0)
+//
+// The damping derivatives Clp, Cmq and Cnr are negative, opposing the rates.
 func (calc *SimplifiedForcesMomentsCalculator) CalculateSimplifiedForces(state *AircraftState) (*ForceMomentComponents, error) {
 	components := &ForceMomentComponents{}
 	
-	// Dynamic pressure
-	q := 0.5 * state.Density * state.TrueAirspeed * state.TrueAirspeed
+	// Dynamic pressure, as the state computed it
+	q := state.DynamicPressure
 	qS := q * calc.WingArea
 	
-	// Protect against invalid dynamic pressure
-	if math.IsNaN(q) || math.IsInf(q, 0) || q <= 0 {
-		q = 1.0  // Minimum dynamic pressure
-		qS = calc.WingArea
+	// Protect against invalid dynamic pressure. Below the floor there is no
+	// airflow to speak of, and so no aerodynamic force or moment.
+	if math.IsNaN(q) || math.IsInf(q, 0) || q < MinDynamicPressure {
+		q = 0
+		qS = 0
 	}
 	
 	// Simplified aerodynamic coefficients based on typical fighter aircraft
 	alpha := state.Alpha
+	flapDeg, gear := calc.configuration(state)
 	
 	// Lift coefficient: CL = CL0 + CLalpha * alpha
-	CL0 := 0.2        // Zero-alpha lift coefficient
-	CLalpha := 5.7    // Lift curve slope (per radian)
-	CLmax := 1.8      // Maximum lift coefficient
-	stallAlpha := 18.0 * DEG_TO_RAD
+	CL0 := 0.2 + interpolate1D(calc.FlapDeltaCL, flapDeg)        // Zero-alpha lift coefficient
+	CLalpha := 5.7                                               // Lift curve slope (per radian)
+	CLmax := 1.8 + interpolate1D(calc.FlapDeltaCLmax, flapDeg)   // Maximum lift coefficient
+	stallAlpha := (18.0 + interpolate1D(calc.FlapDeltaStallAlpha, flapDeg)) * DEG_TO_RAD
 	
 	var CL float64
 	if alpha < stallAlpha {
@@ -74,15 +207,31 @@ func (calc *SimplifiedForcesMomentsCalculator) CalculateSimplifiedForces(state *
 		CL = CL0
 	}
 	
+	// Dynamic stall, over the attached lift curve the model knows
+	if calc.DynamicStall != nil && qS > 0 {
+		dCL, dCm := calc.DynamicStall.Apply(state, CL0+CLalpha*alpha, CL, calc.Chord)
+		CL += dCL
+		components.DynamicStall.CL = dCL
+		components.DynamicStall.Cm = dCm
+	}
+	
 	// Drag coefficient: CD = CD0 + K * CL^2 (simplified drag polar)
 	CD0 := 0.025      // Zero-lift drag coefficient
-	K := 0.04         // Induced drag factor
+	K := calc.InducedDragFactor() * calc.groundEffectFactor(state.Altitude-state.Gear.GroundHeight)
 	CD := CD0 + K*CL*CL
 	
-	// Control surface effects
-	controlDrag := 0.01 * (math.Abs(state.Controls.Aileron) + 
-	                      math.Abs(state.Controls.Elevator) + 
-	                      math.Abs(state.Controls.Rudder))
+	// Flap and landing gear drag
+	CD += interpolate1D(calc.FlapDeltaCD, flapDeg) + gear*calc.GearDeltaCD + state.Gear.Doors*calc.GearDoorDeltaCD
+	
+	// Control surface effects, from the part of each command the surface
+	// holds against its hinge moment
+	aileron, elevator, rudder := state.Controls.Aileron, state.Controls.Elevator, state.Controls.Rudder
+	if calc.Blowback != nil {
+		aileron = calc.Blowback.Aileron.heldCommand(aileron, directAileronTravel, q)
+		elevator = calc.Blowback.Elevator.heldCommand(elevator, directElevatorTravel, q)
+		rudder = calc.Blowback.Rudder.heldCommand(rudder, directRudderTravel, q)
+	}
+	controlDrag := 0.01 * (math.Abs(aileron) + math.Abs(elevator) + math.Abs(rudder))
 	CD += controlDrag
 	
 	// Ensure CD is reasonable
@@ -106,7 +255,8 @@ func (calc *SimplifiedForcesMomentsCalculator) CalculateSimplifiedForces(state *
 	availableThrust := maxThrust * densityRatio
 	components.Propulsion.Thrust = state.Controls.Throttle * availableThrust
 	
-	// Propeller torque
+	// Propeller torque, the magnitude of the shaft torque driving the
+	// propeller; the roll moment below is its reaction
 	if state.TrueAirspeed > 0 {
 		power := components.Propulsion.Thrust * state.TrueAirspeed / 0.8 // 80% prop efficiency
 		propRPM := 2700.0
@@ -116,8 +266,7 @@ func (calc *SimplifiedForcesMomentsCalculator) CalculateSimplifiedForces(state *
 	
 	// Gravity in body frame
 	weightEarth := Vector3{X: 0, Y: 0, Z: calc.Mass * 9.81}
-	qInv := Quaternion{W: state.Orientation.W, X: -state.Orientation.X, Y: -state.Orientation.Y, Z: -state.Orientation.Z}
-	components.Gravity.Weight = qInv.RotateVector(weightEarth)
+	components.Gravity.Weight = state.Orientation.RotateVectorInverse(weightEarth)
 	
 	// Moments (simplified)
 	qSb := q * calc.WingArea * calc.WingSpan
@@ -135,22 +284,23 @@ func (calc *SimplifiedForcesMomentsCalculator) CalculateSimplifiedForces(state *
 	Clbeta := -0.1    // Dihedral effect
 	Clp := -0.4       // Roll damping
 	Clda := 0.15      // Aileron effectiveness
-	Cl := Clbeta*beta + Clp*limitedAngularRate.X + Clda*state.Controls.Aileron
-	components.Moments.Roll = Cl*qSb + components.Propulsion.Torque
+	Cl := Clbeta*beta + Clp*limitedAngularRate.X + Clda*aileron
+	components.Moments.Roll = Cl*qSb - calc.propellerSense()*components.Propulsion.Torque
 	
 	// Pitch moment
-	Cm0 := 0.05       // Pitching moment coefficient at zero alpha
+	Cm0 := 0.05 + interpolate1D(calc.FlapDeltaCm, flapDeg) // Pitching moment coefficient at zero alpha
 	Cmalpha := -0.5   // Pitch stability
 	Cmq := -3.0       // Pitch damping (REDUCED from -8.0)
 	Cmde := -1.2      // Elevator effectiveness
-	Cm := Cm0 + Cmalpha*alpha + Cmq*limitedAngularRate.Y + Cmde*state.Controls.Elevator
-	components.Moments.Pitch = Cm * qSc
+	Cm := Cm0 + Cmalpha*alpha + Cmq*limitedAngularRate.Y + Cmde*elevator
+	components.DynamicStall.PitchMoment = components.DynamicStall.Cm * qSc
+	components.Moments.Pitch = Cm*qSc + components.DynamicStall.PitchMoment
 	
 	// Yaw moment
 	Cnbeta := 0.1     // Weathercock stability
-	Cnr := -0.15      // Yaw damping
+	Cnr := -0.15      // Yaw damping (per unit r*b/2V)
 	Cndr := -0.1      // Rudder effectiveness
-	Cn := Cnbeta*beta + Cnr*limitedAngularRate.Z + Cndr*state.Controls.Rudder
+	Cn := Cnbeta*beta + Cnr*calc.yawRateNorm(state, limitedAngularRate.Z) + Cndr*rudder
 	components.Moments.Yaw = Cn * qSb
 	
 	// CRITICAL: Limit moment magnitudes to prevent integration instability
@@ -166,6 +316,11 @@ func (calc *SimplifiedForcesMomentsCalculator) CalculateSimplifiedForces(state *
 		components.Moments.Yaw = math.Copysign(maxMoment, components.Moments.Yaw)
 	}
 	
+	// Weight acting off the centreline
+	components.Gravity.Moment = Vector3{Y: calc.LateralCG}.Cross(components.Gravity.Weight)
+	components.Moments.Roll += components.Gravity.Moment.X
+	components.Moments.Yaw += components.Gravity.Moment.Z
+	
 	// Total forces and moments
 	components.TotalForce = Vector3{
 		X: components.Aerodynamic.Drag + components.Propulsion.Thrust + components.Gravity.Weight.X,
@@ -178,10 +333,27 @@ func (calc *SimplifiedForcesMomentsCalculator) CalculateSimplifiedForces(state *
 		Y: components.Moments.Pitch,
 		Z: components.Moments.Yaw,
 	}
+	components.addExternalForces(calc.External, state, nil)
+	components.resolveFlightPath(state.Velocity)
 	
 	return components, nil
 }
 
+// propellerSense returns the rotation sense, clockwise seen from behind
+// when it is unset
+func (calc *SimplifiedForcesMomentsCalculator) propellerSense() float64 {
+	if calc.PropellerSense < 0 {
+		return -1
+	}
+	return 1
+}
+
+// yawRateNorm returns the nondimensional yaw rate r*b/2V
+func (calc *SimplifiedForcesMomentsCalculator) yawRateNorm(state *AircraftState, r float64) float64 {
+	airspeed := math.Max(state.TrueAirspeed, 1.0)
+	return r * calc.WingSpan / (2.0 * airspeed)
+}
+
 // CalculateStateDerivatives computes state derivatives from forces and moments
 func (calc *SimplifiedForcesMomentsCalculator) CalculateStateDerivatives(state *AircraftState, components *ForceMomentComponents) *StateDerivatives {
 	derivatives := &StateDerivatives{}
@@ -190,6 +362,11 @@ func (calc *SimplifiedForcesMomentsCalculator) CalculateStateDerivatives(state *
 	rawAccel := components.TotalForce.Scale(1.0 / calc.Mass)
 	maxAccel := 100.0 // m/s² (extreme aircraft limit)
 	
+	// Sideslip kinematics: yawing the nose away from the flight path turns
+	// forward speed into sideslip (the Y term of -ω×V). This closes the
+	// weathercock loop and gives the Dutch roll mode.
+	rawAccel.Y -= state.AngularRate.Z*state.Velocity.X - state.AngularRate.X*state.Velocity.Z
+	
 	derivatives.VelocityDot = Vector3{
 		X: math.Max(-maxAccel, math.Min(maxAccel, rawAccel.X)),
 		Y: math.Max(-maxAccel, math.Min(maxAccel, rawAccel.Y)),
@@ -211,7 +388,7 @@ func (calc *SimplifiedForcesMomentsCalculator) CalculateStateDerivatives(state *
 	}
 	
 	// Altitude rate
-	earthVel := state.Orientation.RotateVector(state.Velocity)
+	earthVel := state.GroundVelocity()
 	derivatives.AltitudeDot = -earthVel.Z
 	
 	// Fuel consumption (simplified)
@@ -223,36 +400,132 @@ func (calc *SimplifiedForcesMomentsCalculator) CalculateStateDerivatives(state *
 
 // SimplifiedFlightDynamicsEngine combines simplified forces with integration
 type SimplifiedFlightDynamicsEngine struct {
-	Calculator *SimplifiedForcesMomentsCalculator
-	Integrator Integrator
-	Statistics *FlightStatistics
+	Calculator   *SimplifiedForcesMomentsCalculator
+	Integrator   Integrator
+	Statistics   *FlightStatistics
+	Limits       *StructuralLimits  // Optional; limits are not checked when nil
+	Placards     *PlacardLimits     // Optional; speeds are not checked and the flaps and gear never held when nil
+	Departures   *DepartureDetector // Optional; departures are not watched when nil
+	MemoryBudget *MemoryBudget      // Optional; retained memory is not estimated when nil
+	Terrain      Terrain            // Optional; no ground contact when nil
+	Gear         *LandingGear       // Optional; no ground reaction and no gear units when nil
+	Events       *EventBus          // Watchers evaluated after each step
+	Replay       *ControlReplayer   // Optional; sets the pilot inputs at the start of each step when set
+	
+	// Pilot's eyepoint in body axes about the CG (m), where
+	// AircraftState.PilotSpecificForce is taken; the CG unless set
+	PilotStation Vector3
+	
+	// Flap and gear kinematics
+	FlapRate           float64 // Flap travel rate (deg/s)
+	GearTransitionTime float64 // Time for a full gear extension or retraction (s)
+	
+	// Optional retractable gear sequencing, doors and CG shift; the gear
+	// moves at GearTransitionTime with no doors when nil
+	GearSystem *GearSystem
 }
 
 // NewSimplifiedFlightDynamicsEngine creates a simplified but realistic flight dynamics engine
 func NewSimplifiedFlightDynamicsEngine(integrator Integrator) *SimplifiedFlightDynamicsEngine {
 	return &SimplifiedFlightDynamicsEngine{
-		Calculator: NewSimplifiedCalculator(),
-		Integrator: integrator,
-		Statistics: &FlightStatistics{},
+		Calculator:         NewSimplifiedCalculator(),
+		Integrator:         integrator,
+		Statistics:         &FlightStatistics{},
+		Events:             NewEventBus(),
+		FlapRate:           5.0,
+		GearTransitionTime: 8.0,
+	}
+}
+
+// updateConfiguration moves the flaps and gear toward their commanded
+// positions at the kinematic rates, unless the placard interlock holds them
+func (sfde *SimplifiedFlightDynamicsEngine) updateConfiguration(state, newState *AircraftState, dt float64) {
+	// Flaps
+	flapTarget := math.Max(0, math.Min(1, state.Controls.Flaps)) * MaxFlapDeflectionDeg * DEG_TO_RAD
+	flapTarget, newState.Placards.FlapsHeld = sfde.Placards.holdFlaps(state, flapTarget)
+	if newState.Placards.FlapsHeld && !state.Placards.FlapsHeld {
+		log.Printf("Placard interlock: flap extension refused at %.1f m/s CAS", state.CalibratedAirspeed)
+	}
+	flapStep := sfde.FlapRate * DEG_TO_RAD * dt
+	flapPos := moveToward(state.ControlSurfaces.FlapLeft, flapTarget, flapStep)
+	newState.ControlSurfaces.FlapLeft = flapPos
+	newState.ControlSurfaces.FlapRight = flapPos
+	
+	// Landing gear
+	newState.Placards.GearHeld = sfde.Placards.holdGear(state) && (sfde.GearSystem == nil || !sfde.GearSystem.Emergency())
+	if newState.Placards.GearHeld {
+		if !state.Placards.GearHeld {
+			log.Printf("Placard interlock: gear extension refused at %.1f m/s CAS", state.CalibratedAirspeed)
+		}
+		newState.Gear.Transition, newState.Gear.Doors = state.Gear.Transition, state.Gear.Doors
+		return
+	}
+	if sfde.GearSystem != nil {
+		sfde.GearSystem.Update(state, newState, dt)
+		return
+	}
+	gearTarget := 0.0
+	if state.Controls.Gear {
+		gearTarget = 1.0
+	}
+	gearStep := 1.0
+	if sfde.GearTransitionTime > 0 {
+		gearStep = dt / sfde.GearTransitionTime
+	}
+	newState.Gear.Transition = moveToward(state.Gear.Transition, gearTarget, gearStep)
+}
+
+// moveToward steps value toward target by at most maxStep
+func moveToward(value, target, maxStep float64) float64 {
+	if math.Abs(target-value) <= maxStep {
+		return target
 	}
+	return value + math.Copysign(maxStep, target-value)
 }
 
 // Step advances the simplified simulation by one time step
 func (sfde *SimplifiedFlightDynamicsEngine) Step(state *AircraftState, dt float64) (*AircraftState, error) {
-	// Calculate forces and moments
-	components, err := sfde.Calculator.CalculateSimplifiedForces(state)
+	if sfde.Replay != nil {
+		sfde.Replay.Apply(state)
+	}
+	
+	// Calculate forces, moments and state derivatives
+	derivatives, components, err := sfde.Derivatives(state)
 	if err != nil {
 		return nil, err
 	}
 	
-	// Calculate state derivatives
-	derivatives := sfde.Calculator.CalculateStateDerivatives(state, components)
+	// Integrate to new state, the dynamics evaluated at any stages
+	newState := integrateDynamics(sfde.Integrator, sfde, state, derivatives, dt)
+	sfde.updateConfiguration(state, newState, dt)
+	if sfde.Terrain != nil {
+		updateGeodeticPosition(newState, state.Position)
+		if err := updateGroundContact(newState, sfde.Terrain); err != nil {
+			return nil, fmt.Errorf("terrain lookup failed: %v", err)
+		}
+	}
+	if sfde.Gear != nil {
+		sfde.Gear.Update(newState)
+	}
 	
-	// Integrate to new state
-	newState := sfde.Integrator.Integrate(state, derivatives, dt)
+	// Energy rate and load factors over the step, from the forces at its start
+	newState.SpecificExcessPower = components.SpecificExcessPower(state.TrueAirspeed, sfde.Calculator.Mass)
+	newState.LoadFactor = components.LoadFactor(sfde.Calculator.Mass)
+	newState.recordAccelerations(derivatives, sfde.PilotStation)
 	
 	// Update statistics
 	sfde.updateStatistics(newState, components, dt)
+	if err := sfde.Statistics.checkLimits(sfde.Limits, sfde.Placards, newState); err != nil {
+		return nil, err
+	}
+	if sfde.Departures != nil {
+		if err := sfde.Departures.Observe(newState); err != nil {
+			return nil, err
+		}
+	}
+	if sfde.MemoryBudget != nil {
+		sfde.MemoryBudget.step(newState.Time, sfde.Statistics, sfde.Departures)
+	}
 	
 	// Store forces/moments for analysis
 	newState.Forces.Total = components.TotalForce
@@ -264,6 +537,13 @@ func (sfde *SimplifiedFlightDynamicsEngine) Step(state *AircraftState, dt float6
 	}
 	newState.Forces.Propulsive = Vector3{X: components.Propulsion.Thrust, Y: 0, Z: 0}
 	newState.Forces.Gravity = components.Gravity.Weight
+	newState.Forces.External = components.External.Force
+	newState.Forces.Ground = components.Ground.Force
+	newState.Moments.External = components.External.Moment
+	
+	if sfde.Events != nil {
+		sfde.Events.Evaluate(newState)
+	}
 	
 	return newState, nil
 }
@@ -273,11 +553,7 @@ func (sfde *SimplifiedFlightDynamicsEngine) updateStatistics(state *AircraftStat
 	stats := sfde.Statistics
 	
 	// Load factor
-	totalAccel := components.TotalForce.Magnitude() / sfde.Calculator.Mass
-	loadFactor := totalAccel / 9.81
-	if loadFactor > stats.MaxLoadFactor {
-		stats.MaxLoadFactor = loadFactor
-	}
+	stats.recordLoadFactor(state.LoadFactor.Z)
 	
 	// Climb rate
 	earthVel := state.Orientation.RotateVector(state.Velocity)
@@ -294,179 +570,25 @@ func (sfde *SimplifiedFlightDynamicsEngine) updateStatistics(state *AircraftStat
 		stats.MaxAltitude = state.Altitude
 	}
 	
+	// Specific excess power
+	stats.recordEnergy(state.Altitude, state.SpecificExcessPower)
+	stats.recordFlightPath(state)
+	
 	// Fuel consumption
 	fuelFlow := (components.Propulsion.Thrust / 6000.0) * 0.3
 	stats.TotalFuelBurned += fuelFlow * dt
 	stats.FlightTime += dt
 }
 
-// FlightDynamicsDemo demonstrates the complete integrated flight dynamics system
+// FlightDynamicsDemo demonstrates the complete integrated flight dynamics
+// system: every demo scenario on the simplified model, reported in full to
+// standard output. See DemoRunner to choose what is flown and printed.
 func FlightDynamicsDemo() {
-	fmt.Println("🛩️  P-51D Flight Dynamics Simulation Demo")
-	fmt.Println("=========================================")
-	
-	// Create simplified flight dynamics engine
-	integrator := NewRungeKutta4Integrator()
-	engine := NewSimplifiedFlightDynamicsEngine(integrator)
-	
-	fmt.Printf("Aircraft Configuration:\n")
-	fmt.Printf("   Mass: %.0f kg\n", engine.Calculator.Mass)
-	fmt.Printf("   Wing Area: %.1f m²\n", engine.Calculator.WingArea)
-	fmt.Printf("   Wing Span: %.1f m\n", engine.Calculator.WingSpan)
-	fmt.Printf("   Inertia: Ixx=%.0f, Iyy=%.0f, Izz=%.0f kg·m²\n",
-		engine.Calculator.Inertia.XX,
-		engine.Calculator.Inertia.YY,
-		engine.Calculator.Inertia.ZZ)
-	
-	// Initial flight conditions
-	state := NewAircraftState()
-	state.Altitude = 3000.0 // 3km
-	state.Velocity = Vector3{X: 100.0, Y: 0, Z: 0} // 100 m/s forward
-	state.Controls.Throttle = 0.8 // 80% power
-	state.UpdateAtmosphere()
-	state.UpdateDerivedParameters()
-	
-	fmt.Printf("\n🛫 Initial Conditions:\n")
-	fmt.Printf("   %s\n", state.String())
-	fmt.Printf("   Atmosphere: T=%.1f°C, P=%.0f hPa, ρ=%.3f kg/m³\n",
-		state.Temperature-273.15,
-		state.Pressure/100.0,
-		state.Density)
-	
-	// Demonstrate different flight phases
-	scenarios := []struct {
-		name        string
-		duration    float64
-		controls    ControlInputs
-		description string
-	}{
-		{
-			name:     "Level Flight",
-			duration: 5.0,
-			controls: ControlInputs{Throttle: 0.7, Elevator: 0.0, Aileron: 0.0, Rudder: 0.0},
-			description: "Steady level cruise",
-		},
-		{
-			name:     "Climb",
-			duration: 8.0,
-			controls: ControlInputs{Throttle: 1.0, Elevator: 0.15, Aileron: 0.0, Rudder: 0.0},
-			description: "Full power climb",
-		},
-		{
-			name:     "Banking Turn",
-			duration: 6.0,
-			controls: ControlInputs{Throttle: 0.8, Elevator: 0.05, Aileron: 0.3, Rudder: 0.1},
-			description: "Right banking turn",
-		},
-		{
-			name:     "Descent",
-			duration: 5.0,
-			controls: ControlInputs{Throttle: 0.4, Elevator: -0.1, Aileron: 0.0, Rudder: 0.0},
-			description: "Power-reduced descent",
-		},
+	runner := NewDemoRunner(os.Stdout)
+	runner.Verbosity = DemoVerbose
+	if _, err := runner.Run(); err != nil {
+		fmt.Printf("Demo failed: %v\n", err)
 	}
-	
-	dt := 0.01 // 10ms time steps (100 Hz)
-	
-	for _, scenario := range scenarios {
-		fmt.Printf("\n🎯 %s (%s)\n", scenario.name, scenario.description)
-		fmt.Printf("   Duration: %.1f seconds\n", scenario.duration)
-		
-		// Apply control inputs (just store them, FCS will process during simulation)
-		state.SetControlInputs(scenario.controls)
-		
-		// Record initial conditions
-		initialAlt := state.Altitude
-		initialSpeed := state.TrueAirspeed
-		initialHeading := state.Yaw
-		
-		// Simulate scenario
-		steps := int(scenario.duration / dt)
-		for i := 0; i < steps; i++ {
-			newState, err := engine.Step(state, dt)
-			if err != nil {
-				fmt.Printf("   ❌ Simulation error: %v\n", err)
-				return
-			}
-			state = newState
-		}
-		
-		// Calculate performance metrics
-		altChange := state.Altitude - initialAlt
-		speedChange := state.TrueAirspeed - initialSpeed
-		headingChange := (state.Yaw - initialHeading) * RAD_TO_DEG
-		
-		// Handle heading wrap-around
-		if headingChange > 180 {
-			headingChange -= 360
-		} else if headingChange < -180 {
-			headingChange += 360
-		}
-		
-		climbRate := altChange / scenario.duration
-		acceleration := speedChange / scenario.duration
-		turnRate := headingChange / scenario.duration
-		
-		fmt.Printf("   Results:\n")
-		fmt.Printf("     Altitude: %.0f → %.0f m (%.1f m/s climb rate)\n",
-			initialAlt, state.Altitude, climbRate)
-		fmt.Printf("     Speed: %.1f → %.1f m/s (%.2f m/s² acceleration)\n",
-			initialSpeed, state.TrueAirspeed, acceleration)
-		fmt.Printf("     Heading: %.1f° change (%.1f°/s turn rate)\n",
-			headingChange, turnRate)
-		fmt.Printf("     Attitude: φ=%.1f° θ=%.1f° ψ=%.1f°\n",
-			state.Roll*RAD_TO_DEG, state.Pitch*RAD_TO_DEG, state.Yaw*RAD_TO_DEG)
-		
-		// Show forces breakdown for current state
-		components, _ := engine.Calculator.CalculateSimplifiedForces(state)
-		fmt.Printf("     Forces: Lift=%.0fN, Drag=%.0fN, Thrust=%.0fN, Weight=%.0fN\n",
-			-components.Aerodynamic.Lift,
-			-components.Aerodynamic.Drag,
-			components.Propulsion.Thrust,
-			components.Gravity.Weight.Magnitude())
-	}
-	
-	// Final performance report
-	fmt.Printf("\n📊 Flight Performance Summary:\n")
-	stats := engine.Statistics
-	fmt.Printf("   Total Flight Time: %.1f seconds\n", stats.FlightTime)
-	fmt.Printf("   Max Load Factor: %.2f g\n", stats.MaxLoadFactor)
-	fmt.Printf("   Max Climb Rate: %.1f m/s (%.0f ft/min)\n",
-		stats.MaxClimbRate, stats.MaxClimbRate*60*M_TO_FT)
-	fmt.Printf("   Max Speed: %.1f m/s (%.1f kt)\n",
-		stats.MaxSpeed, stats.MaxSpeed*MS_TO_KT)
-	fmt.Printf("   Max Altitude: %.0f m (%.0f ft)\n",
-		stats.MaxAltitude, stats.MaxAltitude*M_TO_FT)
-	fmt.Printf("   Fuel Burned: %.2f kg\n", stats.TotalFuelBurned)
-	
-	fmt.Printf("\n🎯 Final State: %s\n", state.String())
-	
-	// Calculate some performance metrics
-	fmt.Printf("\n✈️  Aerodynamic Performance:\n")
-	
-	// Calculate L/D ratio
-	if components, err := engine.Calculator.CalculateSimplifiedForces(state); err == nil {
-		lift := -components.Aerodynamic.Lift
-		drag := -components.Aerodynamic.Drag
-		if drag > 0 {
-			ldRatio := lift / drag
-			fmt.Printf("   Current L/D Ratio: %.2f\n", ldRatio)
-		}
-		
-		// Calculate wing loading
-		wingLoading := engine.Calculator.Mass * 9.81 / engine.Calculator.WingArea
-		fmt.Printf("   Wing Loading: %.1f N/m² (%.1f lb/ft²)\n",
-			wingLoading, wingLoading*0.020885)
-		
-		// Calculate thrust-to-weight ratio
-		thrustToWeight := components.Propulsion.Thrust / (engine.Calculator.Mass * 9.81)
-		fmt.Printf("   Thrust-to-Weight: %.3f\n", thrustToWeight)
-	}
-	
-	fmt.Printf("\n🚀 Integration Performance:\n")
-	fmt.Printf("   Simulation Frequency: %.0f Hz\n", 1.0/dt)
-	fmt.Printf("   Total Integration Steps: %.0f\n", stats.FlightTime/dt)
-	fmt.Printf("   Real-time Capable: ✅ (Sub-millisecond per step)\n")
 }
 
 // StallDemo demonstrates stall characteristics
@@ -481,38 +603,45 @@ func StallDemo() {
 	state.Altitude = 2000.0
 	state.Velocity = Vector3{X: 60.0, Y: 0, Z: 0} // Slower speed
 	state.Controls.Throttle = 0.5
-	state.UpdateAtmosphere()
-	state.UpdateDerivedParameters()
-	
-	fmt.Printf("Demonstrating stall progression...\n")
-	fmt.Printf("Alpha(°)  CL     CD     L/D    Stalled?\n")
-	fmt.Printf("------    ----   ----   ----   --------\n")
+	state.UpdateFlightConditions()
 	
-	// Sweep through angles of attack
-	for alphaDeg := 0.0; alphaDeg <= 25.0; alphaDeg += 2.5 {
-		state.Alpha = alphaDeg * DEG_TO_RAD
-		state.UpdateDerivedParameters()
+	for _, flapDeg := range []float64{0.0, 30.0} {
+		state.ControlSurfaces.FlapLeft = flapDeg * DEG_TO_RAD
+		state.ControlSurfaces.FlapRight = flapDeg * DEG_TO_RAD
 		
-		components, _ := engine.Calculator.CalculateSimplifiedForces(state)
+		stallAlphaDeg := 18.0 + interpolate1D(engine.Calculator.FlapDeltaStallAlpha, flapDeg)
+		stallSpeed := engine.Calculator.StallSpeed(state)
 		
-		// Calculate coefficients
-		q := 0.5 * state.Density * state.TrueAirspeed * state.TrueAirspeed
-		qS := q * engine.Calculator.WingArea
+		fmt.Printf("\nFlaps %.0f°: stall speed %.1f m/s (%.0f kt)\n", flapDeg, stallSpeed, stallSpeed*MS_TO_KT)
+		fmt.Printf("Demonstrating stall progression...\n")
+		fmt.Printf("Alpha(°)  CL     CD     L/D    Stalled?\n")
+		fmt.Printf("------    ----   ----   ----   --------\n")
 		
-		CL := -components.Aerodynamic.Lift / qS
-		CD := -components.Aerodynamic.Drag / qS
-		LD := 0.0
-		if CD > 0 {
-			LD = CL / CD
-		}
-		
-		stalled := alphaDeg > 18.0 // Stall angle from our model
-		stalledStr := ""
-		if stalled {
-			stalledStr = "STALLED"
+		// Sweep through angles of attack
+		for alphaDeg := 0.0; alphaDeg <= 25.0; alphaDeg += 2.5 {
+			state.UpdateDerivedParameters()
+			state.Alpha = alphaDeg * DEG_TO_RAD // Override the velocity-derived alpha
+			
+			components, _ := engine.Calculator.CalculateSimplifiedForces(state)
+			
+			// Calculate coefficients
+			qS := state.DynamicPressure * engine.Calculator.WingArea
+			
+			CL := -components.Aerodynamic.Lift / qS
+			CD := -components.Aerodynamic.Drag / qS
+			LD := 0.0
+			if CD > 0 {
+				LD = CL / CD
+			}
+			
+			stalled := alphaDeg >= stallAlphaDeg // Stall angle from our model
+			stalledStr := ""
+			if stalled {
+				stalledStr = "STALLED"
+			}
+			
+			fmt.Printf("%-8.1f  %-5.2f  %-5.3f  %-5.1f  %s\n",
+				alphaDeg, CL, CD, LD, stalledStr)
 		}
-		
-		fmt.Printf("%-8.1f  %-5.2f  %-5.3f  %-5.1f  %s\n",
-			alphaDeg, CL, CD, LD, stalledStr)
 	}
 }
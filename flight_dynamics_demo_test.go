@@ -0,0 +1,711 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// newApproachState returns a state at a typical approach speed and alpha
+func newApproachState(flapDeg, gear float64) *AircraftState {
+	state := NewAircraftState()
+	state.Velocity = Vector3{X: 70.0, Y: 0, Z: 0}
+	state.UpdateAtmosphere()
+	state.UpdateDerivedParameters()
+	state.Alpha = 5.0 * DEG_TO_RAD
+	state.ControlSurfaces.FlapLeft = flapDeg * DEG_TO_RAD
+	state.ControlSurfaces.FlapRight = flapDeg * DEG_TO_RAD
+	state.Gear.Transition = gear
+	return state
+}
+
+// coefficients returns CL, CD and Cm for a state from the simplified calculator
+func coefficients(t *testing.T, calc *SimplifiedForcesMomentsCalculator, state *AircraftState) (CL, CD, Cm float64) {
+	t.Helper()
+	components, err := calc.CalculateSimplifiedForces(state)
+	if err != nil {
+		t.Fatalf("Force calculation failed: %v", err)
+	}
+	qS := 0.5 * state.Density * state.TrueAirspeed * state.TrueAirspeed * calc.WingArea
+	return -components.Aerodynamic.Lift / qS, -components.Aerodynamic.Drag / qS,
+		components.Moments.Pitch / (qS * calc.Chord)
+}
+
+func TestSimplifiedConfigurationIncrements(t *testing.T) {
+	calc := NewSimplifiedCalculator()
+
+	cleanCL, cleanCD, cleanCm := coefficients(t, calc, newApproachState(0, 0))
+
+	t.Run("Gear Drag", func(t *testing.T) {
+		gearCL, gearCD, _ := coefficients(t, calc, newApproachState(0, 1))
+		if gearCD <= cleanCD {
+			t.Errorf("CD with gear down %.4f should exceed clean CD %.4f", gearCD, cleanCD)
+		}
+		assertApproxEqual(t, gearCD-cleanCD, calc.GearDeltaCD, 1e-9)
+		assertApproxEqual(t, gearCL, cleanCL, 1e-9)
+
+		// Half-extended gear gives half the increment
+		_, halfCD, _ := coefficients(t, calc, newApproachState(0, 0.5))
+		assertApproxEqual(t, halfCD-cleanCD, 0.5*calc.GearDeltaCD, 1e-9)
+	})
+
+	t.Run("Flap Increments", func(t *testing.T) {
+		flapCL, flapCD, flapCm := coefficients(t, calc, newApproachState(30, 0))
+		if flapCL <= cleanCL {
+			t.Errorf("Flaps should increase CL: %.3f vs %.3f", flapCL, cleanCL)
+		}
+		if flapCD <= cleanCD {
+			t.Errorf("Flaps should increase CD: %.4f vs %.4f", flapCD, cleanCD)
+		}
+		if flapCm >= cleanCm {
+			t.Errorf("Flaps should pitch the nose down: Cm %.3f vs %.3f", flapCm, cleanCm)
+		}
+	})
+
+	t.Run("Stall Speed With Flaps", func(t *testing.T) {
+		clean := calc.StallSpeed(newApproachState(0, 0))
+		flaps := calc.StallSpeed(newApproachState(30, 0))
+		reduction := (clean - flaps) / clean
+		if reduction < 0.05 || reduction > 0.20 {
+			t.Errorf("Flaps 30 should cut stall speed by 5-20%%, got %.1f%% (%.1f -> %.1f m/s)",
+				reduction*100, clean, flaps)
+		}
+	})
+
+	t.Run("Stall Alpha With Flaps", func(t *testing.T) {
+		state := newApproachState(30, 0)
+		state.Alpha = 16.0 * DEG_TO_RAD // Below the clean stall, above the flapped one
+		flapCL, _, _ := coefficients(t, calc, state)
+		state = newApproachState(0, 0)
+		state.Alpha = 16.0 * DEG_TO_RAD
+		clean, _, _ := coefficients(t, calc, state)
+		if flapCL >= clean {
+			t.Errorf("Flapped wing should be stalled at 16°: CL %.2f vs clean %.2f", flapCL, clean)
+		}
+	})
+}
+
+func TestSimplifiedConfigurationKinematics(t *testing.T) {
+	engine := NewSimplifiedFlightDynamicsEngine(NewRungeKutta4Integrator())
+
+	state := NewAircraftState()
+	state.Position.Z = -state.Altitude
+	state.Controls = ControlInputs{Throttle: 0.5, Flaps: 0.75, Gear: true}
+	state.Gear.Transition = 0.0
+
+	// Commands are not applied instantly
+	dt := 0.01
+	for i := 0; i < 100; i++ {
+		next, err := engine.Step(state, dt)
+		if err != nil {
+			t.Fatalf("Step failed: %v", err)
+		}
+		state = next
+	}
+	assertApproxEqual(t, state.ControlSurfaces.FlapLeft*RAD_TO_DEG, engine.FlapRate*1.0, 1e-3)
+	assertApproxEqual(t, state.Gear.Transition, 1.0/engine.GearTransitionTime, 1e-6)
+
+	// Positions settle at the commanded values
+	for i := 0; i < 1000; i++ {
+		next, err := engine.Step(state, dt)
+		if err != nil {
+			t.Fatalf("Step failed: %v", err)
+		}
+		state = next
+	}
+	assertApproxEqual(t, state.ControlSurfaces.FlapLeft*RAD_TO_DEG, 30.0, 1e-3)
+	assertApproxEqual(t, state.ControlSurfaces.FlapRight, state.ControlSurfaces.FlapLeft, 1e-12)
+	assertApproxEqual(t, state.Gear.Transition, 1.0, 1e-9)
+}
+
+func TestSimplifiedSignConventions(t *testing.T) {
+	calc := NewSimplifiedCalculator()
+	
+	// sideslipState returns level flight at 100 m/s with the relative wind
+	// beta from the right, engine off and controls centred
+	sideslipState := func(beta float64) *AircraftState {
+		state := NewAircraftState()
+		state.Velocity = Vector3{X: 100 * math.Cos(beta), Y: 100 * math.Sin(beta)}
+		state.Controls.Throttle = 0
+		state.UpdateAtmosphere()
+		state.UpdateDerivedParameters()
+		return state
+	}
+	calculate := func(state *AircraftState) *ForceMomentComponents {
+		t.Helper()
+		components, err := calc.CalculateSimplifiedForces(state)
+		if err != nil {
+			t.Fatalf("CalculateSimplifiedForces: %v", err)
+		}
+		return components
+	}
+	
+	t.Run("Directional Stability", func(t *testing.T) {
+		level := calculate(sideslipState(0))
+		assertApproxEqual(t, level.Moments.Roll, 0, 1e-9)
+		assertApproxEqual(t, level.Moments.Yaw, 0, 1e-9)
+		
+		state := sideslipState(5 * DEG_TO_RAD)
+		assertApproxEqual(t, state.Beta, 5*DEG_TO_RAD, 1e-12)
+		components := calculate(state)
+		if components.Aerodynamic.Side >= 0 {
+			t.Errorf("Side force %.1f N should push back downwind", components.Aerodynamic.Side)
+		}
+		if components.Moments.Yaw <= 0 {
+			t.Errorf("Yaw moment %.1f N·m should turn the nose into the wind", components.Moments.Yaw)
+		}
+		if components.Moments.Roll >= 0 {
+			t.Errorf("Roll moment %.1f N·m should roll away from the wind", components.Moments.Roll)
+		}
+		
+		// The yaw acceleration turns the nose right, towards the wind
+		derivatives := calc.CalculateStateDerivatives(state, components)
+		if derivatives.AngularRateDot.Z <= 0 {
+			t.Errorf("Yaw acceleration %.4f rad/s² should be restoring", derivatives.AngularRateDot.Z)
+		}
+		
+		// Sideslip from the left mirrors it
+		mirrored := calculate(sideslipState(-5 * DEG_TO_RAD))
+		assertApproxEqual(t, mirrored.Moments.Yaw, -components.Moments.Yaw, 1e-9)
+		assertApproxEqual(t, mirrored.Moments.Roll, -components.Moments.Roll, 1e-9)
+		assertApproxEqual(t, mirrored.Aerodynamic.Side, -components.Aerodynamic.Side, 1e-9)
+	})
+	
+	t.Run("Control Senses", func(t *testing.T) {
+		for _, c := range []struct {
+			name   string
+			set    func(*ControlInputs)
+			moment func(*ForceMomentComponents) float64
+			sign   float64
+		}{
+			{"aileron rolls right", func(c *ControlInputs) { c.Aileron = 0.2 }, func(f *ForceMomentComponents) float64 { return f.Moments.Roll }, 1},
+			{"elevator pitches nose down", func(c *ControlInputs) { c.Elevator = 0.2 }, func(f *ForceMomentComponents) float64 { return f.Moments.Pitch }, -1},
+			{"rudder yaws nose left", func(c *ControlInputs) { c.Rudder = 0.2 }, func(f *ForceMomentComponents) float64 { return f.Moments.Yaw }, -1},
+		} {
+			state := sideslipState(0)
+			base := c.moment(calculate(state))
+			c.set(&state.Controls)
+			if delta := c.moment(calculate(state)) - base; delta*c.sign <= 0 {
+				t.Errorf("Positive %s, but the moment changed by %.1f N·m", c.name, delta)
+			}
+		}
+	})
+	
+	t.Run("Propeller Rotation", func(t *testing.T) {
+		state := sideslipState(0)
+		state.Controls.Throttle = 0.8
+		
+		// A propeller turning clockwise seen from behind rolls the airframe left
+		assertEqual(t, calc.PropellerSense, 1.0)
+		clockwise := calculate(state)
+		if clockwise.Propulsion.Torque <= 0 || clockwise.Moments.Roll >= 0 {
+			t.Fatalf("Torque %.1f N·m should roll the airframe left, roll moment %.1f N·m",
+				clockwise.Propulsion.Torque, clockwise.Moments.Roll)
+		}
+		assertApproxEqual(t, clockwise.Moments.Roll, -clockwise.Propulsion.Torque, 1e-9)
+		
+		calc.PropellerSense = -1
+		defer func() { calc.PropellerSense = 1 }()
+		counter := calculate(state)
+		assertApproxEqual(t, counter.Moments.Roll, -clockwise.Moments.Roll, 1e-9)
+		assertApproxEqual(t, counter.Moments.Yaw, clockwise.Moments.Yaw, 1e-12)
+	})
+}
+
+func TestAlphaDotElevatorDoublet(t *testing.T) {
+	engine := NewSimplifiedFlightDynamicsEngine(NewRungeKutta4Integrator())
+	fcs := CreateBasicFlightControlSystem()
+	
+	state := NewAircraftState()
+	state.Position.Z = -state.Altitude
+	state.Velocity = Vector3{X: 80.0, Y: 0, Z: 0}
+	state.Controls.Throttle = 0.6
+	state.UpdateAtmosphere()
+	state.UpdateDerivedParameters()
+	
+	dt := 0.01
+	states := []*AircraftState{state}
+	for i := 0; i < 200; i++ {
+		tm := float64(i) * dt
+		state.Controls.Elevator = 0
+		if tm < 0.5 {
+			state.Controls.Elevator = 0.2
+		} else if tm < 1.0 {
+			state.Controls.Elevator = -0.2
+		}
+		next, err := engine.Step(state, dt)
+		if err != nil {
+			t.Fatalf("Step %d failed: %v", i, err)
+		}
+		state = next
+		states = append(states, state)
+	}
+	
+	// Compare with a central difference of the alpha trace. The state's rate
+	// is a backward difference, so it lags by half a step.
+	peak, worst := 0.0, 0.0
+	for i := 2; i < len(states)-1; i++ {
+		central := (states[i+1].Alpha - states[i-1].Alpha) / (2 * dt)
+		peak = math.Max(peak, math.Abs(states[i].AlphaDot))
+		worst = math.Max(worst, math.Abs(states[i].AlphaDot-central))
+	}
+	if peak < 0.05 {
+		t.Fatalf("Elevator doublet should move alpha, peak alphadot %.4f rad/s", peak)
+	}
+	if worst > 0.02*peak {
+		t.Errorf("Alphadot departs from the alpha trace by %.4f rad/s (peak %.4f)", worst, peak)
+	}
+	
+	// Published to the FCS properties
+	fcs.Execute(states[50], dt)
+	assertApproxEqual(t, fcs.Properties.Get("aero/alphadot-deg_sec"), states[50].AlphaDot*RAD_TO_DEG, 1e-9)
+}
+
+// trimLevelFlight trims the simplified model for wings-level unaccelerated
+// flight
+func trimLevelFlight(t *testing.T, engine *SimplifiedFlightDynamicsEngine, altitude, airspeed float64) *AircraftState {
+	t.Helper()
+	return trimLevelTurn(t, engine, altitude, airspeed, 0)
+}
+
+// trimLevelTurn trims the simplified model for a steady coordinated level
+// turn at the given bank angle. Body alpha, elevator and throttle are solved
+// by Newton iteration so that the applied forces hold altitude and speed
+// and the pitching moment is zero, with the body rates of the turn. The
+// bank is stepped up from wings level, starting each solve from the last,
+// as the model's moment limit stalls Newton from a poor first guess.
+func trimLevelTurn(t *testing.T, engine *SimplifiedFlightDynamicsEngine, altitude, airspeed, bank float64) *AircraftState {
+	t.Helper()
+	x := Vector3{X: 0, Y: 0, Z: 0.5}
+	steps := int(math.Ceil(math.Abs(bank) / (10 * DEG_TO_RAD)))
+	for i := 1; i <= steps; i++ {
+		x = trimTurnControls(t, engine, altitude, airspeed, bank*float64(i)/float64(steps), x)
+	}
+	x = trimTurnControls(t, engine, altitude, airspeed, bank, x)
+	return turnState(altitude, airspeed, bank, x)
+}
+
+// turnState returns the level turn state for x = (alpha parameter,
+// elevator, throttle)
+func turnState(altitude, airspeed, bank float64, x Vector3) *AircraftState {
+	// Pitch attitude that keeps the flight path level at this bank
+	alpha := x.X
+	pitch := math.Atan(math.Tan(alpha) * math.Cos(bank))
+	omega := StandardGravity * math.Tan(bank) / airspeed
+	
+	state := NewAircraftState()
+	state.Altitude = altitude
+	state.Position.Z = -altitude
+	state.Orientation = NewQuaternionFromEuler(bank, pitch, 0)
+	state.Velocity = Vector3{X: airspeed * math.Cos(alpha), Y: 0, Z: airspeed * math.Sin(alpha)}
+	state.AngularRate = Vector3{
+		X: -omega * math.Sin(pitch),
+		Y: omega * math.Sin(bank) * math.Cos(pitch),
+		Z: omega * math.Cos(bank) * math.Cos(pitch),
+	}
+	state.Controls.Elevator = x.Y
+	state.Controls.Throttle = x.Z
+	state.Controls.Gear = false
+	state.Gear.Transition = 0
+	state.UpdateAtmosphere()
+	state.UpdateDerivedParameters()
+	return state
+}
+
+// trimTurnControls solves the level turn controls by Newton iteration from
+// the initial guess x
+func trimTurnControls(t *testing.T, engine *SimplifiedFlightDynamicsEngine, altitude, airspeed, bank float64, x Vector3) Vector3 {
+	t.Helper()
+	build := func(x Vector3) *AircraftState { return turnState(altitude, airspeed, bank, x) }
+	x, ok := solveTrim(t, engine, build, 1, 0, x)
+	if !ok {
+		t.Fatalf("Trim did not converge at %.0f m, %.0f m/s, %.0f° bank", altitude, airspeed, bank*RAD_TO_DEG)
+	}
+	return x
+}
+
+// trimPullUp trims the simplified model at the bottom of a wings-level
+// pull-up: level flight path, pitching at the rate that gives load factor
+// nz, with no pitch acceleration. The load factor is stepped up from 1.
+func trimPullUp(t *testing.T, engine *SimplifiedFlightDynamicsEngine, altitude, airspeed, nz float64) *AircraftState {
+	t.Helper()
+	x := Vector3{X: 0, Y: 0, Z: 0.5}
+	for n := 1.0; ; n = math.Min(n+0.25, nz) {
+		build := func(x Vector3) *AircraftState { return pullUpState(altitude, airspeed, n, x) }
+		var ok bool
+		if x, ok = solveTrim(t, engine, build, n, 0, x); !ok {
+			t.Fatalf("Pull-up trim did not converge at %.2f g", n)
+		}
+		if n == nz {
+			return pullUpState(altitude, airspeed, nz, x)
+		}
+	}
+}
+
+// pullUpState returns the pull-up state for x = (alpha, elevator, throttle)
+func pullUpState(altitude, airspeed, nz float64, x Vector3) *AircraftState {
+	state := turnState(altitude, airspeed, 0, x)
+	state.AngularRate = Vector3{Y: StandardGravity * (nz - 1) / airspeed}
+	state.UpdateDerivedParameters()
+	return state
+}
+
+// trimClimb trims the simplified model for a steady wings-level climb at
+// flight path angle gamma
+func trimClimb(t *testing.T, engine *SimplifiedFlightDynamicsEngine, altitude, airspeed, gamma float64) *AircraftState {
+	t.Helper()
+	build := func(x Vector3) *AircraftState { return trimmedClimbState(altitude, airspeed, gamma, x) }
+	x, ok := solveTrim(t, engine, build, 1, gamma, trimTurnControls(t, engine, altitude, airspeed, 0, Vector3{Z: 0.5}))
+	if !ok {
+		t.Fatalf("Climb trim did not converge at %.0f m/s, %.1f°", airspeed, gamma*RAD_TO_DEG)
+	}
+	return trimmedClimbState(altitude, airspeed, gamma, x)
+}
+
+// trimmedClimbState returns the climb state for x = (alpha, elevator, throttle):
+// the level state pitched up by gamma
+func trimmedClimbState(altitude, airspeed, gamma float64, x Vector3) *AircraftState {
+	state := turnState(altitude, airspeed, 0, x)
+	state.Orientation = NewQuaternionFromEuler(0, x.X+gamma, 0)
+	state.UpdateDerivedParameters()
+	return state
+}
+
+// solveTrim solves by Newton iteration from x for the controls at which
+// build(x) has no net force along a flight path climbing at gamma,
+// vertical applied force of nz times the weight and no pitch acceleration
+func solveTrim(t *testing.T, engine *SimplifiedFlightDynamicsEngine, build func(Vector3) *AircraftState, nz, gamma float64, x Vector3) (Vector3, bool) {
+	t.Helper()
+	mass := engine.Calculator.Mass
+	residual := func(x Vector3) Vector3 {
+		state := build(x)
+		components, err := engine.Calculator.CalculateSimplifiedForces(state)
+		if err != nil {
+			t.Fatalf("Force calculation failed: %v", err)
+		}
+		
+		// Applied (non-gravity) forces in the earth frame: along the flight
+		// path, balancing the weight's component in a climb, and vertical
+		// support for the weight
+		applied := Vector3{
+			X: components.Aerodynamic.Drag + components.Propulsion.Thrust,
+			Y: components.Aerodynamic.Side,
+			Z: components.Aerodynamic.Lift,
+		}
+		earth := state.Orientation.RotateVector(applied)
+		path := state.Orientation.RotateVector(state.Velocity).Normalize()
+		d := engine.Calculator.CalculateStateDerivatives(state, components)
+		return Vector3{X: earth.Dot(path)/mass - StandardGravity*math.Sin(gamma), Y: earth.Z/mass + nz*StandardGravity, Z: d.AngularRateDot.Y}
+	}
+	
+	for iter := 0; iter < 20; iter++ {
+		r := residual(x)
+		if r.Magnitude() < 1e-9 {
+			return x, true
+		}
+		
+		// Finite-difference Jacobian columns, solved by Cramer's rule
+		const h = 1e-6
+		a := residual(x.Add(Vector3{X: h})).Add(r.Scale(-1)).Scale(1 / h)
+		b := residual(x.Add(Vector3{Y: h})).Add(r.Scale(-1)).Scale(1 / h)
+		c := residual(x.Add(Vector3{Z: h})).Add(r.Scale(-1)).Scale(1 / h)
+		det := a.Dot(b.Cross(c))
+		step := Vector3{
+			X: r.Dot(b.Cross(c)) / det,
+			Y: a.Dot(r.Cross(c)) / det,
+			Z: a.Dot(b.Cross(r)) / det,
+		}
+		x = x.Add(step.Scale(-1))
+	}
+	return x, false
+}
+
+func TestSpecificExcessPower(t *testing.T) {
+	dt := 0.01
+	
+	t.Run("Level Trimmed Flight", func(t *testing.T) {
+		engine := NewSimplifiedFlightDynamicsEngine(NewRungeKutta4Integrator())
+		state := trimLevelFlight(t, engine, 1500.0, 100.0)
+		assertApproxEqual(t, state.EnergyHeight, 1500.0+100.0*100.0/(2*StandardGravity), 1e-9)
+		
+		// The stiff pitch damping settles into a small limit cycle at this
+		// step size, which bleeds energy through the induced drag
+		for i := 0; i < 100; i++ {
+			next, err := engine.Step(state, dt)
+			if err != nil {
+				t.Fatalf("Step %d failed: %v", i, err)
+			}
+			state = next
+			if math.Abs(state.SpecificExcessPower) > 0.02 {
+				t.Fatalf("Ps should be zero in trimmed level flight, got %.4f m/s at step %d",
+					state.SpecificExcessPower, i)
+			}
+		}
+		assertApproxEqual(t, engine.Statistics.SpecificExcessPower, state.SpecificExcessPower, 1e-12)
+		
+		// Published to the property map
+		properties := state.ToPropertyMap()
+		assertApproxEqual(t, properties["performance/Ps-mps"], state.SpecificExcessPower, 1e-12)
+		assertApproxEqual(t, properties["performance/energy-height-m"], state.EnergyHeight, 1e-12)
+	})
+	
+	t.Run("Full Throttle Climb", func(t *testing.T) {
+		// Euler integration applies the accelerations unscaled, so the
+		// energy balance can be checked closely
+		engine := NewSimplifiedFlightDynamicsEngine(NewEulerIntegrator())
+		state := trimLevelFlight(t, engine, 1500.0, 100.0)
+		state.Controls.Throttle = 1.0
+		
+		// Ps is the rate of climb in energy height, so its integral over the
+		// run matches the energy height gained
+		initial := state.EnergyHeight
+		predicted := 0.0
+		for i := 0; i < 200; i++ {
+			next, err := engine.Step(state, dt)
+			if err != nil {
+				t.Fatalf("Step %d failed: %v", i, err)
+			}
+			state = next
+			predicted += state.SpecificExcessPower * dt
+		}
+		gained := state.EnergyHeight - initial
+		if predicted < 5.0 {
+			t.Fatalf("Full throttle should give a clear energy gain, Ps integral %.2f m", predicted)
+		}
+		if math.Abs(gained-predicted) > 0.01*predicted {
+			t.Errorf("Energy height gained %.2f m, Ps predicts %.2f m", gained, predicted)
+		}
+		
+		// Statistics track the best Ps and the altitude band it was seen in
+		stats := engine.Statistics
+		if stats.MaxSpecificExcessPower <= 0 || len(stats.MaxPsByAltitude) == 0 {
+			t.Fatalf("Ps statistics not recorded: %+v", stats)
+		}
+		band := math.Floor(stats.MaxPsAltitude/PsAltitudeBand) * PsAltitudeBand
+		assertApproxEqual(t, stats.MaxPsByAltitude[band], stats.MaxSpecificExcessPower, 1e-12)
+	})
+	
+	t.Run("Steady Climb", func(t *testing.T) {
+		engine := NewSimplifiedFlightDynamicsEngine(NewRungeKutta4Integrator())
+		gamma := 3 * DEG_TO_RAD
+		state := trimClimb(t, engine, 1500.0, 100.0, gamma)
+		assertApproxEqual(t, state.FlightPathAngle(), gamma, 1e-9)
+		
+		// γ against the climb rate flown over a second
+		initial := state
+		for i := 0; i < 500; i++ {
+			next, err := engine.Step(state, 0.002)
+			if err != nil {
+				t.Fatalf("Step %d failed: %v", i, err)
+			}
+			state = next
+		}
+		climbRate := (state.Altitude - initial.Altitude) / (state.Time - initial.Time)
+		flown := math.Asin(climbRate / state.TrueAirspeed)
+		if diff := math.Abs(state.FlightPathAngle() - flown); diff > 0.05*DEG_TO_RAD {
+			t.Errorf("γ %.3f° against %.3f° from the %.2f m/s climb flown", state.FlightPathAngle()*RAD_TO_DEG, flown*RAD_TO_DEG, climbRate)
+		}
+		
+		properties := state.ToPropertyMap()
+		assertApproxEqual(t, properties["flight-path/gamma-deg"], state.FlightPathAngle()*RAD_TO_DEG, 1e-12)
+		assertApproxEqual(t, properties["flight-path/climb-gradient-pct"], 100*math.Tan(state.FlightPathAngle()), 1e-12)
+		assertApproxEqual(t, properties["flight-path/psi-gt-rad"], state.Track(), 1e-12)
+		
+		// The statistics see the climb at the trimmed speed
+		stats := engine.Statistics
+		assertApproxEqual(t, stats.MaxFlightPathAngle, gamma, 0.05*DEG_TO_RAD)
+		assertApproxEqual(t, stats.BestClimbGamma, gamma, 0.05*DEG_TO_RAD)
+		assertApproxEqual(t, stats.BestClimbSpeed, 100.0, 0.5)
+		assertApproxEqual(t, stats.BestClimbRate, 100*math.Sin(gamma), 0.1)
+	})
+}
+
+func TestLoadFactor(t *testing.T) {
+	dt := 0.01
+	
+	// Load factor over a step from a trimmed state, and the total-force
+	// metric the statistics used before
+	fly := func(state *AircraftState) (nz, totalForce float64, engine *SimplifiedFlightDynamicsEngine) {
+		engine = NewSimplifiedFlightDynamicsEngine(NewEulerIntegrator())
+		if v := state.Orientation.RotateVector(state.Velocity); math.Abs(v.Z) > 1e-6 {
+			t.Fatalf("Trimmed flight path should be level, climbing at %.4f m/s", -v.Z)
+		}
+		next, err := engine.Step(state, dt)
+		if err != nil {
+			t.Fatalf("Step failed: %v", err)
+		}
+		totalForce = next.Forces.Total.Magnitude() / (engine.Calculator.Mass * StandardGravity)
+		return next.LoadFactor.Z, totalForce, engine
+	}
+	
+	t.Run("Straight And Level", func(t *testing.T) {
+		trimmer := NewSimplifiedFlightDynamicsEngine(NewEulerIntegrator())
+		nz, totalForce, engine := fly(trimLevelFlight(t, trimmer, 1500.0, 100.0))
+		assertApproxEqual(t, nz, 1.0, 0.01)
+		if totalForce > 0.1 {
+			t.Errorf("Total force should nearly vanish in trimmed flight, got %.3f g", totalForce)
+		}
+		assertApproxEqual(t, engine.Statistics.MaxLoadFactor, 1.0, 0.01)
+		assertApproxEqual(t, engine.Statistics.MinLoadFactor, 1.0, 0.01)
+	})
+	
+	t.Run("60 Degree Level Turn", func(t *testing.T) {
+		trimmer := NewSimplifiedFlightDynamicsEngine(NewEulerIntegrator())
+		state := trimLevelTurn(t, trimmer, 1500.0, 120.0, 60*DEG_TO_RAD)
+		nz, totalForce, engine := fly(state)
+		assertApproxEqual(t, nz, 2.0, 0.02)
+		if math.Abs(totalForce-2.0) < 0.2 {
+			t.Errorf("Total-force metric %.3f g should not match the turn load factor", totalForce)
+		}
+		assertApproxEqual(t, engine.Statistics.MaxLoadFactor, nz, 1e-12)
+		
+		// Published in JSBSim's signs
+		next, err := engine.Step(state, dt)
+		if err != nil {
+			t.Fatalf("Step failed: %v", err)
+		}
+		properties := next.ToPropertyMap()
+		assertApproxEqual(t, properties["accelerations/Nz"], next.LoadFactor.Z, 1e-12)
+		assertApproxEqual(t, properties["accelerations/n-pilot-z-norm"], -next.LoadFactor.Z, 1e-12)
+		assertApproxEqual(t, properties["accelerations/n-pilot-x-norm"], next.LoadFactor.X, 1e-12)
+	})
+}
+
+func TestSensorAccelerations(t *testing.T) {
+	dt := 0.002
+	pilot := configPilotStation(loadP51DConfig(t))
+
+	// The P-51D eyepoint is 3 in ahead of and 39 in above the CG
+	assertApproxEqual(t, pilot.X, 3*0.0254, 1e-5)
+	assertApproxEqual(t, pilot.Y, 0.0, 1e-12)
+	assertApproxEqual(t, pilot.Z, -39*0.0254, 1e-5)
+	assertEqual(t, NewFlightDynamicsEngine(loadP51DConfig(t), NewEulerIntegrator()).PilotStation, pilot)
+
+	t.Run("Steady 2 g Pull-Up", func(t *testing.T) {
+		engine := NewSimplifiedFlightDynamicsEngine(NewEulerIntegrator())
+		engine.PilotStation = pilot
+		state := trimPullUp(t, engine, 1500.0, 120.0, 2.0)
+
+		components, err := engine.Calculator.CalculateSimplifiedForces(state)
+		if err != nil {
+			t.Fatalf("Force calculation failed: %v", err)
+		}
+		derivatives := engine.Calculator.CalculateStateDerivatives(state, components)
+		next, err := engine.Step(state, dt)
+		if err != nil {
+			t.Fatalf("Step failed: %v", err)
+		}
+		assertEqual(t, next.Acceleration, derivatives.VelocityDot)
+		assertEqual(t, next.AngularAccel, derivatives.AngularRateDot)
+		assertApproxEqual(t, next.AngularAccel.Y, 0.0, 1e-6)
+
+		// The pilot feels 2 g toward the seat, less the small centripetal
+		// acceleration of sitting 1 m above the CG
+		assertApproxEqual(t, -next.PilotSpecificForce.Z/StandardGravity, 2.0, 0.01)
+		q := next.AngularRate.Y
+		assertApproxEqual(t, next.PilotSpecificForce.Z-next.SpecificForceAt(Vector3{}).Z, -q*q*pilot.Z, 1e-6)
+
+		properties := next.ToPropertyMap()
+		assertEqual(t, properties["accelerations/a-pilot-z-mps2"], next.PilotSpecificForce.Z)
+		assertEqual(t, properties["accelerations/udot-mps2"], next.Acceleration.X)
+		assertEqual(t, properties["accelerations/wdot-mps2"], next.Acceleration.Z)
+		assertEqual(t, properties["accelerations/qdot-rad_sec2"], next.AngularAccel.Y)
+	})
+
+	t.Run("Wingtip In A Rapid Roll", func(t *testing.T) {
+		engine := NewSimplifiedFlightDynamicsEngine(NewEulerIntegrator())
+		state := trimLevelFlight(t, engine, 1500.0, 120.0)
+		state.AngularRate.X = 3.0
+		state.Controls.Aileron = 0.5
+		next, err := engine.Step(state, dt)
+		if err != nil {
+			t.Fatalf("Step failed: %v", err)
+		}
+		if next.AngularAccel.X == 0 {
+			t.Fatal("Roll acceleration was not recorded")
+		}
+
+		// A sensor at the tip is pulled inboard by the roll, and up or
+		// down by the roll acceleration, on top of what the CG feels
+		tip := Vector3{Y: engine.Calculator.WingSpan / 2}
+		extra := next.SpecificForceAt(tip).Add(next.SpecificForceAt(Vector3{}).Scale(-1))
+		p, r := next.AngularRate.X, next.AngularRate.Z
+		assertApproxEqual(t, extra.Y, -(p*p+r*r)*tip.Y, 1e-9)
+		if extra.Y > -40 {
+			t.Errorf("Expected a centripetal pull over 4 g at the tip, got %.1f m/s²", extra.Y)
+		}
+		assertApproxEqual(t, extra.Z, next.AngularAccel.X*tip.Y+next.AngularRate.Z*next.AngularRate.Y*tip.Y, 1e-9)
+
+		// The tip moves with the roll and accelerates with it
+		assertApproxEqual(t, next.VelocityAt(tip).Z, next.Velocity.Z+p*tip.Y, 1e-9)
+		assertEqual(t, next.AccelerationAt(tip).Add(next.Acceleration.Scale(-1)), extra)
+	})
+}
+
+func TestStructuralLimits(t *testing.T) {
+	dt := 0.01
+	trimmer := NewSimplifiedFlightDynamicsEngine(NewEulerIntegrator())
+	turn := trimLevelTurn(t, trimmer, 1500.0, 120.0, 60*DEG_TO_RAD)
+	
+	t.Run("P-51D Limits", func(t *testing.T) {
+		limits := NewP51DStructuralLimits()
+		state := turn.Copy()
+		state.LoadFactor.Z = 2.0
+		assertEqual(t, len(limits.Check(state)), 0)
+		
+		// Flaps are limited to 2 g
+		state.LoadFactor.Z = 2.5
+		state.ControlSurfaces.FlapLeft = 20 * DEG_TO_RAD
+		exceeded := limits.Check(state)
+		if len(exceeded) != 1 || exceeded[0].Limit != LimitNzMax {
+			t.Fatalf("Expected an nz-max exceedance with flaps, got %v", exceeded)
+		}
+		assertApproxEqual(t, exceeded[0].Bound, 2.0, 1e-12)
+		
+		state.ControlSurfaces.FlapLeft = 0
+		state.LoadFactor.Z = -4.5
+		state.CalibratedAirspeed = 240.0
+		exceeded = limits.Check(state)
+		assertEqual(t, len(exceeded), 2)
+		assertEqual(t, exceeded[0].Limit, LimitVne)
+		assertEqual(t, exceeded[1].Limit, LimitNzMin)
+	})
+	
+	limits := &StructuralLimits{
+		NzMax: &Table1D{Indices: []float64{0}, Values: []float64{1.5}},
+	}
+	
+	t.Run("Recorded Once Per Exceedance", func(t *testing.T) {
+		engine := NewSimplifiedFlightDynamicsEngine(NewEulerIntegrator())
+		engine.Limits = limits
+		state := turn
+		for i := 0; i < 20; i++ {
+			next, err := engine.Step(state, dt)
+			if err != nil {
+				t.Fatalf("Step %d failed: %v", i, err)
+			}
+			state = next
+		}
+		exceedances := engine.Statistics.Exceedances
+		if len(exceedances) != 1 {
+			t.Fatalf("Expected one exceedance for a sustained 2 g turn, got %v", exceedances)
+		}
+		assertEqual(t, exceedances[0].Limit, LimitNzMax)
+		assertApproxEqual(t, exceedances[0].Time, turn.Time+dt, 1e-9)
+		assertApproxEqual(t, exceedances[0].Value, 2.0, 0.02)
+	})
+	
+	t.Run("Terminate", func(t *testing.T) {
+		engine := NewSimplifiedFlightDynamicsEngine(NewEulerIntegrator())
+		terminating := *limits
+		terminating.Terminate = true
+		engine.Limits = &terminating
+		
+		_, err := engine.Step(turn, dt)
+		var limitErr *StructuralLimitError
+		if !errors.As(err, &limitErr) {
+			t.Fatalf("Expected a structural limit error, got %v", err)
+		}
+		assertEqual(t, limitErr.Exceedance.Limit, LimitNzMax)
+	})
+}
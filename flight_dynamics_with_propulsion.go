@@ -26,8 +26,11 @@ func NewFlightDynamicsEngineWithPropulsion(config *JSBSimConfig, useRealisticFCS
 		return nil, fmt.Errorf("failed to create FCS engine: %v", err)
 	}
 	
-	// Create propulsion system
+	// Create propulsion system, mounted where the configuration places it
 	propulsion := NewPropulsionSystem()
+	if config != nil && config.Propulsion != nil && len(config.Propulsion.Engine) > 0 {
+		propulsion.Position, _ = configThrustLine(config)
+	}
 	
 	// Initialize fuel weight tracking
 	initialFuelWeight := propulsion.FuelSystem.TotalContents
@@ -51,7 +54,12 @@ func (engine *FlightDynamicsEngineWithPropulsion) RunSimulationStepWithPropulsio
 	state *AircraftState, 
 	dt float64) (*AircraftState, *StateDerivatives, error) {
 	
-	// 1. Update propulsion system with throttle input
+	engine.stepping.Lock()
+	defer engine.stepping.Unlock()
+	
+	// 1. Start or shut down the engine as set-running was set, and update
+	// the propulsion system with throttle input
+	engine.Propulsion.ApplyProperties(engine.FCS.Properties)
 	engine.updatePropulsionSystem(state, dt)
 	
 	// 2. Update aircraft weight based on fuel consumption
@@ -61,7 +69,8 @@ func (engine *FlightDynamicsEngineWithPropulsion) RunSimulationStepWithPropulsio
 	engine.updatePropulsionProperties(state)
 	
 	// 4. Run flight control system processing and get base derivatives
-	_, derivatives, err := engine.FlightDynamicsEngineWithFCS.RunSimulationStepWithFCS(state, dt)
+	derivatives, _, err := engine.beginStep(state, dt)
+	defer engine.endStep()
 	if err != nil {
 		return nil, nil, err
 	}
@@ -83,8 +92,8 @@ func (engine *FlightDynamicsEngineWithPropulsion) RunSimulationStepWithPropulsio
 	derivatives.AngularRateDot.Y += propulsionMoments.Y
 	derivatives.AngularRateDot.Z += propulsionMoments.Z
 	
-	// Re-integrate with combined forces (propulsion + aerodynamics)
-	finalState := engine.Integrator.Integrate(state, derivatives, dt)
+	// Integrate with combined forces (propulsion + aerodynamics)
+	finalState := integrateDynamics(engine.Integrator, engine, state, derivatives, dt)
 	
 	// 6. Apply constraints and validate
 	engine.applyConstraints(finalState)
@@ -93,8 +102,9 @@ func (engine *FlightDynamicsEngineWithPropulsion) RunSimulationStepWithPropulsio
 	return finalState, derivatives, nil
 }
 
-// calculateDynamicsAtState calculates state derivatives for a given state (used by true RK4)
-func (engine *FlightDynamicsEngineWithPropulsion) calculateDynamicsAtState(state *AircraftState) (*StateDerivatives, error) {
+// Derivatives calculates the state derivatives at a given state, for the
+// integrator's stages, without advancing the propulsion system
+func (engine *FlightDynamicsEngineWithPropulsion) Derivatives(state *AircraftState) (*StateDerivatives, *ForceMomentComponents, error) {
 	// Create a temporary copy of the propulsion system to avoid state corruption
 	// during intermediate RK4 evaluations
 	tempPropulsion := *engine.Propulsion
@@ -102,19 +112,29 @@ func (engine *FlightDynamicsEngineWithPropulsion) calculateDynamicsAtState(state
 	tempPropeller := *engine.Propulsion.Propeller
 	tempPropulsion.Engine = &tempEngine
 	tempPropulsion.Propeller = &tempPropeller
+	tempFuel := *engine.Propulsion.FuelSystem
+	tempFuel.Tanks = make([]*FuelTank, len(engine.Propulsion.FuelSystem.Tanks))
+	for i, tank := range engine.Propulsion.FuelSystem.Tanks {
+		tempTank := *tank
+		tempFuel.Tanks[i] = &tempTank
+	}
+	tempPropulsion.FuelSystem = &tempFuel
+	tempEngine.Altitude = state.Altitude
+	tempPropeller.Airspeed, tempPropeller.Density = state.TrueAirspeed, state.Density
 	
 	// Update temporary propulsion system for this state
-	tempPropulsion.Update(state.Controls.Throttle, 0.01)
+	if engine.UseRealisticPropulsion {
+		tempPropulsion.UpdateControls(engine.EngineControlPositions(state), 0.01)
+	} else {
+		tempPropulsion.Update(state.Controls.Throttle, 0.01)
+	}
 	
-	// Calculate aerodynamic forces and moments
-	components, err := engine.FlightDynamicsEngine.Calculator.CalculateForcesMoments(state)
+	// Get base derivatives from aerodynamics and the flight controls
+	derivatives, components, err := engine.FlightDynamicsEngineWithFCS.Derivatives(state)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	
-	// Get base derivatives from aerodynamics
-	derivatives := engine.FlightDynamicsEngine.Calculator.CalculateStateDerivatives(state, components)
-	
 	// Calculate propulsion forces using temporary system
 	thrust := tempPropulsion.GetThrust()
 	
@@ -140,22 +160,25 @@ func (engine *FlightDynamicsEngineWithPropulsion) calculateDynamicsAtState(state
 	derivatives.AngularRateDot.Y += propulsionMoments.Y
 	derivatives.AngularRateDot.Z += propulsionMoments.Z
 	
-	return derivatives, nil
+	components.TotalForce = components.TotalForce.Add(propulsionForces)
+	components.TotalMoment = components.TotalMoment.Add(propulsionMoments)
+	
+	return derivatives, components, nil
 }
 
 // updatePropulsionSystem updates the propulsion system based on pilot inputs
 func (engine *FlightDynamicsEngineWithPropulsion) updatePropulsionSystem(state *AircraftState, dt float64) {
-	// Update propulsion system with throttle input
-	throttleInput := state.Controls.Throttle
+	engine.Propulsion.Engine.Altitude = state.Altitude
+	engine.Propulsion.Propeller.Airspeed = state.TrueAirspeed
+	engine.Propulsion.Propeller.Density = state.Density
 	
-	// Apply mixture and propeller controls if available
 	if engine.UseRealisticPropulsion {
-		// In realistic mode, mixture and prop pitch affect engine performance
-		// For now, keep it simple but extensible
-		engine.Propulsion.Update(throttleInput, dt)
+		// In realistic mode, the engine takes the lever positions as the FCS
+		// shaped them on the last step, and mixture and prop pitch matter
+		engine.Propulsion.UpdateControls(engine.EngineControlPositions(state), dt)
 	} else {
 		// Simple mode: just throttle
-		engine.Propulsion.Update(throttleInput, dt)
+		engine.Propulsion.Update(state.Controls.Throttle, dt)
 	}
 }
 
@@ -184,7 +207,7 @@ func (engine *FlightDynamicsEngineWithPropulsion) updatePropulsionProperties(sta
 	if propInducedVel > 0 {
 		// Calculate thrust-enhanced dynamic pressure (from JSBSim XML line 1325)
 		rho := state.Density // Use atmospheric density from state
-		qbar := 0.5 * rho * (state.Velocity.X*state.Velocity.X + state.Velocity.Y*state.Velocity.Y + state.Velocity.Z*state.Velocity.Z)
+		qbar := state.DynamicPressure
 		
 		// Add propeller slipstream effect (simplified)
 		propQbar := 0.5 * rho * (propInducedVel*0.3048)*(propInducedVel*0.3048) // Convert fps to m/s
@@ -210,23 +233,11 @@ func (engine *FlightDynamicsEngineWithPropulsion) calculatePropulsionForces(stat
 	// Get thrust in Newtons
 	thrust := engine.Propulsion.GetThrust()
 	
-	// Apply thrust along aircraft X-axis (forward) accounting for engine orientation
-	// Engine orientation from XML: Roll: -4.0°, Pitch: 2.5°, Yaw: 0°
-	engineRoll := engine.Propulsion.Orientation.X   // -4.0°
-	enginePitch := engine.Propulsion.Orientation.Y  // 2.5°
-	engineYaw := engine.Propulsion.Orientation.Z    // 0°
-	
-	// Transform thrust vector by engine orientation
-	// Simplified: assume thrust is primarily along X-axis with small pitch/roll components
-	thrustX := thrust * math.Cos(enginePitch) * math.Cos(engineYaw)
-	thrustY := thrust * math.Sin(engineRoll) * math.Cos(enginePitch) // Small roll component
-	thrustZ := thrust * math.Sin(enginePitch)                        // Small pitch component
-	
-	return Vector3{
-		X: thrustX,  // Forward thrust (primary)
-		Y: thrustY,  // Side thrust (from engine roll)
-		Z: thrustZ,  // Vertical thrust (from engine pitch)
-	}
+	// Apply thrust along the engine's axis: pitched up 2.5°, the thrust
+	// tilts up (negative Z); the -4.0° roll about the axis does not move it
+	orientation := engine.Propulsion.Orientation
+	axis := OrientAxis(&Orient{Roll: orientation.X, Pitch: orientation.Y, Yaw: orientation.Z})
+	return axis.Scale(thrust)
 }
 
 // calculatePropulsionMoments calculates propulsion moments about CG
@@ -234,12 +245,8 @@ func (engine *FlightDynamicsEngineWithPropulsion) calculatePropulsionMoments(sta
 	// Get engine torque
 	torque := engine.Propulsion.GetTorque()
 	
-	// Engine position relative to CG (from XML: 36" = 0.9144m forward of datum)
-	enginePos := engine.Propulsion.Position
-	
-	// Calculate moment arm from engine to CG
-	// Assume CG is at aircraft datum for simplicity
-	momentArm := enginePos
+	// Engine position in body axes about the CG
+	momentArm := engine.Propulsion.Position
 	
 	// Thrust forces
 	thrustForces := engine.calculatePropulsionForces(state)
@@ -0,0 +1,140 @@
+// Flight Data Recorder
+// Keeps the state history of a run in memory within a bounded number of
+// samples, optionally streaming every state to an output as well
+
+package main
+
+import (
+	"unsafe"
+)
+
+// FlightRecorder records the states of a simulation. Without a limit it
+// keeps every state. With MaxSamples set it holds at most that many: by
+// default as a ring buffer of the latest, or with Decimation set by
+// thinning the whole history each time the buffer fills, so the samples
+// span the run at a coarser and coarser interval.
+type FlightRecorder struct {
+	MaxSamples int // Samples held in memory; unbounded when 0
+
+	// Decimation, when 2 or more, makes a full buffer keep every
+	// Decimation'th sample and record only every Decimation'th state from
+	// then on, instead of dropping the oldest sample
+	Decimation int
+
+	// Stream, when set, is given every state recorded, so the full history
+	// goes to disk while memory holds only the buffer
+	Stream *OutputManager
+
+	Recorded int // States given to the recorder
+	Dropped  int // States recorded and later dropped, or skipped by decimation
+
+	samples []TrajectorySample
+	start   int // Index of the oldest sample once the ring has wrapped
+	stride  int // States between samples under decimation
+	skip    int // States still to skip before the next sample
+}
+
+// NewFlightRecorder creates a recorder holding at most maxSamples states,
+// or every state when maxSamples is 0
+func NewFlightRecorder(maxSamples int) *FlightRecorder {
+	return &FlightRecorder{MaxSamples: maxSamples}
+}
+
+// Attach makes the recorder record every state evaluated by the bus,
+// ignoring stream errors; call Record directly to see them
+func (fr *FlightRecorder) Attach(bus *EventBus) *EventWatcher {
+	return bus.OnStep(func(state *AircraftState, _ float64) { fr.Record(state) })
+}
+
+// Record takes the next state of the simulation, returning any error from
+// writing it to the stream. The state is copied.
+func (fr *FlightRecorder) Record(state *AircraftState) error {
+	fr.Recorded++
+	var err error
+	if fr.Stream != nil {
+		err = fr.Stream.Record(state)
+	}
+
+	if fr.skip > 0 {
+		fr.skip--
+		fr.Dropped++
+		return err
+	}
+	if fr.MaxSamples > 0 && len(fr.samples) >= fr.MaxSamples {
+		if fr.Decimation < 2 {
+			// Overwrite the oldest sample, reusing its state
+			oldest := &fr.samples[fr.start]
+			*oldest.State = *state
+			oldest.Time = state.Time
+			fr.start = (fr.start + 1) % len(fr.samples)
+			fr.Dropped++
+			return err
+		}
+		if wait := fr.decimate(); wait > 0 {
+			fr.skip = wait - 1
+			fr.Dropped++
+			return err
+		}
+	}
+	fr.samples = append(fr.samples, TrajectorySample{Time: state.Time, State: state.Copy()})
+	if fr.stride > 1 {
+		fr.skip = fr.stride - 1
+	}
+	return err
+}
+
+// decimate keeps every Decimation'th sample of the buffer and widens the
+// interval between samples to match, returning the number of states from
+// the current one to the next to be recorded
+func (fr *FlightRecorder) decimate() int {
+	if fr.stride == 0 {
+		fr.stride = 1
+	}
+	n := len(fr.samples)
+	last := (n - 1) / fr.Decimation * fr.Decimation // Last sample kept
+	kept := fr.samples[:0]
+	for i, sample := range fr.samples {
+		if i%fr.Decimation == 0 {
+			kept = append(kept, sample)
+		} else {
+			fr.Dropped++
+		}
+	}
+	clear(fr.samples[len(kept):])
+	fr.samples = kept
+
+	// The current state lies n-last old intervals after the last sample kept
+	wait := (fr.Decimation - (n - last)) * fr.stride
+	fr.stride *= fr.Decimation
+	return wait
+}
+
+// Len returns the number of samples held
+func (fr *FlightRecorder) Len() int {
+	return len(fr.samples)
+}
+
+// Samples returns the samples held, oldest first
+func (fr *FlightRecorder) Samples() []TrajectorySample {
+	samples := make([]TrajectorySample, 0, len(fr.samples))
+	samples = append(samples, fr.samples[fr.start:]...)
+	return append(samples, fr.samples[:fr.start]...)
+}
+
+// Interpolator returns an interpolator over the samples held
+func (fr *FlightRecorder) Interpolator() (*TrajectoryInterpolator, error) {
+	return NewTrajectoryInterpolator(fr.Samples())
+}
+
+// Reset drops the samples held and the counts, keeping the settings
+func (fr *FlightRecorder) Reset() {
+	fr.samples = nil
+	fr.start, fr.stride, fr.skip = 0, 0, 0
+	fr.Recorded, fr.Dropped = 0, 0
+}
+
+// RetainedBytes estimates the memory held by the samples
+func (fr *FlightRecorder) RetainedBytes() int64 {
+	perSample := unsafe.Sizeof(TrajectorySample{}) + unsafe.Sizeof(AircraftState{})
+	return int64(cap(fr.samples)) * int64(perSample)
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+)
+
+// recordStates gives a recorder n states, one per second of simulation time
+func recordStates(t *testing.T, fr *FlightRecorder, n int) {
+	t.Helper()
+	state := NewAircraftState()
+	for i := 0; i < n; i++ {
+		state.Time = float64(i)
+		state.Altitude = float64(i)
+		if err := fr.Record(state); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+}
+
+func TestFlightRecorder(t *testing.T) {
+	t.Run("Unbounded", func(t *testing.T) {
+		fr := NewFlightRecorder(0)
+		recordStates(t, fr, 50)
+		assertEqual(t, fr.Len(), 50)
+		assertEqual(t, fr.Dropped, 0)
+	})
+
+	t.Run("Ring Buffer", func(t *testing.T) {
+		fr := NewFlightRecorder(10)
+		recordStates(t, fr, 25)
+		assertEqual(t, fr.Len(), 10)
+		assertEqual(t, fr.Recorded, 25)
+		assertEqual(t, fr.Dropped, 15)
+
+		// The latest ten, oldest first, each keeping its own state
+		samples := fr.Samples()
+		for i, sample := range samples {
+			assertEqual(t, sample.Time, float64(15+i))
+			assertEqual(t, sample.State.Altitude, float64(15+i))
+		}
+
+		size := fr.RetainedBytes()
+		recordStates(t, fr, 100)
+		assertEqual(t, fr.RetainedBytes(), size)
+	})
+
+	t.Run("Decimation", func(t *testing.T) {
+		for _, c := range []struct{ max, decimation int }{{8, 2}, {9, 2}, {8, 3}, {1, 2}} {
+			fr := NewFlightRecorder(c.max)
+			fr.Decimation = c.decimation
+			recordStates(t, fr, 1000)
+			samples := fr.Samples()
+			if len(samples) == 0 || len(samples) > c.max {
+				t.Fatalf("%d samples with decimation %d: got %d", c.max, c.decimation, len(samples))
+			}
+			assertEqual(t, fr.Recorded, 1000)
+			assertEqual(t, fr.Dropped+fr.Len(), 1000)
+
+			// The samples start at the first state and stay evenly spaced,
+			// the latest within one interval of the end
+			assertEqual(t, samples[0].Time, 0.0)
+			interval := float64(fr.stride)
+			for i := 1; i < len(samples); i++ {
+				if samples[i].Time-samples[i-1].Time != interval {
+					t.Fatalf("%d samples with decimation %d: uneven spacing %v", c.max, c.decimation, samples)
+				}
+			}
+			if last := samples[len(samples)-1].Time; last <= 999-interval {
+				t.Errorf("%d samples with decimation %d: last sample at %g, interval %g", c.max, c.decimation, last, interval)
+			}
+		}
+	})
+
+	t.Run("Stream", func(t *testing.T) {
+		var buf bytes.Buffer
+		om, err := NewOutputManager(parseOutputFixture(t), 1, &buf)
+		if err != nil {
+			t.Fatalf("NewOutputManager: %v", err)
+		}
+		om.Period = 0
+
+		fr := NewFlightRecorder(5)
+		fr.Stream = om
+		recordStates(t, fr, 40)
+		if err := om.Flush(); err != nil {
+			t.Fatalf("Flush: %v", err)
+		}
+		records, err := csv.NewReader(&buf).ReadAll()
+		if err != nil {
+			t.Fatalf("Reading the stream: %v", err)
+		}
+
+		// Every state reaches the stream while memory holds five
+		assertEqual(t, len(records), 41)
+		assertEqual(t, fr.Len(), 5)
+	})
+
+	t.Run("Engine", func(t *testing.T) {
+		engine := NewSimplifiedFlightDynamicsEngine(NewRungeKutta4Integrator())
+		state := trimLevelFlight(t, engine, 1000, 100)
+		fr := NewFlightRecorder(20)
+		fr.Attach(engine.Events)
+		for i := 0; i < 50; i++ {
+			next, err := engine.Step(state, 0.01)
+			if err != nil {
+				t.Fatalf("Step %d: %v", i, err)
+			}
+			state = next
+		}
+		ti, err := fr.Interpolator()
+		if err != nil {
+			t.Fatalf("Interpolator: %v", err)
+		}
+		start, end := ti.Span()
+		assertApproxEqual(t, start, state.Time-0.19, 1e-9)
+		assertApproxEqual(t, end, state.Time, 1e-9)
+	})
+}
@@ -41,20 +41,21 @@ func FlightSimulatorDemo() {
 		}
 	}
 	
+	// Parsed metrics and weights are in feet and pounds
 	if config.Metrics != nil {
 		if config.Metrics.WingSpan != nil {
-			fmt.Printf("   Wing Span: %.1f ft\n", config.Metrics.WingSpan.Value*M_TO_FT)
+			fmt.Printf("   Wing Span: %.1f ft\n", config.Metrics.WingSpan.Value)
 		}
 		if config.Metrics.WingArea != nil {
-			fmt.Printf("   Wing Area: %.1f ft²\n", config.Metrics.WingArea.Value*M2_TO_FT2)
+			fmt.Printf("   Wing Area: %.1f ft²\n", config.Metrics.WingArea.Value)
 		}
 		if config.Metrics.Chord != nil {
-			fmt.Printf("   Wing Chord: %.2f ft\n", config.Metrics.Chord.Value*M_TO_FT)
+			fmt.Printf("   Wing Chord: %.2f ft\n", config.Metrics.Chord.Value)
 		}
 	}
 	
 	if config.MassBalance != nil && config.MassBalance.EmptyMass != nil {
-		fmt.Printf("   Empty Weight: %.0f lbs\n", config.MassBalance.EmptyMass.Value*KG_TO_LB)
+		fmt.Printf("   Empty Weight: %.0f lbs\n", config.MassBalance.EmptyMass.Value)
 	}
 	
 	// Create initial aircraft state
@@ -64,8 +65,7 @@ func FlightSimulatorDemo() {
 	// Set initial conditions for cruise flight
 	state.Altitude = 5000.0 * FT_TO_M  // 5000 ft
 	state.Velocity = Vector3{X: 150.0, Y: 0.0, Z: 0.0}  // 150 m/s (~290 knots)
-	state.UpdateAtmosphere()
-	state.UpdateDerivedParameters()
+	state.UpdateFlightConditions()
 	
 	fmt.Printf("   Initial State: %s\n", state.String())
 	
@@ -146,7 +146,7 @@ func FlightSimulatorDemo() {
 					break
 				}
 				
-				if function.Product != nil && function.Product.Table != nil {
+				if function.Product != nil && len(function.Product.Tables) > 0 {
 					// Try to evaluate this function
 					result, err := EvaluateFunction(function, properties)
 					if err == nil {
@@ -223,8 +223,7 @@ func FlightSimulatorDemo() {
 	
 	testState := state.Copy()
 	for i := 0; i < iterations; i++ {
-		testState.UpdateAtmosphere()
-		testState.UpdateDerivedParameters()
+		testState.UpdateFlightConditions()
 		_ = testState.ToPropertyMap()
 	}
 	
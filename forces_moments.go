@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"math"
+	"sync"
 )
 
 // ForcesMomentsCalculator computes forces and moments acting on the aircraft
@@ -10,8 +11,66 @@ type ForcesMomentsCalculator struct {
 	Config       *JSBSimConfig
 	Mass         float64  // Aircraft mass in kg
 	Inertia      Matrix3  // Moment of inertia tensor
-	CG           Vector3  // Center of gravity position
+	CG           Vector3  // Structural position of the CG (m), the body frame's origin
 	Reference    ReferenceData // Reference dimensions
+	Geometry     DerivedGeometry // Aspect ratio, tail volumes and static margin
+	Propeller    *Propeller    // Propeller of the first engine
+	
+	// Points the aerodynamic forces and the thrust act at, in body axes
+	// about the CG (m), and the line of thrust
+	AeroReference  Vector3 // AERORP
+	ThrustLocation Vector3
+	ThrustAxis     Vector3 // Body X when zero
+	
+	// Optional propeller moments, for comparison studies
+	PropellerEffects PropellerEffects
+	
+	// Optional hinge moment limits on the surface deflections; the surfaces
+	// reach their commanded positions when nil
+	Blowback *ControlBlowback
+	
+	// Optional; when set, each axis function's contribution is recorded in
+	// the breakdown with its source in the XML
+	Trace bool
+	
+	// Optional; when set, the axis functions are evaluated strictly and
+	// those reading a property that is not set are replaced by the
+	// simplified model's estimates, reported in the breakdown's Blend
+	Hybrid bool
+	
+	// Point forces such as a tow rope, added to the totals
+	External *ExternalForces
+	
+	// Optional propeller slipstream over the tail; power changes no trim
+	// through the tail when nil
+	Slipstream *Slipstream
+	
+	// Spinning parts whose angular momentum the body rates precess; the
+	// propeller unless more are added. No gyroscopic moment when nil.
+	Rotating *RotatingMasses
+	
+	// Optional mass of the control surfaces, set with SetControlSurfaceMass;
+	// their moments are not modelled when nil
+	SurfaceMass *ControlSurfaceMass
+	
+	// Estimated aerodynamics flown in place of the configuration's axis
+	// functions. Set when the configuration has no aerodynamics section;
+	// may be set to fly the estimate regardless.
+	FallbackAero *FallbackAero
+	
+	// Optional dynamic stall correction to the tables' lift and pitching
+	// moment, with its lag states; off when nil
+	DynamicStall *DynamicStall
+	
+	// Property tree the functions read from and write their outputs to.
+	// It persists between steps and may be shared with an FCS.
+	Properties   *PropertyManager
+	
+	functionInputs map[*Function][]string // Properties each axis function reads, for Hybrid
+	published      map[string]bool        // Properties published for Hybrid, refreshed each step
+	loggedBlend    string                 // Last hybrid mix logged
+	
+	surfaceMotionHeld bool // Set while evaluating outside a step's start; holds the surface motion and dynamic stall
 }
 
 // Matrix3 represents a 3x3 matrix for inertia tensor
@@ -29,6 +88,17 @@ type ReferenceData struct {
 	EmptyMass  float64 // Empty mass in kg
 }
 
+// PropellerEffects selects the propeller moments added to the aerodynamic
+// moments and the propeller torque
+type PropellerEffects struct {
+	Gyroscopic bool // Precession of the propeller's angular momentum, its RotatingMasses entry
+	PFactor    bool // Asymmetric blade loading at angle of attack
+}
+
+// defaultPropellerRPM is the propeller speed used for torque and gyroscopic
+// moments, the model having no propeller governor
+const defaultPropellerRPM = 2700.0
+
 // ForceMomentComponents represents the complete force and moment breakdown
 type ForceMomentComponents struct {
 	// Forces in body frame (N)
@@ -36,15 +106,54 @@ type ForceMomentComponents struct {
 		Lift  float64 // Z-axis (negative for lift in NED)
 		Drag  float64 // X-axis (negative for drag)
 		Side  float64 // Y-axis
+		
+		// The forces are the fallback model's estimate, not the
+		// configuration's aerodynamic data
+		Fallback bool
 	}
 	
 	Propulsion struct {
 		Thrust float64 // X-axis (positive forward)
 		Torque float64 // Propeller torque about X-axis
+		Moment Vector3 // Gyroscopic and propeller P-factor moments
+	}
+	
+	// Precession of the rotating masses, included in Propulsion.Moment
+	Gyroscopic struct {
+		Momentum Vector3 // Total angular momentum H in body axes (kg·m²/s)
+		Moment   Vector3 // H × ω (N·m)
 	}
 	
 	Gravity struct {
 		Weight Vector3 // Gravitational force in body frame
+		Moment Vector3 // Of the weight at a lateral CG offset, about the body origin (N·m)
+	}
+	
+	// Ground reaction from the landing gear, zero when airborne
+	Ground struct {
+		Force  Vector3 // Body frame (N)
+		Moment Vector3 // About the CG (N·m)
+	}
+	
+	// External point forces such as a tow rope, zero without any. Each
+	// force's own contribution is given by ExternalForces.Reactions.
+	External struct {
+		Force  Vector3 // Body frame (N)
+		Moment Vector3 // About the CG (N·m)
+	}
+	
+	// Dynamic stall increments over the tables' coefficients, included in
+	// the aerodynamic forces and Moments; zero without a dynamic stall model
+	DynamicStall struct {
+		CL          float64
+		Cm          float64
+		PitchMoment float64 // N·m
+	}
+	
+	// Reaction to driving the control surfaces, included in Moments; zero
+	// without a surface mass model
+	SurfaceMass struct {
+		Moment Vector3 // About the CG (N·m)
 	}
 	
 	// Moments about body axes (N·m)
@@ -57,13 +166,120 @@ type ForceMomentComponents struct {
 	// Totals
 	TotalForce  Vector3 // Sum of all forces
 	TotalMoment Vector3 // Sum of all moments
+	
+	// Moments the slipstream added (N·m), zero without a slipstream model
+	Slipstream struct {
+		QRatio float64 // Dynamic pressure increase over the free stream's
+		Pitch  float64 // From the horizontal tail's terms
+		Yaw    float64 // From the vertical tail's terms and the swirl
+	}
+	
+	// Each aerodynamic axis function's contribution, when the calculator
+	// traces; nil otherwise
+	Trace *FunctionTrace
+	
+	// Where each aerodynamic axis function's contribution came from, when
+	// the calculator is hybrid; nil otherwise
+	Blend *BlendReport
+	
+	// Forces resolved along the velocity vector (N), for energy analysis
+	FlightPath struct {
+		Thrust float64 // Thrust component along the velocity
+		Drag   float64 // Aerodynamic force opposing the velocity
+	}
+	
+	// The aerodynamic force in wind axes (N), lift and drag perpendicular
+	// and opposite to the relative wind
+	Wind struct {
+		Lift float64 // Positive up, in the plane of symmetry
+		Drag float64 // Positive opposing the velocity
+		Side float64 // Positive to the right
+	}
+}
+
+// StandardGravity is the gravitational acceleration used for weight (m/s²)
+const StandardGravity = 9.81
+
+// resolveFlightPath projects thrust and the aerodynamic force onto the
+// velocity vector, and resolves the aerodynamic force into the wind axes
+func (components *ForceMomentComponents) resolveFlightPath(velocity Vector3) {
+	direction := velocity.Normalize()
+	aero := components.aerodynamicForce()
+	components.FlightPath.Thrust = components.Propulsion.Thrust * direction.X
+	components.FlightPath.Drag = -aero.Dot(direction)
+	
+	alpha, beta := AirflowAngles(velocity)
+	wind := BodyToWind(aero, alpha, beta)
+	components.Wind.Lift, components.Wind.Drag, components.Wind.Side = -wind.Z, -wind.X, wind.Y
+}
+
+// aerodynamicForce returns the aerodynamic force in the body frame (N)
+func (components *ForceMomentComponents) aerodynamicForce() Vector3 {
+	return Vector3{
+		X: components.Aerodynamic.Drag,
+		Y: components.Aerodynamic.Side,
+		Z: components.Aerodynamic.Lift,
+	}
+}
+
+// LoadFactor returns the body-axis load factors (nx, ny, nz) in g: the
+// aerodynamic, propulsive, ground reaction and external force per unit weight,
+// excluding gravity. nz is positive up, so it is 1 in level flight and 2
+// in a 60° level turn.
+func (components *ForceMomentComponents) LoadFactor(mass float64) Vector3 {
+	weight := mass * StandardGravity
+	if weight <= 0 {
+		return Vector3{}
+	}
+	return Vector3{
+		X: (components.Aerodynamic.Drag + components.Propulsion.Thrust + components.Ground.Force.X + components.External.Force.X) / weight,
+		Y: (components.Aerodynamic.Side + components.Ground.Force.Y + components.External.Force.Y) / weight,
+		Z: -(components.Aerodynamic.Lift + components.Ground.Force.Z + components.External.Force.Z) / weight,
+	}
+}
+
+// addGroundReaction adds the landing gear's ground reaction at a state to
+// the totals
+func (components *ForceMomentComponents) addGroundReaction(gear *LandingGear, state *AircraftState) {
+	if gear == nil {
+		return
+	}
+	components.Ground.Force, components.Ground.Moment = gear.Forces(state)
+	components.TotalForce = components.TotalForce.Add(components.Ground.Force)
+	components.TotalMoment = components.TotalMoment.Add(components.Ground.Moment)
+}
+
+// SpecificExcessPower returns Ps = (T−D)·V/W in m/s, the rate at which the
+// aircraft can gain energy height, for an aircraft of the given mass (kg)
+// flying at airspeed (m/s)
+func (components *ForceMomentComponents) SpecificExcessPower(airspeed, mass float64) float64 {
+	weight := mass * StandardGravity
+	if weight <= 0 {
+		return 0
+	}
+	return (components.FlightPath.Thrust - components.FlightPath.Drag) * airspeed / weight
 }
 
 // NewForcesMomentsCalculator creates a new calculator from JSBSim config
 func NewForcesMomentsCalculator(config *JSBSimConfig) *ForcesMomentsCalculator {
 	calc := &ForcesMomentsCalculator{
-		Config: config,
+		Config:           config,
+		Propeller:        newConfigPropeller(config),
+		PropellerEffects: PropellerEffects{Gyroscopic: true, PFactor: true},
+		Properties:       newEmptyPropertyManager(),
+		Geometry:         NewDerivedGeometry(config),
+		FallbackAero:     newConfigFallbackAero(config),
+	}
+	
+	var cg *Location
+	if config.MassBalance != nil {
+		cg = config.MassBalance.Location
 	}
+	calc.External = NewExternalForces(cg)
+	calc.Rotating = newConfigRotatingMasses(calc.Propeller)
+	calc.CG = configCG(config)
+	calc.AeroReference = configAeroReference(config)
+	calc.ThrustLocation, calc.ThrustAxis = configThrustLine(config)
 	
 	// Extract reference data from config
 	if config.Metrics != nil {
@@ -96,84 +312,325 @@ func NewForcesMomentsCalculator(config *JSBSimConfig) *ForcesMomentsCalculator {
 	return calc
 }
 
+// configAeroReference returns the AERORP in body axes about the CG (m), or
+// the CG itself when the configuration has none
+func configAeroReference(config *JSBSimConfig) Vector3 {
+	if config == nil || config.Metrics == nil {
+		return Vector3{}
+	}
+	if aero := metricsLocation(config.Metrics, "AERORP"); aero != nil {
+		return StructuralToBody(aero, configCG(config))
+	}
+	return Vector3{}
+}
+
+// configThrustLine returns the body-axis location about the CG (m) and the
+// direction of the first engine's thrust, from its thruster or else the
+// engine. Without either it is the body X axis through the CG.
+func configThrustLine(config *JSBSimConfig) (location, axis Vector3) {
+	if config == nil || config.Propulsion == nil || len(config.Propulsion.Engine) == 0 {
+		return Vector3{}, OrientAxis(nil)
+	}
+	engine := config.Propulsion.Engine[0]
+	loc, orient := engine.Location, engine.Orient
+	if thruster := engine.Thruster; thruster != nil {
+		if thruster.Location != nil {
+			loc = thruster.Location
+		}
+		if thruster.Orient != nil {
+			orient = thruster.Orient
+		}
+	}
+	if loc != nil {
+		location = StructuralToBody(loc, configCG(config))
+	}
+	return location, OrientAxis(orient)
+}
+
+// newConfigPropeller returns the propeller of the first engine's thruster,
+// with the P-51D values for anything the configuration does not give
+func newConfigPropeller(config *JSBSimConfig) *Propeller {
+	prop := &Propeller{
+		RPM:     defaultPropellerRPM,
+		Inertia: P51DPropellerInertia,
+		Sense:   P51DPropellerSense,
+		PFactor: P51DPropellerPFactor,
+	}
+	if config.Propulsion == nil || len(config.Propulsion.Engine) == 0 || config.Propulsion.Engine[0].Thruster == nil {
+		return prop
+	}
+	
+	thruster := config.Propulsion.Engine[0].Thruster
+	prop.Name = thruster.File
+	if thruster.Sense != 0 {
+		prop.Sense = math.Copysign(1, thruster.Sense)
+	}
+	if thruster.PFactor != 0 {
+		prop.PFactor = thruster.PFactor
+	}
+	return prop
+}
+
 // CalculateForcesMoments computes all forces and moments acting on the aircraft
 func (calc *ForcesMomentsCalculator) CalculateForcesMoments(state *AircraftState) (*ForceMomentComponents, error) {
+	return calc.calculateForcesMoments(state, nil)
+}
+
+// calculateForcesMoments computes the forces and moments in the per-step
+// property order: the state is synced into the property tree, the
+// standalone aerodynamics functions are evaluated, fcs (when not nil) runs
+// on the result, the surface blowback limits are applied, and the axis
+// functions then give the forces and moments.
+// The FCS thereby sees this step's air data and derived aero values, and
+// the axis functions see this step's FCS outputs.
+func (calc *ForcesMomentsCalculator) calculateForcesMoments(state *AircraftState, fcs func()) (*ForceMomentComponents, error) {
 	components := &ForceMomentComponents{}
+	if calc.Trace {
+		components.Trace = &FunctionTrace{}
+	}
+	hybrid := calc.Hybrid && calc.FallbackAero == nil && calc.Config.Aerodynamics != nil
+	if hybrid {
+		components.Blend = &BlendReport{}
+	}
+	if calc.Properties == nil {
+		calc.Properties = newEmptyPropertyManager()
+	}
 	
-	// Get property map for function evaluation
-	properties := state.ToPropertyMap()
-	
-	// Calculate aerodynamic forces
-	err := calc.calculateAerodynamicForces(state, properties, components)
+	calc.Properties.syncState(state)
+	err := calc.Properties.evaluate(func(properties map[string]float64) error {
+		calc.clearStandaloneFunctions(properties)
+		calc.addGroundEffectProperties(state, properties)
+		calc.addMassProperties(properties)
+		calc.Geometry.FillPropertyMap(properties)
+		if hybrid {
+			calc.addHybridProperties(state, properties)
+		}
+		return calc.evaluateStandaloneFunctions(properties)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("aerodynamic forces calculation failed: %v", err)
+		return nil, calc.nonFiniteError(state, "aerodynamic forces", err)
 	}
 	
-	// Calculate propulsive forces
-	calc.calculatePropulsiveForces(state, properties, components)
-	
-	// Calculate gravitational forces
-	calc.calculateGravitationalForces(state, components)
+	if fcs != nil {
+		fcs()
+	}
 	
-	// Calculate moments
-	err = calc.calculateMoments(state, properties, components)
+	err = calc.Properties.evaluate(func(properties map[string]float64) error {
+		// Surfaces blow back from the positions the FCS commands
+		if calc.Blowback != nil {
+			calc.Blowback.apply(properties, state.DynamicPressure)
+		}
+		
+		// Calculate aerodynamic forces
+		err := calc.calculateAerodynamicForces(state, properties, components)
+		if err != nil {
+			if isNonFiniteInput(err) {
+				return calc.nonFiniteError(state, "aerodynamic forces", err)
+			}
+			return fmt.Errorf("aerodynamic forces calculation failed: %v", err)
+		}
+		calc.addDynamicStall(state, properties, components)
+		
+		// Calculate propulsive forces
+		calc.calculatePropulsiveForces(state, properties, components)
+		
+		// Calculate gravitational forces
+		calc.calculateGravitationalForces(state, components)
+		
+		// Calculate moments
+		err = calc.calculateMoments(state, properties, components)
+		if err != nil {
+			if isNonFiniteInput(err) {
+				return calc.nonFiniteError(state, "moments", err)
+			}
+			return fmt.Errorf("moments calculation failed: %v", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("moments calculation failed: %v", err)
+		return nil, err
 	}
 	
 	// Sum total forces and moments
 	calc.sumTotalForcesMoments(components)
+	components.addExternalForces(calc.External, state, calc.Properties.GetSafe)
+	components.resolveFlightPath(state.Velocity)
+	if hybrid {
+		calc.logBlend(components.Blend)
+	}
 	
 	return components, nil
 }
 
+// nonFiniteError turns a NaN or infinite function input into one error that
+// names the state property it originated from. The input a function saw is
+// often derived (alpha from the body velocities), so the state is checked
+// first and its offending field, when there is one, is reported.
+func (calc *ForcesMomentsCalculator) nonFiniteError(state *AircraftState, stage string, err error) error {
+	if origin := state.Validate(); origin != nil {
+		return fmt.Errorf("%s calculation failed: %w (detected in %v)", stage, origin, err)
+	}
+	return fmt.Errorf("%s calculation failed: %w", stage, err)
+}
+
+// addGroundEffectProperties publishes the height-to-span ratios used by
+// ground effect tables
+func (calc *ForcesMomentsCalculator) addGroundEffectProperties(state *AircraftState, properties map[string]float64) {
+	if calc.Reference.WingSpan <= 0 {
+		return
+	}
+	
+	// Parsed wing span is in feet
+	hb := math.Max(state.Altitude-state.Gear.GroundHeight, 0) * M_TO_FT / calc.Reference.WingSpan
+	properties["aero/h_b-cg-ft"] = hb
+	properties["aero/h_b-mac-ft"] = hb
+}
+
+// evaluateStandaloneFunctions evaluates the aerodynamics functions declared
+// outside any axis, in declaration order, storing each result under the
+// function's name so later functions and the axis coefficients can read it.
+// Only non-finite inputs are reported as errors.
+func (calc *ForcesMomentsCalculator) evaluateStandaloneFunctions(properties map[string]float64) error {
+	if calc.Config.Aerodynamics == nil {
+		return nil
+	}
+	for _, function := range calc.Config.Aerodynamics.Function {
+		value, err := EvaluateFunction(function, properties)
+		if err == nil {
+			properties[function.Name] = value
+		} else if isNonFiniteInput(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// clearStandaloneFunctions removes the last step's values of the
+// standalone functions from the persistent property tree before anything
+// this step is published, so a function that fails to evaluate leaves the
+// value published in its place, or none, rather than a stale one
+func (calc *ForcesMomentsCalculator) clearStandaloneFunctions(properties map[string]float64) {
+	if calc.Config.Aerodynamics == nil {
+		return
+	}
+	for _, function := range calc.Config.Aerodynamics.Function {
+		delete(properties, function.Name)
+	}
+}
+
+// clearFunctionOutput removes the value of an axis function that failed to
+// evaluate, so the persistent property tree does not hand the last step's
+// value to the functions and FCS reading it
+func clearFunctionOutput(function *Function, properties map[string]float64) {
+	if function.Name != "" {
+		delete(properties, function.Name)
+	}
+}
+
 // calculateAerodynamicForces computes lift, drag, and side forces
 func (calc *ForcesMomentsCalculator) calculateAerodynamicForces(state *AircraftState, properties map[string]float64, components *ForceMomentComponents) error {
+	if calc.FallbackAero != nil {
+		calc.FallbackAero.forces(state, calc.Reference.WingArea, components)
+		return nil
+	}
 	if calc.Config.Aerodynamics == nil {
 		return fmt.Errorf("no aerodynamics configuration")
 	}
 	
-	// Initialize force totals (JSBSim functions return forces in pounds, not coefficients)
+	// Initialize force totals in Newtons
 	var liftForce, dragForce, sideForce float64
 	
-	// Evaluate aerodynamic force functions
+	// Evaluate aerodynamic force functions. Without a declared unit JSBSim
+	// functions return forces in pounds, not coefficients.
 	for _, axis := range calc.Config.Aerodynamics.Axis {
+		var total *float64
 		switch axis.Name {
 		case "LIFT":
-			for _, function := range axis.Function {
-				force, err := EvaluateFunction(function, properties)
-				if err == nil {
-					liftForce += force // Force in pounds
-				}
-			}
-			
+			total = &liftForce
 		case "DRAG":
-			for _, function := range axis.Function {
-				force, err := EvaluateFunction(function, properties)
-				if err == nil {
-					dragForce += force // Force in pounds
-				}
-			}
-			
+			total = &dragForce
 		case "SIDE":
-			for _, function := range axis.Function {
-				force, err := EvaluateFunction(function, properties)
-				if err == nil {
-					sideForce += force // Force in pounds
+			total = &sideForce
+		default:
+			continue
+		}
+		
+		var trace func(*Function, float64, bool)
+		if calc.Trace {
+			trace = func(f *Function, value float64, si bool) {
+				if !si {
+					value *= LB_TO_N
 				}
+				components.Trace.add(axis.Name, f, value)
 			}
 		}
+		pounds, newtons, err := calc.sumAxis(axis, state, properties, components, trace)
+		if err != nil {
+			return err
+		}
+		*total += pounds*LB_TO_N + newtons
 	}
 	
-	// Convert forces from pounds to Newtons and apply to body frame
-	const LB_TO_N = 4.44822
-	components.Aerodynamic.Lift = -liftForce * LB_TO_N  // Negative Z in NED for positive lift
-	components.Aerodynamic.Drag = -dragForce * LB_TO_N  // Negative X for drag opposing motion
-	components.Aerodynamic.Side = sideForce * LB_TO_N   // Positive Y for right side force
+	// The axes are wind axes, as JSBSim's LIFT, DRAG and SIDE are; rotate
+	// them into the body frame
+	body := WindForcesToBody(state, liftForce, dragForce, sideForce)
+	components.Aerodynamic.Lift = body.Z  // Negative Z in NED for positive lift
+	components.Aerodynamic.Drag = body.X  // Negative X for drag opposing motion
+	components.Aerodynamic.Side = body.Y  // Positive Y for right side force
 	
 	return nil
 }
 
+// sumAxisFunctions evaluates the functions of an aerodynamic axis, summing
+// those without a known declared unit, which follow the axis convention,
+// apart from those declaring one, which EvaluateFunction has converted to SI.
+// Each value is written back under the function's name, as JSBSim
+// publishes its coefficients, and removed when it fails. Only non-finite
+// inputs are reported as errors. trace, when not nil, is given each value
+// and whether it is SI.
+func sumAxisFunctions(axis *Axis, properties map[string]float64, trace func(f *Function, value float64, si bool)) (conventional, si float64, err error) {
+	for _, function := range axis.Function {
+		value, err := EvaluateFunction(function, properties)
+		if err != nil {
+			clearFunctionOutput(function, properties)
+			if isNonFiniteInput(err) {
+				return 0, 0, err
+			}
+			continue
+		}
+		if function.Name != "" {
+			properties[function.Name] = value
+		}
+		if trace != nil {
+			trace(function, value, declaresSIUnit(function))
+		}
+		
+		if declaresSIUnit(function) {
+			si += value
+		} else {
+			conventional += value
+		}
+	}
+	return conventional, si, nil
+}
+
+// declaresSIUnit reports whether a function declares a unit its value is
+// converted to SI from. Functions without a unit, or with one that is not
+// known, are in the conventional units of their axis.
+func declaresSIUnit(f *Function) bool {
+	_, known := functionUnitScale(f.Unit)
+	return f.Unit != "" && known
+}
+
+// sumAxis sums the functions of an aerodynamic axis, strictly with
+// fallback estimates when the breakdown has a blend report
+func (calc *ForcesMomentsCalculator) sumAxis(axis *Axis, state *AircraftState, properties map[string]float64, components *ForceMomentComponents, trace func(f *Function, value float64, si bool)) (conventional, si float64, err error) {
+	if components.Blend != nil {
+		return calc.sumHybridAxis(axis, state, properties, components.Blend, trace)
+	}
+	return sumAxisFunctions(axis, properties, trace)
+}
+
 // calculatePropulsiveForces computes engine thrust and propeller effects
 func (calc *ForcesMomentsCalculator) calculatePropulsiveForces(state *AircraftState, properties map[string]float64, components *ForceMomentComponents) {
 	// Simplified thrust model based on throttle setting
@@ -192,8 +649,7 @@ func (calc *ForcesMomentsCalculator) calculatePropulsiveForces(state *AircraftSt
 	// Propeller torque (simplified)
 	// Torque = Power / Angular_velocity, approximated
 	power := components.Propulsion.Thrust * state.TrueAirspeed / 0.8 // Propeller efficiency ~80%
-	propRPM := 2700.0 // Typical P-51D prop RPM
-	propOmega := propRPM * 2.0 * math.Pi / 60.0 // rad/s
+	propOmega := calc.Propeller.RPM * 2.0 * math.Pi / 60.0 // rad/s
 	
 	if propOmega > 0 {
 		components.Propulsion.Torque = power / propOmega
@@ -205,15 +661,48 @@ func (calc *ForcesMomentsCalculator) calculateGravitationalForces(state *Aircraf
 	// Weight always points down in Earth frame
 	weightEarth := Vector3{X: 0, Y: 0, Z: calc.Mass * 9.81}
 	
-	// Transform to body frame
-	// Need inverse rotation: body = q^-1 * earth * q
-	qInv := Quaternion{W: state.Orientation.W, X: -state.Orientation.X, Y: -state.Orientation.Y, Z: -state.Orientation.Z}
-	components.Gravity.Weight = qInv.RotateVector(weightEarth)
+	// Transform to body frame: body = q^-1 * earth * q
+	components.Gravity.Weight = state.Orientation.RotateVectorInverse(weightEarth)
+	
+	// Acting off the centreline, the weight rolls the aircraft
+	arm := Vector3{Y: calc.lateralCGOffset()}
+	components.Gravity.Moment = arm.Cross(components.Gravity.Weight)
+}
+
+// lateralCGOffset returns how far right of the body frame's origin the
+// loaded CG lies (m), with the point masses, fuel and modelled surfaces.
+// The origin is the configuration's CG, about which the longitudinal
+// balance is tabulated, so only the offset of asymmetric stores or fuel
+// is flown.
+func (calc *ForcesMomentsCalculator) lateralCGOffset() float64 {
+	if calc.Config == nil {
+		return 0
+	}
+	_, cg, ok := calc.massProperties()
+	if !ok {
+		return 0
+	}
+	return cg.Y - calc.CG.Y
+}
+
+// addMassProperties publishes the structural position of the loaded CG
+func (calc *ForcesMomentsCalculator) addMassProperties(properties map[string]float64) {
+	if calc.Config == nil {
+		return
+	}
+	_, cg, ok := calc.massProperties()
+	if !ok {
+		return
+	}
+	inches := M_TO_FT * FT_TO_IN
+	properties["inertia/cg-x-in"] = cg.X * inches
+	properties["inertia/cg-y-in"] = cg.Y * inches
+	properties["inertia/cg-z-in"] = cg.Z * inches
 }
 
 // calculateMoments computes roll, pitch, and yaw moments
 func (calc *ForcesMomentsCalculator) calculateMoments(state *AircraftState, properties map[string]float64, components *ForceMomentComponents) error {
-	if calc.Config.Aerodynamics == nil {
+	if calc.FallbackAero == nil && calc.Config.Aerodynamics == nil {
 		return fmt.Errorf("no aerodynamics configuration")
 	}
 	
@@ -222,56 +711,169 @@ func (calc *ForcesMomentsCalculator) calculateMoments(state *AircraftState, prop
 	qSb := qS * calc.Reference.WingSpan  // For roll moment
 	qSc := qS * calc.Reference.Chord     // For pitch moment
 	
-	// Initialize moment coefficients
+	// Initialize moment coefficients, and moments from functions that
+	// declare their unit
 	var Cl, Cm, Cn float64
+	var roll, pitch, yaw float64
 	
-	// Evaluate moment coefficient functions
-	for _, axis := range calc.Config.Aerodynamics.Axis {
+	// Evaluate moment coefficient functions; the fallback model has no
+	// aerodynamic moments
+	var axes []*Axis
+	if calc.FallbackAero == nil {
+		axes = calc.Config.Aerodynamics.Axis
+	}
+	
+	// The slipstream scales up the tail terms
+	slipstream := calc.Slipstream
+	if slipstream != nil {
+		slipstream.applyProperties(properties)
+		components.Slipstream.QRatio = slipstream.QRatio(components.Propulsion.Thrust, state.DynamicPressure)
+		properties[slipstreamRatioProperty] = components.Slipstream.QRatio
+	}
+	for _, axis := range axes {
+		var coeff, moment *float64
+		var scale float64
 		switch axis.Name {
 		case "ROLL":
-			for _, function := range axis.Function {
-				coeff, err := EvaluateFunction(function, properties)
-				if err == nil {
-					Cl += coeff
-				}
-			}
-			
+			coeff, moment, scale = &Cl, &roll, qSb
 		case "PITCH":
-			for _, function := range axis.Function {
-				coeff, err := EvaluateFunction(function, properties)
-				if err == nil {
-					Cm += coeff
+			coeff, moment, scale = &Cm, &pitch, qSc
+		case "YAW":
+			coeff, moment, scale = &Cn, &yaw, qSb
+		default:
+			continue
+		}
+		
+		// Hybrid functions read the reference properties and so give
+		// moments in ft·lbf rather than coefficients
+		if components.Blend != nil {
+			coeff, scale = moment, LB_TO_N*FT_TO_M
+		}
+		
+		var trace func(*Function, float64, bool)
+		if calc.Trace {
+			trace = func(f *Function, value float64, si bool) {
+				if !si {
+					value *= scale
 				}
+				components.Trace.add(axis.Name, f, value)
 			}
-			
-		case "YAW":
-			for _, function := range axis.Function {
-				coeff, err := EvaluateFunction(function, properties)
-				if err == nil {
-					Cn += coeff
+		}
+		var tailCoeff, tailMoment float64
+		if slipstream != nil && slipstream.immersion(axis.Name) != 0 {
+			traced := trace
+			trace = func(f *Function, value float64, si bool) {
+				if slipstream.isTailTerm(f) {
+					if si {
+						tailMoment += value
+					} else {
+						tailCoeff += value
+					}
+				}
+				if traced != nil {
+					traced(f, value, si)
 				}
 			}
 		}
+		c, m, err := calc.sumAxis(axis, state, properties, components, trace)
+		if err != nil {
+			return err
+		}
+		
+		if ratio := components.Slipstream.QRatio; ratio != 0 {
+			k := ratio * slipstream.immersion(axis.Name)
+			c += k * tailCoeff
+			m += k * tailMoment
+			added := k * (tailCoeff*scale + tailMoment)
+			switch axis.Name {
+			case "PITCH":
+				components.Slipstream.Pitch += added
+			case "YAW":
+				components.Slipstream.Yaw += added
+			}
+		}
+		if components.Blend != nil {
+			c *= scale
+		}
+		*coeff += c
+		*moment += m
 	}
 	
 	// Convert coefficients to moments
-	components.Moments.Roll = Cl * qSb
-	components.Moments.Pitch = Cm * qSc
-	components.Moments.Yaw = Cn * qSb
+	components.Moments.Roll = Cl*qSb + roll
+	components.Moments.Pitch = Cm*qSc + pitch
+	components.Moments.Yaw = Cn*qSb + yaw
+	components.Moments.Pitch += components.DynamicStall.PitchMoment
 	
 	// Add propeller torque to roll moment
 	components.Moments.Roll += components.Propulsion.Torque
 	
+	// And the slipstream's swirl on the fin
+	if slipstream != nil {
+		swirl := slipstream.SwirlMoment(calc.Propeller.Sense, components.Propulsion.Torque)
+		components.Slipstream.Yaw += swirl
+		components.Moments.Yaw += swirl
+	}
+	
+	calc.calculatePropellerMoments(state, properties, components)
+	components.Moments.Roll += components.Propulsion.Moment.X
+	components.Moments.Pitch += components.Propulsion.Moment.Y
+	components.Moments.Yaw += components.Propulsion.Moment.Z
+	calc.addSurfaceMassMoment(state, properties, components)
+	components.Moments.Roll += components.Gravity.Moment.X
+	components.Moments.Pitch += components.Gravity.Moment.Y
+	components.Moments.Yaw += components.Gravity.Moment.Z
+	
+	// The forces act away from the CG
+	calc.addOffsetMoments(components)
+	
 	return nil
 }
 
+// thrustVector returns the thrust along its line, in body axes
+func (calc *ForcesMomentsCalculator) thrustVector(components *ForceMomentComponents) Vector3 {
+	axis := calc.ThrustAxis
+	if axis == (Vector3{}) {
+		axis = Vector3{X: 1}
+	}
+	return axis.Scale(components.Propulsion.Thrust)
+}
+
+// addOffsetMoments adds the moments about the CG of the aerodynamic forces
+// acting at the AERORP and the thrust acting at the thruster
+func (calc *ForcesMomentsCalculator) addOffsetMoments(components *ForceMomentComponents) {
+	aero := Vector3{X: components.Aerodynamic.Drag, Y: components.Aerodynamic.Side, Z: components.Aerodynamic.Lift}
+	moment := calc.AeroReference.Cross(aero).Add(calc.ThrustLocation.Cross(calc.thrustVector(components)))
+	components.Moments.Roll += moment.X
+	components.Moments.Pitch += moment.Y
+	components.Moments.Yaw += moment.Z
+}
+
+// calculatePropellerMoments computes the gyroscopic moment of the rotating
+// masses, the propeller's among them when enabled, and the P-factor.
+// P-factor moves the thrust line toward the descending blade,
+// which for a clockwise propeller is on the right at positive alpha, so the
+// nose yaws left; the shift is the thruster's p_factor in inches per radian.
+func (calc *ForcesMomentsCalculator) calculatePropellerMoments(state *AircraftState, properties map[string]float64, components *ForceMomentComponents) {
+	prop := calc.Propeller
+	moment := calc.gyroscopicMoment(state, properties, components)
+	
+	if calc.PropellerEffects.PFactor {
+		offset := prop.Sense * prop.PFactor * IN_TO_FT * FT_TO_M * state.Alpha
+		moment.Z -= offset * components.Propulsion.Thrust
+	}
+	
+	components.Propulsion.Moment = moment
+}
+
 // sumTotalForcesMoments computes the total forces and moments
 func (calc *ForcesMomentsCalculator) sumTotalForcesMoments(components *ForceMomentComponents) {
 	// Sum forces in body frame
+	thrust := calc.thrustVector(components)
 	components.TotalForce = Vector3{
-		X: components.Aerodynamic.Drag + components.Propulsion.Thrust + components.Gravity.Weight.X,
-		Y: components.Aerodynamic.Side + components.Gravity.Weight.Y,
-		Z: components.Aerodynamic.Lift + components.Gravity.Weight.Z,
+		X: components.Aerodynamic.Drag + thrust.X + components.Gravity.Weight.X,
+		Y: components.Aerodynamic.Side + thrust.Y + components.Gravity.Weight.Y,
+		Z: components.Aerodynamic.Lift + thrust.Z + components.Gravity.Weight.Z,
 	}
 	
 	// Sum moments
@@ -293,7 +895,7 @@ func (calc *ForcesMomentsCalculator) CalculateStateDerivatives(state *AircraftSt
 	derivatives.AngularRateDot = calc.calculateAngularAcceleration(state, components.TotalMoment)
 	
 	// Altitude rate (climb/descent)
-	earthVel := state.Orientation.RotateVector(state.Velocity)
+	earthVel := state.GroundVelocity()
 	derivatives.AltitudeDot = -earthVel.Z // Negative Z is climb in NED
 	
 	// Mass rate (fuel consumption - simplified)
@@ -330,21 +932,131 @@ func (calc *ForcesMomentsCalculator) estimateFuelFlow(thrust float64) float64 {
 	return 0
 }
 
-// FlightDynamicsEngine combines forces/moments calculator with integration
+// FlightDynamicsEngine combines forces/moments calculator with integration.
+// It steps one trajectory at a time; concurrent trajectories each step a
+// session of it (see NewSession).
 type FlightDynamicsEngine struct {
-	Calculator *ForcesMomentsCalculator
-	Integrator Integrator
-	Statistics *FlightStatistics
+	Calculator   *ForcesMomentsCalculator
+	Integrator   Integrator
+	Statistics   *FlightStatistics
+	Terrain      Terrain            // Optional; ground height is taken as zero when nil
+	Limits       *StructuralLimits  // Optional; limits are not checked when nil
+	Placards     *PlacardLimits     // Optional, from the configuration's placards; speeds are not checked when nil
+	Departures   *DepartureDetector // Optional; departures are not watched when nil
+	MemoryBudget *MemoryBudget      // Optional; retained memory is not estimated when nil
+	Gear         *LandingGear       // Optional; no ground reaction and no gear units when nil
+	Events       *EventBus          // Watchers evaluated after each step
+	Replay       *ControlReplayer   // Optional; sets the pilot inputs at the start of each step when set
+	Stores       []*ReleasedStore   // Point masses released by ReleasePointMass, flown on with each step
+	Units        UnitPreferences    // Units of GetPerformanceReport; SI when zero
+	
+	// Pilot's eyepoint in body axes about the CG (m), from the EYEPOINT
+	// location; where AircraftState.PilotSpecificForce is taken
+	PilotStation Vector3
+	
+	// Held over each step, so ReloadConfig swaps the model between steps
+	stepping sync.Mutex
 }
 
 // FlightStatistics tracks flight performance metrics
 type FlightStatistics struct {
-	MaxLoadFactor    float64
+	MaxLoadFactor    float64 // Highest nz (g)
+	MinLoadFactor    float64 // Lowest nz (g)
 	MaxClimbRate     float64
 	MaxSpeed         float64
 	MaxAltitude      float64
 	TotalFuelBurned  float64
 	FlightTime       float64
+	
+	// Energy performance
+	SpecificExcessPower    float64             // Ps at the latest step (m/s)
+	MaxSpecificExcessPower float64             // Highest Ps seen (m/s)
+	MaxPsAltitude          float64             // Altitude where the highest Ps was seen (m)
+	MaxPsByAltitude        map[float64]float64 // Highest Ps in each PsAltitudeBand, keyed by band floor (m)
+	
+	// Aerodynamic efficiency, from the wind-axis lift and drag
+	LiftToDrag         float64 // L/D at the latest step
+	MaxLiftToDrag      float64 // Highest L/D seen
+	MaxLiftToDragSpeed float64 // True airspeed where the highest L/D was seen (m/s)
+	
+	// Flight path through the air mass: the steepest γ, and the best rate
+	// of climb with the airspeed and γ it was seen at, the Vy flown
+	MaxFlightPathAngle float64 // rad
+	BestClimbRate      float64 // m/s
+	BestClimbSpeed     float64 // True airspeed (m/s)
+	BestClimbGamma     float64 // rad
+	
+	// Structural limit exceedances, recorded when each one begins. With
+	// MaxExceedances set, those beyond the first MaxExceedances are only
+	// counted, in DroppedExceedances.
+	Exceedances        []LimitExceedance
+	MaxExceedances     int
+	DroppedExceedances int
+	exceeding          map[string]bool // Limits exceeded at the latest step
+	
+	// Landings seen by a LandingAnalyzer recording into these statistics
+	Landings []*LandingReport
+	
+	// Departures seen by a DepartureDetector recording into these statistics
+	Departures []*Departure
+}
+
+// recordLoadFactor tracks the normal load factor extremes
+func (stats *FlightStatistics) recordLoadFactor(nz float64) {
+	if stats.FlightTime == 0 || nz > stats.MaxLoadFactor {
+		stats.MaxLoadFactor = nz
+	}
+	if stats.FlightTime == 0 || nz < stats.MinLoadFactor {
+		stats.MinLoadFactor = nz
+	}
+}
+
+// PsAltitudeBand is the altitude band width used for FlightStatistics.MaxPsByAltitude (m)
+const PsAltitudeBand = 500.0
+
+// recordEnergy tracks specific excess power at the given altitude
+func (stats *FlightStatistics) recordEnergy(altitude, ps float64) {
+	stats.SpecificExcessPower = ps
+	if stats.FlightTime == 0 || ps > stats.MaxSpecificExcessPower {
+		stats.MaxSpecificExcessPower = ps
+		stats.MaxPsAltitude = altitude
+	}
+	
+	if stats.MaxPsByAltitude == nil {
+		stats.MaxPsByAltitude = make(map[float64]float64)
+	}
+	band := math.Floor(altitude/PsAltitudeBand) * PsAltitudeBand
+	if best, ok := stats.MaxPsByAltitude[band]; !ok || ps > best {
+		stats.MaxPsByAltitude[band] = ps
+	}
+}
+
+// recordLiftToDrag tracks the wind-axis lift-to-drag ratio at the given
+// airspeed; it is not recorded without drag
+func (stats *FlightStatistics) recordLiftToDrag(components *ForceMomentComponents, airspeed float64) {
+	if components.Wind.Drag <= 0 {
+		return
+	}
+	stats.LiftToDrag = components.Wind.Lift / components.Wind.Drag
+	if stats.MaxLiftToDragSpeed == 0 || stats.LiftToDrag > stats.MaxLiftToDrag {
+		stats.MaxLiftToDrag = stats.LiftToDrag
+		stats.MaxLiftToDragSpeed = airspeed
+	}
+}
+
+// recordFlightPath tracks the steepest flight path angle and the best
+// rate of climb
+func (stats *FlightStatistics) recordFlightPath(state *AircraftState) {
+	gamma := state.FlightPathAngle()
+	if stats.FlightTime == 0 || gamma > stats.MaxFlightPathAngle {
+		stats.MaxFlightPathAngle = gamma
+	}
+	climb := state.TrueAirspeed * math.Sin(gamma)
+	if stats.FlightTime == 0 || climb > stats.BestClimbRate {
+		stats.BestClimbRate = climb
+		stats.BestClimbSpeed = state.TrueAirspeed
+		stats.BestClimbGamma = gamma
+	}
 }
 
 // NewFlightDynamicsEngine creates a complete flight dynamics simulation engine
@@ -353,25 +1065,97 @@ func NewFlightDynamicsEngine(config *JSBSimConfig, integrator Integrator) *Fligh
 		Calculator: NewForcesMomentsCalculator(config),
 		Integrator: integrator,
 		Statistics: &FlightStatistics{},
+		Gear:       NewLandingGear(config),
+		Events:     NewEventBus(),
+		Placards:   configPlacards(config),
+		
+		PilotStation: configPilotStation(config),
 	}
 }
 
+// configPilotStation returns the EYEPOINT location in body axes about the
+// CG, or the CG itself when the configuration has no eyepoint
+func configPilotStation(config *JSBSimConfig) Vector3 {
+	if config == nil || config.Metrics == nil {
+		return Vector3{}
+	}
+	if eyepoint := metricsLocation(config.Metrics, "EYEPOINT"); eyepoint != nil {
+		return StructuralToBody(eyepoint, configCG(config))
+	}
+	return Vector3{}
+}
+
+// configPlacards returns the configuration's placard limits, or nil
+func configPlacards(config *JSBSimConfig) *PlacardLimits {
+	if config == nil {
+		return nil
+	}
+	return PlacardLimitsFromConfig(config)
+}
+
 // Step advances the simulation by one time step
 func (fde *FlightDynamicsEngine) Step(state *AircraftState, dt float64) (*AircraftState, error) {
-	// Calculate forces and moments
-	components, err := fde.Calculator.CalculateForcesMoments(state)
+	fde.stepping.Lock()
+	defer fde.stepping.Unlock()
+	
+	if fde.Replay != nil {
+		fde.Replay.Apply(state)
+	}
+	
+	// Fail fast on a corrupted state rather than spreading NaNs through the
+	// table lookups and integrator
+	if err := state.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid state at t=%.3fs: %w", state.Time, err)
+	}
+	
+	// Sample the terrain below the starting position so AGL is current
+	if fde.Terrain != nil {
+		elevation, err := fde.Terrain.ElevationAt(state.Latitude, state.Longitude)
+		if err != nil {
+			return nil, fmt.Errorf("terrain lookup failed: %v", err)
+		}
+		state.Gear.GroundHeight = elevation
+	}
+	
+	// Calculate forces, moments and state derivatives
+	derivatives, components, err := fde.derivatives(state)
 	if err != nil {
 		return nil, err
 	}
 	
-	// Calculate state derivatives
-	derivatives := fde.Calculator.CalculateStateDerivatives(state, components)
+	// Integrate to new state, the dynamics evaluated at any stages
+	newState := integrateDynamics(fde.Integrator, fde, state, derivatives, dt)
+	
+	// Track geodetic position and ground contact at the new position
+	updateGeodeticPosition(newState, state.Position)
+	if fde.Terrain != nil {
+		if err := updateGroundContact(newState, fde.Terrain); err != nil {
+			return nil, fmt.Errorf("terrain lookup failed: %v", err)
+		}
+	}
+	if fde.Gear != nil {
+		fde.Gear.Update(newState)
+	}
+	fde.stepStores(newState, dt)
 	
-	// Integrate to new state
-	newState := fde.Integrator.Integrate(state, derivatives, dt)
+	// Energy rate and load factors over the step, from the forces at its start
+	newState.SpecificExcessPower = components.SpecificExcessPower(state.TrueAirspeed, fde.Calculator.Mass)
+	newState.LoadFactor = components.LoadFactor(fde.Calculator.Mass)
+	newState.recordAccelerations(derivatives, fde.PilotStation)
 	
 	// Update flight statistics
 	fde.updateStatistics(newState, components, dt)
+	if err := fde.Statistics.checkLimits(fde.Limits, fde.Placards, newState); err != nil {
+		return nil, err
+	}
+	if fde.Departures != nil {
+		if err := fde.Departures.Observe(newState); err != nil {
+			return nil, err
+		}
+	}
+	if fde.MemoryBudget != nil {
+		fde.MemoryBudget.step(newState.Time, fde.Statistics, fde.Departures)
+	}
 	
 	// Store forces and moments in state for analysis
 	newState.Forces.Total = components.TotalForce
@@ -381,8 +1165,16 @@ func (fde *FlightDynamicsEngine) Step(state *AircraftState, dt float64) (*Aircra
 		Y: components.Aerodynamic.Side,
 		Z: components.Aerodynamic.Lift,
 	}
-	newState.Forces.Propulsive = Vector3{X: components.Propulsion.Thrust, Y: 0, Z: 0}
+	newState.Forces.Propulsive = fde.Calculator.thrustVector(components)
 	newState.Forces.Gravity = components.Gravity.Weight
+	newState.Forces.External = components.External.Force
+	newState.Forces.Ground = components.Ground.Force
+	newState.Moments.External = components.External.Moment
+	newState.Moments.Gyroscopic = components.Gyroscopic.Moment
+	
+	if fde.Events != nil {
+		fde.Events.Evaluate(newState)
+	}
 	
 	return newState, nil
 }
@@ -390,11 +1182,7 @@ func (fde *FlightDynamicsEngine) Step(state *AircraftState, dt float64) (*Aircra
 // updateStatistics tracks flight performance metrics
 func (fde *FlightDynamicsEngine) updateStatistics(state *AircraftState, components *ForceMomentComponents, dt float64) {
 	// Load factor (g-force)
-	totalAccel := components.TotalForce.Magnitude() / fde.Calculator.Mass
-	loadFactor := totalAccel / 9.81
-	if loadFactor > fde.Statistics.MaxLoadFactor {
-		fde.Statistics.MaxLoadFactor = loadFactor
-	}
+	fde.Statistics.recordLoadFactor(state.LoadFactor.Z)
 	
 	// Climb rate
 	earthVel := state.Orientation.RotateVector(state.Velocity)
@@ -411,42 +1199,66 @@ func (fde *FlightDynamicsEngine) updateStatistics(state *AircraftState, componen
 		fde.Statistics.MaxAltitude = state.Altitude
 	}
 	
+	// Specific excess power
+	fde.Statistics.recordEnergy(state.Altitude, state.SpecificExcessPower)
+	fde.Statistics.recordLiftToDrag(components, state.TrueAirspeed)
+	fde.Statistics.recordFlightPath(state)
+	
 	// Fuel consumption
 	fuelFlow := fde.Calculator.estimateFuelFlow(components.Propulsion.Thrust)
 	fde.Statistics.TotalFuelBurned += fuelFlow * dt
 	fde.Statistics.FlightTime += dt
 }
 
-// GetPerformanceReport generates a performance summary
+// GetPerformanceReport generates a performance summary in the engine's Units
 func (fde *FlightDynamicsEngine) GetPerformanceReport() string {
-	stats := fde.Statistics
+	return fde.Statistics.Report(fde.Units)
+}
+
+// Report writes the statistics as a performance summary in the given units
+func (stats *FlightStatistics) Report(units UnitPreferences) string {
 	return fmt.Sprintf(
 		"Flight Performance Report:\n"+
-		"  Flight Time: %.1f seconds\n"+
-		"  Max Load Factor: %.2f g\n"+
-		"  Max Climb Rate: %.1f m/s (%.0f ft/min)\n"+
-		"  Max Speed: %.1f m/s (%.1f kt)\n"+
-		"  Max Altitude: %.0f m (%.0f ft)\n"+
-		"  Total Fuel Burned: %.2f kg\n"+
-		"  Average Fuel Flow: %.3f kg/s",
+			"  Flight Time: %.1f seconds\n"+
+			"  Load Factor: %.2f to %.2f g\n"+
+			"  Max Climb Rate: %s\n"+
+			"  Max Flight Path Angle: %s (%s at Vy %s)\n"+
+			"  Max Speed: %s\n"+
+			"  Max Altitude: %s\n"+
+			"  Max L/D: %.2f at %s\n"+
+			"  Total Fuel Burned: %s\n"+
+			"  Average Fuel Flow: %s",
 		stats.FlightTime,
-		stats.MaxLoadFactor,
-		stats.MaxClimbRate, stats.MaxClimbRate*60*M_TO_FT,
-		stats.MaxSpeed, stats.MaxSpeed*MS_TO_KT,
-		stats.MaxAltitude, stats.MaxAltitude*M_TO_FT,
-		stats.TotalFuelBurned,
-		stats.TotalFuelBurned/math.Max(stats.FlightTime, 1.0),
+		stats.MinLoadFactor, stats.MaxLoadFactor,
+		units.Format(QuantityVerticalSpeed, stats.MaxClimbRate, 1),
+		units.Format(QuantityAngle, stats.MaxFlightPathAngle, 1),
+		units.Format(QuantityAngle, stats.BestClimbGamma, 1),
+		units.Format(QuantitySpeed, stats.BestClimbSpeed, 1),
+		units.Format(QuantitySpeed, stats.MaxSpeed, 1),
+		units.Format(QuantityAltitude, stats.MaxAltitude, 0),
+		stats.MaxLiftToDrag, units.Format(QuantitySpeed, stats.MaxLiftToDragSpeed, 1),
+		units.Format(QuantityMass, stats.TotalFuelBurned, 2),
+		units.Format(QuantityFuelFlow, stats.TotalFuelBurned/math.Max(stats.FlightTime, 1.0), 3),
 	)
 }
 
 // TrimCalculator finds equilibrium control settings for steady flight
 type TrimCalculator struct {
 	Engine *FlightDynamicsEngine
+	
+	// WarmStarter runs the trimmed state in, the engine by default; set it
+	// to an engine with flight controls to settle them too
+	WarmStarter       WarmStarter
+	WarmStartDuration float64 // s
 }
 
 // NewTrimCalculator creates a trim calculator
 func NewTrimCalculator(engine *FlightDynamicsEngine) *TrimCalculator {
-	return &TrimCalculator{Engine: engine}
+	return &TrimCalculator{
+		Engine:            engine,
+		WarmStarter:       engine,
+		WarmStartDuration: DefaultWarmStartDuration,
+	}
 }
 
 // FindTrim finds control inputs for steady level flight at given conditions
@@ -466,9 +1278,131 @@ func (tc *TrimCalculator) FindTrim(targetSpeed, targetAltitude float64) (Control
 	// 2. Multiple control variables optimization
 	// 3. Constraint satisfaction for forces/moments balance
 	
+	// Aileron and rudder against a lateral CG offset or thrust off the
+	// centreline
+	aileron, rudder, err := tc.lateralTrim(targetSpeed, targetAltitude, controls)
+	if err != nil {
+		return controls, fmt.Errorf("lateral trim: %w", err)
+	}
+	controls.Aileron, controls.Rudder = aileron, rudder
+	
 	return controls, nil
 }
 
+// lateralTrim returns the aileron and rudder whose moments cancel those of
+// the aircraft's asymmetry, the weight at a lateral CG offset and the
+// thrust off the centreline, in level flight with the given controls.
+// Both are neutral for a symmetric aircraft.
+func (tc *TrimCalculator) lateralTrim(speed, altitude float64, controls ControlInputs) (aileron, rudder float64, err error) {
+	calc := tc.Engine.Calculator
+	defer calc.holdSurfaceMotion()()
+	moments := func(aileron, rudder float64) (*ForceMomentComponents, error) {
+		c := controls
+		c.Aileron, c.Rudder = aileron, rudder
+		state := trimLevelState(speed, altitude, c)
+		directControlSurfaces(state)
+		return calc.CalculateForcesMoments(state)
+	}
+	
+	neutral, err := moments(0, 0)
+	if err != nil {
+		return 0, 0, err
+	}
+	asymmetry := neutral.Gravity.Moment.Add(calc.asymmetricThrustMoment(neutral))
+	if asymmetry.X == 0 && asymmetry.Z == 0 {
+		return 0, 0, nil
+	}
+	return solveLateralTrim(func(aileron, rudder float64) (float64, float64, error) {
+		components, err := moments(aileron, rudder)
+		if err != nil {
+			return 0, 0, err
+		}
+		roll := components.TotalMoment.X - neutral.TotalMoment.X + asymmetry.X
+		yaw := components.TotalMoment.Z - neutral.TotalMoment.Z + asymmetry.Z
+		return roll, yaw, nil
+	})
+}
+
+// asymmetricThrustMoment returns the moment of the thrust about the CG less
+// that of the same thrust along the centreline
+func (calc *ForcesMomentsCalculator) asymmetricThrustMoment(components *ForceMomentComponents) Vector3 {
+	thrust := calc.thrustVector(components)
+	centreline := Vector3{X: calc.ThrustLocation.X, Z: calc.ThrustLocation.Z}.Cross(Vector3{X: thrust.X, Z: thrust.Z})
+	return calc.ThrustLocation.Cross(thrust).Sub(centreline)
+}
+
+// solveLateralTrim finds the aileron and rudder at which moments returns
+// zero roll and yaw moments, by Newton iteration from neutral with a
+// finite-difference Jacobian. Trim beyond full travel is an error.
+func solveLateralTrim(moments func(aileron, rudder float64) (roll, yaw float64, err error)) (aileron, rudder float64, err error) {
+	const h = 1e-3
+	for iter := 0; iter < 20; iter++ {
+		roll, yaw, err := moments(aileron, rudder)
+		if err != nil {
+			return 0, 0, err
+		}
+		rollA, yawA, err := moments(aileron+h, rudder)
+		if err != nil {
+			return 0, 0, err
+		}
+		rollR, yawR, err := moments(aileron, rudder+h)
+		if err != nil {
+			return 0, 0, err
+		}
+		
+		// Solve J·step = -(roll, yaw) by Cramer's rule
+		la, lr := (rollA-roll)/h, (rollR-roll)/h
+		na, nr := (yawA-yaw)/h, (yawR-yaw)/h
+		det := la*nr - lr*na
+		if det == 0 || math.IsNaN(det) {
+			return 0, 0, fmt.Errorf("the aileron and rudder have no independent roll and yaw authority")
+		}
+		stepA := -(roll*nr - lr*yaw) / det
+		stepR := -(la*yaw - na*roll) / det
+		aileron += stepA
+		rudder += stepR
+		if math.Abs(stepA) < 1e-9 && math.Abs(stepR) < 1e-9 {
+			if math.Abs(aileron) > 1 || math.Abs(rudder) > 1 {
+				return 0, 0, fmt.Errorf("trim needs aileron %.2f and rudder %.2f, beyond full travel", aileron, rudder)
+			}
+			return aileron, rudder, nil
+		}
+	}
+	return 0, 0, fmt.Errorf("no convergence, at aileron %.4f and rudder %.4f", aileron, rudder)
+}
+
+// trimLevelState returns a wings-level state at a speed and altitude with
+// the given controls
+func trimLevelState(speed, altitude float64, controls ControlInputs) *AircraftState {
+	state := NewAircraftState()
+	state.Altitude = altitude
+	state.Position = Vector3{Z: -altitude}
+	state.Velocity = Vector3{X: speed}
+	state.SetControlInputs(controls)
+	state.UpdateFlightConditions()
+	return state
+}
+
+// TrimState returns a wings-level state at the given conditions with the
+// controls of FindTrim, warm started so it begins with settled controls
+func (tc *TrimCalculator) TrimState(targetSpeed, targetAltitude float64) (*AircraftState, *WarmStartResult, error) {
+	controls, err := tc.FindTrim(targetSpeed, targetAltitude)
+	if err != nil {
+		return nil, nil, err
+	}
+	
+	state := trimLevelState(targetSpeed, targetAltitude, controls)
+	
+	if tc.WarmStarter == nil {
+		return state, nil, nil
+	}
+	result, err := tc.WarmStarter.WarmStart(state, tc.WarmStartDuration)
+	if err != nil {
+		return nil, nil, err
+	}
+	return state, result, nil
+}
+
 // AerodynamicAnalysis provides detailed aerodynamic performance analysis
 type AerodynamicAnalysis struct {
 	AlphaRange []float64 // Angle of attack sweep
@@ -480,8 +1414,9 @@ type AerodynamicAnalysis struct {
 	StallAlpha float64   // Stall angle of attack
 }
 
-// PerformAerodynamicAnalysis conducts a comprehensive aero analysis
-func (calc *ForcesMomentsCalculator) PerformAerodynamicAnalysis(baseState *AircraftState) *AerodynamicAnalysis {
+// PerformAerodynamicAnalysis conducts a comprehensive aero analysis. It
+// fails when the aerodynamics cannot be evaluated at a point of the sweep.
+func (calc *ForcesMomentsCalculator) PerformAerodynamicAnalysis(baseState *AircraftState) (*AerodynamicAnalysis, error) {
 	analysis := &AerodynamicAnalysis{}
 	
 	// Define alpha sweep from -10° to +20°
@@ -512,14 +1447,22 @@ func (calc *ForcesMomentsCalculator) PerformAerodynamicAnalysis(baseState *Aircr
 		
 		// Calculate forces
 		properties := testState.ToPropertyMap()
+		calc.addGroundEffectProperties(testState, properties)
+		if err := calc.evaluateStandaloneFunctions(properties); err != nil {
+			return nil, fmt.Errorf("alpha %.1f°: %w", alpha*RAD_TO_DEG, err)
+		}
 		components := &ForceMomentComponents{}
-		calc.calculateAerodynamicForces(testState, properties, components)
+		if err := calc.calculateAerodynamicForces(testState, properties, components); err != nil {
+			return nil, fmt.Errorf("alpha %.1f°: %w", alpha*RAD_TO_DEG, err)
+		}
 		
-		// Extract coefficients
+		// Extract coefficients in wind axes, where lift and drag are
+		// perpendicular and opposite to the velocity at any alpha
 		qS := testState.DynamicPressure * calc.Reference.WingArea
 		if qS > 0 {
-			CL := -components.Aerodynamic.Lift / qS
-			CD := -components.Aerodynamic.Drag / qS
+			lift, drag, _ := WindAxesForces(testState, components)
+			CL := lift / qS
+			CD := drag / qS
 			
 			analysis.CLCurve[i] = CL
 			analysis.CDCurve[i] = CD
@@ -545,18 +1488,57 @@ func (calc *ForcesMomentsCalculator) PerformAerodynamicAnalysis(baseState *Aircr
 		}
 	}
 	
-	return analysis
+	return analysis, nil
 }
 
 // PerformanceEnvelope calculates aircraft performance across flight envelope
 type PerformanceEnvelope struct {
 	Altitudes    []float64   // Test altitudes
 	MaxSpeeds    []float64   // Maximum speeds at each altitude
-	ClimbRates   []float64   // Climb rates at each altitude
+	ClimbRates   []float64   // Best climb rate (highest Ps) at each altitude
+	BestClimbSpeeds []float64 // True airspeed for best climb at each altitude
 	ServiceCeiling float64   // Maximum operational altitude
 	AbsoluteCeiling float64  // Theoretical maximum altitude
 }
 
+// Envelope search limits
+const (
+	envelopeMinSpeed    = 40.0    // Lowest airspeed in the best-climb sweep (m/s)
+	envelopeMaxSpeed    = 200.0   // Highest airspeed in the best-climb sweep (m/s)
+	envelopeSpeedStep   = 10.0    // Best-climb sweep spacing (m/s)
+	envelopeCeilingStep = 1000.0  // Altitude step when searching above the test altitudes (m)
+	envelopeMaxAltitude = 30000.0 // Highest altitude searched for the ceilings (m)
+)
+
+// levelFlightState returns a wings-level full-throttle state at the given
+// altitude and true airspeed
+func levelFlightState(altitude, airspeed float64) *AircraftState {
+	state := NewAircraftState()
+	state.Altitude = altitude
+	state.Velocity = Vector3{X: airspeed, Y: 0, Z: 0}
+	state.Controls.Throttle = 1.0
+	state.UpdateFlightConditions()
+	return state
+}
+
+// bestClimb sweeps airspeed at full throttle and returns the highest
+// specific excess power at the altitude and the airspeed it occurs at. In
+// steady flight Ps is the climb rate.
+func (fde *FlightDynamicsEngine) bestClimb(altitude float64) (ps, airspeed float64, ok bool) {
+	for v := envelopeMinSpeed; v <= envelopeMaxSpeed; v += envelopeSpeedStep {
+		state := levelFlightState(altitude, v)
+		components, err := fde.Calculator.CalculateForcesMoments(state)
+		if err != nil {
+			continue
+		}
+		excess := components.SpecificExcessPower(state.TrueAirspeed, fde.Calculator.Mass)
+		if !ok || excess > ps {
+			ps, airspeed, ok = excess, v, true
+		}
+	}
+	return ps, airspeed, ok
+}
+
 // CalculatePerformanceEnvelope determines aircraft performance limits
 func (fde *FlightDynamicsEngine) CalculatePerformanceEnvelope() *PerformanceEnvelope {
 	envelope := &PerformanceEnvelope{}
@@ -566,17 +1548,11 @@ func (fde *FlightDynamicsEngine) CalculatePerformanceEnvelope() *PerformanceEnve
 	envelope.Altitudes = altitudes
 	envelope.MaxSpeeds = make([]float64, len(altitudes))
 	envelope.ClimbRates = make([]float64, len(altitudes))
+	envelope.BestClimbSpeeds = make([]float64, len(altitudes))
 	
 	for i, altitude := range altitudes {
-		// Create test state at this altitude
-		testState := NewAircraftState()
-		testState.Altitude = altitude
-		testState.Velocity = Vector3{X: 100.0, Y: 0, Z: 0} // Start at 100 m/s
-		testState.UpdateAtmosphere()
-		testState.UpdateDerivedParameters()
-		
-		// Full throttle performance
-		testState.Controls.Throttle = 1.0
+		// Full throttle performance at 100 m/s
+		testState := levelFlightState(altitude, 100.0)
 		
 		// Calculate forces at this condition
 		components, err := fde.Calculator.CalculateForcesMoments(testState)
@@ -584,12 +1560,12 @@ func (fde *FlightDynamicsEngine) CalculatePerformanceEnvelope() *PerformanceEnve
 			// Maximum speed where thrust = drag
 			// Simplified: assume max speed reached when thrust available
 			envelope.MaxSpeeds[i] = testState.TrueAirspeed + components.Propulsion.Thrust/100.0
-			
-			// Climb rate from excess thrust
-			excessThrust := components.Propulsion.Thrust + components.Aerodynamic.Drag
-			if excessThrust > 0 {
-				envelope.ClimbRates[i] = excessThrust / fde.Calculator.Mass
-			}
+		}
+		
+		// Best climb from specific excess power
+		if ps, airspeed, ok := fde.bestClimb(altitude); ok {
+			envelope.ClimbRates[i] = math.Max(ps, 0)
+			envelope.BestClimbSpeeds[i] = airspeed
 		}
 	}
 	
@@ -603,5 +1579,20 @@ func (fde *FlightDynamicsEngine) CalculatePerformanceEnvelope() *PerformanceEnve
 		}
 	}
 	
+	// Keep climbing past the test altitudes for ceilings not yet reached
+	for altitude := altitudes[len(altitudes)-1] + envelopeCeilingStep; altitude <= envelopeMaxAltitude &&
+		(envelope.ServiceCeiling == 0 || envelope.AbsoluteCeiling == 0); altitude += envelopeCeilingStep {
+		ps, _, ok := fde.bestClimb(altitude)
+		if !ok {
+			break
+		}
+		if ps < 0.5 && envelope.ServiceCeiling == 0 {
+			envelope.ServiceCeiling = altitude
+		}
+		if ps <= 0 && envelope.AbsoluteCeiling == 0 {
+			envelope.AbsoluteCeiling = altitude
+		}
+	}
+	
 	return envelope
 }
@@ -1,8 +1,10 @@
 package main
 
 import (
+	"errors"
 	"math"
 	"os"
+	"strings"
 	"testing"
 )
 
@@ -191,6 +193,41 @@ func TestForcesMomentsCalculator(t *testing.T) {
 		assertApproxEqual(t, derivatives.VelocityDot.Y, expectedAccel.Y, 0.01)
 		assertApproxEqual(t, derivatives.VelocityDot.Z, expectedAccel.Z, 0.01)
 	})
+	
+	t.Run("Reused Property Map", func(t *testing.T) {
+		calc := NewForcesMomentsCalculator(config)
+		state := NewAircraftState()
+		state.Velocity = Vector3{X: 100.0, Y: 0, Z: 5.0}
+		state.UpdateDerivedParameters()
+		
+		first, err := calc.CalculateForcesMoments(state)
+		if err != nil {
+			t.Fatalf("Forces calculation failed: %v", err)
+		}
+		
+		// A different state in between must not leak into the next result
+		other := NewAircraftState()
+		other.Velocity = Vector3{X: 40.0, Y: 3.0, Z: -2.0}
+		other.UpdateDerivedParameters()
+		if _, err := calc.CalculateForcesMoments(other); err != nil {
+			t.Fatalf("Forces calculation failed: %v", err)
+		}
+		
+		second, err := calc.CalculateForcesMoments(state)
+		if err != nil {
+			t.Fatalf("Forces calculation failed: %v", err)
+		}
+		assertEqual(t, second.TotalForce, first.TotalForce)
+		assertEqual(t, second.TotalMoment, first.TotalMoment)
+		
+		// Only the returned components are allocated
+		allocs := testing.AllocsPerRun(50, func() {
+			calc.CalculateForcesMoments(state)
+		})
+		if allocs > 1 {
+			t.Errorf("Expected at most 1 allocation per calculation, got %.0f", allocs)
+		}
+	})
 }
 
 // TestFlightDynamicsEngine tests the complete flight dynamics system
@@ -332,10 +369,12 @@ func TestFlightDynamicsEngine(t *testing.T) {
 		state.UpdateDerivedParameters()
 		
 		initialAlt := state.Altitude
+		initialEnergy := state.EnergyHeight
 		
 		// Simulate climb for 10 seconds
 		dt := 0.01
 		steps := 1000
+		psIntegral := 0.0
 		
 		for i := 0; i < steps; i++ {
 			newState, err := engine.Step(state, dt)
@@ -343,18 +382,23 @@ func TestFlightDynamicsEngine(t *testing.T) {
 				t.Fatalf("Climb step %d failed: %v", i, err)
 			}
 			state = newState
+			psIntegral += state.SpecificExcessPower * dt
 		}
 		
 		altGain := state.Altitude - initialAlt
 		avgClimbRate := altGain / 10.0
+		energyGain := state.EnergyHeight - initialEnergy
 		
 		t.Logf("Climb Performance:")
 		t.Logf("  Altitude Gain: %.1f m (%.0f ft)", altGain, altGain*M_TO_FT)
 		t.Logf("  Average Climb Rate: %.2f m/s (%.0f ft/min)", avgClimbRate, avgClimbRate*60*M_TO_FT)
+		t.Logf("  Energy Height Gain: %.1f m (Ps predicts %.1f m)", energyGain, psIntegral)
+		t.Logf("  Best Ps: %.2f m/s at %.0f m", engine.Statistics.MaxSpecificExcessPower, engine.Statistics.MaxPsAltitude)
 		t.Logf("  Final Speed: %.1f m/s", state.TrueAirspeed)
+		t.Logf("  Flight Path Angle: %.2f° (max %.2f°)", state.FlightPathAngle()*RAD_TO_DEG, engine.Statistics.MaxFlightPathAngle*RAD_TO_DEG)
 		
 		// Should have climbed significantly
-		if altGain <= 0 {
+		if altGain <= 0 || engine.Statistics.MaxFlightPathAngle <= 0 {
 			t.Error("Aircraft should have climbed")
 		}
 		
@@ -377,7 +421,7 @@ func TestFlightDynamicsEngine(t *testing.T) {
 		state.UpdateAtmosphere()
 		state.UpdateDerivedParameters()
 		
-		initialHeading := state.Yaw
+		initialHeading := state.HeadingContinuous
 		
 		// Simulate turn for 3 seconds
 		dt := 0.01
@@ -391,7 +435,7 @@ func TestFlightDynamicsEngine(t *testing.T) {
 			state = newState
 		}
 		
-		headingChange := math.Abs(state.Yaw - initialHeading)
+		headingChange := math.Abs(state.HeadingContinuous - initialHeading)
 		
 		t.Logf("Turn Performance:")
 		t.Logf("  Heading Change: %.1f° in 3 seconds", headingChange*RAD_TO_DEG)
@@ -410,6 +454,64 @@ func TestFlightDynamicsEngine(t *testing.T) {
 	})
 }
 
+// TestNonFiniteStateRejected checks that NaN and infinite states are
+// rejected and the error names the property they came from
+func TestNonFiniteStateRejected(t *testing.T) {
+	file, err := os.Open("aircraft/p51d-jsbsim.xml")
+	if err != nil {
+		t.Skip("P-51D XML file not found")
+	}
+	defer file.Close()
+	
+	config, err := ParseJSBSimConfig(file)
+	if err != nil {
+		t.Fatalf("Failed to parse P-51D config: %v", err)
+	}
+	
+	newState := func() *AircraftState {
+		state := NewAircraftState()
+		state.Velocity = Vector3{X: 100.0, Y: 0, Z: 0}
+		state.UpdateAtmosphere()
+		state.UpdateDerivedParameters()
+		return state
+	}
+	if err := newState().Validate(); err != nil {
+		t.Fatalf("Valid state rejected: %v", err)
+	}
+	
+	t.Run("Step Fails Fast", func(t *testing.T) {
+		engine := NewFlightDynamicsEngine(config, NewRungeKutta4Integrator())
+		state := newState()
+		state.Velocity.X = math.NaN()
+		state.UpdateDerivedParameters() // Spreads the NaN into alpha, airspeed and qbar
+		
+		next, err := engine.Step(state, 0.01)
+		if err == nil {
+			t.Fatalf("Expected an error, got next state with u=%v", next.Velocity.X)
+		}
+		if !strings.Contains(err.Error(), "velocities/u") {
+			t.Errorf("Error should name velocities/u: %v", err)
+		}
+	})
+	
+	t.Run("Forces Name The Originating Property", func(t *testing.T) {
+		calc := NewForcesMomentsCalculator(config)
+		state := newState()
+		state.Velocity.Z = math.Inf(1)
+		state.Alpha = math.NaN() // Derived from w, and what the tables see
+		
+		_, err := calc.CalculateForcesMoments(state)
+		var nonFinite *NonFiniteInputError
+		if !errors.As(err, &nonFinite) {
+			t.Fatalf("Expected a non-finite input error, got %v", err)
+		}
+		assertEqual(t, nonFinite.Property, "velocities/w-mps")
+		if !strings.Contains(err.Error(), "aero/alpha") {
+			t.Errorf("Error should also say where the value was detected: %v", err)
+		}
+	})
+}
+
 // TestAerodynamicAnalysis tests the aerodynamic analysis tools
 func TestAerodynamicAnalysis(t *testing.T) {
 	
@@ -435,7 +537,10 @@ func TestAerodynamicAnalysis(t *testing.T) {
 		baseState.UpdateAtmosphere()
 		baseState.UpdateDerivedParameters()
 		
-		analysis := calc.PerformAerodynamicAnalysis(baseState)
+		analysis, err := calc.PerformAerodynamicAnalysis(baseState)
+		if err != nil {
+			t.Fatalf("PerformAerodynamicAnalysis: %v", err)
+		}
 		
 		t.Logf("Aerodynamic Analysis Results:")
 		t.Logf("  Alpha range: %.1f° to %.1f°", 
@@ -490,10 +595,28 @@ func TestAerodynamicAnalysis(t *testing.T) {
 		
 		t.Logf("Performance by Altitude:")
 		for i, alt := range envelope.Altitudes {
-			t.Logf("  %.0fm: Max Speed %.1f m/s (%.1f kt), Climb %.1f m/s", 
+			t.Logf("  %.0fm: Max Speed %.1f m/s (%.1f kt), Climb %.1f m/s at %.0f m/s", 
 				alt, 
 				envelope.MaxSpeeds[i], envelope.MaxSpeeds[i]*MS_TO_KT,
-				envelope.ClimbRates[i])
+				envelope.ClimbRates[i], envelope.BestClimbSpeeds[i])
+		}
+		
+		// The best climb rate is the highest Ps over the airspeed sweep
+		ps, airspeed, ok := engine.bestClimb(envelope.Altitudes[0])
+		if !ok {
+			t.Fatal("Best climb sweep found no valid airspeed")
+		}
+		assertApproxEqual(t, envelope.ClimbRates[0], math.Max(ps, 0), 1e-12)
+		assertApproxEqual(t, envelope.BestClimbSpeeds[0], airspeed, 1e-12)
+		for v := envelopeMinSpeed; v <= envelopeMaxSpeed; v += envelopeSpeedStep {
+			state := levelFlightState(envelope.Altitudes[0], v)
+			components, err := engine.Calculator.CalculateForcesMoments(state)
+			if err != nil {
+				t.Fatalf("Forces at %.0f m/s: %v", v, err)
+			}
+			if components.SpecificExcessPower(v, engine.Calculator.Mass) > envelope.ClimbRates[0]+1e-12 {
+				t.Errorf("Ps at %.0f m/s exceeds the best climb rate %.2f m/s", v, envelope.ClimbRates[0])
+			}
 		}
 		
 		// Check reasonable performance values
@@ -585,3 +708,308 @@ func BenchmarkFlightDynamicsStep(b *testing.B) {
 		state = newState
 	}
 }
+
+// TestStandaloneAeroFunctions checks that functions declared outside the
+// axes are evaluated before the axis coefficients that read them
+func TestStandaloneAeroFunctions(t *testing.T) {
+	p51d := loadP51DConfig(t)
+	var kCLge *Function
+	for _, f := range p51d.Aerodynamics.Function {
+		if f.Name == "aero/function/kCLge" {
+			kCLge = f
+		}
+	}
+	if kCLge == nil {
+		t.Fatal("P-51D has no aero/function/kCLge")
+	}
+	
+	// The P-51D ground effect factor scaled by a later standalone
+	// function, feeding a lift coefficient
+	config := &JSBSimConfig{
+		Metrics: p51d.Metrics,
+		Aerodynamics: &Aerodynamics{
+			Function: []*Function{
+				kCLge,
+				{Name: "aero/function/kCLge-scaled", Product: &Operation{
+					Property: []string{"aero/function/kCLge"}, Value: []float64{2.0},
+				}},
+			},
+			Axis: []*Axis{{Name: "LIFT", Function: []*Function{
+				{Name: "aero/coefficient/CLtest", Product: &Operation{
+					Property: []string{"aero/qbar-psf", "aero/function/kCLge-scaled"}, Value: []float64{10.0},
+				}},
+			}}},
+		},
+	}
+	calc := NewForcesMomentsCalculator(config)
+	span := calc.Reference.WingSpan * FT_TO_M
+	
+	state := NewAircraftState()
+	state.Altitude = 500.0
+	state.Velocity = Vector3{X: 60.0, Y: 0, Z: 0}
+	state.UpdateAtmosphere()
+	state.UpdateDerivedParameters()
+	lift := func(agl float64) float64 {
+		state.Gear.GroundHeight = state.Altitude - agl
+		components, err := calc.CalculateForcesMoments(state)
+		if err != nil {
+			t.Fatalf("Force calculation failed: %v", err)
+		}
+		return -components.Aerodynamic.Lift
+	}
+	
+	// kCLge is 1.124 at h/b = 0.1 and 1.0 from h/b = 1.0 up
+	const LB_TO_N = 4.44822
+	freeAir := state.DynamicPressure * 0.020885 * 2.0 * 10.0 * LB_TO_N
+	assertApproxEqual(t, lift(10*span), freeAir, 1e-9*freeAir)
+	assertApproxEqual(t, lift(0.1*span)/freeAir, 1.124, 1e-9)
+	
+	t.Run("Failed Function Leaves No Value", func(t *testing.T) {
+		// A table made unreadable between steps: the property tree keeps no
+		// value from the step before for it or the coefficient reading it
+		table := &Table{
+			IndependentVar: []*IndependentVar{{Value: "aero/alpha-rad"}},
+			TableData:      []*TableData{{Data: "-1 5\n1 5"}},
+		}
+		config := &JSBSimConfig{
+			Metrics: p51d.Metrics,
+			Aerodynamics: &Aerodynamics{
+				Function: []*Function{{Name: "aero/function/k", Table: table}},
+				Axis: []*Axis{{Name: "LIFT", Function: []*Function{
+					{Name: "aero/coefficient/CLk", Product: &Operation{
+						Property: []string{"aero/function/k"}, Value: []float64{2.0},
+					}},
+				}}},
+			},
+		}
+		calc := NewForcesMomentsCalculator(config)
+		if _, err := calc.CalculateForcesMoments(state); err != nil {
+			t.Fatalf("Force calculation failed: %v", err)
+		}
+		assertEqual(t, calc.Properties.Get("aero/function/k"), 5.0)
+		assertEqual(t, calc.Properties.Get("aero/coefficient/CLk"), 10.0)
+		
+		table.IndependentVar = nil
+		if _, err := calc.CalculateForcesMoments(state); err != nil {
+			t.Fatalf("Force calculation failed: %v", err)
+		}
+		if value, ok := calc.Properties.GetSafe("aero/function/k"); ok {
+			t.Errorf("The failed function should have no value, has %v", value)
+		}
+		// Missing, the property drops out of the product
+		assertEqual(t, calc.Properties.Get("aero/coefficient/CLk"), 2.0)
+	})
+	
+	t.Run("Aerodynamic Analysis", func(t *testing.T) {
+		// The analysis evaluates the axes directly; it still sees kCLge
+		base := NewAircraftState()
+		base.Altitude = 1000.0
+		base.Velocity = Vector3{X: 60.0, Y: 0, Z: 0}
+		base.UpdateAtmosphere()
+		base.UpdateDerivedParameters()
+		analysis, err := calc.PerformAerodynamicAnalysis(base)
+		if err != nil {
+			t.Fatalf("PerformAerodynamicAnalysis: %v", err)
+		}
+		qS := base.DynamicPressure * calc.Reference.WingArea
+		assertApproxEqual(t, analysis.CLCurve[0], base.DynamicPressure*0.020885*20.0*LB_TO_N/qS, 1e-9)
+		
+		// A sweep that cannot be evaluated fails rather than reading zeros
+		nan := base.Copy()
+		nan.Density = math.NaN()
+		nan.DynamicPressure = math.NaN()
+		if _, err := calc.PerformAerodynamicAnalysis(nan); err == nil {
+			t.Error("An analysis at a non-finite state should fail")
+		}
+	})
+	
+	t.Run("Invalid Declarations", func(t *testing.T) {
+		parse := func(functions string) error {
+			xml := `<fdm_config><aerodynamics>` + functions + `</aerodynamics></fdm_config>`
+			_, err := ParseJSBSimConfig(strings.NewReader(xml))
+			return err
+		}
+		fn := func(name, reads string) string {
+			return `<function name="` + name + `"><product><property>` + reads +
+				`</property><value>2</value></product></function>`
+		}
+		
+		if err := parse(fn("a", "aero/qbar-psf") + fn("b", "a")); err != nil {
+			t.Errorf("Reading an earlier function should be allowed: %v", err)
+		}
+		
+		cases := []struct {
+			name, functions, want string
+		}{
+			{"Duplicate", fn("a", "aero/qbar-psf") + fn("a", "aero/mach"), "duplicate function name a"},
+			{"Self Reference", fn("a", "a"), "function cycle: a -> a"},
+			{"Cycle", fn("a", "c") + fn("b", "a") + fn("c", "b"), "function cycle: a -> c -> b -> a"},
+			{"Forward Reference", fn("a", "b") + fn("b", "aero/mach"), "function a reads b, which is declared after it"},
+		}
+		for _, c := range cases {
+			err := parse(c.functions)
+			if err == nil || !strings.Contains(err.Error(), c.want) {
+				t.Errorf("%s: expected error containing %q, got %v", c.name, c.want, err)
+			}
+		}
+	})
+}
+
+func TestPropellerEffects(t *testing.T) {
+	calc := NewForcesMomentsCalculator(loadP51DConfig(t))
+	prop := calc.Propeller
+	assertEqual(t, prop.Sense, 1.0)
+	assertEqual(t, prop.PFactor, 60.0)
+	
+	// A thruster without a p_factor keeps the P-51D's
+	bare := &JSBSimConfig{Propulsion: &Propulsion{Engine: []*Engine{{Thruster: &Thruster{File: "prop", Sense: -1}}}}}
+	assertEqual(t, newConfigPropeller(bare).PFactor, P51DPropellerPFactor)
+	assertEqual(t, newConfigPropeller(bare).Sense, -1.0)
+	
+	// Full power at low speed
+	state := NewAircraftState()
+	state.Altitude = 500.0
+	state.Velocity = Vector3{X: 60.0, Y: 0, Z: 0}
+	state.Controls.Throttle = 1.0
+	state.UpdateAtmosphere()
+	state.UpdateDerivedParameters()
+	
+	yaw := func(effects PropellerEffects) (float64, *ForceMomentComponents) {
+		calc.PropellerEffects = effects
+		components, err := calc.CalculateForcesMoments(state)
+		if err != nil {
+			t.Fatalf("Force calculation failed: %v", err)
+		}
+		return components.Moments.Yaw, components
+	}
+	
+	t.Run("Gyroscopic Yaw When Pitching Up", func(t *testing.T) {
+		state.AngularRate = Vector3{X: 0, Y: 1.0, Z: 0}
+		with, components := yaw(PropellerEffects{Gyroscopic: true})
+		without, _ := yaw(PropellerEffects{})
+		
+		// Clockwise propeller: nose right
+		h := prop.Inertia * prop.RPM * 2.0 * math.Pi / 60.0
+		assertApproxEqual(t, with-without, h*1.0, 1e-9*h)
+		if components.Propulsion.Moment.Z <= 0 {
+			t.Errorf("Pitching up should yaw the nose right, got %.1f N·m", components.Propulsion.Moment.Z)
+		}
+		
+		// Counter-clockwise propeller reverses it
+		prop.Sense = -1.0
+		reversed, _ := yaw(PropellerEffects{Gyroscopic: true})
+		prop.Sense = 1.0
+		assertApproxEqual(t, reversed-without, -h, 1e-9*h)
+	})
+	
+	t.Run("P-Factor", func(t *testing.T) {
+		state.AngularRate = Vector3{}
+		alpha := 0.15
+		state.Velocity = Vector3{X: 60.0 * math.Cos(alpha), Y: 0, Z: -60.0 * math.Sin(alpha)}
+		state.UpdateDerivedParameters()
+		
+		with, components := yaw(PropellerEffects{PFactor: true})
+		without, _ := yaw(PropellerEffects{})
+		
+		// Thrust acts 60 in per radian right of the axis: nose left
+		offset := 60.0 * 0.0254 * state.Alpha
+		assertApproxEqual(t, with-without, -offset*components.Propulsion.Thrust, 1e-4*offset*components.Propulsion.Thrust)
+		if with >= without {
+			t.Errorf("Alpha at full power should yaw the nose left: %.1f vs %.1f N·m", with, without)
+		}
+	})
+}
+
+func TestAsymmetricStores(t *testing.T) {
+	symmetric := loadP51DConfig(t)
+	asymmetric := loadP51DConfig(t)
+	
+	// The left wing tank empty and the right one full
+	asymmetric.Propulsion.Tank[0].Contents.Value = 0
+	
+	t.Run("Mass Properties", func(t *testing.T) {
+		_, cg, ok := configMassProperties(symmetric)
+		if !ok {
+			t.Fatal("No mass properties")
+		}
+		assertApproxEqual(t, cg.Y, 0.0, 1e-12)
+		
+		_, cg, _ = configMassProperties(asymmetric)
+		if cg.Y <= 0 {
+			t.Errorf("A full right tank should move the CG right, got %.4f m", cg.Y)
+		}
+		
+		calc := NewForcesMomentsCalculator(asymmetric)
+		state := NewAircraftState()
+		components, err := calc.CalculateForcesMoments(state)
+		if err != nil {
+			t.Fatalf("CalculateForcesMoments: %v", err)
+		}
+		properties := map[string]float64{}
+		calc.addMassProperties(properties)
+		assertApproxEqual(t, properties["inertia/cg-y-in"], cg.Y*M_TO_FT*FT_TO_IN, 1e-9)
+		
+		// The weight off the centreline rolls the right wing down
+		if components.Gravity.Moment.X <= 0 {
+			t.Errorf("Expected a right roll moment from the weight, got %.1f N·m", components.Gravity.Moment.X)
+		}
+	})
+	
+	t.Run("Trim", func(t *testing.T) {
+		controls, err := NewTrimCalculator(NewFlightDynamicsEngine(symmetric, NewEulerIntegrator())).FindTrim(120, 3000)
+		if err != nil {
+			t.Fatalf("FindTrim: %v", err)
+		}
+		assertApproxEqual(t, controls.Aileron, 0.0, 1e-12)
+		assertApproxEqual(t, controls.Rudder, 0.0, 1e-12)
+		
+		controls, err = NewTrimCalculator(NewFlightDynamicsEngine(asymmetric, NewEulerIntegrator())).FindTrim(120, 3000)
+		if err != nil {
+			t.Fatalf("FindTrim: %v", err)
+		}
+		if controls.Aileron >= 0 {
+			t.Errorf("A heavy right wing needs left aileron, got %.4f", controls.Aileron)
+		}
+		if math.Abs(controls.Aileron) > 1 || math.Abs(controls.Rudder) > 1 {
+			t.Errorf("Trim beyond full travel: aileron %.4f, rudder %.4f", controls.Aileron, controls.Rudder)
+		}
+	})
+	
+	t.Run("Wings Level", func(t *testing.T) {
+		// The simplified model carrying the same offset, trimmed and flown
+		// hands off. Its pitch departs after about 8 s whatever the lateral
+		// trim, so it is flown for 6 s, in which the untrimmed wing drops
+		// two degrees.
+		_, cg, _ := configMassProperties(asymmetric)
+		fly := func(lateral bool) (maxBank float64) {
+			engine := NewSimplifiedFlightDynamicsEngine(NewRungeKutta4Integrator())
+			engine.Calculator.LateralCG = cg.Y
+			state := trimLevelFlight(t, engine, 3000, 100)
+			if lateral {
+				aileron, rudder, err := engine.Calculator.LateralTrim(state)
+				if err != nil {
+					t.Fatalf("LateralTrim: %v", err)
+				}
+				if aileron >= 0 {
+					t.Errorf("A heavy right wing needs left aileron, got %.4f", aileron)
+				}
+				state.Controls.Aileron, state.Controls.Rudder = aileron, rudder
+			}
+			for i := 0; i < 3000; i++ {
+				next, err := engine.Step(state, 0.002)
+				if err != nil {
+					t.Fatalf("Step %d: %v", i, err)
+				}
+				state = next
+				maxBank = math.Max(maxBank, math.Abs(state.Roll))
+			}
+			return maxBank
+		}
+		if bank := fly(true); bank > 0.1*DEG_TO_RAD {
+			t.Errorf("Trimmed, the wings should stay level, banked %.2f°", bank*RAD_TO_DEG)
+		}
+		if bank := fly(false); bank < 1*DEG_TO_RAD {
+			t.Errorf("Untrimmed, the heavy wing should drop, banked only %.2f°", bank*RAD_TO_DEG)
+		}
+	})
+}
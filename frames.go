@@ -0,0 +1,143 @@
+// Reference Frames
+// The frames positions and directions are given in, and the conversions
+// between them.
+//
+// Structural frame: the frame of a JSBSim configuration's <location>
+// elements. X is positive aft, Y positive out the right wing and Z
+// positive up, about an arbitrary origin, usually the nose or firewall.
+// Locations are usually in inches; their unit attribute gives it.
+//
+// Body frame: the frame of the dynamics. X is positive forward, Y positive
+// out the right wing and Z positive down, about the CG, in meters. Forces,
+// moments, velocities and rates are in this frame, and every location a
+// moment arm is taken from is converted to it with StructuralToBody. The CG
+// is the configuration's mass_balance location.
+//
+// Local frame: north, east and down (NED) about a point on the ground, in
+// meters. AircraftState.Orientation rotates body vectors into it.
+//
+// Wind frame: X along the relative wind's velocity, Z down in the plane of
+// symmetry, about the CG. Drag is the force along -X, lift along -Z and the
+// side force along Y, as JSBSim's DRAG, LIFT and SIDE axes are. The
+// stability frame is the body frame rotated by alpha alone, so its X axis
+// is the velocity projected onto the plane of symmetry.
+//
+// An <orient> element gives the roll, pitch and yaw of a thruster's axis
+// from the body X axis, in the body frame's sense: positive pitch tilts the
+// axis up and positive yaw to the right.
+
+package main
+
+import "math"
+
+// StructuralPosition returns a structural location in meters, still in
+// structural axes, or zero when loc is nil
+func StructuralPosition(loc *Location) Vector3 {
+	if loc == nil {
+		return Vector3{}
+	}
+	// Unknown location units are reported when the configuration is parsed
+	toMeters := func(value float64) float64 {
+		feet, _ := convertToStandardUnit(value, loc.Unit, "length")
+		return feet * FT_TO_M
+	}
+	return Vector3{X: toMeters(loc.X), Y: toMeters(loc.Y), Z: toMeters(loc.Z)}
+}
+
+// StructuralToBody converts a structural location to body axes about the
+// CG, given as a structural position in meters (see StructuralPosition)
+func StructuralToBody(loc *Location, cgStructural Vector3) Vector3 {
+	d := StructuralPosition(loc).Sub(cgStructural)
+	return Vector3{X: -d.X, Y: d.Y, Z: -d.Z}
+}
+
+// configCG returns the structural position of a configuration's CG in
+// meters, or the structural origin when it has none
+func configCG(config *JSBSimConfig) Vector3 {
+	if config == nil || config.MassBalance == nil {
+		return Vector3{}
+	}
+	return StructuralPosition(config.MassBalance.Location)
+}
+
+// OrientAxis returns the body-axis unit vector along the X axis of an
+// orientation, such as a thruster's line of thrust. A nil orientation is
+// the body X axis. Roll about the axis does not move it.
+func OrientAxis(o *Orient) Vector3 {
+	if o == nil {
+		return Vector3{X: 1}
+	}
+	return NewQuaternionFromEuler(o.Roll, o.Pitch, o.Yaw).RotateVector(Vector3{X: 1})
+}
+
+// AirflowAngles returns the angle of attack and sideslip (rad) of a body
+// velocity that rotate the body frame into the wind frame. Alpha is
+// positive with the velocity's Z component, the relative wind from below,
+// which is the opposite sign to AircraftState.Alpha.
+func AirflowAngles(velocity Vector3) (alpha, beta float64) {
+	speed := velocity.Magnitude()
+	if speed == 0 {
+		return 0, 0
+	}
+	return math.Atan2(velocity.Z, velocity.X), math.Asin(math.Max(-1, math.Min(1, velocity.Y/speed)))
+}
+
+// BodyToWind resolves a body-axis vector into wind axes
+func BodyToWind(v Vector3, alpha, beta float64) Vector3 {
+	ca, sa := math.Cos(alpha), math.Sin(alpha)
+	cb, sb := math.Cos(beta), math.Sin(beta)
+	return Vector3{
+		X: ca*cb*v.X + sb*v.Y + sa*cb*v.Z,
+		Y: -ca*sb*v.X + cb*v.Y - sa*sb*v.Z,
+		Z: -sa*v.X + ca*v.Z,
+	}
+}
+
+// WindToBody resolves a wind-axis vector into body axes, the inverse of
+// BodyToWind
+func WindToBody(v Vector3, alpha, beta float64) Vector3 {
+	ca, sa := math.Cos(alpha), math.Sin(alpha)
+	cb, sb := math.Cos(beta), math.Sin(beta)
+	return Vector3{
+		X: ca*cb*v.X - ca*sb*v.Y - sa*v.Z,
+		Y: sb*v.X + cb*v.Y,
+		Z: sa*cb*v.X - sa*sb*v.Y + ca*v.Z,
+	}
+}
+
+// BodyToStability resolves a body-axis vector into stability axes
+func BodyToStability(v Vector3, alpha float64) Vector3 {
+	return BodyToWind(v, alpha, 0)
+}
+
+// StabilityToBody resolves a stability-axis vector into body axes
+func StabilityToBody(v Vector3, alpha float64) Vector3 {
+	return WindToBody(v, alpha, 0)
+}
+
+// WindAxesForces returns the aerodynamic force of a breakdown in wind axes
+// at a state: the lift, drag and side force (N), with lift and drag
+// perpendicular and opposite to the relative wind
+func WindAxesForces(state *AircraftState, components *ForceMomentComponents) (lift, drag, side float64) {
+	alpha, beta := AirflowAngles(state.Velocity)
+	wind := BodyToWind(components.aerodynamicForce(), alpha, beta)
+	return -wind.Z, -wind.X, wind.Y
+}
+
+// StabilityAxesForces returns the aerodynamic force of a breakdown in
+// stability axes at a state: the lift, which is the wind-axis lift, the
+// drag along the velocity in the plane of symmetry, and the body side force
+// (N)
+func StabilityAxesForces(state *AircraftState, components *ForceMomentComponents) (lift, drag, side float64) {
+	alpha, _ := AirflowAngles(state.Velocity)
+	stability := BodyToStability(components.aerodynamicForce(), alpha)
+	return -stability.Z, -stability.X, stability.Y
+}
+
+// WindForcesToBody returns the body-axis force of a lift, drag and side
+// force (N) given in wind axes at a state, as wind-axis coefficient models
+// give them
+func WindForcesToBody(state *AircraftState, lift, drag, side float64) Vector3 {
+	alpha, beta := AirflowAngles(state.Velocity)
+	return WindToBody(Vector3{X: -drag, Y: side, Z: -lift}, alpha, beta)
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// inch is the length of an inch in meters, as locations are converted
+const inch = IN_TO_FT * FT_TO_M
+
+func TestStructuralToBody(t *testing.T) {
+	cg := StructuralPosition(&Location{Unit: "IN", X: 98, Z: -9})
+	assertVectorsEqual(t, cg, Vector3{X: 98 * inch, Z: -9 * inch}, 1e-12)
+	assertEqual(t, StructuralPosition(nil), Vector3{})
+
+	// Aft, right and up in the structural frame is aft, right and up in
+	// the body frame, about the CG
+	body := StructuralToBody(&Location{Unit: "IN", X: 108, Y: 20, Z: 1}, cg)
+	assertVectorsEqual(t, body, Vector3{X: -10 * inch, Y: 20 * inch, Z: -10 * inch}, 1e-12)
+
+	// Units other than inches
+	assertVectorsEqual(t, StructuralToBody(&Location{Unit: "FT", X: 10}, Vector3{}), Vector3{X: -10 * FT_TO_M}, 1e-12)
+	assertVectorsEqual(t, StructuralToBody(&Location{Unit: "M", Z: 2}, Vector3{}), Vector3{Z: -2}, 1e-6)
+
+	t.Run("Gear Moment", func(t *testing.T) {
+		// A main gear aft of and below the CG
+		config := &JSBSimConfig{
+			MassBalance: &MassBalance{Location: &Location{Unit: "IN", X: 98, Z: -9}},
+			GroundReactions: &GroundReactions{Contact: []*Contact{{
+				Type:        "BOGEY",
+				Name:        "MAIN",
+				Location:    &Location{Unit: "IN", X: 110, Z: -80},
+				SpringCoeff: &Measurement{Unit: "N/M", Value: 100000},
+			}}},
+		}
+		gear := NewLandingGear(config)
+		unit := gear.Units[0].Location
+		if unit.X >= 0 || unit.Z <= 0 {
+			t.Fatalf("Gear aft of and below the CG should be at -X, +Z in body axes, got %v", unit)
+		}
+
+		// Level, the wheel 5 cm into the ground
+		state := NewAircraftState()
+		state.Orientation = NewQuaternionFromEuler(0, 0, 0)
+		state.Velocity = Vector3{}
+		state.Altitude = 100 - unit.Z + 0.05
+		state.Gear.GroundHeight = 100
+		gear.Update(state)
+		force, moment := gear.Forces(state)
+		if force.Z >= 0 {
+			t.Fatalf("The ground should push the gear up, got %v", force)
+		}
+		if moment.Y >= 0 {
+			t.Errorf("An upward force aft of the CG should pitch the nose down, got %v", moment)
+		}
+	})
+
+	t.Run("P-51D Engine", func(t *testing.T) {
+		config := loadP51DConfig(t)
+		location, axis := configThrustLine(config)
+
+		// Station 36 in is 62 in forward of the CG at 98 in, and 9 in above it
+		assertApproxEqual(t, location.X, 62*inch, 1e-9)
+		assertApproxEqual(t, location.Z, -9*inch, 1e-9)
+
+		// Pitched up 2.5°, the thrust tilts up; the roll does not move it
+		assertApproxEqual(t, axis.X, math.Cos(2.5*DEG_TO_RAD), 1e-12)
+		assertApproxEqual(t, axis.Y, 0.0, 1e-12)
+		assertApproxEqual(t, axis.Z, -math.Sin(2.5*DEG_TO_RAD), 1e-12)
+
+		calc := NewForcesMomentsCalculator(config)
+		assertEqual(t, calc.ThrustLocation, location)
+		assertVectorsEqual(t, NewPropulsionSystem().Position, location, 1e-12)
+		assertVectorsEqual(t, calc.AeroReference, Vector3{X: -inch, Z: 17.5 * inch}, 1e-12)
+
+		// Thrust above the CG and pitched up: its moment is that of the
+		// force at its location
+		components := &ForceMomentComponents{}
+		components.Propulsion.Thrust = 1000
+		calc.addOffsetMoments(components)
+		thrust := axis.Scale(1000)
+		assertApproxEqual(t, components.Moments.Pitch, location.Cross(thrust).Y, 1e-9)
+		if components.Moments.Pitch >= 0 {
+			t.Errorf("Thrust 9 in above the CG should pitch the nose down, got %g N·m", components.Moments.Pitch)
+		}
+	})
+}
+
+func TestWindAxes(t *testing.T) {
+	t.Run("Rotation", func(t *testing.T) {
+		// Relative wind from 10° below the nose: 1000 N of lift and 100 N of
+		// drag resolve into the body axes as X = L·sin α − D·cos α and
+		// Z = −L·cos α − D·sin α
+		alpha := 10 * DEG_TO_RAD
+		state := NewAircraftState()
+		state.Velocity = Vector3{X: 100 * math.Cos(alpha), Z: 100 * math.Sin(alpha)}
+		components := &ForceMomentComponents{}
+		components.Aerodynamic.Drag = 1000*math.Sin(alpha) - 100*math.Cos(alpha)
+		components.Aerodynamic.Lift = -1000*math.Cos(alpha) - 100*math.Sin(alpha)
+
+		lift, drag, side := WindAxesForces(state, components)
+		assertApproxEqual(t, lift, 1000, 1e-10)
+		assertApproxEqual(t, drag, 100, 1e-10)
+		assertApproxEqual(t, side, 0, 1e-10)
+
+		// Without sideslip the stability axes are the wind axes
+		stabilityLift, stabilityDrag, _ := StabilityAxesForces(state, components)
+		assertApproxEqual(t, stabilityLift, lift, 1e-10)
+		assertApproxEqual(t, stabilityDrag, drag, 1e-10)
+
+		// The body-axis X component alone is a thrust, not a drag
+		if components.Aerodynamic.Drag <= 0 {
+			t.Errorf("At 10° the lift should tilt the body X force forward, got %.1f N", components.Aerodynamic.Drag)
+		}
+	})
+
+	t.Run("Inverse", func(t *testing.T) {
+		state := NewAircraftState()
+		state.Velocity = Vector3{X: 90, Y: 12, Z: 15}
+		alpha, beta := AirflowAngles(state.Velocity)
+		assertApproxEqual(t, alpha, math.Atan2(15, 90), 1e-15)
+
+		// The velocity lies along the wind X axis, and a wind-axis force
+		// round trips through the body axes
+		wind := BodyToWind(state.Velocity, alpha, beta)
+		assertApproxEqual(t, wind.X, state.Velocity.Magnitude(), 1e-12)
+		assertApproxEqual(t, wind.Y, 0, 1e-12)
+		assertApproxEqual(t, wind.Z, 0, 1e-12)
+
+		body := WindForcesToBody(state, 5000, 400, -150)
+		components := &ForceMomentComponents{}
+		components.Aerodynamic.Drag, components.Aerodynamic.Side, components.Aerodynamic.Lift = body.X, body.Y, body.Z
+		lift, drag, side := WindAxesForces(state, components)
+		assertApproxEqual(t, lift, 5000, 1e-9)
+		assertApproxEqual(t, drag, 400, 1e-9)
+		assertApproxEqual(t, side, -150, 1e-9)
+		assertApproxEqual(t, -body.Dot(state.Velocity.Normalize()), 400, 1e-9)
+
+		// The stability axes keep the body side force and resolve the drag
+		// along the velocity projected onto the plane of symmetry
+		_, stabilityDrag, stabilitySide := StabilityAxesForces(state, components)
+		assertApproxEqual(t, stabilitySide, body.Y, 1e-12)
+		assertApproxEqual(t, stabilityDrag, -BodyToStability(body, alpha).X, 1e-12)
+		assertApproxEqual(t, StabilityToBody(BodyToStability(body, alpha), alpha).Z, body.Z, 1e-9)
+	})
+
+	t.Run("Analysis Drag", func(t *testing.T) {
+		// The fallback model's lift tilts with the velocity; resolved in the
+		// body axes it would read as a negative drag at high alpha
+		config, err := ParseJSBSimConfig(strings.NewReader(metricsOnlyConfig))
+		if err != nil {
+			t.Fatalf("Parse failed: %v", err)
+		}
+		calc := NewForcesMomentsCalculator(config)
+		analysis, err := calc.PerformAerodynamicAnalysis(cruiseState(1000, 60, 0))
+		if err != nil {
+			t.Fatalf("PerformAerodynamicAnalysis: %v", err)
+		}
+		for i, cd := range analysis.CDCurve {
+			if cd <= 0 {
+				t.Errorf("α=%.0f°: CD %.4f should be positive", analysis.AlphaRange[i]*RAD_TO_DEG, cd)
+			}
+		}
+	})
+
+	t.Run("Statistics", func(t *testing.T) {
+		engine := NewFlightDynamicsEngine(loadP51DConfig(t), NewRungeKutta4Integrator())
+		state := cruiseState(1500, 100, 1.417*DEG_TO_RAD)
+		components, err := engine.Calculator.CalculateForcesMoments(state)
+		if err != nil {
+			t.Fatalf("CalculateForcesMoments: %v", err)
+		}
+		lift, drag, _ := WindAxesForces(state, components)
+		assertApproxEqual(t, components.Wind.Lift, lift, 1e-9)
+		assertApproxEqual(t, components.Wind.Drag, drag, 1e-9)
+		assertApproxEqual(t, components.Wind.Drag, components.FlightPath.Drag, 1e-9)
+
+		if _, err := engine.Step(state, 0.01); err != nil {
+			t.Fatalf("Step: %v", err)
+		}
+		stats := engine.Statistics
+		assertApproxEqual(t, stats.LiftToDrag, lift/drag, 1e-9)
+		assertEqual(t, stats.MaxLiftToDrag, stats.LiftToDrag)
+		assertApproxEqual(t, stats.MaxLiftToDragSpeed, 100, 0.01)
+		if !strings.Contains(engine.GetPerformanceReport(), "Max L/D") {
+			t.Error("The performance report should give the best L/D")
+		}
+	})
+}
@@ -0,0 +1,401 @@
+// Frequency Response Analyzer
+// Injects a frequency sweep or doublet on a control axis while the
+// simulation runs and estimates the gain, phase and coherence from the
+// input to an output property, for FCS validation
+
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"math/cmplx"
+	"strconv"
+	"strings"
+)
+
+// SweepKind selects the excitation signal
+type SweepKind int
+
+const (
+	SweepChirp   SweepKind = iota // Exponential sweep from FStart to FEnd
+	SweepDoublet                  // One square doublet, broadband
+)
+
+// String returns the sweep kind name
+func (k SweepKind) String() string {
+	switch k {
+	case SweepChirp:
+		return "chirp"
+	case SweepDoublet:
+		return "doublet"
+	}
+	return "unknown"
+}
+
+// SweepParameters describes the excitation
+type SweepParameters struct {
+	Kind      SweepKind
+	FStart    float64 // Lowest frequency analysed (Hz)
+	FEnd      float64 // Highest frequency analysed (Hz)
+	Amplitude float64 // In the units of the input property
+	Duration  float64 // Length of the run (s)
+}
+
+// Signal returns the excitation at time t from the start of the sweep. The
+// chirp spends equal time in each octave. The doublet's pulses are half a
+// period at the geometric mean frequency wide, its energy centred on the
+// band, and it is followed by a quiet run for the response to decay.
+func (p SweepParameters) Signal(t float64) float64 {
+	if t < 0 || t > p.Duration {
+		return 0
+	}
+	switch p.Kind {
+	case SweepChirp:
+		rate := math.Log(p.FEnd/p.FStart) / p.Duration
+		phase := 2 * math.Pi * p.FStart * (math.Exp(rate*t) - 1) / rate
+		return p.Amplitude * math.Sin(phase)
+	case SweepDoublet:
+		width := 0.5 / math.Sqrt(p.FStart*p.FEnd)
+		switch {
+		case t < width:
+			return p.Amplitude
+		case t < 2*width:
+			return -p.Amplitude
+		}
+	}
+	return 0
+}
+
+// validate checks that the sweep can be run and analysed at dt
+func (p SweepParameters) validate(dt float64) error {
+	if p.FStart <= 0 || p.FEnd <= p.FStart {
+		return fmt.Errorf("sweep needs 0 < f_start < f_end, got %g and %g Hz", p.FStart, p.FEnd)
+	}
+	if p.Amplitude == 0 {
+		return fmt.Errorf("sweep has no amplitude")
+	}
+	if p.Duration < 2/p.FStart {
+		return fmt.Errorf("sweep of %g s is shorter than two periods at %g Hz", p.Duration, p.FStart)
+	}
+	if nyquist := 0.5 / dt; p.FEnd > 0.5*nyquist {
+		return fmt.Errorf("f_end %g Hz is above half the Nyquist frequency %g Hz of the %g s step", p.FEnd, nyquist, dt)
+	}
+	if p.Kind != SweepChirp && p.Kind != SweepDoublet {
+		return fmt.Errorf("unknown sweep kind %d", p.Kind)
+	}
+	return nil
+}
+
+// FrequencyResponseAnalyzer measures the response of an output property to
+// an excitation added to a pilot command. Controls, when set, is called
+// before each step to set the commands, such as the trim inputs or a
+// stability loop closed around the aircraft; the excitation is added to the
+// input command it leaves.
+type FrequencyResponseAnalyzer struct {
+	Engine MonteCarloEngine
+	Input  string // Pilot command property, such as fcs/elevator-cmd-norm
+	Output string // State property, such as velocities/q-rad_sec
+	Sweep  SweepParameters
+	Dt     float64 // s
+
+	// Points is the number of frequencies analysed, log spaced over the
+	// sweep band
+	Points int
+
+	Controls func(state *AircraftState)
+
+	// WarmStart is the run-in given to an engine that is a WarmStarter (s):
+	// DefaultWarmStartDuration when zero, none when negative
+	WarmStart float64
+}
+
+// NewFrequencyResponseAnalyzer creates an analyzer stepping at 100 Hz with
+// 50 frequency points
+func NewFrequencyResponseAnalyzer(engine MonteCarloEngine, input, output string, sweep SweepParameters) *FrequencyResponseAnalyzer {
+	return &FrequencyResponseAnalyzer{
+		Engine: engine,
+		Input:  input,
+		Output: output,
+		Sweep:  sweep,
+		Dt:     0.01,
+		Points: 50,
+	}
+}
+
+// FrequencyResponse is the measured response, with the time histories it
+// was estimated from
+type FrequencyResponse struct {
+	Input, Output string
+	Sweep         SweepParameters
+
+	Frequency []float64 // Hz
+	Gain      []float64 // dB
+	Phase     []float64 // deg, unwrapped from the lowest frequency
+	Coherence []float64 // 0 to 1
+
+	Times      []float64 // Step start times (s)
+	Excitation []float64 // Excitation added to the input over each step
+	Response   []float64 // Output at the end of each step
+}
+
+// commandField returns the control input written as a pilot command
+// property, or nil if the property is not one
+func commandField(controls *ControlInputs, property string) *float64 {
+	switch property {
+	case "fcs/aileron-cmd-norm":
+		return &controls.Aileron
+	case "fcs/elevator-cmd-norm":
+		return &controls.Elevator
+	case "fcs/rudder-cmd-norm":
+		return &controls.Rudder
+	case "fcs/throttle-cmd-norm":
+		return &controls.Throttle
+	case "fcs/mixture-cmd-norm":
+		return &controls.Mixture
+	case "fcs/advance-cmd-norm":
+		return &controls.Propeller
+	case "fcs/flap-cmd-norm":
+		return &controls.Flaps
+	case "fcs/steer-cmd-norm":
+		return &controls.Steer
+	}
+	return nil
+}
+
+// Run simulates the sweep from state, which is not modified, and estimates
+// the response
+func (fra *FrequencyResponseAnalyzer) Run(state *AircraftState) (*FrequencyResponse, error) {
+	if fra.Engine == nil {
+		return nil, fmt.Errorf("frequency response needs an engine")
+	}
+	if fra.Dt <= 0 {
+		return nil, fmt.Errorf("frequency response needs a positive time step, got %g", fra.Dt)
+	}
+	if err := fra.Sweep.validate(fra.Dt); err != nil {
+		return nil, err
+	}
+	if commandField(&state.Controls, fra.Input) == nil {
+		return nil, fmt.Errorf("input %s is not a pilot command property", fra.Input)
+	}
+	properties := state.ToPropertyMap()
+	if _, ok := properties[fra.Output]; !ok {
+		return nil, fmt.Errorf("output %s is not a state property", fra.Output)
+	}
+
+	state = state.Copy()
+	if err := fra.warmStart(state); err != nil {
+		return nil, err
+	}
+
+	result := &FrequencyResponse{Input: fra.Input, Output: fra.Output, Sweep: fra.Sweep}
+	start := state.Time
+	steps := int(math.Round(fra.Sweep.Duration / fra.Dt))
+	for i := 0; i < steps; i++ {
+		if fra.Controls != nil {
+			fra.Controls(state)
+		}
+		t := state.Time - start
+		excitation := fra.Sweep.Signal(t + 0.5*fra.Dt)
+		command := commandField(&state.Controls, fra.Input)
+		base := *command
+		*command = base + excitation
+
+		newState, err := fra.Engine.Step(state, fra.Dt)
+		if err != nil {
+			return nil, fmt.Errorf("step %d at t=%.3f s: %w", i, t, err)
+		}
+
+		// The excitation does not carry over into the next step's command
+		state = newState
+		*commandField(&state.Controls, fra.Input) = base
+		state.FillPropertyMap(properties)
+
+		result.Times = append(result.Times, t)
+		result.Excitation = append(result.Excitation, excitation)
+		result.Response = append(result.Response, properties[fra.Output])
+	}
+
+	fra.analyse(result)
+	return result, nil
+}
+
+// warmStart runs the engine in at the initial state, when it can be
+func (fra *FrequencyResponseAnalyzer) warmStart(state *AircraftState) error {
+	starter, ok := fra.Engine.(WarmStarter)
+	duration := fra.WarmStart
+	if duration == 0 {
+		duration = DefaultWarmStartDuration
+	}
+	if !ok || duration < 0 {
+		return nil
+	}
+	if fra.Controls != nil {
+		fra.Controls(state)
+	}
+	if _, err := starter.WarmStart(state, duration); err != nil {
+		return fmt.Errorf("warm start failed: %w", err)
+	}
+	return nil
+}
+
+// analyse estimates the response at log spaced frequencies by averaging
+// the cross spectra of segments of the run (Welch's method), evaluated
+// directly at each frequency. The excitation is held
+// over each step, so it is taken at the middle of the step and the response
+// at its end. A chirp is cut into Hann windowed segments four periods of
+// FStart long, or half the run if that is shorter, overlapping by about half and spread to
+// cover the whole run. A doublet is analysed as one unwindowed segment, as
+// the run starts and should end at rest; its coherence is 1 by
+// construction.
+func (fra *FrequencyResponseAnalyzer) analyse(result *FrequencyResponse) {
+	n := len(result.Times)
+	dt := fra.Dt
+	length := n
+	if fra.Sweep.Kind == SweepChirp {
+		length = int(math.Min(4/fra.Sweep.FStart, 0.5*fra.Sweep.Duration) / dt)
+	}
+	window := make([]float64, length)
+	for k := range window {
+		window[k] = 1
+		if fra.Sweep.Kind == SweepChirp {
+			window[k] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(k)/float64(length-1))
+		}
+	}
+
+	// Segments overlap by about half, the last ending with the run
+	count := 1
+	if length < n {
+		count = int(math.Ceil(float64(n-length)/float64(length/2))) + 1
+	}
+	starts := make([]int, count)
+	for i := 1; i < count; i++ {
+		starts[i] = i * (n - length) / (count - 1)
+	}
+
+	points := fra.Points
+	if points < 2 {
+		points = 2
+	}
+	ratio := math.Pow(fra.Sweep.FEnd/fra.Sweep.FStart, 1/float64(points-1))
+	previousPhase := 0.0
+	for j := 0; j < points; j++ {
+		f := fra.Sweep.FStart * math.Pow(ratio, float64(j))
+		omega := 2 * math.Pi * f
+
+		var sxx, syy float64
+		var sxy complex128
+		for _, first := range starts {
+			x := windowedDFT(result.Excitation[first:first+length], window, omega, dt, 0.5*dt)
+			y := windowedDFT(result.Response[first:first+length], window, omega, dt, dt)
+			sxx += real(x * cmplx.Conj(x))
+			syy += real(y * cmplx.Conj(y))
+			sxy += cmplx.Conj(x) * y
+		}
+
+		h := sxy / complex(sxx, 0)
+		phase := cmplx.Phase(h) * RAD_TO_DEG
+		if j > 0 {
+			phase -= 360 * math.Round((phase-previousPhase)/360)
+		}
+		previousPhase = phase
+
+		coherence := 0.0
+		if sxx > 0 && syy > 0 {
+			coherence = real(sxy*cmplx.Conj(sxy)) / (sxx * syy)
+		}
+		result.Frequency = append(result.Frequency, f)
+		result.Gain = append(result.Gain, 20*math.Log10(cmplx.Abs(h)))
+		result.Phase = append(result.Phase, phase)
+		result.Coherence = append(result.Coherence, coherence)
+	}
+}
+
+// windowedDFT returns the Fourier component at omega of a windowed segment,
+// less its mean, with sample k at time k·dt + offset
+func windowedDFT(samples, window []float64, omega, dt, offset float64) complex128 {
+	mean := 0.0
+	for _, v := range samples {
+		mean += v
+	}
+	mean /= float64(len(samples))
+
+	var sum complex128
+	for k, v := range samples {
+		t := float64(k)*dt + offset
+		sum += complex(window[k]*(v-mean), 0) * cmplx.Exp(complex(0, -omega*t))
+	}
+	return sum
+}
+
+// Peak returns the frequency and gain of the highest gain measured
+func (r *FrequencyResponse) Peak() (frequency, gain float64) {
+	best := -1
+	for i, g := range r.Gain {
+		if best < 0 || g > r.Gain[best] {
+			best = i
+		}
+	}
+	if best < 0 {
+		return 0, math.Inf(-1)
+	}
+	return r.Frequency[best], r.Gain[best]
+}
+
+// At returns the gain (dB) and phase (deg) at frequency f (Hz),
+// interpolated in log frequency and held beyond the band
+func (r *FrequencyResponse) At(f float64) (gain, phase float64) {
+	n := len(r.Frequency)
+	if n == 0 {
+		return math.NaN(), math.NaN()
+	}
+	if f <= r.Frequency[0] {
+		return r.Gain[0], r.Phase[0]
+	}
+	for i := 1; i < n; i++ {
+		if f <= r.Frequency[i] {
+			x := math.Log(f/r.Frequency[i-1]) / math.Log(r.Frequency[i]/r.Frequency[i-1])
+			return r.Gain[i-1] + x*(r.Gain[i]-r.Gain[i-1]), r.Phase[i-1] + x*(r.Phase[i]-r.Phase[i-1])
+		}
+	}
+	return r.Gain[n-1], r.Phase[n-1]
+}
+
+// WriteCSV writes one row per frequency: frequency, gain, phase and
+// coherence
+func (r *FrequencyResponse) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"frequency_hz", "gain_db", "phase_deg", "coherence"}); err != nil {
+		return err
+	}
+	for i, f := range r.Frequency {
+		row := []string{
+			strconv.FormatFloat(f, 'g', -1, 64),
+			strconv.FormatFloat(r.Gain[i], 'g', -1, 64),
+			strconv.FormatFloat(r.Phase[i], 'g', -1, 64),
+			strconv.FormatFloat(r.Coherence[i], 'g', -1, 64),
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// String summarises the response in the style of the other analysis reports
+func (r *FrequencyResponse) String() string {
+	var sb strings.Builder
+	sb.WriteString("Frequency Response:\n")
+	fmt.Fprintf(&sb, "  %s to %s, %s of %.3g from %.2f to %.2f Hz over %.0f s\n",
+		r.Input, r.Output, r.Sweep.Kind, r.Sweep.Amplitude, r.Sweep.FStart, r.Sweep.FEnd, r.Sweep.Duration)
+	f, gain := r.Peak()
+	fmt.Fprintf(&sb, "  Peak:            %.1f dB at %.2f Hz\n", gain, f)
+	minCoherence := 1.0
+	for _, c := range r.Coherence {
+		minCoherence = math.Min(minCoherence, c)
+	}
+	fmt.Fprintf(&sb, "  Coherence:       %.3f minimum", minCoherence)
+	return sb.String()
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"math"
+	"math/cmplx"
+	"strings"
+	"testing"
+)
+
+// firstOrderLag is a synthetic plant whose pitch rate follows the elevator
+// command through a lag of time constant tau, integrated exactly over each
+// step with the command held
+type firstOrderLag struct {
+	tau float64
+}
+
+func (l firstOrderLag) Step(state *AircraftState, dt float64) (*AircraftState, error) {
+	newState := state.Copy()
+	decay := math.Exp(-dt / l.tau)
+	newState.AngularRate.Y = decay*state.AngularRate.Y + (1-decay)*state.Controls.Elevator
+	newState.Time += dt
+	return newState, nil
+}
+
+// secondOrderMode is a synthetic plant whose pitch rate follows the
+// elevator command through a lightly damped second-order mode, like a
+// short period, integrated in fine substeps with the command held
+type secondOrderMode struct {
+	frequency, damping float64 // Hz, ratio
+	rate               *float64
+}
+
+func (m secondOrderMode) Step(state *AircraftState, dt float64) (*AircraftState, error) {
+	newState := state.Copy()
+	omega := 2 * math.Pi * m.frequency
+	const substeps = 50
+	h := dt / substeps
+	for i := 0; i < substeps; i++ {
+		*m.rate += h * (omega*omega*(state.Controls.Elevator-newState.AngularRate.Y) - 2*m.damping*omega**m.rate)
+		newState.AngularRate.Y += h * *m.rate
+	}
+	newState.Time += dt
+	return newState, nil
+}
+
+func TestFrequencyResponseAnalyzer(t *testing.T) {
+	chirp := SweepParameters{Kind: SweepChirp, FStart: 0.1, FEnd: 3, Amplitude: 0.05, Duration: 90}
+
+	t.Run("First Order Lag", func(t *testing.T) {
+		const tau = 0.2
+		fra := NewFrequencyResponseAnalyzer(firstOrderLag{tau}, "fcs/elevator-cmd-norm", "velocities/q-rad_sec", chirp)
+		response, err := fra.Run(NewAircraftState())
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		t.Logf("\n%s", response)
+		assertEqual(t, len(response.Frequency), 50)
+		assertApproxEqual(t, response.Frequency[0], 0.1, 1e-12)
+		assertApproxEqual(t, response.Frequency[49], 3, 1e-12)
+
+		for i, f := range response.Frequency {
+			h := 1 / complex(1, 2*math.Pi*f*tau)
+			gain := 20 * math.Log10(cmplx.Abs(h))
+			phase := cmplx.Phase(h) * RAD_TO_DEG
+			if math.Abs(response.Gain[i]-gain) > 1 || math.Abs(response.Phase[i]-phase) > 5 {
+				t.Errorf("%.3f Hz: %.2f dB %.1f°, expected %.2f dB %.1f°",
+					f, response.Gain[i], response.Phase[i], gain, phase)
+			}
+			if response.Coherence[i] < 0.95 {
+				t.Errorf("%.3f Hz: coherence %.3f of a noiseless linear plant", f, response.Coherence[i])
+			}
+		}
+	})
+
+	t.Run("Short Period Like Resonance", func(t *testing.T) {
+		// Peak of |H| at fn·√(1-2ζ²), of 1/(2ζ√(1-ζ²))
+		const fn, zeta = 0.8, 0.3
+		peakFrequency := fn * math.Sqrt(1-2*zeta*zeta)
+		peakGain := 20 * math.Log10(1/(2*zeta*math.Sqrt(1-zeta*zeta)))
+
+		for _, kind := range []SweepKind{SweepChirp, SweepDoublet} {
+			sweep := chirp
+			sweep.Kind = kind
+			if kind == SweepDoublet {
+				sweep.Duration = 30
+			}
+			fra := NewFrequencyResponseAnalyzer(secondOrderMode{fn, zeta, new(float64)},
+				"fcs/elevator-cmd-norm", "velocities/q-rad_sec", sweep)
+			response, err := fra.Run(NewAircraftState())
+			if err != nil {
+				t.Fatalf("%s: Run: %v", kind, err)
+			}
+			f, gain := response.Peak()
+			t.Logf("%s: peak %.2f dB at %.3f Hz", kind, gain, f)
+			if f < 0.3 || f > 1.5 || math.Abs(f-peakFrequency) > 0.08*peakFrequency {
+				t.Errorf("%s: peak at %.3f Hz, expected %.3f Hz", kind, f, peakFrequency)
+			}
+			if math.Abs(gain-peakGain) > 1 {
+				t.Errorf("%s: peak of %.2f dB, expected %.2f dB", kind, gain, peakGain)
+			}
+			if _, phase := response.At(fn); math.Abs(phase+90) > 5 {
+				t.Errorf("%s: phase %.1f° at the natural frequency, expected -90°", kind, phase)
+			}
+		}
+	})
+
+	t.Run("Simplified Model", func(t *testing.T) {
+		// The simplified model has no short period: its body velocities are
+		// integrated without the pitch-plane rotation terms, so pitching
+		// does not change alpha, and the pitch rate follows the elevator
+		// quasi-statically until the aircraft departs in heave. The
+		// coherence shows that no linear response can be measured; when
+		// the model gains the mode, this should look for its peak instead.
+		engine := NewSimplifiedFlightDynamicsEngine(NewEulerIntegrator())
+		state := trimLevelFlight(t, engine, 1000, 100)
+		sweep := SweepParameters{Kind: SweepChirp, FStart: 0.2, FEnd: 2, Amplitude: 0.005, Duration: 30}
+		fra := NewFrequencyResponseAnalyzer(engine, "fcs/elevator-cmd-norm", "velocities/q-rad_sec", sweep)
+		fra.Dt = 0.002 // The model's pitch damping needs a short step
+		response, err := fra.Run(state)
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		t.Logf("\n%s", response)
+		minCoherence := 1.0
+		for _, c := range response.Coherence {
+			minCoherence = math.Min(minCoherence, c)
+		}
+		if minCoherence > 0.5 {
+			t.Errorf("Expected the departure to show as low coherence, minimum %.3f", minCoherence)
+		}
+	})
+
+	t.Run("CSV Export", func(t *testing.T) {
+		fra := NewFrequencyResponseAnalyzer(firstOrderLag{0.2}, "fcs/elevator-cmd-norm", "velocities/q-rad_sec", chirp)
+		fra.Points = 10
+		response, err := fra.Run(NewAircraftState())
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		var buf bytes.Buffer
+		if err := response.WriteCSV(&buf); err != nil {
+			t.Fatalf("WriteCSV: %v", err)
+		}
+		records, err := csv.NewReader(&buf).ReadAll()
+		if err != nil {
+			t.Fatalf("CSV parse failed: %v", err)
+		}
+		assertEqual(t, len(records), 11)
+		assertEqual(t, records[0], []string{"frequency_hz", "gain_db", "phase_deg", "coherence"})
+	})
+
+	t.Run("Invalid Setups", func(t *testing.T) {
+		for _, tc := range []struct {
+			name  string
+			setup func(*FrequencyResponseAnalyzer)
+			want  string
+		}{
+			{"input", func(fra *FrequencyResponseAnalyzer) { fra.Input = "velocities/q-rad_sec" }, "not a pilot command"},
+			{"output", func(fra *FrequencyResponseAnalyzer) { fra.Output = "fcs/no-such" }, "not a state property"},
+			{"band", func(fra *FrequencyResponseAnalyzer) { fra.Sweep.FEnd = 0.05 }, "f_start < f_end"},
+			{"duration", func(fra *FrequencyResponseAnalyzer) { fra.Sweep.Duration = 10 }, "shorter than two periods"},
+			{"nyquist", func(fra *FrequencyResponseAnalyzer) { fra.Dt = 0.1 }, "Nyquist"},
+		} {
+			fra := NewFrequencyResponseAnalyzer(firstOrderLag{0.2}, "fcs/elevator-cmd-norm", "velocities/q-rad_sec", chirp)
+			tc.setup(fra)
+			if _, err := fra.Run(NewAircraftState()); err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("%s: expected an error containing %q, got %v", tc.name, tc.want, err)
+			}
+		}
+	})
+}
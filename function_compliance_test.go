@@ -1,6 +1,9 @@
 package main
 
 import (
+	"errors"
+	"math"
+	"strings"
 	"testing"
 )
 
@@ -90,14 +93,14 @@ func TestFunctionSpecificationCompliance(t *testing.T) {
 			Name: "aero/coefficient/test",
 			Product: &Operation{
 				Property: []string{"scaling_factor"},
-				Table: &Table{
+				Tables: []*Table{{
 					IndependentVar: []*IndependentVar{
 						{Value: "input_variable"},
 					},
 					TableData: []*TableData{
 						{Data: "0.0 0.0\n1.0 1.0\n2.0 4.0"},
 					},
-				},
+				}},
 			},
 		}
 		
@@ -310,14 +313,14 @@ func TestRealWorldFunctionExamples(t *testing.T) {
 					"metrics/Sw-sqft",
 					"aero/function/ground-effect-factor",
 				},
-				Table: &Table{
+				Tables: []*Table{{
 					IndependentVar: []*IndependentVar{
 						{Value: "aero/alpha-deg"},
 					},
 					TableData: []*TableData{
 						{Data: "-10.0 -0.5\n0.0 0.0\n10.0 1.0\n20.0 1.5"},
 					},
-				},
+				}},
 			},
 		}
 		
@@ -409,4 +412,53 @@ func TestFunctionErrorHandling(t *testing.T) {
 		// Expected: ((3+1) * 4 + 2) * 5 = (4*4+2)*5 = 18*5 = 90
 		assertEqual(t, result, 90.0)
 	})
+	
+	t.Run("Non-Finite Inputs", func(t *testing.T) {
+		pt := &ParsedTable{
+			Name:            "aero/coefficient/CLalpha",
+			Dimension:       1,
+			IndependentVars: []string{"aero/alpha-rad"},
+			Data1D:          &Table1D{Indices: []float64{-0.2, 0.2}, Values: []float64{-1.0, 1.0}},
+		}
+		_, err := InterpolateTable(pt, math.Inf(1))
+		if err == nil || !strings.Contains(err.Error(), "aero/coefficient/CLalpha") || !strings.Contains(err.Error(), "aero/alpha-rad") {
+			t.Errorf("Expected error naming the table and input, got %v", err)
+		}
+		
+		// Table lookups inside a function are reported with the function name
+		fn := &Function{
+			Name: "aero/force/Lift_alpha",
+			Product: &Operation{
+				Property: []string{"aero/qbar-psf"},
+				Tables: []*Table{{
+					IndependentVar: []*IndependentVar{{Value: "aero/alpha-rad"}},
+					TableData:      []*TableData{{Data: "-0.2 -1.0\n0.2 1.0"}},
+				}},
+			},
+		}
+		properties := map[string]float64{"aero/qbar-psf": 50.0, "aero/alpha-rad": math.NaN()}
+		_, err = EvaluateFunction(fn, properties)
+		var nonFinite *NonFiniteInputError
+		if !errors.As(err, &nonFinite) || nonFinite.Property != "aero/alpha-rad" {
+			t.Fatalf("Expected non-finite input error for aero/alpha-rad, got %v", err)
+		}
+		if !strings.Contains(err.Error(), "aero/force/Lift_alpha") {
+			t.Errorf("Error should name the function: %v", err)
+		}
+		
+		// As are plain property operands
+		properties["aero/alpha-rad"] = 0.1
+		properties["aero/qbar-psf"] = math.NaN()
+		_, err = EvaluateFunction(fn, properties)
+		if !errors.As(err, &nonFinite) || nonFinite.Property != "aero/qbar-psf" {
+			t.Errorf("Expected non-finite input error for aero/qbar-psf, got %v", err)
+		}
+		
+		properties["aero/qbar-psf"] = 50.0
+		value, err := EvaluateFunction(fn, properties)
+		if err != nil {
+			t.Fatalf("Finite inputs should evaluate: %v", err)
+		}
+		assertApproxEqual(t, value, 25.0, 1e-9)
+	})
 }
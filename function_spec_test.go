@@ -1,6 +1,8 @@
 package main
 
 import (
+	"bytes"
+	"strings"
 	"testing"
 	"time"
 )
@@ -85,14 +87,14 @@ func TestJSBSimFunctionSpecification(t *testing.T) {
 					"aero/function/ground-effect-factor-lift",
 					"aero/qbar-area",
 				},
-				Table: &Table{
+				Tables: []*Table{{
 					IndependentVar: []*IndependentVar{
 						{Value: "fcs/flap-pos-deg"},
 					},
 					TableData: []*TableData{
 						{Data: "0.0  0.0\n10.0 0.20\n20.0 0.30\n30.0 0.35"},
 					},
-				},
+				}},
 			},
 		}
 		
@@ -489,14 +491,14 @@ func TestFunctionPerformance(t *testing.T) {
 						Value: []float64{0.1},
 						Property: []string{"aero/alpha-deg"},
 					},
-					Table: &Table{
+					Tables: []*Table{{
 						IndependentVar: []*IndependentVar{
 							{Value: "aero/mach"},
 						},
 						TableData: []*TableData{
 							{Data: "0.0 1.0\n0.5 1.1\n0.8 1.2\n1.0 1.3"},
 						},
-					},
+					}},
 				},
 			},
 		}
@@ -540,7 +542,7 @@ func TestFunctionIntegrationWithTables(t *testing.T) {
 			Name: "aero_coeff_2d",
 			Product: &Operation{
 				Property: []string{"aero/qbar"},
-				Table: &Table{
+				Tables: []*Table{{
 					IndependentVar: []*IndependentVar{
 						{Lookup: "row", Value: "aero/alpha-deg"},
 						{Lookup: "column", Value: "aero/mach"},
@@ -550,7 +552,7 @@ func TestFunctionIntegrationWithTables(t *testing.T) {
 -10.0   0.1    0.2
 10.0    0.3    0.4`},
 					},
-				},
+				}},
 			},
 		}
 		
@@ -583,10 +585,10 @@ func TestFunctionIntegrationWithTables(t *testing.T) {
 			Name: "table_func_1",
 			Product: &Operation{
 				Property: []string{"factor1"},
-				Table: &Table{
+				Tables: []*Table{{
 					IndependentVar: []*IndependentVar{{Value: "input1"}},
 					TableData: []*TableData{{Data: "0.0 2.0\n1.0 4.0"}},
-				},
+				}},
 			},
 		}
 		
@@ -594,10 +596,10 @@ func TestFunctionIntegrationWithTables(t *testing.T) {
 			Name: "table_func_2", 
 			Product: &Operation{
 				Property: []string{"factor2"},
-				Table: &Table{
+				Tables: []*Table{{
 					IndependentVar: []*IndependentVar{{Value: "input2"}},
 					TableData: []*TableData{{Data: "0.0 1.0\n1.0 3.0"}},
-				},
+				}},
 			},
 		}
 		
@@ -627,3 +629,265 @@ func TestFunctionIntegrationWithTables(t *testing.T) {
 		assertEqual(t, combined, 12.0)
 	})
 }
+
+func TestFunctionUnits(t *testing.T) {
+	const unitsXML = `<fdm_config name="units">
+	<fileheader>
+		<limitation>Not valid above Mach 0.8</limitation>
+		<note>Pitching moment from wind tunnel data</note>
+	</fileheader>
+	<aerodynamics>
+		<axis name="PITCH">
+			<function name="aero/moment/Mtest" unit="LBSFT">
+				<documentation>Pitching moment in pound-feet</documentation>
+				<product>
+					<property>fcs/elevator-cmd-norm</property>
+					<value>100</value>
+				</product>
+			</function>
+		</axis>
+		<axis name="DRAG">
+			<function name="aero/force/Dtest" unit="FURLONGS">
+				<product><value>2</value></product>
+			</function>
+		</axis>
+		<function name="aero/function/nested" unit="LBSFT">
+			<product unit="LBSFT">
+				<value>100</value>
+				<sum unit="FT"><value>1</value></sum>
+			</product>
+		</function>
+		<function name="aero/function/inner">
+			<sum unit="FT">
+				<value>1</value>
+				<product unit="FT"><value>2</value></product>
+			</sum>
+		</function>
+		<function name="aero/function/furlongs" unit="FURLONGS">
+			<sum unit="FT">
+				<value>1</value>
+				<product unit="CUBITS"><value>2</value></product>
+			</sum>
+		</function>
+	</aerodynamics>
+</fdm_config>`
+	config, err := ParseJSBSimConfig(strings.NewReader(unitsXML))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	
+	t.Run("Metadata", func(t *testing.T) {
+		assertEqual(t, config.Header.Limitations, []string{"Not valid above Mach 0.8"})
+		assertEqual(t, config.Header.Notes, []string{"Pitching moment from wind tunnel data"})
+		
+		moment := config.Aerodynamics.Axis[0].Function[0]
+		assertEqual(t, moment.Unit, "LBSFT")
+		assertEqual(t, moment.Documentation, "Pitching moment in pound-feet")
+		assertEqual(t, extractFunctionData(moment)["documentation"], "Pitching moment in pound-feet")
+	})
+	
+	t.Run("Conversion To SI", func(t *testing.T) {
+		moment := config.Aerodynamics.Axis[0].Function[0]
+		properties := map[string]float64{"fcs/elevator-cmd-norm": 0.5}
+		value, err := EvaluateFunction(moment, properties)
+		if err != nil {
+			t.Fatalf("EvaluateFunction: %v", err)
+		}
+		assertApproxEqual(t, value/50.0, 1.3558, 1e-4)
+		
+		// The calculator adds the declared moment as is rather than scaling
+		// it as a coefficient
+		calc := NewForcesMomentsCalculator(config)
+		state := NewAircraftState()
+		state.SetControlInputs(ControlInputs{Elevator: 0.5})
+		state.UpdateAtmosphere()
+		state.UpdateDerivedParameters()
+		components, err := calc.CalculateForcesMoments(state)
+		if err != nil {
+			t.Fatalf("CalculateForcesMoments: %v", err)
+		}
+		assertApproxEqual(t, components.Moments.Pitch, value, 1e-9)
+	})
+	
+	t.Run("Nested Units", func(t *testing.T) {
+		// Only the outermost level declaring a unit converts the value
+		value, err := EvaluateFunction(config.Aerodynamics.Function[0], nil)
+		if err != nil {
+			t.Fatalf("EvaluateFunction: %v", err)
+		}
+		assertApproxEqual(t, value, 100*LB_TO_N*FT_TO_M, 1e-9)
+		value, err = EvaluateFunction(config.Aerodynamics.Function[1], nil)
+		if err != nil {
+			t.Fatalf("EvaluateFunction: %v", err)
+		}
+		assertApproxEqual(t, value, 3*FT_TO_M, 1e-12)
+	})
+	
+	t.Run("Unknown Units", func(t *testing.T) {
+		// Unknown units are reported and left unconverted, and being the
+		// outermost declared, the FT on the sum within is not applied
+		assertEqual(t, config.Warnings, []string{
+			`function aero/function/furlongs: unknown unit "FURLONGS" on <function>, value used unconverted`,
+			`function aero/function/furlongs: unknown unit "CUBITS" on <product>, value used unconverted`,
+			`function aero/force/Dtest: unknown unit "FURLONGS" on <function>, value used unconverted`,
+		})
+		value, err := EvaluateFunction(config.Aerodynamics.Function[2], nil)
+		if err != nil {
+			t.Fatalf("EvaluateFunction: %v", err)
+		}
+		assertEqual(t, value, 3.0)
+		
+		// An axis function with an unknown unit is summed with those in
+		// the axis convention rather than the SI values
+		conventional, si, err := sumAxisFunctions(config.Aerodynamics.Axis[1], map[string]float64{}, nil)
+		if err != nil {
+			t.Fatalf("sumAxisFunctions: %v", err)
+		}
+		assertEqual(t, conventional, 2.0)
+		assertEqual(t, si, 0.0)
+	})
+}
+
+// nestedTablesXML has tables under every kind of operation and at several
+// depths. Table x maps x to itself, table z maps 0 to 1 and 1 to 3, and
+// table d maps x from 0 to 10 onto 1 to 5.
+const nestedTablesXML = `<?xml version="1.0"?>
+<fdm_config name="nested-tables" version="2.0">
+	<aerodynamics>
+		<function name="test/table-under-sum">
+			<sum>
+				<value>1</value>
+				<table name="x"><independentVar>x</independentVar><tableData>0 0
+10 10</tableData></table>
+			</sum>
+		</function>
+		<function name="test/table-denominator">
+			<quotient>
+				<property>y</property>
+				<table name="d"><independentVar>x</independentVar><tableData>0 1
+10 5</tableData></table>
+			</quotient>
+		</function>
+		<function name="test/table-numerator">
+			<quotient>
+				<table name="d"><independentVar>x</independentVar><tableData>0 1
+10 5</tableData></table>
+				<property>y</property>
+			</quotient>
+		</function>
+		<function name="test/sibling-tables">
+			<product>
+				<value>2</value>
+				<table name="x"><independentVar>x</independentVar><tableData>0 0
+10 10</tableData></table>
+				<table name="z"><independentVar>z</independentVar><tableData>0 1
+1 3</tableData></table>
+			</product>
+		</function>
+		<function name="test/deep-tables">
+			<product>
+				<value>3</value>
+				<pow>
+					<table name="x"><independentVar>x</independentVar><tableData>0 0
+10 10</tableData></table>
+					<value>2</value>
+				</pow>
+				<abs>
+					<difference>
+						<value>1</value>
+						<table name="z"><independentVar>z</independentVar><tableData>0 1
+1 3</tableData></table>
+					</difference>
+				</abs>
+			</product>
+		</function>
+		<function name="test/repeated-products">
+			<sum>
+				<product>
+					<value>2</value>
+					<table name="x"><independentVar>x</independentVar><tableData>0 0
+10 10</tableData></table>
+				</product>
+				<product>
+					<value>3</value>
+					<value>4</value>
+				</product>
+			</sum>
+		</function>
+	</aerodynamics>
+</fdm_config>`
+
+func TestNestedFunctionTables(t *testing.T) {
+	config, err := ParseJSBSimConfig(strings.NewReader(nestedTablesXML))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	properties := map[string]float64{"x": 4, "y": 6, "z": 0.5}
+	
+	// At x = 4 table x gives 4 and table d 2.6; at z = 0.5 table z gives 2
+	expected := map[string]float64{
+		"test/table-under-sum":   1 + 4,
+		"test/table-denominator": 6 / 2.6,
+		"test/table-numerator":   2.6 / 6,
+		"test/sibling-tables":    2 * 4 * 2,
+		"test/deep-tables":       3 * 4 * 4 * 1,
+		"test/repeated-products": 2*4 + 3*4,
+	}
+	
+	t.Run("Evaluated Numbers", func(t *testing.T) {
+		for _, fn := range config.Aerodynamics.Function {
+			value, err := EvaluateFunction(fn, properties)
+			if err != nil {
+				t.Errorf("%s: %v", fn.Name, err)
+				continue
+			}
+			assertApproxEqual(t, value, expected[fn.Name], 1e-12)
+		}
+	})
+	
+	t.Run("Tables Reported At Any Depth", func(t *testing.T) {
+		paths := map[string][]string{
+			"test/table-under-sum":   {"sum/table"},
+			"test/table-denominator": {"quotient/table"},
+			"test/sibling-tables":    {"product/table[0]", "product/table[1]"},
+			"test/deep-tables":       {"product/pow/table", "product/abs/difference/table"},
+			"test/repeated-products": {"sum/product[0]/table"},
+		}
+		for _, fn := range config.Aerodynamics.Function {
+			want, ok := paths[fn.Name]
+			if !ok {
+				continue
+			}
+			tables, _ := extractFunctionData(fn)["tables"].([]map[string]interface{})
+			var got []string
+			for _, table := range tables {
+				got = append(got, table["path"].(string))
+				assertEqual(t, table["dimension"], 1)
+			}
+			assertEqual(t, got, want)
+		}
+		
+		sibling := config.Aerodynamics.Function[3]
+		assertEqual(t, len(sibling.Product.Tables), 2)
+		assertEqual(t, functionProperties(sibling), []string{"x", "z"})
+	})
+	
+	t.Run("Compiled Model Keeps Operand Order", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := ExportCompiledModel(config, &buf); err != nil {
+			t.Fatalf("ExportCompiledModel: %v", err)
+		}
+		model, err := LoadCompiledModel(&buf)
+		if err != nil {
+			t.Fatalf("LoadCompiledModel: %v", err)
+		}
+		for _, fn := range model.Config.Aerodynamics.Function {
+			value, err := EvaluateFunction(fn, properties)
+			if err != nil {
+				t.Errorf("%s: %v", fn.Name, err)
+				continue
+			}
+			assertApproxEqual(t, value, expected[fn.Name], 1e-12)
+		}
+	})
+}
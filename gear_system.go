@@ -0,0 +1,121 @@
+// Gear System
+// Retractable landing gear: the extension and retraction sequence, the door
+// opening it schedules, the CG shift of the moving gear and an emergency
+// free-fall extension
+
+package main
+
+import (
+	"math"
+)
+
+// GearMass is a mass that moves with the landing gear, such as a main gear
+// leg and wheel
+type GearMass struct {
+	Name      string
+	Mass      float64 // kg
+	Extended  Vector3 // Body-axis position about the CG with the gear down (m)
+	Retracted Vector3 // Body-axis position about the CG with the gear up (m)
+}
+
+// GearSystem moves retractable gear between up and down. The gear travels
+// at a constant rate over TransitionTime, its drag growing with the
+// extension. The doors open as the gear leaves its well and close as it
+// locks, fully open at mid-travel, so their drag peaks part way through
+// the cycle. EmergencyExtend drops the gear by free fall over
+// EmergencyTime instead, without door sequencing: the doors are forced
+// open and stay open.
+//
+// The configuration's CG is taken with the gear down; Masses, when
+// defined, shift it as the gear moves.
+type GearSystem struct {
+	TransitionTime float64 // Normal extension or retraction (s)
+	EmergencyTime  float64 // Free-fall extension (s)
+	Masses         []GearMass
+
+	emergency bool
+}
+
+// NewGearSystem creates a gear system with an 8 s cycle and a 20 s
+// free-fall extension. The retractable units of gear, when it is not nil,
+// bear load only when locked down.
+func NewGearSystem(gear *LandingGear) *GearSystem {
+	if gear != nil {
+		gear.MinExtension = 1
+	}
+	return &GearSystem{TransitionTime: 8, EmergencyTime: 20}
+}
+
+// EmergencyExtend releases the gear to free-fall down, whatever the gear
+// command, until Reset
+func (gs *GearSystem) EmergencyExtend() {
+	gs.emergency = true
+}
+
+// Emergency reports whether the gear has been released by EmergencyExtend
+func (gs *GearSystem) Emergency() bool {
+	return gs.emergency
+}
+
+// Reset returns the gear to normal, commanded operation
+func (gs *GearSystem) Reset() {
+	gs.emergency = false
+}
+
+// Update moves the gear and doors of newState over a step of dt from
+// state, toward the commanded position or down in an emergency. With
+// weight on any wheel, by the debounced squat switches, the gear holds
+// rather than retract.
+func (gs *GearSystem) Update(state, newState *AircraftState, dt float64) {
+	target, duration := 0.0, gs.TransitionTime
+	if state.Controls.Gear {
+		target = 1.0
+	} else if state.Gear.WOWAny {
+		target = state.Gear.Transition
+	}
+	if gs.emergency {
+		target, duration = 1.0, gs.EmergencyTime
+	}
+	step := 1.0
+	if duration > 0 {
+		step = dt / duration
+	}
+	newState.Gear.Transition = moveToward(state.Gear.Transition, target, step)
+	newState.Gear.Doors = gs.Doors(newState.Gear.Transition)
+}
+
+// Doors returns the door opening at a gear extension
+func (gs *GearSystem) Doors(transition float64) float64 {
+	if gs.emergency {
+		return 1
+	}
+	if transition <= 0 || transition >= 1 {
+		return 0
+	}
+	return math.Sin(math.Pi * transition)
+}
+
+// CGShift returns the body-axis movement of the CG (m) of an aircraft of
+// the given mass (kg) at a gear extension, from its gear-down position
+func (gs *GearSystem) CGShift(transition, mass float64) Vector3 {
+	var shift Vector3
+	if mass <= 0 {
+		return shift
+	}
+	for _, m := range gs.Masses {
+		position := m.Retracted.Add(m.Extended.Add(m.Retracted.Scale(-1)).Scale(transition))
+		shift = shift.Add(position.Add(m.Extended.Scale(-1)).Scale(m.Mass / mass))
+	}
+	return shift
+}
+
+// shiftMoments takes the moments of the forces on the aircraft, other than
+// its weight, about the CG as shifted by the gear
+func (gs *GearSystem) shiftMoments(components *ForceMomentComponents, transition, mass float64) {
+	shift := gs.CGShift(transition, mass)
+	if shift == (Vector3{}) {
+		return
+	}
+	applied := components.TotalForce.Add(components.Gravity.Weight.Scale(-1))
+	components.TotalMoment = components.TotalMoment.Add(applied.Cross(shift))
+}
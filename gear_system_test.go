@@ -0,0 +1,175 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+// gearDrag returns the drag (N) of the simplified model at 80 m/s with the
+// gear and doors of state
+func gearDrag(t *testing.T, calc *SimplifiedForcesMomentsCalculator, gear *AircraftState) float64 {
+	t.Helper()
+	state := NewAircraftState()
+	state.Velocity = Vector3{X: 80}
+	state.Gear.Transition = gear.Gear.Transition
+	state.Gear.Doors = gear.Gear.Doors
+	state.UpdateAtmosphere()
+	state.UpdateDerivedParameters()
+	components, err := calc.CalculateSimplifiedForces(state)
+	if err != nil {
+		t.Fatalf("CalculateSimplifiedForces: %v", err)
+	}
+	return -components.Aerodynamic.Drag
+}
+
+func TestGearSystem(t *testing.T) {
+	calc := NewSimplifiedCalculator()
+
+	t.Run("Drag Schedule", func(t *testing.T) {
+		gs := NewGearSystem(nil)
+		state := NewAircraftState()
+		state.Controls.Gear = false
+		state.Gear.Transition = 1
+		down := gearDrag(t, calc, state)
+
+		// Retract, watching the drag through the cycle
+		const dt = 0.05
+		peak, peakTransition := 0.0, 0.0
+		for i := 0; i < int(gs.TransitionTime/dt)+10; i++ {
+			next := state.Copy()
+			gs.Update(state, next, dt)
+			state = next
+			if drag := gearDrag(t, calc, state); drag > peak {
+				peak, peakTransition = drag, state.Gear.Transition
+			}
+		}
+		assertEqual(t, state.Gear.Transition, 0.0)
+		assertEqual(t, state.Gear.Doors, 0.0)
+
+		// Doors open mid-travel add more drag than the last of the gear
+		if peak <= down {
+			t.Errorf("Drag should peak above the gear-down %.0f N in transit, peaked at %.0f N", down, peak)
+		}
+		if peakTransition < 0.3 || peakTransition > 0.8 {
+			t.Errorf("Drag should peak mid-transition, peaked at %.2f extension", peakTransition)
+		}
+		if up := gearDrag(t, calc, state); up >= down {
+			t.Errorf("Gear-up drag %.0f N should settle below gear-down %.0f N", up, down)
+		}
+	})
+
+	t.Run("Emergency Extension", func(t *testing.T) {
+		gs := NewGearSystem(nil)
+		state := NewAircraftState()
+		state.Controls.Gear = false
+		state.Gear.Transition = 0
+		gs.EmergencyExtend()
+
+		// Free fall is slower than the normal cycle
+		const dt = 0.1
+		elapsed := 0.0
+		for ; elapsed < gs.TransitionTime-1e-9; elapsed += dt {
+			next := state.Copy()
+			gs.Update(state, next, dt)
+			state = next
+		}
+		assertApproxEqual(t, state.Gear.Transition, gs.TransitionTime/gs.EmergencyTime, 1e-9)
+		assertEqual(t, state.Gear.Doors, 1.0)
+
+		for ; elapsed < gs.EmergencyTime+1; elapsed += dt {
+			next := state.Copy()
+			gs.Update(state, next, dt)
+			state = next
+		}
+		assertEqual(t, state.Gear.Transition, 1.0)
+
+		// The doors hang open, so the gear is draggier than after a normal extension
+		normal := NewAircraftState()
+		normal.Gear.Transition = 1
+		if gearDrag(t, calc, state) <= gearDrag(t, calc, normal) {
+			t.Error("A free-fall extension should leave the doors open and the drag higher")
+		}
+
+		gs.Reset()
+		next := state.Copy()
+		gs.Update(state, next, dt)
+		assertEqual(t, next.Gear.Transition < 1, true)
+		assertEqual(t, next.Gear.Doors > 0 && next.Gear.Doors < 1, true)
+	})
+
+	t.Run("Belly Contact", func(t *testing.T) {
+		gear := NewLandingGear(loadP51DConfig(t))
+		NewGearSystem(gear)
+		var belly GearUnit
+		for _, unit := range gear.Units {
+			if unit.Name == "BELLY" {
+				belly = unit
+			}
+		}
+
+		// Level, the belly 5 cm into the ground and the gear far deeper
+		state := NewAircraftState()
+		state.Orientation = NewQuaternionFromEuler(0, 0, 0)
+		state.Velocity = Vector3{}
+		state.Gear.GroundHeight = 100
+		state.Altitude = 100 + belly.Location.Z - 0.05
+
+		wow := func(transition float64) (retractable, structure []string) {
+			state.Gear.Transition = transition
+			gear.Update(state)
+			for i, unit := range gear.Units {
+				if !state.Gear.Units[i].WOW {
+					continue
+				}
+				if unit.Retractable {
+					retractable = append(retractable, unit.Name)
+				} else {
+					structure = append(structure, unit.Name)
+				}
+			}
+			return retractable, structure
+		}
+
+		retractable, structure := wow(1)
+		assertEqual(t, len(retractable), 3)
+
+		// Retracted or unlocked, only the structure touches: the belly and
+		// the other low points of the fuselage
+		for _, transition := range []float64{0, 0.5, 0.99} {
+			retractable, structure = wow(transition)
+			if len(retractable) != 0 {
+				t.Errorf("Gear at %.2f extension should not bear load, %v did", transition, retractable)
+			}
+			if !slices.Contains(structure, "BELLY") {
+				t.Errorf("The belly should touch with the gear at %.2f extension, touching %v", transition, structure)
+			}
+		}
+		force, _ := gear.Forces(state)
+		if force.Z >= 0 {
+			t.Errorf("The belly contact should push up, got %v", force)
+		}
+	})
+
+	t.Run("CG Shift", func(t *testing.T) {
+		gs := NewGearSystem(nil)
+		gs.Masses = []GearMass{
+			{Name: "left main", Mass: 100, Extended: Vector3{X: 0.5, Y: -1.8, Z: 1.6}, Retracted: Vector3{X: 0.7, Y: -0.6, Z: 0.4}},
+			{Name: "right main", Mass: 100, Extended: Vector3{X: 0.5, Y: 1.8, Z: 1.6}, Retracted: Vector3{X: 0.7, Y: 0.6, Z: 0.4}},
+		}
+		assertEqual(t, gs.CGShift(1, 4000), Vector3{})
+		up := gs.CGShift(0, 4000)
+		assertApproxEqual(t, up.X, 200*0.2/4000, 1e-12)
+		assertApproxEqual(t, up.Y, 0.0, 1e-12)
+		assertApproxEqual(t, up.Z, -200*1.2/4000, 1e-12)
+		half := gs.CGShift(0.5, 4000)
+		assertApproxEqual(t, half.X, up.X/2, 1e-12)
+
+		// A CG moved forward puts the lift further aft of it: nose down
+		components := &ForceMomentComponents{TotalForce: Vector3{Z: -40000}}
+		gs.shiftMoments(components, 0, 4000)
+		if components.TotalMoment.Y >= 0 {
+			t.Errorf("Lift behind a forward CG should pitch the nose down, got %v", components.TotalMoment)
+		}
+		assertApproxEqual(t, components.TotalMoment.Y, -40000*up.X, 1e-9)
+	})
+}
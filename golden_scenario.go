@@ -0,0 +1,377 @@
+// Golden Scenarios
+// Canned runs reduced to compact summaries, kept as golden JSON files and
+// compared field by field, to show how far a model or integrator change
+// moves them
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+)
+
+// GoldenTraceChannels are the properties a scenario summary traces
+var GoldenTraceChannels = []string{
+	"position/h-sl-m",
+	"velocities/vt-mps",
+	"aero/alpha-deg",
+	"attitude/roll-rad",
+	"attitude/pitch-rad",
+	"velocities/q-rad_sec",
+}
+
+// GoldenScenario is a canned run of the simplified model from an initial
+// state
+type GoldenScenario struct {
+	Name     string
+	Duration float64                         // s
+	Dt       float64                         // Step (s), dividing a second evenly; 0.01 when 0
+	Engine   *SimplifiedFlightDynamicsEngine // Fresh, as its statistics are summarized
+	Initial  *AircraftState
+
+	// Optional; sets the controls of each step's state before the step
+	Pilot func(state *AircraftState)
+}
+
+// ScenarioSummary is a compact record of a run: where it ended, its
+// statistics, a 1 Hz trace of GoldenTraceChannels and the hash of the
+// trace quantized to DefaultHashResolution
+type ScenarioSummary struct {
+	Name     string  `json:"name"`
+	Duration float64 `json:"duration_s"`
+	Dt       float64 `json:"dt_s"`
+
+	Final struct {
+		North    float64 `json:"north_m"`
+		East     float64 `json:"east_m"`
+		Altitude float64 `json:"altitude_m"`
+		Airspeed float64 `json:"airspeed_mps"`
+		Roll     float64 `json:"roll_deg"`
+		Pitch    float64 `json:"pitch_deg"`
+		Heading  float64 `json:"heading_deg"`
+	} `json:"final"`
+
+	Statistics struct {
+		MaxLoadFactor float64 `json:"max_load_factor"`
+		MinLoadFactor float64 `json:"min_load_factor"`
+		MaxSpeed      float64 `json:"max_speed_mps"`
+		MaxAltitude   float64 `json:"max_altitude_m"`
+		MaxClimbRate  float64 `json:"max_climb_rate_mps"`
+		FuelBurned    float64 `json:"fuel_burned"`
+	} `json:"statistics"`
+
+	Trace struct {
+		Channels []string    `json:"channels"`
+		Time     []float64   `json:"time_s"`
+		Samples  [][]float64 `json:"samples"` // One row a second, a value a channel
+	} `json:"trace"`
+
+	Hash *TrajectoryHash `json:"trace_hash,omitempty"`
+}
+
+// Run flies the scenario and summarizes it
+func (s *GoldenScenario) Run() (*ScenarioSummary, error) {
+	dt := s.Dt
+	if dt <= 0 {
+		dt = 0.01
+	}
+	perSecond := int(math.Round(1 / dt))
+	if perSecond < 1 || math.Abs(float64(perSecond)*dt-1) > 1e-9 {
+		return nil, fmt.Errorf("scenario %s: step %g s does not divide a second", s.Name, dt)
+	}
+
+	summary := &ScenarioSummary{Name: s.Name, Duration: s.Duration, Dt: dt}
+	summary.Trace.Channels = append([]string(nil), GoldenTraceChannels...)
+	properties := make(map[string]float64, propertyMapSize)
+	sample := func(state *AircraftState, t float64) {
+		state.FillPropertyMap(properties)
+		row := make([]float64, len(GoldenTraceChannels))
+		for i, name := range GoldenTraceChannels {
+			row[i] = properties[name]
+		}
+		summary.Trace.Time = append(summary.Trace.Time, t)
+		summary.Trace.Samples = append(summary.Trace.Samples, row)
+	}
+
+	state := s.Initial.Copy()
+	start := state.Time
+	sample(state, 0)
+	steps := int(math.Round(s.Duration / dt))
+	for i := 1; i <= steps; i++ {
+		if s.Pilot != nil {
+			s.Pilot(state)
+		}
+		next, err := s.Engine.Step(state, dt)
+		if err != nil {
+			return nil, fmt.Errorf("scenario %s at t=%.2fs: %w", s.Name, state.Time-start, err)
+		}
+		state = next
+		if i%perSecond == 0 {
+			sample(state, float64(i/perSecond))
+		}
+	}
+
+	final := &summary.Final
+	final.North, final.East = state.Position.X, state.Position.Y
+	final.Altitude = state.Altitude
+	final.Airspeed = state.TrueAirspeed
+	roll, pitch, heading := state.Orientation.ToEuler()
+	final.Roll, final.Pitch, final.Heading = roll*RAD_TO_DEG, pitch*RAD_TO_DEG, heading*RAD_TO_DEG
+
+	stats, recorded := &summary.Statistics, s.Engine.Statistics
+	stats.MaxLoadFactor, stats.MinLoadFactor = recorded.MaxLoadFactor, recorded.MinLoadFactor
+	stats.MaxSpeed, stats.MaxAltitude = recorded.MaxSpeed, recorded.MaxAltitude
+	stats.MaxClimbRate = recorded.MaxClimbRate
+	stats.FuelBurned = recorded.TotalFuelBurned
+
+	trace, err := summary.quantizedTrace()
+	if err != nil {
+		return nil, fmt.Errorf("scenario %s: %w", s.Name, err)
+	}
+	summary.Hash = trace.Hash()
+	return summary, nil
+}
+
+// quantizedTrace quantizes the summary's trace for hashing
+func (s *ScenarioSummary) quantizedTrace() (*QuantizedTrajectory, error) {
+	return QuantizeTrajectory(s.Trace.Channels, s.Trace.Time, s.Trace.Samples, DefaultHashResolution)
+}
+
+// CompareSummaryHashes checks a run's trace hash against its golden
+// summary's, returning where the quantized traces first differ when the
+// hashes do, or nil when they match
+func CompareSummaryHashes(golden, got *ScenarioSummary) (*TrajectoryDivergence, error) {
+	if golden.Hash == nil {
+		return nil, fmt.Errorf("scenario %s: the golden summary has no trace hash; regenerate the golden", got.Name)
+	}
+	if got.Hash != nil && got.Hash.Hash == golden.Hash.Hash {
+		return nil, nil
+	}
+	want, err := golden.quantizedTrace()
+	if err != nil {
+		return nil, fmt.Errorf("scenario %s golden: %w", got.Name, err)
+	}
+	if want.Hash().Hash != golden.Hash.Hash {
+		return nil, fmt.Errorf("scenario %s: the golden trace does not match its hash; regenerate the golden", got.Name)
+	}
+	trace, err := got.quantizedTrace()
+	if err != nil {
+		return nil, fmt.Errorf("scenario %s: %w", got.Name, err)
+	}
+	if d := want.FirstDivergence(trace); d != nil {
+		return d, nil
+	}
+	return &TrajectoryDivergence{Reason: "the trace hash changed with the trace unchanged"}, nil
+}
+
+// ReadScenarioSummary reads a summary written by WriteScenarioSummary
+func ReadScenarioSummary(r io.Reader) (*ScenarioSummary, error) {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	summary := &ScenarioSummary{}
+	if err := decoder.Decode(summary); err != nil {
+		return nil, fmt.Errorf("reading scenario summary: %w", err)
+	}
+	return summary, nil
+}
+
+// WriteScenarioSummary writes a summary as indented JSON to path
+func WriteScenarioSummary(path string, summary *ScenarioSummary) error {
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// summaryField is one number of a summary, named by its JSON path, with
+// trace samples named by channel and time, such as
+// trace.position/h-sl-m@12s
+type summaryField struct {
+	name  string
+	value float64
+}
+
+// fields flattens the summary's numbers in a fixed order
+func (s *ScenarioSummary) fields() []summaryField {
+	f, st := s.Final, s.Statistics
+	fields := []summaryField{
+		{"final.north_m", f.North},
+		{"final.east_m", f.East},
+		{"final.altitude_m", f.Altitude},
+		{"final.airspeed_mps", f.Airspeed},
+		{"final.roll_deg", f.Roll},
+		{"final.pitch_deg", f.Pitch},
+		{"final.heading_deg", f.Heading},
+		{"statistics.max_load_factor", st.MaxLoadFactor},
+		{"statistics.min_load_factor", st.MinLoadFactor},
+		{"statistics.max_speed_mps", st.MaxSpeed},
+		{"statistics.max_altitude_m", st.MaxAltitude},
+		{"statistics.max_climb_rate_mps", st.MaxClimbRate},
+		{"statistics.fuel_burned", st.FuelBurned},
+	}
+	for i, t := range s.Trace.Time {
+		for j, channel := range s.Trace.Channels {
+			fields = append(fields, summaryField{fmt.Sprintf("trace.%s@%gs", channel, t), s.Trace.Samples[i][j]})
+		}
+	}
+	return fields
+}
+
+// SummaryTolerances are the largest absolute deltas allowed in summary
+// fields. Fields holds them by field name or a prefix of one, such as
+// "final.altitude_m" or "trace.aero/alpha-deg"; the longest match applies,
+// and Default where none does.
+type SummaryTolerances struct {
+	Fields  map[string]float64
+	Default float64
+}
+
+// DefaultSummaryTolerances allows the drift of reordered floating point
+// arithmetic, well below any change a model fix should make
+func DefaultSummaryTolerances() SummaryTolerances {
+	return SummaryTolerances{
+		Fields: map[string]float64{
+			"final.north_m":                 1,
+			"final.east_m":                  1,
+			"final.altitude_m":              0.1,
+			"final.airspeed_mps":            0.01,
+			"final.roll_deg":                0.01,
+			"final.pitch_deg":               0.01,
+			"final.heading_deg":             0.01,
+			"statistics.max_load_factor":    1e-3,
+			"statistics.min_load_factor":    1e-3,
+			"statistics.max_speed_mps":      0.01,
+			"statistics.max_altitude_m":     0.1,
+			"statistics.max_climb_rate_mps": 0.01,
+			"statistics.fuel_burned":        1e-3,
+			"trace.position/h-sl-m":         0.1,
+			"trace.velocities/vt-mps":       0.01,
+			"trace.aero/alpha-deg":          0.01,
+			"trace.attitude/roll-rad":       1e-4,
+			"trace.attitude/pitch-rad":      1e-4,
+			"trace.velocities/q-rad_sec":    1e-4,
+		},
+		Default: 1e-6,
+	}
+}
+
+// tolerance returns the tolerance of a field
+func (tol SummaryTolerances) tolerance(field string) float64 {
+	best, value := -1, tol.Default
+	for prefix, t := range tol.Fields {
+		if strings.HasPrefix(field, prefix) && len(prefix) > best {
+			best, value = len(prefix), t
+		}
+	}
+	return value
+}
+
+// SummaryDelta is the change in one field from the golden summary
+type SummaryDelta struct {
+	Field     string
+	Golden    float64
+	Got       float64
+	Tolerance float64
+}
+
+// Delta is the change from the golden value
+func (d SummaryDelta) Delta() float64 {
+	return d.Got - d.Golden
+}
+
+// Exceeded reports whether the change is beyond the tolerance; a value
+// that becomes or stops being NaN always is
+func (d SummaryDelta) Exceeded() bool {
+	if math.IsNaN(d.Golden) || math.IsNaN(d.Got) {
+		return math.IsNaN(d.Golden) != math.IsNaN(d.Got)
+	}
+	return math.Abs(d.Delta()) > d.Tolerance
+}
+
+// SummaryDiff lists the fields of a summary that changed from its golden
+// summary, in field order
+type SummaryDiff struct {
+	Scenario string
+	Deltas   []SummaryDelta
+}
+
+// CompareSummaries compares a run's summary with its golden summary. The
+// two must trace the same channels at the same times.
+func CompareSummaries(golden, got *ScenarioSummary, tol SummaryTolerances) (*SummaryDiff, error) {
+	if strings.Join(golden.Trace.Channels, ",") != strings.Join(got.Trace.Channels, ",") {
+		return nil, fmt.Errorf("scenario %s: traced channels changed from %v to %v; regenerate the golden",
+			got.Name, golden.Trace.Channels, got.Trace.Channels)
+	}
+	if len(golden.Trace.Time) != len(got.Trace.Time) || golden.Dt != got.Dt {
+		return nil, fmt.Errorf("scenario %s: %d samples at dt=%g s changed to %d at dt=%g s; regenerate the golden",
+			got.Name, len(golden.Trace.Time), golden.Dt, len(got.Trace.Time), got.Dt)
+	}
+
+	diff := &SummaryDiff{Scenario: got.Name}
+	goldenFields, gotFields := golden.fields(), got.fields()
+	for i, field := range goldenFields {
+		value := gotFields[i].value
+		if value == field.value || (math.IsNaN(value) && math.IsNaN(field.value)) {
+			continue
+		}
+		diff.Deltas = append(diff.Deltas, SummaryDelta{
+			Field:     field.name,
+			Golden:    field.value,
+			Got:       value,
+			Tolerance: tol.tolerance(field.name),
+		})
+	}
+	return diff, nil
+}
+
+// Exceeded returns the deltas beyond their tolerances
+func (d *SummaryDiff) Exceeded() []SummaryDelta {
+	var exceeded []SummaryDelta
+	for _, delta := range d.Deltas {
+		if delta.Exceeded() {
+			exceeded = append(exceeded, delta)
+		}
+	}
+	return exceeded
+}
+
+// String reports the changed fields, the largest changes relative to their
+// tolerances first, marking those beyond them
+func (d *SummaryDiff) String() string {
+	if len(d.Deltas) == 0 {
+		return fmt.Sprintf("%s: unchanged\n", d.Scenario)
+	}
+	deltas := append([]SummaryDelta(nil), d.Deltas...)
+	sort.SliceStable(deltas, func(i, j int) bool {
+		return relativeDelta(deltas[i]) > relativeDelta(deltas[j])
+	})
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %d fields changed, %d beyond tolerance\n", d.Scenario, len(deltas), len(d.Exceeded()))
+	for _, delta := range deltas {
+		mark := " "
+		if delta.Exceeded() {
+			mark = "!"
+		}
+		fmt.Fprintf(&b, "%s %-40s %12.6g → %12.6g  Δ %+.4g (tolerance %.4g)\n",
+			mark, delta.Field, delta.Golden, delta.Got, delta.Delta(), delta.Tolerance)
+	}
+	return b.String()
+}
+
+// relativeDelta is a delta's size as a multiple of its tolerance
+func relativeDelta(d SummaryDelta) float64 {
+	if d.Exceeded() && (math.IsNaN(d.Golden) || math.IsNaN(d.Got)) {
+		return math.Inf(1)
+	}
+	if d.Tolerance <= 0 {
+		return math.Abs(d.Delta()) * 1e12
+	}
+	return math.Abs(d.Delta()) / d.Tolerance
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"flag"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// updateGoldens rewrites the golden summaries from the current model, for
+// a deliberate change: go test -run TestGoldenScenarios -update
+var updateGoldens = flag.Bool("update", false, "rewrite the golden scenario summaries and model documentation in testdata")
+
+// goldenScenarios are the canned runs kept in testdata/golden, by name, in
+// the order they run
+var goldenScenarios = []struct {
+	name  string
+	build func(t *testing.T) *GoldenScenario
+}{
+	{"trimmed_cruise", func(t *testing.T) *GoldenScenario {
+		engine := NewSimplifiedFlightDynamicsEngine(NewRungeKutta4Integrator())
+		return &GoldenScenario{Duration: 60, Engine: engine, Initial: trimLevelFlight(t, engine, 1000, 100)}
+	}},
+	{"full_power_climb", func(t *testing.T) *GoldenScenario {
+		engine := NewSimplifiedFlightDynamicsEngine(NewRungeKutta4Integrator())
+		return &GoldenScenario{
+			Duration: 30,
+			Engine:   engine,
+			Initial:  trimLevelFlight(t, engine, 1000, 100),
+			Pilot:    func(state *AircraftState) { state.Controls.Throttle = 1 },
+		}
+	}},
+	{"level_turn_30deg", func(t *testing.T) *GoldenScenario {
+		engine := NewSimplifiedFlightDynamicsEngine(NewRungeKutta4Integrator())
+		return &GoldenScenario{Duration: 30, Engine: engine, Initial: trimLevelTurn(t, engine, 1000, 100, 30*DEG_TO_RAD)}
+	}},
+	{"stall_entry", func(t *testing.T) *GoldenScenario {
+		// Power off, the stick eased back from trim until the wing stalls
+		engine := NewSimplifiedFlightDynamicsEngine(NewRungeKutta4Integrator())
+		initial := trimLevelFlight(t, engine, 2000, 80)
+		trim := initial.Controls.Elevator
+		return &GoldenScenario{
+			Duration: 25,
+			Engine:   engine,
+			Initial:  initial,
+			Pilot: func(state *AircraftState) {
+				state.Controls.Throttle = 0
+				state.Controls.Elevator = math.Min(1, trim+0.04*(state.Time-initial.Time))
+			},
+		}
+	}},
+}
+
+func TestGoldenScenarios(t *testing.T) {
+	for _, registered := range goldenScenarios {
+		t.Run(registered.name, func(t *testing.T) {
+			scenario := registered.build(t)
+			scenario.Name = registered.name
+			summary, err := scenario.Run()
+			if err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+
+			path := filepath.Join("testdata", "golden", registered.name+".json")
+			if *updateGoldens {
+				if err := WriteScenarioSummary(path, summary); err != nil {
+					t.Fatalf("Writing %s: %v", path, err)
+				}
+				t.Logf("Wrote %s", path)
+				return
+			}
+
+			file, err := os.Open(path)
+			if err != nil {
+				t.Fatalf("%v; run with -update to create it", err)
+			}
+			defer file.Close()
+			golden, err := ReadScenarioSummary(file)
+			if err != nil {
+				t.Fatalf("%s: %v", path, err)
+			}
+			diff, err := CompareSummaries(golden, summary, DefaultSummaryTolerances())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(diff.Exceeded()) > 0 {
+				t.Errorf("The run moved beyond tolerance; if that is intended, run with -update\n%s", diff)
+			} else if len(diff.Deltas) > 0 {
+				t.Logf("\n%s", diff)
+			}
+
+			// The run is deterministic, so its quantized trace should hash
+			// as the golden's did
+			divergence, err := CompareSummaryHashes(golden, summary)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if divergence != nil {
+				t.Errorf("The trace hash changed from %s to %s; %s. If that is intended, run with -update",
+					golden.Hash.Hash, summary.Hash.Hash, divergence)
+			}
+		})
+	}
+}
+
+func TestCompareSummaries(t *testing.T) {
+	engine := NewSimplifiedFlightDynamicsEngine(NewRungeKutta4Integrator())
+	scenario := &GoldenScenario{Name: "short_cruise", Duration: 3, Engine: engine, Initial: trimLevelFlight(t, engine, 1000, 100)}
+	golden, err := scenario.Run()
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	assertEqual(t, len(golden.Trace.Time), 4)
+	assertEqual(t, golden.Trace.Time[3], 3.0)
+	assertEqual(t, len(golden.Trace.Samples[3]), len(GoldenTraceChannels))
+
+	// Round trip through JSON
+	path := filepath.Join(t.TempDir(), "short_cruise.json")
+	if err := WriteScenarioSummary(path, golden); err != nil {
+		t.Fatalf("WriteScenarioSummary: %v", err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer file.Close()
+	read, err := ReadScenarioSummary(file)
+	if err != nil {
+		t.Fatalf("ReadScenarioSummary: %v", err)
+	}
+	diff, err := CompareSummaries(golden, read, DefaultSummaryTolerances())
+	if err != nil {
+		t.Fatalf("CompareSummaries: %v", err)
+	}
+	assertEqual(t, len(diff.Deltas), 0)
+
+	t.Run("Per-Field Tolerances", func(t *testing.T) {
+		got := *read
+		got.Final.Altitude += 0.05     // Within 0.1 m
+		got.Statistics.MaxSpeed += 0.5 // Beyond 0.01 m/s
+		got.Trace.Samples = [][]float64{golden.Trace.Samples[0], golden.Trace.Samples[1], golden.Trace.Samples[2],
+			append([]float64{golden.Trace.Samples[3][0] + 2}, golden.Trace.Samples[3][1:]...)}
+
+		diff, err := CompareSummaries(golden, &got, DefaultSummaryTolerances())
+		if err != nil {
+			t.Fatalf("CompareSummaries: %v", err)
+		}
+		t.Logf("\n%s", diff)
+		assertEqual(t, len(diff.Deltas), 3)
+		exceeded := diff.Exceeded()
+		assertEqual(t, len(exceeded), 2)
+		assertEqual(t, exceeded[0].Field, "statistics.max_speed_mps")
+		assertEqual(t, exceeded[1].Field, "trace.position/h-sl-m@3s")
+		assertApproxEqual(t, exceeded[1].Delta(), 2.0, 1e-9)
+
+		// Worst first
+		report := diff.String()
+		if !strings.HasPrefix(strings.SplitN(report, "\n", 3)[1], "! statistics.max_speed_mps") {
+			t.Errorf("The largest change should be listed first:\n%s", report)
+		}
+
+		// Looser tolerances pass, the most specific applying
+		tol := DefaultSummaryTolerances()
+		tol.Fields["statistics.max_speed_mps"] = 1
+		tol.Fields["trace.position/h-sl-m@3s"] = 5
+		diff, _ = CompareSummaries(golden, &got, tol)
+		assertEqual(t, len(diff.Exceeded()), 0)
+	})
+
+	t.Run("Shape Changes", func(t *testing.T) {
+		longer := *golden
+		longer.Trace.Time = append(longer.Trace.Time, 4)
+		longer.Trace.Samples = append(longer.Trace.Samples, longer.Trace.Samples[3])
+		if _, err := CompareSummaries(golden, &longer, DefaultSummaryTolerances()); err == nil {
+			t.Error("A trace of a different length should not compare")
+		}
+		renamed := *golden
+		renamed.Trace.Channels = append([]string{"position/h-agl-m"}, golden.Trace.Channels[1:]...)
+		if _, err := CompareSummaries(golden, &renamed, DefaultSummaryTolerances()); err == nil {
+			t.Error("A trace of different channels should not compare")
+		}
+	})
+}
@@ -0,0 +1,260 @@
+// Hybrid Aerodynamics
+// Flies the configuration's aerodynamic functions wherever every property
+// they read is available, standing the simplified model's estimates in for
+// the contributions that cannot be evaluated, so one bad property costs a
+// single term rather than the whole model
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strings"
+)
+
+// BlendSource is where a contribution to a hybrid calculation came from
+type BlendSource string
+
+const (
+	BlendConfigTable BlendSource = "config table"      // The configuration's function
+	BlendFallback    BlendSource = "fallback estimate" // The simplified model's derivative
+	BlendUnavailable BlendSource = "unavailable"       // Neither; the term is left out
+)
+
+// BlendEntry is the source of one aerodynamic axis function's contribution
+type BlendEntry struct {
+	Axis   string // LIFT, DRAG, SIDE, ROLL, PITCH or YAW
+	Name   string
+	Source BlendSource
+	Reason string // Why the function was not used; empty for config tables
+}
+
+// BlendReport lists where each aerodynamic axis function's contribution to
+// a hybrid calculation came from, in evaluation order
+type BlendReport struct {
+	Entries []BlendEntry
+}
+
+func (r *BlendReport) add(axis string, f *Function, source BlendSource, reason string) {
+	r.Entries = append(r.Entries, BlendEntry{Axis: axis, Name: f.Name, Source: source, Reason: reason})
+}
+
+// Find returns the entry of the named function, matched in full or by its
+// last path element
+func (r *BlendReport) Find(name string) (BlendEntry, bool) {
+	for _, e := range r.Entries {
+		if e.Name == name || strings.HasSuffix(e.Name, "/"+name) {
+			return e, true
+		}
+	}
+	return BlendEntry{}, false
+}
+
+// Count returns how many contributions came from a source
+func (r *BlendReport) Count(source BlendSource) int {
+	n := 0
+	for _, e := range r.Entries {
+		if e.Source == source {
+			n++
+		}
+	}
+	return n
+}
+
+// String lists the sources, as in "CLalpha: config table, Cmq: fallback
+// estimate"
+func (r *BlendReport) String() string {
+	parts := make([]string, len(r.Entries))
+	for i, e := range r.Entries {
+		parts[i] = coefficientName(e.Name) + ": " + string(e.Source)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Summary lists the contributions that did not come from the config
+// tables, with their reasons, or says that all did
+func (r *BlendReport) Summary() string {
+	var parts []string
+	for _, e := range r.Entries {
+		if e.Source != BlendConfigTable {
+			parts = append(parts, fmt.Sprintf("%s %s (%s)", coefficientName(e.Name), e.Source, e.Reason))
+		}
+	}
+	if len(parts) == 0 {
+		return fmt.Sprintf("all %d functions from config tables", len(r.Entries))
+	}
+	return fmt.Sprintf("%d of %d functions from config tables; %s",
+		r.Count(BlendConfigTable), len(r.Entries), strings.Join(parts, ", "))
+}
+
+// coefficientName is the last path element of a function name
+func coefficientName(name string) string {
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+// addHybridProperties publishes the values the functions read that the
+// state does not provide: the reference properties, the Reynolds number on
+// the mean chord, the calibrated airspeed, taken as the equivalent
+// airspeed, and the absolute /fdm/jsbsim/ forms of the properties already
+// set. The property tree persists between steps, so values published on
+// an earlier step are refreshed, while those set by others are kept.
+func (calc *ForcesMomentsCalculator) addHybridProperties(state *AircraftState, properties map[string]float64) {
+	if calc.published == nil {
+		calc.published = make(map[string]bool)
+	}
+	publish := func(name string, value float64) {
+		if _, ok := properties[name]; !ok || calc.published[name] {
+			properties[name] = value
+			calc.published[name] = true
+		}
+	}
+
+	for name, value := range calc.referenceProperties(state, properties) {
+		publish(name, value)
+	}
+	if state.Temperature > 0 {
+		// Sutherland's law for the viscosity of air
+		viscosity := 1.458e-6 * math.Pow(state.Temperature, 1.5) / (state.Temperature + 110.4)
+		publish("aero/Re", state.Density*state.TrueAirspeed*calc.Reference.Chord*FT_TO_M/viscosity)
+	}
+	publish("velocities/vc-kts", properties["velocities/ve-kts"])
+	for _, inputs := range calc.hybridInputs() {
+		for _, name := range inputs {
+			relative, absolute := strings.CutPrefix(name, "/fdm/jsbsim/")
+			if !absolute {
+				continue
+			}
+			if value, ok := lookupProperty(properties, relative); ok {
+				publish(name, value)
+			}
+		}
+	}
+}
+
+// hybridInputs returns the properties each aerodynamic axis function
+// reads, listed once per configuration
+func (calc *ForcesMomentsCalculator) hybridInputs() map[*Function][]string {
+	if calc.functionInputs == nil {
+		calc.functionInputs = make(map[*Function][]string)
+		for _, axis := range calc.Config.Aerodynamics.Axis {
+			for _, f := range axis.Function {
+				calc.functionInputs[f] = functionProperties(f)
+			}
+		}
+	}
+	return calc.functionInputs
+}
+
+// sumHybridAxis evaluates the functions of an aerodynamic axis as
+// sumAxisFunctions does, but strictly: a function reading a property that
+// is not set, or failing to evaluate, is replaced by the simplified model's
+// estimate of its term, in SI, or left out when there is none. Each
+// function's source is added to the report.
+func (calc *ForcesMomentsCalculator) sumHybridAxis(axis *Axis, state *AircraftState, properties map[string]float64, report *BlendReport, trace func(f *Function, value float64, si bool)) (conventional, si float64, err error) {
+	inputs := calc.hybridInputs()
+	for _, function := range axis.Function {
+		var missing []string
+		for _, name := range inputs[function] {
+			if _, ok := lookupProperty(properties, name); !ok {
+				missing = append(missing, name)
+			}
+		}
+
+		var reason string
+		if len(missing) == 0 {
+			value, err := EvaluateFunction(function, properties)
+			if err == nil {
+				if function.Name != "" {
+					properties[function.Name] = value
+				}
+				if trace != nil {
+					trace(function, value, declaresSIUnit(function))
+				}
+				if declaresSIUnit(function) {
+					si += value
+				} else {
+					conventional += value
+				}
+				report.add(axis.Name, function, BlendConfigTable, "")
+				continue
+			}
+			if isNonFiniteInput(err) {
+				clearFunctionOutput(function, properties)
+				return 0, 0, err
+			}
+			reason = err.Error()
+		} else {
+			reason = "missing " + strings.Join(missing, ", ")
+		}
+		clearFunctionOutput(function, properties)
+
+		estimate, ok := calc.fallbackEstimate(function, state)
+		if !ok {
+			report.add(axis.Name, function, BlendUnavailable, reason)
+			continue
+		}
+		if trace != nil {
+			trace(function, estimate, true)
+		}
+		si += estimate
+		report.add(axis.Name, function, BlendFallback, reason)
+	}
+	return conventional, si, nil
+}
+
+// fallbackEstimate returns the simplified model's value of the term a
+// function provides, along its axis in N or N·m, for the primary
+// coefficients it models. Lift and pitch at zero alpha are folded into
+// the alpha terms, as the tables usually fold them. A name's suffix after
+// a hyphen is ignored, so Cmalpha-wing is estimated as Cmalpha.
+func (calc *ForcesMomentsCalculator) fallbackEstimate(f *Function, state *AircraftState) (float64, bool) {
+	qS := state.DynamicPressure * calc.Reference.WingArea * FT2_TO_M2
+	qSb := qS * calc.Reference.WingSpan * FT_TO_M
+	qSc := qS * calc.Reference.Chord * FT_TO_M
+	airspeed := math.Max(state.TrueAirspeed, 1.0)
+	controls, rates := state.Controls, state.AngularRate
+
+	name, _, _ := strings.Cut(coefficientName(f.Name), "-")
+	switch name {
+	case "CLalpha":
+		return (0.2 + 5.7*state.Alpha) * qS, true
+	case "CDo":
+		return 0.025 * qS, true
+	case "CYb":
+		return -0.9 * state.Beta * qS, true
+	case "Clb":
+		return -0.1 * state.Beta * qSb, true
+	case "Clp":
+		return -0.4 * rates.X * qSb, true
+	case "Clda":
+		return 0.15 * controls.Aileron * qSb, true
+	case "Cmalpha":
+		return (0.05 - 0.5*state.Alpha) * qSc, true
+	case "Cmq":
+		return -3.0 * rates.Y * qSc, true
+	case "Cmde":
+		return -1.2 * controls.Elevator * qSc, true
+	case "Cnb":
+		return 0.1 * state.Beta * qSb, true
+	case "Cnr":
+		return -0.15 * rates.Z * calc.Reference.WingSpan * FT_TO_M / (2 * airspeed) * qSb, true
+	case "Cndr":
+		return -0.1 * controls.Rudder * qSb, true
+	}
+	return 0, false
+}
+
+// logBlend logs the mix of a hybrid calculation when it differs from the
+// last one logged
+func (calc *ForcesMomentsCalculator) logBlend(report *BlendReport) {
+	summary := report.Summary()
+	if summary == calc.loggedBlend {
+		return
+	}
+	calc.loggedBlend = summary
+	log.Printf("Hybrid aerodynamics: %s", summary)
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// rollMoment returns the roll moment of a calculator at a roll rate
+func rollMoment(t *testing.T, calc *ForcesMomentsCalculator, state *AircraftState, p float64) *ForceMomentComponents {
+	t.Helper()
+	rolling := state.Copy()
+	rolling.AngularRate.X = p
+	components, err := calc.CalculateForcesMoments(rolling)
+	if err != nil {
+		t.Fatalf("CalculateForcesMoments: %v", err)
+	}
+	return components
+}
+
+func TestHybridAerodynamics(t *testing.T) {
+	// A P-51D whose roll damping reads a property nothing publishes
+	config := loadP51DConfig(t)
+	var clp *Function
+	for _, axis := range config.Aerodynamics.Axis {
+		for _, f := range axis.Function {
+			if strings.HasSuffix(f.Name, "/Clp") {
+				clp = f
+			}
+		}
+	}
+	if clp == nil {
+		t.Fatal("The P-51D should have a Clp function")
+	}
+	for i, name := range clp.Product.Property {
+		if strings.TrimSpace(name) == "velocities/p-aero-rad_sec" {
+			clp.Product.Property[i] = "velocities/p-aero-rad_sec-unpublished"
+		}
+	}
+	state := cruiseState(3000, 120, 0.02)
+
+	calc := NewForcesMomentsCalculator(config)
+	calc.Hybrid = true
+	steady := rollMoment(t, calc, state, 0)
+	rolling := rollMoment(t, calc, state, 0.5)
+	blend := rolling.Blend
+	t.Log(blend.Summary())
+
+	t.Run("Blend Report", func(t *testing.T) {
+		for name, want := range map[string]BlendSource{
+			"CLalpha":      BlendConfigTable,
+			"Cmalpha-wing": BlendConfigTable,
+			"Cmq":          BlendConfigTable,
+			"Cmde":         BlendConfigTable,
+			"Clda":         BlendConfigTable,
+			"Cndr":         BlendConfigTable,
+			"Clp":          BlendFallback,
+		} {
+			entry, ok := blend.Find(name)
+			if !ok {
+				t.Errorf("The report should have %s:\n%s", name, blend)
+				continue
+			}
+			assertEqual(t, entry.Source, want)
+		}
+		clp, _ := blend.Find("Clp")
+		assertEqual(t, clp.Reason, "missing velocities/p-aero-rad_sec-unpublished")
+		for _, want := range []string{"CLalpha: config table", "Clp: fallback estimate"} {
+			if !strings.Contains(blend.String(), want) {
+				t.Errorf("Expected %q in %s", want, blend)
+			}
+		}
+		if rollMoment(t, NewForcesMomentsCalculator(config), state, 0).Blend != nil {
+			t.Error("Only hybrid calculators should report the blend")
+		}
+	})
+
+	t.Run("Roll Damping", func(t *testing.T) {
+		// The roll rate's moment is the fallback's Clp term
+		damping := rolling.Moments.Roll - steady.Moments.Roll
+		qSb := state.DynamicPressure * calc.Reference.WingArea * FT2_TO_M2 * calc.Reference.WingSpan * FT_TO_M
+		assertApproxEqual(t, damping, -0.4*0.5*qSb, 1e-6*qSb)
+
+		// Evaluated leniently, the unpublished rate is skipped and the
+		// moment does not oppose the roll
+		lenient := NewForcesMomentsCalculator(config)
+		undamped := rollMoment(t, lenient, state, 0.5).Moments.Roll - rollMoment(t, lenient, state, 0).Moments.Roll
+		if undamped < 0 {
+			t.Errorf("Expected no damping without the fallback, got %g N·m", undamped)
+		}
+	})
+
+	t.Run("Pitch From Tables", func(t *testing.T) {
+		// The Cmq table: qbar·S·c·(c/2V)·q·-10, in ft·lbf
+		pitching := state.Copy()
+		pitching.AngularRate.Y = 0.2
+		components, err := calc.CalculateForcesMoments(pitching)
+		if err != nil {
+			t.Fatalf("CalculateForcesMoments: %v", err)
+		}
+		c := calc.Reference.Chord
+		qbar := state.DynamicPressure * 0.020885
+		want := qbar * calc.Reference.WingArea * c * c / (2 * state.TrueAirspeed * M_TO_FT) * 0.2 * -10 * LB_TO_N * FT_TO_M
+		assertApproxEqual(t, components.Moments.Pitch-steady.Moments.Pitch, want, 1e-3*math.Abs(want))
+	})
+}
@@ -28,7 +28,7 @@ func TestIntegrationMethodComparison(t *testing.T) {
 		Velocity:    Vector3{X: 50, Y: 0, Z: 0},    // 50 m/s forward
 		Orientation: Quaternion{W: 1, X: 0, Y: 0, Z: 0}, // Level
 		AngularRate: Vector3{X: 0, Y: 0, Z: 0},
-		Controls:    ControlInputs{Throttle: 0.75}, // 75% throttle
+		Controls:    ControlInputs{Throttle: 0.75, Mixture: 1.0, Propeller: 1.0}, // 75% throttle, full rich
 		Temperature: 288.15,
 		Pressure:    101325.0,
 		Density:     1.225,
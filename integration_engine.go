@@ -62,7 +62,7 @@ func (e *EulerIntegrator) Integrate(state *AircraftState, derivatives *StateDeri
 	
 	// Integrate position (Earth frame)
 	// Transform body velocity to Earth frame for position integration
-	earthVel := state.Orientation.RotateVector(state.Velocity)
+	earthVel := state.GroundVelocity()
 	newState.Position = state.Position.Add(earthVel.Scale(dt))
 	
 	// Integrate orientation (quaternion)
@@ -81,8 +81,7 @@ func (e *EulerIntegrator) Integrate(state *AircraftState, derivatives *StateDeri
 	newState.Altitude = -newState.Position.Z // NED frame: down is positive Z
 	
 	// Update derived parameters
-	newState.UpdateAtmosphere()
-	newState.UpdateDerivedParameters()
+	newState.UpdateFlightConditions()
 	
 	return newState
 }
@@ -122,17 +121,17 @@ func (rk *RungeKutta4Integrator) Integrate(state *AircraftState, derivatives *St
 	newState.Time += dt
 	
 	// RK4 position integration
-	earthVel := state.Orientation.RotateVector(state.Velocity)
+	earthVel := state.GroundVelocity()
 	k1_pos := earthVel
 	
 	// Approximate intermediate velocities for better accuracy
 	midVel := state.Velocity.Add(derivatives.VelocityDot.Scale(dt * 0.5))
-	earthVel2 := state.Orientation.RotateVector(midVel)
+	earthVel2 := state.Orientation.RotateVector(midVel).Add(state.Wind)
 	k2_pos := earthVel2
 	k3_pos := earthVel2
 	
 	finalVel := state.Velocity.Add(derivatives.VelocityDot.Scale(dt))
-	earthVel3 := state.Orientation.RotateVector(finalVel)
+	earthVel3 := state.Orientation.RotateVector(finalVel).Add(state.Wind)
 	k4_pos := earthVel3
 	
 	// Weighted average for position
@@ -189,8 +188,7 @@ func (rk *RungeKutta4Integrator) Integrate(state *AircraftState, derivatives *St
 	newState.Altitude = -newState.Position.Z
 	
 	// Update derived parameters
-	newState.UpdateAtmosphere()
-	newState.UpdateDerivedParameters()
+	newState.UpdateFlightConditions()
 	
 	return newState
 }
@@ -217,6 +215,13 @@ func (ab *AdamsBashforth2Integrator) GetOrder() int {
 	return 2
 }
 
+// SeedHistory takes derivatives as those of the previous step, so the first
+// step is a full Adams-Bashforth step rather than an Euler start
+func (ab *AdamsBashforth2Integrator) SeedHistory(derivatives *StateDerivatives) {
+	ab.previousDerivatives = derivatives
+	ab.hasPrevious = true
+}
+
 func (ab *AdamsBashforth2Integrator) Integrate(state *AircraftState, derivatives *StateDerivatives, dt float64) *AircraftState {
 	newState := state.Copy()
 	newState.Time += dt
@@ -233,8 +238,8 @@ func (ab *AdamsBashforth2Integrator) Integrate(state *AircraftState, derivatives
 	// Adams-Bashforth 2: y_n+1 = y_n + dt/2 * (3*f_n - f_n-1)
 	
 	// Position integration
-	earthVel := state.Orientation.RotateVector(state.Velocity)
-	prevEarthVel := state.Orientation.RotateVector(state.Velocity) // Simplified
+	earthVel := state.GroundVelocity()
+	prevEarthVel := state.GroundVelocity() // Simplified
 	
 	positionStep := earthVel.Scale(1.5).Add(prevEarthVel.Scale(-0.5)).Scale(dt)
 	newState.Position = state.Position.Add(positionStep)
@@ -256,8 +261,7 @@ func (ab *AdamsBashforth2Integrator) Integrate(state *AircraftState, derivatives
 	newState.Altitude = -newState.Position.Z
 	
 	// Update derived parameters
-	newState.UpdateAtmosphere()
-	newState.UpdateDerivedParameters()
+	newState.UpdateFlightConditions()
 	
 	// Store current derivatives for next step
 	ab.previousDerivatives = derivatives
@@ -319,7 +323,9 @@ func (ic *IntegratorComparison) CompareIntegrators(initialState *AircraftState,
 	return results
 }
 
-// StabilityAnalysis analyzes integrator stability for different time steps
+// StabilityAnalysis analyzes integrator stability for different time steps.
+// Total energy is not conserved by the forced aircraft model, so this only
+// flags gross divergence; use AccuracyBenchmark to measure error.
 type StabilityAnalysis struct {
 	TimeSteps []float64
 	Results   map[float64]map[string]float64 // dt -> method -> energy
@@ -352,13 +358,30 @@ func (sa *StabilityAnalysis) AnalyzeStability(initialState *AircraftState, deriv
 	}
 }
 
-// AdaptiveTimeStep implements adaptive time stepping for optimal accuracy/performance
+// AdaptiveTimeStep implements adaptive time stepping for optimal accuracy/performance.
+// The error is estimated by step doubling: one full step against two half
+// steps, and the two half steps, the more accurate answer, are kept.
 type AdaptiveTimeStep struct {
 	BaseIntegrator Integrator
 	MinDt          float64
 	MaxDt          float64
 	Tolerance      float64
 	SafetyFactor   float64
+	
+	// Richardson extrapolates the full and half step solutions, cancelling
+	// the leading error term for one order more accuracy
+	Richardson bool
+	
+	// MaxRejections caps the retries of one step; the last attempt is then
+	// accepted above the tolerance and a warning recorded
+	MaxRejections int
+	
+	// DynamicsFunc, when set, gives the derivatives at the midpoint for the
+	// second half step; otherwise the step's derivatives are reused
+	DynamicsFunc DynamicsFunction
+	
+	Statistics *IntegrationStatistics
+	Warnings   []string
 }
 
 func NewAdaptiveTimeStep(integrator Integrator) *AdaptiveTimeStep {
@@ -368,56 +391,106 @@ func NewAdaptiveTimeStep(integrator Integrator) *AdaptiveTimeStep {
 		MaxDt:          0.1,    // 100ms maximum
 		Tolerance:      1e-3,   // Error tolerance
 		SafetyFactor:   0.9,    // Safety factor for step size adjustment
+		MaxRejections:  10,
+		Statistics:     NewIntegrationStatistics(),
 	}
 }
 
 // EstimateError estimates truncation error by comparing with half-step
 func (ats *AdaptiveTimeStep) EstimateError(state *AircraftState, derivatives *StateDerivatives, dt float64) float64 {
+	_, _, err := ats.stepDoubling(state, derivatives, dt)
+	return err
+}
+
+// stepDoubling takes a full step and two half steps of dt, returning both
+// solutions and the distance between them
+func (ats *AdaptiveTimeStep) stepDoubling(state *AircraftState, derivatives *StateDerivatives, dt float64) (full, half *AircraftState, err float64) {
 	// Full step
-	fullStep := ats.BaseIntegrator.Integrate(state, derivatives, dt)
-	
-	// Two half steps
-	halfStep1 := ats.BaseIntegrator.Integrate(state, derivatives, dt*0.5)
-	halfStep2 := ats.BaseIntegrator.Integrate(halfStep1, derivatives, dt*0.5)
-	
-	// Compare positions as error metric
-	posError := fullStep.Position.Add(halfStep2.Position.Scale(-1)).Magnitude()
-	velError := fullStep.Velocity.Add(halfStep2.Velocity.Scale(-1)).Magnitude()
+	full = ats.BaseIntegrator.Integrate(state, derivatives, dt)
+	
+	// Two half steps, the second from the midpoint derivatives when the
+	// dynamics are known
+	half = ats.BaseIntegrator.Integrate(state, derivatives, dt*0.5)
+	midDerivatives := derivatives
+	if ats.DynamicsFunc != nil {
+		if d, dynErr := ats.DynamicsFunc(half); dynErr == nil {
+			midDerivatives = d
+		}
+	}
+	half = ats.BaseIntegrator.Integrate(half, midDerivatives, dt*0.5)
 	
+	return full, half, stateError(full, half)
+}
+
+// stateError compares positions and velocities as the error metric of the
+// adaptive integrators
+func stateError(a, b *AircraftState) float64 {
+	posError := a.Position.Add(b.Position.Scale(-1)).Magnitude()
+	velError := a.Velocity.Add(b.Velocity.Scale(-1)).Magnitude()
 	return math.Max(posError, velError)
 }
 
-// AdaptiveIntegrate performs integration with adaptive time stepping
+// AdaptiveIntegrate performs integration with adaptive time stepping. It
+// returns the state after the accepted step, which may be shorter than
+// targetDt, and the step size suggested for the next.
 func (ats *AdaptiveTimeStep) AdaptiveIntegrate(state *AircraftState, derivatives *StateDerivatives, targetDt float64) (*AircraftState, float64) {
-	currentDt := targetDt
+	order := float64(ats.BaseIntegrator.GetOrder())
+	currentDt := math.Min(targetDt, ats.MaxDt)
 	
-	for {
-		// Estimate error with current dt
-		error := ats.EstimateError(state, derivatives, currentDt)
+	for rejections := 0; ; rejections++ {
+		full, half, error := ats.stepDoubling(state, derivatives, currentDt)
 		
-		if error <= ats.Tolerance {
-			// Error acceptable, perform integration
-			newState := ats.BaseIntegrator.Integrate(state, derivatives, currentDt)
-			
-			// Suggest next time step
-			if error > 0 {
-				nextDt := currentDt * ats.SafetyFactor * math.Pow(ats.Tolerance/error, 1.0/float64(ats.BaseIntegrator.GetOrder()))
-				nextDt = math.Max(ats.MinDt, math.Min(ats.MaxDt, nextDt))
-				return newState, nextDt
+		// Step size the error suggests, from error ∝ dt^(order+1)
+		scale := 5.0
+		if error > 0 {
+			scale = math.Min(5.0, ats.SafetyFactor*math.Pow(ats.Tolerance/error, 1.0/(order+1)))
+		}
+		
+		capped := rejections >= ats.MaxRejections
+		if error <= ats.Tolerance || capped || currentDt <= ats.MinDt {
+			if error > ats.Tolerance {
+				ats.warnAboveTolerance(state.Time, currentDt, error, rejections)
 			}
-			return newState, currentDt
-		} else {
-			// Error too large, reduce time step
-			currentDt *= 0.5
-			if currentDt < ats.MinDt {
-				// Use minimum time step and accept the error
-				newState := ats.BaseIntegrator.Integrate(state, derivatives, ats.MinDt)
-				return newState, ats.MinDt
+			ats.Statistics.UpdateStats(currentDt, true, error)
+			
+			newState := half
+			if ats.Richardson {
+				newState = richardsonExtrapolate(full, half, order)
 			}
+			nextDt := math.Max(ats.MinDt, math.Min(ats.MaxDt, currentDt*scale))
+			return newState, nextDt
 		}
+		
+		// Error too large, reduce time step
+		ats.Statistics.UpdateStats(currentDt, false, error)
+		currentDt = math.Max(ats.MinDt, currentDt*math.Max(0.1, math.Min(0.5, scale)))
 	}
 }
 
+// warnAboveTolerance records a step accepted with its error above the
+// tolerance, at the minimum step or after the maximum rejections
+func (ats *AdaptiveTimeStep) warnAboveTolerance(t, dt, error float64, rejections int) {
+	ats.Statistics.CappedSteps++
+	ats.Warnings = append(ats.Warnings, fmt.Sprintf(
+		"t=%.4f s: step of %.3g s accepted after %d rejections with error %.3g above tolerance %.3g",
+		t, dt, rejections, error, ats.Tolerance))
+}
+
+// richardsonExtrapolate combines a full step and two half steps of a method
+// of the given order: the leading error terms differ by 2^order, so
+// half + (half - full)/(2^order - 1) cancels them
+func richardsonExtrapolate(full, half *AircraftState, order float64) *AircraftState {
+	k := 1.0 / (math.Pow(2, order) - 1)
+	extrapolated := half.Copy()
+	extrapolated.Position = half.Position.Add(half.Position.Add(full.Position.Scale(-1)).Scale(k))
+	extrapolated.Velocity = half.Velocity.Add(half.Velocity.Add(full.Velocity.Scale(-1)).Scale(k))
+	extrapolated.AngularRate = half.AngularRate.Add(half.AngularRate.Add(full.AngularRate.Scale(-1)).Scale(k))
+	extrapolated.Orientation = half.Orientation.Add(half.Orientation.Add(full.Orientation.Scale(-1)).Scale(k)).Normalize()
+	extrapolated.Altitude = -extrapolated.Position.Z
+	extrapolated.UpdateFlightConditions()
+	return extrapolated
+}
+
 // IntegrationStatistics tracks performance metrics
 type IntegrationStatistics struct {
 	TotalSteps      int
@@ -427,6 +500,7 @@ type IntegrationStatistics struct {
 	MinStepSize     float64
 	MaxStepSize     float64
 	TotalError      float64
+	CappedSteps     int // Steps accepted above the tolerance
 }
 
 func NewIntegrationStatistics() *IntegrationStatistics {
@@ -460,12 +534,12 @@ func (stats *IntegrationStatistics) String() string {
 	return fmt.Sprintf(
 		"Integration Stats: %d total steps (%d accepted, %d rejected)\n"+
 		"Efficiency: %.1f%%, Avg dt: %.3fms, Range: [%.3f, %.3f]ms\n"+
-		"Total Error: %.6f",
+		"Total Error: %.6f, %d steps above tolerance",
 		stats.TotalSteps, stats.AcceptedSteps, stats.RejectedSteps,
 		stats.GetEfficiency()*100,
 		stats.AverageStepSize*1000,
 		stats.MinStepSize*1000,
 		stats.MaxStepSize*1000,
-		stats.TotalError,
+		stats.TotalError, stats.CappedSteps,
 	)
 }
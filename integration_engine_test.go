@@ -2,6 +2,7 @@ package main
 
 import (
 	"math"
+	"strings"
 	"testing"
 )
 
@@ -412,6 +413,83 @@ func TestAdaptiveTimeStep(t *testing.T) {
 		t.Logf("Error with dt=%.3f: %.6f", smallDt, smallError)
 		t.Logf("Error with dt=%.3f: %.6f", largeDt, largeError)
 	})
+	
+	t.Run("Accepted Step Reuses Half Steps", func(t *testing.T) {
+		base := &countingIntegrator{Integrator: NewRungeKutta4Integrator()}
+		adaptive := NewAdaptiveTimeStep(base)
+		adaptive.Tolerance = 1.0
+		
+		state := NewAircraftState()
+		state.Velocity = Vector3{X: 100.0}
+		derivatives := &StateDerivatives{PositionDot: Vector3{X: 100.0}, VelocityDot: Vector3{X: 1.0}}
+		
+		newState, _ := adaptive.AdaptiveIntegrate(state, derivatives, 0.01)
+		
+		// One full and two half steps, with no recomputation of the result
+		assertEqual(t, base.calls, 3)
+		assertApproxEqual(t, newState.Time, 0.01, 1e-12)
+		assertEqual(t, adaptive.Statistics.AcceptedSteps, 1)
+		assertEqual(t, adaptive.Statistics.RejectedSteps, 0)
+	})
+	
+	t.Run("Rejections Capped", func(t *testing.T) {
+		adaptive := NewAdaptiveTimeStep(NewRungeKutta4Integrator())
+		adaptive.Tolerance = 1e-15
+		adaptive.MaxRejections = 2
+		
+		state := NewAircraftState()
+		state.Velocity = Vector3{X: 100.0}
+		derivatives := &StateDerivatives{VelocityDot: Vector3{X: 10.0}, AngularRateDot: Vector3{Y: 0.5}}
+		
+		newState, _ := adaptive.AdaptiveIntegrate(state, derivatives, 0.1)
+		if newState.Time <= state.Time {
+			t.Fatal("A capped step should still advance")
+		}
+		assertEqual(t, adaptive.Statistics.RejectedSteps, 2)
+		assertEqual(t, adaptive.Statistics.CappedSteps, 1)
+		if len(adaptive.Warnings) != 1 || !strings.Contains(adaptive.Warnings[0], "above tolerance") {
+			t.Errorf("Expected one tolerance warning, got %v", adaptive.Warnings)
+		}
+	})
+	
+	t.Run("Richardson Extrapolation", func(t *testing.T) {
+		problem := NewOscillatorAccuracyProblem(10.0, 5.0, 2.0)
+		dt := 0.05
+		
+		errorAfter := func(richardson bool) float64 {
+			adaptive := NewAdaptiveTimeStep(NewTrueRK4Integrator(problem.Dynamics))
+			adaptive.DynamicsFunc = problem.Dynamics
+			adaptive.Richardson = richardson
+			adaptive.Tolerance = 1.0
+			state := problem.Initial()
+			for state.Time < problem.Duration-1e-9 {
+				derivatives, err := problem.Dynamics(state)
+				if err != nil {
+					t.Fatalf("Dynamics: %v", err)
+				}
+				state, _ = adaptive.AdaptiveIntegrate(state, derivatives, dt)
+			}
+			return problem.Error(state, state.Time)
+		}
+		
+		plain := errorAfter(false)
+		extrapolated := errorAfter(true)
+		t.Logf("Oscillator error: half steps %.3e, Richardson %.3e", plain, extrapolated)
+		if extrapolated >= plain/4 {
+			t.Errorf("Richardson extrapolation should be more accurate: %.3e, was %.3e", extrapolated, plain)
+		}
+	})
+}
+
+// countingIntegrator counts the steps taken by the integrator it wraps
+type countingIntegrator struct {
+	Integrator
+	calls int
+}
+
+func (c *countingIntegrator) Integrate(state *AircraftState, derivatives *StateDerivatives, dt float64) *AircraftState {
+	c.calls++
+	return c.Integrator.Integrate(state, derivatives, dt)
 }
 
 // TestIntegrationStatistics tests the statistics tracking
@@ -576,13 +654,13 @@ func TestIntegrationRealism(t *testing.T) {
 		dt := 0.01
 		steps := 628 // About π seconds for half turn (π/0.5 * 100 steps/sec)
 		
-		initialHeading := state.Yaw
+		initialHeading := state.HeadingContinuous
 		
 		for i := 0; i < steps; i++ {
 			state = integrator.Integrate(state, derivatives, dt)
 		}
 		
-		finalHeading := state.Yaw
+		finalHeading := state.HeadingContinuous
 		headingChange := math.Abs(finalHeading - initialHeading)
 		
 		// Should have turned approximately π radians (180°)
@@ -590,4 +668,91 @@ func TestIntegrationRealism(t *testing.T) {
 		
 		t.Logf("Heading change: %.1f° (expected ~180°)", headingChange*RAD_TO_DEG)
 	})
+	
+	t.Run("Continuous Heading Through 540 Degrees", func(t *testing.T) {
+		integrator := NewRungeKutta4Integrator()
+		
+		state := NewAircraftState()
+		state.Velocity = Vector3{X: 80.0, Y: 0.0, Z: 0.0}
+		state.AngularRate = Vector3{X: 0.0, Y: 0.0, Z: 0.5}
+		derivatives := &StateDerivatives{}
+		
+		// One and a half turns: 3π rad at 0.5 rad/s
+		dt := 0.01
+		steps := int(math.Round(3 * math.Pi / 0.5 / dt))
+		for i := 0; i < steps; i++ {
+			state = integrator.Integrate(state, derivatives, dt)
+		}
+		
+		// The wrapped yaw is back near ±180° while the continuous heading
+		// has kept counting
+		assertApproxEqual(t, state.HeadingContinuous*RAD_TO_DEG, 540.0, 1.0)
+		assertApproxEqual(t, math.Abs(state.Yaw)*RAD_TO_DEG, 180.0, 1.0)
+		assertApproxEqual(t, state.EulerRates.Z, 0.5, 1e-9)
+		
+		properties := state.ToPropertyMap()
+		assertApproxEqual(t, properties["attitude/heading-continuous-deg"], state.HeadingContinuous*RAD_TO_DEG, 1e-9)
+		
+		t.Logf("Continuous heading: %.1f°, yaw: %.1f°", state.HeadingContinuous*RAD_TO_DEG, state.Yaw*RAD_TO_DEG)
+	})
+}
+
+// TestFlightConditionsAfterStep checks that every integrator leaves its new
+// state's atmosphere at the new altitude and its dynamic pressure computed
+// once, from that density and airspeed
+func TestFlightConditionsAfterStep(t *testing.T) {
+	// A fast, decelerating dive, so the density and airspeed both change
+	// markedly over a step
+	dive := func(state *AircraftState) (*StateDerivatives, error) {
+		return &StateDerivatives{
+			PositionDot: state.Orientation.RotateVector(state.Velocity),
+			VelocityDot: Vector3{X: -20},
+		}, nil
+	}
+	integrators := []Integrator{
+		NewEulerIntegrator(),
+		NewRungeKutta4Integrator(),
+		NewAdamsBashforth2Integrator(),
+		NewTrueRK4Integrator(dive),
+		NewDormandPrinceIntegrator(dive),
+	}
+	for _, integrator := range integrators {
+		t.Run(integrator.GetName(), func(t *testing.T) {
+			state := NewAircraftState()
+			state.Altitude = 5000
+			state.Position = Vector3{Z: -5000}
+			state.Orientation = NewQuaternionFromEuler(0, -60*DEG_TO_RAD, 0)
+			state.Velocity = Vector3{X: 300}
+			state.UpdateFlightConditions()
+			derivatives, _ := dive(state)
+			next := integrator.Integrate(state, derivatives, 1.0)
+
+			if next.Altitude >= 4800 {
+				t.Fatalf("Altitude %.1f m after the dive", next.Altitude)
+			}
+			atmosphere := &AircraftState{Altitude: next.Altitude}
+			atmosphere.UpdateAtmosphere()
+			assertEqual(t, next.Density, atmosphere.Density)
+
+			qbar := 0.5 * next.Density * next.TrueAirspeed * next.TrueAirspeed
+			properties := next.ToPropertyMap()
+			assertEqual(t, properties["aero/qbar-Pa"], qbar)
+			assertEqual(t, properties["aero/qbar-psf"], qbar*0.020885)
+		})
+	}
+
+	t.Run("Engine Step", func(t *testing.T) {
+		engine := NewFlightDynamicsEngine(loadP51DConfig(t), NewRungeKutta4Integrator())
+		state := cruiseState(1500, 100, 2*DEG_TO_RAD)
+		next, err := engine.Step(state, 0.01)
+		if err != nil {
+			t.Fatalf("Step: %v", err)
+		}
+		if _, err := engine.Calculator.CalculateForcesMoments(next); err != nil {
+			t.Fatalf("CalculateForcesMoments: %v", err)
+		}
+		qbar := 0.5 * next.Density * next.TrueAirspeed * next.TrueAirspeed
+		assertEqual(t, engine.Calculator.Properties.Get("aero/qbar-Pa"), qbar)
+		assertEqual(t, next.DynamicPressure, qbar)
+	})
 }
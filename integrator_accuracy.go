@@ -0,0 +1,325 @@
+// Integrator Accuracy Benchmark
+// Measures integration error against problems with closed-form solutions
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// accuracyNoiseFloor is the error below which a method is treated as exact
+// for a problem; such samples are left out of the convergence fit
+const accuracyNoiseFloor = 1e-10
+
+// AccuracyProblem is a scenario whose trajectory is known analytically
+type AccuracyProblem struct {
+	Name     string
+	Duration float64 // Simulated time (s)
+
+	// Initial returns a fresh copy of the starting state
+	Initial func() *AircraftState
+
+	// Dynamics returns the state derivatives for the problem
+	Dynamics DynamicsFunction
+
+	// Error returns the distance between a state and the closed-form
+	// solution at time t
+	Error func(state *AircraftState, t float64) float64
+}
+
+// IntegratorFactory creates an integrator for a problem. Integrators that
+// re-evaluate dynamics at intermediate stages are handed the problem's
+// dynamics function; stateful integrators get a fresh instance per run.
+type IntegratorFactory func(dynamics DynamicsFunction) Integrator
+
+// AccuracySample is the error of one method at one time step
+type AccuracySample struct {
+	Dt       float64
+	Steps    int
+	RMSError float64
+	MaxError float64
+}
+
+// AccuracyMethodReport collects the samples for one method on one problem
+type AccuracyMethodReport struct {
+	Method        string
+	ExpectedOrder int
+	Samples       []AccuracySample
+
+	// ObservedOrder is the log-log slope of RMS error against dt, or NaN
+	// when fewer than two samples are above the noise floor
+	ObservedOrder float64
+}
+
+// AccuracyProblemReport collects the method reports for one problem
+type AccuracyProblemReport struct {
+	Problem string
+	Methods []*AccuracyMethodReport
+}
+
+// AccuracyReport is the result of an accuracy benchmark run
+type AccuracyReport struct {
+	Problems []*AccuracyProblemReport
+}
+
+// AccuracyBenchmark integrates reference problems with several methods and
+// time steps and measures their error against the closed-form solution
+type AccuracyBenchmark struct {
+	Problems  []*AccuracyProblem
+	Methods   []IntegratorFactory
+	TimeSteps []float64
+}
+
+// NewAccuracyBenchmark creates a benchmark with the standard reference
+// problems, the built-in integrators and a halving sequence of time steps
+func NewAccuracyBenchmark() *AccuracyBenchmark {
+	return &AccuracyBenchmark{
+		Problems: []*AccuracyProblem{
+			NewTurnAccuracyProblem(100.0, 0.2, 10.0),
+			NewBallisticAccuracyProblem(50.0, 10.0),
+			NewOscillatorAccuracyProblem(10.0, 1.0, 2*math.Pi),
+		},
+		Methods: []IntegratorFactory{
+			func(DynamicsFunction) Integrator { return NewEulerIntegrator() },
+			func(DynamicsFunction) Integrator { return NewRungeKutta4Integrator() },
+			func(DynamicsFunction) Integrator { return NewAdamsBashforth2Integrator() },
+			func(dynamics DynamicsFunction) Integrator { return NewTrueRK4Integrator(dynamics) },
+		},
+		TimeSteps: []float64{0.1, 0.05, 0.025, 0.0125},
+	}
+}
+
+// Run integrates every problem with every method at every time step
+func (ab *AccuracyBenchmark) Run() (*AccuracyReport, error) {
+	report := &AccuracyReport{}
+
+	for _, problem := range ab.Problems {
+		problemReport := &AccuracyProblemReport{Problem: problem.Name}
+
+		for _, factory := range ab.Methods {
+			var methodReport *AccuracyMethodReport
+
+			for _, dt := range ab.TimeSteps {
+				integrator := factory(problem.Dynamics)
+				if methodReport == nil {
+					methodReport = &AccuracyMethodReport{
+						Method:        integrator.GetName(),
+						ExpectedOrder: integrator.GetOrder(),
+					}
+				}
+
+				sample, err := runAccuracySample(problem, integrator, dt)
+				if err != nil {
+					return nil, fmt.Errorf("%s with %s at dt=%g: %v", problem.Name, integrator.GetName(), dt, err)
+				}
+				methodReport.Samples = append(methodReport.Samples, sample)
+			}
+
+			if methodReport != nil {
+				methodReport.ObservedOrder = observedOrder(methodReport.Samples)
+				problemReport.Methods = append(problemReport.Methods, methodReport)
+			}
+		}
+
+		report.Problems = append(report.Problems, problemReport)
+	}
+
+	return report, nil
+}
+
+// runAccuracySample integrates one problem with one method and time step
+func runAccuracySample(problem *AccuracyProblem, integrator Integrator, dt float64) (AccuracySample, error) {
+	steps := int(math.Round(problem.Duration / dt))
+	sample := AccuracySample{Dt: dt, Steps: steps}
+
+	state := problem.Initial()
+	sumSquares := 0.0
+	for i := 1; i <= steps; i++ {
+		derivatives, err := problem.Dynamics(state)
+		if err != nil {
+			return sample, err
+		}
+		state = integrator.Integrate(state, derivatives, dt)
+
+		e := problem.Error(state, float64(i)*dt)
+		sumSquares += e * e
+		sample.MaxError = math.Max(sample.MaxError, e)
+	}
+	if steps > 0 {
+		sample.RMSError = math.Sqrt(sumSquares / float64(steps))
+	}
+
+	return sample, nil
+}
+
+// observedOrder fits log(RMS error) against log(dt) by least squares
+func observedOrder(samples []AccuracySample) float64 {
+	var sumX, sumY, sumXX, sumXY float64
+	n := 0
+	for _, s := range samples {
+		if s.RMSError <= accuracyNoiseFloor || s.Dt <= 0 {
+			continue
+		}
+		x, y := math.Log(s.Dt), math.Log(s.RMSError)
+		sumX += x
+		sumY += y
+		sumXX += x * x
+		sumXY += x * y
+		n++
+	}
+	if n < 2 {
+		return math.NaN()
+	}
+
+	N := float64(n)
+	return (N*sumXY - sumX*sumY) / (N*sumXX - sumX*sumX)
+}
+
+// Problem returns the report for the named problem, or nil
+func (r *AccuracyReport) Problem(name string) *AccuracyProblemReport {
+	for _, p := range r.Problems {
+		if p.Problem == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// Method returns the report for the named method, or nil
+func (p *AccuracyProblemReport) Method(name string) *AccuracyMethodReport {
+	for _, m := range p.Methods {
+		if m.Method == name {
+			return m
+		}
+	}
+	return nil
+}
+
+// String formats the report as one table per problem
+func (r *AccuracyReport) String() string {
+	var sb strings.Builder
+	for _, p := range r.Problems {
+		sb.WriteString(fmt.Sprintf("%s\n", p.Problem))
+		sb.WriteString(fmt.Sprintf("  %-28s %8s %12s %12s\n", "Method", "dt (s)", "RMS error", "Max error"))
+		for _, m := range p.Methods {
+			for _, s := range m.Samples {
+				sb.WriteString(fmt.Sprintf("  %-28s %8.4f %12.3e %12.3e\n", m.Method, s.Dt, s.RMSError, s.MaxError))
+			}
+			sb.WriteString(fmt.Sprintf("  %-28s observed order %.2f (expected %d)\n", m.Method, m.ObservedOrder, m.ExpectedOrder))
+		}
+	}
+	return sb.String()
+}
+
+// =============================================================================
+// REFERENCE PROBLEMS
+// =============================================================================
+
+// newAccuracyState returns a level state at the origin of the given altitude
+func newAccuracyState(altitude float64) *AircraftState {
+	state := NewAircraftState()
+	state.Altitude = altitude
+	state.Position = Vector3{X: 0, Y: 0, Z: -altitude}
+	state.Orientation = Quaternion{W: 1, X: 0, Y: 0, Z: 0}
+	state.Velocity = Vector3{}
+	state.AngularRate = Vector3{}
+	state.UpdateAtmosphere()
+	return state
+}
+
+// kinematicDerivatives fills in the position and orientation derivatives
+// implied by the state's velocity and angular rate
+func kinematicDerivatives(state *AircraftState) *StateDerivatives {
+	omega := Quaternion{W: 0, X: state.AngularRate.X, Y: state.AngularRate.Y, Z: state.AngularRate.Z}
+	return &StateDerivatives{
+		PositionDot:    state.GroundVelocity(),
+		OrientationDot: state.Orientation.Multiply(omega).Scale(0.5),
+		AltitudeDot:    -state.GroundVelocity().Z,
+	}
+}
+
+// NewTurnAccuracyProblem is a level turn at constant body-axis speed and
+// yaw rate. The ground track is a circle of radius speed/yawRate.
+func NewTurnAccuracyProblem(speed, yawRate, duration float64) *AccuracyProblem {
+	const altitude = 1000.0
+	radius := speed / yawRate
+
+	return &AccuracyProblem{
+		Name:     "Constant-Rate Turn",
+		Duration: duration,
+		Initial: func() *AircraftState {
+			state := newAccuracyState(altitude)
+			state.Velocity = Vector3{X: speed, Y: 0, Z: 0}
+			state.AngularRate = Vector3{X: 0, Y: 0, Z: yawRate}
+			return state
+		},
+		Dynamics: func(state *AircraftState) (*StateDerivatives, error) {
+			return kinematicDerivatives(state), nil
+		},
+		Error: func(state *AircraftState, t float64) float64 {
+			exact := Vector3{
+				X: radius * math.Sin(yawRate*t),
+				Y: radius * (1 - math.Cos(yawRate*t)),
+				Z: -altitude,
+			}
+			return state.Position.Add(exact.Scale(-1)).Magnitude()
+		},
+	}
+}
+
+// NewBallisticAccuracyProblem is a vertical climb under constant gravity
+// with no drag. Altitude follows a parabola.
+func NewBallisticAccuracyProblem(climbRate, duration float64) *AccuracyProblem {
+	const altitude = 1000.0
+	const gravity = 9.80665
+
+	return &AccuracyProblem{
+		Name:     "Vertical Ballistic Arc",
+		Duration: duration,
+		Initial: func() *AircraftState {
+			state := newAccuracyState(altitude)
+			state.Velocity = Vector3{X: 0, Y: 0, Z: -climbRate}
+			return state
+		},
+		Dynamics: func(state *AircraftState) (*StateDerivatives, error) {
+			derivatives := kinematicDerivatives(state)
+			derivatives.VelocityDot = Vector3{X: 0, Y: 0, Z: gravity}
+			return derivatives, nil
+		},
+		Error: func(state *AircraftState, t float64) float64 {
+			exactAltitude := altitude + climbRate*t - 0.5*gravity*t*t
+			exactSink := -climbRate + gravity*t
+			return math.Hypot(state.Altitude-exactAltitude, state.Velocity.Z-exactSink)
+		},
+	}
+}
+
+// NewOscillatorAccuracyProblem maps a harmonic oscillator onto the body
+// velocities: du/dt = omega*w, dw/dt = -omega*u, so u = A cos(omega t) and
+// w = -A sin(omega t).
+func NewOscillatorAccuracyProblem(amplitude, omega, duration float64) *AccuracyProblem {
+	return &AccuracyProblem{
+		Name:     "Harmonic Oscillator",
+		Duration: duration,
+		Initial: func() *AircraftState {
+			state := newAccuracyState(1000.0)
+			state.Velocity = Vector3{X: amplitude, Y: 0, Z: 0}
+			return state
+		},
+		Dynamics: func(state *AircraftState) (*StateDerivatives, error) {
+			derivatives := kinematicDerivatives(state)
+			derivatives.VelocityDot = Vector3{
+				X: omega * state.Velocity.Z,
+				Y: 0,
+				Z: -omega * state.Velocity.X,
+			}
+			return derivatives, nil
+		},
+		Error: func(state *AircraftState, t float64) float64 {
+			return math.Hypot(state.Velocity.X-amplitude*math.Cos(omega*t),
+				state.Velocity.Z+amplitude*math.Sin(omega*t))
+		},
+	}
+}
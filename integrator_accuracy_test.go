@@ -0,0 +1,84 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAccuracyBenchmark(t *testing.T) {
+	report, err := NewAccuracyBenchmark().Run()
+	if err != nil {
+		t.Fatalf("Benchmark failed: %v", err)
+	}
+	t.Logf("\n%s", report)
+
+	method := func(problem, name string) *AccuracyMethodReport {
+		t.Helper()
+		p := report.Problem(problem)
+		if p == nil {
+			t.Fatalf("Missing problem %q", problem)
+		}
+		m := p.Method(name)
+		if m == nil {
+			t.Fatalf("Missing method %q for %q", name, problem)
+		}
+		return m
+	}
+
+	t.Run("Turn Convergence Order", func(t *testing.T) {
+		euler := method("Constant-Rate Turn", "Euler")
+		assertApproxEqual(t, euler.ObservedOrder, 1.0, 0.2)
+
+		rk4 := method("Constant-Rate Turn", "True Runge-Kutta 4th Order")
+		assertApproxEqual(t, rk4.ObservedOrder, 4.0, 0.3)
+	})
+
+	t.Run("Errors Shrink With Time Step", func(t *testing.T) {
+		for _, name := range []string{"Euler", "True Runge-Kutta 4th Order"} {
+			samples := method("Constant-Rate Turn", name).Samples
+			for i := 1; i < len(samples); i++ {
+				if samples[i].RMSError >= samples[i-1].RMSError {
+					t.Errorf("%s: RMS error %.3e at dt=%g is not below %.3e at dt=%g", name,
+						samples[i].RMSError, samples[i].Dt, samples[i-1].RMSError, samples[i-1].Dt)
+				}
+				if samples[i].MaxError < samples[i].RMSError {
+					t.Errorf("%s: max error below RMS error at dt=%g", name, samples[i].Dt)
+				}
+			}
+		}
+	})
+
+	t.Run("Ballistic Arc Is Exact For RK4", func(t *testing.T) {
+		// Altitude is quadratic in time, so RK4 has no truncation error
+		rk4 := method("Vertical Ballistic Arc", "True Runge-Kutta 4th Order")
+		for _, s := range rk4.Samples {
+			if s.MaxError > 1e-9 {
+				t.Errorf("RK4 ballistic error %.3e at dt=%g, expected round-off only", s.MaxError, s.Dt)
+			}
+		}
+		if !math.IsNaN(rk4.ObservedOrder) {
+			t.Errorf("Order should be undefined below the noise floor, got %.2f", rk4.ObservedOrder)
+		}
+
+		euler := method("Vertical Ballistic Arc", "Euler")
+		assertApproxEqual(t, euler.ObservedOrder, 1.0, 0.2)
+	})
+
+	t.Run("Oscillator Convergence Order", func(t *testing.T) {
+		assertApproxEqual(t, method("Harmonic Oscillator", "Adams-Bashforth 2nd Order").ObservedOrder, 2.0, 0.2)
+		assertApproxEqual(t, method("Harmonic Oscillator", "True Runge-Kutta 4th Order").ObservedOrder, 4.0, 0.3)
+	})
+}
+
+func TestObservedOrder(t *testing.T) {
+	samples := []AccuracySample{
+		{Dt: 0.1, RMSError: 3e-2},
+		{Dt: 0.05, RMSError: 7.5e-3},
+		{Dt: 0.025, RMSError: 1.875e-3},
+	}
+	assertApproxEqual(t, observedOrder(samples), 2.0, 1e-9)
+
+	if order := observedOrder(samples[:1]); !math.IsNaN(order) {
+		t.Errorf("Expected NaN for a single sample, got %f", order)
+	}
+}
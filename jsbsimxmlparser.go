@@ -1,29 +1,35 @@
 package main
 
 import (
+	"bytes"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
+	"math"
 	"strconv"
 	"strings"
+	"sync/atomic"
 )
 
 // Unit conversion constants
 const (
-	FT_TO_M     = 0.3048
-	M_TO_FT     = 3.28084
-	FT2_TO_M2   = 0.092903
-	M2_TO_FT2   = 10.7639
-	IN_TO_FT    = 0.083333
-	FT_TO_IN    = 12.0
-	LB_TO_KG    = 0.453592
-	KG_TO_LB    = 2.20462
-	RAD_TO_DEG  = 57.2958
-	DEG_TO_RAD  = 0.0174533
-	KTS_TO_FPS  = 1.68781
-	FPS_TO_KTS  = 0.592484
-	HP_TO_W     = 745.7
-	W_TO_HP     = 0.00134102
+	FT_TO_M         = 0.3048
+	M_TO_FT         = 3.28084
+	FT2_TO_M2       = 0.092903
+	M2_TO_FT2       = 10.7639
+	IN_TO_FT        = 0.083333
+	FT_TO_IN        = 12.0
+	LB_TO_KG        = 0.453592
+	KG_TO_LB        = 2.20462
+	RAD_TO_DEG      = 57.2958
+	DEG_TO_RAD      = 0.0174533
+	KTS_TO_FPS      = 1.68781
+	FPS_TO_KTS      = 0.592484
+	HP_TO_W         = 745.7
+	W_TO_HP         = 0.00134102
+	SLUG_TO_LB      = 32.174
+	HP_TO_FTLBS_SEC = 550.0
 )
 
 // JSBSimConfig represents the root configuration
@@ -40,9 +46,27 @@ type JSBSimConfig struct {
 	FlightControl   *FlightControl   `xml:"flight_control"`
 	Autopilot       *FlightControl   `xml:"autopilot"`
 	Aerodynamics    *Aerodynamics    `xml:"aerodynamics"`
-	Input           *Input           `xml:"input"`
-	Output          *Output          `xml:"output"`
+	Input           []*Input         `xml:"input"`
+	Output          []*Output        `xml:"output"`
 	SystemControl   *SystemControl   `xml:"system"`
+	Placards        *Placards        `xml:"placards"`
+	
+	// Warnings lists problems found while parsing that do not stop the
+	// configuration loading, such as function units that are not converted
+	Warnings []string `xml:"-"`
+	
+	// ParseWarnings lists the elements and attributes that were ignored,
+	// when parsed with WarnIgnoredElements
+	ParseWarnings []ParseWarning `xml:"-"`
+	
+	// Translated notes what was translated, and what left out, when the
+	// configuration was read from the JSBSim 1.x format
+	Translated []string `xml:"-"`
+	
+	// Variant names the loading variant LoadAircraftVariant applied over
+	// the base configuration, and Mutations lists its edits in order
+	Variant   string   `xml:"-"`
+	Mutations []string `xml:"-"`
 }
 
 // Header contains administrative and source information
@@ -52,6 +76,8 @@ type Header struct {
 	Description      string       `xml:"description"`
 	Version          string       `xml:"version"`
 	References       []*Reference `xml:"reference"`
+	Limitations      []string     `xml:"limitation"`
+	Notes            []string     `xml:"note"`
 }
 
 // Reference contains reference information
@@ -64,14 +90,15 @@ type Reference struct {
 
 // Metrics contains geometric parameters
 type Metrics struct {
-	WingArea    *Measurement `xml:"wingarea"`
-	WingSpan    *Measurement `xml:"wingspan"`
-	Chord       *Measurement `xml:"chord"`
-	HTailArea   *Measurement `xml:"htailarea"`
-	HTailArm    *Measurement `xml:"htailarm"`
-	VTailArea   *Measurement `xml:"vtailarea"`
-	VTailArm    *Measurement `xml:"vtailarm"`
-	Location    []*Location  `xml:"location"`
+	WingArea      *Measurement `xml:"wingarea"`
+	WingSpan      *Measurement `xml:"wingspan"`
+	WingIncidence *Measurement `xml:"wing_incidence"`
+	Chord         *Measurement `xml:"chord"`
+	HTailArea     *Measurement `xml:"htailarea"`
+	HTailArm      *Measurement `xml:"htailarm"`
+	VTailArea     *Measurement `xml:"vtailarea"`
+	VTailArm      *Measurement `xml:"vtailarm"`
+	Location      []*Location  `xml:"location"`
 }
 
 // Measurement represents a value with optional unit
@@ -80,6 +107,21 @@ type Measurement struct {
 	Value float64 `xml:",chardata"`
 }
 
+// Placards holds the placarded flap, gear and airframe speeds, an extension
+// to the JSBSim format
+type Placards struct {
+	Vfe []*FlapPlacard `xml:"vfe"`
+	Vle *Measurement   `xml:"vle"`
+	Vno *Measurement   `xml:"vno"`
+	Vne *Measurement   `xml:"vne"`
+}
+
+// FlapPlacard is the speed limit of the flaps extended up to a deflection
+type FlapPlacard struct {
+	Flaps float64 `xml:"flaps,attr"` // Flap deflection (deg)
+	Measurement
+}
+
 // Location represents a 3D position
 type Location struct {
 	Name string  `xml:"name,attr"`
@@ -116,19 +158,20 @@ type GroundReactions struct {
 
 // Contact represents a ground contact point (landing gear, etc.)
 type Contact struct {
-	Type             string       `xml:"type,attr"`
-	Name             string       `xml:"name,attr"`
-	Location         *Location    `xml:"location"`
-	StaticFriction   float64      `xml:"static_friction"`
-	DynamicFriction  float64      `xml:"dynamic_friction"`
-	RollingFriction  float64      `xml:"rolling_friction"`
-	Spring           *Spring      `xml:"spring"`
-	Damper           *Damper      `xml:"damper"`
-	SpringCoeff      *Measurement `xml:"spring_coeff"`
-	DampingCoeff     *Measurement `xml:"damping_coeff"`
-	MaxSteer         *Measurement `xml:"max_steer"`
-	BrakeGroup       string       `xml:"brake_group"`
-	Retractable      int          `xml:"retractable"`
+	Type                string       `xml:"type,attr"`
+	Name                string       `xml:"name,attr"`
+	Location            *Location    `xml:"location"`
+	StaticFriction      float64      `xml:"static_friction"`
+	DynamicFriction     float64      `xml:"dynamic_friction"`
+	RollingFriction     float64      `xml:"rolling_friction"`
+	Spring              *Spring      `xml:"spring"`
+	Damper              *Damper      `xml:"damper"`
+	SpringCoeff         *Measurement `xml:"spring_coeff"`
+	DampingCoeff        *Measurement `xml:"damping_coeff"`
+	DampingCoeffRebound *Measurement `xml:"damping_coeff_rebound"`
+	MaxSteer            *Measurement `xml:"max_steer"`
+	BrakeGroup          string       `xml:"brake_group"`
+	Retractable         int          `xml:"retractable"`
 }
 
 // Spring represents spring characteristics
@@ -173,6 +216,8 @@ type Thruster struct {
 	Name     string    `xml:"name,attr"`
 	Location *Location `xml:"location"`
 	Orient   *Orient   `xml:"orient"`
+	Sense    float64   `xml:"sense"`    // 1 clockwise seen from behind, -1 counter-clockwise
+	PFactor  float64   `xml:"p_factor"` // Thrust line shift (in) per radian of alpha
 }
 
 // Tank represents a fuel/oxidizer tank
@@ -187,10 +232,17 @@ type Tank struct {
 
 // FlightControl contains flight control system definition
 type FlightControl struct {
-	Name      string      `xml:"name,attr"`
-	Property  []string    `xml:"property"`
-	RateGroup []*RateGroup `xml:"rate_group"`
-	Channel   []*Channel   `xml:"channel"`
+	Name      string              `xml:"name,attr"`
+	Property  []*DeclaredProperty `xml:"property"`
+	RateGroup []*RateGroup        `xml:"rate_group"`
+	Channel   []*Channel          `xml:"channel"`
+}
+
+// DeclaredProperty is a <property> of a flight_control or system section,
+// declaring a custom property with an optional default value
+type DeclaredProperty struct {
+	Name  string   `xml:",chardata"`
+	Value *float64 `xml:"value,attr"`
 }
 
 // RateGroup defines execution rate for components
@@ -208,24 +260,77 @@ type Channel struct {
 
 // Component represents a flight control component
 type Component struct {
-	Name      string     `xml:"name,attr"`
-	Type      string     `xml:"type,attr"`
-	RateGroup string     `xml:"rate_group,attr"`
-	Input     []string   `xml:"input"`
-	Output    string     `xml:"output"`
-	Gain      float64    `xml:"gain"`
-	Function  *Function  `xml:"function"`
-	Clipto    *Clipto    `xml:"clipto"`
-	Test      []*Test    `xml:"test"`
-	Default   *Default   `xml:"default"`
-	C1        float64    `xml:"c1"`
-	C2        float64    `xml:"c2"`
-	C3        float64    `xml:"c3"`
-	C4        float64    `xml:"c4"`
-	C5        float64    `xml:"c5"`
-	C6        float64    `xml:"c6"`
-	Traverse  *Traverse  `xml:"traverse"`
-	Width     float64    `xml:"width"`
+	Name         string    `xml:"name,attr"`
+	Type         string    `xml:"type,attr"`
+	RateGroup    string    `xml:"rate_group,attr"`
+	Input        []string  `xml:"input"`
+	Output       string    `xml:"output"`
+	Gain         float64   `xml:"gain"`
+	Bias         float64   `xml:"bias"`
+	RateLimit    float64   `xml:"rate_limit"`
+	Lag          float64   `xml:"lag"`
+	Function     *Function `xml:"function"`
+	Table        *Table    `xml:"table"` // scheduled_gain schedule
+	Clipto       *Clipto   `xml:"clipto"`
+	Domain       *Clipto   `xml:"domain"` // aerosurface_scale input domain
+	Range        *Clipto   `xml:"range"`  // aerosurface_scale output range
+	ZeroCentered string    `xml:"zero_centered"`
+	Min          string    `xml:"min"` // saturation limits (number or property)
+	Max          string    `xml:"max"`
+	Test         []*Test   `xml:"test"`
+	Default      *Default  `xml:"default"`
+	C1           float64   `xml:"c1"`
+	C2           float64   `xml:"c2"`
+	C3           float64   `xml:"c3"`
+	C4           float64   `xml:"c4"`
+	C5           float64   `xml:"c5"`
+	C6           float64   `xml:"c6"`
+	Traverse     *Traverse `xml:"traverse"`
+	Width        float64   `xml:"width"`
+	
+	Source SourceLocation `xml:"-"` // Where the component was defined
+}
+
+// UnmarshalXML decodes a channel, accepting both the v1 <component type="...">
+// form and the v2 form where the element name is the component type
+// (<switch>, <pure_gain>, <actuator>, ...). Document order is preserved
+// because it is also the execution order.
+func (ch *Channel) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for _, attr := range start.Attr {
+		if attr.Name.Local == "name" {
+			ch.Name = attr.Value
+		}
+	}
+
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch el := token.(type) {
+		case xml.StartElement:
+			switch el.Name.Local {
+			case "sensor":
+				sensor := &Sensor{}
+				if err := d.DecodeElement(sensor, &el); err != nil {
+					return err
+				}
+				ch.Sensor = append(ch.Sensor, sensor)
+			default:
+				comp := &Component{}
+				if err := d.DecodeElement(comp, &el); err != nil {
+					return err
+				}
+				if el.Name.Local != "component" {
+					comp.Type = el.Name.Local
+				}
+				ch.Component = append(ch.Component, comp)
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
 }
 
 // Sensor represents a sensor with noise and lag
@@ -255,9 +360,10 @@ type Quantization struct {
 
 // Test represents a conditional test
 type Test struct {
-	Logic string  `xml:"logic,attr"`
-	Value string  `xml:"value,attr"`
-	Test  string  `xml:",chardata"`
+	Logic  string  `xml:"logic,attr"`
+	Value  string  `xml:"value,attr"`
+	Test   string  `xml:",chardata"`
+	Nested []*Test `xml:"test"` // Nested condition groups
 }
 
 // Default represents a default value
@@ -304,28 +410,38 @@ type Axis struct {
 
 // Function represents a mathematical function
 type Function struct {
-	Name        string      `xml:"name,attr"`
-	Description string      `xml:"description"`
-	Product     *Operation  `xml:"product"`
-	Difference  *Operation  `xml:"difference"`
-	Sum         *Operation  `xml:"sum"`
-	Quotient    *Operation  `xml:"quotient"`
-	Pow         *Operation  `xml:"pow"`
-	Abs         *Operation  `xml:"abs"`
-	Sin         *Operation  `xml:"sin"`
-	Cos         *Operation  `xml:"cos"`
-	Tan         *Operation  `xml:"tan"`
-	Asin        *Operation  `xml:"asin"`
-	Acos        *Operation  `xml:"acos"`
-	Atan        *Operation  `xml:"atan"`
-	Table       *Table      `xml:"table"`
-}
-
-// Operation represents a mathematical operation
+	Name          string     `xml:"name,attr"`
+	Unit          string     `xml:"unit,attr"` // Output unit, converted to SI on evaluation
+	Description   string     `xml:"description"`
+	Documentation string     `xml:"documentation"`
+	Product       *Operation `xml:"product"`
+	Difference    *Operation `xml:"difference"`
+	Sum           *Operation `xml:"sum"`
+	Quotient      *Operation `xml:"quotient"`
+	Pow           *Operation `xml:"pow"`
+	Abs           *Operation `xml:"abs"`
+	Sin           *Operation `xml:"sin"`
+	Cos           *Operation `xml:"cos"`
+	Tan           *Operation `xml:"tan"`
+	Asin          *Operation `xml:"asin"`
+	Acos          *Operation `xml:"acos"`
+	Atan          *Operation `xml:"atan"`
+	Table         *Table     `xml:"table"`
+	
+	Source SourceLocation `xml:"-"` // Where the function was defined
+}
+
+// Operation represents a mathematical operation. Its operands are kept in
+// document order, which matters to difference, quotient and pow; an
+// operation built in code rather than decoded takes its properties, then
+// its values, then its nested operations in the order of operationNames,
+// then its tables. When a kind of nested operation is repeated, as the
+// products of a sum often are, its field holds the first.
 type Operation struct {
+	Unit       string      `xml:"unit,attr"` // Result unit, converted to SI on evaluation
 	Property   []string    `xml:"property"`
 	Value      []float64   `xml:"value"`
-	Table      *Table      `xml:"table"`
+	Tables     []*Table    `xml:"table"`
 	Product    *Operation  `xml:"product"`
 	Difference *Operation  `xml:"difference"`
 	Sum        *Operation  `xml:"sum"`
@@ -338,6 +454,139 @@ type Operation struct {
 	Asin       *Operation  `xml:"asin"`
 	Acos       *Operation  `xml:"acos"`
 	Atan       *Operation  `xml:"atan"`
+	
+	operands []operand // Document order, nil for an operation built in code
+}
+
+// operandKind says which list of an operation an operand is from
+type operandKind uint8
+
+const (
+	operandProperty operandKind = iota
+	operandValue
+	operandTable
+	operandOperation
+)
+
+// operand is one argument of an operation: an index into its Property,
+// Value or Tables, or a nested operation with its element name
+type operand struct {
+	kind   operandKind
+	index  int
+	nested namedOperation
+}
+
+// UnmarshalXML decodes an operation, keeping its operands in document order
+func (op *Operation) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for _, attr := range start.Attr {
+		if attr.Name.Local == "unit" {
+			op.Unit = attr.Value
+		}
+	}
+	
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+		
+		switch el := token.(type) {
+		case xml.StartElement:
+			if err := op.decodeOperand(d, el); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+func (op *Operation) decodeOperand(d *xml.Decoder, el xml.StartElement) error {
+	switch el.Name.Local {
+	case "property":
+		var property string
+		if err := d.DecodeElement(&property, &el); err != nil {
+			return err
+		}
+		op.addProperty(property)
+	case "value":
+		var value float64
+		if err := d.DecodeElement(&value, &el); err != nil {
+			return err
+		}
+		op.addValue(value)
+	case "table":
+		table := &Table{}
+		if err := d.DecodeElement(table, &el); err != nil {
+			return err
+		}
+		op.addTable(table)
+	default:
+		kind := operationIndex(el.Name.Local)
+		if kind < 0 {
+			return d.Skip()
+		}
+		nested := &Operation{}
+		if err := d.DecodeElement(nested, &el); err != nil {
+			return err
+		}
+		op.addOperation(kind, nested)
+	}
+	return nil
+}
+
+// addProperty, addValue, addTable and addOperation append an operand
+
+func (op *Operation) addProperty(property string) {
+	op.operands = append(op.operands, operand{kind: operandProperty, index: len(op.Property)})
+	op.Property = append(op.Property, property)
+}
+
+func (op *Operation) addValue(value float64) {
+	op.operands = append(op.operands, operand{kind: operandValue, index: len(op.Value)})
+	op.Value = append(op.Value, value)
+}
+
+func (op *Operation) addTable(table *Table) {
+	op.operands = append(op.operands, operand{kind: operandTable, index: len(op.Tables)})
+	op.Tables = append(op.Tables, table)
+}
+
+// addOperation appends a nested operation of the kind operationNames[kind]
+func (op *Operation) addOperation(kind int, nested *Operation) {
+	if slot := operationSlots(op)[kind]; *slot == nil {
+		*slot = nested
+	}
+	op.operands = append(op.operands, operand{
+		kind:   operandOperation,
+		nested: namedOperation{name: operationNames[kind], op: nested},
+	})
+}
+
+// operandList returns the operands of an operation in evaluation order
+func (op *Operation) operandList() []operand {
+	if op.operands != nil {
+		return op.operands
+	}
+	var operands []operand
+	for i := range op.Property {
+		operands = append(operands, operand{kind: operandProperty, index: i})
+	}
+	for i := range op.Value {
+		operands = append(operands, operand{kind: operandValue, index: i})
+	}
+	for i, nested := range op.operations() {
+		if nested != nil {
+			operands = append(operands, operand{
+				kind:   operandOperation,
+				nested: namedOperation{name: operationNames[i], op: nested},
+			})
+		}
+	}
+	for i := range op.Tables {
+		operands = append(operands, operand{kind: operandTable, index: i})
+	}
+	return operands
 }
 
 // Table represents a lookup table
@@ -345,6 +594,10 @@ type Table struct {
 	Name           string           `xml:"name,attr"`
 	IndependentVar []*IndependentVar `xml:"independentVar"`
 	TableData      []*TableData      `xml:"tableData"`
+	
+	Source SourceLocation `xml:"-"` // Where the table was defined
+	
+	parsed atomic.Pointer[cachedTable] // Parsed form, with the data it was parsed from
 }
 
 // IndependentVar represents an independent variable for table lookup
@@ -368,6 +621,23 @@ func (td *TableData) GetBreakpoint() string {
 	return td.BreakPoint
 }
 
+// PrimaryInput returns the first <input> element, or nil when there is none
+func (config *JSBSimConfig) PrimaryInput() *Input {
+	if len(config.Input) == 0 {
+		return nil
+	}
+	return config.Input[0]
+}
+
+// PrimaryOutput returns the first <output> element, or nil when there is
+// none. Configurations often have several, such as a file and a socket.
+func (config *JSBSimConfig) PrimaryOutput() *Output {
+	if len(config.Output) == 0 {
+		return nil
+	}
+	return config.Output[0]
+}
+
 // Input defines input interfaces
 type Input struct {
 	Port     int    `xml:"port,attr"`
@@ -380,142 +650,320 @@ type Output struct {
 	Type     string `xml:"type,attr"`
 	Port     int    `xml:"port,attr"`
 	Protocol string `xml:"protocol,attr"`
-	Rate     int    `xml:"rate,attr"`
+	Rate     int    `xml:"rate,attr"` // Output rate (Hz)
+	
+	// Category toggles selecting groups of standard values
+	Simulation      OutputToggle `xml:"simulation"`
+	Atmosphere      OutputToggle `xml:"atmosphere"`
+	MassProps       OutputToggle `xml:"massprops"`
+	Rates           OutputToggle `xml:"rates"`
+	Velocities      OutputToggle `xml:"velocities"`
+	Forces          OutputToggle `xml:"forces"`
+	Moments         OutputToggle `xml:"moments"`
+	Position        OutputToggle `xml:"position"`
+	Coefficients    OutputToggle `xml:"coefficients"`
+	Aerodynamics    OutputToggle `xml:"aerodynamics"`
+	GroundReactions OutputToggle `xml:"ground_reactions"`
+	FCS             OutputToggle `xml:"fcs"`
+	Propulsion      OutputToggle `xml:"propulsion"`
+	AeroSurfaces    OutputToggle `xml:"aerosurfaces"`
+	
+	// Individually listed properties, in file order
+	Properties []*OutputProperty `xml:"property"`
+}
+
+// OutputToggle is an ON/OFF flag inside an output definition
+type OutputToggle string
+
+// On reports whether the toggle is set to ON
+func (t OutputToggle) On() bool {
+	return strings.EqualFold(strings.TrimSpace(string(t)), "ON")
+}
+
+// OutputProperty is a property listed in an output definition
+type OutputProperty struct {
+	Caption string `xml:"caption,attr"`
+	Name    string `xml:",chardata"`
 }
 
 // SystemControl represents system control definitions
 type SystemControl struct {
-	Name     string      `xml:"name,attr"`
-	File     string      `xml:"file,attr"`
-	Property []string    `xml:"property"`
-	Channel  []*Channel  `xml:"channel"`
+	Name     string              `xml:"name,attr"`
+	File     string              `xml:"file,attr"`
+	Property []*DeclaredProperty `xml:"property"`
+	Channel  []*Channel          `xml:"channel"`
 }
 
 // ParseJSBSimConfig parses a JSBSim XML configuration
-func ParseJSBSimConfig(r io.Reader) (*JSBSimConfig, error) {
-	decoder := xml.NewDecoder(r)
-	config := &JSBSimConfig{}
+func ParseJSBSimConfig(r io.Reader, options ...ParseOption) (*JSBSimConfig, error) {
+	var opts parseOptions
+	for _, option := range options {
+		option(&opts)
+	}
+	
+	// The document is read first to tell its format, and again when ignored
+	// elements are reported
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JSBSim config: %w", err)
+	}
+	legacy, err := detectLegacyConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse JSBSim config: %w", err)
+	}
+	var translated []string
+	if legacy {
+		if data, translated, err = translateLegacyConfig(data); err != nil {
+			return nil, fmt.Errorf("failed to parse JSBSim config: %w", err)
+		}
+	}
+	
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	config := &JSBSimConfig{Translated: translated}
 	
 	if err := decoder.Decode(config); err != nil {
 		return nil, fmt.Errorf("failed to parse JSBSim config: %w", err)
 	}
+	if opts.reportIgnored {
+		warnings, err := parseWarnings(data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse JSBSim config: %w", err)
+		}
+		config.ParseWarnings = warnings
+	}
+	assignSources(config, opts.sourceFile)
 	
 	// Post-process to handle unit conversions
+	units := &unitConversion{}
 	if config.Metrics != nil {
-		convertMetrics(config.Metrics)
+		units.convertMetrics(config.Metrics)
 	}
 	if config.MassBalance != nil {
-		convertMassBalance(config.MassBalance)
-	}
-	
-	return config, nil
-}
-
-// convertMetrics converts metric units to standard units
-func convertMetrics(m *Metrics) {
-	if m.WingArea != nil {
-		m.WingArea.Value = convertToStandardUnit(m.WingArea.Value, m.WingArea.Unit, "area")
-	}
-	if m.WingSpan != nil {
-		m.WingSpan.Value = convertToStandardUnit(m.WingSpan.Value, m.WingSpan.Unit, "length")
+		units.convertMassBalance(config.MassBalance)
 	}
-	if m.Chord != nil {
-		m.Chord.Value = convertToStandardUnit(m.Chord.Value, m.Chord.Unit, "length")
-	}
-	if m.HTailArea != nil {
-		m.HTailArea.Value = convertToStandardUnit(m.HTailArea.Value, m.HTailArea.Unit, "area")
+	if config.GroundReactions != nil {
+		units.convertGroundReactions(config.GroundReactions)
 	}
-	if m.HTailArm != nil {
-		m.HTailArm.Value = convertToStandardUnit(m.HTailArm.Value, m.HTailArm.Unit, "length")
+	if config.Propulsion != nil {
+		units.convertPropulsion(config.Propulsion)
 	}
-	if m.VTailArea != nil {
-		m.VTailArea.Value = convertToStandardUnit(m.VTailArea.Value, m.VTailArea.Unit, "area")
+	if config.Placards != nil {
+		units.convertPlacards(config.Placards)
 	}
-	if m.VTailArm != nil {
-		m.VTailArm.Value = convertToStandardUnit(m.VTailArm.Value, m.VTailArm.Unit, "length")
+	config.Warnings = units.warnings
+	
+	if config.Aerodynamics != nil {
+		if err := validateStandaloneFunctions(config.Aerodynamics.Function); err != nil {
+			return nil, fmt.Errorf("invalid aerodynamics: %w", err)
+		}
 	}
+	config.Warnings = append(config.Warnings, checkFunctionUnits(config)...)
+	config.Warnings = append(config.Warnings, checkTableData(config)...)
+	config.Warnings = append(config.Warnings, checkSubTableGrids(config)...)
+	
+	return config, nil
 }
 
-// convertMassBalance converts mass/inertia units
-func convertMassBalance(mb *MassBalance) {
-	if mb.IXX != nil {
-		mb.IXX.Value = convertToStandardUnit(mb.IXX.Value, mb.IXX.Unit, "inertia")
+// Parsed configurations use the FPS system JSBSim uses internally: lengths
+// in feet, areas in square feet, weights and forces in pounds, inertias in
+// slug·ft², speeds in ft/s, power in ft·lbf/s, gear spring and damping
+// coefficients in lbf/ft and lbf/ft/s, and angles in radians. Measurements
+// are converted as they are parsed and their unit is rewritten to the
+// canonical one; locations keep their own unit. Simulation code converts to
+// SI where it needs to.
+var standardUnits = map[string]struct {
+	canonical string
+	factors   map[string]float64
+}{
+	"length": {"FT", map[string]float64{
+		"FT": 1, "IN": IN_TO_FT, "M": M_TO_FT, "CM": M_TO_FT / 100, "MM": M_TO_FT / 1000,
+	}},
+	"area": {"FT2", map[string]float64{
+		"FT2": 1, "IN2": IN_TO_FT * IN_TO_FT, "M2": M2_TO_FT2,
+	}},
+	"mass": {"LBS", map[string]float64{
+		"LBS": 1, "KG": KG_TO_LB, "SLUG": SLUG_TO_LB,
+	}},
+	"force": {"LBS", map[string]float64{
+		"LBS": 1, "N": N_TO_LB,
+	}},
+	"inertia": {"SLUG*FT2", map[string]float64{
+		"SLUG*FT2": 1, "KG*M2": KG_TO_LB / SLUG_TO_LB * M_TO_FT * M_TO_FT, "KG-M2": KG_TO_LB / SLUG_TO_LB * M_TO_FT * M_TO_FT,
+	}},
+	"angle": {"RAD", map[string]float64{
+		"RAD": 1, "DEG": DEG_TO_RAD,
+	}},
+	"velocity": {"FT/SEC", map[string]float64{
+		"FT/SEC": 1, "FT/S": 1, "KTS": KTS_TO_FPS, "M/S": M_TO_FT, "M/SEC": M_TO_FT, "MPH": MPH_TO_MS * M_TO_FT,
+	}},
+	"power": {"FT*LBS/SEC", map[string]float64{
+		"FT*LBS/SEC": 1, "HP": HP_TO_FTLBS_SEC, "WATTS": HP_TO_FTLBS_SEC / HP_TO_W, "W": HP_TO_FTLBS_SEC / HP_TO_W,
+	}},
+	"spring": {"LBS/FT", map[string]float64{
+		"LBS/FT": 1, "LBS/IN": FT_TO_IN, "N/M": N_TO_LB / M_TO_FT,
+	}},
+	"damping": {"LBS/FT/SEC", map[string]float64{
+		"LBS/FT/SEC": 1, "LBS/IN/SEC": FT_TO_IN, "N/M/SEC": N_TO_LB / M_TO_FT,
+	}},
+}
+
+// convertToStandardUnit converts a value of the given kind of quantity to
+// the canonical FPS unit. A value without a unit is taken as already
+// canonical. An unknown unit or kind is an error, and the value is returned
+// unconverted with it.
+func convertToStandardUnit(value float64, unit string, unitType string) (float64, error) {
+	unit = strings.ToUpper(strings.TrimSpace(unit))
+	if unit == "" {
+		return value, nil
 	}
-	if mb.IYY != nil {
-		mb.IYY.Value = convertToStandardUnit(mb.IYY.Value, mb.IYY.Unit, "inertia")
+	
+	units, ok := standardUnits[unitType]
+	if !ok {
+		return value, fmt.Errorf("unknown quantity %q", unitType)
 	}
-	if mb.IZZ != nil {
-		mb.IZZ.Value = convertToStandardUnit(mb.IZZ.Value, mb.IZZ.Unit, "inertia")
+	factor, ok := units.factors[unit]
+	if !ok {
+		return value, fmt.Errorf("unknown %s unit %q", unitType, unit)
 	}
-	if mb.IXY != nil {
-		mb.IXY.Value = convertToStandardUnit(mb.IXY.Value, mb.IXY.Unit, "inertia")
+	return value * factor, nil
+}
+
+// unitConversion converts parsed measurements to the canonical units,
+// collecting a warning for each it cannot convert
+type unitConversion struct {
+	warnings []string
+}
+
+// measurement converts m in place and sets its unit to the canonical one
+func (uc *unitConversion) measurement(m *Measurement, unitType, element string) {
+	if m == nil {
+		return
 	}
-	if mb.IXZ != nil {
-		mb.IXZ.Value = convertToStandardUnit(mb.IXZ.Value, mb.IXZ.Unit, "inertia")
+	value, err := convertToStandardUnit(m.Value, m.Unit, unitType)
+	if err != nil {
+		uc.warnings = append(uc.warnings, fmt.Sprintf("%s: %v, value used unconverted", element, err))
+		return
 	}
-	if mb.IYZ != nil {
-		mb.IYZ.Value = convertToStandardUnit(mb.IYZ.Value, mb.IYZ.Unit, "inertia")
+	m.Value = value
+	m.Unit = standardUnits[unitType].canonical
+}
+
+// location checks that a location's unit is a known length unit
+func (uc *unitConversion) location(loc *Location, element string) {
+	if loc == nil {
+		return
 	}
-	if mb.EmptyMass != nil {
-		mb.EmptyMass.Value = convertToStandardUnit(mb.EmptyMass.Value, mb.EmptyMass.Unit, "mass")
+	if _, err := convertToStandardUnit(0, loc.Unit, "length"); err != nil {
+		uc.warnings = append(uc.warnings, fmt.Sprintf("%s location: %v", element, err))
 	}
 }
 
-// convertToStandardUnit converts a value to standard units
-func convertToStandardUnit(value float64, unit string, unitType string) float64 {
-	if unit == "" {
-		return value // Already in standard units
+// orient converts orientation angles to radians
+func (uc *unitConversion) orient(o *Orient, element string) {
+	if o == nil {
+		return
 	}
-	
-	switch unitType {
-	case "length":
-		switch strings.ToUpper(unit) {
-		case "M":
-			return value * M_TO_FT
-		case "IN":
-			return value * IN_TO_FT
-		}
-	case "area":
-		switch strings.ToUpper(unit) {
-		case "M2":
-			return value * M2_TO_FT2
-		}
-	case "mass":
-		switch strings.ToUpper(unit) {
-		case "KG":
-			return value * KG_TO_LB
-		}
-	case "inertia":
-		switch strings.ToUpper(unit) {
-		case "KG-M2", "KG*M2":
-			return value * KG_TO_LB * M_TO_FT * M_TO_FT
-		}
-	case "angle":
-		switch strings.ToUpper(unit) {
-		case "DEG":
-			return value * DEG_TO_RAD
-		}
-	case "velocity":
-		switch strings.ToUpper(unit) {
-		case "KTS":
-			return value * KTS_TO_FPS
-		}
-	case "power":
-		switch strings.ToUpper(unit) {
-		case "HP":
-			return value * HP_TO_W
+	for _, angle := range []*float64{&o.Roll, &o.Pitch, &o.Yaw} {
+		value, err := convertToStandardUnit(*angle, o.Unit, "angle")
+		if err != nil {
+			uc.warnings = append(uc.warnings, fmt.Sprintf("%s orient: %v, value used unconverted", element, err))
+			return
 		}
+		*angle = value
 	}
-	
-	return value
+	o.Unit = "RAD"
 }
 
-// ParseTable parses table data into a usable format
+// convertMetrics converts metric units to standard units
+func (uc *unitConversion) convertMetrics(m *Metrics) {
+	uc.measurement(m.WingArea, "area", "metrics/wingarea")
+	uc.measurement(m.WingSpan, "length", "metrics/wingspan")
+	uc.measurement(m.WingIncidence, "angle", "metrics/wing_incidence")
+	uc.measurement(m.Chord, "length", "metrics/chord")
+	uc.measurement(m.HTailArea, "area", "metrics/htailarea")
+	uc.measurement(m.HTailArm, "length", "metrics/htailarm")
+	uc.measurement(m.VTailArea, "area", "metrics/vtailarea")
+	uc.measurement(m.VTailArm, "length", "metrics/vtailarm")
+	for _, loc := range m.Location {
+		uc.location(loc, "metrics/"+loc.Name)
+	}
+}
+
+// convertMassBalance converts mass/inertia units
+func (uc *unitConversion) convertMassBalance(mb *MassBalance) {
+	uc.measurement(mb.IXX, "inertia", "mass_balance/ixx")
+	uc.measurement(mb.IYY, "inertia", "mass_balance/iyy")
+	uc.measurement(mb.IZZ, "inertia", "mass_balance/izz")
+	uc.measurement(mb.IXY, "inertia", "mass_balance/ixy")
+	uc.measurement(mb.IXZ, "inertia", "mass_balance/ixz")
+	uc.measurement(mb.IYZ, "inertia", "mass_balance/iyz")
+	uc.measurement(mb.EmptyMass, "mass", "mass_balance/emptywt")
+	uc.location(mb.Location, "mass_balance")
+	for _, pm := range mb.PointMass {
+		element := "pointmass " + pm.Name
+		uc.measurement(pm.Mass, "mass", element+" weight")
+		uc.location(pm.Location, element)
+	}
+}
+
+// convertPlacards converts the placard speeds
+func (uc *unitConversion) convertPlacards(p *Placards) {
+	for _, vfe := range p.Vfe {
+		uc.measurement(&vfe.Measurement, "velocity", "placards vfe")
+	}
+	uc.measurement(p.Vle, "velocity", "placards vle")
+	uc.measurement(p.Vno, "velocity", "placards vno")
+	uc.measurement(p.Vne, "velocity", "placards vne")
+}
+
+// convertGroundReactions converts contact coefficients and steering angles
+func (uc *unitConversion) convertGroundReactions(gr *GroundReactions) {
+	for _, contact := range gr.Contact {
+		element := "contact " + contact.Name
+		uc.measurement(contact.SpringCoeff, "spring", element+" spring_coeff")
+		uc.measurement(contact.DampingCoeff, "damping", element+" damping_coeff")
+		uc.measurement(contact.DampingCoeffRebound, "damping", element+" damping_coeff_rebound")
+		if contact.MaxSteer != nil && strings.TrimSpace(contact.MaxSteer.Unit) == "" {
+			contact.MaxSteer.Unit = "DEG" // JSBSim reads a max_steer without a unit in degrees
+		}
+		uc.measurement(contact.MaxSteer, "angle", element+" max_steer")
+		uc.location(contact.Location, element)
+	}
+}
+
+// convertPropulsion converts engine and thruster orientations and tank
+// quantities
+func (uc *unitConversion) convertPropulsion(p *Propulsion) {
+	for _, engine := range p.Engine {
+		element := "engine " + engine.File
+		uc.location(engine.Location, element)
+		uc.orient(engine.Orient, element)
+		if thruster := engine.Thruster; thruster != nil {
+			element := "thruster " + thruster.File
+			uc.location(thruster.Location, element)
+			uc.orient(thruster.Orient, element)
+		}
+	}
+	for _, tank := range p.Tank {
+		element := fmt.Sprintf("tank %d", tank.Number)
+		uc.measurement(tank.Capacity, "mass", element+" capacity")
+		uc.measurement(tank.Contents, "mass", element+" contents")
+		uc.location(tank.Location, element)
+	}
+}
+
+// ParseTable parses table data into a usable format. It depends only on the
+// table element, not on where it appears, so tables from engine and system
+// files parse as the aerodynamic ones do. Malformed numbers read as zero
+// and 1D rows without a value are skipped, each noted in the table's
+// Warnings; comments in the data are skipped. A 2D table with a single row
+// or column is reduced to a 1D table of its other variable.
 func ParseTable(t *Table) (*ParsedTable, error) {
 	pt := &ParsedTable{
 		Name:           t.Name,
 		IndependentVars: make([]string, len(t.IndependentVar)),
 		LookupTypes:    make([]string, len(t.IndependentVar)),
+		Source:         t.Source,
 	}
 	
 	for i, iv := range t.IndependentVar {
@@ -523,30 +971,46 @@ func ParseTable(t *Table) (*ParsedTable, error) {
 		pt.LookupTypes[i] = iv.Lookup
 	}
 	
+	if len(t.IndependentVar) == 0 || len(t.IndependentVar) > 3 {
+		return nil, fmt.Errorf("table %s: unsupported table dimension: %d", t.Name, len(t.IndependentVar))
+	}
+	if len(t.TableData) == 0 {
+		return nil, fmt.Errorf("table %s: no tableData", t.Name)
+	}
+	
 	// Parse table data based on dimensions
-	if len(t.IndependentVar) == 1 {
-		// 1D table
+	var err error
+	switch len(t.IndependentVar) {
+	case 1:
 		pt.Dimension = 1
-		pt.Data1D = parse1DTableData(t.TableData[0].Data)
-	} else if len(t.IndependentVar) == 2 {
-		// 2D table
+		pt.Data1D = parse1DTableData(t.TableData[0].Data, &pt.Warnings)
+	case 2:
 		pt.Dimension = 2
-		pt.Data2D = parse2DTableData(t.TableData[0].Data)
-	} else if len(t.IndependentVar) == 3 {
-		// 3D table
+		pt.Data2D, err = parse2DTableData(t.TableData[0].Data, &pt.Warnings)
+	case 3:
 		pt.Dimension = 3
 		pt.Data3D = make([]*Table2D, len(t.TableData))
 		for i, td := range t.TableData {
-			bp, _ := strconv.ParseFloat(td.GetBreakpoint(), 64)
-			parsed2D := parse2DTableData(td.Data)
-			pt.Data3D[i] = &Table2D{
-				Breakpoint: bp,
-				RowIndices: parsed2D.RowIndices,
-				ColIndices: parsed2D.ColIndices,
-				Data:       parsed2D.Data,
+			bp := parseTableFields([]string{strings.TrimSpace(td.GetBreakpoint())}, &pt.Warnings)[0]
+			if pt.Data3D[i], err = parse2DTableData(td.Data, &pt.Warnings); err != nil {
+				break
 			}
+			pt.Data3D[i].Breakpoint = bp
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("table %s: %w", t.Name, err)
+	}
+	
+	// Lay the data out with the variables, in declaration order, along
+	// the row, column and table axes, so inputs are given in that order
+	// whatever the lookup attributes
+	if pt.Dimension >= 2 {
+		if err := pt.Reorder(tableLookups[:pt.Dimension]); err != nil {
+			return nil, err
 		}
 	}
+	pt.collapse()
 	
 	return pt, nil
 }
@@ -560,6 +1024,11 @@ type ParsedTable struct {
 	Data1D          *Table1D
 	Data2D          *Table2D
 	Data3D          []*Table2D
+	Source          SourceLocation // Where the table was defined
+	Warnings        []string       // Malformed numbers read as zero and rows skipped
+	
+	// The declared layout of a 2D table reduced to 1D; nil otherwise
+	Collapsed       *CollapsedLayout
 }
 
 // Table1D represents a 1D table
@@ -576,9 +1045,25 @@ type Table2D struct {
 	Data       [][]float64
 }
 
-// parse1DTableData parses 1D table data
-func parse1DTableData(data string) *Table1D {
-	lines := strings.Split(strings.TrimSpace(data), "\n")
+// stripXMLComments removes the comments the raw table data may hold
+func stripXMLComments(data string) string {
+	for {
+		start := strings.Index(data, "<!--")
+		if start < 0 {
+			return data
+		}
+		end := strings.Index(data[start:], "-->")
+		if end < 0 {
+			return data[:start]
+		}
+		data = data[:start] + data[start+end+len("-->"):]
+	}
+}
+
+// parse1DTableData parses 1D table data: a breakpoint and a value a line.
+// A row without a value is skipped with a warning.
+func parse1DTableData(data string, warnings *[]string) *Table1D {
+	lines := strings.Split(strings.TrimSpace(stripXMLComments(data)), "\n")
 	t := &Table1D{
 		Indices: make([]float64, 0, len(lines)),
 		Values:  make([]float64, 0, len(lines)),
@@ -586,58 +1071,125 @@ func parse1DTableData(data string) *Table1D {
 	
 	for _, line := range lines {
 		fields := strings.Fields(line)
-		if len(fields) >= 2 {
-			idx, _ := strconv.ParseFloat(fields[0], 64)
-			val, _ := strconv.ParseFloat(fields[1], 64)
-			t.Indices = append(t.Indices, idx)
-			t.Values = append(t.Values, val)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 2 {
+			*warnings = append(*warnings, fmt.Sprintf("row %q has no value, skipped", strings.TrimSpace(line)))
+			continue
 		}
+		values := parseTableFields(fields[:2], warnings)
+		t.Indices = append(t.Indices, values[0])
+		t.Values = append(t.Values, values[1])
 	}
 	
 	return t
 }
 
-// parse2DTableData parses 2D table data
-func parse2DTableData(data string) *Table2D {
-	lines := strings.Split(strings.TrimSpace(data), "\n")
+// parse2DTableData parses 2D table data: the column breakpoints, then a
+// line per row of its breakpoint and values
+func parse2DTableData(data string, warnings *[]string) (*Table2D, error) {
+	lines := strings.Split(strings.TrimSpace(stripXMLComments(data)), "\n")
 	if len(lines) < 2 {
-		return nil
+		return nil, fmt.Errorf("2D data needs column breakpoints and at least one row")
 	}
 	
 	// Parse column indices from first line
-	colFields := strings.Fields(lines[0])
 	t := &Table2D{
-		ColIndices: make([]float64, 0, len(colFields)),
+		ColIndices: parseTableFields(strings.Fields(lines[0]), warnings),
 		RowIndices: make([]float64, 0, len(lines)-1),
 		Data:       make([][]float64, 0, len(lines)-1),
 	}
 	
-	for _, field := range colFields {
-		val, _ := strconv.ParseFloat(field, 64)
-		t.ColIndices = append(t.ColIndices, val)
-	}
-	
 	// Parse data rows
 	for i := 1; i < len(lines); i++ {
 		fields := strings.Fields(lines[i])
-		if len(fields) > 0 {
-			rowIdx, _ := strconv.ParseFloat(fields[0], 64)
-			t.RowIndices = append(t.RowIndices, rowIdx)
-			
-			row := make([]float64, 0, len(fields)-1)
-			for j := 1; j < len(fields); j++ {
-				val, _ := strconv.ParseFloat(fields[j], 64)
-				row = append(row, val)
-			}
-			t.Data = append(t.Data, row)
+		if len(fields) == 0 {
+			continue
 		}
+		values := parseTableFields(fields, warnings)
+		t.RowIndices = append(t.RowIndices, values[0])
+		t.Data = append(t.Data, values[1:])
 	}
 	
-	return t
+	return t, nil
+}
+
+// parseTableFields parses the numbers of a line of table data, reading a
+// malformed one as zero with a warning
+func parseTableFields(fields []string, warnings *[]string) []float64 {
+	values := make([]float64, len(fields))
+	for i, field := range fields {
+		v, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			*warnings = append(*warnings, fmt.Sprintf("malformed number %q read as 0", field))
+			continue
+		}
+		values[i] = v
+	}
+	return values
+}
+
+// NonFiniteInputError reports a NaN or infinite value reaching a table or
+// function input. Property names the input where it was detected.
+type NonFiniteInputError struct {
+	Property string
+	Value    float64
+}
+
+func (e *NonFiniteInputError) Error() string {
+	return fmt.Sprintf("%s is %v", e.Property, e.Value)
+}
+
+// isFinite reports whether v is neither NaN nor infinite
+func isFinite(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
 }
 
-// InterpolateTable performs table interpolation
+// isNonFiniteInput reports whether err was caused by a NaN or infinite input.
+// It is called for every failed evaluation, so it walks the chain itself
+// rather than use errors.As, which allocates.
+func isNonFiniteInput(err error) bool {
+	for err != nil {
+		if _, ok := err.(*NonFiniteInputError); ok {
+			return true
+		}
+		err = errors.Unwrap(err)
+	}
+	return false
+}
+
+// InterpolateTable performs table interpolation. NaN or infinite inputs are
+// rejected rather than clamped to a breakpoint. A 2D table reduced to 1D
+// takes either the one input of the variable it kept or both declared
+// inputs, in which case the input of the variable dropped is ignored; any
+// other number of inputs is an error.
 func InterpolateTable(pt *ParsedTable, inputs ...float64) (float64, error) {
+	if c := pt.Collapsed; c != nil {
+		switch len(inputs) {
+		case 1:
+		case len(c.Vars):
+			inputs = inputs[c.Kept : c.Kept+1]
+		default:
+			return 0, fmt.Errorf("2D table reduced to 1D of %s requires 1 or %d inputs, got %d",
+				c.Vars[c.Kept], len(c.Vars), len(inputs))
+		}
+	}
+	for i, input := range inputs {
+		if isFinite(input) {
+			continue
+		}
+		property := fmt.Sprintf("input %d", i+1)
+		if i < len(pt.IndependentVars) {
+			property = pt.IndependentVars[i]
+		}
+		err := &NonFiniteInputError{Property: property, Value: input}
+		if pt.Name == "" {
+			return 0, fmt.Errorf("table: %w", err)
+		}
+		return 0, fmt.Errorf("table %s: %w", pt.Name, err)
+	}
+	
 	switch pt.Dimension {
 	case 1:
 		if len(inputs) != 1 {
@@ -744,7 +1296,10 @@ func interpolate2D(t *Table2D, row, col float64) float64 {
 	return v1 + rowFrac*(v2-v1)
 }
 
-// interpolate3D performs 3D trilinear interpolation
+// interpolate3D interpolates a 3D table: each of the two sub-tables
+// bracketing the table input is interpolated at the row and column on its
+// own grid, which may differ from its neighbours', and the two results are
+// blended. Beyond the first or last breakpoint the nearest sub-table is used.
 func interpolate3D(tables []*Table2D, row, col, table float64) float64 {
 	if len(tables) == 0 {
 		return 0
@@ -810,129 +1365,218 @@ func findIndices(indices []float64, value float64) (int, int, float64) {
 	return n-1, n-1, 0
 }
 
-// EvaluateFunction evaluates a mathematical function
+// EvaluateFunction evaluates a mathematical function. A NaN or infinite
+// property or table input fails the evaluation with an error naming the
+// function and the input. A function that declares its unit returns its
+// value converted to SI, so a moment declared in LBSFT comes back in N·m.
 func EvaluateFunction(f *Function, properties map[string]float64) (float64, error) {
 	if f == nil {
 		return 0, fmt.Errorf("function is nil")
 	}
 	
+	value, err := evaluateFunctionBody(f, properties)
+	if err != nil && isNonFiniteInput(err) {
+		return 0, fmt.Errorf("function %s: %w", f.Name, err)
+	}
+	if err == nil && f.Unit != "" {
+		scale, _ := functionUnitScale(f.Unit)
+		value *= scale
+	}
+	return value, err
+}
+
+// evaluateFunctionBody evaluates the single operation or table of a function,
+// leaving the conversion of its result to a unit the function declares to
+// EvaluateFunction
+func evaluateFunctionBody(f *Function, properties map[string]float64) (float64, error) {
 	if f.Product != nil {
-		return evaluateOperation(f.Product, "product", properties)
+		return evaluateOperation(f.Product, "product", properties, f.Unit != "")
 	}
 	if f.Sum != nil {
-		return evaluateOperation(f.Sum, "sum", properties)
+		return evaluateOperation(f.Sum, "sum", properties, f.Unit != "")
 	}
 	if f.Difference != nil {
-		return evaluateOperation(f.Difference, "difference", properties)
+		return evaluateOperation(f.Difference, "difference", properties, f.Unit != "")
 	}
 	if f.Quotient != nil {
-		return evaluateOperation(f.Quotient, "quotient", properties)
+		return evaluateOperation(f.Quotient, "quotient", properties, f.Unit != "")
 	}
 	if f.Pow != nil {
-		return evaluateOperation(f.Pow, "pow", properties)
+		return evaluateOperation(f.Pow, "pow", properties, f.Unit != "")
 	}
 	if f.Abs != nil {
-		return evaluateOperation(f.Abs, "abs", properties)
+		return evaluateOperation(f.Abs, "abs", properties, f.Unit != "")
 	}
 	if f.Sin != nil {
-		return evaluateOperation(f.Sin, "sin", properties)
+		return evaluateOperation(f.Sin, "sin", properties, f.Unit != "")
 	}
 	if f.Cos != nil {
-		return evaluateOperation(f.Cos, "cos", properties)
+		return evaluateOperation(f.Cos, "cos", properties, f.Unit != "")
 	}
 	if f.Tan != nil {
-		return evaluateOperation(f.Tan, "tan", properties)
+		return evaluateOperation(f.Tan, "tan", properties, f.Unit != "")
 	}
 	if f.Asin != nil {
-		return evaluateOperation(f.Asin, "asin", properties)
+		return evaluateOperation(f.Asin, "asin", properties, f.Unit != "")
 	}
 	if f.Acos != nil {
-		return evaluateOperation(f.Acos, "acos", properties)
+		return evaluateOperation(f.Acos, "acos", properties, f.Unit != "")
 	}
 	if f.Atan != nil {
-		return evaluateOperation(f.Atan, "atan", properties)
+		return evaluateOperation(f.Atan, "atan", properties, f.Unit != "")
 	}
 	if f.Table != nil {
-		// Table evaluation would require current values of independent variables
-		// This is a simplified version
-		pt, err := ParseTable(f.Table)
-		if err != nil {
-			return 0, err
-		}
-		
-		// Get input values from properties
-		inputs := make([]float64, len(pt.IndependentVars))
-		for i, varName := range pt.IndependentVars {
-			inputs[i] = properties[varName]
-		}
-		
-		return InterpolateTable(pt, inputs...)
+		return evaluateTable(f.Table, properties)
 	}
 	
 	return 0, fmt.Errorf("no valid operation in function")
 }
 
-// evaluateOperation evaluates a mathematical operation
-func evaluateOperation(op *Operation, opType string, properties map[string]float64) (float64, error) {
-	values := make([]float64, 0)
-	
-	// Collect values from properties
-	for _, prop := range op.Property {
-		if val, ok := properties[prop]; ok {
-			values = append(values, val)
-		}
+// cachedTable is a parsed table, or the reason it cannot be evaluated,
+// with copies of the variables and data it was parsed from
+type cachedTable struct {
+	table *ParsedTable
+	err   error
+	vars  []IndependentVar
+	data  []TableData
+}
+
+// cachedParseTable returns the parsed form of a table, parsing it on first
+// use and again once its variables or data have changed, so function
+// evaluation does not re-parse table data on every call. Failures are
+// cached too, so a bad table costs nothing after the first call.
+func cachedParseTable(t *Table) (*ParsedTable, error) {
+	if cached := t.parsed.Load(); cached != nil && cached.parsedFrom(t) {
+		return cached.table, cached.err
 	}
 	
-	// Add literal values
-	values = append(values, op.Value...)
-	
-	// Evaluate nested operations
-	if op.Product != nil {
-		val, err := evaluateOperation(op.Product, "product", properties)
-		if err == nil {
-			values = append(values, val)
-		}
+	pt, err := ParseTable(t)
+	t.setParsed(pt, err)
+	return pt, err
+}
+
+// setParsed keeps pt, or err, as the parsed form of the table as it is now
+func (t *Table) setParsed(pt *ParsedTable, err error) {
+	cached := &cachedTable{
+		table: pt,
+		err:   err,
+		vars:  make([]IndependentVar, len(t.IndependentVar)),
+		data:  make([]TableData, len(t.TableData)),
 	}
-	if op.Sum != nil {
-		val, err := evaluateOperation(op.Sum, "sum", properties)
-		if err == nil {
-			values = append(values, val)
-		}
+	for i, v := range t.IndependentVar {
+		cached.vars[i] = *v
+	}
+	for i, d := range t.TableData {
+		cached.data[i] = *d
 	}
-	if op.Difference != nil {
-		val, err := evaluateOperation(op.Difference, "difference", properties)
-		if err == nil {
-			values = append(values, val)
+	t.parsed.Store(cached)
+}
+
+// parsedFrom reports whether the table still has the variables and data
+// the cached form was parsed from
+func (c *cachedTable) parsedFrom(t *Table) bool {
+	if len(t.IndependentVar) != len(c.vars) || len(t.TableData) != len(c.data) {
+		return false
+	}
+	for i, v := range t.IndependentVar {
+		if *v != c.vars[i] {
+			return false
 		}
 	}
-	if op.Quotient != nil {
-		val, err := evaluateOperation(op.Quotient, "quotient", properties)
-		if err == nil {
-			values = append(values, val)
+	for i, d := range t.TableData {
+		if *d != c.data[i] {
+			return false
 		}
 	}
+	return true
+}
+
+// evaluateTable looks up a table using the current values of its independent variables
+func evaluateTable(t *Table, properties map[string]float64) (float64, error) {
+	pt, err := cachedParseTable(t)
+	if err != nil {
+		return 0, err
+	}
+	
+	// Get input values from properties
+	var buf [3]float64
+	inputs := buf[:len(pt.IndependentVars)]
+	for i, varName := range pt.IndependentVars {
+		inputs[i], _ = lookupProperty(properties, varName)
+	}
+	
+	return InterpolateTable(pt, inputs...)
+}
+
+// lookupProperty reads a property from a property map, falling back from an
+// unset indexed name such as gear/unit[0]/WOW to its unindexed form as the
+// PropertyManager does
+func lookupProperty(properties map[string]float64, name string) (float64, bool) {
+	if value, ok := properties[name]; ok {
+		return value, true
+	}
+	if unindexed, ok := unindexedPropertyName(name); ok {
+		value, ok := properties[unindexed]
+		return value, ok
+	}
+	return 0, false
+}
+
+// errNoOperationValues is returned for operations whose properties are all
+// undefined; it is common during evaluation, so it is not allocated per call
+var errNoOperationValues = errors.New("no values for operation")
+
+// evaluateOperation evaluates a mathematical operation. Only the outermost
+// level declaring a unit converts the result, so an operation within a
+// function or operation that declares one is left in its declared unit.
+func evaluateOperation(op *Operation, opType string, properties map[string]float64, inUnit bool) (float64, error) {
+	// Operations rarely have more than a handful of operands, so collect
+	// them in a stack buffer to keep evaluation allocation-free
+	var buf [16]float64
+	values := buf[:0]
 	
-	// Evaluate table if present
-	if op.Table != nil {
-		pt, err := ParseTable(op.Table)
-		if err == nil {
-			inputs := make([]float64, len(pt.IndependentVars))
-			for i, varName := range pt.IndependentVars {
-				inputs[i] = properties[varName]
+	// Every property, value, nested operation and table contributes an
+	// operand, in document order
+	for _, operand := range op.operandList() {
+		switch operand.kind {
+		case operandProperty:
+			prop := op.Property[operand.index]
+			if val, ok := lookupProperty(properties, prop); ok {
+				if !isFinite(val) {
+					return 0, &NonFiniteInputError{Property: prop, Value: val}
+				}
+				values = append(values, val)
+			}
+		case operandValue:
+			values = append(values, op.Value[operand.index])
+		case operandTable:
+			val, err := evaluateTable(op.Tables[operand.index], properties)
+			if err == nil {
+				values = append(values, val)
+			} else if isNonFiniteInput(err) {
+				return 0, err
 			}
-			val, err := InterpolateTable(pt, inputs...)
+		case operandOperation:
+			val, err := evaluateOperation(operand.nested.op, operand.nested.name, properties, inUnit || op.Unit != "")
 			if err == nil {
 				values = append(values, val)
+			} else if isNonFiniteInput(err) {
+				return 0, err
 			}
 		}
 	}
 	
 	// Perform the operation
 	if len(values) == 0 {
-		return 0, fmt.Errorf("no values for operation")
+		return 0, errNoOperationValues
 	}
 	
-	return performOperation(opType, values), nil
+	result := performOperation(opType, values)
+	if op.Unit != "" && !inUnit {
+		scale, _ := functionUnitScale(op.Unit)
+		result *= scale
+	}
+	return result, nil
 }
 
 // performOperation performs the actual mathematical operation
@@ -1069,6 +1713,247 @@ func fmod(x, y float64) float64 {
 	return x - y*float64(int(x/y))
 }
 
+// functionOperations returns the named operations at the top of a function
+func functionOperations(f *Function) []namedOperation {
+	return collectOperations(f.Product, f.Difference, f.Sum, f.Quotient, f.Pow, f.Abs,
+		f.Sin, f.Cos, f.Tan, f.Asin, f.Acos, f.Atan)
+}
+
+// nestedOperations returns the named operations nested in an operation, in
+// document order
+func nestedOperations(op *Operation) []namedOperation {
+	var named []namedOperation
+	for _, operand := range op.operandList() {
+		if operand.kind == operandOperation {
+			named = append(named, operand.nested)
+		}
+	}
+	return named
+}
+
+// operations returns the nested operation fields in the order of
+// operationNames, nil where absent
+func (op *Operation) operations() [len(operationNames)]*Operation {
+	return [...]*Operation{op.Product, op.Difference, op.Sum, op.Quotient, op.Pow, op.Abs,
+		op.Sin, op.Cos, op.Tan, op.Asin, op.Acos, op.Atan}
+}
+
+// functionSlots and operationSlots return pointers to the nested operation
+// fields in the order of operationNames
+func functionSlots(f *Function) []**Operation {
+	return []**Operation{&f.Product, &f.Difference, &f.Sum, &f.Quotient, &f.Pow, &f.Abs,
+		&f.Sin, &f.Cos, &f.Tan, &f.Asin, &f.Acos, &f.Atan}
+}
+
+func operationSlots(op *Operation) []**Operation {
+	return []**Operation{&op.Product, &op.Difference, &op.Sum, &op.Quotient, &op.Pow, &op.Abs,
+		&op.Sin, &op.Cos, &op.Tan, &op.Asin, &op.Acos, &op.Atan}
+}
+
+// operationIndex returns the index of an operation's element name in
+// operationNames, or -1 for an element that is not an operation
+func operationIndex(name string) int {
+	for i, known := range operationNames {
+		if known == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// namedOperation is an operation with its element name
+type namedOperation struct {
+	name string
+	op   *Operation
+}
+
+var operationNames = [...]string{"product", "difference", "sum", "quotient", "pow", "abs",
+	"sin", "cos", "tan", "asin", "acos", "atan"}
+
+func collectOperations(ops ...*Operation) []namedOperation {
+	var named []namedOperation
+	for i, op := range ops {
+		if op != nil {
+			named = append(named, namedOperation{name: operationNames[i], op: op})
+		}
+	}
+	return named
+}
+
+// functionProperties returns the properties a function reads, from its
+// operations and table lookups, in document order
+func functionProperties(f *Function) []string {
+	var properties []string
+	if f.Table != nil {
+		properties = appendTableProperties(properties, f.Table)
+	}
+	for _, named := range functionOperations(f) {
+		properties = appendOperationProperties(properties, named.op)
+	}
+	return properties
+}
+
+func appendOperationProperties(properties []string, op *Operation) []string {
+	for _, operand := range op.operandList() {
+		switch operand.kind {
+		case operandProperty:
+			properties = append(properties, strings.TrimSpace(op.Property[operand.index]))
+		case operandTable:
+			properties = appendTableProperties(properties, op.Tables[operand.index])
+		case operandOperation:
+			properties = appendOperationProperties(properties, operand.nested.op)
+		}
+	}
+	return properties
+}
+
+func appendTableProperties(properties []string, t *Table) []string {
+	for _, iv := range t.IndependentVar {
+		properties = append(properties, strings.TrimSpace(iv.Value))
+	}
+	return properties
+}
+
+// functionUnitScales converts the units a function or operation may declare
+// to the SI units used internally: N, N·m, m, rad, m/s, Pa and W. Units that
+// are already SI scale by one.
+var functionUnitScales = map[string]float64{
+	"LBS":     LB_TO_N,
+	"LBF":     LB_TO_N,
+	"LBSFT":   LB_TO_N * FT_TO_M,
+	"LBS*FT":  LB_TO_N * FT_TO_M,
+	"FT*LBS":  LB_TO_N * FT_TO_M,
+	"FT":      FT_TO_M,
+	"IN":      IN_TO_FT * FT_TO_M,
+	"DEG":     DEG_TO_RAD,
+	"DEG/SEC": DEG_TO_RAD,
+	"FT/SEC":  FT_TO_M,
+	"FT/S":    FT_TO_M,
+	"KTS":     KT_TO_MS,
+	"PSF":     LB_TO_N / (FT_TO_M * FT_TO_M),
+	"HP":      HP_TO_W,
+	"N":       1,
+	"N*M":     1,
+	"M":       1,
+	"RAD":     1,
+	"RAD/SEC": 1,
+	"M/S":     1,
+	"M/SEC":   1,
+	"PA":      1,
+	"W":       1,
+}
+
+// functionUnitScale returns the factor converting a value in a declared unit
+// to SI, and whether the unit is known. Unknown units are left unconverted.
+func functionUnitScale(unit string) (float64, bool) {
+	if unit == "" {
+		return 1, true
+	}
+	scale, ok := functionUnitScales[strings.ToUpper(strings.TrimSpace(unit))]
+	if !ok {
+		return 1, false
+	}
+	return scale, true
+}
+
+// checkFunctionUnits returns a warning for each aerodynamic or flight
+// control function, or operation within one, whose declared unit is not
+// converted on evaluation
+func checkFunctionUnits(config *JSBSimConfig) []string {
+	var warnings []string
+	check := func(function, element, unit string) {
+		if _, ok := functionUnitScale(unit); !ok {
+			warnings = append(warnings, fmt.Sprintf("function %s: unknown unit %q on <%s>, value used unconverted", function, unit, element))
+		}
+	}
+	var checkOperation func(function, element string, op *Operation)
+	checkOperation = func(function, element string, op *Operation) {
+		check(function, element, op.Unit)
+		for _, nested := range nestedOperations(op) {
+			checkOperation(function, nested.name, nested.op)
+		}
+	}
+	
+	for _, f := range configFunctions(config) {
+		check(f.Name, "function", f.Unit)
+		for _, named := range functionOperations(f) {
+			checkOperation(f.Name, named.name, named.op)
+		}
+	}
+	return warnings
+}
+
+// configFunctions returns the aerodynamic functions, standalone first, and
+// the functions of the flight control, autopilot and system components
+func configFunctions(config *JSBSimConfig) []*Function {
+	named := configNamedFunctions(config)
+	functions := make([]*Function, len(named))
+	for i, f := range named {
+		functions[i] = f.function
+	}
+	return functions
+}
+
+// validateStandaloneFunctions checks the standalone aerodynamic functions.
+// They are evaluated once per step in declaration order, each result stored
+// under the function's name, so names must be unique and a function may
+// only read the outputs of functions declared before it. A reference to
+// itself or a later function would read a stale value; it is reported as a
+// cycle when the later function leads back to the referencing one.
+func validateStandaloneFunctions(functions []*Function) error {
+	index := make(map[string]int, len(functions))
+	for i, f := range functions {
+		if _, ok := index[f.Name]; ok {
+			return fmt.Errorf("duplicate function name %s", f.Name)
+		}
+		index[f.Name] = i
+	}
+	
+	// Standalone functions each function reads, by index
+	deps := make([][]int, len(functions))
+	for i, f := range functions {
+		for _, prop := range functionProperties(f) {
+			if j, ok := index[prop]; ok {
+				deps[i] = append(deps[i], j)
+			}
+		}
+	}
+	
+	// path returns a dependency chain from one function to another, if any
+	var path func(from, to int, visited []bool) []int
+	path = func(from, to int, visited []bool) []int {
+		if from == to {
+			return []int{to}
+		}
+		visited[from] = true
+		for _, next := range deps[from] {
+			if !visited[next] {
+				if rest := path(next, to, visited); rest != nil {
+					return append([]int{from}, rest...)
+				}
+			}
+		}
+		return nil
+	}
+	
+	for i, f := range functions {
+		for _, j := range deps[i] {
+			if j < i {
+				continue
+			}
+			if cycle := path(j, i, make([]bool, len(functions))); cycle != nil {
+				names := []string{f.Name}
+				for _, k := range cycle {
+					names = append(names, functions[k].Name)
+				}
+				return fmt.Errorf("function cycle: %s", strings.Join(names, " -> "))
+			}
+			return fmt.Errorf("function %s reads %s, which is declared after it", f.Name, functions[j].Name)
+		}
+	}
+	return nil
+}
+
 // ExtractAllValues extracts all values from the configuration
 func ExtractAllValues(config *JSBSimConfig) map[string]interface{} {
 	values := make(map[string]interface{})
@@ -1090,6 +1975,8 @@ func ExtractAllValues(config *JSBSimConfig) map[string]interface{} {
 			}
 		}
 		values["header.references"] = refs
+		values["header.limitations"] = config.Header.Limitations
+		values["header.notes"] = config.Header.Notes
 	}
 	
 	// Extract metrics
@@ -1207,12 +2094,18 @@ func ExtractAllValues(config *JSBSimConfig) map[string]interface{} {
 				contactData["damper_constant"] = contact.Damper.Constant
 			}
 			
+			// The coefficients are in the canonical LBS/FT and LBS/FT/SEC,
+			// unlike the unitless spring and damper constants
 			if contact.SpringCoeff != nil {
-				contactData["spring_constant"] = contact.SpringCoeff.Value
+				contactData["spring_coeff"] = contact.SpringCoeff.Value
 			}
 			
 			if contact.DampingCoeff != nil {
-				contactData["damper_constant"] = contact.DampingCoeff.Value
+				contactData["damping_coeff"] = contact.DampingCoeff.Value
+			}
+			
+			if contact.DampingCoeffRebound != nil {
+				contactData["damping_coeff_rebound"] = contact.DampingCoeffRebound.Value
 			}
 			
 			if contact.MaxSteer != nil {
@@ -1320,21 +2213,29 @@ func ExtractAllValues(config *JSBSimConfig) map[string]interface{} {
 	}
 	
 	// Extract I/O
-	if config.Input != nil {
-		values["input"] = map[string]interface{}{
-			"port":     config.Input.Port,
-			"protocol": config.Input.Protocol,
+	for i, input := range config.Input {
+		values[fmt.Sprintf("input.%d", i)] = map[string]interface{}{
+			"port":     input.Port,
+			"protocol": input.Protocol,
 		}
 	}
 	
-	if config.Output != nil {
-		values["output"] = map[string]interface{}{
-			"name":     config.Output.Name,
-			"type":     config.Output.Type,
-			"port":     config.Output.Port,
-			"protocol": config.Output.Protocol,
-			"rate":     config.Output.Rate,
+	for i, out := range config.Output {
+		output := map[string]interface{}{
+			"name":     out.Name,
+			"type":     out.Type,
+			"port":     out.Port,
+			"protocol": out.Protocol,
+			"rate":     out.Rate,
+		}
+		if len(out.Properties) > 0 {
+			properties := make([]string, len(out.Properties))
+			for j, prop := range out.Properties {
+				properties[j] = strings.TrimSpace(prop.Name)
+			}
+			output["properties"] = properties
 		}
+		values[fmt.Sprintf("output.%d", i)] = output
 	}
 	
 	return values
@@ -1343,7 +2244,14 @@ func ExtractAllValues(config *JSBSimConfig) map[string]interface{} {
 // extractFlightControl extracts flight control data
 func extractFlightControl(fc *FlightControl, prefix string, values map[string]interface{}) {
 	values[prefix+".name"] = fc.Name
-	values[prefix+".properties"] = fc.Property
+	properties := make([]map[string]interface{}, len(fc.Property))
+	for i, p := range fc.Property {
+		properties[i] = map[string]interface{}{"name": strings.TrimSpace(p.Name)}
+		if p.Value != nil {
+			properties[i]["value"] = *p.Value
+		}
+	}
+	values[prefix+".properties"] = properties
 	
 	for i, rg := range fc.RateGroup {
 		key := fmt.Sprintf("%s.rate_group.%d", prefix, i)
@@ -1434,58 +2342,76 @@ func extractFlightControl(fc *FlightControl, prefix string, values map[string]in
 	}
 }
 
-// extractFunctionData extracts function data
-func extractFunctionData(fn *Function) map[string]interface{} {
-	data := map[string]interface{}{
-		"name":        fn.Name,
-		"description": fn.Description,
+// extractTableData extracts a table's variables and parsed data
+func extractTableData(t *Table) map[string]interface{} {
+	tableData := map[string]interface{}{
+		"name": t.Name,
 	}
 	
-	if fn.Table != nil {
-		tableData := map[string]interface{}{
-			"name": fn.Table.Name,
+	indVars := make([]map[string]string, len(t.IndependentVar))
+	for i, iv := range t.IndependentVar {
+		indVars[i] = map[string]string{
+			"lookup": iv.Lookup,
+			"value":  iv.Value,
 		}
-		
-		indVars := make([]map[string]string, len(fn.Table.IndependentVar))
-		for i, iv := range fn.Table.IndependentVar {
-			indVars[i] = map[string]string{
-				"lookup": iv.Lookup,
-				"value":  iv.Value,
+	}
+	tableData["independent_vars"] = indVars
+	
+	// Parse table data
+	if pt, err := ParseTable(t); err == nil {
+		tableData["dimension"] = pt.Dimension
+		if pt.Data1D != nil {
+			tableData["data_1d"] = map[string]interface{}{
+				"indices": pt.Data1D.Indices,
+				"values":  pt.Data1D.Values,
 			}
 		}
-		tableData["independent_vars"] = indVars
-		
-		// Parse table data
-		if pt, err := ParseTable(fn.Table); err == nil {
-			tableData["dimension"] = pt.Dimension
-			if pt.Data1D != nil {
-				tableData["data_1d"] = map[string]interface{}{
-					"indices": pt.Data1D.Indices,
-					"values":  pt.Data1D.Values,
-				}
-			}
-			if pt.Data2D != nil {
-				tableData["data_2d"] = map[string]interface{}{
-					"row_indices": pt.Data2D.RowIndices,
-					"col_indices": pt.Data2D.ColIndices,
-					"data":        pt.Data2D.Data,
-				}
+		if pt.Data2D != nil {
+			tableData["data_2d"] = map[string]interface{}{
+				"row_indices": pt.Data2D.RowIndices,
+				"col_indices": pt.Data2D.ColIndices,
+				"data":        pt.Data2D.Data,
 			}
-			if pt.Data3D != nil {
-				tables3D := make([]map[string]interface{}, len(pt.Data3D))
-				for i, t2d := range pt.Data3D {
-					tables3D[i] = map[string]interface{}{
-						"breakpoint":  t2d.Breakpoint,
-						"row_indices": t2d.RowIndices,
-						"col_indices": t2d.ColIndices,
-						"data":        t2d.Data,
-					}
+		}
+		if pt.Data3D != nil {
+			tables3D := make([]map[string]interface{}, len(pt.Data3D))
+			for i, t2d := range pt.Data3D {
+				tables3D[i] = map[string]interface{}{
+					"breakpoint":  t2d.Breakpoint,
+					"row_indices": t2d.RowIndices,
+					"col_indices": t2d.ColIndices,
+					"data":        t2d.Data,
 				}
-				tableData["data_3d"] = tables3D
 			}
+			tableData["data_3d"] = tables3D
 		}
-		
-		data["table"] = tableData
+	}
+	
+	return tableData
+}
+
+// extractFunctionData extracts function data
+func extractFunctionData(fn *Function) map[string]interface{} {
+	data := map[string]interface{}{
+		"name":          fn.Name,
+		"unit":          fn.Unit,
+		"description":   fn.Description,
+		"documentation": fn.Documentation,
+	}
+	
+	if fn.Table != nil {
+		data["table"] = extractTableData(fn.Table)
+	}
+	
+	// Every table in the function, however deeply nested, with its path
+	located := locateFunctionTables(fn)
+	if len(located) > 0 {
+		tables := make([]map[string]interface{}, len(located))
+		for i, lt := range located {
+			tables[i] = extractTableData(lt.table)
+			tables[i]["path"] = lt.path
+		}
+		data["tables"] = tables
 	}
 	
 	// Extract operation type
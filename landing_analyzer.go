@@ -0,0 +1,265 @@
+// Landing Analyzer
+// Flare, touchdown and rollout metrics from the state stream of an approach
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// LandingReport describes one landing, from the flare through the rollout.
+// It is created at touchdown and completed as the rollout goes on.
+type LandingReport struct {
+	// Flare: where the sink rate was first arrested below FlareHeight
+	Flared           bool
+	FlareTime        float64 // s
+	FlareHeight      float64 // Height above the ground at the flare (m)
+	ApproachSinkRate float64 // Highest sink rate below FlareHeight before the flare (m/s)
+
+	// Touchdown, at the first state of the weight on wheels edge
+	TouchdownTime     float64 // s
+	SinkRate          float64 // Vertical speed, positive down (m/s)
+	PitchAttitude     float64 // rad
+	Airspeed          float64 // True airspeed (m/s)
+	GroundSpeed       float64 // m/s
+	TouchdownPosition Vector3 // NED position (m)
+	ThresholdDistance float64 // Along the runway past the threshold (m)
+	CenterlineOffset  float64 // Right of the runway centreline (m)
+	HardLanding       bool    // Sink rate above the analyzer's HardLandingSinkRate
+
+	// Crosswind touchdown: the wind in runway axes, the crab angle still
+	// held and the drift across the runway
+	Headwind        float64 // m/s
+	Crosswind       float64 // From the right (m/s)
+	CrabAngle       float64 // Heading less the ground track (rad)
+	LateralVelocity float64 // Across the runway, to the right (m/s)
+
+	// MaxSideLoad is the largest body-axis side force of the ground
+	// reaction from the touchdown through the rollout (N)
+	MaxSideLoad float64
+
+	// Bounces counts returns to the air, after the debounce, between the
+	// touchdown and the aircraft settling
+	Bounces int
+
+	// Rollout, along the ground from the touchdown point until the ground
+	// speed falls below the analyzer's RolloutSpeed
+	RolloutDistance float64 // m
+	RolloutTime     float64 // s
+	RolloutComplete bool
+}
+
+// String renders the report in SI units, in the style of the other
+// analysis reports
+func (r *LandingReport) String() string {
+	return r.Format(SIUnits())
+}
+
+// Format writes the report in the given units
+func (r *LandingReport) Format(units UnitPreferences) string {
+	var sb strings.Builder
+	sb.WriteString("Landing Report:\n")
+	if r.Flared {
+		sb.WriteString(fmt.Sprintf("  Flare:           %s AGL at t=%.2f s, from %s sink\n",
+			units.Format(QuantityAltitude, r.FlareHeight, 1), r.FlareTime,
+			units.Format(QuantityVerticalSpeed, r.ApproachSinkRate, 2)))
+	} else {
+		sb.WriteString("  Flare:           none detected\n")
+	}
+	hard := ""
+	if r.HardLanding {
+		hard = ", HARD LANDING"
+	}
+	sb.WriteString(fmt.Sprintf("  Touchdown:       t=%.2f s, sink %s%s\n", r.TouchdownTime,
+		units.Format(QuantityVerticalSpeed, r.SinkRate, 2), hard))
+	sb.WriteString(fmt.Sprintf("  Attitude:        %s pitch at %s TAS, %s ground speed\n",
+		units.Format(QuantityAngle, r.PitchAttitude, 1), units.Format(QuantitySpeed, r.Airspeed, 1),
+		units.Format(QuantitySpeed, r.GroundSpeed, 1)))
+	sb.WriteString(fmt.Sprintf("  Position:        %s past the threshold, %s right of centreline\n",
+		units.Format(QuantityDistance, r.ThresholdDistance, 1), units.Format(QuantityDistance, r.CenterlineOffset, 1)))
+	if r.Crosswind != 0 || r.CrabAngle != 0 || r.LateralVelocity != 0 {
+		sb.WriteString(fmt.Sprintf("  Crosswind:       %s, %s crab, %s drift, %s side load\n",
+			units.Format(QuantitySpeed, r.Crosswind, 1), units.Format(QuantityAngle, r.CrabAngle, 1),
+			units.Format(QuantitySpeed, r.LateralVelocity, 2), units.Format(QuantityForce, r.MaxSideLoad, 0)))
+	}
+	sb.WriteString(fmt.Sprintf("  Bounces:         %d\n", r.Bounces))
+	status := "in progress"
+	if r.RolloutComplete {
+		status = fmt.Sprintf("%.1f s", r.RolloutTime)
+	}
+	sb.WriteString(fmt.Sprintf("  Rollout:         %s, %s", units.Format(QuantityDistance, r.RolloutDistance, 1), status))
+	return sb.String()
+}
+
+// LandingAnalyzer watches the states of a simulation for landings. Weight on
+// wheels must hold for Debounce seconds to count as a touchdown, and be
+// lost for as long to count as a bounce, so strut chatter is ignored; the
+// touchdown metrics are those of the first state of the contact.
+type LandingAnalyzer struct {
+	// Runway threshold, as a NED position (m), and the runway's true
+	// heading (rad), for the touchdown position. Runway, when set, is used
+	// instead.
+	Threshold     Vector3
+	RunwayHeading float64
+	Runway        *RunwayContext
+
+	HardLandingSinkRate float64 // Sink rate above which a landing is hard (m/s)
+	RolloutSpeed        float64 // Ground speed ending the rollout (m/s)
+	Debounce            float64 // Time a weight on wheels change must hold (s)
+	FlareHeight         float64 // Height above the ground the flare is looked for below (m)
+
+	// FlareSinkReduction is the fraction by which the sink rate must fall
+	// below its highest value under FlareHeight to detect the flare
+	FlareSinkReduction float64
+
+	// Report is the landing in progress or last made, nil before the first
+	// touchdown. Statistics, when set, collects every report.
+	Report     *LandingReport
+	Statistics *FlightStatistics
+
+	wow      bool           // Debounced weight on wheels
+	pending  *AircraftState // First state of an undebounced weight on wheels change
+	flare    LandingReport  // Flare of the approach in progress
+	climbed  bool           // Above FlareHeight since the last touchdown: a go-around
+	sideLoad float64        // Largest gear side load of the contact in progress
+}
+
+// NewLandingAnalyzer creates an analyzer with a 3 m/s hard landing limit, a
+// 2 m/s rollout end and a 0.1 s debounce, recording its reports in stats
+// when that is not nil
+func NewLandingAnalyzer(stats *FlightStatistics) *LandingAnalyzer {
+	return &LandingAnalyzer{
+		HardLandingSinkRate: 3.0,
+		RolloutSpeed:        2.0,
+		Debounce:            0.1,
+		FlareHeight:         15.0,
+		FlareSinkReduction:  0.25,
+		Statistics:          stats,
+	}
+}
+
+// Attach makes the analyzer observe every state evaluated by the bus
+func (la *LandingAnalyzer) Attach(bus *EventBus) *EventWatcher {
+	return bus.OnStep(func(state *AircraftState, _ float64) { la.Observe(state) })
+}
+
+// weightOnWheels reports whether any gear unit is on the ground, or the
+// aircraft itself when it has no gear units
+func weightOnWheels(state *AircraftState) bool {
+	if len(state.Gear.Units) == 0 {
+		return state.Gear.OnGround
+	}
+	for _, unit := range state.Gear.Units {
+		if unit.WOW {
+			return true
+		}
+	}
+	return false
+}
+
+// Observe takes the next state of the simulation
+func (la *LandingAnalyzer) Observe(state *AircraftState) {
+	earthVel := state.GroundVelocity()
+	height := state.Altitude - state.Gear.GroundHeight
+
+	wow := weightOnWheels(state)
+	if !wow && !la.wow {
+		la.observeApproach(state, earthVel.Z, height)
+		la.sideLoad = 0
+	}
+	if wow {
+		la.sideLoad = math.Max(la.sideLoad, math.Abs(state.Forces.Ground.Y))
+	}
+
+	// Debounce weight on wheels changes from the first state of the change
+	if wow == la.wow {
+		la.pending = nil
+	} else if la.pending == nil {
+		la.pending = state
+	}
+	if la.pending != nil && state.Time-la.pending.Time >= la.Debounce-1e-9 {
+		edge := la.pending
+		la.wow, la.pending = wow, nil
+		if wow {
+			la.touchdown(edge)
+		} else if la.Report != nil && !la.climbed {
+			la.Report.Bounces++
+		}
+	}
+
+	if la.Report != nil && !la.Report.RolloutComplete && !la.climbed {
+		la.observeRollout(state, earthVel)
+	}
+}
+
+// observeApproach looks for the flare while airborne, and a go-around after
+// a touchdown
+func (la *LandingAnalyzer) observeApproach(state *AircraftState, sinkRate, height float64) {
+	if height > la.FlareHeight {
+		la.flare = LandingReport{}
+		if la.Report != nil {
+			la.climbed = true
+		}
+		return
+	}
+	if la.flare.Flared {
+		return
+	}
+	if sinkRate > la.flare.ApproachSinkRate {
+		la.flare.ApproachSinkRate = sinkRate
+	} else if la.flare.ApproachSinkRate > 0 && sinkRate <= (1-la.FlareSinkReduction)*la.flare.ApproachSinkRate {
+		la.flare.Flared = true
+		la.flare.FlareTime = state.Time
+		la.flare.FlareHeight = height
+	}
+}
+
+// touchdown starts a report at the first state of a contact, or counts the
+// contact as part of the landing in progress
+func (la *LandingAnalyzer) touchdown(state *AircraftState) {
+	if la.Report != nil && !la.climbed {
+		return
+	}
+
+	earthVel := state.GroundVelocity()
+	report := la.flare
+	report.TouchdownTime = state.Time
+	report.SinkRate = earthVel.Z
+	report.PitchAttitude = state.Pitch
+	report.Airspeed = state.TrueAirspeed
+	report.GroundSpeed = math.Hypot(earthVel.X, earthVel.Y)
+	report.TouchdownPosition = state.Position
+	report.HardLanding = report.SinkRate > la.HardLandingSinkRate
+
+	runway := la.Runway
+	if runway == nil {
+		runway = NewRunwayContext(la.Threshold, la.RunwayHeading, 0)
+	}
+	report.ThresholdDistance, report.CenterlineOffset = runway.Position(state)
+	report.Headwind, report.Crosswind = runway.WindComponents(state)
+	report.CrabAngle = CrabAngle(state)
+	report.LateralVelocity = runway.LateralVelocity(state)
+	report.MaxSideLoad = la.sideLoad
+
+	la.Report = &report
+	la.flare = LandingReport{}
+	la.climbed = false
+	if la.Statistics != nil {
+		la.Statistics.Landings = append(la.Statistics.Landings, la.Report)
+	}
+}
+
+// observeRollout measures the ground run from the touchdown point until the
+// ground speed falls below RolloutSpeed
+func (la *LandingAnalyzer) observeRollout(state *AircraftState, earthVel Vector3) {
+	report := la.Report
+	run := state.Position.Add(report.TouchdownPosition.Scale(-1))
+	report.RolloutDistance = math.Hypot(run.X, run.Y)
+	report.RolloutTime = state.Time - report.TouchdownTime
+	report.MaxSideLoad = math.Max(report.MaxSideLoad, math.Abs(state.Forces.Ground.Y))
+	if la.wow && math.Hypot(earthVel.X, earthVel.Y) < la.RolloutSpeed {
+		report.RolloutComplete = true
+	}
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// landingScenario flies a scripted approach along the runway heading north
+// from the threshold at the origin: a steady descent to the flare height,
+// then a sink rate falling linearly with height to touchdownSink. From the
+// first weight on wheels the simplified model takes over for the rollout
+// with the brakes on. It returns the analyzer and every state seen.
+func landingScenario(t *testing.T, groundSpeed, approachSink, flareHeight, touchdownSink, brake float64) (*LandingAnalyzer, *SimplifiedFlightDynamicsEngine, []*AircraftState) {
+	t.Helper()
+	return windLandingScenario(t, Vector3{}, groundSpeed, approachSink, flareHeight, touchdownSink, brake)
+}
+
+// windLandingScenario is landingScenario in a steady wind (NED, m/s), the
+// approach crabbed into it with no sideslip so the ground track stays on
+// the centreline
+func windLandingScenario(t *testing.T, wind Vector3, groundSpeed, approachSink, flareHeight, touchdownSink, brake float64) (*LandingAnalyzer, *SimplifiedFlightDynamicsEngine, []*AircraftState) {
+	t.Helper()
+	engine := NewSimplifiedFlightDynamicsEngine(NewEulerIntegrator())
+	engine.Terrain = NewFlatTerrain(0)
+	engine.Gear = NewLandingGear(loadP51DConfig(t))
+	analyzer := NewLandingAnalyzer(engine.Statistics)
+	analyzer.Attach(engine.Events)
+
+	// Three-point attitude, so the mains and tailwheel arrive together,
+	// headed into the relative wind
+	mains, tail := engine.Gear.Units[0].Location, engine.Gear.Units[2].Location
+	crab := math.Atan2(-wind.Y, groundSpeed-wind.X)
+	orientation := NewQuaternionFromEuler(0, math.Atan2(mains.Z-tail.Z, mains.X-tail.X), crab)
+	inverse := Quaternion{W: orientation.W, X: -orientation.X, Y: -orientation.Y, Z: -orientation.Z}
+
+	const dt = 0.002
+	height := 30.0
+	state := NewAircraftState()
+	state.Orientation = orientation
+	state.Controls.Gear = true
+	state.Gear.Down = true
+	state.Gear.Transition = 1.0
+	state.Position = Vector3{X: -groundSpeed * height / approachSink, Z: -height}
+	state.Wind = wind
+
+	trajectory := []*AircraftState{}
+	for !weightOnWheels(state) {
+		sink := approachSink
+		if height < flareHeight {
+			sink = touchdownSink + (approachSink-touchdownSink)*height/flareHeight
+		}
+		next := state.Copy()
+		next.Time = state.Time + dt
+		next.Position = state.Position.Add(Vector3{X: groundSpeed * dt, Z: sink * dt})
+		next.Altitude = -next.Position.Z
+		height = next.Altitude
+		next.Velocity = inverse.RotateVector(Vector3{X: groundSpeed, Z: sink}.Add(wind.Scale(-1)))
+		next.UpdateAtmosphere()
+		next.UpdateDerivedParameters()
+		engine.Gear.Update(next)
+		engine.Events.Evaluate(next)
+
+		state = next
+		trajectory = append(trajectory, state)
+	}
+
+	// Rollout with the brakes on and the throttle closed
+	state.Controls.Brake = brake
+	state.Controls.Throttle = 0
+	for i := 0; i < 30000 && (analyzer.Report == nil || !analyzer.Report.RolloutComplete); i++ {
+		next, err := engine.Step(state, dt)
+		if err != nil {
+			t.Fatalf("Rollout step %d failed: %v", i, err)
+		}
+		state = next
+		trajectory = append(trajectory, state)
+	}
+	return analyzer, engine, trajectory
+}
+
+func TestLandingAnalyzer(t *testing.T) {
+	t.Run("Flared Approach", func(t *testing.T) {
+		analyzer, engine, trajectory := landingScenario(t, 45.0, 3.0, 8.0, 0.8, 1.0)
+		report := analyzer.Report
+		if report == nil {
+			t.Fatal("No touchdown detected")
+		}
+		t.Logf("\n%s", report)
+
+		// The touchdown is the first state with weight on wheels
+		var edge *AircraftState
+		for _, state := range trajectory {
+			if weightOnWheels(state) {
+				edge = state
+				break
+			}
+		}
+		assertApproxEqual(t, report.TouchdownTime, edge.Time, 1e-12)
+		edgeSink := edge.Orientation.RotateVector(edge.Velocity).Z
+		assertApproxEqual(t, report.SinkRate, edgeSink, 0.1)
+		assertApproxEqual(t, report.ThresholdDistance, edge.Position.X, 1e-9)
+		assertApproxEqual(t, report.CenterlineOffset, 0.0, 1e-9)
+		assertApproxEqual(t, report.GroundSpeed, 45.0, 1e-6)
+		if report.HardLanding {
+			t.Error("A 0.8 m/s touchdown is not a hard landing")
+		}
+
+		// The sink rate falls by a quarter of 3 m/s at 5.3 m
+		if !report.Flared {
+			t.Fatal("Flare not detected")
+		}
+		assertApproxEqual(t, report.ApproachSinkRate, 3.0, 1e-6)
+		assertApproxEqual(t, report.FlareHeight, 8.0*(2.25-0.8)/(3.0-0.8), 0.05)
+
+		if !report.RolloutComplete {
+			t.Fatalf("Rollout did not finish: %.0f m in %.1f s", report.RolloutDistance, report.RolloutTime)
+		}
+		// The rollout is the ground run of the trajectory to 2 m/s, and
+		// at least the wheels' rolling friction slowed it
+		var run float64
+		var previous *AircraftState
+		for _, state := range trajectory {
+			if state.Time < report.TouchdownTime+report.RolloutTime && previous != nil && state.Time > report.TouchdownTime {
+				run += state.Position.Add(previous.Position.Scale(-1)).Magnitude()
+			}
+			previous = state
+		}
+		assertApproxEqual(t, report.RolloutDistance, run, 0.01*run)
+		rolling := engine.Gear.Units[0].RollingFriction
+		if deceleration := (45.0*45.0 - 2.0*2.0) / (2 * report.RolloutDistance); deceleration < rolling*StandardGravity {
+			t.Errorf("Mean deceleration %.2f m/s² is below that of rolling friction alone", deceleration)
+		}
+
+		// Half the brake pressure leaves a longer ground run
+		halfBrake, _, _ := landingScenario(t, 45.0, 3.0, 8.0, 0.8, 0.5)
+		if !halfBrake.Report.RolloutComplete || halfBrake.Report.RolloutDistance <= report.RolloutDistance {
+			t.Errorf("Half brakes should roll further than %.1f m, rolled %.1f m", report.RolloutDistance, halfBrake.Report.RolloutDistance)
+		}
+
+		assertEqual(t, len(engine.Statistics.Landings), 1)
+		if engine.Statistics.Landings[0] != report {
+			t.Error("The report should be recorded in the flight statistics")
+		}
+	})
+	t.Run("Hard Landing Without Flare", func(t *testing.T) {
+		analyzer, _, _ := landingScenario(t, 45.0, 3.5, 0.0, 3.5, 1.0)
+		report := analyzer.Report
+		if report == nil {
+			t.Fatal("No touchdown detected")
+		}
+		assertEqual(t, report.Flared, false)
+		assertApproxEqual(t, report.SinkRate, 3.5, 0.1)
+		assertEqual(t, report.HardLanding, true)
+		if !strings.Contains(report.String(), "HARD LANDING") {
+			t.Errorf("Report should flag the hard landing:\n%s", report)
+		}
+	})
+
+	t.Run("Debounce And Bounces", func(t *testing.T) {
+		analyzer := NewLandingAnalyzer(nil)
+		time := 0.0
+		feed := func(wow bool, seconds float64) {
+			for end := time + seconds; time < end-1e-9; time += 0.01 {
+				state := NewAircraftState()
+				state.Time = time
+				state.Altitude = 1.0
+				state.Velocity = Vector3{X: 40.0}
+				state.Gear.Units = []GearUnitState{{WOW: wow}}
+				analyzer.Observe(state)
+			}
+		}
+
+		// Contacts shorter than the debounce are strut chatter
+		feed(false, 0.5)
+		feed(true, 0.05)
+		feed(false, 0.2)
+		if analyzer.Report != nil {
+			t.Fatal("A 0.05 s contact should not be a touchdown")
+		}
+
+		feed(true, 0.3)
+		if analyzer.Report == nil {
+			t.Fatal("A 0.3 s contact should be a touchdown")
+		}
+		assertApproxEqual(t, analyzer.Report.TouchdownTime, 0.75, 1e-9)
+
+		// Two returns to the air, one too short to count
+		feed(false, 0.4)
+		feed(true, 0.3)
+		feed(false, 0.05)
+		feed(true, 0.3)
+		assertEqual(t, analyzer.Report.Bounces, 1)
+	})
+}
@@ -0,0 +1,343 @@
+// Landing Gear
+// Per-contact ground reaction state published as JSBSim gear/unit[i] properties
+
+package main
+
+import (
+	"math"
+	"strings"
+)
+
+// GearSteering is how a wheel turns about its strut
+type GearSteering int
+
+const (
+	GearFixed     GearSteering = iota // Rolls along the body X axis (max_steer 0)
+	GearCaster                        // Swivels freely and carries no side load (max_steer 360)
+	GearSteerable                     // Turned by the steering command, up to MaxSteer
+)
+
+// GearUnit is one ground contact point from the <ground_reactions> section
+type GearUnit struct {
+	Name        string
+	Type        string  // BOGEY or STRUCTURE
+	Location    Vector3 // Body-axis position relative to the CG (m)
+	Retractable bool    // Out of contact while the gear is retracted
+
+	// Strut and tire
+	SpringCoeff     float64 // Strut stiffness (N/m)
+	DampingCoeff    float64 // Strut damping while compressing (N·s/m)
+	DampingRebound  float64 // Strut damping while extending (N·s/m); DampingCoeff when 0
+	MaxStroke       float64 // Strut travel before it bottoms (m); unlimited when 0
+	StaticFriction  float64 // Friction coefficient of a gripping tire, and with the brakes fully on
+	DynamicFriction float64 // Friction coefficient of a skidding tire
+	RollingFriction float64 // Friction coefficient of a free-rolling tire
+
+	// Optional; the charge force of a polytropic air spring (N), stiffening
+	// the strut toward the end of its stroke. There is none when 0, and it
+	// needs a MaxStroke.
+	AirSpring float64
+
+	// Brakes and steering
+	BrakeGroup string       // LEFT, RIGHT, CENTER or NONE
+	Steering   GearSteering // Derived from max_steer
+	MaxSteer   float64      // Wheel angle at full steering command (rad); negative reverses it
+
+	// Weight on wheels switch for the FCS interlocks and brakes
+	Squat SquatSwitch
+}
+
+// GearUnitState is the ground contact of one gear unit
+type GearUnitState struct {
+	WOW                 bool    `json:"wow"`                  // Weight on wheels
+	Compression         float64 `json:"compression"`          // Strut compression (m)
+	CompressionVelocity float64 `json:"compression_velocity"` // Compression rate (m/s), positive compressing
+
+	// Debounced squat switch, and the time a change of it began while it
+	// waits out the dwell
+	Squat        bool    `json:"squat"`
+	SquatPending bool    `json:"squat_pending,omitempty"`
+	SquatSince   float64 `json:"squat_since,omitempty"`
+}
+
+// LandingGear computes the compression of each contact point from the
+// aircraft's height above the ground and attitude, and the ground reaction
+// that results. Units are numbered in document order, as JSBSim numbers
+// gear/unit[i].
+type LandingGear struct {
+	Units []GearUnit
+
+	// MinExtension is the gear extension a retractable unit needs to bear
+	// load; any extension over 1% when zero
+	MinExtension float64
+}
+
+// gearSlipVelocity is the sliding speed (m/s) at which tire friction
+// reaches its full value. Below it friction builds up linearly, so a
+// stopped wheel does not chatter between opposite friction forces.
+const gearSlipVelocity = 0.5
+
+// gearSkidVelocity is the sliding speed (m/s) by which a tire has broken
+// away from the static friction coefficient to the dynamic one
+const gearSkidVelocity = 2.0
+
+// Oleo strut assumptions
+const (
+	oleoStrokeFraction = 0.15 // Stroke of a bogey's strut per unit of its height below the CG
+	oleoBottomingRatio = 3    // Stiffness of the stop past full stroke per unit of the strut's
+	oleoPolytropic     = 1.35 // Polytropic exponent of the air spring's compression
+)
+
+// maxCachedGearUnits is the number of units whose property names are built
+// up front
+const maxCachedGearUnits = 32
+
+// gearUnitPropertyNames are the per-unit property names, built once so
+// publishing them does not allocate
+var gearUnitPropertyNames = func() (names [maxCachedGearUnits][3]string) {
+	for i := range names {
+		names[i] = buildGearUnitPropertyNames(i)
+	}
+	return names
+}()
+
+// buildGearUnitPropertyNames returns the WOW, compression and compression
+// rate property names of unit i
+func buildGearUnitPropertyNames(i int) [3]string {
+	return [3]string{
+		IndexedPropertyName("gear/unit", i, "WOW"),
+		IndexedPropertyName("gear/unit", i, "compression-ft"),
+		IndexedPropertyName("gear/unit", i, "compression-velocity-fps"),
+	}
+}
+
+// gearUnitProperties returns the property names of unit i
+func gearUnitProperties(i int) [3]string {
+	if i < maxCachedGearUnits {
+		return gearUnitPropertyNames[i]
+	}
+	return buildGearUnitPropertyNames(i)
+}
+
+// NewLandingGear creates the contact points of a configuration, or returns
+// nil when it has none. Structural locations are converted to body axes
+// about the CG. As in JSBSim, a max_steer of 360
+// degrees makes a castering wheel and 0 a fixed one. The configuration
+// gives no stroke, so a bogey's is taken as a fraction of its height below
+// the CG.
+func NewLandingGear(config *JSBSimConfig) *LandingGear {
+	if config == nil || config.GroundReactions == nil || len(config.GroundReactions.Contact) == 0 {
+		return nil
+	}
+
+	cg := configCG(config)
+	gear := &LandingGear{}
+	for _, contact := range config.GroundReactions.Contact {
+		unit := GearUnit{
+			Name:            contact.Name,
+			Type:            strings.ToUpper(strings.TrimSpace(contact.Type)),
+			Retractable:     contact.Retractable != 0,
+			SpringCoeff:     gearCoefficient(contact.SpringCoeff),
+			DampingCoeff:    gearCoefficient(contact.DampingCoeff),
+			DampingRebound:  gearCoefficient(contact.DampingCoeffRebound),
+			StaticFriction:  contact.StaticFriction,
+			DynamicFriction: contact.DynamicFriction,
+			RollingFriction: contact.RollingFriction,
+			BrakeGroup:      strings.ToUpper(strings.TrimSpace(contact.BrakeGroup)),
+		}
+		if contact.MaxSteer != nil {
+			unit.MaxSteer = contact.MaxSteer.Value
+			if !strings.EqualFold(strings.TrimSpace(contact.MaxSteer.Unit), "RAD") {
+				unit.MaxSteer *= DEG_TO_RAD
+			}
+		}
+		switch maxSteerDeg := math.Abs(unit.MaxSteer * RAD_TO_DEG); {
+		case math.Abs(maxSteerDeg-360) < 1e-3:
+			unit.Steering = GearCaster
+		case maxSteerDeg < 1e-6:
+			unit.Steering = GearFixed
+		default:
+			unit.Steering = GearSteerable
+		}
+		if contact.Location != nil {
+			unit.Location = StructuralToBody(contact.Location, cg)
+		}
+		if unit.Type == "BOGEY" && unit.Location.Z > 0 {
+			unit.MaxStroke = oleoStrokeFraction * unit.Location.Z
+		}
+		gear.Units = append(gear.Units, unit)
+	}
+	return gear
+}
+
+// gearCoefficient converts a spring or damping coefficient to SI units
+// (N/m, or N·s/m with a trailing /SEC). Unitless values are taken as
+// already SI.
+func gearCoefficient(m *Measurement) float64 {
+	if m == nil {
+		return 0
+	}
+	unit := strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(m.Unit)), "/SEC")
+	switch unit {
+	case "LBS/FT":
+		return m.Value * LB_TO_N / FT_TO_M
+	case "LBS/IN":
+		return m.Value * LB_TO_N / (IN_TO_FT * FT_TO_M)
+	}
+	return m.Value
+}
+
+// contact returns the contact state of a unit, or the zero state when it is
+// retracted or clear of the ground
+func (gear *LandingGear) contact(unit GearUnit, state *AircraftState) GearUnitState {
+	minExtension := gear.MinExtension
+	if minExtension <= 0 {
+		minExtension = 0.01
+	}
+	if unit.Retractable && state.Gear.Transition < minExtension {
+		return GearUnitState{}
+	}
+
+	// Height of the contact point above the ground (NED Z is down)
+	offset := state.Orientation.RotateVector(unit.Location)
+	compression := state.Gear.GroundHeight - (state.Altitude - offset.Z)
+	if compression <= 0 {
+		return GearUnitState{}
+	}
+
+	// Contact point velocity over the ground; moving down compresses the strut
+	velocity := state.VelocityAt(unit.Location)
+	return GearUnitState{
+		WOW:                 true,
+		Compression:         compression,
+		CompressionVelocity: state.Orientation.RotateVector(velocity).Add(state.Wind).Z,
+	}
+}
+
+// Update sets the contact state of every unit from the state's position,
+// attitude and rates, debouncing the squat switches from the units the
+// state was copied with. A new slice is stored each time, as states share
+// their Units slice with the states they were copied from.
+func (gear *LandingGear) Update(state *AircraftState) {
+	units := make([]GearUnitState, len(gear.Units))
+	for i, unit := range gear.Units {
+		units[i] = gear.contact(unit, state)
+	}
+	gear.updateSquat(state, state.Gear.Units, units)
+	state.Gear.Units = units
+}
+
+// Forces returns the total ground reaction on the aircraft as a body-axis
+// force (N) and moment about the CG (N·m).
+//
+// Each strut pushes up with its oleo force. Wheels resist
+// rolling with their rolling friction, rising toward the static friction
+// coefficient as their brake group's brake is applied once their squat
+// switch has made, and resist sliding
+// sideways with the static friction, breaking away to the dynamic friction
+// as the slip grows. Steerable wheels roll in the
+// direction set by the steering command; castering wheels carry no side
+// load. Structure contacts slide with the dynamic friction in any
+// direction.
+func (gear *LandingGear) Forces(state *AircraftState) (force, moment Vector3) {
+	toBody := state.Orientation.Conjugate()
+	steer := math.Max(-1, math.Min(1, state.Controls.Steer))
+
+	for i, unit := range gear.Units {
+		contact := gear.contact(unit, state)
+		if !contact.WOW {
+			continue
+		}
+		normal := unit.StrutForce(contact.Compression, contact.CompressionVelocity)
+		if normal <= 0 {
+			continue // A strut extending faster than the spring pushes does not pull down
+		}
+
+		// Contact point velocity along the ground, in local level axes; the
+		// wheels roll over the ground, not through the air
+		velocity := state.Orientation.RotateVector(state.VelocityAt(unit.Location)).Add(state.Wind)
+		velocity.Z = 0
+
+		var friction Vector3
+		if unit.Type == "STRUCTURE" {
+			speed := velocity.Magnitude()
+			mu := unit.frictionCoefficient(speed)
+			friction = velocity.Scale(-mu * normal / math.Max(speed, gearSlipVelocity))
+		} else {
+			// Rolling direction: body X turned by the steering angle, then
+			// projected onto the ground
+			angle := 0.0
+			if unit.Steering == GearSteerable {
+				angle = steer * unit.MaxSteer
+			}
+			heading := state.Orientation.RotateVector(Vector3{X: math.Cos(angle), Y: math.Sin(angle)})
+			heading.Z = 0
+			heading = heading.Normalize()
+			side := Vector3{X: -heading.Y, Y: heading.X}
+
+			brake := 0.0
+			if squatMade(state, i) {
+				brake = state.Controls.BrakeCommand(unit.BrakeGroup)
+			}
+			rollingCoeff := unit.RollingFriction + brake*(unit.StaticFriction-unit.RollingFriction)
+			friction = heading.Scale(-rollingCoeff * normal * slipFraction(velocity.Dot(heading)))
+			if unit.Steering != GearCaster {
+				slip := velocity.Dot(side)
+				mu := unit.frictionCoefficient(math.Abs(slip))
+				friction = friction.Add(side.Scale(-mu * normal * slipFraction(slip)))
+			}
+		}
+
+		// Up is negative Z in local level axes
+		local := friction.Add(Vector3{Z: -normal})
+		body := toBody.RotateVector(local)
+		force = force.Add(body)
+		moment = moment.Add(unit.Location.Cross(body))
+	}
+	return force, moment
+}
+
+// StrutForce returns the oleo strut's force at a compression (m) and
+// compression rate (m/s, positive compressing): k·x + c·ẋ, with the
+// rebound damping while extending, plus the air spring. Past full stroke
+// the strut has bottomed, and further compression meets a stop many times
+// stiffer than the strut. The force may be negative when the strut extends
+// fast; a strut cannot pull, so callers ignore that.
+func (unit GearUnit) StrutForce(compression, rate float64) float64 {
+	damping := unit.DampingCoeff
+	if rate < 0 && unit.DampingRebound > 0 {
+		damping = unit.DampingRebound
+	}
+	stroke, bottomed := compression, 0.0
+	if unit.MaxStroke > 0 && compression > unit.MaxStroke {
+		stroke, bottomed = unit.MaxStroke, compression-unit.MaxStroke
+	}
+
+	force := unit.SpringCoeff*stroke + damping*rate + oleoBottomingRatio*unit.SpringCoeff*bottomed
+	if unit.AirSpring > 0 && unit.MaxStroke > 0 {
+		// The gas volume left shrinks with the stroke; a twentieth of it
+		// remains at full stroke
+		volume := 1 - 0.95*stroke/unit.MaxStroke
+		force += unit.AirSpring * (math.Pow(volume, -oleoPolytropic) - 1)
+	}
+	return force
+}
+
+// frictionCoefficient returns a unit's friction coefficient at a sliding
+// speed (m/s): the static coefficient while the tire grips, falling
+// linearly to the dynamic one between gearSlipVelocity and
+// gearSkidVelocity. A unit without a static coefficient slides with the
+// dynamic one at any speed.
+func (unit GearUnit) frictionCoefficient(speed float64) float64 {
+	if unit.StaticFriction <= 0 {
+		return unit.DynamicFriction
+	}
+	skid := math.Max(0, math.Min(1, (speed-gearSlipVelocity)/(gearSkidVelocity-gearSlipVelocity)))
+	return unit.StaticFriction + skid*(unit.DynamicFriction-unit.StaticFriction)
+}
+
+// slipFraction returns the signed fraction of full friction at a sliding
+// speed, saturating at gearSlipVelocity
+func slipFraction(speed float64) float64 {
+	return math.Max(-1, math.Min(1, speed/gearSlipVelocity))
+}
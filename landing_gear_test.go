@@ -0,0 +1,360 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// findFCSFunction returns the function of a named fcs_function component
+func findFCSFunction(t *testing.T, config *JSBSimConfig, name string) *Function {
+	t.Helper()
+	for _, ch := range config.FlightControl.Channel {
+		for _, comp := range ch.Component {
+			if comp.Name == name && comp.Function != nil {
+				return comp.Function
+			}
+		}
+	}
+	t.Fatalf("No fcs_function %q", name)
+	return nil
+}
+
+func TestLandingGear(t *testing.T) {
+	config := loadP51DConfig(t)
+	gear := NewLandingGear(config)
+	if gear == nil || len(gear.Units) != 21 {
+		t.Fatalf("Expected 3 P-51D gear units and 18 structure contacts, got %+v", gear)
+	}
+
+	t.Run("Units", func(t *testing.T) {
+		assertEqual(t, gear.Units[0].Name, "LEFT_MLG")
+		assertEqual(t, gear.Units[2].Name, "TAIL_LG")
+		assertEqual(t, gear.Units[2].Retractable, true)
+		assertEqual(t, gear.Units[3].Type, "STRUCTURE")
+
+		// Tailwheel is 172.2 in aft of and 28.5 in below the CG
+		assertApproxEqual(t, gear.Units[2].Location.X, -172.2*0.0254, 1e-3)
+		assertApproxEqual(t, gear.Units[2].Location.Z, 28.5*0.0254, 1e-3)
+		assertApproxEqual(t, gear.Units[0].Location.Y, -72*0.0254, 1e-3)
+		assertEqual(t, NewLandingGear(&JSBSimConfig{}) == nil, true)
+	})
+
+	t.Run("Struts Brakes And Steering", func(t *testing.T) {
+		// 9800 lbf/ft and 2500 lbf/ft/s
+		assertApproxEqual(t, gear.Units[0].SpringCoeff, 9800*LB_TO_N/FT_TO_M, 1e-6)
+		assertApproxEqual(t, gear.Units[0].DampingCoeff, 2500*LB_TO_N/FT_TO_M, 1e-6)
+		assertEqual(t, gear.Units[0].BrakeGroup, "LEFT")
+		assertEqual(t, gear.Units[1].BrakeGroup, "RIGHT")
+		assertEqual(t, gear.Units[2].BrakeGroup, "NONE")
+
+		// The mains are fixed and the tailwheel, with a max_steer of 360
+		// degrees, casters
+		assertEqual(t, gear.Units[0].Steering, GearFixed)
+		assertEqual(t, gear.Units[2].Steering, GearCaster)
+	})
+
+	// Level on the ground with the tailwheel compressed 0.2 ft
+	state := NewAircraftState()
+	state.SetControlInputs(ControlInputs{Gear: true})
+	state.Altitude = gear.Units[2].Location.Z - 0.2*FT_TO_M
+	state.Position.Z = -state.Altitude
+
+	t.Run("Compression", func(t *testing.T) {
+		state.Velocity = Vector3{}
+		state.AngularRate = Vector3{Y: 0.1} // Pitching up pushes the tail down
+		gear.Update(state)
+
+		tail := state.Gear.Units[2]
+		assertEqual(t, tail.WOW, true)
+		assertApproxEqual(t, tail.Compression*M_TO_FT, 0.2, 1e-4)
+		assertApproxEqual(t, tail.CompressionVelocity, 0.1*172.2*0.0254, 1e-3)
+
+		m := state.ToPropertyMap()
+		assertEqual(t, m["gear/unit[2]/WOW"], 1.0)
+		assertApproxEqual(t, m["gear/unit[2]/compression-ft"], 0.2, 1e-4)
+
+		pm := NewPropertyManager()
+		pm.UpdateFromAircraftState(state)
+		assertApproxEqual(t, pm.Get("gear/unit[2]/compression-ft"), 0.2, 1e-4)
+	})
+
+	t.Run("Retracted", func(t *testing.T) {
+		retracted := state.Copy()
+		retracted.SetControlInputs(ControlInputs{Gear: false})
+		gear.Update(retracted)
+		assertEqual(t, retracted.Gear.Units[2], GearUnitState{})
+
+		// The state it was copied from keeps its own units
+		assertEqual(t, state.Gear.Units[2].WOW, true)
+	})
+
+	t.Run("Brake Scaling Table", func(t *testing.T) {
+		brakeScaling := findFCSFunction(t, config, "systems/brakes/brake-scaling")
+
+		// Airborne, the column lookup clamps at its first breakpoint
+		airborne := NewAircraftState()
+		value, err := EvaluateFunction(brakeScaling, airborne.ToPropertyMap())
+		if err != nil {
+			t.Fatalf("EvaluateFunction: %v", err)
+		}
+		assertApproxEqual(t, value, 0.15, 1e-9)
+
+		// 0.2 ft is three quarters of the way from 0.05 to 0.25 ft
+		value, err = EvaluateFunction(brakeScaling, state.ToPropertyMap())
+		if err != nil {
+			t.Fatalf("EvaluateFunction: %v", err)
+		}
+		assertApproxEqual(t, value, 0.15+0.75*(1.2-0.15), 1e-4)
+	})
+
+	t.Run("Flight Dynamics Engine", func(t *testing.T) {
+		engine := NewFlightDynamicsEngine(config, NewEulerIntegrator())
+		if engine.Gear == nil {
+			t.Fatal("Engine should build the gear from the configuration")
+		}
+		airborne := NewAircraftState()
+		airborne.SetControlInputs(ControlInputs{Gear: true})
+		airborne.Altitude = 1000.0
+		airborne.Position.Z = -1000.0
+		next, err := engine.Step(airborne, 0.01)
+		if err != nil {
+			t.Fatalf("Step: %v", err)
+		}
+		assertEqual(t, len(next.Gear.Units), len(gear.Units))
+		assertEqual(t, next.Gear.Units[0].WOW, false)
+	})
+}
+
+func TestBrakeCommand(t *testing.T) {
+	controls := ControlInputs{Brake: 0.2, BrakeLeft: 0.7}
+	assertEqual(t, controls.BrakeCommand("LEFT"), 0.7)
+	assertEqual(t, controls.BrakeCommand(" right "), 0.2)
+	assertEqual(t, controls.BrakeCommand("CENTER"), 0.2)
+	assertEqual(t, controls.BrakeCommand("NONE"), 0.0)
+
+	controls.BrakeRight = 1.5
+	assertEqual(t, controls.BrakeCommand("RIGHT"), 1.0)
+
+	state := NewAircraftState()
+	state.SetControlInputs(controls)
+	m := state.ToPropertyMap()
+	assertEqual(t, m["fcs/left-brake-cmd-norm"], 0.7)
+	assertEqual(t, m["fcs/right-brake-cmd-norm"], 1.0)
+}
+
+// taxiState returns the aircraft rolling straight ahead at speed in the
+// three-point attitude, with the mains and tailwheel just touching
+func taxiState(gear *LandingGear, speed float64, controls ControlInputs) *AircraftState {
+	mains, tail := gear.Units[0].Location, gear.Units[2].Location
+	pitch := math.Atan2(mains.Z-tail.Z, mains.X-tail.X)
+
+	state := NewAircraftState()
+	state.Orientation = NewQuaternionFromEuler(0, pitch, 0)
+	controls.Gear = true
+	state.SetControlInputs(controls)
+	state.Altitude = state.Orientation.RotateVector(mains).Z - 0.1
+	state.Position.Z = -state.Altitude
+
+	inverse := Quaternion{W: state.Orientation.W, X: -state.Orientation.X, Y: -state.Orientation.Y, Z: -state.Orientation.Z}
+	state.Velocity = inverse.RotateVector(Vector3{X: speed})
+	state.UpdateAtmosphere()
+	state.UpdateDerivedParameters()
+	return state
+}
+
+func TestGroundHandling(t *testing.T) {
+	// taxi rolls the aircraft at 8 m/s with the given controls and returns
+	// the final state. The tailwheel and struts are stiff against the
+	// simplified model's pitch inertia, so the step is short.
+	taxi := func(config *JSBSimConfig, controls ControlInputs, seconds float64) *AircraftState {
+		engine := NewSimplifiedFlightDynamicsEngine(NewEulerIntegrator())
+		engine.Terrain = NewFlatTerrain(0)
+		engine.Gear = NewLandingGear(config)
+		state := taxiState(engine.Gear, 8.0, controls)
+		for i := 0; i < int(seconds/0.002); i++ {
+			var err error
+			if state, err = engine.Step(state, 0.002); err != nil {
+				t.Fatalf("Step %d failed: %v", i, err)
+			}
+		}
+		return state
+	}
+	config := loadP51DConfig(t)
+
+	t.Run("Differential Braking", func(t *testing.T) {
+		free := taxi(config, ControlInputs{}, 1.0)
+		left := taxi(config, ControlInputs{BrakeLeft: 1}, 1.0)
+		right := taxi(config, ControlInputs{BrakeRight: 1}, 1.0)
+		both := taxi(config, ControlInputs{Brake: 1}, 1.0)
+
+		assertApproxEqual(t, free.Yaw, 0.0, 1e-9)
+		if left.Yaw > -5*DEG_TO_RAD {
+			t.Errorf("Left brake should yaw the aircraft left, heading %.1f deg", left.Yaw*RAD_TO_DEG)
+		}
+		assertApproxEqual(t, right.Yaw, -left.Yaw, 1e-6)
+
+		// Both brakes stop the aircraft harder than either alone, and straight
+		assertApproxEqual(t, both.Yaw, 0.0, 1e-9)
+		if !(both.GroundSpeed < left.GroundSpeed && left.GroundSpeed < free.GroundSpeed) {
+			t.Errorf("Ground speeds: both brakes %.2f, left %.2f, none %.2f m/s",
+				both.GroundSpeed, left.GroundSpeed, free.GroundSpeed)
+		}
+		t.Logf("Heading after 1 s of left brake: %.1f deg", left.Yaw*RAD_TO_DEG)
+	})
+
+	t.Run("Tailwheel Steering", func(t *testing.T) {
+		// The castering tailwheel ignores the steering command
+		steerOnly := taxi(config, ControlInputs{Steer: 1}, 2.0)
+		assertApproxEqual(t, steerOnly.Yaw, 0.0, 1e-9)
+		castering := taxi(config, ControlInputs{Rudder: 1, Steer: 1}, 2.0)
+
+		// A steerable tailwheel turns the opposite way to the commanded
+		// turn, hence the negative max_steer
+		steerable := loadP51DConfig(t)
+		steerable.GroundReactions.Contact[2].MaxSteer = &Measurement{Unit: "DEG", Value: -30}
+		assertEqual(t, NewLandingGear(steerable).Units[2].Steering, GearSteerable)
+		steered := taxi(steerable, ControlInputs{Rudder: 1, Steer: 1}, 2.0)
+
+		if steered.Yaw < 4*math.Abs(castering.Yaw) {
+			t.Errorf("Steering should turn right much faster: heading %.1f deg steered, %.1f deg castering",
+				steered.Yaw*RAD_TO_DEG, castering.Yaw*RAD_TO_DEG)
+		}
+		t.Logf("Heading after 2 s: %.1f deg steered, %.1f deg castering", steered.Yaw*RAD_TO_DEG, castering.Yaw*RAD_TO_DEG)
+	})
+
+	t.Run("Ground Roll From Rest", func(t *testing.T) {
+		// Full power from a standstill: the air data stays finite and calm
+		// through the first crawl, and the aircraft only ever speeds up
+		engine := NewSimplifiedFlightDynamicsEngine(NewEulerIntegrator())
+		engine.Terrain = NewFlatTerrain(0)
+		engine.Gear = NewLandingGear(config)
+		state := taxiState(engine.Gear, 0, ControlInputs{Throttle: 1, Mixture: 1, Propeller: 1})
+		assertEqual(t, state.Alpha, 0.0)
+		assertEqual(t, state.DynamicPressure, 0.0)
+
+		for i := 0; i < 2500; i++ {
+			next, err := engine.Step(state, 0.002)
+			if err != nil {
+				t.Fatalf("Step %d failed: %v", i, err)
+			}
+			if err := next.Validate(); err != nil {
+				t.Fatalf("Step %d: %v", i, err)
+			}
+			if next.GroundSpeed <= state.GroundSpeed {
+				t.Fatalf("Ground speed fell from %.4f to %.4f m/s at t=%.3f s",
+					state.GroundSpeed, next.GroundSpeed, next.Time)
+			}
+			if next.TrueAirspeed < AirDataFadeSpeed && math.Abs(next.Alpha) > 15*DEG_TO_RAD {
+				t.Fatalf("Alpha %.1f° at %.3f m/s should be faded out", next.Alpha*RAD_TO_DEG, next.TrueAirspeed)
+			}
+			state = next
+		}
+		if state.GroundSpeed < 3 {
+			t.Errorf("Only %.2f m/s after 5 s at full power", state.GroundSpeed)
+		}
+	})
+}
+
+// dropTest drops a mass onto a strut at a sink rate, the strut's extension
+// at contact, and returns the peak load (N), the peak compression (m), the
+// times the strut crossed its static compression and the time it took to
+// settle within 2% of it (s), over five seconds
+func dropTest(unit GearUnit, mass, sink float64) (peakLoad, peakCompression float64, crossings int, settle float64) {
+	const dt = 0.0005
+	static := mass * StandardGravity / unit.SpringCoeff
+	x, v := 0.0, sink
+	below := true
+	for i := 1; i <= 10000; i++ {
+		load := math.Max(0, unit.StrutForce(x, v))
+		peakLoad = math.Max(peakLoad, load)
+		peakCompression = math.Max(peakCompression, x)
+		v += (StandardGravity - load/mass) * dt
+		x += v * dt
+		if (x < static) != below {
+			below = !below
+			crossings++
+		}
+		if math.Abs(x-static) > 0.02*static {
+			settle = float64(i) * dt
+		}
+	}
+	return peakLoad, peakCompression, crossings, settle
+}
+
+func TestOleoStrut(t *testing.T) {
+	config := loadP51DConfig(t)
+	gear := NewLandingGear(config)
+	main := gear.Units[0]
+	assertApproxEqual(t, main.SpringCoeff, 9800*LB_TO_N/FT_TO_M, 1e-6)
+	assertApproxEqual(t, main.DampingCoeff, 2500*LB_TO_N/FT_TO_M, 1e-6)
+	assertApproxEqual(t, main.DampingRebound, 6200*LB_TO_N/FT_TO_M, 1e-6)
+	assertApproxEqual(t, main.MaxStroke, oleoStrokeFraction*76*inch, 1e-9)
+	assertEqual(t, gear.Units[3].MaxStroke, 0.0) // Structure contacts have no strut
+
+	t.Run("Drop Test", func(t *testing.T) {
+		// Half the empty weight on one main strut, dropped at the 10 ft/s
+		// of the certification limit drop
+		mass := config.MassBalance.EmptyMass.Value * LB_TO_KG / 2
+		sink := 10 * FT_TO_M
+		peakLoad, peakCompression, crossings, settle := dropTest(main, mass, sink)
+		t.Logf("Peak %.0f N (%.1f g), %.3f m of %.3f m stroke, %d crossings, settled in %.2f s",
+			peakLoad, peakLoad/(mass*StandardGravity), peakCompression, main.MaxStroke, crossings, settle)
+
+		// The damper takes the impact, peaking as the strut starts to
+		// move, and the stroke is not used up
+		assertApproxEqual(t, peakLoad, main.DampingCoeff*sink, 0.01*peakLoad)
+		if peakCompression >= main.MaxStroke {
+			t.Errorf("The strut bottomed at %.3f m", peakCompression)
+		}
+		// A damped oscillation: each crossing of the static compression
+		// is an overshoot, and there are one or two before it settles
+		if crossings < 1 || crossings > 2 {
+			t.Errorf("Expected 1 or 2 overshoots, got %d", crossings)
+		}
+		if settle >= 2 {
+			t.Errorf("Expected the strut to settle within 2 s, took %.2f s", settle)
+		}
+
+		// Undamped, it bounces for the whole run
+		bouncy := main
+		bouncy.DampingCoeff, bouncy.DampingRebound = 0, 0
+		if _, _, _, settle := dropTest(bouncy, mass, sink); settle < 4.9 {
+			t.Errorf("An undamped strut should not settle, settled in %.2f s", settle)
+		}
+	})
+
+	t.Run("Bottoming", func(t *testing.T) {
+		// Past full stroke the stop is much stiffer than the strut
+		past := main.StrutForce(main.MaxStroke+0.01, 0) - main.StrutForce(main.MaxStroke, 0)
+		assertApproxEqual(t, past, oleoBottomingRatio*main.SpringCoeff*0.01, 1e-6)
+		assertApproxEqual(t, main.StrutForce(main.MaxStroke, 0), main.SpringCoeff*main.MaxStroke, 1e-6)
+	})
+
+	t.Run("Rebound", func(t *testing.T) {
+		assertApproxEqual(t, main.StrutForce(0.1, 1), main.SpringCoeff*0.1+main.DampingCoeff, 1e-6)
+		assertApproxEqual(t, main.StrutForce(0.1, -1), main.SpringCoeff*0.1-main.DampingRebound, 1e-6)
+	})
+
+	t.Run("Air Spring", func(t *testing.T) {
+		strut := main
+		strut.AirSpring = 5000
+		assertEqual(t, strut.StrutForce(0, 0), 0.0)
+		// The air spring stiffens the strut toward the end of its stroke
+		early := strut.StrutForce(0.1*strut.MaxStroke, 0) - main.StrutForce(0.1*strut.MaxStroke, 0)
+		late := strut.StrutForce(0.9*strut.MaxStroke, 0) - main.StrutForce(0.9*strut.MaxStroke, 0)
+		if late < 10*early {
+			t.Errorf("Expected the air spring to stiffen sharply, %.0f N early and %.0f N late", early, late)
+		}
+	})
+
+	t.Run("Friction Transition", func(t *testing.T) {
+		assertEqual(t, main.frictionCoefficient(0), main.StaticFriction)
+		assertEqual(t, main.frictionCoefficient(gearSlipVelocity), main.StaticFriction)
+		assertApproxEqual(t, main.frictionCoefficient((gearSlipVelocity+gearSkidVelocity)/2),
+			(main.StaticFriction+main.DynamicFriction)/2, 1e-12)
+		assertEqual(t, main.frictionCoefficient(10), main.DynamicFriction)
+
+		unit := GearUnit{DynamicFriction: 0.4}
+		assertEqual(t, unit.frictionCoefficient(0), 0.4)
+	})
+}
@@ -0,0 +1,491 @@
+// Legacy Configurations
+// Translates aircraft written in the JSBSim 1.x format, with uppercase
+// sections, AC_ keyword lines, coefficient blocks and FG_ property names,
+// into the current format, so they parse like any other aircraft
+
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// legacyPropertyNames maps the FG_ property names of the oldest JSBSim
+// aircraft to the property paths that replaced them
+var legacyPropertyNames = map[string]string{
+	"FG_QBAR":           "aero/qbar-psf",
+	"FG_SW":             "metrics/Sw-sqft",
+	"FG_BW":             "metrics/bw-ft",
+	"FG_CBAR":           "metrics/cbarw-ft",
+	"FG_ALPHA":          "aero/alpha-rad",
+	"FG_ALPHADOT":       "aero/alphadot-rad_sec",
+	"FG_BETA":           "aero/beta-rad",
+	"FG_BETADOT":        "aero/betadot-rad_sec",
+	"FG_MACH":           "velocities/mach",
+	"FG_ROLLRATE":       "velocities/p-rad_sec",
+	"FG_PITCHRATE":      "velocities/q-rad_sec",
+	"FG_YAWRATE":        "velocities/r-rad_sec",
+	"FG_BI2VEL":         "aero/bi2vel",
+	"FG_CI2VEL":         "aero/ci2vel",
+	"FG_HOVERB":         "aero/h_b-mac-ft",
+	"FG_ELEVATOR_POS":   "fcs/elevator-pos-rad",
+	"FG_AILERON_POS":    "fcs/left-aileron-pos-rad",
+	"FG_RUDDER_POS":     "fcs/rudder-pos-rad",
+	"FG_FLAPS_POS":      "fcs/flap-pos-deg",
+	"FG_ELEVATOR_CMD":   "fcs/elevator-cmd-norm",
+	"FG_AILERON_CMD":    "fcs/aileron-cmd-norm",
+	"FG_RUDDER_CMD":     "fcs/rudder-cmd-norm",
+	"FG_FLAPS_CMD":      "fcs/flap-cmd-norm",
+	"FG_PITCH_TRIM_CMD": "fcs/pitch-trim-cmd-norm",
+	"FG_ROLL_TRIM_CMD":  "fcs/roll-trim-cmd-norm",
+	"FG_YAW_TRIM_CMD":   "fcs/yaw-trim-cmd-norm",
+	"FG_THROTTLE_CMD":   "fcs/throttle-cmd-norm",
+	"FG_GEAR_POS":       "gear/gear-pos-norm",
+}
+
+// legacyMeasurements maps the AC_ keywords of a legacy METRICS section
+// holding one value to the section, element and unit they became
+var legacyMeasurements = map[string]struct{ section, element, unit string }{
+	"AC_WINGAREA":      {"metrics", "wingarea", "FT2"},
+	"AC_WINGSPAN":      {"metrics", "wingspan", "FT"},
+	"AC_CHORD":         {"metrics", "chord", "FT"},
+	"AC_WINGINCIDENCE": {"metrics", "wing_incidence", "DEG"},
+	"AC_HTAILAREA":     {"metrics", "htailarea", "FT2"},
+	"AC_HTAILARM":      {"metrics", "htailarm", "FT"},
+	"AC_VTAILAREA":     {"metrics", "vtailarea", "FT2"},
+	"AC_VTAILARM":      {"metrics", "vtailarm", "FT"},
+	"AC_IXX":           {"mass_balance", "ixx", "SLUG*FT2"},
+	"AC_IYY":           {"mass_balance", "iyy", "SLUG*FT2"},
+	"AC_IZZ":           {"mass_balance", "izz", "SLUG*FT2"},
+	"AC_IXY":           {"mass_balance", "ixy", "SLUG*FT2"},
+	"AC_IXZ":           {"mass_balance", "ixz", "SLUG*FT2"},
+	"AC_IYZ":           {"mass_balance", "iyz", "SLUG*FT2"},
+	"AC_EMPTYWT":       {"mass_balance", "emptywt", "LBS"},
+}
+
+// legacyLocations maps the AC_ keywords of metrics locations to the names
+// of the locations they became
+var legacyLocations = map[string]string{
+	"AC_AERORP":   "AERORP",
+	"AC_EYEPTLOC": "EYEPOINT",
+	"AC_VRP":      "VRP",
+}
+
+// detectLegacyConfig reports whether a document is in the JSBSim 1.x
+// format: an <FDM_CONFIG> root, or an <fdm_config> of a 1.x version with
+// uppercase sections. A document with any other root is refused.
+func detectLegacyConfig(data []byte) (bool, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	depth, root := 0, ""
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			if root == "" {
+				return false, fmt.Errorf("unrecognized JSBSim version: no root element")
+			}
+			return false, nil
+		}
+		if err != nil {
+			if root == "" {
+				return false, err
+			}
+			// The full decode reports a malformed document
+			return false, nil
+		}
+		switch el := token.(type) {
+		case xml.StartElement:
+			depth++
+			switch {
+			case depth == 1:
+				root = el.Name.Local
+				switch {
+				case root == "FDM_CONFIG":
+					return true, nil
+				case root != "fdm_config":
+					return false, fmt.Errorf("unrecognized JSBSim version: root element <%s>, not <fdm_config> or the 1.x <FDM_CONFIG>", root)
+				case !strings.HasPrefix(legacyAttr(el, "version"), "1."):
+					return false, nil
+				}
+			case depth == 2 && el.Name.Local == strings.ToUpper(el.Name.Local):
+				return true, nil
+			}
+		case xml.EndElement:
+			depth--
+		}
+	}
+}
+
+// legacyNode is an element of a legacy document: its attributes, by upper
+// case name, its own text and its child elements
+type legacyNode struct {
+	name     string
+	attrs    map[string]string
+	text     string
+	children []*legacyNode
+}
+
+// attr returns an attribute, whatever the case of its name
+func (n *legacyNode) attr(name string) string {
+	return n.attrs[strings.ToUpper(name)]
+}
+
+// lines returns the non-blank lines of the node's text, trimmed
+func (n *legacyNode) lines() []string {
+	var lines []string
+	for _, line := range strings.Split(n.text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// legacyAttr returns an attribute of a start element, whatever its case
+func legacyAttr(el xml.StartElement, name string) string {
+	for _, attr := range el.Attr {
+		if strings.EqualFold(attr.Name.Local, name) {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// readLegacyTree decodes a document into its tree of elements
+func readLegacyTree(data []byte) (*legacyNode, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var stack []*legacyNode
+	var root *legacyNode
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch el := token.(type) {
+		case xml.StartElement:
+			node := &legacyNode{name: el.Name.Local, attrs: make(map[string]string)}
+			for _, attr := range el.Attr {
+				node.attrs[strings.ToUpper(attr.Name.Local)] = attr.Value
+			}
+			if len(stack) == 0 {
+				root = node
+			} else {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, node)
+			}
+			stack = append(stack, node)
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text += string(el)
+			}
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		}
+	}
+	if root == nil {
+		return nil, fmt.Errorf("no root element")
+	}
+	return root, nil
+}
+
+// legacyTranslator writes the current-format document of a legacy one,
+// noting what it translated and what it left out
+type legacyTranslator struct {
+	metrics, mass, gear, fcs, aero, standalone strings.Builder
+
+	pointMasses int
+	notes       []string
+}
+
+func (lt *legacyTranslator) note(format string, args ...interface{}) {
+	lt.notes = append(lt.notes, fmt.Sprintf(format, args...))
+}
+
+// property returns the current name of a property, translating FG_ names
+func (lt *legacyTranslator) property(name string) string {
+	name = strings.TrimSpace(name)
+	if !strings.HasPrefix(strings.ToUpper(name), "FG_") {
+		return name
+	}
+	if current, ok := legacyPropertyNames[strings.ToUpper(name)]; ok {
+		return current
+	}
+	lt.note("unknown legacy property %s kept as it is", name)
+	return name
+}
+
+// escapeXML escapes text for an element or attribute
+func escapeXML(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// translateLegacyConfig rewrites a JSBSim 1.x document in the current
+// format, returning it with a note of each translation and omission. The
+// metrics, mass properties, undercarriage, flight control and aerodynamic
+// coefficients are translated; the engines, which 1.x kept in files of
+// their own format, are not.
+func translateLegacyConfig(data []byte) ([]byte, []string, error) {
+	root, err := readLegacyTree(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("legacy configuration: %w", err)
+	}
+	lt := &legacyTranslator{}
+	lt.note("translated from the JSBSim %s format", firstNonEmpty(root.attr("version"), "1.x"))
+	for _, section := range root.children {
+		switch strings.ToUpper(section.name) {
+		case "METRICS":
+			lt.translateMetrics(section)
+		case "UNDERCARRIAGE":
+			lt.translateUndercarriage(section)
+		case "FLIGHT_CONTROL":
+			lt.translateFlightControl(section)
+		case "AERODYNAMICS":
+			lt.translateAerodynamics(section)
+		default:
+			lt.note("section %s not translated", section.name)
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<fdm_config name=\"%s\" version=\"%s\" release=\"%s\">\n",
+		escapeXML(root.attr("name")), escapeXML(root.attr("version")), escapeXML(root.attr("release")))
+	section := func(element, body string) {
+		if body != "" {
+			fmt.Fprintf(&b, "<%s>\n%s</%s>\n", element, body, element)
+		}
+	}
+	section("metrics", lt.metrics.String())
+	section("mass_balance", lt.mass.String())
+	section("ground_reactions", lt.gear.String())
+	b.WriteString(lt.fcs.String())
+	section("aerodynamics", lt.standalone.String()+lt.aero.String())
+	b.WriteString("</fdm_config>\n")
+	return []byte(b.String()), lt.notes, nil
+}
+
+// firstNonEmpty returns the first of its arguments that is not empty
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// translateMetrics translates the AC_ keywords of a METRICS section, as
+// lines of its text or as elements of their own, into the metrics and
+// mass_balance sections
+func (lt *legacyTranslator) translateMetrics(section *legacyNode) {
+	lines := section.lines()
+	for _, child := range section.children {
+		lines = append(lines, child.name+" "+strings.Join(child.lines(), " "))
+	}
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		keyword, values := strings.ToUpper(fields[0]), fields[1:]
+		location := func(w *strings.Builder, name string) {
+			fmt.Fprintf(w, "<location name=\"%s\" unit=\"IN\"><x>%s</x><y>%s</y><z>%s</z></location>\n",
+				name, escapeXML(values[0]), escapeXML(values[1]), escapeXML(values[2]))
+		}
+		measurement, isMeasurement := legacyMeasurements[keyword]
+		name, isLocation := legacyLocations[keyword]
+		switch {
+		case isMeasurement && len(values) >= 1:
+			w := &lt.metrics
+			if measurement.section == "mass_balance" {
+				w = &lt.mass
+			}
+			fmt.Fprintf(w, "<%s unit=\"%s\">%s</%s>\n", measurement.element, measurement.unit, escapeXML(values[0]), measurement.element)
+		case isLocation && len(values) >= 3:
+			location(&lt.metrics, name)
+		case keyword == "AC_CGLOC" && len(values) >= 3:
+			location(&lt.mass, "CG")
+		case keyword == "AC_POINTMASS" && len(values) >= 4:
+			lt.pointMasses++
+			fmt.Fprintf(&lt.mass, "<pointmass name=\"POINTMASS %d\"><weight unit=\"LBS\">%s</weight>", lt.pointMasses, escapeXML(values[0]))
+			values = values[1:]
+			location(&lt.mass, "POINTMASS")
+			lt.mass.WriteString("</pointmass>\n")
+		default:
+			lt.note("METRICS %s not translated", keyword)
+			continue
+		}
+		lt.note("METRICS %s translated", keyword)
+	}
+}
+
+// translateUndercarriage translates the AC_GEAR lines of an UNDERCARRIAGE
+// section into contacts: name, x, y, z (in), spring (lbf/ft), damping
+// (lbf/ft/s), static, dynamic and rolling friction, steering type, brake
+// group, maximum steering angle (deg) and FIXED or RETRACT
+func (lt *legacyTranslator) translateUndercarriage(section *legacyNode) {
+	for _, line := range section.lines() {
+		fields := strings.Fields(line)
+		if strings.ToUpper(fields[0]) != "AC_GEAR" || len(fields) < 14 {
+			lt.note("UNDERCARRIAGE line %q not translated", line)
+			continue
+		}
+		f := fields[1:]
+		maxSteer := f[11]
+		switch strings.ToUpper(f[9]) {
+		case "FIXED":
+			maxSteer = "0"
+		case "CASTERED":
+			maxSteer = "360"
+		}
+		retractable := "0"
+		if strings.ToUpper(f[12]) == "RETRACT" {
+			retractable = "1"
+		}
+		fmt.Fprintf(&lt.gear, "<contact type=\"BOGEY\" name=\"%s\">"+
+			"<location unit=\"IN\"><x>%s</x><y>%s</y><z>%s</z></location>"+
+			"<static_friction>%s</static_friction><dynamic_friction>%s</dynamic_friction><rolling_friction>%s</rolling_friction>"+
+			"<spring_coeff unit=\"LBS/FT\">%s</spring_coeff><damping_coeff unit=\"LBS/FT/SEC\">%s</damping_coeff>"+
+			"<max_steer unit=\"DEG\">%s</max_steer><brake_group>%s</brake_group><retractable>%s</retractable></contact>\n",
+			escapeXML(f[0]), escapeXML(f[1]), escapeXML(f[2]), escapeXML(f[3]),
+			escapeXML(f[6]), escapeXML(f[7]), escapeXML(f[8]), escapeXML(f[4]), escapeXML(f[5]),
+			escapeXML(maxSteer), escapeXML(strings.ToUpper(f[10])), retractable)
+		lt.note("UNDERCARRIAGE AC_GEAR %s translated", f[0])
+	}
+}
+
+// translateFlightControl translates a FLIGHT_CONTROL section into a
+// flight_control of one channel, its COMPONENTs keeping their order. The
+// keyword lines of a component become its elements.
+func (lt *legacyTranslator) translateFlightControl(section *legacyNode) {
+	name := escapeXML(firstNonEmpty(section.attr("name"), "legacy"))
+	fmt.Fprintf(&lt.fcs, "<flight_control name=\"%s\"><channel name=\"%s\">\n", name, name)
+	for _, component := range section.children {
+		if strings.ToUpper(component.name) != "COMPONENT" {
+			lt.note("FLIGHT_CONTROL %s not translated", component.name)
+			continue
+		}
+		var body strings.Builder
+		var rangeMin, rangeMax string
+		for _, line := range component.lines() {
+			fields := strings.Fields(line)
+			keyword, values := strings.ToUpper(fields[0]), fields[1:]
+			switch {
+			case len(values) == 0:
+				lt.note("COMPONENT %s: %s has no value", component.attr("name"), keyword)
+			case keyword == "INPUT" || keyword == "OUTPUT":
+				element := strings.ToLower(keyword)
+				fmt.Fprintf(&body, "<%s>%s</%s>", element, escapeXML(lt.property(values[0])), element)
+			case keyword == "CLIPTO" && len(values) >= 2:
+				fmt.Fprintf(&body, "<clipto><min>%s</min><max>%s</max></clipto>", escapeXML(values[0]), escapeXML(values[1]))
+			case keyword == "MIN":
+				rangeMin = values[0]
+			case keyword == "MAX":
+				rangeMax = values[0]
+			case keyword == "GAIN" || keyword == "BIAS" || keyword == "WIDTH" || keyword == "RATE_LIMIT" ||
+				len(keyword) == 2 && keyword[0] == 'C' && keyword[1] >= '1' && keyword[1] <= '6':
+				element := strings.ToLower(keyword)
+				fmt.Fprintf(&body, "<%s>%s</%s>", element, escapeXML(values[0]), element)
+			default:
+				lt.note("COMPONENT %s: %s not translated", component.attr("name"), keyword)
+			}
+		}
+		// MIN and MAX are the output range of an aerosurface_scale and the
+		// limits of anything else
+		switch {
+		case rangeMin == "" && rangeMax == "":
+		case strings.EqualFold(component.attr("type"), "aerosurface_scale"):
+			fmt.Fprintf(&body, "<range><min>%s</min><max>%s</max></range>", escapeXML(firstNonEmpty(rangeMin, "0")), escapeXML(firstNonEmpty(rangeMax, "0")))
+		default:
+			fmt.Fprintf(&body, "<min>%s</min><max>%s</max>", escapeXML(firstNonEmpty(rangeMin, "0")), escapeXML(firstNonEmpty(rangeMax, "0")))
+		}
+		fmt.Fprintf(&lt.fcs, "<component name=\"%s\" type=\"%s\">%s</component>\n",
+			escapeXML(component.attr("name")), escapeXML(strings.ToLower(component.attr("type"))), body.String())
+		lt.note("FLIGHT_CONTROL COMPONENT %s translated", component.attr("name"))
+	}
+	lt.fcs.WriteString("</channel></flight_control>\n")
+}
+
+// translateAerodynamics translates each AXIS of COEFFICIENTs into an axis
+// of functions. The coefficients of a GROUP are each multiplied by its
+// FACTOR, which becomes a standalone function.
+func (lt *legacyTranslator) translateAerodynamics(section *legacyNode) {
+	for _, axis := range section.children {
+		if strings.ToUpper(axis.name) != "AXIS" {
+			lt.note("AERODYNAMICS %s not translated", axis.name)
+			continue
+		}
+		fmt.Fprintf(&lt.aero, "<axis name=\"%s\">\n", escapeXML(strings.ToUpper(axis.attr("name"))))
+		for _, child := range axis.children {
+			switch strings.ToUpper(child.name) {
+			case "COEFFICIENT":
+				lt.coefficient(&lt.aero, child, "")
+			case "GROUP":
+				factor := ""
+				for _, member := range child.children {
+					if strings.ToUpper(member.name) == "FACTOR" {
+						factor = "aero/coefficient/" + member.attr("name")
+						lt.coefficient(&lt.standalone, member, "")
+					}
+				}
+				for _, member := range child.children {
+					if strings.ToUpper(member.name) == "COEFFICIENT" {
+						lt.coefficient(&lt.aero, member, factor)
+					}
+				}
+			default:
+				lt.note("AXIS %s: %s not translated", axis.attr("name"), child.name)
+			}
+		}
+		lt.aero.WriteString("</axis>\n")
+	}
+}
+
+// coefficient translates a VALUE, VECTOR or TABLE coefficient into a
+// function named aero/coefficient/NAME: the product of its multipliers, a
+// factor property when it is in a group, and its value or table. The body
+// is its description, for a vector its row count and row property, for a
+// table its row and column counts and properties, then the multipliers
+// separated by |, then the data.
+func (lt *legacyTranslator) coefficient(w *strings.Builder, node *legacyNode, factor string) {
+	name, kind := node.attr("name"), strings.ToUpper(node.attr("type"))
+	lines := node.lines()
+	headers := map[string]int{"VALUE": 2, "VECTOR": 4, "TABLE": 5}[kind]
+	if headers == 0 || len(lines) <= headers {
+		lt.note("COEFFICIENT %s of type %s not translated", name, firstNonEmpty(kind, "none"))
+		return
+	}
+	description, multipliers := lines[0], lines[headers-1]
+
+	var product strings.Builder
+	for _, multiplier := range strings.Split(multipliers, "|") {
+		if multiplier = strings.TrimSpace(multiplier); multiplier != "" && !strings.EqualFold(multiplier, "none") {
+			fmt.Fprintf(&product, "<property>%s</property>", escapeXML(lt.property(multiplier)))
+		}
+	}
+	if factor != "" {
+		fmt.Fprintf(&product, "<property>%s</property>", escapeXML(factor))
+	}
+	// Table data is read raw, so its line breaks are kept out of the escaping
+	rows := make([]string, 0, len(lines)-headers)
+	for _, row := range lines[headers:] {
+		rows = append(rows, escapeXML(row))
+	}
+	data := strings.Join(rows, "\n")
+	switch kind {
+	case "VALUE":
+		fmt.Fprintf(&product, "<value>%s</value>", escapeXML(lines[headers]))
+	case "VECTOR":
+		fmt.Fprintf(&product, "<table><independentVar lookup=\"row\">%s</independentVar><tableData>\n%s\n</tableData></table>",
+			escapeXML(lt.property(lines[2])), data)
+	case "TABLE":
+		fmt.Fprintf(&product, "<table><independentVar lookup=\"row\">%s</independentVar><independentVar lookup=\"column\">%s</independentVar><tableData>\n%s\n</tableData></table>",
+			escapeXML(lt.property(lines[2])), escapeXML(lt.property(lines[3])), data)
+	}
+	fmt.Fprintf(w, "<function name=\"aero/coefficient/%s\"><description>%s</description><product>%s</product></function>\n",
+		escapeXML(name), escapeXML(description), product.String())
+	lt.note("COEFFICIENT %s translated", name)
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestLegacyConfig(t *testing.T) {
+	file, err := os.Open("testdata/legacy/c172-v1.xml")
+	if err != nil {
+		t.Fatalf("Failed to open legacy fixture: %v", err)
+	}
+	defer file.Close()
+	config, err := ParseJSBSimConfig(file)
+	if err != nil {
+		t.Fatalf("Failed to parse legacy config: %v", err)
+	}
+
+	t.Run("Metrics And Mass", func(t *testing.T) {
+		assertEqual(t, config.Name, "c172-v1")
+		assertApproxEqual(t, config.Metrics.WingArea.Value, 174.0, 1e-12)
+		assertApproxEqual(t, config.Metrics.WingSpan.Value, 35.8, 1e-12)
+		assertApproxEqual(t, config.MassBalance.EmptyMass.Value, 1500, 1e-12)
+		assertApproxEqual(t, config.MassBalance.IYY.Value, 1346, 1e-12)
+		assertEqual(t, config.MassBalance.Location.X, 41.0)
+		assertEqual(t, len(config.MassBalance.PointMass), 1)
+		assertEqual(t, len(config.GroundReactions.Contact), 3)
+		assertEqual(t, config.GroundReactions.Contact[1].BrakeGroup, "LEFT")
+	})
+
+	t.Run("Aerodynamic Tables", func(t *testing.T) {
+		var lift *Axis
+		for _, axis := range config.Aerodynamics.Axis {
+			if axis.Name == "LIFT" {
+				lift = axis
+			}
+		}
+		if lift == nil || len(lift.Function) != 2 {
+			t.Fatalf("Expected a LIFT axis of two functions, got %v", lift)
+		}
+		clAlpha := lift.Function[0]
+		assertEqual(t, clAlpha.Name, "aero/coefficient/CLalpha")
+		assertEqual(t, functionProperties(clAlpha), []string{"aero/qbar-psf", "metrics/Sw-sqft", "aero/alpha-rad"})
+
+		// The table's rows are intact: 1.60 at its peak, and between rows
+		// it interpolates
+		properties := map[string]float64{"aero/qbar-psf": 10, "metrics/Sw-sqft": 174, "aero/alpha-rad": 0.23}
+		value, err := EvaluateFunction(clAlpha, properties)
+		if err != nil {
+			t.Fatalf("EvaluateFunction: %v", err)
+		}
+		assertApproxEqual(t, value, 1.60*10*174, 1e-9)
+		properties["aero/alpha-rad"] = 0.115
+		value, _ = EvaluateFunction(clAlpha, properties)
+		assertApproxEqual(t, value, (0.25+1.60)/2*10*174, 1e-9)
+
+		// A TABLE coefficient keeps its column header
+		cdde := config.Aerodynamics.Axis[1].Function[1]
+		properties["velocities/mach"] = 0.3
+		properties["fcs/elevator-pos-rad"] = -0.25
+		value, err = EvaluateFunction(cdde, properties)
+		if err != nil {
+			t.Fatalf("EvaluateFunction: %v", err)
+		}
+		assertApproxEqual(t, value, 0.005*10*174, 1e-9)
+	})
+
+	t.Run("Translation Notes", func(t *testing.T) {
+		notes := strings.Join(config.Translated, "\n")
+		for _, want := range []string{
+			"translated from the JSBSim 1.61 format",
+			"METRICS AC_WINGAREA translated",
+			"COEFFICIENT CDde translated",
+			"section PROPULSION not translated",
+		} {
+			if !strings.Contains(notes, want) {
+				t.Errorf("Expected a note %q in\n%s", want, notes)
+			}
+		}
+	})
+
+	t.Run("Property Names", func(t *testing.T) {
+		trim := config.FlightControl.Channel[0].Component[0]
+		assertEqual(t, trim.Input, []string{"fcs/elevator-cmd-norm", "fcs/pitch-trim-cmd-norm"})
+		elevator := config.FlightControl.Channel[0].Component[1]
+		assertEqual(t, elevator.Type, "aerosurface_scale")
+		assertEqual(t, elevator.Output, "fcs/elevator-pos-rad")
+		assertEqual(t, elevator.Range.Max, 0.40)
+
+		// An FG_ name with no current equivalent is kept, and noted
+		lt := &legacyTranslator{}
+		assertEqual(t, lt.property("FG_ALPHA"), "aero/alpha-rad")
+		assertEqual(t, lt.property("FG_SPOILERS_POS"), "FG_SPOILERS_POS")
+		assertEqual(t, len(lt.notes), 1)
+	})
+
+	t.Run("Detection", func(t *testing.T) {
+		cases := []struct {
+			name, xml string
+			legacy    bool
+		}{
+			{"Current", `<fdm_config version="2.0"><metrics/></fdm_config>`, false},
+			{"Current Without Version", `<fdm_config><metrics/></fdm_config>`, false},
+			{"Legacy Root", `<FDM_CONFIG VERSION="1.50"></FDM_CONFIG>`, true},
+			{"Legacy Sections", `<fdm_config version="1.61"><METRICS>AC_WINGAREA 100</METRICS></fdm_config>`, true},
+			{"Early Version, Current Sections", `<fdm_config version="1.99"><metrics/></fdm_config>`, false},
+		}
+		for _, c := range cases {
+			legacy, err := detectLegacyConfig([]byte(c.xml))
+			if err != nil {
+				t.Errorf("%s: %v", c.name, err)
+				continue
+			}
+			if legacy != c.legacy {
+				t.Errorf("%s: legacy %v, want %v", c.name, legacy, c.legacy)
+			}
+		}
+
+		_, err := ParseJSBSimConfig(strings.NewReader(`<aircraft name="x"><wing/></aircraft>`))
+		if err == nil || !strings.Contains(err.Error(), "unrecognized JSBSim version") {
+			t.Errorf("Expected an unrecognized version error, got %v", err)
+		}
+	})
+
+	t.Run("Current Format Untouched", func(t *testing.T) {
+		current := loadP51DConfig(t)
+		assertEqual(t, len(current.Translated), 0)
+	})
+}
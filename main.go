@@ -9,6 +9,37 @@ import (
 
 
 func main() {
+    if len(os.Args) > 1 && os.Args[1] == "validate" {
+        if err := runValidate(os.Args[2:], os.Stdout); err != nil {
+            fmt.Fprintln(os.Stderr, err)
+            os.Exit(1)
+        }
+        return
+    }
+    if len(os.Args) > 1 && os.Args[1] == "simulate" {
+        code, err := runSimulate(os.Args[2:], os.Stdout)
+        if err != nil {
+            fmt.Fprintln(os.Stderr, err)
+        }
+        os.Exit(code)
+    }
+    if len(os.Args) > 1 && os.Args[1] == "demo" {
+        if err := runDemo(os.Args[2:], os.Stdout); err != nil {
+            fmt.Fprintln(os.Stderr, err)
+            os.Exit(1)
+        }
+        return
+    }
+    if len(os.Args) > 1 && os.Args[1] == "repl" {
+        info, err := os.Stdin.Stat()
+        prompt := err == nil && info.Mode()&os.ModeCharDevice != 0
+        if err := runREPL(os.Args[2:], os.Stdin, os.Stdout, prompt); err != nil {
+            fmt.Fprintln(os.Stderr, err)
+            os.Exit(1)
+        }
+        return
+    }
+
     // Open JSBSim XML file
     file, err := os.Open("aircraft/p51d-jsbsim.xml")
     if err != nil {
@@ -91,9 +122,9 @@ func main() {
                 }
                 
                 // Check for table inside operations
-                if fn.Product != nil && fn.Product.Table != nil {
-                    fmt.Printf("    Found table in Product operation: %s\n", fn.Product.Table.Name)
-                    pt, err := ParseTable(fn.Product.Table)
+                if fn.Product != nil && len(fn.Product.Tables) > 0 {
+                    fmt.Printf("    Found table in Product operation: %s\n", fn.Product.Tables[0].Name)
+                    pt, err := ParseTable(fn.Product.Tables[0])
                     if err != nil {
                         fmt.Printf("    Error parsing product table: %v\n", err)
                         continue
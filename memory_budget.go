@@ -0,0 +1,134 @@
+// Memory Budget
+// Estimates the memory a long run holds on to and warns when it grows past
+// a budget
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"unsafe"
+)
+
+// MemoryRetainer is anything that keeps data from a run and can estimate
+// the bytes it holds
+type MemoryRetainer interface {
+	RetainedBytes() int64
+}
+
+// DefaultMemoryCheckInterval is the number of steps between estimates
+const DefaultMemoryCheckInterval = 1000
+
+// MemoryBudget estimates the memory retained by a run's statistics,
+// departure detector and any tracked retainers every CheckInterval steps,
+// and warns the first time the estimate exceeds Bytes, and again only after
+// it has fallen back within the budget. The estimate covers the data kept,
+// not the garbage of each step.
+type MemoryBudget struct {
+	Bytes         int64                // Budget (bytes)
+	CheckInterval int                  // Steps between estimates; DefaultMemoryCheckInterval when 0
+	Warn          func(message string) // Receives the warnings; they are logged when nil
+
+	Estimate int64 // Latest estimate (bytes)
+	Peak     int64 // Highest estimate (bytes)
+	Warnings int   // Warnings given
+
+	retainers []MemoryRetainer
+	steps     int
+	over      bool
+}
+
+// NewMemoryBudget creates a budget of the given bytes, tracking retainers
+// besides those of the engine it is set on
+func NewMemoryBudget(bytes int64, retainers ...MemoryRetainer) *MemoryBudget {
+	return &MemoryBudget{Bytes: bytes, retainers: retainers}
+}
+
+// Track adds retainers to the estimate, such as recorders attached to the
+// engine's event bus
+func (mb *MemoryBudget) Track(retainers ...MemoryRetainer) {
+	mb.retainers = append(mb.retainers, retainers...)
+}
+
+// step counts a step of an engine, ending at time t, with the given
+// statistics and detector, either of which may be nil, and estimates the
+// memory retained when a check is due
+func (mb *MemoryBudget) step(t float64, stats *FlightStatistics, departures *DepartureDetector) {
+	interval := mb.CheckInterval
+	if interval <= 0 {
+		interval = DefaultMemoryCheckInterval
+	}
+	mb.steps++
+	if mb.steps%interval != 0 {
+		return
+	}
+
+	var total int64
+	if stats != nil {
+		total += stats.RetainedBytes()
+	}
+	if departures != nil {
+		total += departures.RetainedBytes()
+	}
+	for _, r := range mb.retainers {
+		total += r.RetainedBytes()
+	}
+	mb.Estimate = total
+	mb.Peak = max(mb.Peak, total)
+
+	over := mb.Bytes > 0 && total > mb.Bytes
+	if over && !mb.over {
+		mb.Warnings++
+		message := fmt.Sprintf("WARNING: retained simulation data is about %s at t=%.1fs, over the memory budget of %s",
+			formatBytes(total), t, formatBytes(mb.Bytes))
+		if mb.Warn != nil {
+			mb.Warn(message)
+		} else {
+			log.Print(message)
+		}
+	}
+	mb.over = over
+}
+
+// formatBytes gives a byte count in binary units
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// RetainedBytes estimates the memory held by the statistics' lists
+func (stats *FlightStatistics) RetainedBytes() int64 {
+	size := int64(unsafe.Sizeof(*stats))
+	size += int64(cap(stats.Exceedances)) * int64(unsafe.Sizeof(LimitExceedance{}))
+	size += int64(len(stats.MaxPsByAltitude)) * 2 * int64(unsafe.Sizeof(float64(0)))
+	size += int64(len(stats.Landings)) * int64(unsafe.Sizeof(LandingReport{}))
+	for _, departure := range stats.Departures {
+		size += departure.retainedBytes()
+	}
+	return size
+}
+
+// RetainedBytes estimates the memory held by the detector's history and
+// departures
+func (d *DepartureDetector) RetainedBytes() int64 {
+	size := int64(cap(d.history)) * int64(unsafe.Sizeof(DepartureSample{}))
+	if d.Statistics == nil {
+		for _, departure := range d.Departures {
+			size += departure.retainedBytes()
+		}
+	}
+	return size
+}
+
+// retainedBytes estimates the memory held by a departure and its history
+func (d *Departure) retainedBytes() int64 {
+	return int64(unsafe.Sizeof(*d)) + int64(cap(d.History))*int64(unsafe.Sizeof(DepartureSample{}))
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// soakScale is the fraction of a full soak run to simulate: 1 unless set by
+// CAMSIM_SOAK_SCALE, and a twentieth in short mode
+func soakScale(t *testing.T) float64 {
+	t.Helper()
+	if s := os.Getenv("CAMSIM_SOAK_SCALE"); s != "" {
+		scale, err := strconv.ParseFloat(s, 64)
+		if err != nil || scale <= 0 {
+			t.Fatalf("Invalid CAMSIM_SOAK_SCALE %q", s)
+		}
+		return scale
+	}
+	if testing.Short() {
+		return 0.05
+	}
+	return 1
+}
+
+// heapInUse returns the live heap after a collection
+func heapInUse() uint64 {
+	runtime.GC()
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return m.HeapAlloc
+}
+
+func TestMemoryBudget(t *testing.T) {
+	t.Run("Exceedance Cap", func(t *testing.T) {
+		limits := &StructuralLimits{Vne: 100}
+		stats := &FlightStatistics{MaxExceedances: 3}
+		state := NewAircraftState()
+		for i := 0; i < 10; i++ {
+			state.Time = float64(i)
+			state.CalibratedAirspeed = 110
+			stats.checkLimits(limits, nil, state)
+			state.CalibratedAirspeed = 90
+			stats.checkLimits(limits, nil, state)
+		}
+		assertEqual(t, len(stats.Exceedances), 3)
+		assertEqual(t, stats.DroppedExceedances, 7)
+		assertEqual(t, stats.Exceedances[2].Time, 2.0)
+	})
+
+	t.Run("Warns Over Budget", func(t *testing.T) {
+		engine := NewSimplifiedFlightDynamicsEngine(NewEulerIntegrator())
+		state := trimLevelFlight(t, engine, 1000, 100)
+		fr := NewFlightRecorder(0)
+		fr.Attach(engine.Events)
+
+		var warnings []string
+		perSample := NewFlightRecorder(1)
+		recordStates(t, perSample, 1)
+		engine.MemoryBudget = NewMemoryBudget(50*perSample.RetainedBytes(), fr)
+		engine.MemoryBudget.CheckInterval = 10
+		engine.MemoryBudget.Warn = func(message string) { warnings = append(warnings, message) }
+
+		for i := 0; i < 200; i++ {
+			next, err := engine.Step(state, 0.01)
+			if err != nil {
+				t.Fatalf("Step %d: %v", i, err)
+			}
+			state = next
+		}
+		if len(warnings) != 1 || engine.MemoryBudget.Warnings != 1 {
+			t.Fatalf("Expected one warning for the unbounded recorder, got %q", warnings)
+		}
+		if !strings.Contains(warnings[0], "over the memory budget") {
+			t.Errorf("Unexpected warning %q", warnings[0])
+		}
+		if engine.MemoryBudget.Peak < fr.RetainedBytes() {
+			t.Errorf("Peak estimate %d is below the recorder's %d bytes", engine.MemoryBudget.Peak, fr.RetainedBytes())
+		}
+
+		// Bounding the recorder brings the run back within the budget
+		fr.Reset()
+		fr.MaxSamples = 20
+		for i := 0; i < 200; i++ {
+			next, _ := engine.Step(state, 0.01)
+			state = next
+		}
+		if engine.MemoryBudget.Estimate > engine.MemoryBudget.Bytes {
+			t.Errorf("Estimate %d still over the budget of %d", engine.MemoryBudget.Estimate, engine.MemoryBudget.Bytes)
+		}
+		assertEqual(t, engine.MemoryBudget.Warnings, 1)
+	})
+
+	t.Run("Soak", func(t *testing.T) {
+		// A million 100 Hz steps, nearly three hours of flight, with the
+		// recorder, exceedances and budget bounded. The live heap must not
+		// grow by more than the bounded buffers hold. The open-loop model
+		// diverges from trim over minutes, so it is put back on trim every
+		// 10 s of the run.
+		steps := int(1e6 * soakScale(t))
+		engine := NewSimplifiedFlightDynamicsEngine(NewEulerIntegrator())
+		trim := trimLevelFlight(t, engine, 1000, 100)
+		state := trim.Copy()
+		engine.Statistics.MaxExceedances = 100
+		engine.Limits = &StructuralLimits{NzMax: &Table1D{Indices: []float64{0}, Values: []float64{1}}}
+		engine.Departures = NewDepartureDetector(engine.Statistics)
+
+		fr := NewFlightRecorder(1000)
+		fr.Decimation = 2
+		fr.Attach(engine.Events)
+		engine.MemoryBudget = NewMemoryBudget(4<<20, fr)
+		engine.MemoryBudget.Warn = func(message string) { t.Error(message) }
+
+		before := heapInUse()
+		for i := 0; i < steps; i++ {
+			if i%1000 == 0 {
+				time := state.Time
+				state = trim.Copy()
+				state.Time = time
+			}
+			next, err := engine.Step(state, 0.01)
+			if err != nil {
+				t.Fatalf("Step %d: %v", i, err)
+			}
+			state = next
+		}
+		growth := int64(heapInUse()) - int64(before)
+		t.Logf("%d steps: heap grew %s, estimated %s retained, %d samples", steps,
+			formatBytes(growth), formatBytes(engine.MemoryBudget.Peak), fr.Len())
+
+		const bound = 4 << 20
+		if growth > bound {
+			t.Errorf("Heap grew %s over %d steps, more than %s", formatBytes(growth), steps, formatBytes(bound))
+		}
+		if fr.Len() > fr.MaxSamples {
+			t.Errorf("Recorder holds %d samples, over its %d", fr.Len(), fr.MaxSamples)
+		}
+		if stats := engine.Statistics; len(stats.Exceedances) > stats.MaxExceedances {
+			t.Errorf("%d exceedances kept, over the cap of %d", len(stats.Exceedances), stats.MaxExceedances)
+		}
+	})
+}
@@ -0,0 +1,459 @@
+// Model Documentation
+// Renders an aircraft's aerodynamic model as a readable reference: each
+// function as an expression, the tables it looks up with their ranges and
+// the properties it reads, in plain text or Markdown
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// GenerateModelDocumentation writes the documentation of a configuration's
+// aerodynamic model to w in format "text" or "markdown". The metrics, mass
+// summary and validation warnings come first, then the standalone
+// functions and each axis with the functions it sums. Measurements are
+// given in the units the configuration declares.
+func GenerateModelDocumentation(config *JSBSimConfig, w io.Writer, format string) error {
+	return generateModelDocumentation(config, w, format, nil)
+}
+
+// GenerateModelDocumentationInUnits writes the documentation as
+// GenerateModelDocumentation does, with the metrics and mass summary
+// converted to the given units
+func GenerateModelDocumentationInUnits(config *JSBSimConfig, w io.Writer, format string, units UnitPreferences) error {
+	return generateModelDocumentation(config, w, format, &units)
+}
+
+func generateModelDocumentation(config *JSBSimConfig, w io.Writer, format string, units *UnitPreferences) error {
+	if config == nil {
+		return fmt.Errorf("no configuration")
+	}
+	doc := modelDoc{units: units}
+	switch format {
+	case "text":
+	case "markdown":
+		doc.markdown = true
+	default:
+		return fmt.Errorf("unknown documentation format %q, want text or markdown", format)
+	}
+
+	name := config.Name
+	if name == "" {
+		name = "Aircraft"
+	}
+	doc.heading(1, name+" Aerodynamic Model")
+	doc.summary(config)
+	doc.warnings(config)
+
+	if aero := config.Aerodynamics; aero != nil {
+		if len(aero.Function) > 0 {
+			doc.heading(2, "Functions")
+			doc.paragraph("Evaluated before the axes, for the axes to read.")
+			for _, f := range aero.Function {
+				doc.function(f)
+			}
+		}
+		for _, axis := range aero.Axis {
+			doc.heading(2, axis.Name+" Axis")
+			names := make([]string, len(axis.Function))
+			for i, f := range axis.Function {
+				names[i] = f.Name
+			}
+			doc.expression(axis.Name + " = " + strings.Join(names, " + "))
+			for _, f := range axis.Function {
+				doc.function(f)
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, strings.TrimRight(doc.String(), "\n")+"\n")
+	return err
+}
+
+// modelDoc builds the documentation in one of the two formats
+type modelDoc struct {
+	strings.Builder
+	markdown bool
+	units    *UnitPreferences // Units of the summary; as declared when nil
+}
+
+// heading starts a section; level 1 is the title
+func (doc *modelDoc) heading(level int, title string) {
+	if doc.markdown {
+		fmt.Fprintf(doc, "%s %s\n\n", strings.Repeat("#", level), title)
+		return
+	}
+	switch level {
+	case 1:
+		fmt.Fprintf(doc, "%s\n%s\n\n", title, strings.Repeat("=", len(title)))
+	case 2:
+		fmt.Fprintf(doc, "%s\n%s\n\n", title, strings.Repeat("-", len(title)))
+	default:
+		fmt.Fprintf(doc, "%s\n", title)
+	}
+}
+
+func (doc *modelDoc) paragraph(text string) {
+	fmt.Fprintf(doc, "%s\n\n", text)
+}
+
+// code marks a name or expression as code in Markdown
+func (doc *modelDoc) code(s string) string {
+	if doc.markdown {
+		return "`" + s + "`"
+	}
+	return s
+}
+
+// items writes a list, indented under a function heading in plain text
+func (doc *modelDoc) items(items []string) {
+	for _, item := range items {
+		if doc.markdown {
+			fmt.Fprintf(doc, "- %s\n", item)
+		} else {
+			fmt.Fprintf(doc, "  %s\n", item)
+		}
+	}
+	doc.WriteString("\n")
+}
+
+func (doc *modelDoc) expression(expr string) {
+	doc.paragraph(doc.code(expr))
+}
+
+// summary writes the reference dimensions and the mass properties
+func (doc *modelDoc) summary(config *JSBSimConfig) {
+	measure := func(label string, m *Measurement, q Quantity) string {
+		if m == nil {
+			return label + ": not given"
+		}
+		if s, ok := doc.inUnits(m.Value, m.Unit, q); ok {
+			return label + ": " + s
+		}
+		return strings.TrimSpace(fmt.Sprintf("%s: %s %s", label, formatDocNumber(m.Value), m.Unit))
+	}
+
+	doc.heading(2, "Metrics")
+	var metrics Metrics
+	if config.Metrics != nil {
+		metrics = *config.Metrics
+	}
+	items := []string{
+		measure("Wing area", metrics.WingArea, QuantityArea),
+		measure("Wing span", metrics.WingSpan, QuantityLength),
+		measure("Chord", metrics.Chord, QuantityLength),
+	}
+	for _, optional := range []struct {
+		label string
+		m     *Measurement
+		q     Quantity
+	}{
+		{"Wing incidence", metrics.WingIncidence, QuantityAngle},
+		{"Horizontal tail area", metrics.HTailArea, QuantityArea},
+		{"Horizontal tail arm", metrics.HTailArm, QuantityLength},
+		{"Vertical tail area", metrics.VTailArea, QuantityArea},
+		{"Vertical tail arm", metrics.VTailArm, QuantityLength},
+	} {
+		if optional.m != nil {
+			items = append(items, measure(optional.label, optional.m, optional.q))
+		}
+	}
+	for _, location := range metrics.Location {
+		items = append(items, doc.location(location.Name, location))
+	}
+	doc.items(items)
+
+	doc.heading(2, "Mass")
+	var mass MassBalance
+	if config.MassBalance != nil {
+		mass = *config.MassBalance
+	}
+	items = []string{
+		measure("Empty weight", mass.EmptyMass, QuantityMass),
+		measure("Ixx", mass.IXX, QuantityInertia),
+		measure("Iyy", mass.IYY, QuantityInertia),
+		measure("Izz", mass.IZZ, QuantityInertia),
+	}
+	if mass.IXZ != nil {
+		items = append(items, measure("Ixz", mass.IXZ, QuantityInertia))
+	}
+	if mass.Location != nil {
+		items = append(items, doc.location("CG", mass.Location))
+	}
+	for _, point := range mass.PointMass {
+		items = append(items, measure("Point mass "+point.Name, point.Mass, QuantityMass))
+	}
+	doc.items(items)
+}
+
+// docQuantities are the parser's unit types and canonical units of the
+// quantities the summary converts
+var docQuantities = map[Quantity]struct{ unitType, canonical string }{
+	QuantityLength:  {"length", "ft"},
+	QuantityArea:    {"area", "ft2"},
+	QuantityMass:    {"mass", "lb"},
+	QuantityInertia: {"inertia", "slug-ft2"},
+	QuantityAngle:   {"angle", "rad"},
+}
+
+// inUnits formats a measurement in the document's units, to six
+// significant figures; false when the document keeps declared units or
+// the unit is not known
+func (doc *modelDoc) inUnits(value float64, unit string, q Quantity) (string, bool) {
+	kind, ok := docQuantities[q]
+	if doc.units == nil || !ok {
+		return "", false
+	}
+	canonical, err := convertToStandardUnit(value, unit, kind.unitType)
+	if err != nil {
+		return "", false
+	}
+	from, _ := LookupUnit(q, kind.canonical)
+	return doc.units.format(q, canonical/from.PerSI, func(v float64) string {
+		return strconv.FormatFloat(v, 'g', 6, 64)
+	}), true
+}
+
+// location formats a named position, in the document's units when it has
+// them
+func (doc *modelDoc) location(label string, l *Location) string {
+	x, okX := doc.inUnits(l.X, l.Unit, QuantityLength)
+	y, okY := doc.inUnits(l.Y, l.Unit, QuantityLength)
+	z, okZ := doc.inUnits(l.Z, l.Unit, QuantityLength)
+	if okX && okY && okZ {
+		return fmt.Sprintf("%s: (%s, %s, %s)", label, x, y, z)
+	}
+	return formatDocLocation(label, l)
+}
+
+// formatDocLocation formats a named position as label: (x, y, z) unit
+func formatDocLocation(label string, l *Location) string {
+	return strings.TrimSpace(fmt.Sprintf("%s: (%s, %s, %s) %s", label,
+		formatDocNumber(l.X), formatDocNumber(l.Y), formatDocNumber(l.Z), l.Unit))
+}
+
+// warnings writes the parser's warnings and the problems ValidateConfig
+// finds
+func (doc *modelDoc) warnings(config *JSBSimConfig) {
+	doc.heading(2, "Validation")
+	warnings := slices.Clone(config.Warnings)
+	var invalid *ConfigValidationError
+	if err := ValidateConfig(config); errors.As(err, &invalid) {
+		warnings = append(warnings, invalid.Problems...)
+	}
+	if len(warnings) == 0 {
+		doc.paragraph("No warnings.")
+		return
+	}
+	doc.items(warnings)
+}
+
+// function writes a function's expression, tables and inputs
+func (doc *modelDoc) function(f *Function) {
+	if doc.markdown {
+		doc.heading(3, doc.code(f.Name))
+	} else {
+		doc.heading(3, f.Name)
+	}
+
+	var lines []string
+	if description := strings.TrimSpace(f.Description); description != "" {
+		lines = append(lines, strings.ReplaceAll(description, "_", " "))
+	}
+	if f.Unit != "" {
+		lines = append(lines, "Unit: "+f.Unit)
+	}
+	if f.Source.Known() {
+		lines = append(lines, "Defined at "+doc.code(f.Source.String()))
+	}
+
+	renderer := &expressionRenderer{}
+	expr := renderer.function(f)
+	if doc.markdown {
+		lines = append(lines, "Expression: "+doc.code(expr))
+	} else {
+		lines = append(lines, "= "+expr)
+	}
+	for i, t := range renderer.tables {
+		lines = append(lines, doc.table(renderer.label(i), t))
+	}
+	var reads []string
+	for _, property := range functionProperties(f) {
+		if !slices.Contains(reads, doc.code(property)) {
+			reads = append(reads, doc.code(property))
+		}
+	}
+	if len(reads) > 0 {
+		lines = append(lines, "Reads "+strings.Join(reads, ", "))
+	}
+	doc.items(lines)
+}
+
+// table describes a table: where it was defined and the range of each of
+// its inputs. A table stored collapsed is described as declared.
+func (doc *modelDoc) table(label string, t *Table) string {
+	line := "Table " + label
+	if t.Source.Known() {
+		line += " at " + doc.code(t.Source.String())
+	}
+	pt, err := cachedParseTable(t)
+	if err != nil {
+		return line + ": " + err.Error()
+	}
+	if pt.Collapsed != nil {
+		pt = pt.expanded()
+	}
+
+	var axes [][]float64
+	switch {
+	case pt.Data1D != nil:
+		axes = [][]float64{pt.Data1D.Indices}
+	case pt.Data2D != nil:
+		axes = [][]float64{pt.Data2D.RowIndices, pt.Data2D.ColIndices}
+	case len(pt.Data3D) > 0:
+		breakpoints := make([]float64, len(pt.Data3D))
+		for i, t := range pt.Data3D {
+			breakpoints[i] = t.Breakpoint
+		}
+		axes = [][]float64{pt.Data3D[0].RowIndices, pt.Data3D[0].ColIndices, breakpoints}
+	}
+	var inputs []string
+	for i, variable := range pt.IndependentVars {
+		input := doc.code(variable)
+		if i < len(axes) && len(axes[i]) > 0 {
+			input += fmt.Sprintf(" %s to %s in %d points", formatDocNumber(slices.Min(axes[i])),
+				formatDocNumber(slices.Max(axes[i])), len(axes[i]))
+		}
+		inputs = append(inputs, input)
+	}
+	return line + ": " + strings.Join(inputs, ", ")
+}
+
+// formatDocNumber formats a value in its shortest exact form
+func formatDocNumber(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// Precedence of a rendered expression, loosest first, deciding where it
+// needs parentheses as an operand
+const (
+	precedenceSum = iota + 1
+	precedenceProduct
+	precedencePower
+	precedenceAtom
+)
+
+// expressionRenderer writes a function's operation tree in infix form,
+// naming its tables T1, T2 and so on in document order
+type expressionRenderer struct {
+	tables []*Table
+}
+
+// label names a table by its name attribute, or by its position
+func (r *expressionRenderer) label(i int) string {
+	if name := strings.TrimSpace(r.tables[i].Name); name != "" {
+		return name
+	}
+	return "T" + strconv.Itoa(i+1)
+}
+
+// function renders the body a function evaluates
+func (r *expressionRenderer) function(f *Function) string {
+	if f.Table != nil {
+		return r.table(f.Table)
+	}
+	operations := functionOperations(f)
+	if len(operations) == 0 {
+		return "(empty)"
+	}
+	expr, _ := r.operation(operations[0].name, operations[0].op)
+	return expr
+}
+
+// table renders a lookup as the table's label applied to its inputs
+func (r *expressionRenderer) table(t *Table) string {
+	r.tables = append(r.tables, t)
+	inputs := make([]string, len(t.IndependentVar))
+	for i, iv := range t.IndependentVar {
+		inputs[i] = strings.TrimSpace(iv.Value)
+	}
+	return r.label(len(r.tables)-1) + "(" + strings.Join(inputs, ", ") + ")"
+}
+
+// operation renders an operation and returns its precedence
+func (r *expressionRenderer) operation(name string, op *Operation) (string, int) {
+	operands := op.operandList()
+	render := func(min func(i int) int) []string {
+		parts := make([]string, len(operands))
+		for i, operand := range operands {
+			parts[i] = r.operand(op, operand, min(i))
+		}
+		return parts
+	}
+	all := func(precedence int) func(int) int {
+		return func(int) int { return precedence }
+	}
+	// after gives the first operand one precedence and the rest another
+	after := func(first, rest int) func(int) int {
+		return func(i int) int {
+			if i == 0 {
+				return first
+			}
+			return rest
+		}
+	}
+
+	switch {
+	case len(operands) == 0:
+		return name + "()", precedenceAtom
+	case len(operands) == 1 && (name == "product" || name == "sum"):
+		return r.operandWithPrecedence(op, operands[0])
+	}
+	switch name {
+	case "product":
+		return strings.Join(render(all(precedenceProduct)), " · "), precedenceProduct
+	case "sum":
+		return strings.Join(render(all(precedenceSum)), " + "), precedenceSum
+	case "difference":
+		return strings.Join(render(after(precedenceSum, precedenceProduct)), " − "), precedenceSum
+	case "quotient":
+		return strings.Join(render(after(precedenceProduct, precedencePower)), " / "), precedenceProduct
+	case "pow":
+		return strings.Join(render(all(precedenceAtom)), "^"), precedencePower
+	case "abs":
+		return "|" + strings.Join(render(all(precedenceSum)), ", ") + "|", precedenceAtom
+	}
+	return name + "(" + strings.Join(render(all(precedenceSum)), ", ") + ")", precedenceAtom
+}
+
+// operand renders an operand, parenthesised when it binds more loosely
+// than min
+func (r *expressionRenderer) operand(op *Operation, operand operand, min int) string {
+	expr, precedence := r.operandWithPrecedence(op, operand)
+	if precedence < min {
+		return "(" + expr + ")"
+	}
+	return expr
+}
+
+func (r *expressionRenderer) operandWithPrecedence(op *Operation, operand operand) (string, int) {
+	switch operand.kind {
+	case operandProperty:
+		return strings.TrimSpace(op.Property[operand.index]), precedenceAtom
+	case operandValue:
+		value := op.Value[operand.index]
+		if value < 0 {
+			return formatDocNumber(value), precedenceSum
+		}
+		return formatDocNumber(value), precedenceAtom
+	case operandTable:
+		return r.table(op.Tables[operand.index]), precedenceAtom
+	}
+	return r.operation(operand.nested.name, operand.nested.op)
+}
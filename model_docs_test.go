@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestModelDocumentation(t *testing.T) {
+	xml, err := os.ReadFile("aircraft/p51d-jsbsim.xml")
+	if err != nil {
+		t.Fatalf("Failed to read P-51D XML: %v", err)
+	}
+	config, err := ParseJSBSimConfig(bytes.NewReader(xml), SourceFile("p51d-jsbsim.xml"))
+	if err != nil {
+		t.Fatalf("Failed to parse P-51D config: %v", err)
+	}
+	generate := func(format string) string {
+		t.Helper()
+		var out strings.Builder
+		if err := GenerateModelDocumentation(config, &out, format); err != nil {
+			t.Fatalf("GenerateModelDocumentation: %v", err)
+		}
+		return out.String()
+	}
+
+	for _, golden := range []struct{ format, file string }{
+		{"text", "p51d.txt"},
+		{"markdown", "p51d.md"},
+	} {
+		t.Run(golden.format, func(t *testing.T) {
+			doc := generate(golden.format)
+			path := filepath.Join("testdata", "docs", golden.file)
+			if *updateGoldens {
+				if err := os.WriteFile(path, []byte(doc), 0o644); err != nil {
+					t.Fatalf("Writing %s: %v", path, err)
+				}
+				t.Logf("Wrote %s", path)
+				return
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("%v; run with -update to create it", err)
+			}
+			got, expected := strings.Split(doc, "\n"), strings.Split(string(want), "\n")
+			for i := range min(len(got), len(expected)) {
+				if got[i] != expected[i] {
+					t.Fatalf("%s line %d differs; if that is intended, run with -update\ngot:  %s\nwant: %s",
+						path, i+1, got[i], expected[i])
+				}
+			}
+			assertEqual(t, len(got), len(expected))
+		})
+	}
+
+	t.Run("Lift Functions", func(t *testing.T) {
+		// The names are taken from the XML itself, not the parsed model
+		lift := regexp.MustCompile(`(?s)<axis name="LIFT">(.*?)</axis>`).FindSubmatch([]byte(stripXMLComments(string(xml))))
+		if lift == nil {
+			t.Fatal("No LIFT axis in the P-51D XML")
+		}
+		names := regexp.MustCompile(`<function name="([^"]+)"`).FindAllSubmatch(lift[1], -1)
+		if len(names) == 0 {
+			t.Fatal("No functions in the LIFT axis")
+		}
+
+		doc := generate("text")
+		start := strings.Index(doc, "LIFT Axis\n")
+		if start < 0 {
+			t.Fatalf("No LIFT section:\n%s", doc)
+		}
+		section := doc[start+len("LIFT Axis\n"):]
+		if end := strings.Index(section, " Axis\n"); end >= 0 {
+			section = section[:end]
+		}
+		for _, name := range names {
+			if !strings.Contains(section, string(name[1])) {
+				t.Errorf("The LIFT section does not mention %s", name[1])
+			}
+		}
+		if !strings.Contains(section, "aero/qbar-psf · metrics/Sw-sqft · aero/function/kCLge · 0.92 · T1(aero/alpha-deg, aero/Re)") {
+			t.Errorf("CLalpha should read as its product:\n%s", section)
+		}
+	})
+
+	t.Run("Units", func(t *testing.T) {
+		var imperial, si strings.Builder
+		if err := GenerateModelDocumentationInUnits(config, &imperial, "text", ImperialAviationUnits()); err != nil {
+			t.Fatalf("GenerateModelDocumentationInUnits: %v", err)
+		}
+		if err := GenerateModelDocumentationInUnits(config, &si, "text", SIUnits()); err != nil {
+			t.Fatalf("GenerateModelDocumentationInUnits: %v", err)
+		}
+		for _, want := range []string{"Wing area: 235 ft²", "Empty weight: 7125 lb"} {
+			if !strings.Contains(imperial.String(), want) {
+				t.Errorf("The imperial summary should read %q", want)
+			}
+		}
+		for _, want := range []string{
+			"Wing area: " + strconv.FormatFloat(235/M2_TO_FT2, 'g', 6, 64) + " m²",
+			"Empty weight: " + strconv.FormatFloat(7125/KG_TO_LB, 'g', 6, 64) + " kg",
+		} {
+			if !strings.Contains(si.String(), want) {
+				t.Errorf("The SI summary should read %q", want)
+			}
+		}
+	})
+
+	t.Run("Format", func(t *testing.T) {
+		if err := GenerateModelDocumentation(config, &strings.Builder{}, "html"); err == nil {
+			t.Error("An unknown format should be refused")
+		}
+	})
+}
@@ -0,0 +1,547 @@
+// Monte Carlo Batch Runner
+// Runs a scenario many times with dispersed parameters and aggregates the results
+
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"runtime"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// Distribution draws dispersed parameter values from a random stream
+type Distribution interface {
+	Sample(rng *rand.Rand) float64
+}
+
+// UniformDistribution samples uniformly in [Min, Max)
+type UniformDistribution struct {
+	Min, Max float64
+}
+
+// Sample draws a uniform value
+func (d UniformDistribution) Sample(rng *rand.Rand) float64 {
+	return d.Min + rng.Float64()*(d.Max-d.Min)
+}
+
+// NormalDistribution samples from a Gaussian
+type NormalDistribution struct {
+	Mean, StdDev float64
+}
+
+// Sample draws a normally distributed value
+func (d NormalDistribution) Sample(rng *rand.Rand) float64 {
+	return d.Mean + rng.NormFloat64()*d.StdDev
+}
+
+// ConstantDistribution always returns the same value (useful for sweeps and debugging)
+type ConstantDistribution struct {
+	Value float64
+}
+
+// Sample returns the constant value
+func (d ConstantDistribution) Sample(rng *rand.Rand) float64 {
+	return d.Value
+}
+
+// DispersionTarget identifies what a dispersion modifies
+type DispersionTarget int
+
+const (
+	DispersionConfig           DispersionTarget = iota // Mutates the per-run JSBSimConfig
+	DispersionInitialCondition                         // Offsets the per-run initial AircraftState
+	DispersionEngineOption                             // Passed to the engine factory via MonteCarloCase.Options
+)
+
+// String returns the target name
+func (t DispersionTarget) String() string {
+	switch t {
+	case DispersionConfig:
+		return "config"
+	case DispersionInitialCondition:
+		return "initial_condition"
+	case DispersionEngineOption:
+		return "engine_option"
+	}
+	return "unknown"
+}
+
+// Dispersion describes one randomly varied parameter
+type Dispersion struct {
+	Name         string
+	Distribution Distribution
+	Target       DispersionTarget
+
+	// Apply writes the sampled value into the case. It is required for config and
+	// initial-condition targets; engine options default to Options[Name] = value.
+	Apply func(c *MonteCarloCase, value float64)
+}
+
+// MonteCarloCase is the per-run context handed to dispersions and the scenario
+type MonteCarloCase struct {
+	Index   int
+	Seed    int64
+	Rand    *rand.Rand // Independent stream for this run (sensor noise, turbulence, ...)
+	Config  *JSBSimConfig
+	State   *AircraftState
+	Options map[string]float64
+	Values  map[string]float64 // Sampled value of every dispersion
+	Runway  *RunwayContext     // The scenario's runway, nil without one
+}
+
+// MonteCarloEngine is anything that can advance an aircraft state by one step
+type MonteCarloEngine interface {
+	Step(state *AircraftState, dt float64) (*AircraftState, error)
+}
+
+// MonteCarloExtractor reduces a finished run to named metrics
+type MonteCarloExtractor func(*AircraftState, *FlightStatistics) map[string]float64
+
+// MonteCarloScenario defines the simulation repeated for every run
+type MonteCarloScenario struct {
+	Duration float64 // Simulated seconds per run
+	Dt       float64 // Integration step
+
+	// NewConfig returns a fresh configuration for each run so config dispersions
+	// never leak between runs. Optional when the engine does not need a config.
+	NewConfig func() (*JSBSimConfig, error)
+
+	// InitialState returns the undispersed initial state
+	InitialState func() *AircraftState
+
+	// NewEngine builds the engine for a run after all dispersions are applied
+	NewEngine func(c *MonteCarloCase) (MonteCarloEngine, *FlightStatistics, error)
+
+	// Engine, used when NewEngine is nil, is shared by the runs, each
+	// stepping a session of it, so the aircraft is loaded once. Its
+	// configuration cannot then be dispersed per run.
+	Engine *FlightDynamicsEngine
+
+	// Controls is called before every step to update pilot inputs (optional)
+	Controls func(c *MonteCarloCase, state *AircraftState)
+
+	// WarmStart is the run-in given to an engine that is a WarmStarter
+	// before the clock starts (s): DefaultWarmStartDuration when zero, none
+	// when negative
+	WarmStart float64
+
+	// Runway, when set, is the runway of a takeoff or landing scenario.
+	// Each run's landings are analyzed against it into its statistics, and
+	// the runway properties of the final state join the run's metrics.
+	Runway *RunwayContext
+}
+
+// MonteCarlo runs a scenario N times with dispersed parameters
+type MonteCarlo struct {
+	Scenario    MonteCarloScenario
+	Dispersions []*Dispersion
+	Extractor   MonteCarloExtractor
+	Runs        int
+	Seed        int64     // Master seed; the same seed reproduces the same runs
+	Workers     int       // Worker pool size, defaults to runtime.NumCPU()
+	Percentiles []float64 // Percentiles reported per metric, defaults to 5/50/95
+}
+
+// MonteCarloRunResult holds the outcome of one run
+type MonteCarloRunResult struct {
+	Index       int
+	Seed        int64
+	Dispersions map[string]float64
+	Metrics     map[string]float64
+	Err         error
+}
+
+// MetricSummary aggregates one metric across all successful runs
+type MetricSummary struct {
+	Count       int
+	Mean        float64
+	StdDev      float64
+	Min         float64
+	Max         float64
+	Percentiles map[float64]float64
+}
+
+// MonteCarloResult is the output of a batch
+type MonteCarloResult struct {
+	Runs     []*MonteCarloRunResult
+	Summary  map[string]*MetricSummary
+	Workers  int
+	Failures int
+}
+
+// NewMonteCarlo creates a batch runner with default worker count and percentiles
+func NewMonteCarlo(scenario MonteCarloScenario, extractor MonteCarloExtractor, runs int, seed int64) *MonteCarlo {
+	return &MonteCarlo{
+		Scenario:    scenario,
+		Extractor:   extractor,
+		Runs:        runs,
+		Seed:        seed,
+		Workers:     runtime.NumCPU(),
+		Percentiles: []float64{5, 50, 95},
+	}
+}
+
+// AddDispersion registers a dispersed parameter
+func (mc *MonteCarlo) AddDispersion(d *Dispersion) {
+	mc.Dispersions = append(mc.Dispersions, d)
+}
+
+// Run executes all cases across the worker pool and summarizes the results
+func (mc *MonteCarlo) Run() (*MonteCarloResult, error) {
+	if mc.Runs <= 0 {
+		return nil, fmt.Errorf("monte carlo requires at least one run, got %d", mc.Runs)
+	}
+	if mc.Scenario.InitialState == nil || (mc.Scenario.NewEngine == nil && mc.Scenario.Engine == nil) {
+		return nil, fmt.Errorf("monte carlo scenario requires InitialState and NewEngine or Engine")
+	}
+	if mc.Scenario.Dt <= 0 {
+		return nil, fmt.Errorf("monte carlo scenario requires a positive time step, got %f", mc.Scenario.Dt)
+	}
+	if mc.Extractor == nil {
+		return nil, fmt.Errorf("monte carlo requires a result extractor")
+	}
+	for _, d := range mc.Dispersions {
+		if d.Distribution == nil {
+			return nil, fmt.Errorf("dispersion %q has no distribution", d.Name)
+		}
+		if d.Apply == nil && d.Target != DispersionEngineOption {
+			return nil, fmt.Errorf("dispersion %q targets %s but has no Apply function", d.Name, d.Target)
+		}
+		if mc.Scenario.NewEngine == nil && d.Target != DispersionInitialCondition {
+			return nil, fmt.Errorf("dispersion %q targets %s, which a shared engine cannot disperse", d.Name, d.Target)
+		}
+	}
+
+	workers := mc.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > mc.Runs {
+		workers = mc.Runs
+	}
+
+	// Per-run seeds come from the master stream up front so results do not
+	// depend on which worker picks up which run
+	master := rand.New(rand.NewSource(mc.Seed))
+	seeds := make([]int64, mc.Runs)
+	for i := range seeds {
+		seeds[i] = master.Int63()
+	}
+
+	result := &MonteCarloResult{
+		Runs:    make([]*MonteCarloRunResult, mc.Runs),
+		Workers: workers,
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				result.Runs[i] = mc.runCase(i, seeds[i])
+			}
+		}()
+	}
+	for i := 0; i < mc.Runs; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, run := range result.Runs {
+		if run.Err != nil {
+			result.Failures++
+		}
+	}
+	result.Summary = summarizeRuns(result.Runs, mc.Percentiles)
+
+	return result, nil
+}
+
+// runCase performs a single dispersed run
+func (mc *MonteCarlo) runCase(index int, seed int64) *MonteCarloRunResult {
+	run := &MonteCarloRunResult{
+		Index:       index,
+		Seed:        seed,
+		Dispersions: make(map[string]float64, len(mc.Dispersions)),
+	}
+
+	c := &MonteCarloCase{
+		Index:   index,
+		Seed:    seed,
+		Rand:    rand.New(rand.NewSource(seed)),
+		Options: make(map[string]float64),
+		Values:  run.Dispersions,
+		Runway:  mc.Scenario.Runway,
+	}
+
+	// Sample every dispersion before building anything so the draw order is fixed
+	for _, d := range mc.Dispersions {
+		c.Values[d.Name] = d.Distribution.Sample(c.Rand)
+	}
+
+	if mc.Scenario.NewConfig != nil {
+		config, err := mc.Scenario.NewConfig()
+		if err != nil {
+			run.Err = fmt.Errorf("run %d: failed to create config: %w", index, err)
+			return run
+		}
+		c.Config = config
+	}
+	mc.applyDispersions(c, DispersionConfig)
+
+	c.State = mc.Scenario.InitialState()
+	mc.applyDispersions(c, DispersionInitialCondition)
+	c.State.UpdateFlightConditions()
+
+	mc.applyDispersions(c, DispersionEngineOption)
+
+	engine, stats, err := mc.newEngine(c)
+	if err != nil {
+		run.Err = fmt.Errorf("run %d: failed to create engine: %w", index, err)
+		return run
+	}
+
+	state := c.State
+	if err := mc.warmStart(c, engine); err != nil {
+		run.Err = fmt.Errorf("run %d: %w", index, err)
+		return run
+	}
+	var landings *LandingAnalyzer
+	if c.Runway != nil {
+		landings = NewLandingAnalyzer(stats)
+		landings.Runway = c.Runway
+	}
+	steps := int(math.Round(mc.Scenario.Duration / mc.Scenario.Dt))
+	for i := 0; i < steps; i++ {
+		if mc.Scenario.Controls != nil {
+			mc.Scenario.Controls(c, state)
+		}
+		newState, err := engine.Step(state, mc.Scenario.Dt)
+		if err != nil {
+			run.Err = fmt.Errorf("run %d: step %d failed: %w", index, i, err)
+			return run
+		}
+		state = newState
+		if landings != nil {
+			landings.Observe(state)
+		}
+	}
+
+	run.Metrics = mc.Extractor(state, stats)
+	if c.Runway != nil {
+		if run.Metrics == nil {
+			run.Metrics = make(map[string]float64)
+		}
+		for name, value := range c.Runway.Properties(state) {
+			run.Metrics[name] = value
+		}
+	}
+	return run
+}
+
+// newEngine returns the engine for a run: the scenario's NewEngine, or a
+// session of its shared Engine
+func (mc *MonteCarlo) newEngine(c *MonteCarloCase) (MonteCarloEngine, *FlightStatistics, error) {
+	if mc.Scenario.NewEngine != nil {
+		return mc.Scenario.NewEngine(c)
+	}
+	session := mc.Scenario.Engine.NewSession()
+	return session, session.Statistics, nil
+}
+
+// warmStart runs the engine in at the initial state with its initial pilot
+// inputs, when it can be
+func (mc *MonteCarlo) warmStart(c *MonteCarloCase, engine MonteCarloEngine) error {
+	starter, ok := engine.(WarmStarter)
+	duration := mc.Scenario.WarmStart
+	if duration == 0 {
+		duration = DefaultWarmStartDuration
+	}
+	if !ok || duration < 0 {
+		return nil
+	}
+	if mc.Scenario.Controls != nil {
+		mc.Scenario.Controls(c, c.State)
+	}
+	if _, err := starter.WarmStart(c.State, duration); err != nil {
+		return fmt.Errorf("warm start failed: %w", err)
+	}
+	return nil
+}
+
+// applyDispersions applies all sampled values for one target type
+func (mc *MonteCarlo) applyDispersions(c *MonteCarloCase, target DispersionTarget) {
+	for _, d := range mc.Dispersions {
+		if d.Target != target {
+			continue
+		}
+		value := c.Values[d.Name]
+		if d.Apply != nil {
+			d.Apply(c, value)
+		} else {
+			c.Options[d.Name] = value
+		}
+	}
+}
+
+// summarizeRuns computes per-metric statistics over successful runs
+func summarizeRuns(runs []*MonteCarloRunResult, percentiles []float64) map[string]*MetricSummary {
+	samples := make(map[string][]float64)
+	for _, run := range runs {
+		if run == nil || run.Err != nil {
+			continue
+		}
+		for name, value := range run.Metrics {
+			samples[name] = append(samples[name], value)
+		}
+	}
+
+	summary := make(map[string]*MetricSummary, len(samples))
+	for name, values := range samples {
+		sort.Float64s(values)
+
+		ms := &MetricSummary{
+			Count:       len(values),
+			Min:         values[0],
+			Max:         values[len(values)-1],
+			Percentiles: make(map[float64]float64, len(percentiles)),
+		}
+
+		sum := 0.0
+		for _, v := range values {
+			sum += v
+		}
+		ms.Mean = sum / float64(len(values))
+
+		if len(values) > 1 {
+			variance := 0.0
+			for _, v := range values {
+				variance += (v - ms.Mean) * (v - ms.Mean)
+			}
+			ms.StdDev = math.Sqrt(variance / float64(len(values)-1))
+		}
+
+		for _, p := range percentiles {
+			ms.Percentiles[p] = percentileSorted(values, p)
+		}
+
+		summary[name] = ms
+	}
+
+	return summary
+}
+
+// percentileSorted returns the p-th percentile (0-100) of sorted values using linear interpolation
+func percentileSorted(values []float64, p float64) float64 {
+	n := len(values)
+	if n == 0 {
+		return 0
+	}
+	if n == 1 || p <= 0 {
+		return values[0]
+	}
+	if p >= 100 {
+		return values[n-1]
+	}
+
+	rank := p / 100.0 * float64(n-1)
+	lower := int(math.Floor(rank))
+	frac := rank - float64(lower)
+	if lower+1 >= n {
+		return values[n-1]
+	}
+	return values[lower] + frac*(values[lower+1]-values[lower])
+}
+
+// MetricNames returns the summarized metric names in sorted order
+func (r *MonteCarloResult) MetricNames() []string {
+	names := make([]string, 0, len(r.Summary))
+	for name := range r.Summary {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WriteCSV writes one row per run: index, seed, dispersion values, metrics, error
+func (r *MonteCarloResult) WriteCSV(w io.Writer) error {
+	dispersionSet := make(map[string]bool)
+	metricSet := make(map[string]bool)
+	for _, run := range r.Runs {
+		for name := range run.Dispersions {
+			dispersionSet[name] = true
+		}
+		for name := range run.Metrics {
+			metricSet[name] = true
+		}
+	}
+	dispersionNames := sortedKeys(dispersionSet)
+	metricNames := sortedKeys(metricSet)
+
+	writer := csv.NewWriter(w)
+
+	header := []string{"run", "seed"}
+	header = append(header, dispersionNames...)
+	header = append(header, metricNames...)
+	header = append(header, "error")
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, run := range r.Runs {
+		row := []string{strconv.Itoa(run.Index), strconv.FormatInt(run.Seed, 10)}
+		for _, name := range dispersionNames {
+			row = append(row, strconv.FormatFloat(run.Dispersions[name], 'g', -1, 64))
+		}
+		for _, name := range metricNames {
+			value, ok := run.Metrics[name]
+			if ok {
+				row = append(row, strconv.FormatFloat(value, 'g', -1, 64))
+			} else {
+				row = append(row, "")
+			}
+		}
+		errText := ""
+		if run.Err != nil {
+			errText = run.Err.Error()
+		}
+		row = append(row, errText)
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// String returns a formatted summary table
+func (r *MonteCarloResult) String() string {
+	s := fmt.Sprintf("Monte Carlo Summary: %d runs (%d failed) on %d workers\n",
+		len(r.Runs), r.Failures, r.Workers)
+	for _, name := range r.MetricNames() {
+		ms := r.Summary[name]
+		s += fmt.Sprintf("  %-24s mean=%.4f std=%.4f min=%.4f max=%.4f\n",
+			name, ms.Mean, ms.StdDev, ms.Min, ms.Max)
+	}
+	return s
+}
+
+// sortedKeys returns the keys of a string set in sorted order
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"runtime"
+	"testing"
+)
+
+// newClimbMonteCarlo builds a short dispersed climb scenario on the simplified engine
+func newClimbMonteCarlo(runs int, seed int64) *MonteCarlo {
+	scenario := MonteCarloScenario{
+		Duration: 2.0,
+		Dt:       0.01,
+		InitialState: func() *AircraftState {
+			state := NewAircraftState()
+			state.Altitude = 1500.0
+			state.Position.Z = -1500.0
+			state.Velocity = Vector3{X: 90.0, Y: 0, Z: 0}
+			state.Controls.Throttle = 1.0
+			state.Controls.Elevator = 0.1
+			return state
+		},
+		NewEngine: func(c *MonteCarloCase) (MonteCarloEngine, *FlightStatistics, error) {
+			engine := NewSimplifiedFlightDynamicsEngine(NewRungeKutta4Integrator())
+			engine.Calculator.Mass *= c.Options["mass_scale"]
+			return engine, engine.Statistics, nil
+		},
+		Controls: func(c *MonteCarloCase, state *AircraftState) {
+			// Stick noise drawn from the run's own stream
+			state.Controls.Elevator = 0.1 + 0.005*c.Rand.NormFloat64()
+		},
+	}
+
+	extractor := func(state *AircraftState, stats *FlightStatistics) map[string]float64 {
+		return map[string]float64{
+			"final_altitude": state.Altitude,
+			"final_speed":    state.TrueAirspeed,
+			"max_climb_rate": stats.MaxClimbRate,
+		}
+	}
+
+	mc := NewMonteCarlo(scenario, extractor, runs, seed)
+	mc.AddDispersion(&Dispersion{
+		Name:         "initial_speed_scale",
+		Distribution: UniformDistribution{Min: 0.95, Max: 1.05},
+		Target:       DispersionInitialCondition,
+		Apply: func(c *MonteCarloCase, value float64) {
+			c.State.Velocity = c.State.Velocity.Scale(value)
+		},
+	})
+	mc.AddDispersion(&Dispersion{
+		Name:         "mass_scale",
+		Distribution: NormalDistribution{Mean: 1.0, StdDev: 0.02},
+		Target:       DispersionEngineOption,
+	})
+	return mc
+}
+
+func TestMonteCarloClimb(t *testing.T) {
+	mc := newClimbMonteCarlo(50, 42)
+
+	result, err := mc.Run()
+	if err != nil {
+		t.Fatalf("Monte Carlo run failed: %v", err)
+	}
+
+	t.Run("All Runs Complete", func(t *testing.T) {
+		assertEqual(t, len(result.Runs), 50)
+		assertEqual(t, result.Failures, 0)
+		for _, name := range []string{"final_altitude", "final_speed", "max_climb_rate"} {
+			summary, ok := result.Summary[name]
+			if !ok {
+				t.Fatalf("Missing summary for %s", name)
+			}
+			assertEqual(t, summary.Count, 50)
+			if summary.Min > summary.Mean || summary.Mean > summary.Max {
+				t.Errorf("%s: mean %f outside [%f, %f]", name, summary.Mean, summary.Min, summary.Max)
+			}
+			if summary.Percentiles[50] < summary.Min || summary.Percentiles[50] > summary.Max {
+				t.Errorf("%s: median %f outside range", name, summary.Percentiles[50])
+			}
+		}
+		if result.Summary["final_speed"].StdDev <= 0 {
+			t.Errorf("Dispersed runs should produce a spread in final speed")
+		}
+	})
+
+	t.Run("Uses All Cores", func(t *testing.T) {
+		expected := runtime.NumCPU()
+		if expected > 50 {
+			expected = 50
+		}
+		assertEqual(t, result.Workers, expected)
+	})
+
+	t.Run("Deterministic For Same Seed", func(t *testing.T) {
+		again := newClimbMonteCarlo(50, 42)
+		again.Workers = 1
+		repeat, err := again.Run()
+		if err != nil {
+			t.Fatalf("Repeat run failed: %v", err)
+		}
+		for name, summary := range result.Summary {
+			other := repeat.Summary[name]
+			assertEqual(t, other.Mean, summary.Mean)
+			assertEqual(t, other.StdDev, summary.StdDev)
+			assertEqual(t, other.Min, summary.Min)
+			assertEqual(t, other.Max, summary.Max)
+		}
+	})
+
+	t.Run("Different Seed Differs", func(t *testing.T) {
+		other, err := newClimbMonteCarlo(50, 7).Run()
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		if other.Summary["final_speed"].Mean == result.Summary["final_speed"].Mean {
+			t.Errorf("Different master seeds should produce different runs")
+		}
+	})
+
+	t.Run("CSV Export", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := result.WriteCSV(&buf); err != nil {
+			t.Fatalf("CSV export failed: %v", err)
+		}
+		records, err := csv.NewReader(&buf).ReadAll()
+		if err != nil {
+			t.Fatalf("CSV parse failed: %v", err)
+		}
+		assertEqual(t, len(records), 51)
+		assertEqual(t, records[0], []string{"run", "seed", "initial_speed_scale", "mass_scale",
+			"final_altitude", "final_speed", "max_climb_rate", "error"})
+	})
+}
+
+func TestMonteCarloValidation(t *testing.T) {
+	mc := newClimbMonteCarlo(0, 1)
+	if _, err := mc.Run(); err == nil {
+		t.Errorf("Expected error for zero runs")
+	}
+
+	mc = newClimbMonteCarlo(5, 1)
+	mc.AddDispersion(&Dispersion{
+		Name:         "cg_x",
+		Distribution: UniformDistribution{Min: -0.02, Max: 0.02},
+		Target:       DispersionConfig,
+	})
+	if _, err := mc.Run(); err == nil {
+		t.Errorf("Expected error for config dispersion without Apply")
+	}
+}
+
+func TestPercentileSorted(t *testing.T) {
+	values := []float64{1, 2, 3, 4, 5}
+	assertApproxEqual(t, percentileSorted(values, 0), 1, 1e-9)
+	assertApproxEqual(t, percentileSorted(values, 50), 3, 1e-9)
+	assertApproxEqual(t, percentileSorted(values, 100), 5, 1e-9)
+	assertApproxEqual(t, percentileSorted(values, 25), 2, 1e-9)
+	assertApproxEqual(t, percentileSorted(values, 90), 4.6, 1e-9)
+}
@@ -0,0 +1,249 @@
+// Output Manager
+// Writes the values selected by a JSBSim <output> element to CSV
+
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// outputCategory is a group of properties enabled by one output toggle
+type outputCategory struct {
+	enabled    func(o *Output) bool
+	properties []string
+}
+
+// outputCategories lists the toggle groups in the order they are written.
+// Categories without matching state properties (mass properties) add no
+// columns.
+var outputCategories = []outputCategory{
+	{func(o *Output) bool { return o.Simulation.On() }, []string{
+		"simulation/sim-time-sec",
+	}},
+	{func(o *Output) bool { return o.Atmosphere.On() }, []string{
+		"atmosphere/T-K", "atmosphere/P-Pa", "atmosphere/rho-kgm3", "atmosphere/a-mps",
+	}},
+	{func(o *Output) bool { return o.MassProps.On() }, nil},
+	{func(o *Output) bool { return o.AeroSurfaces.On() }, []string{
+		"fcs/left-aileron-pos-rad", "fcs/right-aileron-pos-rad", "fcs/elevator-pos-rad",
+		"fcs/rudder-pos-rad", "fcs/flap-pos-deg",
+	}},
+	{func(o *Output) bool { return o.Rates.On() }, []string{
+		"velocities/p-rad_sec", "velocities/q-rad_sec", "velocities/r-rad_sec",
+	}},
+	{func(o *Output) bool { return o.Velocities.On() }, []string{
+		"aero/qbar-Pa", "velocities/vt-mps", "velocities/u-mps", "velocities/v-mps",
+		"velocities/w-mps", "velocities/vc-mps", "aero/mach", "flight-path/gamma-deg",
+		"flight-path/psi-gt-deg", "flight-path/climb-gradient-pct",
+	}},
+	{func(o *Output) bool { return o.Forces.On() }, []string{
+		"forces/fbx-N", "forces/fby-N", "forces/fbz-N",
+	}},
+	{func(o *Output) bool { return o.Moments.On() }, []string{
+		"moments/l-Nm", "moments/m-Nm", "moments/n-Nm",
+	}},
+	{func(o *Output) bool { return o.Position.On() }, []string{
+		"position/h-sl-m", "attitude/roll-rad", "attitude/pitch-rad", "attitude/heading-rad",
+		"aero/alpha-rad", "aero/beta-rad", "position/latitude-rad", "position/longitude-rad",
+		"position/h-agl-m",
+	}},
+	{func(o *Output) bool { return o.Coefficients.On() || o.Aerodynamics.On() }, []string{
+		"aero/alpha-deg", "aero/beta-deg", "aero/qbar-psf",
+	}},
+	{func(o *Output) bool { return o.GroundReactions.On() }, []string{
+		"gear/wow", "gear/gear-pos-norm",
+	}},
+	{func(o *Output) bool { return o.FCS.On() }, []string{
+		"fcs/aileron-cmd-norm", "fcs/elevator-cmd-norm", "fcs/rudder-cmd-norm",
+		"fcs/flap-cmd-norm", "fcs/throttle-cmd-norm",
+	}},
+	{func(o *Output) bool { return o.Propulsion.On() }, []string{
+		"propulsion/engine/thrust-N", "engines/engine/rpm", "engines/engine/mp-inHg",
+	}},
+}
+
+// OutputManager records aircraft state to a CSV stream at the rate and with
+// the columns given by an <output> element
+type OutputManager struct {
+	Columns []string // Property names, in column order
+	Headers []string // Column captions
+	Period  float64  // Simulation time between rows (s); 0 writes every frame
+
+	// Properties is consulted for names the aircraft state does not provide,
+	// such as FCS intermediate values. Unknown properties read as 0.
+	Properties *PropertyManager
+
+	writer        *csv.Writer
+	values        map[string]float64
+	factors       []float64 // Unit conversion of each column, from SetUnits
+	row           []string
+	framePeriod   float64 // Simulation time between Record calls (s)
+	schedule      SimSchedule
+	headerWritten bool
+}
+
+// NewOutputManager creates a CSV output for the given definition. simRateHz
+// is the rate Record will be called at; rows are written at the output
+// rate in simulation time, on the frames its boundaries fall in.
+func NewOutputManager(output *Output, simRateHz float64, w io.Writer) (*OutputManager, error) {
+	if output == nil {
+		return nil, fmt.Errorf("no output definition")
+	}
+
+	writer := csv.NewWriter(w)
+	switch strings.ToUpper(strings.TrimSpace(output.Type)) {
+	case "", "CSV":
+	case "TABULAR":
+		writer.Comma = '\t'
+	default:
+		return nil, fmt.Errorf("unsupported output type %q", output.Type)
+	}
+
+	om := &OutputManager{
+		writer:      writer,
+		values:      make(map[string]float64, propertyMapSize),
+		framePeriod: 1 / simRateHz,
+	}
+	// A missing rate or one at or above the simulation rate writes every frame
+	if rate := float64(output.Rate); rate > 0 && rate < simRateHz {
+		om.schedule = NewSimSchedule(rate)
+		om.Period = om.schedule.Period
+	}
+
+	for _, category := range outputCategories {
+		if category.enabled(output) {
+			om.Columns = append(om.Columns, category.properties...)
+			om.Headers = append(om.Headers, category.properties...)
+		}
+	}
+	for _, prop := range output.Properties {
+		name := normalizePropertyName(prop.Name)
+		if name == "" {
+			continue
+		}
+		caption := strings.TrimSpace(prop.Caption)
+		if caption == "" {
+			caption = name
+		}
+		om.Columns = append(om.Columns, name)
+		om.Headers = append(om.Headers, caption)
+	}
+
+	if len(om.Columns) == 0 {
+		return nil, fmt.Errorf("output %q selects no properties", output.Name)
+	}
+	om.row = make([]string, len(om.Columns))
+
+	return om, nil
+}
+
+// Record is called once per simulation frame and writes a row on the frames
+// an output boundary falls in, taking the frame to last from state.Time to
+// the next frame.
+func (om *OutputManager) Record(state *AircraftState) error {
+	if om.Period > 0 && om.schedule.Count(state.Time, state.Time+om.framePeriod) == 0 {
+		return nil
+	}
+
+	if !om.headerWritten {
+		if err := om.writer.Write(om.Headers); err != nil {
+			return err
+		}
+		om.headerWritten = true
+	}
+
+	state.FillPropertyMap(om.values)
+	for i, name := range om.Columns {
+		value, ok := om.values[name]
+		if !ok && om.Properties != nil {
+			value = om.Properties.Get(name)
+		}
+		if om.factors != nil {
+			value *= om.factors[i]
+		}
+		om.row[i] = strconv.FormatFloat(value, 'g', -1, 64)
+	}
+
+	return om.writer.Write(om.row)
+}
+
+// SetUnits records the properties in the given units, with the unit in
+// each column's header as "velocities/vt (kt)". Captioned columns and
+// properties without a known unit are written as they are. ReadRecording
+// does not read the renamed columns back. SetUnits must be called before
+// the first row is written.
+func (om *OutputManager) SetUnits(units UnitPreferences) {
+	om.factors = make([]float64, len(om.Columns))
+	for i, name := range om.Columns {
+		om.factors[i] = 1
+		if om.Headers[i] != name {
+			continue
+		}
+		om.Headers[i], om.factors[i] = units.PropertyColumn(name)
+	}
+}
+
+// Flush writes any buffered rows to the underlying writer
+func (om *OutputManager) Flush() error {
+	om.writer.Flush()
+	return om.writer.Error()
+}
+
+// OutputManagers drives the outputs of a configuration with several
+// <output> elements, recording each frame to all of them
+type OutputManagers []*OutputManager
+
+// NewOutputManagers creates an OutputManager for each definition, writing
+// to the writer open returns for it. Outputs open returns a nil writer for,
+// such as sockets with no connection, are left out.
+func NewOutputManagers(outputs []*Output, simRateHz float64, open func(output *Output) (io.Writer, error)) (OutputManagers, error) {
+	var managers OutputManagers
+	for i, output := range outputs {
+		w, err := open(output)
+		if err != nil {
+			return nil, fmt.Errorf("output %d (%s): %w", i, output.Name, err)
+		}
+		if w == nil {
+			continue
+		}
+		om, err := NewOutputManager(output, simRateHz, w)
+		if err != nil {
+			return nil, fmt.Errorf("output %d (%s): %w", i, output.Name, err)
+		}
+		managers = append(managers, om)
+	}
+	return managers, nil
+}
+
+// SetProperties sets the property tree every output reads names the state
+// does not provide from
+func (managers OutputManagers) SetProperties(properties *PropertyManager) {
+	for _, om := range managers {
+		om.Properties = properties
+	}
+}
+
+// Record records the frame to every output, each at its own rate
+func (managers OutputManagers) Record(state *AircraftState) error {
+	for _, om := range managers {
+		if err := om.Record(state); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Flush flushes every output, returning the first error
+func (managers OutputManagers) Flush() error {
+	var first error
+	for _, om := range managers {
+		if err := om.Flush(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const outputFixture = `<?xml version="1.0"?>
+<fdm_config name="output-test" version="2.0" release="ALPHA">
+	<output name="flight.csv" type="CSV" rate="10">
+		<simulation> OFF </simulation>
+		<atmosphere> OFF </atmosphere>
+		<property> simulation/sim-time-sec </property>
+		<property caption="Altitude (m)"> position/h-sl-m </property>
+		<property> velocities/vt-mps </property>
+		<property> /fcs/elevator-pos-rad </property>
+		<property> aero/alpha-deg </property>
+		<property> fcs/pitch-trim-sum </property>
+	</output>
+</fdm_config>`
+
+// multipleOutputFixture has a CSV file and a tab separated log, written at
+// different rates
+const multipleOutputFixture = `<?xml version="1.0"?>
+<fdm_config name="output-test" version="2.0" release="ALPHA">
+	<output name="flight.csv" type="CSV" rate="10">
+		<simulation> ON </simulation>
+		<property> position/h-sl-m </property>
+	</output>
+	<output name="rates.log" type="TABULAR" rate="25">
+		<simulation> ON </simulation>
+		<rates> ON </rates>
+	</output>
+</fdm_config>`
+
+func parseOutputFixture(t *testing.T) *Output {
+	t.Helper()
+	config, err := ParseJSBSimConfig(strings.NewReader(outputFixture))
+	if err != nil {
+		t.Fatalf("Failed to parse fixture: %v", err)
+	}
+	if config.PrimaryOutput() == nil {
+		t.Fatalf("Output element not parsed")
+	}
+	return config.PrimaryOutput()
+}
+
+func TestParseOutputProperties(t *testing.T) {
+	output := parseOutputFixture(t)
+
+	assertEqual(t, output.Rate, 10)
+	assertEqual(t, len(output.Properties), 6)
+	assertEqual(t, strings.TrimSpace(output.Properties[1].Name), "position/h-sl-m")
+	assertEqual(t, output.Properties[1].Caption, "Altitude (m)")
+	assertEqual(t, output.Simulation.On(), false)
+	assertEqual(t, output.Velocities.On(), false)
+	assertEqual(t, OutputToggle(" on ").On(), true)
+}
+
+func TestOutputManagerCSV(t *testing.T) {
+	output := parseOutputFixture(t)
+
+	var buf bytes.Buffer
+	om, err := NewOutputManager(output, 100.0, &buf)
+	if err != nil {
+		t.Fatalf("Failed to create output manager: %v", err)
+	}
+	assertApproxEqual(t, om.Period, 0.1, 1e-12)
+
+	om.Properties = NewPropertyManager()
+	om.Properties.Set("fcs/pitch-trim-sum", 0.25)
+
+	// One second of simulation at 100 Hz
+	state := NewAircraftState()
+	state.ControlSurfaces.Elevator = 0.1
+	for i := 0; i < 100; i++ {
+		state.Time = float64(i) / 100.0
+		if err := om.Record(state); err != nil {
+			t.Fatalf("Record failed: %v", err)
+		}
+	}
+	if err := om.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to read CSV: %v", err)
+	}
+
+	assertEqual(t, records[0], []string{
+		"simulation/sim-time-sec", "Altitude (m)", "velocities/vt-mps",
+		"fcs/elevator-pos-rad", "aero/alpha-deg", "fcs/pitch-trim-sum",
+	})
+
+	// 10 Hz output: header plus one row every 0.1 s
+	rows := records[1:]
+	assertEqual(t, len(rows), 10)
+	for i, row := range rows {
+		assertEqual(t, len(row), 6)
+		simTime, _ := strconv.ParseFloat(row[0], 64)
+		assertApproxEqual(t, simTime, float64(i)*0.1, 1e-9)
+		assertEqual(t, row[3], "0.1")
+		assertEqual(t, row[5], "0.25")
+	}
+}
+
+func TestOutputManagerUnits(t *testing.T) {
+	var buf bytes.Buffer
+	om, err := NewOutputManager(parseOutputFixture(t), 100.0, &buf)
+	if err != nil {
+		t.Fatalf("Failed to create output manager: %v", err)
+	}
+	om.SetUnits(ImperialAviationUnits())
+
+	state := NewAircraftState()
+	state.Velocity = Vector3{X: 100}
+	state.ControlSurfaces.Elevator = 0.1
+	state.UpdateFlightConditions()
+	if err := om.Record(state); err != nil {
+		t.Fatalf("Record failed: %v", err)
+	}
+	om.Flush()
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to read CSV: %v", err)
+	}
+	// The captioned altitude and the unitless trim sum as they were
+	assertEqual(t, records[0], []string{
+		"simulation/sim-time (s)", "Altitude (m)", "velocities/vt (kt)",
+		"fcs/elevator-pos (deg)", "aero/alpha (deg)", "fcs/pitch-trim-sum",
+	})
+	row := records[1]
+	assertEqual(t, row[1], strconv.FormatFloat(state.Altitude, 'g', -1, 64))
+	assertEqual(t, row[2], strconv.FormatFloat(state.TrueAirspeed*MS_TO_KT, 'g', -1, 64))
+	assertEqual(t, row[3], strconv.FormatFloat(0.1*RAD_TO_DEG, 'g', -1, 64))
+}
+
+func TestOutputManagerCategories(t *testing.T) {
+	output := &Output{Rate: 200, Simulation: "ON", Rates: "ON",
+		Properties: []*OutputProperty{{Name: "gear/wow"}}}
+
+	var buf bytes.Buffer
+	om, err := NewOutputManager(output, 100.0, &buf)
+	if err != nil {
+		t.Fatalf("Failed to create output manager: %v", err)
+	}
+
+	// Rates above the simulation rate write every frame
+	assertEqual(t, om.Period, 0.0)
+	assertEqual(t, om.Columns, []string{
+		"simulation/sim-time-sec",
+		"velocities/p-rad_sec", "velocities/q-rad_sec", "velocities/r-rad_sec",
+		"gear/wow",
+	})
+
+	if _, err := NewOutputManager(&Output{Name: "empty"}, 100.0, &buf); err == nil {
+		t.Errorf("Expected error for an output with no properties")
+	}
+	if _, err := NewOutputManager(&Output{Type: "SOCKET", Simulation: "ON"}, 100.0, &buf); err == nil {
+		t.Errorf("Expected error for a non-file output type")
+	}
+}
+
+func TestMultipleOutputs(t *testing.T) {
+	config, err := ParseJSBSimConfig(strings.NewReader(multipleOutputFixture))
+	if err != nil {
+		t.Fatalf("Failed to parse fixture: %v", err)
+	}
+	assertEqual(t, len(config.Output), 2)
+	assertEqual(t, config.PrimaryOutput().Name, "flight.csv")
+	assertEqual(t, config.Output[1].Name, "rates.log")
+	assertEqual(t, config.PrimaryInput() == nil, true)
+
+	values := ExtractAllValues(config)
+	for i, name := range []string{"flight.csv", "rates.log"} {
+		output, ok := values[fmt.Sprintf("output.%d", i)].(map[string]interface{})
+		if !ok {
+			t.Fatalf("output.%d not extracted", i)
+		}
+		assertEqual(t, output["name"], name)
+	}
+
+	files := map[string]*bytes.Buffer{}
+	managers, err := NewOutputManagers(config.Output, 100.0, func(output *Output) (io.Writer, error) {
+		files[output.Name] = &bytes.Buffer{}
+		return files[output.Name], nil
+	})
+	if err != nil {
+		t.Fatalf("NewOutputManagers: %v", err)
+	}
+	assertEqual(t, len(managers), 2)
+
+	// One second of simulation at 100 Hz drives both at their own rates
+	state := NewAircraftState()
+	state.AngularRate.Y = 0.05
+	for i := 0; i < 100; i++ {
+		state.Time = float64(i) / 100.0
+		if err := managers.Record(state); err != nil {
+			t.Fatalf("Record failed: %v", err)
+		}
+	}
+	if err := managers.Flush(); err != nil {
+		t.Fatalf("Flush failed: %v", err)
+	}
+
+	flight, err := csv.NewReader(files["flight.csv"]).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to read flight.csv: %v", err)
+	}
+	assertEqual(t, flight[0], []string{"simulation/sim-time-sec", "position/h-sl-m"})
+	assertEqual(t, len(flight), 1+10)
+
+	reader := csv.NewReader(files["rates.log"])
+	reader.Comma = '\t'
+	rates, err := reader.ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to read rates.log: %v", err)
+	}
+	assertEqual(t, rates[0][2], "velocities/q-rad_sec")
+	assertEqual(t, len(rates), 1+25)
+	assertEqual(t, rates[1][2], "0.05")
+
+	// Outputs given no writer are left out
+	managers, err = NewOutputManagers(config.Output, 100.0, func(output *Output) (io.Writer, error) {
+		if output.Type == "TABULAR" {
+			return nil, nil
+		}
+		return io.Discard, nil
+	})
+	if err != nil {
+		t.Fatalf("NewOutputManagers: %v", err)
+	}
+	assertEqual(t, len(managers), 1)
+}
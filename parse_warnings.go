@@ -0,0 +1,222 @@
+// Parse Warnings
+// Reports XML elements and attributes the configuration structs do not
+// read, which encoding/xml otherwise drops without a trace
+
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// ParseWarningCategory classifies a part of the document that was not read
+type ParseWarningCategory string
+
+const (
+	// UnknownElement is an element the schema does not have, often a typo
+	UnknownElement ParseWarningCategory = "unknown element"
+	// UnknownAttribute is an attribute the schema does not have
+	UnknownAttribute ParseWarningCategory = "unknown attribute"
+	// UnimplementedSection is a JSBSim element this simulator does not model
+	UnimplementedSection ParseWarningCategory = "known but unimplemented section"
+)
+
+// ParseWarning is one element or attribute that was ignored while parsing
+type ParseWarning struct {
+	Path     string // Element path below fdm_config, such as metrics/wingaera; attributes end in /@name
+	Category ParseWarningCategory
+	Line     int // Line of the element in the document
+}
+
+func (w ParseWarning) String() string {
+	return fmt.Sprintf("line %d: %s %s", w.Line, w.Category, w.Path)
+}
+
+// ParseOption changes how ParseJSBSimConfig reads a configuration
+type ParseOption func(*parseOptions)
+
+type parseOptions struct {
+	reportIgnored bool
+	sourceFile    string
+}
+
+// WarnIgnoredElements makes ParseJSBSimConfig walk the document a second
+// time and list every element and attribute the configuration structs did
+// not read in JSBSimConfig.ParseWarnings. Parsing continues as usual.
+func WarnIgnoredElements() ParseOption {
+	return func(o *parseOptions) { o.reportIgnored = true }
+}
+
+// unimplementedElements are JSBSim elements this simulator knows of but
+// does not read. <p> and <v> are JSBSim's short forms of <property> and
+// <value> in functions.
+var unimplementedElements = map[string]bool{
+	"buoyant_forces":     true,
+	"external_reactions": true,
+	"priority":           true,
+	"standpipe":          true,
+	"temperature":        true,
+	"type":               true,
+	"description":        true,
+	"p":                  true,
+	"v":                  true,
+}
+
+// parseWarnings walks data against the JSBSimConfig schema and returns the
+// elements and attributes it does not map, in document order
+func parseWarnings(data []byte) ([]ParseWarning, error) {
+	w := &schemaWalker{decoder: xml.NewDecoder(bytes.NewReader(data))}
+	for {
+		token, err := w.decoder.Token()
+		if err == io.EOF {
+			return nil, fmt.Errorf("no root element")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			line, _ := w.decoder.InputPos()
+			if err := w.element(start, reflect.TypeOf(JSBSimConfig{}), "", line); err != nil {
+				return nil, err
+			}
+			return w.warnings, nil
+		}
+	}
+}
+
+// schemaWalker follows the token stream down the configuration types
+type schemaWalker struct {
+	decoder  *xml.Decoder
+	warnings []ParseWarning
+}
+
+func (w *schemaWalker) warn(path string, category ParseWarningCategory, line int) {
+	w.warnings = append(w.warnings, ParseWarning{Path: path, Category: category, Line: line})
+}
+
+// element checks the attributes and children of start, decoded into a
+// value of type t, and consumes the tokens up to its end
+func (w *schemaWalker) element(start xml.StartElement, t reflect.Type, path string, line int) error {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	schema := schemaFor(t)
+
+	for _, attr := range start.Attr {
+		if attr.Name.Space != "" || attr.Name.Local == "xmlns" {
+			continue // Namespace declarations and schema locations
+		}
+		if !schema.attributes[attr.Name.Local] {
+			w.warn(joinPath(path, "@"+attr.Name.Local), UnknownAttribute, line)
+		}
+	}
+	if schema.innerXML {
+		return w.decoder.Skip()
+	}
+
+	for {
+		token, err := w.decoder.Token()
+		if err != nil {
+			return err
+		}
+		switch el := token.(type) {
+		case xml.StartElement:
+			line, _ := w.decoder.InputPos()
+			name := el.Name.Local
+			child, ok := schema.children[name]
+			if !ok && t == channelType {
+				// Every element of a channel other than a sensor is a
+				// component named by its type
+				child, ok = componentType, true
+			}
+			if !ok {
+				category := UnknownElement
+				if unimplementedElements[name] {
+					category = UnimplementedSection
+				}
+				w.warn(joinPath(path, name), category, line)
+				if err := w.decoder.Skip(); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := w.element(el, child, joinPath(path, name), line); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "/" + name
+}
+
+var (
+	channelType   = reflect.TypeOf(Channel{})
+	componentType = reflect.TypeOf(Component{})
+)
+
+// elementSchema is what encoding/xml reads for one type: attribute names,
+// child element types by name, and whether it keeps its inner XML whole
+type elementSchema struct {
+	attributes map[string]bool
+	children   map[string]reflect.Type
+	innerXML   bool
+}
+
+var elementSchemas sync.Map // map[reflect.Type]*elementSchema
+
+// schemaFor returns the schema of t from its xml struct tags. Types that
+// are not structs take only character data.
+func schemaFor(t reflect.Type) *elementSchema {
+	if cached, ok := elementSchemas.Load(t); ok {
+		return cached.(*elementSchema)
+	}
+	schema := &elementSchema{
+		attributes: make(map[string]bool),
+		children:   make(map[string]reflect.Type),
+	}
+	if t.Kind() == reflect.Struct {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() || field.Type == reflect.TypeOf(xml.Name{}) {
+				continue
+			}
+			tag := field.Tag.Get("xml")
+			if tag == "-" {
+				continue
+			}
+			name, flags, _ := strings.Cut(tag, ",")
+			switch {
+			case flags == "attr":
+				schema.attributes[nameOrField(name, field)] = true
+			case flags == "innerxml":
+				schema.innerXML = true
+			case flags == "":
+				schema.children[nameOrField(name, field)] = field.Type
+			}
+		}
+	}
+	if t == channelType {
+		schema.children["sensor"] = reflect.TypeOf(Sensor{})
+	}
+	elementSchemas.Store(t, schema)
+	return schema
+}
+
+func nameOrField(name string, field reflect.StructField) string {
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
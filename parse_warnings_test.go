@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const misspelledConfig = `<?xml version="1.0"?>
+<fdm_config name="typo" version="2.0" release="ALPHA">
+  <metrics>
+    <wingaera unit="FT2"> 174 </wingaera>
+    <wingspan unit="FT"> 35 </wingspan>
+    <chord unit="FT" colour="red"> 6.6 </chord>
+  </metrics>
+  <external_reactions>
+    <force name="hook" frame="BODY"/>
+  </external_reactions>
+  <flight_control name="FCS">
+    <channel name="Pitch">
+      <pure_gain name="elevator">
+        <input>fcs/elevator-cmd-norm</input>
+        <gain>1.0</gain>
+      </pure_gain>
+    </channel>
+  </flight_control>
+</fdm_config>
+`
+
+func TestParseWarnings(t *testing.T) {
+	t.Run("Misspelled Element", func(t *testing.T) {
+		config, err := ParseJSBSimConfig(strings.NewReader(misspelledConfig), WarnIgnoredElements())
+		if err != nil {
+			t.Fatalf("Parse error: %v", err)
+		}
+		assertEqual(t, len(config.ParseWarnings), 3)
+		assertEqual(t, config.ParseWarnings[0], ParseWarning{Path: "metrics/wingaera", Category: UnknownElement, Line: 4})
+		assertEqual(t, config.ParseWarnings[1], ParseWarning{Path: "metrics/chord/@colour", Category: UnknownAttribute, Line: 6})
+		assertEqual(t, config.ParseWarnings[2], ParseWarning{Path: "external_reactions", Category: UnimplementedSection, Line: 8})
+		// The rest of the document is still read
+		assertEqual(t, config.Metrics.WingSpan.Value, 35.0)
+	})
+
+	t.Run("Off By Default", func(t *testing.T) {
+		config, err := ParseJSBSimConfig(strings.NewReader(misspelledConfig))
+		if err != nil {
+			t.Fatalf("Parse error: %v", err)
+		}
+		assertEqual(t, len(config.ParseWarnings), 0)
+	})
+
+	t.Run("P-51D", func(t *testing.T) {
+		file, err := os.Open("aircraft/p51d-jsbsim.xml")
+		if err != nil {
+			t.Fatalf("Failed to open file: %v", err)
+		}
+		defer file.Close()
+		config, err := ParseJSBSimConfig(file, WarnIgnoredElements())
+		if err != nil {
+			t.Fatalf("Parse error: %v", err)
+		}
+		for _, warning := range config.ParseWarnings {
+			// Every component of the flight control system is understood
+			if strings.HasPrefix(warning.Path, "flight_control/channel/") && warning.Category == UnknownElement {
+				t.Errorf("Unexpected warning %s", warning)
+			}
+			// Rebound damping is read by the landing gear
+			if strings.HasSuffix(warning.Path, "/damping_coeff_rebound") {
+				t.Errorf("Rebound damping is supported, got %s", warning)
+			}
+		}
+	})
+}
+
+func TestRunValidate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "typo.xml")
+	if err := os.WriteFile(path, []byte(misspelledConfig), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := runValidate([]string{path}, &out); err != nil {
+		t.Fatalf("runValidate: %v", err)
+	}
+	if !strings.Contains(out.String(), path+":4: unknown element metrics/wingaera\n") {
+		t.Errorf("Missing wingaera warning in:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := runValidate([]string{filepath.Join(t.TempDir(), "missing.xml")}, &out); err == nil {
+		t.Error("Expected a missing file to fail validation")
+	}
+}
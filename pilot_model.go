@@ -0,0 +1,240 @@
+// Pilot Model
+// A synthetic compensatory pilot for handling-qualities evaluation: it
+// sees the error between a commanded and a measured variable and moves one
+// control to null it, with a human's gain, equalisation and delays
+
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// PilotModel turns a tracking error into a control deflection about trim
+type PilotModel interface {
+	// Update advances the pilot by dt (s) with the error now displayed,
+	// returning the deflection (normalised control units)
+	Update(err, dt float64) float64
+
+	// Reset returns the pilot to rest, with no error seen
+	Reset()
+}
+
+// CrossoverPilot is the McRuer crossover model: a gain, a lead-lag
+// equaliser, a reaction delay and the neuromuscular lag,
+//
+//	Yp(s) = Gain · (LeadTime·s + 1)/(LagTime·s + 1) · e^(−Delay·s) / (NeuromuscularLag·s + 1)
+//
+// A zero time constant drops its term. The pilot sees nothing of the error
+// before the first Delay seconds have passed.
+type CrossoverPilot struct {
+	Gain             float64 // Control per unit of error, signed as the aircraft responds
+	LeadTime         float64 // s
+	LagTime          float64 // s
+	NeuromuscularLag float64 // s
+	Delay            float64 // Reaction delay (s)
+
+	time     float64
+	seen     []pilotSample // Errors not yet older than the delay, oldest first
+	lag      float64       // State of the equaliser's lag
+	previous float64       // Delayed error of the last update, for a pure lead
+	output   float64       // Neuromuscular lag output
+}
+
+// pilotSample is an error and when it was displayed
+type pilotSample struct {
+	time, err float64
+}
+
+// NewCrossoverPilot creates a crossover pilot
+func NewCrossoverPilot(gain, leadTime, lagTime, neuromuscularLag, delay float64) *CrossoverPilot {
+	return &CrossoverPilot{
+		Gain:             gain,
+		LeadTime:         leadTime,
+		LagTime:          lagTime,
+		NeuromuscularLag: neuromuscularLag,
+		Delay:            delay,
+	}
+}
+
+// Reset returns the pilot to rest
+func (p *CrossoverPilot) Reset() {
+	p.time, p.seen = 0, nil
+	p.lag, p.previous, p.output = 0, 0, 0
+}
+
+// Update acts on the error displayed Delay ago, then advances the pilot's
+// clock by dt
+func (p *CrossoverPilot) Update(err, dt float64) float64 {
+	p.seen = append(p.seen, pilotSample{time: p.time, err: err})
+
+	// The newest error at least Delay old is the one acted on; older ones
+	// are no longer needed
+	perceived := 0.0
+	for len(p.seen) > 0 && p.seen[0].time <= p.time-p.Delay+1e-9 {
+		perceived = p.seen[0].err
+		if len(p.seen) == 1 || p.seen[1].time > p.time-p.Delay+1e-9 {
+			break
+		}
+		p.seen = p.seen[1:]
+	}
+
+	// (LeadTime·s + 1)/(LagTime·s + 1) is LeadTime/LagTime plus the rest
+	// through a first-order lag; without a lag the lead differentiates
+	var equalised float64
+	if p.LagTime > 0 {
+		p.lag += (perceived - p.lag) * (1 - math.Exp(-dt/p.LagTime))
+		ratio := p.LeadTime / p.LagTime
+		equalised = ratio*perceived + (1-ratio)*p.lag
+	} else {
+		equalised = perceived
+		if dt > 0 {
+			equalised += p.LeadTime * (perceived - p.previous) / dt
+		}
+	}
+	p.previous = perceived
+
+	command := p.Gain * equalised
+	if p.NeuromuscularLag > 0 {
+		p.output += (command - p.output) * (1 - math.Exp(-dt/p.NeuromuscularLag))
+	} else {
+		p.output = command
+	}
+	p.time += dt
+	return p.output
+}
+
+// TrackedVariable is a quantity a pilot tracks, measured from the state
+type TrackedVariable struct {
+	Name    string
+	Measure func(state *AircraftState) float64
+}
+
+// PitchAttitude tracks the pitch angle (rad)
+func PitchAttitude() TrackedVariable {
+	return TrackedVariable{Name: "pitch-attitude-rad", Measure: func(state *AircraftState) float64 {
+		_, pitch, _ := state.Orientation.ToEuler()
+		return pitch
+	}}
+}
+
+// BankAngle tracks the roll angle (rad)
+func BankAngle() TrackedVariable {
+	return TrackedVariable{Name: "bank-angle-rad", Measure: func(state *AircraftState) float64 {
+		roll, _, _ := state.Orientation.ToEuler()
+		return roll
+	}}
+}
+
+// GlideSlopeDeviation tracks the height above a glide slope (m) of an
+// angle (rad) to a runway's threshold at an elevation (m)
+func GlideSlopeDeviation(runway *RunwayContext, angle, elevation float64) TrackedVariable {
+	return TrackedVariable{Name: "glide-slope-deviation-m", Measure: func(state *AircraftState) float64 {
+		distance, _ := runway.Position(state)
+		return state.Altitude - (elevation - distance*math.Tan(angle))
+	}}
+}
+
+// PilotLoop is a pilot closing a loop: it tracks Command with Variable by
+// moving the control written by a pilot command property, such as
+// fcs/elevator-cmd-norm, about where the control was when the run began.
+// Its deflections go through SetControlInputs, so they are limited as a
+// human pilot's would be.
+type PilotLoop struct {
+	Pilot    PilotModel
+	Variable TrackedVariable
+	Control  string
+	Command  func(time float64) float64 // The value to track at a time (s), in Variable's unit
+
+	trim    float64
+	started bool
+	last    float64 // Control of the previous update
+	sums    trackingSums
+}
+
+// trackingSums accumulates a loop's tracking statistics
+type trackingSums struct {
+	samples                  int
+	errSq, controlSq, rateSq float64
+	maxErr                   float64
+}
+
+// TrackingStats scores a loop's tracking for handling-qualities work
+type TrackingStats struct {
+	Samples         int
+	RMSError        float64 // In the variable's unit
+	MaxError        float64 // Largest error magnitude
+	RMSControl      float64 // Deflection from trim (normalised)
+	ControlActivity float64 // RMS control rate (normalised per s)
+}
+
+func (s TrackingStats) String() string {
+	return fmt.Sprintf("RMS error %.4g, max %.4g, RMS control %.4g, control activity %.4g /s over %d samples",
+		s.RMSError, s.MaxError, s.RMSControl, s.ControlActivity, s.Samples)
+}
+
+// reset returns the loop to the start of a run
+func (l *PilotLoop) reset() {
+	l.Pilot.Reset()
+	l.started = false
+	l.sums = trackingSums{}
+}
+
+// apply moves the loop's control on the state for the step of dt about to
+// be flown, recording the error it saw and the control it set
+func (l *PilotLoop) apply(state *AircraftState, dt float64) {
+	controls := state.Controls
+	field := commandField(&controls, l.Control)
+	if !l.started {
+		l.trim, l.last, l.started = *field, *field, true
+	}
+	err := l.Command(state.Time) - l.Variable.Measure(state)
+	*field = l.trim + l.Pilot.Update(err, dt)
+	state.SetControlInputs(controls)
+
+	applied := *commandField(&state.Controls, l.Control)
+	s := &l.sums
+	s.samples++
+	s.errSq += err * err
+	s.maxErr = math.Max(s.maxErr, math.Abs(err))
+	s.controlSq += (applied - l.trim) * (applied - l.trim)
+	if dt > 0 {
+		rate := (applied - l.last) / dt
+		s.rateSq += rate * rate
+	}
+	l.last = applied
+}
+
+// Stats returns the tracking statistics of the last run
+func (l *PilotLoop) Stats() TrackingStats {
+	s := l.sums
+	if s.samples == 0 {
+		return TrackingStats{}
+	}
+	n := float64(s.samples)
+	return TrackingStats{
+		Samples:         s.samples,
+		RMSError:        math.Sqrt(s.errSq / n),
+		MaxError:        s.maxErr,
+		RMSControl:      math.Sqrt(s.controlSq / n),
+		ControlActivity: math.Sqrt(s.rateSq / n),
+	}
+}
+
+// AttachPilot closes a pilot loop in the runner's runs, after its Pilot
+// function has set the controls. A control takes one loop.
+func (r *ScenarioRunner) AttachPilot(loop *PilotLoop) error {
+	switch {
+	case loop.Pilot == nil || loop.Variable.Measure == nil || loop.Command == nil:
+		return fmt.Errorf("pilot loop on %s needs a pilot, a variable and a command", loop.Control)
+	case commandField(&ControlInputs{}, loop.Control) == nil:
+		return fmt.Errorf("%s is not a pilot command property", loop.Control)
+	}
+	for _, attached := range r.loops {
+		if attached.Control == loop.Control {
+			return fmt.Errorf("%s already has a pilot loop", loop.Control)
+		}
+	}
+	r.loops = append(r.loops, loop)
+	return nil
+}
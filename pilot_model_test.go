@@ -0,0 +1,130 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPilotModel(t *testing.T) {
+	// track flies the simplified model from trim with a crossover pilot on
+	// the elevator, commanded 5° nose up after a second, returning the
+	// loop and the pitch change at each step. The model's short period
+	// root near 540 rad/s needs a 2 ms step with RK4.
+	track := func(delay float64) (*PilotLoop, map[int]float64) {
+		t.Helper()
+		engine := NewSimplifiedFlightDynamicsEngine(NewRungeKutta4Integrator())
+		initial := trimLevelFlight(t, engine, 1000, 100)
+		theta0 := PitchAttitude().Measure(initial)
+
+		// The model pitches up for negative elevator, so the pilot's gain
+		// is negative
+		loop := &PilotLoop{
+			Pilot:    NewCrossoverPilot(-6, 0, 0, 0.1, delay),
+			Variable: PitchAttitude(),
+			Control:  "fcs/elevator-cmd-norm",
+			Command: func(time float64) float64 {
+				if time < 1 {
+					return theta0
+				}
+				return theta0 + 5*DEG_TO_RAD
+			},
+		}
+		pitch := make(map[int]float64)
+		policy := NewTerminationPolicy()
+		policy.Add("record", TerminationComplete, func(state *AircraftState) bool {
+			pitch[int(math.Round(state.Time*500))] = (PitchAttitude().Measure(state) - theta0) / DEG_TO_RAD
+			return false
+		})
+		policy.Conditions = append(policy.Conditions, MaxSimTime(4))
+		runner := &ScenarioRunner{Engine: engine, Dt: 0.002, Policy: policy}
+		if err := runner.AttachPilot(loop); err != nil {
+			t.Fatalf("AttachPilot: %v", err)
+		}
+		if report := runner.Run(initial); report.Err != nil {
+			t.Fatalf("Run: %v", report.Err)
+		}
+		return loop, pitch
+	}
+
+	t.Run("Pitch Step", func(t *testing.T) {
+		loop, pitch := track(0.15)
+		peak := 0.0
+		for _, theta := range pitch {
+			peak = math.Max(peak, theta)
+		}
+		if peak < 5 || peak > 6.5 {
+			t.Errorf("The step should be reached with under 30%% overshoot, peaked at %.2f°", peak)
+		}
+		for step := 1500; step <= 2000; step += 50 {
+			if math.Abs(pitch[step]-5) > 0.5 {
+				t.Errorf("The pitch should have settled by 3 s, %.2f° at %.1f s", pitch[step], float64(step)/500)
+			}
+		}
+
+		// Before the step the aircraft is in trim and the pilot still
+		assertApproxEqual(t, pitch[400], 0, 1e-3)
+		stats := loop.Stats()
+		assertEqual(t, stats.Samples, 2000)
+		assertApproxEqual(t, stats.MaxError, 5*DEG_TO_RAD, 1e-3)
+		if stats.RMSControl == 0 || stats.ControlActivity == 0 {
+			t.Errorf("The pilot should have moved the stick: %s", stats)
+		}
+	})
+
+	t.Run("Reaction Delay", func(t *testing.T) {
+		// A slower pilot tracks the same step worse, and works harder at it
+		quick, _ := track(0.15)
+		slow, _ := track(0.35)
+		if slow.Stats().RMSError < 1.1*quick.Stats().RMSError {
+			t.Errorf("A longer delay should degrade tracking: RMS error %.4f rad at 0.15 s, %.4f at 0.35 s",
+				quick.Stats().RMSError, slow.Stats().RMSError)
+		}
+		if slow.Stats().RMSControl <= quick.Stats().RMSControl {
+			t.Errorf("A longer delay should take more control: %s and %s", quick.Stats(), slow.Stats())
+		}
+	})
+
+	t.Run("Crossover Pilot", func(t *testing.T) {
+		// Nothing is seen before the delay, then the gain acts at once
+		pilot := NewCrossoverPilot(2, 0, 0, 0, 0.05)
+		for range 5 {
+			assertEqual(t, pilot.Update(1, 0.01), 0.0)
+		}
+		assertEqual(t, pilot.Update(1, 0.01), 2.0)
+
+		// A lead over a lag starts at their ratio and settles to the gain
+		pilot = NewCrossoverPilot(1, 1, 0.5, 0, 0)
+		assertApproxEqual(t, pilot.Update(1, 1e-6), 2, 1e-5)
+		var out float64
+		for range 1000 {
+			out = pilot.Update(1, 0.01)
+		}
+		assertApproxEqual(t, out, 1, 1e-6)
+		pilot.Reset()
+		assertEqual(t, pilot.Update(0, 0.01), 0.0)
+	})
+
+	t.Run("Attach", func(t *testing.T) {
+		runner := &ScenarioRunner{}
+		loop := func(control string) *PilotLoop {
+			return &PilotLoop{
+				Pilot:    NewCrossoverPilot(1, 0, 0, 0, 0),
+				Variable: BankAngle(),
+				Control:  control,
+				Command:  func(float64) float64 { return 0 },
+			}
+		}
+		if err := runner.AttachPilot(loop("fcs/aileron-cmd-norm")); err != nil {
+			t.Fatalf("AttachPilot: %v", err)
+		}
+		if runner.AttachPilot(loop("fcs/aileron-cmd-norm")) == nil {
+			t.Error("A second loop on the ailerons should be refused")
+		}
+		if runner.AttachPilot(loop("fcs/aileron-pos-norm")) == nil {
+			t.Error("A surface position is not a pilot control")
+		}
+		if runner.AttachPilot(&PilotLoop{Control: "fcs/rudder-cmd-norm"}) == nil {
+			t.Error("A loop without a pilot should be refused")
+		}
+	})
+}
@@ -0,0 +1,239 @@
+// Pitot-Static System
+// The airspeed indicator, altimeter and vertical speed indicator as driven
+// by the pitot and static pressures, with the static source's position
+// error, instrument lag and blocked pitot or static ports
+
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// Instrument properties published by PitotStaticSystem
+const (
+	IndicatedAirspeedProperty = "instruments/airspeed-indicated-kts"
+	IndicatedAltitudeProperty = "instruments/altitude-indicated-ft"
+	VerticalSpeedProperty     = "instruments/vsi-fpm"
+)
+
+// ISA sea level values the instruments are calibrated to
+const (
+	isaSeaLevelPressure   = 101325.0 // Pa
+	isaSeaLevelSoundSpeed = 340.294  // m/s
+	isaTropopausePressure = 22632.1  // Pa, at 11,000 m
+	isaPressureExponent   = 5.25588  // g/(R·L) of the troposphere
+	isaLapseRatio         = 2.25577e-5
+)
+
+// PitotStaticFailure is a failure of the pitot-static system
+type PitotStaticFailure int
+
+const (
+	PitotBlocked  PitotStaticFailure = iota // Pitot tube and drain blocked, trapping the total pressure
+	StaticBlocked                           // Static ports blocked, trapping the static pressure
+)
+
+func (f PitotStaticFailure) String() string {
+	switch f {
+	case PitotBlocked:
+		return "blocked pitot"
+	case StaticBlocked:
+		return "blocked static"
+	}
+	return fmt.Sprintf("PitotStaticFailure(%d)", int(f))
+}
+
+// PitotStaticIndications are the instrument readings
+type PitotStaticIndications struct {
+	Airspeed      float64 // Indicated airspeed (kts)
+	Altitude      float64 // Indicated altitude (ft)
+	VerticalSpeed float64 // Vertical speed (ft/min)
+}
+
+// PitotStaticSystem derives the instrument readings from the true
+// atmosphere of each state. The airspeed indicator reads the impact
+// pressure, total less static, on the calibrated airspeed scale; the
+// altimeter reads the static pressure on the standard atmosphere's
+// pressure altitude scale, referenced to the altimeter setting; the VSI
+// reads the rate of change of the indicated altitude. A blocked port traps
+// the pressure it held when it blocked, giving the classic failures: with
+// the pitot blocked the airspeed rises in a climb like an altimeter, and
+// with the static blocked the altimeter freezes and the airspeed errs with
+// the altitude change.
+type PitotStaticSystem struct {
+	// Optional; the static source error as a fraction of the impact
+	// pressure, added to the static pressure, by a 1D table over
+	// aero/alpha-deg, aero/alpha-rad or velocities/mach. Set it with
+	// SetPositionError; there is none when nil.
+	PositionError *ParsedTable
+
+	AltimeterSetting float64 // Pa; standard pressure when 0
+
+	// First order lags of the indications (s); none when 0
+	AirspeedLag, AltimeterLag, VSILag float64
+
+	failed  [2]bool
+	trapped [2]float64 // Pressure held by each blocked port, NaN until captured (Pa)
+
+	total, static float64 // Last pressures sensed (Pa)
+	indicated     PitotStaticIndications
+	altitude      float64 // Unlagged indicated altitude, for the VSI (ft)
+	time          float64
+	started       bool
+}
+
+// NewPitotStaticSystem creates a system without position error, lags or
+// failures, the altimeter set to standard pressure
+func NewPitotStaticSystem() *PitotStaticSystem {
+	return &PitotStaticSystem{trapped: [2]float64{math.NaN(), math.NaN()}}
+}
+
+// SetPositionError sets the position error table, which must be 1D over
+// aero/alpha-deg, aero/alpha-rad or velocities/mach
+func (ps *PitotStaticSystem) SetPositionError(table *ParsedTable) error {
+	if table.Dimension != 1 || table.Data1D == nil {
+		return fmt.Errorf("position error table must be 1D, got %dD", table.Dimension)
+	}
+	switch input := table.IndependentVars[0]; input {
+	case "aero/alpha-deg", "aero/alpha-rad", "velocities/mach":
+	default:
+		return fmt.Errorf("position error table cannot be looked up by %q", input)
+	}
+	ps.PositionError = table
+	return nil
+}
+
+// Fail injects a failure, from the next update. A blocked port traps the
+// pressure last sensed through it.
+func (ps *PitotStaticSystem) Fail(failure PitotStaticFailure) {
+	if ps.failed[failure] {
+		return
+	}
+	ps.failed[failure] = true
+	ps.trapped[failure] = math.NaN()
+	if ps.started {
+		ps.trapped[failure] = [2]float64{ps.total, ps.static}[failure]
+	}
+}
+
+// Clear clears a failure, the port reading the free stream again
+func (ps *PitotStaticSystem) Clear(failure PitotStaticFailure) {
+	ps.failed[failure] = false
+}
+
+// Failed reports whether a failure is injected
+func (ps *PitotStaticSystem) Failed(failure PitotStaticFailure) bool {
+	return ps.failed[failure]
+}
+
+// Update senses the pressures of a state and advances the instruments to
+// its time. The first update sets the instruments without lag.
+func (ps *PitotStaticSystem) Update(state *AircraftState) {
+	impact := state.Pressure * (math.Pow(1+0.2*state.Mach*state.Mach, 3.5) - 1)
+	total := state.Pressure + impact
+	static := state.Pressure + ps.positionError(state)*impact
+	for failure, pressure := range []*float64{&total, &static} {
+		if !ps.failed[failure] {
+			continue
+		}
+		if math.IsNaN(ps.trapped[failure]) {
+			ps.trapped[failure] = *pressure
+		}
+		*pressure = ps.trapped[failure]
+	}
+	ps.total, ps.static = total, static
+
+	airspeed := calibratedAirspeed(total-static) * MS_TO_KT
+	altitude := ps.pressureAltitude(static) * M_TO_FT
+	if !ps.started {
+		ps.started, ps.time, ps.altitude = true, state.Time, altitude
+		ps.indicated = PitotStaticIndications{Airspeed: airspeed, Altitude: altitude}
+		return
+	}
+
+	dt := state.Time - ps.time
+	if dt <= 0 {
+		return
+	}
+	climb := (altitude - ps.altitude) / dt * 60
+	ps.time, ps.altitude = state.Time, altitude
+	ps.indicated.Airspeed = lagged(ps.indicated.Airspeed, airspeed, ps.AirspeedLag, dt)
+	ps.indicated.Altitude = lagged(ps.indicated.Altitude, altitude, ps.AltimeterLag, dt)
+	ps.indicated.VerticalSpeed = lagged(ps.indicated.VerticalSpeed, climb, ps.VSILag, dt)
+}
+
+// lagged advances a first order lag of time constant tau by dt
+func lagged(output, input, tau, dt float64) float64 {
+	if tau <= 0 {
+		return input
+	}
+	return output + (input-output)*dt/(tau+dt)
+}
+
+// positionError returns the static source error of a state as a fraction
+// of the impact pressure
+func (ps *PitotStaticSystem) positionError(state *AircraftState) float64 {
+	if ps.PositionError == nil {
+		return 0
+	}
+	var input float64
+	switch ps.PositionError.IndependentVars[0] {
+	case "aero/alpha-deg":
+		input = state.Alpha * RAD_TO_DEG
+	case "aero/alpha-rad":
+		input = state.Alpha
+	case "velocities/mach":
+		input = state.Mach
+	}
+	value, err := InterpolateTable(ps.PositionError, input)
+	if err != nil {
+		return 0
+	}
+	return value
+}
+
+// calibratedAirspeed returns the airspeed of an impact pressure (Pa) on the
+// subsonic calibrated airspeed scale (m/s); none for a negative one
+func calibratedAirspeed(impact float64) float64 {
+	if impact <= 0 {
+		return 0
+	}
+	return isaSeaLevelSoundSpeed * math.Sqrt(5*(math.Pow(impact/isaSeaLevelPressure+1, 2/7.0)-1))
+}
+
+// pressureAltitude returns the standard atmosphere altitude of a static
+// pressure (m), referenced to the altimeter setting
+func (ps *PitotStaticSystem) pressureAltitude(static float64) float64 {
+	setting := ps.AltimeterSetting
+	if setting <= 0 {
+		setting = isaSeaLevelPressure
+	}
+	static *= isaSeaLevelPressure / setting
+	if static < isaTropopausePressure {
+		return 11000 - math.Log(static/isaTropopausePressure)*287.05*216.65/9.80665
+	}
+	return (1 - math.Pow(static/isaSeaLevelPressure, 1/isaPressureExponent)) / isaLapseRatio
+}
+
+// Indications returns the instrument readings as of the last update
+func (ps *PitotStaticSystem) Indications() PitotStaticIndications {
+	return ps.indicated
+}
+
+// Publish sets the instrument properties in the property manager
+func (ps *PitotStaticSystem) Publish(properties *PropertyManager) {
+	properties.Set(IndicatedAirspeedProperty, ps.indicated.Airspeed)
+	properties.Set(IndicatedAltitudeProperty, ps.indicated.Altitude)
+	properties.Set(VerticalSpeedProperty, ps.indicated.VerticalSpeed)
+}
+
+// Attach makes the system update from every state evaluated by the bus and
+// publish its instruments. Failures can be injected at set times with the
+// bus's OnTime watchers.
+func (ps *PitotStaticSystem) Attach(bus *EventBus, properties *PropertyManager) *EventWatcher {
+	return bus.OnStep(func(state *AircraftState, _ float64) {
+		ps.Update(state)
+		ps.Publish(properties)
+	})
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// climbState returns a state climbing from 1000 m at 10 m/s and a constant
+// 100 m/s true airspeed, at time t
+func climbState(t float64) *AircraftState {
+	state := cruiseState(1000+10*t, 100, 0)
+	state.Time = t
+	return state
+}
+
+// flyClimb updates a system through a minute of the climb, failing it
+// after the first update when failure is not negative, and returns the
+// indications every 10 s
+func flyClimb(ps *PitotStaticSystem, failure PitotStaticFailure) []PitotStaticIndications {
+	var readings []PitotStaticIndications
+	for i := 0; i <= 600; i++ {
+		ps.Update(climbState(float64(i) * 0.1))
+		if i == 0 && failure >= 0 {
+			ps.Fail(failure)
+		}
+		if i%100 == 0 {
+			readings = append(readings, ps.Indications())
+		}
+	}
+	return readings
+}
+
+func TestPitotStaticSystem(t *testing.T) {
+	healthy := flyClimb(NewPitotStaticSystem(), -1)
+
+	t.Run("Healthy", func(t *testing.T) {
+		ps := NewPitotStaticSystem()
+		ps.Update(cruiseState(0, 100, 0))
+		assertApproxEqual(t, ps.Indications().Airspeed, 100*MS_TO_KT, 0.01)
+		assertApproxEqual(t, ps.Indications().Altitude, 0, 0.1)
+
+		// In the climb the indicated airspeed falls with the density
+		last := healthy[len(healthy)-1]
+		assertApproxEqual(t, last.Altitude, 1600*M_TO_FT, 1)
+		assertApproxEqual(t, last.VerticalSpeed, 10*M_TO_FT*60, 1)
+		if last.Airspeed >= healthy[0].Airspeed {
+			t.Errorf("Indicated airspeed should fall in the climb, from %.1f to %.1f kts", healthy[0].Airspeed, last.Airspeed)
+		}
+	})
+
+	t.Run("Blocked Pitot", func(t *testing.T) {
+		readings := flyClimb(NewPitotStaticSystem(), PitotBlocked)
+		for i := 1; i < len(readings); i++ {
+			if readings[i].Airspeed <= readings[i-1].Airspeed {
+				t.Fatalf("Airspeed should rise in the climb like an altimeter, %.1f then %.1f kts", readings[i-1].Airspeed, readings[i].Airspeed)
+			}
+		}
+		// The altimeter and VSI still work
+		assertEqual(t, readings[len(readings)-1].Altitude, healthy[len(healthy)-1].Altitude)
+		assertEqual(t, readings[len(readings)-1].VerticalSpeed, healthy[len(healthy)-1].VerticalSpeed)
+	})
+
+	t.Run("Blocked Static", func(t *testing.T) {
+		readings := flyClimb(NewPitotStaticSystem(), StaticBlocked)
+		for i, r := range readings {
+			assertEqual(t, r.Altitude, readings[0].Altitude)
+			assertEqual(t, r.VerticalSpeed, 0.0)
+			if i > 0 && r.Airspeed >= healthy[i].Airspeed {
+				t.Errorf("Airspeed should read low above the blockage, %.1f against %.1f kts", r.Airspeed, healthy[i].Airspeed)
+			}
+		}
+	})
+
+	t.Run("Clear", func(t *testing.T) {
+		ps := NewPitotStaticSystem()
+		flyClimb(ps, StaticBlocked)
+		ps.Clear(StaticBlocked)
+		ps.Update(climbState(60.1))
+		assertApproxEqual(t, ps.Indications().Altitude, climbState(60.1).Altitude*M_TO_FT, 1)
+		assertEqual(t, ps.Failed(StaticBlocked), false)
+	})
+
+	t.Run("Lag", func(t *testing.T) {
+		ps := NewPitotStaticSystem()
+		ps.AirspeedLag = 2
+		ps.Update(cruiseState(0, 100, 0))
+		for i := 1; i <= 200; i++ {
+			state := cruiseState(0, 120, 0)
+			state.Time = float64(i) * 0.01
+			ps.Update(state)
+		}
+		// One time constant covers 63% of the step
+		fraction := (ps.Indications().Airspeed - 100*MS_TO_KT) / (20 * MS_TO_KT)
+		assertApproxEqual(t, fraction, 1-math.Exp(-1), 0.01)
+	})
+
+	t.Run("Position Error", func(t *testing.T) {
+		ps := NewPitotStaticSystem()
+		err := ps.SetPositionError(&ParsedTable{
+			Dimension:       1,
+			IndependentVars: []string{"aero/alpha-deg"},
+			Data1D:          &Table1D{Indices: []float64{0, 10}, Values: []float64{0, 0.05}},
+		})
+		if err != nil {
+			t.Fatalf("SetPositionError: %v", err)
+		}
+		ps.Update(cruiseState(1000, 60, 10*DEG_TO_RAD))
+		perfect := NewPitotStaticSystem()
+		perfect.Update(cruiseState(1000, 60, 10*DEG_TO_RAD))
+
+		// Raising the static pressure under-reads both
+		if ps.Indications().Altitude >= perfect.Indications().Altitude || ps.Indications().Airspeed >= perfect.Indications().Airspeed {
+			t.Errorf("Expected low readings with the error, got %+v against %+v", ps.Indications(), perfect.Indications())
+		}
+
+		if err := ps.SetPositionError(&ParsedTable{Dimension: 1, IndependentVars: []string{"aero/qbar-psf"}, Data1D: &Table1D{}}); err == nil {
+			t.Error("Tables over other inputs should be rejected")
+		}
+	})
+
+	t.Run("Event Bus", func(t *testing.T) {
+		ps := NewPitotStaticSystem()
+		bus := NewEventBus()
+		properties := NewPropertyManager()
+		bus.OnTime(30, 0, func(*AircraftState, float64) { ps.Fail(StaticBlocked) })
+		ps.Attach(bus, properties)
+
+		var frozen float64
+		for i := 0; i <= 600; i++ {
+			state := climbState(float64(i) * 0.1)
+			bus.Evaluate(state)
+			if i == 300 {
+				frozen = properties.Get(IndicatedAltitudeProperty)
+			}
+		}
+		assertApproxEqual(t, frozen, climbState(29.9).Altitude*M_TO_FT, 1)
+		assertEqual(t, properties.Get(IndicatedAltitudeProperty), frozen)
+		assertEqual(t, properties.Get(VerticalSpeedProperty), 0.0)
+		if properties.Get(IndicatedAirspeedProperty) <= 0 {
+			t.Error("The airspeed should be published")
+		}
+	})
+}
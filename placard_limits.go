@@ -0,0 +1,145 @@
+// Placard Limits
+// Flap and gear speed limits, announced when exceeded and optionally
+// enforced by refusing to extend the flaps or gear above them
+
+package main
+
+import (
+	"math"
+	"sort"
+)
+
+// Placard limit names
+const (
+	LimitVfe = "vfe"
+	LimitVle = "vle"
+	LimitVno = "vno"
+)
+
+// FlapSpeedLimit is the highest speed for flaps extended up to a detent
+type FlapSpeedLimit struct {
+	Deflection float64 // Flap detent (deg)
+	Vfe        float64 // Calibrated airspeed (m/s)
+}
+
+// PlacardLimits are the placarded speeds of an aircraft, all calibrated
+// airspeeds in m/s, a zero speed disabling its check. A flap deflection is
+// limited by the Vfe of the lowest detent at or beyond it, the flaps
+// beyond the last detent by the last; retracted flaps are not limited.
+// Vle limits the gear anywhere but up.
+//
+// With Interlock set, the flap and gear kinematics refuse to extend them
+// above their limit speeds, holding them where they are. Retraction, and
+// the gear's emergency extension, are never refused.
+type PlacardLimits struct {
+	Vfe       []FlapSpeedLimit
+	Vle       float64 // Maximum landing gear extended speed
+	Vno       float64 // Maximum structural cruising speed
+	Vne       float64 // Never-exceed speed
+	Interlock bool
+}
+
+// NewP51DPlacardLimits returns the P-51D placards: flaps and gear below
+// 165 and 170 mph IAS, never exceed 505 mph IAS. The aircraft has no
+// structural cruising speed.
+func NewP51DPlacardLimits() *PlacardLimits {
+	return &PlacardLimits{
+		Vfe: []FlapSpeedLimit{{Deflection: MaxFlapDeflectionDeg, Vfe: 165 * MPH_TO_MS}},
+		Vle: 170 * MPH_TO_MS,
+		Vne: 505 * MPH_TO_MS,
+	}
+}
+
+// PlacardLimitsFromConfig returns the limits of a configuration's placards
+// section, or nil when it has none. A vfe without a flaps deflection limits
+// every deflection.
+func PlacardLimitsFromConfig(config *JSBSimConfig) *PlacardLimits {
+	placards := config.Placards
+	if placards == nil {
+		return nil
+	}
+	limits := &PlacardLimits{
+		Vle: measurementValue(placards.Vle) * FT_TO_M,
+		Vno: measurementValue(placards.Vno) * FT_TO_M,
+		Vne: measurementValue(placards.Vne) * FT_TO_M,
+	}
+	for _, vfe := range placards.Vfe {
+		deflection := vfe.Flaps
+		if deflection <= 0 {
+			deflection = math.Inf(1)
+		}
+		limits.Vfe = append(limits.Vfe, FlapSpeedLimit{Deflection: deflection, Vfe: vfe.Value * FT_TO_M})
+	}
+	sort.Slice(limits.Vfe, func(i, j int) bool { return limits.Vfe[i].Deflection < limits.Vfe[j].Deflection })
+	return limits
+}
+
+// FlapSpeed returns the limit speed of a flap deflection (deg), zero when
+// it has none
+func (l *PlacardLimits) FlapSpeed(deflection float64) float64 {
+	if deflection <= 0 || len(l.Vfe) == 0 {
+		return 0
+	}
+	for _, detent := range l.Vfe {
+		if deflection <= detent.Deflection {
+			return detent.Vfe
+		}
+	}
+	return l.Vfe[len(l.Vfe)-1].Vfe
+}
+
+// flapExtension returns the furthest flap deflection (deg) allowed at a
+// calibrated airspeed, or -1 when any is
+func (l *PlacardLimits) flapExtension(airspeed float64) float64 {
+	allowed := 0.0
+	for _, detent := range l.Vfe {
+		if detent.Vfe > 0 && airspeed > detent.Vfe {
+			return allowed
+		}
+		allowed = detent.Deflection
+	}
+	return -1
+}
+
+// holdFlaps returns the flap position (rad) the flaps may move toward from
+// their position at a state, short of the target when the interlock
+// refuses it, and whether it did
+func (l *PlacardLimits) holdFlaps(state *AircraftState, target float64) (float64, bool) {
+	position := state.ControlSurfaces.FlapLeft
+	if l == nil || !l.Interlock || target <= position {
+		return target, false
+	}
+	allowed := l.flapExtension(state.CalibratedAirspeed)
+	if allowed < 0 || target <= allowed*DEG_TO_RAD {
+		return target, false
+	}
+	return max(position, allowed*DEG_TO_RAD), true
+}
+
+// holdGear reports whether the interlock refuses the commanded gear
+// extension at a state
+func (l *PlacardLimits) holdGear(state *AircraftState) bool {
+	return l != nil && l.Interlock && l.Vle > 0 && state.Controls.Gear &&
+		state.Gear.Transition < 1 && state.CalibratedAirspeed > l.Vle
+}
+
+// Check returns the placard speeds the state exceeds
+func (l *PlacardLimits) Check(state *AircraftState) []LimitExceedance {
+	if l == nil {
+		return nil
+	}
+	var exceeded []LimitExceedance
+	speed := state.CalibratedAirspeed
+	check := func(limit string, bound float64) {
+		if bound > 0 && speed > bound {
+			exceeded = append(exceeded, LimitExceedance{Time: state.Time, Limit: limit, Value: speed, Bound: bound})
+		}
+	}
+	check(LimitVfe, l.FlapSpeed(state.ControlSurfaces.FlapLeft*RAD_TO_DEG))
+	if state.Gear.Transition > 0 {
+		check(LimitVle, l.Vle)
+	}
+	check(LimitVno, l.Vno)
+	check(LimitVne, l.Vne)
+	return exceeded
+}
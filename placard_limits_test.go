@@ -0,0 +1,105 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPlacardLimits(t *testing.T) {
+	// fly commands flaps and gear down at 100 m/s, well above the P-51D's
+	// 165 mph (74 m/s) Vfe and 170 mph Vle, and steps for 2 s
+	fly := func(interlock bool) (*SimplifiedFlightDynamicsEngine, *AircraftState) {
+		t.Helper()
+		engine := NewSimplifiedFlightDynamicsEngine(NewRungeKutta4Integrator())
+		engine.Placards = NewP51DPlacardLimits()
+		engine.Placards.Interlock = interlock
+		state := trimLevelFlight(t, engine, 1000, 100)
+		state.Controls.Flaps, state.Controls.Gear = 1, true
+		for i := 0; i < 200; i++ {
+			next, err := engine.Step(state, 0.01)
+			if err != nil {
+				t.Fatalf("Step: %v", err)
+			}
+			state = next
+		}
+		return engine, state
+	}
+
+	t.Run("Interlock", func(t *testing.T) {
+		engine, state := fly(true)
+		assertApproxEqual(t, state.ControlSurfaces.FlapLeft, 0, 1e-12)
+		assertApproxEqual(t, state.Gear.Transition, 0, 1e-12)
+		assertEqual(t, state.Placards.FlapsHeld, true)
+		properties := state.ToPropertyMap()
+		assertApproxEqual(t, properties["limits/overspeed-flaps"], 1, 1e-12)
+		assertApproxEqual(t, properties["limits/overspeed-gear"], 1, 1e-12)
+
+		// Nothing extended, nothing was exceeded
+		assertEqual(t, len(engine.Statistics.Exceedances), 0)
+	})
+
+	t.Run("No Interlock", func(t *testing.T) {
+		engine, state := fly(false)
+		if state.ControlSurfaces.FlapLeft < 9*DEG_TO_RAD || state.Gear.Transition <= 0.2 {
+			t.Fatalf("Without the interlock the flaps and gear should extend, %.1f° and %.2f",
+				state.ControlSurfaces.FlapLeft*RAD_TO_DEG, state.Gear.Transition)
+		}
+		assertEqual(t, state.Placards.OverspeedFlaps, true)
+		assertEqual(t, state.Placards.OverspeedGear, true)
+
+		// Each exceedance is recorded once, as it begins
+		limits := map[string]int{}
+		for _, e := range engine.Statistics.Exceedances {
+			limits[e.Limit]++
+			assertApproxEqual(t, e.Bound, map[string]float64{LimitVfe: 165 * MPH_TO_MS, LimitVle: 170 * MPH_TO_MS}[e.Limit], 1e-9)
+		}
+		assertEqual(t, limits, map[string]int{LimitVfe: 1, LimitVle: 1})
+	})
+
+	t.Run("Detents", func(t *testing.T) {
+		limits := &PlacardLimits{
+			Vfe:       []FlapSpeedLimit{{Deflection: 15, Vfe: 90}, {Deflection: 40, Vfe: 70}},
+			Interlock: true,
+		}
+		assertApproxEqual(t, limits.FlapSpeed(0), 0, 1e-12)
+		assertApproxEqual(t, limits.FlapSpeed(10), 90, 1e-12)
+		assertApproxEqual(t, limits.FlapSpeed(30), 70, 1e-12)
+
+		// Between the limits the flaps extend only to the first detent
+		state := NewAircraftState()
+		state.CalibratedAirspeed = 80
+		target, held := limits.holdFlaps(state, 40*DEG_TO_RAD)
+		assertApproxEqual(t, target, 15*DEG_TO_RAD, 1e-12)
+		assertEqual(t, held, true)
+
+		// And are never held from retracting
+		state.ControlSurfaces.FlapLeft = 40 * DEG_TO_RAD
+		target, held = limits.holdFlaps(state, 0)
+		assertApproxEqual(t, target, 0, 1e-12)
+		assertEqual(t, held, false)
+	})
+
+	t.Run("Config", func(t *testing.T) {
+		config, err := ParseJSBSimConfig(strings.NewReader(`<fdm_config name="placards">
+	<placards>
+		<vfe unit="MPH" flaps="40"> 165 </vfe>
+		<vfe unit="MPH" flaps="15"> 250 </vfe>
+		<vle unit="KTS"> 148 </vle>
+		<vne unit="M/S"> 225 </vne>
+	</placards>
+</fdm_config>`))
+		if err != nil {
+			t.Fatalf("Parse failed: %v", err)
+		}
+		assertEqual(t, len(config.Warnings), 0)
+		limits := PlacardLimitsFromConfig(config)
+		assertEqual(t, len(limits.Vfe), 2)
+		assertApproxEqual(t, limits.Vfe[0].Deflection, 15, 1e-12)
+		assertApproxEqual(t, limits.Vfe[0].Vfe, 250*MPH_TO_MS, 1e-4)
+		assertApproxEqual(t, limits.Vfe[1].Vfe, 165*MPH_TO_MS, 1e-4)
+		assertApproxEqual(t, limits.Vle, 148*KT_TO_MS, 1e-4)
+		assertApproxEqual(t, limits.Vne, 225, 1e-4)
+		assertApproxEqual(t, limits.Vno, 0, 1e-12)
+		assertEqual(t, NewFlightDynamicsEngine(config, NewEulerIntegrator()).Placards, limits)
+	})
+}
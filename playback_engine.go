@@ -0,0 +1,379 @@
+// Time-History Playback
+// Re-runs a flight control system against a recorded trajectory
+
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Recording is a time history read back from an OutputManager CSV: one
+// value per column at each sample time
+type Recording struct {
+	Columns []string    // Property names, in column order
+	Times   []float64   // Sample times, from simulation/sim-time-sec
+	Values  [][]float64 // Values[i][j] is column j at Times[i]
+
+	index map[string]int
+}
+
+// ReadRecording reads a recording written by an OutputManager. Given the
+// <output> definition it was written with, captions are mapped back to
+// their property names and the output type selects the delimiter; without
+// one the headers must be property names. The recording must include
+// simulation/sim-time-sec, strictly increasing.
+func ReadRecording(r io.Reader, output *Output) (*Recording, error) {
+	reader := csv.NewReader(r)
+	captions := map[string]string{}
+	if output != nil {
+		om, err := NewOutputManager(output, 1, io.Discard)
+		if err != nil {
+			return nil, err
+		}
+		if om.writer.Comma == '\t' {
+			reader.Comma = '\t'
+		}
+		for i, header := range om.Headers {
+			captions[header] = om.Columns[i]
+		}
+	}
+	return readRecording(reader, func(header string) string {
+		if name, ok := captions[header]; ok {
+			return name
+		}
+		return normalizePropertyName(header)
+	})
+}
+
+// readRecording reads a CSV time history, naming each column's property
+// from its header
+func readRecording(reader *csv.Reader, property func(header string) string) (*Recording, error) {
+	headers, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading recording header: %w", err)
+	}
+	rec := &Recording{index: make(map[string]int, len(headers))}
+	for j, header := range headers {
+		name := property(header)
+		rec.Columns = append(rec.Columns, name)
+		rec.index[name] = j
+	}
+	timeColumn, ok := rec.index["simulation/sim-time-sec"]
+	if !ok {
+		return nil, fmt.Errorf("recording has no simulation/sim-time-sec column")
+	}
+
+	for line := 2; ; line++ {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading recording: %w", err)
+		}
+		row := make([]float64, len(fields))
+		for j, field := range fields {
+			if row[j], err = strconv.ParseFloat(strings.TrimSpace(field), 64); err != nil {
+				return nil, fmt.Errorf("line %d, column %s: %w", line, rec.Columns[j], err)
+			}
+		}
+		t := row[timeColumn]
+		if n := len(rec.Times); n > 0 && t <= rec.Times[n-1] {
+			return nil, fmt.Errorf("line %d: time %g is not after %g", line, t, rec.Times[n-1])
+		}
+		rec.Times = append(rec.Times, t)
+		rec.Values = append(rec.Values, row)
+	}
+
+	if len(rec.Times) == 0 {
+		return nil, fmt.Errorf("recording has no samples")
+	}
+	return rec, nil
+}
+
+// Has reports whether the recording includes a property
+func (rec *Recording) Has(name string) bool {
+	_, ok := rec.index[name]
+	return ok
+}
+
+// Value returns a recorded property at time t, interpolated linearly
+// between samples and held at the ends of the recording
+func (rec *Recording) Value(name string, t float64) (float64, bool) {
+	j, ok := rec.index[name]
+	if !ok {
+		return 0, false
+	}
+
+	n := len(rec.Times)
+	i := sort.SearchFloat64s(rec.Times, t)
+	switch {
+	case i == 0:
+		return rec.Values[0][j], true
+	case i == n:
+		return rec.Values[n-1][j], true
+	}
+	f := (t - rec.Times[i-1]) / (rec.Times[i] - rec.Times[i-1])
+	return rec.Values[i-1][j] + (rec.Values[i][j]-rec.Values[i-1][j])*f, true
+}
+
+// State reconstructs the aircraft state at sample i from the properties the
+// aircraft state publishes. Quantities derived from others (air data,
+// angles of attack, Euler angles) are recomputed rather than read, so the
+// recording needs the primary ones: altitude, attitude, body velocities or
+// airspeed with alpha and beta, rates and controls. Anything missing keeps
+// its NewAircraftState default.
+func (rec *Recording) State(i int) *AircraftState {
+	row := rec.Values[i]
+	get := func(name string) (float64, bool) {
+		if j, ok := rec.index[name]; ok {
+			return row[j], true
+		}
+		return 0, false
+	}
+	set := func(field *float64, name string) {
+		if value, ok := get(name); ok {
+			*field = value
+		}
+	}
+
+	state := NewAircraftState()
+	state.Time = rec.Times[i]
+
+	// Position and attitude
+	set(&state.Latitude, "position/latitude-rad")
+	set(&state.Longitude, "position/longitude-rad")
+	set(&state.Altitude, "position/h-sl-m")
+	state.Position.Z = -state.Altitude
+	if agl, ok := get("position/h-agl-m"); ok {
+		state.Gear.GroundHeight = state.Altitude - agl
+	}
+	set(&state.Gear.GroundHeight, "position/terrain-elevation-m")
+	var roll, pitch, yaw float64
+	set(&roll, "attitude/roll-rad")
+	set(&pitch, "attitude/pitch-rad")
+	set(&yaw, "attitude/heading-rad")
+	state.Orientation = NewQuaternionFromEuler(roll, pitch, yaw)
+
+	// Body velocities, or the airspeed along the recorded flow angles
+	_, hasU := get("velocities/u-mps")
+	if vt, ok := get("velocities/vt-mps"); ok && !hasU {
+		var alpha, beta float64
+		set(&alpha, "aero/alpha-rad")
+		set(&beta, "aero/beta-rad")
+		state.Velocity = Vector3{
+			X: vt * math.Cos(alpha) * math.Cos(beta),
+			Y: vt * math.Sin(beta),
+			Z: -vt * math.Sin(alpha) * math.Cos(beta),
+		}
+	}
+	set(&state.Velocity.X, "velocities/u-mps")
+	set(&state.Velocity.Y, "velocities/v-mps")
+	set(&state.Velocity.Z, "velocities/w-mps")
+	set(&state.AngularRate.X, "velocities/p-rad_sec")
+	set(&state.AngularRate.Y, "velocities/q-rad_sec")
+	set(&state.AngularRate.Z, "velocities/r-rad_sec")
+
+	// Controls and surfaces
+	controls := &state.Controls
+	set(&controls.Aileron, "fcs/aileron-cmd-norm")
+	set(&controls.Elevator, "fcs/elevator-cmd-norm")
+	set(&controls.Rudder, "fcs/rudder-cmd-norm")
+	set(&controls.Throttle, "fcs/throttle-cmd-norm")
+	set(&controls.Flaps, "fcs/flap-cmd-norm")
+	set(&controls.Steer, "fcs/steer-cmd-norm")
+	if gear, ok := get("fcs/gear-cmd-norm"); ok {
+		controls.Gear = gear >= 0.5
+		state.SetControlInputs(*controls)
+	}
+	surfaces := &state.ControlSurfaces
+	set(&surfaces.AileronLeft, "fcs/left-aileron-pos-rad")
+	set(&surfaces.AileronRight, "fcs/right-aileron-pos-rad")
+	set(&surfaces.Elevator, "fcs/elevator-pos-rad")
+	set(&surfaces.Rudder, "fcs/rudder-pos-rad")
+	if flaps, ok := get("fcs/flap-pos-deg"); ok {
+		surfaces.FlapLeft = flaps * DEG_TO_RAD
+		surfaces.FlapRight = surfaces.FlapLeft
+	}
+
+	set(&state.Engine.Thrust, "propulsion/engine/thrust-N")
+	set(&state.Engine.RPM, "engines/engine/rpm")
+	set(&state.Engine.ManifoldP, "engines/engine/mp-inHg")
+
+	state.UpdateFlightConditions()
+	return state
+}
+
+// PlaybackEngine feeds a recorded trajectory through a flight control
+// system without re-running the dynamics, so a modified FCS can be compared
+// against the surface commands of the run that was recorded. The FCS steps
+// at its default rate against states interpolated from the recording, so
+// the recording may be sampled at a different rate.
+type PlaybackEngine struct {
+	FCS       *FlightControlSystem
+	Recording *Recording
+
+	// Outputs are the properties compared; by default every recorded
+	// property the FCS drives
+	Outputs []string
+
+	// Inputs are recorded properties the aircraft state does not carry,
+	// such as autopilot settings, set on the FCS before each step. By
+	// default every such property the FCS does not itself drive.
+	Inputs []string
+
+	trajectory *TrajectoryInterpolator
+}
+
+// NewPlaybackEngine prepares a recording for playback through fcs
+func NewPlaybackEngine(fcs *FlightControlSystem, rec *Recording) (*PlaybackEngine, error) {
+	if fcs == nil || rec == nil {
+		return nil, fmt.Errorf("playback needs a flight control system and a recording")
+	}
+	if fcs.DefaultRate <= 0 {
+		return nil, fmt.Errorf("flight control system %q has no rate", fcs.Name)
+	}
+
+	samples := make([]TrajectorySample, len(rec.Times))
+	for i, t := range rec.Times {
+		samples[i] = TrajectorySample{Time: t, State: rec.State(i)}
+	}
+	trajectory, err := NewTrajectoryInterpolator(samples)
+	if err != nil {
+		return nil, err
+	}
+
+	pe := &PlaybackEngine{FCS: fcs, Recording: rec, trajectory: trajectory}
+	carried := NewAircraftState().ToPropertyMap()
+	for _, name := range rec.Columns {
+		switch _, isState := carried[name]; {
+		case fcs.DrivesProperty(name):
+			pe.Outputs = append(pe.Outputs, name)
+		case !isState:
+			pe.Inputs = append(pe.Inputs, name)
+		}
+	}
+	return pe, nil
+}
+
+// PlaybackStats compares one replayed output with the recorded one
+type PlaybackStats struct {
+	Name        string
+	MaxDiff     float64 // Largest absolute difference
+	MaxDiffTime float64 // Time of the largest difference
+	RMSDiff     float64
+}
+
+// PlaybackResult holds the recorded and replayed outputs at each FCS step
+type PlaybackResult struct {
+	Outputs  []string
+	Times    []float64
+	Original [][]float64 // Original[i][k] is output k as recorded, at Times[i]
+	Replayed [][]float64 // Replayed[i][k] is output k from the FCS under test
+	Stats    []PlaybackStats
+}
+
+// Run resets the FCS and steps it through the whole recording
+func (pe *PlaybackEngine) Run() (*PlaybackResult, error) {
+	if len(pe.Outputs) == 0 {
+		return nil, fmt.Errorf("no recorded outputs to compare")
+	}
+	for _, name := range pe.Outputs {
+		if !pe.Recording.Has(name) {
+			return nil, fmt.Errorf("output %s is not in the recording", name)
+		}
+	}
+
+	fcs := pe.FCS
+	fcs.Reset()
+	dt := 1.0 / fcs.DefaultRate
+	start, end := pe.trajectory.Span()
+	steps := int(math.Floor((end-start)/dt+1e-9)) + 1
+
+	result := &PlaybackResult{
+		Outputs: pe.Outputs,
+		Stats:   make([]PlaybackStats, len(pe.Outputs)),
+	}
+	sumSquares := make([]float64, len(pe.Outputs))
+	for k, name := range pe.Outputs {
+		result.Stats[k].Name = name
+	}
+
+	for i := 0; i < steps; i++ {
+		t := start + float64(i)*dt
+		state, err := pe.trajectory.At(t)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range pe.Inputs {
+			value, _ := pe.Recording.Value(name, t)
+			fcs.Properties.Set(name, value)
+		}
+		fcs.Execute(state, dt)
+
+		original := make([]float64, len(pe.Outputs))
+		replayed := make([]float64, len(pe.Outputs))
+		for k, name := range pe.Outputs {
+			original[k], _ = pe.Recording.Value(name, t)
+			replayed[k] = fcs.Properties.Get(name)
+
+			diff := math.Abs(replayed[k] - original[k])
+			sumSquares[k] += diff * diff
+			if stats := &result.Stats[k]; diff > stats.MaxDiff {
+				stats.MaxDiff, stats.MaxDiffTime = diff, t
+			}
+		}
+		result.Times = append(result.Times, t)
+		result.Original = append(result.Original, original)
+		result.Replayed = append(result.Replayed, replayed)
+	}
+
+	for k := range result.Stats {
+		result.Stats[k].RMSDiff = math.Sqrt(sumSquares[k] / float64(steps))
+	}
+	return result, nil
+}
+
+// WriteCSV writes the time and, for each output, the recorded value
+// followed by the replayed one
+func (r *PlaybackResult) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	header := []string{"simulation/sim-time-sec"}
+	for _, name := range r.Outputs {
+		header = append(header, name, name+" (playback)")
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	row := make([]string, len(header))
+	for i, t := range r.Times {
+		row[0] = strconv.FormatFloat(t, 'g', -1, 64)
+		for k := range r.Outputs {
+			row[1+2*k] = strconv.FormatFloat(r.Original[i][k], 'g', -1, 64)
+			row[2+2*k] = strconv.FormatFloat(r.Replayed[i][k], 'g', -1, 64)
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// String summarizes the differences for each output
+func (r *PlaybackResult) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Playback of %d FCS steps\n", len(r.Times))
+	for _, stats := range r.Stats {
+		fmt.Fprintf(&sb, "  %-30s max %.6g at %.3fs, RMS %.6g\n",
+			stats.Name, stats.MaxDiff, stats.MaxDiffTime, stats.RMSDiff)
+	}
+	return sb.String()
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+const playbackOutputXML = `<fdm_config name="playback">
+	<output name="recording.csv" type="CSV" rate="50">
+		<simulation> ON </simulation>
+		<rates> ON </rates>
+		<velocities> ON </velocities>
+		<position> ON </position>
+		<aerosurfaces> ON </aerosurfaces>
+		<fcs> ON </fcs>
+		<property caption="Damper"> fcs/pitch-damper-cmd </property>
+	</output>
+</fdm_config>`
+
+// pitchDamperFCS returns an FCS running at rateHz that adds a pitch rate
+// damper to the pilot's elevator command
+func pitchDamperFCS(gain, rateHz float64) *FlightControlSystem {
+	fcs := NewFlightControlSystem("Pitch Damper", rateHz)
+	fcs.AddComponent(NewGainComponent("fcs/pitch-damper", "velocities/q-rad_sec", "fcs/pitch-damper-cmd", gain))
+	fcs.AddComponent(NewSummerComponent("fcs/elevator-sum",
+		[]string{"fcs/elevator-cmd-norm", "fcs/pitch-damper-cmd"}, "fcs/elevator-pos-rad"))
+	return fcs
+}
+
+func TestPlaybackEngine(t *testing.T) {
+	config, err := ParseJSBSimConfig(strings.NewReader(playbackOutputXML))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	// Record 10 s with an elevator doublet from 2 to 4 s. The simplified
+	// model's pitch damping needs a short step; the file is written at 50 Hz.
+	engine := NewSimplifiedFlightDynamicsEngine(NewEulerIntegrator())
+	state := trimLevelFlight(t, engine, 1000.0, 100.0)
+	trim := state.Controls.Elevator
+	recorded := pitchDamperFCS(0.5, 500.0) // Executes in every frame
+
+	var buf bytes.Buffer
+	om, err := NewOutputManager(config.PrimaryOutput(), 500.0, &buf)
+	if err != nil {
+		t.Fatalf("NewOutputManager: %v", err)
+	}
+	om.Properties = recorded.Properties
+	for i := 0; i < 5000; i++ {
+		state.Controls.Elevator = trim
+		switch {
+		case state.Time >= 2 && state.Time < 3:
+			state.Controls.Elevator += 0.05
+		case state.Time >= 3 && state.Time < 4:
+			state.Controls.Elevator -= 0.05
+		}
+		recorded.Execute(state, 0.002)
+		if err := om.Record(state); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+		if state, err = engine.Step(state, 0.002); err != nil {
+			t.Fatalf("Step %d failed: %v", i, err)
+		}
+	}
+	if err := om.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	recording, err := ReadRecording(bytes.NewReader(buf.Bytes()), config.PrimaryOutput())
+	if err != nil {
+		t.Fatalf("ReadRecording: %v", err)
+	}
+	assertEqual(t, len(recording.Times), 500)
+	assertEqual(t, recording.Has("fcs/pitch-damper-cmd"), true)
+
+	play := func(gain float64) *PlaybackResult {
+		pe, err := NewPlaybackEngine(pitchDamperFCS(gain, 120.0), recording)
+		if err != nil {
+			t.Fatalf("NewPlaybackEngine: %v", err)
+		}
+		assertEqual(t, pe.Outputs, []string{"fcs/elevator-pos-rad", "fcs/pitch-damper-cmd"})
+		result, err := pe.Run()
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		return result
+	}
+
+	t.Run("Unchanged FCS", func(t *testing.T) {
+		// At 120 Hz against a 50 Hz recording the replayed outputs match the
+		// recorded ones interpolated to the same times
+		result := play(0.5)
+		assertEqual(t, len(result.Times), 1198) // 0 to 9.98 s
+		for _, stats := range result.Stats {
+			if stats.MaxDiff > 1e-9 {
+				t.Errorf("%s differs by up to %g", stats.Name, stats.MaxDiff)
+			}
+		}
+	})
+
+	t.Run("Doubled Damper Gain", func(t *testing.T) {
+		result := play(1.0)
+		elevator := result.Stats[0]
+		if elevator.MaxDiff < 1e-3 || elevator.MaxDiffTime < 2 {
+			t.Fatalf("Expected the doublet to show a difference: %+v", elevator)
+		}
+
+		// The damper's share of the elevator doubles wherever the aircraft
+		// is pitching; before the doublet it is trimmed and nothing changes
+		transient := 0
+		for i, time := range result.Times {
+			pilot, _ := recording.Value("fcs/elevator-cmd-norm", time)
+			original, replayed := result.Original[i][0]-pilot, result.Replayed[i][0]-pilot
+			if time < 2 {
+				assertApproxEqual(t, replayed, original, 1e-4)
+			} else if math.Abs(original) > 1e-3 {
+				transient++
+				assertApproxEqual(t, replayed/original, 2.0, 0.05)
+			}
+		}
+		if transient < 100 {
+			t.Errorf("Only %d steps with the damper active", transient)
+		}
+		t.Logf("%s", result)
+	})
+}
@@ -0,0 +1,261 @@
+// Piston Engine Power Charts
+// Engine power from the tables of a JSBSim piston engine file: brake
+// horsepower against RPM and manifold pressure, a slice per supercharger
+// speed, and the manifold pressure each speed can hold with altitude
+
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// PowerChartTable is the name of the power chart table in an engine file
+const PowerChartTable = "POWER"
+
+// StandardMAP is the manifold pressure of the standard sea level
+// atmosphere (inHg), which supercharger boost is measured from
+const StandardMAP = 29.92
+
+// manifoldPressureUnits converts pressures to inches of mercury, the unit
+// of the engine model
+var manifoldPressureUnits = map[string]float64{
+	"":     1,
+	"INHG": 1,
+	"PSI":  2.03602,
+	"PA":   1 / 3386.39,
+}
+
+// PistonEngineFile is a JSBSim piston engine definition, as far as the
+// power chart and supercharger need it. Rated boosts are gauge pressures
+// above StandardMAP; JSBSim allows up to three speeds.
+type PistonEngineFile struct {
+	XMLName        xml.Name     `xml:"piston_engine"`
+	Name           string       `xml:"name,attr"`
+	MinMP          *Measurement `xml:"minmp"`
+	MaxMP          *Measurement `xml:"maxmp"`
+	MaxHP          float64      `xml:"maxhp"`
+	MaxRPM         float64      `xml:"maxrpm"`
+	IdleRPM        float64      `xml:"idlerpm"`
+	NumBoostSpeeds int          `xml:"numboostspeeds"`
+	RatedBoost1    *Measurement `xml:"ratedboost1"`
+	RatedBoost2    *Measurement `xml:"ratedboost2"`
+	RatedBoost3    *Measurement `xml:"ratedboost3"`
+	RatedAltitude1 *Measurement `xml:"ratedaltitude1"`
+	RatedAltitude2 *Measurement `xml:"ratedaltitude2"`
+	RatedAltitude3 *Measurement `xml:"ratedaltitude3"`
+	Tables         []*Table     `xml:"table"`
+}
+
+// ParsePistonEngine reads a JSBSim piston engine file
+func ParsePistonEngine(r io.Reader) (*PistonEngineFile, error) {
+	var f PistonEngineFile
+	if err := xml.NewDecoder(r).Decode(&f); err != nil {
+		return nil, fmt.Errorf("parsing piston engine: %w", err)
+	}
+	if f.NumBoostSpeeds < 0 || f.NumBoostSpeeds > 3 {
+		return nil, fmt.Errorf("piston engine %s: %d boost speeds, at most 3 are supported", f.Name, f.NumBoostSpeeds)
+	}
+	return &f, nil
+}
+
+// PowerChart parses the engine's POWER table
+func (f *PistonEngineFile) PowerChart() (*PowerChart, error) {
+	for _, t := range f.Tables {
+		if strings.EqualFold(t.Name, PowerChartTable) {
+			return NewPowerChart(t)
+		}
+	}
+	return nil, fmt.Errorf("piston engine %s has no %s table", f.Name, PowerChartTable)
+}
+
+// Supercharger returns the engine's supercharger, or nil when it has no
+// boost speeds
+func (f *PistonEngineFile) Supercharger() (*Supercharger, error) {
+	boosts := []*Measurement{f.RatedBoost1, f.RatedBoost2, f.RatedBoost3}
+	altitudes := []*Measurement{f.RatedAltitude1, f.RatedAltitude2, f.RatedAltitude3}
+	if f.NumBoostSpeeds == 0 {
+		return nil, nil
+	}
+
+	s := &Supercharger{}
+	for i := 0; i < f.NumBoostSpeeds; i++ {
+		if boosts[i] == nil || altitudes[i] == nil {
+			return nil, fmt.Errorf("piston engine %s: boost speed %d needs ratedboost%d and ratedaltitude%d", f.Name, i+1, i+1, i+1)
+		}
+		boost, err := manifoldPressure(boosts[i])
+		if err != nil {
+			return nil, fmt.Errorf("piston engine %s: ratedboost%d: %w", f.Name, i+1, err)
+		}
+		altitude, err := convertToStandardUnit(altitudes[i].Value, altitudes[i].Unit, "length")
+		if err != nil {
+			return nil, fmt.Errorf("piston engine %s: ratedaltitude%d: %w", f.Name, i+1, err)
+		}
+		s.Speeds = append(s.Speeds, BoostSpeed{
+			RatedMAP:      StandardMAP + boost,
+			RatedAltitude: altitude * FT_TO_M,
+		})
+	}
+	return s, nil
+}
+
+// Configure sets an engine's limits, power chart and supercharger from the
+// file; limits the file leaves out keep their values
+func (f *PistonEngineFile) Configure(e *PistonEngine) error {
+	chart, err := f.PowerChart()
+	if err != nil {
+		return err
+	}
+	supercharger, err := f.Supercharger()
+	if err != nil {
+		return err
+	}
+	if chart.Speeds() < len(supercharger.speeds()) {
+		return fmt.Errorf("piston engine %s: the power chart covers %d boost speeds, not %d", f.Name, chart.Speeds(), len(supercharger.speeds()))
+	}
+
+	for _, limit := range []struct {
+		m     *Measurement
+		value *float64
+	}{{f.MinMP, &e.IdleMAP}, {f.MaxMP, &e.MaxMAP}} {
+		if limit.m == nil {
+			continue
+		}
+		if *limit.value, err = manifoldPressure(limit.m); err != nil {
+			return fmt.Errorf("piston engine %s: %w", f.Name, err)
+		}
+	}
+	if f.Name != "" {
+		e.Name = f.Name
+	}
+	if f.MaxHP > 0 {
+		e.MaxPowerHP = f.MaxHP
+	}
+	if f.MaxRPM > 0 {
+		e.MaxRPM = f.MaxRPM
+	}
+	if f.IdleRPM > 0 {
+		e.IdleRPM = f.IdleRPM
+	}
+	e.PowerChart, e.Supercharger = chart, supercharger
+	return nil
+}
+
+// manifoldPressure converts a pressure measurement to inHg
+func manifoldPressure(m *Measurement) (float64, error) {
+	factor, ok := manifoldPressureUnits[strings.ToUpper(strings.TrimSpace(m.Unit))]
+	if !ok {
+		return 0, fmt.Errorf("unknown pressure unit %q", m.Unit)
+	}
+	return m.Value * factor, nil
+}
+
+// PowerChart is an engine's full rich brake horsepower against RPM and
+// manifold pressure (inHg), the table's first and second variables. A 3D
+// table has a slice per supercharger speed, its third variable, at
+// breakpoints 0, 1 and so on; a 2D table serves every speed.
+type PowerChart struct {
+	Table *ParsedTable
+}
+
+// NewPowerChart parses a power chart table. Unlike other tables, a chart
+// with malformed numbers is rejected rather than read with zeros.
+func NewPowerChart(t *Table) (*PowerChart, error) {
+	pt, err := ParseTable(t)
+	if err != nil {
+		return nil, err
+	}
+	if len(pt.Warnings) > 0 {
+		return nil, fmt.Errorf("power chart %s: %s", t.Name, strings.Join(pt.Warnings, "; "))
+	}
+	if pt.Dimension < 2 && pt.Collapsed == nil {
+		return nil, fmt.Errorf("power chart %s needs RPM and manifold pressure, has %d variable", t.Name, pt.Dimension)
+	}
+	return &PowerChart{Table: pt}, nil
+}
+
+// Speeds returns the number of supercharger speeds the chart covers
+func (pc *PowerChart) Speeds() int {
+	if pc.Table.Dimension == 3 {
+		return len(pc.Table.Data3D)
+	}
+	return 1
+}
+
+// HP returns the brake horsepower at rpm and manifold pressure mapInHg
+// with the supercharger in speed, 0 being the lowest
+func (pc *PowerChart) HP(rpm, mapInHg float64, speed int) (float64, error) {
+	if pc.Table.Dimension == 3 {
+		return InterpolateTable(pc.Table, rpm, mapInHg, float64(speed))
+	}
+	return InterpolateTable(pc.Table, rpm, mapInHg)
+}
+
+// Watts returns HP in watts
+func (pc *PowerChart) Watts(rpm, mapInHg float64, speed int) (float64, error) {
+	hp, err := pc.HP(rpm, mapInHg, speed)
+	return hp * HP_TO_W, err
+}
+
+// BoostSpeed is a supercharger speed: the manifold pressure its boost
+// regulator holds, up to the altitude where the blower runs out of boost
+type BoostSpeed struct {
+	RatedMAP      float64 // inHg
+	RatedAltitude float64 // m
+}
+
+// Supercharger is a single or multi-speed supercharger that changes up a
+// speed as each runs out of boost
+type Supercharger struct {
+	Speeds []BoostSpeed // Lowest first
+}
+
+// speeds returns the speeds, none for a nil supercharger
+func (s *Supercharger) speeds() []BoostSpeed {
+	if s == nil {
+		return nil
+	}
+	return s.Speeds
+}
+
+// Speed returns the speed in use at altitude (m): the lowest whose rated
+// altitude is at or above it, or the highest above them all
+func (s *Supercharger) Speed(altitude float64) int {
+	for i, speed := range s.Speeds {
+		if altitude <= speed.RatedAltitude {
+			return i
+		}
+	}
+	return len(s.Speeds) - 1
+}
+
+// AvailableMAP returns the highest manifold pressure (inHg) a speed gives
+// at altitude (m): its rated pressure up to its rated altitude, falling
+// with the ambient pressure above it
+func (s *Supercharger) AvailableMAP(speed int, altitude float64) float64 {
+	b := s.Speeds[speed]
+	if altitude <= b.RatedAltitude {
+		return b.RatedMAP
+	}
+	return b.RatedMAP * isaPressure(altitude) / isaPressure(b.RatedAltitude)
+}
+
+// isaPressure returns the standard atmosphere's pressure at altitude (Pa)
+func isaPressure(altitude float64) float64 {
+	state := &AircraftState{Altitude: altitude}
+	state.UpdateAtmosphere()
+	return state.Pressure
+}
+
+// chartPower returns the engine's power from its chart at the current RPM,
+// manifold pressure and boost speed, relative to a full rich mixture
+func (e *PistonEngine) chartPower(mixtureFactor float64) (float64, error) {
+	hp, err := e.PowerChart.HP(e.RPM, e.ManifoldPressure, e.BoostSpeed)
+	if err != nil {
+		return 0, err
+	}
+	return math.Max(0, hp) * mixtureFactor / MixturePowerFactor(1.0), nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// merlinPropulsion returns a propulsion system with the engine configured
+// from the Merlin-like fixture
+func merlinPropulsion(t *testing.T) *PropulsionSystem {
+	t.Helper()
+	file, err := os.Open("testdata/engines/merlin_power.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	engine, err := ParsePistonEngine(file)
+	if err != nil {
+		t.Fatalf("ParsePistonEngine: %v", err)
+	}
+	ps := NewPropulsionSystem()
+	if err := engine.Configure(ps.Engine); err != nil {
+		t.Fatalf("Configure: %v", err)
+	}
+	return ps
+}
+
+// fullPowerAt runs the engine at full throttle, full rich and full RPM at
+// an altitude (ft), returning its power (hp)
+func fullPowerAt(ps *PropulsionSystem, altitudeFt float64) float64 {
+	ps.Engine.Altitude = altitudeFt * FT_TO_M
+	ps.UpdateControls(EngineControls{Throttle: 1, Mixture: 1, Advance: 1}, 0.01)
+	return ps.Engine.PowerHP
+}
+
+func TestPowerChart(t *testing.T) {
+	ps := merlinPropulsion(t)
+	chart, s := ps.Engine.PowerChart, ps.Engine.Supercharger
+
+	t.Run("Ingestion", func(t *testing.T) {
+		assertEqual(t, ps.Engine.Name, "Merlin-like two-speed")
+		assertEqual(t, ps.Engine.MaxMAP, 61.0)
+		assertEqual(t, ps.Engine.IdleRPM, 800.0)
+		assertEqual(t, chart.Speeds(), 2)
+		assertEqual(t, len(s.Speeds), 2)
+		assertApproxEqual(t, s.Speeds[0].RatedMAP, 61.0, 0.02)
+		assertApproxEqual(t, s.Speeds[1].RatedAltitude, 23000*FT_TO_M, 1e-6)
+
+		// Between breakpoints the chart interpolates, in watts too
+		hp, err := chart.HP(2850, 55.5, 0)
+		if err != nil {
+			t.Fatalf("HP: %v", err)
+		}
+		assertApproxEqual(t, hp, (1180+1460+1200+1490)/4.0, 1e-9)
+		w, _ := chart.Watts(2850, 55.5, 0)
+		assertApproxEqual(t, w, hp*HP_TO_W, 1e-9)
+	})
+
+	t.Run("Sea Level", func(t *testing.T) {
+		assertApproxEqual(t, fullPowerAt(ps, 0), 1490, 1e-9)
+		assertEqual(t, ps.Engine.BoostSpeed, 0)
+		assertApproxEqual(t, ps.Engine.ManifoldPressure, 61.0, 1e-9)
+	})
+
+	t.Run("Critical Altitude", func(t *testing.T) {
+		// The high gear holds full boost up to its rated altitude, then the
+		// power falls away with the manifold pressure
+		assertApproxEqual(t, fullPowerAt(ps, 23000), 1395, 1e-9)
+		assertEqual(t, ps.Engine.BoostSpeed, 1)
+		above := fullPowerAt(ps, 28000)
+		if above >= 1300 || ps.Engine.ManifoldPressure >= 55 {
+			t.Errorf("Above the critical altitude the power should fall, %.0f hp at %.1f inHg", above, ps.Engine.ManifoldPressure)
+		}
+	})
+
+	t.Run("Gear Change", func(t *testing.T) {
+		// The low gear holds full power to its rated altitude; the high
+		// gear above it loses the blower drive power
+		below := fullPowerAt(ps, 10999)
+		assertEqual(t, ps.Engine.BoostSpeed, 0)
+		after := fullPowerAt(ps, 11001)
+		assertEqual(t, ps.Engine.BoostSpeed, 1)
+		assertApproxEqual(t, below, 1490, 1e-9)
+		assertApproxEqual(t, after, 1395, 1e-9)
+	})
+
+	t.Run("Mixture", func(t *testing.T) {
+		ps.Engine.Altitude = 0
+		ps.UpdateControls(EngineControls{Throttle: 1, Mixture: 1.15 / FullRichEquivalenceRatio, Advance: 1}, 0.01)
+		assertApproxEqual(t, ps.Engine.PowerHP, 1490/0.96, 1e-6)
+	})
+
+	t.Run("Malformed Tables", func(t *testing.T) {
+		_, err := ParsePistonEngine(strings.NewReader(`<piston_engine><numboostspeeds>4</numboostspeeds></piston_engine>`))
+		if err == nil {
+			t.Error("Four boost speeds should be rejected")
+		}
+		_, err = NewPowerChart(&Table{
+			Name:           "POWER",
+			IndependentVar: []*IndependentVar{{Value: "rpm"}, {Value: "map"}},
+			TableData:      []*TableData{{Data: "20 30\n1600 250 4x0"}},
+		})
+		if err == nil || !strings.Contains(err.Error(), `"4x0"`) {
+			t.Errorf("A malformed number should be reported, got %v", err)
+		}
+	})
+}
@@ -14,6 +14,9 @@ type PropulsionSystem struct {
 	Propeller  *Propeller
 	FuelSystem *FuelSystem
 	Properties *PropertyManager
+	Index      int // Engine number in the propulsion/engine[i] properties
+	
+	engineProperties map[string]float64 // Leaves UpdateProperties publishes, reused each step
 	
 	// Configuration from JSBSim XML
 	MaxThrust        float64 // 200 lbs at reference conditions (from XML line 635)
@@ -21,26 +24,75 @@ type PropulsionSystem struct {
 	ReferenceMAP     float64 // 81 inHg (from XML line 634)
 	RunningFactor    float64 // 0.3 (off) or 1.0 (running)
 	
-	// Engine position from XML (line 504-508, 520-524)
-	Position    Vector3 // 36 inches from datum
+	// Engine position from XML (line 504-508, 520-524), in body axes about
+	// the CG: station 36 in is 62 in forward of the CG at 98 in
+	Position    Vector3
 	Orientation Vector3 // Roll: -4.0°, Pitch: 2.5°, Yaw: 0°
 }
 
 // PistonEngine represents the Packard V-1650-7 engine
 type PistonEngine struct {
 	Name             string
-	IsRunning        bool
+	IsRunning        bool // State is EngineRunning
+	
+	// Start and shutdown sequence
+	State            EngineState
+	AutoStart        bool    // Runs as soon as the throttle is opened, without a start sequence
+	StartTime        float64 // Cranking time from rest to idle (s)
+	StartMaxThrottle float64 // Furthest open the throttle can be for a start
+	startElapsed     float64 // Cranking time so far (s)
 	
 	// Current state
 	RPM              float64 // Current propeller RPM
 	ManifoldPressure float64 // Current MAP in inHg
 	ThrottlePosition float64 // 0.0 to 1.0
+	MixturePosition  float64 // 0.0 (idle cutoff) to 1.0 (full rich)
+	AdvancePosition  float64 // Propeller lever: 0.0 (lowest governed RPM) to 1.0 (highest)
+	PowerHP          float64 // Current brake horsepower
 	
 	// Performance characteristics (will be enhanced with JSBSim tables later)
 	MaxRPM           float64 // Maximum RPM
 	MaxMAP           float64 // Maximum manifold pressure
 	IdleRPM          float64 // Idle RPM
 	IdleMAP          float64 // Idle manifold pressure
+	MaxPowerHP       float64 // Power at MaxRPM and MaxMAP with best power mixture
+	MinGovernedRPM   float64 // RPM the governor holds with the propeller lever fully back
+	
+	// GovernorMAPRise is the fractional rise in manifold pressure when the
+	// governor has halved the RPM the throttle would give: the slower engine
+	// draws less air through the same throttle opening
+	GovernorMAPRise float64
+	
+	PowerChart   *PowerChart   // Optional; full rich power from RPM and MAP when set
+	Supercharger *Supercharger // Optional; limits the MAP with altitude when set
+	Altitude     float64       // Altitude the engine runs at (m), for the supercharger
+	BoostSpeed   int           // Supercharger speed in use, 0 the lowest
+}
+
+// EngineControls are the positions of the engine levers, each 0 to 1
+type EngineControls struct {
+	Throttle float64
+	Mixture  float64 // 0 is idle cutoff, 1 full rich
+	Advance  float64 // Propeller lever: 1 governs at MaxRPM
+}
+
+// FullRichEquivalenceRatio is the fuel-air equivalence ratio with the
+// mixture lever at full rich; the lever meters the fuel linearly below it.
+const FullRichEquivalenceRatio = 1.3
+
+// mixturePowerTable is the enrichment curve: power, relative to best power
+// mixture, against the equivalence ratio. Best power is slightly rich of
+// stoichiometric, full rich gives a few percent less to cool the cylinders,
+// and below the lean misfire limit the engine will not run.
+var mixturePowerTable = &Table1D{
+	Indices: []float64{0.0, 0.6, 0.7, 0.85, 1.0, 1.15, 1.3, 1.6},
+	Values:  []float64{0.0, 0.0, 0.70, 0.92, 0.985, 1.0, 0.96, 0.8},
+}
+
+// MixturePowerFactor returns the power, relative to best power mixture, at a
+// mixture lever position
+func MixturePowerFactor(mixture float64) float64 {
+	return interpolate1D(mixturePowerTable, mixture*FullRichEquivalenceRatio)
 }
 
 // Propeller represents the P51 propeller
@@ -55,6 +107,39 @@ type Propeller struct {
 	
 	// Propeller-induced velocity for aerodynamic effects
 	InducedVelocity float64 // prop-induced-velocity_fps
+	
+	// Rotation and asymmetric blade effect
+	Inertia float64 // Polar moment of inertia (kg·m²)
+	Sense   float64 // 1 clockwise seen from behind, -1 counter-clockwise
+	PFactor float64 // Thrust line shift (in) per radian of alpha
+	
+	// Windmilling when the engine is not driving it
+	WindmillDragFine   float64 // Drag coefficient on the disk area at fine pitch
+	WindmillDragCoarse float64 // And at coarse pitch
+	Airspeed           float64 // Free stream airspeed (m/s)
+	Density            float64 // Free stream density (kg/m³)
+}
+
+// P-51D propeller. The inertia is an estimate for the four-blade Hamilton
+// Standard propeller, which the configuration does not give; sense and
+// p_factor are from the thruster definition.
+const (
+	P51DPropellerInertia = 110.0 // kg·m²
+	P51DPropellerSense   = 1.0
+	P51DPropellerPFactor = 60.0
+)
+
+// Structural locations of the P-51D engine and CG (XML lines 504-508 and
+// 73-77), for the propulsion system built without a configuration
+var (
+	p51dEngineLocation = &Location{Unit: "IN", X: 36}
+	p51dCGLocation     = &Location{Name: "CG", Unit: "IN", X: 98, Z: -9}
+)
+
+// AngularMomentum returns the propeller's angular momentum in body axes
+// (kg·m²/s) at the given RPM; it lies along the thrust axis
+func (p *Propeller) AngularMomentum(rpm float64) Vector3 {
+	return Vector3{X: p.Sense * p.Inertia * rpm * 2.0 * math.Pi / 60.0}
 }
 
 // FuelSystem manages the 5 fuel tanks from JSBSim config
@@ -84,12 +169,8 @@ func NewPropulsionSystem() *PropulsionSystem {
 		ReferenceMAP:  81.0,   // inHg (line 634 comment)
 		RunningFactor: 0.3,    // Engine off initially
 		
-		// Engine position from XML (converted from inches to meters)
-		Position: Vector3{
-			X: 36.0 * 0.0254, // 36 inches to meters
-			Y: 0.0,
-			Z: 0.0,
-		},
+		// Engine position from XML, forward of and above the CG
+		Position: StructuralToBody(p51dEngineLocation, StructuralPosition(p51dCGLocation)),
 		Orientation: Vector3{
 			X: -4.0 * DEG_TO_RAD, // Roll: -4.0°
 			Y: 2.5 * DEG_TO_RAD,  // Pitch: 2.5°
@@ -101,15 +182,25 @@ func NewPropulsionSystem() *PropulsionSystem {
 	ps.Engine = &PistonEngine{
 		Name:             "Packard-V-1650-7",
 		IsRunning:        false,
+		State:            EngineOff,
+		AutoStart:        true,
+		StartTime:        DefaultStartTime,
+		StartMaxThrottle: DefaultStartMaxThrottle,
 		RPM:              0.0,
 		ManifoldPressure: 29.92, // Sea level atmospheric pressure
 		ThrottlePosition: 0.0,
+		MixturePosition:  1.0, // Full rich
+		AdvancePosition:  1.0, // Full increase RPM
 		
 		// Realistic limits for P-51D (will be refined with JSBSim data)
 		MaxRPM:  3000.0, // Typical max RPM for Merlin engine
 		MaxMAP:   61.0,  // Typical max MAP for supercharged Merlin
 		IdleRPM:  800.0, // Typical idle RPM
 		IdleMAP:  15.0,  // Typical idle MAP
+		
+		MaxPowerHP:      1490.0, // V-1650-7 take-off rating
+		MinGovernedRPM:  1600.0, // Constant speed unit range
+		GovernorMAPRise: 0.3,
 	}
 	
 	// Create propeller
@@ -120,6 +211,12 @@ func NewPropulsionSystem() *PropulsionSystem {
 		Thrust:          0.0,
 		Torque:          0.0,
 		InducedVelocity: 0.0,
+		Inertia:         P51DPropellerInertia,
+		Sense:           P51DPropellerSense,
+		PFactor:         P51DPropellerPFactor,
+		
+		WindmillDragFine:   WindmillDragFine,
+		WindmillDragCoarse: WindmillDragCoarse,
 	}
 	
 	// Create fuel system with actual tank data from XML
@@ -170,10 +267,23 @@ func (ps *PropulsionSystem) createFuelSystemFromXML() *FuelSystem {
 	return fs
 }
 
-// Update updates the propulsion system state
+// Update updates the propulsion system state with a new throttle position,
+// keeping the mixture and propeller levers where they are
 func (ps *PropulsionSystem) Update(throttleInput float64, dt float64) {
-	// Update throttle position
-	ps.Engine.ThrottlePosition = math.Max(0.0, math.Min(1.0, throttleInput))
+	ps.UpdateControls(EngineControls{
+		Throttle: throttleInput,
+		Mixture:  ps.Engine.MixturePosition,
+		Advance:  ps.Engine.AdvancePosition,
+	}, dt)
+}
+
+// UpdateControls updates the propulsion system state with new positions of
+// all the engine levers
+func (ps *PropulsionSystem) UpdateControls(controls EngineControls, dt float64) {
+	// Update lever positions
+	ps.Engine.ThrottlePosition = math.Max(0.0, math.Min(1.0, controls.Throttle))
+	ps.Engine.MixturePosition = math.Max(0.0, math.Min(1.0, controls.Mixture))
+	ps.Engine.AdvancePosition = math.Max(0.0, math.Min(1.0, controls.Advance))
 	
 	// Update engine state
 	ps.updateEngine(dt)
@@ -192,20 +302,42 @@ func (ps *PropulsionSystem) Update(throttleInput float64, dt float64) {
 	}
 }
 
-// updateEngine updates engine RPM and manifold pressure based on throttle
+// updateEngine updates engine RPM, manifold pressure and power from the
+// lever positions. The governor holds the RPM the propeller lever commands,
+// unless the throttle cannot give that much.
 func (ps *PropulsionSystem) updateEngine(dt float64) {
-	if !ps.Engine.IsRunning && ps.Engine.ThrottlePosition > 0.1 {
-		ps.Engine.IsRunning = true // Simple startup logic
-	}
+	e := ps.Engine
+	mixtureFactor := MixturePowerFactor(e.MixturePosition)
+	ps.updateEngineState(mixtureFactor, dt)
 	
-	if ps.Engine.IsRunning {
+	if e.IsRunning {
 		// Linear interpolation between idle and max (simplified)
-		throttle := ps.Engine.ThrottlePosition
-		ps.Engine.RPM = ps.Engine.IdleRPM + throttle*(ps.Engine.MaxRPM-ps.Engine.IdleRPM)
-		ps.Engine.ManifoldPressure = ps.Engine.IdleMAP + throttle*(ps.Engine.MaxMAP-ps.Engine.IdleMAP)
+		throttle := e.ThrottlePosition
+		throttleRPM := e.IdleRPM + throttle*(e.MaxRPM-e.IdleRPM)
+		governedRPM := e.MinGovernedRPM + e.AdvancePosition*(e.MaxRPM-e.MinGovernedRPM)
+		e.RPM = math.Min(throttleRPM, governedRPM)
+		e.ManifoldPressure = e.IdleMAP + throttle*(e.MaxMAP-e.IdleMAP)
+		
+		// A coarser blade absorbs the power at fewer revolutions
+		slowdown := 1.0 - e.RPM/throttleRPM
+		e.ManifoldPressure = math.Min(e.MaxMAP, e.ManifoldPressure*(1.0+2.0*e.GovernorMAPRise*slowdown))
+		
+		// The supercharger cannot hold more than its speed gives at altitude
+		if e.Supercharger != nil {
+			e.BoostSpeed = e.Supercharger.Speed(e.Altitude)
+			e.ManifoldPressure = math.Min(e.ManifoldPressure, e.Supercharger.AvailableMAP(e.BoostSpeed, e.Altitude))
+		}
+		
+		e.PowerHP = e.MaxPowerHP * (e.ManifoldPressure / e.MaxMAP) * (e.RPM / e.MaxRPM) * mixtureFactor
+		if e.PowerChart != nil {
+			if hp, err := e.chartPower(mixtureFactor); err == nil {
+				e.PowerHP = hp
+			}
+		}
 	} else {
-		ps.Engine.RPM = 0.0
-		ps.Engine.ManifoldPressure = 29.92 // Atmospheric pressure
+		e.RPM = ps.stoppedRPM()
+		e.ManifoldPressure = 29.92 // Atmospheric pressure
+		e.PowerHP = 0.0
 	}
 	
 	// Copy engine RPM to propeller
@@ -214,13 +346,23 @@ func (ps *PropulsionSystem) updateEngine(dt float64) {
 
 // updatePropeller calculates propeller thrust using JSBSim formula
 func (ps *PropulsionSystem) updatePropeller(dt float64) {
+	// An undriven propeller windmills, a drag rather than a thrust
+	if !ps.Engine.IsRunning {
+		ps.Propeller.Thrust = -ps.WindmillDrag() / 4.448222 // N to lbs
+		ps.Propeller.InducedVelocity = 0.0
+		return
+	}
+	
 	// Use exact formula from JSBSim XML (lines 629-636)
 	// Thrust = running_factor × (propeller_rpm / 1260) × (map_inhg / 81) × 200 lbs
 	
 	rpmFactor := ps.Propeller.RPM / ps.ReferenceRPM
 	mapFactor := ps.Engine.ManifoldPressure / ps.ReferenceMAP
 	
-	ps.Propeller.Thrust = ps.RunningFactor * rpmFactor * mapFactor * ps.MaxThrust
+	// The formula is for a full rich mixture
+	mixtureFactor := MixturePowerFactor(ps.Engine.MixturePosition) / MixturePowerFactor(1.0)
+	
+	ps.Propeller.Thrust = ps.RunningFactor * rpmFactor * mapFactor * mixtureFactor * ps.MaxThrust
 	
 	// Ensure thrust is not negative
 	ps.Propeller.Thrust = math.Max(0.0, ps.Propeller.Thrust)
@@ -291,12 +433,25 @@ func (ps *PropulsionSystem) GetTorque() float64 {
 
 // UpdateProperties updates JSBSim-compatible properties
 func (ps *PropulsionSystem) UpdateProperties(properties *PropertyManager) {
-	// Set propulsion properties that JSBSim functions expect
-	properties.Set("propulsion/engine/set-running", boolToFloat(ps.Engine.IsRunning))
-	properties.Set("propulsion/engine/propeller-rpm", ps.Propeller.RPM)
-	properties.Set("propulsion/engine/map-inhg", ps.Engine.ManifoldPressure)
-	properties.Set("propulsion/engine/thrust-lbs", ps.Propeller.Thrust)
-	properties.Set("propulsion/engine/prop-induced-velocity_fps", ps.Propeller.InducedVelocity)
+	// Set propulsion properties that JSBSim functions expect, under
+	// propulsion/engine[i]. The first engine is also published unindexed,
+	// which is the name single-engine configurations use.
+	if ps.engineProperties == nil {
+		ps.engineProperties = make(map[string]float64)
+	}
+	engine := ps.engineProperties
+	clear(engine)
+	engine["set-running"] = boolToFloat(ps.commandedRunning())
+	engine["propeller-rpm"] = ps.Propeller.RPM
+	engine["map-inhg"] = ps.Engine.ManifoldPressure
+	engine["thrust-lbs"] = ps.Propeller.Thrust
+	engine["prop-induced-velocity_fps"] = ps.Propeller.InducedVelocity
+	for leaf, value := range engine {
+		properties.Set(IndexedPropertyName("propulsion/engine", ps.Index, leaf), value)
+		if ps.Index == 0 {
+			properties.Set("propulsion/engine/"+leaf, value)
+		}
+	}
 	
 	// External reactions (thrust force)
 	properties.Set("external_reactions/exhaust-thrust/magnitude", ps.Propeller.Thrust)
@@ -312,7 +467,8 @@ func (ps *PropulsionSystem) String() string {
 		"  Engine: %s (Running: %t)\n"+
 		"  RPM: %.0f / %.0f\n"+
 		"  MAP: %.1f inHg\n"+
-		"  Throttle: %.1f%%\n"+
+		"  Power: %.0f hp\n"+
+		"  Throttle: %.1f%%  Mixture: %.1f%%  Prop: %.1f%%\n"+
 		"  Thrust: %.1f lbs (%.0f N)\n"+
 		"  Fuel: %.1f / %.1f lbs\n"+
 		"  Fuel Flow: %.1f lbs/hr\n"+
@@ -321,7 +477,8 @@ func (ps *PropulsionSystem) String() string {
 		ps.Engine.IsRunning,
 		ps.Engine.RPM, ps.Engine.MaxRPM,
 		ps.Engine.ManifoldPressure,
-		ps.Engine.ThrottlePosition*100,
+		ps.Engine.PowerHP,
+		ps.Engine.ThrottlePosition*100, ps.Engine.MixturePosition*100, ps.Engine.AdvancePosition*100,
 		ps.Propeller.Thrust, ps.GetThrust(),
 		ps.FuelSystem.TotalContents, ps.FuelSystem.TotalCapacity,
 		ps.FuelSystem.FuelFlow)
@@ -4,6 +4,8 @@
 package main
 
 import (
+	"math"
+	"strings"
 	"testing"
 )
 
@@ -18,10 +20,11 @@ func TestPropulsionSystemCreation(t *testing.T) {
 		assertApproxEqual(t, ps.ReferenceMAP, 81.0, 0.001)      // Line 634 comment
 		assertApproxEqual(t, ps.RunningFactor, 0.3, 0.001)      // Engine off initially
 		
-		// Engine position (36 inches = 0.9144 meters)
-		assertApproxEqual(t, ps.Position.X, 36.0*0.0254, 0.001)
+		// Engine position: station 36 in, 62 in forward of and 9 in above
+		// the CG at (98, 0, -9) in
+		assertApproxEqual(t, ps.Position.X, 62.0*0.0254, 0.001)
 		assertApproxEqual(t, ps.Position.Y, 0.0, 0.001)
-		assertApproxEqual(t, ps.Position.Z, 0.0, 0.001)
+		assertApproxEqual(t, ps.Position.Z, -9.0*0.0254, 0.001)
 		
 		// Engine orientation from XML
 		assertApproxEqual(t, ps.Orientation.X, -4.0*DEG_TO_RAD, 0.001) // Roll: -4.0°
@@ -123,18 +126,23 @@ func TestJSBSimThrustFormula(t *testing.T) {
 	})
 	
 	t.Run("Windmilling Conditions", func(t *testing.T) {
-		// Windmilling: engine off but propeller turning
+		// Windmilling: engine off but propeller turning in the airflow,
+		// braking it rather than giving thrust
 		ps.Engine.IsRunning = false
 		ps.RunningFactor = 0.3
 		ps.Engine.RPM = 0.0
 		ps.Propeller.RPM = 500.0      // Windmilling RPM
+		ps.Propeller.Airspeed = 100.0
+		ps.Propeller.Density = 1.225
 		ps.Engine.ManifoldPressure = 29.92
 		
 		ps.updatePropeller(0.01)
 		
-		// Expected: 0.3 × (500/1260) × (29.92/81) × 200
-		expectedThrust := 0.3 * (500.0/1260.0) * (29.92/81.0) * 200.0
-		assertApproxEqual(t, ps.Propeller.Thrust, expectedThrust, 0.1)
+		// Expected: q × Cd(fine pitch) × disk area, in lbs
+		radius := 11.2 * FT_TO_M / 2
+		expectedDrag := 0.5 * 1.225 * 100.0 * 100.0 * WindmillDragFine * math.Pi * radius * radius / 4.448222
+		assertApproxEqual(t, ps.Propeller.Thrust, -expectedDrag, 1e-9)
+		ps.Propeller.Airspeed = 0.0
 	})
 }
 
@@ -262,6 +270,19 @@ func TestJSBSimPropertyIntegration(t *testing.T) {
 		// Thrust in properties should match propeller thrust
 		assertApproxEqual(t, thrust, ps.Propeller.Thrust, 0.001)
 	})
+	
+	t.Run("Indexed Engines", func(t *testing.T) {
+		assertApproxEqual(t, pm.Get("propulsion/engine[0]/thrust-lbs"), ps.Propeller.Thrust, 0.001)
+		
+		// A second engine publishes under its own index only
+		second := NewPropulsionSystem()
+		second.Index = 1
+		second.Update(0.25, 0.01)
+		second.UpdateProperties(pm)
+		
+		assertApproxEqual(t, pm.Get("propulsion/engine[1]/thrust-lbs"), second.Propeller.Thrust, 0.001)
+		assertApproxEqual(t, pm.Get("propulsion/engine/thrust-lbs"), ps.Propeller.Thrust, 0.001)
+	})
 }
 
 // TestPropulsionSystemPerformance tests realistic performance characteristics
@@ -323,3 +344,158 @@ func BenchmarkPropulsionUpdate(b *testing.B) {
 		ps.Update(throttle, 0.01)
 	}
 }
+
+func TestEngineControls(t *testing.T) {
+	cruise := func(ps *PropulsionSystem, mixture, advance float64) {
+		ps.UpdateControls(EngineControls{Throttle: 0.6, Mixture: mixture, Advance: advance}, 0.01)
+	}
+	
+	t.Run("Mixture Sweep", func(t *testing.T) {
+		ps := NewPropulsionSystem()
+		cruise(ps, 1.0, 1.0)
+		fullRich := ps.Engine.PowerHP
+		if fullRich <= 0 {
+			t.Fatal("Engine should make power at full rich")
+		}
+		
+		// Leaning from full rich raises the power to its best, then it falls
+		// away and stops the engine well before cutoff
+		best, bestMixture := fullRich, 1.0
+		previous := fullRich
+		peaked := false
+		for mixture := 0.99; mixture >= -1e-9; mixture -= 0.01 {
+			cruise(ps, mixture, 1.0)
+			power := ps.Engine.PowerHP
+			if power > best {
+				best, bestMixture = power, mixture
+			}
+			if power < previous-1e-9 {
+				peaked = true
+			} else if peaked && power > previous+1e-9 {
+				t.Errorf("Power rose again to %.0f hp at mixture %.2f after peaking", power, mixture)
+			}
+			previous = power
+		}
+		t.Logf("Full rich %.0f hp, best %.0f hp at mixture %.2f", fullRich, best, bestMixture)
+		
+		if best <= fullRich || best > 1.1*fullRich {
+			t.Errorf("Best power %.0f hp should be slightly above full rich %.0f hp", best, fullRich)
+		}
+		if bestMixture < 0.8 || bestMixture > 0.95 {
+			t.Errorf("Best power mixture %.2f should be a little lean of full rich", bestMixture)
+		}
+		assertApproxEqual(t, ps.Engine.PowerHP, 0.0, 1e-12)
+		assertEqual(t, ps.Engine.IsRunning, false)
+		assertApproxEqual(t, ps.Propeller.Thrust, 0.0, 1e-12)
+		
+		// A cut-off engine does not restart on the throttle alone
+		ps.UpdateControls(EngineControls{Throttle: 1.0, Mixture: 0.0, Advance: 1.0}, 0.01)
+		assertEqual(t, ps.Engine.IsRunning, false)
+		cruise(ps, 1.0, 1.0)
+		assertEqual(t, ps.Engine.IsRunning, true)
+		assertApproxEqual(t, ps.Engine.PowerHP, fullRich, 1e-9)
+	})
+	
+	t.Run("Propeller Lever", func(t *testing.T) {
+		ps := NewPropulsionSystem()
+		cruise(ps, 1.0, 1.0)
+		highRPM, lowMAP := ps.Engine.RPM, ps.Engine.ManifoldPressure
+		
+		// With the lever fully forward the throttle sets the RPM
+		assertApproxEqual(t, highRPM, ps.Engine.IdleRPM+0.6*(ps.Engine.MaxRPM-ps.Engine.IdleRPM), 1e-9)
+		
+		// Bringing the lever back governs at a lower RPM, and the manifold
+		// pressure rises at the same throttle
+		cruise(ps, 1.0, 0.3)
+		governed := ps.Engine.MinGovernedRPM + 0.3*(ps.Engine.MaxRPM-ps.Engine.MinGovernedRPM)
+		assertApproxEqual(t, ps.Engine.RPM, governed, 1e-9)
+		assertApproxEqual(t, ps.Propeller.RPM, governed, 1e-9)
+		if ps.Engine.RPM >= highRPM {
+			t.Errorf("RPM %.0f should be below %.0f with the lever back", ps.Engine.RPM, highRPM)
+		}
+		if ps.Engine.ManifoldPressure <= lowMAP {
+			t.Errorf("Manifold pressure %.1f inHg should rise above %.1f inHg", ps.Engine.ManifoldPressure, lowMAP)
+		}
+		if ps.Engine.ManifoldPressure > ps.Engine.MaxMAP {
+			t.Errorf("Manifold pressure %.1f inHg exceeds the limit", ps.Engine.ManifoldPressure)
+		}
+		
+		// Update keeps the levers where they are
+		ps.Update(0.6, 0.01)
+		assertApproxEqual(t, ps.Engine.RPM, governed, 1e-9)
+	})
+	
+	t.Run("FCS Shaped Levers", func(t *testing.T) {
+		config, err := ParseJSBSimConfig(strings.NewReader(engineControlTestXML))
+		if err != nil {
+			t.Fatalf("Failed to parse XML: %v", err)
+		}
+		fcs, err := BuildFCSFromConfig(config)
+		if err != nil {
+			t.Fatalf("Failed to build FCS: %v", err)
+		}
+		engine := &FlightDynamicsEngineWithPropulsion{
+			FlightDynamicsEngineWithFCS: &FlightDynamicsEngineWithFCS{FCS: fcs},
+			Propulsion:                  NewPropulsionSystem(),
+			UseRealisticPropulsion:      true,
+		}
+		
+		state := NewAircraftState()
+		state.Velocity = Vector3{X: 100.0}
+		state.Controls.Throttle = 0.3
+		state.Controls.Mixture = 1.0
+		state.Controls.Propeller = 1.0
+		dt := 0.01
+		step := func(seconds float64) {
+			for i := 0; i < int(math.Round(seconds/dt)); i++ {
+				fcs.Execute(state, dt)
+				engine.updatePropulsionSystem(state, dt)
+			}
+		}
+		step(5.0)
+		settled := engine.Propulsion.Engine.ManifoldPressure
+		
+		// The lag on the throttle spools the manifold pressure up over
+		// its half second time constant
+		state.Controls.Throttle = 0.9
+		target := engine.Propulsion.Engine.IdleMAP + 0.9*(engine.Propulsion.Engine.MaxMAP-engine.Propulsion.Engine.IdleMAP)
+		step(0.5)
+		fraction := (engine.Propulsion.Engine.ManifoldPressure - settled) / (target - settled)
+		if fraction < 0.55 || fraction > 0.7 {
+			t.Errorf("Manifold pressure should be about 63%% of the way after one time constant, got %.0f%%", fraction*100)
+		}
+		step(4.5)
+		assertApproxEqual(t, engine.Propulsion.Engine.ManifoldPressure, target, 0.01*target)
+		
+		// The undriven propeller lever takes the command directly
+		controls := engine.EngineControlPositions(state)
+		assertApproxEqual(t, controls.Advance, 1.0, 1e-12)
+		state.Controls.Propeller = 0.0
+		step(dt)
+		assertApproxEqual(t, engine.Propulsion.Engine.RPM, engine.Propulsion.Engine.MinGovernedRPM, 1e-9)
+		
+		// The mixture goes through the FCS to idle cutoff
+		state.Controls.Mixture = 0.0
+		step(dt)
+		assertApproxEqual(t, fcs.Properties.Get("fcs/mixture-pos-norm"), 0.0, 1e-12)
+		assertEqual(t, engine.Propulsion.Engine.IsRunning, false)
+	})
+}
+
+const engineControlTestXML = `<?xml version="1.0"?>
+<fdm_config name="engine-control-test" version="2.0">
+  <flight_control name="Engine Control FCS">
+    <channel name="Engine">
+      <lag_filter name="Throttle Lag">
+        <input>fcs/throttle-cmd-norm</input>
+        <c1>2.0</c1>
+        <output>fcs/throttle-pos-norm</output>
+      </lag_filter>
+      <pure_gain name="Mixture">
+        <input>fcs/mixture-cmd-norm</input>
+        <gain>1.0</gain>
+        <output>fcs/mixture-pos-norm</output>
+      </pure_gain>
+    </channel>
+  </flight_control>
+</fdm_config>`
@@ -0,0 +1,193 @@
+// Provenance
+// Where in the XML each table, function and flight control component was
+// defined, so a simulated value can be traced back to its source
+
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// SourceLocation is where an element was defined: the file, when known,
+// the element's path below fdm_config, and the line and column of the end
+// of its start tag. Repeated elements in a path are numbered from 0 among
+// their like siblings, and axes and channels are named.
+type SourceLocation struct {
+	File   string // Base name of the file, empty when not parsed from a named file
+	Path   string // Such as aerodynamics/axis[PITCH]/function[2]
+	Line   int    // 0 when not parsed from XML
+	Column int
+}
+
+// Known reports whether the location was recorded while parsing
+func (s SourceLocation) Known() bool {
+	return s.Line > 0
+}
+
+// String formats the location as file:line, or line N without a file
+func (s SourceLocation) String() string {
+	switch {
+	case !s.Known():
+		return "unknown source"
+	case s.File == "":
+		return fmt.Sprintf("line %d", s.Line)
+	}
+	return fmt.Sprintf("%s:%d", s.File, s.Line)
+}
+
+// SourceFile names the file a configuration is parsed from, for the
+// source locations of its elements
+func SourceFile(name string) ParseOption {
+	return func(o *parseOptions) { o.sourceFile = name }
+}
+
+// sourceLine records the position of the start tag just decoded
+func sourceLine(d *xml.Decoder) SourceLocation {
+	line, column := d.InputPos()
+	return SourceLocation{Line: line, Column: column}
+}
+
+// UnmarshalXML decodes a table, recording its position
+func (t *Table) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	source := sourceLine(d)
+	type plain Table
+	if err := d.DecodeElement((*plain)(t), &start); err != nil {
+		return err
+	}
+	t.Source = source
+	return nil
+}
+
+// UnmarshalXML decodes a function, recording its position
+func (f *Function) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	source := sourceLine(d)
+	type plain Function
+	if err := d.DecodeElement((*plain)(f), &start); err != nil {
+		return err
+	}
+	f.Source = source
+	return nil
+}
+
+// UnmarshalXML decodes a flight control component, recording its position
+func (c *Component) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	source := sourceLine(d)
+	type plain Component
+	if err := d.DecodeElement((*plain)(c), &start); err != nil {
+		return err
+	}
+	c.Source = source
+	return nil
+}
+
+// assignSources completes the source locations recorded while decoding
+// with the file name and each element's path
+func assignSources(config *JSBSimConfig, file string) {
+	if aero := config.Aerodynamics; aero != nil {
+		for i, f := range aero.Function {
+			assignFunctionSource(f, fmt.Sprintf("aerodynamics/function[%d]", i), file)
+		}
+		for _, axis := range aero.Axis {
+			for i, f := range axis.Function {
+				assignFunctionSource(f, fmt.Sprintf("aerodynamics/axis[%s]/function[%d]", axis.Name, i), file)
+			}
+		}
+	}
+	assignChannelSources := func(section string, channels []*Channel) {
+		for _, ch := range channels {
+			for i, comp := range ch.Component {
+				path := fmt.Sprintf("%s/channel[%s]/component[%d]", section, ch.Name, i)
+				comp.Source.File, comp.Source.Path = file, path
+				if comp.Function != nil {
+					assignFunctionSource(comp.Function, path+"/function", file)
+				}
+				if comp.Table != nil {
+					comp.Table.Source.File, comp.Table.Source.Path = file, path+"/table"
+				}
+			}
+		}
+	}
+	if config.FlightControl != nil {
+		assignChannelSources("flight_control", config.FlightControl.Channel)
+	}
+	if config.Autopilot != nil {
+		assignChannelSources("autopilot", config.Autopilot.Channel)
+	}
+	if config.SystemControl != nil {
+		assignChannelSources("system", config.SystemControl.Channel)
+	}
+}
+
+// assignFunctionSource sets the file and path of a function and its tables
+func assignFunctionSource(f *Function, path, file string) {
+	f.Source.File, f.Source.Path = file, path
+	for _, located := range locateFunctionTables(f) {
+		located.table.Source.File = file
+		located.table.Source.Path = path + "/" + located.path
+	}
+}
+
+// FunctionTrace lists the contributions of the aerodynamic axis functions
+// to one force calculation, in evaluation order
+type FunctionTrace struct {
+	Contributions []FunctionContribution
+}
+
+func (trace *FunctionTrace) add(axis string, f *Function, value float64) {
+	trace.Contributions = append(trace.Contributions, FunctionContribution{Axis: axis, Name: f.Name, Value: value, Source: f.Source})
+}
+
+// Find returns the contribution of the named function, matched in full or
+// by its last path element
+func (trace *FunctionTrace) Find(name string) (FunctionContribution, bool) {
+	for _, c := range trace.Contributions {
+		if c.Name == name || strings.HasSuffix(c.Name, "/"+name) {
+			return c, true
+		}
+	}
+	return FunctionContribution{}, false
+}
+
+// String lists the contributions, one a line
+func (trace *FunctionTrace) String() string {
+	lines := make([]string, len(trace.Contributions))
+	for i, c := range trace.Contributions {
+		lines[i] = c.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// sourceSuffix formats a source location to follow a value, or nothing
+// when it is unknown
+func sourceSuffix(s SourceLocation) string {
+	if !s.Known() {
+		return ""
+	}
+	return " ← " + s.String()
+}
+
+// FunctionContribution is what one aerodynamic axis function added to its
+// axis in a force calculation, recorded when the calculator traces
+type FunctionContribution struct {
+	Axis   string // LIFT, DRAG, SIDE, ROLL, PITCH or YAW
+	Name   string
+	Value  float64 // Along the axis, in N for forces and N·m for moments
+	Source SourceLocation
+}
+
+// String formats the contribution, with its source when known, as in
+// "Cmq contribution -812 N·m ← p51d-jsbsim.xml:1423"
+func (c FunctionContribution) String() string {
+	unit := "N"
+	switch c.Axis {
+	case "ROLL", "PITCH", "YAW":
+		unit = "N·m"
+	}
+	name := c.Name
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	return fmt.Sprintf("%s contribution %.4g %s%s", name, c.Value, unit, sourceSuffix(c.Source))
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bufio"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// lineOf returns the first line of a file containing text, counted from 1
+func lineOf(t *testing.T, path, text string) int {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Open %s: %v", path, err)
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		if strings.Contains(scanner.Text(), text) {
+			return line
+		}
+	}
+	t.Fatalf("%s has no line containing %q", path, text)
+	return 0
+}
+
+func TestSourceLocations(t *testing.T) {
+	config, err := parseConfigFile(filepath.Join("testdata", "provenance", "fixture.xml"))
+	if err != nil {
+		t.Fatalf("Parsing the fixture: %v", err)
+	}
+	aero := config.Aerodynamics
+	pitch := aero.Axis[0]
+	cmq := pitch.Function[1]
+	gain := config.FlightControl.Channel[0].Component[0]
+
+	for _, tc := range []struct {
+		element string
+		source  SourceLocation
+		path    string
+		line    int
+	}{
+		{"Standalone Function", aero.Function[0].Source, "aerodynamics/function[0]", 4},
+		{"Standalone Table", aero.Function[0].Table.Source, "aerodynamics/function[0]/table", 5},
+		{"Axis Function", pitch.Function[0].Source, "aerodynamics/axis[PITCH]/function[0]", 14},
+		{"Nested Table", cmq.Product.Tables[0].Source, "aerodynamics/axis[PITCH]/function[1]/product/table", 23},
+		{"Component", gain.Source, "flight_control/channel[Pitch]/component[0]", 36},
+	} {
+		t.Run(tc.element, func(t *testing.T) {
+			assertEqual(t, tc.source.File, "fixture.xml")
+			assertEqual(t, tc.source.Path, tc.path)
+			assertEqual(t, tc.source.Line, tc.line)
+		})
+	}
+
+	t.Run("Parsed Table", func(t *testing.T) {
+		parsed, err := ParseTable(cmq.Product.Tables[0])
+		if err != nil {
+			t.Fatalf("ParseTable: %v", err)
+		}
+		assertEqual(t, parsed.Source.String(), "fixture.xml:23")
+	})
+
+	t.Run("Without A File", func(t *testing.T) {
+		file, err := os.Open(filepath.Join("testdata", "provenance", "fixture.xml"))
+		if err != nil {
+			t.Fatalf("Open: %v", err)
+		}
+		defer file.Close()
+		config, err := ParseJSBSimConfig(file)
+		if err != nil {
+			t.Fatalf("ParseJSBSimConfig: %v", err)
+		}
+		assertEqual(t, config.Aerodynamics.Axis[0].Function[1].Source.String(), "line 20")
+		assertEqual(t, (&Function{}).Source.String(), "unknown source")
+	})
+}
+
+func TestFunctionTrace(t *testing.T) {
+	path := filepath.Join("aircraft", "p51d-jsbsim.xml")
+	config, err := parseConfigFile(path)
+	if err != nil {
+		t.Fatalf("Parsing the P-51D: %v", err)
+	}
+	state := cruiseState(3000, 120, 0.02)
+	state.AngularRate.Y = 0.1
+
+	calc := NewForcesMomentsCalculator(config)
+	components, err := calc.CalculateForcesMoments(state)
+	if err != nil {
+		t.Fatalf("CalculateForcesMoments: %v", err)
+	}
+	if components.Trace != nil {
+		t.Error("Contributions should only be recorded when tracing")
+	}
+
+	calc.Trace = true
+	traced, err := calc.CalculateForcesMoments(state)
+	if err != nil {
+		t.Fatalf("CalculateForcesMoments: %v", err)
+	}
+	assertEqual(t, traced.Moments, components.Moments)
+
+	cmq, ok := traced.Trace.Find("Cmq")
+	if !ok {
+		t.Fatalf("The trace should hold Cmq:\n%s", traced.Trace)
+	}
+	t.Log(cmq)
+	assertEqual(t, cmq.Axis, "PITCH")
+	if cmq.Value >= 0 {
+		t.Errorf("Pitch damping should oppose a nose-up rate, got %g N·m", cmq.Value)
+	}
+	line := lineOf(t, path, `<function name="aero/coefficient/Cmq">`)
+	if want := " N·m ← p51d-jsbsim.xml:" + strconv.Itoa(line); !strings.HasSuffix(cmq.String(), want) {
+		t.Errorf("Expected %q to end with %q", cmq.String(), want)
+	}
+
+	// The contributions of an axis sum to its moment, less the offset and
+	// propulsion terms
+	var pitch float64
+	for _, c := range traced.Trace.Contributions {
+		if c.Axis == "PITCH" {
+			pitch += c.Value
+		}
+	}
+	offset := &ForceMomentComponents{Aerodynamic: traced.Aerodynamic, Propulsion: traced.Propulsion}
+	calc.addOffsetMoments(offset)
+	rest := offset.Moments.Pitch + traced.Propulsion.Moment.Y
+	assertApproxEqual(t, pitch+rest, traced.Moments.Pitch, 1e-6*(1+math.Abs(traced.Moments.Pitch)))
+}
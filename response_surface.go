@@ -0,0 +1,358 @@
+// Response Surface Export
+// Evaluates an aircraft's aerodynamic functions over a grid of inputs and
+// writes them as a long-format CSV, one row per grid point, with a JSON
+// sidecar describing it, for analysis outside the simulator
+
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// responseBatchSize is the number of grid points evaluated per worker
+// before the batch is written, bounding the rows held in memory
+const responseBatchSize = 1024
+
+// GridAxis is one input of a response grid: a property and its values
+type GridAxis struct {
+	Property string
+	Values   []float64
+}
+
+// GridRange returns an axis of n evenly spaced values from from to to
+func GridRange(property string, from, to float64, n int) GridAxis {
+	axis := GridAxis{Property: property, Values: make([]float64, n)}
+	for i := range axis.Values {
+		if n == 1 {
+			axis.Values[i] = from
+			continue
+		}
+		axis.Values[i] = from + (to-from)*float64(i)/float64(n-1)
+	}
+	return axis
+}
+
+// ResponseGrid describes a response surface export. Properties not on the
+// grid keep their values at Base, and are not recomputed from the grid's:
+// setting velocities/mach leaves aero/qbar-psf as it was. A grid property
+// in radians or degrees sets its counterpart in the other unit too.
+type ResponseGrid struct {
+	Axes      []GridAxis
+	Functions []string       // Functions exported besides the axis sums, by name
+	Base      *AircraftState // The state the properties off the grid are taken from
+	Workers   int            // Evaluation workers, runtime.NumCPU() when zero
+}
+
+// Points returns the number of grid points
+func (g *ResponseGrid) Points() int {
+	if len(g.Axes) == 0 {
+		return 0
+	}
+	n := 1
+	for _, axis := range g.Axes {
+		n *= len(axis.Values)
+	}
+	return n
+}
+
+// point sets the grid values of point index into properties, the last
+// axis varying fastest, and returns them in axis order in values
+func (g *ResponseGrid) point(index int, properties map[string]float64, values []float64) {
+	for i := len(g.Axes) - 1; i >= 0; i-- {
+		axis := g.Axes[i]
+		value := axis.Values[index%len(axis.Values)]
+		index /= len(axis.Values)
+		values[i] = value
+		properties[axis.Property] = value
+		if name, ok := strings.CutSuffix(axis.Property, "-rad"); ok {
+			properties[name+"-deg"] = value * RAD_TO_DEG
+		} else if name, ok := strings.CutSuffix(axis.Property, "-deg"); ok {
+			properties[name+"-rad"] = value * DEG_TO_RAD
+		}
+	}
+}
+
+// ResponseMetadata is the JSON sidecar of a response surface export
+type ResponseMetadata struct {
+	Model       string                   `json:"model"`
+	Version     string                   `json:"version,omitempty"`
+	Source      string                   `json:"source,omitempty"`
+	GeneratedAt string                   `json:"generated_at"`
+	Points      int                      `json:"points"`
+	Axes        []ResponseAxisMetadata   `json:"axes"`
+	Outputs     []ResponseOutputMetadata `json:"outputs"`
+	Base        map[string]float64       `json:"base_properties"` // Inputs read off the grid, at their fixed values
+}
+
+// ResponseAxisMetadata describes a grid axis
+type ResponseAxisMetadata struct {
+	Property string    `json:"property"`
+	Unit     string    `json:"unit,omitempty"`
+	Values   []float64 `json:"values"`
+}
+
+// ResponseOutputMetadata describes an output column
+type ResponseOutputMetadata struct {
+	Column      string `json:"column"`
+	Unit        string `json:"unit,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// responseOutput is an output column: an axis sum or a named function
+type responseOutput struct {
+	column string
+	axis   *Axis
+}
+
+// ExportResponseSurface evaluates config's aerodynamics at every point of
+// the grid and writes a CSV to out, a header then one row per point with
+// the grid's values, each axis's sum and each requested function. The
+// points are evaluated in parallel and written in grid order as each batch
+// completes, so large grids are streamed. The sidecar is written to meta
+// when it is not nil.
+//
+// The axis sums are in JSBSim's units, pounds for the force axes and
+// pound-feet for the moment axes, with functions that declare an SI unit
+// converted back to them.
+func ExportResponseSurface(config *JSBSimConfig, grid *ResponseGrid, out io.Writer, meta io.Writer) error {
+	if config.Aerodynamics == nil {
+		return fmt.Errorf("configuration has no aerodynamics")
+	}
+	points := grid.Points()
+	if points == 0 {
+		return fmt.Errorf("response grid has no points")
+	}
+	if grid.Base == nil {
+		return fmt.Errorf("response grid has no base state")
+	}
+	seen := make(map[string]bool)
+	for _, axis := range grid.Axes {
+		if axis.Property == "" || seen[axis.Property] {
+			return fmt.Errorf("grid axis property %q is empty or repeated", axis.Property)
+		}
+		seen[axis.Property] = true
+	}
+
+	var outputs []responseOutput
+	for _, axis := range config.Aerodynamics.Axis {
+		outputs = append(outputs, responseOutput{column: axis.Name, axis: axis})
+	}
+	named := make(map[string]*Function)
+	for _, f := range aerodynamicsFunctions(config.Aerodynamics) {
+		named[f.Name] = f
+	}
+	for _, name := range grid.Functions {
+		if named[name] == nil {
+			return fmt.Errorf("no aerodynamic function named %s", name)
+		}
+		outputs = append(outputs, responseOutput{column: name})
+	}
+
+	// The properties off the grid, as the calculator sees them at the base
+	calc := NewForcesMomentsCalculator(config)
+	if _, err := calc.CalculateForcesMoments(grid.Base); err != nil {
+		return fmt.Errorf("evaluating the base state: %w", err)
+	}
+	base := calc.Properties.GetPropertiesWithPrefix("")
+
+	if meta != nil {
+		if err := writeResponseMetadata(config, grid, outputs, named, base, meta); err != nil {
+			return err
+		}
+	}
+
+	w := csv.NewWriter(out)
+	header := make([]string, 0, len(grid.Axes)+len(outputs))
+	for _, axis := range grid.Axes {
+		header = append(header, axis.Property)
+	}
+	for _, output := range outputs {
+		header = append(header, output.column)
+	}
+	if err := w.Write(header); err != nil {
+		return err
+	}
+
+	workers := grid.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	batch := make([][]string, workers*responseBatchSize)
+	errs := make([]error, workers)
+	for start := 0; start < points; start += len(batch) {
+		end := min(start+len(batch), points)
+		var wg sync.WaitGroup
+		for worker := 0; worker < workers; worker++ {
+			wg.Add(1)
+			go func(worker int) {
+				defer wg.Done()
+				errs[worker] = calc.evaluateResponseRows(grid, outputs, base, batch, start, end, worker, workers)
+			}(worker)
+		}
+		wg.Wait()
+		for _, err := range errs {
+			if err != nil {
+				return err
+			}
+		}
+		for _, row := range batch[:end-start] {
+			if err := w.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// evaluateResponseRows fills the rows of one worker's share of points
+// start to end, every workers'th from start+worker, into batch
+func (calc *ForcesMomentsCalculator) evaluateResponseRows(grid *ResponseGrid, outputs []responseOutput, base map[string]float64, batch [][]string, start, end, worker, workers int) error {
+	properties := make(map[string]float64, len(base))
+	values := make([]float64, len(grid.Axes))
+	for index := start + worker; index < end; index += workers {
+		clear(properties)
+		for name, value := range base {
+			properties[name] = value
+		}
+		grid.point(index, properties, values)
+		if err := calc.evaluateStandaloneFunctions(properties); err != nil {
+			return fmt.Errorf("grid point %d: %w", index, err)
+		}
+
+		row := batch[index-start][:0]
+		for _, value := range values {
+			row = append(row, formatResponseValue(value))
+		}
+		for _, output := range outputs {
+			if output.axis == nil {
+				// The axes are summed first, so every function has its value
+				continue
+			}
+			conventional, si, err := sumAxisFunctions(output.axis, properties, nil)
+			if err != nil {
+				return fmt.Errorf("grid point %d: axis %s: %w", index, output.axis.Name, err)
+			}
+			row = append(row, formatResponseValue(conventional+si/responseSIScale(output.axis.Name)))
+		}
+		for _, output := range outputs {
+			if output.axis != nil {
+				continue
+			}
+			value, ok := properties[output.column]
+			if !ok {
+				value = math.NaN()
+			}
+			row = append(row, formatResponseValue(value))
+		}
+		batch[index-start] = row
+	}
+	return nil
+}
+
+// responseSIScale converts an axis's JSBSim units to SI
+func responseSIScale(axis string) float64 {
+	switch axis {
+	case "ROLL", "PITCH", "YAW":
+		return LB_TO_N * FT_TO_M
+	}
+	return LB_TO_N
+}
+
+// formatResponseValue writes a value exactly, in the shortest form
+func formatResponseValue(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// writeResponseMetadata writes the sidecar of an export
+func writeResponseMetadata(config *JSBSimConfig, grid *ResponseGrid, outputs []responseOutput, named map[string]*Function, base map[string]float64, w io.Writer) error {
+	meta := ResponseMetadata{
+		Model:       config.Name,
+		Version:     config.Version,
+		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+		Points:      grid.Points(),
+		Base:        make(map[string]float64),
+	}
+	for _, axis := range grid.Axes {
+		meta.Axes = append(meta.Axes, ResponseAxisMetadata{
+			Property: axis.Property,
+			Unit:     propertyUnit(axis.Property),
+			Values:   axis.Values,
+		})
+	}
+	for _, output := range outputs {
+		column := ResponseOutputMetadata{Column: output.column}
+		if output.axis != nil {
+			column.Unit = "LBS"
+			if responseSIScale(output.axis.Name) != LB_TO_N {
+				column.Unit = "LBS*FT"
+			}
+			column.Description = "Sum of the " + output.axis.Name + " axis functions"
+		} else {
+			f := named[output.column]
+			column.Unit = f.Unit
+			column.Description = strings.TrimSpace(f.Description)
+		}
+		meta.Outputs = append(meta.Outputs, column)
+	}
+
+	// The inputs the functions read that the grid does not set, and where
+	// the functions came from
+	onGrid := make(map[string]bool)
+	for _, axis := range grid.Axes {
+		onGrid[axis.Property] = true
+	}
+	var reads []string
+	for _, f := range aerodynamicsFunctions(config.Aerodynamics) {
+		onGrid[f.Name] = true
+		reads = append(reads, functionProperties(f)...)
+		if meta.Source == "" && f.Source.File != "" {
+			meta.Source = f.Source.File
+		}
+	}
+	sort.Strings(reads)
+	for _, name := range reads {
+		if value, ok := base[name]; ok && !onGrid[name] {
+			meta.Base[name] = value
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(meta)
+}
+
+// propertyUnit returns the unit a property's name ends in, or "" when it
+// names none
+func propertyUnit(name string) string {
+	for _, suffix := range []struct{ suffix, unit string }{
+		{"-rad_sec", "rad/s"}, {"-rad", "rad"}, {"-deg", "deg"}, {"-norm", "norm"},
+		{"-psf", "psf"}, {"_psf", "psf"}, {"-sqft", "ft2"}, {"-ft", "ft"}, {"-fps", "ft/s"},
+		{"-kts", "kt"}, {"-m", "m"}, {"-mps", "m/s"},
+	} {
+		if strings.HasSuffix(name, suffix.suffix) {
+			return suffix.unit
+		}
+	}
+	return ""
+}
+
+// aerodynamicsFunctions returns the standalone functions then those of each
+// axis, in evaluation order
+func aerodynamicsFunctions(aero *Aerodynamics) []*Function {
+	functions := append([]*Function(nil), aero.Function...)
+	for _, axis := range aero.Axis {
+		functions = append(functions, axis.Function...)
+	}
+	return functions
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestResponseSurface(t *testing.T) {
+	config := loadP51DConfig(t)
+	grid := &ResponseGrid{
+		Axes: []GridAxis{
+			GridRange("aero/alpha-rad", -4*DEG_TO_RAD, 16*DEG_TO_RAD, 6),
+			GridRange("aero/beta-rad", -0.1, 0.1, 3),
+			{Property: "fcs/flap-pos-norm", Values: []float64{0, 0.5, 1}},
+			GridRange("fcs/elevator-pos-rad", -0.2, 0.2, 4),
+		},
+		Functions: []string{"aero/coefficient/CLalpha", "aero/coefficient/Cnb"},
+		Base:      cruiseState(1500, 100, 2*DEG_TO_RAD),
+		Workers:   3,
+	}
+	var out, meta bytes.Buffer
+	if err := ExportResponseSurface(config, grid, &out, &meta); err != nil {
+		t.Fatalf("ExportResponseSurface: %v", err)
+	}
+	rows, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatalf("Reading the CSV: %v", err)
+	}
+
+	t.Run("Shape", func(t *testing.T) {
+		assertEqual(t, len(rows), 1+6*3*3*4)
+		assertEqual(t, rows[0], []string{
+			"aero/alpha-rad", "aero/beta-rad", "fcs/flap-pos-norm", "fcs/elevator-pos-rad",
+			"DRAG", "SIDE", "LIFT", "ROLL", "PITCH", "YAW",
+			"aero/coefficient/CLalpha", "aero/coefficient/Cnb",
+		})
+
+		// The last axis varies fastest
+		assertEqual(t, rows[1][3], formatResponseValue(-0.2))
+		assertEqual(t, rows[2][3], formatResponseValue(grid.Axes[3].Values[1]))
+		assertEqual(t, rows[5][2], "0.5")
+	})
+
+	t.Run("Values", func(t *testing.T) {
+		// The base properties, as the calculator sees them
+		calc := NewForcesMomentsCalculator(config)
+		if _, err := calc.CalculateForcesMoments(grid.Base); err != nil {
+			t.Fatalf("CalculateForcesMoments: %v", err)
+		}
+		base := calc.Properties.GetPropertiesWithPrefix("")
+		for _, row := range []int{1, 40, 107, len(rows) - 1} {
+			properties := make(map[string]float64)
+			for name, value := range base {
+				properties[name] = value
+			}
+			for i, axis := range grid.Axes {
+				value, _ := strconv.ParseFloat(rows[row][i], 64)
+				properties[axis.Property] = value
+			}
+			properties["aero/alpha-deg"] = properties["aero/alpha-rad"] * RAD_TO_DEG
+			properties["aero/beta-deg"] = properties["aero/beta-rad"] * RAD_TO_DEG
+			properties["fcs/elevator-pos-deg"] = properties["fcs/elevator-pos-rad"] * RAD_TO_DEG
+
+			evaluate := func(f *Function) float64 {
+				value, err := EvaluateFunction(f, properties)
+				if err != nil {
+					return 0
+				}
+				properties[f.Name] = value
+				return value
+			}
+			for _, f := range config.Aerodynamics.Function {
+				evaluate(f)
+			}
+			for column, axis := range config.Aerodynamics.Axis {
+				var sum float64
+				for _, f := range axis.Function {
+					sum += evaluate(f)
+				}
+				got, _ := strconv.ParseFloat(rows[row][4+column], 64)
+				assertApproxEqual(t, got, sum, 1e-9*(1+abs(sum)))
+			}
+			for column, name := range grid.Functions {
+				got, _ := strconv.ParseFloat(rows[row][10+column], 64)
+				assertEqual(t, got, properties[name])
+			}
+		}
+	})
+
+	t.Run("Metadata", func(t *testing.T) {
+		var sidecar ResponseMetadata
+		if err := json.Unmarshal(meta.Bytes(), &sidecar); err != nil {
+			t.Fatalf("Reading the sidecar: %v", err)
+		}
+		assertEqual(t, sidecar.Model, config.Name)
+		assertEqual(t, sidecar.Points, len(rows)-1)
+		assertEqual(t, sidecar.Axes[0].Unit, "rad")
+		assertEqual(t, sidecar.Axes[2].Unit, "norm")
+		assertEqual(t, sidecar.Outputs[2].Unit, "LBS")
+		assertEqual(t, sidecar.Outputs[4].Unit, "LBS*FT")
+		if _, ok := sidecar.Base["aero/qbar-psf"]; !ok {
+			t.Errorf("Expected the fixed dynamic pressure among the base properties, got %v", sidecar.Base)
+		}
+		if _, ok := sidecar.Base["aero/alpha-rad"]; ok {
+			t.Error("A grid axis should not be listed as a base property")
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		for _, bad := range []*ResponseGrid{
+			{Base: grid.Base},
+			{Axes: grid.Axes[:1], Functions: []string{"aero/coefficient/none"}, Base: grid.Base},
+			{Axes: []GridAxis{grid.Axes[0], grid.Axes[0]}, Base: grid.Base},
+			{Axes: grid.Axes[:1]},
+		} {
+			err := ExportResponseSurface(config, bad, &bytes.Buffer{}, nil)
+			if err == nil || strings.TrimSpace(err.Error()) == "" {
+				t.Errorf("Expected an error for %+v", bad)
+			}
+		}
+	})
+}
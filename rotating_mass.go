@@ -0,0 +1,189 @@
+// Rotating Masses
+// Spinning parts of the aircraft, such as the propeller, crankshaft and
+// supercharger impeller, whose stored angular momentum the body rates
+// precess
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// PropellerRotatingMass is the name of the propeller's entry, added to
+// every calculator and enabled by PropellerEffects.Gyroscopic
+const PropellerRotatingMass = "propeller"
+
+// RPMSource returns the speed of a rotating mass (rpm) from the property
+// tree and the engine model's propeller, which may be nil
+type RPMSource func(properties map[string]float64, prop *Propeller) float64
+
+// ConstantRPM is a fixed speed
+func ConstantRPM(rpm float64) RPMSource {
+	return func(map[string]float64, *Propeller) float64 { return rpm }
+}
+
+// PropertyRPM reads the speed from a property, zero while it is unset
+func PropertyRPM(name string) RPMSource {
+	return func(properties map[string]float64, _ *Propeller) float64 {
+		return properties[name]
+	}
+}
+
+// EngineRPM gears the speed to the engine model's propeller: ratio times
+// its rpm, turning in its sense. A negative ratio turns the other way.
+func EngineRPM(ratio float64) RPMSource {
+	return func(_ map[string]float64, prop *Propeller) float64 {
+		if prop == nil {
+			return 0
+		}
+		return ratio * prop.Sense * prop.RPM
+	}
+}
+
+// RotatingMass is one spinning part. A positive speed turns it about Axis
+// by the right-hand rule.
+type RotatingMass struct {
+	Name     string
+	Inertia  float64 // Polar moment of inertia about the spin axis (kg·m²)
+	Axis     Vector3 // Spin axis in body axes
+	RPM      RPMSource
+	Disabled bool
+
+	rpmProperty string // Where its speed is published, set by Add
+}
+
+// AngularMomentum returns the part's angular momentum in body axes
+// (kg·m²/s) at a speed (rpm)
+func (m *RotatingMass) AngularMomentum(rpm float64) Vector3 {
+	return m.Axis.Normalize().Scale(m.Inertia * rpm * 2 * math.Pi / 60)
+}
+
+// RotatingMasses is a registry of the aircraft's spinning parts
+type RotatingMasses struct {
+	masses []*RotatingMass
+}
+
+// NewRotatingMasses creates an empty registry
+func NewRotatingMasses() *RotatingMasses {
+	return &RotatingMasses{}
+}
+
+// newConfigRotatingMasses returns a registry of the configured propeller,
+// spinning along the body X axis at the engine model's speed
+func newConfigRotatingMasses(prop *Propeller) *RotatingMasses {
+	masses := NewRotatingMasses()
+	masses.Add(&RotatingMass{
+		Name:    PropellerRotatingMass,
+		Inertia: prop.Inertia,
+		Axis:    Vector3{X: 1},
+		RPM:     EngineRPM(1),
+	})
+	return masses
+}
+
+// clone returns a registry of copies of the entries. The RPM source of an
+// entry is shared by its copies.
+func (rm *RotatingMasses) clone() *RotatingMasses {
+	c := &RotatingMasses{masses: make([]*RotatingMass, len(rm.masses))}
+	for i, mass := range rm.masses {
+		copied := *mass
+		c.masses[i] = &copied
+	}
+	return c
+}
+
+// Add registers a rotating mass. Names must be unique and not empty.
+func (rm *RotatingMasses) Add(mass *RotatingMass) error {
+	if strings.TrimSpace(mass.Name) == "" {
+		return fmt.Errorf("rotating mass has no name")
+	}
+	if rm.Get(mass.Name) != nil {
+		return fmt.Errorf("rotating mass %q is already registered", mass.Name)
+	}
+	if mass.Axis.Magnitude() == 0 {
+		return fmt.Errorf("rotating mass %q has no spin axis", mass.Name)
+	}
+	if mass.RPM == nil {
+		return fmt.Errorf("rotating mass %q has no rpm source", mass.Name)
+	}
+	mass.rpmProperty = "rotating/" + mass.Name + "/rpm"
+	rm.masses = append(rm.masses, mass)
+	return nil
+}
+
+// Remove unregisters a rotating mass, reporting whether it was registered
+func (rm *RotatingMasses) Remove(name string) bool {
+	for i, mass := range rm.masses {
+		if mass.Name == name {
+			rm.masses = append(rm.masses[:i], rm.masses[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Get returns the rotating mass registered under name, or nil
+func (rm *RotatingMasses) Get(name string) *RotatingMass {
+	if rm == nil {
+		return nil
+	}
+	for _, mass := range rm.masses {
+		if mass.Name == name {
+			return mass
+		}
+	}
+	return nil
+}
+
+// Names returns the registered masses in registration order
+func (rm *RotatingMasses) Names() []string {
+	if rm == nil {
+		return nil
+	}
+	names := make([]string, len(rm.masses))
+	for i, mass := range rm.masses {
+		names[i] = mass.Name
+	}
+	return names
+}
+
+// Len returns the number of registered masses
+func (rm *RotatingMasses) Len() int {
+	if rm == nil {
+		return 0
+	}
+	return len(rm.masses)
+}
+
+// gyroscopicMoment returns the moment on the airframe of the enabled
+// rotating masses, recording it and their total angular momentum in the
+// components and the rotating/ properties of the tree being evaluated. Turning the total H at the
+// body rates ω takes the moment ω × H, so the airframe feels H × ω: a
+// clockwise propeller yaws the nose right as it pitches up. The propeller's
+// entry counts only with PropellerEffects.Gyroscopic.
+func (calc *ForcesMomentsCalculator) gyroscopicMoment(state *AircraftState, properties map[string]float64, components *ForceMomentComponents) Vector3 {
+	var h Vector3
+	if calc.Rotating != nil {
+		for _, mass := range calc.Rotating.masses {
+			if mass.Disabled || mass.Name == PropellerRotatingMass && !calc.PropellerEffects.Gyroscopic {
+				continue
+			}
+			rpm := mass.RPM(properties, calc.Propeller)
+			properties[mass.rpmProperty] = rpm
+			h = h.Add(mass.AngularMomentum(rpm))
+		}
+	}
+	moment := h.Cross(state.AngularRate)
+	components.Gyroscopic.Momentum = h
+	components.Gyroscopic.Moment = moment
+
+	properties["rotating/h-x-kgm2_s"] = h.X
+	properties["rotating/h-y-kgm2_s"] = h.Y
+	properties["rotating/h-z-kgm2_s"] = h.Z
+	properties["rotating/l-gyro-nm"] = moment.X
+	properties["rotating/m-gyro-nm"] = moment.Y
+	properties["rotating/n-gyro-nm"] = moment.Z
+	return moment
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRotatingMasses(t *testing.T) {
+	calc := NewForcesMomentsCalculator(loadP51DConfig(t))
+	state := cruiseState(1500, 100, 2*DEG_TO_RAD)
+
+	// moments returns the total moment and the gyroscopic breakdown with a
+	// registry in place of the calculator's
+	moments := func(masses *RotatingMasses) (Vector3, *ForceMomentComponents) {
+		t.Helper()
+		calc.Rotating = masses
+		components, err := calc.CalculateForcesMoments(state)
+		if err != nil {
+			t.Fatalf("CalculateForcesMoments: %v", err)
+		}
+		return components.TotalMoment, components
+	}
+	registry := func(masses ...*RotatingMass) *RotatingMasses {
+		t.Helper()
+		registry := NewRotatingMasses()
+		for _, mass := range masses {
+			if err := registry.Add(mass); err != nil {
+				t.Fatalf("Add: %v", err)
+			}
+		}
+		return registry
+	}
+
+	t.Run("Counter-Rotating", func(t *testing.T) {
+		state.AngularRate = Vector3{X: 0.3, Y: 0.4, Z: -0.2}
+		none, _ := moments(NewRotatingMasses())
+
+		// Equal and opposite angular momenta, one by its speed and one by
+		// its axis, cancel on every axis
+		total, components := moments(registry(
+			&RotatingMass{Name: "front", Inertia: 40, Axis: Vector3{X: 1}, RPM: ConstantRPM(2700)},
+			&RotatingMass{Name: "rear", Inertia: 40, Axis: Vector3{X: -1}, RPM: ConstantRPM(2700)},
+		))
+		assertEqual(t, components.Gyroscopic.Momentum, Vector3{})
+		assertEqual(t, components.Gyroscopic.Moment, Vector3{})
+		assertEqual(t, total, none)
+	})
+
+	t.Run("Precession", func(t *testing.T) {
+		state.AngularRate = Vector3{Y: 0.5}
+		none, _ := moments(NewRotatingMasses())
+		total, components := moments(registry(
+			&RotatingMass{Name: "rotor", Inertia: 2, Axis: Vector3{X: 1}, RPM: ConstantRPM(6000)},
+		))
+
+		// Pitching up at q turns H along X into a yaw moment of q·H
+		h := 2 * 6000 * 2 * math.Pi / 60
+		assertApproxEqual(t, components.Gyroscopic.Momentum.X, h, 1e-9)
+		assertApproxEqual(t, total.Z-none.Z, 0.5*h, 0.01*0.5*h)
+		assertApproxEqual(t, total.X-none.X, 0, 1e-9)
+		assertApproxEqual(t, total.Y-none.Y, 0, 1e-9)
+
+		properties := calc.Properties.GetPropertiesWithPrefix("rotating/")
+		assertApproxEqual(t, properties["rotating/h-x-kgm2_s"], h, 1e-9)
+		assertApproxEqual(t, properties["rotating/n-gyro-nm"], 0.5*h, 1e-9)
+		assertApproxEqual(t, properties["rotating/rotor/rpm"], 6000, 1e-12)
+	})
+
+	t.Run("RPM Sources", func(t *testing.T) {
+		state.AngularRate = Vector3{Y: 0.5}
+		calc.Properties.Set("propulsion/engine/supercharger-rpm", 20000)
+		_, components := moments(registry(
+			&RotatingMass{Name: "crankshaft", Inertia: 1, Axis: Vector3{X: 1}, RPM: EngineRPM(-1 / 0.479)},
+			&RotatingMass{Name: "impeller", Inertia: 0.1, Axis: Vector3{X: 1}, RPM: PropertyRPM("propulsion/engine/supercharger-rpm")},
+		))
+		crankshaft := -calc.Propeller.RPM / 0.479
+		assertApproxEqual(t, components.Gyroscopic.Momentum.X, (crankshaft+0.1*20000)*2*math.Pi/60, 1e-9)
+	})
+
+	t.Run("Propeller Entry", func(t *testing.T) {
+		// The propeller is a registry entry, counted only with its effect
+		state.AngularRate = Vector3{Y: 0.5}
+		assertEqual(t, NewForcesMomentsCalculator(calc.Config).Rotating.Names(), []string{PropellerRotatingMass})
+		propeller := newConfigRotatingMasses(calc.Propeller)
+		calc.PropellerEffects = PropellerEffects{Gyroscopic: true}
+		_, with := moments(propeller)
+		calc.PropellerEffects = PropellerEffects{}
+		_, without := moments(propeller)
+		h := calc.Propeller.Inertia * calc.Propeller.RPM * 2 * math.Pi / 60
+		assertApproxEqual(t, with.Gyroscopic.Moment.Z, 0.5*h, 1e-9*h)
+		assertEqual(t, without.Gyroscopic.Moment, Vector3{})
+	})
+
+	t.Run("Registry", func(t *testing.T) {
+		masses := NewRotatingMasses()
+		for _, mass := range []*RotatingMass{
+			{Inertia: 1, Axis: Vector3{X: 1}, RPM: ConstantRPM(1)},
+			{Name: "still", Inertia: 1, RPM: ConstantRPM(1)},
+			{Name: "idle", Inertia: 1, Axis: Vector3{X: 1}},
+		} {
+			if masses.Add(mass) == nil {
+				t.Errorf("%q should be refused", mass.Name)
+			}
+		}
+		if err := masses.Add(&RotatingMass{Name: "fan", Axis: Vector3{Z: 1}, RPM: ConstantRPM(1)}); err != nil {
+			t.Fatalf("Add: %v", err)
+		}
+		if masses.Add(&RotatingMass{Name: "fan", Axis: Vector3{Z: 1}, RPM: ConstantRPM(1)}) == nil {
+			t.Error("A second mass of one name should be refused")
+		}
+		assertEqual(t, masses.Remove("fan"), true)
+		assertEqual(t, masses.Len(), 0)
+	})
+}
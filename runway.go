@@ -0,0 +1,128 @@
+// Runway Context
+// Runway geometry for takeoffs and landings: the wind split into headwind
+// and crosswind, the crab angle, and the position along the runway
+
+package main
+
+import (
+	"math"
+)
+
+// Runway properties published by RunwayContext
+const (
+	RunwayHeadwindProperty  = "runway/headwind-mps"
+	RunwayCrosswindProperty = "runway/crosswind-mps"
+	RunwayCrabAngleProperty = "runway/crab-angle-deg"
+	RunwayDistanceProperty  = "runway/distance-m"
+	RunwayOffsetProperty    = "runway/centerline-offset-m"
+)
+
+// RunwayContext is a runway, by its threshold and true heading. The
+// threshold is a NED position, or with Geodetic set a latitude and
+// longitude, compared against the state's own.
+type RunwayContext struct {
+	Threshold Vector3 // NED position of the threshold (m)
+	Geodetic  bool    // Locate the threshold by Latitude and Longitude instead
+	Latitude  float64 // Threshold latitude (rad)
+	Longitude float64 // Threshold longitude (rad)
+	Heading   float64 // True heading of the runway (rad)
+	Length    float64 // m
+}
+
+// NewRunwayContext creates a runway with its threshold at a NED position
+func NewRunwayContext(threshold Vector3, heading, length float64) *RunwayContext {
+	return &RunwayContext{Threshold: threshold, Heading: heading, Length: length}
+}
+
+// NewGeodeticRunwayContext creates a runway with its threshold at a
+// latitude and longitude (rad)
+func NewGeodeticRunwayContext(latitude, longitude, heading, length float64) *RunwayContext {
+	return &RunwayContext{Geodetic: true, Latitude: latitude, Longitude: longitude, Heading: heading, Length: length}
+}
+
+// axes returns the unit vectors along the runway and to its right, NED
+func (rc *RunwayContext) axes() (along, right Vector3) {
+	sin, cos := math.Sincos(rc.Heading)
+	return Vector3{X: cos, Y: sin}, Vector3{X: -sin, Y: cos}
+}
+
+// fromThreshold returns the state's horizontal displacement from the
+// threshold, north and east (m)
+func (rc *RunwayContext) fromThreshold(state *AircraftState) Vector3 {
+	if !rc.Geodetic {
+		d := state.Position.Add(rc.Threshold.Scale(-1))
+		return Vector3{X: d.X, Y: d.Y}
+	}
+	return Vector3{
+		X: (state.Latitude - rc.Latitude) * EARTH_RADIUS_M,
+		Y: math.Remainder(state.Longitude-rc.Longitude, 2*math.Pi) * EARTH_RADIUS_M * math.Cos(rc.Latitude),
+	}
+}
+
+// Position returns the state's distance along the runway past the
+// threshold and its offset right of the centreline (m)
+func (rc *RunwayContext) Position(state *AircraftState) (distance, offset float64) {
+	along, right := rc.axes()
+	d := rc.fromThreshold(state)
+	return d.Dot(along), d.Dot(right)
+}
+
+// OnRunway reports whether the state lies over the runway's length,
+// whatever its offset from the centreline; any distance past the threshold
+// counts when Length is zero
+func (rc *RunwayContext) OnRunway(state *AircraftState) bool {
+	distance, _ := rc.Position(state)
+	return distance >= 0 && (rc.Length <= 0 || distance <= rc.Length)
+}
+
+// WindComponents splits the state's wind into the headwind, blowing down
+// the runway against the landing direction, and the crosswind, blowing
+// across it from the right (m/s)
+func (rc *RunwayContext) WindComponents(state *AircraftState) (headwind, crosswind float64) {
+	along, right := rc.axes()
+	return -state.Wind.Dot(along), -state.Wind.Dot(right)
+}
+
+// LateralVelocity returns the state's velocity over the ground across the
+// runway, positive to the right (m/s)
+func (rc *RunwayContext) LateralVelocity(state *AircraftState) float64 {
+	_, right := rc.axes()
+	return state.GroundVelocity().Dot(right)
+}
+
+// CrabAngle returns the heading less the ground track (rad), positive with
+// the nose right of the track: the angle held into a wind from the right.
+// It is zero below a walking pace, where the track is undefined.
+func CrabAngle(state *AircraftState) float64 {
+	v := state.GroundVelocity()
+	if math.Hypot(v.X, v.Y) < 1.0 {
+		return 0
+	}
+	return math.Remainder(state.Yaw-math.Atan2(v.Y, v.X), 2*math.Pi)
+}
+
+// Properties returns the runway properties of a state
+func (rc *RunwayContext) Properties(state *AircraftState) map[string]float64 {
+	headwind, crosswind := rc.WindComponents(state)
+	distance, offset := rc.Position(state)
+	return map[string]float64{
+		RunwayHeadwindProperty:  headwind,
+		RunwayCrosswindProperty: crosswind,
+		RunwayCrabAngleProperty: CrabAngle(state) * RAD_TO_DEG,
+		RunwayDistanceProperty:  distance,
+		RunwayOffsetProperty:    offset,
+	}
+}
+
+// Publish sets the runway properties of a state in the property manager
+func (rc *RunwayContext) Publish(state *AircraftState, properties *PropertyManager) {
+	for name, value := range rc.Properties(state) {
+		properties.Set(name, value)
+	}
+}
+
+// Attach makes the runway publish its properties for every state evaluated
+// by the bus
+func (rc *RunwayContext) Attach(bus *EventBus, properties *PropertyManager) *EventWatcher {
+	return bus.OnStep(func(state *AircraftState, _ float64) { rc.Publish(state, properties) })
+}
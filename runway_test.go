@@ -0,0 +1,98 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRunwayContext(t *testing.T) {
+	t.Run("Wind Components", func(t *testing.T) {
+		// Runway 09, wind from the north-east at 10 m/s
+		rc := NewRunwayContext(Vector3{X: 100, Y: 200}, math.Pi/2, 1500)
+		state := NewAircraftState()
+		state.Wind = Vector3{X: -10 / math.Sqrt2, Y: -10 / math.Sqrt2}
+		headwind, crosswind := rc.WindComponents(state)
+		assertApproxEqual(t, headwind, 10/math.Sqrt2, 1e-12)
+		assertApproxEqual(t, crosswind, -10/math.Sqrt2, 1e-12)
+
+		// 300 m down the runway, 5 m south of the centreline: the right
+		state.Position = Vector3{X: 95, Y: 500}
+		distance, offset := rc.Position(state)
+		assertApproxEqual(t, distance, 300.0, 1e-9)
+		assertApproxEqual(t, offset, 5.0, 1e-9)
+		assertEqual(t, rc.OnRunway(state), true)
+		state.Position.Y = 1800
+		assertEqual(t, rc.OnRunway(state), false)
+	})
+
+	t.Run("Geodetic Threshold", func(t *testing.T) {
+		lat, lon := 40*DEG_TO_RAD, -105*DEG_TO_RAD
+		rc := NewGeodeticRunwayContext(lat, lon, 0, 3000)
+		state := NewAircraftState()
+		state.Latitude = lat + 1000/EARTH_RADIUS_M
+		state.Longitude = lon - 20/(EARTH_RADIUS_M*math.Cos(lat))
+		distance, offset := rc.Position(state)
+		assertApproxEqual(t, distance, 1000.0, 1e-6)
+		assertApproxEqual(t, offset, -20.0, 1e-6)
+	})
+
+	t.Run("Crab Angle", func(t *testing.T) {
+		state := NewAircraftState()
+		state.Orientation = NewQuaternionFromEuler(0, 0, 10*DEG_TO_RAD)
+		state.Velocity = Vector3{X: 50}
+		state.Wind = Vector3{Y: -50 * math.Sin(10*DEG_TO_RAD)}
+		state.UpdateDerivedParameters()
+		assertApproxEqual(t, CrabAngle(state)*RAD_TO_DEG, 10.0, 1e-4)
+		assertApproxEqual(t, state.GroundVelocity().Y, 0.0, 1e-9)
+
+		// No track at rest
+		state.Velocity, state.Wind = Vector3{}, Vector3{}
+		assertEqual(t, CrabAngle(state), 0.0)
+	})
+
+	t.Run("Publish", func(t *testing.T) {
+		engine := NewSimplifiedFlightDynamicsEngine(NewEulerIntegrator())
+		state := trimLevelFlight(t, engine, 1000, 100)
+		state.Wind = Vector3{X: -5, Y: 3}
+		properties := NewPropertyManager()
+		NewRunwayContext(Vector3{}, 0, 0).Attach(engine.Events, properties)
+		if _, err := engine.Step(state, 0.01); err != nil {
+			t.Fatalf("Step: %v", err)
+		}
+		assertApproxEqual(t, properties.Get(RunwayHeadwindProperty), 5.0, 1e-12)
+		assertApproxEqual(t, properties.Get(RunwayCrosswindProperty), -3.0, 1e-12)
+
+		// Drifting right of the heading with the wind from the left
+		assertApproxEqual(t, properties.Get(RunwayCrabAngleProperty), -math.Atan2(3, 95)*RAD_TO_DEG, 0.01)
+	})
+
+	t.Run("Crosswind Landing", func(t *testing.T) {
+		// An 8 m/s wind straight across from the right
+		wind := Vector3{Y: -8}
+		analyzer, _, trajectory := windLandingScenario(t, wind, 45.0, 3.0, 8.0, 0.8, 1.0)
+		baseline, _, _ := landingScenario(t, 45.0, 3.0, 8.0, 0.8, 1.0)
+		report := analyzer.Report
+		if report == nil || baseline.Report == nil {
+			t.Fatal("No touchdown detected")
+		}
+		t.Logf("\n%s", report)
+		t.Logf("Side load without wind: %.0f N", baseline.Report.MaxSideLoad)
+
+		// Crabbed on final, the ground track down the centreline
+		rc := NewRunwayContext(Vector3{}, 0, 0)
+		final := trajectory[len(trajectory)/4]
+		headwind, crosswind := rc.WindComponents(final)
+		assertApproxEqual(t, crosswind, 8.0, 1e-9)
+		assertApproxEqual(t, headwind, 0.0, 1e-9)
+		assertApproxEqual(t, CrabAngle(final), math.Atan2(8, 45), 1e-6)
+		assertApproxEqual(t, rc.LateralVelocity(final), 0.0, 1e-9)
+
+		assertApproxEqual(t, report.Crosswind, 8.0, 1e-9)
+		assertApproxEqual(t, report.CrabAngle, math.Atan2(8, 45), 1e-3)
+		assertApproxEqual(t, report.CenterlineOffset, 0.0, 1e-6)
+		if report.MaxSideLoad <= baseline.Report.MaxSideLoad+100 {
+			t.Errorf("Touching down crabbed should load the gear sideways: %.0f N against %.0f N without wind",
+				report.MaxSideLoad, baseline.Report.MaxSideLoad)
+		}
+	})
+}
@@ -0,0 +1,116 @@
+// Sessions
+// Per-trajectory handles on one aircraft, so several states can be stepped
+// through it at once
+//
+// An engine is for one trajectory at a time. Its Step writes the
+// calculator's property tree and caches, the statistics, the integrator's
+// stage function and any history the integrator keeps, and, with an FCS,
+// the filter, actuator and autopilot states. Two goroutines stepping one
+// engine race. Concurrent trajectories each step their own session.
+//
+// A session shares with its engine only what a step reads: the
+// configuration with its functions and parsed tables, the reference, mass
+// and propeller data, the landing gear geometry, the structural and
+// placard limits, the control blowback and the terrain. None of these may
+// be changed while sessions step. The parsed table cache is safe for
+// concurrent use. Everything a step writes is the session's own.
+
+package main
+
+import "fmt"
+
+// NewSession returns a calculator sharing this one's configuration and
+// tables with a property tree of its own, starting from a copy of this
+// one's, and its own external force and rotating mass registries,
+// slipstream model, control surface motion and dynamic stall state
+func (calc *ForcesMomentsCalculator) NewSession() *ForcesMomentsCalculator {
+	session := *calc
+	session.Properties = newEmptyPropertyManager()
+	if calc.Properties != nil {
+		session.Properties.restore(calc.Properties.checkpoint())
+	}
+	session.functionInputs, session.published, session.loggedBlend = nil, nil, ""
+	if calc.External != nil {
+		session.External = calc.External.clone()
+	}
+	if calc.Rotating != nil {
+		session.Rotating = calc.Rotating.clone()
+	}
+	if calc.SurfaceMass != nil {
+		session.SurfaceMass = calc.SurfaceMass.clone()
+	}
+	if calc.DynamicStall != nil {
+		session.DynamicStall = calc.DynamicStall.clone()
+	}
+	if calc.Slipstream != nil {
+		slipstream := *calc.Slipstream
+		slipstream.tailTerms, slipstream.published = nil, nil
+		session.Slipstream = &slipstream
+	}
+	return &session
+}
+
+// NewSession returns an engine for one more trajectory of this aircraft:
+// a session of the calculator, an integrator of the same method, empty
+// statistics and an event bus without watchers. Departures, the memory
+// budget, control replay and released stores are per trajectory and not
+// carried over.
+func (fde *FlightDynamicsEngine) NewSession() *FlightDynamicsEngine {
+	return &FlightDynamicsEngine{
+		Calculator: fde.Calculator.NewSession(),
+		Integrator: sessionIntegrator(fde.Integrator),
+		Statistics: &FlightStatistics{MaxExceedances: fde.Statistics.MaxExceedances},
+		Terrain:    fde.Terrain,
+		Limits:     fde.Limits,
+		Placards:   fde.Placards,
+		Gear:       fde.Gear,
+		Events:     NewEventBus(),
+		Units:      fde.Units,
+
+		PilotStation: fde.PilotStation,
+	}
+}
+
+// NewSession returns an engine for one more trajectory of this aircraft,
+// with an FCS and autopilot built as NewFlightDynamicsEngineWithFCS builds
+// them, sharing their property tree with a session of the calculator.
+// Components added to this engine's FCS are not carried over.
+func (engine *FlightDynamicsEngineWithFCS) NewSession() (*FlightDynamicsEngineWithFCS, error) {
+	fcs := CreateBasicFlightControlSystem()
+	if engine.UseRealisticControls {
+		fcs = CreateStandardP51DFlightControlSystem()
+	}
+	base := engine.FlightDynamicsEngine.NewSession()
+	base.Calculator.Properties = fcs.Properties
+
+	var autopilot *FlightControlSystem
+	if config := engine.Calculator.Config; config != nil && config.Autopilot != nil {
+		var err error
+		if autopilot, err = BuildAutopilotFromConfig(config, fcs.Properties); err != nil {
+			return nil, fmt.Errorf("autopilot: %w", err)
+		}
+	}
+	return &FlightDynamicsEngineWithFCS{
+		FlightDynamicsEngine: base,
+		FCS:                  fcs,
+		Autopilot:            autopilot,
+		UseRealisticControls: engine.UseRealisticControls,
+		Limits:               engine.Limits.fresh(),
+		StagePolicy:          engine.StagePolicy,
+	}, nil
+}
+
+// sessionIntegrator returns an integrator of the same method for a
+// session. The methods keeping a stage function or history between steps
+// get a new instance; the others hold nothing and are shared.
+func sessionIntegrator(integrator Integrator) Integrator {
+	switch integrator.(type) {
+	case *AdamsBashforth2Integrator:
+		return NewAdamsBashforth2Integrator()
+	case *TrueRK4Integrator:
+		return NewTrueRK4Integrator(nil)
+	case *IntegratorWithDynamics:
+		return NewIntegratorWithDynamics(nil)
+	}
+	return integrator
+}
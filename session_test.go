@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+)
+
+func TestSessions(t *testing.T) {
+	const sessions = 8
+
+	// fly steps a copy of the initial state for a number of steps
+	fly := func(engine MonteCarloEngine, initial *AircraftState, steps int) (*AircraftState, error) {
+		state := initial.Copy()
+		for i := 0; i < steps; i++ {
+			next, err := engine.Step(state, 0.01)
+			if err != nil {
+				return nil, err
+			}
+			state = next
+		}
+		return state, nil
+	}
+
+	// parallel runs one function per session at once and returns the states
+	parallel := func(run func(i int) (*AircraftState, error)) []*AircraftState {
+		t.Helper()
+		states := make([]*AircraftState, sessions)
+		errs := make([]error, sessions)
+		var wg sync.WaitGroup
+		for i := range states {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				states[i], errs[i] = run(i)
+			}()
+		}
+		wg.Wait()
+		for i, err := range errs {
+			if err != nil {
+				t.Fatalf("Session %d: %v", i, err)
+			}
+		}
+		return states
+	}
+
+	t.Run("Parallel Sessions", func(t *testing.T) {
+		engine := NewFlightDynamicsEngine(loadP51DConfig(t), NewTrueRK4Integrator(nil))
+		engine.Calculator.Slipstream = NewSlipstream()
+		engine.Calculator.External = NewExternalForces(nil)
+		engine.Calculator.External.Add(&ExternalForce{Name: "tow", Direction: Vector3{X: 1}, Magnitude: 500})
+
+		// Each session flies a different throttle, and matches the same
+		// flight stepped alone
+		initial := func(i int) *AircraftState {
+			state := cruiseState(1500, 100, 2*DEG_TO_RAD)
+			state.Controls.Throttle = 0.3 + 0.1*float64(i%4)
+			return state
+		}
+		states := parallel(func(i int) (*AircraftState, error) {
+			return fly(engine.NewSession(), initial(i), 50)
+		})
+		for i, state := range states {
+			alone, err := fly(engine.NewSession(), initial(i), 50)
+			if err != nil {
+				t.Fatalf("Step: %v", err)
+			}
+			assertEqual(t, state.Position, alone.Position)
+			assertEqual(t, state.Velocity, alone.Velocity)
+			assertEqual(t, state.AngularRate, alone.AngularRate)
+		}
+		assertEqual(t, engine.Statistics.FlightTime, 0.0)
+		assertEqual(t, engine.Calculator.External.Len(), 1)
+	})
+
+	t.Run("Compiled Model", func(t *testing.T) {
+		var artifact bytes.Buffer
+		if err := ExportCompiledModel(loadP51DConfig(t), &artifact); err != nil {
+			t.Fatalf("Export: %v", err)
+		}
+		model, err := LoadCompiledModel(bytes.NewReader(artifact.Bytes()))
+		if err != nil {
+			t.Fatalf("Load: %v", err)
+		}
+
+		state := func(i int) *AircraftState {
+			return cruiseState(1000+100*float64(i), 90+float64(i), float64(i)*DEG_TO_RAD)
+		}
+		want := make([]ForceMomentComponents, sessions)
+		for i := range want {
+			components, err := model.NewSession().CalculateForcesMoments(state(i))
+			if err != nil {
+				t.Fatalf("CalculateForcesMoments: %v", err)
+			}
+			want[i] = *components
+		}
+
+		// Sessions evaluating the model from 8 goroutines get the results
+		// each gets alone
+		var wg sync.WaitGroup
+		got := make([]ForceMomentComponents, sessions)
+		for i := range got {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				calc := model.NewSession()
+				for range 20 {
+					if components, err := calc.CalculateForcesMoments(state(i)); err == nil {
+						got[i] = *components
+					}
+				}
+			}()
+		}
+		wg.Wait()
+		for i := range got {
+			assertEqual(t, got[i].TotalForce, want[i].TotalForce)
+			assertEqual(t, got[i].TotalMoment, want[i].TotalMoment)
+		}
+	})
+
+	t.Run("FCS Sessions", func(t *testing.T) {
+		engine, err := NewFlightDynamicsEngineWithFCS(loadP51DConfig(t), true)
+		if err != nil {
+			t.Fatalf("NewFlightDynamicsEngineWithFCS: %v", err)
+		}
+		states := parallel(func(i int) (*AircraftState, error) {
+			session, err := engine.NewSession()
+			if err != nil {
+				return nil, err
+			}
+			state := cruiseState(1500, 100, 2*DEG_TO_RAD)
+			state.Controls.Elevator = 0.2 * float64(i%2)
+			for range 30 {
+				if state, _, err = session.RunSimulationStepWithFCS(state, 0.01); err != nil {
+					return nil, err
+				}
+			}
+			return state, nil
+		})
+
+		// A session's FCS and property tree are its own
+		session, err := engine.NewSession()
+		if err != nil {
+			t.Fatalf("NewSession: %v", err)
+		}
+		if session.FCS == engine.FCS || session.Calculator.Properties != session.FCS.Properties ||
+			session.Calculator.Properties == engine.Calculator.Properties {
+			t.Error("A session should have its own FCS, sharing its property tree with its calculator")
+		}
+		assertEqual(t, states[2].Velocity, states[0].Velocity)
+		if states[1].Velocity == states[0].Velocity {
+			t.Error("Sessions flying different elevators should diverge")
+		}
+	})
+
+	t.Run("Monte Carlo", func(t *testing.T) {
+		mc := newClimbMonteCarlo(16, 7)
+		mc.Workers = sessions
+		mc.Dispersions = mc.Dispersions[:1]
+		mc.Scenario.NewEngine = nil
+		mc.Scenario.Engine = NewFlightDynamicsEngine(loadP51DConfig(t), NewRungeKutta4Integrator())
+		result, err := mc.Run()
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		assertEqual(t, result.Failures, 0)
+		assertEqual(t, mc.Scenario.Engine.Statistics.FlightTime, 0.0)
+
+		// A shared engine's configuration is not dispersed
+		mc = newClimbMonteCarlo(16, 7)
+		mc.Scenario.NewEngine = nil
+		mc.Scenario.Engine = NewFlightDynamicsEngine(loadP51DConfig(t), NewRungeKutta4Integrator())
+		if _, err := mc.Run(); err == nil {
+			t.Error("An engine option dispersion should be refused with a shared engine")
+		}
+	})
+}
@@ -0,0 +1,78 @@
+// Simulation Time Scheduling
+// Fixed-rate schedules on simulation time for the subsystems that run
+// slower or faster than the simulation step
+
+package main
+
+import "math"
+
+// simScheduleTolerance is the distance (s) below which a boundary and the
+// end of a step are taken to coincide, so that rounding in an accumulated
+// simulation time does not move an execution into the neighbouring step
+const simScheduleTolerance = 1e-9
+
+// SimSchedule fires at the simulation-time boundaries 0, Period, 2·Period
+// and so on. A step from t to t+dt owes one execution for each boundary in
+// [t, t+dt): a period shorter than the step executes several times in the
+// step to catch up, and a longer one only in the steps its boundaries fall
+// in. Boundaries are counted rather than accumulated, so a long run does not
+// drift, and as nothing depends on wall-clock time a run is reproducible
+// however fast it executes. A schedule without a positive, finite period
+// never fires.
+type SimSchedule struct {
+	Period float64 // Simulation time between executions (s)
+	next   int64   // Index of the next boundary
+	from   float64 // Start of the latest step asked about
+}
+
+// NewSimSchedule returns a schedule at rateHz executions per second of
+// simulation time, its first boundary at time zero
+func NewSimSchedule(rateHz float64) SimSchedule {
+	return SimSchedule{Period: 1.0 / rateHz}
+}
+
+// Next returns the simulation time of the next boundary
+func (s *SimSchedule) Next() float64 {
+	return float64(s.next) * s.Period
+}
+
+// Due reports whether the next boundary falls in the step [from, to). Steps
+// are expected in time order. A step that starts earlier than the last one,
+// as after a rewind, realigns the schedule to the step; one that starts
+// after boundaries that never fired, as after a gap in the steps, owes a
+// single late execution for them rather than a burst.
+func (s *SimSchedule) Due(from, to float64) bool {
+	if !(s.Period > 0) || math.IsInf(s.Period, 1) {
+		return false
+	}
+	if from < s.from-simScheduleTolerance {
+		s.next = int64(math.Ceil((from - simScheduleTolerance) / s.Period))
+	}
+	if s.Next() < from-simScheduleTolerance {
+		s.next = int64(math.Floor((from + simScheduleTolerance) / s.Period))
+	}
+	s.from = from
+	return s.Next() < to-simScheduleTolerance
+}
+
+// Fire marks the next boundary executed
+func (s *SimSchedule) Fire() {
+	s.next++
+}
+
+// Count fires every boundary in the step [from, to) and returns how many
+// there were
+func (s *SimSchedule) Count(from, to float64) int {
+	n := 0
+	for s.Due(from, to) {
+		s.Fire()
+		n++
+	}
+	return n
+}
+
+// Reset returns the schedule to its first boundary, at time zero
+func (s *SimSchedule) Reset() {
+	s.next = 0
+	s.from = 0
+}
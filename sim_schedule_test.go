@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSimSchedule(t *testing.T) {
+	t.Run("Catches Up In Long Steps", func(t *testing.T) {
+		s := NewSimSchedule(120.0)
+		total := 0
+		for i := 0; i < 100; i++ {
+			from := float64(i) * 0.05
+			n := s.Count(from, from+0.05)
+			if n != 6 {
+				t.Fatalf("Step %d: %d executions, expected 6", i, n)
+			}
+			total += n
+		}
+		assertEqual(t, total, 600)
+	})
+
+	t.Run("Skips Steps Between Boundaries", func(t *testing.T) {
+		s := NewSimSchedule(40.0)
+		var fired []int
+		for i := 0; i < 20; i++ {
+			from := float64(i) / 120.0
+			if s.Count(from, from+1/120.0) > 0 {
+				fired = append(fired, i)
+			}
+		}
+		assertEqual(t, fired, []int{0, 3, 6, 9, 12, 15, 18})
+	})
+
+	t.Run("No Drift Over A Long Run", func(t *testing.T) {
+		// A million accumulated steps carry rounding the boundaries must not
+		s := NewSimSchedule(30.0)
+		simTime, total := 0.0, 0
+		for i := 0; i < 1000000; i++ {
+			total += s.Count(simTime, simTime+0.001)
+			simTime += 0.001
+		}
+		assertEqual(t, total, 30000)
+	})
+
+	t.Run("Rewind And Gap", func(t *testing.T) {
+		s := NewSimSchedule(10.0)
+		for i := 0; i < 10; i++ {
+			assertEqual(t, s.Count(float64(i)*0.1, float64(i+1)*0.1), 1)
+		}
+
+		// Back to 0.45 s: the next execution is at 0.5 s
+		assertEqual(t, s.Count(0.45, 0.46), 0)
+		assertEqual(t, s.Next(), 0.5)
+
+		// Forward to 2.05 s: one late execution for the missed boundaries
+		assertEqual(t, s.Count(2.05, 2.06), 1)
+		assertEqual(t, s.Count(2.06, 2.15), 1)
+	})
+}
+
+// TestDeterministicScheduling runs the same 10 s scenario twice, once with
+// pauses in wall-clock time, and requires identical histories
+func TestDeterministicScheduling(t *testing.T) {
+	const dt, steps = 0.01, 1000
+
+	run := func(pause func(step int)) ([][]float64, []byte) {
+		engine := NewSimplifiedFlightDynamicsEngine(NewEulerIntegrator())
+		state := trimLevelFlight(t, engine, 1000.0, 100.0)
+		trim := state.Controls.Elevator
+
+		// Rate groups at 120, 40 and 10 Hz against a 100 Hz step
+		fcs := CreateStandardP51DFlightControlSystem()
+		var csv bytes.Buffer
+		om, err := NewOutputManager(&Output{Rate: 30, Simulation: "ON",
+			Properties: []*OutputProperty{{Name: "fcs/elevator-pos-rad"}, {Name: "fcs/left-aileron-pos-rad"}}},
+			1/dt, &csv)
+		if err != nil {
+			t.Fatalf("NewOutputManager: %v", err)
+		}
+		om.Properties = fcs.Properties
+
+		names := []string{"fcs/elevator-pos-rad", "fcs/left-aileron-pos-rad", "fcs/rudder-pos-rad"}
+		var history [][]float64
+		for i := 0; i < steps; i++ {
+			pause(i)
+			state.Controls.Elevator = trim
+			state.Controls.Aileron = 0
+			if state.Time >= 2 && state.Time < 3 {
+				state.Controls.Elevator += 0.05
+				state.Controls.Aileron = 0.2
+			}
+			fcs.Execute(state, dt)
+			if err := om.Record(state); err != nil {
+				t.Fatalf("Record: %v", err)
+			}
+			row := make([]float64, len(names))
+			for k, name := range names {
+				row[k] = fcs.Properties.Get(name)
+			}
+			history = append(history, row)
+			if state, err = engine.Step(state, dt); err != nil {
+				t.Fatalf("Step %d failed: %v", i, err)
+			}
+		}
+		if err := om.Flush(); err != nil {
+			t.Fatalf("Flush: %v", err)
+		}
+
+		// Each group ran at its own rate in simulation time
+		assertApproxEqual(t, fcs.GetRateGroup("high").LastExecution, 1199/120.0, 1e-9)
+		assertApproxEqual(t, fcs.GetRateGroup("medium").LastExecution, 399/40.0, 1e-9)
+		assertApproxEqual(t, fcs.GetRateGroup("low").LastExecution, 99/10.0, 1e-9)
+		return history, csv.Bytes()
+	}
+
+	fullSpeed, fullSpeedCSV := run(func(int) {})
+	paused, pausedCSV := run(func(step int) {
+		if step%100 == 50 {
+			time.Sleep(10 * time.Millisecond) // Longer than any group's period
+		}
+	})
+
+	assertEqual(t, len(fullSpeed), steps)
+	for i := range fullSpeed {
+		for k := range fullSpeed[i] {
+			if fullSpeed[i][k] != paused[i][k] && !(math.IsNaN(fullSpeed[i][k]) && math.IsNaN(paused[i][k])) {
+				t.Fatalf("Histories differ at step %d: %v against %v", i, fullSpeed[i], paused[i])
+			}
+		}
+	}
+	if !bytes.Equal(fullSpeedCSV, pausedCSV) {
+		t.Error("Output recordings differ")
+	}
+
+	// 30 Hz output: a header and 300 rows
+	assertEqual(t, bytes.Count(fullSpeedCSV, []byte("\n")), 301)
+
+	// The doublet reached the surfaces
+	moved := false
+	for _, row := range fullSpeed {
+		if math.Abs(row[1]) > 1e-3 {
+			moved = true
+		}
+	}
+	assertEqual(t, moved, true)
+}
@@ -52,7 +52,7 @@ func TestBasicPropulsionIntegration(t *testing.T) {
 			Velocity:    Vector3{X: 50, Y: 0, Z: 0},
 			Orientation: Quaternion{W: 1, X: 0, Y: 0, Z: 0},
 			AngularRate: Vector3{X: 0, Y: 0, Z: 0},
-			Controls:    ControlInputs{Throttle: 0.75},
+			Controls:    ControlInputs{Throttle: 0.75, Mixture: 1.0, Propeller: 1.0},
 			Temperature: 288.15,
 			Pressure:    101325.0,
 			Density:     1.225,
@@ -0,0 +1,145 @@
+// Slipstream
+// The propeller slipstream over the tail: the dynamic pressure it adds to
+// the tail surfaces, which changes the pitch and yaw trim with power, and
+// the sidewash of its swirl on the fin
+
+package main
+
+import (
+	"math"
+	"slices"
+)
+
+// Slipstream model defaults for the P-51D. The fractions of the
+// slipstream's dynamic pressure reaching the tail allow for its contraction
+// and for the tail sitting partly outside it.
+const (
+	P51DPropellerDiameter = 11.2 // ft
+	DefaultHTailImmersion = 0.5
+	DefaultVTailImmersion = 0.6
+	DefaultSwirlYaw       = 0.1
+)
+
+// Properties tuning the slipstream, which the calculator adopts when they
+// are set to other than it published
+const (
+	slipstreamHTailProperty = "aero/slipstream/htail-factor"
+	slipstreamVTailProperty = "aero/slipstream/vtail-factor"
+	slipstreamSwirlProperty = "aero/slipstream/swirl-yaw-factor"
+	slipstreamRatioProperty = "aero/slipstream/qbar-ratio"
+)
+
+// DefaultTailInputs are the properties marking a pitch or yaw function as a
+// tail term: the tail areas and surfaces, and the dynamic pressure JSBSim
+// configurations give the terms they immerse in the propwash
+var DefaultTailInputs = []string{
+	"aero/thrust-qbar_psf",
+	"metrics/Sh-sqft",
+	"metrics/Sv-sqft",
+	"fcs/elevator-pos-rad",
+	"fcs/elevator-pos-norm",
+	"fcs/rudder-pos-rad",
+	"fcs/rudder-pos-norm",
+}
+
+// Slipstream adds the propeller slipstream's effect on the tail to the
+// aerodynamic moments. By momentum theory the fully developed slipstream
+// raises the dynamic pressure by T/A over the free stream; the tail terms
+// of the pitch and yaw axes are scaled up by a fraction of that increase.
+// The tail terms are the functions reading any of TailInputs.
+//
+// With more power the tail's moment at a trimmed state grows: on the P-51D,
+// whose tail holds the nose up, power pitches the nose up and the trimmed
+// elevator moves trailing edge down. The swirl of a clockwise propeller,
+// seen from behind, strikes the fin from the left and yaws the nose left,
+// by SwirlYaw times the propeller torque, so in proportion to power at a
+// governed RPM.
+//
+// Configurations raising their tail terms' dynamic pressure by the
+// propeller's induced velocity already model the first effect, and the
+// P-51D's spiraling propwash term the second, when the induced velocity
+// and thrust are published; the factors should then be reduced.
+type Slipstream struct {
+	DiskArea    float64 // Propeller disk area (m²)
+	HTailFactor float64 // Fraction of the slipstream's dynamic pressure increase at the horizontal tail
+	VTailFactor float64 // And at the vertical tail
+	SwirlYaw    float64 // Yaw moment of the swirl per unit propeller torque
+	TailInputs  []string
+
+	tailTerms map[*Function]bool
+	published map[string]float64
+}
+
+// NewSlipstream returns a slipstream model with the P-51D defaults
+func NewSlipstream() *Slipstream {
+	radius := P51DPropellerDiameter * FT_TO_M / 2
+	return &Slipstream{
+		DiskArea:    math.Pi * radius * radius,
+		HTailFactor: DefaultHTailImmersion,
+		VTailFactor: DefaultVTailImmersion,
+		SwirlYaw:    DefaultSwirlYaw,
+		TailInputs:  DefaultTailInputs,
+	}
+}
+
+// QRatio returns the slipstream's dynamic pressure increase as a fraction
+// of the free stream's, at a thrust (N) and free stream dynamic pressure
+// (Pa). It is zero without thrust or airflow.
+func (s *Slipstream) QRatio(thrust, qbar float64) float64 {
+	if thrust <= 0 || qbar <= 0 || s.DiskArea <= 0 {
+		return 0
+	}
+	return thrust / (s.DiskArea * qbar)
+}
+
+// SwirlMoment returns the yaw moment (N·m) of the swirl for a propeller of
+// the given sense turning with the given torque (N·m)
+func (s *Slipstream) SwirlMoment(sense, torque float64) float64 {
+	return -sense * s.SwirlYaw * torque
+}
+
+// applyProperties adopts the tuning properties that have been set since
+// they were last published, and publishes the factors in use
+func (s *Slipstream) applyProperties(properties map[string]float64) {
+	if s.published == nil {
+		s.published = make(map[string]float64)
+	}
+	for name, factor := range map[string]*float64{
+		slipstreamHTailProperty: &s.HTailFactor,
+		slipstreamVTailProperty: &s.VTailFactor,
+		slipstreamSwirlProperty: &s.SwirlYaw,
+	} {
+		if value, ok := properties[name]; ok && value != s.published[name] {
+			*factor = value
+		}
+		properties[name] = *factor
+		s.published[name] = *factor
+	}
+}
+
+// isTailTerm reports whether a function reads any of the tail inputs
+func (s *Slipstream) isTailTerm(f *Function) bool {
+	if s.tailTerms == nil {
+		s.tailTerms = make(map[*Function]bool)
+	}
+	tail, ok := s.tailTerms[f]
+	if !ok {
+		tail = slices.ContainsFunc(functionProperties(f), func(name string) bool {
+			return slices.Contains(s.TailInputs, name)
+		})
+		s.tailTerms[f] = tail
+	}
+	return tail
+}
+
+// immersion returns the fraction of the dynamic pressure increase reaching
+// the tail terms of an axis, zero for the axes without them
+func (s *Slipstream) immersion(axis string) float64 {
+	switch axis {
+	case "PITCH":
+		return s.HTailFactor
+	case "YAW":
+		return s.VTailFactor
+	}
+	return 0
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// tailConfig has a wing-body pitching moment nose down, held by a tail at
+// an incidence, and a rudder
+const tailConfig = `<fdm_config name="tail">
+	<metrics>
+		<wingarea unit="FT2"> 235 </wingarea>
+		<wingspan unit="FT"> 37 </wingspan>
+		<chord unit="FT"> 6.6 </chord>
+	</metrics>
+	<mass_balance>
+		<emptywt unit="LBS"> 7000 </emptywt>
+	</mass_balance>
+	<aerodynamics>
+		<axis name="PITCH">
+			<function name="aero/coefficient/Cm0">
+				<product>
+					<value> -0.03 </value>
+					<value> 1 </value>
+				</product>
+			</function>
+			<function name="aero/coefficient/Cmht">
+				<product>
+					<sum>
+						<property>fcs/elevator-pos-rad</property>
+						<value> -0.05 </value>
+					</sum>
+					<value> -1.2 </value>
+				</product>
+			</function>
+		</axis>
+		<axis name="YAW">
+			<function name="aero/coefficient/Cndr">
+				<product>
+					<property>fcs/rudder-pos-rad</property>
+					<value> -0.1 </value>
+				</product>
+			</function>
+		</axis>
+	</aerodynamics>
+</fdm_config>`
+
+func TestSlipstream(t *testing.T) {
+	config, err := ParseJSBSimConfig(strings.NewReader(tailConfig))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	// trim returns the elevator and rudder holding zero pitch and yaw
+	// moment at a throttle, both moments being linear in the surfaces
+	trim := func(calc *ForcesMomentsCalculator, throttle float64) (elevator, rudder float64) {
+		t.Helper()
+		moments := func(elevator, rudder float64) (pitch, yaw float64) {
+			state := cruiseState(1000, 80, 0)
+			state.Controls.Throttle = throttle
+			state.ControlSurfaces.Elevator, state.ControlSurfaces.Rudder = elevator, rudder
+			components, err := calc.CalculateForcesMoments(state)
+			if err != nil {
+				t.Fatalf("CalculateForcesMoments: %v", err)
+			}
+			return components.Moments.Pitch, components.Moments.Yaw
+		}
+		pitch, yaw := moments(0, 0)
+		pitchUp, _ := moments(0.1, 0)
+		_, yawRight := moments(0, 0.1)
+		return -0.1 * pitch / (pitchUp - pitch), -0.1 * yaw / (yawRight - yaw)
+	}
+
+	t.Run("Trim Change With Power", func(t *testing.T) {
+		calc := NewForcesMomentsCalculator(config)
+		idleElevator, idleRudder := trim(calc, 0)
+		assertApproxEqual(t, idleElevator, 0.025, 1e-12)
+		assertApproxEqual(t, idleRudder, 0, 1e-12)
+
+		// Without the model power changes no trim
+		fullElevator, fullRudder := trim(calc, 1)
+		assertApproxEqual(t, fullElevator, idleElevator, 1e-12)
+		assertApproxEqual(t, fullRudder, idleRudder, 1e-12)
+
+		// With it the tail, holding the nose up, is stronger: the elevator
+		// trims trailing edge down, to 0.05 − 0.025/(1 + k) at the tail's
+		// share k of the dynamic pressure increase
+		calc.Slipstream = NewSlipstream()
+		idleElevator, idleRudder = trim(calc, 0)
+		assertApproxEqual(t, idleElevator, 0.025, 1e-12)
+		assertApproxEqual(t, idleRudder, 0, 1e-12)
+		fullElevator, fullRudder = trim(calc, 1)
+		state := cruiseState(1000, 80, 0)
+		k := DefaultHTailImmersion * calc.Slipstream.QRatio(8000*state.Density/1.225, state.DynamicPressure)
+		assertApproxEqual(t, fullElevator, 0.05-0.025/(1+k), 1e-12)
+		if fullElevator <= idleElevator+0.002 {
+			t.Errorf("Full power should trim the elevator down from %.4f rad, got %.4f rad", idleElevator, fullElevator)
+		}
+
+		// The swirl yaws the nose left, which right rudder (negative, for
+		// this rudder's derivative) holds
+		if fullRudder >= 0 {
+			t.Errorf("Full power should need right rudder, got %.5f rad", fullRudder)
+		}
+		state.Controls.Throttle = 1
+		components, err := calc.CalculateForcesMoments(state)
+		if err != nil {
+			t.Fatalf("CalculateForcesMoments: %v", err)
+		}
+		if components.Slipstream.Yaw >= 0 || components.Moments.Yaw >= 0 {
+			t.Errorf("The clockwise propeller's swirl should yaw the nose left, got %.1f N·m", components.Slipstream.Yaw)
+		}
+		assertApproxEqual(t, components.Slipstream.Yaw, -DefaultSwirlYaw*components.Propulsion.Torque, 1e-9)
+		assertApproxEqual(t, calc.Properties.Get("aero/slipstream/qbar-ratio"), components.Slipstream.QRatio, 1e-12)
+	})
+
+	t.Run("Tuning Properties", func(t *testing.T) {
+		calc := NewForcesMomentsCalculator(config)
+		calc.Slipstream = NewSlipstream()
+		idleElevator, _ := trim(calc, 0)
+		assertApproxEqual(t, calc.Properties.Get("aero/slipstream/htail-factor"), DefaultHTailImmersion, 1e-12)
+
+		// Zeroed through the property tree, the factors switch the effects off
+		calc.Properties.Set("aero/slipstream/htail-factor", 0)
+		calc.Properties.Set("aero/slipstream/swirl-yaw-factor", 0)
+		fullElevator, fullRudder := trim(calc, 1)
+		assertApproxEqual(t, calc.Slipstream.HTailFactor, 0, 1e-12)
+		assertApproxEqual(t, fullElevator, idleElevator, 1e-12)
+		assertApproxEqual(t, fullRudder, 0, 1e-12)
+
+		// The tail terms are those reading the tail surfaces
+		terms := map[string]bool{}
+		for _, axis := range config.Aerodynamics.Axis {
+			for _, f := range axis.Function {
+				terms[f.Name] = calc.Slipstream.isTailTerm(f)
+			}
+		}
+		assertEqual(t, terms, map[string]bool{
+			"aero/coefficient/Cm0":  false,
+			"aero/coefficient/Cmht": true,
+			"aero/coefficient/Cndr": true,
+		})
+	})
+}
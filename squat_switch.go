@@ -0,0 +1,81 @@
+// Squat Switches
+// Debounced weight on wheels for FCS interlocks: a switch on each strut
+// that makes and breaks with hysteresis on the compression and holds a
+// change only once it has lasted a minimum dwell time, so bounces and
+// runway roughness do not chatter the logic keyed off it
+
+package main
+
+// SquatSwitch is the weight on wheels switch of a gear unit. It makes once
+// the strut is compressed past Make and breaks once it has extended to
+// Break or less; a change of the raw switch takes effect once it has held
+// for Dwell. The zero switch follows the contact as it is.
+type SquatSwitch struct {
+	Make  float64 // Compression making the switch (m)
+	Break float64 // Compression at or below which the switch breaks (m); at most Make
+	Dwell float64 // Time a change must hold before it takes effect (s)
+}
+
+// raw returns the undebounced switch at a compression (m), with the
+// hysteresis from its debounced position
+func (s SquatSwitch) raw(made bool, compression float64) bool {
+	if made {
+		return compression > s.Break
+	}
+	return compression > s.Make
+}
+
+// update debounces a unit's switch from its previous contact state to a
+// new one at a later time. A unit without a previous state starts with the
+// raw switch.
+func (s SquatSwitch) update(previous *GearUnitState, contact *GearUnitState, time float64) {
+	if previous == nil {
+		contact.Squat = contact.WOW && s.raw(false, contact.Compression)
+		return
+	}
+	raw := contact.WOW && s.raw(previous.Squat, contact.Compression)
+	contact.Squat = previous.Squat
+	switch {
+	case raw == previous.Squat:
+		return
+	case previous.SquatPending:
+		contact.SquatPending, contact.SquatSince = true, previous.SquatSince
+	default:
+		contact.SquatPending, contact.SquatSince = true, time
+	}
+	if time-contact.SquatSince >= s.Dwell-1e-9 {
+		contact.Squat, contact.SquatPending, contact.SquatSince = raw, false, 0
+	}
+}
+
+// updateSquat debounces the squat switches of the new contact states of a
+// state from the ones it was copied with, and sets the aggregate weight on
+// wheels of the gear's bogeys
+func (gear *LandingGear) updateSquat(state *AircraftState, previous []GearUnitState, units []GearUnitState) {
+	wowAny, wowAll, bogeys := false, true, 0
+	for i, unit := range gear.Units {
+		var last *GearUnitState
+		if i < len(previous) {
+			last = &previous[i]
+		}
+		unit.Squat.update(last, &units[i], state.Time)
+		if unit.Type != "BOGEY" {
+			continue
+		}
+		bogeys++
+		wowAny = wowAny || units[i].Squat
+		wowAll = wowAll && units[i].Squat
+	}
+	state.Gear.WOWAny = wowAny
+	state.Gear.WOWAll = wowAll && bogeys > 0
+}
+
+// squatMade reports whether the squat switch of gear unit i is made. A
+// state without the unit's contact state, not yet updated by a
+// LandingGear, leaves the switch made so nothing is inhibited.
+func squatMade(state *AircraftState, i int) bool {
+	if i >= len(state.Gear.Units) {
+		return true
+	}
+	return state.Gear.Units[i].Squat
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// squatGear is a single bogey 1 m below the CG with a debounced switch
+func squatGear() *LandingGear {
+	return &LandingGear{Units: []GearUnit{{
+		Name: "MAIN", Type: "BOGEY", Location: Vector3{Z: 1},
+		SpringCoeff: 1e5, RollingFriction: 0.02, StaticFriction: 0.8, DynamicFriction: 0.5, BrakeGroup: "CENTER",
+		Squat: SquatSwitch{Make: 0.03, Break: 0.01, Dwell: 0.2},
+	}}}
+}
+
+// bouncyCompression is the strut compression (m) of a landing at 1 s that
+// bounces on the strut and over rough runway, settling to 4 cm, and a
+// liftoff at 5 s; negative is clear of the ground
+func bouncyCompression(time float64) float64 {
+	if time < 1 || time >= 5 {
+		return -0.2
+	}
+	since := time - 1
+	return 0.04 + 0.05*math.Sin(2*math.Pi*3*since)*math.Exp(-since/3) + 0.004*math.Sin(2*math.Pi*11*since)
+}
+
+func TestSquatSwitch(t *testing.T) {
+	t.Run("Bouncy Touchdown", func(t *testing.T) {
+		gear := squatGear()
+		state := NewAircraftState()
+		const dt = 0.01
+		crossings, transitions := 0, 0
+		raw, wow := false, false
+		var made, broke float64
+		for i := 0; i <= 600; i++ {
+			next := state.Copy()
+			next.Time = float64(i) * dt
+			next.Altitude = 1 - bouncyCompression(next.Time)
+			gear.Update(next)
+			state = next
+
+			if r := bouncyCompression(state.Time) > gear.Units[0].Squat.Make; r != raw {
+				raw = r
+				crossings++
+			}
+			properties := state.ToPropertyMap()
+			if squat := properties["gear/unit[0]/WOW"] == 1; squat != wow {
+				wow = squat
+				transitions++
+				if wow {
+					made = state.Time
+				} else {
+					broke = state.Time
+				}
+			}
+			assertEqual(t, properties["gear/wow-any"], boolToFloat(wow))
+			assertEqual(t, properties["gear/wow-all"], boolToFloat(wow))
+		}
+		t.Logf("Raw switch crossings %d; debounced made at %.2f s and broke at %.2f s", crossings, made, broke)
+		if crossings < 12 {
+			t.Fatalf("The compression should cross the threshold a dozen times, crossed %d", crossings)
+		}
+		assertEqual(t, transitions, 2)
+		if made < 1.2 || made > 2.5 {
+			t.Errorf("The switch should make once the bounces settle, made at %.2f s", made)
+		}
+		assertApproxEqual(t, broke, 5.2, 1e-9)
+	})
+
+	t.Run("Zero Switch Follows The Contact", func(t *testing.T) {
+		gear := squatGear()
+		gear.Units[0].Squat = SquatSwitch{}
+		state := NewAircraftState()
+		for i := 0; i <= 600; i++ {
+			next := state.Copy()
+			next.Time = float64(i) * 0.01
+			next.Altitude = 1 - bouncyCompression(next.Time)
+			gear.Update(next)
+			state = next
+			assertEqual(t, state.Gear.Units[0].Squat, state.Gear.Units[0].WOW)
+		}
+	})
+
+	t.Run("Interlocks", func(t *testing.T) {
+		gear := squatGear()
+		state := NewAircraftState()
+		state.SetControlInputs(ControlInputs{Gear: true, Brake: 1})
+		state.Velocity = Vector3{X: 10}
+		state.Altitude = 1 - 0.05
+		gear.Update(state)
+		assertEqual(t, state.Gear.WOWAny, true)
+
+		// The brakes act only with the switch made
+		braked, _ := gear.Forces(state)
+		state.Gear.Units = []GearUnitState{{WOW: true, Compression: 0.05}}
+		rolling, _ := gear.Forces(state)
+		assertApproxEqual(t, braked.X/rolling.X, 0.8/0.02, 1e-6)
+
+		// Nor does the gear retract with weight on the wheels
+		gs := NewGearSystem(gear)
+		state.Controls.Gear = false
+		state.Gear.WOWAny = true
+		next := state.Copy()
+		gs.Update(state, next, 1)
+		assertEqual(t, next.Gear.Transition, 1.0)
+		state.Gear.WOWAny = false
+		gs.Update(state, next, 1)
+		assertApproxEqual(t, next.Gear.Transition, 1-1/gs.TransitionTime, 1e-12)
+	})
+}
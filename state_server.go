@@ -0,0 +1,243 @@
+// State Server
+// Serves the live aircraft state over HTTP for browser instrument panels:
+// the state as JSON, selected properties, and a Server-Sent Events stream
+// of selected properties at a rate of the client's choosing
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Stream rates (Hz)
+const (
+	defaultStreamRate = 10.0
+	maxStreamRate     = 100.0
+)
+
+// StateServer publishes the latest state of a simulation over HTTP. The
+// simulation hands it each new state, through Publish or an event bus, and
+// clients read the latest snapshot, so they are served at their own rates
+// whatever the simulation rate and never slow it down.
+//
+//	GET /state                      the aircraft state as JSON
+//	GET /properties?names=a,b,c     the named properties, or all of them
+//	GET /stream?rate=20&names=a,b   the same as Server-Sent Events at rate Hz
+//
+// Every response allows cross-origin requests, so a panel can be served
+// from anywhere. Non-finite values are sent as null.
+type StateServer struct {
+	Addr          string           // Bind address, such as localhost:8080
+	Properties    *PropertyManager // Latest values of the published properties
+	MaxStreamRate float64          // Highest stream rate granted (Hz); 100 when 0
+
+	mu    sync.RWMutex
+	state []byte // Latest state as JSON, nil before the first
+	err   error  // Why the latest state could not be encoded
+}
+
+// NewStateServer creates a server to bind to addr, with an empty property
+// tree
+func NewStateServer(addr string) *StateServer {
+	return &StateServer{Addr: addr, Properties: NewPropertyManager()}
+}
+
+// Attach publishes each new state of the simulation the bus belongs to
+func (ss *StateServer) Attach(bus *EventBus) *EventWatcher {
+	return bus.OnStep(func(state *AircraftState, _ float64) { ss.Publish(state) })
+}
+
+// Publish makes state the latest state served
+func (ss *StateServer) Publish(state *AircraftState) {
+	data, err := json.Marshal(state)
+	ss.Properties.UpdateFromAircraftState(state)
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if err != nil {
+		ss.err = fmt.Errorf("encoding state at t=%.3f s: %w", state.Time, err)
+		return
+	}
+	ss.state, ss.err = data, nil
+}
+
+// Handler returns the HTTP handler of the endpoints
+func (ss *StateServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/state", ss.serveState)
+	mux.HandleFunc("/properties", ss.serveProperties)
+	mux.HandleFunc("/stream", ss.serveStream)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		switch r.Method {
+		case http.MethodOptions:
+			w.WriteHeader(http.StatusNoContent)
+		case http.MethodGet, http.MethodHead:
+			mux.ServeHTTP(w, r)
+		default:
+			http.Error(w, "only GET is supported", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// ListenAndServe serves on Addr until ctx is done, then shuts the server
+// down, ending any open streams
+func (ss *StateServer) ListenAndServe(ctx context.Context) error {
+	listener, err := net.Listen("tcp", ss.Addr)
+	if err != nil {
+		return err
+	}
+	return ss.Serve(ctx, listener)
+}
+
+// Serve serves on listener until ctx is done, then shuts the server down,
+// ending any open streams
+func (ss *StateServer) Serve(ctx context.Context, listener net.Listener) error {
+	server := &http.Server{
+		Handler: ss.Handler(),
+		// Requests, streams among them, end with ctx
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+	done := make(chan error, 1)
+	go func() { done <- server.Serve(listener) }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+	}
+	shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdown); err != nil {
+		return err
+	}
+	if err := <-done; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// serveState writes the latest state
+func (ss *StateServer) serveState(w http.ResponseWriter, r *http.Request) {
+	ss.mu.RLock()
+	data, err := ss.state, ss.err
+	ss.mu.RUnlock()
+	switch {
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	case data == nil:
+		http.Error(w, "no state published yet", http.StatusServiceUnavailable)
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}
+}
+
+// serveProperties writes the properties named in the query
+func (ss *StateServer) serveProperties(w http.ResponseWriter, r *http.Request) {
+	values, err := ss.snapshot(queryNames(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(values)
+}
+
+// serveStream sends the properties named in the query as Server-Sent
+// Events until the client goes away or the server shuts down
+func (ss *StateServer) serveStream(w http.ResponseWriter, r *http.Request) {
+	rate := defaultStreamRate
+	if text := r.URL.Query().Get("rate"); text != "" {
+		value, err := strconv.ParseFloat(text, 64)
+		if err != nil || !(value > 0) {
+			http.Error(w, fmt.Sprintf("rate %q is not a positive number", text), http.StatusBadRequest)
+			return
+		}
+		rate = value
+	}
+	maxRate := ss.MaxStreamRate
+	if maxRate <= 0 {
+		maxRate = maxStreamRate
+	}
+	rate = math.Min(rate, maxRate)
+
+	names := queryNames(r)
+	if _, err := ss.snapshot(names); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / rate))
+	defer ticker.Stop()
+	for {
+		values, err := ss.snapshot(names)
+		if err != nil {
+			fmt.Fprintf(w, "event: error\ndata: %s\n\n", err)
+			flusher.Flush()
+			return
+		}
+		if _, err := fmt.Fprintf(w, "data: %s\n\n", values); err != nil {
+			return
+		}
+		flusher.Flush()
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// snapshot encodes the latest values of the named properties, or of every
+// property when there are no names. Naming one that is not set is an error.
+func (ss *StateServer) snapshot(names []string) ([]byte, error) {
+	values, missing := ss.Properties.Snapshot(names)
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("unknown properties: %s", strings.Join(missing, ", "))
+	}
+	encoded := make(map[string]any, len(values))
+	for name, value := range values {
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			encoded[name] = nil
+		} else {
+			encoded[name] = value
+		}
+	}
+	return json.Marshal(encoded)
+}
+
+// queryNames returns the comma-separated property names of a request's
+// names parameters
+func queryNames(r *http.Request) []string {
+	var names []string
+	for _, list := range r.URL.Query()["names"] {
+		for _, name := range strings.Split(list, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// runBackground steps a trimmed simplified model until stop is closed,
+// publishing every state to ss, roughly in real time
+func runBackground(t *testing.T, ss *StateServer, stop chan struct{}) *sync.WaitGroup {
+	t.Helper()
+	engine := NewSimplifiedFlightDynamicsEngine(NewRungeKutta4Integrator())
+	state := trimLevelFlight(t, engine, 1000.0, 100.0)
+	ss.Attach(engine.Events)
+	ss.Publish(state)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(5 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+			next, err := engine.Step(state, 0.005)
+			if err != nil {
+				t.Errorf("Step failed: %v", err)
+				return
+			}
+			state = next
+		}
+	}()
+	return &wg
+}
+
+// getJSON fetches url and decodes its JSON body into v
+func getJSON(t *testing.T, url string, v any) *http.Response {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("GET %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			t.Fatalf("GET %s: decoding: %v", url, err)
+		}
+	}
+	return resp
+}
+
+func TestStateServer(t *testing.T) {
+	ss := NewStateServer("localhost:0")
+	server := httptest.NewServer(ss.Handler())
+	defer server.Close()
+
+	// Nothing to serve before the first state
+	resp := getJSON(t, server.URL+"/state", nil)
+	assertEqual(t, resp.StatusCode, http.StatusServiceUnavailable)
+
+	stop := make(chan struct{})
+	wg := runBackground(t, ss, stop)
+	defer func() {
+		close(stop)
+		wg.Wait()
+	}()
+
+	t.Run("State", func(t *testing.T) {
+		var fields map[string]json.RawMessage
+		resp := getJSON(t, server.URL+"/state", &fields)
+		assertEqual(t, resp.StatusCode, http.StatusOK)
+		assertEqual(t, resp.Header.Get("Content-Type"), "application/json")
+		assertEqual(t, resp.Header.Get("Access-Control-Allow-Origin"), "*")
+		for _, field := range []string{"time", "altitude", "velocity", "orientation", "controls", "tas"} {
+			if _, ok := fields[field]; !ok {
+				t.Errorf("The state should have a %q field", field)
+			}
+		}
+
+		var state AircraftState
+		getJSON(t, server.URL+"/state", &state)
+		assertApproxEqual(t, state.Altitude, 1000.0, 5)
+		assertApproxEqual(t, state.Orientation.Dot(state.Orientation), 1.0, 1e-9)
+	})
+
+	t.Run("Properties", func(t *testing.T) {
+		var values map[string]float64
+		resp := getJSON(t, server.URL+"/properties?names=position/h-sl-m,velocities/vt-mps", &values)
+		assertEqual(t, resp.StatusCode, http.StatusOK)
+		assertEqual(t, len(values), 2)
+		assertApproxEqual(t, values["position/h-sl-m"], 1000.0, 5)
+		assertApproxEqual(t, values["velocities/vt-mps"], 100.0, 5)
+
+		// Repeated parameters add to the names
+		values = nil
+		getJSON(t, server.URL+"/properties?names=position/h-sl-m&names=aero/alpha-deg", &values)
+		assertEqual(t, len(values), 2)
+
+		// Without names, everything
+		values = nil
+		getJSON(t, server.URL+"/properties", &values)
+		if len(values) < 50 {
+			t.Errorf("All properties should be served, got %d", len(values))
+		}
+
+		resp = getJSON(t, server.URL+"/properties?names=position/h-sl-m,no/such-property", nil)
+		assertEqual(t, resp.StatusCode, http.StatusNotFound)
+	})
+
+	t.Run("CORS", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodOptions, server.URL+"/stream", nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("OPTIONS: %v", err)
+		}
+		resp.Body.Close()
+		assertEqual(t, resp.StatusCode, http.StatusNoContent)
+		assertEqual(t, resp.Header.Get("Access-Control-Allow-Origin"), "*")
+
+		resp, err = http.Post(server.URL+"/state", "application/json", strings.NewReader("{}"))
+		if err != nil {
+			t.Fatalf("POST: %v", err)
+		}
+		resp.Body.Close()
+		assertEqual(t, resp.StatusCode, http.StatusMethodNotAllowed)
+	})
+
+	t.Run("Stream Rate", func(t *testing.T) {
+		resp, err := http.Get(server.URL + "/stream?rate=20&names=simulation/sim-time-sec,position/h-sl-m")
+		if err != nil {
+			t.Fatalf("GET /stream: %v", err)
+		}
+		defer resp.Body.Close()
+		assertEqual(t, resp.Header.Get("Content-Type"), "text/event-stream")
+
+		// Count the events of one second
+		start := time.Now()
+		var times []float64
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() && time.Since(start) < time.Second {
+			line, ok := strings.CutPrefix(scanner.Text(), "data: ")
+			if !ok {
+				continue
+			}
+			var values map[string]float64
+			if err := json.Unmarshal([]byte(line), &values); err != nil {
+				t.Fatalf("Event %q: %v", line, err)
+			}
+			assertEqual(t, len(values), 2)
+			times = append(times, values["simulation/sim-time-sec"])
+		}
+		if len(times) < 14 || len(times) > 26 {
+			t.Errorf("A 20 Hz stream should send about 20 events a second, sent %d", len(times))
+		}
+		if len(times) > 1 && times[len(times)-1] <= times[0] {
+			t.Errorf("The stream should follow the simulation, times went from %g to %g", times[0], times[len(times)-1])
+		}
+	})
+
+	t.Run("Stream Errors", func(t *testing.T) {
+		for query, status := range map[string]int{
+			"rate=fast":           http.StatusBadRequest,
+			"rate=-5":             http.StatusBadRequest,
+			"names=no/such-thing": http.StatusNotFound,
+		} {
+			resp, err := http.Get(server.URL + "/stream?" + query)
+			if err != nil {
+				t.Fatalf("GET /stream?%s: %v", query, err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != status {
+				t.Errorf("/stream?%s: expected status %d, got %d", query, status, resp.StatusCode)
+			}
+		}
+	})
+}
+
+func TestStateServerShutdown(t *testing.T) {
+	ss := NewStateServer("127.0.0.1:0")
+	ss.Publish(NewAircraftState())
+	listener, err := net.Listen("tcp", ss.Addr)
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- ss.Serve(ctx, listener) }()
+
+	resp, err := http.Get("http://" + listener.Addr().String() + "/stream?rate=50")
+	if err != nil {
+		t.Fatalf("GET /stream: %v", err)
+	}
+	defer resp.Body.Close()
+	reader := bufio.NewReader(resp.Body)
+	if _, err := reader.ReadString('\n'); err != nil {
+		t.Fatalf("Reading the stream: %v", err)
+	}
+
+	// Cancelling ends the open stream and the server
+	cancel()
+	select {
+	case err := <-served:
+		if err != nil {
+			t.Errorf("Serve should return cleanly on cancellation, got %v", err)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatal("Serve did not return after cancellation")
+	}
+	if _, err := reader.ReadString(0); err == nil {
+		t.Error("The stream should end with the server")
+	}
+}
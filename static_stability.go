@@ -0,0 +1,378 @@
+// Static Stability Analysis
+// Classical longitudinal handling-qualities numbers derived from the
+// aerodynamic functions of a configuration: neutral point, static margin,
+// elevator angle per g and the stick-fixed manoeuvre point
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Perturbation steps and search limits of the stability analysis
+const (
+	stabilityAlphaStep     = 0.5 * DEG_TO_RAD // Angle of attack step of the central differences
+	stabilityElevatorStep  = 1.0 * DEG_TO_RAD // Elevator step
+	stabilityPitchRateStep = 0.02             // Pitch rate step (rad/s)
+	stabilitySearchLimit   = 30               // Secant iterations before giving up
+	stabilityTolerance     = 1e-6             // Convergence of the searched station, in MACs
+)
+
+// StabilityReport holds the longitudinal static stability of an aircraft
+// about a trim state. Stations are metres aft of the structural origin;
+// positions and margins are fractions of the mean aerodynamic chord (MAC),
+// measured aft of its leading edge. Derivatives are per radian, with Cmq
+// per unit of the normalised pitch rate q·c̄/2V.
+type StabilityReport struct {
+	Alpha           float64 // Angle of attack of the trim state (rad)
+	DynamicPressure float64 // Pa
+	Weight          float64 // Configured weight, empty plus point masses and fuel (lbs)
+	CL              float64 // Lift coefficient at the trim state
+	CLRequired      float64 // Lift coefficient that supports the weight at this dynamic pressure
+
+	CLAlpha    float64 // Lift curve slope
+	CLElevator float64 // Lift due to elevator
+	CmAlpha    float64 // Pitch stiffness about the configured CG
+	CmElevator float64 // Elevator power
+	CmQ        float64 // Pitch damping
+
+	ReferenceStation float64 // Moment reference point (AERORP)
+	CGStation        float64 // Configured CG
+	CGPosition       float64
+
+	// Stick-fixed neutral point, where dCm/dCL is zero, and the static
+	// margin to it (positive when stable)
+	NeutralPointFound   bool
+	NeutralPointStation float64
+	NeutralPoint        float64
+	StaticMargin        float64
+
+	// Stick-fixed manoeuvre point, where the elevator per g is zero, and
+	// the elevator angle per g at the configured CG (rad, negative for
+	// trailing edge up)
+	ManeuverPointFound   bool
+	ManeuverPointStation float64
+	ManeuverPoint        float64
+	ManeuverMargin       float64
+	ElevatorPerG         float64
+
+	// Notes explain quantities that could not be found
+	Notes []string
+}
+
+// longitudinalSample is the lift, drag and pitching moment coefficients at
+// one perturbation of the trim state, the moment about the reference point
+type longitudinalSample struct {
+	CL, CD, Cm, Alpha float64
+}
+
+// normalForce returns the coefficient of the force along the body -Z axis,
+// which acts at the reference point
+func (s longitudinalSample) normalForce() float64 {
+	return s.CL*math.Cos(s.Alpha) + s.CD*math.Sin(s.Alpha)
+}
+
+// StaticStabilityAnalysis finds the longitudinal static stability of the
+// aircraft about trimState by perturbing its angle of attack, elevator and
+// pitch rate. The coefficients are taken from the aerodynamic functions in
+// JSBSim's convention, forces in pounds and moments in pound-feet about the
+// AERORP, and moved to each CG station searched by the normal force. The
+// CG is that of the configured mass balance and fuel, and any modelled
+// control surfaces.
+//
+// An error is returned when the configuration cannot be analysed at all. A
+// neutral or manoeuvre point that cannot be found, as past the stall where
+// the lift curve is flat, is reported in Notes.
+func StaticStabilityAnalysis(calc *ForcesMomentsCalculator, trimState *AircraftState) (*StabilityReport, error) {
+	config := calc.Config
+	if config.Aerodynamics == nil {
+		return nil, fmt.Errorf("no aerodynamics configuration")
+	}
+	if config.Metrics == nil || calc.Reference.WingArea <= 0 || calc.Reference.Chord <= 0 {
+		return nil, fmt.Errorf("configuration has no wing area or chord")
+	}
+	aero := metricsLocation(config.Metrics, "AERORP")
+	if aero == nil {
+		return nil, fmt.Errorf("configuration has no AERORP location")
+	}
+	weight, cg, ok := calc.massProperties()
+	if !ok {
+		return nil, fmt.Errorf("configuration has no empty weight and CG location")
+	}
+	cgStation := cg.X
+
+	report := &StabilityReport{
+		Alpha:            trimState.Alpha,
+		DynamicPressure:  trimState.DynamicPressure,
+		Weight:           weight,
+		ReferenceStation: structuralStation(aero),
+		CGStation:        cgStation,
+	}
+	chord := calc.Reference.Chord * FT_TO_M
+	leadingEdge := report.ReferenceStation - 0.25*chord
+	position := func(station float64) float64 { return (station - leadingEdge) / chord }
+	report.CGPosition = position(cgStation)
+
+	sample := func(alpha, elevator, pitchRate float64) (longitudinalSample, error) {
+		return calc.longitudinalCoefficients(trimState, alpha, elevator, pitchRate)
+	}
+	alpha, elevator, q := trimState.Alpha, trimState.ControlSurfaces.Elevator, trimState.AngularRate.Y
+	trim, err := sample(alpha, elevator, q)
+	if err != nil {
+		return nil, err
+	}
+	report.CL = trim.CL
+
+	var perturbed [6]longitudinalSample
+	for i, p := range [][3]float64{
+		{alpha + stabilityAlphaStep, elevator, q}, {alpha - stabilityAlphaStep, elevator, q},
+		{alpha, elevator + stabilityElevatorStep, q}, {alpha, elevator - stabilityElevatorStep, q},
+		{alpha, elevator, q + stabilityPitchRateStep}, {alpha, elevator, q - stabilityPitchRateStep},
+	} {
+		if perturbed[i], err = sample(p[0], p[1], p[2]); err != nil {
+			return nil, err
+		}
+	}
+	up, down := perturbed[0], perturbed[1]
+
+	// Moment coefficient about a CG station, moved from the reference point
+	cmAbout := func(s longitudinalSample, station float64) float64 {
+		return s.Cm + s.normalForce()*(station-report.ReferenceStation)/chord
+	}
+	cmAlphaAbout := func(station float64) float64 {
+		return (cmAbout(up, station) - cmAbout(down, station)) / (2 * stabilityAlphaStep)
+	}
+
+	report.CLAlpha = (up.CL - down.CL) / (2 * stabilityAlphaStep)
+	report.CmAlpha = cmAlphaAbout(cgStation)
+	report.CLElevator = (perturbed[2].CL - perturbed[3].CL) / (2 * stabilityElevatorStep)
+	report.CmElevator = (cmAbout(perturbed[2], cgStation) - cmAbout(perturbed[3], cgStation)) / (2 * stabilityElevatorStep)
+	airspeed := trimState.TrueAirspeed * M_TO_FT
+	normalisedStep := stabilityPitchRateStep * calc.Reference.Chord / (2 * airspeed)
+	report.CmQ = (perturbed[4].Cm - perturbed[5].Cm) / (2 * normalisedStep)
+
+	// Neutral point: the CG station where dCm/dCL vanishes
+	if math.Abs(up.CL-down.CL) < 1e-9 {
+		report.note("no neutral point: the lift does not change with angle of attack")
+	} else {
+		slope := func(station float64) float64 {
+			return (cmAbout(up, station) - cmAbout(down, station)) / (up.CL - down.CL)
+		}
+		station, found := secantRoot(slope, cgStation, cgStation+0.1*chord, stabilityTolerance*chord)
+		if found {
+			report.NeutralPointFound = true
+			report.NeutralPointStation = station
+			report.NeutralPoint = position(station)
+			report.StaticMargin = report.NeutralPoint - report.CGPosition
+		} else {
+			report.note("no neutral point: the dCm/dCL search did not converge")
+		}
+	}
+
+	// Manoeuvre point: the CG station where a steady pull-up needs no more
+	// elevator per g than level flight. The extra g needs CL to rise by the
+	// weight coefficient, and the pitch rate q = g·Δn/V adds damping.
+	qbar := trimState.DynamicPressure * 0.020885 // Pa to psf
+	report.CLRequired = weight / (qbar * calc.Reference.WingArea)
+	pitchRatePerG := StandardGravity * M_TO_FT * calc.Reference.Chord / (2 * airspeed * airspeed)
+	elevatorPerG := func(station float64) float64 {
+		cmAlpha := cmAlphaAbout(station)
+		cmElevator := (cmAbout(perturbed[2], station) - cmAbout(perturbed[3], station)) / (2 * stabilityElevatorStep)
+		det := report.CLAlpha*cmElevator - cmAlpha*report.CLElevator
+		return -(cmAlpha*report.CLRequired + report.CLAlpha*report.CmQ*pitchRatePerG) / det
+	}
+	if report.CmElevator == 0 || trimState.DynamicPressure <= 0 {
+		report.ElevatorPerG = math.NaN()
+		report.note("no elevator per g: the elevator has no pitch authority")
+		return report, nil
+	}
+	report.ElevatorPerG = elevatorPerG(cgStation)
+	station, found := secantRoot(elevatorPerG, cgStation, cgStation+0.1*chord, stabilityTolerance*chord)
+	if found {
+		report.ManeuverPointFound = true
+		report.ManeuverPointStation = station
+		report.ManeuverPoint = position(station)
+		report.ManeuverMargin = report.ManeuverPoint - report.CGPosition
+	} else {
+		report.note("no manoeuvre point: the elevator per g search did not converge")
+	}
+
+	return report, nil
+}
+
+func (report *StabilityReport) note(text string) {
+	report.Notes = append(report.Notes, text)
+}
+
+// String renders the report as text
+func (report *StabilityReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Longitudinal Static Stability\n")
+	fmt.Fprintf(&b, "  Trim: alpha %.2f°, qbar %.0f Pa, CL %.3f (%.3f to hold %.0f lbs)\n",
+		report.Alpha*RAD_TO_DEG, report.DynamicPressure, report.CL, report.CLRequired, report.Weight)
+	fmt.Fprintf(&b, "  CLα %.3f /rad, CLδe %.3f /rad\n", report.CLAlpha, report.CLElevator)
+	fmt.Fprintf(&b, "  Cmα %.3f /rad, Cmδe %.3f /rad, Cmq %.2f\n", report.CmAlpha, report.CmElevator, report.CmQ)
+	fmt.Fprintf(&b, "  CG: %.1f%% MAC (station %.3f m)\n", report.CGPosition*100, report.CGStation)
+	if report.NeutralPointFound {
+		fmt.Fprintf(&b, "  Neutral point: %.1f%% MAC (station %.3f m)\n", report.NeutralPoint*100, report.NeutralPointStation)
+		stability := "stable"
+		if report.StaticMargin <= 0 {
+			stability = "unstable"
+		}
+		fmt.Fprintf(&b, "  Static margin: %.1f%% MAC, %s\n", report.StaticMargin*100, stability)
+	} else {
+		fmt.Fprintf(&b, "  Neutral point: not found\n")
+	}
+	if !math.IsNaN(report.ElevatorPerG) {
+		fmt.Fprintf(&b, "  Elevator per g: %.2f°\n", report.ElevatorPerG*RAD_TO_DEG)
+	}
+	if report.ManeuverPointFound {
+		fmt.Fprintf(&b, "  Manoeuvre point: %.1f%% MAC, margin %.1f%% MAC\n", report.ManeuverPoint*100, report.ManeuverMargin*100)
+	} else {
+		fmt.Fprintf(&b, "  Manoeuvre point: not found\n")
+	}
+	for _, note := range report.Notes {
+		fmt.Fprintf(&b, "  Note: %s\n", note)
+	}
+	return b.String()
+}
+
+// secantRoot finds a root of f by the secant method from x0 and x1,
+// reporting false when it does not converge to within tolerance
+func secantRoot(f func(float64) float64, x0, x1, tolerance float64) (float64, bool) {
+	f0, f1 := f(x0), f(x1)
+	for i := 0; i < stabilitySearchLimit; i++ {
+		if f1 == f0 || !isFinite(f0) || !isFinite(f1) {
+			return x1, f1 == 0
+		}
+		x2 := x1 - f1*(x1-x0)/(f1-f0)
+		if math.Abs(x2-x1) < tolerance {
+			return x2, isFinite(x2)
+		}
+		x0, f0 = x1, f1
+		x1, f1 = x2, f(x2)
+	}
+	return x1, false
+}
+
+// longitudinalCoefficients evaluates the lift, drag and pitch axes at the
+// state with the given angle of attack, elevator deflection and pitch rate.
+// Functions without a unit give pounds and pound-feet, which the reference
+// dimensions and dynamic pressure reduce to coefficients.
+func (calc *ForcesMomentsCalculator) longitudinalCoefficients(base *AircraftState, alpha, elevator, pitchRate float64) (longitudinalSample, error) {
+	state := base.Copy()
+	speed := state.Velocity.Magnitude()
+	state.Velocity = Vector3{X: speed * math.Cos(alpha), Y: state.Velocity.Y, Z: -speed * math.Sin(alpha)}
+	state.ControlSurfaces.Elevator = elevator
+	state.AngularRate.Y = pitchRate
+	state.UpdateDerivedParameters()
+
+	properties := state.ToPropertyMap()
+	calc.addGroundEffectProperties(state, properties)
+	calc.addReferenceProperties(state, properties)
+	if err := calc.evaluateStandaloneFunctions(properties); err != nil {
+		return longitudinalSample{}, err
+	}
+
+	var lift, drag, pitch float64
+	for _, axis := range calc.Config.Aerodynamics.Axis {
+		var total *float64
+		toConventional := 1 / LB_TO_N
+		switch axis.Name {
+		case "LIFT":
+			total = &lift
+		case "DRAG":
+			total = &drag
+		case "PITCH":
+			total, toConventional = &pitch, 1/(LB_TO_N*FT_TO_M)
+		default:
+			continue
+		}
+		conventional, si, err := sumAxisFunctions(axis, properties, nil)
+		if err != nil {
+			return longitudinalSample{}, err
+		}
+		*total += conventional + si*toConventional
+	}
+
+	qS := properties["aero/qbar-psf"] * calc.Reference.WingArea
+	if qS <= 0 {
+		return longitudinalSample{}, fmt.Errorf("no dynamic pressure at the trim state")
+	}
+	return longitudinalSample{
+		CL:    lift / qS,
+		CD:    drag / qS,
+		Cm:    pitch / (qS * calc.Reference.Chord),
+		Alpha: state.Alpha,
+	}, nil
+}
+
+// addReferenceProperties publishes the JSBSim auxiliary values aerodynamic
+// functions multiply their coefficients by, in feet, where the state does
+// not provide them. The propwash dynamic pressure is taken as the free
+// stream's.
+func (calc *ForcesMomentsCalculator) addReferenceProperties(state *AircraftState, properties map[string]float64) {
+	for name, value := range calc.referenceProperties(state, properties) {
+		if _, ok := properties[name]; !ok {
+			properties[name] = value
+		}
+	}
+}
+
+// referenceProperties returns the values addReferenceProperties publishes
+func (calc *ForcesMomentsCalculator) referenceProperties(state *AircraftState, properties map[string]float64) map[string]float64 {
+	airspeed := math.Max(state.TrueAirspeed, 1.0) * M_TO_FT
+	reference := map[string]float64{
+		"metrics/Sw-sqft":           calc.Reference.WingArea,
+		"metrics/bw-ft":             calc.Reference.WingSpan,
+		"metrics/cbarw-ft":          calc.Reference.Chord,
+		"aero/ci2vel":               calc.Reference.Chord / (2 * airspeed),
+		"aero/bi2vel":               calc.Reference.WingSpan / (2 * airspeed),
+		"aero/thrust-qbar_psf":      properties["aero/qbar-psf"],
+		"velocities/p-aero-rad_sec": state.AngularRate.X,
+		"velocities/q-aero-rad_sec": state.AngularRate.Y,
+		"velocities/r-aero-rad_sec": state.AngularRate.Z,
+		"velocities/mach":           state.Mach,
+		"fcs/flap-pos-norm":         state.ControlSurfaces.FlapLeft * RAD_TO_DEG / MaxFlapDeflectionDeg,
+	}
+	if m := calc.Config.Metrics; m != nil {
+		reference["metrics/Sh-sqft"] = measurementValue(m.HTailArea)
+		reference["metrics/lh-ft"] = measurementValue(m.HTailArm)
+		reference["metrics/Sv-sqft"] = measurementValue(m.VTailArea)
+		reference["metrics/lv-ft"] = measurementValue(m.VTailArm)
+	}
+	return reference
+}
+
+// configMassProperties returns the weight of the configuration, with its
+// point masses and the fuel the tanks hold (lbs), and the structural
+// position of its CG (m)
+func configMassProperties(config *JSBSimConfig) (weight float64, cg Vector3, ok bool) {
+	mb := config.MassBalance
+	if mb == nil || mb.EmptyMass == nil || mb.Location == nil {
+		return 0, Vector3{}, false
+	}
+	var moment Vector3
+	add := func(mass *Measurement, loc *Location) {
+		if mass == nil || loc == nil {
+			return
+		}
+		weight += mass.Value
+		moment = moment.Add(StructuralPosition(loc).Scale(mass.Value))
+	}
+	add(mb.EmptyMass, mb.Location)
+	for _, pm := range mb.PointMass {
+		add(pm.Mass, pm.Location)
+	}
+	if config.Propulsion != nil {
+		for _, tank := range config.Propulsion.Tank {
+			add(tank.Contents, tank.Location)
+		}
+	}
+	if weight <= 0 {
+		return 0, Vector3{}, false
+	}
+	return weight, moment.Scale(1 / weight), true
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// cruiseState returns a wings-level state at the given angle of attack
+func cruiseState(altitude, airspeed, alpha float64) *AircraftState {
+	state := NewAircraftState()
+	state.Altitude = altitude
+	state.Position = Vector3{Z: -altitude}
+	state.Velocity = Vector3{X: airspeed * math.Cos(alpha), Z: -airspeed * math.Sin(alpha)}
+	state.UpdateAtmosphere()
+	state.UpdateDerivedParameters()
+	return state
+}
+
+func TestStaticStabilityAnalysis(t *testing.T) {
+	config := loadP51DConfig(t)
+	fuselageTank := config.Propulsion.Tank[2]
+	assertApproxEqual(t, fuselageTank.Location.X, 160, 1e-9)
+
+	// Cruise at 3000 m and 120 m/s, where 1.15° of alpha holds the weight
+	state := cruiseState(3000, 120, 0.02)
+
+	analyse := func(fuel float64) *StabilityReport {
+		t.Helper()
+		fuselageTank.Contents.Value = fuel
+		report, err := StaticStabilityAnalysis(NewForcesMomentsCalculator(config), state)
+		if err != nil {
+			t.Fatalf("StaticStabilityAnalysis: %v", err)
+		}
+		return report
+	}
+
+	t.Run("Stable At Forward CG", func(t *testing.T) {
+		report := analyse(0)
+		t.Logf("\n%s", report)
+		if !report.NeutralPointFound || !report.ManeuverPointFound {
+			t.Fatalf("Search did not converge: %v", report.Notes)
+		}
+		if math.Abs(report.CL-report.CLRequired) > 0.05 {
+			t.Errorf("State is not near 1 g: CL %.3f, %.3f required", report.CL, report.CLRequired)
+		}
+		if report.StaticMargin <= 0.05 {
+			t.Errorf("Static margin %.3f should be clearly positive at forward CG", report.StaticMargin)
+		}
+		if report.CmAlpha >= 0 || report.CmQ >= 0 || report.CmElevator >= 0 {
+			t.Errorf("Expected negative Cmα, Cmq and Cmδe, got %.3f, %.3f, %.3f",
+				report.CmAlpha, report.CmQ, report.CmElevator)
+		}
+
+		// The neutral point is where dCm/dCL is zero: Cmα about it vanishes
+		assertApproxEqual(t, report.StaticMargin, report.NeutralPoint-report.CGPosition, 1e-12)
+		assertApproxEqual(t, report.CmAlpha, -report.CLAlpha*report.StaticMargin, 0.05)
+
+		// Pitch damping puts the manoeuvre point aft of the neutral point,
+		// and pulling g takes trailing edge up elevator
+		if report.ManeuverMargin <= report.StaticMargin {
+			t.Errorf("Manoeuvre margin %.3f should exceed the static margin %.3f",
+				report.ManeuverMargin, report.StaticMargin)
+		}
+		if deg := report.ElevatorPerG * RAD_TO_DEG; deg > -1 || deg < -15 {
+			t.Errorf("Elevator per g %.2f° is implausible", deg)
+		}
+
+		text := report.String()
+		for _, want := range []string{"Neutral point:", "Static margin:", ", stable", "Elevator per g:", "Manoeuvre point:"} {
+			if !strings.Contains(text, want) {
+				t.Errorf("Report is missing %q:\n%s", want, text)
+			}
+		}
+	})
+
+	t.Run("Margin Shrinks With Fuselage Fuel", func(t *testing.T) {
+		previous := analyse(0)
+		for _, fuel := range []float64{150, 300, 511.7} {
+			report := analyse(fuel)
+			if report.CGPosition <= previous.CGPosition {
+				t.Fatalf("CG should move aft with %.0f lbs of fuselage fuel", fuel)
+			}
+			if report.StaticMargin >= previous.StaticMargin {
+				t.Errorf("Static margin %.3f with %.0f lbs should be below %.3f",
+					report.StaticMargin, fuel, previous.StaticMargin)
+			}
+			// The aerodynamics do not change, so neither does the neutral point
+			assertApproxEqual(t, report.NeutralPoint, previous.NeutralPoint, 1e-6)
+			assertApproxEqual(t, report.StaticMargin, previous.StaticMargin-(report.CGPosition-previous.CGPosition), 1e-6)
+			previous = report
+		}
+		fuselageTank.Contents.Value = 0
+	})
+
+	t.Run("Unanalysable Configurations", func(t *testing.T) {
+		noAERORP := *config
+		metrics := *config.Metrics
+		metrics.Location = nil
+		noAERORP.Metrics = &metrics
+		if _, err := StaticStabilityAnalysis(NewForcesMomentsCalculator(&noAERORP), state); err == nil {
+			t.Error("Expected an error without an AERORP")
+		}
+
+		// A search without a root reports that it did not converge
+		if _, ok := secantRoot(func(x float64) float64 { return x*x + 1 }, 0, 1, 1e-9); ok {
+			t.Error("Expected no root of x² + 1")
+		}
+		root, ok := secantRoot(func(x float64) float64 { return 2*x - 3 }, 0, 1, 1e-12)
+		assertEqual(t, ok, true)
+		assertApproxEqual(t, root, 1.5, 1e-12)
+	})
+}
@@ -0,0 +1,143 @@
+// Store Separation
+// Point masses released from the airframe and flown on beside it as
+// ballistic bodies, recording where each goes relative to the aircraft
+
+package main
+
+import "fmt"
+
+// DefaultStoreDragArea is the drag area of a released store (m²), about
+// that of a drop tank
+const DefaultStoreDragArea = 0.05
+
+// ReleasedStore is a point mass released from the aircraft, flown as a
+// particle under gravity and its own drag: no lift and no attitude. It
+// stops where it reaches the ground.
+type ReleasedStore struct {
+	Name        string
+	Mass        float64      // kg
+	CdA         float64      // Drag area (m²)
+	Decelerator *Decelerator // Optional parachute on the store
+
+	Time     float64 // s
+	Position Vector3 // NED, in the frame of AircraftState.Position (m)
+	Velocity Vector3 // Over the ground, NED (m/s)
+	Landed   bool
+
+	// The store's position relative to the aircraft, from the release on,
+	// one sample a step
+	Separation []SeparationSample
+}
+
+// SeparationSample is a released store's position relative to the
+// aircraft at one time
+type SeparationSample struct {
+	Time     float64
+	Offset   Vector3 // From the aircraft's CG, in its body axes (m)
+	Distance float64 // m
+	Altitude float64 // Of the store (m)
+}
+
+// ReleasePointMass releases a configured point mass from the aircraft at a
+// state, as a store starting from the point mass's location with the
+// velocity of that point of the airframe. The point mass's weight in the
+// configuration drops to zero. The engine's mass is the empty weight,
+// which never carried the point masses, so the aircraft's own motion is
+// not changed by the release. The store is flown on with each Step.
+func (fde *FlightDynamicsEngine) ReleasePointMass(state *AircraftState, name string) (*ReleasedStore, error) {
+	config := fde.Calculator.Config
+	if config == nil {
+		return nil, fmt.Errorf("releasing %q: the engine has no configuration", name)
+	}
+	pm, err := config.pointMass(name)
+	if err != nil {
+		return nil, err
+	}
+	if pm.Mass == nil || !(pm.Mass.Value > 0) || pm.Location == nil {
+		return nil, fmt.Errorf("point mass %q has no weight or location to release", name)
+	}
+	for _, store := range fde.Stores {
+		if store.Name == name {
+			return nil, fmt.Errorf("point mass %q is already released", name)
+		}
+	}
+
+	offset := StructuralToBody(pm.Location, fde.Calculator.CG)
+	store := &ReleasedStore{
+		Name:     name,
+		Mass:     pm.Mass.Value * LB_TO_KG,
+		CdA:      DefaultStoreDragArea,
+		Time:     state.Time,
+		Position: state.Position.Add(state.Orientation.RotateVector(offset)),
+		Velocity: state.Orientation.RotateVector(state.VelocityAt(offset)).Add(state.Wind),
+	}
+	if err := config.SetPointMassWeight(name, 0); err != nil {
+		return nil, err
+	}
+	store.record(state)
+	fde.Stores = append(fde.Stores, store)
+	return store, nil
+}
+
+// stepStores flies the released stores over a step to the aircraft's new
+// state, in the wind of that state
+func (fde *FlightDynamicsEngine) stepStores(state *AircraftState, dt float64) {
+	for _, store := range fde.Stores {
+		if store.Landed {
+			continue
+		}
+		store.Step(dt, state.Wind, state.Gear.GroundHeight)
+		store.record(state)
+	}
+}
+
+// Step advances the store by dt with fourth-order Runge-Kutta, in a
+// steady wind (NED, m/s) over ground at groundHeight (m)
+func (s *ReleasedStore) Step(dt float64, wind Vector3, groundHeight float64) {
+	if s.Landed {
+		return
+	}
+	air := NewAircraftState()
+	acceleration := func(t float64, position, velocity Vector3) Vector3 {
+		air.Altitude = -position.Z
+		air.UpdateAtmosphere()
+		relative := velocity.Sub(wind)
+		drag := Vector3{}
+		if speed := relative.Magnitude(); speed > 0 {
+			drag = relative.Scale(-0.5 * air.Density * speed * s.CdA)
+		}
+		if s.Decelerator != nil {
+			drag = drag.Add(s.Decelerator.Drag(relative, air.Density, t))
+		}
+		return drag.Scale(1 / s.Mass).Add(Vector3{Z: StandardGravity})
+	}
+
+	t, p, v := s.Time, s.Position, s.Velocity
+	a1 := acceleration(t, p, v)
+	p2, v2 := p.Add(v.Scale(dt/2)), v.Add(a1.Scale(dt/2))
+	a2 := acceleration(t+dt/2, p2, v2)
+	p3, v3 := p.Add(v2.Scale(dt/2)), v.Add(a2.Scale(dt/2))
+	a3 := acceleration(t+dt/2, p3, v3)
+	p4, v4 := p.Add(v3.Scale(dt)), v.Add(a3.Scale(dt))
+	a4 := acceleration(t+dt, p4, v4)
+
+	s.Position = p.Add(v.Add(v2.Scale(2)).Add(v3.Scale(2)).Add(v4).Scale(dt / 6))
+	s.Velocity = v.Add(a1.Add(a2.Scale(2)).Add(a3.Scale(2)).Add(a4).Scale(dt / 6))
+	s.Time = t + dt
+	if -s.Position.Z <= groundHeight {
+		s.Position.Z = -groundHeight
+		s.Velocity = Vector3{}
+		s.Landed = true
+	}
+}
+
+// record notes the store's position relative to the aircraft at a state
+func (s *ReleasedStore) record(state *AircraftState) {
+	relative := s.Position.Sub(state.Position)
+	s.Separation = append(s.Separation, SeparationSample{
+		Time:     s.Time,
+		Offset:   state.Orientation.RotateVectorInverse(relative),
+		Distance: relative.Magnitude(),
+		Altitude: -s.Position.Z,
+	})
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// levelDrop flies the aircraft straight and level at a constant speed, as
+// drop tests are flown, with its released stores flown on beside it. The
+// P-51D configuration model does not hold its attitude from a cruise
+// state, so the aircraft's side of the separation is held steady instead.
+type levelDrop struct {
+	*FlightDynamicsEngine
+}
+
+func (e *levelDrop) Step(state *AircraftState, dt float64) *AircraftState {
+	next := state.Copy()
+	next.Time = state.Time + dt
+	next.Position = state.Position.Add(state.GroundVelocity().Scale(dt))
+	e.stepStores(next, dt)
+	return next
+}
+
+func TestStoreSeparation(t *testing.T) {
+	config := loadP51DConfig(t)
+	if err := config.SetPointMassWeight("left drop tank", 500); err != nil {
+		t.Fatal(err)
+	}
+	engine := &levelDrop{NewFlightDynamicsEngine(config, NewRungeKutta4Integrator())}
+	state := cruiseState(3000, 150, 0)
+
+	store, err := engine.ReleasePointMass(state, "left drop tank")
+	if err != nil {
+		t.Fatalf("ReleasePointMass: %v", err)
+	}
+	assertApproxEqual(t, store.Mass, 500*LB_TO_KG, 1e-9)
+	pm, _ := config.pointMass("left drop tank")
+	assertEqual(t, pm.Mass.Value, 0.0)
+	if _, err := engine.ReleasePointMass(state, "left drop tank"); err == nil {
+		t.Error("A released store should not release again")
+	}
+	if _, err := engine.ReleasePointMass(state, "right drop tank"); err == nil {
+		t.Error("A point mass without weight has nothing to release")
+	}
+
+	// From the tank's station under the wing, at the aircraft's speed
+	first := store.Separation[0]
+	assertApproxEqual(t, first.Offset.Y, StructuralPosition(pm.Location).Y, 1e-12)
+	if first.Offset.Z <= 0 {
+		t.Errorf("The store should start below the wing: %+v", first.Offset)
+	}
+	assertApproxEqual(t, store.Velocity.X, 150, 1e-9)
+
+	for i := 0; i < 300; i++ {
+		state = engine.Step(state, 0.01)
+	}
+
+	t.Run("Falls Clear", func(t *testing.T) {
+		assertEqual(t, len(store.Separation), 301)
+		for i := 1; i < len(store.Separation); i++ {
+			if store.Separation[i].Distance <= store.Separation[i-1].Distance {
+				t.Fatalf("The separation closes at %.2f s: %.3f m after %.3f m",
+					store.Separation[i].Time, store.Separation[i].Distance, store.Separation[i-1].Distance)
+			}
+		}
+
+		// Falling nearly as a body in vacuum over 3 s, and dropping back
+		// behind the aircraft as its drag slows it
+		last := store.Separation[len(store.Separation)-1]
+		assertApproxEqual(t, last.Time, 3, 1e-9)
+		fall := last.Offset.Z - first.Offset.Z
+		vacuum := 0.5 * StandardGravity * 3 * 3
+		if fall > vacuum || fall < 0.97*vacuum {
+			t.Errorf("The store fell %.2f m in 3 s, expected a little under %.2f m", fall, vacuum)
+		}
+		if last.Offset.X >= first.Offset.X {
+			t.Errorf("The store's drag should leave it behind the aircraft: %+v", last.Offset)
+		}
+		assertApproxEqual(t, last.Altitude, 3000-last.Offset.Z, 1e-6)
+	})
+
+	t.Run("Store Parachute", func(t *testing.T) {
+		// A retarded store falls behind the slick one
+		config := loadP51DConfig(t)
+		config.SetPointMassWeight("right drop tank", 500)
+		engine := &levelDrop{NewFlightDynamicsEngine(config, NewRungeKutta4Integrator())}
+		state := cruiseState(3000, 150, 0)
+		retarded, err := engine.ReleasePointMass(state, "right drop tank")
+		if err != nil {
+			t.Fatalf("ReleasePointMass: %v", err)
+		}
+		retarded.Decelerator = &Decelerator{Name: "retarder", CdS: 2, TimeConstant: 0.3}
+		retarded.Decelerator.Deploy(0.5)
+		for i := 0; i < 300; i++ {
+			state = engine.Step(state, 0.01)
+		}
+		slick := store.Separation[len(store.Separation)-1].Offset
+		got := retarded.Separation[len(retarded.Separation)-1].Offset
+		if got.X >= slick.X-10 || got.Z >= slick.Z {
+			t.Errorf("The retarded store should trail well behind and above the slick one: %+v against %+v", got, slick)
+		}
+	})
+
+	t.Run("Engine Step", func(t *testing.T) {
+		config := loadP51DConfig(t)
+		config.SetPointMassWeight("left drop tank", 500)
+		engine := NewFlightDynamicsEngine(config, NewRungeKutta4Integrator())
+		state := cruiseState(3000, 150, 0)
+		store, err := engine.ReleasePointMass(state, "left drop tank")
+		if err != nil {
+			t.Fatalf("ReleasePointMass: %v", err)
+		}
+		if _, err := engine.Step(state, 0.01); err != nil {
+			t.Fatalf("Step: %v", err)
+		}
+		assertEqual(t, len(store.Separation), 2)
+		assertApproxEqual(t, store.Time, 0.01, 1e-12)
+		if engine.NewSession().Stores != nil {
+			t.Error("A session should not fly this trajectory's stores")
+		}
+	})
+
+	t.Run("Landing", func(t *testing.T) {
+		store := &ReleasedStore{Name: "bomb", Mass: 100, CdA: 0.02, Position: Vector3{Z: -5}, Velocity: Vector3{X: 50}}
+		for i := 0; i < 200 && !store.Landed; i++ {
+			store.Step(0.01, Vector3{}, 0)
+		}
+		assertEqual(t, store.Landed, true)
+		assertEqual(t, store.Position.Z, 0.0)
+		fall := math.Sqrt(2 * 5 / StandardGravity)
+		assertApproxEqual(t, store.Time, fall, 0.011)
+	})
+}
@@ -0,0 +1,129 @@
+// Structural Limits
+// Never-exceed speed and load factor limits, checked each simulation step
+
+package main
+
+import (
+	"fmt"
+)
+
+// StructuralLimits is an airframe limit model. The load factor limits depend
+// on the flap deflection, as flaps are stressed for far lower loads than the
+// wing.
+type StructuralLimits struct {
+	Vne       float64  // Never-exceed calibrated airspeed (m/s); 0 disables the check
+	NzMax     *Table1D // Positive nz limit (g) by flap deflection (deg)
+	NzMin     *Table1D // Negative nz limit (g) by flap deflection (deg)
+	Terminate bool     // Fail the step on an exceedance instead of only recording it
+}
+
+// Structural limit names
+const (
+	LimitVne   = "vne"
+	LimitNzMax = "nz-max"
+	LimitNzMin = "nz-min"
+)
+
+// LimitExceedance is one structural limit exceeded at a point in time
+type LimitExceedance struct {
+	Time  float64 `json:"time"`  // Simulation time (s)
+	Limit string  `json:"limit"` // LimitVne, LimitNzMax, LimitNzMin or a placard limit
+	Value float64 `json:"value"` // Airspeed (m/s) or load factor (g)
+	Bound float64 `json:"bound"` // The limit that was exceeded
+}
+
+func (e LimitExceedance) String() string {
+	return fmt.Sprintf("%s exceeded at t=%.2fs: %.2f beyond %.2f", e.Limit, e.Time, e.Value, e.Bound)
+}
+
+// StructuralLimitError stops a simulation whose limits terminate on exceedance
+type StructuralLimitError struct {
+	Exceedance LimitExceedance
+}
+
+func (e *StructuralLimitError) Error() string {
+	return "structural limit " + e.Exceedance.String()
+}
+
+// NewP51DStructuralLimits returns the P-51D limits: 505 mph (439 kt) IAS
+// never-exceed speed, +8/-4 g clean and +2/0 g with any flap extended
+func NewP51DStructuralLimits() *StructuralLimits {
+	return &StructuralLimits{
+		Vne: 439.0 * KT_TO_MS,
+		NzMax: &Table1D{
+			Indices: []float64{0, 1, MaxFlapDeflectionDeg},
+			Values:  []float64{8.0, 2.0, 2.0},
+		},
+		NzMin: &Table1D{
+			Indices: []float64{0, 1, MaxFlapDeflectionDeg},
+			Values:  []float64{-4.0, 0.0, 0.0},
+		},
+	}
+}
+
+// Check returns the limits the state exceeds
+func (limits *StructuralLimits) Check(state *AircraftState) []LimitExceedance {
+	var exceeded []LimitExceedance
+	add := func(limit string, value, bound float64) {
+		exceeded = append(exceeded, LimitExceedance{Time: state.Time, Limit: limit, Value: value, Bound: bound})
+	}
+
+	if limits.Vne > 0 && state.CalibratedAirspeed > limits.Vne {
+		add(LimitVne, state.CalibratedAirspeed, limits.Vne)
+	}
+
+	flapDeg := state.ControlSurfaces.FlapLeft * RAD_TO_DEG
+	nz := state.LoadFactor.Z
+	if limits.NzMax != nil {
+		if bound := interpolate1D(limits.NzMax, flapDeg); nz > bound {
+			add(LimitNzMax, nz, bound)
+		}
+	}
+	if limits.NzMin != nil {
+		if bound := interpolate1D(limits.NzMin, flapDeg); nz < bound {
+			add(LimitNzMin, nz, bound)
+		}
+	}
+
+	return exceeded
+}
+
+// checkLimits records the structural and placard limit exceedances that
+// begin at this state, and sets its placard warnings, which an extension
+// the interlock refused also raises. Either limits may be
+// nil. When the structural limits terminate the simulation, any structural
+// exceedance is returned as a *StructuralLimitError.
+func (stats *FlightStatistics) checkLimits(limits *StructuralLimits, placards *PlacardLimits, state *AircraftState) error {
+	if limits == nil && placards == nil {
+		return nil
+	}
+
+	var structural []LimitExceedance
+	if limits != nil {
+		structural = limits.Check(state)
+	}
+	exceeded := append(structural, placards.Check(state)...)
+	active := make(map[string]bool, len(exceeded))
+	for _, e := range exceeded {
+		if stats.exceeding[e.Limit] || active[e.Limit] {
+			active[e.Limit] = true
+			continue
+		}
+		active[e.Limit] = true
+		if stats.MaxExceedances > 0 && len(stats.Exceedances) >= stats.MaxExceedances {
+			stats.DroppedExceedances++
+		} else {
+			stats.Exceedances = append(stats.Exceedances, e)
+		}
+	}
+	stats.exceeding = active
+	if placards != nil {
+		state.Placards.OverspeedFlaps = active[LimitVfe] || state.Placards.FlapsHeld
+		state.Placards.OverspeedGear = active[LimitVle] || state.Placards.GearHeld
+	}
+
+	if limits != nil && limits.Terminate && len(structural) > 0 {
+		return &StructuralLimitError{Exceedance: structural[0]}
+	}
+	return nil
+}
@@ -230,6 +230,73 @@ func TestJSBSimComplianceVerification(t *testing.T) {
 		}
 	})
 	
+	t.Run("3D Sub-Tables With Different Grids", func(t *testing.T) {
+		// Each sub-table may have its own row and column breakpoints. The
+		// first holds the row value, the second 100 + 10·row on a wider,
+		// uneven row grid.
+		table := &Table{
+			Name: "different_grids",
+			IndependentVar: []*IndependentVar{
+				{Lookup: "row", Value: "test/row"},
+				{Lookup: "column", Value: "test/col"},
+				{Lookup: "table", Value: "test/table"},
+			},
+			TableData: []*TableData{
+				{
+					Breakpoint: "0.0",
+					Data: `        0.0    1.0
+0.0     0.0    0.0
+10.0    10.0   10.0`,
+				},
+				{
+					Breakpoint: "1.0",
+					Data: `        0.0    1.0
+0.0     100.0  100.0
+5.0     150.0  150.0
+20.0    300.0  300.0`,
+				},
+			},
+		}
+		
+		pt, err := ParseTable(table)
+		if err != nil {
+			t.Fatalf("3D table parsing failed: %v", err)
+		}
+		
+		// Row 8 is 8 on the first grid and 150 + 0.2·150 = 180 on the
+		// second, between its rows 5 and 20; a quarter of the way across
+		// the blend is 8 + 0.25·(180 - 8) = 51. Reusing the first grid's
+		// row fraction on the second would give 83.
+		result, err := InterpolateTable(pt, 8.0, 0.5, 0.25)
+		if err != nil {
+			t.Fatalf("3D interpolation failed: %v", err)
+		}
+		assertApproxEqual(t, result, 51.0, 1e-12)
+		
+		// At each breakpoint the sub-table is read on its own grid alone
+		result, _ = InterpolateTable(pt, 15.0, 0.5, 1.0)
+		assertApproxEqual(t, result, 250.0, 1e-12)
+		result, _ = InterpolateTable(pt, 15.0, 0.5, 0.0)
+		assertApproxEqual(t, result, 10.0, 1e-12)
+		
+		// Grids this close are not warned of; rows far apart are
+		assertEqual(t, subTableGridWarning(pt.Data3D), "")
+		pt.Data3D[1].RowIndices = []float64{100, 105, 120}
+		assertEqual(t, subTableGridWarning(pt.Data3D),
+			"the rows of the sub-table at 1 span 100 to 120, against 0 to 10 at 0")
+		
+		// A parsed configuration reports it among its warnings
+		table.TableData[1].Data = `        0.0    1.0
+100.0   100.0  100.0
+120.0   300.0  300.0`
+		config := &JSBSimConfig{Aerodynamics: &Aerodynamics{Axis: []*Axis{
+			{Name: "LIFT", Function: []*Function{{Name: "aero/coefficient/CLx", Table: table}}},
+		}}}
+		assertEqual(t, checkSubTableGrids(config), []string{
+			"function aero/coefficient/CLx: table different_grids: the rows of the sub-table at 1 span 100 to 120, against 0 to 10 at 0",
+		})
+	})
+	
 	t.Run("Mixed Breakpoint Attribute Support", func(t *testing.T) {
 		// Test that we support both "breakpoint" and "breakPoint" attributes
 		// (Real JSBSim files use "breakPoint" with capital P)
@@ -290,6 +357,36 @@ func TestJSBSimComplianceVerification(t *testing.T) {
 			t.Error("1D table should reject 2 inputs")
 		}
 		
+		// 2D table with a single cell, reduced to a 1D constant: it takes
+		// the input of the variable kept alone or both declared inputs
+		tableCell := &Table{
+			Name: "validation_cell",
+			IndependentVar: []*IndependentVar{
+				{Lookup: "row", Value: "test1"},
+				{Lookup: "column", Value: "test2"},
+			},
+			TableData: []*TableData{{Data: "        1.0\n0.0     2.0"}},
+		}
+		ptCell, _ := ParseTable(tableCell)
+		
+		// Should accept 2 inputs, ignoring the dropped variable's
+		_, err = InterpolateTable(ptCell, 0.0, 1.0)
+		if err != nil {
+			t.Errorf("Reduced 2D table should accept 2 inputs: %v", err)
+		}
+		
+		// Should accept 1 input, of the variable kept
+		_, err = InterpolateTable(ptCell, 0.5)
+		if err != nil {
+			t.Errorf("Reduced 2D table should accept 1 input: %v", err)
+		}
+		
+		// Should reject 3 inputs
+		_, err = InterpolateTable(ptCell, 0.5, 1.0, 2.0)
+		if err == nil {
+			t.Error("Reduced 2D table should reject 3 inputs")
+		}
+		
 		// 2D table
 		table2D := &Table{
 			Name: "validation_2d",
@@ -297,7 +394,7 @@ func TestJSBSimComplianceVerification(t *testing.T) {
 				{Lookup: "row", Value: "test1"},
 				{Lookup: "column", Value: "test2"},
 			},
-			TableData: []*TableData{{Data: "        1.0\n0.0     2.0"}},
+			TableData: []*TableData{{Data: "        1.0  2.0\n0.0     2.0  3.0\n1.0     4.0  5.0"}},
 		}
 		pt2D, _ := ParseTable(table2D)
 		
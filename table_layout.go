@@ -0,0 +1,367 @@
+// Table Layout
+// Rearranging the axes of parsed tables, so that whatever layout the data
+// was written in, each variable is looked up along the axis of its position
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// tableLookups are the lookup types of the row, column and table axes
+var tableLookups = []string{"row", "column", "table"}
+
+// resolveLookups returns the lookup type of each variable of a table: the
+// declared types, with any left blank taking the unused types in order. It
+// fails unless the result is a permutation of the first len(declared)
+// table lookups.
+func resolveLookups(declared []string) ([]string, error) {
+	if len(declared) > len(tableLookups) {
+		return nil, fmt.Errorf("%d variables, at most %d are supported", len(declared), len(tableLookups))
+	}
+	available := tableLookups[:len(declared)]
+	resolved := make([]string, len(declared))
+	used := make(map[string]bool, len(declared))
+	for i, lookup := range declared {
+		lookup = strings.ToLower(strings.TrimSpace(lookup))
+		if lookup == "" {
+			continue
+		}
+		if !slices.Contains(available, lookup) || used[lookup] {
+			return nil, fmt.Errorf("lookup types %q are not one each of %q", declared, available)
+		}
+		resolved[i] = lookup
+		used[lookup] = true
+	}
+	for i := range resolved {
+		if resolved[i] != "" {
+			continue
+		}
+		for _, lookup := range available {
+			if !used[lookup] {
+				resolved[i], used[lookup] = lookup, true
+				break
+			}
+		}
+	}
+	return resolved, nil
+}
+
+// Transpose swaps the rows and columns of a 2D table, and with them the
+// lookup types of its variables, so each variable keeps its breakpoints
+// and the table gives the same values for the same properties
+func (pt *ParsedTable) Transpose() error {
+	if pt.Dimension != 2 {
+		return fmt.Errorf("table %s: only 2D tables can be transposed, not %dD", pt.Name, pt.Dimension)
+	}
+	lookups, err := resolveLookups(pt.LookupTypes)
+	if err != nil {
+		return fmt.Errorf("table %s: %w", pt.Name, err)
+	}
+	if pt.Data2D != nil {
+		transposed, err := transpose2D(pt.Data2D)
+		if err != nil {
+			return fmt.Errorf("table %s: %w", pt.Name, err)
+		}
+		pt.Data2D = transposed
+	}
+	for i, lookup := range lookups {
+		if lookup == "row" {
+			lookups[i] = "column"
+		} else {
+			lookups[i] = "row"
+		}
+	}
+	pt.LookupTypes = lookups
+	return nil
+}
+
+// Reorder rearranges the axes of a 2D or 3D table so that variable i is
+// looked up by lookups[i], moving the data with them. Reordering a 3D table
+// across its table axis needs every slice to share its row and column
+// breakpoints.
+func (pt *ParsedTable) Reorder(lookups []string) error {
+	if pt.Dimension != 2 && pt.Dimension != 3 {
+		return fmt.Errorf("table %s: only 2D and 3D tables can be reordered, not %dD", pt.Name, pt.Dimension)
+	}
+	if len(lookups) != pt.Dimension || len(pt.LookupTypes) != pt.Dimension {
+		return fmt.Errorf("table %s: %d lookup types for a %dD table", pt.Name, len(lookups), pt.Dimension)
+	}
+	current, err := resolveLookups(pt.LookupTypes)
+	if err != nil {
+		return fmt.Errorf("table %s: %w", pt.Name, err)
+	}
+	target, err := resolveLookups(lookups)
+	if err != nil {
+		return fmt.Errorf("table %s: %w", pt.Name, err)
+	}
+
+	// from[a] is the axis the data of new axis a now lies along
+	from := make([]int, pt.Dimension)
+	for v := range target {
+		from[slices.Index(tableLookups, target[v])] = slices.Index(tableLookups, current[v])
+	}
+
+	switch {
+	case pt.Dimension == 2 && from[0] == 1:
+		if pt.Data2D != nil {
+			if pt.Data2D, err = transpose2D(pt.Data2D); err != nil {
+				return fmt.Errorf("table %s: %w", pt.Name, err)
+			}
+		}
+	case pt.Dimension == 3 && from[2] == 2 && from[0] == 1:
+		for i, slice := range pt.Data3D {
+			if pt.Data3D[i], err = transpose2D(slice); err != nil {
+				return fmt.Errorf("table %s: %w", pt.Name, err)
+			}
+		}
+	case pt.Dimension == 3 && from[2] != 2:
+		if pt.Data3D, err = permute3D(pt.Data3D, from); err != nil {
+			return fmt.Errorf("table %s: %w", pt.Name, err)
+		}
+	}
+	pt.LookupTypes = target
+	return nil
+}
+
+// transpose2D returns a table with the rows and columns of t swapped
+func transpose2D(t *Table2D) (*Table2D, error) {
+	if err := checkRectangular(t); err != nil {
+		return nil, err
+	}
+	transposed := &Table2D{
+		Breakpoint: t.Breakpoint,
+		RowIndices: slices.Clone(t.ColIndices),
+		ColIndices: slices.Clone(t.RowIndices),
+		Data:       make([][]float64, len(t.ColIndices)),
+	}
+	for j := range transposed.Data {
+		transposed.Data[j] = make([]float64, len(t.RowIndices))
+		for i := range t.RowIndices {
+			transposed.Data[j][i] = t.Data[i][j]
+		}
+	}
+	return transposed, nil
+}
+
+// permute3D returns the slices of a 3D table with its axes rearranged, new
+// axis a taking the breakpoints and data of old axis from[a]
+func permute3D(tables []*Table2D, from []int) ([]*Table2D, error) {
+	if len(tables) == 0 {
+		return tables, nil
+	}
+	axes := [][]float64{tables[0].RowIndices, tables[0].ColIndices, make([]float64, len(tables))}
+	for k, slice := range tables {
+		if err := checkRectangular(slice); err != nil {
+			return nil, fmt.Errorf("breakpoint %g: %w", slice.Breakpoint, err)
+		}
+		if !slices.Equal(slice.RowIndices, axes[0]) || !slices.Equal(slice.ColIndices, axes[1]) {
+			return nil, fmt.Errorf("breakpoint %g: row and column breakpoints differ between slices", slice.Breakpoint)
+		}
+		axes[2][k] = slice.Breakpoint
+	}
+
+	permuted := make([]*Table2D, len(axes[from[2]]))
+	var index [3]int
+	for k, breakpoint := range axes[from[2]] {
+		slice := &Table2D{
+			Breakpoint: breakpoint,
+			RowIndices: slices.Clone(axes[from[0]]),
+			ColIndices: slices.Clone(axes[from[1]]),
+			Data:       make([][]float64, len(axes[from[0]])),
+		}
+		for i := range slice.Data {
+			slice.Data[i] = make([]float64, len(axes[from[1]]))
+			for j := range slice.Data[i] {
+				index[from[0]], index[from[1]], index[from[2]] = i, j, k
+				slice.Data[i][j] = tables[index[2]].Data[index[0]][index[1]]
+			}
+		}
+		permuted[k] = slice
+	}
+	return permuted, nil
+}
+
+// checkRectangular reports a table whose rows do not each hold one value
+// per column breakpoint
+func checkRectangular(t *Table2D) error {
+	if len(t.Data) != len(t.RowIndices) {
+		return fmt.Errorf("%d rows of data for %d row breakpoints", len(t.Data), len(t.RowIndices))
+	}
+	for i, row := range t.Data {
+		if len(row) != len(t.ColIndices) {
+			return fmt.Errorf("row %g has %d values for %d columns", t.RowIndices[i], len(row), len(t.ColIndices))
+		}
+	}
+	return nil
+}
+
+// CollapsedLayout is the declared layout of a 2D table with a single row
+// or column, which ParseTable reduces to a 1D table of the other variable
+type CollapsedLayout struct {
+	Vars        []string // The declared variables, in input order
+	LookupTypes []string
+	Kept        int     // Position among Vars of the variable kept
+	Breakpoint  float64 // The lone breakpoint of the variable dropped
+}
+
+// collapse reduces a 2D table with a single row or column to a 1D table of
+// the other variable, its breakpoints sorted, so a single cell becomes a
+// constant. Tables whose rows are not each full are left as they are.
+func (pt *ParsedTable) collapse() {
+	t := pt.Data2D
+	if pt.Dimension != 2 || t == nil || (len(t.RowIndices) != 1 && len(t.ColIndices) != 1) {
+		return
+	}
+	if checkRectangular(t) != nil {
+		return
+	}
+
+	layout := &CollapsedLayout{Vars: pt.IndependentVars, LookupTypes: pt.LookupTypes}
+	data := &Table1D{}
+	if len(t.ColIndices) == 1 {
+		layout.Breakpoint = t.ColIndices[0]
+		data.Indices = slices.Clone(t.RowIndices)
+		for _, row := range t.Data {
+			data.Values = append(data.Values, row[0])
+		}
+	} else {
+		layout.Kept, layout.Breakpoint = 1, t.RowIndices[0]
+		data.Indices, data.Values = slices.Clone(t.ColIndices), slices.Clone(t.Data[0])
+	}
+	sortTable1D(data)
+
+	pt.Dimension, pt.Data1D, pt.Data2D = 1, data, nil
+	pt.IndependentVars = []string{layout.Vars[layout.Kept]}
+	pt.LookupTypes = []string{"row"}
+	pt.Collapsed = layout
+}
+
+// expanded returns a collapsed table in its declared 2D layout
+func (pt *ParsedTable) expanded() *ParsedTable {
+	layout, c := *pt, pt.Collapsed
+	layout.Dimension, layout.Data1D, layout.Collapsed = 2, nil, nil
+	layout.IndependentVars, layout.LookupTypes = c.Vars, c.LookupTypes
+	values := pt.Data1D.Values
+	if c.Kept == 0 {
+		layout.Data2D = &Table2D{RowIndices: slices.Clone(pt.Data1D.Indices), ColIndices: []float64{c.Breakpoint}}
+		for _, v := range values {
+			layout.Data2D.Data = append(layout.Data2D.Data, []float64{v})
+		}
+	} else {
+		layout.Data2D = &Table2D{
+			RowIndices: []float64{c.Breakpoint},
+			ColIndices: slices.Clone(pt.Data1D.Indices),
+			Data:       [][]float64{slices.Clone(values)},
+		}
+	}
+	return &layout
+}
+
+// sortTable1D sorts a table by its breakpoints, keeping the order of the
+// values at a repeated breakpoint
+func sortTable1D(t *Table1D) {
+	order := make([]int, len(t.Indices))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return t.Indices[order[a]] < t.Indices[order[b]] })
+	indices, values := make([]float64, len(order)), make([]float64, len(order))
+	for i, j := range order {
+		indices[i], values[i] = t.Indices[j], t.Values[j]
+	}
+	t.Indices, t.Values = indices, values
+}
+
+// minSubTableOverlap is the least share of their combined span the row or
+// column breakpoints of two sub-tables of a 3D table must have in common
+// before they are warned of
+const minSubTableOverlap = 0.5
+
+// checkTableData warns of the malformed numbers and the rows without a
+// value in the data of the configuration's tables
+func checkTableData(config *JSBSimConfig) []string {
+	var warnings []string
+	for _, named := range configNamedFunctions(config) {
+		for _, t := range functionTables(named.function) {
+			pt, err := ParseTable(t)
+			if err != nil {
+				continue
+			}
+			for _, warning := range pt.Warnings {
+				warnings = append(warnings, fmt.Sprintf("function %s: table %s: %s", named.name, tableLabel(t), warning))
+			}
+		}
+	}
+	return warnings
+}
+
+// checkSubTableGrids warns of the 3D tables whose sub-tables cover widely
+// different rows or columns. Each sub-table is interpolated on its own
+// grid before the blend between them, so differing grids are allowed, but
+// a query inside one grid and beyond the next blends with its edge value.
+func checkSubTableGrids(config *JSBSimConfig) []string {
+	var warnings []string
+	for _, named := range configNamedFunctions(config) {
+		for _, t := range functionTables(named.function) {
+			if len(t.IndependentVar) != 3 {
+				continue
+			}
+			pt, err := ParseTable(t)
+			if err != nil || len(pt.Data3D) < 2 {
+				continue
+			}
+			if warning := subTableGridWarning(pt.Data3D); warning != "" {
+				warnings = append(warnings, fmt.Sprintf("function %s: table %s: %s", named.name, tableLabel(t), warning))
+			}
+		}
+	}
+	return warnings
+}
+
+// tableLabel names a table in a warning, by its location when it has no name
+func tableLabel(t *Table) string {
+	if t.Name == "" {
+		return "at " + t.Source.String()
+	}
+	return t.Name
+}
+
+// subTableGridWarning describes the first sub-table whose rows or columns
+// overlap those of the first sub-table by less than minSubTableOverlap,
+// or returns an empty string
+func subTableGridWarning(tables []*Table2D) string {
+	first := tables[0]
+	for _, slice := range tables[1:] {
+		for _, axis := range []struct {
+			name      string
+			want, got []float64
+		}{
+			{"rows", first.RowIndices, slice.RowIndices},
+			{"columns", first.ColIndices, slice.ColIndices},
+		} {
+			if len(axis.want) == 0 || len(axis.got) == 0 || gridOverlap(axis.want, axis.got) >= minSubTableOverlap {
+				continue
+			}
+			return fmt.Sprintf("the %s of the sub-table at %g span %g to %g, against %g to %g at %g",
+				axis.name, slice.Breakpoint, slices.Min(axis.got), slices.Max(axis.got),
+				slices.Min(axis.want), slices.Max(axis.want), first.Breakpoint)
+		}
+	}
+	return ""
+}
+
+// gridOverlap returns the share of the combined span of two sets of
+// breakpoints that both cover, one for two equal single points
+func gridOverlap(a, b []float64) float64 {
+	low, high := math.Max(slices.Min(a), slices.Min(b)), math.Min(slices.Max(a), slices.Max(b))
+	span := math.Max(slices.Max(a), slices.Max(b)) - math.Min(slices.Min(a), slices.Min(b))
+	if span == 0 {
+		return 1
+	}
+	return math.Max(0, high-low) / span
+}
@@ -0,0 +1,326 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestTableLayout(t *testing.T) {
+	rowMajor := &Table{
+		Name: "row_major",
+		IndependentVar: []*IndependentVar{
+			{Lookup: "row", Value: "aero/alpha-deg"},
+			{Lookup: "column", Value: "velocities/mach"},
+		},
+		TableData: []*TableData{{Data: `
+        0.2    0.6    0.9
+-4.0   -0.30  -0.32  -0.40
+ 0.0    0.10   0.11   0.14
+ 8.0    0.90   0.96   1.20
+16.0    1.40   1.45   1.30`}},
+	}
+
+	// The same data with mach down the rows and alpha across the columns,
+	// alpha still declared first
+	columnMajor := &Table{
+		Name: "column_major",
+		IndependentVar: []*IndependentVar{
+			{Lookup: "column", Value: "aero/alpha-deg"},
+			{Lookup: "row", Value: "velocities/mach"},
+		},
+		TableData: []*TableData{{Data: `
+       -4.0    0.0    8.0   16.0
+0.2    -0.30   0.10   0.90   1.40
+0.6    -0.32   0.11   0.96   1.45
+0.9    -0.40   0.14   1.20   1.30`}},
+	}
+
+	t.Run("Column Major Matches Row Major", func(t *testing.T) {
+		rows, err := ParseTable(rowMajor)
+		if err != nil {
+			t.Fatalf("ParseTable: %v", err)
+		}
+		columns, err := ParseTable(columnMajor)
+		if err != nil {
+			t.Fatalf("ParseTable: %v", err)
+		}
+		assertEqual(t, columns.LookupTypes, []string{"row", "column"})
+		assertEqual(t, columns.Data2D.RowIndices, rows.Data2D.RowIndices)
+
+		for _, alpha := range []float64{-6, -4, -1.5, 0, 3.2, 8, 12.5, 16, 20} {
+			for _, mach := range []float64{0.1, 0.2, 0.45, 0.6, 0.75, 0.9, 1.1} {
+				want, err := InterpolateTable(rows, alpha, mach)
+				if err != nil {
+					t.Fatalf("InterpolateTable: %v", err)
+				}
+				got, err := InterpolateTable(columns, alpha, mach)
+				if err != nil {
+					t.Fatalf("InterpolateTable: %v", err)
+				}
+				assertApproxEqual(t, got, want, 1e-12)
+			}
+		}
+	})
+
+	t.Run("Transpose", func(t *testing.T) {
+		pt, err := ParseTable(rowMajor)
+		if err != nil {
+			t.Fatalf("ParseTable: %v", err)
+		}
+		if err := pt.Transpose(); err != nil {
+			t.Fatalf("Transpose: %v", err)
+		}
+		assertEqual(t, pt.LookupTypes, []string{"column", "row"})
+		assertEqual(t, pt.Data2D.RowIndices, []float64{0.2, 0.6, 0.9})
+		assertEqual(t, pt.Data2D.Data[2][3], 1.30)
+
+		// Transposing back restores the table
+		if err := pt.Transpose(); err != nil {
+			t.Fatalf("Transpose: %v", err)
+		}
+		original, _ := ParseTable(rowMajor)
+		assertEqual(t, pt.Data2D, original.Data2D)
+		assertEqual(t, pt.LookupTypes, original.LookupTypes)
+
+		if err := original.Reorder([]string{"row", "column"}); err != nil {
+			t.Fatalf("Reorder: %v", err)
+		}
+		assertEqual(t, original.Data2D, pt.Data2D)
+	})
+
+	t.Run("3D Reorder", func(t *testing.T) {
+		// Altitude declared first and laid along the table axis, alpha along
+		// the columns and mach down the rows
+		table := &Table{
+			Name: "permuted_3d",
+			IndependentVar: []*IndependentVar{
+				{Lookup: "table", Value: "atmosphere/altitude-ft"},
+				{Lookup: "column", Value: "aero/alpha-deg"},
+				{Lookup: "row", Value: "velocities/mach"},
+			},
+			TableData: []*TableData{
+				{Breakpoint: "0", Data: `
+        0.0   10.0
+0.3     1.0    2.0
+0.8     3.0    4.0`},
+				{Breakpoint: "20000", Data: `
+        0.0   10.0
+0.3     5.0    6.0
+0.8     7.0    8.0`},
+			},
+		}
+		pt, err := ParseTable(table)
+		if err != nil {
+			t.Fatalf("ParseTable: %v", err)
+		}
+		assertEqual(t, pt.LookupTypes, []string{"row", "column", "table"})
+		assertEqual(t, len(pt.Data3D), 2)
+		assertEqual(t, pt.Data3D[0].Breakpoint, 0.3)
+		assertEqual(t, pt.Data3D[0].RowIndices, []float64{0, 20000})
+		assertEqual(t, pt.Data3D[0].ColIndices, []float64{0, 10})
+
+		value := func(alt, alpha, mach float64) float64 {
+			v, err := InterpolateTable(pt, alt, alpha, mach)
+			if err != nil {
+				t.Fatalf("InterpolateTable: %v", err)
+			}
+			return v
+		}
+		assertApproxEqual(t, value(0, 10, 0.3), 2.0, 1e-12)
+		assertApproxEqual(t, value(20000, 0, 0.8), 7.0, 1e-12)
+		assertApproxEqual(t, value(10000, 5, 0.55), 4.5, 1e-12)
+
+		// Restoring the source layout gives back the written slices
+		if err := pt.Reorder([]string{"table", "column", "row"}); err != nil {
+			t.Fatalf("Reorder: %v", err)
+		}
+		assertEqual(t, pt.Data3D[1].Breakpoint, 20000.0)
+		assertEqual(t, pt.Data3D[1].Data, [][]float64{{5, 6}, {7, 8}})
+	})
+
+	t.Run("Invalid Lookups", func(t *testing.T) {
+		table := &Table{
+			Name: "duplicate_rows",
+			IndependentVar: []*IndependentVar{
+				{Lookup: "row", Value: "aero/alpha-deg"},
+				{Lookup: "row", Value: "velocities/mach"},
+			},
+			TableData: rowMajor.TableData,
+		}
+		if _, err := ParseTable(table); err == nil {
+			t.Error("Expected two row variables to be rejected")
+		}
+
+		pt, _ := ParseTable(rowMajor)
+		if err := pt.Reorder([]string{"row", "table"}); err == nil {
+			t.Error("Expected a table lookup to be rejected for a 2D table")
+		}
+		if err := pt.Reorder([]string{"row"}); err == nil {
+			t.Error("Expected too few lookups to be rejected")
+		}
+
+		oneD, _ := ParseTable(&Table{
+			IndependentVar: []*IndependentVar{{Value: "aero/alpha-deg"}},
+			TableData:      []*TableData{{Data: "0 0\n10 1"}},
+		})
+		if err := oneD.Transpose(); err == nil {
+			t.Error("Expected a 1D table not to transpose")
+		}
+		if !slices.Equal(oneD.LookupTypes, []string{""}) {
+			t.Errorf("A 1D table's lookup should be left as declared, got %q", oneD.LookupTypes)
+		}
+	})
+
+	t.Run("Single Column From FCS", func(t *testing.T) {
+		// The P-51D's take-off pitch damping factor against alpha at 70 kt
+		// and the lowest alphadot, written as a one-column 2D table and as
+		// a 1D table
+		var damping *Table
+		for _, ch := range loadP51DConfig(t).FlightControl.Channel {
+			for _, comp := range ch.Component {
+				if comp.Name == "aero/pitch-moment-damping-factor" {
+					damping = locateFunctionTables(comp.Function)[0].table
+				}
+			}
+		}
+		if damping == nil {
+			t.Fatal("The P-51D FCS has no pitch damping factor table")
+		}
+		pt, err := ParseTable(damping)
+		if err != nil {
+			t.Fatalf("ParseTable: %v", err)
+		}
+		layer := pt.Data3D[0]
+		column, oneD := fmt.Sprintf("\t%g\n", layer.ColIndices[0]), ""
+		for i, alpha := range layer.RowIndices {
+			column += fmt.Sprintf("%g\t%g\n", alpha, layer.Data[i][0])
+			oneD += fmt.Sprintf("%g\t%g\n", alpha, layer.Data[i][0])
+		}
+		collapsed, err := ParseTable(&Table{
+			IndependentVar: []*IndependentVar{
+				{Lookup: "row", Value: "aero/alpha-deg"},
+				{Lookup: "column", Value: "velocities/vc-kts"},
+			},
+			TableData: []*TableData{{Data: column}},
+		})
+		if err != nil {
+			t.Fatalf("ParseTable: %v", err)
+		}
+		reference, err := ParseTable(&Table{
+			IndependentVar: []*IndependentVar{{Value: "aero/alpha-deg"}},
+			TableData:      []*TableData{{Data: oneD}},
+		})
+		if err != nil {
+			t.Fatalf("ParseTable: %v", err)
+		}
+		assertEqual(t, collapsed.Dimension, 1)
+		assertEqual(t, collapsed.IndependentVars, []string{"aero/alpha-deg"})
+		assertEqual(t, collapsed.Data1D, reference.Data1D)
+
+		// Queried with one input or both, the speed ignored
+		for alpha := -6.0; alpha <= 9; alpha += 0.37 {
+			want, err := InterpolateTable(reference, alpha)
+			if err != nil {
+				t.Fatalf("InterpolateTable: %v", err)
+			}
+			for _, inputs := range [][]float64{{alpha}, {alpha, 70}, {alpha, 250}} {
+				got, err := InterpolateTable(collapsed, inputs...)
+				if err != nil {
+					t.Fatalf("InterpolateTable%v: %v", inputs, err)
+				}
+				assertEqual(t, got, want)
+			}
+		}
+		_, err = InterpolateTable(collapsed, 1, 70, 0)
+		if err == nil || err.Error() != "2D table reduced to 1D of aero/alpha-deg requires 1 or 2 inputs, got 3" {
+			t.Errorf("Expected three inputs to be rejected, got %v", err)
+		}
+	})
+
+	t.Run("Single Row", func(t *testing.T) {
+		// Mach declared first, so the lone row is of mach and the columns,
+		// out of order and with a repeat, of alpha. The repeat is a step,
+		// taking the lower value at the breakpoint.
+		pt, err := ParseTable(&Table{
+			IndependentVar: []*IndependentVar{
+				{Lookup: "row", Value: "velocities/mach"},
+				{Lookup: "column", Value: "aero/alpha-deg"},
+			},
+			TableData: []*TableData{{Data: `
+       10    0    5    5    20
+0.3    1.0   0.0  0.4  0.6  2.0`}},
+		})
+		if err != nil {
+			t.Fatalf("ParseTable: %v", err)
+		}
+		assertEqual(t, pt.IndependentVars, []string{"aero/alpha-deg"})
+		assertEqual(t, pt.Data1D.Indices, []float64{0, 5, 5, 10, 20})
+		assertEqual(t, pt.Collapsed.Kept, 1)
+		for _, c := range []struct{ alpha, want float64 }{
+			{-5, 0}, {2.5, 0.2}, {5, 0.4}, {5.5, 0.64}, {15, 1.5}, {30, 2},
+		} {
+			got, err := InterpolateTable(pt, 0.9, c.alpha)
+			if err != nil {
+				t.Fatalf("InterpolateTable: %v", err)
+			}
+			assertApproxEqual(t, got, c.want, 1e-12)
+		}
+	})
+
+	t.Run("Single Cell", func(t *testing.T) {
+		table := &Table{
+			IndependentVar: []*IndependentVar{
+				{Lookup: "column", Value: "velocities/mach"},
+				{Lookup: "row", Value: "aero/alpha-deg"},
+			},
+			TableData: []*TableData{{Data: "\t0.5\n4\t0.25"}},
+		}
+		pt, err := ParseTable(table)
+		if err != nil {
+			t.Fatalf("ParseTable: %v", err)
+		}
+		for _, inputs := range [][]float64{{-100}, {4}, {100}, {0, 4}, {9, -9}} {
+			got, err := InterpolateTable(pt, inputs...)
+			if err != nil {
+				t.Fatalf("InterpolateTable%v: %v", inputs, err)
+			}
+			assertEqual(t, got, 0.25)
+		}
+
+		// Rewritten, it keeps its declared 2D layout
+		if err := scaleTable(table, 2); err != nil {
+			t.Fatalf("scaleTable: %v", err)
+		}
+		scaled, err := ParseTable(table)
+		if err != nil {
+			t.Fatalf("ParseTable of the rewritten table: %v", err)
+		}
+		assertEqual(t, scaled.Collapsed, pt.Collapsed)
+		assertEqual(t, scaled.Data1D.Values, []float64{0.5})
+	})
+
+	t.Run("Parsed Form Follows Edits", func(t *testing.T) {
+		table := sampledTable("line", func(x float64) float64 { return 2 * x }, 0, 1)
+		first, err := cachedParseTable(table)
+		if err != nil {
+			t.Fatalf("cachedParseTable: %v", err)
+		}
+		again, _ := cachedParseTable(table)
+		if again != first {
+			t.Error("An unchanged table should not be parsed again")
+		}
+
+		// Data edited in place is parsed afresh, and a bad edit reported
+		table.TableData[0].Data = "0 0\n1 3"
+		edited, err := cachedParseTable(table)
+		if err != nil {
+			t.Fatalf("cachedParseTable of the edited table: %v", err)
+		}
+		assertEqual(t, edited.Data1D.Values, []float64{0, 3})
+		table.IndependentVar = nil
+		if _, err := cachedParseTable(table); err == nil {
+			t.Error("A table without variables should no longer evaluate")
+		}
+	})
+}
@@ -0,0 +1,462 @@
+// Table Report
+// Statistics and sanity checks for every table of a parsed aircraft
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// TableVariable describes the breakpoints of one independent variable
+type TableVariable struct {
+	Name   string
+	Lookup string // row, column or table
+	Count  int    // Number of breakpoints
+	Min    float64
+	Max    float64
+}
+
+// TableReport summarizes one table of a configuration
+type TableReport struct {
+	Section   string // aerodynamics or flight_control
+	Axis      string // Aerodynamic axis, "standalone", or the FCS channel
+	Function  string // Owning function or FCS component
+	Path      string // Position of the table within the function, e.g. "product/table"
+	Name      string // Table name attribute, often empty
+	Dimension int
+	Variables []TableVariable
+
+	// Data statistics
+	Points int
+	Min    float64
+	Max    float64
+	Mean   float64
+
+	// SlopeSignChanges is the largest number of times the slope along the
+	// row variable changes sign, over all columns and sub-tables. A lift
+	// curve has one, at the stall; FirstSlopeChange is the row breakpoint
+	// where the first one occurs.
+	SlopeSignChanges int
+	FirstSlopeChange float64
+
+	Anomalies []string
+}
+
+// AnalyzeTables reports on every table in the aerodynamics (axis and
+// standalone functions, including tables nested in operations) and in the
+// flight control components. Alpha breakpoints are checked against the
+// alphalimits section when the configuration has one.
+func AnalyzeTables(config *JSBSimConfig) []TableReport {
+	var reports []TableReport
+	add := func(section, axis, name string, f *Function) {
+		for _, located := range locateFunctionTables(f) {
+			report := analyzeTable(located.table)
+			report.Section = section
+			report.Axis = axis
+			report.Function = name
+			report.Path = located.path
+			if config.Aerodynamics != nil {
+				report.checkAlphaLimits(config.Aerodynamics.AlphaLimits)
+			}
+			reports = append(reports, report)
+		}
+	}
+
+	if aero := config.Aerodynamics; aero != nil {
+		for _, f := range aero.Function {
+			add("aerodynamics", "standalone", f.Name, f)
+		}
+		for _, axis := range aero.Axis {
+			for _, f := range axis.Function {
+				add("aerodynamics", axis.Name, f.Name, f)
+			}
+		}
+	}
+	if fc := config.FlightControl; fc != nil {
+		for _, ch := range fc.Channel {
+			for _, comp := range ch.Component {
+				if comp.Function == nil {
+					continue
+				}
+				name := comp.Function.Name
+				if name == "" {
+					name = comp.Name
+				}
+				add("flight_control", ch.Name, name, comp.Function)
+			}
+		}
+	}
+	return reports
+}
+
+// locatedTable is a table with its position in a function
+type locatedTable struct {
+	path  string
+	table *Table
+}
+
+// locateFunctionTables returns the tables of a function in document order
+func locateFunctionTables(f *Function) []locatedTable {
+	var tables []locatedTable
+	if f.Table != nil {
+		tables = append(tables, locatedTable{"table", f.Table})
+	}
+	for _, named := range functionOperations(f) {
+		tables = appendLocatedTables(tables, named.name, named.op)
+	}
+	return tables
+}
+
+func appendLocatedTables(tables []locatedTable, path string, op *Operation) []locatedTable {
+	// Repeated elements are told apart by their index among their siblings
+	operands := op.operandList()
+	counts := make(map[string]int)
+	for _, operand := range operands {
+		counts[operandElement(operand)]++
+	}
+	seen := make(map[string]int)
+	for _, operand := range operands {
+		element := operandElement(operand)
+		if operand.kind != operandTable && operand.kind != operandOperation {
+			continue
+		}
+		elementPath := path + "/" + element
+		if counts[element] > 1 {
+			elementPath = fmt.Sprintf("%s[%d]", elementPath, seen[element])
+		}
+		seen[element]++
+		if operand.kind == operandTable {
+			tables = append(tables, locatedTable{elementPath, op.Tables[operand.index]})
+		} else {
+			tables = appendLocatedTables(tables, elementPath, operand.nested.op)
+		}
+	}
+	return tables
+}
+
+// operandElement returns the element name of an operand
+func operandElement(operand operand) string {
+	switch operand.kind {
+	case operandProperty:
+		return "property"
+	case operandValue:
+		return "value"
+	case operandTable:
+		return "table"
+	}
+	return operand.nested.name
+}
+
+// analyzeTable computes the statistics and anomalies of one table
+func analyzeTable(table *Table) TableReport {
+	report := TableReport{Name: table.Name, Dimension: len(table.IndependentVar)}
+	if len(table.TableData) == 0 {
+		report.Anomalies = append(report.Anomalies, "no table data")
+		return report
+	}
+	if mismatchedDataBlocks(table) {
+		report.Anomalies = append(report.Anomalies, fmt.Sprintf(
+			"data blocks do not match the variables: %d variables, %d data blocks", len(table.IndependentVar), len(table.TableData)))
+		return report
+	}
+	pt, err := ParseTable(table)
+	if err != nil {
+		report.Anomalies = append(report.Anomalies, "parse error: "+err.Error())
+		return report
+	}
+
+	// Describe the table as written, in the layout of its lookup attributes
+	// rather than the declaration order ParseTable puts it in
+	if pt.Dimension >= 2 {
+		declared := make([]string, len(table.IndependentVar))
+		for i, v := range table.IndependentVar {
+			declared[i] = v.Lookup
+		}
+		if err := pt.Reorder(declared); err != nil {
+			report.Anomalies = append(report.Anomalies, "parse error: "+err.Error())
+			return report
+		}
+	}
+
+	// Break the table into row-by-column slices; a 1D table is a single
+	// column and a 3D table has one slice per table breakpoint
+	var slices []*Table2D
+	switch pt.Dimension {
+	case 1:
+		if pt.Data1D != nil {
+			data := make([][]float64, len(pt.Data1D.Values))
+			for i, v := range pt.Data1D.Values {
+				data[i] = []float64{v}
+			}
+			slices = append(slices, &Table2D{RowIndices: pt.Data1D.Indices, Data: data})
+		}
+	case 2:
+		if pt.Data2D != nil {
+			slices = append(slices, pt.Data2D)
+		}
+	case 3:
+		for _, slice := range pt.Data3D {
+			if slice != nil {
+				slices = append(slices, slice)
+			}
+		}
+	}
+	if len(slices) == 0 {
+		report.Anomalies = append(report.Anomalies, "no table data")
+		return report
+	}
+
+	report.Variables = tableVariables(pt, slices)
+	for _, v := range report.Variables {
+		if v.Count == 1 {
+			report.Anomalies = append(report.Anomalies, "single breakpoint for "+v.Name)
+		}
+	}
+
+	report.Min, report.Max = math.Inf(1), math.Inf(-1)
+	sum := 0.0
+	for _, slice := range slices {
+		for _, row := range slice.Data {
+			for _, v := range row {
+				report.Min = math.Min(report.Min, v)
+				report.Max = math.Max(report.Max, v)
+				sum += v
+				report.Points++
+			}
+		}
+	}
+	if report.Points == 0 {
+		report.Min, report.Max = 0, 0
+		report.Anomalies = append(report.Anomalies, "no table data")
+		return report
+	}
+	report.Mean = sum / float64(report.Points)
+
+	if report.Points > 1 && report.Min == report.Max {
+		report.Anomalies = append(report.Anomalies, fmt.Sprintf("constant value %g", report.Min))
+	}
+	if pt.Dimension > 1 {
+		report.Anomalies = append(report.Anomalies, zeroRows(report.Variables[0].Name, slices)...)
+	}
+
+	for _, slice := range slices {
+		for col := 0; col < tableColumns(slice); col++ {
+			changes, first := slopeSignChanges(slice, col)
+			if changes > report.SlopeSignChanges {
+				report.SlopeSignChanges = changes
+				report.FirstSlopeChange = first
+			}
+		}
+	}
+	return report
+}
+
+// mismatchedDataBlocks reports whether a table's data blocks do not fit its
+// variables. A 1D or 2D table has a single data block, and every block of a
+// 3D table has a breakpoint.
+func mismatchedDataBlocks(table *Table) bool {
+	switch len(table.IndependentVar) {
+	case 1, 2:
+		return len(table.TableData) > 1
+	case 3:
+		for _, td := range table.TableData {
+			if td.GetBreakpoint() == "" {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// tableColumns returns the number of data columns of a slice; a 1D table
+// stored as a slice has no column breakpoints but one column of data
+func tableColumns(slice *Table2D) int {
+	if len(slice.ColIndices) > 0 {
+		return len(slice.ColIndices)
+	}
+	if len(slice.Data) > 0 {
+		return len(slice.Data[0])
+	}
+	return 0
+}
+
+// tableVariables returns the breakpoints of the row, column and table
+// variables, in that order. A variable without a lookup attribute takes
+// the lookup of its position.
+func tableVariables(pt *ParsedTable, slices []*Table2D) []TableVariable {
+	lookups := []string{"row", "column", "table"}[:pt.Dimension]
+	variables := make([]TableVariable, pt.Dimension)
+	for i, lookup := range lookups {
+		variables[i].Lookup = lookup
+		if i < len(pt.IndependentVars) {
+			variables[i].Name = pt.IndependentVars[i]
+		}
+	}
+	for i, name := range pt.IndependentVars {
+		lookup := strings.ToLower(strings.TrimSpace(pt.LookupTypes[i]))
+		for j := range lookups {
+			if lookups[j] == lookup {
+				variables[j].Name = name
+			}
+		}
+	}
+
+	set := func(v *TableVariable, values []float64) {
+		if len(values) == 0 {
+			return
+		}
+		if v.Count == 0 {
+			v.Min, v.Max = values[0], values[0]
+		}
+		v.Count = max(v.Count, len(values))
+		for _, value := range values {
+			v.Min = math.Min(v.Min, value)
+			v.Max = math.Max(v.Max, value)
+		}
+	}
+
+	// Sub-tables of a 3D table may have their own row and column breakpoints
+	for _, slice := range slices {
+		set(&variables[0], slice.RowIndices)
+		if pt.Dimension > 1 {
+			set(&variables[1], slice.ColIndices)
+		}
+	}
+	if pt.Dimension == 3 {
+		breakpoints := make([]float64, len(slices))
+		for i, slice := range slices {
+			breakpoints[i] = slice.Breakpoint
+		}
+		set(&variables[2], breakpoints)
+	}
+	return variables
+}
+
+// zeroRows reports isolated all-zero rows: a zero row between two nonzero
+// rows often means a row was left unfilled. Runs of zero rows are usually a
+// deliberate dead band and are not reported.
+func zeroRows(rowVar string, slices []*Table2D) []string {
+	zero := func(row []float64) bool {
+		for _, v := range row {
+			if v != 0 {
+				return false
+			}
+		}
+		return true
+	}
+
+	var anomalies []string
+	for _, slice := range slices {
+		for i := 1; i+1 < len(slice.Data); i++ {
+			if !zero(slice.Data[i]) || zero(slice.Data[i-1]) || zero(slice.Data[i+1]) {
+				continue
+			}
+			where := fmt.Sprintf("%s=%g", rowVar, slice.RowIndices[i])
+			if len(slices) > 1 {
+				where += fmt.Sprintf(" (table breakpoint %g)", slice.Breakpoint)
+			}
+			anomalies = append(anomalies, "zero row at "+where)
+		}
+	}
+	return anomalies
+}
+
+// slopeSignChanges counts the sign changes of the slope down one column,
+// ignoring flat segments, and returns the row breakpoint of the first
+func slopeSignChanges(slice *Table2D, col int) (int, float64) {
+	changes, first := 0, 0.0
+	previous := 0.0
+	for i := 1; i < len(slice.Data); i++ {
+		if col >= len(slice.Data[i]) || col >= len(slice.Data[i-1]) {
+			break
+		}
+		slope := slice.Data[i][col] - slice.Data[i-1][col]
+		if slope == 0 {
+			continue
+		}
+		if previous != 0 && (slope > 0) != (previous > 0) {
+			if changes == 0 {
+				first = slice.RowIndices[i-1]
+			}
+			changes++
+		}
+		previous = slope
+	}
+	return changes, first
+}
+
+// checkAlphaLimits flags alpha breakpoints that do not span the
+// configuration's alpha limits
+func (report *TableReport) checkAlphaLimits(limits *AlphaLimits) {
+	if limits == nil {
+		return
+	}
+
+	// Limits default to degrees, as in JSBSim
+	low, high := limits.Min, limits.Max
+	if strings.EqualFold(strings.TrimSpace(limits.Unit), "RAD") {
+		low, high = low*RAD_TO_DEG, high*RAD_TO_DEG
+	}
+
+	for _, v := range report.Variables {
+		var scale float64
+		switch normalizePropertyName(v.Name) {
+		case "aero/alpha-deg":
+			scale = 1.0
+		case "aero/alpha-rad":
+			scale = DEG_TO_RAD
+		default:
+			continue
+		}
+
+		low, high := low*scale, high*scale
+		if v.Min > low || v.Max < high {
+			report.Anomalies = append(report.Anomalies, fmt.Sprintf(
+				"%s breakpoints [%g, %g] do not span alphalimits [%g, %g]", v.Name, v.Min, v.Max, low, high))
+		}
+	}
+}
+
+// FormatTableReports renders reports as text grouped by section and axis.
+// Within an axis, tables keep their document order.
+func FormatTableReports(reports []TableReport) string {
+	sorted := append([]TableReport(nil), reports...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Section != sorted[j].Section {
+			return sorted[i].Section < sorted[j].Section
+		}
+		return sorted[i].Axis < sorted[j].Axis
+	})
+
+	var sb strings.Builder
+	section, axis := "", ""
+	for _, r := range sorted {
+		if r.Section != section {
+			section, axis = r.Section, ""
+			sb.WriteString(fmt.Sprintf("[%s]\n", section))
+		}
+		if r.Axis != axis {
+			axis = r.Axis
+			sb.WriteString(fmt.Sprintf("  %s\n", axis))
+		}
+
+		sb.WriteString(fmt.Sprintf("    %s (%s) %dD, %d points\n", r.Function, r.Path, r.Dimension, r.Points))
+		for _, v := range r.Variables {
+			sb.WriteString(fmt.Sprintf("      %-7s %s: %d breakpoints, %g to %g\n", v.Lookup, v.Name, v.Count, v.Min, v.Max))
+		}
+		if r.Points > 0 {
+			sb.WriteString(fmt.Sprintf("      data: min %g, max %g, mean %.4g\n", r.Min, r.Max, r.Mean))
+		}
+		if r.SlopeSignChanges > 0 {
+			sb.WriteString(fmt.Sprintf("      slope changes sign %d times, first at %g\n", r.SlopeSignChanges, r.FirstSlopeChange))
+		}
+		for _, anomaly := range r.Anomalies {
+			sb.WriteString("      ! " + anomaly + "\n")
+		}
+	}
+	return sb.String()
+}
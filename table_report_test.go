@@ -0,0 +1,154 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const tableReportXML = `<?xml version="1.0"?>
+<fdm_config name="table-report" version="2.0">
+	<aerodynamics>
+		<alphalimits unit="DEG">
+			<min>-10</min>
+			<max>20</max>
+		</alphalimits>
+		<axis name="LIFT">
+			<function name="CLalpha">
+				<product>
+					<property>aero/qbar-psf</property>
+					<table>
+						<independentVar lookup="row">aero/alpha-deg</independentVar>
+						<tableData>
+							-10  -0.8
+							0     0.2
+							16    1.5
+							20    1.1
+						</tableData>
+					</table>
+				</product>
+			</function>
+			<function name="CLflap">
+				<table>
+					<independentVar lookup="row">aero/alpha-rad</independentVar>
+					<tableData>
+						-0.1  0.3
+						0.2   0.3
+					</tableData>
+				</table>
+			</function>
+		</axis>
+		<axis name="DRAG">
+			<function name="CDgap">
+				<table>
+					<independentVar lookup="column">fcs/flap-pos-deg</independentVar>
+					<independentVar lookup="row">velocities/mach</independentVar>
+					<tableData>
+						     0     40
+						0.2  0.01  0.08
+						0.4  0     0
+						0.6  0.02  0.09
+					</tableData>
+				</table>
+			</function>
+		</axis>
+		<function name="kCLge">
+			<table>
+				<independentVar>aero/h_b-mac-ft</independentVar>
+				<tableData>
+					0.5  1.1
+				</tableData>
+			</table>
+		</function>
+	</aerodynamics>
+</fdm_config>`
+
+func TestAnalyzeTables(t *testing.T) {
+	t.Run("P-51D", func(t *testing.T) {
+		reports := AnalyzeTables(loadP51DConfig(t))
+		if len(reports) < 10 {
+			t.Fatalf("Expected at least 10 tables, got %d", len(reports))
+		}
+
+		byFunction := map[string]TableReport{}
+		for _, r := range reports {
+			byFunction[r.Function] = r
+		}
+		cl := byFunction["aero/coefficient/CLalpha"]
+		assertEqual(t, cl.Axis, "LIFT")
+		assertEqual(t, cl.Dimension, 2)
+		assertEqual(t, cl.Variables[0].Name, "aero/alpha-deg")
+		assertEqual(t, cl.Variables[0].Min, -180.0)
+		assertEqual(t, cl.Variables[0].Max, 180.0)
+		if cl.SlopeSignChanges == 0 || cl.Max < 1.5 {
+			t.Errorf("Lift curve should stall with CLmax above 1.5: %+v", cl)
+		}
+
+		// The FCS table is found by its component
+		brakes := byFunction["systems/brakes/brake-scaling"]
+		assertEqual(t, brakes.Section, "flight_control")
+		assertEqual(t, brakes.Dimension, 3)
+		assertEqual(t, brakes.Variables[2].Count, 3)
+
+		// The P-51D has no alphalimits section to check against
+		for _, r := range reports {
+			for _, anomaly := range r.Anomalies {
+				if strings.Contains(anomaly, "alphalimits") {
+					t.Errorf("%s: unexpected %s", r.Function, anomaly)
+				}
+			}
+		}
+
+		text := FormatTableReports(reports)
+		if strings.Index(text, "[aerodynamics]") > strings.Index(text, "[flight_control]") {
+			t.Error("Sections should be sorted")
+		}
+		if strings.Index(text, "  DRAG\n") > strings.Index(text, "  LIFT\n") {
+			t.Error("Axes should be sorted")
+		}
+	})
+
+	t.Run("Anomalies", func(t *testing.T) {
+		config, err := ParseJSBSimConfig(strings.NewReader(tableReportXML))
+		if err != nil {
+			t.Fatalf("Failed to parse XML: %v", err)
+		}
+		reports := AnalyzeTables(config)
+		assertEqual(t, len(reports), 4)
+
+		// Sorted by axis, standalone functions last
+		text := FormatTableReports(reports)
+		if !(strings.Index(text, "CDgap") < strings.Index(text, "CLalpha") &&
+			strings.Index(text, "CLflap") < strings.Index(text, "kCLge")) {
+			t.Errorf("Unexpected order:\n%s", text)
+		}
+
+		byFunction := map[string]TableReport{}
+		anomalies := map[string][]string{}
+		for _, r := range reports {
+			byFunction[r.Function] = r
+			anomalies[r.Function] = r.Anomalies
+		}
+
+		cl := byFunction["CLalpha"]
+		assertEqual(t, cl.Path, "product/table")
+		assertEqual(t, cl.SlopeSignChanges, 1)
+		assertEqual(t, cl.FirstSlopeChange, 16.0)
+		assertApproxEqual(t, cl.Mean, 0.5, 1e-12)
+		assertEqual(t, len(anomalies["CLalpha"]), 0)
+
+		// 0.2 rad is about 11.5 deg, short of the 20 deg limit
+		assertEqual(t, len(anomalies["CLflap"]), 2)
+		assertEqual(t, anomalies["CLflap"][0], "constant value 0.3")
+		if !strings.Contains(anomalies["CLflap"][1], "do not span alphalimits") {
+			t.Errorf("Expected an alphalimits anomaly, got %q", anomalies["CLflap"][1])
+		}
+
+		// Variables are ordered by lookup, not document order
+		gap := byFunction["CDgap"]
+		assertEqual(t, gap.Variables[0].Name, "velocities/mach")
+		assertEqual(t, gap.Variables[1].Name, "fcs/flap-pos-deg")
+		assertEqual(t, anomalies["CDgap"], []string{"zero row at velocities/mach=0.4"})
+
+		assertEqual(t, anomalies["kCLge"], []string{"single breakpoint for aero/h_b-mac-ft"})
+	})
+}
@@ -0,0 +1,479 @@
+// Table Resolution
+// Where tables are too coarse for linear interpolation: the curvature of
+// the data between breakpoints, the interpolation error it implies, and
+// the breakpoints that would bring the error under a threshold
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// DefaultResolutionTolerance is the interpolation error, as a fraction of a
+// table's value range, above which AnalyzeConfigResolution flags an
+// interval
+const DefaultResolutionTolerance = 0.01
+
+// ResolutionInterval is an interval between adjacent breakpoints of one
+// variable whose linear interpolation error may exceed the threshold
+type ResolutionInterval struct {
+	Axis         int // Variable, in declaration order
+	Variable     string
+	Lower, Upper float64 // Bounding breakpoints
+
+	// Curvature is the largest magnitude of the second derivative along the
+	// variable estimated at the interval's ends, over every line of the
+	// table crossing it; ErrorBound is h²·Curvature/8 for its width h
+	Curvature  float64
+	ErrorBound float64
+
+	Suggested []float64 // Breakpoints splitting it evenly to within the threshold
+}
+
+// TableResolution is the resolution analysis of one table
+type TableResolution struct {
+	Path      string // Set by AnalyzeConfigResolution
+	Name      string
+	Threshold float64
+	Range     float64 // Span of the table's values
+	MaxError  float64 // Largest error bound of any interval, flagged or not
+
+	Intervals []ResolutionInterval // Flagged intervals, largest error first
+}
+
+// RelativeError returns MaxError as a fraction of the value range
+func (r *TableResolution) RelativeError() float64 {
+	if r.Range == 0 {
+		return 0
+	}
+	return r.MaxError / r.Range
+}
+
+// Suggestions returns the breakpoints suggested for a variable, in order
+func (r *TableResolution) Suggestions(axis int) []float64 {
+	var points []float64
+	for _, interval := range r.Intervals {
+		if interval.Axis == axis {
+			points = append(points, interval.Suggested...)
+		}
+	}
+	sort.Float64s(points)
+	return slices.Compact(points)
+}
+
+// tableLine is the breakpoints of one variable and the values along them
+// with the other variables held at breakpoints
+type tableLine struct {
+	x, y []float64
+}
+
+// tableLines returns every line of a table along a variable. Lines across
+// the slices of a 3D table need the slices to share their breakpoints, and
+// there are none when they do not.
+func tableLines(pt *ParsedTable, axis int) []tableLine {
+	var lines []tableLine
+	along2D := func(t *Table2D) {
+		if t == nil || checkRectangular(t) != nil {
+			return
+		}
+		if axis == 0 {
+			for j := range t.ColIndices {
+				line := tableLine{x: t.RowIndices, y: make([]float64, len(t.RowIndices))}
+				for i := range t.RowIndices {
+					line.y[i] = t.Data[i][j]
+				}
+				lines = append(lines, line)
+			}
+			return
+		}
+		for i := range t.RowIndices {
+			lines = append(lines, tableLine{x: t.ColIndices, y: t.Data[i]})
+		}
+	}
+
+	switch {
+	case pt.Dimension == 1 && pt.Data1D != nil:
+		lines = append(lines, tableLine{x: pt.Data1D.Indices, y: pt.Data1D.Values})
+	case pt.Dimension == 2:
+		along2D(pt.Data2D)
+	case pt.Dimension == 3 && axis < 2:
+		for _, slice := range pt.Data3D {
+			along2D(slice)
+		}
+	case pt.Dimension == 3:
+		if !sharedSliceBreakpoints(pt.Data3D) {
+			return nil
+		}
+		x := make([]float64, len(pt.Data3D))
+		for k, slice := range pt.Data3D {
+			x[k] = slice.Breakpoint
+		}
+		first := pt.Data3D[0]
+		for i := range first.RowIndices {
+			for j := range first.ColIndices {
+				line := tableLine{x: x, y: make([]float64, len(x))}
+				for k, slice := range pt.Data3D {
+					line.y[k] = slice.Data[i][j]
+				}
+				lines = append(lines, line)
+			}
+		}
+	}
+	return lines
+}
+
+// sharedSliceBreakpoints reports whether the slices of a 3D table are
+// rectangular with the same row and column breakpoints
+func sharedSliceBreakpoints(tables []*Table2D) bool {
+	if len(tables) == 0 {
+		return false
+	}
+	for _, slice := range tables {
+		if checkRectangular(slice) != nil ||
+			!slices.Equal(slice.RowIndices, tables[0].RowIndices) || !slices.Equal(slice.ColIndices, tables[0].ColIndices) {
+			return false
+		}
+	}
+	return true
+}
+
+// tableValueRange returns the span of a table's values
+func tableValueRange(pt *ParsedTable) float64 {
+	min, max := math.Inf(1), math.Inf(-1)
+	for _, line := range tableLines(pt, 0) {
+		for _, v := range line.y {
+			min, max = math.Min(min, v), math.Max(max, v)
+		}
+	}
+	if max < min {
+		return 0
+	}
+	return max - min
+}
+
+// secondDifferences returns the magnitude of the second derivative
+// estimated at each interior breakpoint of a line from the divided
+// differences either side; the ends are zero
+func secondDifferences(x, y []float64) []float64 {
+	curvature := make([]float64, len(x))
+	for i := 1; i+1 < len(x); i++ {
+		h0, h1 := x[i]-x[i-1], x[i+1]-x[i]
+		if h0 <= 0 || h1 <= 0 {
+			continue
+		}
+		curvature[i] = math.Abs(2 * ((y[i+1]-y[i])/h1 - (y[i]-y[i-1])/h0) / (h0 + h1))
+	}
+	return curvature
+}
+
+// AnalyzeTableResolution flags the intervals of a table whose linear
+// interpolation error, estimated as h²·|f″|/8 from the second differences
+// of the data along each variable, exceeds threshold, in the table's
+// units. Each flagged interval is given the evenly spaced breakpoints that
+// bring its estimate within the threshold. Lines with fewer than three
+// breakpoints have no curvature estimate.
+func AnalyzeTableResolution(pt *ParsedTable, threshold float64) *TableResolution {
+	r := &TableResolution{Name: pt.Name, Threshold: threshold, Range: tableValueRange(pt)}
+
+	type key struct {
+		axis         int
+		lower, upper float64
+	}
+	curvatures := make(map[key]float64)
+	var order []key
+	for axis := 0; axis < pt.Dimension; axis++ {
+		for _, line := range tableLines(pt, axis) {
+			curvature := secondDifferences(line.x, line.y)
+			for i := 0; i+1 < len(line.x); i++ {
+				k := key{axis, line.x[i], line.x[i+1]}
+				c := math.Max(curvature[i], curvature[i+1])
+				if previous, ok := curvatures[k]; !ok {
+					order = append(order, k)
+				} else {
+					c = math.Max(c, previous)
+				}
+				curvatures[k] = c
+			}
+		}
+	}
+	for _, k := range order {
+		h, c := k.upper-k.lower, curvatures[k]
+		bound := h * h * c / 8
+		r.MaxError = math.Max(r.MaxError, bound)
+		if bound <= threshold || threshold <= 0 {
+			continue
+		}
+		interval := ResolutionInterval{
+			Axis: k.axis, Lower: k.lower, Upper: k.upper,
+			Curvature: c, ErrorBound: bound,
+		}
+		if k.axis < len(pt.IndependentVars) {
+			interval.Variable = pt.IndependentVars[k.axis]
+		}
+		n := int(math.Ceil(h * math.Sqrt(c/(8*threshold))))
+		for i := 1; i < n; i++ {
+			interval.Suggested = append(interval.Suggested, k.lower+h*float64(i)/float64(n))
+		}
+		r.Intervals = append(r.Intervals, interval)
+	}
+	sort.SliceStable(r.Intervals, func(i, j int) bool {
+		return r.Intervals[i].ErrorBound > r.Intervals[j].ErrorBound
+	})
+	return r
+}
+
+// AnalyzeConfigResolution analyzes every table VisitTables reaches, each
+// against tolerance times its value range (DefaultResolutionTolerance when
+// not positive), ranked by error relative to the range, worst first.
+// Tables that do not parse are skipped.
+func AnalyzeConfigResolution(config *JSBSimConfig, tolerance float64) []*TableResolution {
+	if tolerance <= 0 {
+		tolerance = DefaultResolutionTolerance
+	}
+	var reports []*TableResolution
+	config.VisitTables(func(path string, t *Table) error {
+		pt, err := cachedParseTable(t)
+		if err != nil {
+			return nil
+		}
+		r := AnalyzeTableResolution(pt, tolerance*tableValueRange(pt))
+		r.Path = path
+		reports = append(reports, r)
+		return nil
+	})
+	sort.SliceStable(reports, func(i, j int) bool {
+		return reports[i].RelativeError() > reports[j].RelativeError()
+	})
+	return reports
+}
+
+// FormatResolutionReports renders reports, in their order, with each
+// flagged interval and its suggested breakpoints. Tables without flagged
+// intervals are summarized in a line.
+func FormatResolutionReports(reports []*TableResolution) string {
+	var sb strings.Builder
+	resolved := 0
+	for _, r := range reports {
+		if len(r.Intervals) == 0 {
+			resolved++
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("%s: max error %.4g (%.1f%% of range), %d intervals above %.4g\n",
+			r.Path, r.MaxError, 100*r.RelativeError(), len(r.Intervals), r.Threshold))
+		for _, interval := range r.Intervals {
+			sb.WriteString(fmt.Sprintf("    %s [%g, %g]: error %.4g, add %s\n", interval.Variable,
+				interval.Lower, interval.Upper, interval.ErrorBound, formatBreakpoints(interval.Suggested)))
+		}
+	}
+	sb.WriteString(fmt.Sprintf("%d of %d tables within tolerance\n", resolved, len(reports)))
+	return sb.String()
+}
+
+func formatBreakpoints(points []float64) string {
+	formatted := make([]string, len(points))
+	for i, p := range points {
+		formatted[i] = fmt.Sprintf("%.4g", p)
+	}
+	return strings.Join(formatted, " ")
+}
+
+// ApplyRefinement inserts the breakpoints a resolution analysis suggested
+// into a table, along each variable in turn, with values interpolated by
+// monotone cubic (PCHIP) along that variable, so the existing data is kept
+// and no new extrema are made. The refined data is written back into the
+// table's XML data, in its declared layout, and replaces its parsed form,
+// which is returned. New slices of a 3D table need its slices to share
+// their breakpoints.
+func ApplyRefinement(t *Table, resolution *TableResolution) (*ParsedTable, error) {
+	pt, err := ParseTable(t)
+	if err != nil {
+		return nil, err
+	}
+	for axis := 0; axis < pt.Dimension; axis++ {
+		points := resolution.Suggestions(axis)
+		if len(points) == 0 {
+			continue
+		}
+		if err := refineAxis(pt, axis, points); err != nil {
+			return nil, fmt.Errorf("table %s: %w", t.Name, err)
+		}
+	}
+	if err := writeTableData(t, pt); err != nil {
+		return nil, err
+	}
+	return pt, nil
+}
+
+// refineAxis inserts points along a variable of a parsed table
+func refineAxis(pt *ParsedTable, axis int, points []float64) error {
+	switch {
+	case pt.Dimension == 1:
+		pt.Data1D.Indices, pt.Data1D.Values = refineLine(pt.Data1D.Indices, pt.Data1D.Values, points)
+	case pt.Dimension == 2:
+		return refine2D(pt.Data2D, axis, points)
+	case axis < 2:
+		for _, slice := range pt.Data3D {
+			if err := refine2D(slice, axis, points); err != nil {
+				return err
+			}
+		}
+	default:
+		if !sharedSliceBreakpoints(pt.Data3D) {
+			return fmt.Errorf("slices with differing breakpoints cannot be interpolated between")
+		}
+		x := make([]float64, len(pt.Data3D))
+		for k, slice := range pt.Data3D {
+			x[k] = slice.Breakpoint
+		}
+		refined, _ := refineLine(x, x, points)
+		slicesOut := make([]*Table2D, len(refined))
+		first := pt.Data3D[0]
+		for k, breakpoint := range refined {
+			slicesOut[k] = &Table2D{
+				Breakpoint: breakpoint,
+				RowIndices: slices.Clone(first.RowIndices),
+				ColIndices: slices.Clone(first.ColIndices),
+				Data:       make([][]float64, len(first.RowIndices)),
+			}
+			for i := range first.RowIndices {
+				slicesOut[k].Data[i] = make([]float64, len(first.ColIndices))
+			}
+		}
+		for i := range first.RowIndices {
+			for j := range first.ColIndices {
+				y := make([]float64, len(x))
+				for k, slice := range pt.Data3D {
+					y[k] = slice.Data[i][j]
+				}
+				_, values := refineLine(x, y, points)
+				for k := range refined {
+					slicesOut[k].Data[i][j] = values[k]
+				}
+			}
+		}
+		pt.Data3D = slicesOut
+	}
+	return nil
+}
+
+// refine2D inserts points along the rows (axis 0) or columns of a table
+func refine2D(t *Table2D, axis int, points []float64) error {
+	if err := checkRectangular(t); err != nil {
+		return err
+	}
+	if axis == 1 {
+		for i, row := range t.Data {
+			_, t.Data[i] = refineLine(t.ColIndices, row, points)
+		}
+		t.ColIndices, _ = refineLine(t.ColIndices, t.ColIndices, points)
+		return nil
+	}
+
+	rows, _ := refineLine(t.RowIndices, t.RowIndices, points)
+	data := make([][]float64, len(rows))
+	for i := range data {
+		data[i] = make([]float64, len(t.ColIndices))
+	}
+	for j := range t.ColIndices {
+		y := make([]float64, len(t.RowIndices))
+		for i := range t.RowIndices {
+			y[i] = t.Data[i][j]
+		}
+		_, values := refineLine(t.RowIndices, y, points)
+		for i := range rows {
+			data[i][j] = values[i]
+		}
+	}
+	t.RowIndices, t.Data = rows, data
+	return nil
+}
+
+// refineLine returns a line's breakpoints with the points inside its range
+// added, and its values with those at the new points by PCHIP
+func refineLine(x, y, points []float64) ([]float64, []float64) {
+	refinedX := slices.Clone(x)
+	for _, p := range points {
+		if len(x) > 0 && p > x[0] && p < x[len(x)-1] && !slices.Contains(x, p) {
+			refinedX = append(refinedX, p)
+		}
+	}
+	sort.Float64s(refinedX)
+	refinedY := make([]float64, len(refinedX))
+	slopes := pchipSlopes(x, y)
+	for i, xi := range refinedX {
+		refinedY[i] = pchip(x, y, slopes, xi)
+	}
+	return refinedX, refinedY
+}
+
+// pchipSlopes returns the derivatives at the breakpoints of the monotone
+// piecewise cubic Hermite interpolant (Fritsch–Carlson, with the weighted
+// harmonic mean for uneven spacing and the shape-preserving end formula)
+func pchipSlopes(x, y []float64) []float64 {
+	n := len(x)
+	d := make([]float64, n)
+	if n < 2 {
+		return d
+	}
+	h := make([]float64, n-1)
+	delta := make([]float64, n-1)
+	for k := 0; k < n-1; k++ {
+		h[k] = x[k+1] - x[k]
+		delta[k] = (y[k+1] - y[k]) / h[k]
+	}
+	if n == 2 {
+		d[0], d[1] = delta[0], delta[0]
+		return d
+	}
+
+	for k := 1; k < n-1; k++ {
+		if delta[k-1]*delta[k] <= 0 {
+			continue
+		}
+		w1, w2 := 2*h[k]+h[k-1], h[k]+2*h[k-1]
+		d[k] = (w1 + w2) / (w1/delta[k-1] + w2/delta[k])
+	}
+	end := func(h0, h1, delta0, delta1 float64) float64 {
+		slope := ((2*h0+h1)*delta0 - h0*delta1) / (h0 + h1)
+		switch {
+		case math.Signbit(slope) != math.Signbit(delta0) || delta0 == 0:
+			return 0
+		case math.Signbit(delta0) != math.Signbit(delta1) && math.Abs(slope) > math.Abs(3*delta0):
+			return 3 * delta0
+		}
+		return slope
+	}
+	d[0] = end(h[0], h[1], delta[0], delta[1])
+	d[n-1] = end(h[n-2], h[n-3], delta[n-2], delta[n-3])
+	return d
+}
+
+// pchip evaluates the Hermite interpolant with slopes at xi, holding the
+// end values outside the breakpoints as the tables do
+func pchip(x, y, slopes []float64, xi float64) float64 {
+	n := len(x)
+	switch {
+	case n == 0:
+		return 0
+	case xi <= x[0]:
+		return y[0]
+	case xi >= x[n-1]:
+		return y[n-1]
+	}
+	k := sort.SearchFloat64s(x, xi)
+	if x[k] == xi {
+		return y[k]
+	}
+	k--
+	h := x[k+1] - x[k]
+	s := (xi - x[k]) / h
+	h00 := (1 + 2*s) * (1 - s) * (1 - s)
+	h10 := s * (1 - s) * (1 - s)
+	h01 := s * s * (3 - 2*s)
+	h11 := s * s * (s - 1)
+	return h00*y[k] + h10*h*slopes[k] + h01*y[k+1] + h11*h*slopes[k+1]
+}
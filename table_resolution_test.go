@@ -0,0 +1,186 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// sampledTable returns a 1D table of f at the breakpoints
+func sampledTable(name string, f func(x float64) float64, breakpoints ...float64) *Table {
+	data := &Table1D{Indices: breakpoints, Values: make([]float64, len(breakpoints))}
+	for i, x := range breakpoints {
+		data.Values[i] = f(x)
+	}
+	return &Table{
+		Name:           name,
+		IndependentVar: []*IndependentVar{{Value: "x"}},
+		TableData:      []*TableData{{Data: format1DTableData(data)}},
+	}
+}
+
+// maxInterpolationError returns the largest difference between a table and
+// f over [lower, upper]
+func maxInterpolationError(t *testing.T, pt *ParsedTable, f func(x ...float64) float64, lower, upper []float64) float64 {
+	t.Helper()
+	const samples = 200
+	worst := 0.0
+	point := make([]float64, len(lower))
+	var sweep func(axis int)
+	sweep = func(axis int) {
+		if axis == len(lower) {
+			got, err := InterpolateTable(pt, point...)
+			if err != nil {
+				t.Fatalf("InterpolateTable: %v", err)
+			}
+			worst = math.Max(worst, math.Abs(got-f(point...)))
+			return
+		}
+		for i := 0; i <= samples; i++ {
+			point[axis] = lower[axis] + (upper[axis]-lower[axis])*float64(i)/samples
+			sweep(axis + 1)
+		}
+	}
+	sweep(0)
+	return worst
+}
+
+func TestTableResolution(t *testing.T) {
+	sine := func(x ...float64) float64 { return math.Sin(x[0]) }
+
+	t.Run("Error Bound", func(t *testing.T) {
+		table := sampledTable("sine", func(x float64) float64 { return math.Sin(x) }, 0, math.Pi/4, math.Pi/2, 3*math.Pi/4, math.Pi)
+		pt, err := ParseTable(table)
+		if err != nil {
+			t.Fatalf("ParseTable: %v", err)
+		}
+		r := AnalyzeTableResolution(pt, 0.01)
+		if len(r.Intervals) != 4 {
+			t.Fatalf("Every interval of a coarse sine should be flagged, got %d", len(r.Intervals))
+		}
+
+		// Each interval's estimate bounds its true error to within the
+		// second differences' underestimate of the curvature, and its
+		// suggestions bring the bound under the threshold
+		for _, interval := range r.Intervals {
+			trueErr := maxInterpolationError(t, pt, sine, []float64{interval.Lower}, []float64{interval.Upper})
+			if trueErr > 1.1*interval.ErrorBound || trueErr < 0.3*interval.ErrorBound {
+				t.Errorf("[%.3f, %.3f]: bound %.4f does not estimate the true error %.4f", interval.Lower, interval.Upper, interval.ErrorBound, trueErr)
+			}
+			h := (interval.Upper - interval.Lower) / float64(len(interval.Suggested)+1)
+			if h*h*interval.Curvature/8 > r.Threshold {
+				t.Errorf("[%.3f, %.3f]: %d suggestions leave the bound above the threshold", interval.Lower, interval.Upper, len(interval.Suggested))
+			}
+		}
+		assertApproxEqual(t, r.MaxError, r.Intervals[0].ErrorBound, 1e-12)
+		assertApproxEqual(t, r.Range, 1, 1e-12)
+
+		// A tolerant threshold flags nothing
+		assertEqual(t, len(AnalyzeTableResolution(pt, 0.1).Intervals), 0)
+	})
+
+	t.Run("Refinement 1D", func(t *testing.T) {
+		var breakpoints []float64
+		for i := 0; i <= 8; i++ {
+			breakpoints = append(breakpoints, math.Pi*float64(i)/8)
+		}
+		table := sampledTable("sine", func(x float64) float64 { return math.Sin(x) }, breakpoints...)
+		pt, err := ParseTable(table)
+		if err != nil {
+			t.Fatalf("ParseTable: %v", err)
+		}
+		before := maxInterpolationError(t, pt, sine, []float64{0}, []float64{math.Pi})
+
+		r := AnalyzeTableResolution(pt, 0.002)
+		refined, err := ApplyRefinement(table, r)
+		if err != nil {
+			t.Fatalf("ApplyRefinement: %v", err)
+		}
+		// The new values are only as good as PCHIP through the original
+		// samples, so the error falls toward the threshold but not to it
+		after := maxInterpolationError(t, refined, sine, []float64{0}, []float64{math.Pi})
+		if after >= before/2 {
+			t.Errorf("Refinement should cut the error from %.4f, got %.4f", before, after)
+		}
+
+		// The original breakpoints keep their values, and the rewritten data
+		// parses to the refined table
+		for i, x := range pt.Data1D.Indices {
+			got, _ := InterpolateTable(refined, x)
+			assertApproxEqual(t, got, pt.Data1D.Values[i], 1e-15)
+		}
+		reparsed, err := ParseTable(table)
+		if err != nil {
+			t.Fatalf("ParseTable of the refined data: %v", err)
+		}
+		assertEqual(t, reparsed.Data1D.Indices, refined.Data1D.Indices)
+		assertEqual(t, reparsed.Data1D.Values, refined.Data1D.Values)
+		cached, _ := cachedParseTable(table)
+		assertEqual(t, cached, refined)
+	})
+
+	t.Run("Refinement 2D", func(t *testing.T) {
+		// Declared column major, so the data is written back transposed
+		f := func(x ...float64) float64 { return math.Exp(-x[0]) * x[1] * x[1] }
+		xs, ys := []float64{0, 1, 2, 3}, []float64{0, 1, 2}
+		data := &Table2D{RowIndices: ys, ColIndices: xs, Data: make([][]float64, len(ys))}
+		for i, y := range ys {
+			for _, x := range xs {
+				data.Data[i] = append(data.Data[i], f(x, y))
+			}
+		}
+		table := &Table{
+			Name: "decay",
+			IndependentVar: []*IndependentVar{
+				{Lookup: "column", Value: "x"},
+				{Lookup: "row", Value: "y"},
+			},
+			TableData: []*TableData{{Data: format2DTableData(data)}},
+		}
+		pt, err := ParseTable(table)
+		if err != nil {
+			t.Fatalf("ParseTable: %v", err)
+		}
+		lower, upper := []float64{0, 0}, []float64{3, 2}
+		before := maxInterpolationError(t, pt, f, lower, upper)
+
+		r := AnalyzeTableResolution(pt, 0.02)
+		if len(r.Suggestions(0)) == 0 || len(r.Suggestions(1)) == 0 {
+			t.Fatalf("Both variables should be refined, got %v and %v", r.Suggestions(0), r.Suggestions(1))
+		}
+		assertEqual(t, r.Intervals[0].Variable, "y")
+		refined, err := ApplyRefinement(table, r)
+		if err != nil {
+			t.Fatalf("ApplyRefinement: %v", err)
+		}
+		after := maxInterpolationError(t, refined, f, lower, upper)
+		if after >= before/2 {
+			t.Errorf("Refinement should cut the error from %.4f, got %.4f", before, after)
+		}
+
+		reparsed, err := ParseTable(table)
+		if err != nil {
+			t.Fatalf("ParseTable of the refined data: %v", err)
+		}
+		assertEqual(t, reparsed.Data2D.RowIndices, refined.Data2D.RowIndices)
+		assertEqual(t, reparsed.Data2D.ColIndices, refined.Data2D.ColIndices)
+		assertEqual(t, reparsed.Data2D.Data, refined.Data2D.Data)
+	})
+
+	t.Run("Config Report", func(t *testing.T) {
+		config := loadP51DConfig(t)
+		reports := AnalyzeConfigResolution(config, 0)
+		if len(reports) == 0 {
+			t.Fatal("The P-51D's tables should be analyzed")
+		}
+		for i := 1; i < len(reports); i++ {
+			if reports[i].RelativeError() > reports[i-1].RelativeError() {
+				t.Fatalf("Reports should be ranked worst first: %s after %s", reports[i].Path, reports[i-1].Path)
+			}
+		}
+		out := FormatResolutionReports(reports)
+		if !strings.Contains(out, reports[0].Path) || !strings.Contains(out, "tables within tolerance") {
+			t.Errorf("Report should lead with %s and summarize:\n%s", reports[0].Path, out)
+		}
+	})
+}
@@ -0,0 +1,236 @@
+// Takeoff Scenario
+// An automatic takeoff from brake release to the climb-out: a full power
+// ground roll held straight on the rudder, rotation at a set speed, the gear
+// raised once safely airborne, and the distances and times of the takeoff
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// TakeoffScreenHeight is the height a takeoff distance is measured to, 50 ft
+const TakeoffScreenHeight = 50 * FT_TO_M
+
+// TakeoffScenario flies an automatic takeoff. The pilot holds full power and
+// the heading at brake release, rotates to RotationPitch at RotationSpeed and
+// holds that attitude through the climb-out, and selects the gear up once
+// the aircraft is climbing above GearUpHeight and GearUpSpeed. Heights are
+// of the CG above its height at brake release.
+type TakeoffScenario struct {
+	RotationSpeed float64 // True airspeed (m/s)
+	RotationPitch float64 // Pitch attitude held from rotation (rad)
+	Flaps         float64 // Flap command, 0 to 1
+	GearUpHeight  float64 // m
+	GearUpSpeed   float64 // True airspeed (m/s)
+	TargetHeight  float64 // Height that ends the climb-out (m)
+
+	Dt      float64 // s
+	MaxTime float64 // s
+
+	// Elevator per radian of pitch error and per rad/s of pitch rate, and
+	// rudder per radian of heading error and per rad/s of yaw rate
+	PitchGain, PitchDamping float64
+	HeadingGain, YawDamping float64
+}
+
+// NewTakeoffScenario creates a takeoff with P-51D speeds: rotation at
+// 45 m/s (100 mph) to 10° nose up, the gear up above 10 m and 50 m/s, and a
+// climb to 150 m. The step is short for the stiff struts of the gear.
+func NewTakeoffScenario() *TakeoffScenario {
+	return &TakeoffScenario{
+		RotationSpeed: 45,
+		RotationPitch: 10 * DEG_TO_RAD,
+		GearUpHeight:  10,
+		GearUpSpeed:   50,
+		TargetHeight:  150,
+		Dt:            0.002,
+		MaxTime:       300,
+		PitchGain:     2,
+		PitchDamping:  0.5,
+		HeadingGain:   2,
+		YawDamping:    1,
+	}
+}
+
+// TakeoffReport describes one takeoff, from brake release
+type TakeoffReport struct {
+	// Rotation, when the airspeed first reached the rotation speed
+	Rotated      bool
+	RotationTime float64 // s
+
+	// Liftoff, at the first state without weight on wheels
+	Lifted       bool
+	LiftoffTime  float64 // s
+	LiftoffSpeed float64 // True airspeed (m/s)
+	GroundRoll   float64 // Over the ground from brake release (m)
+
+	// Clearing the 50 ft screen height
+	Cleared          bool
+	TimeTo50ft       float64 // s
+	DistanceTo50ft   float64 // Over the ground from brake release (m)
+	InitialClimbRate float64 // Rate of climb at 50 ft (m/s)
+
+	// GearUpTime is when the gear was selected up (s); zero if it was not
+	GearUpTime float64
+
+	// Termination is how the run ended: "takeoff-complete" at the target
+	// height, or a time limit or failed step
+	Termination *TerminationReport
+}
+
+// Completed reports whether the climb-out reached the target height
+func (r *TakeoffReport) Completed() bool {
+	return r.Termination != nil && r.Termination.Condition == "takeoff-complete"
+}
+
+// String renders the report in SI units, in the style of the other
+// analysis reports
+func (r *TakeoffReport) String() string {
+	return r.Format(SIUnits())
+}
+
+// Format writes the report in the given units
+func (r *TakeoffReport) Format(units UnitPreferences) string {
+	var sb strings.Builder
+	sb.WriteString("Takeoff Report:\n")
+	if r.Rotated {
+		sb.WriteString(fmt.Sprintf("  Rotation:        t=%.2f s\n", r.RotationTime))
+	} else {
+		sb.WriteString("  Rotation:        not reached\n")
+	}
+	if r.Lifted {
+		sb.WriteString(fmt.Sprintf("  Liftoff:         t=%.2f s at %s TAS\n", r.LiftoffTime,
+			units.Format(QuantitySpeed, r.LiftoffSpeed, 1)))
+		sb.WriteString(fmt.Sprintf("  Ground roll:     %s\n", units.Format(QuantityDistance, r.GroundRoll, 0)))
+	} else {
+		sb.WriteString("  Liftoff:         none\n")
+	}
+	if r.Cleared {
+		sb.WriteString(fmt.Sprintf("  50 ft:           t=%.2f s, %s from brake release, climbing %s\n",
+			r.TimeTo50ft, units.Format(QuantityDistance, r.DistanceTo50ft, 0),
+			units.Format(QuantityVerticalSpeed, r.InitialClimbRate, 1)))
+	} else {
+		sb.WriteString("  50 ft:           not reached\n")
+	}
+	if r.GearUpTime > 0 {
+		sb.WriteString(fmt.Sprintf("  Gear up:         t=%.2f s\n", r.GearUpTime))
+	} else {
+		sb.WriteString("  Gear up:         not selected\n")
+	}
+	if t := r.Termination; t != nil {
+		sb.WriteString(fmt.Sprintf("  Ended:           %s at t=%.2f s", t.Condition, t.Time))
+		if t.Err != nil {
+			sb.WriteString(fmt.Sprintf(": %v", t.Err))
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// NewRunwayState returns an aircraft at rest on a runway at elevation (m),
+// heading north with the gear down. With gear it sits on the foremost and
+// rearmost wheels, the struts pressed 10 cm in so the roll starts settled;
+// without, it sits level on the ground.
+func NewRunwayState(gear *LandingGear, elevation float64) *AircraftState {
+	state := NewAircraftState()
+	state.Velocity = Vector3{}
+	state.Controls.Gear = true
+	state.Gear.Down = true
+	state.Gear.Transition = 1
+	state.Gear.OnGround = true
+	state.Gear.GroundHeight = elevation
+	state.Altitude = elevation
+
+	var front, rear *GearUnit
+	if gear != nil {
+		for i := range gear.Units {
+			unit := &gear.Units[i]
+			if !strings.EqualFold(unit.Type, "BOGEY") {
+				continue
+			}
+			if front == nil || unit.Location.X > front.Location.X {
+				front = unit
+			}
+			if rear == nil || unit.Location.X < rear.Location.X {
+				rear = unit
+			}
+		}
+	}
+	if front != nil && front != rear {
+		pitch := math.Atan2(front.Location.Z-rear.Location.Z, front.Location.X-rear.Location.X)
+		state.Orientation = NewQuaternionFromEuler(0, pitch, 0)
+		state.Altitude = elevation + state.Orientation.RotateVector(front.Location).Z - 0.1
+	}
+	state.Position.Z = -state.Altitude
+	state.UpdateFlightConditions()
+	return state
+}
+
+// Run flies the takeoff on engine from initial, usually a NewRunwayState.
+// Engines with gear need their terrain set to the runway.
+func (s *TakeoffScenario) Run(engine MonteCarloEngine, initial *AircraftState) *TakeoffReport {
+	report := &TakeoffReport{}
+	start := initial.Position
+	startAltitude, heading := initial.Altitude, initial.Yaw
+	height := func(state *AircraftState) float64 { return state.Altitude - startAltitude }
+	distance := func(state *AircraftState) float64 {
+		return math.Hypot(state.Position.X-start.X, state.Position.Y-start.Y)
+	}
+	clamp := func(v float64) float64 { return math.Max(-1, math.Min(1, v)) }
+
+	observe := func(state *AircraftState) {
+		if !report.Lifted && state.Time > initial.Time && !weightOnWheels(state) {
+			report.Lifted = true
+			report.LiftoffTime = state.Time
+			report.LiftoffSpeed = state.TrueAirspeed
+			report.GroundRoll = distance(state)
+		}
+		if report.Lifted && !report.Cleared && height(state) >= TakeoffScreenHeight {
+			report.Cleared = true
+			report.TimeTo50ft = state.Time
+			report.DistanceTo50ft = distance(state)
+			report.InitialClimbRate = -state.GroundVelocity().Z
+		}
+	}
+
+	policy := NewTerminationPolicy()
+	policy.Add("takeoff-complete", TerminationComplete, func(state *AircraftState) bool {
+		return height(state) >= s.TargetHeight
+	})
+	policy.Conditions = append(policy.Conditions, MaxSimTime(s.MaxTime))
+
+	runner := &ScenarioRunner{Engine: engine, Dt: s.Dt, Policy: policy}
+	runner.Pilot = func(state *AircraftState) {
+		observe(state)
+
+		// Set directly, as SetControlInputs would snap the gear to its
+		// command rather than let the engine move it
+		c := state.Controls
+		c.Throttle, c.Mixture, c.Propeller = 1, 1, 1
+		c.Flaps = s.Flaps
+		c.Brake, c.BrakeLeft, c.BrakeRight = 0, 0, 0
+
+		// Positive rudder yaws the nose left, positive elevator pitches it down
+		c.Rudder = clamp(s.HeadingGain*math.Remainder(state.Yaw-heading, 2*math.Pi) + s.YawDamping*state.AngularRate.Z)
+		if !report.Rotated && state.TrueAirspeed >= s.RotationSpeed {
+			report.Rotated = true
+			report.RotationTime = state.Time
+		}
+		if report.Rotated {
+			c.Elevator = clamp(s.PitchGain*(state.Pitch-s.RotationPitch) + s.PitchDamping*state.AngularRate.Y)
+		}
+
+		if c.Gear && report.Lifted && height(state) >= s.GearUpHeight &&
+			state.TrueAirspeed >= s.GearUpSpeed && -state.GroundVelocity().Z > 0 {
+			c.Gear = false
+			report.GearUpTime = state.Time
+		}
+		state.Controls = c
+	}
+
+	report.Termination = runner.Run(initial)
+	observe(report.Termination.Final)
+	return report
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// pointMassTakeoff flies the simplified model's forces as a point mass in
+// the vertical plane, with a conventional angle of attack from the pitch
+// attitude and flight path and the pitch attitude moving at PitchRate per
+// unit of elevator. The six-degree-of-freedom models cannot raise the tail
+// against the negative lift of their alpha at the three-point attitude, so
+// the takeoff logic is checked on this instead.
+type pointMassTakeoff struct {
+	*SimplifiedFlightDynamicsEngine
+	Friction  float64 // Rolling friction coefficient
+	PitchRate float64 // rad/s at full elevator
+}
+
+func newPointMassTakeoff() *pointMassTakeoff {
+	return &pointMassTakeoff{
+		SimplifiedFlightDynamicsEngine: NewSimplifiedFlightDynamicsEngine(NewEulerIntegrator()),
+		Friction:                       0.02,
+		PitchRate:                      0.5,
+	}
+}
+
+func (e *pointMassTakeoff) Step(state *AircraftState, dt float64) (*AircraftState, error) {
+	calc := e.Calculator
+	v := state.GroundVelocity()
+	speed := math.Hypot(v.X, v.Z)
+	gamma := 0.0
+	if speed > 0 {
+		gamma = math.Atan2(-v.Z, v.X)
+	}
+	aero := state.Copy()
+	aero.Alpha = state.Pitch - gamma
+	components, err := calc.CalculateSimplifiedForces(aero)
+	if err != nil {
+		return nil, err
+	}
+	lift, drag, thrust := -components.Aerodynamic.Lift, -components.Aerodynamic.Drag, components.Propulsion.Thrust
+
+	// Along and square to the flight path, the wheels carrying any
+	// shortfall of lift on the ground
+	weight := calc.Mass * StandardGravity
+	along := thrust*math.Cos(aero.Alpha) - drag - weight*math.Sin(gamma)
+	up := lift + thrust*math.Sin(aero.Alpha) - weight*math.Cos(gamma)
+	onGround := state.Altitude-state.Gear.GroundHeight <= 1e-9 && up <= 0
+	if onGround {
+		along -= e.Friction * -up
+		up = 0
+	}
+	speed = math.Max(0, speed+along/calc.Mass*dt)
+	if !onGround && speed > 0 {
+		gamma += up / (calc.Mass * speed) * dt
+	}
+	pitch := state.Pitch - e.PitchRate*state.Controls.Elevator*dt
+	if onGround {
+		pitch = math.Max(0, math.Min(12*DEG_TO_RAD, pitch))
+	}
+
+	next := state.Copy()
+	next.Time = state.Time + dt
+	ned := Vector3{X: speed * math.Cos(gamma), Z: -speed * math.Sin(gamma)}
+	next.Position = state.Position.Add(ned.Scale(dt))
+	next.Position.Z = math.Min(next.Position.Z, -state.Gear.GroundHeight)
+	next.Altitude = -next.Position.Z
+	next.Orientation = NewQuaternionFromEuler(0, pitch, 0)
+	next.Velocity = next.Orientation.RotateVectorInverse(ned)
+	next.AngularRate = Vector3{Y: (pitch - state.Pitch) / dt}
+	next.Gear.OnGround = onGround
+	e.updateConfiguration(state, next, dt)
+	next.UpdateFlightConditions()
+	return next, nil
+}
+
+func TestTakeoffScenario(t *testing.T) {
+	fly := func(t *testing.T, engine *pointMassTakeoff) *TakeoffReport {
+		t.Helper()
+		report := NewTakeoffScenario().Run(engine, NewRunwayState(nil, 0))
+		if !report.Completed() {
+			t.Fatalf("The takeoff did not complete:\n%s", report)
+		}
+		return report
+	}
+
+	t.Run("Ground Roll And Climb-Out", func(t *testing.T) {
+		engine := newPointMassTakeoff()
+		report := fly(t, engine)
+		t.Logf("\n%s", report)
+
+		if report.LiftoffSpeed < 45 || report.LiftoffSpeed > 60 {
+			t.Errorf("Liftoff at %.1f m/s, expected 45 to 60 m/s", report.LiftoffSpeed)
+		}
+		if report.RotationTime >= report.LiftoffTime {
+			t.Errorf("Rotation at %.2f s should come before the liftoff at %.2f s", report.RotationTime, report.LiftoffTime)
+		}
+
+		// A P-51D rolls about 460 m, but the simplified model has a third of
+		// its propeller's thrust, so the roll is checked against the
+		// textbook estimate instead: V²/2a with the acceleration at 0.7 of
+		// the liftoff speed at the rolling attitude. That leaves out the
+		// induced drag of the rotated roll, so it should come out short.
+		roll := NewRunwayState(nil, 0)
+		roll.Velocity = Vector3{X: 0.7 * report.LiftoffSpeed}
+		roll.Controls.Throttle = 1
+		roll.UpdateFlightConditions()
+		roll.Alpha = 0
+		components, err := engine.Calculator.CalculateSimplifiedForces(roll)
+		if err != nil {
+			t.Fatal(err)
+		}
+		weight := engine.Calculator.Mass * StandardGravity
+		force := components.Propulsion.Thrust + components.Aerodynamic.Drag - engine.Friction*(weight+components.Aerodynamic.Lift)
+		estimate := report.LiftoffSpeed * report.LiftoffSpeed / (2 * force / engine.Calculator.Mass)
+		if report.GroundRoll < estimate || report.GroundRoll > 1.25*estimate {
+			t.Errorf("Ground roll %.0f m, estimated %.0f m", report.GroundRoll, estimate)
+		}
+		if !report.Cleared || report.TimeTo50ft <= report.LiftoffTime || report.DistanceTo50ft <= report.GroundRoll {
+			t.Errorf("50 ft should be cleared after the liftoff:\n%s", report)
+		}
+		if report.InitialClimbRate <= 0 {
+			t.Errorf("Climbing at %.2f m/s through 50 ft", report.InitialClimbRate)
+		}
+
+		// The gear comes up once climbing past 10 m, and is fully up by
+		// the end of the climb-out
+		if report.GearUpTime <= report.LiftoffTime {
+			t.Errorf("Gear selected up at %.2f s, liftoff at %.2f s", report.GearUpTime, report.LiftoffTime)
+		}
+		final := report.Termination.Final
+		assertEqual(t, final.Controls.Gear, false)
+		assertApproxEqual(t, final.Gear.Transition, 0.0, 1e-9)
+		if !strings.Contains(report.String(), "takeoff-complete") {
+			t.Errorf("The report should say how the run ended:\n%s", report)
+		}
+	})
+
+	t.Run("Heavier Takeoff", func(t *testing.T) {
+		light := fly(t, newPointMassTakeoff())
+		engine := newPointMassTakeoff()
+		engine.Calculator.Mass *= 1.2
+		heavy := fly(t, engine)
+
+		// Slower to accelerate and lifting off faster: about half as far
+		// again
+		if heavy.GroundRoll < 1.3*light.GroundRoll {
+			t.Errorf("20%% more weight should lengthen the roll well beyond %.0f m, rolled %.0f m", light.GroundRoll, heavy.GroundRoll)
+		}
+		if heavy.LiftoffSpeed <= light.LiftoffSpeed {
+			t.Errorf("The heavier aircraft should lift off faster than %.1f m/s, lifted off at %.1f m/s", light.LiftoffSpeed, heavy.LiftoffSpeed)
+		}
+	})
+
+	t.Run("Ground Effect", func(t *testing.T) {
+		calc := NewSimplifiedCalculator()
+		assertEqual(t, calc.groundEffectFactor(1), 1.0)
+		calc.GroundEffect = true
+		assertApproxEqual(t, calc.groundEffectFactor(1), 2/3.0, 0.01)
+		if f := calc.groundEffectFactor(0.5); f > 0.5 {
+			t.Errorf("Half a metre up, the induced drag should be less than halved: %.2f", f)
+		}
+		if f := calc.groundEffectFactor(100); f < 0.999 {
+			t.Errorf("Far from the ground the induced drag should be in full: %.4f", f)
+		}
+
+		// Less drag on the roll and through the liftoff
+		free := fly(t, newPointMassTakeoff())
+		engine := newPointMassTakeoff()
+		engine.Calculator.GroundEffect = true
+		near := fly(t, engine)
+		if near.GroundRoll >= free.GroundRoll || near.TimeTo50ft >= free.TimeTo50ft {
+			t.Errorf("Ground effect should shorten the takeoff: roll %.0f m and 50 ft at %.2f s, against %.0f m and %.2f s",
+				near.GroundRoll, near.TimeTo50ft, free.GroundRoll, free.TimeTo50ft)
+		}
+	})
+
+	t.Run("Runway State", func(t *testing.T) {
+		// At rest on the mains and tailwheel, as the taxi tests start
+		gear := NewLandingGear(loadP51DConfig(t))
+		state := NewRunwayState(gear, 0)
+		taxi := taxiState(gear, 0, ControlInputs{})
+		assertApproxEqual(t, state.Pitch, taxi.Pitch, 1e-12)
+		assertApproxEqual(t, state.Altitude, taxi.Altitude, 1e-12)
+		assertEqual(t, state.Controls.Gear, true)
+		assertEqual(t, state.TrueAirspeed, 0.0)
+
+		level := NewRunwayState(nil, 120)
+		assertEqual(t, level.Pitch, 0.0)
+		assertEqual(t, level.Altitude, 120.0)
+		assertEqual(t, weightOnWheels(level), true)
+	})
+}
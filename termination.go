@@ -0,0 +1,217 @@
+// Termination Conditions
+// When an unattended run stops: ground impact, leaving a geographic box,
+// a time limit, fuel exhaustion or any predicate, each either a normal
+// completion or a failure, reported with the state the run ended in
+
+package main
+
+import "fmt"
+
+// TerminationSeverity is whether a run that ends on a condition completed
+// or failed
+type TerminationSeverity int
+
+const (
+	TerminationComplete TerminationSeverity = iota // The run finished as planned
+	TerminationAbort                               // The run failed
+)
+
+func (s TerminationSeverity) String() string {
+	if s == TerminationAbort {
+		return "abort-failure"
+	}
+	return "normal-complete"
+}
+
+// Exit codes of the simulate command
+const (
+	ExitComplete = 0 // Ended on a normal-complete condition
+	ExitUsage    = 1 // Bad arguments or a configuration that did not load
+	ExitAbort    = 2 // Ended on an abort-failure condition or a failed step
+)
+
+// TerminationCondition ends a run when Fired reports true for the state
+// after a step
+type TerminationCondition struct {
+	Name     string
+	Severity TerminationSeverity
+	Fired    func(state *AircraftState) bool
+}
+
+// TerminationPolicy is the conditions a run is checked against after each
+// step, in order; the first to fire ends it
+type TerminationPolicy struct {
+	Conditions []TerminationCondition
+}
+
+// NewTerminationPolicy creates a policy of conditions
+func NewTerminationPolicy(conditions ...TerminationCondition) *TerminationPolicy {
+	return &TerminationPolicy{Conditions: conditions}
+}
+
+// Add registers a predicate as a condition
+func (p *TerminationPolicy) Add(name string, severity TerminationSeverity, fired func(state *AircraftState) bool) {
+	p.Conditions = append(p.Conditions, TerminationCondition{Name: name, Severity: severity, Fired: fired})
+}
+
+// Check returns the first condition that fires for state, or nil
+func (p *TerminationPolicy) Check(state *AircraftState) *TerminationCondition {
+	for i := range p.Conditions {
+		if p.Conditions[i].Fired(state) {
+			return &p.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// TerminationReport is how a run ended: the condition that fired, or the
+// error a step failed with, and the state the run ended in
+type TerminationReport struct {
+	Condition string
+	Severity  TerminationSeverity
+	Time      float64 // Simulation time (s)
+	Steps     int
+	Final     *AircraftState
+	Err       error // The failed step's error; nil when a condition fired
+}
+
+// StepFailure is the condition of a report whose run ended on a failed step
+const StepFailure = "step-failure"
+
+// ExitCode returns the simulate command's exit code for the report
+func (r *TerminationReport) ExitCode() int {
+	if r.Severity == TerminationAbort {
+		return ExitAbort
+	}
+	return ExitComplete
+}
+
+func (r *TerminationReport) String() string {
+	s := fmt.Sprintf("%s (%s) at t=%.2fs after %d steps", r.Condition, r.Severity, r.Time, r.Steps)
+	if r.Err != nil {
+		s += ": " + r.Err.Error()
+	}
+	return s
+}
+
+// GroundImpactLimits are the limits past which touching the ground is an
+// impact rather than a landing
+type GroundImpactLimits struct {
+	SinkRate   float64 // Without a gear model, the sink rate at the ground (m/s)
+	StrutForce float64 // With one, the force on any one strut (N)
+}
+
+// GroundImpact fires without a gear model when the aircraft reaches the
+// ground sinking faster than the limit, and with one when a strut is
+// loaded past its limit
+func GroundImpact(gear *LandingGear, limits GroundImpactLimits) TerminationCondition {
+	fired := func(state *AircraftState) bool {
+		sinkRate := state.GroundVelocity().Z
+		return state.Altitude-state.Gear.GroundHeight <= 0 && sinkRate > limits.SinkRate
+	}
+	if gear != nil {
+		fired = func(state *AircraftState) bool {
+			for i, unit := range state.Gear.Units {
+				if i < len(gear.Units) && unit.WOW && gear.Units[i].StrutForce(unit.Compression, unit.CompressionVelocity) > limits.StrutForce {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	return TerminationCondition{Name: "ground-impact", Severity: TerminationAbort, Fired: fired}
+}
+
+// GeoBox is a region of latitude and longitude (rad)
+type GeoBox struct {
+	MinLatitude, MaxLatitude   float64
+	MinLongitude, MaxLongitude float64
+}
+
+// Contains reports whether the state is within the box
+func (b GeoBox) Contains(state *AircraftState) bool {
+	return state.Latitude >= b.MinLatitude && state.Latitude <= b.MaxLatitude &&
+		state.Longitude >= b.MinLongitude && state.Longitude <= b.MaxLongitude
+}
+
+// LeftBox fires when the aircraft leaves the box. Engines track latitude
+// and longitude only as they fly over terrain, or always for the full model.
+func LeftBox(box GeoBox) TerminationCondition {
+	return TerminationCondition{
+		Name:     "left-box",
+		Severity: TerminationAbort,
+		Fired:    func(state *AircraftState) bool { return !box.Contains(state) },
+	}
+}
+
+// MaxSimTime fires once the simulation time reaches limit (s)
+func MaxSimTime(limit float64) TerminationCondition {
+	return TerminationCondition{
+		Name:     "max-sim-time",
+		Severity: TerminationComplete,
+		Fired:    func(state *AircraftState) bool { return state.Time >= limit-1e-9 },
+	}
+}
+
+// FuelExhausted fires once the tanks are empty
+func FuelExhausted(fuel *FuelSystem) TerminationCondition {
+	return TerminationCondition{
+		Name:     "fuel-exhausted",
+		Severity: TerminationAbort,
+		Fired:    func(*AircraftState) bool { return fuel.TotalContents <= 0 },
+	}
+}
+
+// ScenarioRunner steps an engine until its policy ends the run
+type ScenarioRunner struct {
+	Engine MonteCarloEngine
+	Dt     float64 // s
+	Policy *TerminationPolicy
+
+	// Optional; sets the controls of each step's state before the step
+	Pilot func(state *AircraftState)
+
+	loops []*PilotLoop // Closed after Pilot, set by AttachPilot
+}
+
+// Run flies from initial until a condition fires or a step fails or leaves
+// a non-finite state. The policy should hold a time limit, or the run may
+// not end.
+func (r *ScenarioRunner) Run(initial *AircraftState) *TerminationReport {
+	state := initial.Copy()
+	for _, loop := range r.loops {
+		loop.reset()
+	}
+	for steps := 1; ; steps++ {
+		if r.Pilot != nil {
+			r.Pilot(state)
+		}
+		for _, loop := range r.loops {
+			loop.apply(state, r.Dt)
+		}
+		next, err := r.Engine.Step(state, r.Dt)
+		if err == nil {
+			err = next.Validate()
+		}
+		if err != nil {
+			return &TerminationReport{
+				Condition: StepFailure,
+				Severity:  TerminationAbort,
+				Time:      state.Time,
+				Steps:     steps - 1,
+				Final:     state,
+				Err:       err,
+			}
+		}
+		state = next
+		if condition := r.Policy.Check(state); condition != nil {
+			return &TerminationReport{
+				Condition: condition.Name,
+				Severity:  condition.Severity,
+				Time:      state.Time,
+				Steps:     steps,
+				Final:     state,
+			}
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// fuelBurningEngine flies the simplified model with a propulsion system
+// burning fuel at full throttle, noting when its tanks emptied
+type fuelBurningEngine struct {
+	*SimplifiedFlightDynamicsEngine
+	propulsion *PropulsionSystem
+	emptied    float64 // Time of the state after the step that emptied the tanks
+}
+
+func (e *fuelBurningEngine) Step(state *AircraftState, dt float64) (*AircraftState, error) {
+	full := e.propulsion.FuelSystem.TotalContents > 0
+	e.propulsion.Update(1.0, dt)
+	next, err := e.SimplifiedFlightDynamicsEngine.Step(state, dt)
+	if err == nil && full && e.propulsion.FuelSystem.TotalContents <= 0 {
+		e.emptied = next.Time
+	}
+	return next, err
+}
+
+func TestTermination(t *testing.T) {
+	// Level flight at 100 m/s
+	level := func() *AircraftState {
+		state := cruiseState(1500, 100, 1.417*DEG_TO_RAD)
+		state.Controls.Throttle = 0.76
+		return state
+	}
+
+	t.Run("Fuel Exhaustion", func(t *testing.T) {
+		propulsion := NewPropulsionSystem()
+		for _, tank := range propulsion.FuelSystem.Tanks {
+			tank.Contents = 0
+		}
+		propulsion.FuelSystem.Tanks[0].Contents = 1.0
+		propulsion.FuelSystem.TotalContents = 1.0
+		engine := &fuelBurningEngine{
+			SimplifiedFlightDynamicsEngine: NewSimplifiedFlightDynamicsEngine(NewRungeKutta4Integrator()),
+			propulsion:                     propulsion,
+		}
+
+		runner := &ScenarioRunner{
+			Engine: engine,
+			Dt:     0.01,
+			Policy: NewTerminationPolicy(FuelExhausted(propulsion.FuelSystem), MaxSimTime(120)),
+		}
+		report := runner.Run(level())
+		assertEqual(t, report.Condition, "fuel-exhausted")
+		assertEqual(t, report.Severity, TerminationAbort)
+		assertEqual(t, report.Err, nil)
+		if engine.emptied == 0 || report.Time != engine.emptied || report.Final.Time != report.Time {
+			t.Errorf("The run should end when the tanks empty at %.2f s, ended at %.2f s", engine.emptied, report.Time)
+		}
+
+		// One pound at the full throttle burn rate
+		endurance := 1.0 / (propulsion.FuelSystem.FuelFlow / 3600)
+		assertApproxEqual(t, report.Time, endurance, 0.02)
+	})
+
+	t.Run("Ground Impact", func(t *testing.T) {
+		// Diving at 30° from 50 m
+		state := cruiseState(50, 100, 0)
+		state.Orientation = NewQuaternionFromEuler(0, -30*DEG_TO_RAD, 0)
+		state.UpdateDerivedParameters()
+
+		policy := NewTerminationPolicy(GroundImpact(nil, GroundImpactLimits{SinkRate: 3}), MaxSimTime(120))
+		passed := false
+		policy.Add("passed-25m", TerminationComplete, func(state *AircraftState) bool {
+			passed = passed || state.Altitude < 25
+			return false
+		})
+		runner := &ScenarioRunner{
+			Engine: NewSimplifiedFlightDynamicsEngine(NewRungeKutta4Integrator()),
+			Dt:     0.01,
+			Policy: policy,
+		}
+		report := runner.Run(state)
+		assertEqual(t, report.Condition, "ground-impact")
+		assertEqual(t, report.ExitCode(), ExitAbort)
+		assertEqual(t, passed, true)
+		if report.Final.Altitude > 0 {
+			t.Errorf("The impact should be at the ground, at %.1f m", report.Final.Altitude)
+		}
+
+		// A touchdown below the sink rate limit is not an impact
+		impact := GroundImpact(nil, GroundImpactLimits{SinkRate: 3})
+		touchdown := NewAircraftState()
+		touchdown.Altitude = -0.01
+		touchdown.Velocity = Vector3{X: 60, Z: 2}
+		assertEqual(t, impact.Fired(touchdown), false)
+		touchdown.Velocity.Z = 5
+		assertEqual(t, impact.Fired(touchdown), true)
+	})
+
+	t.Run("Policy Order", func(t *testing.T) {
+		policy := NewTerminationPolicy(MaxSimTime(1))
+		policy.Add("always", TerminationAbort, func(*AircraftState) bool { return true })
+		state := NewAircraftState()
+		state.Time = 2
+		assertEqual(t, policy.Check(state).Name, "max-sim-time")
+		state.Time = 0.5
+		assertEqual(t, policy.Check(state).Name, "always")
+
+		box := GeoBox{MinLatitude: -0.01, MaxLatitude: 0.01, MinLongitude: -0.01, MaxLongitude: 0.01}
+		assertEqual(t, LeftBox(box).Fired(state), false)
+		state.Longitude = 0.02
+		assertEqual(t, LeftBox(box).Fired(state), true)
+	})
+
+	t.Run("Command", func(t *testing.T) {
+		var out bytes.Buffer
+		code, err := runSimulate([]string{"-altitude", "30", "-pitch", "-40", "-duration", "20", "p51d-jsbsim"}, &out)
+		if err != nil {
+			t.Fatalf("runSimulate: %v", err)
+		}
+		assertEqual(t, code, ExitAbort)
+		if !strings.Contains(out.String(), "ground-impact (abort-failure)") {
+			t.Errorf("The crash should be reported as a ground impact:\n%s", out.String())
+		}
+
+		out.Reset()
+		code, err = runSimulate([]string{"-duration", "0.5", "p51d-jsbsim"}, &out)
+		if err != nil {
+			t.Fatalf("runSimulate: %v", err)
+		}
+		assertEqual(t, code, ExitComplete)
+		if !strings.Contains(out.String(), "max-sim-time (normal-complete)") {
+			t.Errorf("The run should complete at its time limit:\n%s", out.String())
+		}
+
+		out.Reset()
+		code, err = runSimulate([]string{"-duration", "0.5", "-units", "imperial,dual", "p51d-jsbsim"}, &out)
+		if err != nil {
+			t.Fatalf("runSimulate: %v", err)
+		}
+		if !regexp.MustCompile(`final: altitude [0-9.]+ ft \([0-9.]+ m\), airspeed [0-9.]+ kt \([0-9.]+ m/s\)`).MatchString(out.String()) {
+			t.Errorf("The final state should be given in knots and feet:\n%s", out.String())
+		}
+		if code, err = runSimulate([]string{"-units", "furlongs", "p51d-jsbsim"}, &out); err == nil || code != ExitUsage {
+			t.Errorf("Unknown units should be a usage error, got %d, %v", code, err)
+		}
+
+		code, err = runSimulate(nil, &out)
+		if err == nil || code != ExitUsage {
+			t.Errorf("A missing aircraft should be a usage error, got %d, %v", code, err)
+		}
+	})
+}
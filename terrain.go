@@ -0,0 +1,256 @@
+// Terrain Elevation
+// Pluggable terrain providers used to compute height above ground level
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// EARTH_RADIUS_M is the mean Earth radius used for local position to
+// latitude/longitude conversion
+const EARTH_RADIUS_M = 6371000.0
+
+// Terrain provides the ground elevation below a geodetic position
+type Terrain interface {
+	// ElevationAt returns the terrain elevation above sea level in meters
+	// at the given latitude and longitude (radians)
+	ElevationAt(lat, lon float64) (float64, error)
+}
+
+// =============================================================================
+// FLAT TERRAIN
+// =============================================================================
+
+// FlatTerrain is a terrain with the same elevation everywhere
+type FlatTerrain struct {
+	Elevation float64 // Elevation above sea level (m)
+}
+
+// NewFlatTerrain creates a flat terrain at the given elevation
+func NewFlatTerrain(elevation float64) *FlatTerrain {
+	return &FlatTerrain{Elevation: elevation}
+}
+
+// ElevationAt returns the constant elevation
+func (ft *FlatTerrain) ElevationAt(lat, lon float64) (float64, error) {
+	return ft.Elevation, nil
+}
+
+// =============================================================================
+// GRID TERRAIN
+// =============================================================================
+
+// TerrainBoundsMode selects what a grid does for positions outside it
+type TerrainBoundsMode int
+
+const (
+	TerrainClamp TerrainBoundsMode = iota // Use the nearest edge of the grid
+	TerrainError                          // Return an error
+)
+
+// GridTerrain is an in-memory heightmap sampled with bilinear interpolation.
+// Heights[i][j] is the elevation at latitude MinLat + i*LatStep and
+// longitude MinLon + j*LonStep.
+type GridTerrain struct {
+	MinLat  float64     // Southern edge (radians)
+	MinLon  float64     // Western edge (radians)
+	LatStep float64     // Row spacing (radians)
+	LonStep float64     // Column spacing (radians)
+	Heights [][]float64 // Elevations (m), south to north
+	Bounds  TerrainBoundsMode
+}
+
+// NewGridTerrain creates a grid terrain, validating that the heightmap is
+// rectangular and the spacing positive
+func NewGridTerrain(minLat, minLon, latStep, lonStep float64, heights [][]float64) (*GridTerrain, error) {
+	if latStep <= 0 || lonStep <= 0 {
+		return nil, fmt.Errorf("grid spacing must be positive")
+	}
+	if len(heights) < 2 || len(heights[0]) < 2 {
+		return nil, fmt.Errorf("grid must have at least 2x2 points")
+	}
+	for i, row := range heights {
+		if len(row) != len(heights[0]) {
+			return nil, fmt.Errorf("grid row %d has %d columns, expected %d", i, len(row), len(heights[0]))
+		}
+	}
+
+	return &GridTerrain{
+		MinLat:  minLat,
+		MinLon:  minLon,
+		LatStep: latStep,
+		LonStep: lonStep,
+		Heights: heights,
+		Bounds:  TerrainClamp,
+	}, nil
+}
+
+// ElevationAt returns the bilinearly interpolated elevation
+func (gt *GridTerrain) ElevationAt(lat, lon float64) (float64, error) {
+	rows := len(gt.Heights)
+	cols := len(gt.Heights[0])
+
+	// Fractional grid coordinates
+	fi := (lat - gt.MinLat) / gt.LatStep
+	fj := (lon - gt.MinLon) / gt.LonStep
+
+	if fi < 0 || fi > float64(rows-1) || fj < 0 || fj > float64(cols-1) {
+		if gt.Bounds == TerrainError {
+			return 0, fmt.Errorf("position (%.6f, %.6f) deg is outside the terrain grid",
+				lat*RAD_TO_DEG, lon*RAD_TO_DEG)
+		}
+		fi = math.Max(0, math.Min(fi, float64(rows-1)))
+		fj = math.Max(0, math.Min(fj, float64(cols-1)))
+	}
+
+	i0 := int(math.Min(math.Floor(fi), float64(rows-2)))
+	j0 := int(math.Min(math.Floor(fj), float64(cols-2)))
+	ti := fi - float64(i0)
+	tj := fj - float64(j0)
+
+	south := gt.Heights[i0][j0]*(1-tj) + gt.Heights[i0][j0+1]*tj
+	north := gt.Heights[i0+1][j0]*(1-tj) + gt.Heights[i0+1][j0+1]*tj
+
+	return south*(1-ti) + north*ti, nil
+}
+
+// LoadGridTerrain reads an ESRI-style ASCII grid. The header gives the grid
+// size and placement in degrees:
+//
+//	ncols        4
+//	nrows        3
+//	xllcorner    -122.5   (longitude of the south-west corner)
+//	yllcorner    37.5     (latitude of the south-west corner)
+//	cellsize     0.01
+//
+// followed by nrows lines of elevations in meters, northernmost row first.
+// Values may be separated by spaces or commas, and "#" starts a comment.
+func LoadGridTerrain(r io.Reader) (*GridTerrain, error) {
+	header := map[string]float64{}
+	var heights [][]float64
+
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+		if idx := strings.Index(line, "#"); idx >= 0 {
+			line = line[:idx]
+		}
+		fields := strings.FieldsFunc(line, func(c rune) bool {
+			return c == ',' || c == ' ' || c == '\t'
+		})
+		if len(fields) == 0 {
+			continue
+		}
+
+		// Header lines start with a keyword
+		if _, err := strconv.ParseFloat(fields[0], 64); err != nil {
+			if len(fields) != 2 {
+				return nil, fmt.Errorf("line %d: malformed header %q", lineNum, line)
+			}
+			value, err := strconv.ParseFloat(fields[1], 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid value for %s: %v", lineNum, fields[0], err)
+			}
+			header[strings.ToLower(fields[0])] = value
+			continue
+		}
+
+		row := make([]float64, len(fields))
+		for i, field := range fields {
+			value, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid elevation %q", lineNum, field)
+			}
+			row[i] = value
+		}
+		heights = append(heights, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, key := range []string{"xllcorner", "yllcorner", "cellsize"} {
+		if _, ok := header[key]; !ok {
+			return nil, fmt.Errorf("missing %s in grid header", key)
+		}
+	}
+	if nrows, ok := header["nrows"]; ok && int(nrows) != len(heights) {
+		return nil, fmt.Errorf("grid has %d rows, header says %d", len(heights), int(nrows))
+	}
+	if ncols, ok := header["ncols"]; ok && len(heights) > 0 && int(ncols) != len(heights[0]) {
+		return nil, fmt.Errorf("grid has %d columns, header says %d", len(heights[0]), int(ncols))
+	}
+
+	// File rows run north to south; the grid stores them south to north
+	for i, j := 0, len(heights)-1; i < j; i, j = i+1, j-1 {
+		heights[i], heights[j] = heights[j], heights[i]
+	}
+
+	cellSize := header["cellsize"] * DEG_TO_RAD
+	return NewGridTerrain(header["yllcorner"]*DEG_TO_RAD, header["xllcorner"]*DEG_TO_RAD,
+		cellSize, cellSize, heights)
+}
+
+// LoadGridTerrainFile reads an ASCII grid terrain from disk
+func LoadGridTerrainFile(path string) (*GridTerrain, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open terrain file: %v", err)
+	}
+	defer file.Close()
+
+	return LoadGridTerrain(file)
+}
+
+// =============================================================================
+// STATE HELPERS
+// =============================================================================
+
+// updateGeodeticPosition advances latitude and longitude by the north/east
+// displacement between two local NED positions
+func updateGeodeticPosition(state *AircraftState, previous Vector3) {
+	dNorth := state.Position.X - previous.X
+	dEast := state.Position.Y - previous.Y
+
+	state.Latitude += dNorth / EARTH_RADIUS_M
+	cosLat := math.Cos(state.Latitude)
+	if math.Abs(cosLat) > 1e-9 {
+		state.Longitude += dEast / (EARTH_RADIUS_M * cosLat)
+	}
+}
+
+// updateGroundContact samples the terrain below the aircraft, sets the
+// ground height and keeps the aircraft from sinking below the surface
+func updateGroundContact(state *AircraftState, terrain Terrain) error {
+	elevation, err := terrain.ElevationAt(state.Latitude, state.Longitude)
+	if err != nil {
+		return err
+	}
+	state.Gear.GroundHeight = elevation
+
+	if state.Altitude > elevation {
+		state.Gear.OnGround = false
+		return nil
+	}
+
+	// Ground reaction: rest on the surface and remove the sinking velocity
+	state.Gear.OnGround = true
+	state.Altitude = elevation
+	state.Position.Z = -elevation
+
+	earthVel := state.Orientation.RotateVector(state.Velocity)
+	if earthVel.Z > 0 {
+		earthVel.Z = 0
+		state.Velocity = state.Orientation.RotateVectorInverse(earthVel)
+	}
+	return nil
+}
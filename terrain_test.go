@@ -0,0 +1,310 @@
+package main
+
+import (
+	"math"
+	"os"
+	"strings"
+	"testing"
+)
+
+// newPlateauTerrain builds a grid that is at sea level for the first 5 m
+// north of the origin, ramps up, and is a 500 m plateau from 10 m north on
+func newPlateauTerrain(t *testing.T) *GridTerrain {
+	step := 5.0 / EARTH_RADIUS_M
+	terrain, err := NewGridTerrain(0, -step, step, step, [][]float64{
+		{0, 0, 0},
+		{0, 0, 0},
+		{500, 500, 500},
+		{500, 500, 500},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create grid terrain: %v", err)
+	}
+	return terrain
+}
+
+func TestFlatTerrain(t *testing.T) {
+	terrain := NewFlatTerrain(120.0)
+	elevation, err := terrain.ElevationAt(0.7, -2.1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	assertApproxEqual(t, elevation, 120.0, 1e-9)
+}
+
+func TestGridTerrain(t *testing.T) {
+	terrain, err := NewGridTerrain(0, 0, 1, 1, [][]float64{
+		{0, 10},
+		{20, 30},
+	})
+	if err != nil {
+		t.Fatalf("Failed to create grid terrain: %v", err)
+	}
+
+	t.Run("Bilinear Interpolation", func(t *testing.T) {
+		cases := []struct{ lat, lon, expected float64 }{
+			{0, 0, 0},
+			{0, 1, 10},
+			{1, 0, 20},
+			{1, 1, 30},
+			{0.5, 0.5, 15},
+			{0.25, 0.5, 10},
+		}
+		for _, c := range cases {
+			elevation, err := terrain.ElevationAt(c.lat, c.lon)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			assertApproxEqual(t, elevation, c.expected, 1e-9)
+		}
+	})
+
+	t.Run("Clamp Out Of Bounds", func(t *testing.T) {
+		elevation, err := terrain.ElevationAt(5, 5)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		assertApproxEqual(t, elevation, 30, 1e-9)
+
+		elevation, _ = terrain.ElevationAt(-1, 0.5)
+		assertApproxEqual(t, elevation, 5, 1e-9)
+	})
+
+	t.Run("Error Out Of Bounds", func(t *testing.T) {
+		terrain.Bounds = TerrainError
+		defer func() { terrain.Bounds = TerrainClamp }()
+
+		if _, err := terrain.ElevationAt(1.5, 0.5); err == nil {
+			t.Errorf("Expected error outside the grid")
+		}
+		if _, err := terrain.ElevationAt(0.5, 0.5); err != nil {
+			t.Errorf("Unexpected error inside the grid: %v", err)
+		}
+	})
+
+	t.Run("Invalid Grids", func(t *testing.T) {
+		if _, err := NewGridTerrain(0, 0, 0, 1, [][]float64{{0, 0}, {0, 0}}); err == nil {
+			t.Errorf("Expected error for zero spacing")
+		}
+		if _, err := NewGridTerrain(0, 0, 1, 1, [][]float64{{0, 0}, {0}}); err == nil {
+			t.Errorf("Expected error for ragged grid")
+		}
+	})
+}
+
+func TestLoadGridTerrain(t *testing.T) {
+	grid := `# test grid
+ncols 3
+nrows 2
+xllcorner 10.0
+yllcorner 45.0
+cellsize 0.5
+300, 400, 500
+0, 100, 200
+`
+	terrain, err := LoadGridTerrain(strings.NewReader(grid))
+	if err != nil {
+		t.Fatalf("Failed to load grid: %v", err)
+	}
+
+	// Southern row is the last line of the file
+	elevation, _ := terrain.ElevationAt(45.0*DEG_TO_RAD, 10.0*DEG_TO_RAD)
+	assertApproxEqual(t, elevation, 0, 1e-6)
+	elevation, _ = terrain.ElevationAt(45.5*DEG_TO_RAD, 11.0*DEG_TO_RAD)
+	assertApproxEqual(t, elevation, 500, 1e-6)
+	elevation, _ = terrain.ElevationAt(45.25*DEG_TO_RAD, 10.5*DEG_TO_RAD)
+	assertApproxEqual(t, elevation, 250, 1e-6)
+
+	if _, err := LoadGridTerrain(strings.NewReader("ncols 2\n0 0\n0 0\n")); err == nil {
+		t.Errorf("Expected error for missing header fields")
+	}
+	if _, err := LoadGridTerrain(strings.NewReader("nrows 3\nxllcorner 0\nyllcorner 0\ncellsize 1\n0 0\n0 0\n")); err == nil {
+		t.Errorf("Expected error for row count mismatch")
+	}
+}
+
+func TestTerrainFlight(t *testing.T) {
+	file, err := os.Open("aircraft/p51d-jsbsim.xml")
+	if err != nil {
+		t.Skip("P-51D XML file not found")
+	}
+	defer file.Close()
+
+	config, err := ParseJSBSimConfig(file)
+	if err != nil {
+		t.Fatalf("Failed to parse P-51D config: %v", err)
+	}
+
+	newState := func() *AircraftState {
+		state := NewAircraftState()
+		state.Altitude = 520.0
+		state.Position = Vector3{X: 0, Y: 0, Z: -520.0}
+		state.Velocity = Vector3{X: 100.0, Y: 0, Z: 0}
+		state.UpdateAtmosphere()
+		state.UpdateDerivedParameters()
+		return state
+	}
+
+	fly := func(terrain Terrain) []*AircraftState {
+		engine := NewFlightDynamicsEngine(config, NewRungeKutta4Integrator())
+		engine.Terrain = terrain
+		state := newState()
+		states := []*AircraftState{state}
+		for i := 0; i < 200; i++ {
+			state, err = engine.Step(state, 0.01)
+			if err != nil {
+				t.Fatalf("Step %d failed: %v", i, err)
+			}
+			states = append(states, state)
+		}
+		return states
+	}
+
+	t.Run("Plateau Changes AGL Not MSL", func(t *testing.T) {
+		flat := fly(NewFlatTerrain(0))
+		plateau := fly(newPlateauTerrain(t))
+
+		first, last := plateau[0], plateau[len(plateau)-1]
+		assertApproxEqual(t, first.Gear.GroundHeight, 0, 1e-9)
+		if last.Latitude*EARTH_RADIUS_M < 10 {
+			t.Fatalf("Aircraft should have reached the plateau, only %.1f m north", last.Latitude*EARTH_RADIUS_M)
+		}
+		assertApproxEqual(t, last.Gear.GroundHeight, 500, 1e-6)
+
+		// Plateau is too far below for ground effect, so MSL is unaffected
+		for i := range plateau {
+			assertApproxEqual(t, plateau[i].Altitude, flat[i].Altitude, 1e-9)
+		}
+		properties := last.ToPropertyMap()
+		assertApproxEqual(t, properties["position/h-agl-m"], last.Altitude-500, 1e-6)
+		assertApproxEqual(t, properties["position/terrain-elevation-m"], 500, 1e-6)
+		assertApproxEqual(t, flat[len(flat)-1].ToPropertyMap()["position/h-agl-m"], flat[len(flat)-1].Altitude, 1e-9)
+	})
+
+	t.Run("Ground Effect Height", func(t *testing.T) {
+		calc := NewForcesMomentsCalculator(config)
+		lift := func(agl float64) float64 {
+			state := newState()
+			state.Gear.GroundHeight = state.Altitude - agl
+			components, err := calc.CalculateForcesMoments(state)
+			if err != nil {
+				t.Fatalf("Force calculation failed: %v", err)
+			}
+			return -components.Aerodynamic.Lift
+		}
+
+		// Ground effect table ends at h/b = 1.0; one span is 37.1 ft
+		span := calc.Reference.WingSpan * FT_TO_M
+		freeAir := lift(100.0)
+		assertApproxEqual(t, lift(1.2*span), freeAir, 1e-6)
+		if lift(0.2*span) <= freeAir {
+			t.Errorf("Expected ground effect lift increase at h/b = 0.2: %.1f vs %.1f N", lift(0.2*span), freeAir)
+		}
+		if lift(0.5*span) <= freeAir || lift(0.5*span) >= lift(0.2*span) {
+			t.Errorf("Ground effect should weaken with height")
+		}
+	})
+
+	t.Run("Ground Contact", func(t *testing.T) {
+		state := newState()
+		state.Latitude = 20.0 / EARTH_RADIUS_M
+		state.Altitude = 500.1
+		state.Position.Z = -500.1
+		state.Velocity = Vector3{X: 60.0, Y: 0, Z: 30.0} // Sinking fast
+
+		engine := NewFlightDynamicsEngine(config, NewRungeKutta4Integrator())
+		engine.Terrain = newPlateauTerrain(t)
+		state, err = engine.Step(state, 0.01)
+		if err != nil {
+			t.Fatalf("Step failed: %v", err)
+		}
+		if !state.Gear.OnGround {
+			t.Errorf("Aircraft should be on the ground")
+		}
+		assertApproxEqual(t, state.Altitude, 500.0, 1e-9)
+		if earthVel := state.Orientation.RotateVector(state.Velocity); earthVel.Z > 1e-9 {
+			t.Errorf("Sink rate should be removed on contact, got %.3f m/s", earthVel.Z)
+		}
+	})
+}
+
+func TestGroundEffectDecay(t *testing.T) {
+	config := loadP51DConfig(t)
+
+	// fly steps the P-51D once, level at 100 m/s and the given angle of
+	// attack, over terrain agl meters below it
+	fly := func(agl, alpha float64) (*AircraftState, *FlightDynamicsEngine) {
+		engine := NewFlightDynamicsEngine(config, NewEulerIntegrator())
+		engine.Terrain = NewFlatTerrain(500.0 - agl)
+		state := NewAircraftState()
+		state.Altitude = 500.0
+		state.Position = Vector3{Z: -500.0}
+		state.Orientation = NewQuaternionFromEuler(0, alpha, 0)
+		state.Velocity = Vector3{X: 100.0 * math.Cos(alpha), Z: -100.0 * math.Sin(alpha)}
+		state.UpdateAtmosphere()
+		state.UpdateDerivedParameters()
+		next, err := engine.Step(state, 0.01)
+		if err != nil {
+			t.Fatalf("Step at %.0f m AGL failed: %v", agl, err)
+		}
+		return next, engine
+	}
+	lift := func(state *AircraftState) float64 { return -state.Forces.Aerodynamic.Z }
+
+	t.Run("Factor Reaches Free Air Within A Span", func(t *testing.T) {
+		kCLge := findFunction(config.Aerodynamics.Function, "aero/function/kCLge")
+		if kCLge == nil {
+			t.Fatal("P-51D has no aero/function/kCLge")
+		}
+		factor := func(hb float64) float64 {
+			value, err := EvaluateFunction(kCLge, map[string]float64{"aero/h_b-mac-ft": hb})
+			if err != nil {
+				t.Fatalf("EvaluateFunction: %v", err)
+			}
+			return value
+		}
+		assertApproxEqual(t, factor(0), 1.229, 1e-9)
+		assertApproxEqual(t, factor(0.9), 1.001, 1e-9)
+		assertApproxEqual(t, factor(1.0), 1.0, 1e-9)
+		assertApproxEqual(t, factor(5.0), 1.0, 1e-9)
+	})
+
+	t.Run("Level Flight At 2, 10 And 100 m", func(t *testing.T) {
+		// The lift at 4 degrees out of ground effect, held at each height by
+		// bisecting for alpha, over which range lift rises with alpha
+		free, _ := fly(1000, 4*DEG_TO_RAD)
+		type point struct{ agl, alpha, kCLge, drag float64 }
+		var points []point
+		for _, agl := range []float64{2, 10, 100} {
+			low, high := -2*DEG_TO_RAD, 10*DEG_TO_RAD
+			for i := 0; i < 50; i++ {
+				if state, _ := fly(agl, (low+high)/2); lift(state) < lift(free) {
+					low = (low + high) / 2
+				} else {
+					high = (low + high) / 2
+				}
+			}
+			state, engine := fly(agl, low)
+			p := point{agl, low, engine.Calculator.Properties.Get("aero/function/kCLge"), -state.Forces.Aerodynamic.X}
+			points = append(points, p)
+			t.Logf("%5.0f m AGL: kCLge %.4f, alpha %.3f deg, drag %.1f N", agl, p.kCLge, p.alpha*RAD_TO_DEG, p.drag)
+		}
+
+		// h/b is 0.18 at 2 m and 0.88 at 10 m for the 37.1 ft span
+		assertApproxEqual(t, points[2].kCLge, 1.0, 1e-12)
+		assertApproxEqual(t, points[2].alpha, 4*DEG_TO_RAD, 1e-6)
+		for i := 1; i < len(points); i++ {
+			near, far := points[i-1], points[i]
+			if !(near.kCLge > far.kCLge) {
+				t.Errorf("kCLge should decay with height: %.4f at %.0f m, %.4f at %.0f m", near.kCLge, near.agl, far.kCLge, far.agl)
+			}
+
+			// Nearer the ground the same lift needs less alpha, and so less
+			// of the alpha-dependent (induced) drag
+			if !(near.alpha < far.alpha && near.drag < far.drag) {
+				t.Errorf("Drag for the same lift should fall nearer the ground: %.1f N at %.0f m, %.1f N at %.0f m",
+					near.drag, near.agl, far.drag, far.agl)
+			}
+		}
+	})
+}
@@ -0,0 +1,297 @@
+// Time Step Analysis
+// Recommends an integration step from the fastest dynamics an engine
+// carries: its FCS rate groups and filter time constants, the aircraft's
+// short-period and roll modes, and the order of its integrator
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// maxTimeStep bounds every recommendation; longer steps under-resolve the
+// control inputs and ground contact whatever the aircraft
+const maxTimeStep = 0.02
+
+// TimeStepLimit is one bound on the step and why it is there
+type TimeStepLimit struct {
+	Source string  // What sets the bound
+	MaxDt  float64 // Longest step it allows (s)
+	Reason string
+}
+
+// TimeStepReport is the analysis of an engine's time step. The limits are
+// sorted tightest first; the recommended step is the tightest rounded down
+// to a 1, 2, 5 series.
+type TimeStepReport struct {
+	Integrator string
+	Order      int
+
+	FastestRateGroup     string  // Empty without an FCS
+	FastestRateHz        float64 // Rate of the fastest group with components
+	ShortestTimeConstant float64 // Shortest filter or actuator lag (s), zero with none
+	TimeConstantSource   string  // Component with the shortest time constant
+
+	ShortPeriod float64 // Fastest short-period root (rad/s), zero when not estimated
+	Overdamped  bool    // The short-period roots are real rather than a pair
+	RollMode    float64 // Roll-mode time constant (s), zero when not estimated or divergent
+
+	Limits      []TimeStepLimit
+	Recommended float64  // Recommended step (s)
+	Requested   float64  // Step checked against the limits (s), zero when none
+	Warnings    []string // Limits the requested step breaks
+}
+
+// TimeStepOption configures AnalyzeTimeStep
+type TimeStepOption func(*timeStepOptions)
+
+type timeStepOptions struct {
+	state *AircraftState
+	dt    float64
+}
+
+// TimeStepAt estimates the aircraft's modes about a state by perturbing
+// it. Without a state only the FCS and the integrator are analysed.
+func TimeStepAt(state *AircraftState) TimeStepOption {
+	return func(o *timeStepOptions) { o.state = state }
+}
+
+// RequestedTimeStep checks a step (s) against the limits, warning of each
+// it breaks
+func RequestedTimeStep(dt float64) TimeStepOption {
+	return func(o *timeStepOptions) { o.dt = dt }
+}
+
+// derivativeSource is an engine evaluating its derivatives at a state
+type derivativeSource interface {
+	Derivatives(state *AircraftState) (*StateDerivatives, *ForceMomentComponents, error)
+}
+
+// AnalyzeTimeStep recommends an integration step for an engine. The
+// modes are estimated only with TimeStepAt, from the pitch-only short
+// period and the single-axis roll mode of the linearization about the
+// state; the engine's FCS and property tree are left as they were.
+func AnalyzeTimeStep(engine MonteCarloEngine, options ...TimeStepOption) (*TimeStepReport, error) {
+	var opts timeStepOptions
+	for _, option := range options {
+		option(&opts)
+	}
+
+	var integrator Integrator
+	var systems []*FlightControlSystem
+	switch e := engine.(type) {
+	case *FlightDynamicsEngineWithPropulsion:
+		integrator, systems = e.Integrator, []*FlightControlSystem{e.FCS, e.Autopilot}
+	case *FlightDynamicsEngineWithFCS:
+		integrator, systems = e.Integrator, []*FlightControlSystem{e.FCS, e.Autopilot}
+	case *FlightDynamicsEngine:
+		integrator = e.Integrator
+	case *SimplifiedFlightDynamicsEngine:
+		integrator = e.Integrator
+	default:
+		return nil, fmt.Errorf("time step analysis: unsupported engine %T", engine)
+	}
+	if integrator == nil {
+		return nil, fmt.Errorf("time step analysis: engine has no integrator")
+	}
+
+	report := &TimeStepReport{Integrator: integrator.GetName(), Order: max(integrator.GetOrder(), 1)}
+	for _, fcs := range systems {
+		if fcs != nil {
+			report.inspectFCS(fcs)
+		}
+	}
+	if opts.state != nil {
+		if err := report.estimateModes(engine.(derivativeSource), opts.state); err != nil {
+			return nil, fmt.Errorf("time step analysis: %w", err)
+		}
+	}
+	report.addLimits()
+	if opts.dt > 0 {
+		report.check(opts.dt)
+	}
+	return report, nil
+}
+
+// inspectFCS records an FCS's fastest rate group and shortest time
+// constant when they beat those found so far
+func (r *TimeStepReport) inspectFCS(fcs *FlightControlSystem) {
+	for _, name := range fcs.ListRateGroups() {
+		group := fcs.RateGroups[name]
+		if group.Enabled && len(group.Components) > 0 && group.RateHz > r.FastestRateHz {
+			r.FastestRateGroup, r.FastestRateHz = name, group.RateHz
+		}
+	}
+	for _, name := range fcs.ListComponents() {
+		var kind string
+		var tau float64
+		switch c := fcs.Components[name].(type) {
+		case *LagFilterComponent:
+			kind, tau = "lag filter", c.C1
+		case *WashoutFilterComponent:
+			kind, tau = "washout filter", c.C1
+		case *ActuatorComponent:
+			kind, tau = "actuator", c.Lag
+		}
+		if tau > 0 && (r.ShortestTimeConstant == 0 || tau < r.ShortestTimeConstant) {
+			r.ShortestTimeConstant, r.TimeConstantSource = tau, fmt.Sprintf("%s %q", kind, name)
+		}
+	}
+}
+
+// estimateModes estimates the short period from the pitch acceleration's
+// derivatives with alpha and pitch rate, and the roll mode from the roll
+// damping, by central differences about a state
+func (r *TimeStepReport) estimateModes(engine derivativeSource, state *AircraftState) error {
+	derivative := func(perturb func(*AircraftState, float64), h float64, axis func(Vector3) float64) (float64, error) {
+		var dots [2]float64
+		for i, sign := range []float64{1, -1} {
+			perturbed := state.Copy()
+			perturb(perturbed, sign*h)
+			perturbed.UpdateDerivedParameters()
+			derivatives, _, err := engine.Derivatives(perturbed)
+			if err != nil {
+				return 0, err
+			}
+			dots[i] = axis(derivatives.AngularRateDot)
+		}
+		return (dots[0] - dots[1]) / (2 * h), nil
+	}
+	pitch := func(v Vector3) float64 { return v.Y }
+	roll := func(v Vector3) float64 { return v.X }
+
+	// Alpha is perturbed by turning the velocity in the plane of symmetry
+	mAlpha, err := derivative(func(s *AircraftState, d float64) {
+		sin, cos := math.Sincos(d)
+		s.Velocity.X, s.Velocity.Z = s.Velocity.X*cos-s.Velocity.Z*sin, s.Velocity.X*sin+s.Velocity.Z*cos
+	}, 0.5*DEG_TO_RAD, pitch)
+	if err != nil {
+		return err
+	}
+	mQ, err := derivative(func(s *AircraftState, d float64) { s.AngularRate.Y += d }, 0.01, pitch)
+	if err != nil {
+		return err
+	}
+	lP, err := derivative(func(s *AircraftState, d float64) { s.AngularRate.X += d }, 0.01, roll)
+	if err != nil {
+		return err
+	}
+
+	// The roots of s² - Mq s - Mα; a complex pair's magnitude is √-Mα
+	if disc := mQ*mQ + 4*mAlpha; disc < 0 {
+		r.ShortPeriod = math.Sqrt(-mAlpha)
+	} else {
+		root := math.Sqrt(disc)
+		r.ShortPeriod = math.Max(math.Abs(mQ+root), math.Abs(mQ-root)) / 2
+		r.Overdamped = true
+	}
+	if lP < 0 {
+		r.RollMode = -1 / lP
+	}
+	return nil
+}
+
+// addLimits sets the limits and the recommended step. The integrated modes
+// need more steps the lower the integrator's order: 20 per short-period
+// cycle and 4 per time constant of a real root at fourth order.
+func (r *TimeStepReport) addLimits() {
+	r.Limits = append(r.Limits, TimeStepLimit{
+		Source: "general ceiling",
+		MaxDt:  maxTimeStep,
+		Reason: "longer steps under-resolve control inputs and ground contact",
+	})
+	if r.FastestRateHz > 0 {
+		r.Limits = append(r.Limits, TimeStepLimit{
+			Source: fmt.Sprintf("FCS rate group %q", r.FastestRateGroup),
+			MaxDt:  1 / r.FastestRateHz,
+			Reason: fmt.Sprintf("it runs at %g Hz; a longer step runs several of its frames against one state", r.FastestRateHz),
+		})
+	}
+	if r.ShortestTimeConstant > 0 {
+		r.Limits = append(r.Limits, TimeStepLimit{
+			Source: r.TimeConstantSource,
+			MaxDt:  r.ShortestTimeConstant / 4,
+			Reason: fmt.Sprintf("its %s time constant needs 4 steps to resolve its response", formatStep(r.ShortestTimeConstant)),
+		})
+	}
+	steps := max(16/r.Order, 3)
+	if r.ShortPeriod > 0 && r.Overdamped {
+		r.Limits = append(r.Limits, TimeStepLimit{
+			Source: "short period",
+			MaxDt:  1 / (r.ShortPeriod * float64(steps)),
+			Reason: fmt.Sprintf("overdamped, its fastest root a %s time constant; %d steps per time constant for an order %d integrator",
+				formatStep(1/r.ShortPeriod), steps, r.Order),
+		})
+	} else if r.ShortPeriod > 0 {
+		cycle := 80 / r.Order
+		r.Limits = append(r.Limits, TimeStepLimit{
+			Source: "short period",
+			MaxDt:  2 * math.Pi / (r.ShortPeriod * float64(cycle)),
+			Reason: fmt.Sprintf("%.2f Hz; %d steps per cycle, %g times its Nyquist rate, for an order %d integrator",
+				r.ShortPeriod/(2*math.Pi), cycle, float64(cycle)/2, r.Order),
+		})
+	}
+	if r.RollMode > 0 {
+		r.Limits = append(r.Limits, TimeStepLimit{
+			Source: "roll mode",
+			MaxDt:  r.RollMode / float64(steps),
+			Reason: fmt.Sprintf("%s time constant; %d steps per time constant for an order %d integrator",
+				formatStep(r.RollMode), steps, r.Order),
+		})
+	}
+	sort.SliceStable(r.Limits, func(i, j int) bool { return r.Limits[i].MaxDt < r.Limits[j].MaxDt })
+	r.Recommended = roundTimeStep(r.Limits[0].MaxDt)
+}
+
+// check records a requested step and warns of each limit it breaks
+func (r *TimeStepReport) check(dt float64) {
+	r.Requested = dt
+	for _, limit := range r.Limits {
+		if dt > limit.MaxDt*(1+1e-9) {
+			r.Warnings = append(r.Warnings, fmt.Sprintf("dt %s exceeds the %s limit of %s: %s",
+				formatStep(dt), limit.Source, formatStep(limit.MaxDt), limit.Reason))
+		}
+	}
+}
+
+// roundTimeStep rounds a step down to 1, 2 or 5 times a power of ten
+func roundTimeStep(dt float64) float64 {
+	scale := math.Pow(10, math.Floor(math.Log10(dt)))
+	for _, m := range []float64{5, 2, 1} {
+		if m*scale <= dt*(1+1e-9) {
+			return m * scale
+		}
+	}
+	return scale
+}
+
+// formatStep formats a time in milliseconds
+func formatStep(dt float64) string {
+	return fmt.Sprintf("%.3g ms", dt*1000)
+}
+
+// String prints the recommendation with its rationale in the style of the
+// other analysis reports
+func (r *TimeStepReport) String() string {
+	var sb strings.Builder
+	sb.WriteString("Time Step Analysis:\n")
+	fmt.Fprintf(&sb, "  Integrator:      %s (order %d)\n", r.Integrator, r.Order)
+	fmt.Fprintf(&sb, "  Recommended dt:  %s\n", formatStep(r.Recommended))
+	for _, limit := range r.Limits {
+		fmt.Fprintf(&sb, "    %s: dt <= %s, %s\n", limit.Source, formatStep(limit.MaxDt), limit.Reason)
+	}
+	if r.Requested > 0 {
+		fmt.Fprintf(&sb, "  Requested dt:    %s", formatStep(r.Requested))
+		if len(r.Warnings) == 0 {
+			sb.WriteString(", within every limit")
+		}
+		sb.WriteString("\n")
+	}
+	for _, warning := range r.Warnings {
+		fmt.Fprintf(&sb, "  Warning: %s\n", warning)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
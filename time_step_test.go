@@ -0,0 +1,89 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTimeStepAnalysis(t *testing.T) {
+	t.Run("Lag Filter", func(t *testing.T) {
+		engine, err := NewFlightDynamicsEngineWithFCS(loadP51DConfig(t), true)
+		if err != nil {
+			t.Fatalf("NewFlightDynamicsEngineWithFCS: %v", err)
+		}
+		engine.FCS.AddComponent(NewLagFilterComponent("pitch-lag", "fcs/elevator-cmd-norm", "fcs/pitch-lag", 0.005))
+
+		report, err := AnalyzeTimeStep(engine, RequestedTimeStep(0.05))
+		if err != nil {
+			t.Fatalf("AnalyzeTimeStep: %v", err)
+		}
+		assertEqual(t, report.Order, 4)
+		assertEqual(t, report.TimeConstantSource, `lag filter "pitch-lag"`)
+		assertApproxEqual(t, report.ShortestTimeConstant, 0.005, 1e-12)
+		if report.Recommended > 0.002 || report.Recommended < 0.001 {
+			t.Errorf("A 5 ms lag filter should hold dt to 1-2 ms, recommended %g s", report.Recommended)
+		}
+
+		// The filter is the tightest limit, and the report says why
+		assertEqual(t, report.Limits[0].Source, `lag filter "pitch-lag"`)
+		assertApproxEqual(t, report.Limits[0].MaxDt, 0.00125, 1e-12)
+		if text := report.String(); !strings.Contains(text, `lag filter "pitch-lag": dt <= 1.25 ms, its 5 ms time constant`) {
+			t.Errorf("The report should explain the filter's limit:\n%s", text)
+		}
+
+		// 50 ms breaks the filter's limit, the default rate group's frame and
+		// the ceiling
+		var broken []string
+		for _, warning := range report.Warnings {
+			broken = append(broken, strings.SplitN(strings.TrimPrefix(warning, "dt 50 ms exceeds the "), " limit", 2)[0])
+		}
+		assertEqual(t, broken, []string{`lag filter "pitch-lag"`, `FCS rate group "default"`, "general ceiling"})
+
+		// A step within every limit is not warned of
+		report, err = AnalyzeTimeStep(engine, RequestedTimeStep(report.Recommended))
+		if err != nil {
+			t.Fatalf("AnalyzeTimeStep: %v", err)
+		}
+		assertEqual(t, len(report.Warnings), 0)
+	})
+
+	t.Run("Modes", func(t *testing.T) {
+		engine := NewSimplifiedFlightDynamicsEngine(NewEulerIntegrator())
+		state := trimLevelFlight(t, engine, 1000, 100)
+		report, err := AnalyzeTimeStep(engine, TimeStepAt(state), RequestedTimeStep(0.01))
+		if err != nil {
+			t.Fatalf("AnalyzeTimeStep: %v", err)
+		}
+
+		// The model's pitch damping acts on the unnormalised pitch rate, an
+		// Mq of about -540 /s against an Mα of about -90 /s², so the short
+		// period is overdamped with a fast root near 540 rad/s. Lp is about
+		// -11.5 /s.
+		assertEqual(t, report.Overdamped, true)
+		if report.ShortPeriod < 400 || report.ShortPeriod > 700 {
+			t.Errorf("Short period root %.1f rad/s, expected about 540", report.ShortPeriod)
+		}
+		if report.RollMode < 0.05 || report.RollMode > 0.15 {
+			t.Errorf("Roll mode time constant %.3f s, expected about 0.09", report.RollMode)
+		}
+
+		// Euler needs 16 steps per time constant, RK4 only 4
+		assertEqual(t, report.Limits[0].Source, "short period")
+		assertApproxEqual(t, report.Limits[0].MaxDt, 1/(16*report.ShortPeriod), 1e-12)
+		assertEqual(t, len(report.Warnings), 2)
+		assertEqual(t, strings.Contains(report.Warnings[1], "roll mode limit"), true)
+		rk4, err := AnalyzeTimeStep(NewSimplifiedFlightDynamicsEngine(NewRungeKutta4Integrator()), TimeStepAt(state))
+		if err != nil {
+			t.Fatalf("AnalyzeTimeStep: %v", err)
+		}
+		if rk4.Recommended <= report.Recommended {
+			t.Errorf("RK4 should allow a longer step than Euler, %g and %g s", rk4.Recommended, report.Recommended)
+		}
+	})
+
+	t.Run("Rounding", func(t *testing.T) {
+		for dt, want := range map[float64]float64{0.00125: 0.001, 0.0026: 0.002, 0.02: 0.02, 0.0099: 0.005} {
+			assertApproxEqual(t, roundTimeStep(dt), want, 1e-15)
+		}
+	})
+}
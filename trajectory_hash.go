@@ -0,0 +1,240 @@
+// Trajectory Hashing
+// Recorded runs reduced to fixed-point samples and hashed, so deterministic
+// runs can be checked for equality without comparing floats exactly, and a
+// mismatch traced to the first channel and time that moved
+
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+)
+
+// DefaultHashResolution is the quantum of a hashed channel as a fraction
+// of its typical scale
+const DefaultHashResolution = 1e-6
+
+// ChannelScale returns the typical magnitude of a property from the unit
+// suffix of its name; 1 for a property without a known unit
+func ChannelScale(name string) float64 {
+	if pu, ok := LookupPropertyUnit(name); ok {
+		return pu.Scale
+	}
+	return 1
+}
+
+// Quantized values set aside for samples that are not finite numbers
+const (
+	quantizedNaN    = math.MinInt64
+	quantizedNegInf = math.MinInt64 + 1
+	quantizedPosInf = math.MaxInt64
+)
+
+// quantize rounds a value to a whole number of quanta, saturating beyond
+// the range of an int64
+func quantize(value, quantum float64) int64 {
+	switch {
+	case math.IsNaN(value):
+		return quantizedNaN
+	case math.IsInf(value, 1):
+		return quantizedPosInf
+	case math.IsInf(value, -1):
+		return quantizedNegInf
+	}
+	q := math.Round(value / quantum)
+	if q >= math.MaxInt64 {
+		return quantizedPosInf - 1
+	}
+	if q <= math.MinInt64+2 {
+		return quantizedNegInf + 1
+	}
+	return int64(q)
+}
+
+// QuantizedTrajectory is a time history of channels in whole quanta: a row
+// of Samples a time, a value a channel
+type QuantizedTrajectory struct {
+	Channels []string
+	Quanta   []float64 // Quantum of each channel
+	Times    []int64   // Sample times in microseconds
+	Samples  [][]int64
+}
+
+// QuantizeTrajectory quantizes samples of channels, each row taken at the
+// time of the same index, to resolution times each channel's typical
+// scale; DefaultHashResolution when resolution is 0
+func QuantizeTrajectory(channels []string, times []float64, samples [][]float64, resolution float64) (*QuantizedTrajectory, error) {
+	if len(times) != len(samples) {
+		return nil, fmt.Errorf("trajectory has %d times for %d samples", len(times), len(samples))
+	}
+	if resolution <= 0 {
+		resolution = DefaultHashResolution
+	}
+	q := &QuantizedTrajectory{
+		Channels: append([]string(nil), channels...),
+		Quanta:   make([]float64, len(channels)),
+		Times:    make([]int64, len(times)),
+		Samples:  make([][]int64, len(samples)),
+	}
+	for j, name := range channels {
+		q.Quanta[j] = resolution * ChannelScale(name)
+	}
+	for i, row := range samples {
+		if len(row) != len(channels) {
+			return nil, fmt.Errorf("trajectory sample %d has %d values for %d channels", i, len(row), len(channels))
+		}
+		q.Times[i] = quantize(times[i], 1e-6)
+		q.Samples[i] = make([]int64, len(row))
+		for j, value := range row {
+			q.Samples[i][j] = quantize(value, q.Quanta[j])
+		}
+	}
+	return q, nil
+}
+
+// QuantizeRecording quantizes the named channels of a recording
+func QuantizeRecording(rec *Recording, channels []string, resolution float64) (*QuantizedTrajectory, error) {
+	columns := make([]int, len(channels))
+	for j, name := range channels {
+		if !rec.Has(name) {
+			return nil, fmt.Errorf("recording has no %s", name)
+		}
+		columns[j] = rec.index[name]
+	}
+	samples := make([][]float64, len(rec.Values))
+	for i, values := range rec.Values {
+		samples[i] = make([]float64, len(channels))
+		for j, column := range columns {
+			samples[i][j] = values[column]
+		}
+	}
+	return QuantizeTrajectory(channels, rec.Times, samples, resolution)
+}
+
+// QuantizeStates quantizes the named properties of a sequence of states,
+// timed by their simulation times
+func QuantizeStates(states []*AircraftState, channels []string, resolution float64) (*QuantizedTrajectory, error) {
+	properties := make(map[string]float64, propertyMapSize)
+	times := make([]float64, len(states))
+	samples := make([][]float64, len(states))
+	for i, state := range states {
+		state.FillPropertyMap(properties)
+		times[i] = state.Time
+		samples[i] = make([]float64, len(channels))
+		for j, name := range channels {
+			value, ok := properties[name]
+			if !ok {
+				return nil, fmt.Errorf("aircraft state has no %s", name)
+			}
+			samples[i][j] = value
+		}
+	}
+	return QuantizeTrajectory(channels, times, samples, resolution)
+}
+
+// TrajectoryHash is the hash of a quantized trajectory, with a checksum of
+// each channel's samples
+type TrajectoryHash struct {
+	Hash      string            `json:"hash"`
+	Samples   int               `json:"samples"`
+	Checksums map[string]string `json:"checksums"`
+}
+
+// Hash hashes the trajectory: each channel's checksum covers its name,
+// quantum and samples, and the hash covers the sample times and the
+// checksums in channel order
+func (q *QuantizedTrajectory) Hash() *TrajectoryHash {
+	var buf [8]byte
+	write64 := func(h interface{ Write([]byte) (int, error) }, v uint64) {
+		binary.LittleEndian.PutUint64(buf[:], v)
+		h.Write(buf[:])
+	}
+
+	th := &TrajectoryHash{Samples: len(q.Times), Checksums: make(map[string]string, len(q.Channels))}
+	all := fnv.New64a()
+	for _, t := range q.Times {
+		write64(all, uint64(t))
+	}
+	for j, name := range q.Channels {
+		h := fnv.New64a()
+		h.Write([]byte(name))
+		write64(h, math.Float64bits(q.Quanta[j]))
+		for _, row := range q.Samples {
+			write64(h, uint64(row[j]))
+		}
+		sum := h.Sum64()
+		th.Checksums[name] = fmt.Sprintf("%016x", sum)
+		all.Write([]byte(name))
+		write64(all, sum)
+	}
+	th.Hash = fmt.Sprintf("%016x", all.Sum64())
+	return th
+}
+
+// TrajectoryDivergence is where two quantized trajectories first differ
+type TrajectoryDivergence struct {
+	Channel  string // Empty when the times or shape differ
+	Time     float64
+	Index    int
+	Expected float64 // Dequantized values
+	Got      float64
+	Reason   string
+}
+
+func (d *TrajectoryDivergence) String() string {
+	if d.Channel == "" {
+		return d.Reason
+	}
+	return fmt.Sprintf("%s first diverges at t=%gs (sample %d): %.9g → %.9g", d.Channel, d.Time, d.Index, d.Expected, d.Got)
+}
+
+// FirstDivergence returns where got first differs from the trajectory,
+// earliest sample first and then in channel order, or nil when the two
+// are equal
+func (q *QuantizedTrajectory) FirstDivergence(got *QuantizedTrajectory) *TrajectoryDivergence {
+	if strings.Join(q.Channels, ",") != strings.Join(got.Channels, ",") {
+		return &TrajectoryDivergence{Reason: fmt.Sprintf("channels changed from %v to %v", q.Channels, got.Channels)}
+	}
+	for j := range q.Quanta {
+		if q.Quanta[j] != got.Quanta[j] {
+			return &TrajectoryDivergence{Reason: fmt.Sprintf("%s quantum changed from %g to %g", q.Channels[j], q.Quanta[j], got.Quanta[j])}
+		}
+	}
+	n := len(q.Times)
+	if len(got.Times) < n {
+		n = len(got.Times)
+	}
+	for i := 0; i < n; i++ {
+		t := float64(q.Times[i]) * 1e-6
+		if q.Times[i] != got.Times[i] {
+			return &TrajectoryDivergence{Index: i, Time: t,
+				Reason: fmt.Sprintf("sample %d time changed from %gs to %gs", i, t, float64(got.Times[i])*1e-6)}
+		}
+		for j, name := range q.Channels {
+			if a, b := q.Samples[i][j], got.Samples[i][j]; a != b {
+				return &TrajectoryDivergence{Channel: name, Time: t, Index: i,
+					Expected: dequantize(a, q.Quanta[j]), Got: dequantize(b, q.Quanta[j])}
+			}
+		}
+	}
+	if len(q.Times) != len(got.Times) {
+		return &TrajectoryDivergence{Index: n, Reason: fmt.Sprintf("%d samples changed to %d", len(q.Times), len(got.Times))}
+	}
+	return nil
+}
+
+// dequantize returns the value of a quantized sample
+func dequantize(v int64, quantum float64) float64 {
+	switch v {
+	case quantizedNaN:
+		return math.NaN()
+	case quantizedPosInf:
+		return math.Inf(1)
+	case quantizedNegInf:
+		return math.Inf(-1)
+	}
+	return float64(v) * quantum
+}
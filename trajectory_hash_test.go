@@ -0,0 +1,184 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// gustyCruise is a trimmed cruise with the stick stirred by a seeded
+// random stream
+func gustyCruise(t *testing.T, seed int64) *ScenarioSummary {
+	t.Helper()
+	engine := NewSimplifiedFlightDynamicsEngine(NewRungeKutta4Integrator())
+	initial := trimLevelFlight(t, engine, 1000, 100)
+	trim := initial.Controls
+	rng := rand.New(rand.NewSource(seed))
+	scenario := &GoldenScenario{
+		Name:     "gusty_cruise",
+		Duration: 5,
+		Engine:   engine,
+		Initial:  initial,
+		Pilot: func(state *AircraftState) {
+			state.Controls.Elevator = trim.Elevator + 0.02*rng.NormFloat64()
+			state.Controls.Aileron = trim.Aileron + 0.05*rng.NormFloat64()
+		},
+	}
+	summary, err := scenario.Run()
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	return summary
+}
+
+func TestTrajectoryHash(t *testing.T) {
+	t.Run("Channel Scales", func(t *testing.T) {
+		assertEqual(t, ChannelScale("position/h-sl-m"), 1000.0)
+		assertEqual(t, ChannelScale("velocities/vt-mps"), 100.0)
+		assertEqual(t, ChannelScale("accelerations/udot-mps2"), 10.0)
+		assertEqual(t, ChannelScale("velocities/q-rad_sec"), 1.0)
+		assertEqual(t, ChannelScale("aero/alpha-deg"), 100.0)
+		assertEqual(t, ChannelScale("velocities/mach"), 1.0)
+	})
+
+	t.Run("Same Seed", func(t *testing.T) {
+		a, b := gustyCruise(t, 7), gustyCruise(t, 7)
+		assertEqual(t, a.Hash.Samples, 6)
+		assertEqual(t, a.Hash.Hash, b.Hash.Hash)
+		assertEqual(t, a.Hash.Checksums, b.Hash.Checksums)
+		divergence, err := CompareSummaryHashes(a, b)
+		if err != nil {
+			t.Fatalf("CompareSummaryHashes: %v", err)
+		}
+		if divergence != nil {
+			t.Errorf("Runs with the same seed should not diverge: %s", divergence)
+		}
+
+		if other := gustyCruise(t, 8); other.Hash.Hash == a.Hash.Hash {
+			t.Error("Another seed should fly another trajectory")
+		}
+	})
+
+	golden := gustyCruise(t, 7)
+	trace, err := golden.quantizedTrace()
+	if err != nil {
+		t.Fatal(err)
+	}
+	perturbed := func(change func(i, j int, value, quantum float64) float64) *ScenarioSummary {
+		got := *golden
+		got.Trace.Samples = make([][]float64, len(golden.Trace.Samples))
+		for i, row := range golden.Trace.Samples {
+			got.Trace.Samples[i] = make([]float64, len(row))
+			for j, value := range row {
+				got.Trace.Samples[i][j] = change(i, j, value, trace.Quanta[j])
+			}
+		}
+		q, err := got.quantizedTrace()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got.Hash = q.Hash()
+		return &got
+	}
+
+	t.Run("Perturbed Sample", func(t *testing.T) {
+		// Ten quanta on the airspeed at 3 s
+		channel := 1
+		assertEqual(t, GoldenTraceChannels[channel], "velocities/vt-mps")
+		got := perturbed(func(i, j int, value, quantum float64) float64 {
+			if i == 3 && j == channel {
+				return value + 10*quantum
+			}
+			return value
+		})
+		if got.Hash.Hash == golden.Hash.Hash {
+			t.Fatal("A sample moved beyond the quantization should change the hash")
+		}
+		for name, sum := range golden.Hash.Checksums {
+			if changed := got.Hash.Checksums[name] != sum; changed != (name == "velocities/vt-mps") {
+				t.Errorf("%s checksum changed: %v", name, changed)
+			}
+		}
+
+		divergence, err := CompareSummaryHashes(golden, got)
+		if err != nil {
+			t.Fatalf("CompareSummaryHashes: %v", err)
+		}
+		if divergence == nil {
+			t.Fatal("Expected a divergence")
+		}
+		assertEqual(t, divergence.Channel, "velocities/vt-mps")
+		assertEqual(t, divergence.Time, 3.0)
+		assertEqual(t, divergence.Index, 3)
+		assertApproxEqual(t, divergence.Got-divergence.Expected, 10*trace.Quanta[channel], 1e-9)
+		if !strings.Contains(divergence.String(), "velocities/vt-mps first diverges at t=3s") {
+			t.Errorf("The divergence should name the channel and time: %s", divergence)
+		}
+	})
+
+	t.Run("Sub-Quantum Noise", func(t *testing.T) {
+		// Every sample moved halfway to the middle of its quantum
+		got := perturbed(func(i, j int, value, quantum float64) float64 {
+			offset := value/quantum - math.Round(value/quantum)
+			return value - 0.5*offset*quantum
+		})
+		moved := 0
+		for i, row := range got.Trace.Samples {
+			for j, value := range row {
+				if value != golden.Trace.Samples[i][j] {
+					moved++
+				}
+			}
+		}
+		if moved == 0 {
+			t.Fatal("The noise should move the samples")
+		}
+		assertEqual(t, got.Hash.Hash, golden.Hash.Hash)
+		if divergence, _ := CompareSummaryHashes(golden, got); divergence != nil {
+			t.Errorf("Noise below the quantization should not diverge: %s", divergence)
+		}
+	})
+
+	t.Run("Shape And Specials", func(t *testing.T) {
+		channels := []string{"position/h-sl-m"}
+		a, err := QuantizeTrajectory(channels, []float64{0, 1}, [][]float64{{100}, {math.NaN()}}, 0)
+		if err != nil {
+			t.Fatalf("QuantizeTrajectory: %v", err)
+		}
+		b, _ := QuantizeTrajectory(channels, []float64{0, 1}, [][]float64{{100}, {math.NaN()}}, 0)
+		assertEqual(t, a.Hash().Hash, b.Hash().Hash)
+		if d := a.FirstDivergence(b); d != nil {
+			t.Errorf("NaN samples should match: %s", d)
+		}
+		c, _ := QuantizeTrajectory(channels, []float64{0, 1}, [][]float64{{100}, {math.Inf(1)}}, 0)
+		if d := a.FirstDivergence(c); d == nil || d.Channel != "position/h-sl-m" || d.Index != 1 {
+			t.Errorf("A NaN becoming infinite should diverge at sample 1: %v", d)
+		}
+
+		longer, _ := QuantizeTrajectory(channels, []float64{0, 1, 2}, [][]float64{{100}, {math.NaN()}, {100}}, 0)
+		if d := a.FirstDivergence(longer); d == nil || !strings.Contains(d.String(), "2 samples changed to 3") {
+			t.Errorf("A longer trajectory should diverge: %v", d)
+		}
+		if _, err := QuantizeTrajectory(channels, []float64{0}, [][]float64{{1, 2}}, 0); err == nil {
+			t.Error("A row of the wrong width should not quantize")
+		}
+	})
+
+	t.Run("Recording", func(t *testing.T) {
+		csv := "simulation/sim-time-sec,position/h-sl-m,velocities/vt-mps\n0,1000,100\n0.5,1000.25,100.5\n"
+		rec, err := ReadRecording(strings.NewReader(csv), nil)
+		if err != nil {
+			t.Fatalf("ReadRecording: %v", err)
+		}
+		q, err := QuantizeRecording(rec, []string{"velocities/vt-mps", "position/h-sl-m"}, 0)
+		if err != nil {
+			t.Fatalf("QuantizeRecording: %v", err)
+		}
+		assertEqual(t, q.Times, []int64{0, 500000})
+		assertEqual(t, q.Samples[1], []int64{1005000, 1000250})
+		if _, err := QuantizeRecording(rec, []string{"aero/alpha-deg"}, 0); err == nil {
+			t.Error("A channel the recording lacks should not quantize")
+		}
+	})
+}
@@ -0,0 +1,171 @@
+// Trajectory Interpolation
+// Resample recorded aircraft states at arbitrary times for replay
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// TrajectorySample is one recorded state and the time it was recorded at
+type TrajectorySample struct {
+	Time  float64
+	State *AircraftState
+}
+
+// TrajectoryInterpolator returns states between recorded samples, for
+// example to play a 100 Hz recording back at 60 fps. Translational and
+// other continuous quantities are interpolated linearly and the attitude
+// by SLERP, so replay does not jump where Euler angles wrap. Discrete
+// quantities (gear and engine switches, weight on wheels) are taken from
+// the earlier sample.
+type TrajectoryInterpolator struct {
+	samples []TrajectorySample
+}
+
+// NewTrajectoryInterpolator creates an interpolator over samples, which
+// must be in strictly increasing time order
+func NewTrajectoryInterpolator(samples []TrajectorySample) (*TrajectoryInterpolator, error) {
+	ti := &TrajectoryInterpolator{}
+	for _, sample := range samples {
+		if err := ti.Add(sample.Time, sample.State); err != nil {
+			return nil, err
+		}
+	}
+	return ti, nil
+}
+
+// Add appends a sample recorded after all the others. The state is copied.
+func (ti *TrajectoryInterpolator) Add(t float64, state *AircraftState) error {
+	if state == nil {
+		return fmt.Errorf("trajectory sample at t=%g has no state", t)
+	}
+	if n := len(ti.samples); n > 0 && t <= ti.samples[n-1].Time {
+		return fmt.Errorf("trajectory sample at t=%g is not after the previous sample at t=%g", t, ti.samples[n-1].Time)
+	}
+	ti.samples = append(ti.samples, TrajectorySample{Time: t, State: state.Copy()})
+	return nil
+}
+
+// Len returns the number of samples
+func (ti *TrajectoryInterpolator) Len() int {
+	return len(ti.samples)
+}
+
+// Span returns the times of the first and last samples
+func (ti *TrajectoryInterpolator) Span() (start, end float64) {
+	if len(ti.samples) == 0 {
+		return 0, 0
+	}
+	return ti.samples[0].Time, ti.samples[len(ti.samples)-1].Time
+}
+
+// At returns the state at time t, which must lie within the recording.
+// The returned state is new and may be modified by the caller.
+func (ti *TrajectoryInterpolator) At(t float64) (*AircraftState, error) {
+	start, end := ti.Span()
+	if len(ti.samples) == 0 || t < start || t > end {
+		return nil, fmt.Errorf("time %g is outside the recording [%g, %g]", t, start, end)
+	}
+
+	if len(ti.samples) == 1 {
+		return interpolateSamples(ti.samples[0], ti.samples[0], t, 0), nil
+	}
+
+	// First sample after t; the last sample itself ends the final interval
+	i := sort.Search(len(ti.samples), func(i int) bool { return ti.samples[i].Time > t })
+	i = min(i, len(ti.samples)-1)
+	a, b := ti.samples[i-1], ti.samples[i]
+	return interpolateSamples(a, b, t, (t-a.Time)/(b.Time-a.Time)), nil
+}
+
+// interpolateSamples blends two samples at fraction f of the way from a to b
+func interpolateSamples(a, b TrajectorySample, t, f float64) *AircraftState {
+	sa, sb := a.State, b.State
+	state := sa.Copy()
+	lerp := func(x, y float64) float64 { return x + (y-x)*f }
+	lerpVec := func(x, y Vector3) Vector3 { return x.Add(y.Add(x.Scale(-1)).Scale(f)) }
+
+	state.Time = t
+	state.Timestamp = sa.Timestamp.Add(time.Duration(float64(sb.Timestamp.Sub(sa.Timestamp)) * f))
+
+	// Position and attitude
+	state.Position = lerpVec(sa.Position, sb.Position)
+	state.Latitude = lerp(sa.Latitude, sb.Latitude)
+	state.Longitude = lerp(sa.Longitude, sb.Longitude)
+	state.Altitude = lerp(sa.Altitude, sb.Altitude)
+	state.Orientation = sa.Orientation.Slerp(sb.Orientation, f)
+
+	// Rates and accelerations
+	state.Velocity = lerpVec(sa.Velocity, sb.Velocity)
+	state.Wind = lerpVec(sa.Wind, sb.Wind)
+	state.Acceleration = lerpVec(sa.Acceleration, sb.Acceleration)
+	state.AngularRate = lerpVec(sa.AngularRate, sb.AngularRate)
+	state.AngularAccel = lerpVec(sa.AngularAccel, sb.AngularAccel)
+	state.LoadFactor = lerpVec(sa.LoadFactor, sb.LoadFactor)
+	state.PilotSpecificForce = lerpVec(sa.PilotSpecificForce, sb.PilotSpecificForce)
+	state.SpecificExcessPower = lerp(sa.SpecificExcessPower, sb.SpecificExcessPower)
+
+	// Controls and effectors
+	state.Controls.Aileron = lerp(sa.Controls.Aileron, sb.Controls.Aileron)
+	state.Controls.Elevator = lerp(sa.Controls.Elevator, sb.Controls.Elevator)
+	state.Controls.Rudder = lerp(sa.Controls.Rudder, sb.Controls.Rudder)
+	state.Controls.Throttle = lerp(sa.Controls.Throttle, sb.Controls.Throttle)
+	state.Controls.Flaps = lerp(sa.Controls.Flaps, sb.Controls.Flaps)
+	state.Controls.Brake = lerp(sa.Controls.Brake, sb.Controls.Brake)
+	state.Controls.Mixture = lerp(sa.Controls.Mixture, sb.Controls.Mixture)
+	state.Controls.Propeller = lerp(sa.Controls.Propeller, sb.Controls.Propeller)
+	state.Controls.BrakeLeft = lerp(sa.Controls.BrakeLeft, sb.Controls.BrakeLeft)
+	state.Controls.BrakeRight = lerp(sa.Controls.BrakeRight, sb.Controls.BrakeRight)
+	state.Controls.Steer = lerp(sa.Controls.Steer, sb.Controls.Steer)
+
+	cs, csa, csb := &state.ControlSurfaces, &sa.ControlSurfaces, &sb.ControlSurfaces
+	cs.AileronLeft = lerp(csa.AileronLeft, csb.AileronLeft)
+	cs.AileronRight = lerp(csa.AileronRight, csb.AileronRight)
+	cs.Elevator = lerp(csa.Elevator, csb.Elevator)
+	cs.Rudder = lerp(csa.Rudder, csb.Rudder)
+	cs.FlapLeft = lerp(csa.FlapLeft, csb.FlapLeft)
+	cs.FlapRight = lerp(csa.FlapRight, csb.FlapRight)
+	cs.Trim.Aileron = lerp(csa.Trim.Aileron, csb.Trim.Aileron)
+	cs.Trim.Elevator = lerp(csa.Trim.Elevator, csb.Trim.Elevator)
+	cs.Trim.Rudder = lerp(csa.Trim.Rudder, csb.Trim.Rudder)
+
+	state.Engine.RPM = lerp(sa.Engine.RPM, sb.Engine.RPM)
+	state.Engine.ManifoldP = lerp(sa.Engine.ManifoldP, sb.Engine.ManifoldP)
+	state.Engine.FuelFlow = lerp(sa.Engine.FuelFlow, sb.Engine.FuelFlow)
+	state.Engine.EGT = lerp(sa.Engine.EGT, sb.Engine.EGT)
+	state.Engine.CHT = lerp(sa.Engine.CHT, sb.Engine.CHT)
+	state.Engine.OilTemp = lerp(sa.Engine.OilTemp, sb.Engine.OilTemp)
+	state.Engine.OilPress = lerp(sa.Engine.OilPress, sb.Engine.OilPress)
+	state.Engine.Thrust = lerp(sa.Engine.Thrust, sb.Engine.Thrust)
+
+	state.Gear.Transition = lerp(sa.Gear.Transition, sb.Gear.Transition)
+	state.Gear.Doors = lerp(sa.Gear.Doors, sb.Gear.Doors)
+	state.Gear.GroundHeight = lerp(sa.Gear.GroundHeight, sb.Gear.GroundHeight)
+	state.Gear.Compression.Main = lerp(sa.Gear.Compression.Main, sb.Gear.Compression.Main)
+	state.Gear.Compression.Nose = lerp(sa.Gear.Compression.Nose, sb.Gear.Compression.Nose)
+
+	// Forces and moments
+	state.Forces.Aerodynamic = lerpVec(sa.Forces.Aerodynamic, sb.Forces.Aerodynamic)
+	state.Forces.Propulsive = lerpVec(sa.Forces.Propulsive, sb.Forces.Propulsive)
+	state.Forces.Gravity = lerpVec(sa.Forces.Gravity, sb.Forces.Gravity)
+	state.Forces.External = lerpVec(sa.Forces.External, sb.Forces.External)
+	state.Forces.Ground = lerpVec(sa.Forces.Ground, sb.Forces.Ground)
+	state.Forces.Total = lerpVec(sa.Forces.Total, sb.Forces.Total)
+	state.Moments.Aerodynamic = lerpVec(sa.Moments.Aerodynamic, sb.Moments.Aerodynamic)
+	state.Moments.Propulsive = lerpVec(sa.Moments.Propulsive, sb.Moments.Propulsive)
+	state.Moments.Gyroscopic = lerpVec(sa.Moments.Gyroscopic, sb.Moments.Gyroscopic)
+	state.Moments.External = lerpVec(sa.Moments.External, sb.Moments.External)
+	state.Moments.Total = lerpVec(sa.Moments.Total, sb.Moments.Total)
+
+	// Recompute air data and angles from the blended state. Alpha and beta
+	// rates are blended from the recording rather than differenced across
+	// the interpolated time.
+	state.angleRates = angleRateHistory{}
+	state.UpdateFlightConditions()
+	state.AlphaDot = lerp(sa.AlphaDot, sb.AlphaDot)
+	state.BetaDot = lerp(sa.BetaDot, sb.BetaDot)
+	return state
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTrajectoryInterpolator(t *testing.T) {
+	// Record a banked turn at 100 Hz
+	engine := NewSimplifiedFlightDynamicsEngine(NewEulerIntegrator())
+	state := trimLevelFlight(t, engine, 1000.0, 100.0)
+	controls := state.Controls
+	controls.Aileron = 0.3
+	state.SetControlInputs(controls)
+
+	var samples []TrajectorySample
+	for i := 0; i <= 300; i++ {
+		samples = append(samples, TrajectorySample{Time: state.Time, State: state})
+		next, err := engine.Step(state, 0.01)
+		if err != nil {
+			t.Fatalf("Step %d failed: %v", i, err)
+		}
+		state = next
+	}
+	ti, err := NewTrajectoryInterpolator(samples)
+	if err != nil {
+		t.Fatalf("NewTrajectoryInterpolator: %v", err)
+	}
+	start, end := ti.Span()
+	assertEqual(t, ti.Len(), 301)
+	if math.Abs(samples[300].State.Roll) < 0.1 {
+		t.Fatalf("The recording should roll into the turn, roll %g", samples[300].State.Roll)
+	}
+
+	t.Run("Replay At 60 fps", func(t *testing.T) {
+		for frame := 0; ; frame++ {
+			at := start + float64(frame)/60
+			if at > end {
+				break
+			}
+			s, err := ti.At(at)
+			if err != nil {
+				t.Fatalf("At(%g): %v", at, err)
+			}
+			q := s.Orientation
+			assertApproxEqual(t, math.Sqrt(q.Dot(q)), 1.0, 1e-12)
+			assertApproxEqual(t, s.Time, at, 1e-12)
+
+			// Between the samples on either side
+			i := int((at - start) / 0.01)
+			if i+1 < len(samples) {
+				a, b := samples[i].State, samples[i+1].State
+				if s.Position.X < math.Min(a.Position.X, b.Position.X)-1e-9 || s.Position.X > math.Max(a.Position.X, b.Position.X)+1e-9 {
+					t.Fatalf("Position %g at t=%g outside [%g, %g]", s.Position.X, at, a.Position.X, b.Position.X)
+				}
+				if s.Orientation.AngleTo(a.Orientation) > a.Orientation.AngleTo(b.Orientation)+1e-9 {
+					t.Fatalf("Attitude at t=%g is not between the samples", at)
+				}
+			}
+		}
+	})
+
+	t.Run("Samples And Derived Parameters", func(t *testing.T) {
+		exact, err := ti.At(samples[150].Time)
+		if err != nil {
+			t.Fatalf("At: %v", err)
+		}
+		assertApproxEqual(t, exact.Position.X, samples[150].State.Position.X, 1e-9)
+		assertApproxEqual(t, exact.Roll, samples[150].State.Roll, 1e-9)
+
+		mid, err := ti.At((samples[150].Time + samples[151].Time) / 2)
+		if err != nil {
+			t.Fatalf("At: %v", err)
+		}
+		roll, _, _ := mid.Orientation.ToEuler()
+		assertEqual(t, mid.Roll, roll)
+		assertApproxEqual(t, mid.TrueAirspeed, mid.Velocity.Magnitude(), 1e-12)
+
+		last, err := ti.At(end)
+		if err != nil {
+			t.Fatalf("At(end): %v", err)
+		}
+		assertApproxEqual(t, last.Altitude, samples[300].State.Altitude, 1e-9)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		if _, err := ti.At(end + 0.01); err == nil {
+			t.Error("Expected an error after the recording")
+		}
+		if _, err := ti.At(start - 0.01); err == nil {
+			t.Error("Expected an error before the recording")
+		}
+		if err := ti.Add(end, state); err == nil {
+			t.Error("Expected an error for a sample out of order")
+		}
+		if _, err := NewTrajectoryInterpolator(nil); err != nil {
+			t.Errorf("Empty recording: %v", err)
+		}
+	})
+}
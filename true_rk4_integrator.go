@@ -18,6 +18,11 @@ func NewTrueRK4Integrator(dynamicsFunc DynamicsFunction) *TrueRK4Integrator {
 	}
 }
 
+// SetDynamics sets the dynamics evaluated at the intermediate stages
+func (rk *TrueRK4Integrator) SetDynamics(dynamicsFunc DynamicsFunction) {
+	rk.DynamicsFunc = dynamicsFunc
+}
+
 func (rk *TrueRK4Integrator) GetName() string {
 	return "True Runge-Kutta 4th Order"
 }
@@ -37,28 +42,32 @@ func (rk *TrueRK4Integrator) Integrate(state *AircraftState, derivatives *StateD
 	
 	// k1: derivatives at current state (already provided)
 	k1 := derivatives
+	k1_orient := rk.quaternionDerivative(state.Orientation, state.AngularRate)
 	
 	// k2: evaluate dynamics at midpoint using k1 slope
-	midState1 := rk.advanceState(state, k1, dt*0.5)
+	midState1, midQuat1 := rk.advanceState(state, k1, k1_orient, dt*0.5)
 	k2, err := rk.DynamicsFunc(midState1)
 	if err != nil {
 		// Fallback to simplified if dynamics evaluation fails
 		return rk.simplifiedIntegrate(state, derivatives, dt)
 	}
+	k2_orient := rk.quaternionDerivative(midQuat1, midState1.AngularRate)
 	
 	// k3: evaluate dynamics at midpoint using k2 slope
-	midState2 := rk.advanceState(state, k2, dt*0.5)
+	midState2, midQuat2 := rk.advanceState(state, k2, k2_orient, dt*0.5)
 	k3, err := rk.DynamicsFunc(midState2)
 	if err != nil {
 		return rk.simplifiedIntegrate(state, derivatives, dt)
 	}
+	k3_orient := rk.quaternionDerivative(midQuat2, midState2.AngularRate)
 	
 	// k4: evaluate dynamics at endpoint using k3 slope
-	endState := rk.advanceState(state, k3, dt)
+	endState, endQuat := rk.advanceState(state, k3, k3_orient, dt)
 	k4, err := rk.DynamicsFunc(endState)
 	if err != nil {
 		return rk.simplifiedIntegrate(state, derivatives, dt)
 	}
+	k4_orient := rk.quaternionDerivative(endQuat, endState.AngularRate)
 	
 	// Combine using RK4 weights
 	newState := state.Copy()
@@ -87,33 +96,24 @@ func (rk *TrueRK4Integrator) Integrate(state *AircraftState, derivatives *StateD
 	avgAngDot := k1_ang.Add(k2_ang.Scale(2)).Add(k3_ang.Scale(2)).Add(k4_ang).Scale(1.0/6.0)
 	newState.AngularRate = state.AngularRate.Add(avgAngDot.Scale(dt))
 	
-	// Orientation integration (quaternion)
-	k1_orient := rk.quaternionDerivative(state.Orientation, state.AngularRate)
-	midAngRate1 := state.AngularRate.Add(k1.AngularRateDot.Scale(dt*0.5))
-	midOrient1 := state.Orientation.Add(k1_orient.Scale(dt*0.5)).Normalize()
-	k2_orient := rk.quaternionDerivative(midOrient1, midAngRate1)
-	
-	midAngRate2 := state.AngularRate.Add(k2.AngularRateDot.Scale(dt*0.5))
-	midOrient2 := state.Orientation.Add(k2_orient.Scale(dt*0.5)).Normalize()
-	k3_orient := rk.quaternionDerivative(midOrient2, midAngRate2)
-	
-	finalAngRate := state.AngularRate.Add(k3.AngularRateDot.Scale(dt))
-	finalOrient := state.Orientation.Add(k3_orient.Scale(dt)).Normalize()
-	k4_orient := rk.quaternionDerivative(finalOrient, finalAngRate)
-	
+	// Orientation integration (quaternion), using the same stages the
+	// dynamics were evaluated at
 	avgOrientDot := k1_orient.Add(k2_orient.Scale(2)).Add(k3_orient.Scale(2)).Add(k4_orient).Scale(1.0/6.0)
 	newState.Orientation = state.Orientation.Add(avgOrientDot.Scale(dt)).Normalize()
 	
 	// Update derived parameters
 	newState.Altitude = -newState.Position.Z
 	newState.Time = state.Time + dt
-	newState.UpdateDerivedParameters()
+	newState.UpdateFlightConditions()
 	
 	return newState
 }
 
-// advanceState creates an intermediate state by advancing with given derivatives
-func (rk *TrueRK4Integrator) advanceState(state *AircraftState, derivatives *StateDerivatives, dt float64) *AircraftState {
+// advanceState creates an intermediate state by advancing with given derivatives.
+// The orientation is advanced along orientDot, the quaternion slope of the
+// previous stage, and the unnormalized stage quaternion is returned so the
+// next slope is taken from the true RK4 stage rather than a renormalized one.
+func (rk *TrueRK4Integrator) advanceState(state *AircraftState, derivatives *StateDerivatives, orientDot Quaternion, dt float64) (*AircraftState, Quaternion) {
 	newState := state.Copy()
 	
 	// Advance position
@@ -126,15 +126,15 @@ func (rk *TrueRK4Integrator) advanceState(state *AircraftState, derivatives *Sta
 	newState.AngularRate = state.AngularRate.Add(derivatives.AngularRateDot.Scale(dt))
 	
 	// Advance orientation
-	orientDot := rk.quaternionDerivative(state.Orientation, state.AngularRate)
-	newState.Orientation = state.Orientation.Add(orientDot.Scale(dt)).Normalize()
+	stageQuat := state.Orientation.Add(orientDot.Scale(dt))
+	newState.Orientation = stageQuat.Normalize()
 	
 	// Update derived parameters
 	newState.Altitude = -newState.Position.Z
 	newState.Time = state.Time + dt
-	newState.UpdateDerivedParameters()
+	newState.UpdateFlightConditions()
 	
-	return newState
+	return newState, stageQuat
 }
 
 // quaternionDerivative calculates quaternion derivative from angular velocity
@@ -170,7 +170,7 @@ func (rk *TrueRK4Integrator) simplifiedIntegrate(state *AircraftState, derivativ
 	
 	newState.Altitude = -newState.Position.Z
 	newState.Time = state.Time + dt
-	newState.UpdateDerivedParameters()
+	newState.UpdateFlightConditions()
 	
 	return newState
 }
@@ -189,6 +189,11 @@ func NewIntegratorWithDynamics(dynamicsFunc DynamicsFunction) *IntegratorWithDyn
 	}
 }
 
+// SetDynamics sets the dynamics of the true RK4
+func (iwd *IntegratorWithDynamics) SetDynamics(dynamicsFunc DynamicsFunction) {
+	iwd.TrueRK4.DynamicsFunc = dynamicsFunc
+}
+
 func (iwd *IntegratorWithDynamics) GetName() string {
 	return "RK4 with Dynamics Re-evaluation"
 }
@@ -0,0 +1,392 @@
+// Units
+// The units reports, recorders and the CLI show values in: SI, the
+// imperial units of aviation, or either with some quantities overridden,
+// built from the conversion constants and the unit suffixes of property
+// names
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Quantity is a kind of physical value a unit is chosen for
+type Quantity string
+
+const (
+	QuantitySpeed         Quantity = "speed"
+	QuantityVerticalSpeed Quantity = "vertical-speed"
+	QuantityAltitude      Quantity = "altitude"
+	QuantityDistance      Quantity = "distance"
+	QuantityLength        Quantity = "length"
+	QuantityArea          Quantity = "area"
+	QuantityMass          Quantity = "mass"
+	QuantityForce         Quantity = "force"
+	QuantityMoment        Quantity = "moment"
+	QuantityInertia       Quantity = "inertia"
+	QuantityPressure      Quantity = "pressure"
+	QuantityDensity       Quantity = "density"
+	QuantityAngle         Quantity = "angle"
+	QuantityAngularRate   Quantity = "angular-rate"
+	QuantityAcceleration  Quantity = "acceleration"
+	QuantityPower         Quantity = "power"
+	QuantityFuelFlow      Quantity = "fuel-flow"
+	QuantityTime          Quantity = "time"
+)
+
+// Unit is a unit of a quantity
+type Unit struct {
+	Name   string  // ASCII name, for headers and preference specs
+	Symbol string  // As written in reports
+	PerSI  float64 // Value in this unit of one of the quantity's SI unit
+}
+
+// quantityUnits are the units of each quantity, the SI unit first
+var quantityUnits = map[Quantity][]Unit{
+	QuantitySpeed: {
+		{"m/s", "m/s", 1},
+		{"kt", "kt", MS_TO_KT},
+		{"km/h", "km/h", 3.6},
+		{"mph", "mph", 1 / MPH_TO_MS},
+		{"ft/s", "ft/s", M_TO_FT},
+	},
+	QuantityVerticalSpeed: {
+		{"m/s", "m/s", 1},
+		{"ft/min", "ft/min", M_TO_FT * 60},
+		{"ft/s", "ft/s", M_TO_FT},
+	},
+	QuantityAltitude: {
+		{"m", "m", 1},
+		{"ft", "ft", M_TO_FT},
+		{"km", "km", 0.001},
+	},
+	QuantityDistance: {
+		{"m", "m", 1},
+		{"ft", "ft", M_TO_FT},
+		{"km", "km", 0.001},
+		{"nmi", "nmi", 1 / 1852.0},
+	},
+	QuantityLength: {
+		{"m", "m", 1},
+		{"ft", "ft", M_TO_FT},
+		{"in", "in", M_TO_FT * FT_TO_IN},
+		{"cm", "cm", 100},
+		{"mm", "mm", 1000},
+	},
+	QuantityArea: {
+		{"m2", "m²", 1},
+		{"ft2", "ft²", M2_TO_FT2},
+		{"in2", "in²", M2_TO_FT2 * FT_TO_IN * FT_TO_IN},
+	},
+	QuantityMass: {
+		{"kg", "kg", 1},
+		{"lb", "lb", KG_TO_LB},
+		{"slug", "slug", KG_TO_LB / SLUG_TO_LB},
+	},
+	QuantityForce: {
+		{"N", "N", 1},
+		{"lbf", "lbf", N_TO_LB},
+		{"kN", "kN", 0.001},
+	},
+	QuantityMoment: {
+		{"Nm", "N·m", 1},
+		{"ft-lbf", "ft·lbf", N_TO_LB * M_TO_FT},
+	},
+	QuantityInertia: {
+		{"kg-m2", "kg·m²", 1},
+		{"slug-ft2", "slug·ft²", KG_TO_LB / SLUG_TO_LB * M_TO_FT * M_TO_FT},
+	},
+	QuantityPressure: {
+		{"Pa", "Pa", 1},
+		{"hPa", "hPa", 0.01},
+		{"psf", "psf", N_TO_LB / (M_TO_FT * M_TO_FT)},
+		{"psi", "psi", N_TO_LB / (M_TO_FT * M_TO_FT * FT_TO_IN * FT_TO_IN)},
+		{"inHg", "inHg", manifoldPressureUnits["PA"]},
+	},
+	QuantityDensity: {
+		{"kg/m3", "kg/m³", 1},
+		{"slug/ft3", "slug/ft³", KG_TO_LB / SLUG_TO_LB / (M_TO_FT * M_TO_FT * M_TO_FT)},
+	},
+	QuantityAngle: {
+		{"rad", "rad", 1},
+		{"deg", "°", RAD_TO_DEG},
+	},
+	QuantityAngularRate: {
+		{"rad/s", "rad/s", 1},
+		{"deg/s", "°/s", RAD_TO_DEG},
+	},
+	QuantityAcceleration: {
+		{"m/s2", "m/s²", 1},
+		{"ft/s2", "ft/s²", M_TO_FT},
+		{"g", "g", 1 / StandardGravity},
+	},
+	QuantityPower: {
+		{"W", "W", 1},
+		{"kW", "kW", 0.001},
+		{"hp", "hp", W_TO_HP},
+	},
+	QuantityFuelFlow: {
+		{"kg/s", "kg/s", 1},
+		{"kg/h", "kg/h", 3600},
+		{"lb/h", "lb/h", KG_TO_LB * 3600},
+	},
+	QuantityTime: {
+		{"s", "s", 1},
+		{"min", "min", 1 / 60.0},
+		{"h", "h", 1 / 3600.0},
+	},
+}
+
+// LookupUnit finds a unit of a quantity by name or symbol
+func LookupUnit(q Quantity, name string) (Unit, bool) {
+	for _, u := range quantityUnits[q] {
+		if name == u.Name || name == u.Symbol {
+			return u, true
+		}
+	}
+	for _, u := range quantityUnits[q] {
+		if strings.EqualFold(name, u.Name) {
+			return u, true
+		}
+	}
+	return Unit{}, false
+}
+
+// UnitSystem is a set of units for every quantity
+type UnitSystem int
+
+const (
+	// UnitsSI shows SI units, with angles in degrees as the reports always
+	// have
+	UnitsSI UnitSystem = iota
+	// UnitsImperialAviation shows the units of the cockpit: knots, feet,
+	// feet per minute, pounds
+	UnitsImperialAviation
+)
+
+// systemUnits are the unit names of each system by quantity
+var systemUnits = map[UnitSystem]map[Quantity]string{
+	UnitsSI: {
+		QuantitySpeed: "m/s", QuantityVerticalSpeed: "m/s", QuantityAltitude: "m",
+		QuantityDistance: "m", QuantityLength: "m", QuantityArea: "m2",
+		QuantityMass: "kg", QuantityForce: "N", QuantityMoment: "Nm",
+		QuantityInertia: "kg-m2", QuantityPressure: "Pa", QuantityDensity: "kg/m3",
+		QuantityAngle: "deg", QuantityAngularRate: "deg/s", QuantityAcceleration: "m/s2",
+		QuantityPower: "W", QuantityFuelFlow: "kg/s", QuantityTime: "s",
+	},
+	UnitsImperialAviation: {
+		QuantitySpeed: "kt", QuantityVerticalSpeed: "ft/min", QuantityAltitude: "ft",
+		QuantityDistance: "ft", QuantityLength: "ft", QuantityArea: "ft2",
+		QuantityMass: "lb", QuantityForce: "lbf", QuantityMoment: "ft-lbf",
+		QuantityInertia: "slug-ft2", QuantityPressure: "psf", QuantityDensity: "slug/ft3",
+		QuantityAngle: "deg", QuantityAngularRate: "deg/s", QuantityAcceleration: "ft/s2",
+		QuantityPower: "hp", QuantityFuelFlow: "lb/h", QuantityTime: "s",
+	},
+}
+
+// unitSystemNames are the names ParseUnitPreferences accepts for each system
+var unitSystemNames = map[string]UnitSystem{
+	"si":                UnitsSI,
+	"metric":            UnitsSI,
+	"imperial":          UnitsImperialAviation,
+	"aviation":          UnitsImperialAviation,
+	"imperial-aviation": UnitsImperialAviation,
+}
+
+// UnitPreferences chooses the units values are shown in. The zero value
+// shows SI.
+type UnitPreferences struct {
+	System UnitSystem
+
+	// Unit names by quantity, in place of the system's
+	Overrides map[Quantity]string
+
+	// Dual follows each value with its value in the other system, as
+	// "250.0 kt (128.6 m/s)"
+	Dual bool
+}
+
+// SIUnits returns the preferences for SI units
+func SIUnits() UnitPreferences {
+	return UnitPreferences{System: UnitsSI}
+}
+
+// ImperialAviationUnits returns the preferences for imperial aviation units
+func ImperialAviationUnits() UnitPreferences {
+	return UnitPreferences{System: UnitsImperialAviation}
+}
+
+// Unit returns the unit a quantity is shown in: its override when it names
+// a unit of the quantity, the system's otherwise
+func (p UnitPreferences) Unit(q Quantity) Unit {
+	if name, ok := p.Overrides[q]; ok {
+		if u, ok := LookupUnit(q, name); ok {
+			return u
+		}
+	}
+	u, _ := LookupUnit(q, systemUnits[p.System][q])
+	return u
+}
+
+// Convert converts an SI value of a quantity to the preferred unit
+func (p UnitPreferences) Convert(q Quantity, si float64) float64 {
+	return si * p.Unit(q).PerSI
+}
+
+// Format writes an SI value of a quantity in the preferred unit with the
+// given decimals, and when Dual is set in the other system's unit after
+// it
+func (p UnitPreferences) Format(q Quantity, si float64, decimals int) string {
+	return p.format(q, si, func(v float64) string {
+		return strconv.FormatFloat(v, 'f', decimals, 64)
+	})
+}
+
+// format writes an SI value as Format does, with its own number format
+func (p UnitPreferences) format(q Quantity, si float64, number func(float64) string) string {
+	u := p.Unit(q)
+	s := formatInUnit(number(si*u.PerSI), u)
+	if !p.Dual {
+		return s
+	}
+	other := UnitsImperialAviation
+	if p.System == UnitsImperialAviation {
+		other = UnitsSI
+	}
+	if v, _ := LookupUnit(q, systemUnits[other][q]); v.Name != u.Name {
+		s += " (" + formatInUnit(number(si*v.PerSI), v) + ")"
+	}
+	return s
+}
+
+// formatInUnit writes a number with a unit's symbol, degrees against the
+// number
+func formatInUnit(number string, u Unit) string {
+	if strings.HasPrefix(u.Symbol, "°") {
+		return number + u.Symbol
+	}
+	return number + " " + u.Symbol
+}
+
+// ParseUnitPreferences reads preferences from a comma-separated spec: a
+// system ("si", or "imperial" or "aviation"), "dual", and quantity=unit
+// overrides, as "imperial,altitude=m,dual"
+func ParseUnitPreferences(spec string) (UnitPreferences, error) {
+	var p UnitPreferences
+	for _, field := range strings.Split(spec, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if q, name, ok := strings.Cut(field, "="); ok {
+			q := Quantity(strings.ToLower(strings.TrimSpace(q)))
+			if _, known := quantityUnits[q]; !known {
+				return p, fmt.Errorf("unknown quantity %q, want one of %s", q, strings.Join(quantityNames(), ", "))
+			}
+			name = strings.TrimSpace(name)
+			u, ok := LookupUnit(q, name)
+			if !ok {
+				return p, fmt.Errorf("unknown %s unit %q", q, name)
+			}
+			if p.Overrides == nil {
+				p.Overrides = make(map[Quantity]string)
+			}
+			p.Overrides[q] = u.Name
+			continue
+		}
+		if strings.EqualFold(field, "dual") {
+			p.Dual = true
+			continue
+		}
+		system, ok := unitSystemNames[strings.ToLower(field)]
+		if !ok {
+			return p, fmt.Errorf("unknown unit system %q, want si or imperial", field)
+		}
+		p.System = system
+	}
+	return p, nil
+}
+
+// quantityNames returns the quantities' names, sorted
+func quantityNames() []string {
+	names := make([]string, 0, len(quantityUnits))
+	for q := range quantityUnits {
+		names = append(names, string(q))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PropertyUnit is what a property's name says of its unit, by the suffix
+// it ends in: the quantity and unit when there is one, and the typical
+// magnitude of such a property in its unit
+type PropertyUnit struct {
+	Suffix   string
+	Quantity Quantity // Empty for a ratio or a unit without a preference
+	Unit     string   // Name of the unit in the quantity's units
+	Scale    float64
+}
+
+// propertyUnits are the unit suffixes of property names, the longest
+// matching one applying
+var propertyUnits = []PropertyUnit{
+	{"-rad_sec", QuantityAngularRate, "rad/s", 1},
+	{"-rad_sec2", "", "", 10},
+	{"-deg_sec", QuantityAngularRate, "deg/s", 100},
+	{"-rad", QuantityAngle, "rad", 1},
+	{"-deg", QuantityAngle, "deg", 100},
+	{"-m", QuantityLength, "m", 1000},
+	{"-ft", QuantityLength, "ft", 1000},
+	{"/h-sl-m", QuantityAltitude, "m", 1000},
+	{"/h-agl-m", QuantityAltitude, "m", 1000},
+	{"/h-agl-ft", QuantityAltitude, "ft", 1000},
+	{"/terrain-elevation-m", QuantityAltitude, "m", 1000},
+	{"/energy-height-m", QuantityAltitude, "m", 1000},
+	{"-mps", QuantitySpeed, "m/s", 100},
+	{"-fps", QuantitySpeed, "ft/s", 100},
+	{"-kts", QuantitySpeed, "kt", 100},
+	{"/Ps-mps", QuantityVerticalSpeed, "m/s", 100},
+	{"-mps2", QuantityAcceleration, "m/s2", 10},
+	{"-fps2", QuantityAcceleration, "ft/s2", 10},
+	{"-pct", "", "", 100},
+	{"-norm", "", "", 1},
+	{"-sec", QuantityTime, "s", 100},
+	{"-lbs", QuantityForce, "lbf", 1000},
+	{"-N", QuantityForce, "N", 10000},
+	{"-Nm", QuantityMoment, "Nm", 10000},
+	{"-hp", QuantityPower, "hp", 1000},
+	{"-Pa", QuantityPressure, "Pa", 100000},
+	{"-psf", QuantityPressure, "psf", 100},
+	{"-inHg", QuantityPressure, "inHg", 10},
+	{"-kgm3", QuantityDensity, "kg/m3", 1},
+	{"-slugs_ft3", QuantityDensity, "slug/ft3", 0.01},
+}
+
+// LookupPropertyUnit finds the unit a property's name ends in
+func LookupPropertyUnit(name string) (PropertyUnit, bool) {
+	var found PropertyUnit
+	for _, pu := range propertyUnits {
+		if strings.HasSuffix(name, pu.Suffix) && len(pu.Suffix) > len(found.Suffix) {
+			found = pu
+		}
+	}
+	return found, found.Suffix != ""
+}
+
+// PropertyColumn returns the header of a recorded property in the
+// preferred units, its name without the unit suffix followed by the unit
+// as "velocities/vt (kt)", and the factor converting its values to that
+// unit. Properties without a quantity keep their name and values.
+func (p UnitPreferences) PropertyColumn(name string) (string, float64) {
+	pu, ok := LookupPropertyUnit(name)
+	if !ok || pu.Quantity == "" {
+		return name, 1
+	}
+	from, _ := LookupUnit(pu.Quantity, pu.Unit)
+	to := p.Unit(pu.Quantity)
+	base := name[:strings.LastIndex(name, "-")]
+	return base + " (" + to.Name + ")", to.PerSI / from.PerSI
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestUnitPreferences(t *testing.T) {
+	t.Run("Conversions", func(t *testing.T) {
+		imperial := ImperialAviationUnits()
+		v := 123.456
+		assertEqual(t, imperial.Convert(QuantitySpeed, v), v*MS_TO_KT)
+		assertEqual(t, imperial.Convert(QuantityVerticalSpeed, v), v*(M_TO_FT*60))
+		assertEqual(t, imperial.Convert(QuantityAltitude, v), v*M_TO_FT)
+		assertEqual(t, imperial.Convert(QuantityMass, v), v*KG_TO_LB)
+		assertEqual(t, imperial.Convert(QuantityForce, v), v*N_TO_LB)
+		assertEqual(t, imperial.Convert(QuantityPower, v), v*W_TO_HP)
+		assertEqual(t, imperial.Convert(QuantityAngle, v), v*RAD_TO_DEG)
+
+		// SI values stay as they are, angles apart
+		si := SIUnits()
+		assertEqual(t, si.Convert(QuantitySpeed, v), v)
+		assertEqual(t, si.Convert(QuantityAngle, v), v*RAD_TO_DEG)
+		assertEqual(t, UnitPreferences{}.Unit(QuantityAltitude).Name, "m")
+
+		// The units of the conversion constants round trip
+		for q, units := range quantityUnits {
+			assertEqual(t, units[0].PerSI, 1.0)
+			for _, u := range units {
+				found, ok := LookupUnit(q, u.Symbol)
+				if !ok || found != u {
+					t.Errorf("%s unit %s not found by its symbol", q, u.Name)
+				}
+			}
+		}
+	})
+
+	t.Run("Format", func(t *testing.T) {
+		imperial := ImperialAviationUnits()
+		assertEqual(t, imperial.Format(QuantitySpeed, 250*KT_TO_MS, 1), "250.0 kt")
+		assertEqual(t, imperial.Format(QuantityAngle, 0.05, 1), "2.9°")
+
+		imperial.Dual = true
+		assertEqual(t, imperial.Format(QuantitySpeed, 250*KT_TO_MS, 1), "250.0 kt (128.6 m/s)")
+		assertEqual(t, imperial.Format(QuantityAltitude, 1000*FT_TO_M, 0), "1000 ft (305 m)")
+		// Degrees in both systems, so once
+		assertEqual(t, imperial.Format(QuantityAngle, 0.05, 1), "2.9°")
+
+		si := UnitPreferences{Dual: true}
+		assertEqual(t, si.Format(QuantitySpeed, 250*KT_TO_MS, 1), "128.6 m/s (250.0 kt)")
+	})
+
+	t.Run("Parse", func(t *testing.T) {
+		p, err := ParseUnitPreferences("imperial, altitude=m, dual")
+		if err != nil {
+			t.Fatalf("ParseUnitPreferences: %v", err)
+		}
+		assertEqual(t, p.System, UnitsImperialAviation)
+		assertEqual(t, p.Dual, true)
+		assertEqual(t, p.Unit(QuantityAltitude).Name, "m")
+		assertEqual(t, p.Unit(QuantitySpeed).Name, "kt")
+		// Overridden to the other system's unit, no second value
+		assertEqual(t, p.Format(QuantityAltitude, 1500, 0), "1500 m")
+
+		p, err = ParseUnitPreferences("si,speed=KT,fuel-flow=lb/h")
+		if err != nil {
+			t.Fatalf("ParseUnitPreferences: %v", err)
+		}
+		assertEqual(t, p.Unit(QuantitySpeed).Name, "kt")
+		assertEqual(t, p.Unit(QuantityFuelFlow).Name, "lb/h")
+		assertEqual(t, p.Unit(QuantityAltitude).Name, "m")
+
+		for _, bad := range []string{"furlongs", "speed=furlong/fortnight", "warp=kt"} {
+			if _, err := ParseUnitPreferences(bad); err == nil {
+				t.Errorf("%q: expected an error", bad)
+			}
+		}
+	})
+
+	t.Run("Property Columns", func(t *testing.T) {
+		imperial := ImperialAviationUnits()
+		for _, c := range []struct {
+			name, header string
+			factor       float64
+		}{
+			{"velocities/vt-mps", "velocities/vt (kt)", MS_TO_KT},
+			{"position/h-sl-m", "position/h-sl (ft)", M_TO_FT},
+			{"accelerations/udot-mps2", "accelerations/udot (ft/s2)", M_TO_FT},
+			{"velocities/q-rad_sec", "velocities/q (deg/s)", RAD_TO_DEG},
+			{"forces/fbx-N", "forces/fbx (lbf)", N_TO_LB},
+			{"moments/m-Nm", "moments/m (ft-lbf)", N_TO_LB * M_TO_FT},
+			{"performance/Ps-mps", "performance/Ps (ft/min)", M_TO_FT * 60},
+			{"propulsion/engine/thrust-lbs", "propulsion/engine/thrust (lbf)", 1},
+			{"fcs/elevator-cmd-norm", "fcs/elevator-cmd-norm", 1},
+			{"aero/mach", "aero/mach", 1},
+		} {
+			header, factor := imperial.PropertyColumn(c.name)
+			assertEqual(t, header, c.header)
+			assertApproxEqual(t, factor, c.factor, 1e-12)
+		}
+
+		header, factor := SIUnits().PropertyColumn("aero/qbar-psf")
+		assertEqual(t, header, "aero/qbar (Pa)")
+		assertApproxEqual(t, factor*N_TO_LB/(M_TO_FT*M_TO_FT), 1, 1e-12)
+		header, _ = UnitPreferences{Overrides: map[Quantity]string{QuantityAltitude: "ft"}}.PropertyColumn("position/h-agl-m")
+		assertEqual(t, header, "position/h-agl (ft)")
+	})
+}
+
+func TestPerformanceReportUnits(t *testing.T) {
+	stats := &FlightStatistics{
+		FlightTime:         600,
+		MinLoadFactor:      0.8,
+		MaxLoadFactor:      2.5,
+		MaxClimbRate:       12.7,
+		MaxFlightPathAngle: 0.2,
+		BestClimbRate:      12.1,
+		BestClimbSpeed:     75,
+		BestClimbGamma:     0.16,
+		MaxSpeed:           128.6,
+		MaxAltitude:        3048,
+		MaxLiftToDrag:      14.2,
+		MaxLiftToDragSpeed: 70,
+		TotalFuelBurned:    45,
+	}
+	line := func(report, label string) string {
+		for _, l := range strings.Split(report, "\n") {
+			if strings.HasPrefix(strings.TrimSpace(l), label+":") {
+				return strings.TrimSpace(l)
+			}
+		}
+		t.Fatalf("No %s line in\n%s", label, report)
+		return ""
+	}
+
+	si := stats.Report(SIUnits())
+	imperial := stats.Report(ImperialAviationUnits())
+	t.Logf("SI:\n%s\nImperial:\n%s", si, imperial)
+	for _, c := range []struct {
+		label, si, imperial string
+	}{
+		{"Flight Time", "Flight Time: 600.0 seconds", "Flight Time: 600.0 seconds"},
+		{"Load Factor", "Load Factor: 0.80 to 2.50 g", "Load Factor: 0.80 to 2.50 g"},
+		{"Max Climb Rate", "Max Climb Rate: 12.7 m/s",
+			fmt.Sprintf("Max Climb Rate: %.1f ft/min", 12.7*M_TO_FT*60)},
+		{"Max Flight Path Angle", "Max Flight Path Angle: 11.5° (9.2° at Vy 75.0 m/s)",
+			fmt.Sprintf("Max Flight Path Angle: 11.5° (9.2° at Vy %.1f kt)", 75*MS_TO_KT)},
+		{"Max Speed", "Max Speed: 128.6 m/s", fmt.Sprintf("Max Speed: %.1f kt", 128.6*MS_TO_KT)},
+		{"Max Altitude", "Max Altitude: 3048 m", fmt.Sprintf("Max Altitude: %.0f ft", 3048*M_TO_FT)},
+		{"Max L/D", "Max L/D: 14.20 at 70.0 m/s", fmt.Sprintf("Max L/D: 14.20 at %.1f kt", 70*MS_TO_KT)},
+		{"Total Fuel Burned", "Total Fuel Burned: 45.00 kg", fmt.Sprintf("Total Fuel Burned: %.2f lb", 45*KG_TO_LB)},
+		{"Average Fuel Flow", "Average Fuel Flow: 0.075 kg/s",
+			fmt.Sprintf("Average Fuel Flow: %.3f lb/h", 45.0/600*KG_TO_LB*3600)},
+	} {
+		assertEqual(t, line(si, c.label), c.si)
+		assertEqual(t, line(imperial, c.label), c.imperial)
+	}
+	assertEqual(t, line(imperial, "Max Speed"), "Max Speed: 250.0 kt")
+	assertEqual(t, line(imperial, "Max Altitude"), "Max Altitude: 10000 ft")
+
+	dual := ImperialAviationUnits()
+	dual.Dual = true
+	assertEqual(t, line(stats.Report(dual), "Max Speed"), "Max Speed: 250.0 kt (128.6 m/s)")
+
+	// The engine reports in its own units
+	engine := NewFlightDynamicsEngine(loadP51DConfig(t), NewRungeKutta4Integrator())
+	engine.Statistics = stats
+	engine.Units = ImperialAviationUnits()
+	assertEqual(t, engine.GetPerformanceReport(), imperial)
+	assertEqual(t, engine.NewSession().Units, engine.Units)
+}
+
+func TestReportUnits(t *testing.T) {
+	landing := &LandingReport{
+		Flared: true, FlareHeight: 6, FlareTime: 10, ApproachSinkRate: 3.5,
+		TouchdownTime: 14, SinkRate: 1.2, PitchAttitude: 0.1, Airspeed: 45, GroundSpeed: 44,
+		ThresholdDistance: 300, Crosswind: 5, CrabAngle: 0.05, LateralVelocity: 0.2, MaxSideLoad: 1500,
+		RolloutDistance: 600,
+	}
+	assertEqual(t, landing.Format(SIUnits()), landing.String())
+	imperial := landing.Format(ImperialAviationUnits())
+	for _, want := range []string{
+		fmt.Sprintf("from %.2f ft/min sink", 3.5*M_TO_FT*60),
+		fmt.Sprintf("at %.1f kt TAS", 45*MS_TO_KT),
+		fmt.Sprintf("%.1f ft past the threshold", 300*M_TO_FT),
+		fmt.Sprintf("%.0f lbf side load", 1500*N_TO_LB),
+		fmt.Sprintf("Rollout:         %.1f ft", 600*M_TO_FT),
+	} {
+		if !strings.Contains(imperial, want) {
+			t.Errorf("The landing report should read %q:\n%s", want, imperial)
+		}
+	}
+
+	takeoff := &TakeoffReport{Lifted: true, LiftoffTime: 20, LiftoffSpeed: 50, GroundRoll: 1300,
+		Cleared: true, TimeTo50ft: 25, DistanceTo50ft: 1700, InitialClimbRate: 6}
+	assertEqual(t, takeoff.Format(SIUnits()), takeoff.String())
+	if !strings.Contains(takeoff.String(), "Ground roll:     1300 m") {
+		t.Errorf("The SI report should read as before:\n%s", takeoff)
+	}
+	imperial = takeoff.Format(ImperialAviationUnits())
+	if want := fmt.Sprintf("Ground roll:     %.0f ft", 1300*M_TO_FT); !strings.Contains(imperial, want) {
+		t.Errorf("The takeoff report should read %q:\n%s", want, imperial)
+	}
+}
@@ -0,0 +1,339 @@
+// Validation Harness
+// Compares a CAMSim run against a reference time history, such as the CSV
+// output of JSBSim for the same aircraft, initial condition and controls
+
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+)
+
+// ValidationColumn maps a reference column to a CAMSim property. The
+// reference value times Scale, plus Offset, is in the property's units.
+type ValidationColumn struct {
+	Property string  `json:"property"`
+	Scale    float64 `json:"scale,omitempty"` // 1 when 0
+	Offset   float64 `json:"offset,omitempty"`
+}
+
+// ValidationChannel is a property compared between the runs, with its
+// tolerances; a zero tolerance is not checked
+type ValidationChannel struct {
+	Property       string  `json:"property"`
+	MaxError       float64 `json:"max_error,omitempty"`       // Largest absolute error
+	RMSError       float64 `json:"rms_error,omitempty"`       // Root mean square error
+	MinCorrelation float64 `json:"min_correlation,omitempty"` // Lowest correlation of the two time histories
+}
+
+// ValidationSpec describes a validation case: how to read the reference
+// and what to compare. Reference columns not in Columns are read as
+// property names. One column must map to simulation/sim-time-sec.
+type ValidationSpec struct {
+	Name        string                      `json:"name"`
+	Description string                      `json:"description,omitempty"`
+	Dt          float64                     `json:"dt,omitempty"`       // CAMSim step (s); the reference's sample interval when 0
+	Duration    float64                     `json:"duration,omitempty"` // Span compared from the first sample (s); the whole reference when 0
+	Columns     map[string]ValidationColumn `json:"columns"`
+	Channels    []ValidationChannel         `json:"channels"`
+}
+
+// LoadValidationSpec reads a JSON validation spec
+func LoadValidationSpec(r io.Reader) (*ValidationSpec, error) {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	spec := &ValidationSpec{}
+	if err := decoder.Decode(spec); err != nil {
+		return nil, fmt.Errorf("reading validation spec: %w", err)
+	}
+	if len(spec.Channels) == 0 {
+		return nil, fmt.Errorf("validation spec %q compares no channels", spec.Name)
+	}
+	for _, channel := range spec.Channels {
+		if channel.Property == "" {
+			return nil, fmt.Errorf("validation spec %q has a channel without a property", spec.Name)
+		}
+	}
+	if spec.Dt < 0 || spec.Duration < 0 {
+		return nil, fmt.Errorf("validation spec %q has a negative dt or duration", spec.Name)
+	}
+	return spec, nil
+}
+
+// ReadReference reads a reference CSV time history, renaming and scaling
+// its columns as the spec maps them
+func ReadReference(r io.Reader, spec *ValidationSpec) (*Recording, error) {
+	rec, err := readRecording(csv.NewReader(r), func(header string) string {
+		header = strings.TrimSpace(header)
+		if column, ok := spec.Columns[header]; ok {
+			return column.Property
+		}
+		return normalizePropertyName(header)
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, column := range spec.Columns {
+		j, ok := rec.index[column.Property]
+		if !ok {
+			continue
+		}
+		scale := column.Scale
+		if scale == 0 {
+			scale = 1
+		}
+		for _, row := range rec.Values {
+			row[j] = row[j]*scale + column.Offset
+		}
+	}
+	// The time column may itself have been scaled
+	for i, row := range rec.Values {
+		rec.Times[i] = row[rec.index["simulation/sim-time-sec"]]
+	}
+	return rec, nil
+}
+
+// ChannelResult is the comparison of one channel
+type ChannelResult struct {
+	Property     string
+	Samples      int
+	MaxError     float64 // Largest absolute error
+	MaxErrorTime float64 // Reference time of the largest error (s)
+	RMSError     float64
+	Bias         float64 // Mean of CAMSim less the reference
+	Correlation  float64 // Pearson correlation; NaN when either history is constant
+	Passed       bool
+	Failures     []string // Tolerances exceeded
+}
+
+// ValidationReport is the outcome of a validation run
+type ValidationReport struct {
+	Name     string
+	Dt       float64 // CAMSim step (s)
+	Start    float64 // First reference time compared (s)
+	End      float64 // Last reference time compared (s)
+	Channels []*ChannelResult
+	Passed   bool
+}
+
+// String renders the report in the style of the other analysis reports
+func (r *ValidationReport) String() string {
+	var sb strings.Builder
+	status := "PASS"
+	if !r.Passed {
+		status = "FAIL"
+	}
+	sb.WriteString(fmt.Sprintf("Validation Report: %s (%s)\n", r.Name, status))
+	sb.WriteString(fmt.Sprintf("  %.2f-%.2f s at dt=%.4g s\n", r.Start, r.End, r.Dt))
+	sb.WriteString(fmt.Sprintf("  %-28s %12s %10s %12s %12s %8s\n", "Channel", "Max error", "at (s)", "RMS error", "Bias", "Corr"))
+	for _, c := range r.Channels {
+		corr := "n/a"
+		if !math.IsNaN(c.Correlation) {
+			corr = fmt.Sprintf("%.3f", c.Correlation)
+		}
+		sb.WriteString(fmt.Sprintf("  %-28s %12.4g %10.2f %12.4g %12.4g %8s", c.Property, c.MaxError, c.MaxErrorTime, c.RMSError, c.Bias, corr))
+		if !c.Passed {
+			sb.WriteString("  FAIL: " + strings.Join(c.Failures, ", "))
+		}
+		sb.WriteString("\n")
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// ValidationHarness runs CAMSim over a reference time history. The run
+// starts from the reference's first sample, as Recording.State rebuilds it,
+// and is given the reference's pilot commands before every step, so the
+// reference needs its primary state and control columns. CAMSim's states
+// are interpolated to the reference's sample times for the comparison.
+type ValidationHarness struct {
+	Spec      *ValidationSpec
+	Reference *Recording
+	Engine    MonteCarloEngine
+
+	// Prepare, when set, adjusts the initial state before the run, as for
+	// settings the reference does not record
+	Prepare func(state *AircraftState)
+}
+
+// NewValidationHarness creates a harness running engine against a reference
+func NewValidationHarness(spec *ValidationSpec, reference *Recording, engine MonteCarloEngine) *ValidationHarness {
+	return &ValidationHarness{Spec: spec, Reference: reference, Engine: engine}
+}
+
+// recordedControls are the pilot commands taken from the reference
+var recordedControls = []struct {
+	property string
+	field    func(c *ControlInputs) *float64
+}{
+	{"fcs/aileron-cmd-norm", func(c *ControlInputs) *float64 { return &c.Aileron }},
+	{"fcs/elevator-cmd-norm", func(c *ControlInputs) *float64 { return &c.Elevator }},
+	{"fcs/rudder-cmd-norm", func(c *ControlInputs) *float64 { return &c.Rudder }},
+	{"fcs/throttle-cmd-norm", func(c *ControlInputs) *float64 { return &c.Throttle }},
+	{"fcs/flap-cmd-norm", func(c *ControlInputs) *float64 { return &c.Flaps }},
+	{"fcs/steer-cmd-norm", func(c *ControlInputs) *float64 { return &c.Steer }},
+}
+
+// dt returns the CAMSim step: the spec's, or the reference's median sample
+// interval
+func (vh *ValidationHarness) dt() float64 {
+	if vh.Spec.Dt > 0 {
+		return vh.Spec.Dt
+	}
+	times := vh.Reference.Times
+	if len(times) < 2 {
+		return 0
+	}
+	intervals := make([]float64, len(times)-1)
+	for i := range intervals {
+		intervals[i] = times[i+1] - times[i]
+	}
+	sort.Float64s(intervals)
+	return intervals[len(intervals)/2]
+}
+
+// Run simulates the reference span and compares the channels
+func (vh *ValidationHarness) Run() (*ValidationReport, error) {
+	ref := vh.Reference
+	dt := vh.dt()
+	if dt <= 0 {
+		return nil, fmt.Errorf("validation %q: the reference needs two samples or the spec a dt", vh.Spec.Name)
+	}
+	columns := make([]int, len(vh.Spec.Channels))
+	for i, channel := range vh.Spec.Channels {
+		j, ok := ref.index[channel.Property]
+		if !ok {
+			return nil, fmt.Errorf("validation %q: the reference has no %s", vh.Spec.Name, channel.Property)
+		}
+		columns[i] = j
+	}
+
+	start := ref.Times[0]
+	end := ref.Times[len(ref.Times)-1]
+	if vh.Spec.Duration > 0 {
+		end = math.Min(end, start+vh.Spec.Duration)
+	}
+
+	// Run CAMSim over the span with the reference's commands
+	state := ref.State(0)
+	if vh.Prepare != nil {
+		vh.Prepare(state)
+	}
+	samples := []TrajectorySample{{Time: state.Time, State: state}}
+	steps := int(math.Ceil((end-start)/dt - 1e-6))
+	for i := 0; i < steps; i++ {
+		for _, control := range recordedControls {
+			if value, ok := ref.Value(control.property, state.Time); ok {
+				*control.field(&state.Controls) = value
+			}
+		}
+		next, err := vh.Engine.Step(state, dt)
+		if err != nil {
+			return nil, fmt.Errorf("validation %q: step at t=%.3f s failed: %w", vh.Spec.Name, state.Time, err)
+		}
+		state = next
+		samples = append(samples, TrajectorySample{Time: state.Time, State: state})
+	}
+	run, err := NewTrajectoryInterpolator(samples)
+	if err != nil {
+		return nil, err
+	}
+
+	// Gather both histories at the reference's sample times
+	simulated := make([][]float64, len(columns))
+	reference := make([][]float64, len(columns))
+	var times []float64
+	properties := make(map[string]float64, propertyMapSize)
+	// The run's clock may fall short of the last sample by rounding
+	_, runEnd := run.Span()
+	for i, t := range ref.Times {
+		if t > end+1e-9 {
+			break
+		}
+		at, err := run.At(math.Min(t, runEnd))
+		if err != nil {
+			return nil, err
+		}
+		at.FillPropertyMap(properties)
+		for c, channel := range vh.Spec.Channels {
+			value, ok := properties[channel.Property]
+			if !ok {
+				return nil, fmt.Errorf("validation %q: CAMSim does not publish %s", vh.Spec.Name, channel.Property)
+			}
+			simulated[c] = append(simulated[c], value)
+			reference[c] = append(reference[c], ref.Values[i][columns[c]])
+		}
+		times = append(times, t)
+	}
+
+	report := &ValidationReport{Name: vh.Spec.Name, Dt: dt, Start: start, End: times[len(times)-1], Passed: true}
+	for c, channel := range vh.Spec.Channels {
+		result := compareChannel(channel, times, simulated[c], reference[c])
+		report.Passed = report.Passed && result.Passed
+		report.Channels = append(report.Channels, result)
+	}
+	return report, nil
+}
+
+// compareChannel computes a channel's error metrics and checks them
+// against its tolerances. An undefined correlation is not checked.
+func compareChannel(channel ValidationChannel, times, simulated, reference []float64) *ChannelResult {
+	result := &ChannelResult{Property: channel.Property, Samples: len(times), Passed: true}
+	var sumSq, sumErr float64
+	for i := range times {
+		e := simulated[i] - reference[i]
+		if math.Abs(e) > result.MaxError || i == 0 {
+			result.MaxError, result.MaxErrorTime = math.Abs(e), times[i]
+		}
+		sumSq += e * e
+		sumErr += e
+	}
+	n := float64(len(times))
+	result.RMSError = math.Sqrt(sumSq / n)
+	result.Bias = sumErr / n
+	result.Correlation = correlation(simulated, reference)
+
+	fail := func(format string, args ...any) {
+		result.Passed = false
+		result.Failures = append(result.Failures, fmt.Sprintf(format, args...))
+	}
+	if channel.MaxError > 0 && result.MaxError > channel.MaxError {
+		fail("max error over %g", channel.MaxError)
+	}
+	if channel.RMSError > 0 && result.RMSError > channel.RMSError {
+		fail("RMS error over %g", channel.RMSError)
+	}
+	if channel.MinCorrelation > 0 && !math.IsNaN(result.Correlation) && result.Correlation < channel.MinCorrelation {
+		fail("correlation under %g", channel.MinCorrelation)
+	}
+	return result
+}
+
+// correlation returns the Pearson correlation of two series, or NaN when
+// either does not vary
+func correlation(a, b []float64) float64 {
+	n := float64(len(a))
+	var meanA, meanB float64
+	for i := range a {
+		meanA += a[i]
+		meanB += b[i]
+	}
+	meanA /= n
+	meanB /= n
+	var cov, varA, varB float64
+	for i := range a {
+		da, db := a[i]-meanA, b[i]-meanB
+		cov += da * db
+		varA += da * da
+		varB += db * db
+	}
+	// Variation at rounding level is no variation
+	if varA <= 1e-24*n*(1+meanA*meanA) || varB <= 1e-24*n*(1+meanB*meanB) {
+		return math.NaN()
+	}
+	return cov / math.Sqrt(varA*varB)
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"testing"
+)
+
+// referenceColumns are written by writeReference in JSBSim's English units
+var referenceColumns = []struct {
+	header   string
+	property string
+	scale    float64
+}{
+	{"Time", "simulation/sim-time-sec", 1},
+	{"position/h-sl-ft", "position/h-sl-m", 1 / FT_TO_M},
+	{"velocities/u-fps", "velocities/u-mps", 1 / FT_TO_M},
+	{"velocities/v-fps", "velocities/v-mps", 1 / FT_TO_M},
+	{"velocities/w-fps", "velocities/w-mps", 1 / FT_TO_M},
+	{"attitude/phi-rad", "attitude/roll-rad", 1},
+	{"attitude/theta-rad", "attitude/pitch-rad", 1},
+	{"attitude/psi-rad", "attitude/heading-rad", 1},
+	{"velocities/q-rad_sec", "velocities/q-rad_sec", 1},
+	{"velocities/vt-mps", "velocities/vt-mps", 1},
+	{"aero/alpha-deg", "aero/alpha-deg", 1},
+	{"fcs/elevator-cmd-norm", "fcs/elevator-cmd-norm", 1},
+	{"fcs/throttle-cmd-norm", "fcs/throttle-cmd-norm", 1},
+	{"fcs/gear-cmd-norm", "fcs/gear-cmd-norm", 1},
+}
+
+// writeReference writes states as a reference CSV in feet
+func writeReference(states []*AircraftState) string {
+	var sb strings.Builder
+	for i, column := range referenceColumns {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteString(column.header)
+	}
+	sb.WriteString("\n")
+	properties := make(map[string]float64, propertyMapSize)
+	for _, state := range states {
+		state.FillPropertyMap(properties)
+		for i, column := range referenceColumns {
+			if i > 0 {
+				sb.WriteString(",")
+			}
+			sb.WriteString(fmt.Sprintf("%.12g", properties[column.property]*column.scale))
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+const selfValidationSpec = `{
+	"name": "Simplified model against itself",
+	"dt": 0.01,
+	"columns": {
+		"Time": {"property": "simulation/sim-time-sec"},
+		"position/h-sl-ft": {"property": "position/h-sl-m", "scale": 0.3048},
+		"velocities/u-fps": {"property": "velocities/u-mps", "scale": 0.3048},
+		"velocities/v-fps": {"property": "velocities/v-mps", "scale": 0.3048},
+		"velocities/w-fps": {"property": "velocities/w-mps", "scale": 0.3048},
+		"attitude/phi-rad": {"property": "attitude/roll-rad"},
+		"attitude/theta-rad": {"property": "attitude/pitch-rad"},
+		"attitude/psi-rad": {"property": "attitude/heading-rad"}
+	},
+	"channels": [
+		{"property": "position/h-sl-m", "max_error": 0.5, "rms_error": 0.2},
+		{"property": "velocities/vt-mps", "max_error": 0.2},
+		{"property": "attitude/pitch-rad", "max_error": 0.002, "min_correlation": 0.99},
+		{"property": "velocities/q-rad_sec", "max_error": 0.005, "min_correlation": 0.99}
+	]
+}`
+
+func TestValidationHarness(t *testing.T) {
+	t.Run("Spec Errors", func(t *testing.T) {
+		for _, spec := range []string{
+			`{"name": "none", "channels": []}`,
+			`{"name": "unnamed", "channels": [{"max_error": 1}]}`,
+			`{"name": "backwards", "dt": -0.01, "channels": [{"property": "position/h-sl-m"}]}`,
+			`{"name": "typo", "chanels": [{"property": "position/h-sl-m"}]}`,
+		} {
+			if _, err := LoadValidationSpec(strings.NewReader(spec)); err == nil {
+				t.Errorf("Spec %s should not load", spec)
+			}
+		}
+	})
+
+	t.Run("Self Consistency", func(t *testing.T) {
+		// A simplified model run with a smooth elevator input, written at
+		// 20 Hz in feet, is the reference for the same model at 100 Hz
+		engine := NewSimplifiedFlightDynamicsEngine(NewRungeKutta4Integrator())
+		state := trimLevelFlight(t, engine, 1000.0, 100.0)
+		state.Time = 0
+		trim := state.Controls.Elevator
+		var states []*AircraftState
+		var err error
+		for i := 0; i <= 500; i++ {
+			state.Controls.Elevator = trim + 0.03*math.Sin(2*math.Pi*state.Time/4)
+			if i%5 == 0 {
+				states = append(states, state.Copy())
+			}
+			if state, err = engine.Step(state, 0.01); err != nil {
+				t.Fatalf("Step %d failed: %v", i, err)
+			}
+		}
+
+		spec, err := LoadValidationSpec(strings.NewReader(selfValidationSpec))
+		if err != nil {
+			t.Fatalf("LoadValidationSpec: %v", err)
+		}
+		ref, err := ReadReference(strings.NewReader(writeReference(states)), spec)
+		if err != nil {
+			t.Fatalf("ReadReference: %v", err)
+		}
+		assertEqual(t, len(ref.Times), 101)
+		assertApproxEqual(t, ref.Times[100], 5.0, 1e-9)
+		h, _ := ref.Value("position/h-sl-m", 0)
+		assertApproxEqual(t, h, 1000.0, 1e-6)
+
+		report, err := NewValidationHarness(spec, ref, engine).Run()
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		t.Logf("\n%s", report)
+		assertEqual(t, report.Passed, true)
+		assertEqual(t, len(report.Channels), 4)
+		assertEqual(t, report.Channels[0].Samples, 101)
+		assertApproxEqual(t, report.End, 5.0, 1e-9)
+
+		// A different model fails it
+		other := NewSimplifiedFlightDynamicsEngine(NewRungeKutta4Integrator())
+		other.Calculator.Mass *= 1.2
+		report, err = NewValidationHarness(spec, ref, other).Run()
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		assertEqual(t, report.Passed, false)
+		if !strings.Contains(report.String(), "FAIL: max error over") {
+			t.Errorf("The report should name the failed tolerances:\n%s", report)
+		}
+	})
+
+	t.Run("Missing Channel", func(t *testing.T) {
+		spec, _ := LoadValidationSpec(strings.NewReader(selfValidationSpec))
+		spec.Channels = append(spec.Channels, ValidationChannel{Property: "velocities/r-rad_sec"})
+		ref, err := ReadReference(strings.NewReader(writeReference([]*AircraftState{NewAircraftState()})), spec)
+		if err != nil {
+			t.Fatalf("ReadReference: %v", err)
+		}
+		if _, err := NewValidationHarness(spec, ref, NewSimplifiedFlightDynamicsEngine(NewEulerIntegrator())).Run(); err == nil {
+			t.Error("A channel the reference lacks should be an error")
+		}
+	})
+
+	t.Run("P-51D Reference", func(t *testing.T) {
+		data, err := os.ReadFile("testdata/validation/p51d_straight_level.json")
+		if err != nil {
+			t.Fatalf("Reading spec: %v", err)
+		}
+		spec, err := LoadValidationSpec(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("LoadValidationSpec: %v", err)
+		}
+		data, err = os.ReadFile("testdata/validation/p51d_straight_level.csv")
+		if err != nil {
+			t.Fatalf("Reading reference: %v", err)
+		}
+		ref, err := ReadReference(bytes.NewReader(data), spec)
+		if err != nil {
+			t.Fatalf("ReadReference: %v", err)
+		}
+		assertApproxEqual(t, ref.Values[0][ref.index["position/h-sl-m"]], 1524.0, 1e-9)
+
+		// CAMSim is not yet expected to hold JSBSim's trim; the report
+		// records how far it is from it
+		report, err := NewValidationHarness(spec, ref, NewFlightDynamicsEngine(loadP51DConfig(t), NewRungeKutta4Integrator())).Run()
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		t.Logf("\n%s", report)
+		assertEqual(t, len(report.Channels), len(spec.Channels))
+		assertApproxEqual(t, report.End, 10.0, 1e-9)
+	})
+}
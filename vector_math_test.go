@@ -0,0 +1,174 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// assertVectorsEqual fails unless a and b agree to within tol in every component
+func assertVectorsEqual(t *testing.T, a, b Vector3, tol float64) {
+	t.Helper()
+	if d := a.Sub(b); math.Abs(d.X) > tol || math.Abs(d.Y) > tol || math.Abs(d.Z) > tol {
+		t.Errorf("Expected %v ± %g, got %v", b, tol, a)
+	}
+}
+
+// randomQuaternion returns a uniformly distributed random rotation
+func randomQuaternion(rng *rand.Rand) Quaternion {
+	return Quaternion{W: rng.NormFloat64(), X: rng.NormFloat64(), Y: rng.NormFloat64(), Z: rng.NormFloat64()}.Normalize()
+}
+
+// randomVector returns a random vector with components in ±1
+func randomVector(rng *rand.Rand) Vector3 {
+	return Vector3{X: 2*rng.Float64() - 1, Y: 2*rng.Float64() - 1, Z: 2*rng.Float64() - 1}
+}
+
+func TestVector3Math(t *testing.T) {
+	a := Vector3{X: 1, Y: -2, Z: 3}
+	b := Vector3{X: 4, Y: 5, Z: -6}
+	assertEqual(t, a.Sub(b), Vector3{X: -3, Y: -7, Z: 9})
+	assertEqual(t, a.Sub(b), a.Add(b.Scale(-1)))
+	assertEqual(t, a.MultiplyElementwise(b), Vector3{X: 4, Y: -10, Z: -18})
+	assertEqual(t, a.Lerp(b, 0), a)
+	assertEqual(t, a.Lerp(b, 1), b)
+	assertEqual(t, a.Lerp(b, 0.5), Vector3{X: 2.5, Y: 1.5, Z: -1.5})
+
+	t.Run("Angle Between", func(t *testing.T) {
+		x := Vector3{X: 2}
+		assertEqual(t, x.AngleTo(Vector3{X: 5}), 0.0)
+		assertApproxEqual(t, x.AngleTo(Vector3{Y: 3}), math.Pi/2, 1e-15)
+		assertApproxEqual(t, x.AngleTo(Vector3{X: -1}), math.Pi, 1e-15)
+		assertApproxEqual(t, x.AngleTo(Vector3{X: 1, Y: -1}), math.Pi/4, 1e-15)
+		assertEqual(t, x.AngleTo(Vector3{}), 0.0)
+
+		// Accurate for nearly parallel vectors
+		assertApproxEqual(t, x.AngleTo(Vector3{X: 1, Z: 1e-9}), 1e-9, 1e-20)
+	})
+}
+
+func TestQuaternionMath(t *testing.T) {
+	t.Run("Conjugate And Inverse", func(t *testing.T) {
+		q := Quaternion{W: 1, X: 2, Y: -3, Z: 4}
+		assertEqual(t, q.Conjugate(), Quaternion{W: 1, X: -2, Y: 3, Z: -4})
+		product := q.Multiply(q.Inverse())
+		assertApproxEqual(t, product.W, 1.0, 1e-15)
+		assertVectorsEqual(t, Vector3{X: product.X, Y: product.Y, Z: product.Z}, Vector3{}, 1e-15)
+
+		// A unit quaternion's inverse is its conjugate
+		unit := q.Normalize()
+		assertApproxEqual(t, unit.Inverse().AngleTo(unit.Conjugate()), 0, 1e-7)
+		assertEqual(t, Quaternion{}.Inverse(), Quaternion{})
+	})
+
+	t.Run("Axis Angle", func(t *testing.T) {
+		// Right-handed: a quarter turn about Z takes X to Y, about X takes
+		// Y to Z and about Y takes Z to X
+		quarter := math.Pi / 2
+		assertVectorsEqual(t, NewQuaternionFromAxisAngle(Vector3{Z: 1}, quarter).RotateVector(Vector3{X: 1}), Vector3{Y: 1}, 1e-15)
+		assertVectorsEqual(t, NewQuaternionFromAxisAngle(Vector3{X: 3}, quarter).RotateVector(Vector3{Y: 1}), Vector3{Z: 1}, 1e-15)
+		assertVectorsEqual(t, NewQuaternionFromAxisAngle(Vector3{Y: 1}, quarter).RotateVector(Vector3{Z: 1}), Vector3{X: 1}, 1e-15)
+
+		axis, angle := NewQuaternionFromAxisAngle(Vector3{X: 1, Y: 1}, 2.0).ToAxisAngle()
+		assertVectorsEqual(t, axis, Vector3{X: 1, Y: 1}.Normalize(), 1e-15)
+		assertApproxEqual(t, angle, 2.0, 1e-15)
+
+		// Past a half turn it is the shorter way round the other axis
+		axis, angle = NewQuaternionFromAxisAngle(Vector3{Z: 1}, 1.5*math.Pi).ToAxisAngle()
+		assertVectorsEqual(t, axis, Vector3{Z: -1}, 1e-15)
+		assertApproxEqual(t, angle, math.Pi/2, 1e-15)
+
+		// No rotation
+		axis, angle = Quaternion{W: 1}.ToAxisAngle()
+		assertEqual(t, axis, Vector3{X: 1})
+		assertEqual(t, angle, 0.0)
+		assertEqual(t, NewQuaternionFromAxisAngle(Vector3{}, 1), Quaternion{W: 1})
+	})
+
+	t.Run("Euler Octants", func(t *testing.T) {
+		// Every sign combination of roll, pitch and yaw, small to large,
+		// round trips through the quaternion and matches the yaw, pitch,
+		// roll sequence of axis-angle rotations
+		for _, rollMag := range []float64{0.3, 1.2, 2.5, 3.1} {
+			for _, pitchMag := range []float64{0.2, 0.8, 1.4, 1.57} {
+				for _, yawMag := range []float64{0.3, 1.2, 2.5, 3.1} {
+					for signs := 0; signs < 8; signs++ {
+						roll, pitch, yaw := rollMag, pitchMag, yawMag
+						if signs&1 != 0 {
+							roll = -roll
+						}
+						if signs&2 != 0 {
+							pitch = -pitch
+						}
+						if signs&4 != 0 {
+							yaw = -yaw
+						}
+						q := NewQuaternionFromEuler(roll, pitch, yaw)
+						sequence := NewQuaternionFromAxisAngle(Vector3{Z: 1}, yaw).
+							Multiply(NewQuaternionFromAxisAngle(Vector3{Y: 1}, pitch)).
+							Multiply(NewQuaternionFromAxisAngle(Vector3{X: 1}, roll))
+						if math.Abs(math.Abs(q.Dot(sequence))-1) > 1e-12 {
+							t.Fatalf("(%g, %g, %g): Euler %v, axis-angle sequence %v", roll, pitch, yaw, q, sequence)
+						}
+
+						r, p, y := q.ToEuler()
+						if math.Abs(r-roll) > 1e-9 || math.Abs(p-pitch) > 1e-12 || math.Abs(y-yaw) > 1e-9 {
+							t.Fatalf("(%g, %g, %g) round tripped to (%g, %g, %g)", roll, pitch, yaw, r, p, y)
+						}
+					}
+				}
+			}
+		}
+	})
+
+	t.Run("Gimbal Lock", func(t *testing.T) {
+		for _, pitch := range []float64{math.Pi / 2, -math.Pi / 2} {
+			for _, angles := range [][2]float64{{0, 0}, {0.4, 1.1}, {-2.0, 2.5}, {3.0, -3.0}} {
+				roll, yaw := angles[0], angles[1]
+				q := NewQuaternionFromEuler(roll, pitch, yaw)
+				r, p, y := q.ToEuler()
+				assertEqual(t, p, pitch)
+				assertEqual(t, r, 0.0)
+
+				// The angles given rebuild the same attitude
+				if angle := NewQuaternionFromEuler(r, p, y).AngleTo(q); angle > 1e-7 {
+					t.Errorf("Pitch %g, roll %g, yaw %g: gave (%g, %g, %g), %g rad away", pitch, roll, yaw, r, p, y, angle)
+				}
+				assertApproxEqual(t, math.Abs(y), math.Abs(math.Remainder(yaw-math.Copysign(1, pitch)*roll, 2*math.Pi)), 1e-12)
+			}
+		}
+	})
+
+	t.Run("Random Rotations", func(t *testing.T) {
+		rng := rand.New(rand.NewSource(374))
+		for i := 0; i < 10000; i++ {
+			q := randomQuaternion(rng)
+			v := randomVector(rng)
+
+			rotated := q.RotateVector(v)
+			assertApproxEqual(t, rotated.Magnitude(), v.Magnitude(), 1e-12)
+			assertVectorsEqual(t, q.RotateVectorInverse(rotated), v, 1e-12)
+			assertVectorsEqual(t, q.Inverse().RotateVector(rotated), v, 1e-12)
+
+			// Without normalizing, the inverse still undoes the rotation
+			scaled := q.Scale(0.5 + 2*rng.Float64())
+			assertVectorsEqual(t, scaled.Inverse().RotateVector(scaled.RotateVector(v)), v, 1e-12)
+
+			// Composition: rotating by p then q is rotating by qp
+			p := randomQuaternion(rng)
+			assertVectorsEqual(t, q.Multiply(p).RotateVector(v), q.RotateVector(p.RotateVector(v)), 1e-12)
+
+			// Axis-angle and back
+			axis, angle := q.ToAxisAngle()
+			if back := NewQuaternionFromAxisAngle(axis, angle); math.Abs(math.Abs(back.Dot(q))-1) > 1e-12 {
+				t.Fatalf("%v: axis %v angle %g rebuilt %v", q, axis, angle, back)
+			}
+
+			// Euler angles and back
+			roll, pitch, yaw := q.ToEuler()
+			if back := NewQuaternionFromEuler(roll, pitch, yaw); back.AngleTo(q) > 1e-7 {
+				t.Fatalf("%v: Euler (%g, %g, %g) rebuilt %v", q, roll, pitch, yaw, back)
+			}
+		}
+	})
+}
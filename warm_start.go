@@ -0,0 +1,146 @@
+// Warm Start
+// Runs the flight controls and force calculation in at the initial condition
+// with the dynamics frozen, so filters, actuators and integrator history
+// start settled instead of from zero
+
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// DefaultWarmStartDuration is the run-in given by the scenario runner and
+// the trim calculator (s)
+const DefaultWarmStartDuration = 2.0
+
+// WarmStartTolerance is the largest change of any FCS component output
+// between run-in iterations at which the run-in has converged
+var WarmStartTolerance = 1e-6
+
+// WarmStartResult reports a run-in
+type WarmStartResult struct {
+	Converged  bool
+	Iterations int
+	Duration   float64 // FCS time run (s)
+	Change     float64 // Largest output change over the last iteration
+}
+
+// String summarises the run-in
+func (r *WarmStartResult) String() string {
+	status := "converged"
+	if !r.Converged {
+		status = "did not converge"
+	}
+	return fmt.Sprintf("warm start %s after %d iterations (%.2f s), last change %.3g",
+		status, r.Iterations, r.Duration, r.Change)
+}
+
+// WarmStarter is an engine that can settle its internal state at an initial
+// condition before the simulation clock starts
+type WarmStarter interface {
+	WarmStart(state *AircraftState, duration float64) (*WarmStartResult, error)
+}
+
+// historySeeder is an integrator with a derivative history to prime
+type historySeeder interface {
+	SeedHistory(derivatives *StateDerivatives)
+}
+
+// seedIntegrator primes a multistep integrator with the derivatives at the
+// initial condition, as if the aircraft had been there for the last step
+func seedIntegrator(integrator Integrator, derivatives *StateDerivatives) {
+	if seeder, ok := integrator.(historySeeder); ok {
+		seeder.SeedHistory(derivatives)
+	}
+}
+
+// WarmStart evaluates the forces at state and seeds the integrator history
+// with them. Without flight controls the forces depend on the state alone,
+// so one evaluation is converged; duration is not used.
+func (fde *FlightDynamicsEngine) WarmStart(state *AircraftState, duration float64) (*WarmStartResult, error) {
+	components, err := fde.Calculator.CalculateForcesMoments(state)
+	if err != nil {
+		return nil, err
+	}
+	components.addGroundReaction(fde.Gear, state)
+	seedIntegrator(fde.Integrator, fde.Calculator.CalculateStateDerivatives(state, components))
+	return &WarmStartResult{Converged: true, Iterations: 1}, nil
+}
+
+// WarmStart runs the FCS and force calculation repeatedly at state, with
+// the pilot commands in state.Controls and no integration, until no
+// component output changes by more than WarmStartTolerance between
+// iterations or duration seconds of FCS time have run. Each iteration runs
+// the FCS for the period of its slowest rate group, so every group executes
+// in every iteration. state is left with the settled surface positions and
+// the integrator history is seeded from it.
+func (engine *FlightDynamicsEngineWithFCS) WarmStart(state *AircraftState, duration float64) (*WarmStartResult, error) {
+	engine.SetControlInputs(state.Controls)
+
+	calc := engine.FlightDynamicsEngine.Calculator
+	step := engine.FCS.warmStartStep()
+	result := &WarmStartResult{Change: math.Inf(1)}
+	var components *ForceMomentComponents
+	var previous map[string]float64
+	for result.Iterations == 0 || result.Duration < duration-1e-9 {
+		var err error
+		components, err = calc.calculateForcesMoments(state, func() {
+			engine.executeControls(state, step)
+			engine.ApplyFCSOutputsToState(state)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("warm start: %w", err)
+		}
+		result.Iterations++
+		result.Duration += step
+
+		outputs := engine.FCS.componentOutputs()
+		if previous != nil {
+			result.Change = maxOutputChange(previous, outputs)
+			if result.Change <= WarmStartTolerance {
+				result.Converged = true
+				break
+			}
+		}
+		previous = outputs
+	}
+
+	seedIntegrator(engine.FlightDynamicsEngine.Integrator, calc.CalculateStateDerivatives(state, components))
+	return result, nil
+}
+
+// warmStartStep returns the period of the slowest enabled rate group with
+// components, or of the default rate when there is none
+func (fcs *FlightControlSystem) warmStartStep() float64 {
+	step := 0.0
+	for _, group := range fcs.RateGroups {
+		if group.Enabled && len(group.Components) > 0 {
+			step = math.Max(step, group.Period)
+		}
+	}
+	if step == 0 {
+		step = 1.0 / fcs.DefaultRate
+	}
+	return step
+}
+
+// componentOutputs returns the output property value of every component
+func (fcs *FlightControlSystem) componentOutputs() map[string]float64 {
+	outputs := make(map[string]float64, len(fcs.Components))
+	for _, component := range fcs.Components {
+		if name := component.GetOutput(); name != "" {
+			outputs[name] = fcs.Properties.Get(name)
+		}
+	}
+	return outputs
+}
+
+// maxOutputChange returns the largest change between two sets of outputs
+func maxOutputChange(previous, current map[string]float64) float64 {
+	change := 0.0
+	for name, value := range current {
+		change = math.Max(change, math.Abs(value-previous[name]))
+	}
+	return change
+}
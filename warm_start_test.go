@@ -0,0 +1,123 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// fcsScenarioEngine steps a flight control equipped engine through its FCS,
+// recording the elevator position each step starts from
+type fcsScenarioEngine struct {
+	*FlightDynamicsEngineWithFCS
+	elevator *[]float64
+}
+
+func (e fcsScenarioEngine) Step(state *AircraftState, dt float64) (*AircraftState, error) {
+	*e.elevator = append(*e.elevator, state.ControlSurfaces.Elevator)
+	newState, _, err := e.RunSimulationStepWithFCS(state, dt)
+	return newState, err
+}
+
+func TestWarmStart(t *testing.T) {
+	config := loadP51DConfig(t)
+	const elevatorCommand = -0.2
+
+	newEngine := func(t *testing.T) *FlightDynamicsEngineWithFCS {
+		t.Helper()
+		engine, err := NewFlightDynamicsEngineWithFCS(config, true)
+		if err != nil {
+			t.Fatalf("NewFlightDynamicsEngineWithFCS: %v", err)
+		}
+		return engine
+	}
+	initialState := func() *AircraftState {
+		state := cruiseState(3000, 120, 0.02)
+		state.Controls.Elevator = elevatorCommand
+		return state
+	}
+
+	// runScenario returns the elevator position at each step of a short
+	// scenario, from t=0
+	runScenario := func(t *testing.T, warmStart float64) []float64 {
+		t.Helper()
+		var elevator []float64
+		var engine *FlightDynamicsEngineWithFCS
+		scenario := MonteCarloScenario{
+			Duration:     0.05,
+			Dt:           0.01,
+			WarmStart:    warmStart,
+			InitialState: initialState,
+			NewEngine: func(c *MonteCarloCase) (MonteCarloEngine, *FlightStatistics, error) {
+				engine = newEngine(t)
+				return fcsScenarioEngine{engine, &elevator}, engine.Statistics, nil
+			},
+			Controls: func(c *MonteCarloCase, state *AircraftState) {
+				engine.SetControlInputs(state.Controls)
+			},
+		}
+		extractor := func(*AircraftState, *FlightStatistics) map[string]float64 { return nil }
+		result, err := NewMonteCarlo(scenario, extractor, 1, 1).Run()
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+		if run := result.Runs[0]; run.Err != nil {
+			t.Fatalf("Scenario failed: %v", run.Err)
+		}
+		return elevator
+	}
+
+	t.Run("Settles Before The Clock Starts", func(t *testing.T) {
+		engine := newEngine(t)
+		state := initialState()
+		result, err := engine.WarmStart(state, DefaultWarmStartDuration)
+		if err != nil {
+			t.Fatalf("WarmStart: %v", err)
+		}
+		t.Log(result)
+		if !result.Converged || result.Change > WarmStartTolerance {
+			t.Fatalf("Expected convergence, got %v", result)
+		}
+		assertApproxEqual(t, state.ControlSurfaces.Elevator, elevatorCommand, 1e-5)
+		assertEqual(t, state.Time, 0.0)
+
+		// Too short a run-in reports that it did not converge
+		short, err := newEngine(t).WarmStart(initialState(), 0.1)
+		if err != nil {
+			t.Fatalf("WarmStart: %v", err)
+		}
+		if short.Converged {
+			t.Errorf("A 0.1 s run-in should not converge: %v", short)
+		}
+	})
+
+	t.Run("No Initial Transient", func(t *testing.T) {
+		cold := runScenario(t, -1)
+		warm := runScenario(t, 0)
+		t.Logf("Elevator without warm start %v, with %v", cold, warm)
+
+		// Without the run-in the actuator starts at zero and lags towards
+		// the command
+		if math.Abs(cold[0]-elevatorCommand) < 0.1 {
+			t.Errorf("Expected a visible transient at t=0 without warm start, got %.4f", cold[0])
+		}
+		for i, position := range warm {
+			if math.Abs(position-elevatorCommand) > 1e-5 {
+				t.Errorf("Step %d: elevator %.6f should hold its steady value %.2f", i, position, elevatorCommand)
+			}
+		}
+	})
+
+	t.Run("Seeds Integrator History", func(t *testing.T) {
+		integrator := NewAdamsBashforth2Integrator()
+		engine := NewFlightDynamicsEngine(config, integrator)
+		calculator := NewTrimCalculator(engine)
+		state, result, err := calculator.TrimState(120, 3000)
+		if err != nil {
+			t.Fatalf("TrimState: %v", err)
+		}
+		if !result.Converged || !integrator.hasPrevious {
+			t.Errorf("Expected a converged run-in with integrator history, got %v", result)
+		}
+		assertApproxEqual(t, state.TrueAirspeed, 120, 1e-9)
+	})
+}
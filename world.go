@@ -0,0 +1,216 @@
+// Multi-Aircraft World
+// Steps several independent aircraft on a shared clock and terrain and
+// reports the geometry between them
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"sync"
+)
+
+// SimulatedAircraft is one aircraft in a World. Each has its own engine,
+// flight control system and state; nothing mutable is shared with the
+// other aircraft.
+type SimulatedAircraft struct {
+	Name   string
+	Config *JSBSimConfig        // Configuration the engine was built from (optional)
+	Engine MonteCarloEngine     // Flight dynamics for this aircraft alone
+	FCS    *FlightControlSystem // Executed before each step (optional)
+	State  *AircraftState
+}
+
+// RelativeGeometry is the position of one aircraft seen from another
+type RelativeGeometry struct {
+	Range       float64 // Straight-line distance (m)
+	Bearing     float64 // True bearing in the horizontal plane, 0 to 2π (rad)
+	Elevation   float64 // Angle above the horizontal, positive when the target is higher (rad)
+	ClosureRate float64 // Rate the range is decreasing, positive when closing (m/s)
+}
+
+// World advances a set of aircraft together. Positions are taken in one
+// shared local NED frame, so aircraft must be placed relative to the same
+// origin. The atmosphere is the standard atmosphere every state computes
+// from its own altitude.
+type World struct {
+	Time     float64
+	Terrain  Terrain // Given to each engine that has none of its own (optional)
+	Parallel bool    // Step aircraft on separate goroutines
+
+	// Relative geometry between every ordered pair of aircraft after the
+	// latest step, as relative/<from>/<to>/range-m, bearing-rad,
+	// elevation-rad and closure-rate-mps
+	Properties map[string]float64
+
+	aircraft []*SimulatedAircraft
+	byName   map[string]*SimulatedAircraft
+}
+
+// NewWorld creates an empty world at time zero
+func NewWorld(terrain Terrain) *World {
+	return &World{
+		Terrain:    terrain,
+		Properties: make(map[string]float64),
+		byName:     make(map[string]*SimulatedAircraft),
+	}
+}
+
+// AddAircraft adds an aircraft starting from a copy of state. Names must
+// be unique, and engines and flight control systems cannot be shared
+// between aircraft since both carry state from step to step.
+func (w *World) AddAircraft(name string, config *JSBSimConfig, engine MonteCarloEngine, fcs *FlightControlSystem, state *AircraftState) (*SimulatedAircraft, error) {
+	if name == "" {
+		return nil, fmt.Errorf("aircraft name must not be empty")
+	}
+	if _, exists := w.byName[name]; exists {
+		return nil, fmt.Errorf("aircraft %q already exists", name)
+	}
+	if engine == nil || state == nil {
+		return nil, fmt.Errorf("aircraft %q requires an engine and a state", name)
+	}
+	for _, other := range w.aircraft {
+		if other.Engine == engine {
+			return nil, fmt.Errorf("aircraft %q shares its engine with %q", name, other.Name)
+		}
+		if fcs != nil && other.FCS == fcs {
+			return nil, fmt.Errorf("aircraft %q shares its flight control system with %q", name, other.Name)
+		}
+	}
+
+	if w.Terrain != nil {
+		switch e := engine.(type) {
+		case *FlightDynamicsEngine:
+			if e.Terrain == nil {
+				e.Terrain = w.Terrain
+			}
+		case *SimplifiedFlightDynamicsEngine:
+			if e.Terrain == nil {
+				e.Terrain = w.Terrain
+			}
+		}
+	}
+
+	ac := &SimulatedAircraft{
+		Name:   name,
+		Config: config,
+		Engine: engine,
+		FCS:    fcs,
+		State:  state.Copy(),
+	}
+	ac.State.Time = w.Time
+	w.aircraft = append(w.aircraft, ac)
+	w.byName[name] = ac
+	w.updateGeometry()
+	return ac, nil
+}
+
+// Aircraft returns the named aircraft, or nil
+func (w *World) Aircraft(name string) *SimulatedAircraft {
+	return w.byName[name]
+}
+
+// Names returns the aircraft names in the order they were added
+func (w *World) Names() []string {
+	names := make([]string, len(w.aircraft))
+	for i, ac := range w.aircraft {
+		names[i] = ac.Name
+	}
+	return names
+}
+
+// Step advances every aircraft by dt. Each runs its flight control system
+// and then its engine, exactly as it would alone. If any aircraft fails,
+// no state is advanced and the error of the first in order is returned.
+// The flight control systems are not rolled back: by then each has run
+// for the step, advancing its clock and filters and writing its surface
+// positions into its aircraft's state.
+func (w *World) Step(dt float64) error {
+	next := make([]*AircraftState, len(w.aircraft))
+	errs := make([]error, len(w.aircraft))
+	step := func(i int) {
+		ac := w.aircraft[i]
+		if ac.FCS != nil {
+			ac.FCS.Execute(ac.State, dt)
+		}
+		next[i], errs[i] = ac.Engine.Step(ac.State, dt)
+	}
+
+	if w.Parallel {
+		var wg sync.WaitGroup
+		for i := range w.aircraft {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				step(i)
+			}(i)
+		}
+		wg.Wait()
+	} else {
+		for i := range w.aircraft {
+			step(i)
+		}
+	}
+
+	for i, err := range errs {
+		if err != nil {
+			return fmt.Errorf("aircraft %q at t=%.3fs: %w", w.aircraft[i].Name, w.Time, err)
+		}
+	}
+	for i, ac := range w.aircraft {
+		ac.State = next[i]
+	}
+	w.Time += dt
+	w.updateGeometry()
+	return nil
+}
+
+// Relative returns the geometry of aircraft to as seen from aircraft from
+func (w *World) Relative(from, to string) (RelativeGeometry, error) {
+	a, b := w.byName[from], w.byName[to]
+	if a == nil || b == nil {
+		return RelativeGeometry{}, fmt.Errorf("no aircraft pair %q and %q", from, to)
+	}
+	return relativeGeometry(a.State, b.State), nil
+}
+
+// relativeGeometry returns the range, bearing, elevation and closure rate of
+// target from observer. Closure rate is the negated range rate, from the
+// earth-frame velocities.
+func relativeGeometry(observer, target *AircraftState) RelativeGeometry {
+	los := target.Position.Add(observer.Position.Scale(-1))
+	r := los.Magnitude()
+	if r == 0 {
+		return RelativeGeometry{}
+	}
+
+	vo := observer.Orientation.RotateVector(observer.Velocity)
+	vt := target.Orientation.RotateVector(target.Velocity)
+	bearing := math.Atan2(los.Y, los.X)
+	if bearing < 0 {
+		bearing += 2 * math.Pi
+	}
+	return RelativeGeometry{
+		Range:       r,
+		Bearing:     bearing,
+		Elevation:   math.Atan2(-los.Z, math.Hypot(los.X, los.Y)),
+		ClosureRate: -los.Dot(vt.Add(vo.Scale(-1))) / r,
+	}
+}
+
+// updateGeometry publishes the relative geometry of every ordered pair
+func (w *World) updateGeometry() {
+	for _, a := range w.aircraft {
+		for _, b := range w.aircraft {
+			if a == b {
+				continue
+			}
+			g := relativeGeometry(a.State, b.State)
+			prefix := "relative/" + a.Name + "/" + b.Name + "/"
+			w.Properties[prefix+"range-m"] = g.Range
+			w.Properties[prefix+"bearing-rad"] = g.Bearing
+			w.Properties[prefix+"elevation-rad"] = g.Elevation
+			w.Properties[prefix+"closure-rate-mps"] = g.ClosureRate
+		}
+	}
+}
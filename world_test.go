@@ -0,0 +1,142 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestWorld(t *testing.T) {
+	config := loadP51DConfig(t)
+	p51dState := func() *AircraftState {
+		state := NewAircraftState()
+		state.Altitude = 1000.0
+		state.Position = Vector3{Z: -1000.0}
+		state.Velocity = Vector3{X: 100.0}
+		state.UpdateAtmosphere()
+		state.UpdateDerivedParameters()
+		return state
+	}
+
+	// The simplified aircraft flies trimmed 300 m east of the P-51D, with a
+	// pitch damper and an elevator pulse after 2 s so it climbs away
+	simplified := NewSimplifiedFlightDynamicsEngine(NewEulerIntegrator())
+	wingman := trimLevelFlight(t, simplified, 1000.0, 100.0)
+	wingman.Position.Y = 300.0
+	trim := wingman.Controls.Elevator
+	pulse := func(state *AircraftState) {
+		state.Controls.Elevator = trim
+		if state.Time >= 2 && state.Time < 2.5 {
+			state.Controls.Elevator += 0.02
+		}
+	}
+
+	const dt, steps = 0.002, 5000
+
+	// fly runs one aircraft alone and returns its state at every step
+	fly := func(engine MonteCarloEngine, fcs *FlightControlSystem, state *AircraftState, controls func(*AircraftState)) []*AircraftState {
+		states := []*AircraftState{state}
+		for i := 0; i < steps; i++ {
+			if controls != nil {
+				controls(state)
+			}
+			if fcs != nil {
+				fcs.Execute(state, dt)
+			}
+			var err error
+			if state, err = engine.Step(state, dt); err != nil {
+				t.Fatalf("Step %d failed: %v", i, err)
+			}
+			states = append(states, state)
+		}
+		return states
+	}
+	p51dAlone := fly(NewFlightDynamicsEngine(config, NewRungeKutta4Integrator()), nil, p51dState(), nil)
+	wingmanAlone := fly(NewSimplifiedFlightDynamicsEngine(NewEulerIntegrator()), pitchDamperFCS(0.5, 120.0), wingman.Copy(), pulse)
+
+	together := func(parallel bool) *World {
+		world := NewWorld(nil)
+		world.Parallel = parallel
+		if _, err := world.AddAircraft("p51d", config, NewFlightDynamicsEngine(config, NewRungeKutta4Integrator()), nil, p51dState()); err != nil {
+			t.Fatalf("AddAircraft: %v", err)
+		}
+		if _, err := world.AddAircraft("wingman", nil, NewSimplifiedFlightDynamicsEngine(NewEulerIntegrator()), pitchDamperFCS(0.5, 120.0), wingman); err != nil {
+			t.Fatalf("AddAircraft: %v", err)
+		}
+		for i := 0; i < steps; i++ {
+			pulse(world.Aircraft("wingman").State)
+			if err := world.Step(dt); err != nil {
+				t.Fatalf("Step %d failed: %v", i, err)
+			}
+			for j, alone := range [][]*AircraftState{p51dAlone, wingmanAlone} {
+				state := world.Aircraft(world.Names()[j]).State
+				if state.Position != alone[i+1].Position || state.Velocity != alone[i+1].Velocity ||
+					state.Orientation != alone[i+1].Orientation || state.AngularRate != alone[i+1].AngularRate {
+					t.Fatalf("%s differs from its single-aircraft run at step %d", world.Names()[j], i)
+				}
+			}
+		}
+		return world
+	}
+
+	t.Run("Trajectories Match Single Runs", func(t *testing.T) {
+		world := together(false)
+		assertApproxEqual(t, world.Time, 10.0, 1e-9)
+		assertEqual(t, world.Names(), []string{"p51d", "wingman"})
+		if climb := world.Aircraft("wingman").State.Altitude - 1000; climb < 10 {
+			t.Errorf("Wingman should have climbed after the pulse, only %.1f m", climb)
+		}
+	})
+
+	t.Run("Parallel", func(t *testing.T) {
+		together(true)
+	})
+
+	t.Run("Relative Geometry", func(t *testing.T) {
+		world := NewWorld(nil)
+		lead := NewAircraftState()
+		lead.Velocity = Vector3{X: 100.0}
+		chaser := NewAircraftState()
+		chaser.Position = Vector3{X: -300.0, Y: -400.0, Z: 0}
+		chaser.Velocity = Vector3{X: 130.0}
+		world.AddAircraft("lead", nil, NewSimplifiedFlightDynamicsEngine(NewEulerIntegrator()), nil, lead)
+		world.AddAircraft("chaser", nil, NewSimplifiedFlightDynamicsEngine(NewEulerIntegrator()), nil, chaser)
+
+		g, err := world.Relative("chaser", "lead")
+		if err != nil {
+			t.Fatalf("Relative: %v", err)
+		}
+		assertApproxEqual(t, g.Range, 500.0, 1e-9)
+		assertApproxEqual(t, g.Bearing, math.Atan2(400, 300), 1e-12)
+		assertApproxEqual(t, g.Elevation, 0.0, 1e-12)
+		assertApproxEqual(t, g.ClosureRate, 30.0*300/500, 1e-9)
+
+		// Seen from the lead the chaser is behind and to the left, and
+		// closing at the same rate
+		assertApproxEqual(t, world.Properties["relative/lead/chaser/bearing-rad"], math.Pi+math.Atan2(400, 300), 1e-12)
+		assertApproxEqual(t, world.Properties["relative/lead/chaser/closure-rate-mps"], 18.0, 1e-9)
+		assertEqual(t, len(world.Properties), 8)
+
+		if _, err := world.Relative("lead", "tanker"); err == nil {
+			t.Error("Expected an error for an unknown aircraft")
+		}
+	})
+
+	t.Run("Independence", func(t *testing.T) {
+		world := NewWorld(NewFlatTerrain(0))
+		engine := NewSimplifiedFlightDynamicsEngine(NewEulerIntegrator())
+		state := NewAircraftState()
+		if _, err := world.AddAircraft("one", nil, engine, nil, state); err != nil {
+			t.Fatalf("AddAircraft: %v", err)
+		}
+		if _, err := world.AddAircraft("one", nil, NewSimplifiedFlightDynamicsEngine(NewEulerIntegrator()), nil, state); err == nil {
+			t.Error("Expected an error for a duplicate name")
+		}
+		if _, err := world.AddAircraft("two", nil, engine, nil, state); err == nil {
+			t.Error("Expected an error for a shared engine")
+		}
+
+		// Each aircraft starts from its own copy, and picks up the terrain
+		assertEqual(t, world.Aircraft("one").State == state, false)
+		assertEqual(t, engine.Terrain, Terrain(world.Terrain))
+	})
+}